@@ -83,6 +83,12 @@ export default function AuthGate({ children }: AuthGateProps) {
                 setPasskey('');
             }
         } catch (err: any) {
+            if (err.response?.status === 429) {
+                const retryAfter = err.response.headers['retry-after'];
+                setError(`Too many failed attempts. Try again in ${retryAfter || 'a few'} seconds.`);
+                setPasskey('');
+                return;
+            }
             setConnectionError(true);
             setError('Unable to connect to server. Check your connection.');
         } finally {
@@ -19,7 +19,7 @@ import { motion, AnimatePresence } from 'framer-motion';
 import { Dock, DockIcon, DockSeparator } from '@/components/ui/dock';
 
 import { useAuth } from '../contexts/AuthContext';
-import { API_BASE } from '@/lib/api';
+import { API_BASE, getStoredAccessKey } from '@/lib/api';
 import { toast } from 'sonner';
 
 // All navigation items
@@ -47,7 +47,9 @@ export default function Layout() {
 
   // Listen for server events (SSE)
   useEffect(() => {
-    const eventSource = new EventSource(`${API_BASE}/events`);
+    // EventSource can't set headers, so the key goes in the query
+    const accessKey = getStoredAccessKey();
+    const eventSource = new EventSource(`${API_BASE}/events${accessKey ? `?access_key=${encodeURIComponent(accessKey)}` : ''}`);
 
     eventSource.onmessage = (event) => {
       try {
@@ -24,10 +24,19 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// errorMessage returns the API's message for a failed request, or fallback.
+// Errors come back as {"error": {"code", "message", "details", "request_id"}}.
+export const errorMessage = (err: any, fallback: string): string =>
+  err?.response?.data?.error?.message || fallback;
+
 // Auth API
 export const verifyPasskey = (passkey: string) =>
   axios.post(`${API_BASE}/auth/verify`, { passkey });
 
+// Failed-auth lockouts (admin)
+export const getAuthLockouts = () => api.get('/auth/lockouts');
+export const clearAuthLockout = (ip: string) => api.delete(`/auth/lockouts/${encodeURIComponent(ip)}`);
+
 export const setAccessKey = (key: string) => {
   localStorage.setItem(ACCESS_KEY_STORAGE, key);
 };
@@ -62,6 +71,25 @@ export const updateTrader = (id: string, data: any) => api.put(`/traders/${id}`,
 export const deleteTrader = (id: string) => api.delete(`/traders/${id}`);
 export const startTrader = (id: string) => api.post(`/traders/${id}/start`);
 export const stopTrader = (id: string) => api.post(`/traders/${id}/stop`);
+export const getTradersStatus = () => api.get('/traders/status');
+export const bulkTraders = (action: 'start' | 'stop', ids: string[]) => api.post('/traders/bulk', { action, ids });
+export const getTraderOverview = (id: string) => api.get(`/traders/${id}/overview`);
+export const acknowledgeCircuitBreaker = (id: string) => api.post(`/traders/${id}/acknowledge-circuit-breaker`);
+export const getTraderExperiments = (id: string) => api.get(`/traders/${id}/experiments`);
+export const getBlockedDecisions = (id: string, limit?: number) =>
+  api.get(`/traders/${id}/decisions/blocked`, { params: { limit } });
+export const getTraderReport = (id: string, period: 'daily' | 'weekly' = 'daily', date?: string) =>
+  api.get(`/traders/${id}/report`, { params: { period, date } });
+export const getSmartFindRuns = (id: string) => api.get(`/traders/${id}/smart-find`);
+export const refreshSmartFind = (id: string) => api.post(`/traders/${id}/smart-find/refresh`);
+export const getTraderOrders = (id: string) => api.get(`/traders/${id}/orders`);
+export const cancelTraderOrder = (id: string, orderId: number) => api.delete(`/traders/${id}/orders/${orderId}`);
+export const setPositionStops = (id: string, symbol: string, stops: { stop_loss?: number; take_profit?: number }) =>
+  api.put(`/traders/${id}/positions/${symbol}/stops`, stops);
+export const getPositionEvents = (id: string, symbol: string, positionId?: number) =>
+  api.get(`/traders/${id}/positions/${symbol}/events${positionId ? `?position_id=${positionId}` : ''}`);
+export const getPositionDetail = (id: string, positionId: number) =>
+  api.get(`/traders/${id}/positions/${positionId}`);
 
 // Data API
 export const getStatus = (traderId: string) => api.get(`/status?trader_id=${traderId}`);
@@ -69,15 +97,20 @@ export const getAccount = (traderId: string) => api.get(`/account?trader_id=${tr
 export const getPositions = (traderId: string) => api.get(`/positions?trader_id=${traderId}`);
 export const getDecisions = (traderId: string) => api.get(`/decisions?trader_id=${traderId}`);
 export const getTrades = (traderId: string) => api.get(`/trades?trader_id=${traderId}`);
-export const getEquityHistory = (traderId: string) => api.get(`/equity-history?trader_id=${traderId}`);
+export const getEquityHistory = (traderId: string, start?: number) =>
+  api.get(`/equity-history?trader_id=${traderId}${start ? `&start=${start}` : ''}`);
 
 // Health
 export const getHealth = () => api.get('/health');
+export const getDeepHealth = () => api.get('/health/deep', { validateStatus: (status) => status === 200 || status === 503 });
 
 // Backtest API
 export const listBacktests = () => api.get('/backtest');
 export const startBacktest = (data: any) => api.post('/backtest/start', data);
 export const stopBacktest = (runId: string) => api.post(`/backtest/${runId}/stop`);
+export const resumeBacktest = (runId: string) => api.post(`/backtest/${runId}/resume`);
+export const runBacktestMonteCarlo = (runId: string, data: any) => api.post(`/backtest/${runId}/montecarlo`, data);
+export const getBacktestMonteCarlo = (runId: string) => api.get(`/backtest/${runId}/montecarlo`);
 export const getBacktestStatus = (runId: string) => api.get(`/backtest/${runId}/status`);
 export const getBacktestMetrics = (runId: string) => api.get(`/backtest/${runId}/metrics`);
 export const getBacktestEquity = (runId: string) => api.get(`/backtest/${runId}/equity`);
@@ -96,4 +129,8 @@ export const deleteDebate = (sessionId: string) => api.delete(`/debate/sessions/
 export const getSettings = () => api.get('/settings');
 export const updateSettings = (data: any) => api.put('/settings', data);
 
+// Emergency API
+export const emergencyStopAll = (flatten: boolean) => api.post('/emergency/stop-all', { flatten });
+export const emergencyResume = () => api.post('/emergency/resume');
+
 export default api;
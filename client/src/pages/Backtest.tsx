@@ -28,6 +28,7 @@ import {
   getBacktestTrades,
   deleteBacktest,
   getStrategies,
+  errorMessage,
 } from '../lib/api';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -174,7 +175,7 @@ export default function Backtest() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to start backtest',
+        description: errorMessage(err, 'Failed to start backtest'),
         variant: 'danger',
       });
     } finally {
@@ -1,8 +1,8 @@
 import { useEffect, useState } from 'react';
 import { motion, Reorder, useDragControls } from 'framer-motion';
-import { getTraders, getStrategies, createTrader, updateTrader, deleteTrader, getSettings, updateSettings } from '../lib/api';
-import type { Trader, Strategy } from '../types';
-import { Plus, Pencil, Trash2, Save, Eye, EyeOff, Settings, RefreshCw, Zap, AlertTriangle, Key, Globe, GripVertical } from 'lucide-react';
+import { getTraders, getStrategies, createTrader, updateTrader, deleteTrader, getSettings, updateSettings, getHealth, emergencyStopAll, emergencyResume, errorMessage } from '../lib/api';
+import type { Trader, TraderConfig, Strategy, ShutdownPolicy } from '../types';
+import { Plus, Pencil, Trash2, Save, Eye, EyeOff, Settings, RefreshCw, Zap, AlertTriangle, Key, Globe, GripVertical, OctagonX, Play } from 'lucide-react';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
@@ -101,6 +101,11 @@ const TraderItem = ({
   );
 };
 
+const haltDescription = (halt: { halted: boolean; halted_at?: string }) =>
+  halt.halted
+    ? `Trading halted${halt.halted_at ? ` since ${new Date(halt.halted_at).toLocaleString()}` : ''}. Traders can't be started until resumed.`
+    : 'Stop every trader and cancel all open orders at once';
+
 export default function Config() {
   const [traders, setTraders] = useState<Trader[]>([]);
   const [strategies, setStrategies] = useState<Strategy[]>([]);
@@ -117,6 +122,8 @@ export default function Config() {
   });
   const [settingsConfigured, setSettingsConfigured] = useState({ openrouter: false, binance: false });
   const [savingSettings, setSavingSettings] = useState(false);
+  const [halt, setHalt] = useState<{ halted: boolean; halted_at?: string }>({ halted: false });
+  const [haltBusy, setHaltBusy] = useState(false);
   const { confirm, ConfirmDialog } = useConfirm();
   const { alert, AlertDialog } = useAlert();
 
@@ -164,6 +171,9 @@ export default function Config() {
       if (settingsRes.data.configured) {
         setSettingsConfigured(settingsRes.data.configured);
       }
+
+      const healthRes = await getHealth();
+      setHalt({ halted: !!healthRes.data.halted, halted_at: healthRes.data.halted_at });
     } catch (err) {
       console.error('Failed to load data:', err);
     } finally {
@@ -184,7 +194,7 @@ export default function Config() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to save settings',
+        description: errorMessage(err, 'Failed to save settings'),
         variant: 'danger',
       });
     } finally {
@@ -192,6 +202,56 @@ export default function Config() {
     }
   };
 
+  const handleEmergencyStop = async (flatten: boolean) => {
+    const confirmed = await confirm({
+      title: flatten ? 'Stop All & Close Positions' : 'Stop All Traders',
+      description: flatten
+        ? 'Every trader will be stopped, all open orders cancelled and ALL positions market-closed. No trader can be started until trading is resumed.'
+        : 'Every trader will be stopped and all open orders cancelled. Exchange SL/TP orders stay in place. No trader can be started until trading is resumed.',
+      confirmText: flatten ? 'Stop & Close' : 'Stop All',
+      variant: 'danger',
+    });
+    if (!confirmed) return;
+
+    setHaltBusy(true);
+    try {
+      const res = await emergencyStopAll(flatten);
+      const failed = (res.data.actions || []).filter((a: any) => a.result !== 'ok');
+      alert({
+        title: 'Trading Halted',
+        description: failed.length > 0
+          ? `${failed.length} step(s) failed, check the audit log: ${failed.map((a: any) => `${a.action}${a.symbol ? ' ' + a.symbol : ''}: ${a.result}`).join('; ')}`
+          : 'All traders stopped. Review the audit log for details.',
+        variant: failed.length > 0 ? 'danger' : 'success',
+      });
+      await loadData();
+    } catch (err: any) {
+      alert({
+        title: 'Error',
+        description: errorMessage(err, 'Emergency stop failed'),
+        variant: 'danger',
+      });
+    } finally {
+      setHaltBusy(false);
+    }
+  };
+
+  const handleResume = async () => {
+    setHaltBusy(true);
+    try {
+      await emergencyResume();
+      await loadData();
+    } catch (err: any) {
+      alert({
+        title: 'Error',
+        description: errorMessage(err, 'Failed to resume trading'),
+        variant: 'danger',
+      });
+    } finally {
+      setHaltBusy(false);
+    }
+  };
+
   // Handle editing - auto-detect if model is custom
   const handleEdit = (trader: Trader) => {
     const isCustomModel = !!(trader.config?.ai_model && !PRESET_MODELS.includes(trader.config.ai_model));
@@ -224,11 +284,15 @@ export default function Config() {
 
   const handleSave = async () => {
     if (!editingTrader) return;
+    // The server rejects unknown fields: drop the list's runtime status and the form-only toggle
+    const { is_running: _running, ...trader } = editingTrader as Partial<Trader> & { is_running?: boolean };
+    const { use_custom_model: _custom, ...config } = trader.config ?? ({} as TraderConfig);
+    const payload = { ...trader, config };
     try {
       if (isCreating) {
-        await createTrader(editingTrader);
+        await createTrader(payload);
       } else {
-        await updateTrader(editingTrader.id!, editingTrader);
+        await updateTrader(editingTrader.id!, payload);
       }
       setEditingTrader(null);
       setIsCreating(false);
@@ -236,7 +300,7 @@ export default function Config() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to save trader',
+        description: errorMessage(err, 'Failed to save trader'),
         variant: 'danger',
       });
     }
@@ -260,7 +324,7 @@ export default function Config() {
       console.error('Delete failed:', err);
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to delete trader',
+        description: errorMessage(err, 'Failed to delete trader'),
         variant: 'danger',
       });
     }
@@ -356,6 +420,40 @@ export default function Config() {
         </Alert>
       )}
 
+      {/* Emergency Stop */}
+      <GlassCard className={`p-5 ${halt.halted ? 'border-red-500/40 bg-red-500/10' : ''}`}>
+        <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-4">
+          <div className="flex items-center gap-3">
+            <div className="p-2 rounded-lg bg-red-500/20">
+              <OctagonX className="w-5 h-5 text-red-400" />
+            </div>
+            <div>
+              <h3 className="font-semibold text-lg">Emergency Stop</h3>
+              <p className="text-sm text-muted-foreground">
+                {haltDescription(halt)}
+              </p>
+            </div>
+          </div>
+          <div className="flex gap-2">
+            {halt.halted ? (
+              <Button onClick={handleResume} disabled={haltBusy} variant="outline" className="glass">
+                <Play className="w-4 h-4 mr-2" />
+                Resume Trading
+              </Button>
+            ) : (
+              <>
+                <Button onClick={() => handleEmergencyStop(false)} disabled={haltBusy} variant="outline" className="glass text-red-400 hover:text-red-300">
+                  Stop All
+                </Button>
+                <Button onClick={() => handleEmergencyStop(true)} disabled={haltBusy} className="bg-red-600 hover:bg-red-700 text-white">
+                  Stop All & Close Positions
+                </Button>
+              </>
+            )}
+          </div>
+        </div>
+      </GlassCard>
+
       {/* Global Settings */}
       <motion.div
         initial={{ opacity: 0, y: 20 }}
@@ -574,6 +672,26 @@ export default function Config() {
                 </div>
               </div>
 
+              <div className="space-y-2">
+                <Label>On Stop</Label>
+                <Select
+                  value={editingTrader.config?.shutdown_policy || 'leave'}
+                  onValueChange={(v) => setEditingTrader({
+                    ...editingTrader,
+                    config: { ...editingTrader.config!, shutdown_policy: v as ShutdownPolicy }
+                  })}
+                >
+                  <SelectTrigger className="glass">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="leave">Leave positions open</SelectItem>
+                    <SelectItem value="flatten">Close all positions and cancel orders</SelectItem>
+                    <SelectItem value="protect">Move stops to breakeven, close losing positions</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+
               {/* Exchange Settings */}
               <GlassCard className="p-4">
                 <h3 className="font-medium mb-4">Exchange Settings</h3>
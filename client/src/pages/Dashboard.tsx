@@ -7,6 +7,7 @@ import {
   getAccount,
   startTrader,
   stopTrader,
+  errorMessage,
 } from "../lib/api";
 import type { Trader, Position } from "../types";
 import {
@@ -115,7 +116,7 @@ export default function Dashboard() {
     } catch (err: any) {
       alert({
         title: "Error",
-        description: err.response?.data?.error || "Failed to start trader",
+        description: errorMessage(err, "Failed to start trader"),
         variant: "danger",
       });
     }
@@ -22,6 +22,7 @@ import {
   startDebate,
   stopDebate,
   deleteDebate,
+  errorMessage,
 } from '../lib/api';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -262,7 +263,7 @@ export default function Debate() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to create debate',
+        description: errorMessage(err, 'Failed to create debate'),
         variant: 'danger',
       });
     } finally {
@@ -34,7 +34,7 @@ import { StatCard } from '@/components/ui/stat-card';
 
 interface EquityPoint {
   timestamp: string;
-  total_equity: number; // matches server field name
+  total_equity: number; // matches server field name; the close for hourly/daily bars
 }
 
 interface DailyReturn {
@@ -45,8 +45,7 @@ interface DailyReturn {
 export default function Equity() {
   const [traders, setTraders] = useState<any[]>([]);
   const [selectedTrader, setSelectedTrader] = useState<string>('');
-  const [allEquityData, setAllEquityData] = useState<EquityPoint[]>([]); // Store all data
-  const [equityData, setEquityData] = useState<EquityPoint[]>([]); // Filtered data for display
+  const [equityData, setEquityData] = useState<EquityPoint[]>([]);
   const [account, setAccount] = useState<any>(null);
   const [timeRange, setTimeRange] = useState('1M');
   const [loading, setLoading] = useState(true);
@@ -55,16 +54,12 @@ export default function Equity() {
     loadTraders();
   }, []);
 
+  // The server picks raw, hourly or daily points for the range
   useEffect(() => {
     if (selectedTrader) {
       loadEquityData();
     }
-  }, [selectedTrader]);
-
-  // Re-filter when timeRange changes
-  useEffect(() => {
-    setEquityData(filterByTimeRange(allEquityData, timeRange));
-  }, [timeRange, allEquityData]);
+  }, [selectedTrader, timeRange]);
 
   const loadTraders = async () => {
     try {
@@ -80,47 +75,22 @@ export default function Equity() {
     }
   };
 
-  // Filter equity data based on time range
-  const filterByTimeRange = (
-    data: EquityPoint[],
-    range: string,
-  ): EquityPoint[] => {
-    if (!data.length || range === 'ALL') return data;
-
-    const now = new Date();
-    let cutoff: Date;
-
-    switch (range) {
-      case '1D':
-        cutoff = new Date(now.getTime() - 24 * 60 * 60 * 1000);
-        break;
-      case '1W':
-        cutoff = new Date(now.getTime() - 7 * 24 * 60 * 60 * 1000);
-        break;
-      case '1M':
-        cutoff = new Date(now.getTime() - 30 * 24 * 60 * 60 * 1000);
-        break;
-      case '3M':
-        cutoff = new Date(now.getTime() - 90 * 24 * 60 * 60 * 1000);
-        break;
-      default:
-        return data;
-    }
-
-    return data.filter(point => new Date(point.timestamp) >= cutoff);
+  // Start of the time range in Unix ms, undefined for all history
+  const rangeStart = (range: string): number | undefined => {
+    const day = 24 * 60 * 60 * 1000;
+    const days: Record<string, number> = { '1D': 1, '1W': 7, '1M': 30, '3M': 90 };
+    return days[range] ? Date.now() - days[range] * day : undefined;
   };
 
   const loadEquityData = async () => {
     try {
       const [equityRes, accountRes] = await Promise.all([
-        getEquityHistory(selectedTrader).catch(() => ({
+        getEquityHistory(selectedTrader, rangeStart(timeRange)).catch(() => ({
           data: { history: [] },
         })),
         getAccount(selectedTrader).catch(() => ({ data: null })),
       ]);
-      const allData = equityRes.data.history || [];
-      setAllEquityData(allData); // Store all data
-      setEquityData(filterByTimeRange(allData, timeRange)); // Set filtered data
+      setEquityData(equityRes.data.history || []);
       setAccount(accountRes.data);
     } catch (err) {
       console.error('Failed to load equity data:', err);
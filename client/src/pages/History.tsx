@@ -37,6 +37,7 @@ interface Decision {
   confidence: number;
   reasoning: string;
   executed: boolean;
+  skip_reason?: string; // A risk filter passed on the trade, e.g. adverse funding
   pnl?: number;
   created_at: string;
 }
@@ -150,6 +151,7 @@ export default function History() {
               confidence: confidence,
               reasoning: reasoning,
               executed: raw.executed,
+              skip_reason: dec.skip_reason,
               pnl: dec.pnl,
               created_at: raw.timestamp,
             });
@@ -640,7 +642,11 @@ export default function History() {
                   {
                     key: 'executed',
                     label: 'Status',
-                    render: (v) => (
+                    render: (v, d) => d.skip_reason ? (
+                      <span title={d.skip_reason}>
+                        <GlowBadge variant="warning">Skipped</GlowBadge>
+                      </span>
+                    ) : (
                       <GlowBadge variant={v ? 'success' : 'secondary'} dot={v}>
                         {v ? 'Executed' : 'Pending'}
                       </GlowBadge>
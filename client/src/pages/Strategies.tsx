@@ -1,7 +1,7 @@
 import { useEffect, useState } from 'react';
 import { motion, AnimatePresence } from 'framer-motion';
-import { getStrategies, createStrategy, updateStrategy, deleteStrategy, getDefaultConfig, recommendPairs } from '../lib/api';
-import type { Strategy, StrategyConfig } from '../types';
+import { getStrategies, createStrategy, updateStrategy, deleteStrategy, getDefaultConfig, recommendPairs, errorMessage } from '../lib/api';
+import type { Strategy, StrategyConfig, ScheduleConfig } from '../types';
 import {
   Plus,
   Pencil,
@@ -100,6 +100,7 @@ export default function Strategies() {
     coinSource: true,
     indicators: true,
     riskControl: true,
+    schedule: false,
     aiPrompt: false,
   });
   const [coinInput, setCoinInput] = useState("");
@@ -167,7 +168,7 @@ export default function Strategies() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to save strategy',
+        description: errorMessage(err, 'Failed to save strategy'),
         variant: 'danger',
       });
     }
@@ -187,7 +188,7 @@ export default function Strategies() {
     } catch (err: any) {
       alert({
         title: 'Error',
-        description: err.response?.data?.error || 'Failed to delete strategy',
+        description: errorMessage(err, 'Failed to delete strategy'),
         variant: 'danger',
       });
     }
@@ -689,6 +690,94 @@ export default function Strategies() {
                       </p>
                     </div>
 
+                    {/* Smart Find Filters */}
+                    <div className="space-y-3 pt-3 border-t border-white/10">
+                      <div>
+                        <Label className="text-sm font-medium">Smart Find Filters</Label>
+                        <p className="text-xs text-muted-foreground">Applied before the AI sees the candidates. Excluded symbols are dropped even if the AI picks them (0 = off).</p>
+                      </div>
+                      <div className="grid grid-cols-2 gap-3">
+                        <div className="space-y-2">
+                          <Label className="text-xs">Exclude Symbols</Label>
+                          <Input
+                            value={(editingStrategy.config.coin_source.smart_find_exclude_symbols || []).join(',')}
+                            onChange={(e) => setEditingStrategy({
+                              ...editingStrategy,
+                              config: {
+                                ...editingStrategy.config,
+                                coin_source: {
+                                  ...editingStrategy.config.coin_source,
+                                  smart_find_exclude_symbols: e.target.value.toUpperCase().split(',')
+                                }
+                              }
+                            })}
+                            className="glass h-8 text-sm"
+                            placeholder="PEPEUSDT, WIFUSDT"
+                          />
+                        </div>
+                        <div className="space-y-2">
+                          <Label className="text-xs">Include Only</Label>
+                          <Input
+                            value={(editingStrategy.config.coin_source.smart_find_include_only || []).join(',')}
+                            onChange={(e) => setEditingStrategy({
+                              ...editingStrategy,
+                              config: {
+                                ...editingStrategy.config,
+                                coin_source: {
+                                  ...editingStrategy.config.coin_source,
+                                  smart_find_include_only: e.target.value.toUpperCase().split(',')
+                                }
+                              }
+                            })}
+                            className="glass h-8 text-sm"
+                            placeholder="Empty = all symbols"
+                          />
+                        </div>
+                        <div className="space-y-2">
+                          <Label className="text-xs">Min Listing Age (days)</Label>
+                          <Input
+                            type="number"
+                            min="0"
+                            step="1"
+                            value={editingStrategy.config.coin_source.min_listing_age_days ?? 0}
+                            onChange={(e) => setEditingStrategy({
+                              ...editingStrategy,
+                              config: {
+                                ...editingStrategy.config,
+                                coin_source: {
+                                  ...editingStrategy.config.coin_source,
+                                  min_listing_age_days: parseInt(e.target.value) || 0
+                                }
+                              }
+                            })}
+                            className="glass h-8 text-sm"
+                            placeholder="0"
+                          />
+                        </div>
+                        <div className="space-y-2">
+                          <Label className="text-xs">Max |Funding Rate| (%)</Label>
+                          <Input
+                            type="number"
+                            min="0"
+                            step="0.01"
+                            value={editingStrategy.config.coin_source.max_funding_rate_abs ?? 0}
+                            onChange={(e) => setEditingStrategy({
+                              ...editingStrategy,
+                              config: {
+                                ...editingStrategy.config,
+                                coin_source: {
+                                  ...editingStrategy.config.coin_source,
+                                  max_funding_rate_abs: parseFloat(e.target.value) || 0
+                                }
+                              }
+                            })}
+                            className="glass h-8 text-sm"
+                            placeholder="0"
+                          />
+                        </div>
+                      </div>
+                    </div>
+
                   </CollapsibleSection>
 
                   {/* Technical Indicators */}
@@ -812,7 +901,7 @@ export default function Strategies() {
                           { key: 'enable_rsi', label: 'RSI', desc: 'Relative Strength Index' },
                           { key: 'enable_atr', label: 'ATR', desc: 'Average True Range' },
                           { key: 'enable_boll', label: 'Bollinger', desc: 'Bollinger Bands' },
-                          { key: 'enable_volume', label: 'Volume', desc: 'Volume Analysis' },
+                          { key: 'enable_volume', label: 'Volume', desc: 'Volume Trend & VWAP' },
                         ].map((ind) => (
                           <label
                             key={ind.key}
@@ -836,6 +925,112 @@ export default function Strategies() {
                         ))}
                       </div>
 
+                      {/* Bollinger Bands settings */}
+                      {editingStrategy.config.indicators.enable_boll && (
+                        <div className="p-4 rounded-lg bg-white/5 border border-white/10 mt-4 space-y-3">
+                          <div>
+                            <h4 className="font-medium">Bollinger Bands</h4>
+                            <p className="text-xs text-muted-foreground">Bands are the moving average plus/minus this many standard deviations. A squeeze is flagged when they're much narrower than usual.</p>
+                          </div>
+                          <div className="grid grid-cols-2 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Period</Label>
+                              <Input
+                                type="number"
+                                min="1"
+                                step="1"
+                                value={editingStrategy.config.indicators.boll_period ?? 20}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    indicators: {
+                                      ...editingStrategy.config.indicators,
+                                      boll_period: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="20"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Std Dev Multiplier</Label>
+                              <Input
+                                type="number"
+                                min="1"
+                                step="0.1"
+                                value={editingStrategy.config.indicators.boll_std_dev ?? 2}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    indicators: {
+                                      ...editingStrategy.config.indicators,
+                                      boll_std_dev: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="2"
+                              />
+                            </div>
+                          </div>
+                        </div>
+                      )}
+
+                      {/* Support/resistance settings */}
+                      <div className="p-4 rounded-lg bg-white/5 border border-white/10 mt-4 space-y-3">
+                        <div>
+                          <h4 className="font-medium">Key Levels</h4>
+                          <p className="text-xs text-muted-foreground">Support and resistance from swing highs and lows, shown to the AI with the 24h range and previous day close so stops go beyond real levels.</p>
+                        </div>
+                        <div className="grid grid-cols-2 gap-3">
+                          <div className="space-y-2">
+                            <Label className="text-xs">Lookback (candles)</Label>
+                            <Input
+                              type="number"
+                              min="10"
+                              step="1"
+                              value={editingStrategy.config.indicators.sr_lookback ?? 100}
+                              onChange={(e) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  indicators: {
+                                    ...editingStrategy.config.indicators,
+                                    sr_lookback: parseInt(e.target.value)
+                                  }
+                                }
+                              })}
+                              className="glass h-8 text-sm"
+                              placeholder="100"
+                            />
+                          </div>
+                          <div className="space-y-2">
+                            <Label className="text-xs">Sensitivity (candles each side)</Label>
+                            <Input
+                              type="number"
+                              min="1"
+                              step="1"
+                              value={editingStrategy.config.indicators.sr_sensitivity ?? 3}
+                              onChange={(e) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  indicators: {
+                                    ...editingStrategy.config.indicators,
+                                    sr_sensitivity: parseInt(e.target.value)
+                                  }
+                                }
+                              })}
+                              className="glass h-8 text-sm"
+                              placeholder="3"
+                            />
+                          </div>
+                        </div>
+                      </div>
+
                       {/* Multi-Timeframe Confirmation */}
                       <div className="p-4 rounded-lg bg-white/5 border border-white/10 mt-4">
                         <div className="flex items-center justify-between mb-3">
@@ -1396,6 +1591,431 @@ export default function Strategies() {
                           )}
                         </div>
 
+                        {/* Re-entry Cooldown */}
+                        <div className="p-4 rounded-lg bg-sky-400/5 border border-sky-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-sky-300">Re-entry Cooldown</span>
+                            <p className="text-xs text-muted-foreground">Don't reopen a symbol right after closing it (0 = off)</p>
+                          </div>
+                          <div className="grid grid-cols-1 sm:grid-cols-2 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">After Close (mins)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                value={editingStrategy.config.risk_control.cooldown_mins_after_close ?? 15}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      cooldown_mins_after_close: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="15"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">After Stop Loss (mins)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                value={editingStrategy.config.risk_control.cooldown_mins_after_stop_loss ?? 60}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      cooldown_mins_after_stop_loss: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="60"
+                              />
+                            </div>
+                          </div>
+                        </div>
+
+                        {/* Risk Check Interval */}
+                        <div className="p-4 rounded-lg bg-teal-400/5 border border-teal-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-teal-300">Risk Check Interval</span>
+                            <p className="text-xs text-muted-foreground">How often trailing stop, max hold, smart loss cut and daily loss are checked between AI cycles</p>
+                          </div>
+                          <div className="space-y-2">
+                            <Label className="text-xs">Interval (seconds)</Label>
+                            <Input
+                              type="number"
+                              min="5"
+                              value={editingStrategy.config.risk_control.risk_check_interval_secs ?? 20}
+                              onChange={(e) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  risk_control: {
+                                    ...editingStrategy.config.risk_control,
+                                    risk_check_interval_secs: parseInt(e.target.value)
+                                  }
+                                }
+                              })}
+                              className="glass h-8 text-sm"
+                              placeholder="20"
+                            />
+                          </div>
+                        </div>
+
+                        {/* Dead-Man Switch */}
+                        <div className="p-4 rounded-lg bg-rose-400/5 border border-rose-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-rose-300">Dead-Man Switch</span>
+                            <p className="text-xs text-muted-foreground">Bound losses if the server loses its connection to Binance</p>
+                          </div>
+                          <label className="flex items-center gap-3 cursor-pointer">
+                            <Checkbox
+                              checked={editingStrategy.config.risk_control.enable_backstop_stop ?? false}
+                              onCheckedChange={(c) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  risk_control: {
+                                    ...editingStrategy.config.risk_control,
+                                    enable_backstop_stop: !!c
+                                  }
+                                }
+                              })}
+                              className="data-[state=checked]:bg-rose-400 data-[state=checked]:border-rose-400 data-[state=checked]:text-black"
+                            />
+                            <div>
+                              <span className="text-sm text-rose-200">Backstop Stop</span>
+                              <p className="text-xs text-muted-foreground">Wide exchange stop on positions without one while trailing stop or smart loss cut manage them</p>
+                            </div>
+                          </label>
+                          <div className="grid grid-cols-1 sm:grid-cols-3 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Backstop Distance (Price %)</Label>
+                              <Input
+                                type="number"
+                                step="0.5"
+                                min="0"
+                                value={editingStrategy.config.risk_control.backstop_stop_pct ?? 5}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      backstop_stop_pct: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="5"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Failed Fetches Before Alert</Label>
+                              <Input
+                                type="number"
+                                min="1"
+                                value={editingStrategy.config.risk_control.exchange_failure_threshold ?? 5}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      exchange_failure_threshold: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="5"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Auto-Cancel (secs, 0 = off)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                value={editingStrategy.config.risk_control.auto_cancel_secs ?? 0}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      auto_cancel_secs: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="0"
+                              />
+                            </div>
+                          </div>
+                          <label className="flex items-center gap-3 cursor-pointer">
+                            <Checkbox
+                              checked={editingStrategy.config.risk_control.flatten_on_reconnect ?? false}
+                              onCheckedChange={(c) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  risk_control: {
+                                    ...editingStrategy.config.risk_control,
+                                    flatten_on_reconnect: !!c
+                                  }
+                                }
+                              })}
+                              className="data-[state=checked]:bg-rose-400 data-[state=checked]:border-rose-400 data-[state=checked]:text-black"
+                            />
+                            <div>
+                              <span className="text-sm text-rose-200">Flatten On Reconnect</span>
+                              <p className="text-xs text-muted-foreground">Close every position once Binance is reachable again after an outage</p>
+                            </div>
+                          </label>
+                        </div>
+
+                        {/* Position Sizing */}
+                        <div className="p-4 rounded-lg bg-sky-400/5 border border-sky-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-sky-300">Position Sizing</span>
+                            <p className="text-xs text-muted-foreground">ATR risk sizes each entry so a stop the given number of ATRs away loses a fixed percent of equity; position limits still apply</p>
+                          </div>
+                          <div className="space-y-2">
+                            <Label className="text-xs">Sizing Mode</Label>
+                            <Select
+                              value={editingStrategy.config.risk_control.sizing_mode || 'fixed_pct'}
+                              onValueChange={(v) => setEditingStrategy({
+                                ...editingStrategy,
+                                config: {
+                                  ...editingStrategy.config,
+                                  risk_control: {
+                                    ...editingStrategy.config.risk_control,
+                                    sizing_mode: v as 'fixed_pct' | 'atr_risk'
+                                  }
+                                }
+                              })}
+                            >
+                              <SelectTrigger className="glass h-8 text-sm">
+                                <SelectValue />
+                              </SelectTrigger>
+                              <SelectContent>
+                                <SelectItem value="fixed_pct">Fixed percent of equity</SelectItem>
+                                <SelectItem value="atr_risk">ATR risk</SelectItem>
+                              </SelectContent>
+                            </Select>
+                          </div>
+                          {editingStrategy.config.risk_control.sizing_mode === 'atr_risk' && (
+                            <div className="grid grid-cols-2 gap-3">
+                              <div className="space-y-2">
+                                <Label className="text-xs">Risk per Trade (%)</Label>
+                                <Input
+                                  type="number"
+                                  min="0.1"
+                                  step="0.1"
+                                  value={editingStrategy.config.risk_control.risk_per_trade_pct ?? 1}
+                                  onChange={(e) => setEditingStrategy({
+                                    ...editingStrategy,
+                                    config: {
+                                      ...editingStrategy.config,
+                                      risk_control: {
+                                        ...editingStrategy.config.risk_control,
+                                        risk_per_trade_pct: parseFloat(e.target.value)
+                                      }
+                                    }
+                                  })}
+                                  className="glass h-8 text-sm"
+                                  placeholder="1"
+                                />
+                              </div>
+                              <div className="space-y-2">
+                                <Label className="text-xs">Stop Distance (× ATR)</Label>
+                                <Input
+                                  type="number"
+                                  min="0.5"
+                                  step="0.5"
+                                  value={editingStrategy.config.risk_control.atr_stop_multiple ?? 2}
+                                  onChange={(e) => setEditingStrategy({
+                                    ...editingStrategy,
+                                    config: {
+                                      ...editingStrategy.config,
+                                      risk_control: {
+                                        ...editingStrategy.config.risk_control,
+                                        atr_stop_multiple: parseFloat(e.target.value)
+                                      }
+                                    }
+                                  })}
+                                  className="glass h-8 text-sm"
+                                  placeholder="2"
+                                />
+                              </div>
+                            </div>
+                          )}
+                        </div>
+
+                        {/* Slippage & Liquidity */}
+                        <div className="p-4 rounded-lg bg-amber-400/5 border border-amber-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-amber-300">Slippage & Liquidity</span>
+                            <p className="text-xs text-muted-foreground">Entries the order book can't fill within Max Slippage are skipped, and fills further off are warned about with SL/TP placed from the actual fill (0 = off). The AI is warned when the book within 0.5% of mid holds less than Min Depth.</p>
+                          </div>
+                          <div className="grid grid-cols-2 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Max Slippage (%)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                step="0.1"
+                                value={editingStrategy.config.risk_control.max_slippage_pct ?? 0.5}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      max_slippage_pct: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="0.5"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Min Depth ($)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                step="1000"
+                                value={editingStrategy.config.risk_control.min_depth_usd ?? 50000}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      min_depth_usd: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="50000"
+                              />
+                            </div>
+                          </div>
+                        </div>
+
+                        {/* Funding Filter */}
+                        <div className="p-4 rounded-lg bg-amber-400/5 border border-amber-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-amber-300">Funding Filter</span>
+                            <p className="text-xs text-muted-foreground">Entries on the side paying more funding per 8h than Max Adverse Funding are skipped unless the AI's confidence reaches the override (0 = off). The AI sees each symbol's funding cost per day.</p>
+                          </div>
+                          <div className="grid grid-cols-2 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Max Adverse Funding (% per 8h)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                step="0.01"
+                                value={editingStrategy.config.risk_control.max_adverse_funding_rate ?? 0}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      max_adverse_funding_rate: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="0.05"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Override Confidence (%)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                max="100"
+                                step="1"
+                                value={editingStrategy.config.risk_control.funding_override_confidence ?? 95}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      funding_override_confidence: parseInt(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="95"
+                              />
+                            </div>
+                          </div>
+                        </div>
+
+                        {/* Correlation Guard */}
+                        <div className="p-4 rounded-lg bg-rose-400/5 border border-rose-400/20 space-y-3">
+                          <div>
+                            <span className="font-medium text-rose-300">Correlation Guard</span>
+                            <p className="text-xs text-muted-foreground">Same-side positions in symbols that move together count as one bet; entries that push a group past the limit are blocked (0 = off). Without enough history all alts are grouped.</p>
+                          </div>
+                          <div className="grid grid-cols-2 gap-3">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Max Correlated Exposure (% of equity)</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                step="50"
+                                value={editingStrategy.config.risk_control.max_correlated_exposure ?? 400}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      max_correlated_exposure: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="400"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Correlation Threshold</Label>
+                              <Input
+                                type="number"
+                                min="0"
+                                max="1"
+                                step="0.05"
+                                value={editingStrategy.config.risk_control.correlation_threshold ?? 0.8}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: {
+                                    ...editingStrategy.config,
+                                    risk_control: {
+                                      ...editingStrategy.config.risk_control,
+                                      correlation_threshold: parseFloat(e.target.value)
+                                    }
+                                  }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="0.8"
+                              />
+                            </div>
+                          </div>
+                        </div>
+
                         {/* Noise Zone Protection */}
                         <div className="p-4 rounded-lg bg-violet-400/5 border border-violet-400/20 space-y-3">
                           <label className="flex items-center gap-3 cursor-pointer">
@@ -1511,6 +2131,162 @@ export default function Strategies() {
                     </div>
                   </CollapsibleSection>
 
+                  {/* Trading Schedule */}
+                  <CollapsibleSection title="Trading Schedule" icon={Clock} isExpanded={expandedSections.schedule} onToggle={() => toggleSection('schedule')}>
+                    {(() => {
+                      const schedule: ScheduleConfig = editingStrategy.config.schedule ?? {
+                        enabled: false,
+                        timezone: '',
+                        windows: [],
+                        out_of_window: 'pause_entries',
+                      };
+                      const setSchedule = (next: Partial<ScheduleConfig>) => setEditingStrategy({
+                        ...editingStrategy,
+                        config: {
+                          ...editingStrategy.config,
+                          schedule: { ...schedule, ...next }
+                        }
+                      });
+                      const days = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
+
+                      return (
+                        <div className="space-y-4">
+                          <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                            <div className="space-y-2">
+                              <Label className="text-xs">Trading Day Timezone</Label>
+                              <Input
+                                value={editingStrategy.config.timezone ?? ''}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: { ...editingStrategy.config, timezone: e.target.value }
+                                })}
+                                className="glass h-8 text-sm"
+                                placeholder="UTC or Asia/Tokyo"
+                              />
+                            </div>
+                            <div className="space-y-2">
+                              <Label className="text-xs">Day Starts At (hour)</Label>
+                              <Input
+                                type="number"
+                                min={0}
+                                max={23}
+                                value={editingStrategy.config.day_start_hour ?? 0}
+                                onChange={(e) => setEditingStrategy({
+                                  ...editingStrategy,
+                                  config: { ...editingStrategy.config, day_start_hour: parseInt(e.target.value) || 0 }
+                                })}
+                                className="glass h-8 text-sm"
+                              />
+                            </div>
+                          </div>
+                          <p className="text-xs text-muted-foreground -mt-2">Daily loss limits and daily reports reset at this time</p>
+
+                          <div className="flex items-center justify-between">
+                            <div>
+                              <Label className="text-sm font-medium">Only trade during set hours</Label>
+                              <p className="text-xs text-muted-foreground">Open positions stay protected by risk checks outside these windows</p>
+                            </div>
+                            <input
+                              type="checkbox"
+                              checked={schedule.enabled}
+                              onChange={(e) => setSchedule({ enabled: e.target.checked })}
+                              className="w-4 h-4 rounded border-white/20 bg-white/5 text-cyan-500 focus:ring-cyan-500/20"
+                            />
+                          </div>
+
+                          {schedule.enabled && (
+                            <>
+                              <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                                <div className="space-y-2">
+                                  <Label className="text-xs">Timezone</Label>
+                                  <Input
+                                    value={schedule.timezone}
+                                    onChange={(e) => setSchedule({ timezone: e.target.value })}
+                                    className="glass h-8 text-sm"
+                                    placeholder="Trading day timezone by default"
+                                  />
+                                </div>
+                                <div className="space-y-2">
+                                  <Label className="text-xs">Outside Windows</Label>
+                                  <Select
+                                    value={schedule.out_of_window || 'pause_entries'}
+                                    onValueChange={(v) => setSchedule({ out_of_window: v as ScheduleConfig['out_of_window'] })}
+                                  >
+                                    <SelectTrigger className="glass h-8 text-sm">
+                                      <SelectValue />
+                                    </SelectTrigger>
+                                    <SelectContent>
+                                      <SelectItem value="pause_entries">Pause entries (keep managing exits)</SelectItem>
+                                      <SelectItem value="full_pause">Full pause (risk checks only)</SelectItem>
+                                    </SelectContent>
+                                  </Select>
+                                </div>
+                              </div>
+
+                              <div className="space-y-2">
+                                {schedule.windows.map((w, i) => (
+                                  <div key={i} className="flex items-center gap-2">
+                                    <Select
+                                      value={String(w.day)}
+                                      onValueChange={(v) => setSchedule({
+                                        windows: schedule.windows.map((x, j) => j === i ? { ...x, day: parseInt(v) } : x)
+                                      })}
+                                    >
+                                      <SelectTrigger className="w-[90px] h-8 text-xs">
+                                        <SelectValue />
+                                      </SelectTrigger>
+                                      <SelectContent>
+                                        {days.map((d, n) => (
+                                          <SelectItem key={d} value={String(n)}>{d}</SelectItem>
+                                        ))}
+                                      </SelectContent>
+                                    </Select>
+                                    <Input
+                                      type="time"
+                                      value={w.start}
+                                      onChange={(e) => setSchedule({
+                                        windows: schedule.windows.map((x, j) => j === i ? { ...x, start: e.target.value } : x)
+                                      })}
+                                      className="glass h-8 text-sm w-[120px]"
+                                    />
+                                    <span className="text-xs text-muted-foreground">to</span>
+                                    <Input
+                                      value={w.end}
+                                      onChange={(e) => setSchedule({
+                                        windows: schedule.windows.map((x, j) => j === i ? { ...x, end: e.target.value } : x)
+                                      })}
+                                      className="glass h-8 text-sm w-[120px]"
+                                      placeholder="24:00"
+                                    />
+                                    <Button
+                                      variant="ghost"
+                                      size="sm"
+                                      className="h-8 w-8 p-0"
+                                      onClick={() => setSchedule({ windows: schedule.windows.filter((_, j) => j !== i) })}
+                                    >
+                                      <X className="w-4 h-4" />
+                                    </Button>
+                                  </div>
+                                ))}
+                                <Button
+                                  variant="ghost"
+                                  size="sm"
+                                  className="h-7 text-xs text-primary"
+                                  onClick={() => setSchedule({ windows: [...schedule.windows, { day: 1, start: '09:00', end: '17:00' }] })}
+                                >
+                                  <Plus className="w-3 h-3 mr-1" /> Add Window
+                                </Button>
+                                <p className="text-xs text-muted-foreground">
+                                  An end time at or before the start runs past midnight. Windows may not overlap.
+                                </p>
+                              </div>
+                            </>
+                          )}
+                        </div>
+                      );
+                    })()}
+                  </CollapsibleSection>
+
                   {/* AI Settings */}
                   <CollapsibleSection title="AI Settings" icon={Brain} isExpanded={expandedSections.aiPrompt} onToggle={() => toggleSection('aiPrompt')}>
                     <div className="space-y-4">
@@ -14,6 +14,7 @@ export interface StrategyConfig {
   risk_control: RiskControlConfig;
   ai: AIConfig;
   custom_prompt: string;
+  experiment?: ExperimentConfig; // Prompt A/B test, variants replace custom_prompt per cycle
   trading_interval: number;
   turbo_mode: boolean;
   simple_mode?: boolean;
@@ -21,16 +22,74 @@ export interface StrategyConfig {
   // Smart Find Auto-Refresh
   smart_find_auto_refresh?: boolean;
   smart_find_refresh_mins?: number;
+  schedule?: ScheduleConfig;
+  // Trading day boundary for the daily loss baseline, daily reports and the schedule
+  timezone?: string; // IANA name, empty = UTC
+  day_start_hour?: number; // 0-23 in timezone
+}
+
+export interface PromptVariant {
+  id: string;
+  custom_prompt: string;
+}
+
+export interface ExperimentConfig {
+  enabled: boolean;
+  assignment?: 'alternate' | 'random';
+  seed?: number;
+  variants: PromptVariant[];
+}
+
+export interface VariantStats {
+  variant: string;
+  cycles: number;
+  decisions: Record<string, number>; // action -> count
+  ai_failures: number;
+  positions: number; // Closed
+  open_positions: number;
+  wins: number;
+  win_rate: number;
+  total_pnl: number;
+  avg_pnl: number;
+}
+
+export interface ScheduleWindow {
+  day: number; // 0 = Sunday ... 6 = Saturday
+  start: string; // "HH:MM"
+  end: string; // "HH:MM", "24:00" for end of day
+}
+
+export interface ScheduleConfig {
+  enabled: boolean;
+  timezone: string; // Empty = the strategy's timezone
+  windows: ScheduleWindow[];
+  out_of_window: 'pause_entries' | 'full_pause';
 }
 
 export interface AIConfig {
   enable_reasoning: boolean;
   reasoning_model: string;
+  // Sampling settings; left out, the defaults apply
+  temperature?: number; // 0-2, default 0.7
+  top_p?: number; // Above 0, up to 1
+  max_tokens?: number; // Default 4096
+  reasoning_effort?: 'low' | 'medium' | 'high';
+  // Left out, OpenRouter with the trader's model
+  provider?: 'openrouter' | 'ollama';
+  model?: string; // Required for ollama, e.g. llama3:70b
+  fallback_models?: string[]; // Tried in order when the model fails a decision
 }
 
 export interface CoinSourceConfig {
   source_type: string;
   static_coins: string[];
+  remote_url?: string; // JSON or CSV symbol list, for source_type 'remote'
+  remote_max_symbols?: number;
+  remote_refresh_mins?: number;
+  smart_find_exclude_symbols?: string[];
+  smart_find_include_only?: string[];
+  min_listing_age_days?: number;
+  max_funding_rate_abs?: number;
 }
 
 export interface IndicatorConfig {
@@ -46,6 +105,9 @@ export interface IndicatorConfig {
   rsi_period: number;
   atr_period: number;
   boll_period: number;
+  boll_std_dev?: number;
+  sr_lookback?: number;
+  sr_sensitivity?: number;
   macd_fast: number;
   macd_slow: number;
   macd_signal: number;
@@ -58,6 +120,15 @@ export interface RiskControlConfig {
   max_leverage: number;
   max_position_percent: number;
   max_margin_usage: number;
+  max_slippage_pct?: number;
+  min_depth_usd?: number;
+  max_adverse_funding_rate?: number;
+  funding_override_confidence?: number;
+  sizing_mode?: 'fixed_pct' | 'atr_risk';
+  risk_per_trade_pct?: number;
+  atr_stop_multiple?: number;
+  max_correlated_exposure?: number;
+  correlation_threshold?: number;
   min_position_usd: number;
   min_position_size_btc_eth?: number;
   min_confidence: number;
@@ -67,6 +138,8 @@ export interface RiskControlConfig {
   max_drawdown_pct?: number;
   stop_trading_mins?: number;
   close_positions_on_daily_loss?: boolean;
+  max_total_drawdown_pct?: number;
+  max_consecutive_losses?: number;
   enable_emergency_shutdown?: boolean;
   emergency_min_balance?: number;
   // Trailing Stop Loss
@@ -80,6 +153,17 @@ export interface RiskControlConfig {
   enable_smart_loss_cut?: boolean;
   smart_loss_cut_mins?: number;
   smart_loss_cut_pct?: number;
+  // Re-entry Cooldown
+  cooldown_mins_after_close?: number;
+  cooldown_mins_after_stop_loss?: number;
+  // Risk Check Loop
+  risk_check_interval_secs?: number;
+  // Dead-Man Switch
+  enable_backstop_stop?: boolean;
+  backstop_stop_pct?: number;
+  auto_cancel_secs?: number;
+  exchange_failure_threshold?: number;
+  flatten_on_reconnect?: boolean;
   // Noise Zone Protection
   enable_noise_zone_protection?: boolean;
   noise_zone_lower_bound?: number;
@@ -110,6 +194,42 @@ export interface TraderConfig {
   // Per-trader OpenRouter config (falls back to global if empty)
   openrouter_api_key?: string;
   openrouter_model?: string;
+  // What stopping the trader does with its open positions
+  shutdown_policy?: ShutdownPolicy;
+}
+
+export type ShutdownPolicy = 'leave' | 'flatten' | 'protect';
+
+// One step a stop took under the trader's shutdown policy
+export interface ShutdownAction {
+  time: string;
+  trader_id?: string;
+  action: string;
+  symbol?: string;
+  result: string; // "ok" or the error
+}
+
+export interface ShutdownReport {
+  policy: ShutdownPolicy;
+  actions: ShutdownAction[];
+}
+
+export interface SmartFindCandidate {
+  symbol: string;
+  quote_volume: number;
+  price_change_pct: number;
+}
+
+export interface SmartFindRun {
+  id: number;
+  trader_id: string;
+  timestamp: string;
+  trigger: 'auto' | 'manual';
+  candidates: SmartFindCandidate[] | null;
+  recommended: string[] | null;
+  selected: string[] | null;
+  raw_response: string;
+  error?: string;
 }
 
 export interface Position {
@@ -129,4 +249,253 @@ export interface Decision {
   timestamp: string;
   decisions: string;
   executed: boolean;
+  variant?: string;
+  blocked?: BlockedDecision[];
+}
+
+export interface BlockedDecision {
+  id: number;
+  trader_id: string;
+  decision_id: number;
+  timestamp: string;
+  symbol: string;
+  action: string;
+  confidence: number;
+  reason: string;
+}
+
+export interface TraderStats {
+  total_trades: number;
+  win_trades: number;
+  loss_trades: number;
+  win_rate: number;
+  profit_factor: number;
+  sharpe_ratio: number;
+  total_pnl: number;
+  total_fees: number;
+  avg_win: number;
+  avg_loss: number;
+  max_drawdown_pct: number;
+}
+
+export interface OverviewPosition {
+  symbol: string;
+  side: 'LONG' | 'SHORT';
+  amount: number;
+  entry_price: number;
+  mark_price: number;
+  leverage: number;
+  notional: number;
+  margin: number;
+  unrealized_pnl: number;
+  pnl_pct: number;
+  roe_pct: number;
+}
+
+export interface TraderOverview {
+  trader_id: string;
+  name: string;
+  status: string;
+  strategy_id: string;
+  running: boolean;
+  account: {
+    total_equity: number;
+    wallet_balance: number;
+    available: number;
+    unrealized_pnl: number;
+  } | null;
+  positions: OverviewPosition[];
+  stats: TraderStats;
+  realized: {
+    realized_pnl_today: number;
+    realized_pnl_total: number;
+    fees_total: number;
+  };
+  daily: {
+    since: string;
+    start_equity: number;
+    pnl: number;
+    pnl_pct: number;
+    loss_limit_pct: number;
+    limit_active: boolean;
+    headroom_usd: number;
+    paused_until: string | null;
+    realized_pnl: number;
+    closed_trades: number;
+  };
+  margin: {
+    usage_pct: number;
+    max_usage_pct: number;
+    headroom_pct: number;
+  };
+  cycle: {
+    interval_secs: number;
+    last_cycle_at: string | null;
+    next_cycle_at: string | null;
+    paused_by_schedule: boolean;
+    next_active_at: string | null;
+  };
+  circuit_breaker: {
+    tripped: boolean;
+    reason?: 'total_drawdown' | 'consecutive_losses';
+    message?: string;
+    tripped_at: string | null;
+    peak_equity: number;
+    drawdown_pct: number;
+    max_total_drawdown_pct: number;
+    losing_streak: number;
+    max_consecutive_losses: number;
+  };
+  last_decision: {
+    id: number;
+    timestamp: string;
+    executed: boolean;
+    actions: { symbol: string; action: string; confidence?: number; error?: string }[];
+  } | null;
+  generated_at: string;
+}
+
+// Body of every error response, under "error"
+export interface ApiError {
+  code: string;
+  message: string;
+  details?: Record<string, unknown>;
+  request_id?: string;
+}
+
+// Runtime summary per trader ID, GET /api/traders/status
+export interface TraderSummary {
+  running: boolean;
+  equity: number;
+  open_positions: number;
+  last_cycle_at: string | null;
+  last_error?: string;
+  last_error_at?: string;
+}
+
+// Result per trader ID, POST /api/traders/bulk
+export interface BulkTraderResult {
+  status: 'started' | 'already_running' | 'stopped' | 'already_stopped' | 'failed';
+  error?: ApiError;
+  shutdown?: ShutdownReport;
+}
+
+// P&L report, GET /api/traders/{id}/report
+export interface ReportTradeOutcome {
+  symbol: string;
+  side: string;
+  realized_pnl: number;
+  close_reason?: string;
+  exit_time: string;
+}
+
+export interface RiskEvent {
+  id: number;
+  trader_id: string;
+  timestamp: string;
+  type: 'daily_loss_pause' | 'emergency_stop' | 'connectivity_lost' | 'connectivity_restored' | 'circuit_breaker' | 'circuit_breaker_ack' | string;
+  message: string;
+}
+
+export interface TraderReport {
+  trader_id: string;
+  trader_name: string;
+  period: 'daily' | 'weekly';
+  start: string;
+  end: string;
+  trades: {
+    closed: number;
+    wins: number;
+    losses: number;
+    win_rate: number;
+    realized_pnl: number;
+    fees: number;
+    best: ReportTradeOutcome | null;
+    worst: ReportTradeOutcome | null;
+  };
+  equity: {
+    open: number;
+    close: number;
+    change: number;
+    change_pct: number;
+    max_intraday_drawdown_pct: number;
+    resolution: string;
+  } | null;
+  ai: {
+    calls: number;
+    prompt_tokens: number;
+    completion_tokens: number;
+    cost_usd: number;
+    retries: number;
+  };
+  risk_events: RiskEvent[];
+  generated_at: string;
+}
+
+// Live AI calls by model, from /api/ai-calls/models
+export interface AIModelStats {
+  model: string;
+  calls: number;
+  retries: number;
+  parse_failures: number;
+  retry_rate: number;
+}
+
+// An order resting on Binance, from /api/traders/{id}/orders
+export interface OpenOrder {
+  order_id: number; // AlgoID for SL/TP
+  symbol: string;
+  side: string;
+  type: string;
+  price: number; // Trigger price for stops
+  quantity: number; // 0 for stops closing the whole position
+  algo: boolean;
+  role?: 'stop_loss' | 'take_profit';
+  time: number;
+}
+
+// A stop-loss or take-profit move on an open position
+export interface PositionEvent {
+  id: number;
+  trader_id: string;
+  position_id: number; // 0 if the position row wasn't found
+  symbol: string;
+  side: 'long' | 'short';
+  timestamp: string;
+  type:
+    | 'opened'
+    | 'scaled_in'
+    | 'partial_close'
+    | 'closed'
+    | 'stop_loss_moved'
+    | 'take_profit_moved'
+    | 'trailing_stop_activated'
+    | 'drawdown_protection_armed';
+  old_price: number; // SL/TP moves: 0 when there was no order
+  new_price: number; // SL/TP moves: 0 when the order was removed; fills: the fill price; risk rules: the mark
+  quantity?: number;
+  realized_pnl?: number;
+  fee?: number;
+  source: 'ai' | 'api' | 'risk' | 'exchange' | 'system' | 'manual' | 'sync';
+  reason?: string;
+}
+
+// One step of GET /traders/{id}/positions/{position_id}: a decision or a position event
+export interface PositionTimelineEntry {
+  timestamp: string;
+  type: 'decision' | PositionEvent['type'];
+  decision_id?: number;
+  action?: string;
+  confidence?: number;
+  reasoning?: string;
+  event?: PositionEvent;
+}
+
+// An IP blocked from authenticating after failed attempts
+export interface AuthLockout {
+  ip: string;
+  failures: number;
+  last_failure: string;
+  blocked_until: string;
+  locked_out: boolean; // false while only backing off
 }
@@ -10,6 +10,8 @@ import (
 	"net"
 	"net/http"
 	"time"
+
+	"auto-trader-ahh/mcp"
 )
 
 const OpenRouterBaseURL = "https://openrouter.ai/api/v1"
@@ -17,7 +19,13 @@ const OpenRouterBaseURL = "https://openrouter.ai/api/v1"
 type Client struct {
 	apiKey     string
 	model      string
+	params     mcp.GenerationParams
 	httpClient *http.Client
+	backend    mcp.AIClient // When set, requests go here instead of OpenRouter
+	caller     string       // Whose turn requests take in the OpenRouter scheduler
+
+	fallbackModels []string        // Tried in order when the model fails a decision
+	modelStats     *mcp.ModelStats // Decision calls and failures per model
 }
 
 type Message struct {
@@ -26,10 +34,24 @@ type Message struct {
 }
 
 type ChatRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
+	Model       string            `json:"model"`
+	Messages    []Message         `json:"messages"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+	Temperature float64           `json:"temperature"`
+	TopP        float64           `json:"top_p,omitempty"`
+	Reasoning   *ReasoningOptions `json:"reasoning,omitempty"`
+	Usage       *UsageOptions     `json:"usage,omitempty"`
+}
+
+// ReasoningOptions sets how much reasoning models think before answering
+type ReasoningOptions struct {
+	Effort string `json:"effort"` // "low", "medium" or "high"
+}
+
+// UsageOptions turns on OpenRouter usage accounting, which adds the call's
+// cost to the response usage
+type UsageOptions struct {
+	Include bool `json:"include"`
 }
 
 type ChatResponse struct {
@@ -43,9 +65,10 @@ type ChatResponse struct {
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"` // USD, when usage accounting was requested
 	} `json:"usage"`
 	Error *struct {
 		Message string      `json:"message"`
@@ -56,20 +79,62 @@ type ChatResponse struct {
 
 // ChatResult holds the response content and optional reasoning
 type ChatResult struct {
-	Content   string
-	Reasoning string
+	Content          string
+	Reasoning        string
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// DecisionCall is everything sent and received for one trading decision,
+// kept so a live decision can be audited later
+type DecisionCall struct {
+	Model        string
+	Params       mcp.GenerationParams // Sampling settings the call was made with
+	SystemPrompt string
+	UserPrompt   string
+	Response     string
+	Reasoning    string
+	Latency      time.Duration // Includes retries
+	ParseError   string        // Empty when the response parsed into a decision
+
+	// A response that couldn't be used gets one follow-up asking the model to
+	// correct it. Response is then the corrected response.
+	Retries       int
+	RetryReason   string // Why the first response couldn't be used
+	FirstResponse string
+
+	// Set when the primary model failed and a fallback model made the call
+	FallbackFrom   string // The primary model
+	FallbackReason string // How it failed
+
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64 // As reported by OpenRouter, summed over both responses when retried and over failed models
 }
 
 type TradingDecision struct {
-	Action        string  `json:"action"`          // BUY, SELL, HOLD, CLOSE
+	Action        string  `json:"action"`          // BUY, SELL, HOLD, CLOSE, MOVE_STOP, MOVE_TP
 	Symbol        string  `json:"symbol"`          // Trading pair
 	Confidence    float64 `json:"confidence"`      // 0-100
 	Reasoning     string  `json:"reasoning"`       // AI's reasoning
 	StopLossPct   float64 `json:"stop_loss_pct"`   // Stop loss as percentage (e.g., 2.0 = 2%)
 	TakeProfitPct float64 `json:"take_profit_pct"` // Take profit as percentage (e.g., 6.0 = 6%)
-	// Legacy fields for backward compatibility
-	StopLoss   float64 `json:"stop_loss,omitempty"`   // Deprecated: use StopLossPct
-	TakeProfit float64 `json:"take_profit,omitempty"` // Deprecated: use TakeProfitPct
+	ClosePercent  float64 `json:"close_percent"`   // Share of the position to CLOSE, 0 or 100 closes all
+	Leverage      int     `json:"leverage"`        // Requested leverage for BUY/SELL, capped at the symbol max; 0 uses the max
+	// Absolute prices: the new level for MOVE_STOP/MOVE_TP, deprecated for entries
+	StopLoss   float64 `json:"stop_loss,omitempty"`   // Entries: use StopLossPct
+	TakeProfit float64 `json:"take_profit,omitempty"` // Entries: use TakeProfitPct
+	// Execution fields, never read from the AI response
+	PositionSizeUSD float64       `json:"-"` // Margin override for externally sized decisions; 0 uses the strategy position %
+	OrderID         int64         `json:"-"` // Exchange order ID, set by the trader once executed
+	ATR             float64       `json:"-"` // ATR of the analyzed timeframe, for ATR risk sizing
+	RiskUSD         float64       `json:"-"` // Loss at the ATR stop the position was sized for, set by the trader
+	PositionID      int64         `json:"-"` // PositionStore row the decision opened or acted on, set by the trader
+	ReferencePrice  float64       `json:"-"` // Price in the market data the decision was made on, set by the trader
+	ReferenceTime   time.Time     `json:"-"` // When that market data was fetched, set by the trader
+	ExecutionDelay  time.Duration `json:"-"` // From ReferenceTime to the price check before the order, set by the trader
+	ExecutionPrice  float64       `json:"-"` // Price at that check, set by the trader
 }
 
 func NewClient(apiKey, model string) *Client {
@@ -102,10 +167,12 @@ func NewClient(apiKey, model string) *Client {
 	return &Client{
 		apiKey: apiKey,
 		model:  model,
+		params: mcp.DefaultGenerationParams(),
 		httpClient: &http.Client{
 			Timeout:   180 * time.Second, // 3 minutes for slower models
 			Transport: transport,
 		},
+		modelStats: mcp.NewModelStats(),
 	}
 }
 
@@ -114,11 +181,52 @@ func (c *Client) SetModel(model string) {
 	c.model = model
 }
 
+// SetBackend routes requests through another provider's client, e.g. a local
+// Ollama server, instead of OpenRouter
+func (c *Client) SetBackend(backend mcp.AIClient) {
+	c.backend = backend
+}
+
+// SetCaller names the client's requests in the provider scheduler, so callers
+// take turns when calls are limited
+func (c *Client) SetCaller(caller string) {
+	c.caller = caller
+	if setter, ok := c.backend.(mcp.CallerSetter); ok {
+		setter.SetCaller(caller)
+	}
+}
+
+// SetGenerationParams changes the sampling settings used for requests
+func (c *Client) SetGenerationParams(params mcp.GenerationParams) {
+	c.params = params
+}
+
+// GetGenerationParams returns the current sampling settings
+func (c *Client) GetGenerationParams() mcp.GenerationParams {
+	return c.params
+}
+
 // GetModel returns the current model
 func (c *Client) GetModel() string {
 	return c.model
 }
 
+// SetFallbackModels sets the models tried in order when the model fails to
+// produce a decision
+func (c *Client) SetFallbackModels(models []string) {
+	c.fallbackModels = models
+}
+
+// GetFallbackModels returns the current fallback models
+func (c *Client) GetFallbackModels() []string {
+	return c.fallbackModels
+}
+
+// ModelStats returns the decision calls and failures of each model tried
+func (c *Client) ModelStats() map[string]mcp.ModelStat {
+	return c.modelStats.Snapshot()
+}
+
 func (c *Client) Chat(messages []Message) (string, error) {
 	result, err := c.ChatWithReasoning(messages)
 	if err != nil {
@@ -129,11 +237,25 @@ func (c *Client) Chat(messages []Message) (string, error) {
 
 // ChatWithReasoning returns both content and reasoning (for reasoning models)
 func (c *Client) ChatWithReasoning(messages []Message) (*ChatResult, error) {
+	return c.chatWithModel(c.model, messages)
+}
+
+// chatWithModel sends messages to model, retrying transient failures
+func (c *Client) chatWithModel(model string, messages []Message) (*ChatResult, error) {
+	if c.backend != nil {
+		return c.chatBackend(model, messages)
+	}
+
 	const maxRetries = 3
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		result, err := c.doChat(messages, attempt)
+		release, err := mcp.SchedulerFor(mcp.ProviderOpenRouter).Acquire(context.Background(), c.caller)
+		if err != nil {
+			return nil, err
+		}
+		result, err := c.doChat(model, messages, attempt)
+		release()
 		if err == nil {
 			return result, nil
 		}
@@ -156,6 +278,26 @@ func (c *Client) ChatWithReasoning(messages []Message) (*ChatResult, error) {
 	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
+// chatBackend sends the messages through the backend client, which handles
+// its own retries and timeout
+func (c *Client) chatBackend(model string, messages []Message) (*ChatResult, error) {
+	req := &mcp.Request{Model: model, Messages: make([]mcp.Message, len(messages))}
+	for i, m := range messages {
+		req.Messages[i] = mcp.Message{Role: m.Role, Content: m.Content}
+	}
+	c.params.Apply(req)
+
+	resp, err := c.backend.CallWithRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResult{
+		Content:          resp.Content,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
+
 // isRetryableError checks if the error is transient and worth retrying
 func isRetryableError(err error) bool {
 	if err == nil {
@@ -211,14 +353,19 @@ func toLower(s string) string {
 }
 
 // doChat performs a single chat request
-func (c *Client) doChat(messages []Message, attempt int) (*ChatResult, error) {
+func (c *Client) doChat(model string, messages []Message, attempt int) (*ChatResult, error) {
 	start := time.Now()
 
 	req := ChatRequest{
-		Model:       c.model,
+		Model:       model,
 		Messages:    messages,
-		MaxTokens:   4096,
-		Temperature: 0.7,
+		MaxTokens:   c.params.MaxTokens,
+		Temperature: c.params.Temperature,
+		TopP:        c.params.TopP,
+		Usage:       &UsageOptions{Include: true},
+	}
+	if c.params.ReasoningEffort != "" {
+		req.Reasoning = &ReasoningOptions{Effort: c.params.ReasoningEffort}
 	}
 
 	body, err := json.Marshal(req)
@@ -231,7 +378,7 @@ func (c *Client) doChat(messages []Message, attempt int) (*ChatResult, error) {
 	for _, m := range messages {
 		promptSize += len(m.Content)
 	}
-	log.Printf("[OpenRouter] Sending request to %s (prompt size: %d chars, model: %s, attempt: %d)", c.model, promptSize, c.model, attempt)
+	log.Printf("[OpenRouter] Sending request to %s (prompt size: %d chars, model: %s, attempt: %d)", model, promptSize, model, attempt)
 
 	httpReq, err := http.NewRequest("POST", OpenRouterBaseURL+"/chat/completions", bytes.NewBuffer(body))
 	if err != nil {
@@ -278,8 +425,11 @@ func (c *Client) doChat(messages []Message, attempt int) (*ChatResult, error) {
 	}
 
 	result := &ChatResult{
-		Content:   chatResp.Choices[0].Message.Content,
-		Reasoning: chatResp.Choices[0].Message.Reasoning,
+		Content:          chatResp.Choices[0].Message.Content,
+		Reasoning:        chatResp.Choices[0].Message.Reasoning,
+		PromptTokens:     chatResp.Usage.PromptTokens,
+		CompletionTokens: chatResp.Usage.CompletionTokens,
+		CostUSD:          chatResp.Usage.Cost,
 	}
 
 	// Log if reasoning was returned
@@ -290,7 +440,7 @@ func (c *Client) doChat(messages []Message, attempt int) (*ChatResult, error) {
 	return result, nil
 }
 
-func (c *Client) GetTradingDecision(marketData string) (*TradingDecision, string, error) {
+func (c *Client) GetTradingDecision(marketData string) (*TradingDecision, *DecisionCall, error) {
 	systemPrompt := `You are a cryptocurrency futures trader AI. Balance profitability with risk management.
 
 ## TRADING PHILOSOPHY: BALANCED MODE
@@ -302,12 +452,14 @@ Look for QUALITY setups but also trade on MODERATE opportunities when available.
 You MUST respond with ONLY a valid JSON object:
 
 {
-  "action": "BUY" | "SELL" | "HOLD" | "CLOSE",
+  "action": "BUY" | "SELL" | "HOLD" | "CLOSE" | "MOVE_STOP" | "MOVE_TP",
   "symbol": "<EXACT_SYMBOL_FROM_DATA>",
   "confidence": 0-100,
   "reasoning": "Brief explanation",
   "stop_loss_pct": 2.0,
-  "take_profit_pct": 6.0
+  "take_profit_pct": 6.0,
+  "close_percent": 100,
+  "leverage": 10
 }
 
 ## CONFIDENCE DEFINITION (IMPORTANT!)
@@ -334,7 +486,10 @@ Confidence = "How sure are you about YOUR recommended action?"
 - **BUY** = Open a LONG position (confident price will go UP)
 - **SELL** = Open a SHORT position (confident price will go DOWN)  
 - **HOLD** = No action. Waiting is the best choice right now.
-- **CLOSE** = Close the current position (RARELY USED)
+- **CLOSE** = Close the current position (RARELY USED). Set close_percent below 100 to scale out part of it (e.g. 33 to lock in a third at +3%)
+- **MOVE_STOP** = Move the stop loss of the current position to the price in "stop_loss" (e.g. to breakeven once in profit). Below the mark price for a LONG, above it for a SHORT
+- **MOVE_TP** = Move the take profit of the current position to the price in "take_profit". Above the mark price for a LONG, below it for a SHORT
+- BUY/SELL in the direction of an existing position ADDS to it (scale-in), capped by the remaining position limit
 
 ## WHEN TO TRADE (BUY/SELL)
 
@@ -356,6 +511,11 @@ Confidence = "How sure are you about YOUR recommended action?"
 - For MODERATE setups: stop_loss_pct: 1-2%, take_profit_pct: 3-6%
 - Always maintain 3:1 reward-to-risk ratio
 
+## LEVERAGE
+
+- leverage is optional for BUY/SELL; it is capped at the Max Leverage shown in the data
+- Use less than the max for MODERATE setups or volatile coins, omit it to use the max
+
 ## CRITICAL: POSITION MANAGEMENT RULES
 
 If you have an existing position:
@@ -372,48 +532,19 @@ When considering CLOSE, always explain:
 - Momentum has completely reversed against position
 - Significant profits at risk of reversal
 
+✅ MOVE_STOP is appropriate when:
+- The position is +5% or more: move the stop to the entry price (breakeven)
+- Price has moved well in your favor: trail the stop behind the latest swing
+
 For close decisions: Provide clear reasoning about why the position should be closed.
 The system will evaluate your insight and confidence level.`
 
-	messages := []Message{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: "Analyze this market data and provide your trading decision:\n\n" + marketData},
-	}
-
-	result, err := c.ChatWithReasoning(messages)
-	if err != nil {
-		return nil, "", fmt.Errorf("AI chat failed: %w", err)
-	}
-
-	// Log reasoning if present (from reasoning models like deepseek-r1)
-	if result.Reasoning != "" {
-		log.Printf("[OpenRouter] AI Reasoning:\n%s", result.Reasoning)
-	}
-
-	response := result.Content
-
-	// Parse JSON from response
-	var decision TradingDecision
-	if err := json.Unmarshal([]byte(response), &decision); err != nil {
-		// Try to extract JSON from response if wrapped in markdown
-		start := bytes.Index([]byte(response), []byte("{"))
-		end := bytes.LastIndex([]byte(response), []byte("}"))
-		if start >= 0 && end > start {
-			jsonStr := response[start : end+1]
-			if err := json.Unmarshal([]byte(jsonStr), &decision); err != nil {
-				return nil, response, fmt.Errorf("failed to parse AI decision: %w", err)
-			}
-		} else {
-			return nil, response, fmt.Errorf("no JSON found in response")
-		}
-	}
-
-	return &decision, response, nil
+	return c.requestDecision(systemPrompt, "Analyze this market data and provide your trading decision:\n\n"+marketData)
 }
 
 // GetTradingDecisionSimple uses a minimal prompt like v1.4.7
 // This is used when Simple Mode is enabled - less overthinking
-func (c *Client) GetTradingDecisionSimple(marketData string) (*TradingDecision, string, error) {
+func (c *Client) GetTradingDecisionSimple(marketData string) (*TradingDecision, *DecisionCall, error) {
 	systemPrompt := `You are a cryptocurrency futures trader. Make clear BUY, SELL, or HOLD decisions.
 
 RESPONSE FORMAT:
@@ -438,35 +569,143 @@ SIMPLE RULES:
 4. If unsure, HOLD
 5. For existing positions: provide your analysis first if you think action is needed`
 
+	return c.requestDecision(systemPrompt, "Analyze and decide:\n\n"+marketData)
+}
+
+// requestDecision asks the model for one decision, moving down the fallback
+// models while it fails. The returned call record is the last model's, with
+// the usage of the failed ones added.
+func (c *Client) requestDecision(systemPrompt, userPrompt string) (*TradingDecision, *DecisionCall, error) {
+	start := time.Now()
+	models := mcp.FallbackChain(c.model, c.fallbackModels)
+	if len(models) == 0 {
+		models = []string{c.model}
+	}
+
+	var failed DecisionCall // Usage of the models that failed
+	var firstErr error
+	for i := 0; ; i++ {
+		model := models[i]
+		decision, call, err := c.requestDecisionFrom(model, systemPrompt, userPrompt)
+		c.modelStats.Record(model, err != nil)
+		if err != nil && i < len(models)-1 {
+			log.Printf("[OpenRouter] %s failed to decide (%v), falling back to %s", model, err, models[i+1])
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed.PromptTokens += call.PromptTokens
+			failed.CompletionTokens += call.CompletionTokens
+			failed.CostUSD += call.CostUSD
+			continue
+		}
+
+		if err == nil && i > 0 {
+			call.FallbackFrom, call.FallbackReason = models[0], firstErr.Error()
+		}
+		call.PromptTokens += failed.PromptTokens
+		call.CompletionTokens += failed.CompletionTokens
+		call.CostUSD += failed.CostUSD
+		call.Latency = time.Since(start)
+		return decision, call, err
+	}
+}
+
+// requestDecisionFrom asks model for one decision and parses it. A response
+// that doesn't parse into a valid decision gets one follow-up quoting the
+// error. The returned call record is filled in as far as the request got,
+// including on error.
+func (c *Client) requestDecisionFrom(model, systemPrompt, userPrompt string) (*TradingDecision, *DecisionCall, error) {
+	call := &DecisionCall{
+		Model:        model,
+		Params:       c.params,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+	}
+
 	messages := []Message{
 		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: "Analyze and decide:\n\n" + marketData},
+		{Role: "user", Content: userPrompt},
 	}
 
-	result, err := c.ChatWithReasoning(messages)
+	start := time.Now()
+	defer func() { call.Latency = time.Since(start) }()
+
+	result, err := c.chatWithModel(model, messages)
+	if err != nil {
+		call.ParseError = fmt.Sprintf("AI chat failed: %v", err)
+		return nil, call, fmt.Errorf("AI chat failed: %w", err)
+	}
+	call.addResult(result)
+
+	decision, err := parseTradingDecision(result.Content)
+	if err == nil {
+		return decision, call, nil
+	}
+
+	call.Retries, call.RetryReason, call.FirstResponse = 1, err.Error(), result.Content
+	log.Printf("[OpenRouter] Response unusable (%v), asking the model to correct it", err)
+	messages = append(messages,
+		Message{Role: "assistant", Content: result.Content},
+		Message{Role: "user", Content: fmt.Sprintf("Your previous response could not be used: %v\n\nRe-emit only the corrected JSON decision object, with no other text.", err)},
+	)
+	result, retryErr := c.chatWithModel(model, messages)
+	if retryErr != nil {
+		call.ParseError = err.Error()
+		return nil, call, fmt.Errorf("%w (correction request failed: %v)", err, retryErr)
+	}
+	call.addResult(result)
+
+	decision, err = parseTradingDecision(result.Content)
 	if err != nil {
-		return nil, "", fmt.Errorf("AI chat failed: %w", err)
+		call.ParseError = err.Error()
+		return nil, call, err
 	}
+	return decision, call, nil
+}
 
+// addResult records a response, adding its usage to the call's
+func (call *DecisionCall) addResult(result *ChatResult) {
+	// Log reasoning if present (from reasoning models like deepseek-r1)
 	if result.Reasoning != "" {
 		log.Printf("[OpenRouter] AI Reasoning:\n%s", result.Reasoning)
 	}
 
-	response := result.Content
+	call.Response = result.Content
+	call.Reasoning = result.Reasoning
+	call.PromptTokens += result.PromptTokens
+	call.CompletionTokens += result.CompletionTokens
+	call.CostUSD += result.CostUSD
+}
 
+// parseTradingDecision parses a decision object, which may be wrapped in
+// markdown, and checks its action
+func parseTradingDecision(response string) (*TradingDecision, error) {
 	var decision TradingDecision
 	if err := json.Unmarshal([]byte(response), &decision); err != nil {
+		// Try to extract JSON from response if wrapped in markdown
 		start := bytes.Index([]byte(response), []byte("{"))
 		end := bytes.LastIndex([]byte(response), []byte("}"))
-		if start >= 0 && end > start {
-			jsonStr := response[start : end+1]
-			if err := json.Unmarshal([]byte(jsonStr), &decision); err != nil {
-				return nil, response, fmt.Errorf("failed to parse AI decision: %w", err)
-			}
-		} else {
-			return nil, response, fmt.Errorf("no JSON found in response")
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("no JSON found in response")
+		}
+		if err := json.Unmarshal([]byte(response[start:end+1]), &decision); err != nil {
+			return nil, fmt.Errorf("failed to parse AI decision: %w", err)
 		}
 	}
 
-	return &decision, response, nil
+	switch decision.Action {
+	case "BUY", "SELL", "HOLD", "CLOSE":
+		return &decision, nil
+	case "MOVE_STOP":
+		if decision.StopLoss <= 0 {
+			return nil, fmt.Errorf("MOVE_STOP needs the new stop price in stop_loss")
+		}
+		return &decision, nil
+	case "MOVE_TP":
+		if decision.TakeProfit <= 0 {
+			return nil, fmt.Errorf("MOVE_TP needs the new take profit price in take_profit")
+		}
+		return &decision, nil
+	}
+	return nil, fmt.Errorf("invalid action %q, expected BUY, SELL, HOLD, CLOSE, MOVE_STOP or MOVE_TP", decision.Action)
 }
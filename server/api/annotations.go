@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ ANNOTATION ENDPOINTS ============
+
+// annotationRequest is a manual note on a trader's equity curve
+type annotationRequest struct {
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp,omitempty"` // Unix ms, now by default
+}
+
+// handleCreateAnnotation adds a manual annotation, such as a model switch, to
+// a trader's equity curve
+func (s *Server) handleCreateAnnotation(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	var req annotationRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "text required")
+		return
+	}
+	if utf8.RuneCountInString(text) > store.MaxAnnotationText {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("text is longer than %d characters", store.MaxAnnotationText))
+		return
+	}
+
+	annotation := &store.Annotation{
+		TraderID:  t.ID,
+		Type:      store.AnnotationManual,
+		Text:      text,
+		CreatedBy: currentUser(r).ID,
+	}
+	if req.Timestamp != 0 {
+		annotation.Timestamp = time.UnixMilli(req.Timestamp)
+		if annotation.Timestamp.After(time.Now()) {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "timestamp is in the future")
+			return
+		}
+	}
+	if err := s.annotationStore.Create(annotation); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"annotation": annotation, "audit_id": s.recordAudit(r)})
+}
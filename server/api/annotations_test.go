@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/config"
+)
+
+func TestTraderAnnotations(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, created := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	id := created["id"].(string)
+
+	hourAgo := time.Now().Add(-time.Hour).UnixMilli()
+	w, resp := serve(t, mux, "POST", "/api/traders/"+id+"/annotations", fmt.Sprintf(`{"text":" switched model to gpt-4o ","timestamp":%d}`, hourAgo))
+	if w.Code != 200 {
+		t.Fatalf("create = %d %v", w.Code, resp)
+	}
+	if a := resp["annotation"].(map[string]interface{}); a["type"] != "manual" || a["text"] != "switched model to gpt-4o" {
+		t.Errorf("annotation = %v", a)
+	}
+
+	for _, body := range []string{
+		`{"text":"  "}`,
+		`{"text":"` + strings.Repeat("x", 281) + `"}`,
+		fmt.Sprintf(`{"text":"later","timestamp":%d}`, time.Now().Add(time.Hour).UnixMilli()),
+	} {
+		if w, resp := serve(t, mux, "POST", "/api/traders/"+id+"/annotations", body); w.Code != 400 {
+			t.Errorf("create %s = %d %v, want 400", body, w.Code, resp)
+		}
+	}
+
+	_, resp = serve(t, mux, "GET", "/api/equity-history?trader_id="+id, "")
+	if annotations := resp["annotations"].([]interface{}); len(annotations) != 1 {
+		t.Errorf("equity history annotations = %v", resp["annotations"])
+	}
+	// Only the ones in range
+	_, resp = serve(t, mux, "GET", fmt.Sprintf("/api/equity-history?trader_id=%s&start=%d", id, hourAgo+1), "")
+	if annotations := resp["annotations"].([]interface{}); len(annotations) != 0 {
+		t.Errorf("annotations after the range start = %v", annotations)
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+type requestInfoKey struct{}
+
+// requestInfo is shared between requestLogMiddleware and the handlers it wraps,
+// so the outer middleware can see who authenticated and which audit row was used
+type requestInfo struct {
+	requestID      string
+	user           *store.User
+	keyFingerprint string
+	auditID        int64
+	started        time.Time
+}
+
+func requestInfoFrom(r *http.Request) *requestInfo {
+	info, _ := r.Context().Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// statusRecorder captures the response status while keeping SSE flushing and
+// WebSocket upgrades working
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over for WebSocket upgrades
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection can't be hijacked")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// requestLogLine is the structured JSON log record for one request
+type requestLogLine struct {
+	Time           string `json:"time"`
+	RequestID      string `json:"request_id"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	Status         int    `json:"status"`
+	LatencyMs      int64  `json:"latency_ms"`
+	UserID         string `json:"user_id,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	TraderID       string `json:"trader_id,omitempty"`
+	StrategyID     string `json:"strategy_id,omitempty"`
+	AuditID        int64  `json:"audit_id,omitempty"`
+}
+
+// requestLogMiddleware writes a structured log line for every request and an
+// audit row for every mutating request. GETs are sampled to keep polling and
+// SSE reconnects from flooding the log.
+func (s *Server) requestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		info := &requestInfo{requestID: newRequestID(), started: time.Now()}
+		w.Header().Set(requestIDHeader, info.requestID)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		latency := time.Since(info.started).Milliseconds()
+		traderID, strategyID := auditTargets(r)
+
+		if isMutating(r.Method) {
+			if info.auditID != 0 {
+				if err := s.auditStore.Complete(info.auditID, rec.status, latency); err != nil {
+					log.Printf("[Audit] Failed to complete entry %d: %v", info.auditID, err)
+				}
+			} else {
+				entry := s.newAuditEntry(r, info)
+				entry.Status = rec.status
+				entry.LatencyMs = latency
+				if err := s.auditStore.Create(entry); err != nil {
+					log.Printf("[Audit] Failed to record %s %s: %v", r.Method, r.URL.Path, err)
+				}
+				info.auditID = entry.ID
+			}
+		} else if s.cfg.RequestLogGetSampleRate <= 0 || rand.Float64() >= s.cfg.RequestLogGetSampleRate {
+			return
+		}
+
+		line := requestLogLine{
+			Time:           info.started.UTC().Format(time.RFC3339Nano),
+			RequestID:      info.requestID,
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			Status:         rec.status,
+			LatencyMs:      latency,
+			KeyFingerprint: info.keyFingerprint,
+			TraderID:       traderID,
+			StrategyID:     strategyID,
+			AuditID:        info.auditID,
+		}
+		if info.user != nil {
+			line.UserID = info.user.ID
+		}
+		if data, err := json.Marshal(line); err == nil {
+			log.Printf("[HTTP] %s", data)
+		}
+	})
+}
+
+// recordAudit writes the audit row for the current request before the handler
+// responds, so the response can carry the entry ID. The middleware fills in the
+// status and latency once the handler returns.
+func (s *Server) recordAudit(r *http.Request) int64 {
+	info := requestInfoFrom(r)
+	if info == nil {
+		return 0
+	}
+	if info.auditID != 0 {
+		return info.auditID
+	}
+
+	entry := s.newAuditEntry(r, info)
+	if err := s.auditStore.Create(entry); err != nil {
+		log.Printf("[Audit] Failed to record %s %s: %v", r.Method, r.URL.Path, err)
+		return 0
+	}
+	info.auditID = entry.ID
+	return entry.ID
+}
+
+func (s *Server) newAuditEntry(r *http.Request, info *requestInfo) *store.AuditEntry {
+	traderID, strategyID := auditTargets(r)
+	entry := &store.AuditEntry{
+		Timestamp:      info.started,
+		KeyFingerprint: info.keyFingerprint,
+		Method:         r.Method,
+		Path:           r.URL.Path,
+		TraderID:       traderID,
+		StrategyID:     strategyID,
+		RemoteAddr:     r.RemoteAddr,
+	}
+	if info.user != nil {
+		entry.UserID = info.user.ID
+	}
+	return entry
+}
+
+// keyFingerprint identifies an access key in logs without revealing it
+func keyFingerprint(accessKey string) string {
+	if accessKey == "" {
+		return ""
+	}
+	return store.HashAPIKey(accessKey)[:12]
+}
+
+func isMutating(method string) bool {
+	return method == "POST" || method == "PUT" || method == "DELETE" || method == "PATCH"
+}
+
+// auditTargets extracts the trader and strategy IDs a request refers to
+func auditTargets(r *http.Request) (traderID, strategyID string) {
+	traderID = r.URL.Query().Get("trader_id")
+	path := r.URL.Path
+
+	if strings.HasPrefix(path, "/api/traders/") {
+		if parts := splitPath(path[len("/api/traders/"):]); len(parts) > 0 &&
+			parts[0] != "status" && parts[0] != "bulk" {
+			traderID = parts[0]
+		}
+	}
+	if strings.HasPrefix(path, "/api/strategies/") {
+		if parts := splitPath(path[len("/api/strategies/"):]); len(parts) > 0 &&
+			parts[0] != "active" && parts[0] != "default-config" && parts[0] != "recommend-pairs" {
+			strategyID = parts[0]
+		}
+	}
+	return traderID, strategyID
+}
+
+// ============ AUDIT ENDPOINTS ============
+
+// handleAudit returns audit entries. Admins see everyone's, users only their own.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid limit")
+			return
+		}
+		if n > 1000 {
+			n = 1000
+		}
+		limit = n
+	}
+
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		if ts, err := time.Parse(time.RFC3339, v); err == nil {
+			since = ts
+		} else if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			since = time.Unix(secs, 0)
+		} else {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid since (use RFC3339 or unix seconds)")
+			return
+		}
+	}
+
+	userID := ""
+	if user := currentUser(r); !user.IsAdmin() {
+		userID = user.ID
+	}
+
+	entries, err := s.auditStore.List(userID, since, limit)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"entries": entries})
+}
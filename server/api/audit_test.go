@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+func TestAuditTrail(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	// Every GET is logged, so none is left out of the audit table by sampling
+	cfg := &config.Config{AccessPasskey: "correct-horse", RequestLogGetSampleRate: 1}
+	srv := NewServer("0", trader.NewEngineManager(cfg, events.NewHub()), cfg)
+	handler := srv.requestLogMiddleware(srv.routes())
+	alice := &store.User{Name: "alice"}
+	aliceKey, err := srv.userStore.Create(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := &store.User{Name: "bob"}
+	bobKey, err := srv.userStore.Create(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := func(accessKey, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-Access-Key", accessKey)
+		handler.ServeHTTP(w, r)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+	entries := func() []*store.AuditEntry {
+		t.Helper()
+		list, err := srv.auditStore.List("", time.Time{}, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	w, created := as(aliceKey, "POST", "/api/traders", `{"name":"t1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create = %d %v", w.Code, created)
+	}
+	path := "/api/traders/" + created["id"].(string)
+
+	// The middleware records a mutating request the handler didn't
+	list := entries()
+	if len(list) != 1 {
+		t.Fatalf("entries after create = %d, want 1", len(list))
+	}
+	if e := list[0]; e.UserID != alice.ID || e.KeyFingerprint != keyFingerprint(aliceKey) ||
+		e.Method != "POST" || e.Path != "/api/traders" || e.Status != http.StatusOK {
+		t.Errorf("create entry = %+v", e)
+	}
+
+	// GETs are logged but never audited
+	as(aliceKey, "GET", path, "")
+	as(aliceKey, "GET", "/api/traders", "")
+	if n := len(entries()); n != 1 {
+		t.Errorf("entries after GETs = %d, want 1", n)
+	}
+
+	// Handlers that record the entry themselves return its ID, and the
+	// middleware completes that entry instead of adding another
+	for _, step := range []struct{ method, path, status string }{
+		{"POST", path + "/stop", "already_stopped"},
+		{"DELETE", path, "archived"},
+		{"POST", path + "/unarchive", "unarchived"},
+	} {
+		before := len(entries())
+		w, resp := as(aliceKey, step.method, step.path, "")
+		if w.Code != http.StatusOK || resp["status"] != step.status {
+			t.Errorf("%s %s = %d %v", step.method, step.path, w.Code, resp)
+			continue
+		}
+		list := entries()
+		if len(list) != before+1 {
+			t.Errorf("%s %s added %d entries, want 1", step.method, step.path, len(list)-before)
+			continue
+		}
+		if id, _ := resp["audit_id"].(float64); id == 0 || int64(id) != list[0].ID {
+			t.Errorf("%s %s audit_id = %v, want %d", step.method, step.path, resp["audit_id"], list[0].ID)
+		}
+		if e := list[0]; e.UserID != alice.ID || e.KeyFingerprint != keyFingerprint(aliceKey) ||
+			e.TraderID != created["id"] || e.Status != http.StatusOK {
+			t.Errorf("%s %s entry = %+v", step.method, step.path, e)
+		}
+	}
+
+	// A start that fails is audited with its status. A successful one can't
+	// be tried here, as starting connects to the exchange.
+	as(aliceKey, "DELETE", path, "")
+	if w, resp := as(aliceKey, "POST", path+"/start", ""); w.Code != http.StatusConflict {
+		t.Fatalf("start archived = %d %v", w.Code, resp)
+	}
+	if e := entries()[0]; e.Path != path+"/start" || e.UserID != alice.ID || e.Status != http.StatusConflict {
+		t.Errorf("start entry = %+v", e)
+	}
+
+	// Users only see their own entries, admins everyone's
+	if w, resp := as(bobKey, "POST", "/api/traders", `{"name":"t2"}`); w.Code != http.StatusOK {
+		t.Fatalf("bob's create = %d %v", w.Code, resp)
+	}
+	total := len(entries())
+	audited := func(accessKey string) []interface{} {
+		t.Helper()
+		w, resp := as(accessKey, "GET", "/api/audit", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("audit = %d %v", w.Code, resp)
+		}
+		list, _ := resp["entries"].([]interface{})
+		return list
+	}
+	got := audited(aliceKey)
+	if len(got) != total-1 {
+		t.Errorf("alice sees %d entries, want %d", len(got), total-1)
+	}
+	for _, e := range got {
+		if e.(map[string]interface{})["user_id"] != alice.ID {
+			t.Errorf("alice sees %v", e)
+		}
+	}
+	if got := audited(bobKey); len(got) != 1 || got[0].(map[string]interface{})["user_id"] != bob.ID {
+		t.Errorf("bob sees %v", got)
+	}
+	if got := audited(cfg.AccessPasskey); len(got) != total {
+		t.Errorf("admin sees %d entries, want %d", len(got), total)
+	}
+
+	if w, _ := as(aliceKey, "GET", "/api/audit?limit=x", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad limit = %d", w.Code)
+	}
+	if w, _ := as(aliceKey, "GET", "/api/audit?since=yesterday", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad since = %d", w.Code)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build autocert
+
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const autocertAvailable = true
+
+// newAutocert returns the TLS config that obtains and renews certificates for
+// the domains, and the :80 handler that answers HTTP-01 challenges and
+// redirects other requests to HTTPS
+func newAutocert(domains []string, cacheDir, email string) (*tls.Config, http.Handler, error) {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+	}
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig, m.HTTPHandler(nil), nil
+}
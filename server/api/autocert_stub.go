@@ -0,0 +1,16 @@
+//go:build !autocert
+
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// autocertAvailable reports whether Let's Encrypt support is compiled in
+const autocertAvailable = false
+
+func newAutocert(domains []string, cacheDir, email string) (*tls.Config, http.Handler, error) {
+	return nil, nil, fmt.Errorf("this binary was built without autocert support (build with -tags autocert)")
+}
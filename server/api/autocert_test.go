@@ -0,0 +1,52 @@
+//go:build autocert
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutocertChallengeHandler(t *testing.T) {
+	cacheDir := t.TempDir()
+	// A pending HTTP-01 token, as the manager caches it while an order is open
+	if err := os.WriteFile(filepath.Join(cacheDir, "tok123+http-01"), []byte("tok123.thumbprint"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, handler, err := newAutocert([]string{"trader.example.com"}, cacheDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.GetCertificate == nil {
+		t.Error("TLS config doesn't get certificates from the manager")
+	}
+
+	get := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	// Challenges are answered over plain HTTP for the configured domains only
+	if w := get(http.MethodGet, "http://trader.example.com/.well-known/acme-challenge/tok123"); w.Code != http.StatusOK || w.Body.String() != "tok123.thumbprint" {
+		t.Errorf("challenge = %d %q", w.Code, w.Body.String())
+	}
+	if w := get(http.MethodGet, "http://trader.example.com/.well-known/acme-challenge/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown token = %d, want 404", w.Code)
+	}
+	if w := get(http.MethodGet, "http://other.example.com/.well-known/acme-challenge/tok123"); w.Code != http.StatusForbidden {
+		t.Errorf("other host = %d, want 403", w.Code)
+	}
+
+	// Everything else goes to HTTPS, keeping the path and query
+	w := get(http.MethodGet, "http://trader.example.com/api/traders?limit=5")
+	if loc := w.Header().Get("Location"); w.Code != http.StatusFound || loc != "https://trader.example.com/api/traders?limit=5" {
+		t.Errorf("redirect = %d %q", w.Code, loc)
+	}
+	if w := get(http.MethodPost, "http://trader.example.com/api/traders"); w.Code != http.StatusBadRequest {
+		t.Errorf("POST over HTTP = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ COIN UNIVERSE ENDPOINTS ============
+
+var coinSymbolPattern = regexp.MustCompile(`^[A-Z0-9]{2,30}$`)
+
+// handleTraderCoins returns a trader's pins and bans and, while it runs, the
+// effective coin universe with the origin of each symbol
+func (s *Server) handleTraderCoins(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	overrides, err := s.coinStore.List(t.ID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	pinned, banned := []string{}, []string{}
+	for _, o := range overrides {
+		if o.Kind == store.CoinOverridePin {
+			pinned = append(pinned, o.Symbol)
+		} else {
+			banned = append(banned, o.Symbol)
+		}
+	}
+
+	// The universe depends on the source's live state, only a running trader has one
+	var coins []trader.CoinCandidate
+	running := s.engineManager.IsRunning(t.ID)
+	if running {
+		if coins, err = s.engineManager.CoinUniverse(t.ID); err != nil {
+			s.internalError(w, r, err)
+			return
+		}
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"running": running,
+		"coins":   coins,
+		"pinned":  pinned,
+		"banned":  banned,
+	})
+}
+
+// handlePinCoin makes a trader always analyze a symbol, whatever its coin source
+func (s *Server) handlePinCoin(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	s.setCoinOverride(w, r, t, store.CoinOverridePin)
+}
+
+// handleBanCoin makes a trader never analyze a symbol, even when its coin source lists it
+func (s *Server) handleBanCoin(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	s.setCoinOverride(w, r, t, store.CoinOverrideBan)
+}
+
+func (s *Server) setCoinOverride(w http.ResponseWriter, r *http.Request, t *store.Trader, kind string) {
+	var req struct {
+		Symbol string `json:"symbol"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if !coinSymbolPattern.MatchString(symbol) {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid symbol")
+		return
+	}
+	// A banned symbol needn't exist, a pinned one must be tradable
+	if kind == store.CoinOverridePin {
+		if err := s.binanceClient.CheckTradable(symbol); err != nil {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	override, err := s.coinStore.Set(t.ID, symbol, kind)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.engineManager.ReloadCoinOverrides(t.ID)
+	s.jsonResponse(w, map[string]interface{}{"override": override, "audit_id": s.recordAudit(r)})
+}
+
+// handleClearCoinOverride removes a symbol's pin or ban, leaving it to the coin source
+func (s *Server) handleClearCoinOverride(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	deleted, err := s.coinStore.Delete(t.ID, symbol)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if !deleted {
+		s.errorResponse(w, r, http.StatusNotFound, codeNotFound, symbol+" is neither pinned nor banned")
+		return
+	}
+	s.engineManager.ReloadCoinOverrides(t.ID)
+	s.jsonResponse(w, map[string]interface{}{"symbol": symbol, "audit_id": s.recordAudit(r)})
+}
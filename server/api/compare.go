@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"auto-trader-ahh/backtest"
+	"auto-trader-ahh/store"
+)
+
+// ============ BACKTEST VS LIVE ENDPOINTS ============
+
+// handleTraderPositions lists a trader's closed positions, newest first, or
+// its open ones with status=open
+func (s *Server) handleTraderPositions(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	query := r.URL.Query()
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	var positions []store.TraderPosition
+	var err error
+	switch query.Get("status") {
+	case "", "closed":
+		positions, err = s.positionStore.GetClosedPositions(t.ID, limit)
+	case "open":
+		positions, err = s.positionStore.GetOpenPositions(t.ID)
+	default:
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "status must be open or closed")
+		return
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if positions == nil {
+		positions = []store.TraderPosition{}
+	}
+	s.jsonResponse(w, map[string]interface{}{"positions": positions})
+}
+
+// handleBacktestPositions pairs a run's trades into closed positions shaped
+// like a trader's
+func (s *Server) handleBacktestPositions(w http.ResponseWriter, r *http.Request, runID string) {
+	trades, err := s.backtestManager.GetTrades(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"positions": backtest.Positions(trades)})
+}
+
+// compareDiff is the backtest's stats minus the live trader's
+type compareDiff struct {
+	WinRate     float64 `json:"win_rate"`
+	AvgHoldMins float64 `json:"avg_hold_mins"`
+	AvgPnL      float64 `json:"avg_pnl"`
+}
+
+// handleCompare puts a backtest's positions next to a live trader's closed
+// over the same range: the run's, narrowed by from and to
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	runID, traderID := query.Get("backtest_id"), query.Get("trader_id")
+	if runID == "" || traderID == "" {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "backtest_id and trader_id required")
+		return
+	}
+	if !s.authorizeBacktest(w, r, runID) || !s.authorizeTraderID(w, r, traderID) {
+		return
+	}
+
+	meta, err := s.backtestManager.GetStatus(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	from, to := time.UnixMilli(meta.Config.StartTS), time.UnixMilli(meta.Config.EndTS)
+
+	// Optional range in Unix ms
+	for _, p := range []struct {
+		name  string
+		dest  *time.Time
+		later bool
+	}{{"from", &from, true}, {"to", &to, false}} {
+		if v := query.Get(p.name); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid "+p.name)
+				return
+			}
+			if t := time.UnixMilli(ms); t.After(*p.dest) == p.later {
+				*p.dest = t
+			}
+		}
+	}
+	if !from.Before(to) {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "range does not overlap the backtest")
+		return
+	}
+
+	trades, err := s.backtestManager.GetTrades(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	var simulated []store.TraderPosition
+	for _, pos := range backtest.Positions(trades) {
+		if !pos.ExitTime.Before(from) && pos.ExitTime.Before(to) {
+			simulated = append(simulated, pos)
+		}
+	}
+	live, err := s.positionStore.GetClosedBetween(traderID, from, to)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	bt, lv := backtest.SummarizePositions(simulated), backtest.SummarizePositions(live)
+	s.jsonResponse(w, map[string]interface{}{
+		"from":     from.UnixMilli(),
+		"to":       to.UnixMilli(),
+		"backtest": bt,
+		"live":     lv,
+		"diff": compareDiff{
+			WinRate:     bt.WinRate - lv.WinRate,
+			AvgHoldMins: bt.AvgHoldMins - lv.AvgHoldMins,
+			AvgPnL:      bt.PnLDistribution.Mean - lv.PnLDistribution.Mean,
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ============ CORS AND SECURITY HEADERS ============
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds
+const corsMaxAge = 600
+
+// originPattern is one ALLOWED_ORIGINS entry. A host starting with "*."
+// matches any subdomain of the rest, but not the bare domain.
+type originPattern struct {
+	scheme string // Empty matches any scheme
+	host   string // Lowercase, with the port if one was given
+	suffix string // ".example.com" for a wildcard pattern
+}
+
+// originMatcher decides which browser origins get CORS headers
+type originMatcher struct {
+	any      bool
+	patterns []originPattern
+}
+
+// newOriginMatcher parses origin patterns such as "https://app.example.com",
+// "https://*.example.com" or "*" for any origin. Malformed entries are skipped.
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+		if origin == "*" {
+			m.any = true
+			continue
+		}
+
+		var p originPattern
+		if scheme, host, ok := strings.Cut(origin, "://"); ok {
+			p.scheme, origin = scheme, host
+		}
+		if origin == "" || strings.ContainsAny(origin, "/?#") {
+			continue
+		}
+		if strings.HasPrefix(origin, "*.") {
+			p.suffix = origin[1:]
+		} else {
+			p.host = origin
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m
+}
+
+// allowed reports whether a request's Origin header matches a pattern
+func (m *originMatcher) allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if m.any {
+		return true
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return false
+	}
+	for _, p := range m.patterns {
+		if p.scheme != "" && p.scheme != u.Scheme {
+			continue
+		}
+		if p.host != "" && p.host == u.Host {
+			return true
+		}
+		// The wildcard covers subdomains only, and needs a label before the suffix
+		if p.suffix != "" && strings.HasSuffix(u.Host, p.suffix) && len(u.Host) > len(p.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware reflects allowed origins in the CORS headers. Other origins
+// get no CORS headers, so browsers block them while curl and server-to-server
+// clients, which send no Origin, work as before.
+func corsMiddleware(origins *originMatcher, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		allowed := origins.allowed(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Retry-After, Idempotent-Replayed, X-Request-ID")
+		}
+
+		if r.Method == "OPTIONS" {
+			if allowed && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Access-Key, Idempotency-Key")
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// securityHeadersMiddleware sets the standard hardening headers, and HSTS on
+// requests that came in over TLS
+func securityHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
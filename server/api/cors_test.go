@@ -0,0 +1,115 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := newOriginMatcher([]string{
+		"https://app.example.com",
+		"https://*.trader.io",
+		"http://localhost:5173/",
+		"*.internal.net",
+		"not a url/path",
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false}, // Scheme must match
+		{"https://app.example.com:8443", false},
+		{"https://evil.example.com", false},
+		{"https://a.trader.io", true},
+		{"https://a.b.trader.io", true},
+		{"https://trader.io", false},     // Wildcard doesn't cover the bare domain
+		{"https://eviltrader.io", false}, // Nor a lookalike suffix
+		{"https://a.trader.io.evil.com", false},
+		{"http://a.trader.io", false},
+		{"http://localhost:5173", true},
+		{"http://localhost:3000", false},
+		{"http://db.internal.net", true}, // No scheme in the pattern, any scheme
+		{"https://db.internal.net", true},
+		{"", false},
+		{"null", false},
+		{"https://app.example.com/path", false},
+	}
+	for _, tt := range tests {
+		if got := m.allowed(tt.origin); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if !newOriginMatcher([]string{"*"}).allowed("https://anything.example") {
+		t.Error("* should allow any origin")
+	}
+	if newOriginMatcher(nil).allowed("http://localhost:5173") {
+		t.Error("no patterns should allow nothing")
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := securityHeadersMiddleware(corsMiddleware(newOriginMatcher([]string{"https://*.example.com"}), next))
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/traders", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if preflight {
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Allowed origins are reflected, never "*"
+	w := serve("GET", "https://app.example.com", false)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("allowed origin header = %q", got)
+	}
+	if w.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", w.Header().Get("Vary"))
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Referrer-Policy") == "" {
+		t.Errorf("security headers missing: %v", w.Header())
+	}
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS sent over plain HTTP")
+	}
+
+	// Disallowed origins still get served, just without CORS headers
+	w = serve("GET", "https://evil.com", false)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin = %d %v", w.Code, w.Header())
+	}
+
+	// Preflight
+	w = serve("OPTIONS", "https://app.example.com", true)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Max-Age") != "600" ||
+		w.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("preflight = %d %v", w.Code, w.Header())
+	}
+	w = serve("OPTIONS", "https://evil.com", true)
+	if w.Header().Get("Access-Control-Allow-Methods") != "" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight got CORS headers: %v", w.Header())
+	}
+
+	// HSTS only over TLS
+	r := httptest.NewRequest("GET", "/api/health", nil)
+	r.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("HSTS missing over TLS")
+	}
+}
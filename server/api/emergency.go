@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ EMERGENCY ENDPOINTS ============
+
+// handleEmergencyStopAll is the kill switch. It halts trading globally, stops
+// every engine and cancels their open orders; {"flatten": true} also closes
+// all positions. Each step is written to the audit log.
+func (s *Server) handleEmergencyStopAll(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Flatten bool `json:"flatten"`
+	}
+	if !s.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	// Don't let a dropped connection abort the halt halfway through
+	actions, err := s.engineManager.HaltAll(context.Background(), req.Flatten)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.recordAuditActions(r, actions)
+
+	state, _ := s.engineManager.HaltState()
+	s.jsonResponse(w, map[string]interface{}{
+		"status":   "halted",
+		"halt":     state,
+		"actions":  actions,
+		"audit_id": s.recordAudit(r),
+	})
+}
+
+// handleEmergencyResume lifts the halt. Traders stay stopped until started.
+func (s *Server) handleEmergencyResume(w http.ResponseWriter, r *http.Request) {
+	err := s.engineManager.Resume()
+	s.recordAuditActions(r, []trader.EmergencyAction{trader.NewEmergencyAction("", "resume", "", err)})
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"status": "resumed", "audit_id": s.recordAudit(r)})
+}
+
+// recordAuditActions writes one audit row per step taken for the request,
+// alongside the row for the request itself
+func (s *Server) recordAuditActions(r *http.Request, actions []trader.EmergencyAction) {
+	info := requestInfoFrom(r)
+	if info == nil {
+		return
+	}
+
+	for _, a := range actions {
+		entry := s.newAuditEntry(r, info)
+		entry.Timestamp = a.Time
+		entry.TraderID = a.TraderID
+		entry.Action = a.Action
+		if a.Symbol != "" {
+			entry.Action += ":" + a.Symbol
+		}
+		entry.Result = a.Result
+		entry.Status = http.StatusOK
+		if a.Result != "ok" {
+			entry.Status = http.StatusInternalServerError
+		}
+		if err := s.auditStore.Create(entry); err != nil {
+			log.Printf("[Audit] Failed to record %s: %v", entry.Action, err)
+		}
+	}
+}
+
+// haltState returns the halt for the health endpoint, reporting halted when
+// it can't be read
+func (s *Server) haltState() *store.HaltState {
+	state, err := s.engineManager.HaltState()
+	if err != nil {
+		log.Printf("Failed to read halt state: %v", err)
+		return &store.HaltState{Halted: true}
+	}
+	return state
+}
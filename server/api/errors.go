@@ -0,0 +1,178 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/trader"
+)
+
+// ============ ERRORS ============
+
+// errorCode identifies an error for clients to branch on. Codes are stable;
+// messages are for people and may change.
+type errorCode string
+
+const (
+	// Requests
+	codeNotFound         errorCode = "NOT_FOUND" // Unknown path, or nothing to return
+	codeMethodNotAllowed errorCode = "METHOD_NOT_ALLOWED"
+	codeInvalidRequest   errorCode = "INVALID_REQUEST" // Malformed body, query or path parameter
+	codeUnknownField     errorCode = "UNKNOWN_FIELD"
+	codeBodyTooLarge     errorCode = "BODY_TOO_LARGE"
+
+	// Auth
+	codeAuthRequired  errorCode = "AUTH_REQUIRED"
+	codeAuthInvalid   errorCode = "AUTH_INVALID"
+	codeAuthLockedOut errorCode = "AUTH_LOCKED_OUT"
+	codeForbidden     errorCode = "FORBIDDEN"
+	codeAdminRequired errorCode = "ADMIN_REQUIRED"
+
+	// Resources
+	codeTraderNotFound          errorCode = "TRADER_NOT_FOUND"
+	codeStrategyNotFound        errorCode = "STRATEGY_NOT_FOUND"
+	codeStrategyVersionNotFound errorCode = "STRATEGY_VERSION_NOT_FOUND"
+	codeBacktestNotFound        errorCode = "BACKTEST_NOT_FOUND"
+	codeDebateNotFound          errorCode = "DEBATE_NOT_FOUND"
+	codeDecisionNotFound        errorCode = "DECISION_NOT_FOUND"
+	codeUserNotFound            errorCode = "USER_NOT_FOUND"
+	codeOrderNotFound           errorCode = "ORDER_NOT_FOUND"
+	codePositionNotFound        errorCode = "POSITION_NOT_FOUND"
+	codeTraderInvalid           errorCode = "TRADER_INVALID"
+	codeStrategyInvalid         errorCode = "STRATEGY_INVALID"
+	codeBacktestInvalid         errorCode = "BACKTEST_INVALID"
+	codeUserInvalid             errorCode = "USER_INVALID"
+
+	// State
+	codeTradingHalted       errorCode = "TRADING_HALTED"
+	codeTraderNotRunning    errorCode = "TRADER_NOT_RUNNING"
+	codeTraderRunning       errorCode = "TRADER_RUNNING"
+	codeTraderArchived      errorCode = "TRADER_ARCHIVED"
+	codeMainnetUnconfirmed  errorCode = "MAINNET_CONFIRMATION_REQUIRED"
+	codeConflict            errorCode = "CONFLICT" // The operation is already in progress
+	codeIdempotencyConflict errorCode = "IDEMPOTENCY_CONFLICT"
+	codeRateLimited         errorCode = "RATE_LIMITED"
+
+	// Upstream services
+	codeExchangeRejected    errorCode = "EXCHANGE_REJECTED"
+	codeExchangeUnavailable errorCode = "EXCHANGE_UNAVAILABLE"
+	codeAIUnavailable       errorCode = "AI_UNAVAILABLE"
+	codeAIInvalidResponse   errorCode = "AI_INVALID_RESPONSE"
+
+	codeNotImplemented errorCode = "NOT_IMPLEMENTED"
+	codeInternal       errorCode = "INTERNAL"
+)
+
+// apiError is the body of every error response, as {"error": {...}}
+type apiError struct {
+	Code      errorCode   `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // Matches the server log line for the failure
+}
+
+// writeError writes the error envelope every endpoint uses
+func writeError(w http.ResponseWriter, r *http.Request, status int, e apiError) {
+	e.RequestID = requestID(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]apiError{"error": e})
+}
+
+// errorResponse writes an error the client caused or can act on. The message
+// is shown to users, so it must not carry internal error strings.
+func (s *Server) errorResponse(w http.ResponseWriter, r *http.Request, status int, code errorCode, message string) {
+	writeError(w, r, status, apiError{Code: code, Message: message})
+}
+
+// invalidInput rejects a request with err's message. A rejected language also
+// lists the supported ones, for the client to offer instead.
+func (s *Server) invalidInput(w http.ResponseWriter, r *http.Request, code errorCode, err error) {
+	e := apiError{Code: code, Message: err.Error()}
+	if errors.Is(err, decision.ErrUnsupportedLanguage) {
+		e.Details = map[string][]decision.Language{"supported_languages": decision.SupportedLanguages()}
+	}
+	writeError(w, r, http.StatusBadRequest, e)
+}
+
+// internalError logs err in full with the request ID and responds with the
+// code it maps to, without the internal details
+func (s *Server) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	s.upstreamError(w, r, codeInternal, err)
+}
+
+// upstreamError is internalError for a call to the exchange or AI provider:
+// errors that can't be attributed more precisely get the fallback code
+func (s *Server) upstreamError(w http.ResponseWriter, r *http.Request, fallback errorCode, err error) {
+	status, e := classifyError(err, fallback)
+	log.Printf("[HTTP] %s %s failed (request %s): %v", r.Method, r.URL.Path, requestID(r), err)
+	writeError(w, r, status, e)
+}
+
+// classifyError maps store, exchange and AI errors to a status and a safe
+// error. This is the one place internal errors are turned into API codes.
+func classifyError(err error, fallback errorCode) (int, apiError) {
+	var exchangeErr *exchange.APIError
+	var aiErr *mcp.APIError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound, apiError{Code: codeNotFound, Message: "Not found"}
+	case errors.Is(err, trader.ErrTradingHalted):
+		return http.StatusConflict, apiError{Code: codeTradingHalted, Message: "Trading is halted; resume it before starting traders"}
+	case errors.Is(err, trader.ErrTraderArchived):
+		return http.StatusConflict, apiError{Code: codeTraderArchived, Message: "The trader is archived; unarchive it before starting it"}
+	case errors.Is(err, trader.ErrMainnetUnconfirmed):
+		return http.StatusConflict, apiError{Code: codeMainnetUnconfirmed, Message: `The trader would run a turbo mode strategy on mainnet; start it with {"confirm_mainnet": true}`}
+	case errors.As(err, &exchangeErr):
+		// Binance's own reason ("Margin is insufficient.") is meant for users
+		if exchangeErr.StatusCode >= 500 || exchangeErr.StatusCode == http.StatusTooManyRequests || exchangeErr.StatusCode == 418 {
+			return http.StatusBadGateway, apiError{Code: codeExchangeUnavailable, Message: fmt.Sprintf("Binance returned status %d", exchangeErr.StatusCode)}
+		}
+		e := apiError{Code: codeExchangeRejected, Message: "Binance rejected the request"}
+		if exchangeErr.Msg != "" {
+			e.Message = "Binance rejected the request: " + exchangeErr.Msg
+			e.Details = map[string]int{"exchange_code": exchangeErr.Code}
+		}
+		return http.StatusUnprocessableEntity, e
+	case errors.As(err, &aiErr):
+		return http.StatusBadGateway, apiError{Code: codeAIUnavailable, Message: fmt.Sprintf("AI provider returned status %d", aiErr.StatusCode)}
+	case errors.Is(err, mcp.ErrNoAPIKey):
+		return http.StatusServiceUnavailable, apiError{Code: codeAIUnavailable, Message: "No AI API key is configured"}
+	}
+
+	switch fallback {
+	case codeExchangeUnavailable:
+		return http.StatusBadGateway, apiError{Code: fallback, Message: "Binance could not be reached"}
+	case codeAIUnavailable:
+		return http.StatusBadGateway, apiError{Code: fallback, Message: "The AI provider could not be reached"}
+	}
+	return http.StatusInternalServerError, apiError{Code: codeInternal, Message: "Internal server error"}
+}
+
+// ============ REQUEST IDS ============
+
+const requestIDHeader = "X-Request-ID"
+
+// newRequestID returns a random ID to tie a response to its log lines
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID is the ID requestLogMiddleware gave the request, or "" outside it
+func requestID(r *http.Request) string {
+	if info := requestInfoFrom(r); info != nil {
+		return info.requestID
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/trader"
+)
+
+func TestNotFoundCodes(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, created := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+
+	tests := []struct {
+		method, path, code string
+	}{
+		{"GET", "/api/nope", "NOT_FOUND"},
+		{"GET", "/api/traders/missing", "TRADER_NOT_FOUND"},
+		{"POST", "/api/traders/missing/start", "TRADER_NOT_FOUND"},
+		{"GET", "/api/strategies/missing", "STRATEGY_NOT_FOUND"},
+		{"GET", "/api/backtest/missing", "BACKTEST_NOT_FOUND"},
+		{"GET", "/api/debate/sessions/missing", "DEBATE_NOT_FOUND"},
+		{"GET", "/api/users/missing", "USER_NOT_FOUND"},
+		{"GET", "/api/traders/" + created["id"].(string) + "/decisions/1/raw", "DECISION_NOT_FOUND"},
+		{"GET", "/api/traders/" + created["id"].(string) + "/positions/1", "POSITION_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		w, resp := serve(t, mux, tt.method, tt.path, "")
+		if code, msg := errorOf(resp); w.Code != http.StatusNotFound || code != tt.code || msg == "" {
+			t.Errorf("%s %s = %d %v, want 404 %s", tt.method, tt.path, w.Code, resp["error"], tt.code)
+		}
+	}
+}
+
+func TestValidationCodes(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, created := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	traderPath := "/api/traders/" + created["id"].(string)
+
+	tests := []struct {
+		name, method, path, body, code string
+	}{
+		{"malformed body", "POST", "/api/strategies", `{"name":`, "INVALID_REQUEST"},
+		{"wrong type", "POST", "/api/strategies", `{"name":"s1","config":{"trading_interval":"5m"}}`, "INVALID_REQUEST"},
+		{"misspelled field", "POST", "/api/traders", `{"name":"t1","initial_balanse":500}`, "UNKNOWN_FIELD"},
+		{"invalid schedule", "POST", "/api/strategies", `{"name":"s1","config":{"schedule":{"enabled":true}}}`, "STRATEGY_INVALID"},
+		{"invalid timezone", "POST", "/api/strategies", `{"name":"s1","config":{"timezone":"New York"}}`, "STRATEGY_INVALID"},
+		{"invalid temperature", "POST", "/api/strategies", `{"name":"s1","config":{"ai":{"temperature":3}}}`, "STRATEGY_INVALID"},
+		{"invalid fallback models", "POST", "/api/strategies", `{"name":"s1","config":{"ai":{"fallback_models":[""]}}}`, "STRATEGY_INVALID"},
+		{"invalid coin source", "POST", "/api/strategies", `{"name":"s1","config":{"coin_source":{"source_type":"remote","remote_url":"ftp://lists.example"}}}`, "STRATEGY_INVALID"},
+		{"invalid provider", "POST", "/api/strategies", `{"name":"s1","config":{"ai":{"provider":"ollama"}}}`, "STRATEGY_INVALID"},
+		{"single variant experiment", "POST", "/api/strategies", `{"name":"s1","config":{"experiment":{"enabled":true,"variants":[{"id":"a"}]}}}`, "STRATEGY_INVALID"},
+		{"invalid backtest", "POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"sizing_mode":"martingale"}`, "BACKTEST_INVALID"},
+		{"invalid backtest ai", "POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"ai":{"reasoning_effort":"max"}}`, "BACKTEST_INVALID"},
+		{"unsupported backtest language", "POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"language":"fr-FR"}`, "BACKTEST_INVALID"},
+		{"unsupported debate language", "POST", "/api/debate/sessions", `{"name":"d1","symbols":["BTCUSDT"],"language":"fr-FR"}`, "INVALID_REQUEST"},
+		{"backtest with two cadences", "POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"decision_cadence_n_bars":4,"decision_cadence_minutes":60}`, "BACKTEST_INVALID"},
+		{"invalid shutdown policy", "POST", "/api/traders", `{"name":"t1","config":{"shutdown_policy":"close"}}`, "TRADER_INVALID"},
+		{"invalid user", "POST", "/api/users", `{"name":"bob","role":"root"}`, "USER_INVALID"},
+		{"invalid query", "GET", "/api/audit?limit=-1", "", "INVALID_REQUEST"},
+		{"invalid days", "GET", "/api/ai-calls/models?days=0", "", "INVALID_REQUEST"},
+		{"invalid bulk action", "POST", "/api/traders/bulk", `{"action":"restart","ids":["x"]}`, "INVALID_REQUEST"},
+		{"invalid order id", "DELETE", traderPath + "/orders/abc", "", "INVALID_REQUEST"},
+		{"no stop levels", "PUT", traderPath + "/positions/BTCUSDT/stops", `{}`, "INVALID_REQUEST"},
+		{"negative stop", "PUT", traderPath + "/positions/BTCUSDT/stops", `{"stop_loss":-1}`, "INVALID_REQUEST"},
+		{"invalid position id", "GET", traderPath + "/positions/BTCUSDT/events?position_id=x", "", "INVALID_REQUEST"},
+		{"invalid position detail id", "GET", traderPath + "/positions/0", "", "INVALID_REQUEST"},
+	}
+	for _, tt := range tests {
+		w, resp := serve(t, mux, tt.method, tt.path, tt.body)
+		if code, _ := errorOf(resp); w.Code != http.StatusBadRequest || code != tt.code {
+			t.Errorf("%s: %d %v, want 400 %s", tt.name, w.Code, resp["error"], tt.code)
+		}
+	}
+
+	// The offending field is machine-readable
+	_, resp := serve(t, mux, "POST", "/api/traders", `{"name":"t1","initial_balanse":500}`)
+	if details, _ := resp["error"].(map[string]interface{})["details"].(map[string]interface{}); details["field"] != "initial_balanse" {
+		t.Errorf("unknown field details = %v", resp["error"])
+	}
+
+	// So are the languages a rejected one could be replaced with
+	_, resp = serve(t, mux, "POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"language":"fr-FR"}`)
+	details, _ := resp["error"].(map[string]interface{})["details"].(map[string]interface{})
+	if langs, _ := details["supported_languages"].([]interface{}); len(langs) != 4 || langs[2] != "ja-JP" {
+		t.Errorf("unsupported language details = %v", resp["error"])
+	}
+
+	// Orders are managed through the running engine
+	w, resp := serve(t, mux, "PUT", traderPath+"/positions/BTCUSDT/stops", `{"stop_loss":90000}`)
+	if code, _ := errorOf(resp); w.Code != http.StatusConflict || code != "TRADER_NOT_RUNNING" {
+		t.Errorf("stops on a stopped trader = %d %v", w.Code, resp["error"])
+	}
+
+	// Nothing to acknowledge without a tripped circuit breaker
+	w, resp = serve(t, mux, "POST", traderPath+"/acknowledge-circuit-breaker", "")
+	if code, _ := errorOf(resp); w.Code != http.StatusConflict || code != "CONFLICT" {
+		t.Errorf("acknowledge untripped breaker = %d %v", w.Code, resp["error"])
+	}
+
+	// The stop history reads from the store, running or not
+	w, resp = serve(t, mux, "GET", traderPath+"/positions/btcusdt/events", "")
+	if events, ok := resp["events"].([]interface{}); w.Code != http.StatusOK || !ok || len(events) != 0 {
+		t.Errorf("position events on a stopped trader = %d %v", w.Code, resp)
+	}
+
+	w, resp = serve(t, mux, "PATCH", "/api/traders", "")
+	if code, _ := errorOf(resp); w.Code != http.StatusMethodNotAllowed || code != "METHOD_NOT_ALLOWED" {
+		t.Errorf("wrong method = %d %v", w.Code, resp["error"])
+	}
+}
+
+func TestErrorsCarryRequestID(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	handler := (&Server{cfg: &config.Config{}}).requestLogMiddleware(mux)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/traders/missing", nil))
+
+	id := w.Header().Get(requestIDHeader)
+	if id == "" || !strings.Contains(w.Body.String(), `"request_id":"`+id+`"`) {
+		t.Errorf("X-Request-ID %q not in the error body %s", id, w.Body)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback errorCode
+		status   int
+		code     errorCode
+		message  string
+	}{
+		{"no rows", fmt.Errorf("loading: %w", sql.ErrNoRows), codeInternal, 404, codeNotFound, "Not found"},
+		{"halted", fmt.Errorf("start: %w", trader.ErrTradingHalted), codeInternal, 409, codeTradingHalted, ""},
+		{"order rejected", fmt.Errorf("failed to start engine: %w", &exchange.APIError{StatusCode: 400, Code: -2019, Msg: "Margin is insufficient.", Body: `{"code":-2019,"msg":"Margin is insufficient."}`}),
+			codeInternal, 422, codeExchangeRejected, "Binance rejected the request: Margin is insufficient."},
+		{"exchange down", &exchange.APIError{StatusCode: 503}, codeInternal, 502, codeExchangeUnavailable, "Binance returned status 503"},
+		{"exchange unreachable", errors.New("request failed: dial tcp: i/o timeout"), codeExchangeUnavailable, 502, codeExchangeUnavailable, "Binance could not be reached"},
+		{"AI error", fmt.Errorf("max retries exceeded: %w", &mcp.APIError{StatusCode: 500, Body: "upstream trace"}), codeInternal, 502, codeAIUnavailable, "AI provider returned status 500"},
+		{"no AI key", mcp.ErrNoAPIKey, codeInternal, 503, codeAIUnavailable, ""},
+		{"SQL error", errors.New(`pq: relation "traders" does not exist`), codeInternal, 500, codeInternal, "Internal server error"},
+	}
+	for _, tt := range tests {
+		status, e := classifyError(tt.err, tt.fallback)
+		if status != tt.status || e.Code != tt.code || (tt.message != "" && e.Message != tt.message) {
+			t.Errorf("%s = %d %+v, want %d %s %q", tt.name, status, e, tt.status, tt.code, tt.message)
+		}
+		// The wrapped internal error never reaches the client
+		if strings.Contains(e.Message, "pq:") || strings.Contains(e.Message, "trace") || strings.Contains(e.Message, "dial tcp") {
+			t.Errorf("%s leaked %q", tt.name, e.Message)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ PROMPT EXPERIMENT ENDPOINTS ============
+
+// handleTraderExperiments summarizes each prompt variant's cycles, decisions
+// and positions. The strategy's variants come first, zero when they haven't
+// run yet, then variants since removed from it.
+func (s *Server) handleTraderExperiments(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	stats, err := s.experimentStore.VariantStats(t.ID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	var experiment store.ExperimentConfig
+	if t.StrategyID != "" {
+		if strategy, err := s.strategyStore.Get(t.StrategyID); err == nil {
+			experiment = strategy.Config.Experiment
+		}
+	}
+
+	byID := make(map[string]*store.VariantStats, len(stats))
+	for _, v := range stats {
+		byID[v.Variant] = v
+	}
+	configured := make(map[string]bool, len(experiment.Variants))
+	variants := make([]*store.VariantStats, 0, len(stats)+len(experiment.Variants))
+	for _, v := range experiment.Variants {
+		configured[v.ID] = true
+		if stat := byID[v.ID]; stat != nil {
+			variants = append(variants, stat)
+		} else {
+			variants = append(variants, &store.VariantStats{Variant: v.ID, Decisions: map[string]int{}})
+		}
+	}
+	for _, v := range stats {
+		if !configured[v.Variant] {
+			variants = append(variants, v)
+		}
+	}
+
+	assignment := experiment.Assignment
+	if assignment == "" {
+		assignment = store.ExperimentAlternate
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"enabled":    experiment.Enabled,
+		"assignment": assignment,
+		"variants":   variants,
+	})
+}
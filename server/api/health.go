@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/store"
+)
+
+// ============ DEEP HEALTH ============
+
+// Component and overall health states
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+const (
+	healthCheckTimeout = 5 * time.Second
+	aiHealthTTL        = time.Minute     // The AI key check runs at most this often
+	maxClockDrift      = 1 * time.Second // Signed Binance requests past this are at risk of rejection
+)
+
+// componentHealth is the result of one dependency check
+type componentHealth struct {
+	Status    string                 `json:"status"`
+	Critical  bool                   `json:"critical"` // Down makes the whole service down
+	LatencyMs int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// aiHealthCache keeps the last AI key check, so polling monitors don't hit
+// the provider on every request
+type aiHealthCache struct {
+	mu   sync.Mutex
+	last *componentHealth
+}
+
+func (c *aiHealthCache) get() *componentHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.last.CheckedAt) < aiHealthTTL {
+		return c.last
+	}
+	return nil
+}
+
+func (c *aiHealthCache) put(h *componentHealth) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = h
+}
+
+// reset drops the cached result, for when the AI client is replaced
+func (c *aiHealthCache) reset() {
+	c.put(nil)
+}
+
+// handleDeepHealth serves GET /api/health/deep: the database, Binance, the AI
+// provider and every running trader's decision loop, with an overall verdict.
+// Responds 503 when a critical component is down so monitors can alert on it.
+func (s *Server) handleDeepHealth(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(context.Context) *componentHealth{
+		"database": s.checkDatabase,
+		"binance":  s.checkBinance,
+		"ai":       s.checkAI,
+	}
+	if s.usesOllama() {
+		checks["ollama"] = s.checkOllama
+	}
+	components := make(map[string]*componentHealth, len(checks)+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) *componentHealth) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			defer cancel()
+			h := check(ctx)
+			mu.Lock()
+			components[name] = h
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	components["traders"] = s.checkTraderLoops()
+
+	status := overallHealth(components)
+	if status == healthDown {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"status":     status,
+		"time":       time.Now().Format(time.RFC3339),
+		"components": components,
+	})
+}
+
+// overallHealth is down when a critical component is down, degraded when
+// anything else isn't ok
+func overallHealth(components map[string]*componentHealth) string {
+	status := healthOK
+	for _, h := range components {
+		switch {
+		case h.Status == healthDown && h.Critical:
+			return healthDown
+		case h.Status != healthOK:
+			status = healthDegraded
+		}
+	}
+	return status
+}
+
+// timedCheck runs a check and fills in its latency and outcome
+func timedCheck(critical bool, check func() (map[string]interface{}, error)) *componentHealth {
+	start := time.Now()
+	details, err := check()
+	h := &componentHealth{
+		Status:    healthOK,
+		Critical:  critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Details:   details,
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		h.Status = healthDown
+		h.Error = err.Error()
+	}
+	return h
+}
+
+func (s *Server) checkDatabase(ctx context.Context) *componentHealth {
+	return timedCheck(true, func() (map[string]interface{}, error) {
+		return nil, store.Probe(ctx)
+	})
+}
+
+// checkBinance pings the futures API and compares its clock with ours. A
+// drift beyond what the last time sync corrected for degrades it, and so
+// does a local clock off by more than maxClockDrift at all: time syncs paper
+// over it, but a broken system clock should be fixed.
+func (s *Server) checkBinance(ctx context.Context) *componentHealth {
+	var drift time.Duration
+	h := timedCheck(true, func() (map[string]interface{}, error) {
+		if err := s.binanceClient.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("ping: %w", err)
+		}
+		sent := time.Now()
+		serverTime, err := s.binanceClient.ServerTime(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("server time: %w", err)
+		}
+		// Compare against the middle of the round trip
+		local := sent.Add(time.Since(sent) / 2)
+		drift = serverTime.Sub(local)
+		sync := s.binanceClient.TimeSync()
+		details := map[string]interface{}{
+			"testnet":        s.binanceClient.IsTestnet(),
+			"clock_drift_ms": drift.Milliseconds(),
+			"time_offset_ms": sync.Offset.Milliseconds(),
+			"time_resyncs":   sync.Resyncs,
+		}
+		if !sync.LastSync.IsZero() {
+			details["last_time_sync"] = sync.LastSync
+		}
+		return details, nil
+	})
+	if uncorrected := drift - s.binanceClient.ServerTimeOffset(); h.Status == healthOK &&
+		(uncorrected > maxClockDrift || uncorrected < -maxClockDrift) {
+		h.Status = healthDegraded
+		h.Error = fmt.Sprintf("clock off by %dms after time sync correction", uncorrected.Milliseconds())
+	} else if h.Status == healthOK && (drift > maxClockDrift || drift < -maxClockDrift) {
+		h.Status = healthDegraded
+		h.Error = fmt.Sprintf("system clock off by %dms from Binance, corrected by time sync", drift.Milliseconds())
+	}
+	return h
+}
+
+// checkAI verifies the AI provider accepts the configured key, at most once
+// per aiHealthTTL
+func (s *Server) checkAI(ctx context.Context) *componentHealth {
+	if cached := s.aiHealth.get(); cached != nil {
+		return cached
+	}
+
+	var err error
+	h := timedCheck(true, func() (map[string]interface{}, error) {
+		details := map[string]interface{}{"provider": s.aiClient.GetProvider(), "model": s.aiClient.GetModel()}
+		checker, ok := s.aiClient.(mcp.KeyChecker)
+		if !ok {
+			return details, fmt.Errorf("provider can't verify its key")
+		}
+		err = checker.CheckKey(ctx)
+		return details, err
+	})
+	// Traders can bring their own keys, so a missing global one isn't an outage
+	if errors.Is(err, mcp.ErrNoAPIKey) {
+		h.Status = healthDegraded
+	}
+	s.aiHealth.put(h)
+	return h
+}
+
+// usesOllama reports whether Ollama is configured or any strategy runs on it
+func (s *Server) usesOllama() bool {
+	if s.cfg.OllamaBaseURL != "" {
+		return true
+	}
+	strategies, err := s.strategyStore.List()
+	if err != nil {
+		return false
+	}
+	for _, st := range strategies {
+		if st.Config.AI.Provider == mcp.ProviderOllama {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOllama lists the local server's models. Only traders on Ollama depend
+// on it, so it isn't critical.
+func (s *Server) checkOllama(ctx context.Context) *componentHealth {
+	return timedCheck(false, func() (map[string]interface{}, error) {
+		models, err := s.ollamaClient.LocalModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"models": models}, nil
+	})
+}
+
+// checkTraderLoops flags running traders whose decision loop hasn't cycled
+// within twice its interval. A stuck trader degrades the service, it doesn't
+// take it down.
+func (s *Server) checkTraderLoops() *componentHealth {
+	loops := s.engineManager.LoopHealth()
+	h := &componentHealth{Status: healthOK, CheckedAt: time.Now(), Details: map[string]interface{}{}}
+	var stale []string
+	for id, loop := range loops {
+		h.Details[id] = loop
+		if loop.Stale {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		h.Status = healthDegraded
+		h.Error = fmt.Sprintf("%d of %d running traders missed two cycles", len(stale), len(loops))
+	}
+	return h
+}
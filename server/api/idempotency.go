@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ IDEMPOTENCY KEYS ============
+
+const (
+	idempotencyKeyHeader   = "Idempotency-Key"
+	idempotentReplayHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLen   = 255
+	idempotencyPurgeEvery  = time.Hour
+	defaultIdempotencyTTL  = 24 * time.Hour
+)
+
+// idempotencyKeys lets clients retry mutating requests safely. A request sent
+// with an Idempotency-Key has its response saved; retries with the same key
+// and the same request get that response back instead of running again.
+type idempotencyKeys struct {
+	store    *store.IdempotencyStore
+	ttl      time.Duration
+	mu       sync.Mutex
+	inflight map[string]bool // user ID + key of requests still being handled
+}
+
+func newIdempotencyKeys(ttl time.Duration) *idempotencyKeys {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &idempotencyKeys{
+		store:    store.NewIdempotencyStore(),
+		ttl:      ttl,
+		inflight: make(map[string]bool),
+	}
+}
+
+// begin claims a key for a request. Returns false if another request with the
+// key is still being handled.
+func (k *idempotencyKeys) begin(id string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.inflight[id] {
+		return false
+	}
+	k.inflight[id] = true
+	return true
+}
+
+func (k *idempotencyKeys) end(id string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.inflight, id)
+}
+
+// run deletes expired keys periodically
+func (k *idempotencyKeys) run() {
+	ticker := time.NewTicker(idempotencyPurgeEvery)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if _, err := k.store.DeleteExpired(now); err != nil {
+			log.Printf("[Idempotency] Failed to delete expired keys: %v", err)
+		}
+	}
+}
+
+// responseCapture passes a response through while keeping a copy to save
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// serveIdempotent handles an authenticated mutating request that carries an
+// Idempotency-Key. Keys are scoped to the user; reusing one for a different
+// request, or while the first is still running, is a 409. Server errors
+// aren't saved so they can be retried.
+func (s *Server) serveIdempotent(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if len(key) > idempotencyKeyMaxLen {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Idempotency-Key is too long")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxRequestBody()))
+	if err != nil {
+		s.errorResponse(w, r, http.StatusRequestEntityTooLarge, codeBodyTooLarge, fmt.Sprintf("Request body larger than %d KB", s.maxRequestBody()>>10))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	hash := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+	requestHash := hex.EncodeToString(hash[:])
+
+	userID := currentUser(r).ID
+	id := userID + "\x00" + key
+	if !s.idempotency.begin(id) {
+		s.errorResponse(w, r, http.StatusConflict, codeIdempotencyConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+	defer s.idempotency.end(id)
+
+	saved, err := s.idempotency.store.Get(userID, key, time.Now())
+	switch {
+	case err == nil && saved.RequestHash != requestHash:
+		s.errorResponse(w, r, http.StatusConflict, codeIdempotencyConflict, "Idempotency-Key was already used for a different request")
+		return
+	case err == nil:
+		if saved.ContentType != "" {
+			w.Header().Set("Content-Type", saved.ContentType)
+		}
+		w.Header().Set(idempotentReplayHeader, "true")
+		w.WriteHeader(saved.Status)
+		w.Write(saved.Body)
+		return
+	case err != sql.ErrNoRows:
+		s.internalError(w, r, err)
+		return
+	}
+
+	capture := &responseCapture{ResponseWriter: w}
+	next(capture, r)
+	if capture.status == 0 || capture.status >= 500 {
+		return
+	}
+
+	now := time.Now()
+	record := &store.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      capture.status,
+		ContentType: w.Header().Get("Content-Type"),
+		Body:        capture.body.Bytes(),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.idempotency.ttl),
+	}
+	if err := s.idempotency.store.Save(record); err != nil {
+		log.Printf("[Idempotency] Failed to save response for %s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
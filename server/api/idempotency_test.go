@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/store"
+)
+
+func sendWithKey(mux *routeMux, method, path, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyKeyReplays(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	first := sendWithKey(mux, "POST", "/api/traders", "create-1", `{"name":"t1"}`)
+	retry := sendWithKey(mux, "POST", "/api/traders", "create-1", `{"name":"t1"}`)
+	if first.Code != 200 || retry.Code != 200 || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry = %d %s, want the first response %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get(idempotentReplayHeader) != "true" || first.Header().Get(idempotentReplayHeader) != "" {
+		t.Error("replay header not set on the retry only")
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replayed Content-Type = %q", retry.Header().Get("Content-Type"))
+	}
+	if _, list := serve(t, mux, "GET", "/api/traders", ""); len(list["traders"].([]interface{})) != 1 {
+		t.Errorf("retry created another trader: %v", list["traders"])
+	}
+
+	// Same key, different request
+	if w := sendWithKey(mux, "POST", "/api/traders", "create-1", `{"name":"t2"}`); w.Code != http.StatusConflict {
+		t.Errorf("reused key with another body = %d, want 409", w.Code)
+	}
+	if w := sendWithKey(mux, "POST", "/api/strategies", "create-1", `{"name":"t1"}`); w.Code != http.StatusConflict {
+		t.Errorf("reused key on another path = %d, want 409", w.Code)
+	}
+
+	// Without a key every request runs
+	sendWithKey(mux, "POST", "/api/traders", "", `{"name":"t3"}`)
+	sendWithKey(mux, "POST", "/api/traders", "", `{"name":"t3"}`)
+	if _, list := serve(t, mux, "GET", "/api/traders", ""); len(list["traders"].([]interface{})) != 3 {
+		t.Errorf("traders = %d, want 3", len(list["traders"].([]interface{})))
+	}
+}
+
+func TestIdempotencyKeyErrorsAndConcurrency(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := &Server{cfg: &config.Config{}, idempotency: newIdempotencyKeys(0)}
+	calls := 0
+	status := http.StatusBadGateway
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		s.errorResponse(w, r, status, codeInternal, "upstream failed")
+	}
+	send := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/traders/t1/start", nil)
+		r.Header.Set(idempotencyKeyHeader, key)
+		r = r.WithContext(withUser(r.Context(), bootstrapAdmin()))
+		w := httptest.NewRecorder()
+		s.serveIdempotent(w, r, handler)
+		return w
+	}
+
+	// Server errors aren't saved, so the retry runs
+	send("k1")
+	status = http.StatusConflict
+	if w := send("k1"); w.Code != http.StatusConflict || calls != 2 {
+		t.Errorf("retry after 502 = %d after %d calls, want it run again", w.Code, calls)
+	}
+	// Client errors are
+	if w := send("k1"); w.Code != http.StatusConflict || calls != 2 || w.Header().Get(idempotentReplayHeader) != "true" {
+		t.Errorf("retry after 409 = %d after %d calls, want a replay", w.Code, calls)
+	}
+
+	// A retry while the first request is running is rejected
+	s.idempotency.begin(bootstrapAdmin().ID + "\x00k2")
+	if w := send("k2"); w.Code != http.StatusConflict || calls != 2 {
+		t.Errorf("concurrent retry = %d after %d calls", w.Code, calls)
+	}
+
+	if w := send(strings.Repeat("k", idempotencyKeyMaxLen+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized key = %d", w.Code)
+	}
+}
+
+func TestStopTraderIsIdempotent(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, created := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	path := "/api/traders/" + created["id"].(string) + "/stop"
+
+	if w, resp := serve(t, mux, "POST", path, ""); w.Code != 200 || resp["status"] != "already_stopped" {
+		t.Errorf("stopping a stopped trader = %d %v", w.Code, resp)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ DECISION LATENCY ENDPOINTS ============
+
+// handleLatencyReport sums up, by model, how long the trader's decisions took
+// from their market data to their orders and how far prices moved meanwhile
+func (s *Server) handleLatencyReport(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+
+	models, err := s.latencyStore.ModelLatency(t.ID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	maxStaleness := 0
+	if t.StrategyID != "" {
+		if strategy, err := s.strategyStore.Get(t.StrategyID); err == nil {
+			maxStaleness = strategy.Config.RiskControl.MaxDecisionStalenessSecs
+		}
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"days":                        days,
+		"max_decision_staleness_secs": maxStaleness,
+		"models":                      models,
+	})
+}
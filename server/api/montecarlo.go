@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"auto-trader-ahh/backtest"
+)
+
+// ============ BACKTEST MONTE CARLO ENDPOINTS ============
+
+// handleBacktestMonteCarlo resamples a finished run's trades. With Accept:
+// text/event-stream it streams progress events before the result.
+func (s *Server) handleBacktestMonteCarlo(w http.ResponseWriter, r *http.Request, runID string) {
+	var cfg backtest.MonteCarloConfig
+	if !s.decodeOptionalJSON(w, r, &cfg) {
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeBacktestInvalid, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		result, err := s.backtestManager.MonteCarlo(r.Context(), runID, cfg, nil)
+		if err != nil {
+			s.errorResponse(w, r, http.StatusConflict, codeConflict, err.Error())
+			return
+		}
+		s.jsonResponse(w, map[string]interface{}{"result": result, "audit_id": s.recordAudit(r)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	send := func(event map[string]interface{}) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	result, err := s.backtestManager.MonteCarlo(r.Context(), runID, cfg, func(done int) {
+		send(map[string]interface{}{"type": "progress", "done": done, "total": cfg.Iterations})
+	})
+	if err != nil {
+		send(map[string]interface{}{"type": "error", "error": err.Error()})
+		return
+	}
+	send(map[string]interface{}{"type": "result", "result": result, "audit_id": s.recordAudit(r)})
+}
+
+// handleGetBacktestMonteCarlo returns a run's latest Monte Carlo analysis
+func (s *Server) handleGetBacktestMonteCarlo(w http.ResponseWriter, r *http.Request, runID string) {
+	result, err := s.backtestManager.GetMonteCarlo(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, result)
+}
@@ -0,0 +1,644 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/backtest"
+	"auto-trader-ahh/debate"
+	"auto-trader-ahh/market"
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/report"
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ OPENAPI ============
+
+// Who may call an operation
+const (
+	accessPublic = "public"
+	accessUser   = "user"
+	accessAdmin  = "admin"
+)
+
+// apiOperation documents one method on one path. Add an entry here when
+// registering a route; TestOpenAPICoversRoutes fails until you do.
+type apiOperation struct {
+	Method   string
+	Path     string // With {param} placeholders
+	Tag      string
+	Summary  string
+	Access   string
+	Query    []apiParam
+	Body     interface{} // Zero value of the request body type
+	Response interface{} // Zero value of the JSON response type, nil for none
+	Produces []string    // Non-JSON content types, served as text
+	Errors   []int       // Statuses besides the ones implied by Access
+}
+
+// apiParam is a query parameter
+type apiParam struct {
+	Name        string
+	Description string
+	Required    bool
+	Type        string // Defaults to string
+}
+
+// envelope describes a JSON object by example: each value's type is the
+// schema of that property
+type envelope map[string]interface{}
+
+// freeForm is an object whose keys aren't fixed
+type freeForm map[string]interface{}
+
+var (
+	statusResult         = envelope{"status": ""}
+	auditStatusResult    = envelope{"status": "", "audit_id": int64(0)}
+	stopResult           = envelope{"status": "", "shutdown": &trader.ShutdownReport{}, "audit_id": int64(0)}
+	traderIDParam        = apiParam{Name: "trader_id", Required: true, Description: "Trader ID, or debate_{session_id} for a debate account"}
+	includeArchivedParam = apiParam{Name: "include_archived", Type: "boolean", Description: "List archived traders too"}
+)
+
+var apiOperations = []apiOperation{
+	// Health
+	{Method: "GET", Path: "/api/health", Tag: "Health", Summary: "Liveness, halt state and market streams", Access: accessPublic,
+		Response: envelope{"status": "", "time": "", "halted": false, "halted_at": time.Time{}, "streams": map[string]market.StreamHealth{}}},
+	{Method: "GET", Path: "/api/health/deep", Tag: "Health", Summary: "Dependency checks, 503 when a critical one is down", Access: accessPublic,
+		Response: envelope{"status": "", "time": "", "components": map[string]componentHealth{}}, Errors: []int{503}},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "Health", Summary: "This document", Access: accessPublic, Response: freeForm{}},
+	{Method: "GET", Path: "/api/docs", Tag: "Health", Summary: "Swagger UI for this document", Access: accessPublic, Produces: []string{"text/html"}},
+
+	// Auth and users
+	{Method: "POST", Path: "/api/auth/verify", Tag: "Auth", Summary: "Check a passkey or user API key", Access: accessPublic,
+		Body:     envelope{"passkey": ""},
+		Response: envelope{"valid": false, "message": "", "required": false, "user": &store.User{}}, Errors: []int{400, 429}},
+	{Method: "GET", Path: "/api/auth/me", Tag: "Auth", Summary: "Current user", Access: accessUser, Response: &store.User{}},
+	{Method: "GET", Path: "/api/auth/lockouts", Tag: "Auth", Summary: "IPs blocked after failed authentication", Access: accessAdmin, Response: []authFailures{}},
+	{Method: "DELETE", Path: "/api/auth/lockouts/{ip}", Tag: "Auth", Summary: "Unblock an IP", Access: accessAdmin, Response: statusResult, Errors: []int{404}},
+	{Method: "GET", Path: "/api/users", Tag: "Users", Summary: "List users", Access: accessAdmin, Response: envelope{"users": []*store.User{}}},
+	{Method: "POST", Path: "/api/users", Tag: "Users", Summary: "Create a user, returning its API key once", Access: accessAdmin,
+		Body: envelope{"name": "", "role": ""}, Response: envelope{"user": &store.User{}, "api_key": ""}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/users/{id}", Tag: "Users", Summary: "Get a user", Access: accessAdmin, Response: &store.User{}, Errors: []int{404}},
+	{Method: "DELETE", Path: "/api/users/{id}", Tag: "Users", Summary: "Delete a user", Access: accessAdmin, Response: statusResult, Errors: []int{404}},
+
+	// Strategies
+	{Method: "GET", Path: "/api/strategies", Tag: "Strategies", Summary: "List strategies", Access: accessUser, Response: envelope{"strategies": []*store.Strategy{}}},
+	{Method: "POST", Path: "/api/strategies", Tag: "Strategies", Summary: "Create a strategy", Access: accessUser,
+		Body: &store.Strategy{}, Response: &store.Strategy{}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/strategies/{id}", Tag: "Strategies", Summary: "Get a strategy", Access: accessUser, Response: &store.Strategy{}, Errors: []int{404}},
+	{Method: "PUT", Path: "/api/strategies/{id}", Tag: "Strategies", Summary: "Update a strategy, reloading it in running traders", Access: accessUser,
+		Body: &store.Strategy{}, Response: &store.Strategy{}, Errors: []int{400, 404}},
+	{Method: "DELETE", Path: "/api/strategies/{id}", Tag: "Strategies", Summary: "Delete a strategy", Access: accessUser, Response: auditStatusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/strategies/{id}/activate", Tag: "Strategies", Summary: "Make a strategy the active one for every user", Access: accessAdmin, Response: statusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/strategies/{id}/backtest", Tag: "Strategies", Summary: "Backtest the strategy's static coins, timeframes, cadence, risk control, AI settings and custom prompt", Access: accessUser,
+		Query:    []apiParam{{Name: "dry_run", Type: "boolean", Description: "Only build the config and estimate the AI calls, start nothing"}},
+		Body:     &strategyBacktestRequest{},
+		Response: envelope{"run_id": "", "status": "", "config": &backtest.Config{}, "estimate": &backtest.CostEstimate{}}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/strategies/{id}/versions", Tag: "Strategies", Summary: "The strategy's saved versions, newest first, without their configs", Access: accessUser,
+		Response: envelope{"versions": []*store.StrategyVersion{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/strategies/{id}/versions/{version}", Tag: "Strategies", Summary: "A saved version's config and the fields the current config changed from it", Access: accessUser,
+		Response: strategyVersionResult{}, Errors: []int{400, 404}},
+	{Method: "POST", Path: "/api/strategies/{id}/versions/{version}/rollback", Tag: "Strategies", Summary: "Save a version's config as a new version, reloading it in running traders", Access: accessUser,
+		Response: envelope{"strategy": &store.Strategy{}, "rolled_back_to": 0, "audit_id": int64(0)}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/strategies/active", Tag: "Strategies", Summary: "The active strategy", Access: accessUser, Response: &store.Strategy{}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/strategies/default-config", Tag: "Strategies", Summary: "Default strategy config", Access: accessUser, Response: store.StrategyConfig{}},
+	{Method: "GET", Path: "/api/strategies/presets", Tag: "Strategies", Summary: "Named strategy configs for common risk profiles, with what each changes from the default", Access: accessUser,
+		Response: envelope{"presets": []store.StrategyPreset{}}},
+	{Method: "POST", Path: "/api/strategies/from-preset/{name}", Tag: "Strategies", Summary: "Create a strategy from a preset", Access: accessUser,
+		Body: fromPresetRequest{}, Response: &store.Strategy{}, Errors: []int{400, 404}},
+	{Method: "POST", Path: "/api/strategies/recommend-pairs", Tag: "Strategies", Summary: "Ask the AI for trading pairs", Access: accessUser,
+		Body: envelope{"count": 0, "turbo": false}, Response: envelope{"pairs": []string{}, "rejected": map[string]string{}}},
+
+	// Traders
+	{Method: "GET", Path: "/api/traders", Tag: "Traders", Summary: "List traders with their running state", Access: accessUser,
+		Query: []apiParam{includeArchivedParam}, Response: envelope{"traders": []traderListItem{}}},
+	{Method: "POST", Path: "/api/traders", Tag: "Traders", Summary: "Create a trader", Access: accessUser,
+		Body: &store.Trader{}, Response: &store.Trader{}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/traders/status", Tag: "Traders", Summary: "Running state, equity, open positions, last cycle and last error of each trader", Access: accessUser,
+		Query: []apiParam{includeArchivedParam}, Response: envelope{"traders": map[string]trader.Summary{}}},
+	{Method: "POST", Path: "/api/traders/bulk", Tag: "Traders", Summary: "Start or stop several traders concurrently, with a result per ID", Access: accessUser,
+		Body: envelope{"action": "", "ids": []string{}, "confirm_mainnet": false}, Response: envelope{"results": map[string]bulkTraderResult{}, "audit_id": int64(0)}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/traders/{id}", Tag: "Traders", Summary: "Get a trader", Access: accessUser, Response: &store.Trader{}, Errors: []int{404}},
+	{Method: "PUT", Path: "/api/traders/{id}", Tag: "Traders", Summary: "Update a trader", Access: accessUser,
+		Body: &store.Trader{}, Response: &store.Trader{}, Errors: []int{400, 404}},
+	{Method: "DELETE", Path: "/api/traders/{id}", Tag: "Traders", Summary: "Archive a trader like /archive, or with purge delete it and all its history, 409 while it is running", Access: accessUser,
+		Query:    []apiParam{{Name: "purge", Type: "boolean", Description: "Delete the trader with its decisions, positions, orders, equity history and audit rows instead of archiving it"}},
+		Response: stopResult, Errors: []int{404, 409}},
+	{Method: "POST", Path: "/api/traders/{id}/start", Tag: "Traders", Summary: "Start a trader, 409 while trading is halted, it is archived, or it would run a turbo mode strategy on mainnet unconfirmed. Status already_running if it was.", Access: accessUser,
+		Body: &startRequest{}, Response: auditStatusResult, Errors: []int{400, 404, 409, 422, 500}},
+	{Method: "POST", Path: "/api/traders/{id}/stop", Tag: "Traders", Summary: "Stop a trader under its shutdown policy and report what it did. Status already_stopped if it wasn't running.", Access: accessUser,
+		Response: stopResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/traders/{id}/archive", Tag: "Traders", Summary: "Stop a running trader under its shutdown policy and archive it: hidden from lists and not startable, its history kept. Status already_archived if it was.", Access: accessUser,
+		Response: stopResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/traders/{id}/unarchive", Tag: "Traders", Summary: "Bring an archived trader back, stopped. Status not_archived if it wasn't archived.", Access: accessUser,
+		Response: auditStatusResult, Errors: []int{404}},
+	{Method: "GET", Path: "/api/traders/{id}/overview", Tag: "Traders", Summary: "Account, positions, stats and risk headroom", Access: accessUser,
+		Response: &traderOverview{}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/traders/{id}/exchange-info", Tag: "Traders", Summary: "The network the trader's exchange client points at, its account balances and API key permissions, and any problem that would keep it from trading", Access: accessUser,
+		Response: &trader.ExchangeInfo{}, Errors: []int{404, 502}},
+	{Method: "POST", Path: "/api/traders/{id}/acknowledge-circuit-breaker", Tag: "Traders", Summary: "Clear a tripped circuit breaker so the trader opens positions again, 409 if it isn't tripped", Access: accessUser,
+		Response: envelope{"status": "", "tripped": &store.CircuitBreakerTrip{}, "audit_id": int64(0)}, Errors: []int{404, 409}},
+	{Method: "GET", Path: "/api/traders/{id}/report", Tag: "Traders", Summary: "Daily or weekly P&L report", Access: accessUser,
+		Query: []apiParam{
+			{Name: "period", Description: "daily (default) or weekly"},
+			{Name: "date", Description: "YYYY-MM-DD, today by default"},
+			{Name: "format", Description: "json (default), text or markdown"},
+		},
+		Response: &report.Report{}, Produces: []string{"text/plain", "text/markdown"}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/traders/{id}/experiments", Tag: "Traders", Summary: "Prompt experiment results per variant: cycles, decision counts, AI failures, win rate and P&L", Access: accessUser,
+		Response: envelope{"enabled": false, "assignment": "", "variants": []*store.VariantStats{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/traders/{id}/latency-report", Tag: "Traders", Summary: "By model, p50/p95 time from a decision's market data to its order, and the average price move and move against the decision meanwhile", Access: accessUser,
+		Query:    []apiParam{{Name: "days", Type: "integer", Description: "Default 7"}},
+		Response: envelope{"days": 0, "max_decision_staleness_secs": 0, "models": []*store.ModelLatency{}}, Errors: []int{400, 404}},
+	{Method: "POST", Path: "/api/traders/{id}/annotations", Tag: "Traders", Summary: "Add a note to the trader's equity curve", Access: accessUser,
+		Body: annotationRequest{}, Response: envelope{"annotation": &store.Annotation{}, "audit_id": int64(0)}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/traders/{id}/decisions/blocked", Tag: "Traders", Summary: "Decisions a validator or risk rule kept from executing, newest first", Access: accessUser,
+		Query:    []apiParam{{Name: "limit", Type: "integer", Description: "Default 50, at most 500"}},
+		Response: envelope{"blocked": []*store.BlockedDecision{}}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/traders/{id}/decisions/{decision_id}/raw", Tag: "Traders", Summary: "A decision with the full prompts and responses", Access: accessUser,
+		Response: envelope{"decision": &store.Decision{}, "ai_calls": []*store.AICall{}}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/traders/{id}/smart-find", Tag: "Traders", Summary: "Last Smart Find run and history", Access: accessUser,
+		Query:    []apiParam{{Name: "limit", Type: "integer"}},
+		Response: envelope{"last_run": &store.SmartFindRun{}, "runs": []*store.SmartFindRun{}}, Errors: []int{400, 404}},
+	{Method: "POST", Path: "/api/traders/{id}/smart-find/refresh", Tag: "Traders", Summary: "Run Smart Find now", Access: accessUser,
+		Response: envelope{"run": &store.SmartFindRun{}, "audit_id": int64(0)}, Errors: []int{404, 409, 429, 502}},
+	{Method: "GET", Path: "/api/traders/{id}/coins", Tag: "Traders", Summary: "Pinned and banned symbols and, while running, the coin universe with each symbol's origin", Access: accessUser,
+		Response: envelope{"running": true, "coins": []trader.CoinCandidate{}, "pinned": []string{}, "banned": []string{}}, Errors: []int{404}},
+	{Method: "POST", Path: "/api/traders/{id}/coins/pin", Tag: "Traders", Summary: "Always analyze a symbol, whatever the coin source", Access: accessUser,
+		Body:     envelope{"symbol": ""},
+		Response: envelope{"override": &store.CoinOverride{}, "audit_id": int64(0)}, Errors: []int{400, 404}},
+	{Method: "POST", Path: "/api/traders/{id}/coins/ban", Tag: "Traders", Summary: "Never analyze a symbol, even when the coin source lists it", Access: accessUser,
+		Body:     envelope{"symbol": ""},
+		Response: envelope{"override": &store.CoinOverride{}, "audit_id": int64(0)}, Errors: []int{400, 404}},
+	{Method: "DELETE", Path: "/api/traders/{id}/coins/{symbol}", Tag: "Traders", Summary: "Clear a symbol's pin or ban", Access: accessUser,
+		Response: envelope{"symbol": "", "audit_id": int64(0)}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/traders/{id}/orders", Tag: "Traders", Summary: "Open orders, limit and SL/TP, grouped by symbol", Access: accessUser,
+		Response: envelope{"orders": map[string][]trader.OpenOrder{}}, Errors: []int{404, 409, 502}},
+	{Method: "DELETE", Path: "/api/traders/{id}/orders/{order_id}", Tag: "Traders", Summary: "Cancel an open order, by AlgoID for SL/TP", Access: accessUser,
+		Response: envelope{"order": &trader.OpenOrder{}, "audit_id": int64(0)}, Errors: []int{400, 404, 409, 422, 502}},
+	{Method: "GET", Path: "/api/traders/{id}/positions", Tag: "Traders", Summary: "Closed positions, newest first, or open ones", Access: accessUser,
+		Query: []apiParam{
+			{Name: "status", Description: "closed (default) or open"},
+			{Name: "limit", Type: "integer", Description: "Default 100, ignored for open positions"},
+		},
+		Response: envelope{"positions": []store.TraderPosition{}}, Errors: []int{400, 404}},
+	{Method: "PUT", Path: "/api/traders/{id}/positions/{symbol}/stops", Tag: "Traders", Summary: "Replace a position's SL/TP. A level left out is kept, 0 removes it.", Access: accessUser,
+		Body:     envelope{"stop_loss": 0.0, "take_profit": 0.0},
+		Response: envelope{"stops": &trader.StopsUpdate{}, "audit_id": int64(0)}, Errors: []int{400, 404, 409, 422, 502}},
+	{Method: "GET", Path: "/api/traders/{id}/positions/{symbol}/events", Tag: "Traders", Summary: "Fills, SL/TP moves and risk rule activations of a symbol's positions", Access: accessUser,
+		Query: []apiParam{
+			{Name: "position_id", Type: "integer", Description: "Events of one position, oldest first; without it the symbol's latest events, newest first"},
+			{Name: "limit", Type: "integer", Description: "Default 100, ignored with position_id"},
+		},
+		Response: envelope{"events": []*store.PositionEvent{}}, Errors: []int{400, 404}},
+	{Method: "GET", Path: "/api/traders/{id}/positions/{position_id}", Tag: "Traders", Summary: "A position with its timeline of decisions, fills, SL/TP moves, risk rule activations and close", Access: accessUser,
+		Response: envelope{"position": &store.TraderPosition{}, "timeline": []positionTimelineEntry{}}, Errors: []int{400, 404}},
+
+	// Trader data
+	{Method: "GET", Path: "/api/status", Tag: "Data", Summary: "Engine status, or a stopped trader's positions on the exchange (live false)", Access: accessUser,
+		Query: []apiParam{traderIDParam}, Response: freeForm{}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/account", Tag: "Data", Summary: "Account balances, unrealized P&L and realized P&L and fees of closed positions, fetched from the exchange for a stopped trader (live false)", Access: accessUser,
+		Query: []apiParam{traderIDParam}, Response: freeForm{}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/positions", Tag: "Data", Summary: "Open positions, from the engine or for a stopped trader the exchange merged with its position rows", Access: accessUser,
+		Query: []apiParam{traderIDParam}, Response: envelope{"positions": []freeForm{}, "live": false, "error": ""}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/decisions", Tag: "Data", Summary: "Latest 50 decisions", Access: accessUser,
+		Query: []apiParam{traderIDParam}, Response: envelope{"decisions": []*store.Decision{}}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/trades", Tag: "Data", Summary: "Latest 500 trades and stats", Access: accessUser,
+		Query: []apiParam{traderIDParam}, Response: envelope{"trades": []*store.Trade{}, "stats": freeForm{}}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/equity-history", Tag: "Data", Summary: "Equity history, resolution by range", Access: accessUser,
+		Query: []apiParam{
+			traderIDParam,
+			{Name: "start", Type: "integer", Description: "Unix ms"},
+			{Name: "end", Type: "integer", Description: "Unix ms, now by default"},
+		},
+		Response: envelope{"history": []store.EquityPoint{}, "resolution": "", "transfers": []store.IncomeEntry{}, "annotations": []*store.Annotation{}}, Errors: []int{400}},
+
+	// Backtests
+	{Method: "GET", Path: "/api/backtest", Tag: "Backtests", Summary: "List backtest runs, newest first unless sorted otherwise, with the total matching", Access: accessUser,
+		Query: []apiParam{
+			{Name: "tag", Description: "A tag the run has, ignoring case"},
+			{Name: "status", Description: "pending, running, paused, completed, failed or liquidated"},
+			{Name: "symbol", Description: "A symbol the run trades"},
+			{Name: "q", Description: "Text in the name, description or tags"},
+			{Name: "from", Type: "integer", Description: "Unix ms, runs started at or after"},
+			{Name: "to", Type: "integer", Description: "Unix ms, runs started at or before"},
+			{Name: "sort", Description: "date (default), return (best first) or drawdown (smallest first)"},
+			{Name: "limit", Type: "integer", Description: "Default 50, at most 500"},
+			{Name: "offset", Type: "integer"},
+		},
+		Response: envelope{"backtests": []*backtest.RunMetadata{}, "total": 0}, Errors: []int{400}},
+	{Method: "POST", Path: "/api/backtest/start", Tag: "Backtests", Summary: "Start a backtest", Access: accessUser,
+		Query: []apiParam{{Name: "dry_run", Type: "boolean", Description: "Only validate and estimate the AI calls, start nothing"}},
+		Body:  &backtest.Config{}, Response: envelope{"run_id": "", "status": "", "estimate": &backtest.CostEstimate{}}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/backtest/{id}", Tag: "Backtests", Summary: "Run status", Access: accessUser, Response: &backtest.RunMetadata{}, Errors: []int{404}},
+	{Method: "PATCH", Path: "/api/backtest/{id}", Tag: "Backtests", Summary: "Rename, redescribe or retag a completed or liquidated run, 409 for any other", Access: accessUser,
+		Body: &backtest.RunUpdate{}, Response: envelope{"backtest": &backtest.RunMetadata{}, "audit_id": int64(0)}, Errors: []int{400, 404, 409}},
+	{Method: "DELETE", Path: "/api/backtest/{id}", Tag: "Backtests", Summary: "Delete a run", Access: accessUser, Response: auditStatusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/backtest/{id}/stop", Tag: "Backtests", Summary: "Stop a run", Access: accessUser, Response: auditStatusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/backtest/{id}/resume", Tag: "Backtests", Summary: "Resume a stopped or failed run from its last checkpoint", Access: accessUser,
+		Response: envelope{"run_id": "", "status": "", "next_bar": 0, "audit_id": ""}, Errors: []int{404, 409}},
+	{Method: "GET", Path: "/api/backtest/{id}/status", Tag: "Backtests", Summary: "Run status", Access: accessUser, Response: &backtest.RunMetadata{}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/metrics", Tag: "Backtests", Summary: "Performance metrics", Access: accessUser, Response: &backtest.Metrics{}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/equity", Tag: "Backtests", Summary: "Equity curve", Access: accessUser,
+		Response: envelope{"equity_curve": []backtest.EquityPoint{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/trades", Tag: "Backtests", Summary: "Simulated trades", Access: accessUser,
+		Response: envelope{"trades": []backtest.TradeEvent{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/positions", Tag: "Backtests", Summary: "Simulated trades paired into closed positions, in the shape of a trader's", Access: accessUser,
+		Response: envelope{"positions": []store.TraderPosition{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/decisions", Tag: "Backtests", Summary: "AI decisions", Access: accessUser,
+		Response: envelope{"decisions": []backtest.DecisionLog{}}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/backtest/{id}/comparison", Tag: "Backtests", Summary: "Comparison against buy and hold", Access: accessUser,
+		Response: &backtest.ComparisonReport{}, Errors: []int{404}},
+	{Method: "POST", Path: "/api/backtest/{id}/montecarlo", Tag: "Backtests", Summary: "Monte Carlo analysis of a finished run's trades, streamed with Accept: text/event-stream", Access: accessUser,
+		Body: &backtest.MonteCarloConfig{}, Response: envelope{"result": &backtest.MonteCarloResult{}, "audit_id": ""}, Produces: []string{"text/event-stream"}, Errors: []int{400, 404, 409}},
+	{Method: "GET", Path: "/api/backtest/{id}/montecarlo", Tag: "Backtests", Summary: "Latest Monte Carlo analysis", Access: accessUser,
+		Response: &backtest.MonteCarloResult{}, Errors: []int{404}},
+	{Method: "GET", Path: "/api/compare", Tag: "Backtests", Summary: "Win rate, hold time and P&L distribution of a backtest next to a live trader's over the run's range", Access: accessUser,
+		Query: []apiParam{
+			{Name: "backtest_id", Required: true},
+			{Name: "trader_id", Required: true},
+			{Name: "from", Type: "integer", Description: "Unix ms, narrows the run's range"},
+			{Name: "to", Type: "integer", Description: "Unix ms, narrows the run's range"},
+		},
+		Response: envelope{"from": int64(0), "to": int64(0), "backtest": &backtest.PositionSummary{}, "live": &backtest.PositionSummary{}, "diff": &compareDiff{}},
+		Errors:   []int{400, 403, 404}},
+
+	// Debates
+	{Method: "GET", Path: "/api/debate/sessions", Tag: "Debates", Summary: "List debate sessions", Access: accessUser,
+		Response: envelope{"sessions": []*debate.SessionWithDetails{}}},
+	{Method: "POST", Path: "/api/debate/sessions", Tag: "Debates", Summary: "Create a debate session and estimate its AI cost", Access: accessUser,
+		Body: &debate.CreateSessionRequest{}, Response: &debate.SessionWithDetails{}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/debate/sessions/{id}", Tag: "Debates", Summary: "Get a debate session", Access: accessUser,
+		Response: &debate.SessionWithDetails{}, Errors: []int{404}},
+	{Method: "DELETE", Path: "/api/debate/sessions/{id}", Tag: "Debates", Summary: "Stop a debate session", Access: accessUser, Response: auditStatusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/debate/sessions/{id}/start", Tag: "Debates", Summary: "Start a debate with live market data", Access: accessUser,
+		Response: auditStatusResult, Errors: []int{404}},
+	{Method: "POST", Path: "/api/debate/sessions/{id}/stop", Tag: "Debates", Summary: "Stop a debate", Access: accessUser, Response: auditStatusResult, Errors: []int{404}},
+	{Method: "GET", Path: "/api/debate/models", Tag: "Debates", Summary: "Models participants can use", Access: accessUser,
+		Response: envelope{"models": []mcp.ModelInfo{}, "stats": map[string]mcp.ModelStat{}}, Errors: []int{501, 502}},
+	{Method: "GET", Path: "/api/debate/personalities", Tag: "Debates", Summary: "Built-in participant personalities and custom role limits", Access: accessUser,
+		Response: envelope{"personalities": []debate.PersonalityInfo{}, "limits": map[string]int{}}},
+
+	// Administration
+	{Method: "GET", Path: "/api/settings", Tag: "Admin", Summary: "Global settings, keys masked", Access: accessAdmin,
+		Response: envelope{"settings": &store.GlobalSettings{}, "configured": map[string]bool{}}},
+	{Method: "PUT", Path: "/api/settings", Tag: "Admin", Summary: "Save global settings, masked keys are kept", Access: accessAdmin,
+		Body: &store.GlobalSettings{}, Response: statusResult, Errors: []int{400}},
+	{Method: "POST", Path: "/api/emergency/stop-all", Tag: "Admin", Summary: "Halt trading, stop every trader and cancel orders", Access: accessAdmin,
+		Body:     envelope{"flatten": false},
+		Response: envelope{"status": "", "halt": &store.HaltState{}, "actions": []trader.EmergencyAction{}, "audit_id": int64(0)}, Errors: []int{400}},
+	{Method: "POST", Path: "/api/emergency/resume", Tag: "Admin", Summary: "Lift the halt", Access: accessAdmin, Response: auditStatusResult},
+	{Method: "GET", Path: "/api/audit", Tag: "Admin", Summary: "Mutating requests, admins see all users", Access: accessUser,
+		Query: []apiParam{
+			{Name: "limit", Type: "integer", Description: "Default 100, at most 1000"},
+			{Name: "since", Description: "RFC3339 or unix seconds"},
+		},
+		Response: envelope{"entries": []*store.AuditEntry{}}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/ai-calls/models", Tag: "Admin", Summary: "Live AI calls by model with how often a response needed a correction retry", Access: accessAdmin,
+		Query:    []apiParam{{Name: "days", Type: "integer", Description: "Default 7"}},
+		Response: envelope{"days": 0, "models": []*store.AIModelStats{}}, Errors: []int{400}},
+	{Method: "GET", Path: "/api/metrics", Tag: "Admin", Summary: "Each AI provider's call queue (depth, calls in flight, wait times, calls that timed out waiting) and each running trader's orders aborted as stale", Access: accessAdmin,
+		Response: envelope{"ai_schedulers": []mcp.SchedulerStats{}, "stale_decisions": map[string]trader.StaleDecisionStats{}}},
+
+	// Streams
+	{Method: "GET", Path: "/api/events", Tag: "Streams", Summary: "Server-sent trade, decision and error events of the caller's traders", Access: accessUser, Produces: []string{"text/event-stream"}},
+	{Method: "GET", Path: "/api/ws", Tag: "Streams", Summary: "WebSocket pushing the events of subscribed trader:<id>:positions|equity|lifecycle and backtest:<id>:progress topics", Access: accessUser,
+		Query: []apiParam{{Name: "access_key", Description: "Access key, for browsers that can't set X-Access-Key on the handshake"}}, Errors: []int{400, 403}},
+	{Method: "GET", Path: "/api/logs/stream", Tag: "Streams", Summary: "Server-sent log lines", Access: accessAdmin, Produces: []string{"text/event-stream"}},
+}
+
+// traderListItem is a trader as listed by GET /api/traders
+type traderListItem struct {
+	store.Trader
+	IsRunning bool   `json:"is_running"`
+	Network   string `json:"network"` // mainnet, testnet or custom, from the exchange client's base URL
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+// handleOpenAPI serves the OpenAPI 3 document for this API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, _ = json.MarshalIndent(openAPISpec(), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON)
+}
+
+// handleDocs serves Swagger UI for the document, loaded from a CDN
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Passive Income Ahh API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPISpec builds the document from apiOperations
+func openAPISpec() map[string]interface{} {
+	schemas := &schemaRegistry{components: map[string]interface{}{}}
+	schemas.components["Error"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{"error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"code":       map[string]interface{}{"type": "string", "description": "Stable code to branch on, e.g. TRADER_NOT_FOUND"},
+				"message":    map[string]interface{}{"type": "string", "description": "Human-readable, may change"},
+				"details":    map[string]interface{}{"type": "object", "description": "Code-specific, e.g. the field of UNKNOWN_FIELD"},
+				"request_id": map[string]interface{}{"type": "string", "description": "Same as X-Request-ID, for matching server logs"},
+			},
+			"required": []string{"code", "message"},
+		}},
+		"required": []string{"error"},
+	}
+
+	paths := map[string]map[string]interface{}{}
+	for _, op := range apiOperations {
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]interface{}{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = op.document(schemas)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Passive Income Ahh API",
+			"version":     "1.0",
+			"description": "Errors are returned as {\"error\": {\"code\", \"message\", \"details\", \"request_id\"}} with a 4xx or 5xx status.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"accessKey": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Access-Key",
+					"description": "ACCESS_PASSKEY (admin) or a user API key. Not required while neither is configured.",
+				},
+				"accessKeyQuery": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "query",
+					"name":        "access_key",
+					"description": "Same as X-Access-Key, for EventSource clients that can't set headers",
+				},
+			},
+		},
+		"security": []map[string][]string{{"accessKey": {}}, {"accessKeyQuery": {}}},
+	}
+}
+
+// document renders one OpenAPI operation object
+func (op apiOperation) document(schemas *schemaRegistry) map[string]interface{} {
+	doc := map[string]interface{}{
+		"tags":    []string{op.Tag},
+		"summary": op.Summary,
+	}
+	if op.Access == accessPublic {
+		doc["security"] = []interface{}{}
+	}
+	if op.Access == accessAdmin {
+		doc["description"] = "Admin only."
+	}
+
+	var params []map[string]interface{}
+	for _, m := range pathParamRe.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, map[string]interface{}{
+			"name": m[1], "in": "path", "required": true,
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range op.Query {
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		param := map[string]interface{}{
+			"name": p.Name, "in": "query", "required": p.Required,
+			"schema": map[string]interface{}{"type": typ},
+		}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	idempotent := op.Access != accessPublic && isMutating(op.Method)
+	if idempotent {
+		params = append(params, map[string]interface{}{
+			"name": idempotencyKeyHeader, "in": "header", "required": false,
+			"schema":      map[string]interface{}{"type": "string", "maxLength": idempotencyKeyMaxLen},
+			"description": idempotencyKeyDescription,
+		})
+	}
+	if params != nil {
+		doc["parameters"] = params
+	}
+
+	if op.Body != nil {
+		doc["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.valueSchema(op.Body)},
+			},
+		}
+	}
+
+	content := map[string]interface{}{}
+	if op.Response != nil {
+		content["application/json"] = map[string]interface{}{"schema": schemas.valueSchema(op.Response)}
+	}
+	for _, ct := range op.Produces {
+		content[ct] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+	}
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{"description": "OK", "content": content},
+	}
+
+	statuses := append([]int{}, op.Errors...)
+	if idempotent && !containsStatus(statuses, 409) {
+		statuses = append(statuses, 409)
+	}
+	switch op.Access {
+	case accessUser:
+		statuses = append(statuses, 401, 429)
+	case accessAdmin:
+		statuses = append(statuses, 401, 403, 429)
+	}
+	for _, status := range statuses {
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"}},
+			},
+		}
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+// idempotencyKeyDescription documents the Idempotency-Key header
+const idempotencyKeyDescription = "Makes a retry safe. The response to the first request with a key is " +
+	"saved for IDEMPOTENCY_TTL_HOURS and returned, with Idempotent-Replayed: true, to retries of the same " +
+	"request. Reusing the key for a different request, or while the first is running, is a 409. " +
+	"Server errors aren't saved."
+
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ============ SCHEMAS ============
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry turns Go types into JSON schemas, following their JSON tags.
+// Named structs become components referenced by package and type name.
+type schemaRegistry struct {
+	components map[string]interface{}
+}
+
+// valueSchema is the schema of an example value, an envelope or a freeForm
+func (g *schemaRegistry) valueSchema(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case envelope:
+		props := map[string]interface{}{}
+		for name, example := range v {
+			props[name] = g.valueSchema(example)
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	case freeForm:
+		return map[string]interface{}{"type": "object", "additionalProperties": true}
+	case nil:
+		return map[string]interface{}{}
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice && t.Elem() == reflect.TypeOf(freeForm{}) {
+		return map[string]interface{}{"type": "array", "items": g.valueSchema(freeForm{})}
+	}
+	return g.typeSchema(t)
+}
+
+func (g *schemaRegistry) typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.components[name]; !ok {
+			g.components[name] = map[string]interface{}{} // Placeholder for recursive types
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema lists a struct's JSON properties, flattening embedded structs
+func (g *schemaRegistry) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	g.addFields(t, props)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func (g *schemaRegistry) addFields(t reflect.Type, props map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(ft, props)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if strings.Contains(opts, "string") {
+			props[name] = map[string]interface{}{"type": "string"}
+		} else {
+			props[name] = g.typeSchema(f.Type)
+		}
+	}
+}
+
+// schemaName qualifies a type with its package, so store.EquityPoint and
+// backtest.EquityPoint don't collide
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + t.Name()
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/events"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	mux := (&Server{hub: events.NewHub()}).routes()
+	paths := openAPISpec()["paths"].(map[string]map[string]interface{})
+
+	// Every registered route is documented
+	for _, pattern := range mux.patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		if _, ok := paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("route %s is missing from apiOperations", pattern)
+		}
+	}
+
+	// And every documented operation reaches its own route
+	for _, op := range apiOperations {
+		concrete := pathParamRe.ReplaceAllString(op.Path, "x")
+		pattern := mux.match(httptest.NewRequest(op.Method, concrete, nil))
+		if want := op.Method + " " + op.Path; pattern != want {
+			t.Errorf("documented operation %s routes to %q", want, pattern)
+		}
+	}
+}
+
+func TestOpenAPIRefsResolve(t *testing.T) {
+	spec := openAPISpec()
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, m := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(data), -1) {
+		if _, ok := schemas[m[1]]; !ok {
+			t.Errorf("unresolved schema ref %s", m[1])
+		}
+	}
+
+	// Struct schemas follow JSON tags
+	trader, ok := schemas["store.Trader"].(map[string]interface{})
+	if !ok {
+		t.Fatal("store.Trader schema missing")
+	}
+	props := trader["properties"].(map[string]interface{})
+	if _, ok := props["initial_balance"]; !ok {
+		t.Errorf("store.Trader properties = %v", props)
+	}
+	if _, ok := schemas["store.EquityPoint"]; !ok {
+		t.Error("store.EquityPoint collided with backtest.EquityPoint")
+	}
+}
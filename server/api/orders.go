@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ ORDER ENDPOINTS ============
+
+// handleTraderOrders lists a running trader's open orders, regular and SL/TP
+// algo orders, grouped by symbol
+func (s *Server) handleTraderOrders(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	if !s.engineManager.IsRunning(t.ID) {
+		s.errorResponse(w, r, http.StatusConflict, codeTraderNotRunning, "Trader is not running")
+		return
+	}
+
+	orders, err := s.engineManager.OpenOrders(r.Context(), t.ID)
+	if err != nil {
+		s.upstreamError(w, r, codeExchangeUnavailable, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"orders": orders})
+}
+
+// handleCancelTraderOrder cancels one open order by its ID, the AlgoID for
+// SL/TP orders
+func (s *Server) handleCancelTraderOrder(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	orderID, err := strconv.ParseInt(r.PathValue("order_id"), 10, 64)
+	if err != nil || orderID <= 0 {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid order ID")
+		return
+	}
+	if !s.engineManager.IsRunning(t.ID) {
+		s.errorResponse(w, r, http.StatusConflict, codeTraderNotRunning, "Trader is not running")
+		return
+	}
+
+	// Finish the cancel and its bookkeeping even if the client leaves
+	order, err := s.engineManager.CancelOrder(context.Background(), t.ID, orderID)
+	if errors.Is(err, trader.ErrOrderNotFound) {
+		s.errorResponse(w, r, http.StatusNotFound, codeOrderNotFound, "Order not found among the trader's open orders")
+		return
+	}
+	if err != nil {
+		s.upstreamError(w, r, codeExchangeUnavailable, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"order": order, "audit_id": s.recordAudit(r)})
+}
+
+// handleSetPositionStops replaces the SL/TP of a position. A level left out
+// keeps its current order, 0 removes it. Every cancel and placement is written
+// to the audit log.
+func (s *Server) handleSetPositionStops(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	var req struct {
+		StopLoss   *float64 `json:"stop_loss"`
+		TakeProfit *float64 `json:"take_profit"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.StopLoss == nil && req.TakeProfit == nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "stop_loss or take_profit required")
+		return
+	}
+	if (req.StopLoss != nil && *req.StopLoss < 0) || (req.TakeProfit != nil && *req.TakeProfit < 0) {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "stop_loss and take_profit can't be negative")
+		return
+	}
+	if !s.engineManager.IsRunning(t.ID) {
+		s.errorResponse(w, r, http.StatusConflict, codeTraderNotRunning, "Trader is not running")
+		return
+	}
+
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	// Don't leave a position between the cancel and the new stops if the client leaves
+	update, err := s.engineManager.SetStops(context.Background(), t.ID, symbol, req.StopLoss, req.TakeProfit)
+	if update != nil {
+		s.recordAuditActions(r, update.Actions)
+	}
+	switch {
+	case errors.Is(err, trader.ErrNoPosition):
+		s.errorResponse(w, r, http.StatusNotFound, codePositionNotFound, "No open position on "+symbol)
+		return
+	case errors.Is(err, trader.ErrInvalidStops):
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		s.upstreamError(w, r, codeExchangeUnavailable, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"stops": update, "audit_id": s.recordAudit(r)})
+}
+
+// handlePositionEvents returns the SL/TP moves recorded on a symbol, for one
+// position when position_id is given. Works whether or not the trader runs.
+func (s *Server) handlePositionEvents(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	query := r.URL.Query()
+
+	var events []*store.PositionEvent
+	var err error
+	if v := query.Get("position_id"); v != "" {
+		positionID, perr := strconv.ParseInt(v, 10, 64)
+		if perr != nil || positionID <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid position_id")
+			return
+		}
+		events, err = s.posEventStore.ListByPosition(t.ID, positionID)
+	} else {
+		limit := 100
+		if v := query.Get("limit"); v != "" {
+			n, perr := strconv.Atoi(v)
+			if perr != nil || n <= 0 {
+				s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid limit")
+				return
+			}
+			limit = n
+		}
+		events, err = s.posEventStore.ListBySymbol(t.ID, symbol, limit)
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if events == nil {
+		events = []*store.PositionEvent{}
+	}
+	s.jsonResponse(w, map[string]interface{}{"events": events})
+}
+
+// positionTimelineEntry is one step in a position's life: a decision that
+// acted on it, or one of its position events
+type positionTimelineEntry struct {
+	Timestamp  time.Time            `json:"timestamp"`
+	Type       string               `json:"type"` // "decision" or the event type
+	DecisionID int64                `json:"decision_id,omitempty"`
+	Action     string               `json:"action,omitempty"`
+	Confidence float64              `json:"confidence,omitempty"`
+	Reasoning  string               `json:"reasoning,omitempty"`
+	Event      *store.PositionEvent `json:"event,omitempty"`
+}
+
+// handlePositionDetail returns a position with its timeline: the decisions
+// that opened and managed it, its fills, SL/TP moves, the risk rules that
+// took it on and its close
+func (s *Server) handlePositionDetail(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	positionID, err := strconv.ParseInt(r.PathValue("position_id"), 10, 64)
+	if err != nil || positionID <= 0 {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid position ID")
+		return
+	}
+
+	pos, err := s.positionStore.Get(t.ID, positionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.errorResponse(w, r, http.StatusNotFound, codePositionNotFound, "Position not found")
+		return
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	decisions, err := s.decisionStore.ListByPosition(t.ID, positionID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	events, err := s.posEventStore.ListByPosition(t.ID, positionID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"position": pos,
+		"timeline": positionTimeline(pos, decisions, events),
+	})
+}
+
+// positionTimeline merges a position's decisions and events, oldest first.
+// Positions recorded before events were kept get their open and close from
+// the position row.
+func positionTimeline(pos *store.TraderPosition, decisions []*store.Decision, events []*store.PositionEvent) []positionTimelineEntry {
+	timeline := []positionTimelineEntry{}
+
+	for _, d := range decisions {
+		var entries []struct {
+			Action     string    `json:"action"`
+			Confidence float64   `json:"confidence"`
+			Reasoning  string    `json:"reasoning"`
+			PositionID int64     `json:"position_id"`
+			Timestamp  time.Time `json:"timestamp"`
+		}
+		if err := json.Unmarshal([]byte(d.Decisions), &entries); err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.PositionID != pos.ID {
+				continue
+			}
+			// Cycle entries carry their analysis time, the record is saved after the trades
+			at := entry.Timestamp
+			if at.IsZero() {
+				at = d.Timestamp
+			}
+			timeline = append(timeline, positionTimelineEntry{
+				Timestamp:  at,
+				Type:       "decision",
+				DecisionID: d.ID,
+				Action:     entry.Action,
+				Confidence: entry.Confidence,
+				Reasoning:  entry.Reasoning,
+			})
+		}
+	}
+
+	opened, closed := false, false
+	for _, e := range events {
+		opened = opened || e.Type == store.PositionEventOpened
+		closed = closed || e.Type == store.PositionEventClosed
+		timeline = append(timeline, positionTimelineEntry{Timestamp: e.Timestamp, Type: e.Type, Event: e})
+	}
+	if !opened {
+		timeline = append(timeline, positionTimelineEntry{Timestamp: pos.EntryTime, Type: store.PositionEventOpened, Event: &store.PositionEvent{
+			TraderID: pos.TraderID, PositionID: pos.ID, Symbol: pos.Symbol, Side: pos.Side, Timestamp: pos.EntryTime,
+			Type: store.PositionEventOpened, NewPrice: pos.EntryPrice, Quantity: pos.EntryQuantity, Source: pos.Source,
+		}})
+	}
+	if !closed && pos.Status == store.PositionStatusClosed {
+		timeline = append(timeline, positionTimelineEntry{Timestamp: pos.ExitTime, Type: store.PositionEventClosed, Event: &store.PositionEvent{
+			TraderID: pos.TraderID, PositionID: pos.ID, Symbol: pos.Symbol, Side: pos.Side, Timestamp: pos.ExitTime,
+			Type: store.PositionEventClosed, NewPrice: pos.ExitPrice, Quantity: pos.EntryQuantity,
+			RealizedPnL: pos.RealizedPnL, Fee: pos.Fee, Source: pos.Source, Reason: pos.CloseReason,
+		}})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].Timestamp.Before(timeline[j].Timestamp) })
+	return timeline
+}
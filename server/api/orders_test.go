@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+func TestPositionTimeline(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	at := func(mins int) time.Time { return start.Add(time.Duration(mins) * time.Minute) }
+
+	pos := &store.TraderPosition{ID: 7, Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, EntryQuantity: 1, EntryTime: at(0),
+		Status: store.PositionStatusClosed, ExitPrice: 110, ExitTime: at(30), RealizedPnL: 10, CloseReason: "ai_close", Source: store.PositionSourceSystem}
+	decisions := []*store.Decision{
+		// The record is saved at the end of the cycle, after the open
+		{ID: 1, Timestamp: at(1), Decisions: `[{"symbol":"ETHUSDT","action":"HOLD"},` +
+			`{"symbol":"BTCUSDT","action":"BUY","confidence":80,"reasoning":"breakout","position_id":7,"timestamp":"` + at(-1).Format(time.RFC3339) + `"}]`},
+		{ID: 2, Timestamp: at(20), Decisions: `[{"symbol":"BTCUSDT","action":"MOVE_STOP","confidence":70,"position_id":7}]`},
+	}
+	events := []*store.PositionEvent{
+		{PositionID: 7, Timestamp: at(0), Type: store.PositionEventOpened, NewPrice: 100, Quantity: 1},
+		{PositionID: 7, Timestamp: at(10), Type: store.PositionEventTrailingStopActivated, NewPrice: 105},
+		{PositionID: 7, Timestamp: at(20), Type: store.PositionEventStopLossMoved, OldPrice: 95, NewPrice: 102},
+	}
+
+	timeline := positionTimeline(pos, decisions, events)
+	want := []string{"decision", store.PositionEventOpened, store.PositionEventTrailingStopActivated,
+		"decision", store.PositionEventStopLossMoved, store.PositionEventClosed}
+	if len(timeline) != len(want) {
+		t.Fatalf("timeline has %d entries, want %d: %+v", len(timeline), len(want), timeline)
+	}
+	for i, entry := range timeline {
+		if entry.Type != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, entry.Type, want[i])
+		}
+	}
+	if first := timeline[0]; first.DecisionID != 1 || first.Action != "BUY" || first.Confidence != 80 || first.Reasoning != "breakout" {
+		t.Errorf("opening decision = %+v", first)
+	}
+	// No closed event was recorded, so it comes from the row
+	if closed := timeline[5].Event; closed == nil || closed.NewPrice != 110 || closed.RealizedPnL != 10 || closed.Reason != "ai_close" {
+		t.Errorf("close = %+v", closed)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ TRADER OVERVIEW ============
+
+// overviewTTL is how long an overview is served from cache. Dashboards poll
+// it, and the engine state behind it only changes once per cycle or risk check.
+const overviewTTL = 5 * time.Second
+
+// traderOverview is everything the dashboard shows for one trader
+type traderOverview struct {
+	TraderID       string                        `json:"trader_id"`
+	Name           string                        `json:"name"`
+	Status         string                        `json:"status"`
+	StrategyID     string                        `json:"strategy_id"`
+	Running        bool                          `json:"running"`
+	Network        string                        `json:"network"` // mainnet, testnet or custom, from the exchange client's base URL
+	Account        *trader.OverviewAccount       `json:"account"` // nil when not running
+	Positions      []trader.OverviewPosition     `json:"positions"`
+	Stats          *store.TraderStats            `json:"stats"`
+	Realized       store.RealizedTotals          `json:"realized"`
+	Daily          trader.OverviewDaily          `json:"daily"`
+	Margin         trader.OverviewMargin         `json:"margin"`
+	Cycle          trader.OverviewCycle          `json:"cycle"`
+	CircuitBreaker trader.OverviewCircuitBreaker `json:"circuit_breaker"`
+	LastDecision   *overviewDecision             `json:"last_decision"`
+	GeneratedAt    time.Time                     `json:"generated_at"`
+}
+
+// overviewDecision summarizes the latest decision record
+type overviewDecision struct {
+	ID        int64                    `json:"id"`
+	Timestamp time.Time                `json:"timestamp"`
+	Executed  bool                     `json:"executed"`
+	Actions   []overviewDecisionAction `json:"actions"`
+}
+
+type overviewDecisionAction struct {
+	Symbol     string  `json:"symbol"`
+	Action     string  `json:"action"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// overviewCache holds recently built overviews by trader ID
+type overviewCache struct {
+	mu      sync.Mutex
+	entries map[string]*traderOverview
+}
+
+func newOverviewCache() *overviewCache {
+	return &overviewCache{entries: make(map[string]*traderOverview)}
+}
+
+func (c *overviewCache) get(traderID string) *traderOverview {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if o, ok := c.entries[traderID]; ok && time.Since(o.GeneratedAt) < overviewTTL {
+		return o
+	}
+	return nil
+}
+
+func (c *overviewCache) put(o *traderOverview) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cached := range c.entries {
+		if time.Since(cached.GeneratedAt) >= overviewTTL {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[o.TraderID] = o
+}
+
+func (c *overviewCache) drop(traderID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, traderID)
+}
+
+// handleTraderOverview serves GET /api/traders/{id}/overview
+func (s *Server) handleTraderOverview(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	if cached := s.overviews.get(t.ID); cached != nil {
+		s.jsonResponse(w, cached)
+		return
+	}
+
+	o, err := s.buildOverview(t)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.overviews.put(o)
+	s.jsonResponse(w, o)
+}
+
+// handleTraderExchangeInfo serves GET /api/traders/{id}/exchange-info, the
+// account's balances and what its API key allows, with any problem that
+// would keep the trader from trading
+func (s *Server) handleTraderExchangeInfo(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	info, err := s.engineManager.ExchangeInfo(r.Context(), t)
+	if err != nil {
+		s.upstreamError(w, r, codeExchangeUnavailable, err)
+		return
+	}
+	s.jsonResponse(w, info)
+}
+
+// handleAcknowledgeCircuitBreaker serves POST /api/traders/{id}/acknowledge-circuit-breaker,
+// letting a trader whose circuit breaker tripped open positions again
+func (s *Server) handleAcknowledgeCircuitBreaker(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	trip, err := s.engineManager.AcknowledgeCircuitBreaker(t.ID)
+	if errors.Is(err, trader.ErrCircuitBreakerNotTripped) {
+		s.errorResponse(w, r, http.StatusConflict, codeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.overviews.drop(t.ID)
+	s.jsonResponse(w, map[string]interface{}{"status": "acknowledged", "tripped": trip, "audit_id": s.recordAudit(r)})
+}
+
+func (s *Server) buildOverview(t *store.Trader) (*traderOverview, error) {
+	now := time.Now()
+	o := &traderOverview{
+		TraderID:    t.ID,
+		Name:        t.Name,
+		Status:      t.Status,
+		StrategyID:  t.StrategyID,
+		Network:     s.engineManager.ExchangeNetwork(t),
+		Positions:   []trader.OverviewPosition{},
+		GeneratedAt: now,
+	}
+
+	if live := s.engineManager.GetOverview(t.ID); live != nil {
+		o.Running = true
+		o.Account = live.Account
+		o.Positions = live.Positions
+		o.Daily = live.Daily
+		o.Margin = live.Margin
+		o.Cycle = live.Cycle
+		o.CircuitBreaker = live.CircuitBreaker
+	} else {
+		// No engine day window, count today's closes from local midnight
+		y, m, d := now.Date()
+		o.Daily.Since = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+		breaker, err := s.engineManager.SavedCircuitBreaker(t)
+		if err != nil {
+			return nil, err
+		}
+		o.CircuitBreaker = breaker
+	}
+
+	stats, err := s.positionStore.GetFullStats(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	o.Stats = stats
+
+	o.Realized, err = s.positionStore.GetRealizedTotals(t.ID, s.reports.TradingDay(t).Start(now))
+	if err != nil {
+		return nil, err
+	}
+
+	o.Daily.RealizedPnL, o.Daily.ClosedTrades, err = s.positionStore.GetRealizedPnLSince(t.ID, o.Daily.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := s.decisionStore.ListByTrader(t.ID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(decisions) > 0 {
+		o.LastDecision = summarizeDecision(decisions[0])
+	}
+	return o, nil
+}
+
+// summarizeDecision keeps the per-symbol action of a decision record,
+// dropping reasoning and order details
+func summarizeDecision(d *store.Decision) *overviewDecision {
+	summary := &overviewDecision{
+		ID:        d.ID,
+		Timestamp: d.Timestamp,
+		Executed:  d.Executed,
+		Actions:   []overviewDecisionAction{},
+	}
+	if d.Decisions != "" {
+		if err := json.Unmarshal([]byte(d.Decisions), &summary.Actions); err != nil {
+			summary.Actions = []overviewDecisionAction{}
+		}
+	}
+	return summary
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/store"
+)
+
+// TestStrategyPresetsAreValid keeps a broken preset from shipping: each one
+// must pass the validation a saved strategy goes through
+func TestStrategyPresetsAreValid(t *testing.T) {
+	defaults := store.DefaultStrategyConfig()
+	seen := make(map[string]bool)
+	for _, p := range store.StrategyPresets() {
+		if p.Name == "" || p.Title == "" || p.Version < 1 || len(p.Differences) == 0 {
+			t.Errorf("preset %q is missing its name, title, version or differences", p.Name)
+		}
+		if seen[p.Name] {
+			t.Errorf("duplicate preset %q", p.Name)
+		}
+		seen[p.Name] = true
+
+		if err := validateStrategyConfig(&p.Config); err != nil {
+			t.Errorf("preset %s: %v", p.Name, err)
+		}
+		if reflect.DeepEqual(p.Config, defaults) {
+			t.Errorf("preset %s is the default config", p.Name)
+		}
+		if found, ok := store.StrategyPresetByName(p.Name); !ok || found.Version != p.Version {
+			t.Errorf("StrategyPresetByName(%s) = %v, %v", p.Name, found.Name, ok)
+		}
+	}
+
+	// Presets are built per call, so changing one doesn't change the next
+	p, _ := store.StrategyPresetByName(store.PresetScalper)
+	p.Config.CoinSource.StaticCoins[0] = "DOGEUSDT"
+	if again, _ := store.StrategyPresetByName(store.PresetScalper); again.Config.CoinSource.StaticCoins[0] != "BTCUSDT" {
+		t.Error("changing a preset changed the next call's")
+	}
+}
+
+func TestStrategyFromPreset(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	w, created := serve(t, mux, "POST", "/api/strategies/from-preset/scalper", "")
+	if w.Code != 200 || created["name"] != "Scalper" {
+		t.Fatalf("from preset = %d %v", w.Code, created)
+	}
+	cfg := created["config"].(map[string]interface{})
+	if cfg["trading_interval"] != 1.0 {
+		t.Errorf("trading_interval = %v, want the preset's 1", cfg["trading_interval"])
+	}
+
+	// The strategy is an ordinary one, editable like any other
+	if w, resp := serve(t, mux, "GET", "/api/strategies/"+created["id"].(string), ""); w.Code != 200 || resp["name"] != "Scalper" {
+		t.Errorf("get = %d %v", w.Code, resp)
+	}
+
+	if w, resp := serve(t, mux, "POST", "/api/strategies/from-preset/yolo", ""); w.Code != 404 {
+		t.Errorf("unknown preset = %d %v", w.Code, resp)
+	}
+	if w, resp := serve(t, mux, "POST", "/api/strategies/from-preset/scalper", `{"name":"Quick scalps"}`); w.Code != 200 || resp["name"] != "Quick scalps" {
+		t.Errorf("named from preset = %d %v", w.Code, resp)
+	}
+	if w, _ := serve(t, mux, "GET", "/api/strategies/from-preset/scalper", ""); w.Code != 405 {
+		t.Errorf("GET from preset = %d, want 405", w.Code)
+	}
+
+	// Routes on a strategy ID sit next to it
+	if w, resp := serve(t, mux, "POST", "/api/strategies/"+created["id"].(string)+"/activate", ""); w.Code != 200 {
+		t.Errorf("activate = %d %v", w.Code, resp)
+	}
+	if _, resp := serve(t, mux, "GET", "/api/strategies/presets", ""); len(resp["presets"].([]interface{})) != len(store.StrategyPresets()) {
+		t.Errorf("presets = %v", resp)
+	}
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ AUTH RATE LIMITING ============
+
+const (
+	authFreeFailures   = 3               // Failures allowed before backoff starts
+	authBackoffBase    = time.Second     // First backoff, doubled per further failure
+	authBackoffMax     = time.Minute     // Backoff cap below the lockout threshold
+	authFailureWindow  = time.Hour       // Failures older than this are forgotten
+	authPersistEvery   = time.Minute     // How often failure counters are saved
+	authLockoutsSetKey = "auth_lockouts" // Settings key holding the saved counters
+)
+
+// authFailures tracks failed authentication attempts from one client IP
+type authFailures struct {
+	IP           string    `json:"ip"`
+	Failures     int       `json:"failures"`
+	LastFailure  time.Time `json:"last_failure"`
+	BlockedUntil time.Time `json:"blocked_until"`
+	LockedOut    bool      `json:"locked_out"` // Reached the lockout threshold, not just backing off
+}
+
+// authLimiter slows down passkey guessing: after authFreeFailures failures an
+// IP waits exponentially longer between attempts, and at the lockout
+// threshold it is locked out for the lockout duration. Counters live in
+// memory and are saved periodically so a restart doesn't clear them.
+type authLimiter struct {
+	mu        sync.Mutex
+	clients   map[string]*authFailures
+	threshold int // Failures that trigger a lockout, 0 disables lockouts
+	lockout   time.Duration
+	settings  *store.SettingsStore // nil keeps counters in memory only
+	dirty     bool
+}
+
+func newAuthLimiter(threshold int, lockout time.Duration, settings *store.SettingsStore) *authLimiter {
+	return &authLimiter{
+		clients:   make(map[string]*authFailures),
+		threshold: threshold,
+		lockout:   lockout,
+		settings:  settings,
+	}
+}
+
+// check returns how long the IP must wait before its next attempt, zero if
+// it may try now
+func (l *authLimiter) check(ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c := l.clients[ip]; c != nil && now.Before(c.BlockedUntil) {
+		return c.BlockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// fail records a failed attempt. Returns true when it triggered a lockout.
+func (l *authLimiter) fail(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dirty = true
+
+	c := l.clients[ip]
+	if c == nil || now.Sub(c.LastFailure) > authFailureWindow {
+		c = &authFailures{IP: ip}
+		l.clients[ip] = c
+	}
+	c.Failures++
+	c.LastFailure = now
+
+	if l.threshold > 0 && c.Failures >= l.threshold {
+		c.BlockedUntil = now.Add(l.lockout)
+		c.LockedOut = true
+		return true
+	}
+	if c.Failures > authFreeFailures {
+		backoff := authBackoffBase << (c.Failures - authFreeFailures - 1)
+		if backoff > authBackoffMax || backoff <= 0 {
+			backoff = authBackoffMax
+		}
+		c.BlockedUntil = now.Add(backoff)
+	}
+	return false
+}
+
+// succeed clears the IP's failures
+func (l *authLimiter) succeed(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.clients[ip]; ok {
+		delete(l.clients, ip)
+		l.dirty = true
+	}
+}
+
+// unlock clears an IP's failures and any lockout. Returns false if the IP had none.
+func (l *authLimiter) unlock(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.clients[ip]; !ok {
+		return false
+	}
+	delete(l.clients, ip)
+	l.dirty = true
+	return true
+}
+
+// lockouts lists the IPs currently locked out or backing off, longest blocked first
+func (l *authLimiter) lockouts(now time.Time) []authFailures {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := []authFailures{}
+	for _, c := range l.clients {
+		if now.Before(c.BlockedUntil) {
+			list = append(list, *c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].BlockedUntil.After(list[j].BlockedUntil) })
+	return list
+}
+
+// load restores the counters saved by the last run
+func (l *authLimiter) load() {
+	if l.settings == nil {
+		return
+	}
+	raw, err := l.settings.Get(authLockoutsSetKey)
+	if err != nil || raw == "" {
+		return
+	}
+	var saved []*authFailures
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+		log.Printf("[Auth] Failed to restore lockouts: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range saved {
+		l.clients[c.IP] = c
+	}
+}
+
+// save writes the counters if they changed, dropping expired ones
+func (l *authLimiter) save(now time.Time) {
+	if l.settings == nil {
+		return
+	}
+
+	l.mu.Lock()
+	if !l.dirty {
+		l.mu.Unlock()
+		return
+	}
+	saved := []*authFailures{}
+	for ip, c := range l.clients {
+		if now.Sub(c.LastFailure) > authFailureWindow && !now.Before(c.BlockedUntil) {
+			delete(l.clients, ip)
+			continue
+		}
+		copied := *c
+		saved = append(saved, &copied)
+	}
+	l.dirty = false
+	l.mu.Unlock()
+
+	data, err := json.Marshal(saved)
+	if err == nil {
+		err = l.settings.Set(authLockoutsSetKey, string(data))
+	}
+	if err != nil {
+		log.Printf("[Auth] Failed to save lockouts: %v", err)
+	}
+}
+
+// run saves the counters periodically
+func (l *authLimiter) run() {
+	ticker := time.NewTicker(authPersistEvery)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		l.save(now)
+	}
+}
+
+// clientIP is the request's remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// authThrottled writes a 429 when the client must wait before trying again
+func (s *Server) authThrottled(w http.ResponseWriter, r *http.Request) bool {
+	wait := s.authLimiter.check(clientIP(r), time.Now())
+	if wait <= 0 {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	s.errorResponse(w, r, http.StatusTooManyRequests, codeAuthLockedOut, "Too many failed authentication attempts, try again later")
+	return true
+}
+
+// authFailed records a failed attempt, auditing it if it locked the client out
+func (s *Server) authFailed(r *http.Request) {
+	ip := clientIP(r)
+	if !s.authLimiter.fail(ip, time.Now()) {
+		return
+	}
+
+	log.Printf("[Auth] Locked out %s for %s after %d failed attempts", ip, s.authLimiter.lockout, s.authLimiter.threshold)
+	entry := &store.AuditEntry{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     http.StatusUnauthorized,
+		RemoteAddr: r.RemoteAddr,
+		Action:     "auth_lockout:" + ip,
+		Result:     fmt.Sprintf("locked out for %s after %d failed attempts", s.authLimiter.lockout, s.authLimiter.threshold),
+	}
+	if err := s.auditStore.Create(entry); err != nil {
+		log.Printf("[Audit] Failed to record lockout of %s: %v", ip, err)
+	}
+}
+
+// ============ LOCKOUT ENDPOINTS ============
+
+// handleAuthLockouts lists the client IPs currently blocked from authenticating
+func (s *Server) handleAuthLockouts(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, s.authLimiter.lockouts(time.Now()))
+}
+
+// handleAuthLockout unblocks a client IP: DELETE /api/auth/lockouts/{ip}
+func (s *Server) handleAuthLockout(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	if !s.authLimiter.unlock(ip) {
+		s.errorResponse(w, r, http.StatusNotFound, codeNotFound, "No failed attempts recorded for this IP")
+		return
+	}
+	log.Printf("[Auth] %s unblocked by %s", ip, currentUser(r).Name)
+	s.jsonResponse(w, map[string]string{"status": "unblocked"})
+}
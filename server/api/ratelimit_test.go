@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/store"
+)
+
+func TestAuthLimiterBackoffAndLockout(t *testing.T) {
+	l := newAuthLimiter(6, 15*time.Minute, nil)
+	now := time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC)
+	const ip = "203.0.113.7"
+
+	for i := 0; i < authFreeFailures; i++ {
+		if l.fail(ip, now) || l.check(ip, now) != 0 {
+			t.Fatalf("failure %d throttled before backoff starts", i+1)
+		}
+	}
+
+	// Backoff doubles from the first failure past the free ones
+	for i, want := range []time.Duration{time.Second, 2 * time.Second} {
+		if l.fail(ip, now) {
+			t.Fatalf("backoff failure %d locked out", i+1)
+		}
+		if got := l.check(ip, now); got != want {
+			t.Errorf("backoff %d = %s, want %s", i+1, got, want)
+		}
+		now = now.Add(want)
+	}
+	if l.check("198.51.100.1", now) != 0 {
+		t.Error("other IPs throttled")
+	}
+
+	// The threshold locks out for the full duration
+	if !l.fail(ip, now) {
+		t.Fatal("threshold reached without a lockout")
+	}
+	if got := l.check(ip, now); got != 15*time.Minute {
+		t.Errorf("lockout = %s", got)
+	}
+	if list := l.lockouts(now); len(list) != 1 || !list[0].LockedOut || list[0].Failures != 6 {
+		t.Errorf("lockouts = %+v", list)
+	}
+
+	if !l.unlock(ip) || l.check(ip, now) != 0 || len(l.lockouts(now)) != 0 {
+		t.Error("unlock didn't clear the lockout")
+	}
+	if l.unlock(ip) {
+		t.Error("unlocked an IP with no failures")
+	}
+}
+
+func TestAuthLimiterForgets(t *testing.T) {
+	l := newAuthLimiter(0, time.Minute, nil)
+	now := time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC)
+	const ip = "203.0.113.7"
+
+	for i := 0; i < 20; i++ {
+		if l.fail(ip, now) {
+			t.Fatal("locked out with lockouts disabled")
+		}
+	}
+	if got := l.check(ip, now); got != authBackoffMax {
+		t.Errorf("backoff = %s, want capped at %s", got, authBackoffMax)
+	}
+
+	// A failure after the window starts counting again
+	now = now.Add(authFailureWindow + time.Minute)
+	l.fail(ip, now)
+	if l.check(ip, now) != 0 {
+		t.Error("old failures still counted")
+	}
+
+	// Success resets the counter
+	for i := 0; i < authFreeFailures; i++ {
+		l.fail(ip, now)
+	}
+	l.succeed(ip)
+	l.fail(ip, now)
+	if l.check(ip, now) != 0 {
+		t.Error("success didn't reset the counter")
+	}
+}
+
+func TestAuthMiddlewareThrottles(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := &Server{
+		cfg:           &config.Config{},
+		userStore:     store.NewUserStore(),
+		accessPasskey: "correct-horse",
+		authLimiter:   newAuthLimiter(0, time.Minute, nil),
+	}
+	handler := s.authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/traders", nil)
+		r.RemoteAddr = "203.0.113.7:51234"
+		r.Header.Set("X-Access-Key", key)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	for i := 0; i <= authFreeFailures; i++ {
+		if w := serve("wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	// Even the right key waits out the backoff
+	w := serve("correct-horse")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("throttled = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	s.authLimiter.unlock("203.0.113.7")
+	if w := serve("correct-horse"); w.Code != http.StatusOK {
+		t.Errorf("after unlock = %d", w.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"auto-trader-ahh/report"
+	"auto-trader-ahh/store"
+)
+
+// ============ P&L REPORTS ============
+
+// handleTraderReport serves GET /api/traders/{id}/report?period=daily|weekly&date=YYYY-MM-DD&format=json|text|markdown.
+// date defaults to today and picks the trading day, named by the date it starts on, or
+// the Monday-to-Sunday week, to report on.
+func (s *Server) handleTraderReport(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	q := r.URL.Query()
+	period, err := report.ParsePeriod(q.Get("period"))
+	if err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	date := time.Now()
+	if v := q.Get("date"); v != "" {
+		day, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid date, use YYYY-MM-DD")
+			return
+		}
+		date = s.reports.TradingDay(t).On(day)
+	}
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "text" && format != "markdown" {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid format, use json, text or markdown")
+		return
+	}
+
+	rep, err := s.reports.Generate(t, period, date)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	switch format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(rep.Text(false)))
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(rep.Text(true)))
+	default:
+		s.jsonResponse(w, rep)
+	}
+}
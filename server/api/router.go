@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ ROUTING ============
+
+// defaultMaxRequestBody caps JSON request bodies when MAX_REQUEST_BODY_KB is
+// unset. Strategies with long custom prompts are the largest legitimate ones.
+const defaultMaxRequestBody = 1 << 20
+
+// routeMethods are the methods probed when building an Allow header
+var routeMethods = []string{"GET", "POST", "PUT", "DELETE"}
+
+// routeMux is a pattern-based ServeMux that answers unknown paths and wrong
+// methods with the JSON error envelope, ignores trailing slashes, and
+// remembers its patterns so tests can check each route is documented
+type routeMux struct {
+	mux      *http.ServeMux
+	literal  *http.ServeMux // Routes a literal segment keeps apart from wildcard ones, matched first
+	patterns []string       // "METHOD /path" of every route
+}
+
+func newRouteMux() *routeMux {
+	return &routeMux{mux: http.NewServeMux(), literal: http.NewServeMux()}
+}
+
+// handle registers a "METHOD /path/{param}" route
+func (m *routeMux) handle(pattern string, handler http.HandlerFunc) {
+	m.patterns = append(m.patterns, pattern)
+	m.mux.HandleFunc(pattern, handler)
+}
+
+// handleLiteral registers a route whose literal segment stands where other
+// routes have a wildcard, e.g. /api/strategies/from-preset/{name} next to
+// /api/strategies/{id}/activate. ServeMux refuses such pairs, so these are
+// matched first, ahead of the wildcard routes.
+func (m *routeMux) handleLiteral(pattern string, handler http.HandlerFunc) {
+	m.patterns = append(m.patterns, pattern)
+	m.literal.HandleFunc(pattern, handler)
+}
+
+func (m *routeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+		r.URL.Path = strings.TrimRight(r.URL.Path, "/")
+		r.URL.RawPath = ""
+	}
+
+	if _, pattern := m.literal.Handler(r); pattern != "" {
+		m.literal.ServeHTTP(w, r)
+		return
+	}
+	if m.match(r) == "" {
+		var allow []string
+		for _, method := range routeMethods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if m.match(probe) != "" {
+				allow = append(allow, method)
+			}
+		}
+		if len(allow) == 0 {
+			writeError(w, r, http.StatusNotFound, apiError{Code: codeNotFound, Message: "Not found"})
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		writeError(w, r, http.StatusMethodNotAllowed, apiError{Code: codeMethodNotAllowed, Message: "Method not allowed", Details: map[string][]string{"allow": allow}})
+		return
+	}
+	m.mux.ServeHTTP(w, r)
+}
+
+// match returns the pattern of the route r goes to, "" for none
+func (m *routeMux) match(r *http.Request) string {
+	if _, pattern := m.literal.Handler(r); pattern != "" {
+		return pattern
+	}
+	_, pattern := m.mux.Handler(r)
+	return pattern
+}
+
+// decodeJSON reads a JSON request body into v. Writes 400 or 413 and returns
+// false when it can't. Unknown fields are rejected so a misspelled setting
+// isn't silently left at its default; handlers that take free-form objects
+// decode into a map, which accepts any field.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return s.readJSON(w, r, v, false)
+}
+
+// decodeOptionalJSON is decodeJSON for endpoints where the body may be left out
+func (s *Server) decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return s.readJSON(w, r, v, true)
+}
+
+// maxRequestBody is the configured body size cap in bytes
+func (s *Server) maxRequestBody() int64 {
+	if s.cfg != nil && s.cfg.MaxRequestBodyKB > 0 {
+		return int64(s.cfg.MaxRequestBodyKB) << 10
+	}
+	return defaultMaxRequestBody
+}
+
+func (s *Server) readJSON(w http.ResponseWriter, r *http.Request, v interface{}, optional bool) bool {
+	limit := s.maxRequestBody()
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+
+	var tooLarge *http.MaxBytesError
+	var badType *json.UnmarshalTypeError
+	switch {
+	case err == nil, optional && errors.Is(err, io.EOF):
+		return true
+	case errors.As(err, &tooLarge):
+		s.errorResponse(w, r, http.StatusRequestEntityTooLarge, codeBodyTooLarge, fmt.Sprintf("Request body larger than %d KB", limit>>10))
+	case errors.As(err, &badType) && badType.Field != "":
+		writeError(w, r, http.StatusBadRequest, apiError{
+			Code:    codeInvalidRequest,
+			Message: fmt.Sprintf("Invalid value for field %q: expected %s", badType.Field, badType.Type),
+			Details: map[string]string{"field": badType.Field},
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this one
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		writeError(w, r, http.StatusBadRequest, apiError{
+			Code:    codeUnknownField,
+			Message: "Unknown field " + field,
+			Details: map[string]string{"field": strings.Trim(field, `"`)},
+		})
+	default:
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid request body")
+	}
+	return false
+}
+
+// ============ RESOURCE HANDLERS ============
+
+// withStrategy loads the {id} strategy and checks the caller may access it
+func (s *Server) withStrategy(h func(http.ResponseWriter, *http.Request, *store.Strategy)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strategy := s.authorizeStrategy(w, r, r.PathValue("id")); strategy != nil {
+			h(w, r, strategy)
+		}
+	}
+}
+
+// withTrader loads the {id} trader and checks the caller may access it
+func (s *Server) withTrader(h func(http.ResponseWriter, *http.Request, *store.Trader)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if t := s.authorizeTrader(w, r, r.PathValue("id")); t != nil {
+			h(w, r, t)
+		}
+	}
+}
+
+// withBacktest checks the caller may access the {id} backtest run
+func (s *Server) withBacktest(h func(w http.ResponseWriter, r *http.Request, runID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if runID := r.PathValue("id"); s.authorizeBacktest(w, r, runID) {
+			h(w, r, runID)
+		}
+	}
+}
+
+// withDebateSession checks the caller may access the {id} debate session
+func (s *Server) withDebateSession(h func(w http.ResponseWriter, r *http.Request, sessionID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if sessionID := r.PathValue("id"); s.authorizeDebateSession(w, r, sessionID) {
+			h(w, r, sessionID)
+		}
+	}
+}
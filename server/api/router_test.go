@@ -0,0 +1,262 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// newTestRoutes builds the routes of an unprotected server on a temp database
+func newTestRoutes(t *testing.T, cfg *config.Config) *routeMux {
+	t.Helper()
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return NewServer("0", trader.NewEngineManager(cfg, events.NewHub()), cfg).routes()
+}
+
+// serve sends a request through the routes and decodes the JSON response
+func serve(t *testing.T, mux *routeMux, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	var resp map[string]interface{}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		json.Unmarshal(w.Body.Bytes(), &resp)
+	}
+	return w, resp
+}
+
+// errorOf returns the code and message of an error response
+func errorOf(resp map[string]interface{}) (code, message string) {
+	e, _ := resp["error"].(map[string]interface{})
+	code, _ = e["code"].(string)
+	message, _ = e["message"].(string)
+	return code, message
+}
+
+func TestEveryRouteReachesItsHandler(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	// Streams block and these call out to Binance or OpenRouter
+	skip := map[string]bool{
+		"GET /api/events":        true,
+		"GET /api/logs/stream":   true,
+		"GET /api/health/deep":   true,
+		"GET /api/debate/models": true,
+	}
+
+	for _, op := range apiOperations {
+		pattern := op.Method + " " + op.Path
+		if skip[pattern] {
+			continue
+		}
+
+		// Unknown IDs and a malformed body stop each handler before it does anything
+		body := ""
+		if op.Method == "POST" || op.Method == "PUT" {
+			body = "{"
+		}
+		w, resp := serve(t, mux, op.Method, pathParamRe.ReplaceAllString(op.Path, "missing"), body)
+		if _, msg := errorOf(resp); w.Code == http.StatusMethodNotAllowed || msg == "Not found" {
+			t.Errorf("%s: router answered %d %v", pattern, w.Code, resp)
+		}
+	}
+}
+
+func TestRouterErrors(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, trader := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	traderPath := "/api/traders/" + trader["id"].(string)
+
+	tests := []struct {
+		name, method, path string
+		status             int
+		allow              string
+	}{
+		{"unknown path", "GET", "/api/nope", 404, ""},
+		{"unknown sub-action", "GET", traderPath + "/nope", 404, ""},
+		{"unknown post action", "POST", traderPath + "/nope", 404, ""},
+		{"unsupported method", "PATCH", traderPath, 405, "GET, PUT, DELETE"},
+		{"action with wrong method", "GET", traderPath + "/start", 405, "POST"},
+		{"collection with wrong method", "DELETE", "/api/traders", 405, "GET, POST"},
+	}
+	for _, tt := range tests {
+		w, resp := serve(t, mux, tt.method, tt.path, "")
+		if w.Code != tt.status || resp["error"] == nil {
+			t.Errorf("%s: %d %v, want %d with a JSON error", tt.name, w.Code, resp, tt.status)
+		}
+		if got := w.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s: Allow = %q, want %q", tt.name, got, tt.allow)
+		}
+	}
+}
+
+func TestRouterAmbiguousPaths(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, strategy := serve(t, mux, "POST", "/api/strategies", `{"name":"s1"}`)
+	id := strategy["id"].(string)
+
+	// Named sub-resources win over {id}
+	for _, path := range []string{"/api/strategies/active", "/api/strategies/default-config", "/api/strategies/presets"} {
+		_, resp := serve(t, mux, "GET", path, "")
+		if _, msg := errorOf(resp); msg == "Strategy not found" {
+			t.Errorf("%s was routed as a strategy ID", path)
+		}
+	}
+
+	// Activate is an action on {id}, not part of the ID
+	if w, resp := serve(t, mux, "POST", "/api/strategies/"+id+"/activate", ""); w.Code != 200 || resp["status"] != "activated" {
+		t.Errorf("activate = %d %v", w.Code, resp)
+	}
+	if _, resp := serve(t, mux, "GET", "/api/strategies/active", ""); resp["id"] != id {
+		t.Errorf("active strategy = %v, want %s", resp["id"], id)
+	}
+
+	// Trailing slashes reach the same routes
+	if w, resp := serve(t, mux, "GET", "/api/strategies/"+id+"/", ""); w.Code != 200 || resp["id"] != id {
+		t.Errorf("trailing slash = %d %v", w.Code, resp)
+	}
+	if w, _ := serve(t, mux, "GET", "/api/strategies/", ""); w.Code != 200 {
+		t.Errorf("collection with trailing slash = %d", w.Code)
+	}
+
+	// Decision IDs are validated after the trader is found
+	_, trader := serve(t, mux, "POST", "/api/traders", `{"name":"t1","strategy_id":"`+id+`"}`)
+	if w, resp := serve(t, mux, "GET", "/api/traders/"+trader["id"].(string)+"/decisions/abc/raw", ""); w.Code != 400 {
+		t.Errorf("bad decision ID = %d %v", w.Code, resp)
+	}
+	if w, _ := serve(t, mux, "GET", "/api/traders/missing/decisions/1/raw", ""); w.Code != 404 {
+		t.Errorf("missing trader = %d", w.Code)
+	}
+}
+
+func TestRouterRequestBodies(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	huge := `{"name":"` + strings.Repeat("x", defaultMaxRequestBody) + `"}`
+	if w, resp := serve(t, mux, "POST", "/api/strategies", huge); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body = %d %v", w.Code, resp)
+	}
+	if w, _ := serve(t, mux, "POST", "/api/strategies", `{"name":`); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed body = %d", w.Code)
+	}
+	if w, _ := serve(t, mux, "POST", "/api/traders", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("missing required body = %d", w.Code)
+	}
+	if w, resp := serve(t, mux, "POST", "/api/emergency/resume", ""); w.Code != 200 {
+		t.Errorf("endpoint without a body = %d %v", w.Code, resp)
+	}
+}
+
+func TestRequestBodyLimitIsConfigurable(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{MaxRequestBodyKB: 1})
+
+	body := `{"name":"s1","description":"` + strings.Repeat("x", 2048) + `"}`
+	w, resp := serve(t, mux, "POST", "/api/strategies", body)
+	if code, msg := errorOf(resp); w.Code != http.StatusRequestEntityTooLarge || code != "BODY_TOO_LARGE" || msg != "Request body larger than 1 KB" {
+		t.Errorf("over the limit = %d %v", w.Code, resp)
+	}
+	if w, resp := serve(t, mux, "POST", "/api/strategies", `{"name":"s1"}`); w.Code != 200 {
+		t.Errorf("under the limit = %d %v", w.Code, resp)
+	}
+}
+
+func TestMisspelledFieldsAreRejected(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, strategy := serve(t, mux, "POST", "/api/strategies", `{"name":"s1"}`)
+	_, trader := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+
+	tests := []struct {
+		method, path, body, want string
+	}{
+		{"POST", "/api/strategies", `{"name":"s2","config":{"risk_control":{"max_leverge":3}}}`, `Unknown field "max_leverge"`},
+		{"PUT", "/api/strategies/" + strategy["id"].(string), `{"name":"s1","config":{"trading_intervl":5}}`, `Unknown field "trading_intervl"`},
+		{"POST", "/api/traders", `{"name":"t2","initial_balanse":500}`, `Unknown field "initial_balanse"`},
+		{"PUT", "/api/traders/" + trader["id"].(string), `{"name":"t1","config":{"testnett":true}}`, `Unknown field "testnett"`},
+		{"POST", "/api/backtest/start", `{"symbols":["BTCUSDT"],"initial_capital":1000}`, `Unknown field "initial_capital"`},
+		{"POST", "/api/strategies", `{"name":"s2","config":{"risk_control":{"max_leverage":"high"}}}`, `Invalid value for field "config.risk_control.max_leverage": expected int`},
+	}
+	for _, tt := range tests {
+		w, resp := serve(t, mux, tt.method, tt.path, tt.body)
+		if _, msg := errorOf(resp); w.Code != http.StatusBadRequest || msg != tt.want {
+			t.Errorf("%s %s = %d %v, want 400 %q", tt.method, tt.path, w.Code, resp["error"], tt.want)
+		}
+	}
+
+	// Nothing was saved
+	if _, list := serve(t, mux, "GET", "/api/strategies", ""); len(list["strategies"].([]interface{})) != 1 {
+		t.Errorf("strategies = %v", list["strategies"])
+	}
+	if _, got := serve(t, mux, "GET", "/api/traders/"+trader["id"].(string), ""); got["config"].(map[string]interface{})["testnet"] != false {
+		t.Errorf("trader updated by a rejected body: %v", got)
+	}
+}
+
+func TestTraderCRUDRoutes(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	w, created := serve(t, mux, "POST", "/api/traders", `{"name":"t1","initial_balance":1000}`)
+	if w.Code != 200 || created["id"] == "" {
+		t.Fatalf("create = %d %v", w.Code, created)
+	}
+	path := "/api/traders/" + created["id"].(string)
+
+	if _, list := serve(t, mux, "GET", "/api/traders", ""); len(list["traders"].([]interface{})) != 1 {
+		t.Errorf("list = %v", list)
+	}
+	if _, got := serve(t, mux, "GET", path, ""); got["name"] != "t1" {
+		t.Errorf("get = %v", got)
+	}
+	if w, updated := serve(t, mux, "PUT", path, `{"name":"t2","initial_balance":1000}`); w.Code != 200 || updated["id"] != created["id"] {
+		t.Errorf("update = %d %v", w.Code, updated)
+	}
+	if _, got := serve(t, mux, "GET", path, ""); got["name"] != "t2" {
+		t.Errorf("get after update = %v", got)
+	}
+	if w, resp := serve(t, mux, "POST", path+"/stop", ""); w.Code != 200 {
+		t.Errorf("stop = %d %v", w.Code, resp)
+	}
+
+	// Deleting archives: the trader is listed only on request and can't start
+	if w, resp := serve(t, mux, "DELETE", path, ""); w.Code != 200 || resp["status"] != "archived" {
+		t.Errorf("delete = %d %v", w.Code, resp)
+	}
+	if _, list := serve(t, mux, "GET", "/api/traders", ""); len(list["traders"].([]interface{})) != 0 {
+		t.Errorf("list after archiving = %v", list)
+	}
+	if _, list := serve(t, mux, "GET", "/api/traders?include_archived=true", ""); len(list["traders"].([]interface{})) != 1 {
+		t.Errorf("list with archived = %v", list)
+	}
+	if w, resp := serve(t, mux, "POST", path+"/start", ""); w.Code != 409 {
+		t.Errorf("start archived = %d %v", w.Code, resp)
+	} else if code, _ := errorOf(resp); code != string(codeTraderArchived) {
+		t.Errorf("start archived code = %s", code)
+	}
+	if _, got := serve(t, mux, "GET", path, ""); got["archived"] != true {
+		t.Errorf("get archived = %v", got)
+	}
+	if w, resp := serve(t, mux, "POST", path+"/unarchive", ""); w.Code != 200 || resp["status"] != "unarchived" {
+		t.Errorf("unarchive = %d %v", w.Code, resp)
+	}
+	if _, list := serve(t, mux, "GET", "/api/traders", ""); len(list["traders"].([]interface{})) != 1 {
+		t.Errorf("list after unarchiving = %v", list)
+	}
+
+	if w, resp := serve(t, mux, "DELETE", path+"?purge=true", ""); w.Code != 200 || resp["status"] != "purged" {
+		t.Errorf("purge = %d %v", w.Code, resp)
+	}
+	if w, _ := serve(t, mux, "GET", path, ""); w.Code != 404 {
+		t.Errorf("get after purge = %d", w.Code)
+	}
+}
@@ -3,12 +3,16 @@ package api
 import (
 	"context"
 	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"auto-trader-ahh/backtest"
@@ -18,7 +22,9 @@ import (
 	"auto-trader-ahh/events"
 	"auto-trader-ahh/exchange"
 	"auto-trader-ahh/logger"
+	"auto-trader-ahh/market"
 	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/report"
 	"auto-trader-ahh/store"
 	"auto-trader-ahh/trader"
 )
@@ -31,14 +37,34 @@ type Server struct {
 	equityStore     *store.EquityStore
 	tradeStore      *store.TradeStore
 	settingsStore   *store.SettingsStore
+	userStore       *store.UserStore
+	auditStore      *store.AuditStore
+	aiCallStore     *store.AICallStore
+	smartFindStore  *store.SmartFindStore
+	coinStore       *store.CoinOverrideStore
+	positionStore   *store.PositionStore
+	posEventStore   *store.PositionEventStore
+	experimentStore *store.ExperimentStore
+	latencyStore    *store.LatencyStore
+	incomeStore     *store.IncomeStore
+	annotationStore *store.AnnotationStore
 	engineManager   *trader.EngineManager
 	debateEngine    *debate.Engine
 	backtestManager *backtest.Manager
 	aiClient        mcp.AIClient
+	ollamaClient    *mcp.OllamaClient
 	binanceClient   *exchange.BinanceClient
+	marketData      *market.DataProvider // Open interest for debates, cached per period
 	accessPasskey   string
 	cfg             *config.Config
 	hub             *events.Hub
+	origins         *originMatcher // ALLOWED_ORIGINS, for CORS and WebSocket handshakes
+	overviews       *overviewCache
+	reports         *report.Generator
+	aiHealth        *aiHealthCache
+	authLimiter     *authLimiter
+	idempotency     *idempotencyKeys
+	modelPrices     modelPriceCache
 }
 
 func NewServer(port string, em *trader.EngineManager, cfg *config.Config) *Server {
@@ -55,6 +81,10 @@ func NewServer(port string, em *trader.EngineManager, cfg *config.Config) *Serve
 	debateEng.RegisterClient("anthropic", aiClient) // OpenRouter supports these models
 	debateEng.RegisterClient("deepseek", aiClient)
 
+	// Local models, picked per participant by model name
+	ollamaClient := mcp.NewOllamaClient(cfg.OllamaBaseURL, "")
+	debateEng.RegisterClient(mcp.ProviderOllama, ollamaClient)
+
 	equityStore := store.NewEquityStore()
 
 	srv := &Server{
@@ -65,140 +95,323 @@ func NewServer(port string, em *trader.EngineManager, cfg *config.Config) *Serve
 		equityStore:     equityStore,
 		tradeStore:      store.NewTradeStore(),
 		settingsStore:   store.NewSettingsStore(),
+		userStore:       store.NewUserStore(),
+		auditStore:      store.NewAuditStore(),
+		aiCallStore:     store.NewAICallStore(),
+		smartFindStore:  store.NewSmartFindStore(),
+		coinStore:       store.NewCoinOverrideStore(),
+		positionStore:   store.NewPositionStore(),
+		posEventStore:   store.NewPositionEventStore(),
+		experimentStore: store.NewExperimentStore(),
+		latencyStore:    store.NewLatencyStore(),
+		incomeStore:     store.NewIncomeStore(),
+		annotationStore: store.NewAnnotationStore(),
 		engineManager:   em,
 		debateEngine:    debateEng,
 		backtestManager: backtest.NewManager(aiClient, binanceClient),
 		aiClient:        aiClient,
+		ollamaClient:    ollamaClient,
 		binanceClient:   binanceClient,
+		marketData:      market.NewDataProvider(binanceClient, nil),
 		accessPasskey:   cfg.AccessPasskey,
 		cfg:             cfg,
 		hub:             em.GetHub(),
+		origins:         newOriginMatcher(cfg.AllowedOrigins),
+		overviews:       newOverviewCache(),
+		reports:         report.NewGenerator(cfg.EquityRawRetentionDays),
+		aiHealth:        &aiHealthCache{},
+		authLimiter:     newAuthLimiter(cfg.AuthLockoutThreshold, time.Duration(cfg.AuthLockoutMinutes)*time.Minute, store.NewSettingsStore()),
+		idempotency:     newIdempotencyKeys(time.Duration(cfg.IdempotencyTTLHours) * time.Hour),
+	}
+	srv.authLimiter.load()
+	srv.backtestManager.SetProgressListener(srv.publishBacktestProgress)
+	if err := srv.backtestManager.LoadCheckpoints(store.NewBacktestCheckpointStore()); err != nil {
+		log.Printf("Failed to load backtest checkpoints: %v", err)
+	}
+	if err := srv.backtestManager.LoadRuns(store.NewBacktestRunStore()); err != nil {
+		log.Printf("Failed to load backtest runs: %v", err)
 	}
 
-	// Wire up debate engine with market context provider and trade executor
+	// Wire up debate engine with market context provider, trade executor, symbol check and pricing
 	debateEng.SetMarketContextProvider(srv.buildDebateMarketContextForCycle)
 	debateEng.SetTradeExecutor(srv.executeDebateDecisions)
+	debateEng.SetSymbolValidator(binanceClient.CheckTradable)
+	debateEng.SetPricingProvider(srv.modelPrice)
 
 	return srv
 }
 
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
+	mux := s.routes()
+
+	// Wrap with request logging, CORS and security headers
+	handler := securityHeadersMiddleware(corsMiddleware(s.origins, s.requestLogMiddleware(mux)))
+
+	go s.authLimiter.run()
+	go s.idempotency.run()
+
+	log.Printf("CORS allowed origins: %v", s.cfg.AllowedOrigins)
+	if s.accessPasskey != "" || s.hasUsers() {
+		log.Printf("Authentication enabled - passkey or user API key required")
+	} else {
+		log.Printf("WARNING: No ACCESS_PASSKEY set - server is unprotected!")
+	}
+	return s.listen(handler)
+}
+
+// routes registers every endpoint. Document new ones in apiOperations.
+func (s *Server) routes() *routeMux {
+	mux := newRouteMux()
+	auth, admin := s.authMiddleware, s.adminMiddleware
 
 	// Public endpoints (no auth required)
-	mux.HandleFunc("/api/health", s.handleHealth)
-	mux.HandleFunc("/api/auth/verify", s.handleAuthVerify)
-	mux.HandleFunc("/api/events", s.hub.ServeHTTP) // SSE endpoint
+	mux.handle("GET /api/health", s.handleHealth)
+	mux.handle("GET /api/health/deep", s.handleDeepHealth)
+	mux.handle("POST /api/auth/verify", s.handleAuthVerify)
+	mux.handle("GET /api/events", auth(s.handleEvents)) // SSE endpoint
+	mux.handle("GET /api/ws", auth(s.handleWebSocket))
+	mux.handle("GET /api/openapi.json", s.handleOpenAPI)
+	mux.handle("GET /api/docs", s.handleDocs)
+
+	// User endpoints
+	mux.handle("GET /api/auth/me", auth(s.handleAuthMe))
+	mux.handle("GET /api/auth/lockouts", admin(s.handleAuthLockouts))
+	mux.handle("DELETE /api/auth/lockouts/{ip}", admin(s.handleAuthLockout))
+	mux.handle("GET /api/users", admin(s.handleListUsers))
+	mux.handle("POST /api/users", admin(s.handleCreateUser))
+	mux.handle("GET /api/users/{id}", admin(s.handleGetUser))
+	mux.handle("DELETE /api/users/{id}", admin(s.handleDeleteUser))
 
 	// Protected endpoints (auth required)
 	// Strategy endpoints
-	mux.HandleFunc("/api/strategies", s.authMiddleware(s.handleStrategies))
-	mux.HandleFunc("/api/strategies/", s.authMiddleware(s.handleStrategy))
-	mux.HandleFunc("/api/strategies/active", s.authMiddleware(s.handleActiveStrategy))
-	mux.HandleFunc("/api/strategies/default-config", s.authMiddleware(s.handleDefaultConfig))
-	mux.HandleFunc("/api/strategies/recommend-pairs", s.authMiddleware(s.handleRecommendPairs))
+	mux.handle("GET /api/strategies", auth(s.handleListStrategies))
+	mux.handle("POST /api/strategies", auth(s.handleCreateStrategy))
+	mux.handle("GET /api/strategies/active", auth(s.handleActiveStrategy))
+	mux.handle("GET /api/strategies/default-config", auth(s.handleDefaultConfig))
+	mux.handle("GET /api/strategies/presets", auth(s.handleStrategyPresets))
+	mux.handleLiteral("POST /api/strategies/from-preset/{name}", auth(s.handleStrategyFromPreset))
+	mux.handle("POST /api/strategies/recommend-pairs", auth(s.handleRecommendPairs))
+	mux.handle("GET /api/strategies/{id}", auth(s.withStrategy(s.handleGetStrategy)))
+	mux.handle("PUT /api/strategies/{id}", auth(s.withStrategy(s.handleUpdateStrategy)))
+	mux.handle("DELETE /api/strategies/{id}", auth(s.withStrategy(s.handleDeleteStrategy)))
+	mux.handle("POST /api/strategies/{id}/activate", admin(s.withStrategy(s.handleActivateStrategy)))
+	mux.handle("POST /api/strategies/{id}/backtest", auth(s.withStrategy(s.handleStrategyBacktest)))
+	mux.handle("GET /api/strategies/{id}/versions", auth(s.withStrategy(s.handleStrategyVersions)))
+	mux.handle("GET /api/strategies/{id}/versions/{version}", auth(s.withStrategy(s.handleStrategyVersion)))
+	mux.handle("POST /api/strategies/{id}/versions/{version}/rollback", auth(s.withStrategy(s.handleRollbackStrategy)))
 
 	// Trader endpoints
-	mux.HandleFunc("/api/traders", s.authMiddleware(s.handleTraders))
-	mux.HandleFunc("/api/traders/", s.authMiddleware(s.handleTrader))
+	mux.handle("GET /api/traders", auth(s.handleListTraders))
+	mux.handle("POST /api/traders", auth(s.handleCreateTrader))
+	mux.handle("GET /api/traders/status", auth(s.handleTradersStatus))
+	mux.handle("POST /api/traders/bulk", auth(s.handleBulkTraders))
+	mux.handle("GET /api/traders/{id}", auth(s.withTrader(s.handleGetTrader)))
+	mux.handle("PUT /api/traders/{id}", auth(s.withTrader(s.handleUpdateTrader)))
+	mux.handle("DELETE /api/traders/{id}", auth(s.withTrader(s.handleDeleteTrader)))
+	mux.handle("POST /api/traders/{id}/start", auth(s.withTrader(s.handleStartTrader)))
+	mux.handle("POST /api/traders/{id}/stop", auth(s.withTrader(s.handleStopTrader)))
+	mux.handle("POST /api/traders/{id}/archive", auth(s.withTrader(s.handleArchiveTrader)))
+	mux.handle("POST /api/traders/{id}/unarchive", auth(s.withTrader(s.handleUnarchiveTrader)))
+	mux.handle("GET /api/traders/{id}/overview", auth(s.withTrader(s.handleTraderOverview)))
+	mux.handle("GET /api/traders/{id}/exchange-info", auth(s.withTrader(s.handleTraderExchangeInfo)))
+	mux.handle("POST /api/traders/{id}/acknowledge-circuit-breaker", auth(s.withTrader(s.handleAcknowledgeCircuitBreaker)))
+	mux.handle("GET /api/traders/{id}/report", auth(s.withTrader(s.handleTraderReport)))
+	mux.handle("GET /api/traders/{id}/experiments", auth(s.withTrader(s.handleTraderExperiments)))
+	mux.handle("GET /api/traders/{id}/latency-report", auth(s.withTrader(s.handleLatencyReport)))
+	mux.handle("POST /api/traders/{id}/annotations", auth(s.withTrader(s.handleCreateAnnotation)))
+	mux.handle("GET /api/traders/{id}/decisions/blocked", auth(s.withTrader(s.handleBlockedDecisions)))
+	mux.handle("GET /api/traders/{id}/decisions/{decision_id}/raw", auth(s.withTrader(s.handleTraderDecisionRaw)))
+	mux.handle("GET /api/traders/{id}/smart-find", auth(s.withTrader(s.handleSmartFindRuns)))
+	mux.handle("POST /api/traders/{id}/smart-find/refresh", auth(s.withTrader(s.handleSmartFindRefresh)))
+	mux.handle("GET /api/traders/{id}/coins", auth(s.withTrader(s.handleTraderCoins)))
+	mux.handle("POST /api/traders/{id}/coins/pin", auth(s.withTrader(s.handlePinCoin)))
+	mux.handle("POST /api/traders/{id}/coins/ban", auth(s.withTrader(s.handleBanCoin)))
+	mux.handle("DELETE /api/traders/{id}/coins/{symbol}", auth(s.withTrader(s.handleClearCoinOverride)))
+	mux.handle("GET /api/traders/{id}/orders", auth(s.withTrader(s.handleTraderOrders)))
+	mux.handle("DELETE /api/traders/{id}/orders/{order_id}", auth(s.withTrader(s.handleCancelTraderOrder)))
+	mux.handle("GET /api/traders/{id}/positions", auth(s.withTrader(s.handleTraderPositions)))
+	mux.handle("PUT /api/traders/{id}/positions/{symbol}/stops", auth(s.withTrader(s.handleSetPositionStops)))
+	mux.handle("GET /api/traders/{id}/positions/{symbol}/events", auth(s.withTrader(s.handlePositionEvents)))
+	mux.handle("GET /api/traders/{id}/positions/{position_id}", auth(s.withTrader(s.handlePositionDetail)))
 
 	// Data endpoints
-	mux.HandleFunc("/api/status", s.authMiddleware(s.handleStatus))
-	mux.HandleFunc("/api/account", s.authMiddleware(s.handleAccount))
-	mux.HandleFunc("/api/positions", s.authMiddleware(s.handlePositions))
-	mux.HandleFunc("/api/decisions", s.authMiddleware(s.handleDecisions))
-	mux.HandleFunc("/api/trades", s.authMiddleware(s.handleTrades))
-	mux.HandleFunc("/api/equity-history", s.authMiddleware(s.handleEquityHistory))
+	mux.handle("GET /api/status", auth(s.handleStatus))
+	mux.handle("GET /api/account", auth(s.handleAccount))
+	mux.handle("GET /api/positions", auth(s.handlePositions))
+	mux.handle("GET /api/decisions", auth(s.handleDecisions))
+	mux.handle("GET /api/trades", auth(s.handleTrades))
+	mux.handle("GET /api/equity-history", auth(s.handleEquityHistory))
 
 	// Backtest endpoints
-	mux.HandleFunc("/api/backtest", s.authMiddleware(s.handleBacktests))
-	mux.HandleFunc("/api/backtest/start", s.authMiddleware(s.handleBacktestStart))
-	mux.HandleFunc("/api/backtest/", s.authMiddleware(s.handleBacktest))
+	mux.handle("GET /api/backtest", auth(s.handleBacktests))
+	mux.handle("POST /api/backtest/start", auth(s.handleBacktestStart))
+	mux.handle("GET /api/backtest/{id}", auth(s.withBacktest(s.handleBacktestStatus)))
+	mux.handle("PATCH /api/backtest/{id}", auth(s.withBacktest(s.handleUpdateBacktest)))
+	mux.handle("DELETE /api/backtest/{id}", auth(s.withBacktest(s.handleDeleteBacktest)))
+	mux.handle("POST /api/backtest/{id}/stop", auth(s.withBacktest(s.handleStopBacktest)))
+	mux.handle("POST /api/backtest/{id}/resume", auth(s.withBacktest(s.handleResumeBacktest)))
+	mux.handle("GET /api/backtest/{id}/status", auth(s.withBacktest(s.handleBacktestStatus)))
+	mux.handle("GET /api/backtest/{id}/metrics", auth(s.withBacktest(s.handleBacktestMetrics)))
+	mux.handle("GET /api/backtest/{id}/equity", auth(s.withBacktest(s.handleBacktestEquity)))
+	mux.handle("GET /api/backtest/{id}/trades", auth(s.withBacktest(s.handleBacktestTrades)))
+	mux.handle("GET /api/backtest/{id}/positions", auth(s.withBacktest(s.handleBacktestPositions)))
+	mux.handle("GET /api/backtest/{id}/decisions", auth(s.withBacktest(s.handleBacktestDecisions)))
+	mux.handle("GET /api/backtest/{id}/comparison", auth(s.withBacktest(s.handleBacktestComparison)))
+	mux.handle("POST /api/backtest/{id}/montecarlo", auth(s.withBacktest(s.handleBacktestMonteCarlo)))
+	mux.handle("GET /api/compare", auth(s.handleCompare))
+	mux.handle("GET /api/backtest/{id}/montecarlo", auth(s.withBacktest(s.handleGetBacktestMonteCarlo)))
 
 	// Debate endpoints
-	mux.HandleFunc("/api/debate/sessions", s.authMiddleware(s.handleDebateSessions))
-	mux.HandleFunc("/api/debate/sessions/", s.authMiddleware(s.handleDebateSession))
+	mux.handle("GET /api/debate/sessions", auth(s.handleListDebateSessions))
+	mux.handle("POST /api/debate/sessions", auth(s.handleCreateDebateSession))
+	mux.handle("GET /api/debate/sessions/{id}", auth(s.withDebateSession(s.handleGetDebateSession)))
+	mux.handle("DELETE /api/debate/sessions/{id}", auth(s.withDebateSession(s.handleDeleteDebateSession)))
+	mux.handle("POST /api/debate/sessions/{id}/start", auth(s.withDebateSession(s.handleStartDebateSession)))
+	mux.handle("POST /api/debate/sessions/{id}/stop", auth(s.withDebateSession(s.handleStopDebateSession)))
+	mux.handle("GET /api/debate/models", auth(s.handleDebateModels))
+	mux.handle("GET /api/debate/personalities", auth(s.handleDebatePersonalities))
 
 	// Settings endpoints
-	mux.HandleFunc("/api/settings", s.authMiddleware(s.handleSettings))
+	mux.handle("GET /api/settings", admin(s.handleGetSettings))
+	mux.handle("PUT /api/settings", admin(s.handleUpdateSettings))
 
-	// System endpoints
-	mux.HandleFunc("/api/logs/stream", s.authMiddleware(s.handleLogStream))
+	// Emergency endpoints
+	mux.handle("POST /api/emergency/stop-all", admin(s.handleEmergencyStopAll))
+	mux.handle("POST /api/emergency/resume", admin(s.handleEmergencyResume))
 
-	// Wrap with CORS middleware
-	handler := corsMiddleware(mux)
+	// System endpoints
+	mux.handle("GET /api/logs/stream", admin(s.handleLogStream))
+	mux.handle("GET /api/ai-calls/models", admin(s.handleAIModelStats))
+	mux.handle("GET /api/metrics", admin(s.handleMetrics))
+	mux.handle("GET /api/audit", auth(s.handleAudit))
 
-	log.Printf("API server starting at http://localhost:%s", s.port)
-	if s.accessPasskey != "" {
-		log.Printf("Authentication enabled - passkey required")
-	} else {
-		log.Printf("WARNING: No ACCESS_PASSKEY set - server is unprotected!")
-	}
-	return http.ListenAndServe(":"+s.port, handler)
+	return mux
 }
 
-// authMiddleware checks for valid passkey in X-Access-Key header
+// authMiddleware resolves the caller from the X-Access-Key header (or access_key
+// query param) and stores the authenticated user in the request context
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth if no passkey is configured
-		if s.accessPasskey == "" {
-			next(w, r)
+		if s.authThrottled(w, r) {
 			return
 		}
 
-		// Check X-Access-Key header or query param
 		accessKey := r.Header.Get("X-Access-Key")
 		if accessKey == "" {
 			accessKey = r.URL.Query().Get("access_key")
 		}
-		if accessKey == "" {
-			s.errorResponse(w, http.StatusUnauthorized, "Access key required")
+
+		user, err := s.authenticate(accessKey)
+		if err != nil {
+			if accessKey != "" {
+				s.authFailed(r)
+			}
+			code := codeAuthInvalid
+			if accessKey == "" {
+				code = codeAuthRequired
+			}
+			s.errorResponse(w, r, http.StatusUnauthorized, code, err.Error())
 			return
 		}
+		s.authLimiter.succeed(clientIP(r))
+
+		if info := requestInfoFrom(r); info != nil {
+			info.user = user
+			info.keyFingerprint = keyFingerprint(accessKey)
+		}
 
-		// Use constant-time comparison to prevent timing attacks
-		if !secureCompare(accessKey, s.accessPasskey) {
-			s.errorResponse(w, http.StatusUnauthorized, "Invalid access key")
+		r = r.WithContext(withUser(r.Context(), user))
+		if isMutating(r.Method) && r.Header.Get(idempotencyKeyHeader) != "" {
+			s.serveIdempotent(w, r, next)
 			return
 		}
+		next(w, r)
+	}
+}
 
+// adminMiddleware is authMiddleware restricted to admin users
+func (s *Server) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if !currentUser(r).IsAdmin() {
+			s.errorResponse(w, r, http.StatusForbidden, codeAdminRequired, "Admin access required")
+			return
+		}
 		next(w, r)
+	})
+}
+
+// authenticate maps an access key to a user. The legacy ACCESS_PASSKEY
+// authenticates as the bootstrap admin; any other key must belong to a user.
+func (s *Server) authenticate(accessKey string) (*store.User, error) {
+	// Skip auth if nothing is configured yet (legacy single-user mode)
+	if s.accessPasskey == "" && !s.hasUsers() {
+		return bootstrapAdmin(), nil
 	}
+
+	if accessKey == "" {
+		return nil, fmt.Errorf("Access key required")
+	}
+
+	// Use constant-time comparison to prevent timing attacks
+	if s.accessPasskey != "" && secureCompare(accessKey, s.accessPasskey) {
+		return bootstrapAdmin(), nil
+	}
+
+	user, err := s.userStore.GetByAPIKey(accessKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid access key")
+	}
+	return user, nil
 }
 
-// handleAuthVerify verifies the passkey and returns success/failure
-func (s *Server) handleAuthVerify(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+// hasUsers returns true once at least one API user has been created
+func (s *Server) hasUsers() bool {
+	count, err := s.userStore.Count()
+	if err != nil {
+		log.Printf("Failed to count users: %v", err)
+		return true // Fail closed
 	}
+	return count > 0
+}
 
+// handleAuthVerify verifies the passkey and returns success/failure
+func (s *Server) handleAuthVerify(w http.ResponseWriter, r *http.Request) {
 	// If no passkey is configured, always allow
-	if s.accessPasskey == "" {
+	if s.accessPasskey == "" && !s.hasUsers() {
 		s.jsonResponse(w, map[string]interface{}{
 			"valid":    true,
 			"message":  "No authentication required",
 			"required": false,
+			"user":     bootstrapAdmin(),
 		})
 		return
 	}
 
+	if s.authThrottled(w, r) {
+		return
+	}
+
 	var req struct {
 		Passkey string `json:"passkey"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if !s.decodeJSON(w, r, &req) {
 		return
 	}
 
-	// Use constant-time comparison to prevent timing attacks
-	if secureCompare(req.Passkey, s.accessPasskey) {
+	user, err := s.authenticate(req.Passkey)
+	if err == nil {
+		s.authLimiter.succeed(clientIP(r))
 		s.jsonResponse(w, map[string]interface{}{
 			"valid":    true,
 			"message":  "Access granted",
 			"required": true,
+			"user":     user,
 		})
 	} else {
+		// An empty passkey is the client asking whether auth is required, not a guess
+		if req.Passkey != "" {
+			s.authFailed(r)
+		}
 		s.jsonResponse(w, map[string]interface{}{
 			"valid":    false,
 			"message":  "Invalid passkey",
@@ -215,166 +428,255 @@ func secureCompare(a, b string) bool {
 	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Access-Key")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 func (s *Server) jsonResponse(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
 
-func (s *Server) errorResponse(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
 // Health check
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	s.jsonResponse(w, map[string]interface{}{
+	resp := map[string]interface{}{
 		"status": "ok",
 		"time":   time.Now().Format(time.RFC3339),
-	})
+		"halted": false,
+	}
+	if halt := s.haltState(); halt.Halted {
+		resp["halted"] = true
+		resp["halted_at"] = halt.HaltedAt
+	}
+	if s.engineManager != nil {
+		resp["streams"] = s.engineManager.StreamHealth()
+	}
+	s.jsonResponse(w, resp)
 }
 
 // ============ STRATEGY ENDPOINTS ============
 
-func (s *Server) handleStrategies(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		strategies, err := s.strategyStore.List()
-		if err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]interface{}{"strategies": strategies})
+func (s *Server) handleListStrategies(w http.ResponseWriter, r *http.Request) {
+	var strategies []*store.Strategy
+	var err error
+	if user := currentUser(r); user.IsAdmin() {
+		strategies, err = s.strategyStore.List()
+	} else {
+		strategies, err = s.strategyStore.ListByOwner(user.ID)
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"strategies": strategies})
+}
 
-	case "POST":
-		var strategy store.Strategy
-		if err := json.NewDecoder(r.Body).Decode(&strategy); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
-		}
-		if err := s.strategyStore.Create(&strategy); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, strategy)
+func (s *Server) handleCreateStrategy(w http.ResponseWriter, r *http.Request) {
+	var strategy store.Strategy
+	if !s.decodeJSON(w, r, &strategy) {
+		return
+	}
+	strategy.OwnerUserID = currentUser(r).ID
+	strategy.UpdatedBy = currentUser(r).ID
+	if !s.validStrategyConfig(w, r, &strategy.Config) {
+		return
+	}
+	if err := s.strategyStore.Create(&strategy); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, strategy)
+}
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// validStrategyConfig rejects a strategy with an invalid trading day, schedule,
+// coin source, AI sampling settings or circuit breaker limits
+func (s *Server) validStrategyConfig(w http.ResponseWriter, r *http.Request, cfg *store.StrategyConfig) bool {
+	if err := validateStrategyConfig(cfg); err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeStrategyInvalid, err.Error())
+		return false
 	}
+	return true
 }
 
-func (s *Server) handleStrategy(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from path: /api/strategies/{id}
-	id := r.URL.Path[len("/api/strategies/"):]
-	if id == "" {
-		s.errorResponse(w, http.StatusBadRequest, "Strategy ID required")
-		return
+// validateStrategyConfig checks everything validStrategyConfig does, for
+// configs that don't come from a request
+func validateStrategyConfig(cfg *store.StrategyConfig) error {
+	if err := trader.ValidateTradingDay(cfg); err != nil {
+		return fmt.Errorf("Invalid trading day: %v", err)
+	}
+	if err := trader.ValidateSchedule(&cfg.Schedule); err != nil {
+		return fmt.Errorf("Invalid schedule: %v", err)
+	}
+	if err := cfg.CoinSource.Validate(); err != nil {
+		return fmt.Errorf("Invalid coin source: %v", err)
+	}
+	if err := cfg.AI.Validate(); err != nil {
+		return fmt.Errorf("Invalid AI settings: %v", err)
+	}
+	if err := cfg.RiskControl.Validate(); err != nil {
+		return fmt.Errorf("Invalid risk control: %v", err)
+	}
+	if err := cfg.Experiment.Validate(); err != nil {
+		return fmt.Errorf("Invalid experiment: %v", err)
 	}
+	return nil
+}
 
-	// Handle activate endpoint
-	if len(id) > 9 && id[len(id)-9:] == "/activate" {
-		if r.Method == "POST" {
-			stratID := id[:len(id)-9]
-			if err := s.strategyStore.SetActive(stratID); err != nil {
-				s.errorResponse(w, http.StatusInternalServerError, err.Error())
-				return
-			}
-			s.jsonResponse(w, map[string]string{"status": "activated"})
-		}
+func (s *Server) handleGetStrategy(w http.ResponseWriter, r *http.Request, existing *store.Strategy) {
+	s.jsonResponse(w, existing)
+}
+
+func (s *Server) handleUpdateStrategy(w http.ResponseWriter, r *http.Request, existing *store.Strategy) {
+	var strategy store.Strategy
+	if !s.decodeJSON(w, r, &strategy) {
+		return
+	}
+	strategy.ID = existing.ID
+	strategy.OwnerUserID = existing.OwnerUserID
+	strategy.UpdatedBy = currentUser(r).ID
+	if !s.validStrategyConfig(w, r, &strategy.Config) {
+		return
+	}
+	if err := s.strategyStore.Update(&strategy); err != nil {
+		s.internalError(w, r, err)
 		return
 	}
 
-	switch r.Method {
-	case "GET":
-		strategy, err := s.strategyStore.Get(id)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, "Strategy not found")
-			return
-		}
-		s.jsonResponse(w, strategy)
+	// Push updated strategy to running engines (live reload)
+	if err := s.engineManager.ReloadStrategyForTraders(existing.ID); err != nil {
+		log.Printf("Warning: failed to reload strategy for running traders: %v", err)
+	}
 
-	case "PUT":
-		var strategy store.Strategy
-		if err := json.NewDecoder(r.Body).Decode(&strategy); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
-		}
-		strategy.ID = id
-		if err := s.strategyStore.Update(&strategy); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+	s.jsonResponse(w, strategy)
+}
 
-		// Push updated strategy to running engines (live reload)
-		if err := s.engineManager.ReloadStrategyForTraders(id); err != nil {
-			log.Printf("Warning: failed to reload strategy for running traders: %v", err)
-		}
+func (s *Server) handleDeleteStrategy(w http.ResponseWriter, r *http.Request, existing *store.Strategy) {
+	if err := s.strategyStore.Delete(existing.ID); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"status": "deleted", "audit_id": s.recordAudit(r)})
+}
 
-		s.jsonResponse(w, strategy)
+// handleActivateStrategy makes a strategy the server-wide active one. It
+// changes the strategy of every user, so only admins can.
+func (s *Server) handleActivateStrategy(w http.ResponseWriter, r *http.Request, existing *store.Strategy) {
+	if err := s.strategyStore.SetActive(existing.ID); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]string{"status": "activated"})
+}
 
-	case "DELETE":
-		if err := s.strategyStore.Delete(id); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]string{"status": "deleted"})
+// strategyBacktestRequest is the range and balance of a strategy backtest,
+// everything else comes from the strategy
+type strategyBacktestRequest struct {
+	Start          int64   `json:"start"` // Unix ms
+	End            int64   `json:"end"`   // Unix ms
+	InitialBalance float64 `json:"initial_balance"`
+}
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// handleStrategyBacktest starts a backtest of a saved strategy as it is now
+func (s *Server) handleStrategyBacktest(w http.ResponseWriter, r *http.Request, strategy *store.Strategy) {
+	var req strategyBacktestRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
 	}
-}
 
-func (s *Server) handleActiveStrategy(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	cfg, err := backtest.ConfigFromStrategy(strategy, req.Start, req.End, req.InitialBalance)
+	if err != nil {
+		s.invalidInput(w, r, codeBacktestInvalid, err)
 		return
 	}
+	cfg.UserID = currentUser(r).ID
 
-	strategy, err := s.strategyStore.GetActive()
+	estimate, err := cfg.Estimate()
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		s.errorResponse(w, r, http.StatusBadRequest, codeBacktestInvalid, err.Error())
 		return
 	}
-	s.jsonResponse(w, strategy)
+	if r.URL.Query().Get("dry_run") == "true" {
+		s.jsonResponse(w, map[string]interface{}{"status": "dry_run", "config": cfg, "estimate": estimate})
+		return
+	}
+
+	runID, err := s.backtestManager.Start(context.Background(), cfg)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"run_id": runID, "status": "started", "config": cfg, "estimate": estimate})
 }
 
-func (s *Server) handleDefaultConfig(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+func (s *Server) handleActiveStrategy(w http.ResponseWriter, r *http.Request) {
+	strategy, err := s.strategyStore.GetActive()
+	if err != nil {
+		s.internalError(w, r, err)
 		return
 	}
+	// The built-in default has no owner and is visible to everyone
+	if strategy.OwnerUserID != "" && !currentUser(r).CanAccess(strategy.OwnerUserID) {
+		s.errorResponse(w, r, http.StatusNotFound, codeStrategyNotFound, "No active strategy")
+		return
+	}
+	s.jsonResponse(w, strategy)
+}
 
+func (s *Server) handleDefaultConfig(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, store.DefaultStrategyConfig())
 }
 
-func (s *Server) handleRecommendPairs(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+func (s *Server) handleStrategyPresets(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, map[string]interface{}{"presets": store.StrategyPresets()})
+}
+
+// fromPresetRequest names a strategy created from a preset. Both default to
+// the preset's.
+type fromPresetRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// handleStrategyFromPreset creates an editable strategy with a preset's config
+func (s *Server) handleStrategyFromPreset(w http.ResponseWriter, r *http.Request) {
+	var req fromPresetRequest
+	if !s.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+	name := r.PathValue("name")
+	preset, ok := store.StrategyPresetByName(name)
+	if !ok {
+		s.errorResponse(w, r, http.StatusNotFound, codeNotFound, fmt.Sprintf("Unknown preset %q", name))
 		return
 	}
 
+	strategy := store.Strategy{
+		Name:        req.Name,
+		Description: req.Description,
+		Config:      preset.Config,
+		OwnerUserID: currentUser(r).ID,
+		UpdatedBy:   currentUser(r).ID,
+	}
+	if strategy.Name == "" {
+		strategy.Name = preset.Title
+	}
+	if strategy.Description == "" {
+		strategy.Description = fmt.Sprintf("%s (preset %s v%d)", preset.Description, preset.Name, preset.Version)
+	}
+	if !s.validStrategyConfig(w, r, &strategy.Config) {
+		return
+	}
+	if err := s.strategyStore.Create(&strategy); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, strategy)
+}
+
+func (s *Server) handleRecommendPairs(w http.ResponseWriter, r *http.Request) {
 	// 0. Parse Request Body
 	var req struct {
 		Count int  `json:"count"`
 		Turbo bool `json:"turbo"`
 	}
-	if r.Body != nil {
-		json.NewDecoder(r.Body).Decode(&req)
+	if !s.decodeOptionalJSON(w, r, &req) {
+		return
 	}
 	targetCount := req.Count
 	if targetCount <= 0 {
@@ -384,14 +686,14 @@ func (s *Server) handleRecommendPairs(w http.ResponseWriter, r *http.Request) {
 	// 1. Get Top Volume Coins (Raw Data)
 	tickers, err := s.binanceClient.Get24hTicker(context.Background())
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to fetch market data: "+err.Error())
+		s.upstreamError(w, r, codeExchangeUnavailable, fmt.Errorf("fetching market data: %w", err))
 		return
 	}
 
 	// 2. Get Account Info
 	account, err := s.binanceClient.GetAccountInfo(context.Background())
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to fetch account info: "+err.Error())
+		s.upstreamError(w, r, codeExchangeUnavailable, fmt.Errorf("fetching account info: %w", err))
 		return
 	}
 
@@ -406,8 +708,8 @@ func (s *Server) handleRecommendPairs(w http.ResponseWriter, r *http.Request) {
 	for _, t := range tickers {
 		// Basic filter: USDT pairs, reasonable volume
 		if len(t.Symbol) > 4 && t.Symbol[len(t.Symbol)-4:] == "USDT" {
-			// Ensure symbol is actively trading (Futures)
-			if !s.binanceClient.IsActiveSymbol(t.Symbol) {
+			// Ensure symbol is a tradable USDT-M perpetual
+			if !s.binanceClient.IsTradable(t.Symbol) {
 				continue
 			}
 
@@ -481,7 +783,7 @@ Result:`, targetCount)
 	// Using CallWithMessages since GetCompletion is not available in interface
 	response, err := s.aiClient.CallWithMessages("You are a smart crypto trading assistant.", prompt)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, "AI Request failed: "+err.Error())
+		s.upstreamError(w, r, codeAIUnavailable, err)
 		return
 	}
 
@@ -499,11 +801,15 @@ Result:`, targetCount)
 	if err := json.Unmarshal([]byte(jsonStr), &recommended); err != nil {
 		// Fallback: manually split by comma if JSON parse fails (simple robustness)
 		// But giving error is safer
-		s.errorResponse(w, http.StatusInternalServerError, "Failed to parse AI response: "+err.Error())
+		log.Printf("[HTTP] %s %s: unparseable AI response (request %s): %v", r.Method, r.URL.Path, requestID(r), err)
+		s.errorResponse(w, r, http.StatusBadGateway, codeAIInvalidResponse, "The AI response could not be parsed, try again")
 		return
 	}
 
-	s.jsonResponse(w, map[string]interface{}{"pairs": recommended})
+	// Drop invented or untradable symbols, telling the caller why
+	pairs, rejected := s.binanceClient.FilterTradable(recommended)
+
+	s.jsonResponse(w, map[string]interface{}{"pairs": pairs, "rejected": rejected})
 }
 
 // Helper methods for slice sorting and string parsing needed by above handler
@@ -513,121 +819,284 @@ Result:`, targetCount)
 
 // ============ TRADER ENDPOINTS ============
 
-func (s *Server) handleTraders(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		traders, err := s.traderStore.List()
-		if err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+// accessibleTraders lists every trader for admins, and the caller's own
+// otherwise. Archived traders are left out unless ?include_archived=true.
+func (s *Server) accessibleTraders(r *http.Request) ([]*store.Trader, error) {
+	var traders []*store.Trader
+	var err error
+	if user := currentUser(r); !user.IsAdmin() {
+		traders, err = s.traderStore.ListByOwner(user.ID)
+	} else {
+		traders, err = s.traderStore.List()
+	}
+	if err != nil || r.URL.Query().Get("include_archived") == "true" {
+		return traders, err
+	}
 
-		// Enhance with runtime status
-		result := make([]map[string]interface{}, len(traders))
-		for i, t := range traders {
-			result[i] = map[string]interface{}{
-				"id":              t.ID,
-				"name":            t.Name,
-				"strategy_id":     t.StrategyID,
-				"exchange":        t.Exchange,
-				"status":          t.Status,
-				"initial_balance": t.InitialBalance,
-				"config":          t.Config, // Include config for editing
-				"created_at":      t.CreatedAt,
-				"is_running":      s.engineManager.IsRunning(t.ID),
-			}
+	active := traders[:0]
+	for _, t := range traders {
+		if !t.Archived {
+			active = append(active, t)
 		}
-		s.jsonResponse(w, map[string]interface{}{"traders": result})
+	}
+	return active, nil
+}
 
-	case "POST":
-		var trader store.Trader
-		if err := json.NewDecoder(r.Body).Decode(&trader); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
-		}
-		if err := s.traderStore.Create(&trader); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, trader)
+func (s *Server) handleListTraders(w http.ResponseWriter, r *http.Request) {
+	traders, err := s.accessibleTraders(r)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	// Enhance with runtime status
+	result := make([]traderListItem, len(traders))
+	for i, t := range traders {
+		result[i] = traderListItem{Trader: *t, IsRunning: s.engineManager.IsRunning(t.ID), Network: s.engineManager.ExchangeNetwork(t)}
+	}
+	s.jsonResponse(w, map[string]interface{}{"traders": result})
+}
+
+func (s *Server) handleCreateTrader(w http.ResponseWriter, r *http.Request) {
+	var trader store.Trader
+	if !s.decodeJSON(w, r, &trader) {
+		return
+	}
+	if trader.StrategyID != "" && s.authorizeStrategy(w, r, trader.StrategyID) == nil {
+		return
+	}
+	if !s.validTraderConfig(w, r, &trader.Config) {
+		return
+	}
+	trader.OwnerUserID = currentUser(r).ID
+	if err := s.traderStore.Create(&trader); err != nil {
+		s.internalError(w, r, err)
+		return
 	}
+	s.jsonResponse(w, trader)
 }
 
-func (s *Server) handleTrader(w http.ResponseWriter, r *http.Request) {
-	// Extract path parts: /api/traders/{id} or /api/traders/{id}/action
-	path := r.URL.Path[len("/api/traders/"):]
-	parts := splitPath(path)
+func (s *Server) handleGetTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	s.jsonResponse(w, existing)
+}
 
-	if len(parts) == 0 {
-		s.errorResponse(w, http.StatusBadRequest, "Trader ID required")
+func (s *Server) handleUpdateTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	var trader store.Trader
+	if !s.decodeJSON(w, r, &trader) {
+		return
+	}
+	if trader.StrategyID != "" && trader.StrategyID != existing.StrategyID &&
+		s.authorizeStrategy(w, r, trader.StrategyID) == nil {
 		return
 	}
+	if !s.validTraderConfig(w, r, &trader.Config) {
+		return
+	}
+	trader.ID = existing.ID
+	trader.OwnerUserID = existing.OwnerUserID
+	if err := s.traderStore.Update(&trader); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, trader)
+}
 
-	id := parts[0]
-	action := ""
-	if len(parts) > 1 {
-		action = parts[1]
+// handleDeleteTrader archives a trader, or with ?purge=true deletes it with
+// its whole history. Purging refuses a running trader rather than stop it.
+func (s *Server) handleDeleteTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	if r.URL.Query().Get("purge") != "true" {
+		s.handleArchiveTrader(w, r, existing)
+		return
+	}
+	if s.engineManager.IsRunning(existing.ID) {
+		s.errorResponse(w, r, http.StatusConflict, codeTraderRunning, "Stop the trader before purging it")
+		return
 	}
+	if err := s.traderStore.Purge(existing.ID); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"status": "purged", "audit_id": s.recordAudit(r)})
+}
 
-	// Handle actions
-	if action != "" && r.Method == "POST" {
-		switch action {
-		case "start":
-			if err := s.engineManager.Start(id); err != nil {
-				s.errorResponse(w, http.StatusInternalServerError, err.Error())
-				return
-			}
-			s.traderStore.UpdateStatus(id, "running")
-			s.jsonResponse(w, map[string]string{"status": "started"})
+// handleArchiveTrader stops a running trader under its shutdown policy and
+// archives it. Status already_archived if it was.
+func (s *Server) handleArchiveTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	if existing.Archived {
+		s.jsonResponse(w, map[string]interface{}{"status": "already_archived", "audit_id": s.recordAudit(r)})
+		return
+	}
+	_, report := s.stopTrader(existing.ID)
+	if err := s.traderStore.SetArchived(existing.ID, true); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	resp := map[string]interface{}{"status": "archived", "audit_id": s.recordAudit(r)}
+	if report != nil {
+		resp["shutdown"] = report
+	}
+	s.jsonResponse(w, resp)
+}
 
-		case "stop":
-			s.engineManager.Stop(id)
-			s.traderStore.UpdateStatus(id, "stopped")
-			s.jsonResponse(w, map[string]string{"status": "stopped"})
+// handleUnarchiveTrader brings an archived trader back, stopped. Status
+// not_archived if it wasn't archived.
+func (s *Server) handleUnarchiveTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	status := "unarchived"
+	if !existing.Archived {
+		status = "not_archived"
+	} else if err := s.traderStore.SetArchived(existing.ID, false); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"status": status, "audit_id": s.recordAudit(r)})
+}
 
-		default:
-			s.errorResponse(w, http.StatusBadRequest, "Unknown action")
-		}
+// startRequest is the optional body of a trader start
+type startRequest struct {
+	ConfirmMainnet bool `json:"confirm_mainnet"` // Required to start a turbo mode strategy on mainnet
+}
+
+func (s *Server) handleStartTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	var req startRequest
+	if !s.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+	status, err := s.startTrader(existing.ID, req.ConfirmMainnet)
+	if err != nil {
+		s.internalError(w, r, err)
 		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"status": status, "audit_id": s.recordAudit(r)})
+}
 
-	// Standard CRUD
-	switch r.Method {
-	case "GET":
-		trader, err := s.traderStore.Get(id)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, "Trader not found")
-			return
-		}
-		s.jsonResponse(w, trader)
+func (s *Server) handleStopTrader(w http.ResponseWriter, r *http.Request, existing *store.Trader) {
+	status, report := s.stopTrader(existing.ID)
+	resp := map[string]interface{}{"status": status, "audit_id": s.recordAudit(r)}
+	if report != nil {
+		resp["shutdown"] = report
+	}
+	s.jsonResponse(w, resp)
+}
 
-	case "PUT":
-		var trader store.Trader
-		if err := json.NewDecoder(r.Body).Decode(&trader); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
-		}
-		trader.ID = id
-		if err := s.traderStore.Update(&trader); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+// validTraderConfig rejects a trader config the engine can't run with
+func (s *Server) validTraderConfig(w http.ResponseWriter, r *http.Request, cfg *store.TraderConfig) bool {
+	if err := trader.ValidateShutdownPolicy(cfg.ShutdownPolicy); err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeTraderInvalid, fmt.Sprintf("Invalid config: %v", err))
+		return false
+	}
+	return true
+}
+
+// startTrader starts a trader and marks it running. Starting is idempotent:
+// a trader that is already running reports "already_running".
+func (s *Server) startTrader(id string, confirmMainnet bool) (string, error) {
+	err := s.engineManager.Start(id, confirmMainnet)
+	if errors.Is(err, trader.ErrAlreadyRunning) {
+		return "already_running", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	s.traderStore.UpdateStatus(id, "running")
+	return "started", nil
+}
+
+// stopTrader stops a trader under its shutdown policy and marks it stopped,
+// reporting "already_stopped", without a shutdown report, if it wasn't running
+func (s *Server) stopTrader(id string) (string, *trader.ShutdownReport) {
+	status := "stopped"
+	report := s.engineManager.Stop(id)
+	if report == nil {
+		status = "already_stopped"
+	}
+	s.traderStore.UpdateStatus(id, "stopped")
+	return status, report
+}
+
+// handleTraderDecisionRaw returns a decision record with the full prompts and
+// responses of every AI call made in that cycle
+func (s *Server) handleTraderDecisionRaw(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	decisionID, err := strconv.ParseInt(r.PathValue("decision_id"), 10, 64)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid decision ID")
+		return
+	}
+
+	record, err := s.decisionStore.Get(t.ID, decisionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.errorResponse(w, r, http.StatusNotFound, codeDecisionNotFound, "Decision not found")
+		return
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	calls, err := s.aiCallStore.ListByDecision(t.ID, decisionID)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if calls == nil {
+		calls = []*store.AICall{}
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"decision": record,
+		"ai_calls": calls,
+	})
+}
+
+// handleBlockedDecisions returns the decisions a validator or risk rule kept
+// from executing, newest first
+func (s *Server) handleBlockedDecisions(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 500 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid limit")
 			return
 		}
-		s.jsonResponse(w, trader)
+		limit = n
+	}
+
+	blocked, err := s.decisionStore.ListBlocked(t.ID, limit)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"blocked": blocked})
+}
 
-	case "DELETE":
-		s.engineManager.Stop(id) // Stop if running
-		if err := s.traderStore.Delete(id); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+// handleAIModelStats returns live AI call and retry counts by model, so models
+// that often need a correction to produce a usable decision stand out
+func (s *Server) handleAIModelStats(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid days")
 			return
 		}
-		s.jsonResponse(w, map[string]string{"status": "deleted"})
+		days = n
+	}
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	models, err := s.aiCallStore.ModelStats(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		s.internalError(w, r, err)
+		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"days": days, "models": models})
+}
+
+// handleMetrics returns runtime metrics: each AI provider's call queue, with
+// its depth, calls in flight and wait times, and each running trader's orders
+// aborted as stale
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, map[string]interface{}{
+		"ai_schedulers":   mcp.AllSchedulerStats(),
+		"stale_decisions": s.engineManager.StaleDecisions(),
+	})
 }
 
 // ============ DATA ENDPOINTS ============
@@ -635,7 +1104,10 @@ func (s *Server) handleTrader(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
@@ -646,7 +1118,10 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
@@ -657,24 +1132,37 @@ func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handlePositions(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
-	positions := s.engineManager.GetPositions(traderID)
-	s.jsonResponse(w, map[string]interface{}{"positions": positions})
+	positions, live, err := s.engineManager.GetPositions(traderID)
+	if positions == nil {
+		positions = []map[string]interface{}{}
+	}
+	resp := map[string]interface{}{"positions": positions, "live": live}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	s.jsonResponse(w, resp)
 }
 
 func (s *Server) handleDecisions(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
 	decisions, err := s.decisionStore.ListByTrader(traderID, 50)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		s.internalError(w, r, err)
 		return
 	}
 	s.jsonResponse(w, map[string]interface{}{"decisions": decisions})
@@ -683,33 +1171,63 @@ func (s *Server) handleDecisions(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleEquityHistory(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
-	snapshots, err := s.equityStore.GetLatest(traderID, 1000)
+	// Optional range in Unix ms, everything by default
+	var start time.Time
+	end := time.Now()
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"start", &start}, {"end", &end}} {
+		if v := r.URL.Query().Get(p.name); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid "+p.name)
+				return
+			}
+			*p.dest = time.UnixMilli(ms)
+		}
+	}
+
+	history, resolution, err := s.equityStore.GetHistory(traderID, start, end, s.cfg.EquityRawRetentionDays)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		s.internalError(w, r, err)
 		return
 	}
-	s.jsonResponse(w, map[string]interface{}{"history": snapshots})
-}
-
-func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	// Deposits and withdrawals, so the chart can mark jumps that aren't P&L
+	transfers, err := s.incomeStore.ListBetween(traderID, store.IncomeCategoryTransfer, start, end)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	// Notable events and manual notes, for the chart's markers
+	annotations, err := s.annotationStore.ListBetween(traderID, start, end)
+	if err != nil {
+		s.internalError(w, r, err)
 		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"history": history, "resolution": resolution, "transfers": transfers, "annotations": annotations})
+}
 
+func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request) {
 	traderID := r.URL.Query().Get("trader_id")
 	if traderID == "" {
-		s.errorResponse(w, http.StatusBadRequest, "trader_id required")
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "trader_id required")
+		return
+	}
+	if !s.authorizeTraderID(w, r, traderID) {
 		return
 	}
 
 	trades, err := s.tradeStore.GetByTrader(traderID, 500)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		s.internalError(w, r, err)
 		return
 	}
 
@@ -745,246 +1263,353 @@ func splitPath(path string) []string {
 
 // ============ BACKTEST ENDPOINTS ============
 
+// handleBacktests lists the runs the caller may see, filtered, sorted and a
+// page at a time
 func (s *Server) handleBacktests(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	query := r.URL.Query()
+	filter := backtest.RunFilter{
+		Tag:    query.Get("tag"),
+		Status: backtest.RunStatus(query.Get("status")),
+		Symbol: query.Get("symbol"),
+		Query:  query.Get("q"),
+		Sort:   query.Get("sort"),
+		Limit:  50,
+	}
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := query.Get(p.name); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid "+p.name)
+				return
+			}
+			*p.dest = time.UnixMilli(ms)
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dest *int
+	}{{"limit", &filter.Limit}, {"offset", &filter.Offset}} {
+		if v := query.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || (n == 0 && p.name == "limit") {
+				s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid "+p.name)
+				return
+			}
+			*p.dest = n
+		}
+	}
+	if filter.Limit > 500 {
+		filter.Limit = 500
+	}
+	if err := filter.Validate(); err != nil {
+		s.invalidInput(w, r, codeInvalidRequest, err)
 		return
 	}
 
-	runs := s.backtestManager.ListRuns()
-	s.jsonResponse(w, map[string]interface{}{"backtests": runs})
+	user := currentUser(r)
+	runs := make([]*backtest.RunMetadata, 0)
+	for _, run := range s.backtestManager.ListRuns() {
+		if user.CanAccess(ownerOrAdmin(run.UserID)) {
+			runs = append(runs, run)
+		}
+	}
+	page, total := backtest.FilterRuns(runs, filter)
+	s.jsonResponse(w, map[string]interface{}{"backtests": page, "total": total})
 }
 
-func (s *Server) handleBacktestStart(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// handleUpdateBacktest renames, redescribes or retags a finished run
+func (s *Server) handleUpdateBacktest(w http.ResponseWriter, r *http.Request, runID string) {
+	var update backtest.RunUpdate
+	if !s.decodeJSON(w, r, &update) {
+		return
+	}
+	meta, err := s.backtestManager.Update(runID, update)
+	switch {
+	case errors.Is(err, backtest.ErrRunNotFinished):
+		s.errorResponse(w, r, http.StatusConflict, codeConflict, err.Error())
+		return
+	case err != nil:
+		s.invalidInput(w, r, codeBacktestInvalid, err)
 		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"backtest": meta, "audit_id": s.recordAudit(r)})
+}
 
+func (s *Server) handleBacktestStart(w http.ResponseWriter, r *http.Request) {
 	var cfg backtest.Config
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if !s.decodeJSON(w, r, &cfg) {
 		return
 	}
 
 	if err := cfg.Validate(); err != nil {
-		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		s.invalidInput(w, r, codeBacktestInvalid, err)
+		return
+	}
+	cfg.UserID = currentUser(r).ID
+
+	// The estimate comes back before any AI call, a dry run makes none
+	estimate, err := cfg.Estimate()
+	if err != nil {
+		s.errorResponse(w, r, http.StatusBadRequest, codeBacktestInvalid, err.Error())
+		return
+	}
+	if r.URL.Query().Get("dry_run") == "true" {
+		s.jsonResponse(w, map[string]interface{}{"status": "dry_run", "estimate": estimate})
 		return
 	}
 
 	runID, err := s.backtestManager.Start(context.Background(), &cfg)
 	if err != nil {
-		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		s.internalError(w, r, err)
 		return
 	}
 
-	s.jsonResponse(w, map[string]string{"run_id": runID, "status": "started"})
+	s.jsonResponse(w, map[string]interface{}{"run_id": runID, "status": "started", "estimate": estimate})
 }
 
-func (s *Server) handleBacktest(w http.ResponseWriter, r *http.Request) {
-	// Extract path: /api/backtest/{runId} or /api/backtest/{runId}/action
-	path := r.URL.Path[len("/api/backtest/"):]
-	parts := splitPath(path)
+func (s *Server) handleBacktestStatus(w http.ResponseWriter, r *http.Request, runID string) {
+	meta, err := s.backtestManager.GetStatus(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, meta)
+}
 
-	if len(parts) == 0 {
-		s.errorResponse(w, http.StatusBadRequest, "Run ID required")
+func (s *Server) handleDeleteBacktest(w http.ResponseWriter, r *http.Request, runID string) {
+	if err := s.backtestManager.Delete(runID); err != nil {
+		s.internalError(w, r, err)
 		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"status": "deleted", "audit_id": s.recordAudit(r)})
+}
 
-	runID := parts[0]
-	action := ""
-	if len(parts) > 1 {
-		action = parts[1]
+func (s *Server) handleStopBacktest(w http.ResponseWriter, r *http.Request, runID string) {
+	if err := s.backtestManager.Stop(runID); err != nil {
+		s.internalError(w, r, err)
+		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"status": "stopped", "audit_id": s.recordAudit(r)})
+}
 
-	switch action {
-	case "stop":
-		if r.Method != "POST" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		if err := s.backtestManager.Stop(runID); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]string{"status": "stopped"})
+// handleResumeBacktest continues a stopped or failed run from its last checkpoint
+func (s *Server) handleResumeBacktest(w http.ResponseWriter, r *http.Request, runID string) {
+	cp, err := s.backtestManager.Resume(context.Background(), runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusConflict, codeConflict, err.Error())
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"run_id":   runID,
+		"status":   "resumed",
+		"next_bar": cp.NextBar,
+		"audit_id": s.recordAudit(r),
+	})
+}
 
-	case "status":
-		if r.Method != "GET" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		meta, err := s.backtestManager.GetStatus(runID)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, err.Error())
-			return
-		}
-		s.jsonResponse(w, meta)
+func (s *Server) handleBacktestMetrics(w http.ResponseWriter, r *http.Request, runID string) {
+	metrics, err := s.backtestManager.GetMetrics(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, metrics)
+}
 
-	case "metrics":
-		if r.Method != "GET" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		metrics, err := s.backtestManager.GetMetrics(runID)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, err.Error())
-			return
-		}
-		s.jsonResponse(w, metrics)
+func (s *Server) handleBacktestEquity(w http.ResponseWriter, r *http.Request, runID string) {
+	curve, err := s.backtestManager.GetEquityCurve(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"equity_curve": curve})
+}
 
-	case "equity":
-		if r.Method != "GET" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		curve, err := s.backtestManager.GetEquityCurve(runID)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]interface{}{"equity_curve": curve})
+func (s *Server) handleBacktestTrades(w http.ResponseWriter, r *http.Request, runID string) {
+	trades, err := s.backtestManager.GetTrades(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"trades": trades})
+}
 
-	case "trades":
-		if r.Method != "GET" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		trades, err := s.backtestManager.GetTrades(runID)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]interface{}{"trades": trades})
+func (s *Server) handleBacktestDecisions(w http.ResponseWriter, r *http.Request, runID string) {
+	decisions, err := s.backtestManager.GetDecisions(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"decisions": decisions})
+}
 
-	case "":
-		// No action - CRUD on run
-		switch r.Method {
-		case "GET":
-			meta, err := s.backtestManager.GetStatus(runID)
-			if err != nil {
-				s.errorResponse(w, http.StatusNotFound, err.Error())
-				return
-			}
-			s.jsonResponse(w, meta)
+func (s *Server) handleBacktestComparison(w http.ResponseWriter, r *http.Request, runID string) {
+	report, err := s.backtestManager.GetComparison(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, report)
+}
 
-		case "DELETE":
-			if err := s.backtestManager.Delete(runID); err != nil {
-				s.errorResponse(w, http.StatusInternalServerError, err.Error())
-				return
-			}
-			s.jsonResponse(w, map[string]string{"status": "deleted"})
+// ============ DEBATE ENDPOINTS ============
 
-		default:
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+func (s *Server) handleListDebateSessions(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	sessions := make([]*debate.SessionWithDetails, 0)
+	for _, session := range s.debateEngine.ListSessions() {
+		if user.CanAccess(ownerOrAdmin(session.UserID)) {
+			sessions = append(sessions, session)
 		}
-
-	default:
-		s.errorResponse(w, http.StatusBadRequest, "Unknown action")
 	}
+	s.jsonResponse(w, map[string]interface{}{"sessions": sessions})
 }
 
-// ============ DEBATE ENDPOINTS ============
+func (s *Server) handleCreateDebateSession(w http.ResponseWriter, r *http.Request) {
+	var req debate.CreateSessionRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.TraderID != "" && s.authorizeTrader(w, r, req.TraderID) == nil {
+		return
+	}
+	if _, err := decision.ParseLanguage(req.Language); err != nil {
+		s.invalidInput(w, r, codeInvalidRequest, err)
+		return
+	}
+	if err := debate.ValidateParticipants(req.Participants); err != nil {
+		s.invalidInput(w, r, codeInvalidRequest, err)
+		return
+	}
+	req.UserID = currentUser(r).ID
 
-func (s *Server) handleDebateSessions(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		sessions := s.debateEngine.ListSessions()
-		s.jsonResponse(w, map[string]interface{}{"sessions": sessions})
+	session, err := s.debateEngine.CreateSession(&req)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
 
-	case "POST":
-		var req debate.CreateSessionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
-		}
+	// The estimate sizes the prompts on the context a start would build now
+	if _, err := s.debateEngine.EstimateCost(session.ID, s.buildDebateMarketContext(session.Symbols)); err != nil {
+		log.Printf("Failed to estimate debate %s cost: %v", session.ID, err)
+	}
 
-		session, err := s.debateEngine.CreateSession(&req)
-		if err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+	s.jsonResponse(w, session)
+}
 
-		s.jsonResponse(w, session)
+// modelPriceTTL is how long the provider's model prices are reused
+const modelPriceTTL = time.Hour
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
+// modelPriceCache keeps the provider's model prices for debate cost estimates
+// and usage
+type modelPriceCache struct {
+	mu        sync.Mutex
+	models    map[string]mcp.ModelPricing
+	fetchedAt time.Time
 }
 
-func (s *Server) handleDebateSession(w http.ResponseWriter, r *http.Request) {
-	// Extract path: /api/debate/sessions/{sessionId} or /api/debate/sessions/{sessionId}/action
-	path := r.URL.Path[len("/api/debate/sessions/"):]
-	parts := splitPath(path)
+// modelPrice returns a model's USD price per token from the AI provider's
+// model list, refetched at most once per modelPriceTTL
+func (s *Server) modelPrice(model string) (prompt, completion float64, ok bool) {
+	c := &s.modelPrices
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) > modelPriceTTL {
+		// A failed fetch keeps the old prices until the next attempt
+		c.fetchedAt = time.Now()
+		if lister, ok := s.aiClient.(mcp.ModelLister); ok {
+			if models, err := lister.ListModels(); err != nil {
+				log.Printf("Failed to fetch model prices: %v", err)
+			} else {
+				c.models = make(map[string]mcp.ModelPricing, len(models))
+				for _, m := range models {
+					c.models[m.ID] = m.Pricing
+				}
+			}
+		}
+	}
 
-	if len(parts) == 0 {
-		s.errorResponse(w, http.StatusBadRequest, "Session ID required")
-		return
+	pricing, found := c.models[model]
+	if !found {
+		return 0, 0, false
 	}
+	return pricing.PerToken()
+}
 
-	sessionID := parts[0]
-	action := ""
-	if len(parts) > 1 {
-		action = parts[1]
+// handleDebateModels lists the models participants can be assigned
+func (s *Server) handleDebateModels(w http.ResponseWriter, r *http.Request) {
+	lister, ok := s.aiClient.(mcp.ModelLister)
+	if !ok {
+		s.errorResponse(w, r, http.StatusNotImplemented, codeNotImplemented, "AI client does not support listing models")
+		return
 	}
 
-	switch action {
-	case "start":
-		if r.Method != "POST" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
+	models, err := lister.ListModels()
+	if err != nil {
+		s.upstreamError(w, r, codeAIUnavailable, err)
+		return
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
 
-		// Get session to retrieve symbols
-		session, err := s.debateEngine.GetSession(sessionID)
-		if err != nil {
-			s.errorResponse(w, http.StatusNotFound, err.Error())
-			return
-		}
+	s.jsonResponse(w, map[string]interface{}{"models": models, "stats": s.debateEngine.ModelStats()})
+}
 
-		// Build market context with real data
-		marketCtx := s.buildDebateMarketContext(session.Symbols)
+// handleDebatePersonalities lists the built-in personalities participants can
+// take; custom_role_description replaces their brief
+func (s *Server) handleDebatePersonalities(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, map[string]interface{}{
+		"personalities": debate.Personalities(),
+		"limits": map[string]int{
+			"personality":             debate.MaxPersonalityLength,
+			"custom_role_description": debate.MaxRoleDescriptionLength,
+			"custom_emoji":            debate.MaxEmojiLength,
+		},
+	})
+}
 
-		if err := s.debateEngine.Start(context.Background(), sessionID, marketCtx); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]string{"status": "started"})
+func (s *Server) handleGetDebateSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	session, err := s.debateEngine.GetSession(sessionID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeDebateNotFound, err.Error())
+		return
+	}
+	s.jsonResponse(w, session)
+}
 
-	case "stop":
-		if r.Method != "POST" {
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-			return
-		}
-		if err := s.debateEngine.Stop(sessionID); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		s.jsonResponse(w, map[string]string{"status": "stopped"})
+func (s *Server) handleDeleteDebateSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	// For now, just stop it
+	s.debateEngine.Stop(sessionID)
+	s.jsonResponse(w, map[string]interface{}{"status": "deleted", "audit_id": s.recordAudit(r)})
+}
 
-	case "":
-		// No action - CRUD on session
-		switch r.Method {
-		case "GET":
-			session, err := s.debateEngine.GetSession(sessionID)
-			if err != nil {
-				s.errorResponse(w, http.StatusNotFound, err.Error())
-				return
-			}
-			s.jsonResponse(w, session)
+func (s *Server) handleStartDebateSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	// Get session to retrieve symbols
+	session, err := s.debateEngine.GetSession(sessionID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeDebateNotFound, err.Error())
+		return
+	}
 
-		case "DELETE":
-			// For now, just stop it
-			s.debateEngine.Stop(sessionID)
-			s.jsonResponse(w, map[string]string{"status": "deleted"})
+	// Build market context with real data
+	marketCtx := s.buildDebateMarketContext(session.Symbols)
 
-		default:
-			s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		}
+	if err := s.debateEngine.Start(context.Background(), sessionID, marketCtx); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"status": "started", "audit_id": s.recordAudit(r)})
+}
 
-	default:
-		s.errorResponse(w, http.StatusBadRequest, "Unknown action")
+func (s *Server) handleStopDebateSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if err := s.debateEngine.Stop(sessionID); err != nil {
+		s.internalError(w, r, err)
+		return
 	}
+	s.jsonResponse(w, map[string]interface{}{"status": "stopped", "audit_id": s.recordAudit(r)})
 }
 
 // buildDebateMarketContext fetches real market data and creates a simulated account for debate
@@ -1056,6 +1681,12 @@ func (s *Server) buildDebateMarketContext(symbols []string) *debate.MarketContex
 			Timestamp:    time.Now(),
 			Klines:       decisionKlines,
 		}
+		if index, err := s.binanceClient.GetFundingRate(ctx, symbol); err == nil {
+			md.FundingRate = index.LastFundingRate
+		}
+		if oi, err := s.marketData.OpenInterest(ctx, symbol); err == nil {
+			md.OpenInterest, md.OIChange24h, md.OITrend = oi.Value, oi.Change24h, oi.Trend
+		}
 		marketData[symbol] = md
 	}
 
@@ -1143,6 +1774,12 @@ func (s *Server) buildDebateMarketContextForCycle(symbols []string) (*debate.Mar
 			Timestamp:    time.Now(),
 			Klines:       decisionKlines,
 		}
+		if index, err := s.binanceClient.GetFundingRate(ctx, symbol); err == nil {
+			md.FundingRate = index.LastFundingRate
+		}
+		if oi, err := s.marketData.OpenInterest(ctx, symbol); err == nil {
+			md.OpenInterest, md.OIChange24h, md.OITrend = oi.Value, oi.Change24h, oi.Trend
+		}
 		marketData[symbol] = md
 	}
 
@@ -1216,6 +1853,10 @@ func (s *Server) executeDebateDecisions(session *debate.Session, decisions []*de
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
+	if session.TraderID != "" {
+		return s.executeDebateDecisionsOnTrader(ctx, session, decisions)
+	}
+
 	// Create session-specific Binance client using session's credentials
 	binanceClient := exchange.NewBinanceClient(
 		session.BinanceAPIKey,
@@ -1331,61 +1972,96 @@ func (s *Server) executeDebateDecisions(session *debate.Session, decisions []*de
 	return nil
 }
 
-// ============ SETTINGS ENDPOINTS ============
-
-func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		settings, err := s.settingsStore.GetGlobalSettings()
-		if err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-
-		// Include whether settings are configured (for UI to know if setup is needed)
-		response := map[string]interface{}{
-			"settings": settings,
-			"configured": map[string]bool{
-				"openrouter": settings.OpenRouterAPIKey != "" || s.cfg.OpenRouterAPIKey != "",
-				"binance":    settings.BinanceAPIKey != "" || s.cfg.BinanceAPIKey != "",
+// executeDebateDecisionsOnTrader hands the consensus to the session's target trader,
+// which applies its own validation, position limits and daily-loss pause
+func (s *Server) executeDebateDecisionsOnTrader(ctx context.Context, session *debate.Session, decisions []*debate.Decision) error {
+	external := make([]trader.ExternalDecision, len(decisions))
+	for i, d := range decisions {
+		external[i] = trader.ExternalDecision{
+			Decision: decision.Decision{
+				Symbol:          d.Symbol,
+				Action:          d.Action,
+				Leverage:        d.Leverage,
+				PositionSizeUSD: d.PositionSizeUSD,
+				StopLoss:        d.StopLoss,
+				TakeProfit:      d.TakeProfit,
+				Confidence:      d.Confidence,
+				Reasoning:       d.Reasoning,
 			},
+			PositionPct: d.PositionPct,
 		}
-		s.jsonResponse(w, response)
+	}
 
-	case "PUT":
-		var req store.GlobalSettings
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			s.errorResponse(w, http.StatusBadRequest, "Invalid request body")
-			return
+	results, err := s.engineManager.ExecuteDecisions(ctx, session.TraderID, external)
+	if err != nil {
+		return err
+	}
+
+	for i, res := range results {
+		d := decisions[i]
+		d.Executed = res.Executed
+		d.OrderID = res.OrderID
+		d.Error = res.Error
+		if res.Executed {
+			d.ExecutedAt = time.Now()
+			log.Printf("[Debate] Executed %s on %s via trader %s (order %s)", d.Action, d.Symbol, session.TraderID, res.OrderID)
+		} else if res.Error != "" {
+			log.Printf("[Debate] %s on %s not executed via trader %s: %s", d.Action, d.Symbol, session.TraderID, res.Error)
 		}
+	}
 
-		// Get existing settings to preserve masked values
-		existing, _ := s.settingsStore.GetGlobalSettings()
+	return nil
+}
 
-		// Only update non-masked values (if masked value sent, keep existing)
-		if isMasked(req.OpenRouterAPIKey) {
-			req.OpenRouterAPIKey = existing.OpenRouterAPIKey
-		}
-		if isMasked(req.BinanceAPIKey) {
-			req.BinanceAPIKey = existing.BinanceAPIKey
-		}
-		if isMasked(req.BinanceSecretKey) {
-			req.BinanceSecretKey = existing.BinanceSecretKey
-		}
+// ============ SETTINGS ENDPOINTS ============
 
-		if err := s.settingsStore.SaveGlobalSettings(&req); err != nil {
-			s.errorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.settingsStore.GetGlobalSettings()
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	// Include whether settings are configured (for UI to know if setup is needed)
+	response := map[string]interface{}{
+		"settings": settings,
+		"configured": map[string]bool{
+			"openrouter": settings.OpenRouterAPIKey != "" || s.cfg.OpenRouterAPIKey != "",
+			"binance":    settings.BinanceAPIKey != "" || s.cfg.BinanceAPIKey != "",
+		},
+	}
+	s.jsonResponse(w, response)
+}
 
-		// Reload config to apply new settings
-		s.reloadConfig()
+func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req store.GlobalSettings
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
 
-		s.jsonResponse(w, map[string]string{"status": "saved"})
+	// Get existing settings to preserve masked values
+	existing, _ := s.settingsStore.GetGlobalSettings()
 
-	default:
-		s.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	// Only update non-masked values (if masked value sent, keep existing)
+	if isMasked(req.OpenRouterAPIKey) {
+		req.OpenRouterAPIKey = existing.OpenRouterAPIKey
 	}
+	if isMasked(req.BinanceAPIKey) {
+		req.BinanceAPIKey = existing.BinanceAPIKey
+	}
+	if isMasked(req.BinanceSecretKey) {
+		req.BinanceSecretKey = existing.BinanceSecretKey
+	}
+
+	if err := s.settingsStore.SaveGlobalSettings(&req); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	// Reload config to apply new settings
+	s.reloadConfig()
+
+	s.jsonResponse(w, map[string]string{"status": "saved"})
 }
 
 // isMasked checks if a string contains masked characters
@@ -1413,6 +2089,7 @@ func (s *Server) reloadConfig() {
 
 	// Update AI client
 	s.aiClient = mcp.NewOpenRouterClient(apiKey, model)
+	s.aiHealth.reset()
 	s.debateEngine.RegisterClient("openrouter", s.aiClient)
 	s.debateEngine.RegisterClient("openai", s.aiClient)
 	s.debateEngine.RegisterClient("anthropic", s.aiClient)
@@ -1434,6 +2111,7 @@ func (s *Server) reloadConfig() {
 
 	s.binanceClient = exchange.NewBinanceClient(binanceKey, binanceSecret, testnet)
 	s.backtestManager = backtest.NewManager(s.aiClient, s.binanceClient)
+	s.backtestManager.SetProgressListener(s.publishBacktestProgress)
 
 	log.Printf("Config reloaded: OpenRouter model=%s, Binance testnet=%v", model, testnet)
 }
@@ -1445,12 +2123,11 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Flush now to send headers
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		s.errorResponse(w, r, http.StatusInternalServerError, codeNotImplemented, "Streaming not supported")
 		return
 	}
 	flusher.Flush()
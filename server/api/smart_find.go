@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+// ============ SMART FIND ENDPOINTS ============
+
+// handleSmartFindRuns returns the last Smart Find run and the kept history,
+// newest first
+func (s *Server) handleSmartFindRuns(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	limit := store.SmartFindRunsKept
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	runs, err := s.smartFindStore.List(t.ID, limit)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	var last *store.SmartFindRun
+	if len(runs) > 0 {
+		last = runs[0]
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"last_run": last,
+		"runs":     runs,
+	})
+}
+
+// handleSmartFindRefresh runs Smart Find on a running trader without waiting
+// for the auto-refresh timer. Runs are spaced to limit AI calls.
+func (s *Server) handleSmartFindRefresh(w http.ResponseWriter, r *http.Request, t *store.Trader) {
+	if !s.engineManager.IsRunning(t.ID) {
+		s.errorResponse(w, r, http.StatusConflict, codeTraderNotRunning, "Trader is not running")
+		return
+	}
+
+	// The run counts against the spacing once started, so finish it even if the client leaves
+	run, err := s.engineManager.RefreshSmartFind(context.Background(), t.ID)
+	switch {
+	case errors.Is(err, trader.ErrSmartFindTooSoon):
+		s.errorResponse(w, r, http.StatusTooManyRequests, codeRateLimited, err.Error())
+		return
+	case errors.Is(err, trader.ErrSmartFindRunning):
+		s.errorResponse(w, r, http.StatusConflict, codeConflict, err.Error())
+		return
+	case err != nil && run != nil:
+		// Recorded as a failed run
+		s.upstreamError(w, r, codeAIUnavailable, fmt.Errorf("smart find: %w", err))
+		return
+	case err != nil:
+		s.internalError(w, r, err)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{"run": run, "audit_id": s.recordAudit(r)})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+func TestActivateStrategyNeedsAdmin(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := &config.Config{AccessPasskey: "correct-horse"}
+	srv := NewServer("0", trader.NewEngineManager(cfg, events.NewHub()), cfg)
+	mux := srv.routes()
+	key, err := srv.userStore.Create(&store.User{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := func(accessKey, method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-Access-Key", accessKey)
+		mux.ServeHTTP(w, r)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, created := as(key, "POST", "/api/strategies/from-preset/scalper")
+	if w.Code != http.StatusOK {
+		t.Fatalf("create = %d %v", w.Code, created)
+	}
+	activate := "/api/strategies/" + created["id"].(string) + "/activate"
+
+	// Even their own strategy: it would become every user's
+	w, resp := as(key, "POST", activate)
+	if code, _ := errorOf(resp); w.Code != http.StatusForbidden || code != string(codeAdminRequired) {
+		t.Errorf("non-admin activate = %d %v, want 403", w.Code, resp)
+	}
+	if active, _ := store.NewStrategyStore().GetActive(); active != nil && active.ID == created["id"] {
+		t.Error("a non-admin activated the strategy")
+	}
+
+	if w, resp := as(cfg.AccessPasskey, "POST", activate); w.Code != http.StatusOK {
+		t.Errorf("admin activate = %d %v", w.Code, resp)
+	}
+	if active, err := store.NewStrategyStore().GetActive(); err != nil || active.ID != created["id"] {
+		t.Errorf("active = %+v, %v", active, err)
+	}
+}
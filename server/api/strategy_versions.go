@@ -0,0 +1,96 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"auto-trader-ahh/store"
+)
+
+// ============ STRATEGY VERSION ENDPOINTS ============
+
+// strategyVersionResult is a saved version with what changed from it to the
+// current config
+type strategyVersionResult struct {
+	*store.StrategyVersion
+	Diff []store.ConfigChange `json:"diff"`
+}
+
+// handleStrategyVersions lists a strategy's versions, newest first
+func (s *Server) handleStrategyVersions(w http.ResponseWriter, r *http.Request, strategy *store.Strategy) {
+	versions, err := s.strategyStore.ListVersions(strategy)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"versions": versions})
+}
+
+// strategyVersion loads the {version} of a strategy, writing the error
+// response and returning nil when it can't
+func (s *Server) strategyVersion(w http.ResponseWriter, r *http.Request, strategy *store.Strategy) *store.StrategyVersion {
+	n, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || n <= 0 {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid version")
+		return nil
+	}
+	v, err := s.strategyStore.GetVersion(strategy, n)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.errorResponse(w, r, http.StatusNotFound, codeStrategyVersionNotFound, fmt.Sprintf("Strategy has no version %d", n))
+		return nil
+	}
+	if err != nil {
+		s.internalError(w, r, err)
+		return nil
+	}
+	return v
+}
+
+// handleStrategyVersion returns one version's full config and the fields the
+// current config changed from it
+func (s *Server) handleStrategyVersion(w http.ResponseWriter, r *http.Request, strategy *store.Strategy) {
+	v := s.strategyVersion(w, r, strategy)
+	if v == nil {
+		return
+	}
+	diff, err := store.DiffStrategyConfigs(*v.Config, strategy.Config)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, strategyVersionResult{StrategyVersion: v, Diff: diff})
+}
+
+// handleRollbackStrategy saves an old version's name, description and config
+// as a new version and reloads it in running traders. The version it replaces
+// stays in the history, so a rollback can itself be rolled back.
+func (s *Server) handleRollbackStrategy(w http.ResponseWriter, r *http.Request, existing *store.Strategy) {
+	v := s.strategyVersion(w, r, existing)
+	if v == nil {
+		return
+	}
+	if v.Current {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("Version %d is already the current one", v.Version))
+		return
+	}
+	// Validation may have tightened since the version was saved
+	if !s.validStrategyConfig(w, r, v.Config) {
+		return
+	}
+
+	strategy := *existing
+	strategy.Name, strategy.Description, strategy.Config = v.Name, v.Description, *v.Config
+	strategy.UpdatedBy = currentUser(r).ID
+	if err := s.strategyStore.Update(&strategy); err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if err := s.engineManager.ReloadStrategyForTraders(existing.ID); err != nil {
+		log.Printf("Warning: failed to reload strategy for running traders: %v", err)
+	}
+	s.jsonResponse(w, map[string]interface{}{"strategy": strategy, "rolled_back_to": v.Version, "audit_id": s.recordAudit(r)})
+}
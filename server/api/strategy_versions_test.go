@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"auto-trader-ahh/config"
+)
+
+func TestStrategyVersionRoutes(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+
+	_, created := serve(t, mux, "POST", "/api/strategies/from-preset/scalper", "")
+	id := created["id"].(string)
+	cfg := created["config"].(map[string]interface{})
+	cfg["custom_prompt"] = "Only trade with the trend"
+	body, _ := json.Marshal(map[string]interface{}{"name": "Scalper v2", "config": cfg})
+	if w, resp := serve(t, mux, "PUT", "/api/strategies/"+id, string(body)); w.Code != 200 || resp["version"] != 2.0 {
+		t.Fatalf("update = %d %v", w.Code, resp)
+	}
+
+	_, resp := serve(t, mux, "GET", "/api/strategies/"+id+"/versions", "")
+	if versions := resp["versions"].([]interface{}); len(versions) != 2 {
+		t.Fatalf("versions = %v", resp)
+	}
+
+	w, resp := serve(t, mux, "GET", "/api/strategies/"+id+"/versions/1", "")
+	if w.Code != 200 || resp["name"] != "Scalper" || resp["config"] == nil {
+		t.Fatalf("version 1 = %d %v", w.Code, resp)
+	}
+	if diff := resp["diff"].([]interface{}); len(diff) != 1 || diff[0].(map[string]interface{})["field"] != "custom_prompt" {
+		t.Errorf("diff = %v, want custom_prompt", diff)
+	}
+
+	for path, want := range map[string]int{"/versions/9": 404, "/versions/x": 400, "/versions/2/rollback": 400} {
+		method := "GET"
+		if path == "/versions/2/rollback" {
+			method = "POST"
+		}
+		if w, resp := serve(t, mux, method, "/api/strategies/"+id+path, ""); w.Code != want {
+			t.Errorf("%s %s = %d %v, want %d", method, path, w.Code, resp, want)
+		}
+	}
+
+	// The rollback is a new version, the one it replaces stays in the history
+	w, resp = serve(t, mux, "POST", "/api/strategies/"+id+"/versions/1/rollback", "")
+	if w.Code != 200 || resp["rolled_back_to"] != 1.0 {
+		t.Fatalf("rollback = %d %v", w.Code, resp)
+	}
+	strategy := resp["strategy"].(map[string]interface{})
+	if strategy["version"] != 3.0 || strategy["name"] != "Scalper" || strategy["config"].(map[string]interface{})["custom_prompt"] == "Only trade with the trend" {
+		t.Errorf("rolled back strategy = %v", strategy)
+	}
+	if _, resp := serve(t, mux, "GET", "/api/strategies/"+id+"/versions", ""); len(resp["versions"].([]interface{})) != 3 {
+		t.Errorf("versions after rollback = %v", resp)
+	}
+}
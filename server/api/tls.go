@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"auto-trader-ahh/config"
+)
+
+// ============ TLS ============
+
+// TLS modes the server can run in
+const (
+	tlsOff      = "off"
+	tlsStatic   = "static"   // TLS_CERT_FILE and TLS_KEY_FILE
+	tlsAutocert = "autocert" // Let's Encrypt for TLS_AUTOCERT_DOMAINS
+)
+
+// tlsMode picks how the API is served from the config
+func tlsMode(cfg *config.Config) string {
+	switch {
+	case len(cfg.AutocertDomains) > 0:
+		return tlsAutocert
+	case cfg.TLSCertFile != "" || cfg.TLSKeyFile != "":
+		return tlsStatic
+	}
+	return tlsOff
+}
+
+// CheckTLS validates the TLS settings before the server starts, so a typo in
+// a cert path fails startup instead of quietly serving plain HTTP
+func CheckTLS(cfg *config.Config) error {
+	switch tlsMode(cfg) {
+	case tlsStatic:
+		_, err := loadCertificate(cfg.TLSCertFile, cfg.TLSKeyFile)
+		return err
+	case tlsAutocert:
+		if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+			return fmt.Errorf("set either TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS, not both")
+		}
+		if !autocertAvailable {
+			return fmt.Errorf("TLS_AUTOCERT_DOMAINS is set but this binary was built without autocert support (build with -tags autocert)")
+		}
+		if err := os.MkdirAll(cfg.AutocertCacheDir, 0700); err != nil {
+			return fmt.Errorf("TLS_AUTOCERT_CACHE_DIR %s: %w", cfg.AutocertCacheDir, err)
+		}
+	}
+	return nil
+}
+
+// loadCertificate reads the cert and key, naming the setting at fault
+func loadCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	certPEM, err := readTLSFile("TLS_CERT_FILE", certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := readTLSFile("TLS_KEY_FILE", keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("TLS_CERT_FILE %s and TLS_KEY_FILE %s: %w", certFile, keyFile, err)
+	}
+	return cert, nil
+}
+
+func readTLSFile(setting, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		abs, _ := filepath.Abs(path)
+		return nil, fmt.Errorf("%s %s does not exist", setting, abs)
+	case errors.Is(err, fs.ErrPermission):
+		return nil, fmt.Errorf("%s %s is not readable by this user", setting, path)
+	case err != nil:
+		return nil, fmt.Errorf("%s %s: %w", setting, path, err)
+	}
+	return data, nil
+}
+
+// listen serves the handler over plain HTTP, HTTPS with the configured
+// certificate, or HTTPS with Let's Encrypt certificates on :443 while :80
+// answers ACME challenges and redirects everything else to HTTPS
+func (s *Server) listen(handler http.Handler) error {
+	srv := &http.Server{Addr: ":" + s.port, Handler: handler}
+
+	switch tlsMode(s.cfg) {
+	case tlsStatic:
+		cert, err := loadCertificate(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		log.Printf("API server starting at https://localhost:%s", s.port)
+		return srv.ListenAndServeTLS("", "")
+
+	case tlsAutocert:
+		tlsConfig, challengeHandler, err := newAutocert(s.cfg.AutocertDomains, s.cfg.AutocertCacheDir, s.cfg.AutocertEmail)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := http.ListenAndServe(":80", challengeHandler); err != nil {
+				log.Printf("HTTP redirect listener on :80 stopped: %v", err)
+			}
+		}()
+		srv.Addr = ":443"
+		srv.TLSConfig = tlsConfig
+		log.Printf("API server starting at https://%s (Let's Encrypt, :80 redirects to HTTPS)", s.cfg.AutocertDomains[0])
+		return srv.ListenAndServeTLS("", "")
+	}
+
+	log.Printf("API server starting at http://localhost:%s", s.port)
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"auto-trader-ahh/trader"
+)
+
+// ============ TRADER STATUS AND BULK ACTIONS ============
+
+// maxBulkTraders caps the IDs in one bulk request
+const maxBulkTraders = 100
+
+// bulkTraderResult is the outcome of a bulk action on one trader
+type bulkTraderResult struct {
+	Status   string                 `json:"status"` // started, already_running, stopped, already_stopped or failed
+	Error    *apiError              `json:"error,omitempty"`
+	Shutdown *trader.ShutdownReport `json:"shutdown,omitempty"` // What stopping did under the trader's shutdown policy
+}
+
+// handleTradersStatus returns the runtime summary of every trader the caller
+// can see, keyed by trader ID, so a list page needs one request
+func (s *Server) handleTradersStatus(w http.ResponseWriter, r *http.Request) {
+	traders, err := s.accessibleTraders(r)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	snapshot := s.engineManager.Snapshot()
+	status := make(map[string]trader.Summary, len(traders))
+	for _, t := range traders {
+		status[t.ID] = snapshot[t.ID] // Zero summary when not running
+	}
+	s.jsonResponse(w, map[string]interface{}{"traders": status})
+}
+
+// handleBulkTraders starts or stops several traders at once. Each trader is
+// handled like the single start/stop endpoints; one failing doesn't stop the
+// others, and the response has a result per ID.
+func (s *Server) handleBulkTraders(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action         string   `json:"action"`
+		IDs            []string `json:"ids"`
+		ConfirmMainnet bool     `json:"confirm_mainnet"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Action != "start" && req.Action != "stop" {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "action must be start or stop")
+		return
+	}
+	if len(req.IDs) == 0 {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, "ids is required")
+		return
+	}
+	if len(req.IDs) > maxBulkTraders {
+		s.errorResponse(w, r, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("At most %d ids per request", maxBulkTraders))
+		return
+	}
+
+	user := currentUser(r)
+	results := make(map[string]bulkTraderResult, len(req.IDs))
+	var allowed []string
+	for _, id := range req.IDs {
+		if _, seen := results[id]; seen {
+			continue
+		}
+		t, err := s.traderStore.Get(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			results[id] = bulkTraderResult{Status: "failed", Error: &apiError{Code: codeTraderNotFound, Message: "Trader not found"}}
+		case err != nil:
+			results[id] = s.bulkFailure(r, id, err)
+		case !user.CanAccess(t.OwnerUserID):
+			results[id] = bulkTraderResult{Status: "failed", Error: &apiError{Code: codeForbidden, Message: "You do not have access to this trader"}}
+		default:
+			results[id] = bulkTraderResult{}
+			allowed = append(allowed, id)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, id := range allowed {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			var result bulkTraderResult
+			if req.Action == "start" {
+				status, err := s.startTrader(id, req.ConfirmMainnet)
+				if err != nil {
+					result = s.bulkFailure(r, id, err)
+				} else {
+					result.Status = status
+				}
+			} else {
+				result.Status, result.Shutdown = s.stopTrader(id)
+			}
+			mu.Lock()
+			results[id] = result
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	s.jsonResponse(w, map[string]interface{}{"results": results, "audit_id": s.recordAudit(r)})
+}
+
+// bulkFailure logs a trader's failure and maps it to a result like the single
+// endpoints' error responses
+func (s *Server) bulkFailure(r *http.Request, id string, err error) bulkTraderResult {
+	log.Printf("[HTTP] %s %s failed for trader %s (request %s): %v", r.Method, r.URL.Path, id, requestID(r), err)
+	_, e := classifyError(err, codeInternal)
+	return bulkTraderResult{Status: "failed", Error: &e}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"testing"
+
+	"auto-trader-ahh/config"
+)
+
+func TestTradersStatus(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, t1 := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	_, t2 := serve(t, mux, "POST", "/api/traders", `{"name":"t2"}`)
+
+	w, resp := serve(t, mux, "GET", "/api/traders/status", "")
+	if w.Code != 200 {
+		t.Fatalf("status = %d %v", w.Code, resp)
+	}
+	traders := resp["traders"].(map[string]interface{})
+	if len(traders) != 2 {
+		t.Fatalf("traders = %v, want both", traders)
+	}
+	for _, id := range []string{t1["id"].(string), t2["id"].(string)} {
+		summary, ok := traders[id].(map[string]interface{})
+		if !ok || summary["running"] != false || summary["open_positions"] != float64(0) || summary["last_cycle_at"] != nil {
+			t.Errorf("%s = %v, want a stopped summary", id, traders[id])
+		}
+	}
+}
+
+func TestBulkTraders(t *testing.T) {
+	mux := newTestRoutes(t, &config.Config{})
+	_, t1 := serve(t, mux, "POST", "/api/traders", `{"name":"t1"}`)
+	_, t2 := serve(t, mux, "POST", "/api/traders", `{"name":"t2"}`)
+	id1, id2 := t1["id"].(string), t2["id"].(string)
+
+	body := `{"action":"stop","ids":["` + id1 + `","` + id2 + `","missing","` + id1 + `"]}`
+	w, resp := serve(t, mux, "POST", "/api/traders/bulk", body)
+	if w.Code != 200 {
+		t.Fatalf("bulk stop = %d %v", w.Code, resp)
+	}
+	results := resp["results"].(map[string]interface{})
+	if len(results) != 3 {
+		t.Errorf("results = %v, want one per distinct ID", results)
+	}
+	for _, id := range []string{id1, id2} {
+		if r := results[id].(map[string]interface{}); r["status"] != "already_stopped" {
+			t.Errorf("%s = %v", id, r)
+		}
+	}
+	if r := results["missing"].(map[string]interface{}); r["status"] != "failed" || r["error"].(map[string]interface{})["code"] != "TRADER_NOT_FOUND" {
+		t.Errorf("missing = %v", r)
+	}
+
+	for _, body := range []string{
+		`{"action":"restart","ids":["` + id1 + `"]}`,
+		`{"action":"stop","ids":[]}`,
+		`{"action":"stop"}`,
+	} {
+		if w, resp := serve(t, mux, "POST", "/api/traders/bulk", body); w.Code != 400 {
+			t.Errorf("%s = %d %v, want 400", body, w.Code, resp)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"auto-trader-ahh/store"
+)
+
+type userContextKey struct{}
+
+// withUser attaches the authenticated user to a request context
+func withUser(ctx context.Context, user *store.User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// currentUser returns the authenticated user for a request (nil if none)
+func currentUser(r *http.Request) *store.User {
+	user, _ := r.Context().Value(userContextKey{}).(*store.User)
+	return user
+}
+
+// bootstrapAdmin is the principal for the legacy ACCESS_PASSKEY
+func bootstrapAdmin() *store.User {
+	return &store.User{
+		ID:   store.BootstrapAdminID,
+		Name: "admin",
+		Role: store.RoleAdmin,
+	}
+}
+
+// ============ OWNERSHIP CHECKS ============
+
+// authorizeStrategy loads a strategy and checks the caller may access it.
+// Writes 404/403 and returns nil on failure.
+func (s *Server) authorizeStrategy(w http.ResponseWriter, r *http.Request, id string) *store.Strategy {
+	strategy, err := s.strategyStore.Get(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.errorResponse(w, r, http.StatusNotFound, codeStrategyNotFound, "Strategy not found")
+		} else {
+			s.internalError(w, r, err)
+		}
+		return nil
+	}
+	if !currentUser(r).CanAccess(strategy.OwnerUserID) {
+		s.errorResponse(w, r, http.StatusForbidden, codeForbidden, "You do not have access to this strategy")
+		return nil
+	}
+	return strategy
+}
+
+// authorizeTrader loads a trader and checks the caller may access it.
+// Writes 404/403 and returns nil on failure.
+func (s *Server) authorizeTrader(w http.ResponseWriter, r *http.Request, id string) *store.Trader {
+	trader, err := s.traderStore.Get(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.errorResponse(w, r, http.StatusNotFound, codeTraderNotFound, "Trader not found")
+		} else {
+			s.internalError(w, r, err)
+		}
+		return nil
+	}
+	if !currentUser(r).CanAccess(trader.OwnerUserID) {
+		s.errorResponse(w, r, http.StatusForbidden, codeForbidden, "You do not have access to this trader")
+		return nil
+	}
+	return trader
+}
+
+// authorizeTraderID checks access for a trader_id query param. Debate equity
+// series use "debate_auto" (global account, admin only) or "debate_{sessionId}".
+func (s *Server) authorizeTraderID(w http.ResponseWriter, r *http.Request, traderID string) bool {
+	if strings.HasPrefix(traderID, "debate_") {
+		sessionID := strings.TrimPrefix(traderID, "debate_")
+		if sessionID == "auto" {
+			if !currentUser(r).IsAdmin() {
+				s.errorResponse(w, r, http.StatusForbidden, codeAdminRequired, "Admin access required")
+				return false
+			}
+			return true
+		}
+		if _, err := s.debateEngine.GetSession(sessionID); err == nil {
+			return s.authorizeDebateSession(w, r, sessionID)
+		}
+	}
+	return s.authorizeTrader(w, r, traderID) != nil
+}
+
+// authorizeBacktest checks the caller may access a backtest run
+func (s *Server) authorizeBacktest(w http.ResponseWriter, r *http.Request, runID string) bool {
+	meta, err := s.backtestManager.GetStatus(runID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeBacktestNotFound, err.Error())
+		return false
+	}
+	if !currentUser(r).CanAccess(ownerOrAdmin(meta.UserID)) {
+		s.errorResponse(w, r, http.StatusForbidden, codeForbidden, "You do not have access to this backtest")
+		return false
+	}
+	return true
+}
+
+// authorizeDebateSession checks the caller may access a debate session
+func (s *Server) authorizeDebateSession(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	session, err := s.debateEngine.GetSession(sessionID)
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeDebateNotFound, err.Error())
+		return false
+	}
+	if !currentUser(r).CanAccess(ownerOrAdmin(session.UserID)) {
+		s.errorResponse(w, r, http.StatusForbidden, codeForbidden, "You do not have access to this debate session")
+		return false
+	}
+	return true
+}
+
+// ownerOrAdmin treats unowned in-memory resources as belonging to the bootstrap admin
+func ownerOrAdmin(ownerUserID string) string {
+	if ownerUserID == "" {
+		return store.BootstrapAdminID
+	}
+	return ownerUserID
+}
+
+// ============ USER ENDPOINTS ============
+
+// handleAuthMe returns the authenticated user
+func (s *Server) handleAuthMe(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, currentUser(r))
+}
+
+func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.userStore.List()
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"users": users})
+}
+
+func (s *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		s.errorResponse(w, r, http.StatusBadRequest, codeUserInvalid, "name required")
+		return
+	}
+	if req.Role != "" && req.Role != store.RoleAdmin && req.Role != store.RoleUser {
+		s.errorResponse(w, r, http.StatusBadRequest, codeUserInvalid, "role must be admin or user")
+		return
+	}
+
+	user := &store.User{Name: req.Name, Role: req.Role}
+	apiKey, err := s.userStore.Create(user)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+
+	// The plaintext key is only returned once
+	s.jsonResponse(w, map[string]interface{}{
+		"user":    user,
+		"api_key": apiKey,
+	})
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.userStore.Get(r.PathValue("id"))
+	if err != nil {
+		s.errorResponse(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+		return
+	}
+	s.jsonResponse(w, user)
+}
+
+func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := s.userStore.Delete(r.PathValue("id")); err != nil {
+		if err == sql.ErrNoRows {
+			s.errorResponse(w, r, http.StatusNotFound, codeUserNotFound, "User not found")
+			return
+		}
+		s.internalError(w, r, err)
+		return
+	}
+	s.jsonResponse(w, map[string]string{"status": "deleted"})
+}
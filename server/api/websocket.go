@@ -0,0 +1,250 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"auto-trader-ahh/backtest"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+)
+
+// ============ WEBSOCKET ============
+
+const (
+	wsMaxSubscriptions = 20   // Topics one connection may follow
+	wsMaxMessageBytes  = 4096 // Largest client message
+	wsPongWait         = 60 * time.Second
+	wsPingPeriod       = wsPongWait * 9 / 10 // Ping idle clients well before they time out
+	wsWriteWait        = 10 * time.Second
+)
+
+// wsRequest is a client message changing the connection's topics
+type wsRequest struct {
+	Subscribe   []string `json:"subscribe,omitempty"`
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
+}
+
+// wsReply answers a wsRequest with every topic the connection now follows and
+// why any requested topic was refused
+type wsReply struct {
+	Type    string            `json:"type"` // "subscribed" or "error"
+	Topics  []string          `json:"topics,omitempty"`
+	Errors  map[string]string `json:"errors,omitempty"` // key: refused topic
+	Message string            `json:"message,omitempty"`
+}
+
+// handleWebSocket upgrades to a WebSocket that pushes the events of the
+// topics the client subscribes to, such as "trader:<id>:positions" or
+// "backtest:<id>:progress". Authentication happens on the handshake, through
+// the access_key query param for browsers.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: s.wsOriginAllowed}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote the error response
+		return
+	}
+	defer conn.Close()
+
+	sub := s.hub.Subscribe()
+	defer sub.Close()
+
+	replies := make(chan wsReply)
+	readerDone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(readerDone)
+		s.readWebSocket(conn, sub, currentUser(r), replies, stop)
+	}()
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		var msg interface{}
+		select {
+		case evt, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			msg = evt
+		case reply := <-replies:
+			msg = reply
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			continue
+		case <-readerDone:
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// readWebSocket applies the client's subscription requests until the
+// connection closes or goes a pong wait without answering a ping
+func (s *Server) readWebSocket(conn *websocket.Conn, sub *events.Subscription, user *store.User, replies chan<- wsReply, stop <-chan struct{}) {
+	conn.SetReadLimit(wsMaxMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	topics := make(map[string]bool)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				log.Printf("[WS] Connection closed: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var reply wsReply
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			reply = wsReply{Type: "error", Message: "Invalid message: " + err.Error()}
+		} else {
+			reply = s.applySubscriptions(sub, user, topics, req)
+		}
+
+		select {
+		case replies <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// applySubscriptions updates topics and sub from req, refusing topics that
+// are malformed, not the user's or past wsMaxSubscriptions
+func (s *Server) applySubscriptions(sub *events.Subscription, user *store.User, topics map[string]bool, req wsRequest) wsReply {
+	reply := wsReply{Type: "subscribed"}
+	for _, topic := range req.Unsubscribe {
+		delete(topics, topic)
+		sub.Remove(topic)
+	}
+	for _, topic := range req.Subscribe {
+		if topics[topic] {
+			continue
+		}
+		var err error
+		if len(topics) >= wsMaxSubscriptions {
+			err = fmt.Errorf("at most %d subscriptions per connection", wsMaxSubscriptions)
+		} else {
+			err = s.authorizeTopic(user, topic)
+		}
+		if err != nil {
+			if reply.Errors == nil {
+				reply.Errors = make(map[string]string)
+			}
+			reply.Errors[topic] = err.Error()
+			continue
+		}
+		topics[topic] = true
+		sub.Add(topic)
+	}
+
+	reply.Topics = make([]string, 0, len(topics))
+	for topic := range topics {
+		reply.Topics = append(reply.Topics, topic)
+	}
+	slices.Sort(reply.Topics)
+	return reply
+}
+
+// handleEvents streams the broadcast events of the traders the caller can
+// access over SSE. Events without a trader go to admins only.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	owners := make(map[string]string) // Trader ID -> owner, looked up once per stream
+	s.hub.ServeEvents(w, r, func(traderID string) bool {
+		if traderID == "" {
+			return user.IsAdmin()
+		}
+		owner, ok := owners[traderID]
+		if !ok {
+			t, err := s.traderStore.Get(traderID)
+			if err != nil {
+				return false
+			}
+			owner = t.OwnerUserID
+			owners[traderID] = owner
+		}
+		return user.CanAccess(owner)
+	})
+}
+
+// authorizeTopic checks topic is well formed and names a trader or backtest
+// run the user may access
+func (s *Server) authorizeTopic(user *store.User, topic string) error {
+	scope, id, _, err := events.ParseTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	switch scope {
+	case events.ScopeTrader:
+		trader, err := s.traderStore.Get(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("trader not found")
+		}
+		if err != nil {
+			return err
+		}
+		if !user.CanAccess(trader.OwnerUserID) {
+			return fmt.Errorf("you do not have access to this trader")
+		}
+	case events.ScopeBacktest:
+		meta, err := s.backtestManager.GetStatus(id)
+		if err != nil {
+			return fmt.Errorf("backtest not found")
+		}
+		if !user.CanAccess(ownerOrAdmin(meta.UserID)) {
+			return fmt.Errorf("you do not have access to this backtest")
+		}
+	}
+	return nil
+}
+
+// wsOriginAllowed accepts clients without an Origin header, same-host pages
+// and the ALLOWED_ORIGINS ones, so other sites can't ride a browser's access
+// key
+func (s *Server) wsOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if s.origins != nil && s.origins.allowed(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// publishBacktestProgress pushes a backtest run's metadata to the
+// subscribers of its progress topic
+func (s *Server) publishBacktestProgress(meta backtest.RunMetadata) {
+	s.hub.Publish(events.BacktestTopic(meta.RunID), events.Event{
+		Type:    events.TypeBacktestProgress,
+		Message: fmt.Sprintf("%s %.0f%%", meta.Status, meta.Progress),
+		Data:    meta,
+	})
+}
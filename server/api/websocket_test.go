@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+	"auto-trader-ahh/trader"
+)
+
+func TestWebSocketSubscriptions(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	cfg := &config.Config{AccessPasskey: "correct-horse"}
+	srv := NewServer("0", trader.NewEngineManager(cfg, events.NewHub()), cfg)
+	ts := httptest.NewServer(srv.requestLogMiddleware(srv.routes()))
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/ws"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("handshake without a key = %v, %v", resp, err)
+	}
+
+	user := &store.User{Name: "alice"}
+	key, err := srv.userStore.Create(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var own []string
+	for i := 0; i < 7; i++ {
+		tr := &store.Trader{Name: fmt.Sprintf("t%d", i), OwnerUserID: user.ID}
+		if err := srv.traderStore.Create(tr); err != nil {
+			t.Fatal(err)
+		}
+		own = append(own, tr.ID)
+	}
+	other := &store.Trader{Name: "other", OwnerUserID: "someone-else"}
+	if err := srv.traderStore.Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?access_key="+key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	positions := events.TraderTopic(own[0], events.StreamPositions)
+	conn.WriteJSON(wsRequest{Subscribe: []string{
+		positions,
+		events.TraderTopic(other.ID, events.StreamPositions),
+		events.TraderTopic(other.ID, events.StreamReports),
+		"trader:" + own[0] + ":orders",
+		"backtest:missing:progress",
+	}})
+	var reply wsReply
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Topics) != 1 || reply.Topics[0] != positions || len(reply.Errors) != 4 {
+		t.Fatalf("reply = %+v, want only %s", reply, positions)
+	}
+
+	// Only the subscribed topic comes through
+	srv.hub.Publish(events.TraderTopic(other.ID, events.StreamPositions), events.Event{Type: events.TypePositions})
+	srv.hub.Publish(positions, events.Event{Type: events.TypePositions, Message: "mine"})
+	var evt events.TopicEvent
+	if err := conn.ReadJSON(&evt); err != nil {
+		t.Fatal(err)
+	}
+	if evt.Topic != positions || evt.Message != "mine" || evt.Timestamp == 0 {
+		t.Errorf("event = %+v", evt)
+	}
+
+	// 7 traders have 21 topics, one past the limit
+	var all []string
+	for _, id := range own {
+		for _, stream := range []string{events.StreamPositions, events.StreamEquity, events.StreamLifecycle} {
+			all = append(all, events.TraderTopic(id, stream))
+		}
+	}
+	conn.WriteJSON(wsRequest{Subscribe: all})
+	reply = wsReply{}
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Topics) != wsMaxSubscriptions || len(reply.Errors) != 1 {
+		t.Errorf("past the limit: %d topics, errors %v", len(reply.Topics), reply.Errors)
+	}
+
+	conn.WriteJSON(wsRequest{Unsubscribe: []string{positions}})
+	reply = wsReply{}
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Topics) != wsMaxSubscriptions-1 {
+		t.Errorf("after unsubscribing: %d topics", len(reply.Topics))
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	s := &Server{origins: newOriginMatcher([]string{"https://app.example.com"})}
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"https://app.example.com", true},
+		{"http://trader.local:8080", true},
+		{"https://evil.example.com", false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "http://trader.local:8080/api/ws", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if got := s.wsOriginAllowed(r); got != c.want {
+			t.Errorf("origin %q allowed = %v, want %v", c.origin, got, c.want)
+		}
+	}
+}
+
+func TestEventsStreamFiltersTraders(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	cfg := &config.Config{AccessPasskey: "correct-horse"}
+	hub := events.NewHub()
+	go hub.Run()
+	srv := NewServer("0", trader.NewEngineManager(cfg, hub), cfg)
+	ts := httptest.NewServer(srv.requestLogMiddleware(srv.routes()))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("stream without a key = %d", resp.StatusCode)
+	}
+
+	user := &store.User{Name: "alice"}
+	key, err := srv.userStore.Create(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := &store.Trader{Name: "mine", OwnerUserID: user.ID}
+	other := &store.Trader{Name: "other", OwnerUserID: "someone-else"}
+	for _, tr := range []*store.Trader{own, other} {
+		if err := srv.traderStore.Create(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err = http.Get(ts.URL + "/api/events?access_key=" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := bufio.NewReader(resp.Body)
+	if line, err := body.ReadString('\n'); err != nil || !strings.Contains(line, "connected") {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+
+	// Another user's and system events are skipped
+	hub.Broadcast(events.Event{Type: events.TypeTrade, TraderID: other.ID, Message: "theirs"})
+	hub.Broadcast(events.Event{Type: events.TypeError, Message: "system"})
+	hub.Broadcast(events.Event{Type: events.TypeTrade, TraderID: own.ID, Message: "mine"})
+	body.ReadString('\n') // Blank line after the connected event
+	line, err := body.ReadString('\n')
+	if err != nil || !strings.Contains(line, `"message":"mine"`) {
+		t.Errorf("first event = %q, %v, want the own trader's", line, err)
+	}
+}
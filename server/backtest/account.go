@@ -2,7 +2,12 @@ package backtest
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"slices"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
 )
 
 // Account manages simulated trading account
@@ -12,6 +17,7 @@ type Account struct {
 	realizedPnL   float64
 	feeRate       float64 // Fee rate as decimal (e.g., 0.0004 for 4 bps)
 	slippageRate  float64 // Slippage rate as decimal
+	brackets      map[string][]exchange.LeverageBracket // Maintenance margin tiers by symbol
 }
 
 // NewAccount creates a new simulated account
@@ -21,9 +27,16 @@ func NewAccount(initialBalance, feeBps, slippageBps float64) *Account {
 		positions:    make(map[string]*Position),
 		feeRate:      feeBps / 10000,
 		slippageRate: slippageBps / 10000,
+		brackets:     make(map[string][]exchange.LeverageBracket),
 	}
 }
 
+// SetLeverageBrackets sets the maintenance margin tiers used for symbol's
+// liquidation prices, instead of the defaults
+func (a *Account) SetLeverageBrackets(symbol string, brackets []exchange.LeverageBracket) {
+	a.brackets[symbol] = brackets
+}
+
 // GetCash returns available cash
 func (a *Account) GetCash() float64 {
 	return a.cash
@@ -76,9 +89,6 @@ func (a *Account) Open(symbol, side string, quantity float64, leverage int, pric
 	// Deduct from cash
 	a.cash -= required
 
-	// Calculate liquidation price
-	liqPrice := a.computeLiquidationPrice(execPrice, leverage, side)
-
 	// Create or update position
 	key := positionKey(symbol, side)
 	pos := a.positions[key]
@@ -93,11 +103,11 @@ func (a *Account) Open(symbol, side string, quantity float64, leverage int, pric
 			Leverage:         leverage,
 			Margin:           margin,
 			Notional:         notional,
-			LiquidationPrice: liqPrice,
 			OpenTime:         ts,
 			AccumulatedFee:   fee,
 		}
 		a.positions[key] = pos
+		pos.LiquidationPrice = a.computeLiquidationPrice(pos)
 	} else {
 		// Add to existing - calculate weighted average entry
 		totalQty := pos.Quantity + quantity
@@ -106,7 +116,7 @@ func (a *Account) Open(symbol, side string, quantity float64, leverage int, pric
 		pos.Margin += margin
 		pos.Notional += notional
 		pos.AccumulatedFee += fee
-		pos.LiquidationPrice = a.computeLiquidationPrice(pos.EntryPrice, pos.Leverage, pos.Side)
+		pos.LiquidationPrice = a.computeLiquidationPrice(pos)
 	}
 
 	return pos, fee, execPrice, nil
@@ -161,6 +171,7 @@ func (a *Account) Close(symbol, side string, quantity float64, price float64) (f
 		pos.Margin -= marginReturn
 		pos.Notional -= closeNotional
 		pos.AccumulatedFee -= openFee
+		pos.LiquidationPrice = a.computeLiquidationPrice(pos)
 	}
 
 	return netRealized, totalFee, execPrice, nil
@@ -171,7 +182,8 @@ func (a *Account) TotalEquity(priceMap map[string]float64) (equity, unrealized f
 	perSymbol = make(map[string]float64)
 	totalMargin := 0.0
 
-	for key, pos := range a.positions {
+	for _, key := range a.positionKeys() {
+		pos := a.positions[key]
 		price, ok := priceMap[pos.Symbol]
 		if !ok {
 			price = pos.EntryPrice // Fallback to entry price
@@ -193,12 +205,28 @@ func (a *Account) TotalEquity(priceMap map[string]float64) (equity, unrealized f
 	return equity, unrealized, perSymbol
 }
 
+// Exposure sums the notional of the open positions at priceMap's prices,
+// entry price for symbols without one
+func (a *Account) Exposure(priceMap map[string]float64) decision.Exposure {
+	var x decision.Exposure
+	for _, key := range a.positionKeys() {
+		pos := a.positions[key]
+		price, ok := priceMap[pos.Symbol]
+		if !ok {
+			price = pos.EntryPrice
+		}
+		x = x.Add(pos.Symbol, pos.Side, pos.Quantity*price)
+	}
+	return x
+}
+
 // CheckLiquidation checks if any positions should be liquidated
 func (a *Account) CheckLiquidation(priceMap map[string]float64, ts int64, cycle int) ([]TradeEvent, string, error) {
 	var events []TradeEvent
 	var notes []string
 
-	for key, pos := range a.positions {
+	for _, key := range a.positionKeys() {
+		pos := a.positions[key]
 		price, ok := priceMap[pos.Symbol]
 		if !ok {
 			continue
@@ -245,6 +273,52 @@ func (a *Account) CheckLiquidation(priceMap map[string]float64, ts int64, cycle
 	return nil, "", nil
 }
 
+// CheckStops closes positions whose SL or TP the price has reached, filling at
+// the stop level like the exchange-side stop-market orders of live trading
+func (a *Account) CheckStops(priceMap map[string]float64, ts int64, cycle int) ([]TradeEvent, error) {
+	var events []TradeEvent
+
+	for _, key := range a.positionKeys() {
+		pos := a.positions[key]
+		price, ok := priceMap[pos.Symbol]
+		if !ok {
+			continue
+		}
+
+		action, level := "", 0.0
+		isLong := pos.Side == "long"
+		switch {
+		case pos.StopLoss > 0 && ((isLong && price <= pos.StopLoss) || (!isLong && price >= pos.StopLoss)):
+			action, level = "stop_loss", pos.StopLoss
+		case pos.TakeProfit > 0 && ((isLong && price >= pos.TakeProfit) || (!isLong && price <= pos.TakeProfit)):
+			action, level = "take_profit", pos.TakeProfit
+		default:
+			continue
+		}
+
+		quantity := pos.Quantity
+		realized, fee, execPrice, err := a.Close(pos.Symbol, pos.Side, quantity, level)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, TradeEvent{
+			Timestamp:   ts,
+			Symbol:      pos.Symbol,
+			Action:      action,
+			Side:        pos.Side,
+			Quantity:    quantity,
+			Price:       execPrice,
+			Fee:         fee,
+			RealizedPnL: realized,
+			Leverage:    pos.Leverage,
+			Cycle:       cycle,
+			Note:        fmt.Sprintf("%s hit at %.4f (price %.4f)", action, level, price),
+		})
+	}
+
+	return events, nil
+}
+
 // applySlippage applies slippage to execution price
 func (a *Account) applySlippage(price float64, side string, isOpen bool) float64 {
 	if a.slippageRate == 0 {
@@ -266,15 +340,14 @@ func (a *Account) applySlippage(price float64, side string, isOpen bool) float64
 	return price * (1 + a.slippageRate)
 }
 
-// computeLiquidationPrice calculates the liquidation price
-func (a *Account) computeLiquidationPrice(entry float64, leverage int, side string) float64 {
-	// Liquidation when position loses ~100% of margin
-	// margin = notional / leverage
-	// loss% = 1 / leverage
-	if side == "long" {
-		return entry * (1 - 1.0/float64(leverage))
+// computeLiquidationPrice calculates the liquidation price: where the
+// position's margin falls to the maintenance margin of its notional tier
+func (a *Account) computeLiquidationPrice(pos *Position) float64 {
+	brackets := a.brackets[pos.Symbol]
+	if len(brackets) == 0 {
+		brackets = exchange.DefaultLeverageBrackets(pos.Symbol)
 	}
-	return entry * (1 + 1.0/float64(leverage))
+	return exchange.LiquidationPrice(pos.Side == "long", pos.EntryPrice, pos.Quantity, pos.Margin, brackets)
 }
 
 // RestoreFromState restores account from a saved state
@@ -299,6 +372,12 @@ func (a *Account) SaveToState(state *State) {
 	}
 }
 
+// positionKeys returns the position keys in order, so that runs over the same
+// bars add up fees, P&L and equity in the same order
+func (a *Account) positionKeys() []string {
+	return slices.Sorted(maps.Keys(a.positions))
+}
+
 // positionKey creates a unique key for a position
 func positionKey(symbol, side string) string {
 	return symbol + "_" + side
@@ -0,0 +1,83 @@
+package backtest
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+// maxStaleBars is how many bars a symbol's last close is carried over a gap
+// in its data before it counts as stale: still marked at that close, but
+// neither shown to the AI nor traded
+const maxStaleBars = 3
+
+// alignedKlines puts every symbol's klines on one timeline, the union of
+// their bar close times in the run's range, so a symbol's bar at a step is
+// an index lookup rather than a scan
+type alignedKlines struct {
+	times []int64           // Bar close times, ascending
+	last  map[string][]int  // symbol -> index of its last kline closed at each step, -1 before it lists
+	fresh map[string][]bool // symbol -> whether that kline is at most maxStaleBars old at each step
+}
+
+// alignKlines builds the timeline of the bars between startTS and endTS and
+// forward-fills each symbol onto it, for at most maxStaleBars steps
+func alignKlines(klines map[string][]Kline, startTS, endTS int64) *alignedKlines {
+	seen := make(map[int64]bool)
+	for _, symbolKlines := range klines {
+		for _, k := range symbolKlines {
+			if k.CloseTime >= startTS && k.OpenTime <= endTS {
+				seen[k.CloseTime] = true
+			}
+		}
+	}
+
+	a := &alignedKlines{
+		times: slices.Sorted(maps.Keys(seen)),
+		last:  make(map[string][]int, len(klines)),
+		fresh: make(map[string][]bool, len(klines)),
+	}
+	for symbol, symbolKlines := range klines {
+		last := make([]int, len(a.times))
+		fresh := make([]bool, len(a.times))
+		j, sinceOwnBar := -1, 0
+		for step, ts := range a.times {
+			for j+1 < len(symbolKlines) && symbolKlines[j+1].CloseTime <= ts {
+				j++
+			}
+			if j >= 0 && symbolKlines[j].CloseTime == ts {
+				sinceOwnBar = 0
+			} else {
+				sinceOwnBar++
+			}
+			last[step] = j
+			fresh[step] = j >= 0 && sinceOwnBar <= maxStaleBars
+		}
+		a.last[symbol] = last
+		a.fresh[symbol] = fresh
+	}
+	return a
+}
+
+// price returns symbol's last close at step, false before it lists
+func (a *alignedKlines) price(klines []Kline, symbol string, step int) (float64, bool) {
+	if !a.listed(symbol, step) {
+		return 0, false
+	}
+	return klines[a.last[symbol][step]].Close, true
+}
+
+// stale reports whether symbol's data at step has a gap longer than maxStaleBars
+func (a *alignedKlines) stale(symbol string, step int) bool {
+	return a.listed(symbol, step) && !a.fresh[symbol][step]
+}
+
+// listed reports whether symbol had a bar by step
+func (a *alignedKlines) listed(symbol string, step int) bool {
+	return a.last[symbol][step] >= 0
+}
+
+// closedBy returns how many of klines closed by ts
+func closedBy(klines []Kline, ts int64) int {
+	return sort.Search(len(klines), func(i int) bool { return klines[i].CloseTime > ts })
+}
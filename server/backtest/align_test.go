@@ -0,0 +1,79 @@
+package backtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlignKlinesLateListingAndGap(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	btc := syntheticKlines(48, start)
+	// Lists at bar 24, then misses bars 35 to 40
+	var late []Kline
+	for _, k := range syntheticKlines(48, start)[24:] {
+		if i := int((k.OpenTime - start) / 3600_000); i < 35 || i > 40 {
+			k.Close /= 10
+			late = append(late, k)
+		}
+	}
+
+	a := alignKlines(map[string][]Kline{"BTCUSDT": btc, "NEWUSDT": late}, start, btc[47].CloseTime)
+	if len(a.times) != 48 {
+		t.Fatalf("timeline has %d bars, want 48", len(a.times))
+	}
+	for step, want := range map[int]bool{0: false, 23: false, 24: true, 47: true} {
+		if got := a.listed("NEWUSDT", step); got != want {
+			t.Errorf("listed at step %d = %v, want %v", step, got, want)
+		}
+	}
+	if _, ok := a.price(late, "NEWUSDT", 23); ok {
+		t.Error("price before listing")
+	}
+	if price, ok := a.price(late, "NEWUSDT", 30); !ok || price != btc[30].Close/10 {
+		t.Errorf("price at step 30 = %v, %v; want its own bar's close %v", price, ok, btc[30].Close/10)
+	}
+
+	// The last close before the gap is carried for maxStaleBars bars, then goes stale
+	for step := 35; step <= 41; step++ {
+		price, _ := a.price(late, "NEWUSDT", step)
+		wantStale := step-34 > maxStaleBars && step <= 40
+		if a.stale("NEWUSDT", step) != wantStale {
+			t.Errorf("stale at step %d = %v, want %v", step, !wantStale, wantStale)
+		}
+		if step <= 40 && price != btc[34].Close/10 {
+			t.Errorf("price at step %d = %v, want the close before the gap %v", step, price, btc[34].Close/10)
+		}
+	}
+}
+
+func TestLateListingNotShownFrozen(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	btc := syntheticKlines(96, start)
+	late := syntheticKlines(96, start)[48:]
+
+	cfg := testConfig("late_listing", btc)
+	cfg.Symbols = []string{"BTCUSDT", "NEWUSDT"}
+	client := &mockTrader{}
+	r := newRunner(cfg, client, nil)
+	r.LoadKlines("BTCUSDT", btc)
+	r.LoadKlines("NEWUSDT", late)
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.GetMetadata().TotalBars; got != 96 {
+		t.Errorf("%d bars, want the full range of 96", got)
+	}
+	notListed := "### NEWUSDT\n- Not yet listed"
+	for i, prompt := range client.prompts {
+		listed := (i+1)*cfg.DecisionCadenceNBars > 48
+		if strings.Contains(prompt, notListed) == listed {
+			t.Errorf("cycle %d: not-yet-listed note shown = %v, want %v", i+1, !listed, !listed)
+		}
+		if !listed && strings.Contains(prompt, "### NEWUSDT\n- Price") {
+			t.Errorf("cycle %d: NEWUSDT priced before it lists", i+1)
+		}
+	}
+}
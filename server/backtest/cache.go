@@ -0,0 +1,137 @@
+package backtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"auto-trader-ahh/mcp"
+)
+
+// aiCache stores AI responses by caller and prompt so repeated runs over the
+// same bars (and the two sides of a comparison) don't pay for identical calls
+type aiCache struct {
+	entries map[string]*mcp.Response
+	mu      sync.RWMutex
+}
+
+func newAICache() *aiCache {
+	return &aiCache{entries: make(map[string]*mcp.Response)}
+}
+
+func (c *aiCache) get(key string) (*mcp.Response, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resp, ok := c.entries[key]
+	return resp, ok
+}
+
+func (c *aiCache) put(key string, resp *mcp.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = resp
+}
+
+// cacheKey hashes the prompts under a namespace, e.g. one per debate participant
+func cacheKey(namespace, model string, messages ...string) string {
+	h := sha256.New()
+	for _, m := range messages {
+		h.Write([]byte(m))
+		h.Write([]byte{0})
+	}
+	return namespace + "|" + model + "|" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachingClient answers from the cache before calling the wrapped client.
+// With replayOnly set, a cache miss is an error instead of a live call.
+type cachingClient struct {
+	mcp.AIClient
+	cache      *aiCache
+	namespace  string
+	replayOnly bool
+}
+
+func (c *aiCache) wrap(client mcp.AIClient, namespace string, replayOnly bool) mcp.AIClient {
+	return &cachingClient{AIClient: client, cache: c, namespace: namespace, replayOnly: replayOnly}
+}
+
+func (c *cachingClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	key := cacheKey(c.namespace, model, systemPrompt, userPrompt)
+	if resp, ok := c.cache.get(key); ok {
+		return resp, nil
+	}
+	if c.replayOnly {
+		return nil, fmt.Errorf("replay only: no cached response for %s", c.namespace)
+	}
+
+	resp, err := c.AIClient.CallWithModel(ctx, model, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, resp)
+	return resp, nil
+}
+
+// CallStructured keeps structured output available through the cache. The format
+// is part of the key so structured and plain answers to the same prompt don't mix.
+func (c *cachingClient) CallStructured(ctx context.Context, model, systemPrompt, userPrompt string, format *mcp.ResponseFormat) (*mcp.Response, error) {
+	sc, ok := c.AIClient.(mcp.StructuredCaller)
+	if !ok {
+		return c.CallWithModel(ctx, model, systemPrompt, userPrompt)
+	}
+
+	key := cacheKey(c.namespace, model, systemPrompt, userPrompt, "structured")
+	if resp, ok := c.cache.get(key); ok {
+		return resp, nil
+	}
+	if c.replayOnly {
+		return nil, fmt.Errorf("replay only: no cached response for %s", c.namespace)
+	}
+
+	resp, err := sc.CallStructured(ctx, model, systemPrompt, userPrompt, format)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, resp)
+	return resp, nil
+}
+
+func (c *cachingClient) CallStream(req *mcp.Request, handler mcp.ChunkHandler) (*mcp.Response, error) {
+	messages := make([]string, 0, len(req.Messages)*2)
+	for _, m := range req.Messages {
+		messages = append(messages, m.Role, m.Content)
+	}
+	// Default settings keep the keys cached before they were configurable
+	if params := generationParams(req); params != mcp.DefaultGenerationParams() {
+		messages = append(messages, fmt.Sprintf("params:%+v", params))
+	}
+	key := cacheKey(c.namespace, req.Model, messages...)
+	if resp, ok := c.cache.get(key); ok {
+		return resp, nil
+	}
+	if c.replayOnly {
+		return nil, fmt.Errorf("replay only: no cached response for %s", c.namespace)
+	}
+
+	resp, err := c.AIClient.CallStream(req, handler)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, resp)
+	return resp, nil
+}
+
+// generationParams returns the sampling settings req was made with
+func generationParams(req *mcp.Request) mcp.GenerationParams {
+	params := mcp.GenerationParams{
+		TopP:            req.TopP,
+		MaxTokens:       req.MaxTokens,
+		ReasoningEffort: req.ReasoningEffort,
+	}
+	if req.Temperature != nil {
+		params.Temperature = *req.Temperature
+	}
+	return params
+}
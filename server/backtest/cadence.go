@@ -0,0 +1,82 @@
+package backtest
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CostEstimate is how much AI a run is expected to use, known before it starts
+type CostEstimate struct {
+	Bars      int `json:"bars"`
+	Decisions int `json:"decisions"`
+	AICalls   int `json:"ai_calls"` // Before parse retries; cached answers are free
+}
+
+// timeframeMillis returns the length of a Binance kline interval, a month
+// counting as 30 days
+func timeframeMillis(tf string) (int64, error) {
+	if len(tf) < 2 {
+		return 0, fmt.Errorf("invalid timeframe %q", tf)
+	}
+	n, err := strconv.ParseInt(tf[:len(tf)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid timeframe %q", tf)
+	}
+	const minute = int64(60000)
+	switch tf[len(tf)-1] {
+	case 'm':
+		return n * minute, nil
+	case 'h':
+		return n * 60 * minute, nil
+	case 'd':
+		return n * 24 * 60 * minute, nil
+	case 'w':
+		return n * 7 * 24 * 60 * minute, nil
+	case 'M':
+		return n * 30 * 24 * 60 * minute, nil
+	}
+	return 0, fmt.Errorf("invalid timeframe %q", tf)
+}
+
+// decidesAt reports whether bar i ends with a decision: every
+// DecisionCadenceNBars bars, or with DecisionCadenceMinutes when the simulated
+// clock crosses a multiple of the cadence during the bar. Cadence boundaries
+// are aligned to the epoch, so the timeframe doesn't change when they fall.
+func (c *Config) decidesAt(i int, bar Kline) bool {
+	if c.DecisionCadenceMinutes > 0 {
+		cadence := int64(c.DecisionCadenceMinutes) * 60000
+		return bar.OpenTime/cadence < (bar.CloseTime+1)/cadence
+	}
+	return (i+1)%c.DecisionCadenceNBars == 0
+}
+
+// Estimate counts the bars and decisions of a validated config over its range
+// and the AI calls they make: one per decision for a single model, one per
+// participant and round plus the vote for a debate, and the single-model twin's.
+func (c *Config) Estimate() (*CostEstimate, error) {
+	tf, err := timeframeMillis(c.DecisionTimeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &CostEstimate{}
+	for open := c.StartTS / tf * tf; open <= c.EndTS; open += tf {
+		if c.decidesAt(est.Bars, Kline{OpenTime: open, CloseTime: open + tf - 1}) {
+			est.Decisions++
+		}
+		est.Bars++
+	}
+
+	est.AICalls = est.Decisions
+	if c.Debate != nil {
+		rounds := c.Debate.Rounds
+		if rounds <= 0 {
+			rounds = 1
+		}
+		est.AICalls = est.Decisions * len(c.Debate.Participants) * (rounds + 1)
+		if c.Debate.CompareSingle {
+			est.AICalls += est.Decisions
+		}
+	}
+	return est, nil
+}
@@ -0,0 +1,83 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/debate"
+)
+
+func TestMinuteCadenceIgnoresTimeframe(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	end := start + 24*time.Hour.Milliseconds() - 1
+
+	for _, tf := range []string{"5m", "15m", "1h"} {
+		cfg := &Config{Symbols: []string{"BTCUSDT"}, DecisionTimeframe: tf, DecisionCadenceMinutes: 60, StartTS: start, EndTS: end}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("%s: %v", tf, err)
+		}
+		est, err := cfg.Estimate()
+		if err != nil {
+			t.Fatalf("%s: %v", tf, err)
+		}
+		if est.Decisions != 24 || est.AICalls != 24 {
+			t.Errorf("%s bars: %d decisions and %d AI calls a day, want 24 hourly", tf, est.Decisions, est.AICalls)
+		}
+	}
+
+	// A bar count cadence scales with the timeframe
+	cfg := &Config{Symbols: []string{"BTCUSDT"}, DecisionTimeframe: "5m", DecisionCadenceNBars: 4, StartTS: start, EndTS: end}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if est, _ := cfg.Estimate(); est.Bars != 288 || est.Decisions != 72 {
+		t.Errorf("4 bar cadence on 5m: %d bars, %d decisions; want 288 and 72", est.Bars, est.Decisions)
+	}
+}
+
+func TestMinuteCadenceDecidesOnClockBoundaries(t *testing.T) {
+	cfg := &Config{DecisionCadenceMinutes: 15}
+	bar := func(open time.Time) Kline {
+		return Kline{OpenTime: open.UnixMilli(), CloseTime: open.Add(5*time.Minute).UnixMilli() - 1}
+	}
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, want := range []bool{false, false, true, false, false, true} {
+		if got := cfg.decidesAt(i, bar(base.Add(time.Duration(i)*5*time.Minute))); got != want {
+			t.Errorf("bar %d (%s): decides %v, want %v", i, base.Add(time.Duration(i)*5*time.Minute).Format("15:04"), got, want)
+		}
+	}
+}
+
+func TestDebateEstimate(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	cfg := &Config{
+		Symbols:           []string{"BTCUSDT"},
+		DecisionTimeframe: "1h",
+		StartTS:           start,
+		EndTS:             start + 8*time.Hour.Milliseconds() - 1,
+		Debate:            &DebateConfig{Participants: make([]debate.CreateParticipantRequest, 3), Rounds: 2, CompareSingle: true},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	est, err := cfg.Estimate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 decisions, each 3 participants over 2 rounds and the vote, plus the single model twin
+	if est.Decisions != 2 || est.AICalls != 2*3*3+2 {
+		t.Errorf("estimate %+v, want 2 decisions and %d calls", est, 2*3*3+2)
+	}
+}
+
+func TestCadenceValidation(t *testing.T) {
+	for _, cfg := range []*Config{
+		{DecisionCadenceNBars: 4, DecisionCadenceMinutes: 60},
+		{DecisionCadenceMinutes: -5},
+		{DecisionTimeframe: "7x"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v: validated", cfg)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package backtest
+
+import (
+	"errors"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/mcp"
+)
+
+// DefaultCheckpointEveryBars is how often a run saves a checkpoint by default
+const DefaultCheckpointEveryBars = 100
+
+// ErrNoCheckpoint is returned when resuming a run that never saved a checkpoint
+var ErrNoCheckpoint = errors.New("backtest has no checkpoint to resume from")
+
+// Checkpoint is a run's progress up to a completed bar: the simulated account
+// and state and everything recorded so far. The simulation has no randomness,
+// so a run resumed from it over the same klines, with the AI cache answering
+// the same prompts, ends exactly like an uninterrupted one.
+type Checkpoint struct {
+	Config      *Config       `json:"config"`
+	NextBar     int           `json:"next_bar"` // First bar the resumed run processes
+	State       *State        `json:"state"`
+	EquityCurve []EquityPoint `json:"equity_curve"`
+	Trades      []TradeEvent  `json:"trades"`
+	Decisions   []DecisionLog `json:"decisions"`
+	TotalBars   int           `json:"total_bars"`
+	StartedAt   time.Time     `json:"started_at"`
+	SavedAt     time.Time     `json:"saved_at"`
+}
+
+// saveCheckpoint hands the run's progress up to nextBar to onCheckpoint
+func (r *Runner) saveCheckpoint(nextBar int) {
+	if r.onCheckpoint == nil {
+		return
+	}
+	r.onCheckpoint(r.checkpoint(nextBar))
+}
+
+// checkpoint copies the run's progress, so the run can go on while it's saved
+func (r *Runner) checkpoint(nextBar int) *Checkpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state := *r.state
+	state.Positions = make(map[string]*Position, len(r.state.Positions))
+	for key, pos := range r.state.Positions {
+		p := *pos
+		state.Positions[key] = &p
+	}
+
+	return &Checkpoint{
+		Config:      r.config,
+		NextBar:     nextBar,
+		State:       &state,
+		EquityCurve: append([]EquityPoint(nil), r.equityCurve...),
+		Trades:      append([]TradeEvent(nil), r.trades...),
+		Decisions:   append([]DecisionLog(nil), r.decisions...),
+		TotalBars:   r.metadata.TotalBars,
+		StartedAt:   r.metadata.StartedAt,
+		SavedAt:     time.Now(),
+	}
+}
+
+// withoutPrompts returns a copy of cp whose decision log leaves out prompts
+// and raw responses, the bulk of a checkpoint, for storing it
+func (cp *Checkpoint) withoutPrompts() *Checkpoint {
+	c := *cp
+	c.Decisions = make([]DecisionLog, len(cp.Decisions))
+	for i, l := range cp.Decisions {
+		l.SystemPrompt, l.UserPrompt, l.RawResponse, l.FirstResponse = "", "", "", ""
+		c.Decisions[i] = l
+	}
+	return &c
+}
+
+// resumeRunner creates a runner that continues from cp. Its klines and
+// leverage brackets still need loading.
+func resumeRunner(cp *Checkpoint, client mcp.AIClient, cache *aiCache) *Runner {
+	r := newRunner(cp.Config, client, cache)
+
+	r.state = cp.State
+	r.account.RestoreFromState(cp.State)
+	r.equityCurve = append(r.equityCurve, cp.EquityCurve...)
+	r.trades = append(r.trades, cp.Trades...)
+	r.decisions = append(r.decisions, cp.Decisions...)
+	r.startBar = cp.NextBar
+
+	r.metadata.Status = StatusPaused
+	r.metadata.StartedAt = cp.StartedAt
+	r.metadata.TotalBars = cp.TotalBars
+	r.metadata.CurrentBar = cp.NextBar - 1
+	r.metadata.CurrentEquity = cp.State.Equity
+	if cp.TotalBars > 0 {
+		r.metadata.Progress = float64(cp.NextBar) / float64(cp.TotalBars) * 100
+	}
+	return r
+}
+
+// copyMarketData gives r the klines and leverage brackets already loaded into from
+func (r *Runner) copyMarketData(from *Runner) {
+	from.mu.RLock()
+	klines := make(map[string][]Kline, len(from.klines))
+	for symbol, k := range from.klines {
+		klines[symbol] = k
+	}
+	brackets := make(map[string][]exchange.LeverageBracket, len(from.account.brackets))
+	for symbol, b := range from.account.brackets {
+		brackets[symbol] = b
+	}
+	from.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.klines = klines
+	r.account.brackets = brackets
+}
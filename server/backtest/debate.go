@@ -0,0 +1,130 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/debate"
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/mcp"
+)
+
+// newDebateEngine builds the debate engine for a debate backtest. Every
+// participant gets its own client key so cached answers stay per participant.
+func newDebateEngine(cfg *DebateConfig, client mcp.AIClient, cache *aiCache, replayOnly bool) (*debate.Engine, []debate.CreateParticipantRequest) {
+	engine := debate.NewEngine()
+	participants := make([]debate.CreateParticipantRequest, len(cfg.Participants))
+
+	for i, p := range cfg.Participants {
+		key := fmt.Sprintf("backtest_%d_%s_%s", i, p.Personality, p.AIModelID)
+		p.Provider = key
+		participants[i] = p
+
+		if cache != nil {
+			engine.RegisterClient(key, cache.wrap(client, key, replayOnly))
+		} else {
+			engine.RegisterClient(key, client)
+		}
+	}
+
+	return engine, participants
+}
+
+// makeDebateDecision runs a compact debate for one decision cycle and turns the
+// consensus into decisions the simulated account can execute
+func (r *Runner) makeDebateDecision(ctx context.Context, decisionCtx *decision.Context) (DecisionLog, []decision.Decision, error) {
+	promptBuilder := decision.NewPromptBuilder(r.lang)
+	systemPrompt := promptBuilder.BuildSystemPrompt()
+	userPrompt := promptBuilder.BuildUserPrompt(decisionCtx)
+
+	decisionLog := DecisionLog{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+	}
+
+	start := time.Now()
+	result, err := r.debateEngine.RunCompact(ctx, &debate.CompactRequest{
+		Participants:  r.participants,
+		Rounds:        r.config.Debate.Rounds,
+		Consensus:     r.config.Debate.Consensus,
+		ParallelCalls: r.config.Debate.ParallelCalls,
+	}, systemPrompt, userPrompt)
+	decisionLog.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return decisionLog, nil, fmt.Errorf("debate failed: %w", err)
+	}
+
+	for _, vote := range result.Votes {
+		pd := ParticipantDecision{
+			Name:        vote.AIModelName,
+			Model:       vote.AIModelID,
+			Personality: vote.Personality,
+			Reasoning:   vote.Reasoning,
+			ParsePath:   vote.ParsePath,
+		}
+		for _, d := range vote.Decisions {
+			pd.Decisions = append(pd.Decisions, toDecision(d))
+		}
+		decisionLog.Participants = append(decisionLog.Participants, pd)
+	}
+	decisionLog.VoteTally = result.Tally
+
+	validationCfg := *r.validationCfg
+	validationCfg.AccountEquity = decisionCtx.Account.TotalEquity
+	validationCfg.CurrentPrices = make(map[string]float64)
+	for symbol, data := range decisionCtx.MarketDataMap {
+		validationCfg.CurrentPrices[symbol] = data.Price
+	}
+
+	var decisions []decision.Decision
+	var rejected []string
+	for _, d := range result.Decisions {
+		dec := r.sizeConsensusDecision(d, decisionCtx.Account.TotalEquity)
+		if err := decision.ValidateDecision(&dec, &validationCfg); err != nil {
+			log.Printf("Debate consensus %s %s rejected at cycle %d: %v", dec.Action, dec.Symbol, r.state.DecisionCycle, err)
+			rejected = append(rejected, fmt.Sprintf("%s %s rejected: %v", dec.Action, dec.Symbol, err))
+			continue
+		}
+		decisions = append(decisions, dec)
+	}
+	decisionLog.Decisions = decisions
+	decisionLog.Error = strings.Join(rejected, "; ")
+
+	return decisionLog, decisions, nil
+}
+
+// sizeConsensusDecision fills in leverage and position size the way the single
+// model would have: PositionPct is the share of equity as position value
+func (r *Runner) sizeConsensusDecision(d *debate.Decision, equity float64) decision.Decision {
+	dec := toDecision(d)
+	if !decision.IsOpeningAction(dec.Action) {
+		return dec
+	}
+
+	if dec.Leverage <= 0 {
+		dec.Leverage = r.config.AltcoinLeverage
+		if isBTCOrETH(dec.Symbol) {
+			dec.Leverage = r.config.BTCETHLeverage
+		}
+	}
+	if dec.PositionSizeUSD <= 0 && d.PositionPct > 0 {
+		dec.PositionSizeUSD = equity * d.PositionPct
+	}
+	return dec
+}
+
+func toDecision(d *debate.Decision) decision.Decision {
+	return decision.Decision{
+		Symbol:          d.Symbol,
+		Action:          d.Action,
+		Leverage:        d.Leverage,
+		PositionSizeUSD: d.PositionSizeUSD,
+		StopLoss:        d.StopLoss,
+		TakeProfit:      d.TakeProfit,
+		Confidence:      d.Confidence,
+		Reasoning:       d.Reasoning,
+	}
+}
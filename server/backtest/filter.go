@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits on a run's tags
+const (
+	MaxTags      = 20
+	MaxTagLength = 40
+)
+
+// How FilterRuns orders runs
+const (
+	SortByDate     = "date"     // Newest first
+	SortByReturn   = "return"   // Best total return first
+	SortByDrawdown = "drawdown" // Smallest max drawdown first
+)
+
+// NormalizeTags trims tags and drops empty and repeated ones, ignoring case,
+// keeping the first spelling of each
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		seen[strings.ToLower(tag)] = true
+		out = append(out, tag)
+	}
+	if len(out) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags, got %d", MaxTags, len(out))
+	}
+	return out, nil
+}
+
+// RunFilter narrows and orders a list of runs. Zero fields match every run.
+type RunFilter struct {
+	Tag    string // Matches a tag, ignoring case
+	Status RunStatus
+	Symbol string    // A symbol the run trades
+	Query  string    // Free text found in the name, description or a tag, ignoring case
+	From   time.Time // Runs started at or after
+	To     time.Time // Runs started at or before
+	Sort   string    // SortByDate by default
+	Limit  int       // 0 for every match
+	Offset int
+}
+
+// Validate checks the sort order, and the range when both ends are set
+func (f *RunFilter) Validate() error {
+	switch f.Sort {
+	case "", SortByDate, SortByReturn, SortByDrawdown:
+	default:
+		return fmt.Errorf("sort must be %s, %s or %s", SortByDate, SortByReturn, SortByDrawdown)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("to is before from")
+	}
+	if f.Limit < 0 || f.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
+
+// matches reports whether run passes every set field of the filter
+func (f *RunFilter) matches(run *RunMetadata) bool {
+	if f.Status != "" && run.Status != f.Status {
+		return false
+	}
+	if f.Tag != "" && !containsFold(run.Tags, f.Tag) {
+		return false
+	}
+	if f.Symbol != "" && (run.Config == nil || !containsFold(run.Config.Symbols, f.Symbol)) {
+		return false
+	}
+	if !f.From.IsZero() && run.StartedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && (run.StartedAt.IsZero() || run.StartedAt.After(f.To)) {
+		return false
+	}
+	if q := strings.ToLower(strings.TrimSpace(f.Query)); q != "" {
+		text := strings.ToLower(run.Name + "\n" + run.Description + "\n" + strings.Join(run.Tags, "\n"))
+		if !strings.Contains(text, q) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterRuns returns the page of runs the filter selects, in its order, and
+// how many runs matched in all. Sorting by return or drawdown reads the
+// summary stored on completion; runs without one go last. Ties go to the
+// newer run.
+func FilterRuns(runs []*RunMetadata, f RunFilter) ([]*RunMetadata, int) {
+	matched := make([]*RunMetadata, 0, len(runs))
+	for _, run := range runs {
+		if f.matches(run) {
+			matched = append(matched, run)
+		}
+	}
+
+	newer := func(a, b *RunMetadata) bool {
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.After(b.StartedAt)
+		}
+		return a.RunID > b.RunID
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if f.Sort == SortByReturn || f.Sort == SortByDrawdown {
+			if (a.Summary == nil) != (b.Summary == nil) {
+				return a.Summary != nil
+			}
+			if a.Summary != nil {
+				if f.Sort == SortByReturn && a.Summary.TotalReturnPct != b.Summary.TotalReturnPct {
+					return a.Summary.TotalReturnPct > b.Summary.TotalReturnPct
+				}
+				if f.Sort == SortByDrawdown && a.Summary.MaxDrawdownPct != b.Summary.MaxDrawdownPct {
+					return a.Summary.MaxDrawdownPct < b.Summary.MaxDrawdownPct
+				}
+			}
+		}
+		return newer(a, b)
+	})
+
+	total := len(matched)
+	if f.Offset >= total {
+		return []*RunMetadata{}, total
+	}
+	matched = matched[f.Offset:]
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+	}
+	return matched, total
+}
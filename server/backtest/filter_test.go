@@ -0,0 +1,142 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags([]string{" momentum ", "", "BTC", "btc", "momentum"})
+	if err != nil || !reflect.DeepEqual(got, []string{"momentum", "BTC"}) {
+		t.Errorf("NormalizeTags = %q, %v", got, err)
+	}
+	if _, err := NormalizeTags([]string{"this tag is far too long to be a useful label"}); err == nil {
+		t.Error("long tag accepted")
+	}
+}
+
+func TestFilterRuns(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	run := func(id string, daysIn int, status RunStatus, ret, dd float64, tags ...string) *RunMetadata {
+		meta := &RunMetadata{
+			RunID:     id,
+			Name:      "run " + id,
+			Status:    status,
+			StartedAt: day.AddDate(0, 0, daysIn),
+			Tags:      tags,
+			Config:    &Config{Symbols: []string{"BTCUSDT"}},
+		}
+		if status == StatusCompleted {
+			meta.Summary = &RunSummary{TotalReturnPct: ret, MaxDrawdownPct: dd}
+		}
+		return meta
+	}
+	runs := []*RunMetadata{
+		run("a", 0, StatusCompleted, 5, 10, "momentum"),
+		run("b", 1, StatusCompleted, 12, 20, "Momentum", "v2"),
+		run("c", 2, StatusFailed, 0, 0, "momentum"),
+		run("d", 3, StatusCompleted, -3, 4),
+	}
+	runs[3].Description = "Mean reversion on ETH"
+	runs[3].Config.Symbols = []string{"ETHUSDT"}
+
+	ids := func(page []*RunMetadata) []string {
+		var out []string
+		for _, r := range page {
+			out = append(out, r.RunID)
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		name   string
+		filter RunFilter
+		want   []string
+		total  int
+	}{
+		{"newest first", RunFilter{}, []string{"d", "c", "b", "a"}, 4},
+		{"tag ignores case", RunFilter{Tag: "MOMENTUM"}, []string{"c", "b", "a"}, 3},
+		{"status", RunFilter{Status: StatusCompleted, Sort: SortByDate}, []string{"d", "b", "a"}, 3},
+		{"symbol", RunFilter{Symbol: "ethusdt"}, []string{"d"}, 1},
+		{"text search", RunFilter{Query: "reversion"}, []string{"d"}, 1},
+		{"range", RunFilter{From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 2)}, []string{"c", "b"}, 2},
+		{"best return, unfinished last", RunFilter{Sort: SortByReturn}, []string{"b", "a", "d", "c"}, 4},
+		{"smallest drawdown", RunFilter{Sort: SortByDrawdown, Status: StatusCompleted}, []string{"d", "a", "b"}, 3},
+		{"page", RunFilter{Sort: SortByReturn, Limit: 2, Offset: 1}, []string{"a", "d"}, 4},
+		{"past the end", RunFilter{Offset: 10}, nil, 4},
+	} {
+		page, total := FilterRuns(runs, tc.filter)
+		if got := ids(page); !reflect.DeepEqual(got, tc.want) || total != tc.total {
+			t.Errorf("%s: got %v of %d, want %v of %d", tc.name, got, total, tc.want, tc.total)
+		}
+	}
+
+	bad := RunFilter{Sort: "sharpe"}
+	if err := bad.Validate(); err == nil {
+		t.Error("unknown sort accepted")
+	}
+}
+
+// TestFinishedRunStored checks a finished run keeps its summary, can be
+// retagged, and is still listed by a manager started after a restart
+func TestFinishedRunStored(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	m := NewManager(&mockTrader{}, nil)
+	if err := m.LoadRuns(store.NewBacktestRunStore()); err != nil {
+		t.Fatal(err)
+	}
+	klines := syntheticKlines(240, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	cfg := testConfig("tagged", klines)
+	cfg.Tags = []string{"baseline"}
+	runner := newRunner(cfg, m.client, nil)
+	runner.LoadKlines("BTCUSDT", klines)
+	m.runners[cfg.RunID] = runner
+	m.run(context.Background(), runner, nil, false)
+
+	meta, err := m.GetStatus("tagged")
+	if err != nil || meta.Summary == nil {
+		t.Fatalf("status = %+v, %v; want a summary", meta, err)
+	}
+	metrics := runner.GetMetrics()
+	if meta.Summary.TotalReturnPct != metrics.TotalReturnPct || meta.Summary.TotalTrades != metrics.TotalTrades {
+		t.Errorf("summary %+v doesn't match metrics", meta.Summary)
+	}
+
+	name := "Baseline, 5x"
+	if _, err := m.Update("tagged", RunUpdate{Name: &name, Tags: []string{"baseline", "keep"}}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	pending := newRunner(testConfig("pending", klines), m.client, nil)
+	m.runners["pending"] = pending
+	if _, err := m.Update("pending", RunUpdate{Name: &name}); !errors.Is(err, ErrRunNotFinished) {
+		t.Errorf("Update of a pending run = %v, want ErrRunNotFinished", err)
+	}
+
+	restarted := NewManager(&mockTrader{}, nil)
+	if err := restarted.LoadRuns(store.NewBacktestRunStore()); err != nil {
+		t.Fatal(err)
+	}
+	runs := restarted.ListRuns()
+	if len(runs) != 1 {
+		t.Fatalf("%d runs after restart, want 1", len(runs))
+	}
+	if got := runs[0]; got.Name != name || !reflect.DeepEqual(got.Tags, []string{"baseline", "keep"}) || got.Summary == nil || *got.Summary != *meta.Summary {
+		t.Errorf("restored run = %+v", got)
+	}
+
+	if err := restarted.Delete("tagged"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if saved, err := store.NewBacktestRunStore().List(); err != nil || len(saved) != 0 {
+		t.Errorf("stored runs after Delete = %d, %v", len(saved), err)
+	}
+}
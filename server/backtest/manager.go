@@ -2,6 +2,8 @@ package backtest
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -9,29 +11,55 @@ import (
 
 	"auto-trader-ahh/exchange"
 	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/store"
 )
 
+// ErrRunActive is returned when resuming a run that is still going
+var ErrRunActive = errors.New("backtest is still running")
+
+// ErrRunNotFinished is returned when updating a run that hasn't completed
+var ErrRunNotFinished = errors.New("backtest has not finished")
+
 // Manager manages multiple backtest runs
 type Manager struct {
-	runners  map[string]*Runner
-	metadata map[string]*RunMetadata
-	cancels  map[string]context.CancelFunc
-	client   mcp.AIClient
-	exchange *exchange.BinanceClient
-	mu       sync.RWMutex
+	runners         map[string]*Runner
+	metadata        map[string]*RunMetadata
+	cancels         map[string]context.CancelFunc
+	comparisons     map[string]*ComparisonReport // debate run ID -> report
+	checkpoints     map[string]*Checkpoint       // run ID -> latest checkpoint
+	monteCarlo      map[string]*MonteCarloResult // run ID -> latest analysis
+	archived        map[string]*RunMetadata      // Finished runs from before a restart, without their results
+	checkpointStore *store.BacktestCheckpointStore
+	runStore        *store.BacktestRunStore
+	cache           *aiCache
+	client          mcp.AIClient
+	exchange        *exchange.BinanceClient
+	onProgress      func(RunMetadata)
+	mu              sync.RWMutex
 }
 
 // NewManager creates a new backtest manager
 func NewManager(client mcp.AIClient, exch *exchange.BinanceClient) *Manager {
 	return &Manager{
-		runners:  make(map[string]*Runner),
-		metadata: make(map[string]*RunMetadata),
-		cancels:  make(map[string]context.CancelFunc),
-		client:   client,
-		exchange: exch,
+		runners:     make(map[string]*Runner),
+		metadata:    make(map[string]*RunMetadata),
+		cancels:     make(map[string]context.CancelFunc),
+		comparisons: make(map[string]*ComparisonReport),
+		checkpoints: make(map[string]*Checkpoint),
+		monteCarlo:  make(map[string]*MonteCarloResult),
+		archived:    make(map[string]*RunMetadata),
+		cache:       newAICache(),
+		client:      client,
+		exchange:    exch,
 	}
 }
 
+// SetProgressListener has fn receive every run's metadata as the run starts,
+// moves on by a whole percent and finishes. Set it before starting runs.
+func (m *Manager) SetProgressListener(fn func(RunMetadata)) {
+	m.onProgress = fn
+}
+
 // Start starts a new backtest run
 func (m *Manager) Start(ctx context.Context, cfg *Config) (string, error) {
 	if cfg.RunID == "" {
@@ -43,61 +71,373 @@ func (m *Manager) Start(ctx context.Context, cfg *Config) (string, error) {
 		cfg.DecisionTimeframe = "5m"
 	}
 
+	cache := m.cacheFor(cfg)
+
+	// A debate run can bring a single-model twin over the same bars
+	var singleCfg *Config
+	if cfg.Debate != nil && cfg.Debate.CompareSingle {
+		c := *cfg
+		c.RunID = cfg.RunID + "_single"
+		c.Name = cfg.Name + " (single model)"
+		c.Debate = nil
+		singleCfg = &c
+	}
+
 	m.mu.Lock()
 	if _, exists := m.runners[cfg.RunID]; exists {
 		m.mu.Unlock()
 		return "", fmt.Errorf("backtest %s already exists", cfg.RunID)
 	}
 
-	runner := NewRunner(cfg, m.client)
+	runner := newRunner(cfg, m.client, cache)
+	runner.onProgress = m.onProgress
 	m.runners[cfg.RunID] = runner
 	m.metadata[cfg.RunID] = runner.GetMetadata()
+
+	var singleRunner *Runner
+	if singleCfg != nil {
+		singleRunner = newRunner(singleCfg, m.client, cache)
+		singleRunner.onProgress = m.onProgress
+		m.runners[singleCfg.RunID] = singleRunner
+		m.metadata[singleCfg.RunID] = singleRunner.GetMetadata()
+	} else {
+		// A comparison is only meaningful over one uninterrupted pass
+		runner.onCheckpoint = m.saveCheckpoint
+	}
 	m.mu.Unlock()
 
 	// Start in background
-	go func() {
-		runCtx, cancel := context.WithCancel(ctx)
-		m.mu.Lock()
-		m.cancels[cfg.RunID] = cancel
+	go m.run(ctx, runner, singleRunner, true)
+
+	return cfg.RunID, nil
+}
+
+// Resume continues a stopped, failed or interrupted run from its last
+// checkpoint, reusing its klines when they are still loaded
+func (m *Manager) Resume(ctx context.Context, runID string) (*Checkpoint, error) {
+	m.mu.Lock()
+	old, exists := m.runners[runID]
+	cp := m.checkpoints[runID]
+	if exists {
+		switch old.GetMetadata().Status {
+		case StatusPending, StatusRunning:
+			m.mu.Unlock()
+			return nil, ErrRunActive
+		case StatusCompleted, StatusLiquidated:
+			m.mu.Unlock()
+			return nil, fmt.Errorf("backtest %s already finished", runID)
+		}
+	}
+	if cp == nil {
 		m.mu.Unlock()
+		if !exists {
+			return nil, fmt.Errorf("backtest %s not found", runID)
+		}
+		return nil, ErrNoCheckpoint
+	}
+
+	runner := resumeRunner(cp, m.client, m.cacheFor(cp.Config))
+	runner.onCheckpoint = m.saveCheckpoint
+	runner.onProgress = m.onProgress
+	runner.metadata.Status = StatusPending
+	loaded := false
+	if exists {
+		runner.copyMarketData(old)
+		loaded = len(runner.klines) > 0
+	}
+	m.runners[runID] = runner
+	m.metadata[runID] = runner.GetMetadata()
+	m.mu.Unlock()
+
+	go m.run(ctx, runner, nil, !loaded)
+
+	return cp, nil
+}
+
+// cacheFor returns the AI cache a run with cfg answers from, nil for none
+func (m *Manager) cacheFor(cfg *Config) *aiCache {
+	if cfg.CacheAI || cfg.ReplayOnly {
+		return m.cache
+	}
+	return nil
+}
+
+// run loads the run's market data when asked, runs it alongside its
+// single-model twin if it has one, and records the outcome
+func (m *Manager) run(ctx context.Context, runner, singleRunner *Runner, fetch bool) {
+	cfg := runner.config
+	runCtx, cancel := context.WithCancel(ctx)
+	m.mu.Lock()
+	m.cancels[cfg.RunID] = cancel
+	if singleRunner != nil {
+		// Stopping either side stops the comparison
+		m.cancels[singleRunner.config.RunID] = cancel
+	}
+	m.mu.Unlock()
+
+	if fetch {
+		runners := []*Runner{runner}
+		if singleRunner != nil {
+			runners = append(runners, singleRunner)
+		}
+		m.loadMarketData(runCtx, cfg, runners)
+	}
+
+	var wg sync.WaitGroup
+	if singleRunner != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := singleRunner.Start(runCtx); err != nil {
+				log.Printf("Backtest %s failed: %v\n", singleRunner.config.RunID, err)
+			}
+		}()
+	}
+
+	if err := runner.Start(runCtx); err != nil {
+		log.Printf("Backtest %s failed: %v\n", cfg.RunID, err)
+	}
+	wg.Wait()
+
+	// Update metadata
+	m.mu.Lock()
+	m.metadata[cfg.RunID] = runner.GetMetadata()
+	if singleRunner != nil {
+		m.metadata[singleRunner.config.RunID] = singleRunner.GetMetadata()
+		m.comparisons[cfg.RunID] = compareRuns(runner, singleRunner)
+	}
+	status := runner.GetMetadata().Status
+	finished := status == StatusCompleted || status == StatusLiquidated
+	if finished {
+		delete(m.checkpoints, cfg.RunID)
+	}
+	m.mu.Unlock()
+
+	if finished {
+		m.deleteStoredCheckpoint(cfg.RunID)
+		m.saveRun(runner.metadataCopy())
+	}
+	if singleRunner != nil {
+		if meta := singleRunner.metadataCopy(); meta.Status == StatusCompleted || meta.Status == StatusLiquidated {
+			m.saveRun(meta)
+		}
+	}
+}
 
-		// Fetch klines from Binance if exchange client is available
-		if m.exchange != nil {
-			for _, symbol := range cfg.Symbols {
-				exchKlines, err := m.exchange.GetHistoricalKlines(runCtx, symbol, cfg.DecisionTimeframe, cfg.StartTS, cfg.EndTS)
-				if err != nil {
-					log.Printf("Backtest %s: failed to fetch klines for %s: %v\n", cfg.RunID, symbol, err)
-					continue
-				}
-				// Convert exchange.Kline to backtest.Kline
-				klines := make([]Kline, len(exchKlines))
-				for i, k := range exchKlines {
-					klines[i] = Kline{
-						OpenTime:  k.OpenTime,
-						Open:      k.Open,
-						High:      k.High,
-						Low:       k.Low,
-						Close:     k.Close,
-						Volume:    k.Volume,
-						CloseTime: k.CloseTime,
-					}
-				}
-				runner.LoadKlines(symbol, klines)
-				log.Printf("Backtest %s: loaded %d klines for %s\n", cfg.RunID, len(klines), symbol)
+// loadMarketData fetches the run's klines and leverage brackets from Binance
+// into each of runners, when an exchange client is available
+func (m *Manager) loadMarketData(ctx context.Context, cfg *Config, runners []*Runner) {
+	if m.exchange == nil {
+		return
+	}
+	for _, symbol := range cfg.Symbols {
+		exchKlines, err := m.exchange.GetHistoricalKlines(ctx, symbol, cfg.DecisionTimeframe, cfg.StartTS, cfg.EndTS)
+		if err != nil {
+			log.Printf("Backtest %s: failed to fetch klines for %s: %v\n", cfg.RunID, symbol, err)
+			continue
+		}
+		// Convert exchange.Kline to backtest.Kline
+		klines := make([]Kline, len(exchKlines))
+		for i, k := range exchKlines {
+			klines[i] = Kline{
+				OpenTime:  k.OpenTime,
+				Open:      k.Open,
+				High:      k.High,
+				Low:       k.Low,
+				Close:     k.Close,
+				Volume:    k.Volume,
+				CloseTime: k.CloseTime,
 			}
 		}
+		for _, r := range runners {
+			r.LoadKlines(symbol, klines)
+		}
+		log.Printf("Backtest %s: loaded %d klines for %s\n", cfg.RunID, len(klines), symbol)
 
-		if err := runner.Start(runCtx); err != nil {
-			log.Printf("Backtest %s failed: %v\n", cfg.RunID, err)
+		brackets, err := m.exchange.GetLeverageBrackets(ctx, symbol)
+		if err != nil {
+			log.Printf("Backtest %s: using default leverage brackets for %s: %v\n", cfg.RunID, symbol, err)
+			continue
 		}
+		for _, r := range runners {
+			r.LoadLeverageBrackets(symbol, brackets)
+		}
+	}
+}
 
-		// Update metadata
-		m.mu.Lock()
-		m.metadata[cfg.RunID] = runner.GetMetadata()
-		m.mu.Unlock()
-	}()
+// saveCheckpoint keeps a run's latest checkpoint, and stores it once
+// LoadCheckpoints gave the manager a store
+func (m *Manager) saveCheckpoint(cp *Checkpoint) {
+	m.mu.Lock()
+	m.checkpoints[cp.Config.RunID] = cp
+	checkpointStore := m.checkpointStore
+	m.mu.Unlock()
 
-	return cfg.RunID, nil
+	if checkpointStore == nil {
+		return
+	}
+	data, err := json.Marshal(cp.withoutPrompts())
+	if err != nil {
+		log.Printf("Backtest %s: failed to encode checkpoint: %v\n", cp.Config.RunID, err)
+		return
+	}
+	if err := checkpointStore.Save(&store.BacktestCheckpoint{
+		RunID:   cp.Config.RunID,
+		UserID:  cp.Config.UserID,
+		NextBar: cp.NextBar,
+		Data:    data,
+	}); err != nil {
+		log.Printf("Backtest %s: failed to save checkpoint: %v\n", cp.Config.RunID, err)
+	}
+}
+
+// deleteStoredCheckpoint drops a run's stored checkpoint, if there's a store
+func (m *Manager) deleteStoredCheckpoint(runID string) {
+	m.mu.RLock()
+	checkpointStore := m.checkpointStore
+	m.mu.RUnlock()
+
+	if checkpointStore == nil {
+		return
+	}
+	if err := checkpointStore.Delete(runID); err != nil {
+		log.Printf("Backtest %s: failed to delete checkpoint: %v\n", runID, err)
+	}
+}
+
+// LoadCheckpoints makes the manager store checkpoints in cs from now on, and
+// lists the runs checkpointed there as paused so that runs a restart
+// interrupted can be resumed. Their prompts weren't stored.
+func (m *Manager) LoadCheckpoints(cs *store.BacktestCheckpointStore) error {
+	saved, err := cs.List()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpointStore = cs
+	for _, sc := range saved {
+		if _, exists := m.runners[sc.RunID]; exists {
+			continue
+		}
+		var cp Checkpoint
+		if err := json.Unmarshal(sc.Data, &cp); err != nil || cp.Config == nil || cp.State == nil {
+			log.Printf("Backtest %s: skipping unreadable checkpoint: %v\n", sc.RunID, err)
+			continue
+		}
+		runner := resumeRunner(&cp, m.client, m.cacheFor(cp.Config))
+		m.runners[sc.RunID] = runner
+		m.metadata[sc.RunID] = runner.GetMetadata()
+		m.checkpoints[sc.RunID] = &cp
+	}
+	if len(saved) > 0 {
+		log.Printf("Loaded %d backtest checkpoints\n", len(saved))
+	}
+	return nil
+}
+
+// saveRun stores a finished run's metadata, once LoadRuns gave the manager a store
+func (m *Manager) saveRun(meta RunMetadata) {
+	m.mu.RLock()
+	runStore := m.runStore
+	m.mu.RUnlock()
+
+	if runStore == nil {
+		return
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		log.Printf("Backtest %s: failed to encode run: %v\n", meta.RunID, err)
+		return
+	}
+	if err := runStore.Save(&store.BacktestRun{RunID: meta.RunID, UserID: meta.UserID, Data: data}); err != nil {
+		log.Printf("Backtest %s: failed to save run: %v\n", meta.RunID, err)
+	}
+}
+
+// LoadRuns makes the manager store finished runs in rs from now on, and lists
+// the runs stored there. A run from before a restart keeps its metadata and
+// summary; its trades, equity curve and decisions weren't stored.
+func (m *Manager) LoadRuns(rs *store.BacktestRunStore) error {
+	saved, err := rs.List()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runStore = rs
+	for _, sr := range saved {
+		if _, exists := m.runners[sr.RunID]; exists {
+			continue
+		}
+		var meta RunMetadata
+		if err := json.Unmarshal(sr.Data, &meta); err != nil {
+			log.Printf("Backtest %s: skipping unreadable run: %v\n", sr.RunID, err)
+			continue
+		}
+		m.archived[sr.RunID] = &meta
+	}
+	if len(saved) > 0 {
+		log.Printf("Loaded %d finished backtest runs\n", len(saved))
+	}
+	return nil
+}
+
+// RunUpdate renames, redescribes or retags a finished run. Nil fields are
+// left as they are; an empty tags list clears them.
+type RunUpdate struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Update applies u to a completed or liquidated run and stores the result
+func (m *Manager) Update(runID string, u RunUpdate) (*RunMetadata, error) {
+	tags, err := NormalizeTags(u.Tags)
+	if err != nil {
+		return nil, err
+	}
+	apply := func(meta *RunMetadata) {
+		if u.Name != nil {
+			meta.Name = *u.Name
+		}
+		if u.Description != nil {
+			meta.Description = *u.Description
+		}
+		if u.Tags != nil {
+			meta.Tags = tags
+		}
+	}
+
+	var meta RunMetadata
+	m.mu.Lock()
+	runner, exists := m.runners[runID]
+	archived, isArchived := m.archived[runID]
+	if isArchived && !exists {
+		apply(archived)
+		meta = *archived
+	}
+	m.mu.Unlock()
+
+	switch {
+	case exists:
+		runner.mu.Lock()
+		if status := runner.metadata.Status; status == StatusCompleted || status == StatusLiquidated {
+			apply(runner.metadata)
+		}
+		meta = *runner.metadata
+		runner.mu.Unlock()
+	case !isArchived:
+		return nil, fmt.Errorf("backtest %s not found", runID)
+	}
+
+	if meta.Status != StatusCompleted && meta.Status != StatusLiquidated {
+		return nil, fmt.Errorf("%w: %s is %s", ErrRunNotFinished, runID, meta.Status)
+	}
+	m.saveRun(meta)
+	return &meta, nil
 }
 
 // Stop stops a running backtest
@@ -121,6 +461,9 @@ func (m *Manager) GetStatus(runID string) (*RunMetadata, error) {
 
 	runner, exists := m.runners[runID]
 	if !exists {
+		if meta, ok := m.archived[runID]; ok {
+			return meta, nil
+		}
 		return nil, fmt.Errorf("backtest %s not found", runID)
 	}
 
@@ -166,15 +509,118 @@ func (m *Manager) GetTrades(runID string) ([]TradeEvent, error) {
 	return runner.GetTrades(), nil
 }
 
-// ListRuns returns all backtest runs
+// GetDecisions returns the decision log of a backtest
+func (m *Manager) GetDecisions(runID string) ([]DecisionLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	runner, exists := m.runners[runID]
+	if !exists {
+		return nil, fmt.Errorf("backtest %s not found", runID)
+	}
+
+	return runner.GetDecisions(), nil
+}
+
+// MonteCarlo analyzes how path-dependent a finished run's result is by
+// resampling its trades, and keeps the analysis with the run
+func (m *Manager) MonteCarlo(ctx context.Context, runID string, cfg MonteCarloConfig, progress func(done int)) (*MonteCarloResult, error) {
+	m.mu.RLock()
+	runner, exists := m.runners[runID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("backtest %s not found", runID)
+	}
+	meta := runner.GetMetadata()
+	if meta.Status != StatusCompleted && meta.Status != StatusLiquidated {
+		return nil, fmt.Errorf("backtest %s is %s, not finished", runID, meta.Status)
+	}
+
+	result, err := RunMonteCarlo(ctx, runner.config.InitialBalance, runner.GetTrades(), cfg, progress)
+	if err != nil {
+		return nil, err
+	}
+	result.RunID = runID
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.runners[runID]; exists {
+		m.monteCarlo[runID] = result
+	}
+	return result, nil
+}
+
+// GetMonteCarlo returns the latest Monte Carlo analysis of a backtest
+func (m *Manager) GetMonteCarlo(runID string) (*MonteCarloResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result, ok := m.monteCarlo[runID]
+	if !ok {
+		return nil, fmt.Errorf("backtest %s has no Monte Carlo analysis", runID)
+	}
+	return result, nil
+}
+
+// GetComparison returns the debate vs single model report of a debate backtest
+func (m *Manager) GetComparison(runID string) (*ComparisonReport, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	runner, exists := m.runners[runID]
+	if !exists {
+		return nil, fmt.Errorf("backtest %s not found", runID)
+	}
+	if report, ok := m.comparisons[runID]; ok {
+		return report, nil
+	}
+
+	debateCfg := runner.GetMetadata().Config.Debate
+	if debateCfg == nil || !debateCfg.CompareSingle {
+		return nil, fmt.Errorf("backtest %s has no single model comparison", runID)
+	}
+	return nil, fmt.Errorf("backtest %s comparison not ready yet", runID)
+}
+
+// compareRuns reports how the debate run did against its single-model twin
+func compareRuns(debateRunner, singleRunner *Runner) *ComparisonReport {
+	debateMetrics := debateRunner.GetMetrics()
+	singleMetrics := singleRunner.GetMetrics()
+
+	report := &ComparisonReport{
+		DebateRunID:     debateRunner.GetMetadata().RunID,
+		SingleRunID:     singleRunner.GetMetadata().RunID,
+		Debate:          debateMetrics,
+		Single:          singleMetrics,
+		ReturnDiffPct:   debateMetrics.TotalReturnPct - singleMetrics.TotalReturnPct,
+		DrawdownDiffPct: debateMetrics.MaxDrawdownPct - singleMetrics.MaxDrawdownPct,
+		SharpeDiff:      debateMetrics.SharpeRatio - singleMetrics.SharpeRatio,
+		CompletedAt:     time.Now(),
+	}
+
+	switch {
+	case report.ReturnDiffPct > 0:
+		report.Winner = "debate"
+	case report.ReturnDiffPct < 0:
+		report.Winner = "single"
+	default:
+		report.Winner = "tie"
+	}
+	return report
+}
+
+// ListRuns returns all backtest runs, with the finished ones from before a restart
 func (m *Manager) ListRuns() []*RunMetadata {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	runs := make([]*RunMetadata, 0, len(m.runners))
+	runs := make([]*RunMetadata, 0, len(m.runners)+len(m.archived))
 	for _, runner := range m.runners {
 		runs = append(runs, runner.GetMetadata())
 	}
+	for _, meta := range m.archived {
+		runs = append(runs, meta)
+	}
 	return runs
 }
 
@@ -184,19 +630,33 @@ func (m *Manager) Delete(runID string) error {
 	defer m.mu.Unlock()
 
 	runner, exists := m.runners[runID]
-	if !exists {
+	_, archived := m.archived[runID]
+	if !exists && !archived {
 		return fmt.Errorf("backtest %s not found", runID)
 	}
 
-	meta := runner.GetMetadata()
-	if meta.Status == StatusRunning {
+	if exists && runner.GetMetadata().Status == StatusRunning {
 		return fmt.Errorf("cannot delete running backtest")
 	}
 
 	delete(m.runners, runID)
 	delete(m.metadata, runID)
 	delete(m.cancels, runID)
-
+	delete(m.comparisons, runID)
+	delete(m.checkpoints, runID)
+	delete(m.monteCarlo, runID)
+	delete(m.archived, runID)
+
+	if m.checkpointStore != nil {
+		if err := m.checkpointStore.Delete(runID); err != nil {
+			log.Printf("Backtest %s: failed to delete checkpoint: %v\n", runID, err)
+		}
+	}
+	if m.runStore != nil {
+		if err := m.runStore.Delete(runID); err != nil {
+			log.Printf("Backtest %s: failed to delete run: %v\n", runID, err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,215 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Monte Carlo resampling methods
+const (
+	MonteCarloBootstrap = "bootstrap" // Draw trades with replacement
+	MonteCarloShuffle   = "shuffle"   // Reorder the run's trades
+)
+
+const (
+	DefaultMonteCarloIterations = 1000
+	MaxMonteCarloIterations     = 100000
+	DefaultMonteCarloSeed       = 1
+)
+
+// MonteCarloConfig configures a Monte Carlo analysis of a run's trades
+type MonteCarloConfig struct {
+	Iterations       int     `json:"iterations"`         // Simulated paths, default 1000
+	Method           string  `json:"method"`             // bootstrap (default) or shuffle
+	Seed             uint64  `json:"seed"`               // Same seed, same result; default 1
+	DrawdownLimitPct float64 `json:"drawdown_limit_pct"` // Drawdown whose breach probability is reported, 0 skips it
+}
+
+// Validate validates and normalizes the config
+func (c *MonteCarloConfig) Validate() error {
+	if c.Iterations <= 0 {
+		c.Iterations = DefaultMonteCarloIterations
+	}
+	if c.Iterations > MaxMonteCarloIterations {
+		return fmt.Errorf("iterations must be at most %d", MaxMonteCarloIterations)
+	}
+	switch c.Method {
+	case "":
+		c.Method = MonteCarloBootstrap
+	case MonteCarloBootstrap, MonteCarloShuffle:
+	default:
+		return fmt.Errorf("unknown method %q", c.Method)
+	}
+	if c.Seed == 0 {
+		c.Seed = DefaultMonteCarloSeed
+	}
+	if c.DrawdownLimitPct < 0 || c.DrawdownLimitPct > 100 {
+		return fmt.Errorf("drawdown_limit_pct must be between 0 and 100")
+	}
+	return nil
+}
+
+// Distribution summarizes a simulated quantity over all paths
+type Distribution struct {
+	P5   float64 `json:"p5"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	Mean float64 `json:"mean"`
+}
+
+// PathStats are the outcomes of one sequence of trades
+type PathStats struct {
+	FinalEquity    float64 `json:"final_equity"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	TimeUnderWater int     `json:"time_under_water"` // Longest run of trades closed below the previous equity peak
+}
+
+// MonteCarloResult is the spread of outcomes the run's trades could have had
+// in another order or mix
+type MonteCarloResult struct {
+	RunID             string           `json:"run_id"`
+	Config            MonteCarloConfig `json:"config"`
+	Trades            int              `json:"trades"` // Closing trades resampled
+	Actual            PathStats        `json:"actual"` // The run's own order of trades
+	FinalEquity       Distribution     `json:"final_equity"`
+	MaxDrawdownPct    Distribution     `json:"max_drawdown_pct"`
+	TimeUnderWater    Distribution     `json:"time_under_water"`
+	BreachProbability float64          `json:"breach_probability"` // Share of paths whose drawdown reached the limit
+	CompletedAt       time.Time        `json:"completed_at"`
+}
+
+// tradeReturns returns each closing trade's realized P&L as a fraction of the
+// realized equity before it, in the order the trades closed
+func tradeReturns(initialBalance float64, trades []TradeEvent) []float64 {
+	var returns []float64
+	equity := initialBalance
+	for _, trade := range trades {
+		if trade.RealizedPnL == 0 || equity <= 0 {
+			continue // Opens and adds realize nothing
+		}
+		returns = append(returns, trade.RealizedPnL/equity)
+		equity += trade.RealizedPnL
+	}
+	return returns
+}
+
+// simulatePath compounds returns from initialBalance. An account that is
+// wiped out stays at zero.
+func simulatePath(initialBalance float64, returns []float64) PathStats {
+	stats := PathStats{FinalEquity: initialBalance}
+	peak := initialBalance
+	underWater := 0
+	for _, r := range returns {
+		stats.FinalEquity *= 1 + r
+		if stats.FinalEquity <= 0 {
+			stats.FinalEquity = 0
+		}
+		if stats.FinalEquity >= peak {
+			peak = stats.FinalEquity
+			underWater = 0
+			continue
+		}
+		underWater++
+		if underWater > stats.TimeUnderWater {
+			stats.TimeUnderWater = underWater
+		}
+		if dd := (peak - stats.FinalEquity) / peak * 100; dd > stats.MaxDrawdownPct {
+			stats.MaxDrawdownPct = dd
+		}
+	}
+	return stats
+}
+
+// RunMonteCarlo resamples the per-trade returns of a run cfg.Iterations times.
+// The seed makes it reproducible. progress, if set, is called with the paths
+// done so far every 1% of them; a canceled ctx stops the analysis.
+func RunMonteCarlo(ctx context.Context, initialBalance float64, trades []TradeEvent, cfg MonteCarloConfig, progress func(done int)) (*MonteCarloResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	returns := tradeReturns(initialBalance, trades)
+	if len(returns) < 2 {
+		return nil, fmt.Errorf("need at least 2 closed trades, the run has %d", len(returns))
+	}
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	path := make([]float64, len(returns))
+	finals := make([]float64, cfg.Iterations)
+	drawdowns := make([]float64, cfg.Iterations)
+	underWater := make([]float64, cfg.Iterations)
+	breaches := 0
+	step := cfg.Iterations / 100
+	if step < 1 {
+		step = 1
+	}
+
+	for i := 0; i < cfg.Iterations; i++ {
+		if cfg.Method == MonteCarloShuffle {
+			copy(path, returns)
+			rng.Shuffle(len(path), func(a, b int) { path[a], path[b] = path[b], path[a] })
+		} else {
+			for j := range path {
+				path[j] = returns[rng.IntN(len(returns))]
+			}
+		}
+
+		stats := simulatePath(initialBalance, path)
+		finals[i] = stats.FinalEquity
+		drawdowns[i] = stats.MaxDrawdownPct
+		underWater[i] = float64(stats.TimeUnderWater)
+		if cfg.DrawdownLimitPct > 0 && stats.MaxDrawdownPct >= cfg.DrawdownLimitPct {
+			breaches++
+		}
+
+		if (i+1)%step == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if progress != nil {
+				progress(i + 1)
+			}
+		}
+	}
+
+	result := &MonteCarloResult{
+		Config:         cfg,
+		Trades:         len(returns),
+		Actual:         simulatePath(initialBalance, returns),
+		FinalEquity:    distribution(finals),
+		MaxDrawdownPct: distribution(drawdowns),
+		TimeUnderWater: distribution(underWater),
+		CompletedAt:    time.Now(),
+	}
+	if cfg.DrawdownLimitPct > 0 {
+		result.BreachProbability = float64(breaches) / float64(cfg.Iterations)
+	}
+	return result, nil
+}
+
+// distribution sorts values and returns their percentiles and mean
+func distribution(values []float64) Distribution {
+	slices.Sort(values)
+	return Distribution{
+		P5:   percentile(values, 5),
+		P50:  percentile(values, 50),
+		P95:  percentile(values, 95),
+		Mean: mean(values),
+	}
+}
+
+// percentile interpolates the pct percentile of sorted values
+func percentile(sorted []float64, pct float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := pct / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*frac
+}
@@ -0,0 +1,74 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func closingTrades(pnls ...float64) []TradeEvent {
+	trades := make([]TradeEvent, 0, 2*len(pnls))
+	for _, pnl := range pnls {
+		trades = append(trades,
+			TradeEvent{Symbol: "BTCUSDT", Action: "open_long", Side: "long"},
+			TradeEvent{Symbol: "BTCUSDT", Action: "close_long", Side: "long", RealizedPnL: pnl})
+	}
+	return trades
+}
+
+func TestMonteCarloIsReproducible(t *testing.T) {
+	trades := closingTrades(300, -200, 150, -400, 500, -100, 250, -300)
+	cfg := MonteCarloConfig{Iterations: 500, Seed: 42, DrawdownLimitPct: 5}
+
+	var progress []int
+	first, err := RunMonteCarlo(context.Background(), 10000, trades, cfg, func(done int) { progress = append(progress, done) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := RunMonteCarlo(context.Background(), 10000, trades, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.CompletedAt = first.CompletedAt
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed, different results:\n%+v\n%+v", first, second)
+	}
+
+	if len(progress) != 100 || progress[99] != 500 {
+		t.Errorf("progress reported %d times, last %v; want every 1%%", len(progress), progress[len(progress)-1:])
+	}
+	if first.Trades != 8 || math.Abs(first.Actual.FinalEquity-10200) > 1e-6 {
+		t.Errorf("actual path = %d trades ending at %.2f, want 8 ending at 10200", first.Trades, first.Actual.FinalEquity)
+	}
+	if d := first.FinalEquity; !(d.P5 <= d.P50 && d.P50 <= d.P95) {
+		t.Errorf("final equity percentiles out of order: %+v", d)
+	}
+	if first.BreachProbability <= 0 || first.BreachProbability >= 1 {
+		t.Errorf("breach probability %.3f, want some but not all paths past 5%%", first.BreachProbability)
+	}
+}
+
+func TestMonteCarloShuffleKeepsFinalEquity(t *testing.T) {
+	trades := closingTrades(300, -200, 150, -400, 500)
+	result, err := RunMonteCarlo(context.Background(), 10000, trades, MonteCarloConfig{Iterations: 200, Method: MonteCarloShuffle}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compounding the same returns in any order ends at the same equity
+	if d := result.FinalEquity; math.Abs(d.P95-d.P5) > 1e-6 || math.Abs(d.P50-result.Actual.FinalEquity) > 1e-6 {
+		t.Errorf("shuffled final equity %+v, want every path at %.2f", d, result.Actual.FinalEquity)
+	}
+	if d := result.MaxDrawdownPct; d.P5 == d.P95 {
+		t.Errorf("shuffled drawdowns all %.2f, order should change them", d.P5)
+	}
+}
+
+func TestMonteCarloNeedsTrades(t *testing.T) {
+	if _, err := RunMonteCarlo(context.Background(), 10000, closingTrades(100), MonteCarloConfig{}, nil); err == nil {
+		t.Error("one closed trade analyzed, want an error")
+	}
+	if _, err := RunMonteCarlo(context.Background(), 10000, closingTrades(100, 200), MonteCarloConfig{Method: "jackknife"}, nil); err == nil {
+		t.Error("unknown method accepted")
+	}
+}
@@ -0,0 +1,128 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/store"
+)
+
+// PositionSourceBacktest marks position records built from a backtest's trades
+const PositionSourceBacktest = "backtest"
+
+// closeReasons maps the actions of closing trades to a live trader's
+// PositionStore close reasons
+var closeReasons = map[string]string{
+	decision.ActionCloseLong:  "ai_close",
+	decision.ActionCloseShort: "ai_close",
+	"stop_loss":               "stop_loss",
+	"take_profit":             "take_profit",
+	"liquidated":              "liquidated",
+}
+
+// Positions pairs a run's trades into closed positions shaped like a live
+// trader's PositionStore rows, in order of closing. P&L is before fees as it
+// is live; positions still open at the end of the run are left out.
+func Positions(trades []TradeEvent) []store.TraderPosition {
+	type openPosition struct {
+		row        store.TraderPosition
+		entryValue float64
+		exitQty    float64
+		exitValue  float64
+	}
+	open := make(map[string]*openPosition)
+	positions := []store.TraderPosition{}
+
+	for _, trade := range trades {
+		key := positionKey(trade.Symbol, trade.Side)
+		switch trade.Action {
+		case decision.ActionOpenLong, decision.ActionOpenShort:
+			p := open[key]
+			if p == nil {
+				entryTime := time.UnixMilli(trade.Timestamp)
+				p = &openPosition{row: store.TraderPosition{
+					ExchangeType:       PositionSourceBacktest,
+					ExchangePositionID: fmt.Sprintf("%s_%d", key, trade.Timestamp),
+					Symbol:             trade.Symbol,
+					Side:               trade.Side,
+					EntryTime:          entryTime,
+					Leverage:           trade.Leverage,
+					Status:             store.PositionStatusOpen,
+					Source:             PositionSourceBacktest,
+					CreatedAt:          entryTime,
+				}}
+				open[key] = p
+			}
+			p.row.EntryQuantity += trade.Quantity
+			p.entryValue += trade.Price * trade.Quantity
+			p.row.EntryPrice = p.entryValue / p.row.EntryQuantity
+			p.row.EntryFee += trade.Fee
+
+		default:
+			reason, ok := closeReasons[trade.Action]
+			p := open[key]
+			if !ok || p == nil {
+				continue
+			}
+			p.exitQty += trade.Quantity
+			p.exitValue += trade.Price * trade.Quantity
+			// A close's fee carries its share of the entry fee, and its P&L
+			// is after both
+			p.row.Fee += trade.Fee
+			p.row.RealizedPnL += trade.RealizedPnL + trade.Fee
+			if p.exitQty < p.row.EntryQuantity*(1-1e-9) {
+				continue
+			}
+
+			exitTime := time.UnixMilli(trade.Timestamp)
+			p.row.ID = int64(len(positions) + 1)
+			p.row.Quantity = p.row.EntryQuantity
+			p.row.ExitPrice = p.exitValue / p.exitQty
+			p.row.ExitTime = exitTime
+			p.row.Status = store.PositionStatusClosed
+			p.row.CloseReason = reason
+			p.row.UpdatedAt = exitTime
+			positions = append(positions, p.row)
+			delete(open, key)
+		}
+	}
+	return positions
+}
+
+// PositionSummary describes a set of closed positions, for comparing a
+// backtest with a live trader
+type PositionSummary struct {
+	Trades          int          `json:"trades"`
+	WinRate         float64      `json:"win_rate"`
+	AvgHoldMins     float64      `json:"avg_hold_mins"`
+	TotalPnL        float64      `json:"total_pnl"` // Before fees
+	Fees            float64      `json:"fees"`
+	PnLDistribution Distribution `json:"pnl_distribution"` // Per position
+}
+
+// SummarizePositions computes the win rate, hold time and P&L distribution of
+// closed positions
+func SummarizePositions(positions []store.TraderPosition) PositionSummary {
+	s := PositionSummary{Trades: len(positions)}
+	if len(positions) == 0 {
+		return s
+	}
+
+	pnls := make([]float64, 0, len(positions))
+	var wins int
+	var holdMins float64
+	for _, pos := range positions {
+		if pos.RealizedPnL > 0 {
+			wins++
+		}
+		holdMins += pos.ExitTime.Sub(pos.EntryTime).Minutes()
+		s.TotalPnL += pos.RealizedPnL
+		s.Fees += pos.Fee
+		pnls = append(pnls, pos.RealizedPnL)
+	}
+	s.WinRate = float64(wins) / float64(len(positions)) * 100
+	s.AvgHoldMins = holdMins / float64(len(positions))
+	s.PnLDistribution = distribution(pnls)
+	return s
+}
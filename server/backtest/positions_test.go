@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+func TestPositionsPairsTrades(t *testing.T) {
+	const minute = int64(time.Minute / time.Millisecond)
+	trades := []TradeEvent{
+		{Timestamp: 0, Symbol: "BTCUSDT", Action: "open_long", Side: "long", Quantity: 1, Price: 100, Fee: 0.5, Leverage: 5},
+		{Timestamp: 10 * minute, Symbol: "ETHUSDT", Action: "open_short", Side: "short", Quantity: 2, Price: 50, Fee: 0.2, Leverage: 3},
+		{Timestamp: 20 * minute, Symbol: "BTCUSDT", Action: "open_long", Side: "long", Quantity: 1, Price: 110, Fee: 0.5, Leverage: 5},
+		// Net P&L: the fee carries the open fee's share
+		{Timestamp: 30 * minute, Symbol: "BTCUSDT", Action: "close_long", Side: "long", Quantity: 1, Price: 120, Fee: 1, RealizedPnL: 14},
+		{Timestamp: 60 * minute, Symbol: "BTCUSDT", Action: "take_profit", Side: "long", Quantity: 1, Price: 130, Fee: 1, RealizedPnL: 24},
+		{Timestamp: 70 * minute, Symbol: "ETHUSDT", Action: "stop_loss", Side: "short", Quantity: 2, Price: 55, Fee: 0.4, RealizedPnL: -10.4},
+		// Still open at the end
+		{Timestamp: 80 * minute, Symbol: "SOLUSDT", Action: "open_long", Side: "long", Quantity: 1, Price: 10},
+	}
+
+	positions := Positions(trades)
+	if len(positions) != 2 {
+		t.Fatalf("got %d positions, want 2", len(positions))
+	}
+
+	btc := positions[0]
+	if btc.Symbol != "BTCUSDT" || btc.Status != store.PositionStatusClosed || btc.CloseReason != "take_profit" {
+		t.Errorf("BTC position = %+v", btc)
+	}
+	if btc.EntryPrice != 105 || btc.ExitPrice != 125 || btc.EntryQuantity != 2 || btc.Leverage != 5 {
+		t.Errorf("BTC prices %v -> %v qty %v leverage %d", btc.EntryPrice, btc.ExitPrice, btc.EntryQuantity, btc.Leverage)
+	}
+	if btc.RealizedPnL != 40 || btc.Fee != 2 || btc.EntryFee != 1 {
+		t.Errorf("BTC pnl %v fee %v entry fee %v, want 40, 2, 1", btc.RealizedPnL, btc.Fee, btc.EntryFee)
+	}
+	if hold := btc.ExitTime.Sub(btc.EntryTime); hold != time.Hour {
+		t.Errorf("BTC held %v, want 1h", hold)
+	}
+
+	eth := positions[1]
+	if eth.Side != "short" || eth.CloseReason != "stop_loss" || math.Abs(eth.RealizedPnL+10) > 1e-9 {
+		t.Errorf("ETH position = %+v", eth)
+	}
+	if positions[0].ID == positions[1].ID {
+		t.Error("positions share an ID")
+	}
+}
+
+func TestSummarizePositions(t *testing.T) {
+	start := time.Unix(0, 0)
+	positions := []store.TraderPosition{
+		{EntryTime: start, ExitTime: start.Add(30 * time.Minute), RealizedPnL: 10, Fee: 1},
+		{EntryTime: start, ExitTime: start.Add(90 * time.Minute), RealizedPnL: -4, Fee: 1},
+	}
+	s := SummarizePositions(positions)
+	if s.Trades != 2 || s.WinRate != 50 || s.AvgHoldMins != 60 {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.TotalPnL != 6 || s.Fees != 2 || s.PnLDistribution.Mean != 3 {
+		t.Errorf("P&L %v fees %v mean %v, want 6, 2, 3", s.TotalPnL, s.Fees, s.PnLDistribution.Mean)
+	}
+
+	if empty := SummarizePositions(nil); empty.Trades != 0 || empty.WinRate != 0 {
+		t.Errorf("empty summary = %+v", empty)
+	}
+}
@@ -2,54 +2,81 @@ package backtest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
+	"auto-trader-ahh/debate"
 	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/market"
 	"auto-trader-ahh/mcp"
 )
 
 // Runner executes a backtest simulation
 type Runner struct {
-	config      *Config
-	account     *Account
-	state       *State
-	engine      *decision.Engine
-	klines      map[string][]Kline // symbol -> klines
-	metadata    *RunMetadata
-	equityCurve []EquityPoint
-	trades      []TradeEvent
-	decisions   []DecisionLog
-	mu          sync.RWMutex
-	cancel      context.CancelFunc
+	config        *Config
+	account       *Account
+	state         *State
+	engine        *decision.Engine
+	lang          decision.Language
+	validationCfg *decision.ValidationConfig
+	debateEngine  *debate.Engine                    // Debate mode only
+	participants  []debate.CreateParticipantRequest // Debate mode only
+	klines        map[string][]Kline                // symbol -> klines
+	aligned       *alignedKlines                    // klines on the run's timeline, built when it starts
+	metadata      *RunMetadata
+	equityCurve   []EquityPoint
+	trades        []TradeEvent
+	decisions     []DecisionLog
+	startBar      int               // First bar to process, past 0 when resumed
+	onCheckpoint  func(*Checkpoint) // Receives the run's checkpoints, nil keeps none
+	onProgress    func(RunMetadata) // Receives the run's metadata as it moves on, nil for none
+	mu            sync.RWMutex
+	cancel        context.CancelFunc
 }
 
 // NewRunner creates a new backtest runner
 func NewRunner(cfg *Config, client mcp.AIClient) *Runner {
+	return newRunner(cfg, client, nil)
+}
+
+// newRunner creates a runner whose AI calls go through cache when it is set
+func newRunner(cfg *Config, client mcp.AIClient, cache *aiCache) *Runner {
 	if err := cfg.Validate(); err != nil {
 		log.Printf("Config validation warning: %v", err)
 	}
 
-	lang := decision.LangEnglish
-	if cfg.Language == "zh-CN" {
-		lang = decision.LangChinese
+	lang := decision.Language(cfg.Language)
+
+	decisionClient := client
+	if cache != nil {
+		decisionClient = cache.wrap(client, "single", cfg.ReplayOnly)
 	}
 
 	r := &Runner{
 		config:  cfg,
 		account: NewAccount(cfg.InitialBalance, cfg.FeeBps, cfg.SlippageBps),
 		state:   NewState(cfg.InitialBalance),
-		engine:  decision.NewEngine(client, lang),
+		engine:  decision.NewEngine(decisionClient, lang),
+		lang:    lang,
 		klines:  make(map[string][]Kline),
 		metadata: &RunMetadata{
 			RunID:       cfg.RunID,
 			UserID:      cfg.UserID,
 			Name:        cfg.Name,
 			Description: cfg.Description,
+			Tags:        cfg.Tags,
 			Status:      StatusPending,
 			Config:      cfg,
+
+			StrategyID:        cfg.StrategyID,
+			StrategyVersion:   cfg.StrategyVersion,
+			StrategyUpdatedAt: cfg.StrategyUpdatedAt,
 		},
 		equityCurve: make([]EquityPoint, 0),
 		trades:      make([]TradeEvent, 0),
@@ -57,16 +84,24 @@ func NewRunner(cfg *Config, client mcp.AIClient) *Runner {
 	}
 
 	// Set validation config
-	r.engine.SetValidationConfig(&decision.ValidationConfig{
-		AccountEquity:     cfg.InitialBalance,
-		BTCETHLeverage:    cfg.BTCETHLeverage,
-		AltcoinLeverage:   cfg.AltcoinLeverage,
-		BTCETHPosRatio:    cfg.BTCETHPosRatio,
-		AltcoinPosRatio:   cfg.AltcoinPosRatio,
-		MinPositionBTCETH: 60,
-		MinPositionAlt:    12,
-		MinRiskReward:     3.0,
-	})
+	r.validationCfg = &decision.ValidationConfig{
+		AccountEquity:      cfg.InitialBalance,
+		BTCETHLeverage:     cfg.BTCETHLeverage,
+		AltcoinLeverage:    cfg.AltcoinLeverage,
+		BTCETHPosRatio:     cfg.BTCETHPosRatio,
+		AltcoinPosRatio:    cfg.AltcoinPosRatio,
+		ExposureLimits:     cfg.ExposureLimits,
+		MinPositionBTCETH:  60,
+		MinPositionAlt:     12,
+		MinRiskReward:      3.0,
+		MinStopDistancePct: 0.2,
+	}
+	r.engine.SetValidationConfig(r.validationCfg)
+	r.engine.SetGenerationParams(cfg.AI.GenerationParams())
+
+	if cfg.Debate != nil {
+		r.debateEngine, r.participants = newDebateEngine(cfg.Debate, client, cache, cfg.ReplayOnly)
+	}
 
 	return r
 }
@@ -78,6 +113,13 @@ func (r *Runner) LoadKlines(symbol string, klines []Kline) {
 	r.klines[symbol] = klines
 }
 
+// LoadLeverageBrackets sets symbol's maintenance margin tiers for liquidations
+func (r *Runner) LoadLeverageBrackets(symbol string, brackets []exchange.LeverageBracket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.account.SetLeverageBrackets(symbol, brackets)
+}
+
 // GetMetadata returns current run metadata
 func (r *Runner) GetMetadata() *RunMetadata {
 	r.mu.RLock()
@@ -85,6 +127,13 @@ func (r *Runner) GetMetadata() *RunMetadata {
 	return r.metadata
 }
 
+// metadataCopy returns a copy of the run's metadata, safe to read while it runs
+func (r *Runner) metadataCopy() RunMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return *r.metadata
+}
+
 // GetState returns current state
 func (r *Runner) GetState() *State {
 	r.mu.RLock()
@@ -106,11 +155,42 @@ func (r *Runner) GetTrades() []TradeEvent {
 	return r.trades
 }
 
+// GetDecisions returns the decision log
+func (r *Runner) GetDecisions() []DecisionLog {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.decisions
+}
+
 // GetMetrics calculates and returns performance metrics
 func (r *Runner) GetMetrics() *Metrics {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return CalculateMetrics(r.config.InitialBalance, r.equityCurve, r.trades)
+	metrics := CalculateMetrics(r.config.InitialBalance, r.equityCurve, r.trades)
+	metrics.StructuredParses, metrics.RegexFallbacks = countParsePaths(r.decisions)
+	for _, l := range r.decisions {
+		metrics.ParseRetries += l.Retries
+	}
+	return metrics
+}
+
+// countParsePaths tallies parse paths over single-model answers and debate votes
+func countParsePaths(logs []DecisionLog) (structured, regex int) {
+	count := func(path string) {
+		switch path {
+		case decision.ParsePathStructured:
+			structured++
+		case decision.ParsePathRegex:
+			regex++
+		}
+	}
+	for _, l := range logs {
+		count(l.ParsePath)
+		for _, p := range l.Participants {
+			count(p.ParsePath)
+		}
+	}
+	return structured, regex
 }
 
 // Start begins the backtest simulation
@@ -120,14 +200,22 @@ func (r *Runner) Start(ctx context.Context) error {
 
 	r.mu.Lock()
 	r.metadata.Status = StatusRunning
-	r.metadata.StartedAt = time.Now()
+	if r.metadata.StartedAt.IsZero() {
+		r.metadata.StartedAt = time.Now()
+	}
+	r.metadata.CompletedAt = time.Time{}
+	r.metadata.Error = ""
 	r.mu.Unlock()
+	r.reportProgress()
 
 	// Run the simulation
 	err := r.loop(ctx)
 
 	r.mu.Lock()
-	if err != nil {
+	if errors.Is(err, context.Canceled) {
+		// Stopped, it can resume from its last checkpoint
+		r.metadata.Status = StatusPaused
+	} else if err != nil {
 		r.metadata.Status = StatusFailed
 		r.metadata.Error = err.Error()
 	} else if r.state.Liquidated {
@@ -135,12 +223,32 @@ func (r *Runner) Start(ctx context.Context) error {
 	} else {
 		r.metadata.Status = StatusCompleted
 	}
+	if err == nil {
+		m := CalculateMetrics(r.config.InitialBalance, r.equityCurve, r.trades)
+		r.metadata.Summary = &RunSummary{
+			TotalReturnPct: m.TotalReturnPct,
+			MaxDrawdownPct: m.MaxDrawdownPct,
+			SharpeRatio:    m.SharpeRatio,
+			WinRate:        m.WinRate,
+			TotalTrades:    m.TotalTrades,
+			FinalEquity:    m.FinalEquity,
+		}
+	}
 	r.metadata.CompletedAt = time.Now()
 	r.mu.Unlock()
+	r.reportProgress()
 
 	return err
 }
 
+// reportProgress hands a copy of the run's metadata to onProgress
+func (r *Runner) reportProgress() {
+	if r.onProgress == nil {
+		return
+	}
+	r.onProgress(r.metadataCopy())
+}
+
 // Stop stops the running backtest
 func (r *Runner) Stop() {
 	if r.cancel != nil {
@@ -155,43 +263,43 @@ func (r *Runner) loop(ctx context.Context) error {
 		return fmt.Errorf("no klines loaded")
 	}
 
-	// Find the symbol with most klines for iteration
-	var primarySymbol string
-	var primaryKlines []Kline
-	for symbol, klines := range r.klines {
-		if len(klines) > len(primaryKlines) {
-			primarySymbol = symbol
-			primaryKlines = klines
-		}
-	}
-
-	if len(primaryKlines) == 0 {
+	// Step through the bars of every symbol together, each at its own latest close
+	r.aligned = alignKlines(r.klines, r.config.StartTS, r.config.EndTS)
+	if len(r.aligned.times) == 0 {
 		return fmt.Errorf("no klines available for simulation")
 	}
+	timeframe, _ := timeframeMillis(r.config.DecisionTimeframe)
 
-	// Filter klines to time range
-	var filteredKlines []Kline
-	for _, k := range primaryKlines {
-		if k.CloseTime >= r.config.StartTS && k.OpenTime <= r.config.EndTS {
-			filteredKlines = append(filteredKlines, k)
-		}
-	}
-
-	totalBars := len(filteredKlines)
+	totalBars := len(r.aligned.times)
 	r.mu.Lock()
 	r.metadata.TotalBars = totalBars
 	r.mu.Unlock()
 
-	log.Printf("Starting backtest: %s, %d bars from %s to %s",
-		primarySymbol, totalBars,
+	log.Printf("Starting backtest: %v, %d bars from %s to %s",
+		slices.Sorted(maps.Keys(r.klines)), totalBars,
 		time.Unix(r.config.StartTS/1000, 0).Format(time.RFC3339),
 		time.Unix(r.config.EndTS/1000, 0).Format(time.RFC3339))
 
+	if r.startBar > 0 {
+		log.Printf("Resuming backtest %s at bar %d", r.config.RunID, r.startBar)
+	}
+
 	// Main loop through bars
-	for i, bar := range filteredKlines {
+	reportedPct := -1
+	for i, closeTime := range r.aligned.times {
+		if i < r.startBar {
+			continue
+		}
+		bar := Kline{OpenTime: closeTime + 1 - timeframe, CloseTime: closeTime}
+		if i > 0 {
+			// Across a gap in every symbol's data the clock still moved on from the last bar
+			bar.OpenTime = r.aligned.times[i-1] + 1
+		}
 		select {
 		case <-ctx.Done():
 			log.Printf("Backtest cancelled at bar %d", i)
+			// Every bar before this one is complete
+			r.saveCheckpoint(i)
 			return ctx.Err()
 		default:
 		}
@@ -200,7 +308,7 @@ func (r *Runner) loop(ctx context.Context) error {
 		r.state.BarTimestamp = bar.CloseTime
 
 		// Build price map for all symbols
-		priceMap := r.buildPriceMap(bar.CloseTime)
+		priceMap := r.buildPriceMap(i)
 
 		// Check liquidation
 		liqEvents, liqNote, err := r.account.CheckLiquidation(priceMap, bar.CloseTime, r.state.DecisionCycle)
@@ -217,25 +325,59 @@ func (r *Runner) loop(ctx context.Context) error {
 			break
 		}
 
+		// Simulated SL/TP orders
+		stopEvents, err := r.account.CheckStops(priceMap, bar.CloseTime, r.state.DecisionCycle)
+		if err != nil {
+			return fmt.Errorf("stop check failed: %w", err)
+		}
+		if len(stopEvents) > 0 {
+			r.mu.Lock()
+			r.trades = append(r.trades, stopEvents...)
+			r.mu.Unlock()
+		}
+
 		// Check if decision should trigger
-		if (i+1)%r.config.DecisionCadenceNBars == 0 {
+		if r.config.decidesAt(i, bar) {
 			r.state.DecisionCycle++
 
 			// Make AI decision
-			decisionCtx := r.buildDecisionContext(bar.CloseTime, priceMap)
-			fullDecision, err := r.engine.MakeDecision(decisionCtx)
-
-			decisionLog := DecisionLog{
-				Timestamp:    bar.CloseTime,
-				Cycle:        r.state.DecisionCycle,
-				BarIndex:     i,
-				SystemPrompt: fullDecision.SystemPrompt,
-				UserPrompt:   fullDecision.UserPrompt,
-				RawResponse:  fullDecision.RawResponse,
-				CoTTrace:     fullDecision.CoTTrace,
-				Decisions:    fullDecision.Decisions,
-				DurationMs:   fullDecision.AIRequestDurationMs,
+			decisionCtx := r.buildDecisionContext(i, bar.CloseTime, priceMap)
+
+			var decisionLog DecisionLog
+			var decisions []decision.Decision
+			var err error
+			if r.debateEngine != nil {
+				decisionLog, decisions, err = r.makeDebateDecision(ctx, decisionCtx)
+				if err != nil && ctx.Err() != nil {
+					log.Printf("Backtest cancelled at bar %d", i)
+					return ctx.Err()
+				}
+			} else {
+				var fullDecision *decision.FullDecision
+				fullDecision, err = r.engine.MakeDecision(decisionCtx)
+				if fullDecision != nil {
+					decisionLog = DecisionLog{
+						SystemPrompt: fullDecision.SystemPrompt,
+						UserPrompt:   fullDecision.UserPrompt,
+						RawResponse:  fullDecision.RawResponse,
+						CoTTrace:     fullDecision.CoTTrace,
+						Decisions:    fullDecision.Decisions,
+						DurationMs:   fullDecision.AIRequestDurationMs,
+						ParsePath:    fullDecision.ParsePath,
+
+						Retries:       fullDecision.Retries,
+						RetryReason:   fullDecision.RetryReason,
+						FirstResponse: fullDecision.FirstResponse,
+						AIParams:      &fullDecision.AIParams,
+						PromptFit:     &fullDecision.PromptFit,
+					}
+					decisions = fullDecision.Decisions
+				}
 			}
+
+			decisionLog.Timestamp = bar.CloseTime
+			decisionLog.Cycle = r.state.DecisionCycle
+			decisionLog.BarIndex = i
 			if err != nil {
 				decisionLog.Error = err.Error()
 				log.Printf("Decision error at cycle %d: %v", r.state.DecisionCycle, err)
@@ -247,7 +389,7 @@ func (r *Runner) loop(ctx context.Context) error {
 
 			// Execute decisions
 			if err == nil {
-				r.executeDecisions(fullDecision.Decisions, bar.CloseTime, priceMap)
+				r.executeDecisions(decisions, bar.CloseTime, priceMap)
 			}
 		}
 
@@ -285,27 +427,34 @@ func (r *Runner) loop(ctx context.Context) error {
 		r.metadata.CurrentBar = i
 		r.metadata.Progress = float64(i+1) / float64(totalBars) * 100
 		r.metadata.CurrentEquity = equity
+		pct := int(r.metadata.Progress)
 		r.mu.Unlock()
 
+		// Report each whole percent rather than every bar
+		if pct != reportedPct {
+			reportedPct = pct
+			r.reportProgress()
+		}
+
 		r.state.LastUpdate = time.Now()
 		r.account.SaveToState(r.state)
+
+		if (i+1)%r.config.CheckpointEveryBars == 0 {
+			r.saveCheckpoint(i + 1)
+		}
 	}
 
 	log.Printf("Backtest completed: %d cycles, final equity: %.2f", r.state.DecisionCycle, r.state.Equity)
 	return nil
 }
 
-// buildPriceMap builds a map of current prices for all symbols
-func (r *Runner) buildPriceMap(ts int64) map[string]float64 {
+// buildPriceMap builds a map of the prices of the symbols listed by step
+func (r *Runner) buildPriceMap(step int) map[string]float64 {
 	priceMap := make(map[string]float64)
 
 	for symbol, klines := range r.klines {
-		// Find the kline at or before timestamp
-		for i := len(klines) - 1; i >= 0; i-- {
-			if klines[i].CloseTime <= ts {
-				priceMap[symbol] = klines[i].Close
-				break
-			}
+		if price, ok := r.aligned.price(klines, symbol, step); ok {
+			priceMap[symbol] = price
 		}
 	}
 
@@ -313,12 +462,13 @@ func (r *Runner) buildPriceMap(ts int64) map[string]float64 {
 }
 
 // buildDecisionContext builds the context for AI decision
-func (r *Runner) buildDecisionContext(ts int64, priceMap map[string]float64) *decision.Context {
+func (r *Runner) buildDecisionContext(step int, ts int64, priceMap map[string]float64) *decision.Context {
 	equity, unrealized, _ := r.account.TotalEquity(priceMap)
 
 	// Convert positions
 	var positions []decision.PositionInfo
-	for _, pos := range r.account.GetPositions() {
+	for _, key := range r.account.positionKeys() {
+		pos := r.account.GetPositions()[key]
 		price := priceMap[pos.Symbol]
 		var pnlPct float64
 		if pos.Side == "long" {
@@ -336,6 +486,8 @@ func (r *Runner) buildDecisionContext(ts int64, priceMap map[string]float64) *de
 			Leverage:         pos.Leverage,
 			UnrealizedPnL:    (price - pos.EntryPrice) * pos.Quantity,
 			UnrealizedPnLPct: pnlPct,
+			StopLoss:         pos.StopLoss,
+			TakeProfit:       pos.TakeProfit,
 			LiquidationPrice: pos.LiquidationPrice,
 			MarginUsed:       pos.Margin,
 			UpdateTime:       ts,
@@ -345,11 +497,21 @@ func (r *Runner) buildDecisionContext(ts int64, priceMap map[string]float64) *de
 	// Build market data map
 	marketDataMap := make(map[string]*decision.MarketData)
 	for symbol := range r.klines {
-		price := priceMap[symbol]
-		marketDataMap[symbol] = &decision.MarketData{
+		price, ok := priceMap[symbol]
+		if !ok || r.aligned.stale(symbol, step) {
+			// No frozen price for a symbol not trading yet or missing bars
+			marketDataMap[symbol] = &decision.MarketData{Symbol: symbol, NotListed: !ok, DataGap: ok}
+			continue
+		}
+		data := &decision.MarketData{
 			Symbol: symbol,
 			Price:  price,
 		}
+		if levels := r.keyLevelsAt(symbol, ts, price); !levels.Empty() {
+			data.KeyLevels = levels
+			data.HighPrice24h, data.LowPrice24h = levels.High24h, levels.Low24h
+		}
+		marketDataMap[symbol] = data
 	}
 
 	// Calculate margin usage
@@ -361,15 +523,23 @@ func (r *Runner) buildDecisionContext(ts int64, priceMap map[string]float64) *de
 	if equity > 0 {
 		marginUsedPct = totalMargin / equity * 100
 	}
+	realizedToday, realizedTotal, fees := realizedTotals(r.trades, ts)
+	var promptBudget int
+	if r.config.AI != nil {
+		promptBudget = r.config.AI.PromptTokenBudget
+	}
 
 	return &decision.Context{
 		CurrentTime:    time.Unix(ts/1000, 0).Format(time.RFC3339),
-		RuntimeMinutes: int(time.Since(r.metadata.StartedAt).Minutes()),
+		RuntimeMinutes: int((ts - r.config.StartTS) / 60000), // Simulated, so prompts repeat across runs
 		CallCount:      r.state.DecisionCycle,
 		Account: decision.AccountInfo{
 			TotalEquity:      equity,
 			AvailableBalance: r.account.GetCash(),
 			UnrealizedPnL:    unrealized,
+			RealizedPnLToday: realizedToday,
+			RealizedPnLTotal: realizedTotal,
+			FeesTotal:        fees,
 			TotalPnL:         equity - r.config.InitialBalance,
 			TotalPnLPct:      (equity - r.config.InitialBalance) / r.config.InitialBalance * 100,
 			MarginUsed:       totalMargin,
@@ -382,13 +552,46 @@ func (r *Runner) buildDecisionContext(ts int64, priceMap map[string]float64) *de
 		AltcoinLeverage: r.config.AltcoinLeverage,
 		BTCETHPosRatio:  r.config.BTCETHPosRatio,
 		AltcoinPosRatio: r.config.AltcoinPosRatio,
+		ExposureBudget:  r.exposureBudget(equity, priceMap),
+		StrategyRules:   r.config.CustomPrompt,
+
+		PromptTokenBudget: promptBudget,
 	}
 }
 
+// exposureBudget returns the exposure limits and what's held against them for
+// the AI context, nil without limits
+func (r *Runner) exposureBudget(equity float64, priceMap map[string]float64) *decision.ExposureBudget {
+	if !r.config.ExposureLimits.Enabled() {
+		return nil
+	}
+	return &decision.ExposureBudget{Limits: r.config.ExposureLimits, Exposure: r.account.Exposure(priceMap), Equity: equity}
+}
+
+// realizedTotals sums the P&L and fees of the closes in trades: those on ts's
+// UTC day and all of them. P&L is before fees, as a live trader reports it.
+func realizedTotals(trades []TradeEvent, ts int64) (today, total, fees float64) {
+	dayStart := ts - ts%(24*3600_000)
+	for _, trade := range trades {
+		switch trade.Action {
+		case decision.ActionOpenLong, decision.ActionOpenShort, decision.ActionAddLong, decision.ActionAddShort:
+			continue // Entry fees are counted with the close, pro rata
+		}
+		gross := trade.RealizedPnL + trade.Fee
+		total += gross
+		fees += trade.Fee
+		if trade.Timestamp >= dayStart {
+			today += gross
+		}
+	}
+	return today, total, fees
+}
+
 // executeDecisions executes AI decisions
 func (r *Runner) executeDecisions(decisions []decision.Decision, ts int64, priceMap map[string]float64) {
-	// Sort: closes first, then opens
+	// Sort: closes first, then stop moves, then opens
 	closes := decision.FilterClosingDecisions(decisions)
+	moves := decision.FilterStopAdjustments(decisions)
 	opens := decision.FilterOpeningDecisions(decisions)
 
 	// Execute closes first
@@ -396,6 +599,11 @@ func (r *Runner) executeDecisions(decisions []decision.Decision, ts int64, price
 		r.executeDecision(dec, ts, priceMap)
 	}
 
+	// Then stop moves on what's still open
+	for _, dec := range moves {
+		r.executeDecision(dec, ts, priceMap)
+	}
+
 	// Then opens
 	for _, dec := range opens {
 		r.executeDecision(dec, ts, priceMap)
@@ -405,7 +613,7 @@ func (r *Runner) executeDecisions(decisions []decision.Decision, ts int64, price
 // executeDecision executes a single decision
 func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[string]float64) {
 	price, ok := priceMap[dec.Symbol]
-	if !ok {
+	if !ok || r.aligned.stale(dec.Symbol, r.state.BarIndex) {
 		log.Printf("No price for symbol %s, skipping decision", dec.Symbol)
 		return
 	}
@@ -416,6 +624,22 @@ func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[s
 	event.Action = dec.Action
 	event.Cycle = r.state.DecisionCycle
 
+	if dec.Action == decision.ActionOpenLong || dec.Action == decision.ActionOpenShort {
+		if !r.applyATRSizing(&dec, ts, price, priceMap) {
+			return
+		}
+		event.RiskUSD = dec.RiskUSD
+	}
+	if decision.IsOpeningAction(dec.Action) {
+		// Closes of the batch have run, so the budget they freed is available
+		equity, _, _ := r.account.TotalEquity(priceMap)
+		side := decision.GetActionDirection(dec.Action)
+		if err := r.config.ExposureLimits.Check(r.account.Exposure(priceMap), dec.Symbol, side, dec.PositionSizeUSD, equity); err != nil {
+			log.Printf("Rejected %s on %s: %v", dec.Action, dec.Symbol, err)
+			return
+		}
+	}
+
 	switch dec.Action {
 	case decision.ActionOpenLong:
 		leverage := dec.Leverage
@@ -433,6 +657,8 @@ func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[s
 			return
 		}
 
+		pos.StopLoss, pos.TakeProfit = dec.StopLoss, dec.TakeProfit
+
 		event.Side = "long"
 		event.Quantity = quantity
 		event.Price = execPrice
@@ -458,6 +684,8 @@ func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[s
 			return
 		}
 
+		pos.StopLoss, pos.TakeProfit = dec.StopLoss, dec.TakeProfit
+
 		event.Side = "short"
 		event.Quantity = quantity
 		event.Price = execPrice
@@ -509,6 +737,11 @@ func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[s
 		event.Leverage = pos.Leverage
 		event.Note = dec.Reasoning
 
+	case decision.ActionMoveStop, decision.ActionMoveTP:
+		if !r.moveStop(dec, price, &event) {
+			return
+		}
+
 	default:
 		// hold or wait - no action
 		return
@@ -523,6 +756,124 @@ func (r *Runner) executeDecision(dec decision.Decision, ts int64, priceMap map[s
 		dec.Action, dec.Symbol, event.Quantity, event.Price, event.Fee, event.RealizedPnL)
 }
 
+// moveStop moves the SL or TP of the position on dec.Symbol and fills in its
+// trade event. It returns false if there's no position or the level would
+// trigger at once.
+func (r *Runner) moveStop(dec decision.Decision, price float64, event *TradeEvent) bool {
+	pos := r.account.GetPosition(dec.Symbol, "long")
+	if pos == nil {
+		pos = r.account.GetPosition(dec.Symbol, "short")
+	}
+	if pos == nil {
+		log.Printf("No position to %s for %s", dec.Action, dec.Symbol)
+		return false
+	}
+
+	level, current := dec.StopLoss, &pos.StopLoss
+	if dec.Action == decision.ActionMoveTP {
+		level, current = dec.TakeProfit, &pos.TakeProfit
+	}
+	if err := decision.CheckStopAdjustment(dec.Action, pos.Side, price, level); err != nil {
+		log.Printf("Rejected %s on %s: %v", dec.Action, dec.Symbol, err)
+		return false
+	}
+
+	event.Side = pos.Side
+	event.Price = level
+	event.Leverage = pos.Leverage
+	event.PositionAfter = pos.Quantity
+	event.Note = fmt.Sprintf("%.4f -> %.4f: %s", *current, level, dec.Reasoning)
+	*current = level
+	return true
+}
+
+// applyATRSizing re-sizes an open so a stop ATRStopMultiple ATRs away loses
+// RiskPerTradePct of equity, capped by the position ratio. It keeps the AI's
+// size when there's no ATR yet and returns false when the result is below the
+// minimum position.
+func (r *Runner) applyATRSizing(dec *decision.Decision, ts int64, price float64, priceMap map[string]float64) bool {
+	if r.config.SizingMode != decision.SizingATRRisk {
+		return true
+	}
+
+	atr := r.atrAt(dec.Symbol, ts)
+	equity, _, _ := r.account.TotalEquity(priceMap)
+	maxValue := equity * r.config.AltcoinPosRatio
+	minValue := r.validationCfg.MinPositionAlt
+	if isBTCOrETH(dec.Symbol) {
+		maxValue = equity * r.config.BTCETHPosRatio
+		minValue = r.validationCfg.MinPositionBTCETH
+	}
+
+	value, riskUSD, err := decision.ATRRiskSize(equity, r.config.RiskPerTradePct, atr, r.config.ATRStopMultiple, price, maxValue)
+	if err != nil {
+		log.Printf("ATR sizing unavailable for %s, keeping AI size: %v", dec.Symbol, err)
+		return true
+	}
+	if value < minValue {
+		log.Printf("ATR-sized %s position $%.2f is below the $%.2f minimum, skipping", dec.Symbol, value, minValue)
+		return false
+	}
+
+	dec.PositionSizeUSD = value
+	dec.RiskUSD = riskUSD
+	return true
+}
+
+// keyLevelsAt returns symbol's key levels from the bars closed by ts, the same
+// levels a live trader's prompt shows
+func (r *Runner) keyLevelsAt(symbol string, ts int64, price float64) *market.KeyLevels {
+	klines := r.klines[symbol]
+	end := closedBy(klines, ts)
+	// The swing window, extended back to cover the previous UTC day
+	start := end - r.config.SRLookback
+	if start < 0 {
+		start = 0
+	}
+	from := time.UnixMilli(ts).UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour).UnixMilli()
+	for start > 0 && klines[start-1].OpenTime >= from {
+		start--
+	}
+
+	bars := make([]exchange.Kline, 0, end-start)
+	for _, k := range klines[start:end] {
+		bars = append(bars, exchange.Kline{
+			OpenTime:  k.OpenTime,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			CloseTime: k.CloseTime,
+		})
+	}
+
+	levels := &market.KeyLevels{}
+	levels.Resistances, levels.Supports = market.FindSwingLevels(bars, price, r.config.SRLookback, r.config.SRSensitivity)
+	levels.High24h, levels.Low24h, levels.PrevDayClose = market.DailyLevels(bars, time.UnixMilli(ts))
+	return levels
+}
+
+// atrAt returns the ATR of symbol from the bars closed by ts, 0 if there
+// aren't enough yet
+func (r *Runner) atrAt(symbol string, ts int64) float64 {
+	klines := r.klines[symbol]
+	end := closedBy(klines, ts)
+	start := end - (decision.ATRPeriod + 1)
+	if start < 0 {
+		return 0
+	}
+
+	bars := klines[start:end]
+	highs := make([]float64, len(bars))
+	lows := make([]float64, len(bars))
+	closes := make([]float64, len(bars))
+	for i, k := range bars {
+		highs[i], lows[i], closes[i] = k.High, k.Low, k.Close
+	}
+	return market.CalculateATR(highs, lows, closes, decision.ATRPeriod)
+}
+
 // isBTCOrETH checks if symbol is BTC or ETH
 func isBTCOrETH(symbol string) bool {
 	return symbol == "BTCUSDT" || symbol == "ETHUSDT"
@@ -0,0 +1,252 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/mcp"
+)
+
+var (
+	analysisCountPattern = regexp.MustCompile(`\*\*Analysis Count\*\*: #(\d+)`)
+	pricePattern         = regexp.MustCompile(`- Price: \$([0-9.]+)`)
+)
+
+// mockTrader answers from the prompt alone, so the same prompt always gets
+// the same decision: it opens a long every fourth cycle and closes it two
+// cycles later. afterCall runs after each answer.
+type mockTrader struct {
+	calls     int
+	prompts   []string
+	afterCall func(calls int)
+}
+
+func (c *mockTrader) SetAPIKey(apiKey, customURL, customModel string) {}
+func (c *mockTrader) SetTimeout(timeout time.Duration)                {}
+func (c *mockTrader) GetProvider() string                             { return "mock" }
+func (c *mockTrader) GetModel() string                                { return "mock-model" }
+
+func (c *mockTrader) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return c.answer(userPrompt), nil
+}
+
+func (c *mockTrader) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	return &mcp.Response{Content: c.answer(userPrompt), Model: model}, nil
+}
+
+func (c *mockTrader) CallWithRequest(req *mcp.Request) (*mcp.Response, error) {
+	return &mcp.Response{Content: c.answer(req.Messages[len(req.Messages)-1].Content), Model: req.Model}, nil
+}
+
+func (c *mockTrader) CallStream(req *mcp.Request, handler mcp.ChunkHandler) (*mcp.Response, error) {
+	return c.CallWithRequest(req)
+}
+
+func (c *mockTrader) answer(prompt string) string {
+	c.calls++
+	c.prompts = append(c.prompts, prompt)
+	if c.afterCall != nil {
+		defer c.afterCall(c.calls)
+	}
+
+	var cycle int
+	if m := analysisCountPattern.FindStringSubmatch(prompt); m != nil {
+		cycle, _ = strconv.Atoi(m[1])
+	}
+	var price float64
+	if m := pricePattern.FindStringSubmatch(prompt); m != nil {
+		price, _ = strconv.ParseFloat(m[1], 64)
+	}
+
+	decision := `{"symbol": "BTCUSDT", "action": "wait", "reasoning": "No edge"}`
+	switch cycle % 4 {
+	case 1:
+		decision = fmt.Sprintf(`{"symbol": "BTCUSDT", "action": "open_long", "leverage": 5, "position_size_usd": 1000, "stop_loss": %.2f, "take_profit": %.2f, "confidence": 80, "reasoning": "Trend up"}`,
+			price*0.98, price*1.08)
+	case 3:
+		decision = `{"symbol": "BTCUSDT", "action": "close_long", "reasoning": "Take the move"}`
+	}
+	return "<reasoning>Scripted</reasoning>\n<decision>\n[" + decision + "]\n</decision>"
+}
+
+// syntheticKlines returns hourly BTC bars oscillating around an uptrend
+func syntheticKlines(n int, start int64) []Kline {
+	klines := make([]Kline, n)
+	prev := 50000.0
+	for i := range klines {
+		price := 50000 + 1500*math.Sin(float64(i)/7) + 10*float64(i)
+		open := start + int64(i)*3600_000
+		klines[i] = Kline{
+			OpenTime:  open,
+			Open:      prev,
+			High:      math.Max(prev, price) * 1.002,
+			Low:       math.Min(prev, price) * 0.998,
+			Close:     price,
+			Volume:    100,
+			CloseTime: open + 3600_000 - 1,
+		}
+		prev = price
+	}
+	return klines
+}
+
+func testConfig(runID string, klines []Kline) *Config {
+	cfg := DefaultConfig()
+	cfg.RunID = runID
+	cfg.Symbols = []string{"BTCUSDT"}
+	cfg.StartTS = klines[0].OpenTime
+	cfg.EndTS = klines[len(klines)-1].CloseTime
+	cfg.CheckpointEveryBars = 25
+	cfg.CacheAI = true
+	return cfg
+}
+
+func TestResumedRunMatchesUninterruptedRun(t *testing.T) {
+	klines := syntheticKlines(240, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+
+	straight := newRunner(testConfig("straight", klines), &mockTrader{}, newAICache())
+	straight.LoadKlines("BTCUSDT", klines)
+	if err := straight.Start(context.Background()); err != nil {
+		t.Fatalf("straight run: %v", err)
+	}
+	want := straight.GetMetrics()
+	if want.TotalTrades == 0 {
+		t.Fatal("straight run made no trades, the comparison would prove nothing")
+	}
+
+	// Stop midway, between checkpoints, and keep the checkpoint the stop saves
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := newAICache()
+	client := &mockTrader{afterCall: func(calls int) {
+		if calls == 33 {
+			cancel()
+		}
+	}}
+	stopped := newRunner(testConfig("resumed", klines), client, cache)
+	stopped.LoadKlines("BTCUSDT", klines)
+	var last *Checkpoint
+	stopped.onCheckpoint = func(cp *Checkpoint) { last = cp }
+	if err := stopped.Start(ctx); err != context.Canceled {
+		t.Fatalf("stopped run: got %v, want context.Canceled", err)
+	}
+	if status := stopped.GetMetadata().Status; status != StatusPaused {
+		t.Fatalf("stopped run status %s, want %s", status, StatusPaused)
+	}
+	if last == nil || last.NextBar != 33*4 {
+		t.Fatalf("stop checkpoint %+v, want one at bar %d", last, 33*4)
+	}
+
+	// Resume from the checkpoint as stored, prompts left out
+	data, err := json.Marshal(last.withoutPrompts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		t.Fatal(err)
+	}
+	resumed := resumeRunner(&cp, client, cache)
+	resumed.copyMarketData(stopped)
+	if err := resumed.Start(context.Background()); err != nil {
+		t.Fatalf("resumed run: %v", err)
+	}
+
+	if got := resumed.GetMetrics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed metrics differ:\n got %+v\nwant %+v", got, want)
+	}
+	if got, want := resumed.GetEquityCurve(), straight.GetEquityCurve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed equity curve differs: %d points, want %d", len(got), len(want))
+	}
+	if got, want := resumed.GetTrades(), straight.GetTrades(); !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed trades differ:\n got %+v\nwant %+v", got, want)
+	}
+	if got, want := len(resumed.GetDecisions()), len(straight.GetDecisions()); got != want {
+		t.Errorf("resumed run logged %d decisions, want %d", got, want)
+	}
+}
+
+func TestRunnerReportsProgress(t *testing.T) {
+	klines := syntheticKlines(240, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	r := newRunner(testConfig("progress", klines), &mockTrader{}, nil)
+	r.LoadKlines("BTCUSDT", klines)
+	var reports []RunMetadata
+	r.onProgress = func(meta RunMetadata) { reports = append(reports, meta) }
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Started, every whole percent of 240 bars, then finished
+	if len(reports) != 103 {
+		t.Fatalf("%d reports, want 103", len(reports))
+	}
+	if first := reports[0]; first.Status != StatusRunning || first.Progress != 0 {
+		t.Errorf("first report %s at %.1f%%", first.Status, first.Progress)
+	}
+	if last := reports[len(reports)-1]; last.Status != StatusCompleted || last.Progress != 100 {
+		t.Errorf("last report %s at %.1f%%", last.Status, last.Progress)
+	}
+	for i := 2; i < len(reports)-1; i++ {
+		if int(reports[i].Progress) != int(reports[i-1].Progress)+1 {
+			t.Fatalf("report %d at %.1f%% after %.1f%%", i, reports[i].Progress, reports[i-1].Progress)
+		}
+	}
+}
+
+func TestRealizedTotalsBeforeFees(t *testing.T) {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC).UnixMilli()
+	trades := []TradeEvent{
+		{Timestamp: day - 3600_000, Action: "open_long", Fee: 1},
+		{Timestamp: day - 1800_000, Action: "close_long", Fee: 2, RealizedPnL: 48},
+		{Timestamp: day + 3600_000, Action: "open_short", Fee: 1},
+		{Timestamp: day + 7200_000, Action: "liquidated", Fee: 2, RealizedPnL: -82},
+	}
+
+	today, total, fees := realizedTotals(trades, day+8*3600_000)
+	if today != -80 || total != -30 || fees != 4 {
+		t.Errorf("realizedTotals = %.2f today, %.2f total, %.2f fees; want -80, -30, 4", today, total, fees)
+	}
+}
+
+func TestExposureLimitsFreedByCloseInSameBatch(t *testing.T) {
+	klines := syntheticKlines(48, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	cfg := testConfig("exposure", klines)
+	cfg.Symbols = []string{"SOLUSDT", "XRPUSDT"}
+	cfg.FeeBps, cfg.SlippageBps = 0, 0
+	cfg.ExposureLimits = decision.ExposureLimits{MaxAltcoinNotionalPct: 30}
+
+	newTestRunner := func() *Runner {
+		r := newRunner(cfg, &mockTrader{}, nil)
+		r.LoadKlines("SOLUSDT", klines)
+		r.LoadKlines("XRPUSDT", klines)
+		r.aligned = alignKlines(r.klines, cfg.StartTS, cfg.EndTS)
+		r.state.BarIndex = 24
+		return r
+	}
+	prices := map[string]float64{"SOLUSDT": 100, "XRPUSDT": 100}
+	open := decision.Decision{Symbol: "XRPUSDT", Action: decision.ActionOpenLong, Leverage: 5, PositionSizeUSD: 1000}
+	closeSOL := decision.Decision{Symbol: "SOLUSDT", Action: decision.ActionCloseLong}
+
+	// 2500 of the 3000 altcoin budget is held, so the open alone is refused
+	r := newTestRunner()
+	if _, _, _, err := r.account.Open("SOLUSDT", "long", 25, 5, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	r.executeDecisions([]decision.Decision{open}, 0, prices)
+	if r.account.HasPosition("XRPUSDT", "long") {
+		t.Fatal("open past the altcoin limit was executed")
+	}
+
+	// Listed before the close, the open still runs after it and uses the budget it freed
+	r.executeDecisions([]decision.Decision{open, closeSOL}, 0, prices)
+	if r.account.HasPosition("SOLUSDT", "long") || !r.account.HasPosition("XRPUSDT", "long") {
+		t.Errorf("positions after the batch: %v", r.account.positionKeys())
+	}
+}
@@ -0,0 +1,85 @@
+package backtest
+
+import (
+	"fmt"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/store"
+)
+
+// ConfigFromStrategy builds a run of a saved strategy over [startTS, endTS)
+// (Unix ms), with the settings a live trader running it would use. Only the
+// static coin list can be replayed, so strategies without one are refused.
+func ConfigFromStrategy(strategy *store.Strategy, startTS, endTS int64, initialBalance float64) (*Config, error) {
+	sc := strategy.Config
+	if len(sc.CoinSource.StaticCoins) == 0 {
+		return nil, fmt.Errorf("strategy has no static coins to backtest")
+	}
+	if endTS <= startTS {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	cfg := DefaultConfig()
+	cfg.Name = strategy.Name
+	cfg.Description = fmt.Sprintf("Backtest of strategy %s", strategy.Name)
+	cfg.Symbols = append([]string(nil), sc.CoinSource.StaticCoins...)
+	cfg.StartTS, cfg.EndTS = startTS, endTS
+	cfg.InitialBalance = initialBalance
+	cfg.CustomPrompt = sc.CustomPrompt
+	cfg.StrategyID = strategy.ID
+	cfg.StrategyVersion = strategy.Version
+	cfg.StrategyUpdatedAt = strategy.UpdatedAt
+
+	// Timeframes and cadence
+	if tf := sc.Indicators.PrimaryTimeframe; tf != "" {
+		cfg.DecisionTimeframe = tf
+		cfg.Timeframes = []string{tf}
+		if sc.Indicators.EnableMultiTF && sc.Indicators.ConfirmationTimeframe != "" && sc.Indicators.ConfirmationTimeframe != tf {
+			cfg.Timeframes = append(cfg.Timeframes, sc.Indicators.ConfirmationTimeframe)
+		}
+	}
+	if sc.TradingInterval > 0 {
+		cfg.DecisionCadenceNBars = 0
+		cfg.DecisionCadenceMinutes = sc.TradingInterval
+	}
+	if sc.Indicators.SRLookback > 0 {
+		cfg.SRLookback = sc.Indicators.SRLookback
+	}
+	if sc.Indicators.SRSensitivity > 0 {
+		cfg.SRSensitivity = sc.Indicators.SRSensitivity
+	}
+
+	// Leverage and position value ratios, with the live engine's fallbacks
+	rc := sc.RiskControl
+	cfg.BTCETHLeverage = firstPositive(rc.BTCETHMaxLeverage, rc.MaxLeverage, 10)
+	cfg.AltcoinLeverage = firstPositive(rc.AltcoinMaxLeverage, rc.MaxLeverage, 20)
+	cfg.BTCETHPosRatio, cfg.AltcoinPosRatio = 5.0, 1.0
+	if rc.BTCETHMaxPositionValueRatio > 0 {
+		cfg.BTCETHPosRatio = rc.BTCETHMaxPositionValueRatio
+	}
+	if rc.AltcoinMaxPositionValueRatio > 0 {
+		cfg.AltcoinPosRatio = rc.AltcoinMaxPositionValueRatio
+	}
+	cfg.ExposureLimits = decision.ExposureLimits(rc.ExposureLimits)
+	cfg.SizingMode = rc.SizingMode
+	cfg.RiskPerTradePct = rc.RiskPerTradePct
+	cfg.ATRStopMultiple = rc.ATRStopMultiple
+
+	ai := sc.AI
+	cfg.AI = &ai
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// firstPositive returns the first of values above zero, or zero
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
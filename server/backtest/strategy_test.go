@@ -0,0 +1,85 @@
+package backtest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+func TestConfigFromStrategy(t *testing.T) {
+	sc := store.DefaultStrategyConfig()
+	sc.CoinSource.StaticCoins = []string{"BTCUSDT", "SOLUSDT"}
+	sc.TradingInterval = 15
+	sc.CustomPrompt = "Only trade with the trend"
+	sc.RiskControl.MaxLeverage = 7
+	sc.RiskControl.AltcoinMaxLeverage = 3
+	sc.RiskControl.ExposureLimits.MaxNetLongPct = 150
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	strategy := &store.Strategy{ID: "s1", Name: "Trend", Config: sc, Version: 3, UpdatedAt: updated}
+
+	const day = int64(24 * time.Hour / time.Millisecond)
+	cfg, err := ConfigFromStrategy(strategy, 10*day, 12*day, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cfg.Symbols, ",") != "BTCUSDT,SOLUSDT" || cfg.StartTS != 10*day || cfg.EndTS != 12*day || cfg.InitialBalance != 5000 {
+		t.Errorf("symbols %v range %d-%d balance %v", cfg.Symbols, cfg.StartTS, cfg.EndTS, cfg.InitialBalance)
+	}
+	if cfg.DecisionTimeframe != "5m" || strings.Join(cfg.Timeframes, ",") != "5m,15m" {
+		t.Errorf("timeframes %s %v, want 5m [5m 15m]", cfg.DecisionTimeframe, cfg.Timeframes)
+	}
+	if cfg.DecisionCadenceMinutes != 15 || cfg.DecisionCadenceNBars != 0 {
+		t.Errorf("cadence %d min / %d bars, want 15 min", cfg.DecisionCadenceMinutes, cfg.DecisionCadenceNBars)
+	}
+	// Majors fall back to the legacy leverage, alts have their own
+	if cfg.BTCETHLeverage != 7 || cfg.AltcoinLeverage != 3 {
+		t.Errorf("leverage %d/%d, want 7/3", cfg.BTCETHLeverage, cfg.AltcoinLeverage)
+	}
+	if cfg.BTCETHPosRatio != 5 || cfg.AltcoinPosRatio != 1 || cfg.ExposureLimits.MaxNetLongPct != 150 {
+		t.Errorf("ratios %v/%v limits %+v", cfg.BTCETHPosRatio, cfg.AltcoinPosRatio, cfg.ExposureLimits)
+	}
+	if cfg.CustomPrompt != sc.CustomPrompt || cfg.AI == nil {
+		t.Errorf("prompt %q ai %v", cfg.CustomPrompt, cfg.AI)
+	}
+	if cfg.StrategyID != "s1" || cfg.StrategyVersion != 3 || !cfg.StrategyUpdatedAt.Equal(updated) {
+		t.Errorf("strategy %s version %d of %v", cfg.StrategyID, cfg.StrategyVersion, cfg.StrategyUpdatedAt)
+	}
+
+	meta := newRunner(cfg, nil, nil).GetMetadata()
+	if meta.StrategyID != "s1" || meta.StrategyVersion != 3 || !meta.StrategyUpdatedAt.Equal(updated) {
+		t.Errorf("metadata strategy %s version %d of %v", meta.StrategyID, meta.StrategyVersion, meta.StrategyUpdatedAt)
+	}
+}
+
+// TestRunMetadataReadsTimestampVersion checks runs saved when
+// strategy_version was the strategy's updated_at still load
+func TestRunMetadataReadsTimestampVersion(t *testing.T) {
+	var meta RunMetadata
+	if err := json.Unmarshal([]byte(`{"run_id":"r1","strategy_id":"s1","strategy_version":"2026-01-02T03:04:05Z"}`), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunID != "r1" || meta.StrategyVersion != 0 || meta.StrategyUpdatedAt.Year() != 2026 {
+		t.Errorf("legacy metadata = %+v", meta)
+	}
+
+	if err := json.Unmarshal([]byte(`{"run_id":"r2","strategy_version":4}`), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.RunID != "r2" || meta.StrategyVersion != 4 {
+		t.Errorf("metadata = %+v", meta)
+	}
+}
+
+func TestConfigFromStrategyNeedsStaticCoins(t *testing.T) {
+	sc := store.DefaultStrategyConfig()
+	sc.CoinSource.StaticCoins = nil
+	if _, err := ConfigFromStrategy(&store.Strategy{Config: sc}, 0, 1000, 0); err == nil {
+		t.Error("a strategy without static coins was accepted")
+	}
+	if _, err := ConfigFromStrategy(&store.Strategy{Config: store.DefaultStrategyConfig()}, 1000, 1000, 0); err == nil {
+		t.Error("an empty range was accepted")
+	}
+}
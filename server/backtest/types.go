@@ -1,9 +1,15 @@
 package backtest
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"auto-trader-ahh/debate"
 	"auto-trader-ahh/decision"
+	"auto-trader-ahh/market"
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/store"
 )
 
 // RunStatus represents the status of a backtest run
@@ -34,10 +40,12 @@ type Config struct {
 	UserID               string     `json:"user_id"`
 	Name                 string     `json:"name"`
 	Description          string     `json:"description"`
+	Tags                 []string   `json:"tags,omitempty"` // Labels to find the run by, see NormalizeTags
 	Symbols              []string   `json:"symbols"`
 	Timeframes           []string   `json:"timeframes"`
 	DecisionTimeframe    string     `json:"decision_timeframe"`
 	DecisionCadenceNBars int        `json:"decision_cadence_n_bars"`
+	DecisionCadenceMinutes int      `json:"decision_cadence_minutes"` // Decide on the simulated clock instead of every N bars
 	StartTS              int64      `json:"start_ts"`
 	EndTS                int64      `json:"end_ts"`
 	InitialBalance       float64    `json:"initial_balance"`
@@ -48,9 +56,33 @@ type Config struct {
 	AltcoinLeverage      int        `json:"altcoin_leverage"`
 	BTCETHPosRatio       float64    `json:"btc_eth_pos_ratio"`
 	AltcoinPosRatio      float64    `json:"altcoin_pos_ratio"`
+	ExposureLimits       decision.ExposureLimits `json:"exposure_limits"` // Net direction and symbol class caps, as a live strategy's
+	SizingMode           string     `json:"sizing_mode"`        // "fixed_pct" (AI-sized) or "atr_risk"
+	RiskPerTradePct      float64    `json:"risk_per_trade_pct"` // atr_risk: % of equity lost at the ATR stop
+	ATRStopMultiple      float64    `json:"atr_stop_multiple"`  // atr_risk: stop distance in ATRs
+	SRLookback           int        `json:"sr_lookback"`        // Bars searched for support/resistance levels
+	SRSensitivity        int        `json:"sr_sensitivity"`     // Bars on each side of a swing point
+	CheckpointEveryBars  int        `json:"checkpoint_every_bars"` // Bars between saved checkpoints to resume from
 	CacheAI              bool       `json:"cache_ai"`
 	ReplayOnly           bool       `json:"replay_only"`
 	Language             string     `json:"language"`
+	Debate               *DebateConfig `json:"debate,omitempty"` // nil runs a single model
+	AI                   *store.AIConfig `json:"ai,omitempty"`   // Sampling settings of the single model, as in a strategy
+	CustomPrompt         string     `json:"custom_prompt,omitempty"` // Strategy rules added to every decision prompt
+
+	// The strategy a run was built from, its version and its updated_at then
+	StrategyID        string    `json:"strategy_id,omitempty"`
+	StrategyVersion   int       `json:"strategy_version,omitempty"`
+	StrategyUpdatedAt time.Time `json:"strategy_updated_at,omitempty"`
+}
+
+// DebateConfig makes each decision cycle a compact debate instead of one model call
+type DebateConfig struct {
+	Participants  []debate.CreateParticipantRequest `json:"participants"`
+	Rounds        int                               `json:"rounds"` // 0 means one round before the vote
+	Consensus     *debate.ConsensusConfig           `json:"consensus,omitempty"`
+	ParallelCalls bool                              `json:"parallel_calls"`
+	CompareSingle bool                              `json:"compare_single"` // Also run the single model over the same bars
 }
 
 // DefaultConfig returns a default backtest configuration
@@ -68,6 +100,7 @@ func DefaultConfig() *Config {
 		AltcoinLeverage:      10,
 		BTCETHPosRatio:       0.3,
 		AltcoinPosRatio:      0.15,
+		SizingMode:           decision.SizingFixedPct,
 		Language:             "en-US",
 	}
 }
@@ -77,12 +110,27 @@ func (c *Config) Validate() error {
 	if c.InitialBalance <= 0 {
 		c.InitialBalance = 10000
 	}
-	if c.DecisionCadenceNBars <= 0 {
+	if c.DecisionCadenceMinutes < 0 {
+		return fmt.Errorf("decision_cadence_minutes must not be negative")
+	}
+	if c.DecisionCadenceMinutes > 0 && c.DecisionCadenceNBars > 0 {
+		return fmt.Errorf("set decision_cadence_n_bars or decision_cadence_minutes, not both")
+	}
+	if c.DecisionCadenceMinutes == 0 && c.DecisionCadenceNBars <= 0 {
 		c.DecisionCadenceNBars = 4
 	}
+	if c.CheckpointEveryBars <= 0 {
+		c.CheckpointEveryBars = DefaultCheckpointEveryBars
+	}
 	if c.DecisionTimeframe == "" && len(c.Timeframes) > 0 {
 		c.DecisionTimeframe = c.Timeframes[0]
 	}
+	if c.DecisionTimeframe == "" {
+		c.DecisionTimeframe = "5m"
+	}
+	if _, err := timeframeMillis(c.DecisionTimeframe); err != nil {
+		return err
+	}
 	if c.BTCETHLeverage <= 0 {
 		c.BTCETHLeverage = 20
 	}
@@ -95,12 +143,59 @@ func (c *Config) Validate() error {
 	if c.AltcoinPosRatio <= 0 {
 		c.AltcoinPosRatio = 0.15
 	}
+	if l := c.ExposureLimits; l.MaxNetLongPct < 0 || l.MaxNetShortPct < 0 || l.MaxBTCETHNotionalPct < 0 || l.MaxAltcoinNotionalPct < 0 {
+		return fmt.Errorf("exposure limits must not be negative")
+	}
+	switch c.SizingMode {
+	case "":
+		c.SizingMode = decision.SizingFixedPct
+	case decision.SizingFixedPct:
+	case decision.SizingATRRisk:
+		if c.RiskPerTradePct <= 0 {
+			c.RiskPerTradePct = 1.0
+		}
+		if c.ATRStopMultiple <= 0 {
+			c.ATRStopMultiple = 2.0
+		}
+	default:
+		return fmt.Errorf("unknown sizing mode %q", c.SizingMode)
+	}
 	if c.FillPolicy == "" {
 		c.FillPolicy = FillPolicyNextOpen
 	}
+	if c.SRLookback <= 0 {
+		c.SRLookback = market.DefaultLevelLookback
+	}
+	if c.SRSensitivity <= 0 {
+		c.SRSensitivity = market.DefaultLevelSensitivity
+	}
 	if c.Language == "" {
-		c.Language = "en-US"
+		c.Language = string(decision.LangEnglish)
+	}
+	if _, err := decision.ParseLanguage(c.Language); err != nil {
+		return err
+	}
+	if c.Debate != nil {
+		if len(c.Debate.Participants) == 0 {
+			return fmt.Errorf("debate backtest needs at least one participant")
+		}
+		if err := debate.ValidateParticipants(c.Debate.Participants); err != nil {
+			return fmt.Errorf("debate: %w", err)
+		}
+		if c.Debate.Rounds <= 0 {
+			c.Debate.Rounds = 1
+		}
+	}
+	if c.AI != nil {
+		if err := c.AI.Validate(); err != nil {
+			return fmt.Errorf("ai: %w", err)
+		}
+	}
+	tags, err := NormalizeTags(c.Tags)
+	if err != nil {
+		return err
 	}
+	c.Tags = tags
 	return nil
 }
 
@@ -116,6 +211,8 @@ type Position struct {
 	LiquidationPrice float64 `json:"liquidation_price"`
 	OpenTime         int64   `json:"open_time"`
 	AccumulatedFee   float64 `json:"accumulated_fee"`
+	StopLoss         float64 `json:"stop_loss,omitempty"`   // Simulated SL, 0 if none
+	TakeProfit       float64 `json:"take_profit,omitempty"` // Simulated TP, 0 if none
 }
 
 // State represents the current backtest state
@@ -175,6 +272,7 @@ type TradeEvent struct {
 	Cycle           int     `json:"cycle"`
 	PositionAfter   float64 `json:"position_after"`
 	LiquidationFlag bool    `json:"liquidation_flag"`
+	RiskUSD         float64 `json:"risk_usd,omitempty"` // ATR sizing: loss at the ATR stop
 	Note            string  `json:"note"`
 }
 
@@ -190,6 +288,24 @@ type DecisionLog struct {
 	Decisions       []decision.Decision  `json:"decisions"`
 	DurationMs      int64                `json:"duration_ms"`
 	Error           string               `json:"error,omitempty"`
+	ParsePath       string               `json:"parse_path,omitempty"`   // Single mode: structured or regex
+	Retries         int                  `json:"retries,omitempty"`      // Single mode: follow-ups for an unusable response
+	RetryReason     string               `json:"retry_reason,omitempty"`
+	FirstResponse   string               `json:"first_response,omitempty"` // The response the retry replaced
+	AIParams        *mcp.GenerationParams `json:"ai_params,omitempty"`     // Single mode: sampling settings of the request
+	PromptFit       *decision.PromptFit   `json:"prompt_fit,omitempty"`    // Single mode: estimated user prompt size and what its budget trimmed
+	Participants    []ParticipantDecision `json:"participants,omitempty"` // Debate mode: each participant's vote
+	VoteTally       []*debate.SymbolTally `json:"vote_tally,omitempty"`
+}
+
+// ParticipantDecision is one debate participant's vote in a decision cycle
+type ParticipantDecision struct {
+	Name        string              `json:"name"`
+	Model       string              `json:"model"`
+	Personality debate.Personality  `json:"personality"`
+	Decisions   []decision.Decision `json:"decisions"`
+	Reasoning   string              `json:"reasoning"`
+	ParsePath   string              `json:"parse_path,omitempty"`
 }
 
 // Metrics represents backtest performance metrics
@@ -213,6 +329,11 @@ type Metrics struct {
 	TotalFees       float64            `json:"total_fees"`
 	FinalEquity     float64            `json:"final_equity"`
 	SymbolStats     map[string]*SymbolStats `json:"symbol_stats"`
+
+	// How AI answers were parsed: schema-constrained JSON vs the regex scraper
+	StructuredParses int `json:"structured_parses"`
+	RegexFallbacks   int `json:"regex_fallbacks"`
+	ParseRetries     int `json:"parse_retries"` // Follow-ups asking the model to correct an unusable response
 }
 
 // SymbolStats represents per-symbol statistics
@@ -236,6 +357,9 @@ type RunMetadata struct {
 	Description    string    `json:"description"`
 	Status         RunStatus `json:"status"`
 	Config         *Config   `json:"config"`
+	StrategyID     string    `json:"strategy_id,omitempty"`
+	StrategyVersion int      `json:"strategy_version,omitempty"`
+	StrategyUpdatedAt time.Time `json:"strategy_updated_at,omitempty"` // The strategy's updated_at when the run was built
 	StartedAt      time.Time `json:"started_at"`
 	CompletedAt    time.Time `json:"completed_at,omitempty"`
 	Progress       float64   `json:"progress"`
@@ -243,6 +367,43 @@ type RunMetadata struct {
 	TotalBars      int       `json:"total_bars"`
 	CurrentEquity  float64   `json:"current_equity"`
 	Error          string    `json:"error,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Summary        *RunSummary `json:"summary,omitempty"` // Set when the run completes or is liquidated
+}
+
+// UnmarshalJSON reads runs saved when strategy_version held the strategy's
+// updated_at rather than its version number
+func (m *RunMetadata) UnmarshalJSON(data []byte) error {
+	type plain RunMetadata
+	var saved struct {
+		*plain
+		StrategyVersion json.RawMessage `json:"strategy_version,omitempty"`
+	}
+	saved.plain = (*plain)(m)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	if len(saved.StrategyVersion) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(saved.StrategyVersion, &m.StrategyVersion); err == nil {
+		return nil
+	}
+	if m.StrategyUpdatedAt.IsZero() {
+		json.Unmarshal(saved.StrategyVersion, &m.StrategyUpdatedAt)
+	}
+	return nil
+}
+
+// RunSummary is the headline of a finished run's metrics, kept on its
+// metadata so listings can sort runs without recomputing them
+type RunSummary struct {
+	TotalReturnPct float64 `json:"total_return_pct"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	SharpeRatio    float64 `json:"sharpe_ratio"`
+	WinRate        float64 `json:"win_rate"`
+	TotalTrades    int     `json:"total_trades"`
+	FinalEquity    float64 `json:"final_equity"`
 }
 
 // Kline represents a candlestick
@@ -255,3 +416,16 @@ type Kline struct {
 	Volume    float64 `json:"volume"`
 	CloseTime int64   `json:"close_time"`
 }
+
+// ComparisonReport compares a debate backtest with a single model over the same bars
+type ComparisonReport struct {
+	DebateRunID     string   `json:"debate_run_id"`
+	SingleRunID     string   `json:"single_run_id"`
+	Debate          *Metrics `json:"debate"`
+	Single          *Metrics `json:"single"`
+	ReturnDiffPct   float64  `json:"return_diff_pct"`   // Debate minus single, percentage points
+	DrawdownDiffPct float64  `json:"drawdown_diff_pct"` // Debate minus single, percentage points
+	SharpeDiff      float64  `json:"sharpe_diff"`
+	Winner          string   `json:"winner"` // debate, single or tie, by total return
+	CompletedAt     time.Time `json:"completed_at"`
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,11 +13,18 @@ type Config struct {
 	OpenRouterAPIKey string
 	OpenRouterModel  string
 
+	// Local Ollama server for strategies with the "ollama" provider, empty uses
+	// http://localhost:11434
+	OllamaBaseURL string
+
 	// Binance Futures
 	BinanceAPIKey    string
 	BinanceSecretKey string
 	BinanceTestnet   bool
 
+	BinanceRequestsPerSec int // REST requests a second shared by all clients, 0 = unlimited
+	MarketDataConcurrency int // Symbols whose market data a cycle fetches at once
+
 	// Trading Settings
 	TradingPairs    []string
 	Leverage        int
@@ -24,10 +32,58 @@ type Config struct {
 	TradingInterval int     // Minutes between AI decisions
 
 	// Server
-	APIPort string
+	APIPort             string
+	AllowedOrigins      []string // Browser origins allowed by CORS, exact or "https://*.example.com"
+	TLSCertFile         string   // Serve HTTPS when both cert and key are set
+	TLSKeyFile          string
+	AutocertDomains     []string // Let's Encrypt certificates for these domains, served on :443
+	AutocertCacheDir    string   // Where issued certificates and the ACME account key are kept
+	AutocertEmail       string   // Contact address for expiry notices, optional
+	MaxRequestBodyKB    int      // Cap on JSON request bodies
+	IdempotencyTTLHours int      // How long responses to requests with an Idempotency-Key are replayed
+
+	// Database
+	DatabaseURL string // postgres:// URL, empty uses the SQLite file in ./data
 
 	// Authentication
-	AccessPasskey string
+	AccessPasskey        string
+	AuthLockoutThreshold int // Failed attempts from one IP before it is locked out, 0 disables lockouts
+	AuthLockoutMinutes   int
+
+	// Request logging
+	RequestLogGetSampleRate float64 // Fraction of GET requests written to the structured log (0-1)
+
+	// Live AI call history (prompts and raw responses)
+	AICallRetentionDays int // Days to keep AI calls, 0 keeps them forever
+	AICallMaxRows       int // Most recent AI calls kept per trader, 0 for no limit
+	AICallMaxFieldKB    int // Size cap per stored prompt/response
+
+	// AI call scheduling, each provider queued separately
+	AIMinIntervalMs    int // Least time between the starts of two calls, 0 = none
+	AIMaxConcurrent    int // Calls in flight at once, 0 = unlimited
+	AIQueueTimeoutSecs int // Longest a call waits for its turn before failing
+
+	// Equity history
+	EquityRawRetentionDays int // Days of raw equity snapshots kept before rolling up to hourly/daily bars, 0 keeps them
+
+	// Crash recovery
+	AutoRestartTraders bool // Restart traders left "running" when the server starts
+
+	// Reports
+	ReportDailyHour int // Local hour (0-23) to push the previous day's report to event clients, -1 disables
+
+	// Fault injection, for testing traders against a misbehaving exchange and
+	// AI provider. Only applied to testnet traders.
+	FaultInjection          bool
+	FaultLatencyMs          int     // Added to every exchange request
+	FaultLatencyJitterMs    int     // Random extra exchange latency, up to this
+	FaultErrorRate          float64 // Chance (0-1) an exchange request fails with a 502
+	FaultEndpointErrorRates string  // Per endpoint overrides, "POST /fapi/v1/order=0.2,/fapi/v2/positionRisk=0.1"
+	FaultTimestampErrorRate float64 // Chance a signed request is rejected with -1021
+	FaultPositionDelaySecs  int     // How long a new position stays out of position fetches
+	FaultPartialFillRate    float64 // Chance a market entry fills only half
+	FaultAILatencyMs        int     // Added to every AI call
+	FaultAIErrorRate        float64 // Chance an AI call fails
 }
 
 var cfg *Config
@@ -39,12 +95,16 @@ func Load() *Config {
 		// OpenRouter
 		OpenRouterAPIKey: getEnv("OPENROUTER_API_KEY", ""),
 		OpenRouterModel:  getEnv("OPENROUTER_MODEL", "deepseek/deepseek-v3.2"),
+		OllamaBaseURL:    getEnv("OLLAMA_BASE_URL", ""),
 
 		// Binance
 		BinanceAPIKey:    getEnv("BINANCE_API_KEY", ""),
 		BinanceSecretKey: getEnv("BINANCE_SECRET_KEY", ""),
 		BinanceTestnet:   getEnvBool("BINANCE_TESTNET", true),
 
+		BinanceRequestsPerSec: getEnvInt("BINANCE_REQUESTS_PER_SEC", 20),
+		MarketDataConcurrency: getEnvInt("MARKET_DATA_CONCURRENCY", 4),
+
 		// Trading
 		TradingPairs:    []string{"BTCUSDT", "ETHUSDT"},
 		Leverage:        getEnvInt("LEVERAGE", 5),
@@ -52,10 +112,57 @@ func Load() *Config {
 		TradingInterval: getEnvInt("TRADING_INTERVAL", 5),
 
 		// Server
-		APIPort: getEnv("API_PORT", "8080"),
+		APIPort:             getEnv("API_PORT", "8080"),
+		AllowedOrigins:      getEnvList("ALLOWED_ORIGINS", []string{"http://localhost:5173"}),
+		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		AutocertDomains:     getEnvList("TLS_AUTOCERT_DOMAINS", nil),
+		AutocertCacheDir:    getEnv("TLS_AUTOCERT_CACHE_DIR", "data/autocert"),
+		AutocertEmail:       getEnv("TLS_AUTOCERT_EMAIL", ""),
+		MaxRequestBodyKB:    getEnvInt("MAX_REQUEST_BODY_KB", 1024),
+		IdempotencyTTLHours: getEnvInt("IDEMPOTENCY_TTL_HOURS", 24),
+
+		// Database
+		DatabaseURL: getEnv("DATABASE_URL", ""),
 
 		// Authentication
-		AccessPasskey: getEnv("ACCESS_PASSKEY", ""),
+		AccessPasskey:        getEnv("ACCESS_PASSKEY", ""),
+		AuthLockoutThreshold: getEnvInt("AUTH_LOCKOUT_THRESHOLD", 10),
+		AuthLockoutMinutes:   getEnvInt("AUTH_LOCKOUT_MINUTES", 15),
+
+		// Request logging
+		RequestLogGetSampleRate: getEnvFloat("REQUEST_LOG_GET_SAMPLE_RATE", 0.1),
+
+		// AI call history
+		AICallRetentionDays: getEnvInt("AI_CALL_RETENTION_DAYS", 14),
+		AICallMaxRows:       getEnvInt("AI_CALL_MAX_ROWS", 5000),
+		AICallMaxFieldKB:    getEnvInt("AI_CALL_MAX_FIELD_KB", 256),
+
+		// AI call scheduling
+		AIMinIntervalMs:    getEnvInt("AI_MIN_INTERVAL_MS", 0),
+		AIMaxConcurrent:    getEnvInt("AI_MAX_CONCURRENT", 0),
+		AIQueueTimeoutSecs: getEnvInt("AI_QUEUE_TIMEOUT_SECS", 120),
+
+		// Equity history
+		EquityRawRetentionDays: getEnvInt("EQUITY_RAW_RETENTION_DAYS", 7),
+
+		// Crash recovery
+		AutoRestartTraders: getEnvBool("AUTO_RESTART_TRADERS", false),
+
+		// Reports
+		ReportDailyHour: getEnvInt("REPORT_DAILY_HOUR", -1),
+
+		// Fault injection
+		FaultInjection:          getEnvBool("FAULT_INJECTION", false),
+		FaultLatencyMs:          getEnvInt("FAULT_LATENCY_MS", 0),
+		FaultLatencyJitterMs:    getEnvInt("FAULT_LATENCY_JITTER_MS", 0),
+		FaultErrorRate:          getEnvFloat("FAULT_ERROR_RATE", 0),
+		FaultEndpointErrorRates: getEnv("FAULT_ENDPOINT_ERROR_RATES", ""),
+		FaultTimestampErrorRate: getEnvFloat("FAULT_TIMESTAMP_ERROR_RATE", 0),
+		FaultPositionDelaySecs:  getEnvInt("FAULT_POSITION_DELAY_SECS", 0),
+		FaultPartialFillRate:    getEnvFloat("FAULT_PARTIAL_FILL_RATE", 0),
+		FaultAILatencyMs:        getEnvInt("FAULT_AI_LATENCY_MS", 0),
+		FaultAIErrorRate:        getEnvFloat("FAULT_AI_ERROR_RATE", 0),
 	}
 
 	return cfg
@@ -75,6 +182,21 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvList reads a comma-separated list, ignoring empty entries
+func getEnvList(key string, defaultVal []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvBool(key string, defaultVal bool) bool {
 	if val := os.Getenv(key); val != "" {
 		b, err := strconv.ParseBool(val)
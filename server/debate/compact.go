@@ -0,0 +1,81 @@
+package debate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CompactRequest configures a one-off debate that isn't a session, such as a
+// backtest decision cycle
+type CompactRequest struct {
+	Participants       []CreateParticipantRequest
+	Rounds             int              // 0 means a single round
+	Consensus          *ConsensusConfig // nil uses DefaultConsensusConfig
+	ParallelCalls      bool
+	CallTimeoutSeconds int // 0 uses DefaultCallTimeoutSeconds
+}
+
+// CompactResult is everything a compact debate produced
+type CompactResult struct {
+	Participants []*Participant
+	Messages     []*Message
+	Votes        []*Vote
+	Decisions    []*Decision
+	Tally        []*SymbolTally
+}
+
+// RunCompact runs rounds, vote and consensus against prebuilt base prompts.
+// The debate is not registered as a session, emits no events and never waits
+// between speakers, so callers replaying history run as fast as the AI answers.
+func (e *Engine) RunCompact(ctx context.Context, req *CompactRequest, systemPrompt, userPrompt string) (*CompactResult, error) {
+	if len(req.Participants) == 0 {
+		return nil, fmt.Errorf("compact debate needs at least one participant")
+	}
+	consensus, err := normalizeConsensusConfig(req.Consensus)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateParticipants(req.Participants); err != nil {
+		return nil, err
+	}
+
+	rounds := req.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+	callTimeout := req.CallTimeoutSeconds
+	if callTimeout <= 0 {
+		callTimeout = DefaultCallTimeoutSeconds
+	}
+
+	session := &SessionWithDetails{
+		Session: Session{
+			ID:                 fmt.Sprintf("compact_%d", time.Now().UnixNano()),
+			Status:             StatusRunning,
+			MaxRounds:          rounds,
+			CallTimeoutSeconds: callTimeout,
+			ParallelCalls:      req.ParallelCalls,
+			MaxConcurrentCalls: DefaultMaxConcurrentCalls,
+			Consensus:          consensus,
+			CreatedAt:          time.Now(),
+		},
+		Messages: make([]*Message, 0),
+		Votes:    make([]*Vote, 0),
+	}
+	session.Participants = newParticipants(session.ID, req.Participants)
+
+	if err := e.runDebateWithPrompts(ctx, session, systemPrompt, userPrompt); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return &CompactResult{
+		Participants: session.Participants,
+		Messages:     session.Messages,
+		Votes:        session.Votes,
+		Decisions:    session.FinalDecisions,
+		Tally:        session.VoteTally,
+	}, nil
+}
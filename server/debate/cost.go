@@ -0,0 +1,168 @@
+package debate
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"auto-trader-ahh/mcp"
+)
+
+// ErrBudgetExceeded cancels a session whose AI calls cost more than its MaxCostUSD
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Assumptions of cost estimates
+const (
+	estimatedCompletionTokens = 800 // Length of an answer
+	quotedMessageTokens       = 125 // A message cut to 500 characters in later rounds' prompts
+	votedMessageTokens        = 50  // A message summarized to 200 characters in the vote prompt
+	moderatorPromptTokens     = 150 // Moderator instructions, without the transcript
+	votePromptTokens          = 150 // Vote instructions appended to the prompt
+)
+
+// PricingProvider returns a model's USD price per prompt and completion token
+type PricingProvider func(model string) (prompt, completion float64, ok bool)
+
+// Usage is what AI calls used
+type Usage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"` // Calls to unpriced models count as free
+}
+
+func (u *Usage) add(o Usage) {
+	u.Calls += o.Calls
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.CostUSD += o.CostUSD
+}
+
+// CostEstimate is the AI usage a session is expected to take, known before it
+// starts. Tokens are approximated at 4 characters each.
+type CostEstimate struct {
+	AICalls          int      `json:"ai_calls"`
+	ContextTokens    int      `json:"context_tokens"` // Market context every call carries
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	CostUSD          float64  `json:"cost_usd"`
+	UnpricedModels   []string `json:"unpriced_models,omitempty"` // Left out of CostUSD
+}
+
+// approxTokens estimates the tokens of a text
+func approxTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// SetPricingProvider sets where model prices for estimates and usage come from
+func (e *Engine) SetPricingProvider(pricing PricingProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pricing = pricing
+}
+
+// price looks a model's prices up, ok is false without a provider
+func (e *Engine) price(model string) (prompt, completion float64, ok bool) {
+	e.mu.RLock()
+	pricing := e.pricing
+	e.mu.RUnlock()
+	if pricing == nil {
+		return 0, 0, false
+	}
+	return pricing(model)
+}
+
+// EstimateCost estimates a session's AI usage from its participants, rounds
+// and the market context it would start with, and keeps it on the session
+func (e *Engine) EstimateCost(sessionID string, marketCtx *MarketContext) (*CostEstimate, error) {
+	session, err := e.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, userPrompt := debatePrompts(session, marketCtx)
+	est := e.estimateCost(session, systemPrompt, userPrompt)
+
+	e.mu.Lock()
+	session.CostEstimate = est
+	e.mu.Unlock()
+	return est, nil
+}
+
+// estimateCost counts the calls a debate makes, each participant every round,
+// the moderator after each round and each participant's vote, with the prompts
+// growing by the earlier rounds they quote
+func (e *Engine) estimateCost(session *SessionWithDetails, systemPrompt, userPrompt string) *CostEstimate {
+	est := &CostEstimate{ContextTokens: approxTokens(userPrompt)}
+	unpriced := make(map[string]bool)
+	call := func(model string, promptTokens int) {
+		est.AICalls++
+		est.PromptTokens += promptTokens
+		est.CompletionTokens += estimatedCompletionTokens
+		prompt, completion, ok := e.price(model)
+		if !ok {
+			unpriced[model] = true
+			return
+		}
+		est.CostUSD += float64(promptTokens)*prompt + estimatedCompletionTokens*completion
+	}
+
+	participants := len(session.Participants)
+	quotedRound := participants * quotedMessageTokens
+	votedRound := participants * votedMessageTokens
+	if session.ModeratorSummary {
+		quotedRound = estimatedCompletionTokens
+		votedRound = estimatedCompletionTokens
+	}
+
+	for round := 1; round <= session.MaxRounds; round++ {
+		for _, p := range session.Participants {
+			system := approxTokens(e.buildDebateSystemPrompt(systemPrompt, p, round, session.MaxRounds))
+			call(p.AIModelID, system+est.ContextTokens+(round-1)*quotedRound)
+		}
+		if session.ModeratorSummary && participants > 0 {
+			model := session.ModeratorModelID
+			if model == "" {
+				model = session.Participants[0].AIModelID
+			}
+			call(model, moderatorPromptTokens+participants*estimatedCompletionTokens)
+		}
+	}
+	for _, p := range session.Participants {
+		call(p.AIModelID, approxTokens(systemPrompt)+est.ContextTokens+session.MaxRounds*votedRound+votePromptTokens)
+	}
+
+	for model := range unpriced {
+		est.UnpricedModels = append(est.UnpricedModels, model)
+	}
+	sort.Strings(est.UnpricedModels)
+	return est
+}
+
+// usageOf prices a call's tokens at the model that answered, or the one asked
+// when the response doesn't name it
+func (e *Engine) usageOf(model string, resp *mcp.Response) Usage {
+	u := Usage{Calls: 1, PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	if prompt, completion, ok := e.price(model); ok {
+		u.CostUSD = float64(u.PromptTokens)*prompt + float64(u.CompletionTokens)*completion
+	}
+	return u
+}
+
+// addUsage adds a call to the session's usage and stops the debate once it
+// costs more than the session's cap
+func (e *Engine) addUsage(session *SessionWithDetails, u Usage) {
+	e.mu.Lock()
+	session.Usage.add(u)
+	total := session.Usage.CostUSD
+	over := session.MaxCostUSD > 0 && total > session.MaxCostUSD
+	abort := session.abort
+	e.mu.Unlock()
+
+	if over && abort != nil {
+		log.Printf("[Debate] Session %s used $%.4f of its $%.4f budget, stopping", session.ID, total, session.MaxCostUSD)
+		abort(ErrBudgetExceeded)
+	}
+}
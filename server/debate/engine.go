@@ -3,9 +3,12 @@ package debate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,10 +20,29 @@ import (
 // MarketContextProvider is a function that provides fresh market context
 type MarketContextProvider func(symbols []string) (*MarketContext, error)
 
+// Session defaults for pacing and AI calls
+const (
+	DefaultSpeakerDelaySeconds = 3.0 // Pause between participants
+	DefaultCallTimeoutSeconds  = 120 // Deadline for a single AI call
+	DefaultMaxConcurrentCalls  = 4   // Worker pool size for parallel rounds
+)
+
+// Clock abstracts waiting so tests can run debates without real delays
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
 // TradeExecutor is a function that executes trades based on debate decisions
 // Now includes session for accessing Binance credentials
 type TradeExecutor func(session *Session, decisions []*Decision) error
 
+// SymbolValidator rejects symbols that can't be traded on the exchange
+type SymbolValidator func(symbol string) error
+
 // Engine runs debate sessions
 type Engine struct {
 	sessions            map[string]*SessionWithDetails
@@ -30,15 +52,21 @@ type Engine struct {
 	mu                  sync.RWMutex
 	marketCtxProvider   MarketContextProvider
 	tradeExecutor       TradeExecutor
+	symbolValidator     SymbolValidator
+	clock               Clock
+	modelStats          *mcp.ModelStats // Participant calls and failures per model
+	pricing             PricingProvider // Model prices for cost estimates and usage
 }
 
 // NewEngine creates a new debate engine
 func NewEngine() *Engine {
 	return &Engine{
-		sessions:  make(map[string]*SessionWithDetails),
-		clients:   make(map[string]mcp.AIClient),
-		eventChan: make(map[string]chan *Event),
-		cancels:   make(map[string]context.CancelFunc),
+		sessions:   make(map[string]*SessionWithDetails),
+		clients:    make(map[string]mcp.AIClient),
+		eventChan:  make(map[string]chan *Event),
+		cancels:    make(map[string]context.CancelFunc),
+		clock:      realClock{},
+		modelStats: mcp.NewModelStats(),
 	}
 }
 
@@ -63,19 +91,54 @@ func (e *Engine) SetTradeExecutor(executor TradeExecutor) {
 	e.tradeExecutor = executor
 }
 
+// SetSymbolValidator sets the check every session symbol must pass on creation
+func (e *Engine) SetSymbolValidator(validator SymbolValidator) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.symbolValidator = validator
+}
+
 // CreateSession creates a new debate session
 func (e *Engine) CreateSession(req *CreateSessionRequest) (*SessionWithDetails, error) {
+	consensus, err := normalizeConsensusConfig(req.Consensus)
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxCostUSD < 0 {
+		return nil, fmt.Errorf("max_cost_usd must not be negative")
+	}
+	if err := ValidateParticipants(req.Participants); err != nil {
+		return nil, err
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if e.symbolValidator != nil {
+		for _, symbol := range req.Symbols {
+			if err := e.symbolValidator(symbol); err != nil {
+				return nil, fmt.Errorf("untradable symbol: %w", err)
+			}
+		}
+	}
+
 	session := &SessionWithDetails{
 		Session: Session{
 			ID:                   fmt.Sprintf("debate_%d", time.Now().UnixNano()),
+			UserID:               req.UserID,
 			Name:                 req.Name,
 			Status:               StatusPending,
 			Symbols:              req.Symbols,
 			MaxRounds:            req.MaxRounds,
 			IntervalMinutes:      req.IntervalMinutes,
+			SpeakerDelaySeconds:  req.SpeakerDelaySeconds,
+			CallTimeoutSeconds:   req.CallTimeoutSeconds,
+			ParallelCalls:        req.ParallelCalls,
+			MaxConcurrentCalls:   req.MaxConcurrentCalls,
+			ModeratorSummary:     req.ModeratorSummary,
+			ModeratorModelID:     req.ModeratorModelID,
+			Consensus:            consensus,
+			MaxCostUSD:           req.MaxCostUSD,
 			PromptVariant:        req.PromptVariant,
 			AutoExecute:          req.AutoExecute,
 			TraderID:             req.TraderID,
@@ -98,30 +161,51 @@ func (e *Engine) CreateSession(req *CreateSessionRequest) (*SessionWithDetails,
 	if session.Language == "" {
 		session.Language = "en-US"
 	}
+	if session.SpeakerDelaySeconds <= 0 {
+		session.SpeakerDelaySeconds = DefaultSpeakerDelaySeconds
+	}
+	if session.CallTimeoutSeconds <= 0 {
+		session.CallTimeoutSeconds = DefaultCallTimeoutSeconds
+	}
+	if session.MaxConcurrentCalls <= 0 {
+		session.MaxConcurrentCalls = DefaultMaxConcurrentCalls
+	}
 	if session.CycleIntervalMinutes <= 0 {
 		session.CycleIntervalMinutes = 5 // Default 5 minutes between cycles
 	}
 
 	// Add participants
-	for i, p := range req.Participants {
+	session.Participants = newParticipants(session.ID, req.Participants)
+
+	e.sessions[session.ID] = session
+	e.eventChan[session.ID] = make(chan *Event, 100)
+
+	return session, nil
+}
+
+// newParticipants builds a session's participants in speaking order
+func newParticipants(sessionID string, reqs []CreateParticipantRequest) []*Participant {
+	participants := make([]*Participant, 0, len(reqs))
+	for i, p := range reqs {
+		role, emoji, color := p.resolveRole()
 		participant := &Participant{
 			ID:          fmt.Sprintf("participant_%d_%d", time.Now().UnixNano(), i),
-			SessionID:   session.ID,
+			SessionID:   sessionID,
 			AIModelID:   p.AIModelID,
 			AIModelName: p.AIModelName,
 			Provider:    p.Provider,
 			Personality: p.Personality,
-			Color:       PersonalityColors[p.Personality],
+			Color:       color,
+			Emoji:       emoji,
 			SpeakOrder:  i + 1,
 			CreatedAt:   time.Now(),
+
+			FallbackModels:  p.FallbackModels,
+			RoleDescription: role,
 		}
-		session.Participants = append(session.Participants, participant)
+		participants = append(participants, participant)
 	}
-
-	e.sessions[session.ID] = session
-	e.eventChan[session.ID] = make(chan *Event, 100)
-
-	return session, nil
+	return participants
 }
 
 // ListSessions returns all sessions
@@ -177,8 +261,9 @@ func (e *Engine) Start(ctx context.Context, sessionID string, marketCtx *MarketC
 	session.Status = StatusRunning
 	session.StartedAt = time.Now()
 
-	ctx, cancel := context.WithCancel(ctx)
-	e.cancels[sessionID] = cancel
+	ctx, cancel := context.WithCancelCause(ctx)
+	e.cancels[sessionID] = func() { cancel(nil) }
+	session.abort = cancel
 	e.mu.Unlock()
 
 	// Run debate in background
@@ -187,17 +272,13 @@ func (e *Engine) Start(ctx context.Context, sessionID string, marketCtx *MarketC
 			e.runAutoCycle(ctx, session, marketCtx)
 		} else {
 			if err := e.runDebate(ctx, session, marketCtx); err != nil {
+				if cause := context.Cause(ctx); errors.Is(cause, ErrBudgetExceeded) {
+					err = cause
+				}
 				log.Printf("Debate error: %v", err)
-				e.mu.Lock()
-				session.Status = StatusCancelled
-				session.Error = err.Error()
-				e.mu.Unlock()
-				e.sendEvent(sessionID, &Event{
-					Type:      "error",
-					SessionID: sessionID,
-					Data:      err.Error(),
-					Timestamp: time.Now(),
-				})
+				e.cancelSession(session, err)
+			} else if session.AutoExecute && len(session.FinalDecisions) > 0 {
+				e.executeDecisions(session)
 			}
 		}
 	}()
@@ -205,6 +286,20 @@ func (e *Engine) Start(ctx context.Context, sessionID string, marketCtx *MarketC
 	return nil
 }
 
+// cancelSession marks a session cancelled by err and sends an error event
+func (e *Engine) cancelSession(session *SessionWithDetails, err error) {
+	e.mu.Lock()
+	session.Status = StatusCancelled
+	session.Error = err.Error()
+	e.mu.Unlock()
+	e.sendEvent(session.ID, &Event{
+		Type:      "error",
+		SessionID: session.ID,
+		Data:      err.Error(),
+		Timestamp: time.Now(),
+	})
+}
+
 // runAutoCycle runs the debate in continuous cycles
 func (e *Engine) runAutoCycle(ctx context.Context, session *SessionWithDetails, initialMarketCtx *MarketContext) {
 	log.Printf("[Debate] Starting auto-cycle for session %s (interval: %d minutes)", session.ID, session.CycleIntervalMinutes)
@@ -241,6 +336,7 @@ func (e *Engine) runAutoCycle(ctx context.Context, session *SessionWithDetails,
 		session.Messages = make([]*Message, 0)
 		session.Votes = make([]*Vote, 0)
 		session.FinalDecisions = nil
+		session.VoteTally = nil
 		session.Error = ""
 		e.mu.Unlock()
 
@@ -262,6 +358,9 @@ func (e *Engine) runAutoCycle(ctx context.Context, session *SessionWithDetails,
 		if err := e.runDebate(ctx, session, marketCtx); err != nil {
 			if ctx.Err() != nil {
 				// Context cancelled, stop cycling
+				if cause := context.Cause(ctx); errors.Is(cause, ErrBudgetExceeded) {
+					e.cancelSession(session, cause)
+				}
 				return
 			}
 			log.Printf("[Debate] Cycle #%d error: %v", session.CycleCount, err)
@@ -308,7 +407,9 @@ func (e *Engine) runAutoCycle(ctx context.Context, session *SessionWithDetails,
 	}
 }
 
-// executeDecisions executes the final decisions from the debate
+// executeDecisions executes the final decisions from the debate.
+// The executor works on copies; outcomes are written back under the lock so
+// GET /api/debate/sessions/{id} shows what actually happened.
 func (e *Engine) executeDecisions(session *SessionWithDetails) {
 	e.mu.RLock()
 	executor := e.tradeExecutor
@@ -319,21 +420,47 @@ func (e *Engine) executeDecisions(session *SessionWithDetails) {
 		return
 	}
 
-	// Check if Binance credentials are configured
-	if session.BinanceAPIKey == "" || session.BinanceSecretKey == "" {
+	// Without a target trader, trades go through the session's own Binance credentials
+	if session.TraderID == "" && (session.BinanceAPIKey == "" || session.BinanceSecretKey == "") {
 		log.Printf("[Debate] No Binance credentials configured for session, skipping execution")
 		e.sendEvent(session.ID, &Event{
 			Type:      "execution_error",
 			SessionID: session.ID,
-			Data:      "No Binance API credentials configured. Please add your API key and secret to enable auto-execution.",
+			Data:      "No Binance API credentials configured. Please add your API key and secret, or select a trader, to enable auto-execution.",
 			Timestamp: time.Now(),
 		})
 		return
 	}
 
-	log.Printf("[Debate] Executing %d decisions from cycle #%d", len(session.FinalDecisions), session.CycleCount)
+	e.mu.RLock()
+	sessionCopy := session.Session
+	decisions := make([]*Decision, len(session.FinalDecisions))
+	for i, d := range session.FinalDecisions {
+		dc := *d
+		decisions[i] = &dc
+	}
+	e.mu.RUnlock()
 
-	if err := executor(&session.Session, session.FinalDecisions); err != nil {
+	log.Printf("[Debate] Executing %d decisions from cycle #%d", len(decisions), session.CycleCount)
+
+	err := executor(&sessionCopy, decisions)
+
+	e.mu.Lock()
+	for i, d := range decisions {
+		if i >= len(session.FinalDecisions) {
+			break
+		}
+		if err != nil && !d.Executed && d.Error == "" {
+			d.Error = err.Error()
+		}
+		session.FinalDecisions[i].Executed = d.Executed
+		session.FinalDecisions[i].ExecutedAt = d.ExecutedAt
+		session.FinalDecisions[i].OrderID = d.OrderID
+		session.FinalDecisions[i].Error = d.Error
+	}
+	e.mu.Unlock()
+
+	if err != nil {
 		log.Printf("[Debate] Trade execution error: %v", err)
 		e.sendEvent(session.ID, &Event{
 			Type:      "execution_error",
@@ -342,15 +469,10 @@ func (e *Engine) executeDecisions(session *SessionWithDetails) {
 			Timestamp: time.Now(),
 		})
 	} else {
-		// Mark decisions as executed
-		for _, d := range session.FinalDecisions {
-			d.Executed = true
-			d.ExecutedAt = time.Now()
-		}
 		e.sendEvent(session.ID, &Event{
 			Type:      "execution_complete",
 			SessionID: session.ID,
-			Data:      session.FinalDecisions,
+			Data:      decisions,
 			Timestamp: time.Now(),
 		})
 	}
@@ -377,10 +499,13 @@ func (e *Engine) Stop(sessionID string) error {
 
 // runDebate executes the debate process
 func (e *Engine) runDebate(ctx context.Context, session *SessionWithDetails, marketCtx *MarketContext) error {
-	lang := decision.LangEnglish
-	if session.Language == "zh-CN" {
-		lang = decision.LangChinese
-	}
+	baseSystemPrompt, userPrompt := debatePrompts(session, marketCtx)
+	return e.runDebateWithPrompts(ctx, session, baseSystemPrompt, userPrompt)
+}
+
+// debatePrompts builds the base prompts of a session's debate on marketCtx
+func debatePrompts(session *SessionWithDetails, marketCtx *MarketContext) (string, string) {
+	lang := decision.Language(session.Language)
 
 	// Build base prompts
 	promptBuilder := decision.NewPromptBuilder(lang)
@@ -402,6 +527,13 @@ func (e *Engine) runDebate(ctx context.Context, session *SessionWithDetails, mar
 	}
 	userPrompt := promptBuilder.BuildUserPrompt(decisionCtx)
 
+	return baseSystemPrompt, userPrompt
+}
+
+// runDebateWithPrompts runs the rounds, vote and consensus from prebuilt base prompts
+func (e *Engine) runDebateWithPrompts(ctx context.Context, session *SessionWithDetails, baseSystemPrompt, userPrompt string) error {
+	speakerDelay := time.Duration(session.SpeakerDelaySeconds * float64(time.Second))
+
 	// Run debate rounds
 	for round := 1; round <= session.MaxRounds; round++ {
 		select {
@@ -419,69 +551,34 @@ func (e *Engine) runDebate(ctx context.Context, session *SessionWithDetails, mar
 		})
 
 		// Get response from each participant
-		for _, participant := range session.Participants {
-			// Build personality-enhanced prompt
-			systemPrompt := e.buildDebateSystemPrompt(baseSystemPrompt, participant, round, session.MaxRounds)
-			debateUserPrompt := e.buildDebateUserPrompt(userPrompt, session.Messages, participant, round)
-
-			// Get AI client
-			client := e.clients[participant.Provider]
-			if client == nil {
-				// Try default client
-				for _, c := range e.clients {
-					client = c
-					break
-				}
-			}
-			if client == nil {
-				return fmt.Errorf("no AI client available for %s", participant.Provider)
+		if session.ParallelCalls {
+			if err := e.runRoundParallel(ctx, session, round, baseSystemPrompt, userPrompt); err != nil {
+				return err
 			}
+		} else {
+			for i, participant := range session.Participants {
+				// Pause between speakers; cancellable so Stop takes effect immediately
+				if i > 0 {
+					if err := e.sleep(ctx, speakerDelay); err != nil {
+						return err
+					}
+				}
 
-			// Call AI
-			response, err := client.CallWithMessages(systemPrompt, debateUserPrompt)
-			if err != nil {
-				log.Printf("AI call failed for %s: %v", participant.AIModelName, err)
-				continue
-			}
-
-			// Parse decisions
-			decisions, confidence := parseDecisions(response)
-
-			// Create message
-			msgType := "analysis"
-			if round > 1 {
-				msgType = "rebuttal"
+				msg, err := e.speak(ctx, session, participant, round, baseSystemPrompt, userPrompt, session.Messages)
+				if err != nil {
+					return err
+				}
+				e.addMessage(session, msg)
 			}
+		}
 
-			msg := &Message{
-				ID:          fmt.Sprintf("msg_%d", time.Now().UnixNano()),
-				SessionID:   session.ID,
-				Round:       round,
-				AIModelID:   participant.AIModelID,
-				AIModelName: participant.AIModelName,
-				Provider:    participant.Provider,
-				Personality: participant.Personality,
-				MessageType: msgType,
-				Content:     response,
-				Decisions:   decisions,
-				Confidence:  confidence,
-				CreatedAt:   time.Now(),
+		if session.ModeratorSummary {
+			if err := e.moderateRound(ctx, session, round); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("[Debate] Moderator summary for round %d failed: %v", round, err)
 			}
-
-			e.mu.Lock()
-			session.Messages = append(session.Messages, msg)
-			e.mu.Unlock()
-
-			e.sendEvent(session.ID, &Event{
-				Type:      "message",
-				SessionID: session.ID,
-				Round:     round,
-				Data:      msg,
-				Timestamp: time.Now(),
-			})
-
-			// Wait between participants
-			time.Sleep(time.Duration(session.IntervalMinutes) * time.Minute / time.Duration(len(session.Participants)))
 		}
 
 		e.sendEvent(session.ID, &Event{
@@ -505,34 +602,270 @@ func (e *Engine) runDebate(ctx context.Context, session *SessionWithDetails, mar
 	if err != nil {
 		return fmt.Errorf("voting failed: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	e.mu.Lock()
 	session.Votes = votes
 	e.mu.Unlock()
 
 	// Determine consensus
-	finalDecisions := e.determineConsensus(votes)
+	finalDecisions, tally := e.determineConsensus(votes, session.Consensus)
 
 	e.mu.Lock()
 	session.FinalDecisions = finalDecisions
+	session.VoteTally = tally
 	session.Status = StatusCompleted
 	session.CompletedAt = time.Now()
+	usage := session.Usage
 	e.mu.Unlock()
 
 	e.sendEvent(session.ID, &Event{
 		Type:      "consensus",
 		SessionID: session.ID,
 		Data:      finalDecisions,
+		Usage:     &usage,
 		Timestamp: time.Now(),
 	})
 
 	return nil
 }
 
+// runRoundParallel queries all participants of a round through a bounded worker
+// pool. Everyone sees the same history; messages are appended in speak order.
+func (e *Engine) runRoundParallel(ctx context.Context, session *SessionWithDetails, round int, baseSystemPrompt, userPrompt string) error {
+	e.mu.RLock()
+	history := append([]*Message(nil), session.Messages...)
+	e.mu.RUnlock()
+
+	workers := session.MaxConcurrentCalls
+	if workers <= 0 {
+		workers = DefaultMaxConcurrentCalls
+	}
+
+	msgs := make([]*Message, len(session.Participants))
+	errs := make([]error, len(session.Participants))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, participant := range session.Participants {
+		wg.Add(1)
+		go func(i int, participant *Participant) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			msgs[i], errs[i] = e.speak(ctx, session, participant, round, baseSystemPrompt, userPrompt, history)
+		}(i, participant)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for i, msg := range msgs {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		e.addMessage(session, msg)
+	}
+	return nil
+}
+
+// speak asks one participant for its turn. A failed or timed-out call yields a
+// no_response message so one slow provider can't stall the round; an error is
+// only returned when no client is available at all.
+func (e *Engine) speak(ctx context.Context, session *SessionWithDetails, participant *Participant, round int, baseSystemPrompt, userPrompt string, history []*Message) (*Message, error) {
+	// Build personality-enhanced prompt
+	systemPrompt := e.buildDebateSystemPrompt(baseSystemPrompt, participant, round, session.MaxRounds)
+	debateUserPrompt := e.buildDebateUserPrompt(userPrompt, history, participant, round)
+
+	client := e.clientFor(participant.Provider)
+	if client == nil {
+		return nil, fmt.Errorf("no AI client available for %s", participant.Provider)
+	}
+
+	msgType := "analysis"
+	if round > 1 {
+		msgType = "rebuttal"
+	}
+
+	msg := &Message{
+		ID:          fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		SessionID:   session.ID,
+		Round:       round,
+		AIModelID:   participant.AIModelID,
+		AIModelName: participant.AIModelName,
+		Provider:    participant.Provider,
+		Personality: participant.Personality,
+		MessageType: msgType,
+	}
+
+	// Call AI with the participant's own model
+	resp, err := e.callParticipant(ctx, session, client, participant, systemPrompt, debateUserPrompt, nil)
+	if err != nil {
+		log.Printf("AI call failed for %s: %v", participant.AIModelName, err)
+		msg.MessageType = "no_response"
+		msg.Error = err.Error()
+		msg.CreatedAt = time.Now()
+		return msg, nil
+	}
+
+	usage := e.usageOf(participant.AIModelID, resp)
+	msg.Model = resp.Model
+	msg.Usage = &usage
+	msg.Content = resp.Content
+	msg.Decisions, msg.Confidence = parseDecisions(resp.Content)
+	msg.CreatedAt = time.Now()
+	return msg, nil
+}
+
+// callParticipant calls the participant's model, then its fallback models in
+// order while calls fail. The session's first fallback is announced with a
+// model_fallback event.
+func (e *Engine) callParticipant(ctx context.Context, session *SessionWithDetails, client mcp.AIClient, participant *Participant, systemPrompt, userPrompt string, format *mcp.ResponseFormat) (*mcp.Response, error) {
+	models := mcp.FallbackChain(participant.AIModelID, participant.FallbackModels)
+	if len(models) == 0 {
+		models = []string{participant.AIModelID}
+	}
+
+	var firstErr error
+	for i, model := range models {
+		resp, err := e.callAI(ctx, session, client, model, systemPrompt, userPrompt, format)
+		e.modelStats.Record(model, err != nil)
+		if err == nil {
+			if i > 0 {
+				e.announceFallback(session, participant, model, firstErr)
+			}
+			return resp, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		// A stopped session isn't the model's fault
+		if ctx.Err() != nil || i == len(models)-1 {
+			return nil, err
+		}
+		log.Printf("AI call failed for %s with %s (%v), falling back to %s", participant.AIModelName, model, err, models[i+1])
+	}
+	return nil, firstErr
+}
+
+// announceFallback sends a model_fallback event the first time one of the
+// session's participants falls back to another model
+func (e *Engine) announceFallback(session *SessionWithDetails, participant *Participant, model string, cause error) {
+	e.mu.Lock()
+	announced := session.fallbackAnnounced
+	session.fallbackAnnounced = true
+	e.mu.Unlock()
+	if announced {
+		return
+	}
+
+	log.Printf("Debate %s: %s fell back from %s to %s: %v", session.ID, participant.AIModelName, participant.AIModelID, model, cause)
+	e.sendEvent(session.ID, &Event{
+		Type:      "model_fallback",
+		SessionID: session.ID,
+		Data: map[string]interface{}{
+			"participant": participant.AIModelName,
+			"primary":     participant.AIModelID,
+			"model":       model,
+			"error":       cause.Error(),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// ModelStats returns the calls and failures of each model participants used
+func (e *Engine) ModelStats() map[string]mcp.ModelStat {
+	return e.modelStats.Snapshot()
+}
+
+// callAI makes one AI call bounded by the session's per-call deadline. A format
+// is only sent to clients that support structured output.
+func (e *Engine) callAI(ctx context.Context, session *SessionWithDetails, client mcp.AIClient, model, systemPrompt, userPrompt string, format *mcp.ResponseFormat) (*mcp.Response, error) {
+	timeout := time.Duration(session.CallTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultCallTimeoutSeconds * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var resp *mcp.Response
+	var err error
+	if sc, ok := client.(mcp.StructuredCaller); ok && format != nil {
+		resp, err = sc.CallStructured(callCtx, model, systemPrompt, userPrompt, format)
+	} else {
+		resp, err = client.CallWithModel(callCtx, model, systemPrompt, userPrompt)
+	}
+	if err != nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, fmt.Errorf("no response within %v: %w", timeout, err)
+	}
+	if err == nil {
+		e.addUsage(session, e.usageOf(model, resp))
+	}
+	return resp, err
+}
+
+// clientFor returns the client registered for a provider, or any client as a fallback
+func (e *Engine) clientFor(provider string) mcp.AIClient {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if client := e.clients[provider]; client != nil {
+		return client
+	}
+	for _, c := range e.clients {
+		return c
+	}
+	return nil
+}
+
+// addMessage appends a message to the session and publishes it
+func (e *Engine) addMessage(session *SessionWithDetails, msg *Message) {
+	e.mu.Lock()
+	session.Messages = append(session.Messages, msg)
+	e.mu.Unlock()
+
+	e.sendEvent(session.ID, &Event{
+		Type:      "message",
+		SessionID: session.ID,
+		Round:     msg.Round,
+		Data:      msg,
+		Timestamp: time.Now(),
+	})
+}
+
+// sleep waits for d or until ctx is cancelled
+func (e *Engine) sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	clock := e.clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
+
 // buildDebateSystemPrompt builds personality-enhanced system prompt (NOFX-style exact copy)
 func (e *Engine) buildDebateSystemPrompt(basePrompt string, participant *Participant, round, maxRounds int) string {
-	personality := GetPersonalityDescription(participant.Personality)
-	emoji := PersonalityEmojis[participant.Personality]
+	personality := participant.RoleDescription
+	if personality == "" {
+		personality = GetPersonalityDescription(participant.Personality)
+	}
+	emoji := participant.Emoji
+	if emoji == "" {
+		emoji = PersonalityEmojis[participant.Personality]
+	}
 
 	debateInstructions := fmt.Sprintf(`You are a professional quantitative trading AI assistant participating in a multi-AI market debate.
 
@@ -566,6 +899,8 @@ You are %s %s.
 - Use Open Interest (OI) changes to validate capital flow authenticity
 - OI up + Price up = Strong bullish trend
 - OI down + Price up = Shorts covering (potential reversal)
+- OI up + Price down = Strong bearish trend
+- OI down + Price down = Longs closing (potential reversal)
 
 ### Scale Operations
 - Scale-in: First entry max 50%% of target position
@@ -640,12 +975,23 @@ func (e *Engine) buildDebateUserPrompt(basePrompt string, messages []*Message, p
 		return basePrompt
 	}
 
+	// Rounds with a moderator summary are represented by the summary alone
+	summarized := summarizedRounds(messages)
+
 	var sb strings.Builder
 	sb.WriteString(basePrompt)
 	sb.WriteString("\n\n---\n\n## Previous Round Messages\n\n")
 
 	for _, msg := range messages {
-		if msg.Round < round {
+		if msg.Round >= round {
+			continue
+		}
+		if msg.MessageType == "moderator_summary" {
+			sb.WriteString(fmt.Sprintf("### 🧑‍⚖️ Moderator Summary (Round %d)\n", msg.Round))
+			sb.WriteString(msg.Content + "\n\n")
+			continue
+		}
+		if !summarized[msg.Round] && msg.MessageType != "no_response" {
 			emoji := PersonalityEmojis[msg.Personality]
 			sb.WriteString(fmt.Sprintf("### %s %s (%s)\n", emoji, msg.AIModelName, msg.Personality))
 			// Include summary, not full content
@@ -662,6 +1008,94 @@ func (e *Engine) buildDebateUserPrompt(basePrompt string, messages []*Message, p
 	return sb.String()
 }
 
+// summarizedRounds returns the rounds that have a moderator summary
+func summarizedRounds(messages []*Message) map[int]bool {
+	rounds := make(map[int]bool)
+	for _, msg := range messages {
+		if msg.MessageType == "moderator_summary" {
+			rounds[msg.Round] = true
+		}
+	}
+	return rounds
+}
+
+// moderateRound asks a neutral moderator to summarize each participant's position
+// per symbol and the key disagreements, and records it as a moderator_summary message
+func (e *Engine) moderateRound(ctx context.Context, session *SessionWithDetails, round int) error {
+	if len(session.Participants) == 0 {
+		return nil
+	}
+
+	e.mu.RLock()
+	var transcript strings.Builder
+	for _, msg := range session.Messages {
+		if msg.Round != round || msg.MessageType == "no_response" || msg.MessageType == "moderator_summary" {
+			continue
+		}
+		transcript.WriteString(fmt.Sprintf("### %s (%s)\n%s\n\n", msg.AIModelName, msg.Personality, msg.Content))
+	}
+	e.mu.RUnlock()
+
+	if transcript.Len() == 0 {
+		return nil
+	}
+
+	model := session.ModeratorModelID
+	if model == "" {
+		model = session.Participants[0].AIModelID
+	}
+	client := e.clientFor(session.Participants[0].Provider)
+	if client == nil {
+		return fmt.Errorf("no AI client available for moderator")
+	}
+
+	systemPrompt := `You are the neutral moderator of a multi-AI crypto trading debate. You do not take sides or make trading decisions.
+
+Summarize the round you are given:
+1. For each symbol, list every participant's position: action, confidence, and their strongest supporting argument with the specific data points they cited.
+2. Under "Key Disagreements", state precisely where participants conflict (direction, entry, stop loss, take profit, sizing) and what evidence each side relies on.
+3. Note any claims that were left unanswered.
+
+Be concise but keep the substance. Use markdown headings per symbol.`
+	if lang := decision.Language(session.Language); lang != decision.LangEnglish && lang.Name() != "" {
+		systemPrompt += "\n\nRespond in " + lang.Name() + "."
+	}
+	userPrompt := fmt.Sprintf("Symbols: %s\n\n## Round %d Transcript\n\n%s", strings.Join(session.Symbols, ", "), round, transcript.String())
+
+	resp, err := e.callAI(ctx, session, client, model, systemPrompt, userPrompt, nil)
+	if err != nil {
+		return err
+	}
+
+	usage := e.usageOf(model, resp)
+	msg := &Message{
+		ID:          fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		SessionID:   session.ID,
+		Round:       round,
+		AIModelID:   model,
+		AIModelName: "Moderator",
+		Provider:    session.Participants[0].Provider,
+		Model:       resp.Model,
+		MessageType: "moderator_summary",
+		Content:     resp.Content,
+		Usage:       &usage,
+		CreatedAt:   time.Now(),
+	}
+
+	e.mu.Lock()
+	session.Messages = append(session.Messages, msg)
+	e.mu.Unlock()
+
+	e.sendEvent(session.ID, &Event{
+		Type:      "moderator_summary",
+		SessionID: session.ID,
+		Round:     round,
+		Data:      msg,
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+
 // collectVotes collects final votes from all participants
 func (e *Engine) collectVotes(ctx context.Context, session *SessionWithDetails, systemPrompt, userPrompt string) ([]*Vote, error) {
 	var votes []*Vote
@@ -687,40 +1121,54 @@ Your final analysis summarizing the key points from the debate...
 `
 
 	for _, participant := range session.Participants {
-		client := e.clients[participant.Provider]
-		if client == nil {
-			for _, c := range e.clients {
-				client = c
-				break
-			}
-		}
+		client := e.clientFor(participant.Provider)
 		if client == nil {
 			continue
 		}
 
 		// Build vote context with all messages
 		fullPrompt := userPrompt + "\n\n## Debate Summary\n\n"
+		summarized := summarizedRounds(session.Messages)
 		for _, msg := range session.Messages {
+			if msg.MessageType == "moderator_summary" {
+				fullPrompt += fmt.Sprintf("**Moderator (Round %d)**:\n%s\n\n", msg.Round, msg.Content)
+				continue
+			}
+			if msg.MessageType == "no_response" || summarized[msg.Round] {
+				continue
+			}
 			fullPrompt += fmt.Sprintf("**%s**: %s\n\n", msg.AIModelName, summarizeMessage(msg.Content))
 		}
 		fullPrompt += votePrompt
 
-		response, err := client.CallWithMessages(systemPrompt, fullPrompt)
+		// Votes are pure decisions, so ask for the schema where the model supports it
+		resp, err := e.callParticipant(ctx, session, client, participant, systemPrompt, fullPrompt, decision.DecisionResponseFormat())
 		if err != nil {
 			log.Printf("Vote failed for %s: %v", participant.AIModelName, err)
 			continue
 		}
-
-		decisions, _ := parseDecisions(response)
+		response := resp.Content
+
+		decisions, reasoning, ok := parseStructuredDecisions(response, resp.Structured)
+		parsePath := decision.ParsePathStructured
+		if !ok {
+			decisions, _ = parseDecisions(response)
+			reasoning = extractReasoning(response)
+			parsePath = decision.ParsePathRegex
+		}
+		usage := e.usageOf(participant.AIModelID, resp)
 
 		vote := &Vote{
 			ID:          fmt.Sprintf("vote_%d", time.Now().UnixNano()),
 			SessionID:   session.ID,
 			AIModelID:   participant.AIModelID,
 			AIModelName: participant.AIModelName,
+			Model:       resp.Model,
 			Personality: participant.Personality,
 			Decisions:   decisions,
-			Reasoning:   extractReasoning(response),
+			Reasoning:   reasoning,
+			ParsePath:   parsePath,
+			Usage:       &usage,
 			CreatedAt:   time.Now(),
 		}
 
@@ -737,8 +1185,14 @@ Your final analysis summarizing the key points from the debate...
 	return votes, nil
 }
 
-// determineConsensus determines the final consensus from votes
-func (e *Engine) determineConsensus(votes []*Vote) []*Decision {
+// determineConsensus determines the final consensus from votes using the
+// session's rules (nil uses DefaultConsensusConfig). Symbols where the rules
+// aren't met get an explicit wait; the tally shows how close each vote was.
+func (e *Engine) determineConsensus(votes []*Vote, cfg *ConsensusConfig) ([]*Decision, []*SymbolTally) {
+	if cfg == nil {
+		cfg = DefaultConsensusConfig()
+	}
+
 	type actionData struct {
 		score       float64
 		totalConf   int
@@ -752,15 +1206,16 @@ func (e *Engine) determineConsensus(votes []*Vote) []*Decision {
 	}
 
 	symbolActions := make(map[string]map[string]*actionData)
+	ignored := make(map[string]int)
 
 	// Aggregate votes - filter out low confidence decisions
-	const minConfidenceThreshold = 50 // Minimum confidence to include in consensus
 	for _, vote := range votes {
 		for _, d := range vote.Decisions {
 			// Skip low confidence decisions - they shouldn't influence consensus
-			if d.Confidence < minConfidenceThreshold {
+			if d.Confidence < cfg.MinConfidence {
 				log.Printf("[Debate] Skipping low confidence decision: %s %s (confidence: %d%% < %d%%)",
-					d.Symbol, d.Action, d.Confidence, minConfidenceThreshold)
+					d.Symbol, d.Action, d.Confidence, cfg.MinConfidence)
+				ignored[d.Symbol]++
 				continue
 			}
 
@@ -789,25 +1244,88 @@ func (e *Engine) determineConsensus(votes []*Vote) []*Decision {
 		}
 	}
 
+	// Deterministic order instead of map order
+	symbols := make([]string, 0, len(symbolActions)+len(ignored))
+	for symbol := range symbolActions {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range ignored {
+		if symbolActions[symbol] == nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
 	// Determine winning action per symbol
 	var results []*Decision
-	for symbol, actions := range symbolActions {
-		var winningAction string
-		var maxScore float64
-		var winningData *actionData
-
+	var tallies []*SymbolTally
+	for _, symbol := range symbols {
+		actions := symbolActions[symbol]
+		tally := &SymbolTally{Symbol: symbol, Ignored: ignored[symbol]}
+		tallies = append(tallies, tally)
+
+		var totalScore float64
+		var counted int
 		for action, ad := range actions {
-			if ad.score > maxScore {
-				maxScore = ad.score
-				winningAction = action
-				winningData = ad
+			tally.Actions = append(tally.Actions, &ActionTally{
+				Action:        action,
+				Votes:         ad.count,
+				Score:         ad.score,
+				AvgConfidence: ad.totalConf / ad.count,
+			})
+			totalScore += ad.score
+			counted += ad.count
+		}
+		sort.Slice(tally.Actions, func(i, j int) bool {
+			a, b := tally.Actions[i], tally.Actions[j]
+			if !scoresEqual(a.Score, b.Score) {
+				return a.Score > b.Score
+			}
+			if a.AvgConfidence != b.AvgConfidence {
+				return a.AvgConfidence > b.AvgConfidence
 			}
+			return a.Action < b.Action
+		})
+
+		if counted == 0 {
+			// Nothing above the confidence floor - nothing to decide
+			tally.Reason = "no votes above minimum confidence"
+			continue
+		}
+
+		top := tally.Actions[0]
+		reason := ""
+		switch {
+		case counted < cfg.MinParticipation:
+			reason = fmt.Sprintf("only %d of %d required votes", counted, cfg.MinParticipation)
+		case len(tally.Actions) > 1 && scoresEqual(tally.Actions[1].Score, top.Score) &&
+			(cfg.TieBreak != TieBreakHighestConfidence || tally.Actions[1].AvgConfidence == top.AvgConfidence):
+			reason = fmt.Sprintf("tie between %s and %s", top.Action, tally.Actions[1].Action)
+		case cfg.Strategy == ConsensusSupermajority && top.Score/totalScore < cfg.SupermajorityPct:
+			reason = fmt.Sprintf("%s has %.0f%% of the weighted vote, supermajority needs %.0f%%",
+				top.Action, top.Score/totalScore*100, cfg.SupermajorityPct*100)
+		case cfg.Strategy == ConsensusUnanimousOrWait && len(tally.Actions) > 1:
+			reason = fmt.Sprintf("not unanimous (%d different actions)", len(tally.Actions))
+		case top.Score < cfg.MinWinningScore:
+			reason = fmt.Sprintf("%s scored %.2f, below minimum %.2f", top.Action, top.Score, cfg.MinWinningScore)
 		}
 
-		if winningData == nil || winningData.count == 0 {
+		if reason != "" {
+			tally.Outcome = "wait"
+			tally.Reason = reason
+			results = append(results, &Decision{
+				Symbol:    symbol,
+				Action:    "wait",
+				Reasoning: "no consensus: " + reason,
+			})
 			continue
 		}
 
+		winningAction := top.Action
+		winningData := actions[winningAction]
+		tally.Outcome = winningAction
+		tally.Consensus = true
+
 		// Calculate averages
 		avgConf := winningData.totalConf / winningData.count
 		avgLev := winningData.totalLev / winningData.count
@@ -839,7 +1357,53 @@ func (e *Engine) determineConsensus(votes []*Vote) []*Decision {
 		results = append(results, decision)
 	}
 
-	return results
+	return results, tallies
+}
+
+// scoresEqual compares confidence-weighted scores, which are sums of floats
+func scoresEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// normalizeConsensusConfig fills unset fields with defaults and rejects unknown rules
+func normalizeConsensusConfig(cfg *ConsensusConfig) (*ConsensusConfig, error) {
+	defaults := DefaultConsensusConfig()
+	if cfg == nil {
+		return defaults, nil
+	}
+
+	normalized := *cfg
+	if normalized.Strategy == "" {
+		normalized.Strategy = defaults.Strategy
+	}
+	if normalized.MinConfidence <= 0 {
+		normalized.MinConfidence = defaults.MinConfidence
+	}
+	if normalized.MinParticipation <= 0 {
+		normalized.MinParticipation = defaults.MinParticipation
+	}
+	if normalized.SupermajorityPct <= 0 {
+		normalized.SupermajorityPct = defaults.SupermajorityPct
+	}
+	if normalized.TieBreak == "" {
+		normalized.TieBreak = defaults.TieBreak
+	}
+
+	switch normalized.Strategy {
+	case ConsensusPlurality, ConsensusSupermajority, ConsensusUnanimousOrWait:
+	default:
+		return nil, fmt.Errorf("unknown consensus strategy: %s", normalized.Strategy)
+	}
+	switch normalized.TieBreak {
+	case TieBreakWait, TieBreakHighestConfidence:
+	default:
+		return nil, fmt.Errorf("unknown tie break rule: %s", normalized.TieBreak)
+	}
+	if normalized.SupermajorityPct > 1 {
+		return nil, fmt.Errorf("supermajority_pct must be between 0 and 1")
+	}
+
+	return &normalized, nil
 }
 
 // sendEvent sends an event to subscribers
@@ -1000,6 +1564,36 @@ func parseDecisions(response string) ([]*Decision, int) {
 	return convertRawDecisions(rawDecisions)
 }
 
+// parseStructuredDecisions reads a response produced under decision.DecisionResponseFormat.
+// ok is false when the schema wasn't applied or the model ignored it.
+func parseStructuredDecisions(response string, structured bool) ([]*Decision, string, bool) {
+	if !structured {
+		return nil, "", false
+	}
+
+	var out struct {
+		Reasoning string `json:"reasoning"`
+		Decisions []struct {
+			Symbol          string  `json:"symbol"`
+			Action          string  `json:"action"`
+			Confidence      int     `json:"confidence"`
+			Leverage        int     `json:"leverage"`
+			PositionPct     float64 `json:"position_pct"`
+			PositionSizeUSD float64 `json:"position_size_usd"`
+			StopLoss        float64 `json:"stop_loss"`
+			TakeProfit      float64 `json:"take_profit"`
+			Reasoning       string  `json:"reasoning"`
+		} `json:"decisions"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &out); err != nil || len(out.Decisions) == 0 {
+		log.Printf("⚠️  Structured response didn't match the schema, falling back to text parsing")
+		return nil, "", false
+	}
+
+	decisions, _ := convertRawDecisions(out.Decisions)
+	return decisions, strings.TrimSpace(out.Reasoning), true
+}
+
 // convertRawDecisions converts raw parsed decisions to Decision structs
 func convertRawDecisions(rawDecisions []struct {
 	Symbol          string  `json:"symbol"`
@@ -1,7 +1,17 @@
 package debate
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/market"
+	"auto-trader-ahh/mcp"
 )
 
 func TestDetermineConsensus_ConfidenceThreshold(t *testing.T) {
@@ -100,7 +110,7 @@ func TestDetermineConsensus_ConfidenceThreshold(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results := e.determineConsensus(tt.votes)
+			results, _ := e.determineConsensus(tt.votes, nil)
 			if len(results) != tt.wantDecisions {
 				t.Errorf("determineConsensus() returned %d decisions, want %d. %s",
 					len(results), tt.wantDecisions, tt.description)
@@ -141,7 +151,7 @@ func TestDetermineConsensus_PreservesPositionSizeUSD(t *testing.T) {
 		},
 	}
 
-	results := e.determineConsensus(votes)
+	results, _ := e.determineConsensus(votes, nil)
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 decision, got %d", len(results))
@@ -181,7 +191,7 @@ func TestDetermineConsensus_WeightsByConfidence(t *testing.T) {
 		},
 	}
 
-	results := e.determineConsensus(votes)
+	results, _ := e.determineConsensus(votes, nil)
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 decision, got %d", len(results))
@@ -213,7 +223,7 @@ func TestDetermineConsensus_MultipleSymbols(t *testing.T) {
 		},
 	}
 
-	results := e.determineConsensus(votes)
+	results, _ := e.determineConsensus(votes, nil)
 
 	// Should have decisions for BTCUSDT, ETHUSDT, and SOLUSDT
 	if len(results) != 3 {
@@ -251,7 +261,7 @@ func TestDetermineConsensus_DefaultValues(t *testing.T) {
 		},
 	}
 
-	results := e.determineConsensus(votes)
+	results, _ := e.determineConsensus(votes, nil)
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 decision, got %d", len(results))
@@ -288,7 +298,7 @@ func TestDetermineConsensus_NoDefaultWhenPositionProvided(t *testing.T) {
 		},
 	}
 
-	results := e.determineConsensus(votes)
+	results, _ := e.determineConsensus(votes, nil)
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 decision, got %d", len(results))
@@ -306,6 +316,190 @@ func TestDetermineConsensus_NoDefaultWhenPositionProvided(t *testing.T) {
 	}
 }
 
+func TestDetermineConsensus_TieWaits(t *testing.T) {
+	e := &Engine{}
+
+	votes := []*Vote{
+		{Personality: "Bull", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70}}},
+		{Personality: "Bear", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_short", Confidence: 70}}},
+	}
+
+	results, tallies := e.determineConsensus(votes, nil)
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 decision, got %d", len(results))
+	}
+	if results[0].Action != "wait" {
+		t.Errorf("Tie should resolve to wait, got %s", results[0].Action)
+	}
+	if !strings.HasPrefix(results[0].Reasoning, "no consensus") {
+		t.Errorf("Reasoning = %q, want no consensus", results[0].Reasoning)
+	}
+
+	if len(tallies) != 1 || len(tallies[0].Actions) != 2 {
+		t.Fatalf("Expected tally with 2 actions, got %+v", tallies)
+	}
+	if tallies[0].Consensus {
+		t.Error("Tally should not report consensus on a tie")
+	}
+}
+
+func TestDetermineConsensus_TieBreakHighestConfidence(t *testing.T) {
+	e := &Engine{}
+
+	// Equal weighted scores, but the bear is more sure of itself
+	votes := []*Vote{
+		{Personality: "Bull", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 50}}},
+		{Personality: "Analyst", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 50}}},
+		{Personality: "Bear", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_short", Confidence: 100}}},
+	}
+
+	cfg := DefaultConsensusConfig()
+	cfg.TieBreak = TieBreakHighestConfidence
+	results, _ := e.determineConsensus(votes, cfg)
+
+	if len(results) != 1 || results[0].Action != "open_short" {
+		t.Errorf("Expected open_short to win the tie on confidence, got %+v", results)
+	}
+}
+
+func TestDetermineConsensus_Supermajority(t *testing.T) {
+	e := &Engine{}
+
+	// Two lukewarm bulls vs one confident bear
+	votes := []*Vote{
+		{Personality: "Bull", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 55}}},
+		{Personality: "Analyst", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 55}}},
+		{Personality: "Bear", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_short", Confidence: 95}}},
+	}
+
+	// Plurality lets the bulls win
+	results, _ := e.determineConsensus(votes, nil)
+	if len(results) != 1 || results[0].Action != "open_long" {
+		t.Fatalf("Plurality: expected open_long, got %+v", results)
+	}
+
+	// 1.10 of 2.05 is ~54%, short of a two-thirds supermajority
+	cfg := DefaultConsensusConfig()
+	cfg.Strategy = ConsensusSupermajority
+	results, tallies := e.determineConsensus(votes, cfg)
+	if len(results) != 1 || results[0].Action != "wait" {
+		t.Fatalf("Supermajority: expected wait, got %+v", results)
+	}
+	if tallies[0].Outcome != "wait" || tallies[0].Reason == "" {
+		t.Errorf("Tally should explain the missing supermajority, got %+v", tallies[0])
+	}
+
+	// A lower bar is met
+	cfg.SupermajorityPct = 0.5
+	results, tallies = e.determineConsensus(votes, cfg)
+	if len(results) != 1 || results[0].Action != "open_long" {
+		t.Errorf("Supermajority 50%%: expected open_long, got %+v", results)
+	}
+	if !tallies[0].Consensus {
+		t.Error("Tally should report consensus")
+	}
+}
+
+func TestDetermineConsensus_UnanimousOrWait(t *testing.T) {
+	e := &Engine{}
+
+	cfg := DefaultConsensusConfig()
+	cfg.Strategy = ConsensusUnanimousOrWait
+	cfg.MinParticipation = 2
+
+	split := []*Vote{
+		{Personality: "Bull", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90}}},
+		{Personality: "Analyst", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90}}},
+		{Personality: "Bear", Decisions: []*Decision{{Symbol: "BTCUSDT", Action: "hold", Confidence: 60}}},
+	}
+	results, _ := e.determineConsensus(split, cfg)
+	if len(results) != 1 || results[0].Action != "wait" {
+		t.Errorf("Split vote: expected wait, got %+v", results)
+	}
+
+	unanimous := split[:2]
+	results, _ = e.determineConsensus(unanimous, cfg)
+	if len(results) != 1 || results[0].Action != "open_long" {
+		t.Errorf("Unanimous vote: expected open_long, got %+v", results)
+	}
+
+	// Not enough participants
+	results, _ = e.determineConsensus(split[:1], cfg)
+	if len(results) != 1 || results[0].Action != "wait" {
+		t.Errorf("Single vote: expected wait, got %+v", results)
+	}
+}
+
+func TestNormalizeConsensusConfig(t *testing.T) {
+	cfg, err := normalizeConsensusConfig(&ConsensusConfig{Strategy: ConsensusSupermajority})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinConfidence != 50 || cfg.TieBreak != TieBreakWait || cfg.SupermajorityPct != 0.67 {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+
+	if _, err := normalizeConsensusConfig(&ConsensusConfig{Strategy: "majority"}); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+	if _, err := normalizeConsensusConfig(&ConsensusConfig{TieBreak: "coin_flip"}); err == nil {
+		t.Error("expected error for unknown tie break")
+	}
+}
+
+func TestCreateSession_RejectsUntradableSymbol(t *testing.T) {
+	e := NewEngine()
+	e.SetSymbolValidator(func(symbol string) error {
+		if symbol != "BTCUSDT" {
+			return fmt.Errorf("%s is not listed on Binance USDT-M futures", symbol)
+		}
+		return nil
+	})
+
+	req := &CreateSessionRequest{
+		Name:    "tradable",
+		Symbols: []string{"BTCUSDT", "FAKEUSDT"},
+		Participants: []CreateParticipantRequest{
+			{AIModelID: "a", AIModelName: "A", Provider: "stub", Personality: PersonalityBull},
+		},
+	}
+	if _, err := e.CreateSession(req); err == nil || !strings.Contains(err.Error(), "FAKEUSDT") {
+		t.Fatalf("expected FAKEUSDT to be rejected, got: %v", err)
+	}
+	if len(e.ListSessions()) != 0 {
+		t.Error("rejected session must not be stored")
+	}
+
+	req.Symbols = []string{"BTCUSDT"}
+	if _, err := e.CreateSession(req); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseStructuredDecisions(t *testing.T) {
+	response := `{"reasoning": "Momentum favours longs", "decisions": [{"symbol": "BTCUSDT", "action": "open_long", "leverage": 5, "position_size_usd": 1000, "stop_loss": 42000, "take_profit": 48000, "confidence": 80, "reasoning": "Breakout"}]}`
+
+	decisions, reasoning, ok := parseStructuredDecisions(response, true)
+	if !ok {
+		t.Fatal("expected structured response to parse")
+	}
+	if reasoning != "Momentum favours longs" {
+		t.Errorf("reasoning = %q", reasoning)
+	}
+	if len(decisions) != 1 || decisions[0].PositionSizeUSD != 1000 || decisions[0].Confidence != 80 {
+		t.Errorf("unexpected decisions: %+v", decisions)
+	}
+
+	// Without the schema applied, the caller must use the text parser
+	if _, _, ok := parseStructuredDecisions(response, false); ok {
+		t.Error("unstructured response must not take the structured path")
+	}
+	if _, _, ok := parseStructuredDecisions("<decision>[]</decision>", true); ok {
+		t.Error("non-JSON content must fall back")
+	}
+}
+
 func TestConvertRawDecisions_PreservesPositionSizeUSD(t *testing.T) {
 	rawDecisions := []struct {
 		Symbol          string  `json:"symbol"`
@@ -391,3 +585,480 @@ func TestConvertRawDecisions_DefaultsWhenNothingProvided(t *testing.T) {
 		t.Errorf("Expected default PositionPct 0.2, got %f", d.PositionPct)
 	}
 }
+
+// fakeClock fires immediately and records how much time would have passed
+type fakeClock struct {
+	mu      sync.Mutex
+	elapsed time.Duration
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.elapsed += d
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// stubAIClient answers every call with a fixed response
+type stubAIClient struct {
+	response string
+}
+
+func (c *stubAIClient) SetAPIKey(apiKey, customURL, customModel string) {}
+func (c *stubAIClient) SetTimeout(timeout time.Duration)               {}
+func (c *stubAIClient) GetProvider() string                            { return "stub" }
+func (c *stubAIClient) GetModel() string                               { return "stub-model" }
+
+func (c *stubAIClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return c.response, nil
+}
+
+func (c *stubAIClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	return &mcp.Response{Content: c.response, Model: model}, nil
+}
+
+func (c *stubAIClient) CallWithRequest(req *mcp.Request) (*mcp.Response, error) {
+	return &mcp.Response{Content: c.response, Model: req.Model}, nil
+}
+
+func (c *stubAIClient) CallStream(req *mcp.Request, handler mcp.ChunkHandler) (*mcp.Response, error) {
+	return c.CallWithRequest(req)
+}
+
+func newPacingTestSession(e *Engine) *SessionWithDetails {
+	session, _ := e.CreateSession(&CreateSessionRequest{
+		Name:                "pacing",
+		Symbols:             []string{"BTCUSDT"},
+		MaxRounds:           2,
+		IntervalMinutes:     5, // Must not affect pacing between speakers
+		SpeakerDelaySeconds: 0.2,
+		Participants: []CreateParticipantRequest{
+			{AIModelID: "a", AIModelName: "A", Provider: "stub", Personality: PersonalityBull},
+			{AIModelID: "b", AIModelName: "B", Provider: "stub", Personality: PersonalityBear},
+			{AIModelID: "c", AIModelName: "C", Provider: "stub", Personality: PersonalityAnalyst},
+		},
+	})
+	return session
+}
+
+func TestRunDebate_PacingUsesSpeakerDelay(t *testing.T) {
+	clock := &fakeClock{}
+	e := NewEngine()
+	e.clock = clock
+	e.RegisterClient("stub", &stubAIClient{response: "<reasoning>ok</reasoning>"})
+
+	session := newPacingTestSession(e)
+	marketCtx := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+
+	if err := e.runDebate(context.Background(), session, marketCtx); err != nil {
+		t.Fatalf("runDebate() error = %v", err)
+	}
+
+	if got := len(session.Messages); got != 6 {
+		t.Errorf("messages = %d, want 6", got)
+	}
+	// 2 rounds x 2 gaps between 3 speakers
+	if clock.elapsed != 4*200*time.Millisecond {
+		t.Errorf("simulated wait = %v, want %v", clock.elapsed, 4*200*time.Millisecond)
+	}
+	if clock.elapsed >= time.Second {
+		t.Errorf("debate took %v of simulated time, want under 1s", clock.elapsed)
+	}
+}
+
+func TestRunDebate_StopInterruptsSpeakerDelay(t *testing.T) {
+	e := NewEngine() // Real clock: the default delay would block for seconds
+	e.RegisterClient("stub", &stubAIClient{response: "<reasoning>ok</reasoning>"})
+
+	session := newPacingTestSession(e)
+	session.SpeakerDelaySeconds = DefaultSpeakerDelaySeconds
+	marketCtx := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := e.runDebate(ctx, session, marketCtx)
+	if err != context.Canceled {
+		t.Fatalf("runDebate() error = %v, want context.Canceled", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("cancel took %v to take effect", took)
+	}
+}
+
+// hangingAIClient never answers for the given model until the call's context ends
+type hangingAIClient struct {
+	stubAIClient
+	hangModel string
+}
+
+func (c *hangingAIClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	if model == c.hangModel {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.stubAIClient.CallWithModel(ctx, model, systemPrompt, userPrompt)
+}
+
+func TestRunDebate_TimedOutParticipantDoesNotBlockRound(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		e := NewEngine()
+		e.clock = &fakeClock{}
+		e.RegisterClient("stub", &hangingAIClient{
+			stubAIClient: stubAIClient{response: "<reasoning>ok</reasoning>"},
+			hangModel:    "b",
+		})
+
+		session := newPacingTestSession(e)
+		session.MaxRounds = 1
+		session.CallTimeoutSeconds = 1
+		session.ParallelCalls = parallel
+		session.MaxConcurrentCalls = 2
+		marketCtx := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+
+		if err := e.runDebate(context.Background(), session, marketCtx); err != nil {
+			t.Fatalf("parallel=%v: runDebate() error = %v", parallel, err)
+		}
+
+		if len(session.Messages) != 3 {
+			t.Fatalf("parallel=%v: messages = %d, want 3", parallel, len(session.Messages))
+		}
+		for i, want := range []string{"a", "b", "c"} {
+			if got := session.Messages[i].AIModelID; got != want {
+				t.Errorf("parallel=%v: message %d from %s, want %s (speak order)", parallel, i, got, want)
+			}
+		}
+		hung := session.Messages[1]
+		if hung.MessageType != "no_response" || hung.Error == "" {
+			t.Errorf("parallel=%v: hung participant message = %q/%q, want no_response with error",
+				parallel, hung.MessageType, hung.Error)
+		}
+	}
+}
+
+func TestRunDebate_FallsBackToNextModel(t *testing.T) {
+	e := NewEngine()
+	e.clock = &fakeClock{}
+	e.RegisterClient("stub", &hangingAIClient{
+		stubAIClient: stubAIClient{response: "<reasoning>ok</reasoning>"},
+		hangModel:    "b",
+	})
+
+	session := newPacingTestSession(e)
+	session.CallTimeoutSeconds = 1
+	session.Participants[1].FallbackModels = []string{"b", "b2"}
+	marketCtx := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+
+	if err := e.runDebate(context.Background(), session, marketCtx); err != nil {
+		t.Fatalf("runDebate() error = %v", err)
+	}
+
+	for _, msg := range session.Messages {
+		if msg.AIModelID == "b" && (msg.MessageType == "no_response" || msg.Model != "b2") {
+			t.Errorf("round %d message from b = %s by %q, want an answer by b2", msg.Round, msg.MessageType, msg.Model)
+		}
+	}
+	// Both rounds and the vote
+	if stat := e.ModelStats()["b"]; stat.Calls != 3 || stat.Failures != 3 || stat.FailureRate != 1 {
+		t.Errorf("stats of b = %+v, want 3 failed calls", stat)
+	}
+	if stat := e.ModelStats()["b2"]; stat.Calls != 3 || stat.Failures != 0 {
+		t.Errorf("stats of b2 = %+v, want 3 calls", stat)
+	}
+
+	// Announced once, though b failed every time
+	fallbacks := 0
+	for len(e.eventChan[session.ID]) > 0 {
+		if event := <-e.eventChan[session.ID]; event.Type == "model_fallback" {
+			fallbacks++
+		}
+	}
+	if fallbacks != 1 {
+		t.Errorf("model_fallback events = %d, want 1", fallbacks)
+	}
+}
+
+func TestBuildDebateUserPrompt_UsesModeratorSummary(t *testing.T) {
+	e := &Engine{}
+	raw := "RAW ARGUMENT FROM BULL"
+	messages := []*Message{
+		{Round: 1, AIModelName: "A", Personality: PersonalityBull, MessageType: "analysis", Content: raw},
+		{Round: 1, AIModelName: "Moderator", MessageType: "moderator_summary", Content: "BTC: A long 80%"},
+	}
+
+	prompt := e.buildDebateUserPrompt("base", messages, &Participant{}, 2)
+
+	if !strings.Contains(prompt, "BTC: A long 80%") {
+		t.Errorf("prompt should include the moderator summary")
+	}
+	if strings.Contains(prompt, raw) {
+		t.Errorf("prompt should not include raw content of a summarized round")
+	}
+}
+
+func TestRunDebate_ModeratorSummaryPerRound(t *testing.T) {
+	e := NewEngine()
+	e.clock = &fakeClock{}
+	e.RegisterClient("stub", &stubAIClient{response: "<reasoning>ok</reasoning>"})
+
+	session := newPacingTestSession(e)
+	session.ModeratorSummary = true
+	marketCtx := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+
+	if err := e.runDebate(context.Background(), session, marketCtx); err != nil {
+		t.Fatalf("runDebate() error = %v", err)
+	}
+
+	summaries := 0
+	for _, msg := range session.Messages {
+		if msg.MessageType == "moderator_summary" {
+			summaries++
+		}
+	}
+	if summaries != session.MaxRounds {
+		t.Errorf("moderator summaries = %d, want %d", summaries, session.MaxRounds)
+	}
+}
+
+func TestRunCompact_NoPacingAndNoSession(t *testing.T) {
+	clock := &fakeClock{}
+	e := NewEngine()
+	e.clock = clock
+	e.RegisterClient("stub", &stubAIClient{response: "<reasoning>up</reasoning><decision>```json\n" +
+		`[{"symbol": "BTCUSDT", "action": "open_long", "confidence": 80, "leverage": 5}]` + "\n```</decision>"})
+
+	result, err := e.RunCompact(context.Background(), &CompactRequest{
+		Participants: []CreateParticipantRequest{
+			{AIModelID: "a", AIModelName: "A", Provider: "stub", Personality: PersonalityBull},
+			{AIModelID: "b", AIModelName: "B", Provider: "stub", Personality: PersonalityBear},
+		},
+	}, "system", "user")
+	if err != nil {
+		t.Fatalf("RunCompact() error = %v", err)
+	}
+
+	if got := len(result.Messages); got != 2 {
+		t.Errorf("messages = %d, want 2 (one round)", got)
+	}
+	if got := len(result.Votes); got != 2 {
+		t.Errorf("votes = %d, want 2", got)
+	}
+	if len(result.Decisions) != 1 || result.Decisions[0].Action != "open_long" {
+		t.Errorf("decisions = %+v, want one open_long", result.Decisions)
+	}
+	if clock.elapsed != 0 {
+		t.Errorf("compact debate waited %v between speakers, want 0", clock.elapsed)
+	}
+	if len(e.ListSessions()) != 0 {
+		t.Error("compact debate should not register a session")
+	}
+}
+
+func TestSystemPromptStatesOIVerdicts(t *testing.T) {
+	// The market data prints these verdicts, the system prompt has to explain the same ones
+	prompt := NewEngine().buildDebateSystemPrompt("", &Participant{Personality: PersonalityBull}, 1, 3)
+	for _, verdict := range []string{market.OIVerdictStrongBullish, market.OIVerdictShortCovering,
+		market.OIVerdictStrongBearish, market.OIVerdictLongsClosing} {
+		if !strings.Contains(prompt, verdict) {
+			t.Errorf("system prompt missing %q", verdict)
+		}
+	}
+}
+
+// meteredAIClient answers like stubAIClient and reports fixed token usage
+type meteredAIClient struct {
+	stubAIClient
+	usage mcp.Usage
+}
+
+func (c *meteredAIClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	return &mcp.Response{Content: c.response, Model: model, Usage: c.usage}, nil
+}
+
+// flatPricing prices every model but "free" at $1 per million prompt tokens
+// and $2 per million completion tokens
+func flatPricing(model string) (float64, float64, bool) {
+	if model == "free" {
+		return 0, 0, false
+	}
+	return 1e-6, 2e-6, true
+}
+
+func TestEstimateCost(t *testing.T) {
+	e := NewEngine()
+	e.SetPricingProvider(flatPricing)
+	session := newPacingTestSession(e)
+	session.MaxRounds = 3
+	session.Participants = append(session.Participants, newParticipants(session.ID, []CreateParticipantRequest{
+		{AIModelID: "free", AIModelName: "Free", Provider: "stub", Personality: PersonalityContrarian},
+	})...)
+
+	small, err := e.EstimateCost(session.ID, &MarketContext{MarketData: map[string]*decision.MarketData{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 participants x 3 rounds, then 4 votes
+	if small.AICalls != 16 {
+		t.Errorf("calls = %d, want 16", small.AICalls)
+	}
+	if small.CostUSD <= 0 || len(small.UnpricedModels) != 1 || small.UnpricedModels[0] != "free" {
+		t.Errorf("estimate = %+v, want a cost without the free model", small)
+	}
+	if session.CostEstimate != small {
+		t.Error("estimate not kept on the session")
+	}
+
+	// A larger market context costs more on every call
+	big := &MarketContext{MarketData: map[string]*decision.MarketData{}}
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+		big.MarketData[symbol] = &decision.MarketData{Symbol: symbol, Price: 100}
+	}
+	large, _ := e.EstimateCost(session.ID, big)
+	if large.ContextTokens <= small.ContextTokens || large.CostUSD <= small.CostUSD {
+		t.Errorf("estimate with more symbols = %+v, want more than %+v", large, small)
+	}
+
+	session.ModeratorSummary = true
+	moderated, _ := e.EstimateCost(session.ID, big)
+	if moderated.AICalls != 19 {
+		t.Errorf("calls with a moderator = %d, want 19", moderated.AICalls)
+	}
+}
+
+func TestRunDebate_AccumulatesUsage(t *testing.T) {
+	e := NewEngine()
+	e.clock = &fakeClock{}
+	e.SetPricingProvider(flatPricing)
+	e.RegisterClient("stub", &meteredAIClient{
+		stubAIClient: stubAIClient{response: "<reasoning>ok</reasoning>"},
+		usage:        mcp.Usage{PromptTokens: 1000, CompletionTokens: 500},
+	})
+
+	session := newPacingTestSession(e)
+	events, _ := e.GetEvents(session.ID)
+	if err := e.runDebate(context.Background(), session, &MarketContext{MarketData: map[string]*decision.MarketData{}}); err != nil {
+		t.Fatalf("runDebate() error = %v", err)
+	}
+
+	// 3 participants x 2 rounds plus 3 votes, $0.002 each
+	if session.Usage.Calls != 9 || session.Usage.PromptTokens != 9000 || math.Abs(session.Usage.CostUSD-0.018) > 1e-9 {
+		t.Errorf("usage = %+v, want 9 calls for $0.018", session.Usage)
+	}
+	if msg := session.Messages[0]; msg.Usage == nil || msg.Usage.CompletionTokens != 500 {
+		t.Errorf("message usage = %+v", msg.Usage)
+	}
+
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "consensus" {
+			if ev.Usage == nil || ev.Usage.Calls != 9 {
+				t.Errorf("consensus usage = %+v, want the session's", ev.Usage)
+			}
+			return
+		}
+	}
+	t.Error("no consensus event")
+}
+
+func TestStart_BudgetExceededCancels(t *testing.T) {
+	e := NewEngine()
+	e.clock = &fakeClock{}
+	e.SetPricingProvider(flatPricing)
+	e.RegisterClient("stub", &meteredAIClient{
+		stubAIClient: stubAIClient{response: "<reasoning>ok</reasoning>"},
+		usage:        mcp.Usage{PromptTokens: 1000, CompletionTokens: 500},
+	})
+
+	session, err := e.CreateSession(&CreateSessionRequest{
+		Symbols:    []string{"BTCUSDT"},
+		MaxRounds:  3,
+		MaxCostUSD: 0.005, // The third call crosses it
+		Participants: []CreateParticipantRequest{
+			{AIModelID: "a", AIModelName: "A", Provider: "stub", Personality: PersonalityBull},
+			{AIModelID: "b", AIModelName: "B", Provider: "stub", Personality: PersonalityBear},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Start(context.Background(), session.ID, &MarketContext{MarketData: map[string]*decision.MarketData{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e.mu.RLock()
+		status, reason, usage := session.Status, session.Error, session.Usage
+		e.mu.RUnlock()
+		if status == StatusCancelled {
+			if reason != "budget exceeded" {
+				t.Errorf("error = %q, want budget exceeded", reason)
+			}
+			if usage.Calls != 3 {
+				t.Errorf("calls = %d, want the debate to stop at the third", usage.Calls)
+			}
+			break
+		}
+		if status == StatusCompleted || time.Now().After(deadline) {
+			t.Fatalf("status = %s, want cancelled over budget", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if session.FinalDecisions != nil {
+		t.Errorf("final decisions = %v, want none", session.FinalDecisions)
+	}
+
+	if _, err := e.CreateSession(&CreateSessionRequest{MaxCostUSD: -1}); err == nil {
+		t.Error("negative max_cost_usd accepted")
+	}
+}
+
+func TestCreateSession_CustomRoles(t *testing.T) {
+	e := NewEngine()
+	brief := "Macro Economist - You weigh rates, the dollar and liquidity over chart patterns."
+	session, err := e.CreateSession(&CreateSessionRequest{
+		Symbols: []string{"BTCUSDT"},
+		Participants: []CreateParticipantRequest{
+			{AIModelID: "a", Personality: "macro_economist", CustomRoleDescription: "  " + brief + "\n", CustomEmoji: "🌍", CustomColor: "#0EA5E9"},
+			{AIModelID: "b", Personality: PersonalityBear, CustomColor: "#112233"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	macro, bear := session.Participants[0], session.Participants[1]
+	if macro.RoleDescription != brief || macro.Emoji != "🌍" || macro.Color != "#0EA5E9" {
+		t.Errorf("custom participant = %+v", macro)
+	}
+	// Unset overrides keep the built-ins, resolved into the session
+	if bear.RoleDescription != GetPersonalityDescription(PersonalityBear) || bear.Emoji != "🐻" || bear.Color != "#112233" {
+		t.Errorf("bear = %+v", bear)
+	}
+
+	prompt := e.buildDebateSystemPrompt("", macro, 1, 3)
+	if !strings.Contains(prompt, brief) || !strings.Contains(prompt, "🌍 macro_economist") {
+		t.Errorf("system prompt doesn't carry the custom role:\n%s", prompt)
+	}
+	if strings.Contains(prompt, GetPersonalityDescription("macro_economist")) {
+		t.Error("system prompt still has the default brief")
+	}
+
+	invalid := []CreateParticipantRequest{
+		{Personality: Personality(strings.Repeat("x", MaxPersonalityLength+1))},
+		{CustomRoleDescription: strings.Repeat("é", MaxRoleDescriptionLength+1)},
+		{CustomEmoji: "🌍🌍🌍🌍🌍🌍🌍🌍🌍"},
+		{CustomColor: "blue"},
+	}
+	for _, p := range invalid {
+		if _, err := e.CreateSession(&CreateSessionRequest{Participants: []CreateParticipantRequest{p}}); err == nil {
+			t.Errorf("participant %+v accepted", p)
+		}
+	}
+	// Length limits count characters, not bytes
+	if err := ValidateParticipants([]CreateParticipantRequest{{CustomRoleDescription: strings.Repeat("é", MaxRoleDescriptionLength)}}); err != nil {
+		t.Errorf("role at the limit rejected: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package debate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on participant roles, in characters
+const (
+	MaxPersonalityLength     = 40
+	MaxRoleDescriptionLength = 1000
+	MaxEmojiLength           = 8 // Room for flags and skin tone modifiers
+)
+
+// BuiltinPersonalities lists the built-in personalities in display order
+var BuiltinPersonalities = []Personality{
+	PersonalityBull,
+	PersonalityBear,
+	PersonalityAnalyst,
+	PersonalityContrarian,
+	PersonalityRiskManager,
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// PersonalityInfo describes a built-in personality
+type PersonalityInfo struct {
+	Personality Personality `json:"personality"`
+	Description string      `json:"description"`
+	Emoji       string      `json:"emoji"`
+	Color       string      `json:"color"`
+}
+
+// Personalities returns the built-in personalities participants can take
+func Personalities() []PersonalityInfo {
+	infos := make([]PersonalityInfo, 0, len(BuiltinPersonalities))
+	for _, p := range BuiltinPersonalities {
+		infos = append(infos, PersonalityInfo{
+			Personality: p,
+			Description: GetPersonalityDescription(p),
+			Emoji:       PersonalityEmojis[p],
+			Color:       PersonalityColors[p],
+		})
+	}
+	return infos
+}
+
+// ValidateParticipants checks participants' personalities and custom roles
+// against the length limits and custom colors are #RRGGBB
+func ValidateParticipants(reqs []CreateParticipantRequest) error {
+	for i, p := range reqs {
+		if n := utf8.RuneCountInString(string(p.Personality)); n > MaxPersonalityLength {
+			return fmt.Errorf("participant %d: personality is %d characters, at most %d allowed", i+1, n, MaxPersonalityLength)
+		}
+		if n := utf8.RuneCountInString(strings.TrimSpace(p.CustomRoleDescription)); n > MaxRoleDescriptionLength {
+			return fmt.Errorf("participant %d: custom_role_description is %d characters, at most %d allowed", i+1, n, MaxRoleDescriptionLength)
+		}
+		if n := utf8.RuneCountInString(p.CustomEmoji); n > MaxEmojiLength {
+			return fmt.Errorf("participant %d: custom_emoji is %d characters, at most %d allowed", i+1, n, MaxEmojiLength)
+		}
+		if p.CustomColor != "" && !hexColorPattern.MatchString(p.CustomColor) {
+			return fmt.Errorf("participant %d: custom_color %q is not a #RRGGBB color", i+1, p.CustomColor)
+		}
+	}
+	return nil
+}
+
+// resolveRole returns the brief, emoji and color a participant debates with:
+// its custom ones where set, otherwise its personality's built-ins
+func (p *CreateParticipantRequest) resolveRole() (role, emoji, color string) {
+	role = strings.TrimSpace(p.CustomRoleDescription)
+	if role == "" {
+		role = GetPersonalityDescription(p.Personality)
+	}
+	emoji = p.CustomEmoji
+	if emoji == "" {
+		emoji = PersonalityEmojis[p.Personality]
+	}
+	color = p.CustomColor
+	if color == "" {
+		color = PersonalityColors[p.Personality]
+	}
+	return role, emoji, color
+}
@@ -1,6 +1,7 @@
 package debate
 
 import (
+	"context"
 	"time"
 
 	"auto-trader-ahh/decision"
@@ -73,7 +74,18 @@ type Session struct {
 	Symbols         []string     `json:"symbols"`
 	MaxRounds       int          `json:"max_rounds"`
 	CurrentRound    int          `json:"current_round"`
-	IntervalMinutes int          `json:"interval_minutes"`
+	IntervalMinutes int          `json:"interval_minutes"` // Gap between scheduled sessions, not between speakers
+	SpeakerDelaySeconds float64  `json:"speaker_delay_seconds"` // Pause between speakers within a round
+	CallTimeoutSeconds  int      `json:"call_timeout_seconds"`  // Deadline for each AI call
+	ParallelCalls       bool     `json:"parallel_calls"`        // Query a round's participants concurrently
+	MaxConcurrentCalls  int      `json:"max_concurrent_calls"`  // Worker pool size when ParallelCalls is set
+	ModeratorSummary    bool     `json:"moderator_summary"`     // Summarize each round for the next one (one extra call per round)
+	ModeratorModelID    string   `json:"moderator_model_id,omitempty"` // Defaults to the first participant's model
+	Consensus           *ConsensusConfig `json:"consensus"`
+	VoteTally           []*SymbolTally   `json:"vote_tally,omitempty"` // How close each symbol's vote was
+	MaxCostUSD          float64          `json:"max_cost_usd,omitempty"` // Cancel once the AI calls cost more, 0 for no cap
+	CostEstimate        *CostEstimate    `json:"cost_estimate,omitempty"` // Expected usage, estimated on creation
+	Usage               Usage            `json:"usage"`                   // What the AI calls used so far, across cycles
 	PromptVariant   string       `json:"prompt_variant"`
 	FinalDecisions  []*Decision  `json:"final_decisions"`
 	AutoExecute     bool         `json:"auto_execute"`
@@ -105,8 +117,15 @@ type Participant struct {
 	Provider    string      `json:"provider"`
 	Personality Personality `json:"personality"`
 	Color       string      `json:"color"`
+	Emoji       string      `json:"emoji"`
 	SpeakOrder  int         `json:"speak_order"`
 	CreatedAt   time.Time   `json:"created_at"`
+
+	// Tried in order when a call to AIModelID fails
+	FallbackModels []string `json:"fallback_models,omitempty"`
+
+	// Role brief the participant debated with, kept as it was when the session was created
+	RoleDescription string `json:"role_description"`
 }
 
 // Message represents a debate message from a participant
@@ -117,9 +136,12 @@ type Message struct {
 	AIModelID   string       `json:"ai_model_id"`
 	AIModelName string       `json:"ai_model_name"`
 	Provider    string       `json:"provider"`
+	Model       string       `json:"model,omitempty"` // Model that actually answered
 	Personality Personality  `json:"personality"`
-	MessageType string       `json:"message_type"` // analysis, rebuttal, final, vote
+	MessageType string       `json:"message_type"` // analysis, rebuttal, final, vote, no_response, moderator_summary
 	Content     string       `json:"content"`
+	Error       string       `json:"error,omitempty"` // Why a no_response message got no answer
+	Usage       *Usage       `json:"usage,omitempty"` // The call that produced it
 	Decisions   []*Decision  `json:"decisions"`
 	Confidence  int          `json:"confidence"`
 	CreatedAt   time.Time    `json:"created_at"`
@@ -131,9 +153,12 @@ type Vote struct {
 	SessionID   string       `json:"session_id"`
 	AIModelID   string       `json:"ai_model_id"`
 	AIModelName string       `json:"ai_model_name"`
+	Model       string       `json:"model,omitempty"` // Model that actually answered
 	Personality Personality  `json:"personality"`
 	Decisions   []*Decision  `json:"decisions"`
 	Reasoning   string       `json:"reasoning"`
+	ParsePath   string       `json:"parse_path,omitempty"` // decision.ParsePathStructured or ParsePathRegex
+	Usage       *Usage       `json:"usage,omitempty"`
 	CreatedAt   time.Time    `json:"created_at"`
 }
 
@@ -160,6 +185,9 @@ type SessionWithDetails struct {
 	Participants []*Participant `json:"participants"`
 	Messages     []*Message     `json:"messages"`
 	Votes        []*Vote        `json:"votes"`
+
+	fallbackAnnounced bool                    // A model_fallback event was sent
+	abort             context.CancelCauseFunc // Stops the running debate with a cause, nil outside Start
 }
 
 // CreateSessionRequest is the request to create a debate session
@@ -167,7 +195,15 @@ type CreateSessionRequest struct {
 	Name                 string                      `json:"name"`
 	Symbols              []string                    `json:"symbols"`
 	MaxRounds            int                         `json:"max_rounds"`
-	IntervalMinutes      int                         `json:"interval_minutes"`
+	IntervalMinutes      int                         `json:"interval_minutes"` // Gap between scheduled sessions
+	SpeakerDelaySeconds  float64                     `json:"speaker_delay_seconds"` // 0 uses DefaultSpeakerDelaySeconds
+	CallTimeoutSeconds   int                         `json:"call_timeout_seconds"`  // 0 uses DefaultCallTimeoutSeconds
+	ParallelCalls        bool                        `json:"parallel_calls"`
+	MaxConcurrentCalls   int                         `json:"max_concurrent_calls"` // 0 uses DefaultMaxConcurrentCalls
+	ModeratorSummary     bool                        `json:"moderator_summary"`
+	ModeratorModelID     string                      `json:"moderator_model_id"`
+	Consensus            *ConsensusConfig            `json:"consensus"` // nil uses DefaultConsensusConfig
+	MaxCostUSD           float64                     `json:"max_cost_usd"` // 0 for no cap
 	PromptVariant        string                      `json:"prompt_variant"`
 	AutoExecute          bool                        `json:"auto_execute"`
 	TraderID             string                      `json:"trader_id"`
@@ -179,22 +215,83 @@ type CreateSessionRequest struct {
 	BinanceAPIKey    string `json:"binance_api_key"`
 	BinanceSecretKey string `json:"binance_secret_key"`
 	BinanceTestnet   bool   `json:"binance_testnet"`
+	// Owner is set by the API from the authenticated user, never from the body
+	UserID string `json:"-"`
+}
+
+// Consensus strategies
+const (
+	ConsensusPlurality       = "plurality"         // Highest confidence-weighted score wins
+	ConsensusSupermajority   = "supermajority"     // Winner needs SupermajorityPct of the total score
+	ConsensusUnanimousOrWait = "unanimous_or_wait" // All counted votes must agree, otherwise wait
+)
+
+// Tie-break rules
+const (
+	TieBreakWait              = "wait"               // Tied leaders produce an explicit wait
+	TieBreakHighestConfidence = "highest_confidence" // Prefer the higher average confidence, wait if still tied
+)
+
+// ConsensusConfig controls how votes become final decisions
+type ConsensusConfig struct {
+	Strategy         string  `json:"strategy"`
+	MinConfidence    int     `json:"min_confidence"`    // Votes below this are ignored
+	MinParticipation int     `json:"min_participation"` // Counted votes required per symbol
+	MinWinningScore  float64 `json:"min_winning_score"` // Confidence-weighted score the winner needs (1 vote at 100% = 1.0)
+	SupermajorityPct float64 `json:"supermajority_pct"` // Winner's share of total score for supermajority (0-1)
+	TieBreak         string  `json:"tie_break"`
+}
+
+// DefaultConsensusConfig returns the default consensus rules
+func DefaultConsensusConfig() *ConsensusConfig {
+	return &ConsensusConfig{
+		Strategy:         ConsensusPlurality,
+		MinConfidence:    50,
+		MinParticipation: 1,
+		SupermajorityPct: 0.67,
+		TieBreak:         TieBreakWait,
+	}
+}
+
+// ActionTally is the vote count for one action on a symbol
+type ActionTally struct {
+	Action        string  `json:"action"`
+	Votes         int     `json:"votes"`
+	Score         float64 `json:"score"` // Sum of confidence weights
+	AvgConfidence int     `json:"avg_confidence"`
+}
+
+// SymbolTally shows how a symbol's vote went
+type SymbolTally struct {
+	Symbol    string         `json:"symbol"`
+	Actions   []*ActionTally `json:"actions"` // Highest score first
+	Ignored   int            `json:"ignored"` // Votes below the minimum confidence
+	Outcome   string         `json:"outcome"` // Winning action, or wait when there is no consensus
+	Consensus bool           `json:"consensus"`
+	Reason    string         `json:"reason,omitempty"`
 }
 
 // CreateParticipantRequest is the request to add a participant
 type CreateParticipantRequest struct {
-	AIModelID   string      `json:"ai_model_id"`
-	AIModelName string      `json:"ai_model_name"`
-	Provider    string      `json:"provider"`
-	Personality Personality `json:"personality"`
+	AIModelID      string      `json:"ai_model_id"`
+	AIModelName    string      `json:"ai_model_name"`
+	Provider       string      `json:"provider"`
+	Personality    Personality `json:"personality"`
+	FallbackModels []string    `json:"fallback_models,omitempty"`
+
+	// Override the personality's built-in brief, emoji and color, e.g. for a "macro_economist"
+	CustomRoleDescription string `json:"custom_role_description,omitempty"`
+	CustomEmoji           string `json:"custom_emoji,omitempty"`
+	CustomColor           string `json:"custom_color,omitempty"` // #RRGGBB
 }
 
 // Event represents a real-time debate event
 type Event struct {
-	Type      string      `json:"type"` // round_start, message, round_end, vote, consensus, error
+	Type      string      `json:"type"` // round_start, message, round_end, vote, consensus, model_fallback, error
 	SessionID string      `json:"session_id"`
 	Round     int         `json:"round,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
+	Usage     *Usage      `json:"usage,omitempty"` // The session's usage so far, on consensus
 	Timestamp time.Time   `json:"timestamp"`
 }
 
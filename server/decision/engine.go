@@ -3,6 +3,7 @@ package decision
 import (
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"auto-trader-ahh/mcp"
@@ -14,6 +15,20 @@ type Engine struct {
 	promptBuilder *PromptBuilder
 	validationCfg *ValidationConfig
 	lang          Language
+	params        mcp.GenerationParams
+
+	structuredParses atomic.Int64
+	regexParses      atomic.Int64
+	parseRetries     atomic.Int64
+}
+
+// ParseStats counts how decisions were parsed, to track how often models still
+// ignore the response schema and need the regex scraper
+type ParseStats struct {
+	Structured   int64   `json:"structured"`
+	Regex        int64   `json:"regex"`
+	FallbackRate float64 `json:"fallback_rate"` // Regex share of all parses, 0-1
+	Retries      int64   `json:"retries"`       // Follow-ups asking the model to correct an unusable response
 }
 
 // NewEngine creates a new decision engine
@@ -23,9 +38,15 @@ func NewEngine(client mcp.AIClient, lang Language) *Engine {
 		promptBuilder: NewPromptBuilder(lang),
 		validationCfg: DefaultValidationConfig(),
 		lang:          lang,
+		params:        mcp.DefaultGenerationParams(),
 	}
 }
 
+// SetGenerationParams sets the sampling settings of decision requests
+func (e *Engine) SetGenerationParams(params mcp.GenerationParams) {
+	e.params = params
+}
+
 // SetValidationConfig sets custom validation configuration
 func (e *Engine) SetValidationConfig(cfg *ValidationConfig) {
 	e.validationCfg = cfg
@@ -38,6 +59,19 @@ func (e *Engine) UpdateValidationFromContext(ctx *Context) {
 	e.validationCfg.AltcoinLeverage = ctx.AltcoinLeverage
 	e.validationCfg.BTCETHPosRatio = ctx.BTCETHPosRatio
 	e.validationCfg.AltcoinPosRatio = ctx.AltcoinPosRatio
+
+	prices := make(map[string]float64)
+	for _, pos := range ctx.Positions {
+		if pos.MarkPrice > 0 {
+			prices[pos.Symbol] = pos.MarkPrice
+		}
+	}
+	for symbol, data := range ctx.MarketDataMap {
+		if data != nil && data.Price > 0 {
+			prices[symbol] = data.Price
+		}
+	}
+	e.validationCfg.CurrentPrices = prices
 }
 
 // MakeDecision calls the AI to make a trading decision
@@ -52,7 +86,11 @@ func (e *Engine) MakeDecision(ctx *Context) (*FullDecision, error) {
 
 	// Build prompts
 	systemPrompt := e.promptBuilder.BuildSystemPrompt()
-	userPrompt := e.promptBuilder.BuildUserPrompt(ctx)
+	userPrompt, promptFit := e.promptBuilder.FitUserPrompt(ctx)
+	if promptFit.Over() {
+		log.Printf("[Decision] User prompt is ~%d tokens after trimming %v, over its %d-token budget",
+			promptFit.Tokens, promptFit.Trimmed, promptFit.Budget)
+	}
 
 	// Call AI
 	start := time.Now()
@@ -63,10 +101,10 @@ func (e *Engine) MakeDecision(ctx *Context) (*FullDecision, error) {
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
-		Temperature: 0.7,
-		MaxTokens:   4096,
-		Stream:      true,
+		Stream:         true,
+		ResponseFormat: DecisionResponseFormat(),
 	}
+	e.params.Apply(req)
 
 	log.Printf("[Decision] Requesting AI (streaming)... ")
 
@@ -80,19 +118,46 @@ func (e *Engine) MakeDecision(ctx *Context) (*FullDecision, error) {
 	}
 
 	responseObj, err := e.client.CallStream(req, handler)
-	duration := time.Since(start)
-
 	if err != nil {
 		return nil, fmt.Errorf("AI call failed: %w", err)
 	}
-
-	// Use content from response object which is built from stream
 	response := responseObj.Content
-
+	fullDecision, parseErr := e.parse(responseObj)
+
+	// One follow-up for a response that couldn't be used, quoting why
+	var retries int
+	var retryReason, firstResponse string
+	if reason := unusableReason(fullDecision, parseErr); reason != "" {
+		retries, retryReason = 1, reason
+		e.parseRetries.Add(1)
+		log.Printf("[Decision] Response unusable (%s), asking the model to correct it", reason)
+
+		req.Messages = append(req.Messages,
+			mcp.Message{Role: "assistant", Content: response},
+			mcp.Message{Role: "user", Content: e.promptBuilder.BuildCorrectionPrompt(reason, responseObj.Structured)},
+		)
+		fullResponse = ""
+		retryObj, err := e.client.CallStream(req, handler)
+		if err != nil {
+			log.Printf("[Decision] Correction request failed: %v", err)
+		} else {
+			cotTrace := fullDecision.CoTTrace
+			firstResponse, response = response, retryObj.Content
+			fullDecision, parseErr = e.parse(retryObj)
+			if fullDecision.ParsePath != ParsePathStructured {
+				// A corrected <decision> block comes without the reasoning
+				fullDecision.CoTTrace = cotTrace
+			}
+		}
+	}
+	duration := time.Since(start)
 	log.Printf("Done in %v\n", duration)
 
-	// Parse response
-	fullDecision, parseErr := ParseFullDecisionResponse(response, e.validationCfg)
+	if fullDecision.ParsePath == ParsePathStructured {
+		e.structuredParses.Add(1)
+	} else {
+		e.regexParses.Add(1)
+	}
 	if parseErr != nil {
 		log.Printf("WARNING: Decision parsing/validation error: %v", parseErr)
 		// Still return what we parsed, but with the error
@@ -104,10 +169,49 @@ func (e *Engine) MakeDecision(ctx *Context) (*FullDecision, error) {
 	fullDecision.RawResponse = response
 	fullDecision.Timestamp = time.Now()
 	fullDecision.AIRequestDurationMs = duration.Milliseconds()
+	fullDecision.AIParams = e.params
+	fullDecision.PromptFit = promptFit
+	fullDecision.Retries = retries
+	fullDecision.RetryReason = retryReason
+	fullDecision.FirstResponse = firstResponse
 
 	return fullDecision, parseErr
 }
 
+// parse parses a response the way it was requested
+func (e *Engine) parse(resp *mcp.Response) (*FullDecision, error) {
+	if resp.Structured {
+		return ParseStructuredDecisionResponse(resp.Content, e.validationCfg)
+	}
+	return ParseFullDecisionResponse(resp.Content, e.validationCfg)
+}
+
+// unusableReason says why a parsed response needs correcting: it failed to
+// parse or validate, or had no decision array and fell back to a safe wait.
+// It is empty for a usable response.
+func unusableReason(fd *FullDecision, parseErr error) string {
+	if parseErr != nil {
+		return parseErr.Error()
+	}
+	if isSafeWait(fd.Decisions) {
+		return "no JSON decision array was found"
+	}
+	return ""
+}
+
+// ParseStats returns how this engine's decisions have been parsed so far
+func (e *Engine) ParseStats() ParseStats {
+	stats := ParseStats{
+		Structured: e.structuredParses.Load(),
+		Regex:      e.regexParses.Load(),
+		Retries:    e.parseRetries.Load(),
+	}
+	if total := stats.Structured + stats.Regex; total > 0 {
+		stats.FallbackRate = float64(stats.Regex) / float64(total)
+	}
+	return stats
+}
+
 // MakeDecisionWithRetry makes a decision with retry logic
 func (e *Engine) MakeDecisionWithRetry(ctx *Context, maxRetries int) (*FullDecision, error) {
 	var lastErr error
@@ -164,6 +268,17 @@ func FilterClosingDecisions(decisions []Decision) []Decision {
 	return closing
 }
 
+// FilterStopAdjustments returns only move_stop and move_tp decisions
+func FilterStopAdjustments(decisions []Decision) []Decision {
+	var moves []Decision
+	for _, d := range decisions {
+		if IsStopAdjustment(d.Action) {
+			moves = append(moves, d)
+		}
+	}
+	return moves
+}
+
 // GetDecisionsBySymbol returns decisions for a specific symbol
 func GetDecisionsBySymbol(decisions []Decision, symbol string) []Decision {
 	var result []Decision
@@ -210,6 +325,10 @@ func SummarizeDecisions(decisions []Decision) string {
 			summary += fmt.Sprintf("CLOSE LONG %s", d.Symbol)
 		case ActionCloseShort:
 			summary += fmt.Sprintf("CLOSE SHORT %s", d.Symbol)
+		case ActionMoveStop:
+			summary += fmt.Sprintf("MOVE SL %s $%.4f", d.Symbol, d.StopLoss)
+		case ActionMoveTP:
+			summary += fmt.Sprintf("MOVE TP %s $%.4f", d.Symbol, d.TakeProfit)
 		case ActionHold:
 			summary += fmt.Sprintf("HOLD %s", d.Symbol)
 		case ActionWait:
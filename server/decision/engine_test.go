@@ -0,0 +1,98 @@
+package decision
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/mcp"
+)
+
+// scriptedClient answers calls with responses in order and keeps the requests
+type scriptedClient struct {
+	responses []string
+	requests  []*mcp.Request
+}
+
+func (c *scriptedClient) SetAPIKey(apiKey, customURL, customModel string) {}
+func (c *scriptedClient) SetTimeout(timeout time.Duration)                {}
+func (c *scriptedClient) GetProvider() string                             { return "scripted" }
+func (c *scriptedClient) GetModel() string                                { return "scripted-model" }
+
+func (c *scriptedClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return "", nil
+}
+
+func (c *scriptedClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*mcp.Response, error) {
+	return &mcp.Response{}, nil
+}
+
+func (c *scriptedClient) CallWithRequest(req *mcp.Request) (*mcp.Response, error) {
+	// Copy the messages, the engine appends to the same request
+	copied := *req
+	copied.Messages = append([]mcp.Message(nil), req.Messages...)
+	c.requests = append(c.requests, &copied)
+	content := c.responses[0]
+	c.responses = c.responses[1:]
+	return &mcp.Response{Content: content, Model: req.Model}, nil
+}
+
+func (c *scriptedClient) CallStream(req *mcp.Request, handler mcp.ChunkHandler) (*mcp.Response, error) {
+	return c.CallWithRequest(req)
+}
+
+func TestMakeDecisionRetriesUnusableResponse(t *testing.T) {
+	corrected := "<decision>\n" + `[{"symbol": "BTCUSDT", "action": "wait", "reasoning": "No edge"}]` + "\n</decision>"
+	valid := "<reasoning>Flat market</reasoning>\n" + corrected
+
+	tests := []struct {
+		name, first, reason string
+	}{
+		{"no decision array", "<reasoning>Flat market</reasoning>\nI would wait.", "no JSON decision array"},
+		{"malformed JSON", "<reasoning>Flat market</reasoning>\n<decision>\n" + `[{"symbol": "BTCUSDT", "action": }]` + "\n</decision>", "JSON"},
+		{"invalid action", "<reasoning>Flat market</reasoning>\n<decision>\n" + `[{"symbol": "BTCUSDT", "action": "buy_the_dip"}]` + "\n</decision>", "validation failed"},
+	}
+	for _, tt := range tests {
+		client := &scriptedClient{responses: []string{tt.first, corrected}}
+		e := NewEngine(client, LangEnglish)
+
+		fd, err := e.MakeDecision(&Context{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error after the retry: %v", tt.name, err)
+		}
+		if len(client.requests) != 2 {
+			t.Fatalf("%s: %d requests, want the first and one retry", tt.name, len(client.requests))
+		}
+		followUp := client.requests[1].Messages
+		if n := len(followUp); followUp[n-2].Role != "assistant" || followUp[n-2].Content != tt.first ||
+			followUp[n-1].Role != "user" || !strings.Contains(followUp[n-1].Content, tt.reason) {
+			t.Errorf("%s: follow-up doesn't quote the bad response and error: %+v", tt.name, followUp[n-2:])
+		}
+		if fd.Retries != 1 || fd.FirstResponse != tt.first || !strings.Contains(fd.RetryReason, tt.reason) {
+			t.Errorf("%s: retry not recorded: retries=%d reason=%q", tt.name, fd.Retries, fd.RetryReason)
+		}
+		if len(fd.Decisions) != 1 || fd.Decisions[0].Symbol != "BTCUSDT" || fd.CoTTrace != "Flat market" {
+			t.Errorf("%s: corrected decision = %+v, cot %q", tt.name, fd.Decisions, fd.CoTTrace)
+		}
+		if stats := e.ParseStats(); stats.Retries != 1 {
+			t.Errorf("%s: ParseStats.Retries = %d", tt.name, stats.Retries)
+		}
+	}
+
+	// A usable response isn't retried
+	client := &scriptedClient{responses: []string{valid}}
+	e := NewEngine(client, LangEnglish)
+	if fd, err := e.MakeDecision(&Context{}); err != nil || fd.Retries != 0 || len(client.requests) != 1 {
+		t.Errorf("valid response: err=%v retries=%d requests=%d", err, fd.Retries, len(client.requests))
+	}
+
+	// At most one retry: a second bad response is returned with its error
+	bad := `<decision>[{"symbol": "BTCUSDT", "action": "buy_the_dip"}]</decision>`
+	client = &scriptedClient{responses: []string{bad, bad}}
+	e = NewEngine(client, LangEnglish)
+	fd, err := e.MakeDecision(&Context{})
+	if err == nil || fd.Retries != 1 || len(client.requests) != 2 {
+		t.Errorf("bad retry: err=%v retries=%d requests=%d", err, fd.Retries, len(client.requests))
+	}
+}
@@ -0,0 +1,176 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExposureLimits caps the account's net direction and the notional held in
+// each symbol class, as % of equity. 0 disables a limit.
+type ExposureLimits struct {
+	MaxNetLongPct         float64 `json:"max_net_long_pct"`         // Long notional minus short notional
+	MaxNetShortPct        float64 `json:"max_net_short_pct"`        // Short notional minus long notional
+	MaxBTCETHNotionalPct  float64 `json:"max_btc_eth_notional_pct"` // BTC and ETH positions together, both sides
+	MaxAltcoinNotionalPct float64 `json:"max_altcoin_notional_pct"` // Altcoin positions together, both sides
+}
+
+// Enabled reports whether any limit is set
+func (l ExposureLimits) Enabled() bool {
+	return l.MaxNetLongPct > 0 || l.MaxNetShortPct > 0 || l.MaxBTCETHNotionalPct > 0 || l.MaxAltcoinNotionalPct > 0
+}
+
+// Exposure is the notional an account holds, in USDT
+type Exposure struct {
+	LongUSD    float64 `json:"long_usd"`
+	ShortUSD   float64 `json:"short_usd"`
+	BTCETHUSD  float64 `json:"btc_eth_usd"`
+	AltcoinUSD float64 `json:"altcoin_usd"`
+}
+
+// MeasureExposure sums open positions by side and symbol class
+func MeasureExposure(positions map[string]*PositionExposure) Exposure {
+	var x Exposure
+	for symbol, pos := range positions {
+		if pos != nil {
+			x = x.Add(symbol, pos.Side, pos.Notional)
+		}
+	}
+	return x
+}
+
+// Add returns x with notional added on side ("long" or "short") in symbol. A
+// negative notional takes it away.
+func (x Exposure) Add(symbol, side string, notional float64) Exposure {
+	if side == "long" {
+		x.LongUSD += notional
+	} else {
+		x.ShortUSD += notional
+	}
+	if isBTCOrETH(symbol) {
+		x.BTCETHUSD += notional
+	} else {
+		x.AltcoinUSD += notional
+	}
+	return x
+}
+
+// NetUSD is the long notional minus the short notional
+func (x Exposure) NetUSD() float64 {
+	return x.LongUSD - x.ShortUSD
+}
+
+// exposureRemaining is what a limit of pct of equity leaves once held is
+// counted, never below 0
+func exposureRemaining(equity, pct, held float64) float64 {
+	return max(equity*pct/100-held, 0)
+}
+
+// Check returns why adding notional on side in symbol to x would break a
+// limit, nil if it fits. Only limits the entry pushes further are checked, so
+// a short that brings a net long book back is never refused for being long.
+func (l ExposureLimits) Check(x Exposure, symbol, side string, notional, equity float64) error {
+	if equity <= 0 || notional <= 0 {
+		return nil
+	}
+	after := x.Add(symbol, side, notional)
+
+	exceeds := func(name string, pct, held, heldAfter float64) error {
+		if pct <= 0 || heldAfter <= equity*pct/100 {
+			return nil
+		}
+		return fmt.Errorf("%s exposure would reach %.0f USDT (%.0f%% of equity), above the %.0f%% limit: %.0f USDT left",
+			name, heldAfter, heldAfter/equity*100, pct, exposureRemaining(equity, pct, held))
+	}
+
+	var err error
+	if side == "long" {
+		err = exceeds("net long", l.MaxNetLongPct, x.NetUSD(), after.NetUSD())
+	} else {
+		err = exceeds("net short", l.MaxNetShortPct, -x.NetUSD(), -after.NetUSD())
+	}
+	if err != nil {
+		return err
+	}
+	if isBTCOrETH(symbol) {
+		return exceeds("BTC/ETH", l.MaxBTCETHNotionalPct, x.BTCETHUSD, after.BTCETHUSD)
+	}
+	return exceeds("altcoin", l.MaxAltcoinNotionalPct, x.AltcoinUSD, after.AltcoinUSD)
+}
+
+// ApplyToPositions updates positions with an executed decision: a close takes
+// away the closed share of the position and an open or add puts its notional
+// on, so later decisions of a batch see the budget it freed or used
+func ApplyToPositions(positions map[string]*PositionExposure, d *Decision) {
+	if positions == nil {
+		return
+	}
+	pos := positions[d.Symbol]
+	switch {
+	case IsClosingAction(d.Action):
+		if pos == nil || pos.Side != GetActionDirection(d.Action) {
+			return
+		}
+		if d.ClosePercent > 0 && d.ClosePercent < 100 {
+			positions[d.Symbol] = &PositionExposure{Side: pos.Side, Notional: pos.Notional * (1 - d.ClosePercent/100)}
+			return
+		}
+		delete(positions, d.Symbol)
+	case IsOpeningAction(d.Action) || IsAddAction(d.Action):
+		side := GetActionDirection(d.Action)
+		notional := d.PositionSizeUSD
+		if pos != nil && pos.Side == side {
+			notional += pos.Notional
+		}
+		positions[d.Symbol] = &PositionExposure{Side: side, Notional: notional}
+	}
+}
+
+// checkExposureLimits refuses an open or add that would break cfg's exposure
+// limits, against the positions the batch's earlier decisions leave
+func checkExposureLimits(d *Decision, cfg *ValidationConfig) error {
+	if !cfg.ExposureLimits.Enabled() || cfg.Positions == nil {
+		return nil
+	}
+	positions := cfg.Positions
+	if cfg.batchPositions != nil {
+		positions = cfg.batchPositions
+	}
+	x := MeasureExposure(positions)
+	if err := cfg.ExposureLimits.Check(x, d.Symbol, GetActionDirection(d.Action), d.PositionSizeUSD, cfg.AccountEquity); err != nil {
+		return fmt.Errorf("%s %s rejected: %w - close or reduce positions first", d.Symbol, d.Action, err)
+	}
+	return nil
+}
+
+// ExposureBudget is the account's exposure against its limits, for the prompt
+type ExposureBudget struct {
+	Limits   ExposureLimits `json:"limits"`
+	Exposure Exposure       `json:"exposure"`
+	Equity   float64        `json:"equity"`
+}
+
+// Enabled reports whether the budget has limits to show
+func (b *ExposureBudget) Enabled() bool {
+	return b != nil && b.Limits.Enabled() && b.Equity > 0
+}
+
+// FormatExposureBudget renders a line per limit with what's held and what's
+// left, empty without limits
+func FormatExposureBudget(b *ExposureBudget, lang Language) string {
+	if !b.Enabled() {
+		return ""
+	}
+
+	var sb strings.Builder
+	line := func(key promptKey, pct, held float64) {
+		if pct > 0 {
+			sb.WriteString(textf(lang, key, held, exposureRemaining(b.Equity, pct, held), pct))
+		}
+	}
+	x := b.Exposure
+	line(keyBudgetNetLong, b.Limits.MaxNetLongPct, max(x.NetUSD(), 0))
+	line(keyBudgetNetShort, b.Limits.MaxNetShortPct, max(-x.NetUSD(), 0))
+	line(keyBudgetBTCETH, b.Limits.MaxBTCETHNotionalPct, x.BTCETHUSD)
+	line(keyBudgetAltcoin, b.Limits.MaxAltcoinNotionalPct, x.AltcoinUSD)
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExposureLimitsCheck(t *testing.T) {
+	limits := ExposureLimits{MaxNetLongPct: 50, MaxNetShortPct: 30, MaxBTCETHNotionalPct: 60, MaxAltcoinNotionalPct: 40}
+	// Net long 2000 of 5000, BTC/ETH 3000 of 6000, altcoins 1000 of 4000
+	held := Exposure{LongUSD: 2500, ShortUSD: 500, BTCETHUSD: 3000, AltcoinUSD: 1000}
+
+	tests := []struct {
+		name     string
+		symbol   string
+		side     string
+		notional float64
+		wantErr  string
+	}{
+		{"within every limit", "SOLUSDT", "long", 2000, ""},
+		{"net long at the limit", "SOLUSDT", "long", 3000, ""},
+		{"net long past the limit", "SOLUSDT", "long", 3100, "net long exposure would reach 5100 USDT"},
+		{"short reducing a net long book", "BTCUSDT", "short", 2900, ""},
+		{"net short past the limit", "SOLUSDT", "short", 5100, "net short"},
+		{"BTC/ETH class past the limit", "ETHUSDT", "short", 3100, "BTC/ETH exposure"},
+		{"altcoin class past the limit", "SOLUSDT", "short", 3100, "3000 USDT left"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(held, tt.symbol, tt.side, tt.notional, 10000)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDecisions_CloseFreesExposureBudget(t *testing.T) {
+	newCfg := func() *ValidationConfig {
+		cfg := DefaultValidationConfig()
+		cfg.MinRiskReward = 0
+		cfg.ExposureLimits = ExposureLimits{MaxAltcoinNotionalPct: 20} // 2000 of 10000
+		cfg.Positions = map[string]*PositionExposure{"SOLUSDT": {Side: "long", Notional: 1400}}
+		return cfg
+	}
+	open := func(symbol string) Decision {
+		return Decision{Symbol: symbol, Action: ActionOpenLong, Leverage: 5, PositionSizeUSD: 1000, StopLoss: 90, TakeProfit: 120}
+	}
+	closeSOL := Decision{Symbol: "SOLUSDT", Action: ActionCloseLong}
+
+	err := ValidateDecisions([]Decision{open("XRPUSDT")}, newCfg())
+	if err == nil || !strings.Contains(err.Error(), "altcoin exposure") {
+		t.Fatalf("open past the altcoin limit: got %v", err)
+	}
+
+	// The close comes later in the batch but runs first, so both opens fit
+	cfg := newCfg()
+	if err := ValidateDecisions([]Decision{open("XRPUSDT"), closeSOL, open("DOGEUSDT")}, cfg); err != nil {
+		t.Fatalf("opens after a close: %v", err)
+	}
+	if cfg.Positions["SOLUSDT"] == nil {
+		t.Error("validating a batch changed the caller's positions")
+	}
+
+	// The two opens use up the freed budget, leaving none for a third
+	err = ValidateDecisions([]Decision{open("XRPUSDT"), closeSOL, open("DOGEUSDT"), open("ADAUSDT")}, newCfg())
+	if err == nil || !strings.Contains(err.Error(), "decision #4") {
+		t.Fatalf("third open: got %v", err)
+	}
+}
+
+func TestApplyToPositions(t *testing.T) {
+	positions := map[string]*PositionExposure{
+		"BTCUSDT": {Side: "long", Notional: 2000},
+		"SOLUSDT": {Side: "short", Notional: 1000},
+	}
+
+	ApplyToPositions(positions, &Decision{Symbol: "BTCUSDT", Action: ActionCloseLong, ClosePercent: 25})
+	ApplyToPositions(positions, &Decision{Symbol: "SOLUSDT", Action: ActionCloseShort})
+	ApplyToPositions(positions, &Decision{Symbol: "ETHUSDT", Action: ActionCloseLong}) // Nothing held
+	ApplyToPositions(positions, &Decision{Symbol: "XRPUSDT", Action: ActionOpenShort, PositionSizeUSD: 300})
+	ApplyToPositions(positions, &Decision{Symbol: "XRPUSDT", Action: ActionAddShort, PositionSizeUSD: 200})
+
+	x := MeasureExposure(positions)
+	if len(positions) != 2 || x.LongUSD != 1500 || x.ShortUSD != 500 || x.AltcoinUSD != 500 {
+		t.Errorf("positions %v, exposure %+v", positions, x)
+	}
+}
@@ -18,6 +18,10 @@ var (
 	reInvisibleRunes = regexp.MustCompile(`[\x00-\x08\x0B\x0C\x0E-\x1F\x7F]`)
 )
 
+// safeWaitReasoning starts the reasoning of the wait decision that stands in
+// for a response without a JSON decision array
+const safeWaitReasoning = "Model didn't output structured JSON decision, entering safe wait"
+
 // ParseFullDecisionResponse parses AI response into decisions
 func ParseFullDecisionResponse(aiResponse string, cfg *ValidationConfig) (*FullDecision, error) {
 	cotTrace := extractCoTTrace(aiResponse)
@@ -27,6 +31,7 @@ func ParseFullDecisionResponse(aiResponse string, cfg *ValidationConfig) (*FullD
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: []Decision{},
+			ParsePath: ParsePathRegex,
 		}, fmt.Errorf("failed to extract decisions: %w", err)
 	}
 
@@ -34,15 +39,40 @@ func ParseFullDecisionResponse(aiResponse string, cfg *ValidationConfig) (*FullD
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
+			ParsePath: ParsePathRegex,
 		}, fmt.Errorf("decision validation failed: %w", err)
 	}
 
 	return &FullDecision{
 		CoTTrace:  cotTrace,
 		Decisions: decisions,
+		ParsePath: ParsePathRegex,
 	}, nil
 }
 
+// ParseStructuredDecisionResponse parses output requested with DecisionResponseFormat.
+// Models that ignore the schema still answer in free text, so anything that isn't
+// the expected JSON object goes through ParseFullDecisionResponse instead.
+func ParseStructuredDecisionResponse(aiResponse string, cfg *ValidationConfig) (*FullDecision, error) {
+	var structured struct {
+		Reasoning string     `json:"reasoning"`
+		Decisions []Decision `json:"decisions"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(aiResponse)), &structured); err != nil || len(structured.Decisions) == 0 {
+		return ParseFullDecisionResponse(aiResponse, cfg)
+	}
+
+	fullDecision := &FullDecision{
+		CoTTrace:  strings.TrimSpace(structured.Reasoning),
+		Decisions: structured.Decisions,
+		ParsePath: ParsePathStructured,
+	}
+	if err := ValidateDecisions(fullDecision.Decisions, cfg); err != nil {
+		return fullDecision, fmt.Errorf("decision validation failed: %w", err)
+	}
+	return fullDecision, nil
+}
+
 // extractCoTTrace extracts chain of thought from AI response
 func extractCoTTrace(response string) string {
 	// Try <reasoning> tags first
@@ -110,7 +140,7 @@ func extractDecisions(response string) ([]Decision, error) {
 		fallbackDecision := Decision{
 			Symbol:    "ALL",
 			Action:    ActionWait,
-			Reasoning: fmt.Sprintf("Model didn't output structured JSON decision, entering safe wait; summary: %s", cotSummary),
+			Reasoning: fmt.Sprintf("%s; summary: %s", safeWaitReasoning, cotSummary),
 		}
 		return []Decision{fallbackDecision}, nil
 	}
@@ -130,6 +160,13 @@ func extractDecisions(response string) ([]Decision, error) {
 	return decisions, nil
 }
 
+// isSafeWait reports whether decisions is the safe wait extractDecisions
+// returns when the response had no JSON decision array
+func isSafeWait(decisions []Decision) bool {
+	return len(decisions) == 1 && decisions[0].Symbol == "ALL" &&
+		strings.HasPrefix(decisions[0].Reasoning, safeWaitReasoning)
+}
+
 // fixMissingQuotes fixes common quote and bracket issues from AI output
 func fixMissingQuotes(jsonStr string) string {
 	// Curly quotes to straight quotes
@@ -0,0 +1,34 @@
+package decision
+
+import "testing"
+
+func TestParseStructuredDecisionResponse(t *testing.T) {
+	cfg := DefaultValidationConfig()
+
+	structured := `{"reasoning": "Trend is flat", "decisions": [{"symbol": "BTCUSDT", "action": "wait", "leverage": 0, "position_size_usd": 0, "stop_loss": 0, "take_profit": 0, "confidence": 40, "reasoning": "No edge"}]}`
+	fd, err := ParseStructuredDecisionResponse(structured, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fd.ParsePath != ParsePathStructured {
+		t.Errorf("ParsePath = %q, want %q", fd.ParsePath, ParsePathStructured)
+	}
+	if fd.CoTTrace != "Trend is flat" || len(fd.Decisions) != 1 || fd.Decisions[0].Action != ActionWait {
+		t.Errorf("unexpected decision: %+v", fd)
+	}
+
+	// A model that ignored the schema still gets parsed by the scraper
+	fenced := "<reasoning>Trend is flat</reasoning>\n<decision>\n```json\n" +
+		`[{"symbol": "BTCUSDT", "action": "wait", "confidence": 40, "reasoning": "No edge"}]` +
+		"\n```\n</decision>"
+	fd, err = ParseStructuredDecisionResponse(fenced, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fd.ParsePath != ParsePathRegex {
+		t.Errorf("ParsePath = %q, want %q", fd.ParsePath, ParsePathRegex)
+	}
+	if len(fd.Decisions) != 1 || fd.Decisions[0].Symbol != "BTCUSDT" {
+		t.Errorf("unexpected decisions: %+v", fd.Decisions)
+	}
+}
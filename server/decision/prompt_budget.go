@@ -0,0 +1,108 @@
+package decision
+
+import (
+	"maps"
+	"slices"
+	"unicode/utf8"
+)
+
+// Prompt sections a token budget trims, in the order they go. A prompt only
+// goes through the steps it has sections for.
+const (
+	TrimOldCandles     = "old_candles"      // Candles before the latest few
+	TrimRecentTrades   = "recent_trades"    // Per-trade lines of the recent trades, summed up instead
+	TrimIdleMarketData = "idle_market_data" // Market data of symbols without a position
+)
+
+// PromptFit is the estimated size of a prompt and what was trimmed to fit it
+// into its token budget
+type PromptFit struct {
+	Budget  int      `json:"budget,omitempty"` // 0 without a budget
+	Tokens  int      `json:"tokens"`           // Estimated tokens of the prompt as sent
+	Trimmed []string `json:"trimmed,omitempty"`
+}
+
+// Over reports whether the prompt is still over its budget with every step
+// applied
+func (f PromptFit) Over() bool {
+	return f.Budget > 0 && f.Tokens > f.Budget
+}
+
+// EstimateTokens estimates the tokens s takes: about four characters per
+// token for Latin text, one per character for CJK text and symbols. It's for
+// budgeting, not billing; the provider reports the real count.
+func EstimateTokens(s string) int {
+	var latin, other int
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			latin++
+		} else {
+			other++
+		}
+	}
+	return (latin+3)/4 + other
+}
+
+// FitUserPrompt builds the user prompt within ctx.PromptTokenBudget,
+// trimming recent trades and then the market data of symbols without a
+// position until it fits. A prompt still over once both are trimmed is sent
+// as it is.
+func (pb *PromptBuilder) FitUserPrompt(ctx *Context) (string, PromptFit) {
+	prompt := pb.BuildUserPrompt(ctx)
+	fit := PromptFit{Budget: ctx.PromptTokenBudget, Tokens: EstimateTokens(prompt)}
+	if !fit.Over() {
+		return prompt, fit
+	}
+
+	trimmed := *ctx
+	for _, step := range []string{TrimRecentTrades, TrimIdleMarketData} {
+		if !trimmed.canTrim(step) {
+			continue
+		}
+		trimmed.trimmed = append(slices.Clone(trimmed.trimmed), step)
+		prompt = pb.BuildUserPrompt(&trimmed)
+		fit.Tokens, fit.Trimmed = EstimateTokens(prompt), trimmed.trimmed
+		if !fit.Over() {
+			break
+		}
+	}
+	return prompt, fit
+}
+
+// canTrim reports whether ctx has anything for step to trim
+func (ctx *Context) canTrim(step string) bool {
+	switch step {
+	case TrimRecentTrades:
+		return len(ctx.RecentOrders) > 0
+	case TrimIdleMarketData:
+		_, idle := ctx.marketSymbols(true)
+		return len(idle) > 0
+	}
+	return false
+}
+
+// trims reports whether step was applied to ctx's prompt
+func (ctx *Context) trims(step string) bool {
+	return slices.Contains(ctx.trimmed, step)
+}
+
+// marketSymbols returns the market data symbols to format, sorted, and with
+// idleTrimmed the ones left out for holding no position
+func (ctx *Context) marketSymbols(idleTrimmed bool) (shown, idle []string) {
+	symbols := slices.Sorted(maps.Keys(ctx.MarketDataMap))
+	if !idleTrimmed {
+		return symbols, nil
+	}
+	held := make(map[string]bool, len(ctx.Positions))
+	for _, pos := range ctx.Positions {
+		held[pos.Symbol] = true
+	}
+	for _, symbol := range symbols {
+		if held[symbol] {
+			shown = append(shown, symbol)
+		} else {
+			idle = append(idle, symbol)
+		}
+	}
+	return shown, idle
+}
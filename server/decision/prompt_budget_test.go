@@ -0,0 +1,60 @@
+package decision
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"BTCUSDT", 2},
+		{"12345678", 2},
+		{"近期交易", 4},
+		{"## 近期交易\n", 5},
+	} {
+		if got := EstimateTokens(tc.s); got != tc.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestFitUserPrompt(t *testing.T) {
+	pb := NewPromptBuilder(LangEnglish)
+	ctx := goldenContext()
+
+	full, fit := pb.FitUserPrompt(ctx)
+	if full != pb.BuildUserPrompt(ctx) || fit.Tokens != EstimateTokens(full) || len(fit.Trimmed) != 0 || fit.Over() {
+		t.Fatalf("no budget: fit = %+v", fit)
+	}
+
+	// Recent trades go first; a budget they make room for stops there
+	recentOnly := *ctx
+	recentOnly.trimmed = []string{TrimRecentTrades}
+	ctx.PromptTokenBudget = EstimateTokens(pb.BuildUserPrompt(&recentOnly))
+	prompt, fit := pb.FitUserPrompt(ctx)
+	if !slices.Equal(fit.Trimmed, []string{TrimRecentTrades}) || fit.Over() {
+		t.Errorf("fit = %+v, want only recent trades trimmed", fit)
+	}
+	if strings.Contains(prompt, "Entry $3400.0000") || !strings.Contains(prompt, "- 1 trades closed, net PnL $30.00") {
+		t.Errorf("recent trades weren't summed up:\n%s", prompt)
+	}
+
+	// Then the market data of symbols without a position
+	ctx.PromptTokenBudget = 1
+	prompt, fit = pb.FitUserPrompt(ctx)
+	if !slices.Equal(fit.Trimmed, []string{TrimRecentTrades, TrimIdleMarketData}) || !fit.Over() {
+		t.Errorf("fit = %+v, want both trimmed and still over", fit)
+	}
+	if strings.Contains(prompt, "### ETHUSDT") || !strings.Contains(prompt, "### BTCUSDT") ||
+		!strings.Contains(prompt, "no position held: ETHUSDT, GAPUSDT, NEWUSDT") {
+		t.Errorf("idle market data wasn't left out:\n%s", prompt)
+	}
+	if len(ctx.trimmed) != 0 {
+		t.Errorf("the caller's context was trimmed: %v", ctx.trimmed)
+	}
+}
@@ -0,0 +1,216 @@
+package decision
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/market"
+)
+
+var update = flag.Bool("update", false, "rewrite the prompt golden files")
+
+// badVerb matches fmt's output for a verb without a matching argument
+var badVerb = regexp.MustCompile(`%!\w*\(`)
+
+// goldenContext fills every section of the user prompt
+func goldenContext() *Context {
+	return &Context{
+		CurrentTime:    "2026-01-02 15:04:05",
+		RuntimeMinutes: 135,
+		CallCount:      28,
+		Account: AccountInfo{
+			TotalEquity:      1000,
+			AvailableBalance: 420.5,
+			UnrealizedPnL:    -62.25,
+			RealizedPnLToday: 12.5,
+			RealizedPnLTotal: 80,
+			FeesTotal:        6.4,
+			TotalPnL:         17.75,
+			TotalPnLPct:      1.78,
+			MarginUsed:       579.5,
+			MarginUsedPct:    57.95,
+			PositionCount:    2,
+		},
+		TradingStats: &TradingStats{
+			TotalTrades:    14,
+			WinRate:        57.1,
+			ProfitFactor:   1.8,
+			SharpeRatio:    1.12,
+			TotalPnL:       80,
+			AvgWin:         22.5,
+			AvgLoss:        -15.25,
+			MaxDrawdownPct: 6.3,
+		},
+		Positions: []PositionInfo{
+			{
+				Symbol: "BTCUSDT", Side: "long", EntryPrice: 95000, MarkPrice: 97000,
+				Quantity: 0.02, EntryQuantity: 0.03, Leverage: 10,
+				UnrealizedPnL: 40, UnrealizedPnLPct: 2.1, PeakPnLPct: 12.4,
+				StopLoss: 95000, TakeProfit: 102000, LiquidationPrice: 86000, MarginUsed: 194,
+			},
+			{
+				Symbol: "SOLUSDT", Side: "short", EntryPrice: 180, MarkPrice: 191,
+				Quantity: -10, Leverage: 5,
+				UnrealizedPnL: -110, UnrealizedPnLPct: -6.1, PeakPnLPct: 0.8, MarginUsed: 382,
+			},
+		},
+		RecentOrders: []RecentOrder{
+			{Symbol: "ETHUSDT", Side: "long", EntryPrice: 3400, ExitPrice: 3502, RealizedPnL: 30, PnLPct: 3, HoldDuration: "2h30m"},
+		},
+		CandidateCoins: []CandidateCoin{
+			{Symbol: "BTCUSDT", Sources: []string{"ai500", "oi_top"}},
+			{Symbol: "ETHUSDT", Sources: []string{"ai500"}},
+		},
+		Cooldowns: []SymbolCooldown{
+			{Symbol: "DOGEUSDT", RemainingMins: 12},
+			{Symbol: "XRPUSDT", RemainingMins: 40, AfterStopLoss: true},
+		},
+		MaxCorrelatedExposurePct: 60,
+		Exposure: []ExposureCluster{
+			{Side: "long", Symbols: []string{"BTCUSDT"}, NotionalUSD: 1940, EquityPct: 194},
+			{Side: "short", Symbols: []string{"SOLUSDT"}, NotionalUSD: 1910, EquityPct: 191, Static: true},
+		},
+		CorrelationBlocks: []CorrelationBlock{
+			{Symbol: "AVAXUSDT", Side: "short", Reason: "correlated with SOLUSDT (0.87)"},
+		},
+		ExposureBudget: &ExposureBudget{
+			Limits:   ExposureLimits{MaxNetLongPct: 300, MaxNetShortPct: 300, MaxBTCETHNotionalPct: 250, MaxAltcoinNotionalPct: 200},
+			Exposure: Exposure{LongUSD: 1940, ShortUSD: 1910, BTCETHUSD: 1940, AltcoinUSD: 1910},
+			Equity:   1000,
+		},
+		StrategyRules: "Only trade with the 4h trend.",
+		MarketDataMap: map[string]*MarketData{
+			"BTCUSDT": {
+				Symbol: "BTCUSDT", Price: 97000, Change24h: 2.4, Volume24h: 1.25e9,
+				OpenInterest: 8.1e9, OIChange24h: 3.2, OITrend: "RISING", FundingRate: 0.0001,
+				HighPrice24h: 97800, LowPrice24h: 94100,
+				KeyLevels: &market.KeyLevels{
+					Resistances:  []market.Level{{Price: 98000, Touches: 1}, {Price: 100000, Touches: 3}},
+					Supports:     []market.Level{{Price: 95500, Touches: 2}},
+					High24h:      97800,
+					Low24h:       94100,
+					PrevDayClose: 94700,
+				},
+			},
+			"ETHUSDT": {
+				Symbol: "ETHUSDT", Price: 3500, Change24h: -1.1, Volume24h: 6.5e8,
+				FundingRate: -0.00005, HighPrice24h: 3560, LowPrice24h: 3440,
+			},
+			"NEWUSDT": {Symbol: "NEWUSDT", NotListed: true},
+			"GAPUSDT": {Symbol: "GAPUSDT", DataGap: true},
+		},
+		BTCETHLeverage:  20,
+		AltcoinLeverage: 10,
+		BTCETHPosRatio:  0.3,
+		AltcoinPosRatio: 0.15,
+	}
+}
+
+// renderPrompts renders every template of lang into one document
+func renderPrompts(lang Language) string {
+	pb := NewPromptBuilder(lang)
+	pb.SetNoiseZoneConfig(-1, 1.5)
+
+	var sb strings.Builder
+	sb.WriteString("=== SYSTEM ===\n")
+	sb.WriteString(pb.BuildSystemPrompt())
+	sb.WriteString("\n\n=== USER ===\n")
+	sb.WriteString(pb.BuildUserPrompt(goldenContext()))
+	sb.WriteString("\n\n=== USER (no positions) ===\n")
+	sb.WriteString(FormatContextForAI(&Context{CurrentTime: "2026-01-02 15:04:05"}, lang))
+	sb.WriteString("\n=== CORRECTION ===\n")
+	sb.WriteString(pb.BuildCorrectionPrompt("stop_loss above entry", false))
+	sb.WriteString("\n\n=== CORRECTION (structured) ===\n")
+	sb.WriteString(pb.BuildCorrectionPrompt("stop_loss above entry", true))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func TestPromptGolden(t *testing.T) {
+	for _, lang := range SupportedLanguages() {
+		t.Run(string(lang), func(t *testing.T) {
+			path := filepath.Join("testdata", "prompt_"+string(lang)+".golden")
+			got := renderPrompts(lang)
+			if badVerb.MatchString(got) {
+				t.Errorf("a template's verbs don't match its arguments:\n%s", got)
+			}
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test ./decision -run TestPromptGolden -update)", err)
+			}
+			if got != string(want) {
+				t.Errorf("prompts differ from %s; if the change is intended, rerun with -update\n%s", path, firstDiff(got, string(want)))
+			}
+		})
+	}
+}
+
+// firstDiff describes the first line where got and want differ
+func firstDiff(got, want string) string {
+	g, w := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(g) || i < len(w); i++ {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			return "line " + strconv.Itoa(i+1) + ":\n got: " + gl + "\nwant: " + wl
+		}
+	}
+	return ""
+}
+
+func TestTranslationsCoverEveryKey(t *testing.T) {
+	for _, lang := range SupportedLanguages() {
+		for key := promptKey(0); key < numPromptKeys; key++ {
+			if translations[lang][key] == "" {
+				t.Errorf("%s has no text for prompt key %d", lang, key)
+			}
+		}
+	}
+}
+
+func TestTextFallsBackToEnglish(t *testing.T) {
+	const partial Language = "xx-XX"
+	translations[partial] = map[promptKey]string{keyContextTitle: "# Contexte\n\n"}
+	defer delete(translations, partial)
+
+	got := FormatContextForAI(&Context{}, partial)
+	if !strings.HasPrefix(got, "# Contexte\n\n") {
+		t.Errorf("translated section not used:\n%s", got)
+	}
+	if !strings.Contains(got, "## Account Status") {
+		t.Errorf("missing section didn't fall back to English:\n%s", got)
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Language
+	}{{"", LangEnglish}, {"en-US", LangEnglish}, {"zh-CN", LangChinese}, {"ja-JP", LangJapanese}, {"ko-KR", LangKorean}} {
+		if got, err := ParseLanguage(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseLanguage(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	_, err := ParseLanguage("fr-FR")
+	if !errors.Is(err, ErrUnsupportedLanguage) || !strings.Contains(err.Error(), "en-US, zh-CN, ja-JP, ko-KR") {
+		t.Errorf("ParseLanguage(fr-FR) error = %v, want ErrUnsupportedLanguage listing the supported languages", err)
+	}
+}
@@ -0,0 +1,61 @@
+package decision
+
+import "auto-trader-ahh/mcp"
+
+// Parse paths recorded on each decision
+const (
+	ParsePathStructured = "structured" // Bare JSON produced under DecisionResponseFormat
+	ParsePathRegex      = "regex"      // Scraped from free text by extractDecisions
+)
+
+// DecisionResponseFormat is the response_format schema for a decision array.
+// Strict schemas need an object at the root, so the array sits under "decisions"
+// next to the chain of thought.
+func DecisionResponseFormat() *mcp.ResponseFormat {
+	decisionItem := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"symbol": map[string]interface{}{"type": "string"},
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{
+					ActionOpenLong, ActionOpenShort, ActionAddLong, ActionAddShort,
+					ActionCloseLong, ActionCloseShort, ActionMoveStop, ActionMoveTP,
+					ActionHold, ActionWait,
+				},
+			},
+			"leverage":          map[string]interface{}{"type": "integer"},
+			"position_size_usd": map[string]interface{}{"type": "number"},
+			"stop_loss":         map[string]interface{}{"type": "number"},
+			"take_profit":       map[string]interface{}{"type": "number"},
+			"close_percent":     map[string]interface{}{"type": "number"},
+			"confidence":        map[string]interface{}{"type": "integer"},
+			"reasoning":         map[string]interface{}{"type": "string"},
+		},
+		"required": []string{
+			"symbol", "action", "leverage", "position_size_usd",
+			"stop_loss", "take_profit", "close_percent", "confidence", "reasoning",
+		},
+		"additionalProperties": false,
+	}
+
+	return &mcp.ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &mcp.JSONSchema{
+			Name:   "trading_decisions",
+			Strict: true,
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reasoning": map[string]interface{}{"type": "string"},
+					"decisions": map[string]interface{}{
+						"type":  "array",
+						"items": decisionItem,
+					},
+				},
+				"required":             []string{"reasoning", "decisions"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package decision
+
+import "fmt"
+
+// Position sizing modes
+const (
+	SizingFixedPct = "fixed_pct" // Margin is a fixed percent of equity
+	SizingATRRisk  = "atr_risk"  // Size so a stop k*ATR away risks a fixed percent of equity
+)
+
+// ATRPeriod is the ATR lookback used for sizing, matching the live market data
+const ATRPeriod = 14
+
+// ATRRiskSize returns the position value whose loss at a stop atrMultiple ATRs
+// from entry is riskPct percent of equity, capped at maxValue (0 = no cap).
+// riskUSD is what the returned position loses at that stop.
+func ATRRiskSize(equity, riskPct, atr, atrMultiple, price, maxValue float64) (value, riskUSD float64, err error) {
+	if atr <= 0 {
+		return 0, 0, fmt.Errorf("no ATR available")
+	}
+	if price <= 0 {
+		return 0, 0, fmt.Errorf("invalid price %.8f", price)
+	}
+	if equity <= 0 || riskPct <= 0 || atrMultiple <= 0 {
+		return 0, 0, fmt.Errorf("invalid ATR sizing parameters (equity %.2f, risk %.2f%%, multiple %.2f)", equity, riskPct, atrMultiple)
+	}
+
+	stopDistance := atrMultiple * atr
+	quantity := equity * riskPct / 100 / stopDistance
+	value = quantity * price
+	if maxValue > 0 && value > maxValue {
+		value = maxValue
+		quantity = value / price
+	}
+	return value, quantity * stopDistance, nil
+}
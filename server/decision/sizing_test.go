@@ -0,0 +1,50 @@
+package decision
+
+import (
+	"math"
+	"testing"
+)
+
+func TestATRRiskSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		equity    float64
+		riskPct   float64
+		atr       float64
+		multiple  float64
+		price     float64
+		maxValue  float64
+		wantValue float64
+		wantRisk  float64
+		wantErr   bool
+	}{
+		// $10k at 1% risks $100; stop 2*$50 = $100 away -> 1 unit at $2000
+		{"risk sized", 10000, 1, 50, 2, 2000, 0, 2000, 100, false},
+		{"wider stop shrinks the position", 10000, 1, 100, 2, 2000, 0, 1000, 100, false},
+		// Capped at $1000 -> 0.5 units, losing $50 at the stop
+		{"capped", 10000, 1, 50, 2, 2000, 1000, 1000, 50, false},
+		{"cap above size is ignored", 10000, 1, 50, 2, 2000, 5000, 2000, 100, false},
+		{"no ATR", 10000, 1, 0, 2, 2000, 0, 0, 0, true},
+		{"bad price", 10000, 1, 50, 2, 0, 0, 0, 0, true},
+		{"no equity", 0, 1, 50, 2, 2000, 0, 0, 0, true},
+		{"no risk", 10000, 0, 50, 2, 2000, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, risk, err := ATRRiskSize(tt.equity, tt.riskPct, tt.atr, tt.multiple, tt.price, tt.maxValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ATRRiskSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if math.Abs(value-tt.wantValue) > 1e-9 {
+				t.Errorf("value = %v, want %v", value, tt.wantValue)
+			}
+			if math.Abs(risk-tt.wantRisk) > 1e-9 {
+				t.Errorf("riskUSD = %v, want %v", risk, tt.wantRisk)
+			}
+		})
+	}
+}
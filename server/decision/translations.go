@@ -0,0 +1,193 @@
+package decision
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedLanguage is returned for a language without prompt translations
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// supportedLanguages are the languages with prompt translations, in the order
+// they're listed to users
+var supportedLanguages = []Language{LangEnglish, LangChinese, LangJapanese, LangKorean}
+
+// languageNames are the supported languages' English names, for asking a
+// model to respond in one
+var languageNames = map[Language]string{
+	LangEnglish:  "English",
+	LangChinese:  "Chinese",
+	LangJapanese: "Japanese",
+	LangKorean:   "Korean",
+}
+
+// SupportedLanguages returns the languages prompts can be built in
+func SupportedLanguages() []Language {
+	return append([]Language(nil), supportedLanguages...)
+}
+
+// ParseLanguage checks that s is a supported language, English when empty
+func ParseLanguage(s string) (Language, error) {
+	if s == "" {
+		return LangEnglish, nil
+	}
+	lang := Language(s)
+	if _, ok := translations[lang]; !ok {
+		names := make([]string, len(supportedLanguages))
+		for i, l := range supportedLanguages {
+			names[i] = string(l)
+		}
+		return "", fmt.Errorf("%w %q (supported: %s)", ErrUnsupportedLanguage, s, strings.Join(names, ", "))
+	}
+	return lang, nil
+}
+
+// Name returns the language's English name, empty when it isn't supported
+func (l Language) Name() string {
+	return languageNames[l]
+}
+
+// promptKey names one translated section or label of the prompts
+type promptKey int
+
+const (
+	// System prompt, with the noise zone bounds as %.1f: lower, lower, upper, upper
+	keySystemPrompt promptKey = iota
+	keyDecisionRequirements
+	keyCorrection           // %s: why the response couldn't be used
+	keyCorrectionStructured // %s: why the response couldn't be used
+
+	// Context header
+	keyContextTitle
+	keyTime
+	keyRuntime
+	keyCallCount
+
+	// Account
+	keyAccountTitle
+	keyTotalEquity
+	keyAvailableBalance
+	keyUnrealizedPnL
+	keyRealizedPnL
+	keyTotalPnL
+	keyMarginUsed
+	keyPositionCount
+	keyWarnMargin
+	keyWarnLosses
+
+	// Trading statistics
+	keyStatsTitle
+	keyStatsTrades
+	keyStatsWinRate
+	keyStatsProfitFactor
+	keyStatsSharpe
+	keyStatsPnL
+	keyStatsAvgWinLoss
+	keyStatsDrawdown
+
+	// Positions
+	keyPositionsTitle
+	keyNoPositions
+	keyLong
+	keyShort
+	keyPosEntryMark
+	keyPosQuantity
+	keyPosPartial // Open, then entered quantity
+	keyPosPnL
+	keyPosPeak
+	keyPosStops
+	keyNone
+	keyPosLiquidation
+	keyLiquidationUnknown
+	keyLiquidationDistance
+	keyPosMargin
+	keyAlertLoss
+	keyAlertRetrace
+
+	// Recent trades, candidates and cooldowns
+	keyRecentTitle
+	keyRecentTrade
+	keyRecentTrimmed // Trade count, net PnL
+	keyCandidatesTitle
+	keyCandidate
+	keyCooldownTitle
+	keyCooldown
+	keyCooldownClosed
+	keyCooldownStopped
+
+	// Correlated exposure
+	keyExposureTitle
+	keyExposureLimit
+	keyExposureCluster
+	keyExposureStatic
+	keyExposureBlocked
+
+	// Exposure budget, each line with the held and remaining USDT as %.0f and
+	// the limit as %.0f
+	keyBudgetTitle
+	keyBudgetNetLong
+	keyBudgetNetShort
+	keyBudgetBTCETH
+	keyBudgetAltcoin
+	keyBudgetNote
+
+	// Market data
+	keyMarketTitle
+	keyMarketTrimmed // Symbols left out
+	keyNotListed
+	keyDataGap
+	keyPrice
+	keyHighLow
+	keyVolume
+	keyOpenInterest
+	keyOITrend
+	keyOIVerdict
+	keyFunding
+	keyFundingCost // Paying side, % of notional per day
+	keyLongs
+	keyShorts
+
+	// Key levels
+	keyLevelsTitle
+	keyResistance
+	keySupport
+	keyOneTouch
+	keyTouches
+	keyLevels24h
+	keyPrevDayClose
+	keyLevelsHint
+
+	// Position limits
+	keyLimitsTitle
+	keyLimitsMajors
+	keyLimitsAlts
+
+	// Strategy rules
+	keyRulesTitle
+
+	numPromptKeys
+)
+
+// translations holds each supported language's prompt text. A language may
+// leave sections out; text falls back to English for them.
+var translations = map[Language]map[promptKey]string{
+	LangEnglish:  promptsEN,
+	LangChinese:  promptsZH,
+	LangJapanese: promptsJA,
+	LangKorean:   promptsKO,
+}
+
+// text returns the section key in lang, or in English when lang has no
+// translation of it
+func text(lang Language, key promptKey) string {
+	if s, ok := translations[lang][key]; ok {
+		return s
+	}
+	return promptsEN[key]
+}
+
+// textf formats the section key in lang with args
+func textf(lang Language, key promptKey, args ...interface{}) string {
+	return fmt.Sprintf(text(lang, key), args...)
+}
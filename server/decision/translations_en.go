@@ -0,0 +1,237 @@
+package decision
+
+// promptsEN is the English prompt text, and the fallback for sections other
+// languages don't translate
+var promptsEN = map[promptKey]string{
+	keySystemPrompt: `You are a professional cryptocurrency futures trading analyst. Your task is to analyze market data and current positions, then make trading decisions.
+
+## Role Definition
+You are a disciplined, risk-first trading decision maker. You prioritize capital preservation over profit maximization.
+
+## Core Decision Principles
+
+### 1. Risk-First Philosophy
+- Never risk more than the specified position limits
+- Always set stop-loss before considering take-profit
+- You MAY close losing positions identifying invalidation of the trade thesis
+- Preserve capital - missing opportunities is better than losing capital
+
+### 2. Trailing Take-Profit Strategy
+- For profitable positions: Move stop-loss to breakeven when +5%% profit (action "move_stop")
+- Trail stops to lock in profits as price moves favorably
+- Let winners run but protect unrealized gains
+- Consider partial exits at key resistance/support levels
+
+### 3. Trend-Following Approach
+- Trade in the direction of the larger timeframe trend
+- Don't fight strong momentum
+- Wait for pullbacks to enter rather than chasing
+- Use multiple timeframe confirmation
+
+### CRITICAL: Trend Strength Gate
+- **DO NOT OPEN** new positions when EMA9 vs EMA21 spread is below 0.2%%
+- Very weak trends (< 0.2%% EMA spread) lead to choppy price action and stop-outs
+- If you see "VERY WEAK TREND" or "SIDEWAYS MARKET" warnings, use action: "wait"
+- Only enter when trend strength shows "Moderate" (> 0.2%%) or "Strong" (> 0.5%%)
+
+### 4. Position Management
+- Scale into positions gradually, not all at once: first entry at most 50%% of the intended size, then add_to_long/add_to_short (capped by the remaining position limit)
+- Scale out with partial closes (close_percent), e.g. close 33%% at +3%%
+- Keep total margin usage below risk limits
+- Diversify across uncorrelated assets when possible
+- Reduce exposure during high uncertainty
+
+## CRITICAL RULE: Smart Loss Management
+
+**The Three Zones:**
+
+1. **Significant Loss Zone** (Below %.1f%%)
+   - ✅ You CAN recommend close_long/close_short
+   - Purpose: Cut losses early when trade thesis is invalidated
+   - Use when: Clear technical invalidation or fundamental shift
+
+2. **Noise Zone** (%.1f%% to +%.1f%%)
+   - ⚠️ Provide your analysis and reasoning if you think closing is needed
+   - Explain WHY you believe the position should be closed
+   - The system will evaluate your reasoning and confidence level
+
+3. **Profit Zone** (Above +%.1f%%)
+   - ✅ You CAN recommend close to lock in profits
+   - Purpose: Secure gains when momentum weakens or resistance hit
+   - But prefer letting TP order reach the target if momentum is strong
+
+**Key Guidelines:**
+- Focus on finding high-quality ENTRY points with 3:1 R:R
+- For existing positions: Provide your analysis FIRST, then your recommendation
+- Always explain your reasoning clearly - the system needs your insight
+- HOLD positions for 30-60 minutes unless there's major invalidation
+- If you just opened/closed a position, recommend HOLD for next few cycles
+
+## Output Format Requirements
+
+You MUST output your decisions in valid JSON format wrapped in <decision> tags:
+
+<decision>
+[
+  {
+    "symbol": "<THE_SYMBOL_YOU_ARE_ANALYZING>",
+    "action": "open_long",
+    "leverage": 10,
+    "position_size_usd": 500,
+    "stop_loss": 95000,
+    "take_profit": 105000,
+    "confidence": 75,
+    "reasoning": "Strong bullish momentum on daily, breaking key resistance"
+  }
+]
+</decision>
+
+## Field Descriptions
+
+- symbol: The EXACT trading pair you are analyzing (use the symbol from the market data provided, e.g., "BTCUSDT", "ETHUSDT", "DOGEUSDT", etc.)
+- action: One of "open_long", "open_short", "add_to_long", "add_to_short", "close_long", "close_short", "move_stop", "move_tp", "hold", "wait"
+- leverage: Leverage multiplier (1-20 for BTC/ETH, 1-10 for altcoins)
+- position_size_usd: Position size in USDT
+- stop_loss: Stop-loss price level; for move_stop, the new stop of the open position
+- take_profit: Take-profit price level; for move_tp, the new target of the open position
+- close_percent: For close actions, the share of the position to close (e.g. 33 to scale out a third). 0 or 100 closes everything
+- confidence: Confidence level 0-100
+- reasoning: Brief explanation of the decision
+
+## Critical Reminders
+
+1. ALL numeric values must be precise single numbers - NO ranges like "100-200"
+2. stop_loss and take_profit must be valid price levels (not percentages)
+3. For LONG positions: stop_loss < current_price < take_profit
+4. For SHORT positions: take_profit < current_price < stop_loss
+5. Risk/Reward ratio must be at least 3:1
+6. If no good opportunities exist, use action: "wait" with symbol: "ALL"
+7. Always output valid JSON - use straight quotes, not curly quotes
+8. For close decisions: provide clear reasoning about why the position should be closed
+9. move_stop and move_tp only adjust an open position: the new level must stay on the stop or target side of the current price`,
+
+	keyDecisionRequirements: `
+
+---
+
+## Decision Steps
+
+1. **Analyze Market Context**: Review account status, current positions, and market conditions
+2. **Assess Risk**: Check margin usage, unrealized PnL, and potential exposure
+3. **Evaluate Opportunities**: Look for high-probability setups with favorable risk/reward
+4. **Make Decisions**: Output specific, actionable decisions with clear parameters
+
+## Your Response
+
+First, provide your reasoning in a <reasoning> tag:
+
+<reasoning>
+Your chain of thought analysis here...
+</reasoning>
+
+Then output your decisions in <decision> tags as shown in the format above.
+
+If there are no actionable opportunities, output:
+<decision>
+[{"symbol": "ALL", "action": "wait", "reasoning": "No favorable setups identified"}]
+</decision>`,
+
+	keyCorrection:           "Your previous response could not be used: %s\n\nRe-emit only the corrected <decision> block containing a valid JSON array of decisions, with no other text.",
+	keyCorrectionStructured: "Your previous response could not be used: %s\n\nRe-emit only the corrected JSON object, with no other text.",
+
+	keyContextTitle: "# Current Trading Context\n\n",
+	keyTime:         "**Time**: %s\n",
+	keyRuntime:      "**Runtime**: %d minutes\n",
+	keyCallCount:    "**Analysis Count**: #%d\n\n",
+
+	keyAccountTitle:     "## Account Status\n\n",
+	keyTotalEquity:      "- Total Equity: $%.2f\n",
+	keyAvailableBalance: "- Available Balance: $%.2f\n",
+	keyUnrealizedPnL:    "- Unrealized PnL: $%.2f (open positions, not banked)\n",
+	keyRealizedPnL:      "- Realized PnL: $%.2f today, $%.2f since start (closed positions, before $%.2f fees)\n",
+	keyTotalPnL:         "- Total PnL: $%.2f (%.2f%%)\n",
+	keyMarginUsed:       "- Margin Used: $%.2f (%.2f%%)\n",
+	keyPositionCount:    "- Position Count: %d\n\n",
+	keyWarnMargin:       "**WARNING: High margin usage! Consider reducing positions.**\n\n",
+	keyWarnLosses:       "**WARNING: Significant unrealized losses! Review positions carefully.**\n\n",
+
+	keyStatsTitle:        "## Trading Statistics\n\n",
+	keyStatsTrades:       "- Total Trades: %d\n",
+	keyStatsWinRate:      "- Win Rate: %.1f%%\n",
+	keyStatsProfitFactor: "- Profit Factor: %.2f\n",
+	keyStatsSharpe:       "- Sharpe Ratio: %.2f\n",
+	keyStatsPnL:          "- Total PnL: $%.2f\n",
+	keyStatsAvgWinLoss:   "- Avg Win: $%.2f | Avg Loss: $%.2f\n",
+	keyStatsDrawdown:     "- Max Drawdown: %.2f%%\n\n",
+
+	keyPositionsTitle:      "## Current Positions\n\n",
+	keyNoPositions:         "No open positions.\n\n",
+	keyLong:                "LONG",
+	keyShort:               "SHORT",
+	keyPosEntryMark:        "- Entry: $%.4f | Mark: $%.4f\n",
+	keyPosQuantity:         "- Quantity: %.4f | Leverage: %dx\n",
+	keyPosPartial:          "- Partially closed: %.4f of %.4f entered still open\n",
+	keyPosPnL:              "- Unrealized PnL: $%.2f (%.2f%%)\n",
+	keyPosPeak:             "- Peak PnL: %.2f%%\n",
+	keyPosStops:            "- Exchange Stops: SL %s | TP %s\n",
+	keyNone:                "none",
+	keyPosLiquidation:      "- Liquidation Price: %s\n",
+	keyLiquidationUnknown:  "unknown",
+	keyLiquidationDistance: "$%.4f (%.2f%% away)",
+	keyPosMargin:           "- Margin Used: $%.2f\n\n",
+	keyAlertLoss:           "**ALERT: Position down >5%! Consider cutting losses.**\n\n",
+	keyAlertRetrace:        "**ALERT: Position retraced significantly from peak! Consider trailing stop.**\n\n",
+
+	keyRecentTitle:     "## Recent Trades\n\n",
+	keyRecentTrade:     "- %s %s: Entry $%.4f -> Exit $%.4f | PnL: $%.2f (%.2f%%) | Duration: %s\n",
+	keyRecentTrimmed:   "- %d trades closed, net PnL $%.2f (per-trade detail left out to fit the prompt budget)\n",
+	keyCandidatesTitle: "## Candidate Coins for Analysis\n\n",
+	keyCandidate:       "- %s (Sources: %s)\n",
+	keyCooldownTitle:   "## Symbols in Cooldown (do NOT open)\n\n",
+	keyCooldown:        "- %s: symbol in cooldown for %d more minutes (%s)\n",
+	keyCooldownClosed:  "recently closed",
+	keyCooldownStopped: "stopped out",
+
+	keyExposureTitle:   "## Correlated Exposure\n\n",
+	keyExposureLimit:   "Same-side positions in correlated symbols count as one bet, limited to %.0f%% of equity in notional.\n",
+	keyExposureCluster: "- %s %s: $%.2f (%.0f%% of equity%s)\n",
+	keyExposureStatic:  ", grouped as alts (short history)",
+	keyExposureBlocked: "\nBlocked entries (propose uncorrelated symbols instead):\n",
+
+	keyBudgetTitle:    "## Exposure Budget Remaining\n\n",
+	keyBudgetNetLong:  "- Net long: %.0f USDT held, %.0f USDT left (limit %.0f%% of equity)\n",
+	keyBudgetNetShort: "- Net short: %.0f USDT held, %.0f USDT left (limit %.0f%% of equity)\n",
+	keyBudgetBTCETH:   "- BTC/ETH notional: %.0f USDT held, %.0f USDT left (limit %.0f%% of equity)\n",
+	keyBudgetAltcoin:  "- Altcoin notional: %.0f USDT held, %.0f USDT left (limit %.0f%% of equity)\n",
+	keyBudgetNote:     "Entries past a limit are rejected. Closes in this response run first, so closing a position frees budget for an entry in the same response.\n\n",
+
+	keyMarketTitle:   "## Market Data\n\n",
+	keyMarketTrimmed: "Left out to fit the prompt budget, no position held: %s\n\n",
+	keyNotListed:     "- Not yet listed: no price data, can't be traded yet\n\n",
+	keyDataGap:       "- No recent price data (gap in the market data), don't trade it this cycle\n\n",
+	keyPrice:         "- Price: $%.4f | 24h Change: %.2f%%\n",
+	keyHighLow:       "- 24h High: $%.4f | Low: $%.4f\n",
+	keyVolume:        "- 24h Volume: $%.2f\n",
+	keyOpenInterest:  "- Open Interest: $%.2f | OI Change: %.2f%%",
+	keyOITrend:       " | OI Trend (4h): %s",
+	keyOIVerdict:     "- OI + Price: %s\n",
+	keyFunding:       "- Funding Rate: %.4f%% per 8h | Cost per Day: %s\n\n",
+	keyFundingCost:   "%s pays ~%.3f%% of notional",
+	keyLongs:         "LONG",
+	keyShorts:        "SHORT",
+
+	keyLevelsTitle:  "--- Key Levels ---\n",
+	keyResistance:   "Resistance: $%.4f (%+.2f%%, %s)\n",
+	keySupport:      "Support: $%.4f (%+.2f%%, %s)\n",
+	keyOneTouch:     "1 touch",
+	keyTouches:      "%d touches",
+	keyLevels24h:    "24h High: $%.4f (%+.2f%%) | 24h Low: $%.4f (%+.2f%%)\n",
+	keyPrevDayClose: "Previous Day Close: $%.4f (%+.2f%%)\n",
+	keyLevelsHint:   "Place stop losses just beyond these levels, not at round numbers.\n",
+
+	keyLimitsTitle:  "## Position Limits\n\n",
+	keyLimitsMajors: "- BTC/ETH: Max %dx leverage, Max %.0f%% of equity per position\n",
+	keyLimitsAlts:   "- Altcoins: Max %dx leverage, Max %.0f%% of equity per position\n\n",
+
+	keyRulesTitle: "## Strategy Rules\n\n",
+}
@@ -0,0 +1,236 @@
+package decision
+
+// promptsJA is the Japanese prompt text
+var promptsJA = map[promptKey]string{
+	keySystemPrompt: `あなたはプロの暗号資産先物トレーディングアナリストです。市場データと現在のポジションを分析し、取引判断を下すことがあなたの任務です。
+
+## 役割
+あなたは規律を守り、リスクを最優先する取引判断者です。利益の最大化よりも資金の保全を優先します。
+
+## 判断の基本原則
+
+### 1. リスク優先の考え方
+- 指定されたポジション上限を超えるリスクは決して取らない
+- 利確より先に必ず損切りを設定する
+- トレードの根拠が崩れたと判断した場合、損失中のポジションを決済してよい
+- 資金を守る - 機会を逃す方が資金を失うよりましである
+
+### 2. トレーリング利確戦略
+- 利益が出ているポジション：+5%%の利益で損切りを建値に移動する（action "move_stop"）
+- 価格が有利に動くにつれて損切りを引き上げ、利益を確保する
+- 利益は伸ばしつつ、含み益を守る
+- 主要なレジスタンス/サポートでの部分決済を検討する
+
+### 3. トレンドフォロー
+- 上位時間足のトレンド方向に取引する
+- 強いモメンタムに逆らわない
+- 高値を追わず、押し目・戻りを待ってエントリーする
+- 複数の時間足で確認する
+
+### 重要：トレンド強度の基準
+- EMA9とEMA21の乖離が0.2%%未満のときは新規ポジションを**建てない**
+- 非常に弱いトレンド（EMA乖離 < 0.2%%）はもみ合いと損切りにつながる
+- 「非常に弱いトレンド」や「横ばい相場」の警告がある場合は action: "wait" を使う
+- トレンド強度が「中程度」（> 0.2%%）または「強い」（> 0.5%%）のときだけエントリーする
+
+### 4. ポジション管理
+- 一度に全量を建てず段階的に建てる：初回エントリーは予定サイズの最大50%%とし、その後 add_to_long/add_to_short で追加する（残りのポジション上限まで）
+- 部分決済（close_percent）で段階的に利確する。例：+3%%で33%%を決済
+- 証拠金の総使用量をリスク上限以下に保つ
+- 可能な限り相関の低い銘柄に分散する
+- 不確実性が高いときはエクスポージャーを減らす
+
+## 重要ルール：スマートな損失管理
+
+**3つのゾーン：**
+
+1. **大幅損失ゾーン**（%.1f%% 未満）
+   - ✅ close_long/close_short を推奨してよい
+   - 目的：トレードの根拠が崩れたときに早めに損失を確定する
+   - 使う場面：明確なテクニカル上の否定、またはファンダメンタルズの変化
+
+2. **ノイズゾーン**（%.1f%% ～ +%.1f%%）
+   - ⚠️ 決済が必要だと考える場合は、分析と理由を示す
+   - なぜポジションを決済すべきかを説明する
+   - システムがあなたの理由と確信度を評価する
+
+3. **利益ゾーン**（+%.1f%% 超）
+   - ✅ 利益確定のための決済を推奨してよい
+   - 目的：モメンタムが弱まった、またはレジスタンスに到達したときに利益を確保する
+   - ただしモメンタムが強い場合は、利確注文が目標に達するのを優先する
+
+**重要な指針：**
+- リスクリワード3:1の質の高いエントリーポイントを探すことに集中する
+- 既存ポジションについては、まず分析を示し、その後に推奨を示す
+- 常に理由を明確に説明する - システムはあなたの洞察を必要としている
+- 重大な否定がない限り、ポジションは30～60分保有する
+- 直前にポジションを建てた/決済した場合は、次の数サイクルは HOLD を推奨する
+
+## 出力形式の要件
+
+判断は有効なJSON形式で出力し、<decision>タグで囲むこと：
+
+<decision>
+[
+  {
+    "symbol": "<THE_SYMBOL_YOU_ARE_ANALYZING>",
+    "action": "open_long",
+    "leverage": 10,
+    "position_size_usd": 500,
+    "stop_loss": 95000,
+    "take_profit": 105000,
+    "confidence": 75,
+    "reasoning": "日足で強い上昇モメンタム、主要レジスタンスを突破"
+  }
+]
+</decision>
+
+## フィールドの説明
+
+- symbol: 分析している取引ペアそのもの（市場データの symbol を使う。例："BTCUSDT", "ETHUSDT", "DOGEUSDT"）
+- action: "open_long", "open_short", "add_to_long", "add_to_short", "close_long", "close_short", "move_stop", "move_tp", "hold", "wait" のいずれか
+- leverage: レバレッジ倍率（BTC/ETH は 1-20、アルトコインは 1-10）
+- position_size_usd: ポジションサイズ（USDT）
+- stop_loss: 損切り価格。move_stop の場合は保有ポジションの新しい損切り価格
+- take_profit: 利確価格。move_tp の場合は保有ポジションの新しい利確価格
+- close_percent: 決済アクションで決済する割合（例：33 で3分の1を決済）。0 または 100 で全量決済
+- confidence: 確信度 0-100
+- reasoning: 判断の簡潔な説明
+
+## 重要な注意事項
+
+1. すべての数値は正確な単一の数値とする - "100-200" のような範囲は不可
+2. stop_loss と take_profit は有効な価格とする（パーセンテージではない）
+3. ロング：損切り < 現在価格 < 利確
+4. ショート：利確 < 現在価格 < 損切り
+5. リスクリワード比は3:1以上とする
+6. 良い機会がない場合は action: "wait"、symbol: "ALL" を使う
+7. 常に有効なJSONを出力する - 全角や曲がった引用符ではなく、半角のストレートクォートを使う
+8. 決済の判断では、なぜ決済すべきかを明確に説明する
+9. move_stop と move_tp は保有ポジションの調整のみ：新しい価格は現在価格に対して損切り側または利確側に留まること`,
+
+	keyDecisionRequirements: `
+
+---
+
+## 判断の手順
+
+1. **市場環境の分析**：口座状況、現在のポジション、市場の状態を確認する
+2. **リスクの評価**：証拠金使用率、含み損益、潜在的なエクスポージャーを確認する
+3. **機会の評価**：リスクリワードが有利で確率の高いセットアップを探す
+4. **判断**：明確なパラメータを持つ、具体的で実行可能な判断を出力する
+
+## 回答
+
+まず <reasoning> タグ内に推論を示すこと：
+
+<reasoning>
+ここに思考過程の分析...
+</reasoning>
+
+次に、上記の形式で <decision> タグ内に判断を出力すること。
+
+実行可能な機会がない場合は、次を出力すること：
+<decision>
+[{"symbol": "ALL", "action": "wait", "reasoning": "有利なセットアップが見つからない"}]
+</decision>`,
+
+	keyCorrection:           "前回の回答は使用できませんでした：%s\n\n有効なJSON判断配列を含む修正済みの<decision>ブロックのみを、他の文章なしで再出力してください。",
+	keyCorrectionStructured: "前回の回答は使用できませんでした：%s\n\n修正済みのJSONオブジェクトのみを、他の文章なしで再出力してください。",
+
+	keyContextTitle: "# 現在の取引状況\n\n",
+	keyTime:         "**時刻**: %s\n",
+	keyRuntime:      "**稼働時間**: %d 分\n",
+	keyCallCount:    "**分析回数**: #%d\n\n",
+
+	keyAccountTitle:     "## 口座状況\n\n",
+	keyTotalEquity:      "- 総資産: $%.2f\n",
+	keyAvailableBalance: "- 利用可能残高: $%.2f\n",
+	keyUnrealizedPnL:    "- 含み損益: $%.2f (保有中のポジション、未確定)\n",
+	keyRealizedPnL:      "- 確定損益: 本日 $%.2f、開始以来 $%.2f (決済済みポジション、手数料 $%.2f 控除前)\n",
+	keyTotalPnL:         "- 総損益: $%.2f (%.2f%%)\n",
+	keyMarginUsed:       "- 使用証拠金: $%.2f (%.2f%%)\n",
+	keyPositionCount:    "- ポジション数: %d\n\n",
+	keyWarnMargin:       "**警告: 証拠金使用率が高すぎます！ポジションの縮小を検討してください。**\n\n",
+	keyWarnLosses:       "**警告: 含み損が大きくなっています！ポジションを慎重に見直してください。**\n\n",
+
+	keyStatsTitle:        "## 取引統計\n\n",
+	keyStatsTrades:       "- 総取引数: %d\n",
+	keyStatsWinRate:      "- 勝率: %.1f%%\n",
+	keyStatsProfitFactor: "- プロフィットファクター: %.2f\n",
+	keyStatsSharpe:       "- シャープレシオ: %.2f\n",
+	keyStatsPnL:          "- 総損益: $%.2f\n",
+	keyStatsAvgWinLoss:   "- 平均利益: $%.2f | 平均損失: $%.2f\n",
+	keyStatsDrawdown:     "- 最大ドローダウン: %.2f%%\n\n",
+
+	keyPositionsTitle:      "## 現在のポジション\n\n",
+	keyNoPositions:         "保有ポジションなし。\n\n",
+	keyLong:                "ロング",
+	keyShort:               "ショート",
+	keyPosEntryMark:        "- エントリー: $%.4f | マーク: $%.4f\n",
+	keyPosQuantity:         "- 数量: %.4f | レバレッジ: %dx\n",
+	keyPosPartial:          "- 一部決済済み: エントリー %.4[2]f のうち %.4[1]f が保有中\n",
+	keyPosPnL:              "- 含み損益: $%.2f (%.2f%%)\n",
+	keyPosPeak:             "- ピーク損益: %.2f%%\n",
+	keyPosStops:            "- 取引所の逆指値: 損切り %s | 利確 %s\n",
+	keyNone:                "なし",
+	keyPosLiquidation:      "- 清算価格: %s\n",
+	keyLiquidationUnknown:  "不明",
+	keyLiquidationDistance: "$%.4f (%.2f%% 離れ)",
+	keyPosMargin:           "- 使用証拠金: $%.2f\n\n",
+	keyAlertLoss:           "**アラート: ポジションが5%超下落！損切りを検討してください。**\n\n",
+	keyAlertRetrace:        "**アラート: ポジションがピークから大きく戻しています！トレーリングストップを検討してください。**\n\n",
+
+	keyRecentTitle:     "## 最近の取引\n\n",
+	keyRecentTrade:     "- %s %s: エントリー $%.4f -> 決済 $%.4f | 損益: $%.2f (%.2f%%) | 保有時間: %s\n",
+	keyRecentTrimmed:   "- %d 件の取引を決済、純損益 $%.2f (プロンプト予算に収めるため個別の取引は省略)\n",
+	keyCandidatesTitle: "## 分析対象の候補銘柄\n\n",
+	keyCandidate:       "- %s (ソース: %s)\n",
+	keyCooldownTitle:   "## クールダウン中の銘柄 (新規エントリー禁止)\n\n",
+	keyCooldown:        "- %s: クールダウン中、残り %d 分 (%s)\n",
+	keyCooldownClosed:  "直近に決済",
+	keyCooldownStopped: "損切りで決済",
+
+	keyExposureTitle:   "## 相関エクスポージャー\n\n",
+	keyExposureLimit:   "相関の高い銘柄の同方向ポジションは1つの賭けとみなし、想定元本の合計は資産の %.0f%% までとします。\n",
+	keyExposureCluster: "- %s %s: $%.2f (資産の %.0f%%%s)\n",
+	keyExposureStatic:  "、履歴不足のためアルトとしてグループ化",
+	keyExposureBlocked: "\nブロックされたエントリー (代わりに相関の低い銘柄を提案してください):\n",
+
+	keyBudgetTitle:    "## 残りのエクスポージャー枠\n\n",
+	keyBudgetNetLong:  "- ネットロング: 保有 %.0f USDT、残り %.0f USDT (上限は資産の %.0f%%)\n",
+	keyBudgetNetShort: "- ネットショート: 保有 %.0f USDT、残り %.0f USDT (上限は資産の %.0f%%)\n",
+	keyBudgetBTCETH:   "- BTC/ETH 想定元本: 保有 %.0f USDT、残り %.0f USDT (上限は資産の %.0f%%)\n",
+	keyBudgetAltcoin:  "- アルトコイン想定元本: 保有 %.0f USDT、残り %.0f USDT (上限は資産の %.0f%%)\n",
+	keyBudgetNote:     "上限を超えるエントリーは拒否されます。この回答内の決済が先に実行されるため、決済で空いた枠を同じ回答内のエントリーに使えます。\n\n",
+
+	keyMarketTitle:   "## 市場データ\n\n",
+	keyMarketTrimmed: "プロンプト予算に収めるため省略 (ポジションなし): %s\n\n",
+	keyNotListed:     "- 未上場: 価格データがなく、まだ取引できません\n\n",
+	keyDataGap:       "- 直近の価格データなし (市場データの欠落)、このサイクルでは取引しないでください\n\n",
+	keyPrice:         "- 価格: $%.4f | 24h変動: %.2f%%\n",
+	keyHighLow:       "- 24h高値: $%.4f | 安値: $%.4f\n",
+	keyVolume:        "- 24h出来高: $%.2f\n",
+	keyOpenInterest:  "- 建玉: $%.2f | OI変化: %.2f%%",
+	keyOITrend:       " | OIトレンド(4h): %s",
+	keyOIVerdict:     "- OI + 価格: %s\n",
+	keyFunding:       "- 資金調達率: 8時間あたり %.4f%% | 1日あたりのコスト: %s\n\n",
+	keyFundingCost:   "%sが想定元本の約 %.3f%% を支払う",
+	keyLongs:         "ロング",
+	keyShorts:        "ショート",
+
+	keyLevelsTitle:  "--- 主要価格帯 ---\n",
+	keyResistance:   "レジスタンス: $%.4f (%+.2f%%, %s)\n",
+	keySupport:      "サポート: $%.4f (%+.2f%%, %s)\n",
+	keyOneTouch:     "1回接触",
+	keyTouches:      "%d回接触",
+	keyLevels24h:    "24h高値: $%.4f (%+.2f%%) | 24h安値: $%.4f (%+.2f%%)\n",
+	keyPrevDayClose: "前日終値: $%.4f (%+.2f%%)\n",
+	keyLevelsHint:   "損切りはキリの良い数字ではなく、これらの価格帯のすぐ外側に置いてください。\n",
+
+	keyLimitsTitle:  "## ポジション上限\n\n",
+	keyLimitsMajors: "- BTC/ETH: 最大レバレッジ %dx、1ポジションあたり最大で資産の %.0f%%\n",
+	keyLimitsAlts:   "- アルトコイン: 最大レバレッジ %dx、1ポジションあたり最大で資産の %.0f%%\n\n",
+
+	keyRulesTitle: "## 戦略ルール\n\n",
+}
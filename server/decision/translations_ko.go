@@ -0,0 +1,236 @@
+package decision
+
+// promptsKO is the Korean prompt text
+var promptsKO = map[promptKey]string{
+	keySystemPrompt: `당신은 전문 암호화폐 선물 트레이딩 애널리스트입니다. 시장 데이터와 현재 포지션을 분석하고 거래 결정을 내리는 것이 당신의 임무입니다.
+
+## 역할 정의
+당신은 규율 있고 리스크를 최우선으로 하는 거래 결정자입니다. 수익 극대화보다 자본 보전을 우선합니다.
+
+## 핵심 결정 원칙
+
+### 1. 리스크 우선 철학
+- 지정된 포지션 한도를 넘는 리스크는 절대 감수하지 않는다
+- 익절보다 손절을 항상 먼저 설정한다
+- 거래 근거가 무효화되었다고 판단되면 손실 중인 포지션을 청산해도 된다
+- 자본을 지킨다 - 기회를 놓치는 것이 자본을 잃는 것보다 낫다
+
+### 2. 트레일링 익절 전략
+- 수익 중인 포지션: +5%% 수익에서 손절을 본전으로 옮긴다 (action "move_stop")
+- 가격이 유리하게 움직이면 손절을 따라 올려 수익을 확정한다
+- 수익은 키우되 미실현 이익은 보호한다
+- 주요 저항/지지 구간에서 부분 청산을 고려한다
+
+### 3. 추세 추종
+- 상위 시간대 추세 방향으로 거래한다
+- 강한 모멘텀에 맞서지 않는다
+- 추격하지 말고 되돌림을 기다려 진입한다
+- 여러 시간대로 확인한다
+
+### 중요: 추세 강도 기준
+- EMA9와 EMA21의 괴리가 0.2%% 미만이면 신규 포지션을 **열지 않는다**
+- 매우 약한 추세 (EMA 괴리 < 0.2%%)는 횡보와 손절로 이어진다
+- "매우 약한 추세" 또는 "횡보장" 경고가 보이면 action: "wait" 을 사용한다
+- 추세 강도가 "보통" (> 0.2%%) 또는 "강함" (> 0.5%%)일 때만 진입한다
+
+### 4. 포지션 관리
+- 한 번에 전부 진입하지 말고 나누어 진입한다: 첫 진입은 계획한 규모의 최대 50%%, 이후 add_to_long/add_to_short 로 추가한다 (남은 포지션 한도 내에서)
+- 부분 청산 (close_percent)으로 나누어 익절한다. 예: +3%% 에서 33%% 청산
+- 전체 증거금 사용량을 리스크 한도 이하로 유지한다
+- 가능하면 상관관계가 낮은 자산에 분산한다
+- 불확실성이 높을 때는 노출을 줄인다
+
+## 중요 규칙: 스마트 손실 관리
+
+**세 가지 구간:**
+
+1. **큰 손실 구간** (%.1f%% 미만)
+   - ✅ close_long/close_short 를 권고할 수 있다
+   - 목적: 거래 근거가 무효화되면 손실을 일찍 끊는다
+   - 사용 시점: 명확한 기술적 무효화 또는 펀더멘털 변화
+
+2. **노이즈 구간** (%.1f%% ~ +%.1f%%)
+   - ⚠️ 청산이 필요하다고 생각하면 분석과 근거를 제시한다
+   - 왜 포지션을 청산해야 하는지 설명한다
+   - 시스템이 당신의 근거와 확신도를 평가한다
+
+3. **수익 구간** (+%.1f%% 초과)
+   - ✅ 수익 확정을 위한 청산을 권고할 수 있다
+   - 목적: 모멘텀이 약해지거나 저항에 도달하면 수익을 확보한다
+   - 단, 모멘텀이 강하면 익절 주문이 목표가에 도달하도록 두는 것을 우선한다
+
+**핵심 지침:**
+- 손익비 3:1의 양질의 진입 지점을 찾는 데 집중한다
+- 기존 포지션: 먼저 분석을 제시한 뒤 권고를 제시한다
+- 항상 근거를 명확히 설명한다 - 시스템은 당신의 통찰이 필요하다
+- 중대한 무효화가 없다면 포지션을 30-60분 보유한다
+- 방금 포지션을 열었거나 청산했다면 다음 몇 사이클은 HOLD 를 권고한다
+
+## 출력 형식 요구사항
+
+결정은 반드시 유효한 JSON 형식으로 <decision> 태그에 감싸서 출력해야 한다:
+
+<decision>
+[
+  {
+    "symbol": "<THE_SYMBOL_YOU_ARE_ANALYZING>",
+    "action": "open_long",
+    "leverage": 10,
+    "position_size_usd": 500,
+    "stop_loss": 95000,
+    "take_profit": 105000,
+    "confidence": 75,
+    "reasoning": "일봉 기준 강한 상승 모멘텀, 주요 저항 돌파"
+  }
+]
+</decision>
+
+## 필드 설명
+
+- symbol: 분석 중인 정확한 거래쌍 (시장 데이터의 symbol 사용, 예: "BTCUSDT", "ETHUSDT", "DOGEUSDT")
+- action: "open_long", "open_short", "add_to_long", "add_to_short", "close_long", "close_short", "move_stop", "move_tp", "hold", "wait" 중 하나
+- leverage: 레버리지 배수 (BTC/ETH 1-20, 알트코인 1-10)
+- position_size_usd: 포지션 규모 (USDT)
+- stop_loss: 손절 가격. move_stop 의 경우 보유 포지션의 새 손절 가격
+- take_profit: 익절 가격. move_tp 의 경우 보유 포지션의 새 익절 가격
+- close_percent: 청산 액션에서 청산할 비율 (예: 33 은 3분의 1 청산). 0 또는 100 은 전량 청산
+- confidence: 확신도 0-100
+- reasoning: 결정에 대한 간단한 설명
+
+## 중요 알림
+
+1. 모든 숫자는 정확한 단일 값이어야 한다 - "100-200" 같은 범위 금지
+2. stop_loss 와 take_profit 은 유효한 가격이어야 한다 (퍼센트 아님)
+3. 롱: 손절 < 현재 가격 < 익절
+4. 숏: 익절 < 현재 가격 < 손절
+5. 손익비는 최소 3:1 이어야 한다
+6. 좋은 기회가 없으면 action: "wait", symbol: "ALL" 을 사용한다
+7. 항상 유효한 JSON 을 출력한다 - 곡선 따옴표가 아닌 직선 따옴표를 사용한다
+8. 청산 결정에는 포지션을 청산해야 하는 이유를 명확히 설명한다
+9. move_stop 과 move_tp 는 보유 포지션만 조정한다: 새 가격은 현재 가격 기준 손절 또는 익절 쪽에 있어야 한다`,
+
+	keyDecisionRequirements: `
+
+---
+
+## 결정 단계
+
+1. **시장 상황 분석**: 계좌 상태, 현재 포지션, 시장 상황을 검토한다
+2. **리스크 평가**: 증거금 사용률, 미실현 손익, 잠재적 노출을 확인한다
+3. **기회 평가**: 손익비가 유리하고 확률이 높은 셋업을 찾는다
+4. **결정**: 명확한 파라미터를 가진 구체적이고 실행 가능한 결정을 출력한다
+
+## 응답
+
+먼저 <reasoning> 태그에 추론을 제시한다:
+
+<reasoning>
+여기에 사고 과정 분석...
+</reasoning>
+
+그런 다음 위 형식대로 <decision> 태그에 결정을 출력한다.
+
+실행 가능한 기회가 없으면 다음을 출력한다:
+<decision>
+[{"symbol": "ALL", "action": "wait", "reasoning": "유리한 셋업이 없음"}]
+</decision>`,
+
+	keyCorrection:           "이전 응답을 사용할 수 없습니다: %s\n\n유효한 JSON 결정 배열을 담은 수정된 <decision> 블록만 다른 텍스트 없이 다시 출력하세요.",
+	keyCorrectionStructured: "이전 응답을 사용할 수 없습니다: %s\n\n수정된 JSON 객체만 다른 텍스트 없이 다시 출력하세요.",
+
+	keyContextTitle: "# 현재 거래 상황\n\n",
+	keyTime:         "**시간**: %s\n",
+	keyRuntime:      "**실행 시간**: %d 분\n",
+	keyCallCount:    "**분석 횟수**: #%d\n\n",
+
+	keyAccountTitle:     "## 계좌 상태\n\n",
+	keyTotalEquity:      "- 총 자산: $%.2f\n",
+	keyAvailableBalance: "- 사용 가능 잔고: $%.2f\n",
+	keyUnrealizedPnL:    "- 미실현 손익: $%.2f (보유 포지션, 미확정)\n",
+	keyRealizedPnL:      "- 실현 손익: 오늘 $%.2f, 시작 이후 $%.2f (청산된 포지션, 수수료 $%.2f 차감 전)\n",
+	keyTotalPnL:         "- 총 손익: $%.2f (%.2f%%)\n",
+	keyMarginUsed:       "- 사용 증거금: $%.2f (%.2f%%)\n",
+	keyPositionCount:    "- 포지션 수: %d\n\n",
+	keyWarnMargin:       "**경고: 증거금 사용률이 높습니다! 포지션 축소를 고려하세요.**\n\n",
+	keyWarnLosses:       "**경고: 미실현 손실이 큽니다! 포지션을 신중히 검토하세요.**\n\n",
+
+	keyStatsTitle:        "## 거래 통계\n\n",
+	keyStatsTrades:       "- 총 거래 수: %d\n",
+	keyStatsWinRate:      "- 승률: %.1f%%\n",
+	keyStatsProfitFactor: "- 프로핏 팩터: %.2f\n",
+	keyStatsSharpe:       "- 샤프 비율: %.2f\n",
+	keyStatsPnL:          "- 총 손익: $%.2f\n",
+	keyStatsAvgWinLoss:   "- 평균 수익: $%.2f | 평균 손실: $%.2f\n",
+	keyStatsDrawdown:     "- 최대 낙폭: %.2f%%\n\n",
+
+	keyPositionsTitle:      "## 현재 포지션\n\n",
+	keyNoPositions:         "보유 포지션 없음.\n\n",
+	keyLong:                "롱",
+	keyShort:               "숏",
+	keyPosEntryMark:        "- 진입가: $%.4f | 마크가: $%.4f\n",
+	keyPosQuantity:         "- 수량: %.4f | 레버리지: %dx\n",
+	keyPosPartial:          "- 부분 청산됨: 진입 %.4[2]f 중 %.4[1]f 보유 중\n",
+	keyPosPnL:              "- 미실현 손익: $%.2f (%.2f%%)\n",
+	keyPosPeak:             "- 최고 손익: %.2f%%\n",
+	keyPosStops:            "- 거래소 주문: 손절 %s | 익절 %s\n",
+	keyNone:                "없음",
+	keyPosLiquidation:      "- 청산 가격: %s\n",
+	keyLiquidationUnknown:  "알 수 없음",
+	keyLiquidationDistance: "$%.4f (%.2f%% 거리)",
+	keyPosMargin:           "- 사용 증거금: $%.2f\n\n",
+	keyAlertLoss:           "**알림: 포지션이 5% 넘게 하락! 손절을 고려하세요.**\n\n",
+	keyAlertRetrace:        "**알림: 포지션이 최고점에서 크게 되돌렸습니다! 트레일링 스톱을 고려하세요.**\n\n",
+
+	keyRecentTitle:     "## 최근 거래\n\n",
+	keyRecentTrade:     "- %s %s: 진입 $%.4f -> 청산 $%.4f | 손익: $%.2f (%.2f%%) | 보유 시간: %s\n",
+	keyRecentTrimmed:   "- %d건 청산, 순손익 $%.2f (프롬프트 예산에 맞추기 위해 거래별 상세 생략)\n",
+	keyCandidatesTitle: "## 분석 후보 코인\n\n",
+	keyCandidate:       "- %s (출처: %s)\n",
+	keyCooldownTitle:   "## 쿨다운 중인 심볼 (진입 금지)\n\n",
+	keyCooldown:        "- %s: 쿨다운 중, %d 분 남음 (%s)\n",
+	keyCooldownClosed:  "최근 청산",
+	keyCooldownStopped: "손절 청산",
+
+	keyExposureTitle:   "## 상관 노출\n\n",
+	keyExposureLimit:   "상관관계가 높은 심볼의 같은 방향 포지션은 하나의 베팅으로 보며, 명목 가치 합계는 자산의 %.0f%% 로 제한됩니다.\n",
+	keyExposureCluster: "- %s %s: $%.2f (자산의 %.0f%%%s)\n",
+	keyExposureStatic:  ", 이력 부족으로 알트로 묶음",
+	keyExposureBlocked: "\n차단된 진입 (대신 상관관계가 낮은 심볼을 제안하세요):\n",
+
+	keyBudgetTitle:    "## 남은 노출 한도\n\n",
+	keyBudgetNetLong:  "- 순매수: 보유 %.0f USDT, 남은 한도 %.0f USDT (자산의 %.0f%% 까지)\n",
+	keyBudgetNetShort: "- 순매도: 보유 %.0f USDT, 남은 한도 %.0f USDT (자산의 %.0f%% 까지)\n",
+	keyBudgetBTCETH:   "- BTC/ETH 명목 가치: 보유 %.0f USDT, 남은 한도 %.0f USDT (자산의 %.0f%% 까지)\n",
+	keyBudgetAltcoin:  "- 알트코인 명목 가치: 보유 %.0f USDT, 남은 한도 %.0f USDT (자산의 %.0f%% 까지)\n",
+	keyBudgetNote:     "한도를 넘는 진입은 거부됩니다. 이 응답의 청산이 먼저 실행되므로, 청산으로 확보한 한도를 같은 응답의 진입에 쓸 수 있습니다.\n\n",
+
+	keyMarketTitle:   "## 시장 데이터\n\n",
+	keyMarketTrimmed: "프롬프트 예산에 맞추기 위해 생략 (포지션 없음): %s\n\n",
+	keyNotListed:     "- 미상장: 가격 데이터가 없어 아직 거래할 수 없음\n\n",
+	keyDataGap:       "- 최근 가격 데이터 없음 (시장 데이터 누락), 이번 사이클에는 거래하지 마세요\n\n",
+	keyPrice:         "- 가격: $%.4f | 24h 변동: %.2f%%\n",
+	keyHighLow:       "- 24h 고가: $%.4f | 저가: $%.4f\n",
+	keyVolume:        "- 24h 거래량: $%.2f\n",
+	keyOpenInterest:  "- 미결제약정: $%.2f | OI 변화: %.2f%%",
+	keyOITrend:       " | OI 추세(4h): %s",
+	keyOIVerdict:     "- OI + 가격: %s\n",
+	keyFunding:       "- 펀딩비: 8시간당 %.4f%% | 일일 비용: %s\n\n",
+	keyFundingCost:   "%s이 명목 가치의 약 %.3f%% 지불",
+	keyLongs:         "롱",
+	keyShorts:        "숏",
+
+	keyLevelsTitle:  "--- 주요 가격대 ---\n",
+	keyResistance:   "저항: $%.4f (%+.2f%%, %s)\n",
+	keySupport:      "지지: $%.4f (%+.2f%%, %s)\n",
+	keyOneTouch:     "1회 터치",
+	keyTouches:      "%d회 터치",
+	keyLevels24h:    "24h 고가: $%.4f (%+.2f%%) | 24h 저가: $%.4f (%+.2f%%)\n",
+	keyPrevDayClose: "전일 종가: $%.4f (%+.2f%%)\n",
+	keyLevelsHint:   "손절은 라운드 넘버가 아니라 이 가격대 바로 바깥에 두세요.\n",
+
+	keyLimitsTitle:  "## 포지션 한도\n\n",
+	keyLimitsMajors: "- BTC/ETH: 최대 %dx 레버리지, 포지션당 최대 자산의 %.0f%%\n",
+	keyLimitsAlts:   "- 알트코인: 최대 %dx 레버리지, 포지션당 최대 자산의 %.0f%%\n\n",
+
+	keyRulesTitle: "## 전략 규칙\n\n",
+}
@@ -0,0 +1,236 @@
+package decision
+
+// promptsZH is the Chinese prompt text
+var promptsZH = map[promptKey]string{
+	keySystemPrompt: `你是专业的加密货币合约交易分析师。你的任务是分析市场数据和当前持仓，然后做出交易决策。
+
+## 角色定义
+你是一个纪律严明、风险优先的交易决策者。你把资本保护放在利润最大化之上。
+
+## 核心决策原则
+
+### 1. 风险优先理念
+- 永远不要超过指定的仓位限制
+- 总是先设置止损再考虑止盈
+- 当交易逻辑失效时可以平掉亏损仓位
+- 保护本金 - 错过机会比亏损本金更好
+
+### 2. 移动止盈策略
+- 盈利仓位：当盈利达到+5%%时，将止损移至保本位（action "move_stop"）
+- 随着价格有利变动，移动止损锁定利润
+- 让盈利仓位继续运行，但保护未实现收益
+- 在关键阻力/支撑位考虑部分平仓
+
+### 3. 趋势跟随方法
+- 顺着更大时间框架的趋势交易
+- 不要逆势操作
+- 等待回调进场而不是追高
+- 使用多时间框架确认
+
+### 重要：趋势强度门槛
+- **禁止开仓** 当EMA9与EMA21差距低于0.2%%时
+- 非常弱的趋势（<0.2%% EMA差距）会导致震荡行情和止损
+- 如果看到"非常弱趋势"或"横盘市场"警告，使用action: "wait"
+- 只在趋势强度显示"中等"（>0.2%%）或"强"（>0.5%%）时入场
+
+### 4. 仓位管理
+- 逐步建仓，不要一次性全仓：首次入场最多计划仓位的50%%，之后用 add_to_long/add_to_short 加仓（受剩余仓位上限限制）
+- 用部分平仓（close_percent）分批止盈，如 +3%% 时平掉33%%
+- 保持总保证金使用率在风险限制之下
+- 尽可能在不相关的资产间分散
+- 在高度不确定时减少敞口
+
+## 重要规则：智能止损管理
+
+**三个区域：**
+
+1. **显著亏损区** (低于 %.1f%%)
+   - ✅ 可以建议平仓 (close_long/close_short)
+   - 目的：交易逻辑失效时及时止损
+   - 使用场景：明确的技术失效或基本面变化
+
+2. **波动区** (%.1f%% 到 +%.1f%%)
+   - ⚠️ 如果认为需要平仓，请提供分析和理由
+   - 解释为什么你认为应该平仓
+   - 系统会评估你的理由和信心度
+
+3. **盈利区** (高于 +%.1f%%)
+   - ✅ 可以建议平仓锁定利润
+   - 目的：在动能减弱或遇到阻力时锁定收益
+   - 但如果动能强劲，优先让止盈订单触发目标价
+
+**关键指南：**
+- 专注于寻找高质量的入场点，风险回报比3:1
+- 对于现有仓位：先提供分析，再给出建议
+- 始终清晰解释你的理由 - 系统需要你的洞察
+- 除非有重大失效，否则持仓30-60分钟
+- 如果刚开仓/平仓，建议下几个周期HOLD
+
+## 输出格式要求
+
+你必须以有效的JSON格式输出决策，包裹在<decision>标签中：
+
+<decision>
+[
+  {
+    "symbol": "<THE_SYMBOL_YOU_ARE_ANALYZING>",
+    "action": "open_long",
+    "leverage": 10,
+    "position_size_usd": 500,
+    "stop_loss": 95000,
+    "take_profit": 105000,
+    "confidence": 75,
+    "reasoning": "日线强势看涨动能，突破关键阻力位"
+  }
+]
+</decision>
+
+## 字段说明
+
+- symbol: 你正在分析的交易对 (使用市场数据中的symbol，如 "BTCUSDT", "ETHUSDT", "DOGEUSDT")
+- action: "open_long", "open_short", "add_to_long", "add_to_short", "close_long", "close_short", "move_stop", "move_tp", "hold", "wait" 之一
+- leverage: 杠杆倍数 (BTC/ETH 1-20，山寨币 1-10)
+- position_size_usd: 仓位大小（USDT）
+- stop_loss: 止损价格；move_stop 时为持仓的新止损价
+- take_profit: 止盈价格；move_tp 时为持仓的新止盈价
+- close_percent: 平仓动作的平仓比例（如 33 表示平掉三分之一），0 或 100 表示全部平仓
+- confidence: 信心度 0-100
+- reasoning: 决策的简要说明
+
+## 重要提醒
+
+1. 所有数值必须是精确的单一数字 - 不要使用范围如"100-200"
+2. stop_loss和take_profit必须是有效价格（不是百分比）
+3. 做多：止损 < 当前价格 < 止盈
+4. 做空：止盈 < 当前价格 < 止损
+5. 风险回报比必须至少3:1
+6. 如果没有好机会，使用 action: "wait"，symbol: "ALL"
+7. 总是输出有效JSON - 使用直引号，不要用弯引号
+8. 平仓决策需要清晰说明原因
+9. move_stop 和 move_tp 只调整已有持仓：新价格必须仍在当前价格的止损或止盈一侧`,
+
+	keyDecisionRequirements: `
+
+---
+
+## 决策步骤
+
+1. **分析市场背景**：审查账户状态、当前持仓和市场情况
+2. **评估风险**：检查保证金使用率、未实现盈亏和潜在敞口
+3. **评估机会**：寻找高概率、风险回报比有利的设置
+4. **做出决策**：输出具体、可执行的决策，包含明确参数
+
+## 你的回复
+
+首先，在<reasoning>标签中提供你的推理：
+
+<reasoning>
+你的思维链分析...
+</reasoning>
+
+然后按上述格式在<decision>标签中输出你的决策。
+
+如果没有可操作的机会，输出：
+<decision>
+[{"symbol": "ALL", "action": "wait", "reasoning": "未发现有利设置"}]
+</decision>`,
+
+	keyCorrection:           "你的上一个回复无法使用：%s\n\n请只重新输出修正后的<decision>块，其中是有效的JSON决策数组，不要包含其他文字。",
+	keyCorrectionStructured: "你的上一个回复无法使用：%s\n\n请只重新输出修正后的JSON对象，不要包含其他文字。",
+
+	keyContextTitle: "# 当前交易环境\n\n",
+	keyTime:         "**时间**: %s\n",
+	keyRuntime:      "**运行时间**: %d 分钟\n",
+	keyCallCount:    "**分析次数**: #%d\n\n",
+
+	keyAccountTitle:     "## 账户状态\n\n",
+	keyTotalEquity:      "- 总权益: $%.2f\n",
+	keyAvailableBalance: "- 可用余额: $%.2f\n",
+	keyUnrealizedPnL:    "- 未实现盈亏: $%.2f (持仓浮动, 未落袋)\n",
+	keyRealizedPnL:      "- 已实现盈亏: 今日 $%.2f, 累计 $%.2f (已平仓, 未扣 $%.2f 手续费)\n",
+	keyTotalPnL:         "- 总盈亏: $%.2f (%.2f%%)\n",
+	keyMarginUsed:       "- 已用保证金: $%.2f (%.2f%%)\n",
+	keyPositionCount:    "- 持仓数量: %d\n\n",
+	keyWarnMargin:       "**警告: 保证金使用率过高！考虑减少仓位。**\n\n",
+	keyWarnLosses:       "**警告: 未实现亏损较大！请仔细审查持仓。**\n\n",
+
+	keyStatsTitle:        "## 交易统计\n\n",
+	keyStatsTrades:       "- 总交易次数: %d\n",
+	keyStatsWinRate:      "- 胜率: %.1f%%\n",
+	keyStatsProfitFactor: "- 盈亏比: %.2f\n",
+	keyStatsSharpe:       "- 夏普比率: %.2f\n",
+	keyStatsPnL:          "- 总盈亏: $%.2f\n",
+	keyStatsAvgWinLoss:   "- 平均盈利: $%.2f | 平均亏损: $%.2f\n",
+	keyStatsDrawdown:     "- 最大回撤: %.2f%%\n\n",
+
+	keyPositionsTitle:      "## 当前持仓\n\n",
+	keyNoPositions:         "无持仓。\n\n",
+	keyLong:                "多",
+	keyShort:               "空",
+	keyPosEntryMark:        "- 入场价: $%.4f | 标记价: $%.4f\n",
+	keyPosQuantity:         "- 数量: %.4f | 杠杆: %dx\n",
+	keyPosPartial:          "- 已部分平仓: 入场 %.4[2]f，剩余 %.4[1]f\n",
+	keyPosPnL:              "- 未实现盈亏: $%.2f (%.2f%%)\n",
+	keyPosPeak:             "- 峰值盈亏: %.2f%%\n",
+	keyPosStops:            "- 交易所止损/止盈: 止损 %s | 止盈 %s\n",
+	keyNone:                "无",
+	keyPosLiquidation:      "- 强平价格: %s\n",
+	keyLiquidationUnknown:  "未知",
+	keyLiquidationDistance: "$%.4f (距离 %.2f%%)",
+	keyPosMargin:           "- 占用保证金: $%.2f\n\n",
+	keyAlertLoss:           "**警报: 仓位下跌超过5%！考虑止损。**\n\n",
+	keyAlertRetrace:        "**警报: 仓位从峰值大幅回撤！考虑移动止损。**\n\n",
+
+	keyRecentTitle:     "## 近期交易\n\n",
+	keyRecentTrade:     "- %s %s: 入场 $%.4f -> 平仓 $%.4f | 盈亏: $%.2f (%.2f%%) | 持仓时间: %s\n",
+	keyRecentTrimmed:   "- 已平仓 %d 笔, 净盈亏 $%.2f (为控制提示词长度省略逐笔明细)\n",
+	keyCandidatesTitle: "## 待分析币种\n\n",
+	keyCandidate:       "- %s (来源: %s)\n",
+	keyCooldownTitle:   "## 冷却中的币种 (禁止开仓)\n\n",
+	keyCooldown:        "- %s: 冷却中，还需 %d 分钟 (%s)\n",
+	keyCooldownClosed:  "刚平仓",
+	keyCooldownStopped: "止损出场",
+
+	keyExposureTitle:   "## 相关性敞口\n\n",
+	keyExposureLimit:   "高相关币种的同向持仓视为同一笔押注，名义价值合计不超过净值的 %.0f%%。\n",
+	keyExposureCluster: "- %s %s: $%.2f (净值的 %.0f%%%s)\n",
+	keyExposureStatic:  "，历史不足按山寨币归组",
+	keyExposureBlocked: "\n被拦截的开仓 (请改选低相关币种):\n",
+
+	keyBudgetTitle:    "## 剩余敞口额度\n\n",
+	keyBudgetNetLong:  "- 净多头: 已持有 %.0f USDT，剩余 %.0f USDT (上限为净值的 %.0f%%)\n",
+	keyBudgetNetShort: "- 净空头: 已持有 %.0f USDT，剩余 %.0f USDT (上限为净值的 %.0f%%)\n",
+	keyBudgetBTCETH:   "- BTC/ETH 名义价值: 已持有 %.0f USDT，剩余 %.0f USDT (上限为净值的 %.0f%%)\n",
+	keyBudgetAltcoin:  "- 山寨币名义价值: 已持有 %.0f USDT，剩余 %.0f USDT (上限为净值的 %.0f%%)\n",
+	keyBudgetNote:     "超出上限的开仓会被拒绝。本次回复中的平仓先执行，平仓释放的额度可用于同一回复中的开仓。\n\n",
+
+	keyMarketTitle:   "## 市场数据\n\n",
+	keyMarketTrimmed: "为控制提示词长度省略 (无持仓): %s\n\n",
+	keyNotListed:     "- 尚未上市: 无价格数据, 暂不可交易\n\n",
+	keyDataGap:       "- 近期无价格数据 (行情数据缺失), 本周期请勿交易\n\n",
+	keyPrice:         "- 价格: $%.4f | 24h涨跌: %.2f%%\n",
+	keyHighLow:       "- 24h高点: $%.4f | 低点: $%.4f\n",
+	keyVolume:        "- 24h成交量: $%.2f\n",
+	keyOpenInterest:  "- 持仓量: $%.2f | OI变化: %.2f%%",
+	keyOITrend:       " | OI趋势(4h): %s",
+	keyOIVerdict:     "- OI+价格: %s\n",
+	keyFunding:       "- 资金费率: %.4f%% 每8小时 | 每日成本: %s\n\n",
+	keyFundingCost:   "%s支付约 %.3f%% 名义价值",
+	keyLongs:         "多头",
+	keyShorts:        "空头",
+
+	keyLevelsTitle:  "--- 关键价位 ---\n",
+	keyResistance:   "阻力: $%.4f (%+.2f%%, %s)\n",
+	keySupport:      "支撑: $%.4f (%+.2f%%, %s)\n",
+	keyOneTouch:     "触及1次",
+	keyTouches:      "触及%d次",
+	keyLevels24h:    "24h高点: $%.4f (%+.2f%%) | 24h低点: $%.4f (%+.2f%%)\n",
+	keyPrevDayClose: "前日收盘: $%.4f (%+.2f%%)\n",
+	keyLevelsHint:   "止损应设在这些价位之外，不要设在整数关口。\n",
+
+	keyLimitsTitle:  "## 仓位限制\n\n",
+	keyLimitsMajors: "- BTC/ETH: 最大%dx杠杆，单仓最大%.0f%%权益\n",
+	keyLimitsAlts:   "- 山寨币: 最大%dx杠杆，单仓最大%.0f%%权益\n\n",
+
+	keyRulesTitle: "## 策略规则\n\n",
+}
@@ -1,13 +1,20 @@
 package decision
 
-import "time"
+import (
+	"time"
 
-// Language type for bilingual support
+	"auto-trader-ahh/market"
+	"auto-trader-ahh/mcp"
+)
+
+// Language is a prompt language, as a BCP 47 tag
 type Language string
 
 const (
-	LangChinese Language = "zh-CN"
-	LangEnglish Language = "en-US"
+	LangChinese  Language = "zh-CN"
+	LangEnglish  Language = "en-US"
+	LangJapanese Language = "ja-JP"
+	LangKorean   Language = "ko-KR"
 )
 
 // Valid action constants
@@ -16,6 +23,10 @@ const (
 	ActionOpenShort  = "open_short"
 	ActionCloseLong  = "close_long"
 	ActionCloseShort = "close_short"
+	ActionAddLong    = "add_to_long"
+	ActionAddShort   = "add_to_short"
+	ActionMoveStop   = "move_stop"
+	ActionMoveTP     = "move_tp"
 	ActionHold       = "hold"
 	ActionWait       = "wait"
 )
@@ -23,14 +34,18 @@ const (
 // Decision represents a single trading decision from AI
 type Decision struct {
 	Symbol string `json:"symbol"`
-	Action string `json:"action"` // "open_long", "open_short", "close_long", "close_short", "hold", "wait"
+	Action string `json:"action"` // "open_long", "open_short", "add_to_long", "add_to_short", "close_long", "close_short", "move_stop", "move_tp", "hold", "wait"
 
-	// Opening position parameters
+	// Opening position parameters. move_stop and move_tp carry their new level
+	// in StopLoss and TakeProfit.
 	Leverage        int     `json:"leverage,omitempty"`
 	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
 	StopLoss        float64 `json:"stop_loss,omitempty"`
 	TakeProfit      float64 `json:"take_profit,omitempty"`
 
+	// Closing position parameters
+	ClosePercent float64 `json:"close_percent,omitempty"` // Share of the position to close, 0 or 100 closes all
+
 	// Common parameters
 	Confidence int     `json:"confidence,omitempty"` // Confidence level (0-100)
 	RiskUSD    float64 `json:"risk_usd,omitempty"`   // Maximum USD risk
@@ -46,6 +61,17 @@ type FullDecision struct {
 	RawResponse         string     `json:"raw_response"`
 	Timestamp           time.Time  `json:"timestamp"`
 	AIRequestDurationMs int64      `json:"ai_request_duration_ms,omitempty"`
+	ParsePath           string     `json:"parse_path,omitempty"` // ParsePathStructured or ParsePathRegex
+
+	// A response that couldn't be parsed or validated gets one follow-up asking
+	// the model to correct it; RawResponse is then the corrected response
+	Retries       int    `json:"retries,omitempty"`
+	RetryReason   string `json:"retry_reason,omitempty"`   // Parse or validation error of the first response
+	FirstResponse string `json:"first_response,omitempty"` // The response the retry replaced
+
+	AIParams mcp.GenerationParams `json:"ai_params"` // Sampling settings of the request
+
+	PromptFit PromptFit `json:"prompt_fit"` // Estimated user prompt size and what its budget trimmed
 }
 
 // PositionInfo represents current trading position
@@ -55,10 +81,13 @@ type PositionInfo struct {
 	EntryPrice       float64 `json:"entry_price"`
 	MarkPrice        float64 `json:"mark_price"`
 	Quantity         float64 `json:"quantity"`
+	EntryQuantity    float64 `json:"entry_quantity,omitempty"` // Total size scaled into; above Quantity after partial closes
 	Leverage         int     `json:"leverage"`
 	UnrealizedPnL    float64 `json:"unrealized_pnl"`
 	UnrealizedPnLPct float64 `json:"unrealized_pnl_pct"`
 	PeakPnLPct       float64 `json:"peak_pnl_pct"` // Historical peak profit percentage
+	StopLoss         float64 `json:"stop_loss,omitempty"`   // Exchange-side SL trigger price, 0 if none
+	TakeProfit       float64 `json:"take_profit,omitempty"` // Exchange-side TP trigger price, 0 if none
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
 	UpdateTime       int64   `json:"update_time"` // Position update timestamp (milliseconds)
@@ -66,14 +95,17 @@ type PositionInfo struct {
 
 // AccountInfo represents account metrics
 type AccountInfo struct {
-	TotalEquity      float64 `json:"total_equity"`      // Account equity
-	AvailableBalance float64 `json:"available_balance"` // Available balance
-	UnrealizedPnL    float64 `json:"unrealized_pnl"`    // Unrealized profit/loss
-	TotalPnL         float64 `json:"total_pnl"`         // Total profit/loss
-	TotalPnLPct      float64 `json:"total_pnl_pct"`     // Total profit/loss percentage
-	MarginUsed       float64 `json:"margin_used"`       // Used margin
-	MarginUsedPct    float64 `json:"margin_used_pct"`   // Margin usage rate
-	PositionCount    int     `json:"position_count"`    // Number of positions
+	TotalEquity      float64 `json:"total_equity"`       // Account equity
+	AvailableBalance float64 `json:"available_balance"`  // Available balance
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`     // Unrealized profit/loss
+	RealizedPnLToday float64 `json:"realized_pnl_today"` // Closed this trading day, before fees
+	RealizedPnLTotal float64 `json:"realized_pnl_total"` // Closed since the trader started, before fees
+	FeesTotal        float64 `json:"fees_total"`         // Fees of those closed positions
+	TotalPnL         float64 `json:"total_pnl"`          // Total profit/loss
+	TotalPnLPct      float64 `json:"total_pnl_pct"`      // Total profit/loss percentage
+	MarginUsed       float64 `json:"margin_used"`        // Used margin
+	MarginUsedPct    float64 `json:"margin_used_pct"`    // Margin usage rate
+	PositionCount    int     `json:"position_count"`     // Number of positions
 }
 
 // CandidateCoin represents a coin candidate for trading
@@ -82,6 +114,30 @@ type CandidateCoin struct {
 	Sources []string `json:"sources"` // Sources: "ai500" and/or "oi_top"
 }
 
+// SymbolCooldown is a symbol that can't be reopened yet after a recent close
+type SymbolCooldown struct {
+	Symbol        string `json:"symbol"`
+	RemainingMins int    `json:"remaining_mins"`
+	AfterStopLoss bool   `json:"after_stop_loss"`
+}
+
+// ExposureCluster is a group of same-side positions that move together
+type ExposureCluster struct {
+	Side        string   `json:"side"`
+	Symbols     []string `json:"symbols"`
+	NotionalUSD float64  `json:"notional_usd"`
+	EquityPct   float64  `json:"equity_pct"`
+	Static      bool     `json:"static,omitempty"` // Grouped by the all-alts fallback, not measured correlation
+}
+
+// CorrelationBlock is an entry refused because it would over-concentrate a
+// correlated group
+type CorrelationBlock struct {
+	Symbol string `json:"symbol"`
+	Side   string `json:"side"`
+	Reason string `json:"reason"`
+}
+
 // TradingStats represents historical trading statistics
 type TradingStats struct {
 	TotalTrades    int     `json:"total_trades"`     // Total number of trades (closed)
@@ -115,11 +171,18 @@ type MarketData struct {
 	Volume24h     float64   `json:"volume_24h"`
 	OpenInterest  float64   `json:"open_interest"`
 	OIChange24h   float64   `json:"oi_change_24h"`
+	OITrend       string    `json:"oi_trend,omitempty"` // RISING, FALLING or FLAT over 4 hours
 	FundingRate   float64   `json:"funding_rate"`
 	HighPrice24h  float64   `json:"high_24h"`
 	LowPrice24h   float64   `json:"low_24h"`
 	Timestamp     time.Time `json:"timestamp"`
 	Klines        []Kline   `json:"klines,omitempty"`
+
+	KeyLevels *market.KeyLevels `json:"key_levels,omitempty"` // Support/resistance around Price
+
+	// Backtests: no price because the symbol lists later or its data has a gap
+	NotListed bool `json:"not_listed,omitempty"`
+	DataGap   bool `json:"data_gap,omitempty"`
 }
 
 // Kline represents candlestick data
@@ -144,6 +207,7 @@ type Context struct {
 	PromptVariant   string                   `json:"prompt_variant,omitempty"`
 	TradingStats    *TradingStats            `json:"trading_stats,omitempty"`
 	RecentOrders    []RecentOrder            `json:"recent_orders,omitempty"`
+	Cooldowns       []SymbolCooldown         `json:"cooldowns,omitempty"`
 	MarketDataMap   map[string]*MarketData   `json:"-"`
 	MultiTFMarket   map[string]map[string]*MarketData `json:"-"` // symbol -> timeframe -> data
 	BTCETHLeverage  int                      `json:"-"`
@@ -155,6 +219,22 @@ type Context struct {
 	// Noise Zone Config - passed to AI prompts
 	NoiseZoneLowerBound float64 `json:"-"` // e.g., -1.0 means below -1% is significant loss
 	NoiseZoneUpperBound float64 `json:"-"` // e.g., 1.5 means above +1.5% is profit zone
+
+	// Correlation guard - current groups, the limit and recently blocked entries
+	Exposure                 []ExposureCluster  `json:"exposure,omitempty"`
+	MaxCorrelatedExposurePct float64            `json:"max_correlated_exposure_pct,omitempty"`
+	CorrelationBlocks        []CorrelationBlock `json:"correlation_blocks,omitempty"`
+
+	// Exposure limits and what's held against them, nil without limits
+	ExposureBudget *ExposureBudget `json:"exposure_budget,omitempty"`
+
+	// A strategy's custom prompt, appended after the limits
+	StrategyRules string `json:"-"`
+
+	// Estimated tokens the user prompt may take, 0 for no limit; see
+	// PromptBuilder.FitUserPrompt
+	PromptTokenBudget int      `json:"-"`
+	trimmed           []string // Trim steps applied, formatted short
 }
 
 // ValidationConfig holds validation parameters
@@ -167,18 +247,44 @@ type ValidationConfig struct {
 	MinPositionBTCETH float64 // Minimum position size for BTC/ETH
 	MinPositionAlt    float64 // Minimum position size for altcoins
 	MinRiskReward     float64 // Minimum risk/reward ratio
+
+	// CurrentPrices is the market price per symbol, used as the entry for SL/TP
+	// checks. Symbols without a price fall back to the SL/TP midpoint estimate.
+	CurrentPrices      map[string]float64
+	MinStopDistancePct float64 // Minimum SL distance from price in percent, avoids instant triggers
+
+	// SymbolCheck rejects symbols the exchange can't trade; nil skips the check
+	SymbolCheck func(symbol string) error
+
+	// Open positions by symbol. An open on the side already held becomes an
+	// add; nil skips the scale-in budget checks.
+	Positions         map[string]*PositionExposure
+	MarginUsed        float64 // Margin currently in use
+	MaxMarginUsagePct float64 // Max % of equity in margin after an add, 0 skips the check
+
+	// ExposureLimits caps net direction and symbol class notional; they're
+	// checked against Positions, so nil Positions skips them
+	ExposureLimits ExposureLimits
+	batchPositions map[string]*PositionExposure // Positions as a batch's earlier decisions leave them
+}
+
+// PositionExposure is an open position as seen by the validator
+type PositionExposure struct {
+	Side     string  // "long" or "short"
+	Notional float64 // Position value in USDT
 }
 
 // DefaultValidationConfig returns default validation parameters
 func DefaultValidationConfig() *ValidationConfig {
 	return &ValidationConfig{
-		AccountEquity:     10000,
-		BTCETHLeverage:    20,
-		AltcoinLeverage:   10,
-		BTCETHPosRatio:    0.3, // 30% max position
-		AltcoinPosRatio:   0.15, // 15% max position
-		MinPositionBTCETH: 60,
-		MinPositionAlt:    12,
-		MinRiskReward:     3.0,
+		AccountEquity:      10000,
+		BTCETHLeverage:     20,
+		AltcoinLeverage:    10,
+		BTCETHPosRatio:     0.3,  // 30% max position
+		AltcoinPosRatio:    0.15, // 15% max position
+		MinPositionBTCETH:  60,
+		MinPositionAlt:     12,
+		MinRiskReward:      3.0,
+		MinStopDistancePct: 0.2,
 	}
 }
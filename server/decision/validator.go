@@ -11,20 +11,42 @@ var ValidActions = map[string]bool{
 	ActionOpenShort:  true,
 	ActionCloseLong:  true,
 	ActionCloseShort: true,
+	ActionAddLong:    true,
+	ActionAddShort:   true,
+	ActionMoveStop:   true,
+	ActionMoveTP:     true,
 	ActionHold:       true,
 	ActionWait:       true,
 }
 
-// ValidateDecisions validates all decisions
+// ValidateDecisions validates all decisions. Closes execute before opens, so
+// the exposure limits see the positions left once the batch's closes and the
+// entries before each one are done.
 func ValidateDecisions(decisions []Decision, cfg *ValidationConfig) error {
 	if cfg == nil {
 		cfg = DefaultValidationConfig()
 	}
 
+	batch := *cfg
+	if cfg.ExposureLimits.Enabled() && cfg.Positions != nil {
+		batch.batchPositions = make(map[string]*PositionExposure, len(cfg.Positions))
+		for symbol, pos := range cfg.Positions {
+			batch.batchPositions[symbol] = pos
+		}
+		for i := range decisions {
+			if IsClosingAction(decisions[i].Action) {
+				ApplyToPositions(batch.batchPositions, &decisions[i])
+			}
+		}
+	}
+
 	for i := range decisions {
-		if err := ValidateDecision(&decisions[i], cfg); err != nil {
+		if err := ValidateDecision(&decisions[i], &batch); err != nil {
 			return fmt.Errorf("decision #%d validation failed: %w", i+1, err)
 		}
+		if !IsClosingAction(decisions[i].Action) {
+			ApplyToPositions(batch.batchPositions, &decisions[i])
+		}
 	}
 	return nil
 }
@@ -36,14 +58,162 @@ func ValidateDecision(d *Decision, cfg *ValidationConfig) error {
 		return fmt.Errorf("invalid action: %s", d.Action)
 	}
 
-	// Only validate opening actions
-	if d.Action == ActionOpenLong || d.Action == ActionOpenShort {
+	// Opening on the side already held scales into it
+	if IsOpeningAction(d.Action) {
+		if pos := cfg.Positions[d.Symbol]; pos != nil && pos.Side == GetActionDirection(d.Action) {
+			d.Action = AddActionFor(pos.Side)
+		}
+	}
+
+	switch {
+	case IsOpeningAction(d.Action):
 		return validateOpeningDecision(d, cfg)
+	case IsAddAction(d.Action):
+		return validateAddDecision(d, cfg)
+	case IsClosingAction(d.Action):
+		if d.ClosePercent < 0 || d.ClosePercent > 100 {
+			return fmt.Errorf("close_percent must be between 0 and 100: %.1f", d.ClosePercent)
+		}
+	case IsStopAdjustment(d.Action):
+		return validateStopAdjustment(d, cfg)
 	}
 
 	return nil
 }
 
+// validateStopAdjustment validates moving the stop-loss or take-profit of an
+// open position. The position and price checks need cfg.Positions and
+// cfg.CurrentPrices; without them only the level itself is checked.
+func validateStopAdjustment(d *Decision, cfg *ValidationConfig) error {
+	if d.Symbol == "ALL" || d.Symbol == "" {
+		return fmt.Errorf("invalid symbol '%s' for %s - name the position's symbol", d.Symbol, d.Action)
+	}
+
+	level, field := d.StopLoss, "stop_loss"
+	if d.Action == ActionMoveTP {
+		level, field = d.TakeProfit, "take_profit"
+	}
+	if level <= 0 {
+		return fmt.Errorf("%s %s needs the new price in %s", d.Symbol, d.Action, field)
+	}
+
+	if cfg.Positions == nil {
+		return nil
+	}
+	pos := cfg.Positions[d.Symbol]
+	if pos == nil {
+		return fmt.Errorf("no open position in %s to %s", d.Symbol, d.Action)
+	}
+	price := cfg.CurrentPrices[d.Symbol]
+	if price <= 0 {
+		return nil
+	}
+	if err := CheckStopAdjustment(d.Action, pos.Side, price, level); err != nil {
+		return fmt.Errorf("%s %w", d.Symbol, err)
+	}
+
+	if d.Action == ActionMoveStop {
+		distance := price - level
+		if pos.Side == "short" {
+			distance = level - price
+		}
+		if distancePct := distance / price * 100; distancePct < cfg.MinStopDistancePct {
+			return fmt.Errorf("%s %s stop_loss %.4f is only %.2f%% from current price %.4f, minimum is %.2f%% - leave more room or close instead",
+				d.Symbol, pos.Side, level, distancePct, price, cfg.MinStopDistancePct)
+		}
+	}
+	return nil
+}
+
+// CheckStopAdjustment checks that a move_stop or move_tp level sits on the
+// right side of price for a "long" or "short" position, so the new order
+// doesn't trigger the moment it's placed
+func CheckStopAdjustment(action, side string, price, level float64) error {
+	stop := action == ActionMoveStop
+	name := "take_profit"
+	if stop {
+		name = "stop_loss"
+	}
+
+	// A long's stop and a short's target sit below the market
+	below := (side == "long") == stop
+	switch {
+	case below && level >= price:
+		return fmt.Errorf("%s %s %.4f must be below current price %.4f - move it under the market", side, name, level, price)
+	case !below && level <= price:
+		return fmt.Errorf("%s %s %.4f must be above current price %.4f - move it over the market", side, name, level, price)
+	}
+	return nil
+}
+
+// validateAddDecision validates scaling into an open position. The add is capped
+// to what's left of the symbol's position value limit.
+func validateAddDecision(d *Decision, cfg *ValidationConfig) error {
+	if d.Symbol == "ALL" || d.Symbol == "" {
+		return fmt.Errorf("invalid symbol '%s' for adding to position", d.Symbol)
+	}
+
+	var existing float64
+	if cfg.Positions != nil {
+		pos := cfg.Positions[d.Symbol]
+		if pos == nil || pos.Side != GetActionDirection(d.Action) {
+			return fmt.Errorf("no %s position in %s to add to", GetActionDirection(d.Action), d.Symbol)
+		}
+		existing = pos.Notional
+	}
+
+	maxLeverage := cfg.AltcoinLeverage
+	posRatio := cfg.AltcoinPosRatio
+	minPositionSize := cfg.MinPositionAlt
+	if isBTCOrETH(d.Symbol) {
+		maxLeverage = cfg.BTCETHLeverage
+		posRatio = cfg.BTCETHPosRatio
+		minPositionSize = cfg.MinPositionBTCETH
+	}
+
+	if d.Leverage <= 0 {
+		return fmt.Errorf("leverage must be greater than 0: %d", d.Leverage)
+	}
+	if d.Leverage > maxLeverage {
+		return fmt.Errorf("%s leverage %dx exceeds maximum %dx - rejecting trade to prevent unintended risk",
+			d.Symbol, d.Leverage, maxLeverage)
+	}
+	if d.PositionSizeUSD <= 0 {
+		return fmt.Errorf("position size must be greater than 0: %.2f", d.PositionSizeUSD)
+	}
+
+	// Cap to the remaining risk budget for the symbol
+	remaining := cfg.AccountEquity*posRatio - existing
+	if remaining < minPositionSize {
+		return fmt.Errorf("%s has no risk budget left to add (%.0f USDT held, limit %.0f USDT)",
+			d.Symbol, existing, cfg.AccountEquity*posRatio)
+	}
+	if d.PositionSizeUSD > remaining {
+		log.Printf("[Validator] %s add of %.0f USDT capped to remaining budget %.0f USDT", d.Symbol, d.PositionSizeUSD, remaining)
+		d.PositionSizeUSD = remaining
+	}
+	if d.PositionSizeUSD < minPositionSize {
+		return fmt.Errorf("add amount too small (%.2f USDT), must be >= %.2f USDT", d.PositionSizeUSD, minPositionSize)
+	}
+	if err := checkExposureLimits(d, cfg); err != nil {
+		return err
+	}
+
+	return CheckMarginUsage(cfg.MarginUsed, d.PositionSizeUSD/float64(d.Leverage), cfg.AccountEquity, cfg.MaxMarginUsagePct)
+}
+
+// CheckMarginUsage rejects adding margin that would push usage past maxPct of
+// equity. A zero maxPct or equity skips the check.
+func CheckMarginUsage(used, added, equity, maxPct float64) error {
+	if maxPct <= 0 || equity <= 0 {
+		return nil
+	}
+	if usage := (used + added) / equity * 100; usage > maxPct {
+		return fmt.Errorf("margin usage would reach %.1f%%, above the %.0f%% limit", usage, maxPct)
+	}
+	return nil
+}
+
 // validateOpeningDecision validates decisions that open new positions
 func validateOpeningDecision(d *Decision, cfg *ValidationConfig) error {
 	// CRITICAL: Reject "ALL" symbol for opening positions
@@ -51,6 +221,11 @@ func validateOpeningDecision(d *Decision, cfg *ValidationConfig) error {
 	if d.Symbol == "ALL" || d.Symbol == "" {
 		return fmt.Errorf("invalid symbol '%s' for opening position - cannot trade on ALL/empty symbol", d.Symbol)
 	}
+	if cfg.SymbolCheck != nil {
+		if err := cfg.SymbolCheck(d.Symbol); err != nil {
+			return fmt.Errorf("untradable symbol: %w - pick a listed USDT perpetual", err)
+		}
+	}
 
 	// Determine max leverage and position ratio based on symbol
 	maxLeverage := cfg.AltcoinLeverage
@@ -100,6 +275,10 @@ func validateOpeningDecision(d *Decision, cfg *ValidationConfig) error {
 			maxPositionValue, posRatio, d.PositionSizeUSD)
 	}
 
+	if err := checkExposureLimits(d, cfg); err != nil {
+		return err
+	}
+
 	// Stop-loss and take-profit validation
 	if d.StopLoss <= 0 || d.TakeProfit <= 0 {
 		return fmt.Errorf("stop loss and take profit must be greater than 0")
@@ -116,6 +295,11 @@ func validateOpeningDecision(d *Decision, cfg *ValidationConfig) error {
 		}
 	}
 
+	// Check against the real price when we have one, else estimate R:R from the midpoint
+	if price := cfg.CurrentPrices[d.Symbol]; price > 0 {
+		return validateAgainstPrice(d, price, cfg)
+	}
+
 	// Risk/Reward ratio validation
 	if err := validateRiskReward(d, cfg.MinRiskReward); err != nil {
 		return err
@@ -124,8 +308,53 @@ func validateOpeningDecision(d *Decision, cfg *ValidationConfig) error {
 	return nil
 }
 
+// validateAgainstPrice checks SL/TP direction, stop distance and risk/reward
+// using the current market price as the entry
+func validateAgainstPrice(d *Decision, price float64, cfg *ValidationConfig) error {
+	var risk, reward float64
+	side := "long"
+	if d.Action == ActionOpenLong {
+		if d.StopLoss >= price {
+			return fmt.Errorf("%s long stop_loss %.4f must be below current price %.4f - move the stop under the market",
+				d.Symbol, d.StopLoss, price)
+		}
+		if d.TakeProfit <= price {
+			return fmt.Errorf("%s long take_profit %.4f must be above current price %.4f - move the target over the market",
+				d.Symbol, d.TakeProfit, price)
+		}
+		risk = price - d.StopLoss
+		reward = d.TakeProfit - price
+	} else {
+		side = "short"
+		if d.StopLoss <= price {
+			return fmt.Errorf("%s short stop_loss %.4f must be above current price %.4f - move the stop over the market",
+				d.Symbol, d.StopLoss, price)
+		}
+		if d.TakeProfit >= price {
+			return fmt.Errorf("%s short take_profit %.4f must be below current price %.4f - move the target under the market",
+				d.Symbol, d.TakeProfit, price)
+		}
+		risk = d.StopLoss - price
+		reward = price - d.TakeProfit
+	}
+
+	if distancePct := risk / price * 100; distancePct < cfg.MinStopDistancePct {
+		return fmt.Errorf("%s %s stop_loss %.4f is only %.2f%% from current price %.4f, minimum is %.2f%% - widen the stop",
+			d.Symbol, side, d.StopLoss, distancePct, price, cfg.MinStopDistancePct)
+	}
+
+	if cfg.MinRiskReward > 0 {
+		if ratio := reward / risk; ratio < cfg.MinRiskReward {
+			return fmt.Errorf("%s %s risk-reward %.2f:1 at current price %.4f is below minimum %.2f:1 (risk %.4f, reward %.4f) - move take_profit further or tighten stop_loss",
+				d.Symbol, side, ratio, price, cfg.MinRiskReward, risk, reward)
+		}
+	}
+
+	return nil
+}
+
 // validateRiskReward validates the risk/reward ratio of a decision
-// For decisions with absolute SL/TP prices, we estimate R:R using the midpoint as entry
+// For decisions with absolute SL/TP prices and no known market price, we estimate R:R using the midpoint as entry
 func validateRiskReward(d *Decision, minRatio float64) error {
 	if minRatio <= 0 {
 		return nil // Validation disabled
@@ -189,11 +418,29 @@ func IsOpeningAction(action string) bool {
 	return action == ActionOpenLong || action == ActionOpenShort
 }
 
+// IsAddAction checks if action scales into an existing position
+func IsAddAction(action string) bool {
+	return action == ActionAddLong || action == ActionAddShort
+}
+
+// AddActionFor returns the add action for a "long" or "short" side
+func AddActionFor(side string) string {
+	if side == "short" {
+		return ActionAddShort
+	}
+	return ActionAddLong
+}
+
 // IsClosingAction checks if action closes a position
 func IsClosingAction(action string) bool {
 	return action == ActionCloseLong || action == ActionCloseShort
 }
 
+// IsStopAdjustment checks if action moves the SL or TP of an open position
+func IsStopAdjustment(action string) bool {
+	return action == ActionMoveStop || action == ActionMoveTP
+}
+
 // IsPassiveAction checks if action is passive (hold/wait)
 func IsPassiveAction(action string) bool {
 	return action == ActionHold || action == ActionWait
@@ -202,9 +449,9 @@ func IsPassiveAction(action string) bool {
 // GetActionDirection returns "long" or "short" for an action
 func GetActionDirection(action string) string {
 	switch action {
-	case ActionOpenLong, ActionCloseLong:
+	case ActionOpenLong, ActionAddLong, ActionCloseLong:
 		return "long"
-	case ActionOpenShort, ActionCloseShort:
+	case ActionOpenShort, ActionAddShort, ActionCloseShort:
 		return "short"
 	default:
 		return ""
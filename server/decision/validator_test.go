@@ -1,6 +1,7 @@
 package decision
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -464,3 +465,292 @@ func TestIsClosingAction(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateOpeningDecision_CurrentPrice(t *testing.T) {
+	cfg := DefaultValidationConfig()
+	cfg.CurrentPrices = map[string]float64{"BTCUSDT": 50000}
+
+	tests := []struct {
+		name        string
+		action      string
+		stopLoss    float64
+		takeProfit  float64
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:       "Long with 1:3 from current price passes",
+			action:     ActionOpenLong,
+			stopLoss:   49000,
+			takeProfit: 53000,
+		},
+		{
+			name:        "Long stop above market rejected",
+			action:      ActionOpenLong,
+			stopLoss:    50500,
+			takeProfit:  60000,
+			wantErr:     true,
+			errContains: "stop_loss 50500.0000 must be below current price",
+		},
+		{
+			name:        "Long target below market rejected",
+			action:      ActionOpenLong,
+			stopLoss:    45000,
+			takeProfit:  49000,
+			wantErr:     true,
+			errContains: "take_profit 49000.0000 must be above current price",
+		},
+		{
+			name:        "Long stop too close rejected",
+			action:      ActionOpenLong,
+			stopLoss:    49950, // 0.1% away
+			takeProfit:  53000,
+			wantErr:     true,
+			errContains: "widen the stop",
+		},
+		{
+			// Midpoint estimate would call this 1:1; from the real entry it is 1:4
+			name:       "Long R:R uses current price as entry",
+			action:     ActionOpenLong,
+			stopLoss:   48000,
+			takeProfit: 58000,
+		},
+		{
+			name:        "Long R:R below minimum at current price",
+			action:      ActionOpenLong,
+			stopLoss:    46000,
+			takeProfit:  54000,
+			wantErr:     true,
+			errContains: "risk-reward 1.00:1 at current price",
+		},
+		{
+			name:       "Short with 1:3 from current price passes",
+			action:     ActionOpenShort,
+			stopLoss:   51000,
+			takeProfit: 47000,
+		},
+		{
+			name:        "Short stop below market rejected",
+			action:      ActionOpenShort,
+			stopLoss:    49800,
+			takeProfit:  40000,
+			wantErr:     true,
+			errContains: "must be above current price",
+		},
+		{
+			name:        "Short target above market rejected",
+			action:      ActionOpenShort,
+			stopLoss:    55000,
+			takeProfit:  50100,
+			wantErr:     true,
+			errContains: "must be below current price",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Decision{
+				Symbol:          "BTCUSDT",
+				Action:          tt.action,
+				Leverage:        5,
+				PositionSizeUSD: 1000,
+				StopLoss:        tt.stopLoss,
+				TakeProfit:      tt.takeProfit,
+			}
+			err := ValidateDecision(d, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateDecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got: %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestValidateOpeningDecision_SymbolCheck(t *testing.T) {
+	cfg := DefaultValidationConfig()
+	cfg.SymbolCheck = func(symbol string) error {
+		if symbol == "FAKEUSDT" {
+			return fmt.Errorf("%s is not listed on Binance USDT-M futures", symbol)
+		}
+		return nil
+	}
+
+	d := &Decision{
+		Symbol:          "FAKEUSDT",
+		Action:          ActionOpenLong,
+		Leverage:        5,
+		PositionSizeUSD: 1000,
+		StopLoss:        0.9,
+		TakeProfit:      1.5,
+	}
+	err := ValidateDecision(d, cfg)
+	if err == nil || !strings.Contains(err.Error(), "untradable symbol") {
+		t.Fatalf("Expected untradable symbol error, got: %v", err)
+	}
+
+	// Closing a position in a symbol that stopped trading must still be allowed
+	d.Action = ActionCloseLong
+	if err := ValidateDecision(d, cfg); err != nil {
+		t.Errorf("Close should skip the symbol check, got: %v", err)
+	}
+}
+
+func TestValidateDecision_ScaleIn(t *testing.T) {
+	newCfg := func() *ValidationConfig {
+		cfg := DefaultValidationConfig()
+		cfg.Positions = map[string]*PositionExposure{
+			"BTCUSDT": {Side: "long", Notional: 2000},  // Limit is 10000 * 0.3 = 3000
+			"SOLUSDT": {Side: "short", Notional: 1500}, // Limit is 10000 * 0.15 = 1500
+		}
+		cfg.MarginUsed = 4000
+		cfg.MaxMarginUsagePct = 50
+		return cfg
+	}
+
+	tests := []struct {
+		name       string
+		symbol     string
+		action     string
+		size       float64
+		wantAction string
+		wantSize   float64
+		wantErr    string
+	}{
+		{"open on held side becomes add", "BTCUSDT", ActionOpenLong, 500, ActionAddLong, 500, ""},
+		{"add capped to remaining budget", "BTCUSDT", ActionAddLong, 2000, ActionAddLong, 1000, ""},
+		{"open on opposite side stays an open", "BTCUSDT", ActionOpenShort, 500, ActionOpenShort, 500, ""},
+		{"no budget left", "SOLUSDT", ActionAddShort, 100, ActionAddShort, 100, "no risk budget left"},
+		{"add without a position", "ETHUSDT", ActionAddLong, 500, ActionAddLong, 500, "no long position"},
+		{"add on the wrong side", "BTCUSDT", ActionAddShort, 500, ActionAddShort, 500, "no short position"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCfg()
+			cfg.MinRiskReward = 0
+			d := &Decision{
+				Symbol:          tt.symbol,
+				Action:          tt.action,
+				Leverage:        10,
+				PositionSizeUSD: tt.size,
+				StopLoss:        55000,
+				TakeProfit:      45000,
+			}
+			err := ValidateDecision(d, cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.Action != tt.wantAction {
+				t.Errorf("action = %s, want %s", d.Action, tt.wantAction)
+			}
+			if d.PositionSizeUSD != tt.wantSize {
+				t.Errorf("position size = %.2f, want %.2f", d.PositionSizeUSD, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestValidateDecision_ScaleInMarginUsage(t *testing.T) {
+	cfg := DefaultValidationConfig()
+	cfg.Positions = map[string]*PositionExposure{"BTCUSDT": {Side: "long", Notional: 1000}}
+	cfg.MarginUsed = 4900
+	cfg.MaxMarginUsagePct = 50
+
+	// 1000 USDT at 10x adds 100 margin: 5000 / 10000 = 50%, right at the limit
+	d := &Decision{Symbol: "BTCUSDT", Action: ActionAddLong, Leverage: 10, PositionSizeUSD: 1000}
+	if err := ValidateDecision(d, cfg); err != nil {
+		t.Fatalf("add at the margin limit should pass, got: %v", err)
+	}
+
+	cfg.MarginUsed = 4950
+	d = &Decision{Symbol: "BTCUSDT", Action: ActionAddLong, Leverage: 10, PositionSizeUSD: 1000}
+	err := ValidateDecision(d, cfg)
+	if err == nil || !strings.Contains(err.Error(), "margin usage") {
+		t.Fatalf("expected margin usage rejection, got: %v", err)
+	}
+}
+
+func TestValidateDecision_ClosePercent(t *testing.T) {
+	cfg := DefaultValidationConfig()
+
+	tests := []struct {
+		pct     float64
+		wantErr bool
+	}{
+		{0, false},
+		{33, false},
+		{100, false},
+		{-10, true},
+		{150, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%.0f%%", tt.pct), func(t *testing.T) {
+			d := &Decision{Symbol: "BTCUSDT", Action: ActionCloseLong, ClosePercent: tt.pct}
+			err := ValidateDecision(d, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDecision_StopAdjustment(t *testing.T) {
+	newCfg := func() *ValidationConfig {
+		cfg := DefaultValidationConfig()
+		cfg.Positions = map[string]*PositionExposure{
+			"BTCUSDT": {Side: "long", Notional: 2000},
+			"SOLUSDT": {Side: "short", Notional: 500},
+		}
+		cfg.CurrentPrices = map[string]float64{"BTCUSDT": 50000, "SOLUSDT": 100}
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		d       Decision
+		wantErr string
+	}{
+		{"long stop to breakeven", Decision{Symbol: "BTCUSDT", Action: ActionMoveStop, StopLoss: 49000}, ""},
+		{"long stop above price", Decision{Symbol: "BTCUSDT", Action: ActionMoveStop, StopLoss: 51000}, "must be below"},
+		{"long stop too close", Decision{Symbol: "BTCUSDT", Action: ActionMoveStop, StopLoss: 49990}, "minimum is"},
+		{"long target above price", Decision{Symbol: "BTCUSDT", Action: ActionMoveTP, TakeProfit: 56000}, ""},
+		{"long target below price", Decision{Symbol: "BTCUSDT", Action: ActionMoveTP, TakeProfit: 48000}, "must be above"},
+		{"short stop above price", Decision{Symbol: "SOLUSDT", Action: ActionMoveStop, StopLoss: 104}, ""},
+		{"short stop below price", Decision{Symbol: "SOLUSDT", Action: ActionMoveStop, StopLoss: 98}, "must be above"},
+		{"short target above price", Decision{Symbol: "SOLUSDT", Action: ActionMoveTP, TakeProfit: 101}, "must be below"},
+		{"missing level", Decision{Symbol: "BTCUSDT", Action: ActionMoveStop, TakeProfit: 56000}, "needs the new price in stop_loss"},
+		{"no position", Decision{Symbol: "ETHUSDT", Action: ActionMoveStop, StopLoss: 3000}, "no open position"},
+		{"ALL symbol", Decision{Symbol: "ALL", Action: ActionMoveTP, TakeProfit: 56000}, "invalid symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.d
+			err := ValidateDecision(&d, newCfg())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Without positions, as in the backtest, only the level is checked
+	d := &Decision{Symbol: "ETHUSDT", Action: ActionMoveStop, StopLoss: 3000}
+	if err := ValidateDecision(d, DefaultValidationConfig()); err != nil {
+		t.Errorf("move_stop without position info should pass, got: %v", err)
+	}
+}
@@ -16,6 +16,7 @@ const (
 	TypeError    EventType = "error"
 	TypeInfo     EventType = "info"
 	TypeTrade    EventType = "trade"
+	TypeReport   EventType = "report"
 )
 
 // Event represents a notification to be sent to clients
@@ -28,29 +29,40 @@ type Event struct {
 	Timestamp int64       `json:"timestamp"`
 }
 
+// message is a broadcast event, marshalled once for every SSE client
+type message struct {
+	traderID string
+	data     []byte
+}
+
 // Hub maintains the set of active clients and broadcasts messages to the clients.
 type Hub struct {
 	// Registered clients.
-	clients map[chan []byte]bool
+	clients map[chan message]bool
 
 	// Inbound messages from the clients.
-	broadcast chan []byte
+	broadcast chan message
 
 	// Register requests from the clients.
-	register chan chan []byte
+	register chan chan message
 
 	// Unregister requests from clients.
-	unregister chan chan []byte
+	unregister chan chan message
 
 	mu sync.Mutex
+
+	// Topic subscriptions, served by Publish
+	subs  map[*Subscription]bool
+	subMu sync.RWMutex
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte),
-		register:   make(chan chan []byte),
-		unregister: make(chan chan []byte),
-		clients:    make(map[chan []byte]bool),
+		broadcast:  make(chan message),
+		register:   make(chan chan message),
+		unregister: make(chan chan message),
+		clients:    make(map[chan message]bool),
+		subs:       make(map[*Subscription]bool),
 	}
 }
 
@@ -72,11 +84,11 @@ func (h *Hub) Run() {
 			h.mu.Unlock()
 			log.Printf("[EventHub] Client unregistered. Total clients: %d", len(h.clients))
 
-		case message := <-h.broadcast:
+		case msg := <-h.broadcast:
 			h.mu.Lock()
 			for client := range h.clients {
 				select {
-				case client <- message:
+				case client <- msg:
 				default:
 					close(client)
 					delete(h.clients, client)
@@ -87,26 +99,27 @@ func (h *Hub) Run() {
 	}
 }
 
-// Broadcast sends an event to all connected clients
+// Broadcast sends an event to the connected SSE clients allowed its trader
 func (h *Hub) Broadcast(evt Event) {
 	bytes, err := json.Marshal(evt)
 	if err != nil {
 		log.Printf("[EventHub] Failed to marshal event: %v", err)
 		return
 	}
-	h.broadcast <- bytes
+	h.broadcast <- message{traderID: evt.TraderID, data: bytes}
 }
 
-// ServeHTTP handles SSE connections
-func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+// ServeEvents streams the broadcast events to an SSE client, skipping those
+// of traders allow refuses. Events without a trader ask allow for "". allow
+// runs on the client's goroutine, so a slow check holds up only that client.
+func (h *Hub) ServeEvents(w http.ResponseWriter, r *http.Request, allow func(traderID string) bool) {
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Create a channel for this client
-	client := make(chan []byte, 256)
+	client := make(chan message, 256)
 
 	// Register client
 	h.register <- client
@@ -131,7 +144,10 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			fmt.Fprintf(w, "data: %s\n\n", msg)
+			if !allow(msg.traderID) {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg.data)
 			w.(http.Flusher).Flush()
 		}
 	}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TypePositions        EventType = "positions"
+	TypeEquity           EventType = "equity"
+	TypeLifecycle        EventType = "lifecycle"
+	TypeBacktestProgress EventType = "backtest_progress"
+)
+
+// Topic scopes and streams. A topic is "<scope>:<id>:<stream>", such as
+// "trader:<id>:positions".
+const (
+	ScopeTrader   = "trader"
+	ScopeBacktest = "backtest"
+
+	StreamPositions = "positions" // Position marks, each risk check
+	StreamEquity    = "equity"    // Equity snapshots, as they're saved
+	StreamLifecycle = "lifecycle" // Engine started and stopped
+	StreamReports   = "reports"   // Scheduled P&L reports
+	StreamProgress  = "progress"  // Backtest progress
+)
+
+// streams lists the streams of each scope
+var streams = map[string][]string{
+	ScopeTrader:   {StreamPositions, StreamEquity, StreamLifecycle, StreamReports},
+	ScopeBacktest: {StreamProgress},
+}
+
+// TraderTopic names one of a trader's streams
+func TraderTopic(traderID, stream string) string {
+	return ScopeTrader + ":" + traderID + ":" + stream
+}
+
+// BacktestTopic names a backtest run's progress stream
+func BacktestTopic(runID string) string {
+	return ScopeBacktest + ":" + runID + ":" + StreamProgress
+}
+
+// ParseTopic splits a topic into its scope, ID and stream
+func ParseTopic(topic string) (scope, id, stream string, err error) {
+	scope, rest, ok := strings.Cut(topic, ":")
+	if !ok {
+		return "", "", "", fmt.Errorf("topic %q is not <scope>:<id>:<stream>", topic)
+	}
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", "", fmt.Errorf("topic %q is not <scope>:<id>:<stream>", topic)
+	}
+	id, stream = rest[:i], rest[i+1:]
+
+	known, ok := streams[scope]
+	if !ok {
+		return "", "", "", fmt.Errorf("unknown topic scope %q", scope)
+	}
+	for _, s := range known {
+		if s == stream {
+			return scope, id, stream, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("unknown %s stream %q (%s)", scope, stream, strings.Join(known, ", "))
+}
+
+// TopicEvent is an event as delivered to a topic's subscribers
+type TopicEvent struct {
+	Topic string `json:"topic"`
+	Event
+}
+
+// Subscription receives the events published to its topics. Events that
+// arrive while C is full are dropped, so a slow reader can't hold up the
+// publishers.
+type Subscription struct {
+	C      chan TopicEvent
+	hub    *Hub
+	topics map[string]bool // Guarded by hub.subMu
+}
+
+// Subscribe creates a subscription with no topics. Close it when done.
+func (h *Hub) Subscribe() *Subscription {
+	sub := &Subscription{C: make(chan TopicEvent, 64), hub: h, topics: make(map[string]bool)}
+	h.subMu.Lock()
+	h.subs[sub] = true
+	h.subMu.Unlock()
+	return sub
+}
+
+// Add subscribes to topic
+func (s *Subscription) Add(topic string) {
+	s.hub.subMu.Lock()
+	s.topics[topic] = true
+	s.hub.subMu.Unlock()
+}
+
+// Remove unsubscribes from topic
+func (s *Subscription) Remove(topic string) {
+	s.hub.subMu.Lock()
+	delete(s.topics, topic)
+	s.hub.subMu.Unlock()
+}
+
+// Topics returns the number of subscribed topics
+func (s *Subscription) Topics() int {
+	s.hub.subMu.RLock()
+	defer s.hub.subMu.RUnlock()
+	return len(s.topics)
+}
+
+// Close stops delivery and closes C
+func (s *Subscription) Close() {
+	s.hub.subMu.Lock()
+	if s.hub.subs[s] {
+		delete(s.hub.subs, s)
+		close(s.C)
+	}
+	s.hub.subMu.Unlock()
+}
+
+// Publish sends evt to the subscribers of topic. Unlike Broadcast it doesn't
+// reach the SSE clients and never blocks.
+func (h *Hub) Publish(topic string, evt Event) {
+	if evt.Timestamp == 0 {
+		evt.Timestamp = time.Now().UnixMilli()
+	}
+	h.subMu.RLock()
+	defer h.subMu.RUnlock()
+	for sub := range h.subs {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.C <- TopicEvent{Topic: topic, Event: evt}:
+		default:
+		}
+	}
+}
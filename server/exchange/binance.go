@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,10 +30,18 @@ type BinanceClient struct {
 	secretKey        string
 	baseURL          string
 	httpClient       *http.Client
-	serverTimeOffset int64 // Offset between local time and Binance server time (in ms)
+	serverTimeOffset atomic.Int64 // Offset between local time and Binance server time (in ms)
 
-	// Symbol precision cache (fetched from exchange)
+	// Time sync history, for health checks
+	timeSyncMu   sync.Mutex
+	lastTimeSync time.Time
+	timeResyncs  int // Resyncs forced by -1021 rejections
+
+	// Symbol precision and tradability cache (fetched from exchange, refreshed daily)
 	symbolInfo map[string]*SymbolInfo
+	symbolMu   sync.RWMutex
+
+	faults *faultTransport // Set by InjectFaults
 }
 
 // SymbolInfo holds precision info for a trading symbol
@@ -41,6 +52,9 @@ type SymbolInfo struct {
 	MinQty            float64
 	StepSize          float64
 	Status            string
+	ContractType      string // PERPETUAL, CURRENT_QUARTER, ...
+	QuoteAsset        string
+	DeliveryDate      int64 // ms; far future for perpetuals, near when delisting
 }
 
 type AccountInfo struct {
@@ -58,6 +72,7 @@ type Position struct {
 	Leverage         int     `json:"leverage,string"`
 	PositionSide     string  `json:"positionSide"`
 	MarkPrice        float64 `json:"markPrice,string"`
+	LiquidationPrice float64 `json:"liquidationPrice,string"` // 0 when Binance reports none
 }
 
 type Order struct {
@@ -92,11 +107,38 @@ type Kline struct {
 }
 
 func NewBinanceClient(apiKey, secretKey string, testnet bool) *BinanceClient {
-	baseURL := BinanceMainnetURL
+	return NewBinanceClientAt(apiKey, secretKey, FuturesBaseURL(testnet))
+}
+
+// Futures networks a client can point at
+const (
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+	NetworkCustom  = "custom" // Any other futures API, such as a simulated exchange
+)
+
+// FuturesBaseURL is the futures API NewBinanceClient points a client at
+func FuturesBaseURL(testnet bool) string {
 	if testnet {
-		baseURL = BinanceTestnetURL
+		return BinanceTestnetURL
 	}
+	return BinanceMainnetURL
+}
+
+// NetworkOf names the network of a futures API base URL
+func NetworkOf(baseURL string) string {
+	switch baseURL {
+	case BinanceMainnetURL:
+		return NetworkMainnet
+	case BinanceTestnetURL:
+		return NetworkTestnet
+	}
+	return NetworkCustom
+}
 
+// NewBinanceClientAt creates a client for a futures API at baseURL, such as a
+// simulated exchange
+func NewBinanceClientAt(apiKey, secretKey, baseURL string) *BinanceClient {
 	client := &BinanceClient{
 		apiKey:    apiKey,
 		secretKey: secretKey,
@@ -104,34 +146,74 @@ func NewBinanceClient(apiKey, secretKey string, testnet bool) *BinanceClient {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		serverTimeOffset: 0,
-		symbolInfo:       make(map[string]*SymbolInfo),
+		symbolInfo: make(map[string]*SymbolInfo),
 	}
 
 	// Sync time with Binance server
-	client.syncServerTime()
+	client.syncServerTime(context.Background())
 
-	// Start periodic time sync (every 15 minutes) to prevent drift
+	// Start periodic time sync to prevent drift
 	client.startPeriodicTimeSync()
 
-	// Fetch exchange info for precision data
+	// Fetch exchange info for precision data and the tradable symbol list
 	client.fetchExchangeInfo()
+	client.startPeriodicExchangeInfoRefresh()
 
 	return client
 }
 
-// startPeriodicTimeSync starts a goroutine that syncs server time every 15 minutes
-// This prevents timestamp drift issues during long-running sessions
+// IsTestnet reports whether the client talks to the futures testnet
+func (c *BinanceClient) IsTestnet() bool {
+	return c.baseURL == BinanceTestnetURL
+}
+
+// Network names the network the client's base URL points at, which is what
+// it actually trades on whatever the config said
+func (c *BinanceClient) Network() string {
+	return NetworkOf(c.baseURL)
+}
+
+// BaseURL is the futures API the client sends requests to
+func (c *BinanceClient) BaseURL() string {
+	return c.baseURL
+}
+
+// AccountFingerprint identifies the exchange account the client trades on
+// without revealing its API key. Clients with the same key and network share it.
+func (c *BinanceClient) AccountFingerprint() string {
+	if c.apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.baseURL + "|" + c.apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// startPeriodicExchangeInfoRefresh re-fetches exchange info daily so listings,
+// delistings and delivery schedules are picked up without a restart
+func (c *BinanceClient) startPeriodicExchangeInfoRefresh() {
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			c.fetchExchangeInfo()
+		}
+	}()
+}
+
+// startPeriodicTimeSync starts a goroutine that syncs server time every
+// timeSyncInterval. This prevents timestamp drift issues during long-running
+// sessions, e.g. after an NTP step.
 func (c *BinanceClient) startPeriodicTimeSync() {
 	go func() {
-		ticker := time.NewTicker(15 * time.Minute)
+		ticker := time.NewTicker(timeSyncInterval)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			c.syncServerTime()
+			c.syncServerTime(context.Background())
 		}
 	}()
-	log.Printf("[Binance] Periodic time sync started (every 15 minutes)")
+	log.Printf("[Binance] Periodic time sync started (every %v)", timeSyncInterval)
 }
 
 // fetchExchangeInfo fetches symbol precision info from Binance
@@ -153,6 +235,9 @@ func (c *BinanceClient) fetchExchangeInfo() {
 		Symbols []struct {
 			Symbol            string `json:"symbol"`
 			Status            string `json:"status"`
+			ContractType      string `json:"contractType"`
+			QuoteAsset        string `json:"quoteAsset"`
+			DeliveryDate      int64  `json:"deliveryDate"`
 			QuantityPrecision int    `json:"quantityPrecision"`
 			PricePrecision    int    `json:"pricePrecision"`
 			Filters           []struct {
@@ -168,10 +253,14 @@ func (c *BinanceClient) fetchExchangeInfo() {
 		return
 	}
 
+	symbols := make(map[string]*SymbolInfo, len(result.Symbols))
 	for _, s := range result.Symbols {
 		info := &SymbolInfo{
 			Symbol:            s.Symbol,
 			Status:            s.Status,
+			ContractType:      s.ContractType,
+			QuoteAsset:        s.QuoteAsset,
+			DeliveryDate:      s.DeliveryDate,
 			QuantityPrecision: s.QuantityPrecision,
 			PricePrecision:    s.PricePrecision,
 		}
@@ -185,38 +274,108 @@ func (c *BinanceClient) fetchExchangeInfo() {
 			}
 		}
 
-		c.symbolInfo[s.Symbol] = info
+		symbols[s.Symbol] = info
 	}
 
-	log.Printf("[Binance] Fetched exchange info for %d symbols", len(c.symbolInfo))
+	if len(symbols) == 0 {
+		log.Printf("[Binance] Exchange info contained no symbols, keeping previous list")
+		return
+	}
+
+	c.symbolMu.Lock()
+	c.symbolInfo = symbols
+	c.symbolMu.Unlock()
+
+	log.Printf("[Binance] Fetched exchange info for %d symbols", len(symbols))
 }
 
-// syncServerTime fetches server time and calculates offset
-func (c *BinanceClient) syncServerTime() {
-	localTime := time.Now().UnixMilli()
+// getSymbolInfo returns cached exchange info for a symbol
+func (c *BinanceClient) getSymbolInfo(symbol string) (*SymbolInfo, bool) {
+	c.symbolMu.RLock()
+	defer c.symbolMu.RUnlock()
+	info, ok := c.symbolInfo[symbol]
+	return info, ok
+}
 
-	resp, err := c.httpClient.Get(c.baseURL + "/fapi/v1/time")
+// Time sync settings
+const (
+	timeSyncInterval = 30 * time.Minute
+	clockDriftWarn   = time.Second // Offsets past this mean the local clock is off
+
+	errCodeTimestamp = -1021 // Timestamp outside recvWindow
+)
+
+// syncServerTime fetches server time and sets the offset signed requests
+// add to the local clock, measured against the middle of the round trip
+func (c *BinanceClient) syncServerTime(ctx context.Context) error {
+	sent := time.Now()
+	serverTime, err := c.ServerTime(ctx)
 	if err != nil {
 		log.Printf("[Binance] Failed to sync server time: %v", err)
-		return
+		return err
+	}
+	local := sent.Add(time.Since(sent) / 2)
+	offset := serverTime.Sub(local)
+
+	c.serverTimeOffset.Store(offset.Milliseconds())
+	c.timeSyncMu.Lock()
+	c.lastTimeSync = time.Now()
+	c.timeSyncMu.Unlock()
+
+	if offset > clockDriftWarn || offset < -clockDriftWarn {
+		log.Printf("[Binance] Local clock is %dms off Binance server time, check the system clock (NTP)", offset.Milliseconds())
+	} else {
+		log.Printf("[Binance] Server time synced, offset: %dms", offset.Milliseconds())
+	}
+	return nil
+}
+
+// TimeSyncStatus is the state of the client's server time sync
+type TimeSyncStatus struct {
+	Offset   time.Duration // Added to the local clock for signed requests
+	LastSync time.Time     // Zero if no sync succeeded yet
+	Resyncs  int           // Resyncs forced by -1021 timestamp rejections
+}
+
+// TimeSync returns the offset from the last time sync, when it ran and how
+// often a rejected timestamp forced one
+func (c *BinanceClient) TimeSync() TimeSyncStatus {
+	c.timeSyncMu.Lock()
+	defer c.timeSyncMu.Unlock()
+	return TimeSyncStatus{Offset: c.ServerTimeOffset(), LastSync: c.lastTimeSync, Resyncs: c.timeResyncs}
+}
+
+// Ping checks that the futures API is reachable
+func (c *BinanceClient) Ping(ctx context.Context) error {
+	_, err := c.doRequest(ctx, "GET", "/fapi/v1/ping", url.Values{}, false)
+	return err
+}
+
+// ServerTime returns Binance's current server time
+func (c *BinanceClient) ServerTime(ctx context.Context) (time.Time, error) {
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/time", url.Values{}, false)
+	if err != nil {
+		return time.Time{}, err
 	}
-	defer resp.Body.Close()
 
 	var result struct {
 		ServerTime int64 `json:"serverTime"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("[Binance] Failed to parse server time: %v", err)
-		return
+	if err := json.Unmarshal(body, &result); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse server time: %w", err)
 	}
+	return time.UnixMilli(result.ServerTime), nil
+}
 
-	c.serverTimeOffset = result.ServerTime - localTime
-	log.Printf("[Binance] Server time synced, offset: %dms", c.serverTimeOffset)
+// ServerTimeOffset is the offset applied to signed request timestamps, from
+// the last time sync
+func (c *BinanceClient) ServerTimeOffset() time.Duration {
+	return time.Duration(c.serverTimeOffset.Load()) * time.Millisecond
 }
 
 func (c *BinanceClient) sign(params url.Values) string {
 	// Use server time with offset for accurate timestamp
-	timestamp := time.Now().UnixMilli() + c.serverTimeOffset
+	timestamp := time.Now().UnixMilli() + c.serverTimeOffset.Load()
 	params.Set("timestamp", strconv.FormatInt(timestamp, 10))
 	params.Set("recvWindow", "10000") // Increased from 5000 for more tolerance
 
@@ -227,7 +386,65 @@ func (c *BinanceClient) sign(params url.Values) string {
 	return signature
 }
 
+// APIError is a non-200 response from Binance. Code and Msg are Binance's own
+// error code and reason (e.g. -2019 "Margin is insufficient.") when the body
+// carries them.
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API error (status %d)", e.StatusCode)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+func newAPIError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status, Body: string(body)}
+	var payload struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		e.Code, e.Msg = payload.Code, payload.Msg
+	}
+	return e
+}
+
+// doRequest sends a request, signed with a fresh timestamp when signed. A
+// signed request Binance rejects with -1021, its timestamp outside the
+// recvWindow, is retried once after resyncing the server time.
 func (c *BinanceClient) doRequest(ctx context.Context, method, endpoint string, params url.Values, signed bool) ([]byte, error) {
+	body, err := c.doRequestOnce(ctx, method, endpoint, params, signed)
+	var apiErr *APIError
+	if !signed || !errors.As(err, &apiErr) || apiErr.Code != errCodeTimestamp {
+		return body, err
+	}
+
+	log.Printf("[Binance] %s rejected the request timestamp (offset %dms), resyncing server time",
+		endpoint, c.ServerTimeOffset().Milliseconds())
+	c.timeSyncMu.Lock()
+	c.timeResyncs++
+	c.timeSyncMu.Unlock()
+	if syncErr := c.syncServerTime(ctx); syncErr != nil {
+		return nil, err
+	}
+	params.Del("signature")
+	return c.doRequestOnce(ctx, method, endpoint, params, signed)
+}
+
+// doRequestOnce sends a request without retrying, once the shared request
+// budget allows
+func (c *BinanceClient) doRequestOnce(ctx context.Context, method, endpoint string, params url.Values, signed bool) ([]byte, error) {
+	// Before signing, so waiting doesn't age the timestamp
+	if err := restLimiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
 	var reqURL string
 	var body io.Reader
 
@@ -268,7 +485,7 @@ func (c *BinanceClient) doRequest(ctx context.Context, method, endpoint string,
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, newAPIError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
@@ -316,6 +533,28 @@ func (c *BinanceClient) GetPositions(ctx context.Context) ([]Position, error) {
 	return activePositions, nil
 }
 
+// GetPosition gets the open position on symbol, nil when flat
+func (c *BinanceClient) GetPosition(ctx context.Context, symbol string) (*Position, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v2/positionRisk", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	if err := json.Unmarshal(body, &positions); err != nil {
+		return nil, fmt.Errorf("failed to parse positions: %w", err)
+	}
+	for i := range positions {
+		if positions[i].Symbol == symbol && positions[i].PositionAmt != 0 {
+			return &positions[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // GetTicker gets current price for a symbol
 func (c *BinanceClient) GetTicker(ctx context.Context, symbol string) (*Ticker, error) {
 	params := url.Values{}
@@ -440,7 +679,7 @@ func (c *BinanceClient) SetLeverage(ctx context.Context, symbol string, leverage
 // getQuantityPrecision returns the quantity precision for a symbol
 func (c *BinanceClient) getQuantityPrecision(symbol string) int {
 	// Check cached exchange info first
-	if info, ok := c.symbolInfo[symbol]; ok {
+	if info, ok := c.getSymbolInfo(symbol); ok {
 		return info.QuantityPrecision
 	}
 
@@ -481,7 +720,7 @@ func (c *BinanceClient) getQuantityPrecision(symbol string) int {
 // getPricePrecision returns the price precision for a symbol
 func (c *BinanceClient) getPricePrecision(symbol string) int {
 	// Check cached exchange info first
-	if info, ok := c.symbolInfo[symbol]; ok {
+	if info, ok := c.getSymbolInfo(symbol); ok {
 		return info.PricePrecision
 	}
 
@@ -519,11 +758,25 @@ func (c *BinanceClient) getPricePrecision(symbol string) int {
 	return 4 // default to 4 decimal places
 }
 
+// RoundPrice rounds a price to the precision Binance accepts for the symbol,
+// the precision SL/TP trigger prices are sent with
+func (c *BinanceClient) RoundPrice(symbol string, price float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(price, 'f', c.getPricePrecision(symbol), 64), 64)
+	return rounded
+}
+
+// RoundQuantity rounds a quantity down to the symbol's step size, 0 when it
+// is below one step
+func (c *BinanceClient) RoundQuantity(symbol string, quantity float64) float64 {
+	return c.roundToStepSize(symbol, quantity)
+}
+
 // roundToStepSize rounds a quantity to the symbol's step size
 func (c *BinanceClient) roundToStepSize(symbol string, quantity float64) float64 {
-	if info, ok := c.symbolInfo[symbol]; ok && info.StepSize > 0 {
-		// Round down to nearest step size
-		steps := int(quantity / info.StepSize)
+	if info, ok := c.getSymbolInfo(symbol); ok && info.StepSize > 0 {
+		// Round down to nearest step size. The nudge keeps 0.829/0.001 =
+		// 828.9999... from losing a step.
+		steps := int(quantity/info.StepSize + 1e-9)
 		return float64(steps) * info.StepSize
 	}
 
@@ -533,10 +786,21 @@ func (c *BinanceClient) roundToStepSize(symbol string, quantity float64) float64
 	for i := 0; i < precision; i++ {
 		multiplier *= 10
 	}
-	return float64(int(quantity*multiplier)) / multiplier
+	return float64(int(quantity*multiplier+1e-9)) / multiplier
 }
 
-// PlaceOrder places a new order
+// errCodeReduceOnlyRejected is Binance's rejection of a reduce-only order
+// with no position, or less position than its quantity, left to reduce
+const errCodeReduceOnlyRejected = -2022
+
+// ErrReduceOnlyRejected is returned, wrapping the APIError, for a reduce-only
+// order Binance refused because the position is already flat, e.g. closed by
+// an exchange-side SL/TP first. Resync positions rather than retrying.
+var ErrReduceOnlyRejected = errors.New("reduce-only order rejected, position already flat")
+
+// PlaceOrder places a new order. A reduceOnly order can only shrink the
+// position, so a close racing another close can't open the opposite side;
+// when there's nothing to reduce it fails with ErrReduceOnlyRejected.
 func (c *BinanceClient) PlaceOrder(ctx context.Context, symbol, side, orderType string, quantity float64, price float64, reduceOnly bool) (*Order, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
@@ -562,7 +826,16 @@ func (c *BinanceClient) PlaceOrder(ctx context.Context, symbol, side, orderType
 	}
 
 	log.Printf("[Binance] Placing %s %s order: %s %s @ %s (reduceOnly=%v)", orderType, side, symbol, qtyStr, "MARKET", reduceOnly)
+	order, err := c.submitOrder(ctx, params)
+	var apiErr *APIError
+	if reduceOnly && errors.As(err, &apiErr) && apiErr.Code == errCodeReduceOnlyRejected {
+		return nil, fmt.Errorf("%w: %w", ErrReduceOnlyRejected, err)
+	}
+	return order, err
+}
 
+// submitOrder sends a new order built by PlaceOrder or PlaceLimitOrder
+func (c *BinanceClient) submitOrder(ctx context.Context, params url.Values) (*Order, error) {
 	body, err := c.doRequest(ctx, "POST", "/fapi/v1/order", params, true)
 	if err != nil {
 		log.Printf("[Binance] Order failed: %v", err)
@@ -578,7 +851,7 @@ func (c *BinanceClient) PlaceOrder(ctx context.Context, symbol, side, orderType
 	return &order, nil
 }
 
-// ClosePosition closes an existing position
+// ClosePosition closes an existing position with a reduce-only market order
 func (c *BinanceClient) ClosePosition(ctx context.Context, symbol string, positionAmt float64) (*Order, error) {
 	side := "SELL"
 	quantity := positionAmt
@@ -602,6 +875,17 @@ func (c *BinanceClient) CancelAllOrders(ctx context.Context, symbol string) erro
 	return err
 }
 
+// SetAutoCancel starts or renews Binance's countdown that cancels every open
+// order of symbol unless renewed within countdown. Zero turns it off.
+func (c *BinanceClient) SetAutoCancel(ctx context.Context, symbol string, countdown time.Duration) error {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("countdownTime", strconv.FormatInt(countdown.Milliseconds(), 10))
+
+	_, err := c.doRequest(ctx, "POST", "/fapi/v1/countdownCancelAll", params, true)
+	return err
+}
+
 // PlaceStopLoss places a stop-loss order (STOP_MARKET) using Algo Order API
 // For LONG positions: side should be "SELL", stopPrice below entry
 // For SHORT positions: side should be "BUY", stopPrice above entry
@@ -612,7 +896,9 @@ func (c *BinanceClient) PlaceStopLoss(ctx context.Context, symbol, side string,
 	params.Set("side", side)
 	params.Set("type", "STOP_MARKET")
 	params.Set("algoType", "CONDITIONAL")
-	params.Set("closePosition", "true") // Close entire position when triggered
+	// Close entire position when triggered. Reduce-only by nature: Binance
+	// rejects reduceOnly alongside it and never opens a position from it.
+	params.Set("closePosition", "true")
 
 	// Set trigger price with proper precision (renamed from stopPrice for algo orders)
 	pricePrecision := c.getPricePrecision(symbol)
@@ -662,7 +948,7 @@ func (c *BinanceClient) PlaceTakeProfit(ctx context.Context, symbol, side string
 	params.Set("side", side)
 	params.Set("type", "TAKE_PROFIT_MARKET")
 	params.Set("algoType", "CONDITIONAL")
-	params.Set("closePosition", "true") // Close entire position when triggered
+	params.Set("closePosition", "true") // Close entire position when triggered, reduce-only like the stop loss
 
 	// Set trigger price with proper precision (renamed from stopPrice for algo orders)
 	pricePrecision := c.getPricePrecision(symbol)
@@ -773,10 +1059,13 @@ func (c *BinanceClient) CancelAlgoOrder(ctx context.Context, symbol string, algo
 	return nil
 }
 
-// GetOpenOrders returns all open orders for a symbol
+// GetOpenOrders returns the open regular orders for a symbol, or for every
+// symbol when symbol is empty. SL/TP algo orders are listed by GetOpenAlgoOrders.
 func (c *BinanceClient) GetOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
 	params := url.Values{}
-	params.Set("symbol", symbol)
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
 
 	body, err := c.doRequest(ctx, "GET", "/fapi/v1/openOrders", params, true)
 	if err != nil {
@@ -791,6 +1080,74 @@ func (c *BinanceClient) GetOpenOrders(ctx context.Context, symbol string) ([]Ord
 	return orders, nil
 }
 
+// GetOpenAlgoOrders returns the open algo orders (the SL/TP placed through
+// /fapi/v1/algoOrder) for a symbol, or for every symbol when symbol is empty.
+// They are converted to Order like PlaceStopLoss does: OrderID is the AlgoID
+// and Price the trigger price.
+func (c *BinanceClient) GetOpenAlgoOrders(ctx context.Context, symbol string) ([]Order, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/openAlgoOrders", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var algoOrders []struct {
+		AlgoID       int64  `json:"algoId"`
+		AlgoStatus   string `json:"algoStatus"`
+		OrderType    string `json:"orderType"`
+		Symbol       string `json:"symbol"`
+		Side         string `json:"side"`
+		PositionSide string `json:"positionSide"`
+		Quantity     string `json:"quantity"`
+		TriggerPrice string `json:"triggerPrice"`
+		CreateTime   int64  `json:"createTime"`
+		UpdateTime   int64  `json:"updateTime"`
+	}
+	if err := json.Unmarshal(body, &algoOrders); err != nil {
+		return nil, fmt.Errorf("failed to parse algo orders: %w", err)
+	}
+
+	orders := make([]Order, 0, len(algoOrders))
+	for _, a := range algoOrders {
+		orders = append(orders, Order{
+			OrderID:      a.AlgoID,
+			Symbol:       a.Symbol,
+			Status:       a.AlgoStatus,
+			Side:         a.Side,
+			PositionSide: a.PositionSide,
+			Type:         a.OrderType,
+			Price:        parseFloat(a.TriggerPrice),
+			OrigQty:      parseFloat(a.Quantity),
+			Time:         a.CreateTime,
+			UpdateTime:   a.UpdateTime,
+		})
+	}
+	return orders, nil
+}
+
+// GetOrder retrieves a single order by ID
+func (c *BinanceClient) GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(orderID, 10))
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/order", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var order Order
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, fmt.Errorf("failed to parse order: %w", err)
+	}
+
+	return &order, nil
+}
+
 // Trade represents a single trade (fill) from Binance
 type Trade struct {
 	ID              int64   `json:"id"`
@@ -839,9 +1196,61 @@ func (c *BinanceClient) GetTradeHistory(ctx context.Context, symbol string, star
 	return trades, nil
 }
 
+// GetUserTrades retrieves the fills of a single order
+func (c *BinanceClient) GetUserTrades(ctx context.Context, symbol string, orderID int64) ([]Trade, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(orderID, 10))
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/userTrades", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []Trade
+	if err := json.Unmarshal(body, &trades); err != nil {
+		return nil, fmt.Errorf("failed to parse trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// Income types returned by GetIncomeHistory
+const (
+	IncomeRealizedPnL = "REALIZED_PNL"
+	IncomeCommission  = "COMMISSION"
+	IncomeFundingFee  = "FUNDING_FEE"
+	IncomeTransfer    = "TRANSFER" // Deposits into and withdrawals from the futures wallet
+
+	IncomeInternalTransfer        = "INTERNAL_TRANSFER"
+	IncomeCrossCollateralTransfer = "CROSS_COLLATERAL_TRANSFER"
+)
+
+// IsTransferIncome reports whether incomeType moves funds into or out of the
+// account rather than coming from trading
+func IsTransferIncome(incomeType string) bool {
+	switch incomeType {
+	case IncomeTransfer, IncomeInternalTransfer, IncomeCrossCollateralTransfer:
+		return true
+	}
+	return false
+}
+
+// Income is one entry of the futures income history
+type Income struct {
+	Symbol     string  `json:"symbol"`
+	IncomeType string  `json:"incomeType"`
+	Income     float64 `json:"income,string"` // Signed; commissions are negative
+	Asset      string  `json:"asset"`
+	Info       string  `json:"info"`
+	Time       int64   `json:"time"`
+	TranID     int64   `json:"tranId"`
+	TradeID    string  `json:"tradeId"`
+}
+
 // GetIncomeHistory retrieves income history (PnL, funding, commission, etc.)
 // incomeType can be: REALIZED_PNL, FUNDING_FEE, COMMISSION, etc.
-func (c *BinanceClient) GetIncomeHistory(ctx context.Context, symbol, incomeType string, startTime int64, limit int) ([]map[string]interface{}, error) {
+func (c *BinanceClient) GetIncomeHistory(ctx context.Context, symbol, incomeType string, startTime int64, limit int) ([]Income, error) {
 	params := url.Values{}
 	if symbol != "" {
 		params.Set("symbol", symbol)
@@ -863,7 +1272,7 @@ func (c *BinanceClient) GetIncomeHistory(ctx context.Context, symbol, incomeType
 		return nil, err
 	}
 
-	var income []map[string]interface{}
+	var income []Income
 	if err := json.Unmarshal(body, &income); err != nil {
 		return nil, fmt.Errorf("failed to parse income: %w", err)
 	}
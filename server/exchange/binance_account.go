@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// AccountDetails is the futures account with what it may do and its balance
+// per asset
+type AccountDetails struct {
+	FeeTier     int            `json:"feeTier"`
+	CanTrade    bool           `json:"canTrade"`
+	CanDeposit  bool           `json:"canDeposit"`
+	CanWithdraw bool           `json:"canWithdraw"`
+	Assets      []AssetBalance `json:"assets"`
+}
+
+// AssetBalance is one asset of a futures account
+type AssetBalance struct {
+	Asset            string  `json:"asset"`
+	WalletBalance    float64 `json:"walletBalance,string"`
+	AvailableBalance float64 `json:"availableBalance,string"`
+	MarginBalance    float64 `json:"marginBalance,string"`
+}
+
+// GetAccountDetails retrieves the futures account with its permissions and
+// per-asset balances
+func (c *BinanceClient) GetAccountDetails(ctx context.Context) (*AccountDetails, error) {
+	body, err := c.doRequest(ctx, "GET", "/fapi/v2/account", url.Values{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var details AccountDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("failed to parse account details: %w", err)
+	}
+	return &details, nil
+}
+
+// APIKeyPermissions is what the API key was created to allow
+type APIKeyPermissions struct {
+	IPRestrict                 bool `json:"ipRestrict"`
+	EnableReading              bool `json:"enableReading"`
+	EnableFutures              bool `json:"enableFutures"`
+	EnableSpotAndMarginTrading bool `json:"enableSpotAndMarginTrading"`
+	EnableWithdrawals          bool `json:"enableWithdrawals"`
+}
+
+// GetAPIKeyPermissions retrieves the API key's permissions from the spot API.
+// Only mainnet keys have them; the testnets don't serve the endpoint.
+func (c *BinanceClient) GetAPIKeyPermissions(ctx context.Context) (*APIKeyPermissions, error) {
+	if c.Network() != NetworkMainnet {
+		return nil, fmt.Errorf("API key permissions are only available on mainnet")
+	}
+	body, err := c.doSAPIRequest(ctx, "GET", "/sapi/v1/account/apiRestrictions", url.Values{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var perms APIKeyPermissions
+	if err := json.Unmarshal(body, &perms); err != nil {
+		return nil, fmt.Errorf("failed to parse API key permissions: %w", err)
+	}
+	return &perms, nil
+}
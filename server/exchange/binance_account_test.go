@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestNetwork(t *testing.T) {
+	for _, tc := range []struct {
+		client *BinanceClient
+		want   string
+	}{
+		{&BinanceClient{baseURL: FuturesBaseURL(false)}, NetworkMainnet},
+		{&BinanceClient{baseURL: FuturesBaseURL(true)}, NetworkTestnet},
+		{&BinanceClient{baseURL: "http://127.0.0.1:8080"}, NetworkCustom},
+	} {
+		if got := tc.client.Network(); got != tc.want {
+			t.Errorf("Network(%s) = %s, want %s", tc.client.BaseURL(), got, tc.want)
+		}
+	}
+
+	// Only mainnet keys are asked for their permissions
+	if _, err := (&BinanceClient{baseURL: BinanceTestnetURL}).GetAPIKeyPermissions(context.Background()); err == nil {
+		t.Error("testnet permissions were requested")
+	}
+}
+
+func TestGetAccountDetails(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) *http.Response {
+		return jsonResponse(http.StatusOK, `{"feeTier":1,"canTrade":true,"canDeposit":true,"canWithdraw":false,
+			"assets":[{"asset":"USDT","walletBalance":"120.5","availableBalance":"100.25","marginBalance":"121.0"}]}`)
+	})
+	c := &BinanceClient{baseURL: "https://fapi.test", httpClient: &http.Client{Transport: transport}}
+
+	details, err := c.GetAccountDetails(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.FeeTier != 1 || !details.CanTrade || details.CanWithdraw || len(details.Assets) != 1 {
+		t.Fatalf("details = %+v", details)
+	}
+	if a := details.Assets[0]; a.Asset != "USDT" || a.WalletBalance != 120.5 || a.AvailableBalance != 100.25 {
+		t.Errorf("asset = %+v", a)
+	}
+}
@@ -59,7 +59,7 @@ func (c *BinanceClient) doSAPIRequest(ctx context.Context, method, endpoint stri
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, newAPIError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
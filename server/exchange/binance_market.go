@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Ticker24h represents 24h ticker statistics
@@ -34,7 +37,7 @@ func (c *BinanceClient) Get24hTicker(ctx context.Context) ([]Ticker24h, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d)", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var tickers []Ticker24h
@@ -59,7 +62,7 @@ func (c *BinanceClient) GetTickerStats(ctx context.Context, symbol string) (*Tic
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d)", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var ticker Ticker24h
@@ -70,6 +73,154 @@ func (c *BinanceClient) GetTickerStats(ctx context.Context, symbol string) (*Tic
 	return &ticker, nil
 }
 
+// PremiumIndex is a symbol's mark price and current funding
+type PremiumIndex struct {
+	Symbol          string  `json:"symbol"`
+	MarkPrice       float64 `json:"markPrice,string"`
+	LastFundingRate float64 `json:"lastFundingRate,string"` // Per funding interval, 0.0001 = 0.01%
+	NextFundingTime int64   `json:"nextFundingTime"`
+}
+
+// GetPremiumIndex returns mark price and funding for all symbols
+func (c *BinanceClient) GetPremiumIndex(ctx context.Context) ([]PremiumIndex, error) {
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/premiumIndex", nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var index []PremiumIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse premium index: %w", err)
+	}
+	return index, nil
+}
+
+// GetFundingRate returns mark price and funding for one symbol
+func (c *BinanceClient) GetFundingRate(ctx context.Context, symbol string) (*PremiumIndex, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/premiumIndex", params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var index PremiumIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse premium index: %w", err)
+	}
+	return &index, nil
+}
+
+// OpenInterestHist is a symbol's open interest at the end of a period
+type OpenInterestHist struct {
+	Symbol               string  `json:"symbol"`
+	SumOpenInterest      float64 `json:"sumOpenInterest,string"`      // Contracts
+	SumOpenInterestValue float64 `json:"sumOpenInterestValue,string"` // USDT
+	Timestamp            int64   `json:"timestamp"`
+}
+
+// GetOpenInterestHistory returns symbol's open interest over the last limit
+// periods (5m, 15m, 30m, 1h, ...), oldest first. Binance keeps 30 days.
+func (c *BinanceClient) GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OpenInterestHist, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("period", period)
+	params.Set("limit", strconv.Itoa(limit))
+
+	body, err := c.doRequest(ctx, "GET", "/futures/data/openInterestHist", params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var hist []OpenInterestHist
+	if err := json.Unmarshal(body, &hist); err != nil {
+		return nil, fmt.Errorf("failed to parse open interest history: %w", err)
+	}
+	sort.Slice(hist, func(i, j int) bool { return hist[i].Timestamp < hist[j].Timestamp })
+	return hist, nil
+}
+
+// GetListingTime returns when symbol started trading, the open time of its
+// earliest daily kline
+func (c *BinanceClient) GetListingTime(ctx context.Context, symbol string) (time.Time, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("interval", "1d")
+	params.Set("startTime", "0")
+	params.Set("limit", "1")
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/klines", params, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var raw [][]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse klines: %w", err)
+	}
+	if len(raw) == 0 || len(raw[0]) == 0 {
+		return time.Time{}, fmt.Errorf("no klines for %s", symbol)
+	}
+	openTime, ok := raw[0][0].(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected kline open time %v", raw[0][0])
+	}
+	return time.UnixMilli(int64(openTime)), nil
+}
+
+// BookLevel is one price level of the order book
+type BookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook is a snapshot of the best bids and asks, best price first
+type OrderBook struct {
+	Symbol       string
+	Bids         []BookLevel
+	Asks         []BookLevel
+	LastUpdateID int64
+}
+
+// GetDepth returns the top limit levels on each side of symbol's order book.
+// Binance accepts limits of 5, 10, 20, 50, 100, 500 and 1000.
+func (c *BinanceClient) GetDepth(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/depth", params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		LastUpdateID int64           `json:"lastUpdateId"`
+		Bids         [][]interface{} `json:"bids"`
+		Asks         [][]interface{} `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse depth: %w", err)
+	}
+
+	book := &OrderBook{Symbol: symbol, LastUpdateID: raw.LastUpdateID}
+	book.Bids = parseBookLevels(raw.Bids)
+	book.Asks = parseBookLevels(raw.Asks)
+	return book, nil
+}
+
+func parseBookLevels(raw [][]interface{}) []BookLevel {
+	levels := make([]BookLevel, 0, len(raw))
+	for _, l := range raw {
+		if len(l) < 2 {
+			continue
+		}
+		levels = append(levels, BookLevel{Price: parseFloat(l[0]), Quantity: parseFloat(l[1])})
+	}
+	return levels
+}
+
 // GetTopVolumeCoins returns top N coins by 24h Quote Volume (USDT)
 // Filters out stablecoins and non-USDT pairs
 func (c *BinanceClient) GetTopVolumeCoins(ctx context.Context, limit int) ([]string, error) {
@@ -119,8 +270,62 @@ func (c *BinanceClient) GetTopVolumeCoins(ctx context.Context, limit int) ([]str
 
 // IsActiveSymbol checks if a symbol is currently trading
 func (c *BinanceClient) IsActiveSymbol(symbol string) bool {
-	if info, ok := c.symbolInfo[symbol]; ok {
+	if info, ok := c.getSymbolInfo(symbol); ok {
 		return info.Status == "TRADING"
 	}
 	return false
 }
+
+// CheckTradable returns why a symbol can't be opened as a USDT-M perpetual, or nil.
+// If exchange info never loaded every symbol passes and the order itself decides.
+func (c *BinanceClient) CheckTradable(symbol string) error {
+	c.symbolMu.RLock()
+	loaded := len(c.symbolInfo) > 0
+	info, ok := c.symbolInfo[symbol]
+	c.symbolMu.RUnlock()
+
+	if !loaded {
+		return nil
+	}
+	return checkTradable(symbol, info, ok, time.Now())
+}
+
+// IsTradable reports whether a symbol can be opened as a USDT-M perpetual
+func (c *BinanceClient) IsTradable(symbol string) bool {
+	return c.CheckTradable(symbol) == nil
+}
+
+// FilterTradable splits symbols into tradable ones and the reasons the rest were dropped
+func (c *BinanceClient) FilterTradable(symbols []string) ([]string, map[string]string) {
+	tradable := make([]string, 0, len(symbols))
+	rejected := make(map[string]string)
+	for _, symbol := range symbols {
+		if err := c.CheckTradable(symbol); err != nil {
+			rejected[symbol] = err.Error()
+			continue
+		}
+		tradable = append(tradable, symbol)
+	}
+	return tradable, rejected
+}
+
+func checkTradable(symbol string, info *SymbolInfo, ok bool, now time.Time) error {
+	if !ok {
+		return fmt.Errorf("%s is not listed on Binance USDT-M futures", symbol)
+	}
+	if info.ContractType != "" && info.ContractType != "PERPETUAL" {
+		return fmt.Errorf("%s is a %s contract, not a perpetual", symbol, info.ContractType)
+	}
+	if info.QuoteAsset != "" && info.QuoteAsset != "USDT" {
+		return fmt.Errorf("%s is quoted in %s, not USDT", symbol, info.QuoteAsset)
+	}
+	if info.Status != "TRADING" {
+		return fmt.Errorf("%s is not trading (status %s)", symbol, info.Status)
+	}
+	// A perpetual gets a real delivery date when it is about to be delisted
+	if info.DeliveryDate > 0 && time.UnixMilli(info.DeliveryDate).Before(now.Add(24*time.Hour)) {
+		return fmt.Errorf("%s settles at %s and can't be opened", symbol,
+			time.UnixMilli(info.DeliveryDate).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
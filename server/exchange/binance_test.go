@@ -1,7 +1,10 @@
 package exchange
 
 import (
+	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestOrderSideLogic tests the side logic for closing positions
@@ -346,3 +349,101 @@ func TestQuantityPrecision(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckTradable(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perpetual := func(mod func(*SymbolInfo)) *SymbolInfo {
+		info := &SymbolInfo{
+			Symbol:       "BTCUSDT",
+			Status:       "TRADING",
+			ContractType: "PERPETUAL",
+			QuoteAsset:   "USDT",
+			DeliveryDate: 4133404800000, // Binance's far-future date for perpetuals
+		}
+		if mod != nil {
+			mod(info)
+		}
+		return info
+	}
+
+	tests := []struct {
+		name    string
+		info    *SymbolInfo
+		listed  bool
+		wantErr string
+	}{
+		{name: "Listed perpetual", info: perpetual(nil), listed: true},
+		{name: "Unknown symbol", listed: false, wantErr: "not listed"},
+		{
+			name:    "Quarterly contract",
+			info:    perpetual(func(i *SymbolInfo) { i.ContractType = "CURRENT_QUARTER" }),
+			listed:  true,
+			wantErr: "not a perpetual",
+		},
+		{
+			name:    "USDC quoted",
+			info:    perpetual(func(i *SymbolInfo) { i.QuoteAsset = "USDC" }),
+			listed:  true,
+			wantErr: "not USDT",
+		},
+		{
+			name:    "Settling",
+			info:    perpetual(func(i *SymbolInfo) { i.Status = "SETTLING" }),
+			listed:  true,
+			wantErr: "status SETTLING",
+		},
+		{
+			name:    "Delivery within a day",
+			info:    perpetual(func(i *SymbolInfo) { i.DeliveryDate = now.Add(6 * time.Hour).UnixMilli() }),
+			listed:  true,
+			wantErr: "settles at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTradable("BTCUSDT", tt.info, tt.listed, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected tradable, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAccountFingerprint(t *testing.T) {
+	testnet := &BinanceClient{apiKey: "key-1", baseURL: BinanceTestnetURL}
+	same := &BinanceClient{apiKey: "key-1", baseURL: BinanceTestnetURL}
+	mainnet := &BinanceClient{apiKey: "key-1", baseURL: BinanceMainnetURL}
+
+	if testnet.AccountFingerprint() != same.AccountFingerprint() {
+		t.Error("same credentials, different fingerprints")
+	}
+	if testnet.AccountFingerprint() == mainnet.AccountFingerprint() {
+		t.Error("testnet and mainnet accounts share a fingerprint")
+	}
+	if strings.Contains(testnet.AccountFingerprint(), "key-1") {
+		t.Error("fingerprint reveals the API key")
+	}
+	if (&BinanceClient{baseURL: BinanceMainnetURL}).AccountFingerprint() != "" {
+		t.Error("client without a key has a fingerprint")
+	}
+}
+
+func TestRoundToStepSizeExactSteps(t *testing.T) {
+	c := &BinanceClient{symbolInfo: map[string]*SymbolInfo{"ETHUSDT": {StepSize: 0.001}}}
+	// 0.829/0.001 is 828.999... in floating point
+	for _, qty := range []float64{0.829, 1.714, 0.007} {
+		if got := c.roundToStepSize("ETHUSDT", qty); math.Abs(got-qty) > 1e-12 {
+			t.Errorf("roundToStepSize(%v) = %v", qty, got)
+		}
+	}
+	if got := c.roundToStepSize("ETHUSDT", 0.8299); math.Abs(got-0.829) > 1e-12 {
+		t.Errorf("roundToStepSize(0.8299) = %v, want 0.829", got)
+	}
+}
@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is a fake transport answering requests in memory
+type roundTripFunc func(*http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// fakeBinance serves /fapi/v1/time with a server clock ahead of ours by
+// ahead, and rejects signed requests whose timestamp is off by more than a
+// second with -1021
+func fakeBinance(ahead time.Duration, signedCalls *int) *BinanceClient {
+	transport := roundTripFunc(func(req *http.Request) *http.Response {
+		serverNow := time.Now().Add(ahead).UnixMilli()
+		if req.URL.Path == "/fapi/v1/time" {
+			return jsonResponse(http.StatusOK, fmt.Sprintf(`{"serverTime":%d}`, serverNow))
+		}
+		*signedCalls++
+		ts, _ := strconv.ParseInt(req.URL.Query().Get("timestamp"), 10, 64)
+		if d := serverNow - ts; d > 1000 || d < -1000 {
+			return jsonResponse(http.StatusBadRequest, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)
+		}
+		return jsonResponse(http.StatusOK, `{"totalWalletBalance":"100","availableBalance":"80","totalUnrealizedProfit":"0","totalMarginBalance":"100"}`)
+	})
+	return &BinanceClient{
+		baseURL:    "https://fapi.test",
+		httpClient: &http.Client{Transport: transport},
+		symbolInfo: make(map[string]*SymbolInfo),
+	}
+}
+
+func TestResyncOnTimestampRejection(t *testing.T) {
+	var signedCalls int
+	c := fakeBinance(5*time.Second, &signedCalls)
+
+	account, err := c.GetAccountInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetAccountInfo after resync: %v", err)
+	}
+	if account.AvailableBalance != 80 {
+		t.Errorf("available balance = %v, want 80", account.AvailableBalance)
+	}
+	if signedCalls != 2 {
+		t.Errorf("signed calls = %d, want the rejected one and its retry", signedCalls)
+	}
+
+	sync := c.TimeSync()
+	if sync.Resyncs != 1 || sync.LastSync.IsZero() {
+		t.Errorf("time sync = %+v, want one forced resync", sync)
+	}
+	if off := sync.Offset - 5*time.Second; off > 500*time.Millisecond || off < -500*time.Millisecond {
+		t.Errorf("offset = %v, want about 5s", sync.Offset)
+	}
+
+	// Synced, the next request goes through first time
+	if _, err := c.GetAccountInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if signedCalls != 3 || c.TimeSync().Resyncs != 1 {
+		t.Errorf("signed calls = %d, resyncs = %d after sync, want 3 and 1", signedCalls, c.TimeSync().Resyncs)
+	}
+}
+
+func TestTimestampRejectionRetriedOnce(t *testing.T) {
+	var calls int
+	transport := roundTripFunc(func(req *http.Request) *http.Response {
+		if req.URL.Path == "/fapi/v1/time" {
+			return jsonResponse(http.StatusOK, fmt.Sprintf(`{"serverTime":%d}`, time.Now().UnixMilli()))
+		}
+		calls++
+		return jsonResponse(http.StatusBadRequest, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)
+	})
+	c := &BinanceClient{baseURL: "https://fapi.test", httpClient: &http.Client{Transport: transport}}
+
+	_, err := c.GetAccountInfo(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != errCodeTimestamp {
+		t.Fatalf("err = %v, want the -1021 APIError", err)
+	}
+	if calls != 2 {
+		t.Errorf("signed calls = %d, want 2", calls)
+	}
+}
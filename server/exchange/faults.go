@@ -0,0 +1,342 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FaultConfig describes the failures injected into a client's requests, to
+// see how a trader copes with a misbehaving exchange without waiting for one.
+// The zero value injects nothing.
+type FaultConfig struct {
+	Latency            time.Duration      // Added to every request
+	LatencyJitter      time.Duration      // Random extra latency, up to this
+	ErrorRate          float64            // Chance (0-1) a request fails with a 502
+	EndpointErrorRates map[string]float64 // By "POST /fapi/v1/order" or "/fapi/v1/order", over ErrorRate
+	TimestampErrorRate float64            // Chance a signed request is rejected with -1021
+	PositionDelay      time.Duration      // How long positionRisk leaves out a position after an entry fills
+	PartialFillRate    float64            // Chance a market entry fills only PartialFillRatio of its quantity
+	PartialFillRatio   float64            // 0 uses half
+	Seed               int64              // 0 seeds from the clock
+}
+
+// Enabled reports whether the config injects anything
+func (f FaultConfig) Enabled() bool {
+	return f.Latency > 0 || f.LatencyJitter > 0 || f.ErrorRate > 0 || len(f.EndpointErrorRates) > 0 ||
+		f.TimestampErrorRate > 0 || f.PositionDelay > 0 || f.PartialFillRate > 0
+}
+
+// ParseEndpointRates parses per endpoint error rates written as
+// "POST /fapi/v1/order=0.2,/fapi/v2/positionRisk=0.1"
+func ParseEndpointRates(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		endpoint, rate, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("endpoint error rate %q is not endpoint=rate", item)
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("endpoint error rate %q must be between 0 and 1", item)
+		}
+		rates[strings.TrimSpace(endpoint)] = r
+	}
+	return rates, nil
+}
+
+// FaultStats counts the faults injected so far
+type FaultStats struct {
+	Requests        int `json:"requests"`
+	Errors          int `json:"errors"` // 502s
+	TimestampErrors int `json:"timestamp_errors"`
+	PartialFills    int `json:"partial_fills"`
+	HiddenPositions int `json:"hidden_positions"` // positionRisk responses that left a position out
+}
+
+// InjectFaults makes every later request of the client go through faults.
+// Partial fills re-sign the order, so they need the client's secret key.
+func (c *BinanceClient) InjectFaults(faults FaultConfig) {
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if faults.PartialFillRatio <= 0 || faults.PartialFillRatio >= 1 {
+		faults.PartialFillRatio = 0.5
+	}
+	t := &faultTransport{
+		base:      base,
+		faults:    faults,
+		secretKey: c.secretKey,
+		rng:       rand.New(rand.NewSource(seed)),
+		hidden:    make(map[string]time.Time),
+	}
+	c.faults = t
+	c.httpClient = &http.Client{Timeout: c.httpClient.Timeout, Transport: t}
+	log.Printf("[Binance] ⚠️ Fault injection on: %+v", faults)
+}
+
+// InjectingFaults reports whether InjectFaults was called
+func (c *BinanceClient) InjectingFaults() bool {
+	return c.faults != nil
+}
+
+// FaultStats returns the faults injected so far, zero without fault injection
+func (c *BinanceClient) FaultStats() FaultStats {
+	if c.faults == nil {
+		return FaultStats{}
+	}
+	c.faults.mu.Lock()
+	defer c.faults.mu.Unlock()
+	return c.faults.stats
+}
+
+// faultTransport injects FaultConfig's failures into requests to the exchange
+type faultTransport struct {
+	base      http.RoundTripper
+	faults    FaultConfig
+	secretKey string
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	hidden map[string]time.Time // key: symbol -> position hidden until
+	stats  FaultStats
+}
+
+// roll returns true with the chance rate
+func (t *faultTransport) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rng.Float64() < rate
+}
+
+func (t *faultTransport) count(f func(*FaultStats)) {
+	t.mu.Lock()
+	f(&t.stats)
+	t.mu.Unlock()
+}
+
+// errorRate is the chance a request to endpoint fails
+func (t *faultTransport) errorRate(method, path string) float64 {
+	if r, ok := t.faults.EndpointErrorRates[method+" "+path]; ok {
+		return r
+	}
+	if r, ok := t.faults.EndpointErrorRates[path]; ok {
+		return r
+	}
+	return t.faults.ErrorRate
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count(func(s *FaultStats) { s.Requests++ })
+
+	if err := t.delay(req.Context()); err != nil {
+		return nil, err
+	}
+
+	path := req.URL.Path
+	if t.roll(t.errorRate(req.Method, path)) {
+		t.count(func(s *FaultStats) { s.Errors++ })
+		return faultResponse(req, http.StatusBadGateway, "text/html", "<html><body><h1>502 Bad Gateway</h1></body></html>"), nil
+	}
+
+	var form url.Values
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		form, _ = url.ParseQuery(string(body))
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	signed := req.URL.Query().Get("signature") != "" || form.Get("signature") != ""
+	if signed && t.roll(t.faults.TimestampErrorRate) {
+		t.count(func(s *FaultStats) { s.TimestampErrors++ })
+		return faultResponse(req, http.StatusBadRequest, "application/json", `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`), nil
+	}
+
+	isEntry := req.Method == http.MethodPost && path == "/fapi/v1/order" && form.Get("reduceOnly") != "true"
+	partial := ""
+	if isEntry && form.Get("type") == "MARKET" && t.roll(t.faults.PartialFillRate) {
+		partial = form.Get("quantity")
+		if err := t.shrinkOrder(req, form); err != nil {
+			partial = ""
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	switch {
+	case isEntry:
+		if t.faults.PositionDelay > 0 {
+			t.mu.Lock()
+			t.hidden[form.Get("symbol")] = time.Now().Add(t.faults.PositionDelay)
+			t.mu.Unlock()
+		}
+		if partial != "" {
+			t.count(func(s *FaultStats) { s.PartialFills++ })
+			return rewriteJSON(resp, func(v interface{}) {
+				if order, ok := v.(map[string]interface{}); ok {
+					order["origQty"] = partial
+					order["status"] = "PARTIALLY_FILLED"
+				}
+			})
+		}
+	case req.Method == http.MethodGet && path == "/fapi/v2/positionRisk":
+		if hidden := t.hiddenSymbols(); len(hidden) > 0 {
+			return rewriteJSON(resp, func(v interface{}) {
+				positions, _ := v.([]interface{})
+				for _, p := range positions {
+					pos, ok := p.(map[string]interface{})
+					if !ok || !hidden[fmt.Sprint(pos["symbol"])] || pos["positionAmt"] == "0" {
+						continue
+					}
+					pos["positionAmt"] = "0"
+					t.count(func(s *FaultStats) { s.HiddenPositions++ })
+				}
+			})
+		}
+	}
+	return resp, nil
+}
+
+// delay waits the configured latency, or until ctx is done
+func (t *faultTransport) delay(ctx context.Context) error {
+	d := t.faults.Latency
+	if t.faults.LatencyJitter > 0 {
+		t.mu.Lock()
+		d += time.Duration(t.rng.Int63n(int64(t.faults.LatencyJitter)))
+		t.mu.Unlock()
+	}
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// shrinkOrder sends the order in req for PartialFillRatio of its quantity,
+// signed again
+func (t *faultTransport) shrinkOrder(req *http.Request, form url.Values) error {
+	qtyStr := form.Get("quantity")
+	qty, err := strconv.ParseFloat(qtyStr, 64)
+	if err != nil {
+		return err
+	}
+	decimals := 0
+	if i := strings.IndexByte(qtyStr, '.'); i >= 0 {
+		decimals = len(qtyStr) - i - 1
+	}
+	step := 1.0
+	for i := 0; i < decimals; i++ {
+		step /= 10
+	}
+	shrunk := float64(int64(qty*t.faults.PartialFillRatio/step)) * step
+	if shrunk <= 0 {
+		return fmt.Errorf("quantity %s too small to fill partially", qtyStr)
+	}
+
+	params := url.Values{}
+	for k, v := range form {
+		if k != "signature" {
+			params[k] = v
+		}
+	}
+	params.Set("quantity", strconv.FormatFloat(shrunk, 'f', decimals, 64))
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(params.Encode()))
+	params.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	body := params.Encode()
+	req.Body = io.NopCloser(strings.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+// hiddenSymbols returns the symbols whose positions are still hidden
+func (t *faultTransport) hiddenSymbols() map[string]bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	hidden := make(map[string]bool)
+	for symbol, until := range t.hidden {
+		if now.Before(until) {
+			hidden[symbol] = true
+		} else {
+			delete(t.hidden, symbol)
+		}
+	}
+	return hidden
+}
+
+// rewriteJSON replaces resp's JSON body with what edit makes of it. A body
+// that isn't JSON is passed on unchanged.
+func rewriteJSON(resp *http.Response, edit func(interface{})) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // Keep millisecond timestamps exact
+	if dec.Decode(&v) == nil {
+		edit(v)
+		if edited, err := json.Marshal(v); err == nil {
+			body = edited
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+func faultResponse(req *http.Request, status int, contentType, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
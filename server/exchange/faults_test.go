@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange/paper"
+)
+
+func TestParseEndpointRates(t *testing.T) {
+	rates, err := ParseEndpointRates(" POST /fapi/v1/order=0.2, /fapi/v2/positionRisk=1 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates["POST /fapi/v1/order"] != 0.2 || rates["/fapi/v2/positionRisk"] != 1 {
+		t.Errorf("rates = %v", rates)
+	}
+
+	for _, bad := range []string{"/fapi/v1/order", "/fapi/v1/order=abc", "/fapi/v1/order=1.5"} {
+		if _, err := ParseEndpointRates(bad); err == nil {
+			t.Errorf("ParseEndpointRates(%q) accepted", bad)
+		}
+	}
+}
+
+// paperClient is a client trading on a fresh paper exchange with BTCUSDT at
+// 60000
+func paperClient(t *testing.T) (*BinanceClient, *paper.Exchange) {
+	const secret = "paper-secret"
+	sim := paper.New(10000, secret)
+	sim.AddSymbol("BTCUSDT", 60000, 3)
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+	return NewBinanceClientAt("paper-key", secret, srv.URL), sim
+}
+
+func TestInjectedErrors(t *testing.T) {
+	c, _ := paperClient(t)
+	c.InjectFaults(FaultConfig{EndpointErrorRates: map[string]float64{"/fapi/v1/ticker/price": 1}, TimestampErrorRate: 1})
+	ctx := context.Background()
+
+	_, err := c.GetTicker(ctx, "BTCUSDT")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("GetTicker err = %v, want an injected 502", err)
+	}
+
+	// Every signed request is rejected, the retry after resyncing too
+	_, err = c.GetAccountInfo(ctx)
+	if !errors.As(err, &apiErr) || apiErr.Code != errCodeTimestamp {
+		t.Errorf("GetAccountInfo err = %v, want -1021", err)
+	}
+
+	// Unlisted endpoints fall back to the overall rate of 0
+	if _, err := c.ServerTime(ctx); err != nil {
+		t.Errorf("ServerTime: %v", err)
+	}
+
+	stats := c.FaultStats()
+	if stats.Errors != 1 || stats.TimestampErrors != 2 {
+		t.Errorf("stats = %+v, want 1 error and 2 timestamp errors", stats)
+	}
+}
+
+func TestInjectedPartialFillAndHiddenPosition(t *testing.T) {
+	c, sim := paperClient(t)
+	c.InjectFaults(FaultConfig{PartialFillRate: 1, PositionDelay: time.Hour, Seed: 1})
+	ctx := context.Background()
+
+	order, err := c.PlaceOrder(ctx, "BTCUSDT", "BUY", "MARKET", 0.01, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "PARTIALLY_FILLED" || order.OrigQty != 0.01 || order.ExecutedQty != 0.005 {
+		t.Errorf("order = %+v, want 0.005 of 0.01 filled", order)
+	}
+	if got := sim.Positions()["BTCUSDT"]; got != 0.005 {
+		t.Errorf("exchange position = %v, want 0.005", got)
+	}
+
+	// The filled position stays out of position fetches for the delay
+	positions, err := c.GetPositions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 0 {
+		t.Errorf("positions = %+v, want the new one hidden", positions)
+	}
+
+	// Closes go through in full
+	if _, err := c.ClosePosition(ctx, "BTCUSDT", 0.005); err != nil {
+		t.Fatal(err)
+	}
+	if got := sim.Positions(); len(got) != 0 {
+		t.Errorf("exchange positions after close = %v", got)
+	}
+	if stats := c.FaultStats(); stats.PartialFills != 1 || stats.HiddenPositions != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
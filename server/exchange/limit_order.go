@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+)
+
+// errCodePostOnlyRejected is Binance's rejection of a post-only order that
+// would have filled as a taker
+const errCodePostOnlyRejected = -5022
+
+// ErrPostOnlyRejected is returned for a post-only order the book would have
+// filled straight away; re-price it behind the touch and place it again
+var ErrPostOnlyRejected = errors.New("post-only order would have taken liquidity")
+
+// PlaceLimitOrder places a limit order for quantity at price, good till
+// cancelled, or post-only (GTX) when postOnly so it only ever fills as a
+// maker. A post-only order that would have crossed the book returns
+// ErrPostOnlyRejected.
+func (c *BinanceClient) PlaceLimitOrder(ctx context.Context, symbol, side string, quantity, price float64, postOnly bool) (*Order, error) {
+	quantity = c.roundToStepSize(symbol, quantity)
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity below the step size of %s", symbol)
+	}
+	price = c.RoundPrice(symbol, price)
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", side)
+	params.Set("type", "LIMIT")
+	params.Set("quantity", strconv.FormatFloat(quantity, 'f', c.getQuantityPrecision(symbol), 64))
+	params.Set("price", strconv.FormatFloat(price, 'f', c.getPricePrecision(symbol), 64))
+	timeInForce := "GTC"
+	if postOnly {
+		timeInForce = "GTX"
+	}
+	params.Set("timeInForce", timeInForce)
+
+	log.Printf("[Binance] Placing %s LIMIT %s order: %s %s @ %s", timeInForce, side, symbol, params.Get("quantity"), params.Get("price"))
+	order, err := c.submitOrder(ctx, params)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == errCodePostOnlyRejected {
+		return nil, ErrPostOnlyRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Older API versions accept the order and expire it at once instead
+	if postOnly && order.Status == "EXPIRED" && order.ExecutedQty == 0 {
+		return nil, ErrPostOnlyRejected
+	}
+	return order, nil
+}
+
+// ReplaceLimitOrder cancels limit order orderID and places what it left
+// unfilled at price, post-only when postOnly. It returns the cancelled
+// order's final state, which may have filled further before the cancel
+// landed, and the replacement, nil when nothing was left to place. A failed
+// replacement is returned with the final state.
+func (c *BinanceClient) ReplaceLimitOrder(ctx context.Context, symbol string, orderID int64, price float64, postOnly bool) (old, replacement *Order, err error) {
+	// The order may have filled or expired since it was last read; its
+	// final state says which
+	cancelErr := c.CancelOrder(ctx, symbol, orderID)
+	old, err = c.GetOrder(ctx, symbol, orderID)
+	if err != nil {
+		if cancelErr != nil {
+			return nil, nil, fmt.Errorf("failed to cancel order %d: %w", orderID, cancelErr)
+		}
+		return nil, nil, fmt.Errorf("failed to read cancelled order %d: %w", orderID, err)
+	}
+	if old.Status == "NEW" || old.Status == "PARTIALLY_FILLED" {
+		if cancelErr == nil {
+			cancelErr = errors.New("cancel not applied yet")
+		}
+		return old, nil, fmt.Errorf("order %d still %s: %w", orderID, old.Status, cancelErr)
+	}
+
+	remaining := c.roundToStepSize(symbol, old.OrigQty-old.ExecutedQty)
+	if remaining <= 0 {
+		return old, nil, nil
+	}
+	replacement, err = c.PlaceLimitOrder(ctx, symbol, old.Side, remaining, price, postOnly)
+	return old, replacement, err
+}
@@ -0,0 +1,144 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// LeverageBracket is one notional tier of a symbol's maintenance margin
+// schedule. Cum is the maintenance amount that makes the tiers continuous.
+type LeverageBracket struct {
+	Bracket          int     `json:"bracket"`
+	InitialLeverage  int     `json:"initialLeverage"`
+	NotionalCap      float64 `json:"notionalCap"`
+	NotionalFloor    float64 `json:"notionalFloor"`
+	MaintMarginRatio float64 `json:"maintMarginRatio"`
+	Cum              float64 `json:"cum"`
+}
+
+// symbolBrackets is one entry of the /fapi/v1/leverageBracket response
+type symbolBrackets struct {
+	Symbol   string            `json:"symbol"`
+	Brackets []LeverageBracket `json:"brackets"`
+}
+
+// GetLeverageBrackets returns symbol's maintenance margin tiers, lowest
+// notional first
+func (c *BinanceClient) GetLeverageBrackets(ctx context.Context, symbol string) ([]LeverageBracket, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+
+	body, err := c.doRequest(ctx, "GET", "/fapi/v1/leverageBracket", params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// A list, or a single object on some API versions when symbol is sent
+	var list []symbolBrackets
+	if err := json.Unmarshal(body, &list); err != nil {
+		var single symbolBrackets
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil, fmt.Errorf("failed to parse leverage brackets: %w", err)
+		}
+		list = []symbolBrackets{single}
+	}
+	for _, sb := range list {
+		if sb.Symbol == symbol && len(sb.Brackets) > 0 {
+			return sb.Brackets, nil
+		}
+	}
+	return nil, fmt.Errorf("no leverage brackets for %s", symbol)
+}
+
+// Maintenance margin tiers used when a symbol's own aren't known. BTC and ETH
+// follow Binance's BTCUSDT schedule, other symbols a typical altcoin one.
+var (
+	defaultMajorBrackets = []LeverageBracket{
+		{1, 125, 50_000, 0, 0.004, 0},
+		{2, 100, 500_000, 50_000, 0.005, 50},
+		{3, 50, 8_000_000, 500_000, 0.01, 2_550},
+		{4, 20, 50_000_000, 8_000_000, 0.025, 122_550},
+		{5, 10, 80_000_000, 50_000_000, 0.05, 1_372_550},
+		{6, 5, 100_000_000, 80_000_000, 0.1, 5_372_550},
+		{7, 4, 200_000_000, 100_000_000, 0.125, 7_872_550},
+		{8, 3, 300_000_000, 200_000_000, 0.15, 12_872_550},
+		{9, 1, 500_000_000, 300_000_000, 0.25, 42_872_550},
+	}
+	defaultAltBrackets = []LeverageBracket{
+		{1, 50, 5_000, 0, 0.01, 0},
+		{2, 20, 50_000, 5_000, 0.025, 75},
+		{3, 10, 250_000, 50_000, 0.05, 1_325},
+		{4, 5, 1_000_000, 250_000, 0.1, 13_825},
+		{5, 4, 2_000_000, 1_000_000, 0.125, 38_825},
+		{6, 2, 5_000_000, 2_000_000, 0.25, 288_825},
+		{7, 1, 10_000_000, 5_000_000, 0.5, 1_538_825},
+	}
+)
+
+// DefaultLeverageBrackets returns the fallback tiers for symbol
+func DefaultLeverageBrackets(symbol string) []LeverageBracket {
+	switch symbol {
+	case "BTCUSDT", "ETHUSDT", "BTCUSD", "ETHUSD", "BTCUSDC", "ETHUSDC":
+		return defaultMajorBrackets
+	}
+	return defaultAltBrackets
+}
+
+// BracketFor returns the tier a position of the given notional falls in. Past
+// the last cap the last tier applies.
+func BracketFor(brackets []LeverageBracket, notional float64) LeverageBracket {
+	for _, b := range brackets {
+		if notional < b.NotionalCap {
+			return b
+		}
+	}
+	if len(brackets) == 0 {
+		return LeverageBracket{}
+	}
+	return brackets[len(brackets)-1]
+}
+
+// MaintenanceMargin is the margin a position of the given notional must keep
+// to avoid liquidation
+func MaintenanceMargin(brackets []LeverageBracket, notional float64) float64 {
+	b := BracketFor(brackets, notional)
+	return notional*b.MaintMarginRatio - b.Cum
+}
+
+// LiquidationPrice returns the price at which an isolated position of qty at
+// entry with the given margin is liquidated: where its margin plus unrealized
+// P&L falls to the maintenance margin. The tier is taken at the entry notional.
+// Returns 0 for a position that can't be liquidated, e.g. a long with margin
+// above its notional.
+func LiquidationPrice(isLong bool, entry, qty, margin float64, brackets []LeverageBracket) float64 {
+	if entry <= 0 || qty <= 0 {
+		return 0
+	}
+	b := BracketFor(brackets, entry*qty)
+
+	// margin + side*qty*(liq - entry) = qty*liq*mmr - cum, solved for liq
+	var liq float64
+	if isLong {
+		liq = (qty*entry - margin - b.Cum) / (qty * (1 - b.MaintMarginRatio))
+	} else {
+		liq = (qty*entry + margin + b.Cum) / (qty * (1 + b.MaintMarginRatio))
+	}
+	if liq < 0 {
+		return 0
+	}
+	return liq
+}
+
+// LiquidationDistancePct is how far mark has to move against the position to
+// reach liq, in percent of mark. 0 without a liquidation price.
+func LiquidationDistancePct(isLong bool, mark, liq float64) float64 {
+	if mark <= 0 || liq <= 0 {
+		return 0
+	}
+	if isLong {
+		return (mark - liq) / mark * 100
+	}
+	return (liq - mark) / mark * 100
+}
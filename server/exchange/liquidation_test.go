@@ -0,0 +1,78 @@
+package exchange
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLiquidationPrice(t *testing.T) {
+	flat := []LeverageBracket{{Bracket: 1, NotionalCap: math.MaxFloat64}}
+	// Without maintenance margin a 10x position loses its margin 10% away
+	if got := LiquidationPrice(true, 100, 1, 10, flat); math.Abs(got-90) > 1e-9 {
+		t.Errorf("long 10x without maintenance = %v, want 90", got)
+	}
+	if got := LiquidationPrice(false, 100, 1, 10, flat); math.Abs(got-110) > 1e-9 {
+		t.Errorf("short 10x without maintenance = %v, want 110", got)
+	}
+	// Margin above the notional can't be lost on a long
+	if got := LiquidationPrice(true, 100, 1, 150, flat); got != 0 {
+		t.Errorf("overcollateralized long = %v, want 0", got)
+	}
+
+	// BTC at 10x: $40k sits in the 0.4% tier, $2M in the 1% tier with $2,550 cum
+	brackets := DefaultLeverageBrackets("BTCUSDT")
+	small := LiquidationPrice(true, 40_000, 1, 4_000, brackets)
+	if want := (40_000.0 - 4_000) / (1 - 0.004); math.Abs(small-want) > 1e-6 {
+		t.Errorf("small long = %v, want %v", small, want)
+	}
+	large := LiquidationPrice(true, 40_000, 50, 200_000, brackets)
+	if want := (2_000_000.0 - 200_000 - 2_550) / (50 * (1 - 0.01)); math.Abs(large-want) > 1e-6 {
+		t.Errorf("large long = %v, want %v", large, want)
+	}
+	if large <= small {
+		t.Errorf("larger position liquidates at %v, not closer than the small one at %v", large, small)
+	}
+
+	// At the liquidation price the margin left equals the maintenance margin
+	liq := LiquidationPrice(false, 40_000, 50, 200_000, brackets)
+	left := 200_000 - 50*(liq-40_000)
+	if mm := 50*liq*0.01 - 2_550; math.Abs(left-mm) > 1e-6 {
+		t.Errorf("short at liq %v keeps %v, maintenance %v", liq, left, mm)
+	}
+}
+
+func TestBracketFor(t *testing.T) {
+	brackets := DefaultLeverageBrackets("SOLUSDT")
+	tests := []struct {
+		notional float64
+		want     int
+	}{
+		{1_000, 1},
+		{5_000, 2}, // The cap belongs to the next tier
+		{300_000, 4},
+		{1e12, 7},
+	}
+	for _, tt := range tests {
+		if got := BracketFor(brackets, tt.notional).Bracket; got != tt.want {
+			t.Errorf("BracketFor(%v) = %d, want %d", tt.notional, got, tt.want)
+		}
+	}
+	if got := MaintenanceMargin(brackets, 100_000); math.Abs(got-(5_000-1_325)) > 1e-9 {
+		t.Errorf("MaintenanceMargin(100k) = %v", got)
+	}
+	if DefaultLeverageBrackets("ETHUSDC")[0].MaintMarginRatio != 0.004 {
+		t.Error("ETHUSDC doesn't get the major tiers")
+	}
+}
+
+func TestLiquidationDistancePct(t *testing.T) {
+	if got := LiquidationDistancePct(true, 100, 90); math.Abs(got-10) > 1e-9 {
+		t.Errorf("long distance = %v", got)
+	}
+	if got := LiquidationDistancePct(false, 100, 105); math.Abs(got-5) > 1e-9 {
+		t.Errorf("short distance = %v", got)
+	}
+	if got := LiquidationDistancePct(true, 100, 0); got != 0 {
+		t.Errorf("distance without liquidation price = %v", got)
+	}
+}
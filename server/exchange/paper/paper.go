@@ -0,0 +1,923 @@
+package paper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	takerFee = 0.0004 // Commission on fills that take liquidity, as a share of their notional
+	makerFee = 0.0002 // Commission on resting limit orders the price reached
+
+	errPostOnlyRejected = -5022
+
+	errUnknownOrder = -2011
+	errReduceOnly   = -2022
+	errSignature    = -1022
+	errBadParam     = -1102
+)
+
+// Exchange is an in-memory Binance USDⓈ-M futures API for paper trading and
+// tests. It serves the endpoints the exchange client uses, in one-way position
+// mode, and fills market orders at the price set with SetPrice. Limit orders
+// fill at once when marketable, otherwise at their price as makers once
+// SetPrice reaches it.
+type Exchange struct {
+	secretKey string
+
+	mu        sync.Mutex
+	balance   float64 // Wallet balance, USDT
+	symbols   map[string]*symbol
+	orders    map[int64]*order
+	algo      map[int64]*algoOrder
+	trades    []trade
+	income    []income
+	fills     []Fill
+	nextID    int64
+	autoClose map[string]int64 // key: symbol -> countdown ms
+}
+
+type symbol struct {
+	name         string
+	price        float64
+	qtyPrecision int
+	leverage     int
+
+	positionAmt float64 // Negative when short
+	entryPrice  float64
+}
+
+type order struct {
+	ID          int64
+	Symbol      string
+	Side        string
+	Type        string
+	Status      string
+	Price       float64
+	AvgPrice    float64
+	OrigQty     float64
+	ExecutedQty float64
+	ReduceOnly  bool
+	TimeInForce string
+	Maker       bool // Filled resting on the book, at Price
+	Time        int64
+}
+
+type algoOrder struct {
+	ID           int64
+	Symbol       string
+	Side         string
+	Type         string // STOP_MARKET or TAKE_PROFIT_MARKET
+	TriggerPrice float64
+	Time         int64
+}
+
+type trade struct {
+	ID          int64
+	Symbol      string
+	OrderID     int64
+	Side        string
+	Price       float64
+	Qty         float64
+	RealizedPnL float64
+	Commission  float64
+	Maker       bool
+	Time        int64
+}
+
+type income struct {
+	TranID int64
+	Symbol string
+	Type   string
+	Amount float64
+	Time   int64
+}
+
+// Fill is one executed order, with the position before and after it
+type Fill struct {
+	OrderID        int64
+	Symbol         string
+	Side           string
+	Qty            float64
+	Price          float64
+	ReduceOnly     bool
+	Maker          bool   // A resting limit order the price reached
+	Trigger        string // STOP_MARKET or TAKE_PROFIT_MARKET for a triggered SL/TP
+	PositionBefore float64
+	PositionAfter  float64
+}
+
+// New creates an exchange with balance USDT in the wallet. Signed requests
+// must be signed with secretKey.
+func New(balance float64, secretKey string) *Exchange {
+	return &Exchange{
+		secretKey: secretKey,
+		balance:   balance,
+		symbols:   make(map[string]*symbol),
+		orders:    make(map[int64]*order),
+		algo:      make(map[int64]*algoOrder),
+		autoClose: make(map[string]int64),
+		nextID:    1000,
+	}
+}
+
+// AddSymbol lists a perpetual at price, traded in steps of 10^-qtyPrecision
+func (x *Exchange) AddSymbol(name string, price float64, qtyPrecision int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.symbols[name] = &symbol{name: name, price: price, qtyPrecision: qtyPrecision, leverage: 20}
+}
+
+// SetPrice moves symbol's price, triggering the SL/TP orders it crosses and
+// filling the limit orders it reaches
+func (x *Exchange) SetPrice(name string, price float64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	s, ok := x.symbols[name]
+	if !ok {
+		return
+	}
+	s.price = price
+
+	ids := make([]int64, 0, len(x.algo))
+	for id := range x.algo {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		a := x.algo[id]
+		if a.Symbol != name || !triggered(a, price) {
+			continue
+		}
+		delete(x.algo, id)
+		if s.positionAmt == 0 || (a.Side == "SELL") != (s.positionAmt > 0) {
+			continue // Nothing for it to close
+		}
+		x.fill(s, &order{ID: x.newID(), Symbol: name, Side: a.Side, Type: "MARKET", OrigQty: math.Abs(s.positionAmt), ReduceOnly: true}, a.Type)
+	}
+
+	for _, o := range x.sortedOrders() {
+		if o.Symbol != name || o.Type != "LIMIT" || o.Status != "NEW" || !marketable(o, price) {
+			continue
+		}
+		if o.ReduceOnly && (s.positionAmt == 0 || (o.Side == "SELL") != (s.positionAmt > 0)) {
+			o.Status = "EXPIRED"
+			continue
+		}
+		o.Maker = true
+		x.fill(s, o, "")
+	}
+}
+
+// marketable reports whether limit order o fills at price
+func marketable(o *order, price float64) bool {
+	if o.Side == "BUY" {
+		return price <= o.Price
+	}
+	return price >= o.Price
+}
+
+func triggered(a *algoOrder, price float64) bool {
+	// A stop sells below its trigger and buys above it, a take-profit the reverse
+	sellsLow := (a.Type == "STOP_MARKET") == (a.Side == "SELL")
+	if sellsLow {
+		return price <= a.TriggerPrice
+	}
+	return price >= a.TriggerPrice
+}
+
+// Positions returns the open positions by symbol, negative when short
+func (x *Exchange) Positions() map[string]float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	positions := make(map[string]float64)
+	for name, s := range x.symbols {
+		if s.positionAmt != 0 {
+			positions[name] = s.positionAmt
+		}
+	}
+	return positions
+}
+
+// OpenAlgoOrders returns the types of symbol's open SL/TP orders
+func (x *Exchange) OpenAlgoOrders(name string) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var types []string
+	for _, a := range x.algo {
+		if a.Symbol == name {
+			types = append(types, a.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Fills returns every executed order, oldest first
+func (x *Exchange) Fills() []Fill {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return append([]Fill(nil), x.fills...)
+}
+
+// Balance returns the wallet balance
+func (x *Exchange) Balance() float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.balance
+}
+
+func (x *Exchange) newID() int64 {
+	x.nextID++
+	return x.nextID
+}
+
+// apiError is Binance's error body
+type apiError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	writeJSON(w, status, apiError{Code: code, Msg: msg})
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// params returns the request's query and form values together
+func params(r *http.Request) (url.Values, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return r.Form, nil
+}
+
+// validSignature checks the HMAC of the request's parameters
+func (x *Exchange) validSignature(p url.Values) bool {
+	sig := p.Get("signature")
+	if sig == "" {
+		return false
+	}
+	unsigned := url.Values{}
+	for k, v := range p {
+		if k != "signature" {
+			unsigned[k] = v
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(x.secretKey))
+	mac.Write([]byte(unsigned.Encode()))
+	return hmac.Equal([]byte(sig), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+func (x *Exchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p, err := params(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errBadParam, err.Error())
+		return
+	}
+	// Signed requests carry a timestamp
+	if p.Get("timestamp") != "" && !x.validSignature(p) {
+		writeError(w, http.StatusBadRequest, errSignature, "Signature for this request is not valid.")
+		return
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	route := r.Method + " " + r.URL.Path
+	switch route {
+	case "GET /fapi/v1/ping":
+		writeJSON(w, http.StatusOK, struct{}{})
+	case "GET /fapi/v1/time":
+		writeJSON(w, http.StatusOK, map[string]int64{"serverTime": time.Now().UnixMilli()})
+	case "GET /fapi/v1/exchangeInfo":
+		x.exchangeInfo(w)
+	case "GET /fapi/v2/account":
+		x.account(w)
+	case "GET /fapi/v2/positionRisk":
+		x.positionRisk(w, p)
+	case "GET /fapi/v1/ticker/price":
+		x.tickerPrice(w, p)
+	case "GET /fapi/v1/ticker/24hr":
+		x.ticker24h(w, p)
+	case "GET /fapi/v1/premiumIndex":
+		x.premiumIndex(w, p)
+	case "GET /fapi/v1/depth":
+		x.depth(w, p)
+	case "GET /fapi/v1/klines":
+		x.klines(w, p)
+	case "GET /futures/data/openInterestHist":
+		writeJSON(w, http.StatusOK, []struct{}{})
+	case "POST /fapi/v1/leverage":
+		x.setLeverage(w, p)
+	case "POST /fapi/v1/order":
+		x.placeOrder(w, p)
+	case "GET /fapi/v1/order":
+		x.getOrder(w, p)
+	case "DELETE /fapi/v1/order":
+		x.cancelOrder(w, p)
+	case "GET /fapi/v1/openOrders":
+		x.openOrders(w, p)
+	case "DELETE /fapi/v1/allOpenOrders":
+		x.cancelAllOrders(w, p)
+	case "POST /fapi/v1/algoOrder":
+		x.placeAlgoOrder(w, p)
+	case "DELETE /fapi/v1/algoOrder":
+		x.cancelAlgoOrder(w, p)
+	case "GET /fapi/v1/openAlgoOrders":
+		x.openAlgoOrders(w, p)
+	case "GET /fapi/v1/userTrades":
+		x.userTrades(w, p)
+	case "GET /fapi/v1/income":
+		x.incomeHistory(w, p)
+	case "POST /fapi/v1/countdownCancelAll":
+		ms, _ := strconv.ParseInt(p.Get("countdownTime"), 10, 64)
+		x.autoClose[p.Get("symbol")] = ms
+		writeJSON(w, http.StatusOK, map[string]string{"symbol": p.Get("symbol"), "countdownTime": p.Get("countdownTime")})
+	default:
+		writeError(w, http.StatusNotFound, -1000, "Unknown endpoint "+route)
+	}
+}
+
+func (x *Exchange) symbolParam(w http.ResponseWriter, p url.Values) (*symbol, bool) {
+	s, ok := x.symbols[p.Get("symbol")]
+	if !ok {
+		writeError(w, http.StatusBadRequest, -1121, "Invalid symbol.")
+	}
+	return s, ok
+}
+
+func (x *Exchange) exchangeInfo(w http.ResponseWriter) {
+	type filter struct {
+		FilterType string `json:"filterType"`
+		MinQty     string `json:"minQty"`
+		StepSize   string `json:"stepSize"`
+	}
+	type symbolInfo struct {
+		Symbol            string   `json:"symbol"`
+		Status            string   `json:"status"`
+		ContractType      string   `json:"contractType"`
+		QuoteAsset        string   `json:"quoteAsset"`
+		DeliveryDate      int64    `json:"deliveryDate"`
+		QuantityPrecision int      `json:"quantityPrecision"`
+		PricePrecision    int      `json:"pricePrecision"`
+		Filters           []filter `json:"filters"`
+	}
+	infos := make([]symbolInfo, 0, len(x.symbols))
+	for _, s := range x.symbols {
+		step := num(math.Pow10(-s.qtyPrecision))
+		infos = append(infos, symbolInfo{
+			Symbol:            s.name,
+			Status:            "TRADING",
+			ContractType:      "PERPETUAL",
+			QuoteAsset:        "USDT",
+			DeliveryDate:      4133404800000,
+			QuantityPrecision: s.qtyPrecision,
+			PricePrecision:    2,
+			Filters:           []filter{{FilterType: "LOT_SIZE", MinQty: step, StepSize: step}},
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Symbol < infos[j].Symbol })
+	writeJSON(w, http.StatusOK, map[string]interface{}{"symbols": infos})
+}
+
+// unrealized returns the unrealized P&L and initial margin of the positions
+func (x *Exchange) unrealized() (pnl, margin float64) {
+	for _, s := range x.symbols {
+		if s.positionAmt == 0 {
+			continue
+		}
+		pnl += (s.price - s.entryPrice) * s.positionAmt
+		margin += math.Abs(s.positionAmt) * s.price / float64(s.leverage)
+	}
+	return pnl, margin
+}
+
+func (x *Exchange) account(w http.ResponseWriter) {
+	pnl, margin := x.unrealized()
+	available := num(math.Max(0, x.balance+pnl-margin))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"feeTier":               0,
+		"canTrade":              true,
+		"canDeposit":            true,
+		"canWithdraw":           false,
+		"totalWalletBalance":    num(x.balance),
+		"availableBalance":      available,
+		"totalUnrealizedProfit": num(pnl),
+		"totalMarginBalance":    num(x.balance + pnl),
+		"assets": []map[string]string{{
+			"asset":            "USDT",
+			"walletBalance":    num(x.balance),
+			"availableBalance": available,
+			"marginBalance":    num(x.balance + pnl),
+		}},
+	})
+}
+
+func (x *Exchange) positionRisk(w http.ResponseWriter, p url.Values) {
+	names := make([]string, 0, len(x.symbols))
+	for name := range x.symbols {
+		if symbol := p.Get("symbol"); symbol == "" || symbol == name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	positions := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		s := x.symbols[name]
+		positions = append(positions, map[string]interface{}{
+			"symbol":           s.name,
+			"positionAmt":      num(s.positionAmt),
+			"entryPrice":       num(s.entryPrice),
+			"markPrice":        num(s.price),
+			"unRealizedProfit": num((s.price - s.entryPrice) * s.positionAmt),
+			"unrealizedProfit": num((s.price - s.entryPrice) * s.positionAmt),
+			"leverage":         strconv.Itoa(s.leverage),
+			"liquidationPrice": "0",
+			"positionSide":     "BOTH",
+			"updateTime":       time.Now().UnixMilli(),
+		})
+	}
+	writeJSON(w, http.StatusOK, positions)
+}
+
+func (x *Exchange) tickerPrice(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"symbol": s.name, "price": num(s.price), "time": time.Now().UnixMilli()})
+}
+
+func (x *Exchange) ticker24hOf(s *symbol) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":             s.name,
+		"priceChangePercent": "0",
+		"lastPrice":          num(s.price),
+		"volume":             "1000000",
+		"quoteVolume":        num(1000000 * s.price),
+		"count":              100000,
+	}
+}
+
+func (x *Exchange) ticker24h(w http.ResponseWriter, p url.Values) {
+	if p.Get("symbol") != "" {
+		if s, ok := x.symbolParam(w, p); ok {
+			writeJSON(w, http.StatusOK, x.ticker24hOf(s))
+		}
+		return
+	}
+	tickers := make([]map[string]interface{}, 0, len(x.symbols))
+	for _, s := range x.symbols {
+		tickers = append(tickers, x.ticker24hOf(s))
+	}
+	writeJSON(w, http.StatusOK, tickers)
+}
+
+func (x *Exchange) premiumIndexOf(s *symbol) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":          s.name,
+		"markPrice":       num(s.price),
+		"lastFundingRate": "0.0001",
+		"nextFundingTime": time.Now().Truncate(8 * time.Hour).Add(8 * time.Hour).UnixMilli(),
+	}
+}
+
+func (x *Exchange) premiumIndex(w http.ResponseWriter, p url.Values) {
+	if p.Get("symbol") != "" {
+		if s, ok := x.symbolParam(w, p); ok {
+			writeJSON(w, http.StatusOK, x.premiumIndexOf(s))
+		}
+		return
+	}
+	all := make([]map[string]interface{}, 0, len(x.symbols))
+	for _, s := range x.symbols {
+		all = append(all, x.premiumIndexOf(s))
+	}
+	writeJSON(w, http.StatusOK, all)
+}
+
+// depth serves a deep book a tick either side of the price
+func (x *Exchange) depth(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(p.Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	tick := s.price * 0.0001
+	bids := make([][]string, 0, limit)
+	asks := make([][]string, 0, limit)
+	for i := 1; i <= limit; i++ {
+		bids = append(bids, []string{num(s.price - float64(i)*tick), "1000000"})
+		asks = append(asks, []string{num(s.price + float64(i)*tick), "1000000"})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"lastUpdateId": x.newID(), "bids": bids, "asks": asks})
+}
+
+// klines serves flat candles at the current price
+func (x *Exchange) klines(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(p.Get("limit"))
+	if limit <= 0 || limit > 1500 {
+		limit = 500
+	}
+	interval := time.Minute
+	now := time.Now().Truncate(interval)
+	klines := make([][]interface{}, 0, limit)
+	for i := limit - 1; i >= 0; i-- {
+		open := now.Add(-time.Duration(i) * interval)
+		wiggle := s.price * 0.001 * math.Sin(float64(i))
+		klines = append(klines, []interface{}{
+			open.UnixMilli(), num(s.price - wiggle), num(s.price * 1.002), num(s.price * 0.998), num(s.price), "1000",
+			open.Add(interval).UnixMilli() - 1,
+		})
+	}
+	writeJSON(w, http.StatusOK, klines)
+}
+
+func (x *Exchange) setLeverage(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	leverage, err := strconv.Atoi(p.Get("leverage"))
+	if err != nil || leverage < 1 || leverage > 125 {
+		writeError(w, http.StatusBadRequest, -4028, "Leverage is not valid")
+		return
+	}
+	s.leverage = leverage
+	writeJSON(w, http.StatusOK, map[string]interface{}{"symbol": s.name, "leverage": leverage, "maxNotionalValue": "1000000"})
+}
+
+func (x *Exchange) orderJSON(o *order) map[string]interface{} {
+	return map[string]interface{}{
+		"orderId":      o.ID,
+		"symbol":       o.Symbol,
+		"status":       o.Status,
+		"side":         o.Side,
+		"positionSide": "BOTH",
+		"type":         o.Type,
+		"price":        num(o.Price),
+		"avgPrice":     num(o.AvgPrice),
+		"origQty":      num(o.OrigQty),
+		"executedQty":  num(o.ExecutedQty),
+		"reduceOnly":   o.ReduceOnly,
+		"time":         o.Time,
+		"updateTime":   o.Time,
+	}
+}
+
+func (x *Exchange) placeOrder(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	qty, err := strconv.ParseFloat(p.Get("quantity"), 64)
+	if err != nil || qty <= 0 {
+		writeError(w, http.StatusBadRequest, errBadParam, "Parameter 'quantity' was empty or invalid.")
+		return
+	}
+	side := p.Get("side")
+	if side != "BUY" && side != "SELL" {
+		writeError(w, http.StatusBadRequest, errBadParam, "Parameter 'side' was invalid.")
+		return
+	}
+	o := &order{
+		ID:          x.newID(),
+		Symbol:      s.name,
+		Side:        side,
+		Type:        p.Get("type"),
+		Status:      "NEW",
+		OrigQty:     qty,
+		ReduceOnly:  p.Get("reduceOnly") == "true",
+		TimeInForce: p.Get("timeInForce"),
+		Time:        time.Now().UnixMilli(),
+	}
+	if o.ReduceOnly && (s.positionAmt == 0 || (side == "SELL") != (s.positionAmt > 0)) {
+		writeError(w, http.StatusBadRequest, errReduceOnly, "ReduceOnly Order is rejected.")
+		return
+	}
+
+	switch o.Type {
+	case "MARKET":
+		x.fill(s, o, "")
+	case "LIMIT":
+		o.Price, _ = strconv.ParseFloat(p.Get("price"), 64)
+		if o.Price <= 0 {
+			writeError(w, http.StatusBadRequest, errBadParam, "Parameter 'price' was empty or invalid.")
+			return
+		}
+		if marketable(o, s.price) {
+			if o.TimeInForce == "GTX" {
+				writeError(w, http.StatusBadRequest, errPostOnlyRejected,
+					"Due to the order could not be executed as maker, the Post Only order will be rejected.")
+				return
+			}
+			x.fill(s, o, "")
+		}
+	default:
+		writeError(w, http.StatusBadRequest, -1116, "Invalid orderType.")
+		return
+	}
+	x.orders[o.ID] = o
+	writeJSON(w, http.StatusOK, x.orderJSON(o))
+}
+
+// fill executes o in full at the symbol's price and moves the position
+func (x *Exchange) fill(s *symbol, o *order, trigger string) {
+	qty := o.OrigQty
+	signed := qty
+	if o.Side == "SELL" {
+		signed = -qty
+	}
+	if o.ReduceOnly {
+		// Never past flat
+		if math.Abs(signed) > math.Abs(s.positionAmt) {
+			signed = -s.positionAmt
+			qty = math.Abs(signed)
+		}
+	}
+
+	before := s.positionAmt
+	price, feeRate := s.price, takerFee
+	if o.Maker {
+		price, feeRate = o.Price, makerFee
+	}
+	var realized float64
+	switch {
+	case before == 0 || (before > 0) == (signed > 0):
+		// Opening or adding: average the entry
+		total := before + signed
+		s.entryPrice = (s.entryPrice*math.Abs(before) + price*math.Abs(signed)) / math.Abs(total)
+		s.positionAmt = total
+	default:
+		closed := math.Min(math.Abs(signed), math.Abs(before))
+		direction := 1.0
+		if before < 0 {
+			direction = -1
+		}
+		realized = (price - s.entryPrice) * closed * direction
+		s.positionAmt = before + signed
+		switch {
+		case math.Abs(s.positionAmt) < 1e-12:
+			s.positionAmt = 0
+			s.entryPrice = 0
+		case (s.positionAmt > 0) != (before > 0):
+			s.entryPrice = price // Flipped
+		}
+	}
+	s.positionAmt = roundQty(s.positionAmt, s.qtyPrecision)
+
+	now := time.Now().UnixMilli()
+	fee := qty * price * feeRate
+	x.balance += realized - fee
+	o.Status = "FILLED"
+	o.AvgPrice = price
+	o.ExecutedQty = qty
+	o.Time = now
+	x.orders[o.ID] = o
+
+	tradeID := x.newID()
+	x.trades = append(x.trades, trade{ID: tradeID, Symbol: s.name, OrderID: o.ID, Side: o.Side, Price: price, Qty: qty,
+		RealizedPnL: realized, Commission: fee, Maker: o.Maker, Time: now})
+	if realized != 0 {
+		x.income = append(x.income, income{TranID: x.newID(), Symbol: s.name, Type: "REALIZED_PNL", Amount: realized, Time: now})
+	}
+	x.income = append(x.income, income{TranID: x.newID(), Symbol: s.name, Type: "COMMISSION", Amount: -fee, Time: now})
+	x.fills = append(x.fills, Fill{OrderID: o.ID, Symbol: s.name, Side: o.Side, Qty: qty, Price: price,
+		ReduceOnly: o.ReduceOnly, Maker: o.Maker, Trigger: trigger, PositionBefore: before, PositionAfter: s.positionAmt})
+}
+
+func roundQty(qty float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(qty*scale) / scale
+}
+
+func (x *Exchange) orderParam(w http.ResponseWriter, p url.Values) (*order, bool) {
+	id, _ := strconv.ParseInt(p.Get("orderId"), 10, 64)
+	o, ok := x.orders[id]
+	if !ok || o.Symbol != p.Get("symbol") {
+		writeError(w, http.StatusBadRequest, errUnknownOrder, "Unknown order sent.")
+		return nil, false
+	}
+	return o, true
+}
+
+func (x *Exchange) getOrder(w http.ResponseWriter, p url.Values) {
+	if o, ok := x.orderParam(w, p); ok {
+		writeJSON(w, http.StatusOK, x.orderJSON(o))
+	}
+}
+
+func (x *Exchange) cancelOrder(w http.ResponseWriter, p url.Values) {
+	o, ok := x.orderParam(w, p)
+	if !ok {
+		return
+	}
+	if o.Status != "NEW" && o.Status != "PARTIALLY_FILLED" {
+		writeError(w, http.StatusBadRequest, errUnknownOrder, "Unknown order sent.")
+		return
+	}
+	o.Status = "CANCELED"
+	writeJSON(w, http.StatusOK, x.orderJSON(o))
+}
+
+func (x *Exchange) openOrders(w http.ResponseWriter, p url.Values) {
+	symbol := p.Get("symbol")
+	open := make([]map[string]interface{}, 0)
+	for _, o := range x.sortedOrders() {
+		if o.Status == "NEW" && (symbol == "" || o.Symbol == symbol) {
+			open = append(open, x.orderJSON(o))
+		}
+	}
+	writeJSON(w, http.StatusOK, open)
+}
+
+func (x *Exchange) sortedOrders() []*order {
+	orders := make([]*order, 0, len(x.orders))
+	for _, o := range x.orders {
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+func (x *Exchange) cancelAllOrders(w http.ResponseWriter, p url.Values) {
+	if _, ok := x.symbolParam(w, p); !ok {
+		return
+	}
+	for _, o := range x.orders {
+		if o.Symbol == p.Get("symbol") && o.Status == "NEW" {
+			o.Status = "CANCELED"
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 200, "msg": "The operation of cancel all open order is done."})
+}
+
+func (x *Exchange) algoJSON(a *algoOrder) map[string]interface{} {
+	return map[string]interface{}{
+		"algoId":        a.ID,
+		"algoStatus":    "NEW",
+		"algoType":      "CONDITIONAL",
+		"orderType":     a.Type,
+		"symbol":        a.Symbol,
+		"side":          a.Side,
+		"positionSide":  "BOTH",
+		"quantity":      "0",
+		"triggerPrice":  num(a.TriggerPrice),
+		"closePosition": true,
+		"createTime":    a.Time,
+		"updateTime":    a.Time,
+	}
+}
+
+func (x *Exchange) placeAlgoOrder(w http.ResponseWriter, p url.Values) {
+	s, ok := x.symbolParam(w, p)
+	if !ok {
+		return
+	}
+	trigger, err := strconv.ParseFloat(p.Get("triggerPrice"), 64)
+	if err != nil || trigger <= 0 {
+		writeError(w, http.StatusBadRequest, errBadParam, "Parameter 'triggerPrice' was empty or invalid.")
+		return
+	}
+	a := &algoOrder{ID: x.newID(), Symbol: s.name, Side: p.Get("side"), Type: p.Get("type"), TriggerPrice: trigger, Time: time.Now().UnixMilli()}
+	if a.Type != "STOP_MARKET" && a.Type != "TAKE_PROFIT_MARKET" {
+		writeError(w, http.StatusBadRequest, -1116, "Invalid orderType.")
+		return
+	}
+	// Binance refuses a conditional order that would trigger at once
+	if triggered(a, s.price) {
+		writeError(w, http.StatusBadRequest, -2021, "Order would immediately trigger.")
+		return
+	}
+	x.algo[a.ID] = a
+	writeJSON(w, http.StatusOK, x.algoJSON(a))
+}
+
+func (x *Exchange) cancelAlgoOrder(w http.ResponseWriter, p url.Values) {
+	id, _ := strconv.ParseInt(p.Get("algoId"), 10, 64)
+	if _, ok := x.algo[id]; !ok {
+		writeError(w, http.StatusBadRequest, errUnknownOrder, "Unknown order sent.")
+		return
+	}
+	delete(x.algo, id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"algoId": id, "code": "200", "msg": "success"})
+}
+
+func (x *Exchange) openAlgoOrders(w http.ResponseWriter, p url.Values) {
+	symbol := p.Get("symbol")
+	ids := make([]int64, 0, len(x.algo))
+	for id, a := range x.algo {
+		if symbol == "" || a.Symbol == symbol {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	open := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		open = append(open, x.algoJSON(x.algo[id]))
+	}
+	writeJSON(w, http.StatusOK, open)
+}
+
+func (x *Exchange) userTrades(w http.ResponseWriter, p url.Values) {
+	symbol := p.Get("symbol")
+	orderID, _ := strconv.ParseInt(p.Get("orderId"), 10, 64)
+	start, _ := strconv.ParseInt(p.Get("startTime"), 10, 64)
+	limit, _ := strconv.Atoi(p.Get("limit"))
+	if limit <= 0 {
+		limit = 500
+	}
+	trades := make([]map[string]interface{}, 0)
+	for _, t := range x.trades {
+		if (symbol != "" && t.Symbol != symbol) || (orderID != 0 && t.OrderID != orderID) || t.Time < start {
+			continue
+		}
+		trades = append(trades, map[string]interface{}{
+			"id":              t.ID,
+			"symbol":          t.Symbol,
+			"orderId":         t.OrderID,
+			"side":            t.Side,
+			"price":           num(t.Price),
+			"qty":             num(t.Qty),
+			"realizedPnl":     num(t.RealizedPnL),
+			"quoteQty":        num(t.Price * t.Qty),
+			"commission":      num(t.Commission),
+			"commissionAsset": "USDT",
+			"time":            t.Time,
+			"positionSide":    "BOTH",
+			"buyer":           t.Side == "BUY",
+			"maker":           t.Maker,
+		})
+		if len(trades) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
+
+func (x *Exchange) incomeHistory(w http.ResponseWriter, p url.Values) {
+	symbol := p.Get("symbol")
+	incomeType := p.Get("incomeType")
+	start, _ := strconv.ParseInt(p.Get("startTime"), 10, 64)
+	limit, _ := strconv.Atoi(p.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	entries := make([]map[string]interface{}, 0)
+	for _, in := range x.income {
+		if (symbol != "" && in.Symbol != symbol) || (incomeType != "" && in.Type != incomeType) || in.Time < start {
+			continue
+		}
+		entries = append(entries, map[string]interface{}{
+			"symbol":     in.Symbol,
+			"incomeType": in.Type,
+			"income":     num(in.Amount),
+			"asset":      "USDT",
+			"info":       "",
+			"time":       in.Time,
+			"tranId":     in.TranID,
+			"tradeId":    "",
+		})
+		if len(entries) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// String summarizes the account, for test failures
+func (x *Exchange) String() string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return fmt.Sprintf("paper exchange: balance %.2f, %d fills, %d open SL/TP", x.balance, len(x.fills), len(x.algo))
+}
@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerSec paces REST requests to Binance. Its limits are per IP,
+// 2400 request weight a minute on futures, so every client shares one budget.
+const DefaultRequestsPerSec = 20
+
+// requestLimiter is a token bucket: a burst of up to one second's requests,
+// then requests at the rate as tokens refill
+type requestLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens a second, 0 = unlimited
+	tokens float64
+	last   time.Time
+}
+
+var restLimiter = newRequestLimiter(DefaultRequestsPerSec)
+
+func newRequestLimiter(perSec int) *requestLimiter {
+	return &requestLimiter{rate: float64(perSec), tokens: float64(perSec), last: time.Now()}
+}
+
+// SetRequestRate changes the REST requests per second shared by all clients,
+// 0 = unlimited
+func SetRequestRate(perSec int) {
+	restLimiter.setRate(perSec)
+}
+
+func (l *requestLimiter) setRate(perSec int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(perSec)
+	l.tokens = min(l.tokens, l.rate)
+}
+
+// wait blocks until a request may be sent or ctx is done
+func (l *requestLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		now := time.Now()
+		l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiterPaces(t *testing.T) {
+	l := newRequestLimiter(50)
+
+	start := time.Now()
+	for i := 0; i < 60; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first 50 are the burst, the other 10 come 20ms apart
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("60 requests at 50/s with a burst of 50 took %v, want about 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.setRate(1)
+	l.tokens = 0
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Errorf("wait canceled = %v", err)
+	}
+
+	l.setRate(0)
+	if err := l.wait(context.Background()); err != nil {
+		t.Errorf("unlimited wait = %v", err)
+	}
+}
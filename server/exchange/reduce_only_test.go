@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// orderClient answers every request with status and body, keeping the form
+// of the last order it was sent
+func orderClient(status int, body string) (*BinanceClient, url.Values) {
+	sent := url.Values{}
+	transport := roundTripFunc(func(req *http.Request) *http.Response {
+		form, _ := io.ReadAll(req.Body)
+		values, _ := url.ParseQuery(string(form))
+		for k, v := range values {
+			sent[k] = v
+		}
+		return jsonResponse(status, body)
+	})
+	return &BinanceClient{baseURL: "https://fapi.test", httpClient: &http.Client{Transport: transport}}, sent
+}
+
+func TestReduceOnlyRejection(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		reduceOnly bool
+		response   string
+		want       bool // ErrReduceOnlyRejected
+	}{
+		{"flat position", true, `{"code":-2022,"msg":"ReduceOnly Order is rejected."}`, true},
+		{"other rejection", true, `{"code":-2019,"msg":"Margin is insufficient."}`, false},
+		{"not reduce-only", false, `{"code":-2022,"msg":"ReduceOnly Order is rejected."}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, sent := orderClient(http.StatusBadRequest, tc.response)
+
+			_, err := c.PlaceOrder(context.Background(), "BTCUSDT", "SELL", "MARKET", 0.01, 0, tc.reduceOnly)
+			if got := errors.Is(err, ErrReduceOnlyRejected); got != tc.want {
+				t.Errorf("err = %v, reduce-only rejection %v, want %v", err, got, tc.want)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Errorf("err = %v, want the APIError kept", err)
+			}
+			if got := sent.Get("reduceOnly") == "true"; got != tc.reduceOnly {
+				t.Errorf("reduceOnly sent = %q, want %v", sent.Get("reduceOnly"), tc.reduceOnly)
+			}
+		})
+	}
+}
+
+// TestClosePositionReduceOnly checks closes can only shrink the position
+func TestClosePositionReduceOnly(t *testing.T) {
+	c, sent := orderClient(http.StatusOK, `{"orderId":1,"symbol":"BTCUSDT","status":"FILLED","side":"BUY","type":"MARKET"}`)
+
+	if _, err := c.ClosePosition(context.Background(), "BTCUSDT", -0.01); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Get("side") != "BUY" || sent.Get("reduceOnly") != "true" {
+		t.Errorf("close sent %v, want a reduce-only BUY", sent)
+	}
+}
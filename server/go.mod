@@ -5,5 +5,14 @@ go 1.23
 require (
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.12.3
 	github.com/mattn/go-sqlite3 v1.14.32
+	golang.org/x/crypto v0.33.0
+)
+
+require github.com/gorilla/websocket v1.5.3
+
+require (
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 )
@@ -7,11 +7,15 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"auto-trader-ahh/api"
 	"auto-trader-ahh/config"
 	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
 	"auto-trader-ahh/logger"
+	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/report"
 	"auto-trader-ahh/store"
 	"auto-trader-ahh/trader"
 )
@@ -34,6 +38,9 @@ func main() {
 	if cfg.OpenRouterAPIKey == "" {
 		log.Printf("Warning: OPENROUTER_API_KEY not set in environment. Traders must provide their own keys.")
 	}
+	if err := api.CheckTLS(cfg); err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
 
 	log.Printf("Configuration loaded:")
 	log.Printf("  - AI Model: %s", cfg.OpenRouterModel)
@@ -43,18 +50,38 @@ func main() {
 	log.Printf("  - Default Interval: %d minutes", cfg.TradingInterval)
 	fmt.Println()
 
+	// Space out AI calls per provider, e.g. for free-tier per-minute limits
+	mcp.ConfigureSchedulers(mcp.SchedulerConfig{
+		MinInterval:   time.Duration(cfg.AIMinIntervalMs) * time.Millisecond,
+		MaxConcurrent: cfg.AIMaxConcurrent,
+		QueueTimeout:  time.Duration(cfg.AIQueueTimeoutSecs) * time.Second,
+	})
+
+	exchange.SetRequestRate(cfg.BinanceRequestsPerSec)
+
 	// Initialize database
-	if err := store.Init("data"); err != nil {
+	if err := store.Init("data", cfg.DatabaseURL); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer store.Close()
 
+	// Roll up old equity snapshots, migrating existing history on the first run, then hourly
+	go store.NewEquityStore().RunRollups(cfg.EquityRawRetentionDays, time.Hour)
+
 	// Create event hub
 	hub := events.NewHub()
 	go hub.Run()
 
+	// Push daily P&L reports to event clients
+	if cfg.ReportDailyHour >= 0 && cfg.ReportDailyHour < 24 {
+		go report.NewGenerator(cfg.EquityRawRetentionDays).RunDaily(cfg.ReportDailyHour, hub)
+	}
+
 	// Create engine manager
 	engineManager := trader.NewEngineManager(cfg, hub)
+	if cfg.AutoRestartTraders {
+		engineManager.RestoreRunning()
+	}
 
 	// Handle shutdown signals
 	sigCh := make(chan os.Signal, 1)
@@ -64,27 +91,37 @@ func main() {
 	server := api.NewServer(cfg.APIPort, engineManager, cfg)
 	go func() {
 		if err := server.Start(); err != nil {
-			log.Printf("API server error: %v", err)
+			log.Fatalf("API server error: %v", err)
 		}
 	}()
 
-	log.Printf("API server running on http://localhost:%s", cfg.APIPort)
 	log.Println()
 	log.Println("Endpoints:")
 	log.Println("  - GET  /api/health                 - Health check")
+	log.Println("  - GET  /api/health/deep            - Dependency checks, 503 when a critical one is down")
+	log.Println("  - GET  /api/docs                   - API reference (OpenAPI at /api/openapi.json)")
+	log.Println("  - GET  /api/users                  - List users (admin)")
+	log.Println("  - POST /api/users                  - Create user (admin)")
+	log.Println("  - GET  /api/auth/lockouts          - IPs blocked after failed auth (admin)")
 	log.Println("  - GET  /api/strategies             - List strategies")
 	log.Println("  - POST /api/strategies             - Create strategy")
 	log.Println("  - GET  /api/traders                - List traders")
 	log.Println("  - POST /api/traders                - Create trader")
+	log.Println("  - GET  /api/traders/status         - Runtime summary of every trader")
+	log.Println("  - POST /api/traders/bulk           - Start or stop several traders")
 	log.Println("  - POST /api/traders/{id}/start     - Start trader")
 	log.Println("  - POST /api/traders/{id}/stop      - Stop trader")
 	log.Println("  - GET  /api/status?trader_id=x     - Get trader status")
 	log.Println("  - GET  /api/positions?trader_id=x  - Get positions")
 	log.Println("  - GET  /api/decisions?trader_id=x  - Get decisions")
+	log.Println("  - GET  /api/equity-history?trader_id=x&start=ms&end=ms - Equity history, resolution by range")
+	log.Println("  - GET  /api/traders/{id}/decisions/{decision_id}/raw - Raw AI calls for a decision")
+	log.Println("  - GET  /api/traders/{id}/report?period=daily|weekly&date=YYYY-MM-DD - P&L report")
 	log.Println("  - GET  /api/backtest               - List backtests")
 	log.Println("  - POST /api/backtest/start         - Start backtest")
 	log.Println("  - GET  /api/debate/sessions        - List debates")
 	log.Println("  - POST /api/debate/sessions        - Create debate")
+	log.Println("  - GET  /api/debate/models          - List selectable debate models")
 	log.Println()
 	log.Println("Press Ctrl+C to stop")
 	fmt.Println()
@@ -93,7 +130,7 @@ func main() {
 	<-sigCh
 	log.Println("\nShutdown signal received...")
 
-	// Stop all engines
+	// Stop all engines, applying each trader's shutdown policy
 	engineManager.StopAll()
 
 	log.Println("Goodbye!")
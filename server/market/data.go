@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"auto-trader-ahh/exchange"
 )
@@ -25,15 +27,81 @@ type MarketData struct {
 	Trend          string // BULLISH, BEARISH, NEUTRAL
 	BTCPrice       float64
 	BTCChange24h   float64
+
+	// Bollinger Bands
+	BOLLMiddle    float64
+	BOLLUpper     float64
+	BOLLLower     float64
+	BOLLBandwidth float64 // (upper - lower) / middle, in %
+	BOLLSqueeze   bool    // Bandwidth well under its recent average
+
+	// Session VWAP (UTC day) from the klines since the session opened
+	VWAP     float64
+	VWAPBars int
+
+	// Last closed bar's volume vs the 20 bars before it
+	VolumeRatio float64
+	VolumeTrend string // HIGH, LOW, NORMAL
+
+	KeyLevels *KeyLevels    // Support, resistance and daily reference levels
+	Depth     *DepthStats   // Order book spread and depth, nil if unavailable
+	Funding   *Funding      // Current funding rate, nil if unavailable
+	OI        *OpenInterest // 24h and 4h open interest changes, nil if unavailable
+
+	Indicators Indicators // What was calculated, and what FormatForAI shows
+
+	FetchedAt time.Time // When the data was fetched, for its age in the prompt
+}
+
+// Indicators selects the indicators shown to the AI and tunes the Bollinger
+// Bands and key level detection. Zero values use the defaults.
+type Indicators struct {
+	EMA    bool
+	MACD   bool
+	RSI    bool
+	ATR    bool
+	BOLL   bool
+	Volume bool // Volume trend and VWAP
+
+	BOLLPeriod int     // Default 20
+	BOLLStdDev float64 // Band width in standard deviations, default 2
+
+	SRLookback    int // Bars searched for swing levels, default 100
+	SRSensitivity int // Bars on each side of a swing point, default 3
+
+	MinDepthUSD float64 // Order book depth under this gets a liquidity warning, default 50000
+
+	// Funding above MaxAdverseFundingRate % per 8h on the paying side gets a
+	// warning that entries need FundingMinConfidence; 0 = no warning
+	MaxAdverseFundingRate float64
+	FundingMinConfidence  int
+}
+
+const (
+	defaultBOLLPeriod   = 20
+	defaultBOLLStdDev   = 2.0
+	volumeAvgBars       = 20
+	bollSqueezeLookback = 50  // Bars of bandwidth history for squeeze detection
+	bollSqueezeRatio    = 0.5 // Bandwidth under half its lookback average counts as a squeeze
+)
+
+// DefaultIndicators enables every indicator
+func DefaultIndicators() Indicators {
+	return Indicators{EMA: true, MACD: true, RSI: true, ATR: true, BOLL: true, Volume: true}
 }
 
 type DataProvider struct {
 	binance *exchange.BinanceClient
+	stream  *StreamManager // Optional, REST only when nil
+
+	oiCache map[string]cachedOI // key: symbol
+	oiMu    sync.Mutex
 }
 
-func NewDataProvider(binance *exchange.BinanceClient) *DataProvider {
+func NewDataProvider(binance *exchange.BinanceClient, stream *StreamManager) *DataProvider {
 	return &DataProvider{
 		binance: binance,
+		stream:  stream,
 	}
 }
 
@@ -44,10 +112,23 @@ func (d *DataProvider) GetMarketData(ctx context.Context, symbol string) (*Marke
 
 // GetMarketDataWithConfig fetches market data with custom timeframe and count
 func (d *DataProvider) GetMarketDataWithConfig(ctx context.Context, symbol, timeframe string, count int) (*MarketData, error) {
-	// Get klines
-	klines, err := d.binance.GetKlines(ctx, symbol, timeframe, count)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get klines: %w", err)
+	return d.GetMarketDataWithIndicators(ctx, symbol, timeframe, count, DefaultIndicators())
+}
+
+// GetMarketDataWithIndicators fetches market data and calculates the
+// selected indicators
+func (d *DataProvider) GetMarketDataWithIndicators(ctx context.Context, symbol, timeframe string, count int, ind Indicators) (*MarketData, error) {
+	// Get klines, from the stream cache when it's live and complete
+	klines, cached := d.streamKlines(symbol, timeframe, count)
+	if !cached {
+		var err error
+		klines, err = d.binance.GetKlines(ctx, symbol, timeframe, count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get klines: %w", err)
+		}
+		if d.stream != nil {
+			d.stream.Seed(symbol, timeframe, klines)
+		}
 	}
 
 	if len(klines) < 26 {
@@ -55,9 +136,9 @@ func (d *DataProvider) GetMarketDataWithConfig(ctx context.Context, symbol, time
 	}
 
 	// Get current price
-	ticker, err := d.binance.GetTicker(ctx, symbol)
+	price, err := d.CurrentPrice(ctx, symbol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ticker: %w", err)
+		return nil, err
 	}
 
 	// Calculate indicators
@@ -77,7 +158,7 @@ func (d *DataProvider) GetMarketDataWithConfig(ctx context.Context, symbol, time
 	ema21 := calculateEMA(closes, 21)
 	rsi := calculateRSI(closes, 14)
 	macd, signal, hist := calculateMACD(closes)
-	atr := calculateATR(highs, lows, closes, 14)
+	atr := CalculateATR(highs, lows, closes, 14)
 
 	// Calculate 24h stats
 	volume24h := 0.0
@@ -98,9 +179,9 @@ func (d *DataProvider) GetMarketDataWithConfig(ctx context.Context, symbol, time
 		trend = "BEARISH"
 	}
 
-	return &MarketData{
+	data := &MarketData{
 		Symbol:         symbol,
-		CurrentPrice:   ticker.Price,
+		CurrentPrice:   price,
 		Klines:         klines,
 		EMA9:           ema9,
 		EMA21:          ema21,
@@ -112,14 +193,97 @@ func (d *DataProvider) GetMarketDataWithConfig(ctx context.Context, symbol, time
 		Volume24h:      volume24h,
 		PriceChange24h: priceChange24h,
 		Trend:          trend,
-	}, nil
+		Indicators:     ind,
+		FetchedAt:      time.Now(),
+	}
+
+	if ind.BOLL {
+		period, stdDev := ind.bollParams()
+		data.BOLLMiddle, data.BOLLUpper, data.BOLLLower = CalculateBollinger(closes, period, stdDev)
+		data.BOLLBandwidth, data.BOLLSqueeze = bollingerSqueeze(closes, period, stdDev)
+	}
+	if ind.Volume {
+		data.VWAP, data.VWAPBars = CalculateSessionVWAP(klines)
+		data.VolumeRatio = volumeRatio(volumes, volumeAvgBars)
+		data.VolumeTrend = volumeTrend(data.VolumeRatio)
+	}
+	data.KeyLevels = d.keyLevels(ctx, symbol, klines, price, ind)
+	if book, err := d.binance.GetDepth(ctx, symbol, DepthLimit); err == nil {
+		data.Depth = AnalyzeDepth(book)
+	}
+	if index, err := d.binance.GetFundingRate(ctx, symbol); err == nil {
+		data.Funding = &Funding{Rate: index.LastFundingRate}
+		if index.NextFundingTime > 0 {
+			data.Funding.NextFunding = time.UnixMilli(index.NextFundingTime)
+		}
+	}
+	if oi, err := d.OpenInterest(ctx, symbol); err == nil {
+		data.OI = oi
+	}
+
+	return data, nil
+}
+
+// keyLevels finds the swing levels in klines and adds the daily references
+// from 1h klines. Without those only the swing levels are returned.
+func (d *DataProvider) keyLevels(ctx context.Context, symbol string, klines []exchange.Kline, price float64, ind Indicators) *KeyLevels {
+	levels := &KeyLevels{}
+	levels.Resistances, levels.Supports = FindSwingLevels(klines, price, ind.SRLookback, ind.SRSensitivity)
+	if hourly, err := d.binance.GetKlines(ctx, symbol, "1h", dailyLevelBars); err == nil {
+		levels.High24h, levels.Low24h, levels.PrevDayClose = DailyLevels(hourly, time.Now())
+	}
+	return levels
+}
+
+func (ind Indicators) bollParams() (int, float64) {
+	period, stdDev := ind.BOLLPeriod, ind.BOLLStdDev
+	if period <= 1 {
+		period = defaultBOLLPeriod
+	}
+	if stdDev <= 0 {
+		stdDev = defaultBOLLStdDev
+	}
+	return period, stdDev
+}
+
+// CurrentPrice returns symbol's last price, streamed when the stream has it
+func (d *DataProvider) CurrentPrice(ctx context.Context, symbol string) (float64, error) {
+	if d.stream != nil {
+		if price, ok := d.stream.LastPrice(symbol); ok {
+			return price, nil
+		}
+	}
+	ticker, err := d.binance.GetTicker(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ticker: %w", err)
+	}
+	return ticker.Price, nil
 }
 
+func (d *DataProvider) streamKlines(symbol, timeframe string, count int) ([]exchange.Kline, bool) {
+	if d.stream == nil {
+		return nil, false
+	}
+	return d.stream.Klines(symbol, timeframe, count)
+}
+
+// PromptCandles is how many of the latest candles FormatForAI lists
+const PromptCandles = 10
+
 // FormatForAI formats market data as a string for AI analysis
 func (d *DataProvider) FormatForAI(data *MarketData) string {
+	return d.FormatForAIWithCandles(data, PromptCandles)
+}
+
+// FormatForAIWithCandles is FormatForAI listing up to candles of the latest
+// candles, leaving the recent price action out for 0
+func (d *DataProvider) FormatForAIWithCandles(data *MarketData, candles int) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("=== %s Market Analysis ===\n\n", data.Symbol))
+	if !data.FetchedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("Data As Of: %s UTC (%ds old)\n", data.FetchedAt.UTC().Format("15:04:05"), int(time.Since(data.FetchedAt).Seconds())))
+	}
 	sb.WriteString(fmt.Sprintf("Current Price: $%.2f\n", data.CurrentPrice))
 	sb.WriteString(fmt.Sprintf("24h Price Change: %.2f%%\n", data.PriceChange24h))
 	sb.WriteString(fmt.Sprintf("24h Volume: $%.2f\n\n", data.Volume24h))
@@ -137,71 +301,105 @@ func (d *DataProvider) FormatForAI(data *MarketData) string {
 		sb.WriteString("\n")
 	}
 
+	ind := data.Indicators
+
 	sb.WriteString("--- Technical Indicators ---\n")
-	sb.WriteString(fmt.Sprintf("EMA 9: $%.2f\n", data.EMA9))
-	sb.WriteString(fmt.Sprintf("EMA 21: $%.2f\n", data.EMA21))
-
-	// Calculate trend strength
-	emaSpread := ((data.EMA9 - data.EMA21) / data.EMA21) * 100
-	absEmaSpread := emaSpread
-	if absEmaSpread < 0 {
-		absEmaSpread = -absEmaSpread
-	}
-	if data.EMA9 > data.EMA21 {
-		sb.WriteString(fmt.Sprintf("EMA Trend: BULLISH (EMA9 > EMA21 by %.2f%%)\n", emaSpread))
-		if emaSpread > 0.5 {
-			sb.WriteString("📈 Strong bullish trend. Good for LONG.\n")
-		} else if emaSpread > 0.2 {
-			sb.WriteString("📊 Moderate bullish trend. LONG possible with caution.\n")
-		} else {
-			sb.WriteString("🚫 VERY WEAK TREND (<0.2%). DO NOT OPEN NEW POSITIONS. Wait for stronger momentum.\n")
+	if ind.EMA {
+		sb.WriteString(fmt.Sprintf("EMA 9: $%.2f\n", data.EMA9))
+		sb.WriteString(fmt.Sprintf("EMA 21: $%.2f\n", data.EMA21))
+
+		// Calculate trend strength
+		emaSpread := ((data.EMA9 - data.EMA21) / data.EMA21) * 100
+		absEmaSpread := emaSpread
+		if absEmaSpread < 0 {
+			absEmaSpread = -absEmaSpread
 		}
-	} else {
-		sb.WriteString(fmt.Sprintf("EMA Trend: BEARISH (EMA9 < EMA21 by %.2f%%)\n", -emaSpread))
-		if emaSpread < -0.5 {
-			sb.WriteString("📉 Strong bearish trend. Good for SHORT.\n")
-		} else if emaSpread < -0.2 {
-			sb.WriteString("📊 Moderate bearish trend. SHORT possible with caution.\n")
+		if data.EMA9 > data.EMA21 {
+			sb.WriteString(fmt.Sprintf("EMA Trend: BULLISH (EMA9 > EMA21 by %.2f%%)\n", emaSpread))
+			if emaSpread > 0.5 {
+				sb.WriteString("📈 Strong bullish trend. Good for LONG.\n")
+			} else if emaSpread > 0.2 {
+				sb.WriteString("📊 Moderate bullish trend. LONG possible with caution.\n")
+			} else {
+				sb.WriteString("🚫 VERY WEAK TREND (<0.2%). DO NOT OPEN NEW POSITIONS. Wait for stronger momentum.\n")
+			}
 		} else {
-			sb.WriteString("🚫 VERY WEAK TREND (<0.2%). DO NOT OPEN NEW POSITIONS. Wait for stronger momentum.\n")
+			sb.WriteString(fmt.Sprintf("EMA Trend: BEARISH (EMA9 < EMA21 by %.2f%%)\n", -emaSpread))
+			if emaSpread < -0.5 {
+				sb.WriteString("📉 Strong bearish trend. Good for SHORT.\n")
+			} else if emaSpread < -0.2 {
+				sb.WriteString("📊 Moderate bearish trend. SHORT possible with caution.\n")
+			} else {
+				sb.WriteString("🚫 VERY WEAK TREND (<0.2%). DO NOT OPEN NEW POSITIONS. Wait for stronger momentum.\n")
+			}
 		}
-	}
 
-	// Add explicit trend strength gate
-	if absEmaSpread < 0.2 {
-		sb.WriteString(fmt.Sprintf("\n⛔ TREND STRENGTH GATE: EMA spread is only %.2f%% - TOO WEAK for new entries!\n", absEmaSpread))
-		sb.WriteString("   Action: WAIT or HOLD existing positions. Do not open new trades.\n\n")
+		// Add explicit trend strength gate
+		if absEmaSpread < 0.2 {
+			sb.WriteString(fmt.Sprintf("\n⛔ TREND STRENGTH GATE: EMA spread is only %.2f%% - TOO WEAK for new entries!\n", absEmaSpread))
+			sb.WriteString("   Action: WAIT or HOLD existing positions. Do not open new trades.\n\n")
+		}
 	}
 
 	// RSI with entry guidance
-	sb.WriteString(fmt.Sprintf("RSI (14): %.2f", data.RSI))
-	if data.RSI > 75 {
-		sb.WriteString(" [OVERBOUGHT ⚠️ Risky for LONG]\n")
-	} else if data.RSI > 65 {
-		sb.WriteString(" [HIGH - Still OK for LONG with tight SL]\n")
-	} else if data.RSI < 25 {
-		sb.WriteString(" [OVERSOLD ⚠️ Risky for SHORT]\n")
-	} else if data.RSI < 35 {
-		sb.WriteString(" [LOW - Still OK for SHORT with tight SL]\n")
-	} else if data.RSI > 45 && data.RSI <= 65 {
-		sb.WriteString(" [BULLISH - Good for LONG]\n")
-	} else if data.RSI >= 35 && data.RSI < 55 {
-		sb.WriteString(" [BEARISH - Good for SHORT]\n")
-	} else {
-		sb.WriteString(" [NEUTRAL - Either direction OK]\n")
+	if ind.RSI {
+		sb.WriteString(fmt.Sprintf("RSI (14): %.2f", data.RSI))
+		if data.RSI > 75 {
+			sb.WriteString(" [OVERBOUGHT ⚠️ Risky for LONG]\n")
+		} else if data.RSI > 65 {
+			sb.WriteString(" [HIGH - Still OK for LONG with tight SL]\n")
+		} else if data.RSI < 25 {
+			sb.WriteString(" [OVERSOLD ⚠️ Risky for SHORT]\n")
+		} else if data.RSI < 35 {
+			sb.WriteString(" [LOW - Still OK for SHORT with tight SL]\n")
+		} else if data.RSI > 45 && data.RSI <= 65 {
+			sb.WriteString(" [BULLISH - Good for LONG]\n")
+		} else if data.RSI >= 35 && data.RSI < 55 {
+			sb.WriteString(" [BEARISH - Good for SHORT]\n")
+		} else {
+			sb.WriteString(" [NEUTRAL - Either direction OK]\n")
+		}
 	}
 
-	sb.WriteString(fmt.Sprintf("MACD: %.4f\n", data.MACD))
-	sb.WriteString(fmt.Sprintf("MACD Signal: %.4f\n", data.MACDSignal))
-	sb.WriteString(fmt.Sprintf("MACD Histogram: %.4f", data.MACDHist))
-	if data.MACDHist > 0 && data.MACD > data.MACDSignal {
-		sb.WriteString(" [BULLISH MOMENTUM ✅]\n")
-	} else if data.MACDHist < 0 && data.MACD < data.MACDSignal {
-		sb.WriteString(" [BEARISH MOMENTUM ✅]\n")
-	} else {
-		sb.WriteString(" [WEAKENING/TRANSITIONING ⚠️]\n")
+	if ind.MACD {
+		sb.WriteString(fmt.Sprintf("MACD: %.4f\n", data.MACD))
+		sb.WriteString(fmt.Sprintf("MACD Signal: %.4f\n", data.MACDSignal))
+		sb.WriteString(fmt.Sprintf("MACD Histogram: %.4f", data.MACDHist))
+		if data.MACDHist > 0 && data.MACD > data.MACDSignal {
+			sb.WriteString(" [BULLISH MOMENTUM ✅]\n")
+		} else if data.MACDHist < 0 && data.MACD < data.MACDSignal {
+			sb.WriteString(" [BEARISH MOMENTUM ✅]\n")
+		} else {
+			sb.WriteString(" [WEAKENING/TRANSITIONING ⚠️]\n")
+		}
+	}
+	if ind.ATR {
+		sb.WriteString(fmt.Sprintf("ATR (14): %.4f (Volatility: %.2f%%)\n", data.ATR, (data.ATR/data.CurrentPrice)*100))
+	}
+	if ind.BOLL && data.BOLLMiddle > 0 {
+		formatBollinger(&sb, data)
+	}
+	if ind.Volume {
+		formatVolume(&sb, data)
+	}
+	sb.WriteString("\n")
+
+	if data.KeyLevels != nil && !data.KeyLevels.Empty() {
+		FormatKeyLevels(&sb, data.KeyLevels, data.CurrentPrice)
+		sb.WriteString("\n")
+	}
+	if data.Depth != nil {
+		formatDepth(&sb, data.Depth, ind.MinDepthUSD)
+		sb.WriteString("\n")
+	}
+	if data.Funding != nil {
+		formatFunding(&sb, data.Funding, ind.MaxAdverseFundingRate, ind.FundingMinConfidence)
+		sb.WriteString("\n")
+	}
+	if data.OI != nil {
+		formatOpenInterest(&sb, data.OI)
+		sb.WriteString("\n")
 	}
-	sb.WriteString(fmt.Sprintf("ATR (14): %.4f (Volatility: %.2f%%)\n\n", data.ATR, (data.ATR/data.CurrentPrice)*100))
 
 	// Overall trend assessment
 	sb.WriteString(fmt.Sprintf("--- Overall Trend: %s ---\n", data.Trend))
@@ -212,49 +410,26 @@ func (d *DataProvider) FormatForAI(data *MarketData) string {
 
 	// Entry quality summary
 	sb.WriteString("--- ENTRY QUALITY CHECK ---\n")
-	longScore := 0
-	shortScore := 0
-
-	if data.EMA9 > data.EMA21 {
-		longScore++
-	} else {
-		shortScore++
-	}
-	if data.RSI > 45 && data.RSI < 65 {
-		longScore++
-	}
-	if data.RSI > 35 && data.RSI < 55 {
-		shortScore++
-	}
-	if data.MACDHist > 0 {
-		longScore++
-	} else {
-		shortScore++
-	}
-	if data.BTCChange24h > 0 {
-		longScore++
-	} else {
-		shortScore++
-	}
-
-	sb.WriteString(fmt.Sprintf("LONG Score: %d/4 | SHORT Score: %d/4\n", longScore, shortScore))
-	if longScore >= 3 {
+	longScore, shortScore, total := entryScores(data)
+	strong, moderate := max(total-1, 2), max(total-2, 1)
+	sb.WriteString(fmt.Sprintf("LONG Score: %d/%d | SHORT Score: %d/%d\n", longScore, total, shortScore, total))
+	if longScore >= strong {
 		sb.WriteString("✅ STRONG: CONDITIONS FAVOR LONG ENTRY\n")
-	} else if shortScore >= 3 {
+	} else if shortScore >= strong {
 		sb.WriteString("✅ STRONG: CONDITIONS FAVOR SHORT ENTRY\n")
-	} else if longScore >= 2 {
+	} else if longScore >= moderate {
 		sb.WriteString("📊 MODERATE: LONG entry possible with caution\n")
-	} else if shortScore >= 2 {
+	} else if shortScore >= moderate {
 		sb.WriteString("📊 MODERATE: SHORT entry possible with caution\n")
 	} else {
 		sb.WriteString("⚠️ WEAK: Mixed signals, higher risk entry\n")
 	}
 	sb.WriteString("\n")
 
-	// Recent price action (last 10 candles only for clarity)
-	candleCount := len(data.Klines)
-	if candleCount > 10 {
-		candleCount = 10
+	// Recent price action, the latest candles only for clarity
+	candleCount := min(len(data.Klines), candles)
+	if candleCount <= 0 {
+		return sb.String()
 	}
 	sb.WriteString(fmt.Sprintf("--- Recent Price Action (Last %d Candles) ---\n", candleCount))
 	startIdx := len(data.Klines) - candleCount
@@ -289,6 +464,97 @@ func (d *DataProvider) FormatForAI(data *MarketData) string {
 	return sb.String()
 }
 
+// formatBollinger writes the bands with where price sits in them
+func formatBollinger(sb *strings.Builder, data *MarketData) {
+	period, stdDev := data.Indicators.bollParams()
+	sb.WriteString(fmt.Sprintf("Bollinger (%d, %.1f): Upper $%.4f | Middle $%.4f | Lower $%.4f (Bandwidth %.2f%%)",
+		period, stdDev, data.BOLLUpper, data.BOLLMiddle, data.BOLLLower, data.BOLLBandwidth))
+	switch price := data.CurrentPrice; {
+	case price > data.BOLLUpper:
+		sb.WriteString(" [ABOVE UPPER BAND ⚠️ Overextended, risky for LONG]\n")
+	case price < data.BOLLLower:
+		sb.WriteString(" [BELOW LOWER BAND ⚠️ Overextended, risky for SHORT]\n")
+	case price >= data.BOLLMiddle:
+		sb.WriteString(" [UPPER HALF - Bullish, supports LONG]\n")
+	default:
+		sb.WriteString(" [LOWER HALF - Bearish, supports SHORT]\n")
+	}
+	if data.BOLLSqueeze {
+		sb.WriteString("🔄 SQUEEZE: Bands much narrower than usual. Expect a breakout, enter in its direction rather than before it.\n")
+	}
+}
+
+// formatVolume writes the session VWAP and the volume trend
+func formatVolume(sb *strings.Builder, data *MarketData) {
+	if data.VWAP > 0 {
+		diff := (data.CurrentPrice - data.VWAP) / data.VWAP * 100
+		side := "ABOVE VWAP - buyers in control"
+		if diff < 0 {
+			side = "BELOW VWAP - sellers in control"
+		}
+		sb.WriteString(fmt.Sprintf("VWAP (session, %d bars): $%.4f [%s, %+.2f%%]\n", data.VWAPBars, data.VWAP, side, diff))
+	}
+	if data.VolumeRatio > 0 {
+		sb.WriteString(fmt.Sprintf("Volume: %.2fx the %d-bar average", data.VolumeRatio, volumeAvgBars))
+		switch data.VolumeTrend {
+		case "HIGH":
+			sb.WriteString(" [HIGH - Confirms the move ✅]\n")
+		case "LOW":
+			sb.WriteString(" [LOW ⚠️ Weak conviction, breakouts may fail]\n")
+		default:
+			sb.WriteString(" [NORMAL]\n")
+		}
+	}
+}
+
+// entryScores counts the enabled signals favoring each side. BTC's direction
+// always counts.
+func entryScores(data *MarketData) (longScore, shortScore, total int) {
+	ind := data.Indicators
+	if ind.EMA {
+		total++
+		if data.EMA9 > data.EMA21 {
+			longScore++
+		} else {
+			shortScore++
+		}
+	}
+	if ind.RSI {
+		total++
+		if data.RSI > 45 && data.RSI < 65 {
+			longScore++
+		}
+		if data.RSI > 35 && data.RSI < 55 {
+			shortScore++
+		}
+	}
+	if ind.MACD {
+		total++
+		if data.MACDHist > 0 {
+			longScore++
+		} else {
+			shortScore++
+		}
+	}
+	// Between the middle and an outer band is a trend with room left to run
+	if ind.BOLL && data.BOLLMiddle > 0 {
+		total++
+		price := data.CurrentPrice
+		if price >= data.BOLLMiddle && price <= data.BOLLUpper {
+			longScore++
+		} else if price < data.BOLLMiddle && price >= data.BOLLLower {
+			shortScore++
+		}
+	}
+	total++
+	if data.BTCChange24h > 0 {
+		longScore++
+	} else {
+		shortScore++
+	}
+	return longScore, shortScore, total
+}
+
 // calculateEMA calculates Exponential Moving Average
 func calculateEMA(data []float64, period int) float64 {
 	if len(data) < period {
@@ -366,14 +632,14 @@ func calculateMACD(data []float64) (macd, signal, histogram float64) {
 	return
 }
 
-// calculateATR calculates Average True Range
-func calculateATR(highs, lows, closes []float64, period int) float64 {
+// CalculateATR calculates the Average True Range over the last period bars
+func CalculateATR(highs, lows, closes []float64, period int) float64 {
 	if len(highs) < period+1 {
 		return 0
 	}
 
 	trSum := 0.0
-	for i := 1; i <= period; i++ {
+	for i := len(highs) - period; i < len(highs); i++ {
 		tr := math.Max(
 			highs[i]-lows[i],
 			math.Max(
@@ -386,3 +652,108 @@ func calculateATR(highs, lows, closes []float64, period int) float64 {
 
 	return trSum / float64(period)
 }
+
+// CalculateBollinger returns the Bollinger Bands of the last period closes:
+// their SMA, and the SMA plus and minus stdDev population standard deviations
+func CalculateBollinger(closes []float64, period int, stdDev float64) (middle, upper, lower float64) {
+	if period <= 0 || len(closes) < period {
+		return 0, 0, 0
+	}
+
+	window := closes[len(closes)-period:]
+	sum := 0.0
+	for _, c := range window {
+		sum += c
+	}
+	middle = sum / float64(period)
+
+	variance := 0.0
+	for _, c := range window {
+		variance += (c - middle) * (c - middle)
+	}
+	sd := math.Sqrt(variance / float64(period))
+
+	return middle, middle + stdDev*sd, middle - stdDev*sd
+}
+
+// bollingerSqueeze returns the current bandwidth (%) and whether it's under
+// bollSqueezeRatio of its average over the last bollSqueezeLookback bars
+func bollingerSqueeze(closes []float64, period int, stdDev float64) (bandwidth float64, squeeze bool) {
+	bandwidthAt := func(end int) float64 {
+		middle, upper, lower := CalculateBollinger(closes[:end], period, stdDev)
+		if middle == 0 {
+			return 0
+		}
+		return (upper - lower) / middle * 100
+	}
+	if len(closes) < period {
+		return 0, false
+	}
+
+	bandwidth = bandwidthAt(len(closes))
+	sum := 0.0
+	history := 0
+	for end := len(closes) - 1; end >= period && history < bollSqueezeLookback; end-- {
+		sum += bandwidthAt(end)
+		history++
+	}
+	// Too little history to call the bands narrow
+	if history < period {
+		return bandwidth, false
+	}
+	return bandwidth, bandwidth < sum/float64(history)*bollSqueezeRatio
+}
+
+// CalculateSessionVWAP returns the volume-weighted average typical price of
+// the klines in the last kline's UTC day, and how many bars that covered
+func CalculateSessionVWAP(klines []exchange.Kline) (vwap float64, bars int) {
+	if len(klines) == 0 {
+		return 0, 0
+	}
+
+	last := time.UnixMilli(klines[len(klines)-1].OpenTime).UTC()
+	sessionStart := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC).UnixMilli()
+
+	var priceVolume, volume float64
+	for i := len(klines) - 1; i >= 0 && klines[i].OpenTime >= sessionStart; i-- {
+		k := klines[i]
+		priceVolume += (k.High + k.Low + k.Close) / 3 * k.Volume
+		volume += k.Volume
+		bars++
+	}
+	if volume == 0 {
+		return 0, bars
+	}
+	return priceVolume / volume, bars
+}
+
+// volumeRatio compares the last closed bar's volume (the final bar is still
+// forming) with the average of the n bars before it. 0 without enough bars.
+func volumeRatio(volumes []float64, n int) float64 {
+	closed := len(volumes) - 2
+	if n <= 0 || closed < n {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range volumes[closed-n : closed] {
+		sum += v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return volumes[closed] / (sum / float64(n))
+}
+
+func volumeTrend(ratio float64) string {
+	switch {
+	case ratio == 0:
+		return ""
+	case ratio >= 1.5:
+		return "HIGH"
+	case ratio <= 0.5:
+		return "LOW"
+	default:
+		return "NORMAL"
+	}
+}
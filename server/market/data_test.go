@@ -0,0 +1,221 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+)
+
+func TestCalculateBollinger(t *testing.T) {
+	ramp := make([]float64, 20)
+	for i := range ramp {
+		ramp[i] = float64(i + 1)
+	}
+
+	tests := []struct {
+		name                          string
+		closes                        []float64
+		period                        int
+		stdDev                        float64
+		wantMiddle, wantUpper, wantLo float64
+	}{
+		// Mean 5, population standard deviation 2
+		{"textbook series", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2, 5, 9, 1},
+		{"wider multiplier", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 3, 5, 11, -1},
+		// Only the last period closes count
+		{"window", []float64{100, 100, 2, 4, 4, 4, 5, 5, 7, 9}, 8, 2, 5, 9, 1},
+		// 1..20: mean 10.5, variance (20²-1)/12 = 33.25
+		{"ramp", ramp, 20, 2, 10.5, 10.5 + 2*math.Sqrt(33.25), 10.5 - 2*math.Sqrt(33.25)},
+		{"flat", []float64{3, 3, 3, 3}, 4, 2, 3, 3, 3},
+		{"too short", []float64{1, 2, 3}, 20, 2, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middle, upper, lower := CalculateBollinger(tt.closes, tt.period, tt.stdDev)
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{{"middle", middle, tt.wantMiddle}, {"upper", upper, tt.wantUpper}, {"lower", lower, tt.wantLo}} {
+				if math.Abs(v.got-v.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", v.name, v.got, v.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBollingerSqueeze(t *testing.T) {
+	// Wide swings that calm down into a tight range
+	closes := make([]float64, 0, 100)
+	for i := 0; i < 70; i++ {
+		closes = append(closes, 100+10*math.Sin(float64(i)))
+	}
+	for i := 0; i < 30; i++ {
+		closes = append(closes, 100+0.1*math.Sin(float64(i)))
+	}
+	if _, squeeze := bollingerSqueeze(closes, 20, 2); !squeeze {
+		t.Error("tight range after wide swings should be a squeeze")
+	}
+
+	// The reverse is an expansion
+	reversed := make([]float64, len(closes))
+	for i, c := range closes {
+		reversed[len(closes)-1-i] = c
+	}
+	if _, squeeze := bollingerSqueeze(reversed, 20, 2); squeeze {
+		t.Error("wide swings after a tight range should not be a squeeze")
+	}
+
+	if _, squeeze := bollingerSqueeze(closes[:25], 20, 2); squeeze {
+		t.Error("too little history should not be a squeeze")
+	}
+}
+
+func TestCalculateSessionVWAP(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	bar := func(at time.Time, price, volume float64) exchange.Kline {
+		return exchange.Kline{OpenTime: at.UnixMilli(), High: price, Low: price, Close: price, Volume: volume}
+	}
+
+	klines := []exchange.Kline{
+		bar(day.Add(-10*time.Minute), 500, 1000), // Previous session, ignored
+		bar(day, 100, 1),
+		bar(day.Add(5*time.Minute), 110, 3),
+	}
+	vwap, bars := CalculateSessionVWAP(klines)
+	if bars != 2 || math.Abs(vwap-107.5) > 1e-9 {
+		t.Errorf("CalculateSessionVWAP() = %v over %d bars, want 107.5 over 2", vwap, bars)
+	}
+
+	// Typical price is (high + low + close) / 3
+	k := exchange.Kline{OpenTime: day.UnixMilli(), High: 12, Low: 6, Close: 9, Volume: 5}
+	if vwap, _ := CalculateSessionVWAP([]exchange.Kline{k}); math.Abs(vwap-9) > 1e-9 {
+		t.Errorf("typical price VWAP = %v, want 9", vwap)
+	}
+
+	if vwap, bars := CalculateSessionVWAP(nil); vwap != 0 || bars != 0 {
+		t.Errorf("no klines = %v over %d bars, want 0", vwap, bars)
+	}
+}
+
+func TestVolumeRatio(t *testing.T) {
+	volumes := make([]float64, 22)
+	for i := range volumes {
+		volumes[i] = 100
+	}
+	volumes[20] = 300 // Last closed bar
+	volumes[21] = 5   // Still forming, ignored
+
+	if got := volumeRatio(volumes, 20); math.Abs(got-3) > 1e-9 {
+		t.Errorf("volumeRatio() = %v, want 3", got)
+	}
+	if got := volumeTrend(volumeRatio(volumes, 20)); got != "HIGH" {
+		t.Errorf("volumeTrend() = %s, want HIGH", got)
+	}
+	if got := volumeRatio(volumes[:15], 20); got != 0 {
+		t.Errorf("short history volumeRatio() = %v, want 0", got)
+	}
+	for ratio, want := range map[float64]string{0: "", 0.4: "LOW", 1: "NORMAL", 1.5: "HIGH"} {
+		if got := volumeTrend(ratio); got != want {
+			t.Errorf("volumeTrend(%v) = %q, want %q", ratio, got, want)
+		}
+	}
+}
+
+func TestEntryScoresBandPosition(t *testing.T) {
+	base := MarketData{
+		EMA9:         101,
+		EMA21:        100,
+		RSI:          50,
+		MACDHist:     1,
+		BTCChange24h: 1,
+		BOLLMiddle:   100,
+		BOLLUpper:    110,
+		BOLLLower:    90,
+	}
+
+	tests := []struct {
+		name      string
+		price     float64
+		ind       Indicators
+		wantLong  int
+		wantShort int
+		wantTotal int
+	}{
+		// EMA, RSI (45-55 counts for both), MACD, BTC, upper half of the bands
+		{"upper half favors long", 105, DefaultIndicators(), 5, 1, 5},
+		{"lower half favors short", 95, DefaultIndicators(), 4, 2, 5},
+		{"above the upper band counts for neither", 115, DefaultIndicators(), 4, 1, 5},
+		{"bands off", 105, Indicators{EMA: true, MACD: true, RSI: true}, 4, 1, 4},
+		{"only BTC", 105, Indicators{}, 1, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := base
+			data.CurrentPrice = tt.price
+			data.Indicators = tt.ind
+			long, short, total := entryScores(&data)
+			if long != tt.wantLong || short != tt.wantShort || total != tt.wantTotal {
+				t.Errorf("entryScores() = %d/%d/%d, want %d/%d/%d", long, short, total, tt.wantLong, tt.wantShort, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestFormatForAIHonorsIndicators(t *testing.T) {
+	data := &MarketData{
+		Symbol:       "BTCUSDT",
+		CurrentPrice: 115,
+		EMA9:         101,
+		EMA21:        100,
+		RSI:          60,
+		ATR:          2,
+		BOLLMiddle:   100,
+		BOLLUpper:    110,
+		BOLLLower:    90,
+		BOLLSqueeze:  true,
+		VWAP:         105,
+		VWAPBars:     12,
+		VolumeRatio:  2,
+		VolumeTrend:  "HIGH",
+		Trend:        "BULLISH",
+	}
+	d := &DataProvider{}
+
+	data.Indicators = DefaultIndicators()
+	out := d.FormatForAI(data)
+	for _, want := range []string{"EMA 9", "RSI (14)", "MACD:", "ATR (14)", "ABOVE UPPER BAND", "SQUEEZE", "VWAP (session, 12 bars)", "2.00x the 20-bar average"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("all indicators: output missing %q", want)
+		}
+	}
+
+	data.Indicators = Indicators{RSI: true}
+	out = d.FormatForAI(data)
+	for _, unwanted := range []string{"EMA 9", "MACD:", "ATR (14)", "Bollinger", "VWAP"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("RSI only: output contains %q", unwanted)
+		}
+	}
+	if !strings.Contains(out, "RSI (14)") {
+		t.Error("RSI only: output missing RSI")
+	}
+}
+
+func TestFormatForAIDataAge(t *testing.T) {
+	d := &DataProvider{}
+	data := &MarketData{Symbol: "BTCUSDT", CurrentPrice: 100}
+	if out := d.FormatForAI(data); strings.Contains(out, "Data As Of") {
+		t.Errorf("data without a fetch time has an age:\n%s", out)
+	}
+
+	data.FetchedAt = time.Now().Add(-30 * time.Second)
+	if out := d.FormatForAI(data); !strings.Contains(out, "(30s old)") {
+		t.Errorf("output missing the data age:\n%s", out)
+	}
+}
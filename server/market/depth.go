@@ -0,0 +1,118 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-trader-ahh/exchange"
+)
+
+// Order book analysis
+const (
+	DepthLimit         = 50    // Book levels fetched per side
+	depthBandPct       = 0.5   // Depth is measured within this % of mid
+	defaultMinDepthUSD = 50000 // Band depth under this on either side gets a liquidity warning
+	imbalanceThreshold = 1.5   // Bid/ask depth ratio (or its inverse) worth pointing out
+)
+
+// DepthStats summarizes the order book around the mid price
+type DepthStats struct {
+	Mid         float64
+	SpreadPct   float64 // Best ask - best bid, in % of mid
+	BidDepthUSD float64 // Bid notional within depthBandPct of mid
+	AskDepthUSD float64 // Ask notional within depthBandPct of mid
+	Imbalance   float64 // BidDepthUSD / AskDepthUSD, above 1 means more bids
+}
+
+// AnalyzeDepth computes the spread, the depth near mid and the bid/ask
+// imbalance of book. It returns nil for a one-sided or empty book.
+func AnalyzeDepth(book *exchange.OrderBook) *DepthStats {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil
+	}
+	bid, ask := book.Bids[0].Price, book.Asks[0].Price
+	if bid <= 0 || ask <= bid {
+		return nil
+	}
+
+	mid := (bid + ask) / 2
+	stats := &DepthStats{Mid: mid, SpreadPct: (ask - bid) / mid * 100}
+	band := mid * depthBandPct / 100
+	for _, l := range book.Bids {
+		if l.Price < mid-band {
+			break
+		}
+		stats.BidDepthUSD += l.Price * l.Quantity
+	}
+	for _, l := range book.Asks {
+		if l.Price > mid+band {
+			break
+		}
+		stats.AskDepthUSD += l.Price * l.Quantity
+	}
+	if stats.AskDepthUSD > 0 {
+		stats.Imbalance = stats.BidDepthUSD / stats.AskDepthUSD
+	}
+	return stats
+}
+
+// ExpectedSlippagePct estimates how far a market order for notional USD would
+// fill from mid, walking the book on the side it takes: asks for a long,
+// bids for a short. It includes half the spread. ok is false when the book
+// snapshot is too thin to fill the order.
+func ExpectedSlippagePct(book *exchange.OrderBook, side string, notional float64) (pct float64, ok bool) {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 || notional <= 0 {
+		return 0, false
+	}
+	mid := (book.Bids[0].Price + book.Asks[0].Price) / 2
+	levels := book.Asks
+	if side == "short" {
+		levels = book.Bids
+	}
+
+	remaining, cost, qty := notional, 0.0, 0.0
+	for _, l := range levels {
+		fill := l.Price * l.Quantity
+		if fill > remaining {
+			fill = remaining
+		}
+		cost += fill
+		qty += fill / l.Price
+		remaining -= fill
+		if remaining <= 0 {
+			break
+		}
+	}
+	if remaining > 0 || qty == 0 {
+		return 0, false
+	}
+
+	avg := cost / qty
+	if side == "short" {
+		return (mid - avg) / mid * 100, true
+	}
+	return (avg - mid) / mid * 100, true
+}
+
+// formatDepth writes the spread and depth, with a warning when the book is thin
+func formatDepth(sb *strings.Builder, depth *DepthStats, minDepthUSD float64) {
+	if minDepthUSD <= 0 {
+		minDepthUSD = defaultMinDepthUSD
+	}
+
+	sb.WriteString("--- Order Book ---\n")
+	sb.WriteString(fmt.Sprintf("Spread: %.3f%%\n", depth.SpreadPct))
+	sb.WriteString(fmt.Sprintf("Depth within %.1f%%: Bids $%.0f | Asks $%.0f", depthBandPct, depth.BidDepthUSD, depth.AskDepthUSD))
+	switch {
+	case depth.Imbalance >= imbalanceThreshold:
+		sb.WriteString(fmt.Sprintf(" [BID HEAVY %.2fx - buyers stacked below]\n", depth.Imbalance))
+	case depth.Imbalance > 0 && depth.Imbalance <= 1/imbalanceThreshold:
+		sb.WriteString(fmt.Sprintf(" [ASK HEAVY %.2fx - sellers stacked above]\n", 1/depth.Imbalance))
+	default:
+		sb.WriteString(" [BALANCED]\n")
+	}
+	if thinnest := min(depth.BidDepthUSD, depth.AskDepthUSD); thinnest < minDepthUSD {
+		sb.WriteString(fmt.Sprintf("⚠️ LOW LIQUIDITY: Only $%.0f within %.1f%% of mid (minimum $%.0f). Expect slippage, use a smaller size or skip.\n",
+			thinnest, depthBandPct, minDepthUSD))
+	}
+}
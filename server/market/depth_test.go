@@ -0,0 +1,94 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// testBook has a 100.00/100.10 top of book, then 100 bid and 50 ask units every 0.1
+func testBook() *exchange.OrderBook {
+	book := &exchange.OrderBook{Symbol: "TESTUSDT"}
+	for i := 0; i < 10; i++ {
+		book.Bids = append(book.Bids, exchange.BookLevel{Price: 100 - 0.1*float64(i), Quantity: 100})
+		book.Asks = append(book.Asks, exchange.BookLevel{Price: 100.1 + 0.1*float64(i), Quantity: 50})
+	}
+	return book
+}
+
+func TestAnalyzeDepth(t *testing.T) {
+	stats := AnalyzeDepth(testBook())
+	if stats == nil {
+		t.Fatal("AnalyzeDepth() = nil")
+	}
+	if math.Abs(stats.Mid-100.05) > 1e-9 || math.Abs(stats.SpreadPct-0.1/100.05*100) > 1e-9 {
+		t.Errorf("mid %v spread %v%%, want 100.05 and 0.0999%%", stats.Mid, stats.SpreadPct)
+	}
+
+	// 0.5% of 100.05 reaches down to 99.55 and up to 100.55: bids 100.0-99.6, asks 100.1-100.5
+	var wantBids, wantAsks float64
+	for i := 0; i < 5; i++ {
+		wantBids += (100 - 0.1*float64(i)) * 100
+		wantAsks += (100.1 + 0.1*float64(i)) * 50
+	}
+	if math.Abs(stats.BidDepthUSD-wantBids) > 1e-6 || math.Abs(stats.AskDepthUSD-wantAsks) > 1e-6 {
+		t.Errorf("depth = $%.2f / $%.2f, want $%.2f / $%.2f", stats.BidDepthUSD, stats.AskDepthUSD, wantBids, wantAsks)
+	}
+	if math.Abs(stats.Imbalance-wantBids/wantAsks) > 1e-9 {
+		t.Errorf("imbalance = %v, want %v", stats.Imbalance, wantBids/wantAsks)
+	}
+
+	if AnalyzeDepth(&exchange.OrderBook{Bids: testBook().Bids}) != nil {
+		t.Error("one-sided book should give nil")
+	}
+}
+
+func TestExpectedSlippagePct(t *testing.T) {
+	book := testBook()
+	mid := 100.05
+
+	// Fits in the best ask: half the spread
+	pct, ok := ExpectedSlippagePct(book, "long", 1000)
+	if !ok || math.Abs(pct-(100.1-mid)/mid*100) > 1e-9 {
+		t.Errorf("small long = %v%% (ok %v), want half the spread", pct, ok)
+	}
+
+	// Takes the first two bid levels in full
+	pct, ok = ExpectedSlippagePct(book, "short", 100*100+99.9*100)
+	avg := (100*100 + 99.9*100) / 200 // 100 of each
+	if !ok || math.Abs(pct-(mid-avg)/mid*100) > 1e-9 {
+		t.Errorf("two-level short = %v%% (ok %v), want %v%%", pct, ok, (mid-avg)/mid*100)
+	}
+
+	// Bigger orders walk further
+	small, _ := ExpectedSlippagePct(book, "long", 1000)
+	large, _ := ExpectedSlippagePct(book, "long", 30000)
+	if large <= small {
+		t.Errorf("$30k slippage %v%% should exceed $1k slippage %v%%", large, small)
+	}
+
+	if _, ok := ExpectedSlippagePct(book, "long", 1e9); ok {
+		t.Error("an order larger than the book should not be estimated")
+	}
+}
+
+func TestFormatDepthLiquidityWarning(t *testing.T) {
+	depth := &DepthStats{SpreadPct: 0.05, BidDepthUSD: 30000, AskDepthUSD: 10000, Imbalance: 3}
+
+	var sb strings.Builder
+	formatDepth(&sb, depth, 0)
+	out := sb.String()
+	for _, want := range []string{"Spread: 0.050%", "BID HEAVY 3.00x", "LOW LIQUIDITY: Only $10000"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	sb.Reset()
+	formatDepth(&sb, depth, 5000)
+	if strings.Contains(sb.String(), "LOW LIQUIDITY") {
+		t.Error("depth above a $5000 threshold should not warn")
+	}
+}
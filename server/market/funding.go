@@ -0,0 +1,56 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FundingIntervalsPerDay is how often funding is paid on Binance's standard
+// 8-hour schedule
+const FundingIntervalsPerDay = 3
+
+// Funding is a symbol's current funding rate
+type Funding struct {
+	Rate        float64   // Per funding interval, 0.0001 = 0.01%; positive means longs pay shorts
+	NextFunding time.Time // Zero if unknown
+}
+
+// AdverseFundingPct is the funding a long (or short) pays per interval, in %
+// of notional. Negative when that side receives funding.
+func AdverseFundingPct(rate float64, isLong bool) float64 {
+	if isLong {
+		return rate * 100
+	}
+	return -rate * 100
+}
+
+// formatFunding writes the rate and the daily cost of holding the paying
+// side, with a warning when it pays more than maxAdversePct per interval
+func formatFunding(sb *strings.Builder, f *Funding, maxAdversePct float64, minConfidence int) {
+	sb.WriteString("--- Funding ---\n")
+	sb.WriteString(fmt.Sprintf("Funding Rate: %.4f%% per 8h", f.Rate*100))
+	if !f.NextFunding.IsZero() {
+		if until := time.Until(f.NextFunding); until > 0 {
+			sb.WriteString(fmt.Sprintf(" (next in %s)", until.Round(time.Minute)))
+		}
+	}
+	sb.WriteString("\n")
+
+	if f.Rate == 0 {
+		sb.WriteString("Funding Cost per Day: none\n")
+		return
+	}
+	payer, receiver := "LONG", "SHORT"
+	if f.Rate < 0 {
+		payer, receiver = "SHORT", "LONG"
+	}
+	daily := AdverseFundingPct(f.Rate, payer == "LONG") * FundingIntervalsPerDay
+	sb.WriteString(fmt.Sprintf("Funding Cost per Day: %s pays ~%.3f%% of notional ($%.2f per $1,000), %s receives it\n",
+		payer, daily, daily*10, receiver))
+
+	if maxAdversePct > 0 && daily/FundingIntervalsPerDay > maxAdversePct {
+		sb.WriteString(fmt.Sprintf("⚠️ HIGH FUNDING: %s pays above the %.4f%% per 8h limit. New %s entries are blocked below %d%% confidence.\n",
+			payer, maxAdversePct, payer, minConfidence))
+	}
+}
@@ -0,0 +1,36 @@
+package market
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdverseFundingPct(t *testing.T) {
+	if got := AdverseFundingPct(0.001, true); got != 0.1 {
+		t.Errorf("long at +0.1%% pays %v%%, want 0.1", got)
+	}
+	if got := AdverseFundingPct(0.001, false); got != -0.1 {
+		t.Errorf("short at +0.1%% pays %v%%, want -0.1", got)
+	}
+	if got := AdverseFundingPct(-0.0005, false); got != 0.05 {
+		t.Errorf("short at -0.05%% pays %v%%, want 0.05", got)
+	}
+}
+
+func TestFormatFunding(t *testing.T) {
+	var sb strings.Builder
+	formatFunding(&sb, &Funding{Rate: 0.001}, 0.05, 95)
+	out := sb.String()
+	for _, want := range []string{"Funding Rate: 0.1000% per 8h", "LONG pays ~0.300% of notional ($3.00 per $1,000)", "HIGH FUNDING: LONG", "below 95% confidence"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	sb.Reset()
+	formatFunding(&sb, &Funding{Rate: -0.0001}, 0.05, 95)
+	out = sb.String()
+	if !strings.Contains(out, "SHORT pays ~0.030%") || strings.Contains(out, "HIGH FUNDING") {
+		t.Errorf("normal negative funding:\n%s", out)
+	}
+}
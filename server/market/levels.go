@@ -0,0 +1,205 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/exchange"
+)
+
+// Key level detection
+const (
+	DefaultLevelLookback    = 100 // Bars searched for swing points
+	DefaultLevelSensitivity = 3   // Bars on each side a swing point must clear
+	maxKeyLevels            = 5
+	levelMergeATRs          = 0.5  // Swing points within half an ATR are one level
+	minLevelSeparationPct   = 0.15 // Floor for the merge distance, in % of price
+	dailyLevelBars          = 48   // 1h bars fetched for the 24h range and previous close
+)
+
+// KeyLevels are the support and resistance levels around the current price
+type KeyLevels struct {
+	Resistances  []Level // Above the price, nearest first
+	Supports     []Level // At or below the price, nearest first
+	High24h      float64
+	Low24h       float64
+	PrevDayClose float64 // Close of the previous UTC day, 0 if unknown
+}
+
+// Level is a price zone where one or more swing points turned the market
+type Level struct {
+	Price   float64
+	Touches int   // Swing points merged into the level
+	LastAt  int64 // Open time of the latest of them, ms
+}
+
+// Empty reports whether no level was found
+func (l *KeyLevels) Empty() bool {
+	return len(l.Resistances) == 0 && len(l.Supports) == 0 && l.High24h == 0 && l.PrevDayClose == 0
+}
+
+// FindSwingLevels finds the most significant support and resistance levels in
+// the last lookback klines. A swing high (low) is a bar whose high (low) clears
+// the sensitivity bars on each side. Swing points closer than the merge
+// distance form one level, and levels are ranked by touches, then recency.
+// Zero lookback or sensitivity uses the defaults.
+func FindSwingLevels(klines []exchange.Kline, price float64, lookback, sensitivity int) (resistances, supports []Level) {
+	if lookback <= 0 {
+		lookback = DefaultLevelLookback
+	}
+	if sensitivity <= 0 {
+		sensitivity = DefaultLevelSensitivity
+	}
+	if len(klines) > lookback {
+		klines = klines[len(klines)-lookback:]
+	}
+	if price <= 0 || len(klines) < 2*sensitivity+1 {
+		return nil, nil
+	}
+
+	var pivots []Level
+	for i := sensitivity; i < len(klines)-sensitivity; i++ {
+		high, low := true, true
+		for j := i - sensitivity; j <= i+sensitivity; j++ {
+			if j == i {
+				continue
+			}
+			// Strict on the left so a flat top counts once
+			if klines[j].High > klines[i].High || (j < i && klines[j].High == klines[i].High) {
+				high = false
+			}
+			if klines[j].Low < klines[i].Low || (j < i && klines[j].Low == klines[i].Low) {
+				low = false
+			}
+		}
+		if high {
+			pivots = append(pivots, Level{Price: klines[i].High, Touches: 1, LastAt: klines[i].OpenTime})
+		}
+		if low {
+			pivots = append(pivots, Level{Price: klines[i].Low, Touches: 1, LastAt: klines[i].OpenTime})
+		}
+	}
+	if len(pivots) == 0 {
+		return nil, nil
+	}
+
+	// Merge neighboring swing points. Highs and lows share levels: broken
+	// resistance becomes support.
+	separation := price * minLevelSeparationPct / 100
+	highs := make([]float64, len(klines))
+	lows := make([]float64, len(klines))
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		highs[i], lows[i], closes[i] = k.High, k.Low, k.Close
+	}
+	if atr := CalculateATR(highs, lows, closes, 14); atr*levelMergeATRs > separation {
+		separation = atr * levelMergeATRs
+	}
+
+	sort.Slice(pivots, func(i, j int) bool { return pivots[i].Price < pivots[j].Price })
+	var levels []Level
+	sum, start := 0.0, 0
+	for i, p := range pivots {
+		if i > 0 && p.Price-pivots[start].Price > separation {
+			levels = append(levels, mergeLevel(pivots[start:i], sum))
+			sum, start = 0, i
+		}
+		sum += p.Price
+	}
+	levels = append(levels, mergeLevel(pivots[start:], sum))
+
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Touches != levels[j].Touches {
+			return levels[i].Touches > levels[j].Touches
+		}
+		return levels[i].LastAt > levels[j].LastAt
+	})
+	if len(levels) > maxKeyLevels {
+		levels = levels[:maxKeyLevels]
+	}
+
+	for _, l := range levels {
+		if l.Price > price {
+			resistances = append(resistances, l)
+		} else {
+			supports = append(supports, l)
+		}
+	}
+	sort.Slice(resistances, func(i, j int) bool { return resistances[i].Price < resistances[j].Price })
+	sort.Slice(supports, func(i, j int) bool { return supports[i].Price > supports[j].Price })
+	return resistances, supports
+}
+
+// mergeLevel averages a cluster of swing points into one level
+func mergeLevel(pivots []Level, sum float64) Level {
+	level := Level{Price: sum / float64(len(pivots)), Touches: len(pivots)}
+	for _, p := range pivots {
+		if p.LastAt > level.LastAt {
+			level.LastAt = p.LastAt
+		}
+	}
+	return level
+}
+
+// DailyLevels returns the high and low of the 24h before now and the close of
+// the previous UTC day from klines covering them. Values the klines don't
+// reach are 0.
+func DailyLevels(klines []exchange.Kline, now time.Time) (high24h, low24h, prevDayClose float64) {
+	from := now.Add(-24 * time.Hour).UnixMilli()
+	dayStart := now.UTC().Truncate(24 * time.Hour).UnixMilli()
+	for _, k := range klines {
+		if k.OpenTime > now.UnixMilli() {
+			break
+		}
+		if k.OpenTime < dayStart {
+			prevDayClose = k.Close
+		}
+		if k.OpenTime < from {
+			continue
+		}
+		if k.High > high24h {
+			high24h = k.High
+		}
+		if low24h == 0 || k.Low < low24h {
+			low24h = k.Low
+		}
+	}
+	return high24h, low24h, prevDayClose
+}
+
+// FormatKeyLevels writes the key levels with their distance from price, top
+// down: resistances, then supports, then the daily references
+func FormatKeyLevels(sb *strings.Builder, levels *KeyLevels, price float64) {
+	if levels == nil || levels.Empty() || price <= 0 {
+		return
+	}
+	distance := func(level float64) float64 {
+		return (level - price) / price * 100
+	}
+
+	sb.WriteString("--- Key Levels ---\n")
+	for i := len(levels.Resistances) - 1; i >= 0; i-- {
+		l := levels.Resistances[i]
+		sb.WriteString(fmt.Sprintf("Resistance: $%.4f (%+.2f%%, %s)\n", l.Price, distance(l.Price), touches(l.Touches)))
+	}
+	for _, l := range levels.Supports {
+		sb.WriteString(fmt.Sprintf("Support: $%.4f (%+.2f%%, %s)\n", l.Price, distance(l.Price), touches(l.Touches)))
+	}
+	if levels.High24h > 0 {
+		sb.WriteString(fmt.Sprintf("24h High: $%.4f (%+.2f%%) | 24h Low: $%.4f (%+.2f%%)\n",
+			levels.High24h, distance(levels.High24h), levels.Low24h, distance(levels.Low24h)))
+	}
+	if levels.PrevDayClose > 0 {
+		sb.WriteString(fmt.Sprintf("Previous Day Close: $%.4f (%+.2f%%)\n", levels.PrevDayClose, distance(levels.PrevDayClose)))
+	}
+	sb.WriteString("Place stop losses just beyond these levels, not at round numbers.\n")
+}
+
+func touches(n int) string {
+	if n == 1 {
+		return "1 touch"
+	}
+	return fmt.Sprintf("%d touches", n)
+}
@@ -0,0 +1,136 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+)
+
+// zigzag builds 1m klines from closes with highs and lows 0.1 around them
+func zigzag(closes ...float64) []exchange.Kline {
+	klines := make([]exchange.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = exchange.Kline{OpenTime: int64(i) * 60000, Open: c, High: c + 0.1, Low: c - 0.1, Close: c}
+	}
+	return klines
+}
+
+func TestFindSwingLevels(t *testing.T) {
+	// Tops at 110 twice and 120 once, bottoms at 100 twice and 95 once
+	closes := []float64{
+		100, 104, 107, 110, 107, 104, 100, 104, 107, 110, 107, 104, 100,
+		105, 112, 120, 112, 105, 95, 100, 103, 105, 104, 105,
+	}
+	klines := zigzag(closes...)
+
+	resistances, supports := FindSwingLevels(klines, 105, 0, 3)
+	if len(resistances) != 2 || math.Abs(resistances[0].Price-110.1) > 1e-9 || resistances[0].Touches != 2 ||
+		math.Abs(resistances[1].Price-120.1) > 1e-9 {
+		t.Errorf("resistances = %+v, want 110.1 (2 touches) then 120.1", resistances)
+	}
+	if len(supports) != 2 || math.Abs(supports[0].Price-99.9) > 1e-9 || supports[0].Touches != 2 ||
+		math.Abs(supports[1].Price-94.9) > 1e-9 {
+		t.Errorf("supports = %+v, want 99.9 (2 touches) then 94.9", supports)
+	}
+
+	// A short lookback only sees the last swing low
+	resistances, supports = FindSwingLevels(klines, 105, 10, 3)
+	if len(resistances) != 0 || len(supports) != 1 || math.Abs(supports[0].Price-94.9) > 1e-9 {
+		t.Errorf("lookback 10: resistances = %+v, supports = %+v, want only 94.9", resistances, supports)
+	}
+
+	// Higher sensitivity needs swings that clear more bars
+	if resistances, supports = FindSwingLevels(klines, 105, 0, 6); len(resistances)+len(supports) >= 4 {
+		t.Errorf("sensitivity 6 kept %d levels, want fewer than 4", len(resistances)+len(supports))
+	}
+
+	if resistances, supports = FindSwingLevels(klines[:5], 105, 0, 3); resistances != nil || supports != nil {
+		t.Error("too few klines should find no levels")
+	}
+}
+
+func TestFindSwingLevelsKeepsMostSignificant(t *testing.T) {
+	// Eight separate swing highs, the 130 top tested three times
+	var closes []float64
+	for _, top := range []float64{110, 130, 114, 130, 118, 130, 122, 126} {
+		closes = append(closes, 100, 100+(top-100)/3, 100+2*(top-100)/3, top, 100+2*(top-100)/3, 100+(top-100)/3)
+	}
+	closes = append(closes, 100, 100, 100)
+
+	// The bottoms between them merge into one support, the strongest level.
+	// Of the single-touch tops only the three most recent fit, 110 and 114
+	// are dropped.
+	resistances, supports := FindSwingLevels(zigzag(closes...), 105, 0, 2)
+	if len(supports) != 1 || supports[0].Touches < 2 {
+		t.Errorf("supports = %+v, want one merged level", supports)
+	}
+	var prices []float64
+	for _, l := range resistances {
+		prices = append(prices, math.Round(l.Price*10)/10)
+	}
+	if len(prices) != 4 || prices[0] != 118.1 || prices[1] != 122.1 || prices[2] != 126.1 || prices[3] != 130.1 {
+		t.Errorf("resistances = %v, want 118.1, 122.1, 126.1, 130.1", prices)
+	}
+}
+
+func TestDailyLevels(t *testing.T) {
+	now := time.Date(2025, 3, 10, 6, 30, 0, 0, time.UTC)
+	var klines []exchange.Kline
+	for i := 48; i >= 0; i-- {
+		at := now.Truncate(time.Hour).Add(-time.Duration(i) * time.Hour)
+		price := 100 + float64(i)
+		klines = append(klines, exchange.Kline{OpenTime: at.UnixMilli(), High: price + 1, Low: price - 1, Close: price})
+	}
+
+	high, low, prevClose := DailyLevels(klines, now)
+	// The 24h window opens with the 07:00 bar a day ago (i = 23)
+	if high != 124 || low != 99 {
+		t.Errorf("24h range = %v-%v, want 99-124", low, high)
+	}
+	// 23:00 the day before is 7 bars back
+	if prevClose != 107 {
+		t.Errorf("previous day close = %v, want 107", prevClose)
+	}
+
+	if high, low, prevClose := DailyLevels(nil, now); high != 0 || low != 0 || prevClose != 0 {
+		t.Error("no klines should give zeros")
+	}
+}
+
+func TestFormatKeyLevels(t *testing.T) {
+	levels := &KeyLevels{
+		Resistances:  []Level{{Price: 102, Touches: 2}, {Price: 110, Touches: 1}},
+		Supports:     []Level{{Price: 95, Touches: 3}},
+		High24h:      104,
+		Low24h:       90,
+		PrevDayClose: 99,
+	}
+
+	var sb strings.Builder
+	FormatKeyLevels(&sb, levels, 100)
+	out := sb.String()
+	for _, want := range []string{
+		"--- Key Levels ---",
+		"Resistance: $110.0000 (+10.00%, 1 touch)",
+		"Resistance: $102.0000 (+2.00%, 2 touches)",
+		"Support: $95.0000 (-5.00%, 3 touches)",
+		"24h High: $104.0000 (+4.00%) | 24h Low: $90.0000 (-10.00%)",
+		"Previous Day Close: $99.0000 (-1.00%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "$110") > strings.Index(out, "$102") {
+		t.Error("resistances should read top down")
+	}
+
+	sb.Reset()
+	FormatKeyLevels(&sb, &KeyLevels{}, 100)
+	if sb.Len() != 0 {
+		t.Errorf("empty levels wrote %q", sb.String())
+	}
+}
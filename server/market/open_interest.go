@@ -0,0 +1,136 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/exchange"
+)
+
+const (
+	// OIPeriod is the open interest history's period; fetched history is
+	// cached until the next one starts
+	OIPeriod         = "1h"
+	oiPeriodDuration = time.Hour
+	oiHistoryPoints  = 25 // 24 hours of change
+	oiTrendPoints    = 5  // 4 hours of change
+	oiTrendFlatPct   = 0.5
+)
+
+// Open interest and price verdicts, worded as in the debate system prompt's
+// trend following rules
+const (
+	OIVerdictStrongBullish = "OI up + Price up = Strong bullish trend"
+	OIVerdictShortCovering = "OI down + Price up = Shorts covering (potential reversal)"
+	OIVerdictStrongBearish = "OI up + Price down = Strong bearish trend"
+	OIVerdictLongsClosing  = "OI down + Price down = Longs closing (potential reversal)"
+)
+
+// OpenInterest is a symbol's open interest and how it moved
+type OpenInterest struct {
+	Value          float64 // USDT, latest period
+	Change24h      float64 // % change in contracts over 24 hours
+	Change4h       float64 // % change in contracts over 4 hours
+	Trend          string  // RISING, FALLING or FLAT over 4 hours
+	PriceChange24h float64 // % over the same 24 hours, from the value per contract
+}
+
+// AnalyzeOpenInterest computes the 24 hour change and the 4 hour trend from
+// hourly history, oldest first. Nil with fewer than two points.
+func AnalyzeOpenInterest(hist []exchange.OpenInterestHist) *OpenInterest {
+	if len(hist) < 2 {
+		return nil
+	}
+	last := hist[len(hist)-1]
+	first := hist[max(len(hist)-oiHistoryPoints, 0)]
+	recent := hist[max(len(hist)-oiTrendPoints, 0)]
+
+	oi := &OpenInterest{
+		Value:     last.SumOpenInterestValue,
+		Change24h: pctChange(first.SumOpenInterest, last.SumOpenInterest),
+		Change4h:  pctChange(recent.SumOpenInterest, last.SumOpenInterest),
+		Trend:     "FLAT",
+	}
+	if first.SumOpenInterest > 0 && last.SumOpenInterest > 0 {
+		oi.PriceChange24h = pctChange(first.SumOpenInterestValue/first.SumOpenInterest,
+			last.SumOpenInterestValue/last.SumOpenInterest)
+	}
+	if oi.Change4h >= oiTrendFlatPct {
+		oi.Trend = "RISING"
+	} else if oi.Change4h <= -oiTrendFlatPct {
+		oi.Trend = "FALLING"
+	}
+	return oi
+}
+
+// OIVerdict combines the open interest and price changes into the system
+// prompt's reading of them, empty when either didn't move
+func OIVerdict(oiChangePct, priceChangePct float64) string {
+	switch {
+	case oiChangePct > 0 && priceChangePct > 0:
+		return OIVerdictStrongBullish
+	case oiChangePct < 0 && priceChangePct > 0:
+		return OIVerdictShortCovering
+	case oiChangePct > 0 && priceChangePct < 0:
+		return OIVerdictStrongBearish
+	case oiChangePct < 0 && priceChangePct < 0:
+		return OIVerdictLongsClosing
+	}
+	return ""
+}
+
+func pctChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
+
+// cachedOI is a symbol's open interest, fetched during period
+type cachedOI struct {
+	oi     *OpenInterest
+	period time.Time
+}
+
+// OpenInterest returns symbol's open interest analysis, fetched at most once
+// per OIPeriod
+func (d *DataProvider) OpenInterest(ctx context.Context, symbol string) (*OpenInterest, error) {
+	period := time.Now().Truncate(oiPeriodDuration)
+
+	d.oiMu.Lock()
+	if cached, ok := d.oiCache[symbol]; ok && cached.period.Equal(period) {
+		d.oiMu.Unlock()
+		return cached.oi, nil
+	}
+	d.oiMu.Unlock()
+
+	hist, err := d.binance.GetOpenInterestHistory(ctx, symbol, OIPeriod, oiHistoryPoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	oi := AnalyzeOpenInterest(hist)
+	if oi == nil {
+		return nil, fmt.Errorf("not enough open interest history for %s", symbol)
+	}
+
+	d.oiMu.Lock()
+	if d.oiCache == nil {
+		d.oiCache = make(map[string]cachedOI)
+	}
+	d.oiCache[symbol] = cachedOI{oi: oi, period: period}
+	d.oiMu.Unlock()
+	return oi, nil
+}
+
+// formatOpenInterest writes the open interest, its changes and the verdict
+// combining them with the price
+func formatOpenInterest(sb *strings.Builder, oi *OpenInterest) {
+	sb.WriteString("--- Open Interest ---\n")
+	sb.WriteString(fmt.Sprintf("Open Interest: $%.2f | 24h Change: %+.2f%% (price %+.2f%%)\n", oi.Value, oi.Change24h, oi.PriceChange24h))
+	sb.WriteString(fmt.Sprintf("OI Trend (4h): %s (%+.2f%%)\n", oi.Trend, oi.Change4h))
+	if verdict := OIVerdict(oi.Change24h, oi.PriceChange24h); verdict != "" {
+		sb.WriteString(fmt.Sprintf("OI + Price: %s\n", verdict))
+	}
+}
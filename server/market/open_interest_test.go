@@ -0,0 +1,72 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// hourlyOI builds hourly history from contract counts at a fixed price
+func hourlyOI(price float64, contracts ...float64) []exchange.OpenInterestHist {
+	hist := make([]exchange.OpenInterestHist, len(contracts))
+	for i, c := range contracts {
+		hist[i] = exchange.OpenInterestHist{SumOpenInterest: c, SumOpenInterestValue: c * price, Timestamp: int64(i) * 3600_000}
+	}
+	return hist
+}
+
+func TestAnalyzeOpenInterest(t *testing.T) {
+	contracts := make([]float64, 30)
+	for i := range contracts {
+		contracts[i] = 1000 + 10*float64(i)
+	}
+	hist := hourlyOI(100, contracts...)
+	hist[len(hist)-1].SumOpenInterestValue = hist[len(hist)-1].SumOpenInterest * 110 // Price up 10%
+
+	oi := AnalyzeOpenInterest(hist)
+	// 24 hours back is the 25th point from the end: 1050 -> 1290 contracts
+	if math.Abs(oi.Change24h-240.0/1050*100) > 1e-9 || math.Abs(oi.PriceChange24h-10) > 1e-9 {
+		t.Errorf("24h change = %.4f%% OI, %.4f%% price; want %.4f%%, 10%%", oi.Change24h, oi.PriceChange24h, 240.0/1050*100)
+	}
+	if oi.Trend != "RISING" || math.Abs(oi.Change4h-40.0/1250*100) > 1e-9 {
+		t.Errorf("4h trend = %s %.4f%%, want RISING %.4f%%", oi.Trend, oi.Change4h, 40.0/1250*100)
+	}
+	if oi.Value != 1290*110 {
+		t.Errorf("value = %.2f, want the latest period's", oi.Value)
+	}
+
+	if got := AnalyzeOpenInterest(hourlyOI(100, 1000, 1001, 1002)).Trend; got != "FLAT" {
+		t.Errorf("0.2%% move trend = %s, want FLAT", got)
+	}
+	if AnalyzeOpenInterest(hourlyOI(100, 1000)) != nil {
+		t.Error("one point analyzed")
+	}
+}
+
+func TestOIVerdict(t *testing.T) {
+	for _, tt := range []struct {
+		oi, price float64
+		want      string
+	}{
+		{5, 2, OIVerdictStrongBullish},
+		{-5, 2, OIVerdictShortCovering},
+		{5, -2, OIVerdictStrongBearish},
+		{-5, -2, OIVerdictLongsClosing},
+		{0, 2, ""},
+	} {
+		if got := OIVerdict(tt.oi, tt.price); got != tt.want {
+			t.Errorf("OIVerdict(%v, %v) = %q, want %q", tt.oi, tt.price, got, tt.want)
+		}
+	}
+
+	var sb strings.Builder
+	formatOpenInterest(&sb, &OpenInterest{Value: 5e8, Change24h: -3.2, Change4h: -1.1, Trend: "FALLING", PriceChange24h: 1.5})
+	out := sb.String()
+	for _, want := range []string{"24h Change: -3.20% (price +1.50%)", "OI Trend (4h): FALLING (-1.10%)", "OI + Price: " + OIVerdictShortCovering} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
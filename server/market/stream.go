@@ -0,0 +1,436 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/exchange"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	binanceStreamURL        = "wss://fstream.binance.com/stream"
+	binanceTestnetStreamURL = "wss://stream.binancefuture.com/stream"
+
+	// StreamInterval is the kline interval kept live by the streams; other
+	// timeframes always come from REST
+	StreamInterval = "5m"
+
+	streamIntervalMs   = 5 * 60 * 1000
+	maxStreamKlines    = 500
+	streamStaleAfter   = 15 * time.Second // markPrice@1s goes quiet this long -> reconnect
+	streamReconnectMin = time.Second
+	streamReconnectMax = time.Minute
+	streamWriteTimeout = 5 * time.Second
+	streamDialTimeout  = 10 * time.Second
+)
+
+// StreamHealth is the state of a trader's market data connection
+type StreamHealth struct {
+	Connected   bool      `json:"connected"`
+	Symbols     int       `json:"symbols"`
+	ConnectedAt time.Time `json:"connected_at"`
+	LastMessage time.Time `json:"last_message"`
+	Reconnects  int       `json:"reconnects"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// streamSymbol is the cached market data of one subscribed symbol
+type streamSymbol struct {
+	klines    []exchange.Kline // StreamInterval bars, oldest first
+	seeded    bool             // klines hold REST history with no gap since
+	markPrice float64
+	markAt    time.Time
+}
+
+// StreamManager keeps klines and mark prices for a set of symbols current
+// over Binance's combined websocket streams. Klines are seeded from REST and
+// only served while the connection is live and no bar was missed.
+type StreamManager struct {
+	name string // Owner, for logs
+	url  string
+
+	mu      sync.RWMutex
+	symbols map[string]*streamSymbol
+	conn    *websocket.Conn
+	health  StreamHealth
+	nextID  int
+
+	writeMu sync.Mutex
+	stopCh  chan struct{}
+	running bool
+}
+
+// NewStreamManager creates a stream manager for mainnet or testnet futures.
+// name prefixes its log lines.
+func NewStreamManager(name string, testnet bool) *StreamManager {
+	url := binanceStreamURL
+	if testnet {
+		url = binanceTestnetStreamURL
+	}
+	return &StreamManager{
+		name:    name,
+		url:     url,
+		symbols: make(map[string]*streamSymbol),
+	}
+}
+
+// Start connects in the background and keeps reconnecting until Stop
+func (m *StreamManager) Start() {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = true
+	m.stopCh = make(chan struct{})
+	stopCh := m.stopCh
+	m.mu.Unlock()
+
+	go m.run(stopCh)
+}
+
+// Stop closes the connection and ends reconnecting
+func (m *StreamManager) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	close(m.stopCh)
+	conn := m.conn
+	m.mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// SetSymbols changes the subscribed symbols. Symbols dropped lose their cache.
+func (m *StreamManager) SetSymbols(symbols []string) {
+	want := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		want[strings.ToUpper(s)] = true
+	}
+
+	m.mu.Lock()
+	var added, removed []string
+	for s := range want {
+		if _, ok := m.symbols[s]; !ok {
+			m.symbols[s] = &streamSymbol{}
+			added = append(added, s)
+		}
+	}
+	for s := range m.symbols {
+		if !want[s] {
+			delete(m.symbols, s)
+			removed = append(removed, s)
+		}
+	}
+	m.health.Symbols = len(m.symbols)
+	conn := m.conn
+	m.mu.Unlock()
+
+	if conn == nil {
+		return // Subscribed on connect
+	}
+	if err := m.send(conn, "UNSUBSCRIBE", removed); err != nil {
+		log.Printf("[%s] Market stream unsubscribe failed: %v", m.name, err)
+	}
+	if err := m.send(conn, "SUBSCRIBE", added); err != nil {
+		log.Printf("[%s] Market stream subscribe failed: %v", m.name, err)
+	}
+}
+
+// Seed stores REST klines for symbol as the base the stream keeps current.
+// Only StreamInterval klines of subscribed symbols are kept.
+func (m *StreamManager) Seed(symbol, interval string, klines []exchange.Kline) {
+	if interval != StreamInterval || len(klines) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sym := m.symbols[symbol]
+	if sym == nil {
+		return
+	}
+	sym.klines = append([]exchange.Kline(nil), klines...)
+	if len(sym.klines) > maxStreamKlines {
+		sym.klines = sym.klines[len(sym.klines)-maxStreamKlines:]
+	}
+	sym.seeded = true
+}
+
+// Klines returns the last count cached klines for symbol, if the connection is
+// live and the cache is complete up to the current bar
+func (m *StreamManager) Klines(symbol, interval string, count int) ([]exchange.Kline, bool) {
+	if interval != StreamInterval {
+		return nil, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sym := m.symbols[symbol]
+	if !m.liveLocked() || sym == nil || !sym.seeded || len(sym.klines) < count {
+		return nil, false
+	}
+	last := sym.klines[len(sym.klines)-1]
+	if time.Now().UnixMilli() >= last.OpenTime+streamIntervalMs {
+		return nil, false // No update since the current bar opened
+	}
+	return append([]exchange.Kline(nil), sym.klines[len(sym.klines)-count:]...), true
+}
+
+// LastPrice returns the close of symbol's current cached bar
+func (m *StreamManager) LastPrice(symbol string) (float64, bool) {
+	klines, ok := m.Klines(symbol, StreamInterval, 1)
+	if !ok {
+		return 0, false
+	}
+	return klines[0].Close, true
+}
+
+// MarkPrice returns symbol's streamed mark price, if it's fresh
+func (m *StreamManager) MarkPrice(symbol string) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sym := m.symbols[symbol]
+	if !m.liveLocked() || sym == nil || sym.markPrice <= 0 || time.Since(sym.markAt) > streamStaleAfter {
+		return 0, false
+	}
+	return sym.markPrice, true
+}
+
+// Health returns the connection state
+func (m *StreamManager) Health() StreamHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	h := m.health
+	h.Connected = m.liveLocked()
+	return h
+}
+
+// liveLocked reports whether the connection is up and still receiving.
+// Caller must hold m.mu.
+func (m *StreamManager) liveLocked() bool {
+	return m.conn != nil && time.Since(m.health.LastMessage) < streamStaleAfter
+}
+
+// run connects and reads until stopped, backing off between reconnects
+func (m *StreamManager) run(stopCh chan struct{}) {
+	backoff := streamReconnectMin
+	for {
+		connectedAt := time.Now()
+		err := m.connectAndRead(stopCh)
+
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		m.mu.Lock()
+		m.health.Reconnects++
+		if err != nil {
+			m.health.LastError = err.Error()
+		}
+		m.mu.Unlock()
+
+		// A connection that held for a while starts the backoff over
+		if time.Since(connectedAt) > streamReconnectMax {
+			backoff = streamReconnectMin
+		}
+		log.Printf("[%s] Market stream disconnected (%v), reconnecting in %v", m.name, err, backoff)
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > streamReconnectMax {
+			backoff = streamReconnectMax
+		}
+	}
+}
+
+// connectAndRead dials, subscribes every symbol and handles messages until
+// the connection fails
+func (m *StreamManager) connectAndRead(stopCh chan struct{}) error {
+	dialer := websocket.Dialer{HandshakeTimeout: streamDialTimeout}
+	conn, _, err := dialer.Dial(m.url, nil)
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	m.mu.Lock()
+	select {
+	case <-stopCh:
+		m.mu.Unlock()
+		return nil
+	default:
+	}
+	m.conn = conn
+	m.health.ConnectedAt = time.Now()
+	m.health.LastMessage = time.Now()
+	symbols := make([]string, 0, len(m.symbols))
+	for s := range m.symbols {
+		symbols = append(symbols, s)
+	}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.conn = nil
+		m.mu.Unlock()
+	}()
+
+	sort.Strings(symbols)
+	if err := m.send(conn, "SUBSCRIBE", symbols); err != nil {
+		return fmt.Errorf("subscribe failed: %w", err)
+	}
+	log.Printf("[%s] Market stream connected, %d symbols", m.name, len(symbols))
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(streamStaleAfter))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		m.handleMessage(msg)
+	}
+}
+
+// send subscribes or unsubscribes the kline and mark price streams of symbols
+func (m *StreamManager) send(conn *websocket.Conn, method string, symbols []string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	params := make([]string, 0, len(symbols)*2)
+	for _, s := range symbols {
+		s = strings.ToLower(s)
+		params = append(params, s+"@kline_"+StreamInterval, s+"@markPrice@1s")
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := m.nextID
+	m.mu.Unlock()
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return conn.WriteJSON(map[string]interface{}{"method": method, "params": params, "id": id})
+}
+
+// streamMessage is a combined stream envelope
+type streamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type klineEvent struct {
+	Symbol string `json:"s"`
+	Kline  struct {
+		OpenTime  int64  `json:"t"`
+		CloseTime int64  `json:"T"`
+		Open      string `json:"o"`
+		High      string `json:"h"`
+		Low       string `json:"l"`
+		Close     string `json:"c"`
+		Volume    string `json:"v"`
+	} `json:"k"`
+}
+
+type markPriceEvent struct {
+	Symbol    string `json:"s"`
+	MarkPrice string `json:"p"`
+}
+
+// handleMessage applies one stream message to the cache. Subscription
+// replies carry no stream and are ignored.
+func (m *StreamManager) handleMessage(msg []byte) {
+	var env streamMessage
+	if err := json.Unmarshal(msg, &env); err != nil || env.Stream == "" {
+		m.mu.Lock()
+		m.health.LastMessage = time.Now()
+		m.mu.Unlock()
+		return
+	}
+
+	switch {
+	case strings.Contains(env.Stream, "@kline_"):
+		var ev klineEvent
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return
+		}
+		k := exchange.Kline{
+			OpenTime:  ev.Kline.OpenTime,
+			Open:      parseStreamFloat(ev.Kline.Open),
+			High:      parseStreamFloat(ev.Kline.High),
+			Low:       parseStreamFloat(ev.Kline.Low),
+			Close:     parseStreamFloat(ev.Kline.Close),
+			Volume:    parseStreamFloat(ev.Kline.Volume),
+			CloseTime: ev.Kline.CloseTime,
+		}
+		m.mu.Lock()
+		m.health.LastMessage = time.Now()
+		if sym := m.symbols[ev.Symbol]; sym != nil && sym.seeded {
+			var ok bool
+			if sym.klines, ok = applyKline(sym.klines, k); !ok {
+				// Missed bars, drop the cache until REST seeds it again
+				sym.klines, sym.seeded = nil, false
+			}
+		}
+		m.mu.Unlock()
+
+	case strings.Contains(env.Stream, "@markPrice"):
+		var ev markPriceEvent
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.health.LastMessage = time.Now()
+		if sym := m.symbols[ev.Symbol]; sym != nil {
+			sym.markPrice = parseStreamFloat(ev.MarkPrice)
+			sym.markAt = time.Now()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// applyKline merges a streamed bar into klines: an update to the last bar
+// replaces it, the next bar is appended. ok is false when bars are missing
+// in between.
+func applyKline(klines []exchange.Kline, k exchange.Kline) ([]exchange.Kline, bool) {
+	if len(klines) == 0 {
+		return klines, false
+	}
+	last := klines[len(klines)-1]
+	switch {
+	case k.OpenTime == last.OpenTime:
+		klines[len(klines)-1] = k
+	case k.OpenTime == last.OpenTime+streamIntervalMs:
+		klines = append(klines, k)
+		if len(klines) > maxStreamKlines {
+			klines = klines[len(klines)-maxStreamKlines:]
+		}
+	case k.OpenTime < last.OpenTime:
+		// Late update for a bar already closed
+	default:
+		return klines, false
+	}
+	return klines, true
+}
+
+func parseStreamFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
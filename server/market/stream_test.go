@@ -0,0 +1,371 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"auto-trader-ahh/exchange"
+)
+
+// streamServer is a local stand-in for Binance's combined streams. Tests read
+// each connection and the subscription requests it receives, and push
+// messages to the client over the connection.
+type streamServer struct {
+	url   string
+	conns chan *websocket.Conn
+	subs  chan streamRequest
+}
+
+type streamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+func newStreamServer(t *testing.T) *streamServer {
+	t.Helper()
+	s := &streamServer{conns: make(chan *websocket.Conn, 4), subs: make(chan streamRequest, 16)}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.conns <- conn
+		for {
+			var req streamRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.subs <- req
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s.url = "ws" + strings.TrimPrefix(srv.URL, "http")
+	return s
+}
+
+func (s *streamServer) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream never connected")
+		return nil
+	}
+}
+
+// expect waits for the next subscription request and checks it
+func (s *streamServer) expect(t *testing.T, method string, symbols ...string) {
+	t.Helper()
+	var want []string
+	for _, sym := range symbols {
+		want = append(want, sym+"@kline_"+StreamInterval, sym+"@markPrice@1s")
+	}
+	select {
+	case req := <-s.subs:
+		if req.Method != method || strings.Join(req.Params, ",") != strings.Join(want, ",") {
+			t.Errorf("request = %s %v, want %s %v", req.Method, req.Params, method, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s %v", method, symbols)
+	}
+}
+
+func startTestStream(t *testing.T, url string, symbols ...string) *StreamManager {
+	t.Helper()
+	m := NewStreamManager("test", false)
+	m.url = url
+	m.SetSymbols(symbols)
+	m.Start()
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func pushKline(t *testing.T, conn *websocket.Conn, symbol string, openTime int64, close float64) {
+	t.Helper()
+	msg := fmt.Sprintf(`{"stream":"%s@kline_5m","data":{"e":"kline","s":"%s","k":{"t":%d,"T":%d,"o":"100","h":"%g","l":"99","c":"%g","v":"12"}}}`,
+		strings.ToLower(symbol), symbol, openTime, openTime+streamIntervalMs-1, close, close)
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls until cond holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// testBars returns n flat StreamInterval bars, the last one open now
+func testBars(n int, price float64) []exchange.Kline {
+	current := time.Now().UnixMilli() / streamIntervalMs * streamIntervalMs
+	bars := make([]exchange.Kline, n)
+	for i := range bars {
+		open := current - int64(n-1-i)*streamIntervalMs
+		bars[i] = exchange.Kline{OpenTime: open, Open: price, High: price, Low: price, Close: price, Volume: 10, CloseTime: open + streamIntervalMs - 1}
+	}
+	return bars
+}
+
+// awayFromBarClose waits out the end of the current bar if it's near, so a
+// new bar doesn't open in the middle of a test and make its cache stale
+func awayFromBarClose(t *testing.T) {
+	t.Helper()
+	if left := streamIntervalMs - time.Now().UnixMilli()%streamIntervalMs; left < 5000 {
+		time.Sleep(time.Duration(left+100) * time.Millisecond)
+	}
+}
+
+// ageStream makes the connection look quiet for longer than streamStaleAfter
+func ageStream(m *StreamManager) {
+	m.mu.Lock()
+	m.health.LastMessage = time.Now().Add(-streamStaleAfter)
+	m.mu.Unlock()
+}
+
+func TestStreamCache(t *testing.T) {
+	awayFromBarClose(t)
+	server := newStreamServer(t)
+	m := startTestStream(t, server.url, "btcusdt")
+	conn := server.accept(t)
+	server.expect(t, "SUBSCRIBE", "btcusdt")
+
+	// Nothing is served before REST seeds the cache, and bars streamed
+	// until then aren't kept
+	bars := testBars(30, 100)
+	current := bars[len(bars)-1].OpenTime
+	pushKline(t, conn, "BTCUSDT", current, 100.5)
+	if _, ok := m.Klines("BTCUSDT", StreamInterval, 1); ok {
+		t.Error("klines served before seeding")
+	}
+
+	m.Seed("BTCUSDT", "1h", bars)
+	m.Seed("ETHUSDT", StreamInterval, bars)
+	if _, ok := m.Klines("BTCUSDT", StreamInterval, 1); ok {
+		t.Error("another interval seeded the cache")
+	}
+	if _, ok := m.Klines("ETHUSDT", StreamInterval, 1); ok {
+		t.Error("an unsubscribed symbol was seeded")
+	}
+	m.Seed("BTCUSDT", StreamInterval, bars)
+
+	// An update to the current bar replaces it
+	pushKline(t, conn, "BTCUSDT", current, 101.5)
+	waitFor(t, "the bar update", func() bool {
+		price, ok := m.LastPrice("BTCUSDT")
+		return ok && price == 101.5
+	})
+	klines, ok := m.Klines("BTCUSDT", StreamInterval, 30)
+	if !ok || len(klines) != 30 || klines[29].High != 101.5 || klines[28].Close != 100 {
+		t.Errorf("klines = %d, %v", len(klines), ok)
+	}
+	if _, ok := m.Klines("BTCUSDT", StreamInterval, 31); ok {
+		t.Error("served more klines than cached")
+	}
+	if _, ok := m.Klines("BTCUSDT", "1h", 1); ok {
+		t.Error("served another interval from the cache")
+	}
+
+	// The next bar is appended
+	pushKline(t, conn, "BTCUSDT", current+streamIntervalMs, 102)
+	waitFor(t, "the next bar", func() bool {
+		price, _ := m.LastPrice("BTCUSDT")
+		return price == 102
+	})
+	if klines, ok := m.Klines("BTCUSDT", StreamInterval, 2); !ok || klines[0].Close != 101.5 || klines[1].OpenTime != current+streamIntervalMs {
+		t.Errorf("klines after the next bar = %+v", klines)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@markPrice@1s","data":{"e":"markPriceUpdate","s":"BTCUSDT","p":"101.75"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the mark price", func() bool {
+		price, ok := m.MarkPrice("BTCUSDT")
+		return ok && price == 101.75
+	})
+	if h := m.Health(); !h.Connected || h.Symbols != 1 || h.Reconnects != 0 {
+		t.Errorf("health = %+v", h)
+	}
+
+	// A quiet connection serves nothing until it hears from Binance again
+	ageStream(m)
+	if _, ok := m.LastPrice("BTCUSDT"); ok {
+		t.Error("last price served from a stale connection")
+	}
+	if _, ok := m.MarkPrice("BTCUSDT"); ok {
+		t.Error("mark price served from a stale connection")
+	}
+	if m.Health().Connected {
+		t.Error("stale connection reported connected")
+	}
+
+	// A missed bar drops the cache until it's seeded again
+	pushKline(t, conn, "BTCUSDT", current+3*streamIntervalMs, 103)
+	waitFor(t, "the gap", func() bool {
+		_, ok := m.Klines("BTCUSDT", StreamInterval, 1)
+		return !ok && m.Health().Connected
+	})
+	m.Seed("BTCUSDT", StreamInterval, testBars(30, 104))
+	if price, ok := m.LastPrice("BTCUSDT"); !ok || price != 104 {
+		t.Errorf("last price after reseeding = %v, %v", price, ok)
+	}
+
+	// A cache whose last bar closed got no update for the current one
+	m.Seed("BTCUSDT", StreamInterval, bars[:len(bars)-1])
+	if _, ok := m.Klines("BTCUSDT", StreamInterval, 1); ok {
+		t.Error("klines served without the current bar")
+	}
+}
+
+func TestStreamSetSymbolsAndReconnect(t *testing.T) {
+	awayFromBarClose(t)
+	server := newStreamServer(t)
+	m := startTestStream(t, server.url, "btcusdt")
+	conn := server.accept(t)
+	server.expect(t, "SUBSCRIBE", "btcusdt")
+	m.Seed("BTCUSDT", StreamInterval, testBars(30, 100))
+
+	// Changing symbols moves the live subscription and drops the old cache
+	m.SetSymbols([]string{"ethusdt"})
+	server.expect(t, "UNSUBSCRIBE", "btcusdt")
+	server.expect(t, "SUBSCRIBE", "ethusdt")
+	if _, ok := m.Klines("BTCUSDT", StreamInterval, 1); ok {
+		t.Error("a dropped symbol kept its cache")
+	}
+	m.SetSymbols([]string{"ETHUSDT"})
+	m.Seed("ETHUSDT", StreamInterval, testBars(30, 2000))
+	if price, ok := m.LastPrice("ETHUSDT"); !ok || price != 2000 {
+		t.Errorf("ETHUSDT price = %v, %v", price, ok)
+	}
+
+	// After a drop it reconnects and subscribes the current symbols again
+	conn.Close()
+	conn = server.accept(t)
+	server.expect(t, "SUBSCRIBE", "ethusdt")
+	waitFor(t, "the reconnect", func() bool {
+		h := m.Health()
+		return h.Connected && h.Reconnects == 1
+	})
+	pushKline(t, conn, "ETHUSDT", testBars(1, 0)[0].OpenTime, 2010)
+	waitFor(t, "a bar on the new connection", func() bool {
+		price, _ := m.LastPrice("ETHUSDT")
+		return price == 2010
+	})
+	select {
+	case req := <-server.subs:
+		t.Errorf("unexpected request %+v", req)
+	default:
+	}
+}
+
+// restServer serves StreamInterval klines opening now and a ticker price,
+// counting the requests for each
+type restServer struct {
+	mu      sync.Mutex
+	klines  int
+	tickers int
+	price   float64
+}
+
+func (s *restServer) counts() (klines, tickers int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.klines, s.tickers
+}
+
+func (s *restServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.URL.Path == "/fapi/v1/klines" && r.URL.Query().Get("interval") == StreamInterval:
+		s.klines++
+		var rows [][]interface{}
+		for _, k := range testBars(30, s.price) {
+			p := fmt.Sprint(k.Close)
+			rows = append(rows, []interface{}{k.OpenTime, p, p, p, p, "10", k.CloseTime})
+		}
+		json.NewEncoder(w).Encode(rows)
+	case r.URL.Path == "/fapi/v1/ticker/price":
+		s.tickers++
+		fmt.Fprintf(w, `{"symbol":"BTCUSDT","price":"%g"}`, s.price)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDataProviderStreamFallback(t *testing.T) {
+	awayFromBarClose(t)
+	exchange.SetRequestRate(0)
+	t.Cleanup(func() { exchange.SetRequestRate(exchange.DefaultRequestsPerSec) })
+	rest := &restServer{price: 100}
+	restSrv := httptest.NewServer(rest)
+	t.Cleanup(restSrv.Close)
+	server := newStreamServer(t)
+	m := startTestStream(t, server.url, "btcusdt")
+	conn := server.accept(t)
+	server.expect(t, "SUBSCRIBE", "btcusdt")
+	d := NewDataProvider(exchange.NewBinanceClientAt("", "", restSrv.URL), m)
+	ctx := context.Background()
+
+	fetch := func(wantKlines, wantTickers int, wantPrice float64) {
+		t.Helper()
+		data, err := d.GetMarketDataWithIndicators(ctx, "BTCUSDT", StreamInterval, 30, Indicators{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if klines, tickers := rest.counts(); klines != wantKlines || tickers != wantTickers {
+			t.Errorf("REST requests = %d klines, %d tickers; want %d, %d", klines, tickers, wantKlines, wantTickers)
+		}
+		if data.CurrentPrice != wantPrice || len(data.Klines) != 30 {
+			t.Errorf("price = %v with %d klines, want %v", data.CurrentPrice, len(data.Klines), wantPrice)
+		}
+	}
+
+	// The first fetch seeds the cache from REST, the next one is served from it
+	fetch(1, 0, 100)
+	pushKline(t, conn, "BTCUSDT", testBars(1, 0)[0].OpenTime, 100.5)
+	waitFor(t, "the bar update", func() bool {
+		price, _ := m.LastPrice("BTCUSDT")
+		return price == 100.5
+	})
+	fetch(1, 0, 100.5)
+
+	// A gap goes back to REST, which seeds the cache again
+	rest.mu.Lock()
+	rest.price = 101
+	rest.mu.Unlock()
+	pushKline(t, conn, "BTCUSDT", testBars(1, 0)[0].OpenTime+2*streamIntervalMs, 110)
+	waitFor(t, "the gap", func() bool {
+		_, ok := m.Klines("BTCUSDT", StreamInterval, 1)
+		return !ok
+	})
+	fetch(2, 0, 101)
+
+	// So does a stale connection, for the price too
+	ageStream(m)
+	fetch(3, 1, 101)
+	if price, err := d.CurrentPrice(ctx, "BTCUSDT"); err != nil || price != 101 {
+		t.Errorf("CurrentPrice = %v, %v", price, err)
+	}
+	if _, tickers := rest.counts(); tickers != 2 {
+		t.Errorf("ticker requests = %d, want 2", tickers)
+	}
+}
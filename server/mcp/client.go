@@ -3,11 +3,14 @@ package mcp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,6 +18,10 @@ import (
 type Client struct {
 	config     *Config
 	httpClient *http.Client
+
+	// Models the provider refused response_format for; they get plain requests
+	unstructured   map[string]bool
+	unstructuredMu sync.RWMutex
 }
 
 // NewClient creates a new AI client with the given options
@@ -29,6 +36,7 @@ func NewClient(opts ...Option) *Client {
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 		},
+		unstructured: make(map[string]bool),
 	}
 }
 
@@ -80,6 +88,13 @@ func WithMaxRetries(n int) Option {
 	}
 }
 
+// WithStructuredOutput enables sending response_format schemas
+func WithStructuredOutput(enabled bool) Option {
+	return func(c *Config) {
+		c.StructuredOutput = enabled
+	}
+}
+
 // WithTokenUsageCallback sets the token usage callback
 func WithTokenUsageCallback(cb TokenUsageCallback) Option {
 	return func(c *Config) {
@@ -87,6 +102,18 @@ func WithTokenUsageCallback(cb TokenUsageCallback) Option {
 	}
 }
 
+// WithCaller sets the caller the client's calls queue as
+func WithCaller(caller string) Option {
+	return func(c *Config) {
+		c.Caller = caller
+	}
+}
+
+// SetCaller implements CallerSetter
+func (c *Client) SetCaller(caller string) {
+	c.config.Caller = caller
+}
+
 // SetAPIKey implements AIClient
 func (c *Client) SetAPIKey(apiKey, customURL, customModel string) {
 	c.config.APIKey = apiKey
@@ -116,42 +143,148 @@ func (c *Client) GetModel() string {
 
 // CallWithMessages implements AIClient
 func (c *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	resp, err := c.CallWithModel(context.Background(), "", systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// CallWithModel implements AIClient
+func (c *Client) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*Response, error) {
+	req := &Request{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	DefaultGenerationParams().Apply(req)
+	return c.callWithRequest(ctx, req)
+}
+
+// CallStructured implements StructuredCaller
+func (c *Client) CallStructured(ctx context.Context, model, systemPrompt, userPrompt string, format *ResponseFormat) (*Response, error) {
 	req := &Request{
-		Model: c.config.Model,
+		Model: model,
 		Messages: []Message{
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
-		Temperature: 0.7,
-		MaxTokens:   4096,
+		ResponseFormat: format,
 	}
-	resp, err := c.CallWithRequest(req)
+	DefaultGenerationParams().Apply(req)
+	return c.callWithRequest(ctx, req)
+}
+
+// ListModels implements ModelLister using the OpenAI-compatible /models endpoint
+func (c *Client) ListModels() ([]ModelInfo, error) {
+	httpReq, err := http.NewRequest("GET", c.config.BaseURL+"/models", nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return resp.Content, nil
+	if c.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Data, nil
+}
+
+// CheckKey implements KeyChecker with an authenticated /models request.
+// OpenRouter lists models for anyone, so there /auth/key is asked instead.
+func (c *Client) CheckKey(ctx context.Context) error {
+	if c.config.APIKey == "" {
+		return ErrNoAPIKey
+	}
+	endpoint := "/models"
+	if c.config.Provider == ProviderOpenRouter {
+		endpoint = "/auth/key"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.config.BaseURL+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+	io.Copy(io.Discard, httpResp.Body)
+
+	switch {
+	case httpResp.StatusCode == http.StatusUnauthorized || httpResp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("API key rejected (status %d)", httpResp.StatusCode)
+	case httpResp.StatusCode != http.StatusOK:
+		return &APIError{StatusCode: httpResp.StatusCode}
+	}
+	return nil
 }
 
 // CallWithRequest implements AIClient
 func (c *Client) CallWithRequest(req *Request) (*Response, error) {
+	return c.callWithRequest(context.Background(), req)
+}
+
+func (c *Client) callWithRequest(ctx context.Context, req *Request) (*Response, error) {
 	if req.Model == "" {
 		req.Model = c.config.Model
 	}
+	c.applyResponseFormat(req)
 
 	var lastErr error
 	for attempt := 1; attempt <= c.config.MaxRetries; attempt++ {
-		resp, err := c.doCall(req)
+		release, err := SchedulerFor(c.config.Provider).Acquire(ctx, c.config.Caller)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.doCall(ctx, req)
+		if err != nil && c.rejectedStructured(req, err) {
+			resp, err = c.doCall(ctx, req)
+		}
+		release()
 		if err == nil {
 			return resp, nil
 		}
 
 		lastErr = err
+		if ctx.Err() != nil {
+			return nil, err
+		}
 		if !isRetryableError(err) {
 			return nil, err
 		}
 
 		if attempt < c.config.MaxRetries {
-			time.Sleep(c.config.RetryDelay * time.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.config.RetryDelay * time.Duration(attempt)):
+			}
 		}
 	}
 
@@ -164,10 +297,19 @@ func (c *Client) CallStream(req *Request, handler ChunkHandler) (*Response, erro
 		req.Model = c.config.Model
 	}
 	req.Stream = true
+	c.applyResponseFormat(req)
 
 	var lastErr error
 	for attempt := 1; attempt <= c.config.MaxRetries; attempt++ {
+		release, err := SchedulerFor(c.config.Provider).Acquire(context.Background(), c.config.Caller)
+		if err != nil {
+			return nil, err
+		}
 		resp, err := c.doCallStream(req, handler)
+		if err != nil && c.rejectedStructured(req, err) {
+			resp, err = c.doCallStream(req, handler)
+		}
+		release()
 		if err == nil {
 			return resp, nil
 		}
@@ -185,8 +327,38 @@ func (c *Client) CallStream(req *Request, handler ChunkHandler) (*Response, erro
 	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
+// applyResponseFormat drops the schema unless this client and model can use it
+func (c *Client) applyResponseFormat(req *Request) {
+	if req.ResponseFormat == nil {
+		return
+	}
+	c.unstructuredMu.RLock()
+	unsupported := c.unstructured[req.Model]
+	c.unstructuredMu.RUnlock()
+
+	if !c.config.StructuredOutput || c.config.Provider == ProviderAnthropic || unsupported {
+		req.ResponseFormat = nil
+	}
+}
+
+// rejectedStructured reports whether err is the provider refusing response_format.
+// The model is remembered and the schema removed so the caller can retry plainly.
+func (c *Client) rejectedStructured(req *Request, err error) bool {
+	if req.ResponseFormat == nil || !isStructuredOutputUnsupported(err) {
+		return false
+	}
+	log.Printf("[MCP] %s rejected response_format, falling back to plain output: %v", req.Model, err)
+
+	c.unstructuredMu.Lock()
+	c.unstructured[req.Model] = true
+	c.unstructuredMu.Unlock()
+
+	req.ResponseFormat = nil
+	return true
+}
+
 // doCall makes a single API call
-func (c *Client) doCall(req *Request) (*Response, error) {
+func (c *Client) doCall(ctx context.Context, req *Request) (*Response, error) {
 	start := time.Now()
 
 	// Build request based on provider
@@ -206,7 +378,7 @@ func (c *Client) doCall(req *Request) (*Response, error) {
 	}
 
 	// Make request
-	httpResp, err := c.httpClient.Do(httpReq)
+	httpResp, err := c.httpClient.Do(httpReq.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -218,7 +390,7 @@ func (c *Client) doCall(req *Request) (*Response, error) {
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", httpResp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
 	}
 
 	// Parse response based on provider
@@ -237,7 +409,10 @@ func (c *Client) doCall(req *Request) (*Response, error) {
 	resp.Duration = time.Since(start)
 	resp.Timestamp = time.Now()
 	resp.Provider = c.config.Provider
-	resp.Model = req.Model
+	resp.Structured = req.ResponseFormat != nil
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
 
 	// Call token usage callback if set
 	if c.config.OnTokenUsage != nil {
@@ -279,7 +454,7 @@ func (c *Client) doCallStream(req *Request, handler ChunkHandler) (*Response, er
 
 	if httpResp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", httpResp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
 	}
 
 	// Handle streaming response
@@ -332,11 +507,12 @@ func (c *Client) doCallStream(req *Request, handler ChunkHandler) (*Response, er
 	}
 
 	resp := &Response{
-		Content:   fullContent.String(),
-		Duration:  time.Since(start),
-		Timestamp: time.Now(),
-		Model:     req.Model,
-		Provider:  c.config.Provider,
+		Content:    fullContent.String(),
+		Duration:   time.Since(start),
+		Timestamp:  time.Now(),
+		Model:      req.Model,
+		Provider:   c.config.Provider,
+		Structured: req.ResponseFormat != nil,
 	}
 
 	return resp, nil
@@ -350,8 +526,8 @@ func (c *Client) buildOpenAIRequest(req *Request) (*http.Request, error) {
 		"stream":   req.Stream,
 	}
 
-	if req.Temperature > 0 {
-		payload["temperature"] = req.Temperature
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
 	}
 	if req.MaxTokens > 0 {
 		payload["max_tokens"] = req.MaxTokens
@@ -359,9 +535,20 @@ func (c *Client) buildOpenAIRequest(req *Request) (*http.Request, error) {
 	if req.TopP > 0 {
 		payload["top_p"] = req.TopP
 	}
+	if req.ReasoningEffort != "" {
+		// OpenRouter normalizes its reasoning option across providers
+		if c.config.Provider == ProviderOpenRouter {
+			payload["reasoning"] = map[string]string{"effort": req.ReasoningEffort}
+		} else {
+			payload["reasoning_effort"] = req.ReasoningEffort
+		}
+	}
 	if len(req.Stop) > 0 {
 		payload["stop"] = req.Stop
 	}
+	if req.ResponseFormat != nil {
+		payload["response_format"] = req.ResponseFormat
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -413,8 +600,11 @@ func (c *Client) buildAnthropicRequest(req *Request) (*http.Request, error) {
 	if systemPrompt != "" {
 		payload["system"] = systemPrompt
 	}
-	if req.Temperature > 0 {
-		payload["temperature"] = req.Temperature
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
+	}
+	if req.TopP > 0 {
+		payload["top_p"] = req.TopP
 	}
 	if len(req.Stop) > 0 {
 		payload["stop_sequences"] = req.Stop
@@ -441,6 +631,7 @@ func (c *Client) buildAnthropicRequest(req *Request) (*http.Request, error) {
 // parseOpenAIResponse parses an OpenAI-compatible response
 func (c *Client) parseOpenAIResponse(body []byte) (*Response, error) {
 	var result struct {
+		Model   string `json:"model"` // May differ from the request when the provider routes it
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
@@ -470,6 +661,7 @@ func (c *Client) parseOpenAIResponse(body []byte) (*Response, error) {
 
 	return &Response{
 		Content: result.Choices[0].Message.Content,
+		Model:   result.Model,
 		Usage: Usage{
 			PromptTokens:     result.Usage.PromptTokens,
 			CompletionTokens: result.Usage.CompletionTokens,
@@ -481,6 +673,7 @@ func (c *Client) parseOpenAIResponse(body []byte) (*Response, error) {
 // parseAnthropicResponse parses an Anthropic response
 func (c *Client) parseAnthropicResponse(body []byte) (*Response, error) {
 	var result struct {
+		Model   string `json:"model"`
 		Content []struct {
 			Type string `json:"type"`
 			Text string `json:"text"`
@@ -512,6 +705,7 @@ func (c *Client) parseAnthropicResponse(body []byte) (*Response, error) {
 
 	return &Response{
 		Content: content,
+		Model:   result.Model,
 		Usage: Usage{
 			PromptTokens:     result.Usage.InputTokens,
 			CompletionTokens: result.Usage.OutputTokens,
@@ -548,12 +742,38 @@ func isRetryableError(err error) bool {
 	return false
 }
 
-// NewOpenRouterClient creates a client configured for OpenRouter
+// isStructuredOutputUnsupported checks if a request failed because of response_format
+func isStructuredOutputUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := strings.ToLower(err.Error())
+	if !strings.Contains(errStr, "status 400") && !strings.Contains(errStr, "status 404") &&
+		!strings.Contains(errStr, "status 422") {
+		return false
+	}
+	patterns := []string{
+		"response_format",
+		"json_schema",
+		"structured output",
+		"no endpoints found that can handle the requested parameters",
+	}
+	for _, pattern := range patterns {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewOpenRouterClient creates a client configured for OpenRouter.
+// Structured output is on; models that don't support it fall back to plain text.
 func NewOpenRouterClient(apiKey, model string) *Client {
 	return NewClient(
 		WithProvider(ProviderOpenRouter),
 		WithAPIKey(apiKey),
 		WithModel(model),
+		WithStructuredOutput(true),
 	)
 }
 
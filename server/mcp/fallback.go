@@ -0,0 +1,66 @@
+package mcp
+
+import "sync"
+
+// FallbackChain is primary followed by the fallback models, in order, without
+// blanks or repeats
+func FallbackChain(primary string, fallbacks []string) []string {
+	chain := make([]string, 0, len(fallbacks)+1)
+	seen := make(map[string]bool, len(fallbacks)+1)
+	for _, model := range append([]string{primary}, fallbacks...) {
+		if model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		chain = append(chain, model)
+	}
+	return chain
+}
+
+// ModelStat is one model's call record
+type ModelStat struct {
+	Calls       int64   `json:"calls"`
+	Failures    int64   `json:"failures"`
+	FailureRate float64 `json:"failure_rate"` // Failed share of calls, 0-1
+}
+
+// ModelStats counts calls and failures per model, to show which models of a
+// fallback chain are degraded
+type ModelStats struct {
+	mu     sync.Mutex
+	models map[string]*ModelStat
+}
+
+// NewModelStats creates an empty record
+func NewModelStats() *ModelStats {
+	return &ModelStats{models: make(map[string]*ModelStat)}
+}
+
+// Record counts one call to model
+func (s *ModelStats) Record(model string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat := s.models[model]
+	if stat == nil {
+		stat = &ModelStat{}
+		s.models[model] = stat
+	}
+	stat.Calls++
+	if failed {
+		stat.Failures++
+	}
+	stat.FailureRate = float64(stat.Failures) / float64(stat.Calls)
+}
+
+// Snapshot returns a copy of every model's counts so far
+func (s *ModelStats) Snapshot() map[string]ModelStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]ModelStat, len(s.models))
+	for model, stat := range s.models {
+		snapshot[model] = *stat
+	}
+	return snapshot
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultConfig describes the latency and failures injected into AI calls. The
+// zero value injects nothing.
+type FaultConfig struct {
+	Latency   time.Duration // Added to every call
+	ErrorRate float64       // Chance (0-1) a call fails with a 502
+	Seed      int64         // 0 seeds from the clock
+}
+
+// Enabled reports whether the config injects anything
+func (f FaultConfig) Enabled() bool {
+	return f.Latency > 0 || f.ErrorRate > 0
+}
+
+// FaultyClient is an AIClient that delays calls and fails some of them
+// before they reach the wrapped client, to test how callers cope with a slow
+// or failing provider
+type FaultyClient struct {
+	AIClient
+	faults FaultConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewFaultyClient wraps client with faults
+func NewFaultyClient(client AIClient, faults FaultConfig) *FaultyClient {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &FaultyClient{AIClient: client, faults: faults, rng: rand.New(rand.NewSource(seed))}
+}
+
+// inject waits the latency and returns the injected error, if any
+func (c *FaultyClient) inject(ctx context.Context) error {
+	if c.faults.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.faults.Latency):
+		}
+	}
+	c.mu.Lock()
+	failed := c.faults.ErrorRate > 0 && c.rng.Float64() < c.faults.ErrorRate
+	c.mu.Unlock()
+	if failed {
+		return &APIError{StatusCode: 502, Body: "injected fault"}
+	}
+	return nil
+}
+
+// SetCaller implements CallerSetter when the wrapped client does
+func (c *FaultyClient) SetCaller(caller string) {
+	if setter, ok := c.AIClient.(CallerSetter); ok {
+		setter.SetCaller(caller)
+	}
+}
+
+// CallWithMessages implements AIClient
+func (c *FaultyClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	if err := c.inject(context.Background()); err != nil {
+		return "", err
+	}
+	return c.AIClient.CallWithMessages(systemPrompt, userPrompt)
+}
+
+// CallWithModel implements AIClient
+func (c *FaultyClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*Response, error) {
+	if err := c.inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.AIClient.CallWithModel(ctx, model, systemPrompt, userPrompt)
+}
+
+// CallWithRequest implements AIClient
+func (c *FaultyClient) CallWithRequest(req *Request) (*Response, error) {
+	if err := c.inject(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.AIClient.CallWithRequest(req)
+}
+
+// CallStream implements AIClient
+func (c *FaultyClient) CallStream(req *Request, handler ChunkHandler) (*Response, error) {
+	if err := c.inject(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.AIClient.CallStream(req, handler)
+}
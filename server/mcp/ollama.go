@@ -0,0 +1,272 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOllamaURL is where Ollama listens by default
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaClient calls a local Ollama server's /api/chat. Ollama has no
+// response_format, so it doesn't implement StructuredCaller and decisions
+// always go through the regex parser.
+type OllamaClient struct {
+	baseURL    string
+	model      string
+	timeout    time.Duration // Total time for one call, including reading the stream
+	httpClient *http.Client
+	caller     string // Whose turn calls take in the Ollama scheduler
+}
+
+// NewOllamaClient creates a client for the Ollama server at baseURL, the
+// default local one when empty
+func NewOllamaClient(baseURL, model string) *OllamaClient {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	return &OllamaClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		timeout: DefaultConfig().Timeout,
+		// No client timeout: it would cut long streams, the call deadline bounds them
+		httpClient: &http.Client{},
+	}
+}
+
+// ollamaChatRequest is the /api/chat request body
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// ollamaChatChunk is one line of the /api/chat stream. The last one has Done
+// set and the token counts.
+type ollamaChatChunk struct {
+	Model   string  `json:"model"`
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	Error   string  `json:"error"`
+
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// SetCaller implements CallerSetter
+func (c *OllamaClient) SetCaller(caller string) {
+	c.caller = caller
+}
+
+// SetAPIKey implements AIClient. Ollama needs no key; the URL and model are used
+// when set.
+func (c *OllamaClient) SetAPIKey(apiKey, customURL, customModel string) {
+	if customURL != "" {
+		c.baseURL = strings.TrimRight(customURL, "/")
+	}
+	if customModel != "" {
+		c.model = customModel
+	}
+}
+
+// SetTimeout implements AIClient
+func (c *OllamaClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// GetProvider implements AIClient
+func (c *OllamaClient) GetProvider() string {
+	return ProviderOllama
+}
+
+// GetModel implements AIClient
+func (c *OllamaClient) GetModel() string {
+	return c.model
+}
+
+// CallWithMessages implements AIClient
+func (c *OllamaClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	resp, err := c.CallWithModel(context.Background(), "", systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// CallWithModel implements AIClient
+func (c *OllamaClient) CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*Response, error) {
+	req := &Request{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	DefaultGenerationParams().Apply(req)
+	return c.chat(ctx, req, nil)
+}
+
+// CallWithRequest implements AIClient
+func (c *OllamaClient) CallWithRequest(req *Request) (*Response, error) {
+	return c.chat(context.Background(), req, nil)
+}
+
+// CallStream implements AIClient
+func (c *OllamaClient) CallStream(req *Request, handler ChunkHandler) (*Response, error) {
+	return c.chat(context.Background(), req, handler)
+}
+
+// chat streams one /api/chat call, passing each chunk to handler when set.
+// The whole call, reading included, is bounded by the client timeout.
+func (c *OllamaClient) chat(ctx context.Context, req *Request, handler ChunkHandler) (*Response, error) {
+	start := time.Now()
+	model := req.Model
+	if model == "" {
+		model = c.model
+	}
+	if model == "" {
+		return nil, errors.New("no Ollama model configured")
+	}
+
+	options := map[string]interface{}{}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.TopP > 0 {
+		options["top_p"] = req.TopP
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(req.Stop) > 0 {
+		options["stop"] = req.Stop
+	}
+	body, err := json.Marshal(ollamaChatRequest{Model: model, Messages: req.Messages, Stream: true, Options: options})
+	if err != nil {
+		return nil, err
+	}
+
+	// The wait for a turn doesn't count against the call's timeout
+	release, err := SchedulerFor(ProviderOllama).Acquire(ctx, c.caller)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(httpResp.Body)
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(respBody)}
+	}
+
+	resp := &Response{Model: model, Provider: ProviderOllama}
+	var content strings.Builder
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	done := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if text := chunk.Message.Content; text != "" {
+			content.WriteString(text)
+			if handler != nil {
+				if err := handler(text); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if chunk.Done {
+			done = true
+			resp.Usage = Usage{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("no complete response within %v: %w", c.timeout, err)
+		}
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if !done {
+		return nil, errors.New("response stream ended early")
+	}
+
+	resp.Content = content.String()
+	resp.Duration = time.Since(start)
+	resp.Timestamp = time.Now()
+	return resp, nil
+}
+
+// LocalModels lists the models pulled on the server. Health checks use it to
+// see that Ollama is up.
+func (c *OllamaClient) LocalModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	models := make([]string, len(result.Models))
+	for i, m := range result.Models {
+		models[i] = m.Name
+	}
+	return models, nil
+}
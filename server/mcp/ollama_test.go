@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ollamaServer answers /api/chat by writing lines one by one, flushing each
+func ollamaServer(t *testing.T, lines ...string) (*httptest.Server, *ollamaChatRequest) {
+	t.Helper()
+	var got ollamaChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	srv, got := ollamaServer(t,
+		`{"model":"llama3","message":{"role":"assistant","content":"Hold "},"done":false}`,
+		``,
+		`{"model":"llama3","message":{"role":"assistant","content":"BTC"},"done":false}`,
+		`{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":42,"eval_count":7}`,
+	)
+	c := NewOllamaClient(srv.URL+"/", "llama3")
+
+	var chunks []string
+	resp, err := c.CallStream(&Request{
+		Messages:  []Message{{Role: "user", Content: "What now?"}},
+		MaxTokens: 256,
+	}, func(text string) error {
+		chunks = append(chunks, text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Hold BTC" || strings.Join(chunks, "|") != "Hold |BTC" {
+		t.Errorf("content = %q, chunks = %q", resp.Content, chunks)
+	}
+	if resp.Usage != (Usage{PromptTokens: 42, CompletionTokens: 7, TotalTokens: 49}) {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if resp.Provider != ProviderOllama || resp.Model != "llama3" {
+		t.Errorf("provider/model = %s/%s", resp.Provider, resp.Model)
+	}
+
+	// The request streams with the generation options Ollama understands
+	if !got.Stream || got.Model != "llama3" || got.Options["num_predict"] != 256.0 {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestOllamaChatStreamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"error chunk", []string{
+			`{"message":{"content":"Hol"},"done":false}`,
+			`{"error":"model 'llama3' ran out of memory"}`,
+		}, "ollama error: model 'llama3' ran out of memory"},
+		{"ended early", []string{
+			`{"message":{"content":"Hold"},"done":false}`,
+		}, "response stream ended early"},
+		{"bad line", []string{`not json`}, "failed to parse response"},
+	}
+	for _, tt := range tests {
+		srv, _ := ollamaServer(t, tt.lines...)
+		_, err := NewOllamaClient(srv.URL, "llama3").CallWithMessages("system", "user")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	// No model configured anywhere
+	if _, err := NewOllamaClient("http://127.0.0.1:1", "").CallWithMessages("system", "user"); err == nil {
+		t.Error("call without a model succeeded")
+	}
+}
+
+func TestOllamaChatTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A first chunk, then the model stalls
+		fmt.Fprintln(w, `{"message":{"content":"Hold"},"done":false}`)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	c := NewOllamaClient(srv.URL, "llama3")
+	c.SetTimeout(100 * time.Millisecond)
+	start := time.Now()
+	_, err := c.CallWithMessages("system", "user")
+	if err == nil || !strings.Contains(err.Error(), "no complete response within 100ms") {
+		t.Errorf("err = %v, want the total timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stalled call returned after %v", elapsed)
+	}
+}
@@ -0,0 +1,297 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrRateBudgetExceeded is returned when a call waited in a provider's queue
+// longer than the scheduler allows, or than its context deadline
+var ErrRateBudgetExceeded = errors.New("rate budget exceeded")
+
+// DefaultQueueTimeout bounds how long a call waits for its turn when the
+// scheduler config leaves it out
+const DefaultQueueTimeout = 2 * time.Minute
+
+// SchedulerConfig limits the calls made to one provider
+type SchedulerConfig struct {
+	MinInterval   time.Duration // Least time between the starts of two calls, 0 = none
+	MaxConcurrent int           // Calls in flight at once, 0 = unlimited
+	QueueTimeout  time.Duration // Longest a call waits for its turn, 0 = DefaultQueueTimeout
+}
+
+// SchedulerStats are a provider's queue and wait times since start
+type SchedulerStats struct {
+	Provider      string  `json:"provider"`
+	MinIntervalMs int64   `json:"min_interval_ms"`
+	MaxConcurrent int     `json:"max_concurrent"`
+	QueueDepth    int     `json:"queue_depth"`
+	InFlight      int     `json:"in_flight"`
+	Granted       int64   `json:"granted"`
+	TimedOut      int64   `json:"timed_out"` // Gave up in the queue with ErrRateBudgetExceeded
+	AvgWaitMs     float64 `json:"avg_wait_ms"`
+	MaxWaitMs     int64   `json:"max_wait_ms"`
+	Callers       int     `json:"callers"` // Callers with calls queued
+}
+
+// Scheduler spaces out and caps the calls made to one provider. Waiting
+// calls are queued per caller and callers take turns, so a trader calling
+// every minute can't hold up the others.
+type Scheduler struct {
+	provider string
+
+	mu       sync.Mutex
+	cfg      SchedulerConfig
+	queues   map[string][]*schedWaiter // Per caller, oldest first
+	turns    []string                  // Callers with queued calls, next first
+	inFlight int
+	last     time.Time   // When the last call was let through
+	timer    *time.Timer // Pending dispatch once MinInterval passes
+
+	granted   int64
+	timedOut  int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// schedWaiter is one call waiting for its turn
+type schedWaiter struct {
+	caller  string
+	queued  time.Time
+	ready   chan struct{}
+	granted bool
+}
+
+// NewScheduler creates a scheduler for provider with the given limits
+func NewScheduler(provider string, cfg SchedulerConfig) *Scheduler {
+	return &Scheduler{provider: provider, cfg: cfg, queues: make(map[string][]*schedWaiter)}
+}
+
+// SetConfig changes the limits, for calls from now on
+func (s *Scheduler) SetConfig(cfg SchedulerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.dispatchLocked()
+}
+
+// Acquire waits for caller's turn to call the provider and returns the func
+// that ends the call. It fails with ErrRateBudgetExceeded when the queue
+// timeout or ctx's deadline passes first, and with ctx's error when it is
+// canceled.
+func (s *Scheduler) Acquire(ctx context.Context, caller string) (func(), error) {
+	w := &schedWaiter{caller: caller, queued: time.Now(), ready: make(chan struct{})}
+
+	s.mu.Lock()
+	timeout := s.cfg.QueueTimeout
+	if timeout <= 0 {
+		timeout = DefaultQueueTimeout
+	}
+	if len(s.queues[caller]) == 0 {
+		s.turns = append(s.turns, caller)
+	}
+	s.queues[caller] = append(s.queues[caller], w)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+		return s.releaseFunc(), nil
+	case <-timer.C:
+		err = fmt.Errorf("%w: %s queue wait passed %v", ErrRateBudgetExceeded, s.provider, timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %s queue wait passed the call deadline", ErrRateBudgetExceeded, s.provider)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.granted {
+		// Let through as it gave up: hand the slot back
+		s.inFlight--
+		s.dispatchLocked()
+	} else {
+		s.removeLocked(w)
+	}
+	if errors.Is(err, ErrRateBudgetExceeded) {
+		s.timedOut++
+	}
+	return nil, err
+}
+
+// releaseFunc ends a call once, freeing its slot for the next in line
+func (s *Scheduler) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.inFlight--
+			s.dispatchLocked()
+		})
+	}
+}
+
+// dispatchLocked lets queued calls through while the limits allow, one
+// caller's call per turn
+func (s *Scheduler) dispatchLocked() {
+	for len(s.turns) > 0 {
+		if s.cfg.MaxConcurrent > 0 && s.inFlight >= s.cfg.MaxConcurrent {
+			return
+		}
+		if s.cfg.MinInterval > 0 && !s.last.IsZero() {
+			if wait := s.cfg.MinInterval - time.Since(s.last); wait > 0 {
+				if s.timer == nil {
+					s.timer = time.AfterFunc(wait, func() {
+						s.mu.Lock()
+						defer s.mu.Unlock()
+						s.timer = nil
+						s.dispatchLocked()
+					})
+				}
+				return
+			}
+		}
+
+		caller := s.turns[0]
+		s.turns = s.turns[1:]
+		queue := s.queues[caller]
+		w := queue[0]
+		if len(queue) > 1 {
+			s.queues[caller] = queue[1:]
+			s.turns = append(s.turns, caller)
+		} else {
+			delete(s.queues, caller)
+		}
+
+		wait := time.Since(w.queued)
+		s.granted++
+		s.totalWait += wait
+		if wait > s.maxWait {
+			s.maxWait = wait
+		}
+		s.inFlight++
+		s.last = time.Now()
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// removeLocked takes a call that gave up out of its caller's queue
+func (s *Scheduler) removeLocked(w *schedWaiter) {
+	queue := s.queues[w.caller]
+	for i, q := range queue {
+		if q == w {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		s.queues[w.caller] = queue
+		return
+	}
+	delete(s.queues, w.caller)
+	for i, caller := range s.turns {
+		if caller == w.caller {
+			s.turns = append(s.turns[:i:i], s.turns[i+1:]...)
+			break
+		}
+	}
+}
+
+// Stats returns the scheduler's current queue and its wait times so far
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SchedulerStats{
+		Provider:      s.provider,
+		MinIntervalMs: s.cfg.MinInterval.Milliseconds(),
+		MaxConcurrent: s.cfg.MaxConcurrent,
+		InFlight:      s.inFlight,
+		Granted:       s.granted,
+		TimedOut:      s.timedOut,
+		MaxWaitMs:     s.maxWait.Milliseconds(),
+		Callers:       len(s.queues),
+	}
+	for _, queue := range s.queues {
+		stats.QueueDepth += len(queue)
+	}
+	if s.granted > 0 {
+		stats.AvgWaitMs = float64(s.totalWait.Milliseconds()) / float64(s.granted)
+	}
+	return stats
+}
+
+// Every client of a provider shares its scheduler
+var (
+	schedulersMu     sync.Mutex
+	schedulers       = make(map[string]*Scheduler)
+	schedulerConfigs = make(map[string]SchedulerConfig)
+	defaultSchedCfg  SchedulerConfig
+)
+
+// ConfigureSchedulers sets the limits of every provider without its own
+func ConfigureSchedulers(cfg SchedulerConfig) {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	defaultSchedCfg = cfg
+	for provider, s := range schedulers {
+		if _, own := schedulerConfigs[provider]; !own {
+			s.SetConfig(cfg)
+		}
+	}
+}
+
+// ConfigureScheduler sets one provider's limits
+func ConfigureScheduler(provider string, cfg SchedulerConfig) {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	schedulerConfigs[provider] = cfg
+	if s, ok := schedulers[provider]; ok {
+		s.SetConfig(cfg)
+	}
+}
+
+// SchedulerFor returns provider's scheduler, created on first use
+func SchedulerFor(provider string) *Scheduler {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	if s, ok := schedulers[provider]; ok {
+		return s
+	}
+	cfg, own := schedulerConfigs[provider]
+	if !own {
+		cfg = defaultSchedCfg
+	}
+	s := NewScheduler(provider, cfg)
+	schedulers[provider] = s
+	return s
+}
+
+// AllSchedulerStats returns the stats of every provider called so far, by name
+func AllSchedulerStats() []SchedulerStats {
+	schedulersMu.Lock()
+	all := make([]*Scheduler, 0, len(schedulers))
+	for _, s := range schedulers {
+		all = append(all, s)
+	}
+	schedulersMu.Unlock()
+
+	stats := make([]SchedulerStats, 0, len(all))
+	for _, s := range all {
+		stats = append(stats, s.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerSpacesCalls(t *testing.T) {
+	s := NewScheduler("test", SchedulerConfig{MinInterval: 30 * time.Millisecond})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := s.Acquire(context.Background(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("3 calls took %v, want at least 2 intervals", elapsed)
+	}
+	if stats := s.Stats(); stats.Granted != 3 || stats.QueueDepth != 0 || stats.InFlight != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestSchedulerTakesCallersInTurn(t *testing.T) {
+	s := NewScheduler("test", SchedulerConfig{MaxConcurrent: 1})
+
+	// Hold the only slot while an aggressive caller queues three calls and a
+	// quiet one queues one behind them
+	hold, err := s.Acquire(context.Background(), "busy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for i, caller := range []string{"busy", "busy", "busy", "quiet"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.Acquire(context.Background(), caller)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, caller)
+			mu.Unlock()
+			release()
+		}()
+		// Let it join the queue before the next one
+		for deadline := time.Now().Add(time.Second); s.Stats().QueueDepth <= i && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	hold()
+	wg.Wait()
+	if len(order) != 4 || order[1] != "quiet" {
+		t.Errorf("calls went %v, want the quiet caller second", order)
+	}
+}
+
+func TestSchedulerQueueTimeout(t *testing.T) {
+	s := NewScheduler("test", SchedulerConfig{MaxConcurrent: 1, QueueTimeout: 20 * time.Millisecond})
+	hold, err := s.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hold()
+
+	if _, err := s.Acquire(context.Background(), "b"); !errors.Is(err, ErrRateBudgetExceeded) {
+		t.Errorf("queue timeout = %v, want ErrRateBudgetExceeded", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, "b"); !errors.Is(err, ErrRateBudgetExceeded) {
+		t.Errorf("deadline while queued = %v, want ErrRateBudgetExceeded", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Acquire(ctx, "b"); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled while queued = %v, want context.Canceled", err)
+	}
+
+	if stats := s.Stats(); stats.TimedOut != 2 || stats.QueueDepth != 0 || stats.InFlight != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
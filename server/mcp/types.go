@@ -1,6 +1,12 @@
 package mcp
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
 
 // Message represents a chat message
 type Message struct {
@@ -12,13 +18,63 @@ type Message struct {
 type Request struct {
 	Model            string    `json:"model"`
 	Messages         []Message `json:"messages"`
-	Temperature      float64   `json:"temperature,omitempty"`
+	Temperature      *float64  `json:"temperature,omitempty"` // nil leaves the provider default
 	MaxTokens        int       `json:"max_tokens,omitempty"`
 	TopP             float64   `json:"top_p,omitempty"`
+	ReasoningEffort  string    `json:"reasoning_effort,omitempty"` // "low", "medium" or "high", for reasoning models
 	FrequencyPenalty float64   `json:"frequency_penalty,omitempty"`
 	PresencePenalty  float64   `json:"presence_penalty,omitempty"`
 	Stop             []string  `json:"stop,omitempty"`
 	Stream           bool      `json:"stream,omitempty"`
+
+	// ResponseFormat asks for schema-constrained output. It is only sent when
+	// the client has structured output enabled and the model hasn't rejected it.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// Sampling settings used when a strategy leaves them out
+const (
+	DefaultTemperature = 0.7
+	DefaultMaxTokens   = 4096
+)
+
+// ReasoningEfforts are the accepted GenerationParams.ReasoningEffort values
+var ReasoningEfforts = []string{"low", "medium", "high"}
+
+// GenerationParams are the effective sampling settings of a request
+type GenerationParams struct {
+	Temperature     float64 `json:"temperature"`
+	TopP            float64 `json:"top_p,omitempty"` // 0 leaves the provider default
+	MaxTokens       int     `json:"max_tokens"`
+	ReasoningEffort string  `json:"reasoning_effort,omitempty"`
+}
+
+// DefaultGenerationParams returns the settings requests used before they
+// were configurable
+func DefaultGenerationParams() GenerationParams {
+	return GenerationParams{Temperature: DefaultTemperature, MaxTokens: DefaultMaxTokens}
+}
+
+// Apply sets the params on req
+func (p GenerationParams) Apply(req *Request) {
+	temperature := p.Temperature
+	req.Temperature = &temperature
+	req.TopP = p.TopP
+	req.MaxTokens = p.MaxTokens
+	req.ReasoningEffort = p.ReasoningEffort
+}
+
+// ResponseFormat is the OpenAI-compatible response_format parameter
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema names a schema the response must conform to
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 // Usage represents token usage information
@@ -36,6 +92,10 @@ type Response struct {
 	Provider  string
 	Duration  time.Duration
 	Timestamp time.Time
+
+	// Structured is true when the request went out with a ResponseFormat, so
+	// Content should be bare JSON matching the schema
+	Structured bool
 }
 
 // AIClient is the interface for AI providers
@@ -46,6 +106,9 @@ type AIClient interface {
 	SetTimeout(timeout time.Duration)
 	// CallWithMessages makes a simple call with system and user prompts
 	CallWithMessages(systemPrompt, userPrompt string) (string, error)
+	// CallWithModel makes a simple call against a specific model (empty uses the configured one).
+	// The call, including retries, is abandoned when ctx is done.
+	CallWithModel(ctx context.Context, model, systemPrompt, userPrompt string) (*Response, error)
 	// CallWithRequest makes a call with full request control
 	CallWithRequest(req *Request) (*Response, error)
 	// GetProvider returns the provider name
@@ -56,6 +119,71 @@ type AIClient interface {
 	CallStream(req *Request, handler ChunkHandler) (*Response, error)
 }
 
+// ModelLister is implemented by clients that can list selectable models
+type ModelLister interface {
+	ListModels() ([]ModelInfo, error)
+}
+
+// StructuredCaller is implemented by clients that can request schema-constrained output.
+// Callers must still check Response.Structured: the schema is dropped for models that
+// don't support it, and the call then behaves like CallWithModel.
+type StructuredCaller interface {
+	CallStructured(ctx context.Context, model, systemPrompt, userPrompt string, format *ResponseFormat) (*Response, error)
+}
+
+// CallerSetter is implemented by clients whose calls queue in their
+// provider's Scheduler, to share it fairly between callers
+type CallerSetter interface {
+	SetCaller(caller string)
+}
+
+// ErrNoAPIKey is returned by CheckKey when the client has no key to check
+var ErrNoAPIKey = errors.New("no API key configured")
+
+// APIError is a non-200 response from the AI provider
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API error (status %d)", e.StatusCode)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// KeyChecker is implemented by clients that can verify their API key without
+// spending tokens
+type KeyChecker interface {
+	CheckKey(ctx context.Context) error
+}
+
+// ModelInfo describes a model offered by a provider
+type ModelInfo struct {
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	ContextLength int          `json:"context_length"`
+	Pricing       ModelPricing `json:"pricing"`
+}
+
+// ModelPricing is the per-token price in USD, as reported by the provider
+type ModelPricing struct {
+	Prompt     string `json:"prompt"`
+	Completion string `json:"completion"`
+}
+
+// PerToken parses the prompt and completion prices. ok is false when either
+// is missing or negative, as providers report variable-priced models.
+func (p ModelPricing) PerToken() (prompt, completion float64, ok bool) {
+	prompt, err1 := strconv.ParseFloat(p.Prompt, 64)
+	completion, err2 := strconv.ParseFloat(p.Completion, 64)
+	if err1 != nil || err2 != nil || prompt < 0 || completion < 0 {
+		return 0, 0, false
+	}
+	return prompt, completion, true
+}
+
 // ChunkHandler is called for each streaming chunk
 type ChunkHandler func(chunk string) error
 
@@ -72,6 +200,12 @@ type Config struct {
 	MaxRetries   int
 	RetryDelay   time.Duration
 	OnTokenUsage TokenUsageCallback
+
+	// StructuredOutput sends Request.ResponseFormat to the provider; off, it is ignored
+	StructuredOutput bool
+
+	// Caller names whose turn calls take in the provider's scheduler, e.g. a trader ID
+	Caller string
 }
 
 // DefaultConfig returns a default configuration
@@ -91,6 +225,7 @@ const (
 	ProviderDeepSeek   = "deepseek"
 	ProviderGoogle     = "google"
 	ProviderQwen       = "qwen"
+	ProviderOllama     = "ollama" // Local server, see OllamaClient
 )
 
 // Default base URLs
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// TradingDay is where a trader's day begins: an hour of the clock in the
+// strategy's timezone. The daily loss baseline and daily reports share it.
+type TradingDay struct {
+	Location  *time.Location
+	StartHour int // 0-23
+}
+
+// UTCDay is the trading day of a strategy without a timezone or start hour
+var UTCDay = TradingDay{Location: time.UTC}
+
+// NewTradingDay validates a timezone (IANA name, empty = UTC) and day start hour
+func NewTradingDay(timezone string, startHour int) (TradingDay, error) {
+	if startHour < 0 || startHour > 23 {
+		return UTCDay, fmt.Errorf("invalid day_start_hour %d, expected 0-23", startHour)
+	}
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return UTCDay, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+	return TradingDay{Location: loc, StartHour: startHour}, nil
+}
+
+// StrategyDay returns a strategy's trading day. Without a strategy, or with
+// invalid settings, the day runs from UTC midnight.
+func StrategyDay(s *store.Strategy) (TradingDay, error) {
+	if s == nil {
+		return UTCDay, nil
+	}
+	return NewTradingDay(s.Config.Timezone, s.Config.DayStartHour)
+}
+
+// On returns the start of the trading day named by date's calendar date.
+// Days are built from calendar dates rather than by adding 24 hours, so they
+// are 23 or 25 hours long across DST changes. A start hour that a DST change
+// skips begins the day at the change.
+func (d TradingDay) On(date time.Time) time.Time {
+	y, m, day := date.Date()
+	start := time.Date(y, m, day, d.StartHour, 0, 0, 0, d.Location)
+	if start.Hour() != d.StartHour {
+		// time.Date moved the missing hour back into the zone before the
+		// change, which ends at the change
+		_, start = start.ZoneBounds()
+	}
+	return start
+}
+
+// Start returns the start of the trading day containing t
+func (d TradingDay) Start(t time.Time) time.Time {
+	start := d.On(t.In(d.Location))
+	if start.After(t) {
+		start = d.On(start.AddDate(0, 0, -1))
+	}
+	return start
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// markdownEscaper escapes the characters Telegram's Markdown treats as markup
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// Text renders the report as a short message for chat notifiers. With
+// markdown, headings are bold in Telegram's Markdown (*bold*) and the rest is
+// escaped.
+func (r *Report) Text(markdown bool) string {
+	var sb strings.Builder
+	line := func(format string, args ...interface{}) {
+		text := fmt.Sprintf(format, args...)
+		if markdown {
+			text = markdownEscaper.Replace(text)
+		}
+		sb.WriteString(text + "\n")
+	}
+	heading := func(text string) {
+		if markdown {
+			text = "*" + markdownEscaper.Replace(text) + "*"
+		}
+		sb.WriteString(text + "\n")
+	}
+
+	title := "Daily report"
+	dates := r.Start.Format("2006-01-02")
+	if r.Period == PeriodWeekly {
+		title = "Weekly report"
+		dates += " to " + r.End.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	heading(fmt.Sprintf("%s: %s", title, r.TraderName))
+	line("%s", dates)
+
+	line("")
+	heading("Trades")
+	t := r.Trades
+	if t.Closed == 0 {
+		line("No positions closed")
+	} else {
+		line("Closed: %d (%d won, %d lost, %.1f%% win rate)", t.Closed, t.Wins, t.Losses, t.WinRate)
+		line("Realized P&L: %s USDT", signed(t.RealizedPnL))
+		line("Fees: %.2f USDT", t.Fees)
+		line("Gross vs net P&L: %s -> %s USDT (fees %.2f, slippage %.2f)", signed(t.GrossPnL), signed(t.NetPnL), t.Fees, t.Slippage)
+		line("Best: %s", formatOutcome(t.Best))
+		line("Worst: %s", formatOutcome(t.Worst))
+	}
+
+	line("")
+	heading("Equity")
+	if e := r.Equity; e == nil {
+		line("No equity snapshots")
+	} else {
+		line("Open %.2f, close %.2f (%s, %s%%)", e.Open, e.Close, signed(e.Change), signed(e.ChangePct))
+		if e.Transfers != 0 {
+			line("Transfers: %s USDT, trading change %s (%s%%)", signed(e.Transfers), signed(e.TradingChange), signed(e.TradingChangePct))
+		}
+		line("Max intraday drawdown: %.2f%%", e.MaxIntradayDrawdownPct)
+	}
+
+	line("")
+	heading("Income")
+	in := r.Income
+	line("Realized %s, funding %s, commission %s USDT", signed(in.RealizedPnL), signed(in.Funding), signed(in.Commission))
+	if in.Deposits != 0 || in.Withdrawals != 0 {
+		line("Deposits %.2f, withdrawals %.2f USDT", in.Deposits, -in.Withdrawals)
+	}
+
+	line("")
+	heading("AI")
+	line("Calls: %d (%d retried), tokens: %d in / %d out, cost: $%.4f", r.AI.Calls, r.AI.Retries, r.AI.PromptTokens, r.AI.CompletionTokens, r.AI.CostUSD)
+
+	line("")
+	heading("Risk events")
+	if len(r.RiskEvents) == 0 {
+		line("None")
+	}
+	for _, ev := range r.RiskEvents {
+		line("%s %s: %s", ev.Timestamp.In(r.Start.Location()).Format("01-02 15:04"), ev.Type, ev.Message)
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatOutcome(o *TradeOutcome) string {
+	if o == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%s %s %s", o.Symbol, o.Side, signed(o.RealizedPnL))
+	if o.CloseReason != "" {
+		s += " (" + o.CloseReason + ")"
+	}
+	return s
+}
+
+// signed formats a value with an explicit sign
+func signed(v float64) string {
+	return fmt.Sprintf("%+.2f", v)
+}
@@ -0,0 +1,297 @@
+package report
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// Period is the span a report covers
+type Period string
+
+const (
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+// ParsePeriod validates a period name, defaulting to daily
+func ParsePeriod(s string) (Period, error) {
+	switch Period(s) {
+	case "", PeriodDaily:
+		return PeriodDaily, nil
+	case PeriodWeekly:
+		return PeriodWeekly, nil
+	}
+	return "", fmt.Errorf("unknown period %q, use daily or weekly", s)
+}
+
+// Range returns the [start, end) of the period containing t. Days follow the
+// trading day; weeks start on the trading day that begins on Monday.
+func (p Period) Range(t time.Time, day TradingDay) (time.Time, time.Time) {
+	start := day.Start(t)
+	local := start.In(day.Location)
+	if p == PeriodWeekly {
+		monday := local.AddDate(0, 0, -(int(local.Weekday())+6)%7)
+		return day.On(monday), day.On(monday.AddDate(0, 0, 7))
+	}
+	return start, day.On(local.AddDate(0, 0, 1))
+}
+
+// Report is a trader's P&L and activity over one period
+type Report struct {
+	TraderID    string             `json:"trader_id"`
+	TraderName  string             `json:"trader_name"`
+	Period      Period             `json:"period"`
+	Start       time.Time          `json:"start"`
+	End         time.Time          `json:"end"`
+	Trades      TradeSummary       `json:"trades"`
+	Equity      *EquitySummary     `json:"equity"` // nil without equity snapshots in the period
+	Income      store.IncomeTotals `json:"income"` // Exchange income history by category
+	AI          store.AICallUsage  `json:"ai"`
+	RiskEvents  []*store.RiskEvent `json:"risk_events"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// TradeSummary covers the positions closed in the period
+type TradeSummary struct {
+	Closed      int           `json:"closed"`
+	Wins        int           `json:"wins"`
+	Losses      int           `json:"losses"`
+	WinRate     float64       `json:"win_rate"`
+	RealizedPnL float64       `json:"realized_pnl"`
+	Fees        float64       `json:"fees"`
+	Slippage    float64       `json:"slippage"`  // USDT lost filling away from the decision-time price
+	GrossPnL    float64       `json:"gross_pnl"` // Realized P&L had every order filled at the decision-time price
+	NetPnL      float64       `json:"net_pnl"`   // Realized P&L after fees
+	Best        *TradeOutcome `json:"best"`
+	Worst       *TradeOutcome `json:"worst"`
+}
+
+// TradeOutcome identifies one closed position
+type TradeOutcome struct {
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	CloseReason string    `json:"close_reason,omitempty"`
+	ExitTime    time.Time `json:"exit_time"`
+}
+
+// EquitySummary is the equity curve over the period
+type EquitySummary struct {
+	Open                   float64 `json:"open"`
+	Close                  float64 `json:"close"`
+	Change                 float64 `json:"change"`
+	ChangePct              float64 `json:"change_pct"`
+	Transfers              float64 `json:"transfers"`                 // Net deposits less withdrawals in the period
+	TradingChange          float64 `json:"trading_change"`            // Change less transfers
+	TradingChangePct       float64 `json:"trading_change_pct"`        // Of the open plus deposits
+	MaxIntradayDrawdownPct float64 `json:"max_intraday_drawdown_pct"` // Worst peak-to-trough fall within a single day
+	Resolution             string  `json:"resolution"`                // Equity history resolution the figures come from
+}
+
+// Generator compiles reports from the stores
+type Generator struct {
+	positionStore    *store.PositionStore
+	equityStore      *store.EquityStore
+	aiCallStore      *store.AICallStore
+	riskEventStore   *store.RiskEventStore
+	incomeStore      *store.IncomeStore
+	strategyStore    *store.StrategyStore
+	rawRetentionDays int
+}
+
+// NewGenerator creates a report generator. rawRetentionDays is the equity
+// raw snapshot retention, used to pick the history resolution.
+func NewGenerator(rawRetentionDays int) *Generator {
+	return &Generator{
+		positionStore:    store.NewPositionStore(),
+		equityStore:      store.NewEquityStore(),
+		aiCallStore:      store.NewAICallStore(),
+		riskEventStore:   store.NewRiskEventStore(),
+		incomeStore:      store.NewIncomeStore(),
+		strategyStore:    store.NewStrategyStore(),
+		rawRetentionDays: rawRetentionDays,
+	}
+}
+
+// TradingDay returns the trading day of the trader's strategy, or of the
+// active strategy for a trader without one
+func (g *Generator) TradingDay(t *store.Trader) TradingDay {
+	var strategy *store.Strategy
+	var err error
+	if t.StrategyID != "" {
+		strategy, err = g.strategyStore.Get(t.StrategyID)
+	} else {
+		strategy, err = g.strategyStore.GetActive()
+	}
+	if err != nil {
+		return UTCDay
+	}
+	day, err := StrategyDay(strategy)
+	if err != nil {
+		log.Printf("[Report] Strategy %s: %v, using UTC days", strategy.ID, err)
+	}
+	return day
+}
+
+// Generate compiles the trader's report for the period containing date, in
+// the trader's trading days
+func (g *Generator) Generate(t *store.Trader, period Period, date time.Time) (*Report, error) {
+	day := g.TradingDay(t)
+	start, end := period.Range(date, day)
+	r := &Report{
+		TraderID:    t.ID,
+		TraderName:  t.Name,
+		Period:      period,
+		Start:       start,
+		End:         end,
+		RiskEvents:  []*store.RiskEvent{},
+		GeneratedAt: time.Now(),
+	}
+
+	positions, err := g.positionStore.GetClosedBetween(t.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load closed positions: %w", err)
+	}
+	r.Trades = summarizeTrades(positions)
+
+	points, resolution, err := g.equityPoints(t.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load equity history: %w", err)
+	}
+	r.Equity = summarizeEquity(points, resolution, day)
+
+	income, err := g.incomeStore.GetTotals(t.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load income history: %w", err)
+	}
+	r.Income = income
+	r.Equity.applyTransfers(income)
+
+	usage, err := g.aiCallStore.GetUsage(t.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI usage: %w", err)
+	}
+	r.AI = *usage
+
+	events, err := g.riskEventStore.ListBetween(t.ID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load risk events: %w", err)
+	}
+	if events != nil {
+		r.RiskEvents = events
+	}
+	return r, nil
+}
+
+// equityPoints returns the finest equity history still kept for the range:
+// raw snapshots while retention covers it, otherwise hourly or daily bars
+func (g *Generator) equityPoints(traderID string, start, end time.Time) ([]store.EquityPoint, string, error) {
+	if g.rawRetentionDays > 0 && start.Before(time.Now().AddDate(0, 0, -g.rawRetentionDays)) {
+		return g.equityStore.GetHistory(traderID, start, end, g.rawRetentionDays)
+	}
+
+	snapshots, err := g.equityStore.GetByTimeRange(traderID, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	points := make([]store.EquityPoint, 0, len(snapshots))
+	for _, snap := range snapshots {
+		points = append(points, store.EquityPoint{
+			Timestamp:   snap.Timestamp,
+			TotalEquity: snap.TotalEquity,
+			Open:        snap.TotalEquity,
+			High:        snap.TotalEquity,
+			Low:         snap.TotalEquity,
+			Samples:     1,
+		})
+	}
+	return points, store.EquityResolutionRaw, nil
+}
+
+func summarizeTrades(positions []store.TraderPosition) TradeSummary {
+	var s TradeSummary
+	for _, pos := range positions {
+		s.Closed++
+		s.RealizedPnL += pos.RealizedPnL
+		s.Fees += pos.Fee
+		s.Slippage += pos.SlippageCost
+		if pos.RealizedPnL > 0 {
+			s.Wins++
+		} else {
+			s.Losses++
+		}
+
+		outcome := &TradeOutcome{
+			Symbol:      pos.Symbol,
+			Side:        pos.Side,
+			RealizedPnL: pos.RealizedPnL,
+			CloseReason: pos.CloseReason,
+			ExitTime:    pos.ExitTime,
+		}
+		if s.Best == nil || pos.RealizedPnL > s.Best.RealizedPnL {
+			s.Best = outcome
+		}
+		if s.Worst == nil || pos.RealizedPnL < s.Worst.RealizedPnL {
+			s.Worst = outcome
+		}
+	}
+	if s.Closed > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Closed) * 100
+	}
+	// Realized P&L already has slippage in its fill prices but not the fees
+	s.GrossPnL = s.RealizedPnL + s.Slippage
+	s.NetPnL = s.RealizedPnL - s.Fees
+	return s
+}
+
+// applyTransfers separates the deposits and withdrawals in the period from
+// the equity change, so they don't read as trading P&L
+func (s *EquitySummary) applyTransfers(income store.IncomeTotals) {
+	if s == nil {
+		return
+	}
+	s.Transfers = income.NetTransfers()
+	s.TradingChange = s.Change - s.Transfers
+	s.TradingChangePct = 0
+	if base := s.Open + income.Deposits; base > 0 {
+		s.TradingChangePct = s.TradingChange / base * 100
+	}
+}
+
+// summarizeEquity takes the open and close of the curve and its worst
+// drawdown within any one trading day. With bars the order of high and low
+// inside a bar is unknown, so a bar's low is only measured against the peak
+// before it.
+func summarizeEquity(points []store.EquityPoint, resolution string, tradingDay TradingDay) *EquitySummary {
+	if len(points) == 0 {
+		return nil
+	}
+
+	s := &EquitySummary{
+		Open:       points[0].Open,
+		Close:      points[len(points)-1].TotalEquity,
+		Resolution: resolution,
+	}
+	s.Change = s.Close - s.Open
+	if s.Open > 0 {
+		s.ChangePct = s.Change / s.Open * 100
+	}
+
+	var day time.Time
+	var peak float64
+	for _, p := range points {
+		if pointDay := tradingDay.Start(p.Timestamp); !pointDay.Equal(day) {
+			day, peak = pointDay, 0
+		}
+		peak = math.Max(peak, p.Open)
+		if peak > 0 {
+			s.MaxIntradayDrawdownPct = math.Max(s.MaxIntradayDrawdownPct, (peak-p.Low)/peak*100)
+		}
+		peak = math.Max(peak, p.High)
+	}
+	return s
+}
@@ -0,0 +1,238 @@
+package report
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+)
+
+func TestPeriodRange(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	date := time.Date(2024, 3, 14, 15, 30, 0, 0, loc) // Thursday
+
+	day := TradingDay{Location: loc}
+
+	start, end := PeriodDaily.Range(date, day)
+	if !start.Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, loc)) || !end.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, loc)) {
+		t.Errorf("daily range = %s - %s", start, end)
+	}
+
+	start, end = PeriodWeekly.Range(date, day)
+	if !start.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, loc)) || !end.Equal(time.Date(2024, 3, 18, 0, 0, 0, 0, loc)) {
+		t.Errorf("weekly range = %s - %s, want Monday to Monday", start, end)
+	}
+
+	// A Sunday belongs to the week that started the Monday before
+	start, _ = PeriodWeekly.Range(time.Date(2024, 3, 17, 12, 0, 0, 0, loc), day)
+	if !start.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, loc)) {
+		t.Errorf("Sunday week starts %s", start)
+	}
+
+	if _, err := ParsePeriod("monthly"); err == nil {
+		t.Error("monthly period accepted")
+	}
+}
+
+// TestTradingDayDST tests a New York trading day starting at 17:00 across both
+// DST changes: the days are 23 and 25 hours long and keep their wall-clock start
+func TestTradingDayDST(t *testing.T) {
+	day, err := NewTradingDay("America/New_York", 17)
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	loc := day.Location
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		start  time.Time
+		length time.Duration
+	}{
+		{"spring forward", time.Date(2024, 3, 10, 12, 0, 0, 0, loc), time.Date(2024, 3, 9, 17, 0, 0, 0, loc), 23 * time.Hour},
+		{"fall back", time.Date(2024, 11, 3, 12, 0, 0, 0, loc), time.Date(2024, 11, 2, 17, 0, 0, 0, loc), 25 * time.Hour},
+		{"after the start hour", time.Date(2024, 3, 10, 18, 0, 0, 0, loc), time.Date(2024, 3, 10, 17, 0, 0, 0, loc), 24 * time.Hour},
+		{"at the start hour", time.Date(2024, 3, 10, 17, 0, 0, 0, loc), time.Date(2024, 3, 10, 17, 0, 0, 0, loc), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		start, end := PeriodDaily.Range(tt.at, day)
+		if !start.Equal(tt.start) || end.Sub(start) != tt.length {
+			t.Errorf("%s: day = %s - %s, want from %s for %s", tt.name, start, end, tt.start, tt.length)
+		}
+		if got := day.Start(tt.at); !got.Equal(start) {
+			t.Errorf("%s: Start = %s, want %s", tt.name, got, start)
+		}
+	}
+
+	// A start hour the spring change skips begins the day at the change
+	early := TradingDay{Location: loc, StartHour: 2}
+	if got := early.On(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)); !got.Equal(time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("skipped start hour = %s, want 03:00 EDT", got.In(loc))
+	}
+
+	// The week runs Monday 17:00 to Monday 17:00 and loses an hour
+	start, end := PeriodWeekly.Range(time.Date(2024, 3, 10, 12, 0, 0, 0, loc), day)
+	if !start.Equal(time.Date(2024, 3, 4, 17, 0, 0, 0, loc)) || end.Sub(start) != 7*24*time.Hour-time.Hour {
+		t.Errorf("weekly range = %s - %s", start, end)
+	}
+}
+
+func TestNewTradingDay(t *testing.T) {
+	for _, tt := range []struct {
+		timezone string
+		hour     int
+	}{{"Mars/Olympus_Mons", 0}, {"", 24}, {"UTC", -1}} {
+		if _, err := NewTradingDay(tt.timezone, tt.hour); err == nil {
+			t.Errorf("NewTradingDay(%q, %d) accepted", tt.timezone, tt.hour)
+		}
+	}
+	if day, err := NewTradingDay("", 0); err != nil || day.Location != time.UTC {
+		t.Errorf("default day = %+v, %v", day, err)
+	}
+}
+
+func TestSummarizeTrades(t *testing.T) {
+	s := summarizeTrades([]store.TraderPosition{
+		{Symbol: "BTCUSDT", Side: "LONG", RealizedPnL: 12, Fee: 0.5, SlippageCost: 0.3},
+		{Symbol: "ETHUSDT", Side: "SHORT", RealizedPnL: -8, Fee: 0.4, SlippageCost: -0.1, CloseReason: "stop_loss"},
+		{Symbol: "SOLUSDT", Side: "LONG", RealizedPnL: 3, Fee: 0.1},
+	})
+
+	if s.Closed != 3 || s.Wins != 2 || s.Losses != 1 || math.Abs(s.WinRate-200.0/3) > 1e-9 {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.RealizedPnL != 7 || math.Abs(s.Fees-1) > 1e-9 {
+		t.Errorf("pnl = %v, fees = %v", s.RealizedPnL, s.Fees)
+	}
+	if math.Abs(s.GrossPnL-7.2) > 1e-9 || math.Abs(s.NetPnL-6) > 1e-9 {
+		t.Errorf("gross = %v, net = %v", s.GrossPnL, s.NetPnL)
+	}
+	if s.Best == nil || s.Best.Symbol != "BTCUSDT" || s.Worst == nil || s.Worst.Symbol != "ETHUSDT" {
+		t.Errorf("best = %+v, worst = %+v", s.Best, s.Worst)
+	}
+}
+
+func TestSummarizeEquity(t *testing.T) {
+	day := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	bar := func(offset time.Duration, open, high, low, close float64) store.EquityPoint {
+		return store.EquityPoint{Timestamp: day.Add(offset), Open: open, High: high, Low: low, TotalEquity: close}
+	}
+
+	s := summarizeEquity([]store.EquityPoint{
+		bar(1*time.Hour, 1000, 1100, 1000, 1050),
+		bar(2*time.Hour, 1050, 1060, 990, 1000), // 10% off the 1100 peak
+		// Next day starts a new peak, this 5% fall from 1000 doesn't beat the first day
+		bar(25*time.Hour, 1000, 1000, 950, 960),
+	}, store.EquityResolutionHour, UTCDay)
+
+	if s == nil {
+		t.Fatal("no summary")
+	}
+	if s.Open != 1000 || s.Close != 960 || s.Change != -40 || math.Abs(s.ChangePct+4) > 1e-9 {
+		t.Errorf("summary = %+v", s)
+	}
+	if math.Abs(s.MaxIntradayDrawdownPct-10) > 1e-9 {
+		t.Errorf("max intraday drawdown = %v, want 10", s.MaxIntradayDrawdownPct)
+	}
+
+	if summarizeEquity(nil, store.EquityResolutionRaw, UTCDay) != nil {
+		t.Error("summary without equity points")
+	}
+}
+
+// TestEquityTransfers tests that a deposit doesn't turn a losing day into a
+// winning one
+func TestEquityTransfers(t *testing.T) {
+	s := &EquitySummary{Open: 1000, Close: 1400, Change: 400}
+	s.applyTransfers(store.IncomeTotals{Deposits: 500, RealizedPnL: -100})
+	if s.Transfers != 500 || s.TradingChange != -100 || math.Abs(s.TradingChangePct+100.0/15) > 1e-9 {
+		t.Errorf("summary = %+v", s)
+	}
+
+	r := &Report{TraderName: "t", Period: PeriodDaily, Equity: s, Income: store.IncomeTotals{Deposits: 500, RealizedPnL: -100}}
+	text := r.Text(false)
+	if !strings.Contains(text, "Transfers: +500.00 USDT, trading change -100.00") || !strings.Contains(text, "Deposits 500.00") {
+		t.Errorf("report text:\n%s", text)
+	}
+}
+
+func TestTextMarkdownEscapes(t *testing.T) {
+	r := &Report{
+		TraderName: "my_trader",
+		Period:     PeriodDaily,
+		Start:      time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		Trades: TradeSummary{
+			Closed: 1, Losses: 1, RealizedPnL: -8,
+			Best:  &TradeOutcome{Symbol: "ETHUSDT", Side: "SHORT", RealizedPnL: -8, CloseReason: "stop_loss"},
+			Worst: &TradeOutcome{Symbol: "ETHUSDT", Side: "SHORT", RealizedPnL: -8, CloseReason: "stop_loss"},
+		},
+	}
+
+	md := r.Text(true)
+	if !strings.Contains(md, `*Daily report: my\_trader*`) || !strings.Contains(md, `(stop\_loss)`) {
+		t.Errorf("markdown not escaped:\n%s", md)
+	}
+	plain := r.Text(false)
+	if strings.Contains(plain, `\_`) || strings.Contains(plain, "*") {
+		t.Errorf("plain text has markup:\n%s", plain)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC)
+	if got := nextRun(now, 10); !got.Equal(time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("nextRun before the hour = %s", got)
+	}
+	if got := nextRun(now, 9); !got.Equal(time.Date(2024, 3, 12, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("nextRun after the hour = %s", got)
+	}
+}
+
+func TestSendDailyToOwnerOnly(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	traders := store.NewTraderStore()
+	alice := &store.Trader{Name: "alice's", OwnerUserID: "alice"}
+	bob := &store.Trader{Name: "bob's", OwnerUserID: "bob"}
+	for _, tr := range []*store.Trader{alice, bob} {
+		if err := traders.Create(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	if err := store.NewRiskEventStore().Create(&store.RiskEvent{
+		TraderID: alice.ID, Timestamp: now.Add(-24 * time.Hour), Type: store.RiskEventDailyLossPause, Message: "daily loss limit hit",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	hub := events.NewHub()
+	aliceSub, bobSub := hub.Subscribe(), hub.Subscribe()
+	defer aliceSub.Close()
+	defer bobSub.Close()
+	aliceSub.Add(events.TraderTopic(alice.ID, events.StreamReports))
+	bobSub.Add(events.TraderTopic(bob.ID, events.StreamReports))
+
+	NewGenerator(0).sendDaily(now, hub)
+
+	select {
+	case evt := <-aliceSub.C:
+		if evt.Type != events.TypeReport || evt.TraderID != alice.ID || !strings.Contains(evt.Message, "daily loss limit hit") {
+			t.Errorf("alice got %+v", evt)
+		}
+	default:
+		t.Fatal("the owner didn't get the report")
+	}
+	// Bob's trader had nothing to report, and alice's isn't on his topics
+	select {
+	case evt := <-bobSub.C:
+		t.Errorf("a non-owner got %+v", evt)
+	default:
+	}
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"log"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/store"
+)
+
+// Notifier delivers scheduled reports to a topic's subscribers
+type Notifier interface {
+	Publish(topic string, evt events.Event)
+}
+
+// RunDaily sends each trader's report for its last complete trading day at
+// hour (0-23, local time) every day. Traders with nothing to report are skipped. It
+// blocks, run it in a goroutine.
+func (g *Generator) RunDaily(hour int, notifier Notifier) {
+	for {
+		next := nextRun(time.Now(), hour)
+		log.Printf("[Report] Next daily report at %s", next.Format(time.RFC3339))
+		time.Sleep(time.Until(next))
+		g.sendDaily(next, notifier)
+	}
+}
+
+// sendDaily publishes each trader's report for the trading day before now on
+// the trader's reports topic, which only users with access to the trader can
+// subscribe to
+func (g *Generator) sendDaily(now time.Time, notifier Notifier) {
+	traders, err := store.NewTraderStore().List()
+	if err != nil {
+		log.Printf("[Report] Failed to list traders: %v", err)
+		return
+	}
+	for _, t := range traders {
+		yesterday := g.TradingDay(t).Start(now).Add(-time.Nanosecond)
+		r, err := g.Generate(t, PeriodDaily, yesterday)
+		if err != nil {
+			log.Printf("[Report] Failed to generate daily report for %s: %v", t.ID, err)
+			continue
+		}
+		if r.empty() {
+			continue
+		}
+		notifier.Publish(events.TraderTopic(t.ID, events.StreamReports), events.Event{
+			Type:      events.TypeReport,
+			TraderID:  t.ID,
+			Message:   r.Text(false),
+			Data:      r,
+			Timestamp: time.Now().UnixMilli(),
+		})
+		log.Printf("[Report] Sent daily report for %s (%s)", t.Name, r.Start.Format("2006-01-02"))
+	}
+}
+
+// nextRun returns the next time after now that the local clock reads hour:00
+func nextRun(now time.Time, hour int) time.Time {
+	y, m, d := now.Date()
+	next := time.Date(y, m, d, hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// empty reports whether nothing happened in the period
+func (r *Report) empty() bool {
+	return r.Trades.Closed == 0 && r.Equity == nil && r.AI.Calls == 0 && len(r.RiskEvents) == 0
+}
@@ -0,0 +1,264 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// AI call storage limits
+const (
+	DefaultAICallMaxFieldBytes = 256 * 1024 // Larger prompts/responses are truncated
+	aiCallCompressMinBytes     = 1024       // Smaller fields aren't worth gzipping
+)
+
+// AICall is the full record of one live AI decision request
+type AICall struct {
+	ID           int64     `json:"id"`
+	TraderID     string    `json:"trader_id"`
+	DecisionID   int64     `json:"decision_id"` // decisions row of the cycle that made the call
+	Symbol       string    `json:"symbol"`
+	Model        string    `json:"model"`
+	Timestamp    time.Time `json:"timestamp"`
+	SystemPrompt string    `json:"system_prompt"`
+	UserPrompt   string    `json:"user_prompt"`
+	RawResponse  string    `json:"raw_response"`
+	Reasoning    string    `json:"reasoning,omitempty"` // Reasoning tokens from reasoning models
+	ParseOutcome string    `json:"parse_outcome"`       // "ok", or why the response couldn't be used
+	LatencyMs    int64     `json:"latency_ms"`
+
+	// A response that couldn't be used gets one follow-up asking the model to
+	// correct it; RawResponse is then the corrected response
+	Retries       int    `json:"retries"`
+	RetryReason   string `json:"retry_reason,omitempty"`
+	FirstResponse string `json:"first_response,omitempty"`
+
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"` // As reported by the provider, 0 when unknown
+}
+
+// AICallUsage totals a trader's AI calls over a time range
+type AICallUsage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	Retries          int     `json:"retries"`
+}
+
+// AIModelStats totals the AI calls made with one model, to spot models that
+// often need a retry to produce a usable decision
+type AIModelStats struct {
+	Model         string  `json:"model"`
+	Calls         int     `json:"calls"`
+	Retries       int     `json:"retries"`
+	ParseFailures int     `json:"parse_failures"` // Calls left without a usable decision, after any retry
+	RetryRate     float64 `json:"retry_rate"`     // Retries per call, 0-1
+}
+
+// AICallStore handles AI call persistence. Text fields are capped at
+// MaxFieldBytes and stored gzipped once they pass a small threshold.
+type AICallStore struct {
+	MaxFieldBytes int
+}
+
+// NewAICallStore creates a new AI call store
+func NewAICallStore() *AICallStore {
+	return &AICallStore{MaxFieldBytes: DefaultAICallMaxFieldBytes}
+}
+
+// Create saves an AI call
+func (s *AICallStore) Create(call *AICall) error {
+	if call.Timestamp.IsZero() {
+		call.Timestamp = time.Now()
+	}
+
+	fields := make([][]byte, 5)
+	for i, text := range []string{call.SystemPrompt, call.UserPrompt, call.RawResponse, call.Reasoning, call.FirstResponse} {
+		blob, err := encodeAICallField(text, s.MaxFieldBytes)
+		if err != nil {
+			return err
+		}
+		fields[i] = blob
+	}
+
+	id, err := db.Insert(`
+		INSERT INTO ai_calls (
+			trader_id, decision_id, symbol, model, timestamp,
+			system_prompt, user_prompt, raw_response, reasoning, parse_outcome, latency_ms,
+			prompt_tokens, completion_tokens, cost_usd, retries, retry_reason, first_response
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, call.TraderID, call.DecisionID, call.Symbol, call.Model, call.Timestamp,
+		fields[0], fields[1], fields[2], fields[3], call.ParseOutcome, call.LatencyMs,
+		call.PromptTokens, call.CompletionTokens, call.CostUSD, call.Retries, call.RetryReason, fields[4])
+	if err != nil {
+		return err
+	}
+
+	call.ID = id
+	return nil
+}
+
+// ListByDecision returns the AI calls made in one decision cycle, in call order
+func (s *AICallStore) ListByDecision(traderID string, decisionID int64) ([]*AICall, error) {
+	rows, err := db.Query(`
+		SELECT id, trader_id, decision_id, COALESCE(symbol, ''), COALESCE(model, ''), timestamp,
+			system_prompt, user_prompt, raw_response, reasoning, COALESCE(parse_outcome, ''), latency_ms,
+			COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0), COALESCE(cost_usd, 0),
+			COALESCE(retries, 0), COALESCE(retry_reason, ''), first_response
+		FROM ai_calls WHERE trader_id = ? AND decision_id = ?
+		ORDER BY id ASC
+	`, traderID, decisionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []*AICall
+	for rows.Next() {
+		var c AICall
+		var blobs [5][]byte
+		if err := rows.Scan(&c.ID, &c.TraderID, &c.DecisionID, &c.Symbol, &c.Model, &c.Timestamp,
+			&blobs[0], &blobs[1], &blobs[2], &blobs[3], &c.ParseOutcome, &c.LatencyMs,
+			&c.PromptTokens, &c.CompletionTokens, &c.CostUSD,
+			&c.Retries, &c.RetryReason, &blobs[4]); err != nil {
+			return nil, err
+		}
+
+		texts := make([]string, len(blobs))
+		for i, blob := range blobs {
+			text, err := decodeAICallField(blob)
+			if err != nil {
+				return nil, fmt.Errorf("ai call %d: %w", c.ID, err)
+			}
+			texts[i] = text
+		}
+		c.SystemPrompt, c.UserPrompt, c.RawResponse, c.Reasoning, c.FirstResponse = texts[0], texts[1], texts[2], texts[3], texts[4]
+		calls = append(calls, &c)
+	}
+
+	return calls, rows.Err()
+}
+
+// GetUsage totals the calls a trader made in [start, end). Calls already
+// pruned by retention aren't counted.
+func (s *AICallStore) GetUsage(traderID string, start, end time.Time) (*AICallUsage, error) {
+	var u AICallUsage
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost_usd), 0),
+			COALESCE(SUM(retries), 0)
+		FROM ai_calls WHERE trader_id = ? AND timestamp >= ? AND timestamp < ?
+	`, traderID, start, end).Scan(&u.Calls, &u.PromptTokens, &u.CompletionTokens, &u.CostUSD, &u.Retries)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ModelStats totals the calls made since a time by model, most retried first
+func (s *AICallStore) ModelStats(since time.Time) ([]*AIModelStats, error) {
+	rows, err := db.Query(`
+		SELECT COALESCE(model, ''), COUNT(*), COALESCE(SUM(retries), 0),
+			COALESCE(SUM(CASE WHEN parse_outcome = 'ok' THEN 0 ELSE 1 END), 0)
+		FROM ai_calls WHERE timestamp >= ?
+		GROUP BY COALESCE(model, '')
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*AIModelStats{}
+	for rows.Next() {
+		var m AIModelStats
+		if err := rows.Scan(&m.Model, &m.Calls, &m.Retries, &m.ParseFailures); err != nil {
+			return nil, err
+		}
+		if m.Calls > 0 {
+			m.RetryRate = float64(m.Retries) / float64(m.Calls)
+		}
+		stats = append(stats, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].RetryRate != stats[j].RetryRate {
+			return stats[i].RetryRate > stats[j].RetryRate
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats, nil
+}
+
+// Prune deletes a trader's calls older than retentionDays and beyond the newest
+// maxRows. A zero limit is not applied.
+func (s *AICallStore) Prune(traderID string, retentionDays, maxRows int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if retentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -retentionDays)
+		if _, err := tx.Exec(`DELETE FROM ai_calls WHERE trader_id = ? AND timestamp < ?`, traderID, cutoff); err != nil {
+			return err
+		}
+	}
+	if maxRows > 0 {
+		if _, err := tx.Exec(`
+			DELETE FROM ai_calls WHERE trader_id = ? AND id NOT IN (
+				SELECT id FROM ai_calls WHERE trader_id = ? ORDER BY id DESC LIMIT ?
+			)
+		`, traderID, traderID, maxRows); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// encodeAICallField truncates text to maxBytes and gzips it when large enough.
+// Plain text never starts with the gzip magic bytes, so reads can tell them apart.
+func encodeAICallField(text string, maxBytes int) ([]byte, error) {
+	if maxBytes > 0 && len(text) > maxBytes {
+		text = fmt.Sprintf("%s\n...[truncated %d bytes]", text[:maxBytes], len(text)-maxBytes)
+	}
+	if len(text) < aiCallCompressMinBytes {
+		return []byte(text), nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		return nil, fmt.Errorf("failed to compress ai call field: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress ai call field: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeAICallField reverses encodeAICallField
+func decodeAICallField(blob []byte) (string, error) {
+	if len(blob) < 2 || blob[0] != 0x1f || blob[1] != 0x8b {
+		return string(blob), nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress field: %w", err)
+	}
+	defer zr.Close()
+
+	text, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress field: %w", err)
+	}
+	return string(text), nil
+}
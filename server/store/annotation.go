@@ -0,0 +1,79 @@
+package store
+
+import (
+	"time"
+)
+
+// Annotation types
+const (
+	AnnotationDailyLossPause  = "daily_loss_pause"
+	AnnotationEmergencyStop   = "emergency_stop"
+	AnnotationCircuitBreaker  = "circuit_breaker"
+	AnnotationStrategyVersion = "strategy_version"
+	AnnotationFlatten         = "flatten"
+	AnnotationLargeLoss       = "large_loss"
+	AnnotationManual          = "manual"
+)
+
+// MaxAnnotationText is the longest annotation text kept
+const MaxAnnotationText = 280
+
+// Annotation marks an event on a trader's equity curve
+type Annotation struct {
+	ID        int64     `json:"id"`
+	TraderID  string    `json:"trader_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Text      string    `json:"text"`
+	CreatedBy string    `json:"created_by,omitempty"` // User who added a manual annotation
+}
+
+// AnnotationStore handles equity curve annotation persistence
+type AnnotationStore struct{}
+
+// NewAnnotationStore creates a new annotation store
+func NewAnnotationStore() *AnnotationStore {
+	return &AnnotationStore{}
+}
+
+// Create records an annotation, now if it has no timestamp
+func (s *AnnotationStore) Create(a *Annotation) error {
+	if a.Timestamp.IsZero() {
+		a.Timestamp = time.Now()
+	}
+
+	id, err := db.Insert(`
+		INSERT INTO trader_annotations (trader_id, timestamp, type, text, created_by) VALUES (?, ?, ?, ?, ?)
+	`, a.TraderID, a.Timestamp, a.Type, a.Text, a.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	a.ID = id
+	return nil
+}
+
+// ListBetween returns a trader's annotations in [start, end), oldest first
+func (s *AnnotationStore) ListBetween(traderID string, start, end time.Time) ([]*Annotation, error) {
+	rows, err := db.Query(`
+		SELECT id, trader_id, timestamp, type, text, COALESCE(created_by, '')
+		FROM trader_annotations
+		WHERE trader_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC, id ASC
+	`, traderID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := []*Annotation{}
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.ID, &a.TraderID, &a.Timestamp, &a.Type, &a.Text, &a.CreatedBy); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, &a)
+	}
+
+	return annotations, rows.Err()
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"time"
+)
+
+// AuditEntry records a mutating API request
+type AuditEntry struct {
+	ID             int64     `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	UserID         string    `json:"user_id"`
+	KeyFingerprint string    `json:"key_fingerprint"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	TraderID       string    `json:"trader_id,omitempty"`
+	StrategyID     string    `json:"strategy_id,omitempty"`
+	Status         int       `json:"status"` // 0 while the request is still in flight
+	LatencyMs      int64     `json:"latency_ms"`
+	RemoteAddr     string    `json:"remote_addr"`
+	Action         string    `json:"action,omitempty"` // Step taken on behalf of the request, e.g. "cancel_orders:BTCUSDT"
+	Result         string    `json:"result,omitempty"` // "ok" or the step's error
+}
+
+// AuditStore handles audit trail persistence
+type AuditStore struct{}
+
+// NewAuditStore creates a new audit store
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+// Create records an audit entry
+func (s *AuditStore) Create(entry *AuditEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	id, err := db.Insert(`
+		INSERT INTO audit_log (
+			timestamp, user_id, key_fingerprint, method, path,
+			trader_id, strategy_id, status, latency_ms, remote_addr, action, result
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.Timestamp, entry.UserID, entry.KeyFingerprint, entry.Method, entry.Path,
+		entry.TraderID, entry.StrategyID, entry.Status, entry.LatencyMs, entry.RemoteAddr,
+		entry.Action, entry.Result)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = id
+	return nil
+}
+
+// Complete fills in the outcome of an entry created while the request was in flight
+func (s *AuditStore) Complete(id int64, status int, latencyMs int64) error {
+	_, err := db.Exec(`UPDATE audit_log SET status = ?, latency_ms = ? WHERE id = ?`,
+		status, latencyMs, id)
+	return err
+}
+
+// List returns the most recent entries since a time, newest first.
+// An empty userID returns entries for all users.
+func (s *AuditStore) List(userID string, since time.Time, limit int) ([]*AuditEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+	SELECT id, timestamp, COALESCE(user_id, ''), COALESCE(key_fingerprint, ''), method, path,
+		COALESCE(trader_id, ''), COALESCE(strategy_id, ''), status, latency_ms, COALESCE(remote_addr, ''),
+		COALESCE(action, ''), COALESCE(result, '')
+	FROM audit_log
+	WHERE timestamp >= ?`
+	args := []interface{}{since.Local()}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY timestamp DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.UserID, &e.KeyFingerprint, &e.Method, &e.Path,
+			&e.TraderID, &e.StrategyID, &e.Status, &e.LatencyMs, &e.RemoteAddr,
+			&e.Action, &e.Result); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
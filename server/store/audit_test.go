@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditStore(t *testing.T) {
+	openTestDB(t)
+	audit := NewAuditStore()
+
+	now := time.Now()
+	old := &AuditEntry{Timestamp: now.Add(-2 * time.Hour), UserID: "u1", KeyFingerprint: "fp1", Method: "POST", Path: "/api/traders", Status: 200}
+	inFlight := &AuditEntry{Timestamp: now.Add(-time.Minute), UserID: "u1", KeyFingerprint: "fp1", Method: "POST", Path: "/api/traders/t1/stop", TraderID: "t1"}
+	other := &AuditEntry{Timestamp: now, UserID: "u2", Method: "DELETE", Path: "/api/strategies/s1", StrategyID: "s1", Status: 200,
+		Action: "cancel_orders:BTCUSDT", Result: "ok"}
+	for _, e := range []*AuditEntry{old, inFlight, other} {
+		if err := audit.Create(e); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if e.ID == 0 {
+			t.Fatalf("Create left the ID unset: %+v", e)
+		}
+	}
+	if err := audit.Complete(inFlight.ID, 200, 35); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	all, err := audit.List("", time.Time{}, 0)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("List = %d entries, %v", len(all), err)
+	}
+	// Newest first
+	if all[0].ID != other.ID || all[1].ID != inFlight.ID || all[2].ID != old.ID {
+		t.Errorf("order = %d %d %d", all[0].ID, all[1].ID, all[2].ID)
+	}
+	if e := all[0]; e.StrategyID != "s1" || e.Action != "cancel_orders:BTCUSDT" || e.Result != "ok" || e.KeyFingerprint != "" {
+		t.Errorf("entry = %+v", e)
+	}
+	if e := all[1]; e.Status != 200 || e.LatencyMs != 35 || e.TraderID != "t1" || e.KeyFingerprint != "fp1" {
+		t.Errorf("completed entry = %+v", e)
+	}
+
+	if mine, _ := audit.List("u1", time.Time{}, 0); len(mine) != 2 || mine[0].UserID != "u1" || mine[1].UserID != "u1" {
+		t.Errorf("u1's entries = %+v", mine)
+	}
+	if recent, _ := audit.List("u1", now.Add(-time.Hour), 0); len(recent) != 1 || recent[0].ID != inFlight.ID {
+		t.Errorf("u1's entries in the last hour = %+v", recent)
+	}
+	if limited, _ := audit.List("", time.Time{}, 1); len(limited) != 1 || limited[0].ID != other.ID {
+		t.Errorf("limited = %+v", limited)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+)
+
+// BacktestCheckpoint is the saved progress of a backtest run, kept so the run
+// can resume after a failure, a stop or a server restart
+type BacktestCheckpoint struct {
+	RunID     string    `json:"run_id"`
+	UserID    string    `json:"user_id"`
+	NextBar   int       `json:"next_bar"` // First bar a resumed run processes
+	Data      []byte    `json:"-"`        // The run's progress, encoded by the backtest package
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BacktestCheckpointStore handles backtest checkpoint persistence
+type BacktestCheckpointStore struct{}
+
+// NewBacktestCheckpointStore creates a new backtest checkpoint store
+func NewBacktestCheckpointStore() *BacktestCheckpointStore {
+	return &BacktestCheckpointStore{}
+}
+
+// Save replaces a run's checkpoint. The data is stored gzipped.
+func (s *BacktestCheckpointStore) Save(cp *BacktestCheckpoint) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(cp.Data); err != nil {
+		return fmt.Errorf("failed to compress checkpoint: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress checkpoint: %w", err)
+	}
+
+	cp.UpdatedAt = time.Now()
+	_, err := db.Exec(`
+		INSERT INTO backtest_checkpoints (run_id, user_id, next_bar, data, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET next_bar = excluded.next_bar, data = excluded.data, updated_at = excluded.updated_at
+	`, cp.RunID, cp.UserID, cp.NextBar, buf.Bytes(), cp.UpdatedAt)
+	return err
+}
+
+// List returns every saved checkpoint, oldest first
+func (s *BacktestCheckpointStore) List() ([]*BacktestCheckpoint, error) {
+	rows, err := db.Query(`
+		SELECT run_id, user_id, next_bar, data, updated_at FROM backtest_checkpoints ORDER BY updated_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBacktestCheckpoints(rows)
+}
+
+// Delete removes a run's checkpoint
+func (s *BacktestCheckpointStore) Delete(runID string) error {
+	_, err := db.Exec(`DELETE FROM backtest_checkpoints WHERE run_id = ?`, runID)
+	return err
+}
+
+func scanBacktestCheckpoints(rows *sql.Rows) ([]*BacktestCheckpoint, error) {
+	defer rows.Close()
+
+	var checkpoints []*BacktestCheckpoint
+	for rows.Next() {
+		var cp BacktestCheckpoint
+		var blob []byte
+		if err := rows.Scan(&cp.RunID, &cp.UserID, &cp.NextBar, &blob, &cp.UpdatedAt); err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(blob))
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", cp.RunID, err)
+		}
+		if cp.Data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("checkpoint %s: %w", cp.RunID, err)
+		}
+		checkpoints = append(checkpoints, &cp)
+	}
+	return checkpoints, rows.Err()
+}
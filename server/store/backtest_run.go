@@ -0,0 +1,67 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// BacktestRun is a finished backtest run's metadata and metrics summary, kept
+// so the run stays listed after a server restart
+type BacktestRun struct {
+	RunID     string    `json:"run_id"`
+	UserID    string    `json:"user_id"`
+	Data      []byte    `json:"-"` // The run's metadata, encoded by the backtest package
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BacktestRunStore handles finished backtest run persistence
+type BacktestRunStore struct{}
+
+// NewBacktestRunStore creates a new backtest run store
+func NewBacktestRunStore() *BacktestRunStore {
+	return &BacktestRunStore{}
+}
+
+// Save replaces a run's record
+func (s *BacktestRunStore) Save(run *BacktestRun) error {
+	run.UpdatedAt = time.Now()
+	_, err := db.Exec(`
+		INSERT INTO backtest_runs (run_id, user_id, data, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at
+	`, run.RunID, run.UserID, string(run.Data), run.UpdatedAt)
+	return err
+}
+
+// List returns every saved run, oldest first
+func (s *BacktestRunStore) List() ([]*BacktestRun, error) {
+	rows, err := db.Query(`
+		SELECT run_id, user_id, data, updated_at FROM backtest_runs ORDER BY updated_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBacktestRuns(rows)
+}
+
+// Delete removes a run's record
+func (s *BacktestRunStore) Delete(runID string) error {
+	_, err := db.Exec(`DELETE FROM backtest_runs WHERE run_id = ?`, runID)
+	return err
+}
+
+func scanBacktestRuns(rows *sql.Rows) ([]*BacktestRun, error) {
+	defer rows.Close()
+
+	var runs []*BacktestRun
+	for rows.Next() {
+		var run BacktestRun
+		var data string
+		if err := rows.Scan(&run.RunID, &run.UserID, &data, &run.UpdatedAt); err != nil {
+			return nil, err
+		}
+		run.Data = []byte(data)
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"time"
+)
+
+// Coin override kinds
+const (
+	CoinOverridePin = "pin" // Always analyzed, whatever the coin source
+	CoinOverrideBan = "ban" // Never analyzed, even when the coin source lists it
+)
+
+// CoinOverride forces a symbol into or out of a trader's coin universe
+type CoinOverride struct {
+	TraderID  string    `json:"trader_id"`
+	Symbol    string    `json:"symbol"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CoinOverrideStore handles coin pin and ban persistence
+type CoinOverrideStore struct{}
+
+// NewCoinOverrideStore creates a new coin override store
+func NewCoinOverrideStore() *CoinOverrideStore {
+	return &CoinOverrideStore{}
+}
+
+// Set pins or bans a symbol for a trader, replacing any earlier override of it
+func (s *CoinOverrideStore) Set(traderID, symbol, kind string) (*CoinOverride, error) {
+	o := &CoinOverride{TraderID: traderID, Symbol: symbol, Kind: kind, CreatedAt: time.Now()}
+	_, err := db.Exec(`
+		INSERT INTO trader_coin_overrides (trader_id, symbol, kind, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (trader_id, symbol) DO UPDATE SET kind = excluded.kind, created_at = excluded.created_at
+	`, o.TraderID, o.Symbol, o.Kind, o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Delete removes a trader's override of symbol. Returns false when there was none.
+func (s *CoinOverrideStore) Delete(traderID, symbol string) (bool, error) {
+	res, err := db.Exec(`DELETE FROM trader_coin_overrides WHERE trader_id = ? AND symbol = ?`, traderID, symbol)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// List returns a trader's pins and bans, oldest first
+func (s *CoinOverrideStore) List(traderID string) ([]*CoinOverride, error) {
+	rows, err := db.Query(`
+		SELECT trader_id, symbol, kind, created_at
+		FROM trader_coin_overrides
+		WHERE trader_id = ?
+		ORDER BY created_at ASC, symbol ASC
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*CoinOverride
+	for rows.Next() {
+		var o CoinOverride
+		if err := rows.Scan(&o.TraderID, &o.Symbol, &o.Kind, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, &o)
+	}
+
+	return overrides, rows.Err()
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavor of the configured database
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// DB is the shared connection pool. Store queries are written once in SQLite
+// syntax with ? placeholders and rewritten here for the active dialect.
+type DB struct {
+	*sql.DB
+	dialect Dialect
+}
+
+// Tx is a transaction on DB with the same query rewriting
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+func (d *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.DB.Exec(rebind(d.dialect, query), args...)
+}
+
+func (d *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.DB.Query(rebind(d.dialect, query), args...)
+}
+
+func (d *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.DB.QueryRow(rebind(d.dialect, query), args...)
+}
+
+func (d *DB) Begin() (*Tx, error) {
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: d.dialect}, nil
+}
+
+// Insert runs an INSERT into a table with an id primary key and returns the
+// new row's id
+func (d *DB) Insert(query string, args ...interface{}) (int64, error) {
+	return insertID(d, d.dialect, query, args...)
+}
+
+// Dialect returns the SQL flavor of the connection
+func (d *DB) Dialect() Dialect {
+	return d.dialect
+}
+
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(rebind(t.dialect, query), args...)
+}
+
+func (t *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.Query(rebind(t.dialect, query), args...)
+}
+
+func (t *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(rebind(t.dialect, query), args...)
+}
+
+func (t *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return t.Tx.Prepare(rebind(t.dialect, query))
+}
+
+func (t *Tx) Insert(query string, args ...interface{}) (int64, error) {
+	return insertID(t, t.dialect, query, args...)
+}
+
+type execQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// insertID gets the new row's id. Postgres drivers don't report
+// LastInsertId, so there it comes back through RETURNING.
+func insertID(q execQueryer, dialect Dialect, query string, args ...interface{}) (int64, error) {
+	if dialect == DialectPostgres {
+		var id int64
+		err := q.QueryRow(strings.TrimSpace(query)+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// rebind turns ? placeholders into $1, $2, ... for Postgres, leaving quoted
+// strings alone
+func rebind(dialect Dialect, query string) string {
+	if dialect != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(query) + 16)
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// hoursBetween is a SQL expression for the hours from column start to end
+func hoursBetween(dialect Dialect, start, end string) string {
+	if dialect == DialectPostgres {
+		return fmt.Sprintf("(EXTRACT(EPOCH FROM (%s - %s)) / 3600)", end, start)
+	}
+	return fmt.Sprintf("((julianday(%s) - julianday(%s)) * 24)", end, start)
+}
+
+// greatest and least are the two-argument MAX/MIN scalars, which SQLite
+// spells as aggregates
+func greatest(dialect Dialect, a, b string) string {
+	if dialect == DialectPostgres {
+		return fmt.Sprintf("GREATEST(%s, %s)", a, b)
+	}
+	return fmt.Sprintf("MAX(%s, %s)", a, b)
+}
+
+func least(dialect Dialect, a, b string) string {
+	if dialect == DialectPostgres {
+		return fmt.Sprintf("LEAST(%s, %s)", a, b)
+	}
+	return fmt.Sprintf("MIN(%s, %s)", a, b)
+}
+
+// translateDDL rewrites the SQLite schema for Postgres
+func translateDDL(dialect Dialect, ddl string) string {
+	if dialect != DialectPostgres {
+		return ddl
+	}
+	return strings.NewReplacer(
+		"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+		"INTEGER", "BIGINT",
+		"DATETIME", "TIMESTAMPTZ",
+		"REAL", "DOUBLE PRECISION",
+		"BLOB", "BYTEA",
+		"BOOLEAN DEFAULT 0", "BOOLEAN DEFAULT FALSE",
+		"BOOLEAN DEFAULT 1", "BOOLEAN DEFAULT TRUE",
+	).Replace(ddl)
+}
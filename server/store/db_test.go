@@ -0,0 +1,169 @@
+package store
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	query := `SELECT * FROM t WHERE a = ? AND b = '?' AND c IN (?, ?)`
+	if got := rebind(DialectSQLite, query); got != query {
+		t.Errorf("sqlite query rewritten: %s", got)
+	}
+	want := `SELECT * FROM t WHERE a = $1 AND b = '?' AND c IN ($2, $3)`
+	if got := rebind(DialectPostgres, query); got != want {
+		t.Errorf("rebind = %s, want %s", got, want)
+	}
+}
+
+func TestTranslateDDL(t *testing.T) {
+	got := translateDDL(DialectPostgres, `CREATE TABLE t (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		n INTEGER,
+		at DATETIME,
+		price REAL,
+		body BLOB,
+		flag BOOLEAN DEFAULT 0
+	)`)
+	for _, want := range []string{"id BIGSERIAL PRIMARY KEY", "n BIGINT", "at TIMESTAMPTZ",
+		"price DOUBLE PRECISION", "body BYTEA", "flag BOOLEAN DEFAULT FALSE"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("translated DDL missing %q:\n%s", want, got)
+		}
+	}
+}
+
+// TestDialectSpecificQueries covers the queries that differ between SQLite
+// and PostgreSQL: generated ids, upserts, booleans and date math
+func TestDialectSpecificQueries(t *testing.T) {
+	openTestDB(t)
+
+	strategies := NewStrategyStore()
+	for _, id := range []string{"s1", "s2"} {
+		if err := strategies.Create(&Strategy{ID: id, Name: id}); err != nil {
+			t.Fatalf("create strategy: %v", err)
+		}
+	}
+	if err := strategies.SetActive("s2"); err != nil {
+		t.Fatalf("SetActive: %v", err)
+	}
+	if active, err := strategies.GetActive(); err != nil || active.ID != "s2" {
+		t.Fatalf("GetActive = %+v, %v, want s2", active, err)
+	}
+
+	traders := NewTraderStore()
+	if err := traders.Create(&Trader{ID: "t1", Name: "t1", StrategyID: "s1", Exchange: "binance"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	if err := traders.Create(&Trader{ID: "t2", Name: "t2", Exchange: "binance"}); err != nil {
+		t.Fatalf("create trader without strategy: %v", err)
+	}
+
+	// Ids come back from the insert on both backends
+	decisions := NewDecisionStore()
+	first, second := &Decision{TraderID: "t1"}, &Decision{TraderID: "t1"}
+	decisions.Create(first)
+	decisions.Create(second)
+	if first.ID == 0 || second.ID != first.ID+1 {
+		t.Errorf("decision ids = %d, %d", first.ID, second.ID)
+	}
+
+	// Upserts replace instead of failing on the key
+	settings := NewSettingsStore()
+	for _, v := range []string{"a", "b"} {
+		if err := settings.Set("k", v); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if v, _ := settings.Get("k"); v != "b" {
+		t.Errorf("setting = %q, want b", v)
+	}
+
+	trades := NewTradeStore()
+	at := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	for _, price := range []float64{100, 101} {
+		err := trades.SaveBatch([]*Trade{{ID: 1, TraderID: "t1", Symbol: "BTCUSDT", Side: "BUY", Price: price, Timestamp: at}})
+		if err != nil {
+			t.Fatalf("SaveBatch: %v", err)
+		}
+	}
+	if got, _ := trades.GetByTrader("t1", 10); len(got) != 1 || got[0].Price != 101 {
+		t.Errorf("trades after re-save = %+v", got)
+	}
+	if last, err := trades.GetLastTradeTime("t1"); err != nil || last != at.UnixMilli() {
+		t.Errorf("GetLastTradeTime = %d, %v, want %d", last, err, at.UnixMilli())
+	}
+
+	// Holding time math
+	positions := NewPositionStore()
+	id, err := positions.Create(&TraderPosition{
+		TraderID: "t1", Symbol: "BTCUSDT", Side: "LONG",
+		EntryQuantity: 1, Quantity: 1, EntryPrice: 100, EntryTime: time.Now().Add(-2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+	if err := positions.ClosePosition(id, 110, 0.1, 10, "test", true); err != nil {
+		t.Fatalf("ClosePosition: %v", err)
+	}
+	if closed, _ := positions.GetClosedPositions("t1", 1); len(closed) != 1 || time.Since(closed[0].ExitTime) > time.Minute {
+		t.Errorf("closed position exit time not read back: %+v", closed)
+	}
+	summary, err := positions.GetHistorySummary("t1")
+	if err != nil {
+		t.Fatalf("GetHistorySummary: %v", err)
+	}
+	if math.Abs(summary.AvgHoldMins-120) > 1 {
+		t.Errorf("avg hold = %.1f min, want ~120", summary.AvgHoldMins)
+	}
+	if len(summary.HoldingTime) != 1 || summary.HoldingTime[0].Bucket != "1-4h" {
+		t.Errorf("holding time buckets = %+v", summary.HoldingTime)
+	}
+
+	// Rolling up merges into existing bars
+	equity := NewEquityStore()
+	old := time.Now().AddDate(0, 0, -10).Truncate(time.Hour)
+	for i, v := range []float64{100, 120, 90, 110} {
+		equity.Save(&EquitySnapshot{TraderID: "t1", Timestamp: old.Add(time.Duration(i) * time.Minute), TotalEquity: v})
+		if _, err := equity.Rollup("t1", 7); err != nil {
+			t.Fatalf("Rollup: %v", err)
+		}
+	}
+	bars, resolution, err := equity.GetHistory("t1", old.Add(-time.Hour), old.Add(time.Hour), 7)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if resolution != EquityResolutionHour || len(bars) != 1 {
+		t.Fatalf("history = %s %+v", resolution, bars)
+	}
+	if bar := bars[0]; bar.Open != 100 || bar.High != 120 || bar.Low != 90 || bar.TotalEquity != 110 {
+		t.Errorf("bar = %+v", bar)
+	}
+
+	// Foreign keys hold when deleting
+	if err := strategies.Delete("s1"); err != nil {
+		t.Fatalf("delete referenced strategy: %v", err)
+	}
+	if err := traders.Purge("t1"); err != nil {
+		t.Fatalf("purge trader with decisions: %v", err)
+	}
+	for _, table := range []string{"decisions", "trades", "trader_positions", "trader_equity_snapshots", "trader_equity_rollups"} {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE trader_id = ?`, "t1").Scan(&n); err != nil || n != 0 {
+			t.Errorf("%s after purge = %d rows, %v", table, n, err)
+		}
+	}
+
+	// Archiving round-trips
+	for _, archived := range []bool{true, false} {
+		if err := traders.SetArchived("t2", archived); err != nil {
+			t.Fatalf("SetArchived(%v): %v", archived, err)
+		}
+		got, err := traders.Get("t2")
+		if err != nil || got.Archived != archived || (got.ArchivedAt != nil) != archived {
+			t.Errorf("trader after SetArchived(%v) = %+v, %v", archived, got, err)
+		}
+	}
+}
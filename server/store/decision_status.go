@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Execution statuses of a decision record's entry, saved under "status". A
+// blocked or failed entry carries the rule or error after a colon, e.g.
+// "blocked_by_risk:symbol in cooldown".
+const (
+	DecisionStatusExecuted             = "executed"               // The order went through
+	DecisionStatusNoAction             = "no_action"              // HOLD/WAIT, nothing to place
+	DecisionStatusSkippedLowConfidence = "skipped_low_confidence" // Below the trader's minimum confidence
+	DecisionStatusUnchanged            = "skipped_unchanged"      // A repeat of a decision already acted on
+	DecisionStatusBlockedByRisk        = "blocked_by_risk"
+	DecisionStatusOrderFailed          = "order_failed"
+	DecisionStatusCooldown             = "cooldown"
+	DecisionStatusAIFailed             = "ai_failed"
+	DecisionStatusNoDecision           = "no_decision" // The symbol was never put to the AI, e.g. its market data failed
+	DecisionStatusUnknown              = "unknown"     // Saved before statuses were recorded
+)
+
+// DecisionStatus joins a status and its rule or error, "" detail for none
+func DecisionStatus(status, detail string) string {
+	if detail == "" {
+		return status
+	}
+	return status + ":" + detail
+}
+
+// ParseDecisionStatus splits a saved status into the status and its rule or
+// error. An empty one is DecisionStatusUnknown.
+func ParseDecisionStatus(s string) (status, detail string) {
+	if s == "" {
+		return DecisionStatusUnknown, ""
+	}
+	status, detail, _ = strings.Cut(s, ":")
+	return status, detail
+}
+
+// DecisionExecution is what became of one entry of a decision record
+type DecisionExecution struct {
+	Symbol  string `json:"symbol"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`   // The rule that blocked it or the error the order failed with
+	OrderID string `json:"order_id,omitempty"` // Set when executed
+}
+
+// decisionEntry is the part of a decision record's entry the execution
+// statuses are read from
+type decisionEntry struct {
+	Symbol  string `json:"symbol"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+	OrderID string `json:"order_id"`
+}
+
+// Executed reports whether the entry's order went through
+func (d decisionEntry) Executed() bool {
+	status, _ := ParseDecisionStatus(d.Status)
+	return status == DecisionStatusExecuted
+}
+
+// parseExecutions reads the execution status of each of a decision record's
+// entries, nil if they can't be parsed
+func parseExecutions(raw string) []*DecisionExecution {
+	var entries []decisionEntry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil
+	}
+	executions := make([]*DecisionExecution, len(entries))
+	for i, e := range entries {
+		status, detail := ParseDecisionStatus(e.Status)
+		executions[i] = &DecisionExecution{Symbol: e.Symbol, Action: e.Action, Status: status, Detail: detail, OrderID: e.OrderID}
+	}
+	return executions
+}
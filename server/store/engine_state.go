@@ -0,0 +1,108 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EngineState is the runtime state a trader needs to pick up where it left off
+type EngineState struct {
+	TraderID     string                    `json:"trader_id"`
+	LastCycleAt  time.Time                 `json:"last_cycle_at"`
+	DailyBalance float64                   `json:"daily_balance"`  // Baseline for the daily loss limit
+	DailyResetAt time.Time                 `json:"daily_reset_at"` // When the baseline was taken
+	StopUntil    time.Time                 `json:"stop_until"`     // Daily loss pause, zero when not paused
+	Positions    map[string]*PositionState `json:"positions"`      // key: "symbol_side"
+	LastCloses   map[string]*CloseRecord   `json:"last_closes"`    // key: symbol, for re-entry cooldowns
+
+	// Circuit breaker
+	PeakEquity     float64             `json:"peak_equity"`               // All-time peak, rebased when the breaker is acknowledged
+	CircuitBreaker *CircuitBreakerTrip `json:"circuit_breaker,omitempty"` // Set while tripped
+	BreakerResetAt time.Time           `json:"breaker_reset_at"`          // Last acknowledgement, earlier losses don't count toward the streak
+
+	ExperimentCycles int64 `json:"experiment_cycles"` // Cycles assigned a prompt variant, for the assignment sequence
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Circuit breaker trip reasons
+const (
+	CircuitBreakerTotalDrawdown     = "total_drawdown"
+	CircuitBreakerConsecutiveLosses = "consecutive_losses"
+)
+
+// CircuitBreakerTrip is why and when a trader's circuit breaker tripped
+type CircuitBreakerTrip struct {
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	TrippedAt time.Time `json:"tripped_at"`
+}
+
+// CloseRecord is the most recent close of a symbol
+type CloseRecord struct {
+	ClosedAt time.Time `json:"closed_at"`
+	StopLoss bool      `json:"stop_loss"` // Closed by a stop loss, which gets the longer cooldown
+}
+
+// PositionState is what the engine tracks for an open position beyond the exchange data
+type PositionState struct {
+	Symbol            string  `json:"symbol"`
+	Side              string  `json:"side"` // LONG or SHORT
+	PeakPnLPct        float64 `json:"peak_pnl_pct"`
+	FirstSeenMs       int64   `json:"first_seen_ms"`
+	StopLossOrderID   int64   `json:"stop_loss_order_id,omitempty"`
+	TakeProfitOrderID int64   `json:"take_profit_order_id,omitempty"`
+	EntryPrice        float64 `json:"entry_price,omitempty"`
+	StopLossPct       float64 `json:"stop_loss_pct,omitempty"`
+	TakeProfitPct     float64 `json:"take_profit_pct,omitempty"`
+}
+
+// EngineStateStore handles engine state persistence
+type EngineStateStore struct{}
+
+// NewEngineStateStore creates a new engine state store
+func NewEngineStateStore() *EngineStateStore {
+	return &EngineStateStore{}
+}
+
+// Save replaces a trader's stored state
+func (s *EngineStateStore) Save(state *EngineState) error {
+	state.UpdatedAt = time.Now()
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal engine state: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO trader_engine_state (trader_id, state, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (trader_id) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at
+	`, state.TraderID, string(stateJSON), state.UpdatedAt)
+	return err
+}
+
+// Get returns a trader's stored state, or nil if it has none
+func (s *EngineStateStore) Get(traderID string) (*EngineState, error) {
+	var stateJSON string
+	err := db.QueryRow(`SELECT state FROM trader_engine_state WHERE trader_id = ?`, traderID).Scan(&stateJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state EngineState
+	if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal engine state: %w", err)
+	}
+	return &state, nil
+}
+
+// Delete removes a trader's stored state
+func (s *EngineStateStore) Delete(traderID string) error {
+	_, err := db.Exec(`DELETE FROM trader_engine_state WHERE trader_id = ?`, traderID)
+	return err
+}
@@ -0,0 +1,28 @@
+package store
+
+// EntryFill is how the engine's opening order of a position filled
+type EntryFill struct {
+	OrderType      string   // EntryOrderMarket or EntryOrderLimitChase
+	MakerPct       float64  // Share of the quantity filled as maker, %
+	ImprovementBps *float64 // Fill price better than the decision-time price, negative when worse; nil without one
+}
+
+// SetEntryFill records how position id's opening order filled
+func (s *PositionStore) SetEntryFill(id int64, f EntryFill) error {
+	_, err := db.Exec(`
+		UPDATE trader_positions
+		SET entry_order_type = ?, entry_maker_pct = ?, entry_improvement_bps = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, f.OrderType, f.MakerPct, f.ImprovementBps, id)
+	return err
+}
+
+// addEntryFillStats sums up the recorded entry fills of a trader's positions
+// into stats. Positions opened before fills were recorded are left out.
+func (s *PositionStore) addEntryFillStats(traderID string, stats *TraderStats) error {
+	return db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(entry_maker_pct), 0), COALESCE(AVG(entry_improvement_bps), 0)
+		FROM trader_positions
+		WHERE trader_id = ? AND entry_maker_pct IS NOT NULL
+	`, traderID).Scan(&stats.EntryFills, &stats.MakerFillPct, &stats.AvgEntryImprovementBps)
+}
@@ -1,6 +1,9 @@
 package store
 
 import (
+	"database/sql"
+	"fmt"
+	"log"
 	"time"
 )
 
@@ -16,6 +19,32 @@ type EquitySnapshot struct {
 	MarginUsagePct float64  `json:"margin_usage_pct"`
 }
 
+// Equity history resolutions. Raw snapshots older than the retention window
+// are rolled up into hourly and daily bars.
+const (
+	EquityResolutionRaw  = "raw"
+	EquityResolutionHour = "hour"
+	EquityResolutionDay  = "day"
+
+	equityRawMaxSpan  = 2 * 24 * time.Hour  // Longest range served from raw snapshots
+	equityHourMaxSpan = 60 * 24 * time.Hour // Longest range served from hourly bars
+)
+
+// EquityPoint is one point of equity history: a raw snapshot, or an hourly or
+// daily bar whose TotalEquity is its close
+type EquityPoint struct {
+	Timestamp      time.Time `json:"timestamp"` // Snapshot time, or the bar's start
+	TotalEquity    float64   `json:"total_equity"`
+	Open           float64   `json:"open"`
+	High           float64   `json:"high"`
+	Low            float64   `json:"low"`
+	Balance        float64   `json:"balance"`
+	UnrealizedPnL  float64   `json:"unrealized_pnl"`
+	PositionCount  int       `json:"position_count"`
+	MarginUsagePct float64   `json:"margin_usage_pct"`
+	Samples        int       `json:"samples"` // Snapshots in the bar
+}
+
 // EquityStore manages equity snapshot data
 type EquityStore struct{}
 
@@ -24,29 +53,6 @@ func NewEquityStore() *EquityStore {
 	return &EquityStore{}
 }
 
-// InitTables creates the equity tables
-func (s *EquityStore) InitTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS trader_equity_snapshots (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		trader_id TEXT NOT NULL,
-		timestamp DATETIME NOT NULL,
-		total_equity REAL NOT NULL,
-		balance REAL,
-		unrealized_pnl REAL,
-		position_count INTEGER,
-		margin_usage_pct REAL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_equity_trader ON trader_equity_snapshots(trader_id);
-	CREATE INDEX IF NOT EXISTS idx_equity_timestamp ON trader_equity_snapshots(timestamp);
-	CREATE INDEX IF NOT EXISTS idx_equity_trader_time ON trader_equity_snapshots(trader_id, timestamp);
-	`
-	_, err := db.Exec(query)
-	return err
-}
-
 // Save records an equity snapshot
 func (s *EquityStore) Save(snapshot *EquitySnapshot) error {
 	query := `
@@ -273,3 +279,269 @@ func (s *EquityStore) GetDrawdownStats(traderID string) (float64, float64, float
 
 	return maxDrawdown, currentDrawdown, peak, nil
 }
+
+// Rollup aggregates a trader's snapshots older than rawRetentionDays into
+// hourly and daily bars, deletes them and returns how many there were. Bars
+// that already exist are extended, so it can run at any interval. Zero
+// retention keeps raw snapshots forever.
+func (s *EquityStore) Rollup(traderID string, rawRetentionDays int) (int, error) {
+	if rawRetentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -rawRetentionDays)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+	SELECT id, trader_id, timestamp, total_equity, COALESCE(balance, 0),
+		COALESCE(unrealized_pnl, 0), COALESCE(position_count, 0), COALESCE(margin_usage_pct, 0)
+	FROM trader_equity_snapshots
+	WHERE trader_id = ? AND timestamp < ?
+	ORDER BY timestamp ASC
+	`, traderID, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	snapshots, err := scanEquitySnapshots(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	for _, resolution := range []string{EquityResolutionHour, EquityResolutionDay} {
+		for _, bar := range bucketSnapshots(snapshots, resolution) {
+			_, err := tx.Exec(`
+			INSERT INTO trader_equity_rollups AS r (
+				trader_id, resolution, bucket, open, high, low, close, balance,
+				unrealized_pnl, position_count, margin_usage_pct, samples
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (trader_id, resolution, bucket) DO UPDATE SET
+				high = `+greatest(tx.dialect, "r.high", "excluded.high")+`,
+				low = `+least(tx.dialect, "r.low", "excluded.low")+`,
+				close = excluded.close,
+				balance = excluded.balance,
+				unrealized_pnl = excluded.unrealized_pnl,
+				position_count = excluded.position_count,
+				margin_usage_pct = excluded.margin_usage_pct,
+				samples = r.samples + excluded.samples
+			`, traderID, resolution, bar.Timestamp, bar.Open, bar.High, bar.Low, bar.TotalEquity, bar.Balance,
+				bar.UnrealizedPnL, bar.PositionCount, bar.MarginUsagePct, bar.Samples)
+			if err != nil {
+				return 0, fmt.Errorf("failed to save %s equity bar: %w", resolution, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM trader_equity_snapshots WHERE trader_id = ? AND timestamp < ?`, traderID, cutoff); err != nil {
+		return 0, err
+	}
+	return len(snapshots), tx.Commit()
+}
+
+// RollupAll runs Rollup for every trader with snapshots past the retention
+// window. On the first run it migrates the existing history.
+func (s *EquityStore) RollupAll(rawRetentionDays int) error {
+	if rawRetentionDays <= 0 {
+		return nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -rawRetentionDays)
+	rows, err := db.Query(`SELECT DISTINCT trader_id FROM trader_equity_snapshots WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return err
+	}
+	var traderIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		traderIDs = append(traderIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range traderIDs {
+		n, err := s.Rollup(id, rawRetentionDays)
+		if err != nil {
+			return fmt.Errorf("trader %s: %w", id, err)
+		}
+		if n > 0 {
+			log.Printf("Rolled up %d equity snapshots for %s", n, id)
+		}
+	}
+	return nil
+}
+
+// RunRollups runs RollupAll now and then every interval. It doesn't return.
+func (s *EquityStore) RunRollups(rawRetentionDays int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RollupAll(rawRetentionDays); err != nil {
+			log.Printf("Equity rollup failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// EquityResolutionFor picks the resolution for a history range: raw
+// snapshots for short ranges they still cover, then hourly, then daily bars
+func EquityResolutionFor(start, end time.Time, rawRetentionDays int) string {
+	span := end.Sub(start)
+	rawCovered := rawRetentionDays <= 0 || !start.Before(time.Now().AddDate(0, 0, -rawRetentionDays))
+	switch {
+	case span <= equityRawMaxSpan && rawCovered:
+		return EquityResolutionRaw
+	case span <= equityHourMaxSpan:
+		return EquityResolutionHour
+	default:
+		return EquityResolutionDay
+	}
+}
+
+// GetHistory returns a trader's equity between start and end at the
+// resolution suited to the range, and that resolution. A zero start begins at
+// the trader's first record. Bars combine rolled up history with bars built
+// from the raw snapshots not yet rolled up.
+func (s *EquityStore) GetHistory(traderID string, start, end time.Time, rawRetentionDays int) ([]EquityPoint, string, error) {
+	if start.IsZero() {
+		first, err := s.firstRecord(traderID)
+		if err != nil {
+			return nil, "", err
+		}
+		start = first
+	}
+
+	resolution := EquityResolutionFor(start, end, rawRetentionDays)
+	raw, err := s.GetByTimeRange(traderID, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	if resolution == EquityResolutionRaw {
+		return bucketSnapshots(raw, EquityResolutionRaw), resolution, nil
+	}
+
+	rows, err := db.Query(`
+	SELECT bucket, open, high, low, close, COALESCE(balance, 0), COALESCE(unrealized_pnl, 0),
+		COALESCE(position_count, 0), COALESCE(margin_usage_pct, 0), samples
+	FROM trader_equity_rollups
+	WHERE trader_id = ? AND resolution = ? AND bucket BETWEEN ? AND ?
+	ORDER BY bucket ASC
+	`, traderID, resolution, bucketStart(start, resolution), end.UTC())
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	points := make([]EquityPoint, 0)
+	for rows.Next() {
+		var p EquityPoint
+		if err := rows.Scan(&p.Timestamp, &p.Open, &p.High, &p.Low, &p.TotalEquity, &p.Balance,
+			&p.UnrealizedPnL, &p.PositionCount, &p.MarginUsagePct, &p.Samples); err != nil {
+			return nil, "", err
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// The oldest raw snapshots can share a bar with the newest rolled up ones
+	recent := bucketSnapshots(raw, resolution)
+	if n := len(points); n > 0 && len(recent) > 0 && points[n-1].Timestamp.Equal(recent[0].Timestamp) {
+		last, next := &points[n-1], recent[0]
+		last.High = max(last.High, next.High)
+		last.Low = min(last.Low, next.Low)
+		last.TotalEquity, last.Balance, last.UnrealizedPnL = next.TotalEquity, next.Balance, next.UnrealizedPnL
+		last.PositionCount, last.MarginUsagePct = next.PositionCount, next.MarginUsagePct
+		last.Samples += next.Samples
+		recent = recent[1:]
+	}
+	return append(points, recent...), resolution, nil
+}
+
+// firstRecord returns when a trader's equity history begins, now if it has none
+func (s *EquityStore) firstRecord(traderID string) (time.Time, error) {
+	var first time.Time
+	err := db.QueryRow(`
+	SELECT bucket FROM trader_equity_rollups WHERE trader_id = ? AND resolution = ?
+	ORDER BY bucket ASC LIMIT 1
+	`, traderID, EquityResolutionDay).Scan(&first)
+	if err == sql.ErrNoRows {
+		err = db.QueryRow(`
+		SELECT timestamp FROM trader_equity_snapshots WHERE trader_id = ?
+		ORDER BY timestamp ASC LIMIT 1
+		`, traderID).Scan(&first)
+	}
+	if err == sql.ErrNoRows {
+		return time.Now(), nil
+	}
+	return first, err
+}
+
+// bucketStart returns the start of the UTC hour or day t falls in
+func bucketStart(t time.Time, resolution string) time.Time {
+	if resolution == EquityResolutionDay {
+		return t.UTC().Truncate(24 * time.Hour)
+	}
+	return t.UTC().Truncate(time.Hour)
+}
+
+// bucketSnapshots turns chronological snapshots into points, one per snapshot
+// for raw resolution and one bar per UTC hour or day otherwise
+func bucketSnapshots(snapshots []EquitySnapshot, resolution string) []EquityPoint {
+	points := make([]EquityPoint, 0, len(snapshots))
+	for _, snap := range snapshots {
+		ts := snap.Timestamp
+		if resolution != EquityResolutionRaw {
+			ts = bucketStart(ts, resolution)
+		}
+		if n := len(points); n > 0 && resolution != EquityResolutionRaw && points[n-1].Timestamp.Equal(ts) {
+			bar := &points[n-1]
+			bar.High = max(bar.High, snap.TotalEquity)
+			bar.Low = min(bar.Low, snap.TotalEquity)
+			bar.TotalEquity, bar.Balance, bar.UnrealizedPnL = snap.TotalEquity, snap.Balance, snap.UnrealizedPnL
+			bar.PositionCount, bar.MarginUsagePct = snap.PositionCount, snap.MarginUsagePct
+			bar.Samples++
+			continue
+		}
+		points = append(points, EquityPoint{
+			Timestamp:      ts,
+			TotalEquity:    snap.TotalEquity,
+			Open:           snap.TotalEquity,
+			High:           snap.TotalEquity,
+			Low:            snap.TotalEquity,
+			Balance:        snap.Balance,
+			UnrealizedPnL:  snap.UnrealizedPnL,
+			PositionCount:  snap.PositionCount,
+			MarginUsagePct: snap.MarginUsagePct,
+			Samples:        1,
+		})
+	}
+	return points
+}
+
+func scanEquitySnapshots(rows *sql.Rows) ([]EquitySnapshot, error) {
+	defer rows.Close()
+	var snapshots []EquitySnapshot
+	for rows.Next() {
+		var s EquitySnapshot
+		if err := rows.Scan(
+			&s.ID, &s.TraderID, &s.Timestamp, &s.TotalEquity, &s.Balance,
+			&s.UnrealizedPnL, &s.PositionCount, &s.MarginUsagePct,
+		); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
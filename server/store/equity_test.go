@@ -0,0 +1,253 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// bar is the part of an equity bar the tests compare
+type bar struct {
+	ts                     time.Time
+	open, high, low, close float64
+	samples                int
+}
+
+func checkBars(t *testing.T, name string, got []EquityPoint, want []bar) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d bars, want %d: %+v", name, len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if !g.Timestamp.Equal(w.ts) || g.Open != w.open || g.High != w.high || g.Low != w.low ||
+			g.TotalEquity != w.close || g.Samples != w.samples {
+			t.Errorf("%s bar %d = %s O%.0f H%.0f L%.0f C%.0f n%d, want %+v", name, i,
+				g.Timestamp.UTC().Format(time.RFC3339), g.Open, g.High, g.Low, g.TotalEquity, g.Samples, w)
+		}
+	}
+}
+
+func createEquityTrader(t *testing.T, id string) {
+	t.Helper()
+	if err := NewTraderStore().Create(&Trader{ID: id, Name: id, Exchange: "binance", Status: "stopped"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+}
+
+func TestBucketSnapshots(t *testing.T) {
+	day := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	snaps := []EquitySnapshot{
+		{Timestamp: day.Add(22*time.Hour + 10*time.Minute), TotalEquity: 100},
+		{Timestamp: day.Add(22*time.Hour + 50*time.Minute), TotalEquity: 110},
+		// 07:30 at UTC+8 is 23:30 UTC, still the 9th
+		{Timestamp: time.Date(2026, 3, 10, 7, 30, 0, 0, time.FixedZone("UTC+8", 8*3600)), TotalEquity: 90},
+		{Timestamp: day.Add(24*time.Hour + 15*time.Minute), TotalEquity: 105, PositionCount: 1},
+		{Timestamp: day.Add(24*time.Hour + 45*time.Minute), TotalEquity: 95, PositionCount: 2},
+	}
+
+	checkBars(t, "hour", bucketSnapshots(snaps, EquityResolutionHour), []bar{
+		{day.Add(22 * time.Hour), 100, 110, 100, 110, 2},
+		{day.Add(23 * time.Hour), 90, 90, 90, 90, 1},
+		{day.Add(24 * time.Hour), 105, 105, 95, 95, 2},
+	})
+	days := bucketSnapshots(snaps, EquityResolutionDay)
+	checkBars(t, "day", days, []bar{
+		{day, 100, 110, 90, 90, 3},
+		{day.Add(24 * time.Hour), 105, 105, 95, 95, 2},
+	})
+	if days[1].PositionCount != 2 {
+		t.Errorf("bar position count = %d, want the close's 2", days[1].PositionCount)
+	}
+
+	raw := bucketSnapshots(snaps, EquityResolutionRaw)
+	if len(raw) != len(snaps) || !raw[2].Timestamp.Equal(snaps[2].Timestamp) || raw[2].Samples != 1 || raw[2].High != 90 {
+		t.Errorf("raw points = %+v", raw)
+	}
+}
+
+func TestEquityResolutionFor(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name      string
+		start     time.Time
+		span      time.Duration
+		retention int
+		want      string
+	}{
+		{"recent hours", now.Add(-6 * time.Hour), 6 * time.Hour, 7, EquityResolutionRaw},
+		{"two recent days", now.Add(-48 * time.Hour), 48 * time.Hour, 7, EquityResolutionRaw},
+		{"three days", now.Add(-72 * time.Hour), 72 * time.Hour, 7, EquityResolutionHour},
+		{"a day rolled up", now.AddDate(0, 0, -10), 24 * time.Hour, 7, EquityResolutionHour},
+		{"a day, nothing rolled up", now.AddDate(0, 0, -10), 24 * time.Hour, 0, EquityResolutionRaw},
+		{"sixty days", now.AddDate(0, 0, -60), 60 * 24 * time.Hour, 7, EquityResolutionHour},
+		{"a quarter", now.AddDate(0, 0, -90), 90 * 24 * time.Hour, 7, EquityResolutionDay},
+	} {
+		if got := EquityResolutionFor(tc.start, tc.start.Add(tc.span), tc.retention); got != tc.want {
+			t.Errorf("%s: resolution = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEquityRollup(t *testing.T) {
+	openTestDB(t)
+	createEquityTrader(t, "t1")
+	createEquityTrader(t, "t2")
+	equity := NewEquityStore()
+
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -5)
+	for _, snap := range []EquitySnapshot{
+		{TraderID: "t1", Timestamp: day.Add(22*time.Hour + 10*time.Minute), TotalEquity: 100},
+		{TraderID: "t1", Timestamp: day.Add(22*time.Hour + 50*time.Minute), TotalEquity: 110},
+		{TraderID: "t1", Timestamp: day.Add(23*time.Hour + 30*time.Minute), TotalEquity: 90},
+		{TraderID: "t1", Timestamp: day.Add(24*time.Hour + 15*time.Minute), TotalEquity: 105},
+		{TraderID: "t1", Timestamp: day.Add(24*time.Hour + 45*time.Minute), TotalEquity: 95},
+		{TraderID: "t1", Timestamp: time.Now().Add(-time.Hour), TotalEquity: 200},
+		{TraderID: "t2", Timestamp: day.Add(12 * time.Hour), TotalEquity: 50},
+	} {
+		if err := equity.Save(&snap); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	if err := equity.RollupAll(1); err != nil {
+		t.Fatalf("RollupAll: %v", err)
+	}
+	// Only the snapshot inside the retention window stays raw
+	if raw, _ := equity.GetLatest("t1", 10); len(raw) != 1 || raw[0].TotalEquity != 200 {
+		t.Errorf("t1 raw snapshots after rollup = %+v", raw)
+	}
+	if raw, _ := equity.GetLatest("t2", 10); len(raw) != 0 {
+		t.Errorf("t2 raw snapshots after rollup = %+v", raw)
+	}
+
+	end := day.Add(48 * time.Hour)
+	hours, resolution, err := equity.GetHistory("t1", day.Add(-time.Hour), end, 1)
+	if err != nil || resolution != EquityResolutionHour {
+		t.Fatalf("GetHistory = %s, %v", resolution, err)
+	}
+	checkBars(t, "hour", hours, []bar{
+		{day.Add(22 * time.Hour), 100, 110, 100, 110, 2},
+		{day.Add(23 * time.Hour), 90, 90, 90, 90, 1},
+		{day.Add(24 * time.Hour), 105, 105, 95, 95, 2},
+	})
+	days, resolution, err := equity.GetHistory("t1", day.AddDate(0, 0, -90), end, 1)
+	if err != nil || resolution != EquityResolutionDay {
+		t.Fatalf("GetHistory = %s, %v", resolution, err)
+	}
+	checkBars(t, "day", days, []bar{
+		{day, 100, 110, 90, 90, 3},
+		{day.Add(24 * time.Hour), 105, 105, 95, 95, 2},
+	})
+	t2, _, err := equity.GetHistory("t2", day.AddDate(0, 0, -90), end, 1)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	checkBars(t, "t2 day", t2, []bar{{day, 50, 50, 50, 50, 1}})
+
+	// Nothing left to roll up
+	if n, err := equity.Rollup("t1", 1); n != 0 || err != nil {
+		t.Errorf("second Rollup = %d, %v", n, err)
+	}
+}
+
+func TestEquityRollupExtendsBars(t *testing.T) {
+	openTestDB(t)
+	createEquityTrader(t, "t1")
+	equity := NewEquityStore()
+
+	// A day bar straddling the 3-day cutoff: rolled up in part now and the
+	// rest once the 1-day retention catches up with it
+	cutoff := time.Now().UTC().AddDate(0, 0, -3)
+	day := bucketStart(cutoff, EquityResolutionDay)
+	if cutoff.Sub(day) < time.Second || day.Add(24*time.Hour).Sub(cutoff) < time.Second {
+		t.Skip("too close to midnight UTC")
+	}
+	for _, snap := range []EquitySnapshot{
+		{TraderID: "t1", Timestamp: day, TotalEquity: 100},
+		{TraderID: "t1", Timestamp: day.Add(cutoff.Sub(day) / 2), TotalEquity: 80},
+		{TraderID: "t1", Timestamp: cutoff.Add(day.Add(24*time.Hour).Sub(cutoff) / 2), TotalEquity: 130},
+	} {
+		if err := equity.Save(&snap); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	if n, err := equity.Rollup("t1", 3); n != 2 || err != nil {
+		t.Fatalf("first Rollup = %d, %v; want 2", n, err)
+	}
+	if n, err := equity.Rollup("t1", 1); n != 1 || err != nil {
+		t.Fatalf("second Rollup = %d, %v; want 1", n, err)
+	}
+
+	days, _, err := equity.GetHistory("t1", day.AddDate(0, 0, -90), day.Add(23*time.Hour), 1)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	// The open stays the first run's; high, low, close and samples take in the second
+	checkBars(t, "day", days, []bar{{day, 100, 130, 80, 130, 3}})
+}
+
+func TestEquityHistoryMergesRawBar(t *testing.T) {
+	openTestDB(t)
+	createEquityTrader(t, "t1")
+	equity := NewEquityStore()
+
+	hour := time.Now().UTC().AddDate(0, 0, -10).Truncate(time.Hour)
+	save := func(offset time.Duration, v float64) {
+		t.Helper()
+		if err := equity.Save(&EquitySnapshot{TraderID: "t1", Timestamp: hour.Add(offset), TotalEquity: v}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	save(5*time.Minute, 100)
+	save(10*time.Minute, 120)
+	if n, err := equity.Rollup("t1", 7); n != 2 || err != nil {
+		t.Fatalf("Rollup = %d, %v", n, err)
+	}
+	// Past the retention window but not rolled up yet
+	save(20*time.Minute, 70)
+	save(30*time.Minute, 90)
+	save(65*time.Minute, 95)
+
+	bars, resolution, err := equity.GetHistory("t1", hour.Add(-time.Hour), hour.Add(3*time.Hour), 7)
+	if err != nil || resolution != EquityResolutionHour {
+		t.Fatalf("GetHistory = %s, %v", resolution, err)
+	}
+	checkBars(t, "hour", bars, []bar{
+		{hour, 100, 120, 70, 90, 4},
+		{hour.Add(time.Hour), 95, 95, 95, 95, 1},
+	})
+}
+
+func TestEquityRollupZeroRetention(t *testing.T) {
+	openTestDB(t)
+	createEquityTrader(t, "t1")
+	equity := NewEquityStore()
+
+	old := time.Now().AddDate(0, 0, -400)
+	for i := 0; i < 3; i++ {
+		if err := equity.Save(&EquitySnapshot{TraderID: "t1", Timestamp: old.Add(time.Duration(i) * time.Hour), TotalEquity: 100}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	if n, err := equity.Rollup("t1", 0); n != 0 || err != nil {
+		t.Errorf("Rollup = %d, %v", n, err)
+	}
+	if err := equity.RollupAll(0); err != nil {
+		t.Errorf("RollupAll: %v", err)
+	}
+	if raw, _ := equity.GetLatest("t1", 10); len(raw) != 3 {
+		t.Errorf("raw snapshots = %d, want all 3 kept", len(raw))
+	}
+	var bars int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM trader_equity_rollups`).Scan(&bars); err != nil || bars != 0 {
+		t.Errorf("rollups = %d, %v", bars, err)
+	}
+
+	// With every snapshot kept, a short range is served raw however old
+	points, resolution, err := equity.GetHistory("t1", old.Add(-time.Hour), old.Add(3*time.Hour), 0)
+	if err != nil || resolution != EquityResolutionRaw || len(points) != 3 {
+		t.Errorf("GetHistory = %s %d points, %v", resolution, len(points), err)
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// VariantStats is how a prompt experiment variant did: the cycles it ran,
+// what it decided, and the positions opened while it was in use
+type VariantStats struct {
+	Variant       string         `json:"variant"`
+	Cycles        int            `json:"cycles"`
+	Decisions     map[string]int `json:"decisions"`   // key: action, one per symbol analyzed; NONE without a decision
+	Executed      map[string]int `json:"executed"`    // key: action, only the decisions whose order went through
+	AIFailures    int            `json:"ai_failures"` // Symbols whose AI call failed
+	Positions     int            `json:"positions"`   // Closed positions
+	OpenPositions int            `json:"open_positions"`
+	Wins          int            `json:"wins"`
+	WinRate       float64        `json:"win_rate"` // % of closed positions
+	TotalPnL      float64        `json:"total_pnl"`
+	AvgPnL        float64        `json:"avg_pnl"`
+}
+
+// ExperimentStore summarizes prompt experiments from the variant tags on
+// decisions and positions
+type ExperimentStore struct{}
+
+// NewExperimentStore creates a new experiment store
+func NewExperimentStore() *ExperimentStore {
+	return &ExperimentStore{}
+}
+
+// VariantStats returns each variant the trader's decisions or positions are
+// tagged with, by variant ID
+func (s *ExperimentStore) VariantStats(traderID string) ([]*VariantStats, error) {
+	stats := make(map[string]*VariantStats)
+	get := func(variant string) *VariantStats {
+		if stats[variant] == nil {
+			stats[variant] = &VariantStats{Variant: variant, Decisions: make(map[string]int), Executed: make(map[string]int)}
+		}
+		return stats[variant]
+	}
+
+	rows, err := db.Query(`
+		SELECT variant, decisions FROM decisions
+		WHERE trader_id = ? AND variant IS NOT NULL AND variant != ''
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var variant, raw string
+		if err := rows.Scan(&variant, &raw); err != nil {
+			return nil, err
+		}
+		v := get(variant)
+		v.Cycles++
+
+		var decisions []struct {
+			decisionEntry
+			AIFailed bool `json:"ai_failed"`
+		}
+		if err := json.Unmarshal([]byte(raw), &decisions); err != nil {
+			return nil, fmt.Errorf("failed to parse decisions of variant %s: %w", variant, err)
+		}
+		for _, d := range decisions {
+			action := strings.ToUpper(d.Action)
+			if action == "" {
+				action = "NONE"
+			}
+			v.Decisions[action]++
+			// Records saved before execution statuses count as not executed
+			if d.Executed() {
+				v.Executed[action]++
+			}
+			if d.AIFailed {
+				v.AIFailures++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	posRows, err := db.Query(`
+		SELECT variant, status, COUNT(*),
+			SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), COALESCE(SUM(realized_pnl), 0)
+		FROM trader_positions
+		WHERE trader_id = ? AND variant IS NOT NULL AND variant != ''
+		GROUP BY variant, status
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer posRows.Close()
+	for posRows.Next() {
+		var variant, status string
+		var count, wins int
+		var pnl float64
+		if err := posRows.Scan(&variant, &status, &count, &wins, &pnl); err != nil {
+			return nil, err
+		}
+		v := get(variant)
+		if status != PositionStatusClosed {
+			v.OpenPositions += count
+			continue
+		}
+		v.Positions, v.Wins, v.TotalPnL = count, wins, pnl
+		if count > 0 {
+			v.WinRate = float64(wins) / float64(count) * 100
+			v.AvgPnL = pnl / float64(count)
+		}
+	}
+	if err := posRows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*VariantStats, 0, len(stats))
+	for _, variant := range slices.Sorted(maps.Keys(stats)) {
+		result = append(result, stats[variant])
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRecord is the saved response to a request sent with an
+// Idempotency-Key, replayed when the same request is retried
+type IdempotencyRecord struct {
+	UserID      string
+	Key         string
+	RequestHash string // Method, path and body of the original request
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore handles idempotency key persistence
+type IdempotencyStore struct{}
+
+// NewIdempotencyStore creates a new idempotency store
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{}
+}
+
+// Get returns a user's unexpired record for a key, sql.ErrNoRows if there is none
+func (s *IdempotencyStore) Get(userID, key string, now time.Time) (*IdempotencyRecord, error) {
+	var rec IdempotencyRecord
+	var contentType sql.NullString
+	err := db.QueryRow(`
+		SELECT user_id, idem_key, request_hash, status, content_type, body, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = ? AND idem_key = ?
+	`, userID, key).Scan(&rec.UserID, &rec.Key, &rec.RequestHash, &rec.Status,
+		&contentType, &rec.Body, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !now.Before(rec.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	rec.ContentType = contentType.String
+	return &rec, nil
+}
+
+// Save stores a record, replacing an expired one left under the same key
+func (s *IdempotencyStore) Save(rec *IdempotencyRecord) error {
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	_, err := db.Exec(`
+		INSERT INTO idempotency_keys (user_id, idem_key, request_hash, status, content_type, body, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, idem_key) DO UPDATE SET
+			request_hash = excluded.request_hash, status = excluded.status,
+			content_type = excluded.content_type, body = excluded.body,
+			created_at = excluded.created_at, expires_at = excluded.expires_at
+	`, rec.UserID, rec.Key, rec.RequestHash, rec.Status, rec.ContentType, rec.Body, rec.CreatedAt, rec.ExpiresAt)
+	return err
+}
+
+// DeleteExpired removes records past their expiry, returning how many
+func (s *IdempotencyStore) DeleteExpired(now time.Time) (int64, error) {
+	res, err := db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Income categories. Exchange income types are grouped into these so account
+// flows aren't mistaken for trading P&L.
+const (
+	IncomeCategoryRealizedPnL = "realized_pnl"
+	IncomeCategoryFunding     = "funding_fee"
+	IncomeCategoryCommission  = "commission"
+	IncomeCategoryTransfer    = "transfer" // Deposits and withdrawals
+	IncomeCategoryOther       = "other"
+)
+
+// IncomeEntry is one entry of a trader's exchange income history
+type IncomeEntry struct {
+	TraderID   string    `json:"trader_id"`
+	TranID     int64     `json:"tran_id"`
+	IncomeType string    `json:"income_type"` // As reported by the exchange
+	Category   string    `json:"category"`
+	Symbol     string    `json:"symbol,omitempty"`
+	Amount     float64   `json:"amount"` // Signed
+	Asset      string    `json:"asset"`
+	Info       string    `json:"info,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// IncomeTotals sums a trader's income by category
+type IncomeTotals struct {
+	RealizedPnL float64 `json:"realized_pnl"`
+	Funding     float64 `json:"funding"`
+	Commission  float64 `json:"commission"` // Negative when paid
+	Deposits    float64 `json:"deposits"`
+	Withdrawals float64 `json:"withdrawals"` // Negative
+	Other       float64 `json:"other"`
+}
+
+// NetTransfers is deposits less withdrawals
+func (t IncomeTotals) NetTransfers() float64 {
+	return t.Deposits + t.Withdrawals
+}
+
+// TradingIncome is the income that came from trading: realized P&L, funding
+// and commission
+func (t IncomeTotals) TradingIncome() float64 {
+	return t.RealizedPnL + t.Funding + t.Commission
+}
+
+// IncomeStore handles exchange income persistence
+type IncomeStore struct{}
+
+// NewIncomeStore creates a new income store
+func NewIncomeStore() *IncomeStore {
+	return &IncomeStore{}
+}
+
+// Save records entries not recorded yet and returns those it added, so the
+// same history can be fetched again without counting anything twice
+func (s *IncomeStore) Save(entries []IncomeEntry) ([]IncomeEntry, error) {
+	var added []IncomeEntry
+	for _, e := range entries {
+		res, err := db.Exec(`
+			INSERT INTO trader_income (trader_id, tran_id, income_type, category, symbol, amount, asset, info, time)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (trader_id, tran_id, income_type, symbol) DO NOTHING
+		`, e.TraderID, e.TranID, e.IncomeType, e.Category, e.Symbol, e.Amount, e.Asset, e.Info, e.Time)
+		if err != nil {
+			return added, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			added = append(added, e)
+		}
+	}
+	return added, nil
+}
+
+// LatestTime returns the time of a trader's newest entry, zero without any
+func (s *IncomeStore) LatestTime(traderID string) (time.Time, error) {
+	var latest time.Time
+	err := db.QueryRow(`
+		SELECT time FROM trader_income WHERE trader_id = ?
+		ORDER BY time DESC LIMIT 1
+	`, traderID).Scan(&latest)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return latest, err
+}
+
+// ListBetween returns a trader's entries of category in [start, end), oldest
+// first. An empty category returns all of them.
+func (s *IncomeStore) ListBetween(traderID, category string, start, end time.Time) ([]IncomeEntry, error) {
+	rows, err := db.Query(`
+		SELECT trader_id, tran_id, income_type, category, COALESCE(symbol, ''), amount,
+			COALESCE(asset, ''), COALESCE(info, ''), time
+		FROM trader_income
+		WHERE trader_id = ? AND (? = '' OR category = ?) AND time >= ? AND time < ?
+		ORDER BY time ASC, tran_id ASC
+	`, traderID, category, category, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]IncomeEntry, 0)
+	for rows.Next() {
+		var e IncomeEntry
+		if err := rows.Scan(&e.TraderID, &e.TranID, &e.IncomeType, &e.Category, &e.Symbol, &e.Amount,
+			&e.Asset, &e.Info, &e.Time); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetTotals sums a trader's income in [start, end) by category
+func (s *IncomeStore) GetTotals(traderID string, start, end time.Time) (IncomeTotals, error) {
+	var t IncomeTotals
+	err := db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN category = ? THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN category = ? THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN category = ? THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN category = ? AND amount > 0 THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN category = ? AND amount < 0 THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN category = ? THEN amount ELSE 0 END), 0)
+		FROM trader_income
+		WHERE trader_id = ? AND time >= ? AND time < ?
+	`, IncomeCategoryRealizedPnL, IncomeCategoryFunding, IncomeCategoryCommission,
+		IncomeCategoryTransfer, IncomeCategoryTransfer, IncomeCategoryOther,
+		traderID, start, end).Scan(&t.RealizedPnL, &t.Funding, &t.Commission, &t.Deposits, &t.Withdrawals, &t.Other)
+	return t, err
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// DecisionLatency is how long a decision took from the market data it was
+// made on to its order, and how far the symbol's price moved meanwhile. It is
+// saved under "latency" in the decision record's entry.
+type DecisionLatency struct {
+	Model          string  `json:"model"`
+	LatencyMs      int64   `json:"latency_ms"`
+	PriceChangePct float64 `json:"price_change_pct"`  // Absolute move over the latency
+	AdverseMovePct float64 `json:"adverse_move_pct"`  // The move against the decision's side, 0 when in its favor
+	Expired        bool    `json:"expired,omitempty"` // Discarded for being older than the strategy allows
+}
+
+// ModelLatency sums up the latency of a model's decisions
+type ModelLatency struct {
+	Model             string  `json:"model"`
+	Decisions         int     `json:"decisions"`
+	Expired           int     `json:"expired"`
+	P50LatencyMs      int64   `json:"p50_latency_ms"`
+	P95LatencyMs      int64   `json:"p95_latency_ms"`
+	MaxLatencyMs      int64   `json:"max_latency_ms"`
+	AvgPriceChangePct float64 `json:"avg_price_change_pct"`
+	AvgAdverseMovePct float64 `json:"avg_adverse_move_pct"`
+}
+
+// LatencyStore summarizes decision latency from the decision records
+type LatencyStore struct{}
+
+// NewLatencyStore creates a new latency store
+func NewLatencyStore() *LatencyStore {
+	return &LatencyStore{}
+}
+
+// ModelLatency returns the latency of the trader's decisions since, by model
+func (s *LatencyStore) ModelLatency(traderID string, since time.Time) ([]*ModelLatency, error) {
+	rows, err := db.Query(`
+		SELECT id, decisions FROM decisions WHERE trader_id = ? AND timestamp >= ?
+	`, traderID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byModel := make(map[string][]DecisionLatency)
+	for rows.Next() {
+		var id int64
+		var raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		var entries []struct {
+			Latency *DecisionLatency `json:"latency"`
+		}
+		if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse decision %d: %w", id, err)
+		}
+		for _, e := range entries {
+			if e.Latency != nil {
+				byModel[e.Latency.Model] = append(byModel[e.Latency.Model], *e.Latency)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*ModelLatency, 0, len(byModel))
+	for _, model := range slices.Sorted(maps.Keys(byModel)) {
+		samples := byModel[model]
+		stats := &ModelLatency{Model: model, Decisions: len(samples)}
+		latencies := make([]int64, len(samples))
+		for i, l := range samples {
+			latencies[i] = l.LatencyMs
+			stats.AvgPriceChangePct += l.PriceChangePct / float64(len(samples))
+			stats.AvgAdverseMovePct += l.AdverseMovePct / float64(len(samples))
+			if l.Expired {
+				stats.Expired++
+			}
+		}
+		slices.Sort(latencies)
+		stats.P50LatencyMs = latencyPercentile(latencies, 50)
+		stats.P95LatencyMs = latencyPercentile(latencies, 95)
+		stats.MaxLatencyMs = latencies[len(latencies)-1]
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// latencyPercentile is the nearest-rank pct percentile of sorted latencies
+func latencyPercentile(sorted []int64, pct int) int64 {
+	rank := (pct*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,671 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration is one schema change. Migrations run in version order, each in
+// its own transaction, and are recorded in schema_version. Once released a
+// migration never changes; later changes go in a new one.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *Tx) error
+}
+
+var migrations = []migration{
+	{1, "baseline schema", migrateBaseline},
+	{2, "index positions by close reason", execMigration(`
+	CREATE INDEX IF NOT EXISTS idx_positions_close_reason ON trader_positions(trader_id, close_reason);
+	`)},
+	// Foreign keys weren't enforced before, so older databases can hold
+	// references that would now fail any update touching them
+	{3, "clear dangling references before enforcing foreign keys", execMigration(`
+	UPDATE traders SET strategy_id = NULL
+	WHERE strategy_id = '' OR strategy_id NOT IN (SELECT id FROM strategies);
+	DELETE FROM decisions WHERE trader_id NOT IN (SELECT id FROM traders);
+	`)},
+	{4, "record AI call token usage and cost", func(tx *Tx) error {
+		for _, col := range []struct{ column, definition string }{
+			{"prompt_tokens", "INTEGER DEFAULT 0"},
+			{"completion_tokens", "INTEGER DEFAULT 0"},
+			{"cost_usd", "REAL DEFAULT 0"},
+		} {
+			if err := addColumnIfMissing(tx, "ai_calls", col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{5, "risk event log", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS risk_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			message TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_risk_events_trader_time ON risk_events(trader_id, timestamp);
+		`))
+		return err
+	}},
+	{6, "idempotency keys", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			user_id TEXT NOT NULL,
+			idem_key TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			content_type TEXT,
+			body BLOB,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, idem_key)
+		);
+		CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+		`))
+		return err
+	}},
+	{7, "record AI call correction retries", func(tx *Tx) error {
+		for _, col := range []struct{ column, definition string }{
+			{"retries", "INTEGER DEFAULT 0"},
+			{"retry_reason", "TEXT"},
+			{"first_response", "BLOB"},
+		} {
+			if err := addColumnIfMissing(tx, "ai_calls", col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{8, "position event log", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS position_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			position_id INTEGER DEFAULT 0,
+			symbol TEXT NOT NULL,
+			side TEXT,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			old_price REAL DEFAULT 0,
+			new_price REAL DEFAULT 0,
+			source TEXT,
+			reason TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_position_events_trader_symbol ON position_events(trader_id, symbol, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_position_events_position ON position_events(position_id);
+		`))
+		return err
+	}},
+	{9, "link decisions and fills to positions", func(tx *Tx) error {
+		for _, col := range []struct{ column, definition string }{
+			{"quantity", "REAL DEFAULT 0"},
+			{"realized_pnl", "REAL DEFAULT 0"},
+			{"fee", "REAL DEFAULT 0"},
+		} {
+			if err := addColumnIfMissing(tx, "position_events", col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS decision_positions (
+			decision_id INTEGER NOT NULL,
+			position_id INTEGER NOT NULL,
+			trader_id TEXT NOT NULL,
+			PRIMARY KEY (decision_id, position_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_decision_positions_position ON decision_positions(trader_id, position_id);
+		`))
+		return err
+	}},
+	{10, "trader coin pins and bans", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS trader_coin_overrides (
+			trader_id TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (trader_id, symbol)
+		);
+		`))
+		return err
+	}},
+	{11, "backtest checkpoints", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS backtest_checkpoints (
+			run_id TEXT PRIMARY KEY,
+			user_id TEXT,
+			next_bar INTEGER NOT NULL,
+			data BLOB NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+		`))
+		return err
+	}},
+	{12, "blocked decisions", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS blocked_decisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			decision_id INTEGER DEFAULT 0,
+			timestamp DATETIME NOT NULL,
+			symbol TEXT NOT NULL,
+			action TEXT NOT NULL,
+			confidence REAL DEFAULT 0,
+			reason TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_blocked_decisions_trader ON blocked_decisions(trader_id, timestamp);
+		`))
+		return err
+	}},
+	{13, "tag decisions and positions with their prompt experiment variant", func(tx *Tx) error {
+		for _, table := range []string{"decisions", "trader_positions"} {
+			if err := addColumnIfMissing(tx, table, "variant", "TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{14, "record entry fees and slippage on positions", func(tx *Tx) error {
+		for _, col := range []struct{ column, definition string }{
+			{"entry_fee", "REAL DEFAULT 0"},
+			{"slippage_cost", "REAL DEFAULT 0"},
+		} {
+			if err := addColumnIfMissing(tx, "trader_positions", col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{15, "exchange income history", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS trader_income (
+			trader_id TEXT NOT NULL,
+			tran_id INTEGER NOT NULL,
+			income_type TEXT NOT NULL,
+			category TEXT NOT NULL,
+			symbol TEXT NOT NULL DEFAULT '',
+			amount REAL NOT NULL,
+			asset TEXT,
+			info TEXT,
+			time DATETIME NOT NULL,
+			PRIMARY KEY (trader_id, tran_id, income_type, symbol)
+		);
+		CREATE INDEX IF NOT EXISTS idx_trader_income_time ON trader_income(trader_id, time);
+		`))
+		return err
+	}},
+	{16, "finished backtest runs", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS backtest_runs (
+			run_id TEXT PRIMARY KEY,
+			user_id TEXT,
+			data TEXT NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+		`))
+		return err
+	}},
+	{17, "record how entry orders filled", func(tx *Tx) error {
+		for _, col := range []struct{ column, definition string }{
+			{"entry_order_type", "TEXT"},
+			{"entry_maker_pct", "REAL"},
+			{"entry_improvement_bps", "REAL"},
+		} {
+			if err := addColumnIfMissing(tx, "trader_positions", col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{18, "archive traders", func(tx *Tx) error {
+		return addColumnIfMissing(tx, "traders", "archived_at", "DATETIME")
+	}},
+	{19, "strategy version history", func(tx *Tx) error {
+		for _, col := range []struct{ table, column, definition string }{
+			{"strategies", "version", "INTEGER DEFAULT 1"},
+			{"strategies", "updated_by", "TEXT"},
+			{"traders", "strategy_version", "INTEGER"},
+		} {
+			if err := addColumnIfMissing(tx, col.table, col.column, col.definition); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS strategy_versions (
+			strategy_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT,
+			config TEXT NOT NULL,
+			edited_by TEXT,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (strategy_id, version)
+		);
+		`))
+		return err
+	}},
+	{20, "equity curve annotations", func(tx *Tx) error {
+		_, err := tx.Exec(translateDDL(tx.dialect, `
+		CREATE TABLE IF NOT EXISTS trader_annotations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			text TEXT NOT NULL,
+			created_by TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_trader_annotations_trader_time ON trader_annotations(trader_id, timestamp);
+		`))
+		return err
+	}},
+}
+
+// SchemaVersion is the newest schema version this binary understands
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// runMigrations brings the database up to SchemaVersion. It refuses a
+// database written by a newer binary rather than risk corrupting it.
+func runMigrations() error {
+	if _, err := db.Exec(translateDDL(db.dialect, `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > SchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d), upgrade the server",
+			current, SchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+		log.Printf("Applied schema migration %d: %s", m.version, m.description)
+		current = m.version
+	}
+
+	log.Printf("Database schema version %d", current)
+	return nil
+}
+
+func applyMigration(m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.description, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execMigration is a migration that only runs SQL
+func execMigration(query string) func(tx *Tx) error {
+	return func(tx *Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// migrateBaseline creates the schema as it was before versioning. Databases
+// from then already have some or all of it, so tables are only created when
+// missing and columns added since they shipped are added if missing.
+func migrateBaseline(tx *Tx) error {
+	if _, err := tx.Exec(translateDDL(tx.dialect, schemaBaseline)); err != nil {
+		return err
+	}
+
+	for _, col := range []struct{ table, column, definition string }{
+		{"strategies", "owner_user_id", "TEXT DEFAULT 'admin'"},
+		{"traders", "owner_user_id", "TEXT DEFAULT 'admin'"},
+		{"trader_positions", "pnl_estimated", "BOOLEAN DEFAULT 0"},
+		{"audit_log", "action", "TEXT"},
+		{"audit_log", "result", "TEXT"},
+	} {
+		if err := addColumnIfMissing(tx, col.table, col.column, col.definition); err != nil {
+			return err
+		}
+	}
+
+	// Ownership for rows from before multi-user support
+	for _, table := range []string{"strategies", "traders"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET owner_user_id = ? WHERE owner_user_id IS NULL OR owner_user_id = ''`, BootstrapAdminID); err != nil {
+			return fmt.Errorf("failed to backfill %s owners: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table if it isn't there yet
+func addColumnIfMissing(tx *Tx, table, column, definition string) error {
+	if tx.dialect == DialectPostgres {
+		_, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS ` + column + ` ` + translateDDL(tx.dialect, definition))
+		return err
+	}
+
+	rows, err := tx.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// schemaBaseline is migration 1
+const schemaBaseline = `
+	-- Strategies, traders and AI decision logs
+	CREATE TABLE IF NOT EXISTS strategies (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		is_active BOOLEAN DEFAULT 0,
+		config TEXT NOT NULL,
+		owner_user_id TEXT DEFAULT 'admin',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS traders (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		strategy_id TEXT,
+		exchange TEXT NOT NULL DEFAULT 'binance',
+		status TEXT DEFAULT 'stopped',
+		initial_balance REAL DEFAULT 0,
+		config TEXT NOT NULL,
+		owner_user_id TEXT DEFAULT 'admin',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (strategy_id) REFERENCES strategies(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS decisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+		market_data TEXT,
+		ai_response TEXT,
+		decisions TEXT,
+		executed BOOLEAN DEFAULT 0,
+		FOREIGN KEY (trader_id) REFERENCES traders(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_decisions_trader ON decisions(trader_id);
+
+	-- Positions
+	CREATE TABLE IF NOT EXISTS trader_positions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		exchange_id TEXT,
+		exchange_type TEXT,
+		exchange_position_id TEXT,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		entry_quantity REAL NOT NULL,
+		quantity REAL NOT NULL,
+		entry_price REAL NOT NULL,
+		exit_price REAL DEFAULT 0,
+		entry_order_id TEXT,
+		exit_order_id TEXT,
+		entry_time DATETIME NOT NULL,
+		exit_time DATETIME,
+		realized_pnl REAL DEFAULT 0,
+		fee REAL DEFAULT 0,
+		leverage INTEGER DEFAULT 1,
+		status TEXT NOT NULL DEFAULT 'OPEN',
+		close_reason TEXT,
+		source TEXT DEFAULT 'system',
+		pnl_estimated BOOLEAN DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_positions_trader ON trader_positions(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_positions_symbol ON trader_positions(symbol);
+	CREATE INDEX IF NOT EXISTS idx_positions_status ON trader_positions(status);
+	CREATE INDEX IF NOT EXISTS idx_positions_exchange ON trader_positions(exchange_id, exchange_position_id);
+
+	-- Orders and fills
+	CREATE TABLE IF NOT EXISTS trader_orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		exchange_id TEXT,
+		exchange_type TEXT,
+		exchange_order_id TEXT,
+		client_order_id TEXT,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		position_side TEXT,
+		type TEXT NOT NULL,
+		time_in_force TEXT,
+		quantity REAL NOT NULL,
+		price REAL,
+		stop_price REAL,
+		status TEXT NOT NULL,
+		filled_quantity REAL DEFAULT 0,
+		avg_fill_price REAL DEFAULT 0,
+		commission REAL DEFAULT 0,
+		leverage INTEGER DEFAULT 1,
+		reduce_only BOOLEAN DEFAULT 0,
+		close_position BOOLEAN DEFAULT 0,
+		working_type TEXT,
+		price_protect BOOLEAN DEFAULT 0,
+		order_action TEXT,
+		position_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		filled_at DATETIME,
+		UNIQUE(exchange_id, exchange_order_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_orders_trader ON trader_orders(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_orders_symbol ON trader_orders(symbol);
+	CREATE INDEX IF NOT EXISTS idx_orders_status ON trader_orders(status);
+	CREATE INDEX IF NOT EXISTS idx_orders_exchange ON trader_orders(exchange_id, exchange_order_id);
+
+	CREATE TABLE IF NOT EXISTS trader_fills (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		order_id INTEGER,
+		exchange_id TEXT,
+		exchange_trade_id TEXT,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		price REAL NOT NULL,
+		quantity REAL NOT NULL,
+		quote_quantity REAL,
+		commission REAL DEFAULT 0,
+		realized_pnl REAL DEFAULT 0,
+		is_maker BOOLEAN DEFAULT 0,
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(exchange_id, exchange_trade_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_fills_trader ON trader_fills(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_fills_order ON trader_fills(order_id);
+	CREATE INDEX IF NOT EXISTS idx_fills_symbol ON trader_fills(symbol);
+
+	-- Equity snapshots and their hourly/daily rollups
+	CREATE TABLE IF NOT EXISTS trader_equity_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		total_equity REAL NOT NULL,
+		balance REAL,
+		unrealized_pnl REAL,
+		position_count INTEGER,
+		margin_usage_pct REAL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_equity_trader ON trader_equity_snapshots(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_equity_timestamp ON trader_equity_snapshots(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_equity_trader_time ON trader_equity_snapshots(trader_id, timestamp);
+
+	CREATE TABLE IF NOT EXISTS trader_equity_rollups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		resolution TEXT NOT NULL,
+		bucket DATETIME NOT NULL,
+		open REAL NOT NULL,
+		high REAL NOT NULL,
+		low REAL NOT NULL,
+		close REAL NOT NULL,
+		balance REAL,
+		unrealized_pnl REAL,
+		position_count INTEGER,
+		margin_usage_pct REAL,
+		samples INTEGER NOT NULL,
+		UNIQUE (trader_id, resolution, bucket)
+	);
+
+	-- Exchange trades
+	CREATE TABLE IF NOT EXISTS trades (
+		id INTEGER PRIMARY KEY,
+		trader_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		price REAL NOT NULL,
+		quantity REAL NOT NULL,
+		quote_qty REAL NOT NULL,
+		realized_pnl REAL DEFAULT 0,
+		commission REAL DEFAULT 0,
+		timestamp DATETIME NOT NULL,
+		order_id INTEGER,
+		UNIQUE(id, trader_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_trades_trader ON trades(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_trades_timestamp ON trades(trader_id, timestamp DESC);
+	CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(trader_id, symbol);
+
+	-- Settings
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Users
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'user',
+		api_key_hash TEXT NOT NULL UNIQUE,
+		api_key_prefix TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_users_api_key ON users(api_key_hash);
+
+	-- API audit log
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		user_id TEXT,
+		key_fingerprint TEXT,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		trader_id TEXT,
+		strategy_id TEXT,
+		status INTEGER DEFAULT 0,
+		latency_ms INTEGER DEFAULT 0,
+		remote_addr TEXT,
+		action TEXT,
+		result TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_log(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id);
+
+	-- Live AI calls
+	CREATE TABLE IF NOT EXISTS ai_calls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		decision_id INTEGER DEFAULT 0,
+		symbol TEXT,
+		model TEXT,
+		timestamp DATETIME NOT NULL,
+		system_prompt BLOB,
+		user_prompt BLOB,
+		raw_response BLOB,
+		reasoning BLOB,
+		parse_outcome TEXT,
+		latency_ms INTEGER DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ai_calls_trader_time ON ai_calls(trader_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_ai_calls_decision ON ai_calls(decision_id);
+
+	-- Engine runtime state
+	CREATE TABLE IF NOT EXISTS trader_engine_state (
+		trader_id TEXT PRIMARY KEY,
+		state TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	-- Smart Find runs
+	CREATE TABLE IF NOT EXISTS smart_find_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		trigger_type TEXT NOT NULL,
+		candidates TEXT,
+		recommended TEXT,
+		selected TEXT,
+		raw_response TEXT,
+		error TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_smart_find_runs_trader ON smart_find_runs(trader_id, id);
+`
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMigrations makes runMigrations see only ms until the test ends
+func useMigrations(t *testing.T, ms []migration) {
+	t.Helper()
+	all := migrations
+	migrations = ms
+	t.Cleanup(func() { migrations = all })
+}
+
+func schemaVersion(t *testing.T) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		t.Fatalf("read schema version: %v", err)
+	}
+	return version
+}
+
+func indexExists(t *testing.T, name string) bool {
+	t.Helper()
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?`
+	if db.dialect == DialectPostgres {
+		query = `SELECT COUNT(*) FROM pg_indexes WHERE indexname = ?`
+	}
+	var n int
+	if err := db.QueryRow(query, name).Scan(&n); err != nil {
+		t.Fatalf("look up index %s: %v", name, err)
+	}
+	return n > 0
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	openTestDB(t)
+
+	if v := schemaVersion(t); v != SchemaVersion() {
+		t.Errorf("schema version = %d, want %d", v, SchemaVersion())
+	}
+	var applied int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_version`).Scan(&applied); err != nil || applied != len(migrations) {
+		t.Errorf("applied migrations = %d, %v; want %d", applied, err, len(migrations))
+	}
+
+	// Running again is a no-op
+	if err := runMigrations(); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if v := schemaVersion(t); v != SchemaVersion() {
+		t.Errorf("schema version after second run = %d", v)
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	all := migrations
+	useMigrations(t, all[:1])
+	openTestDB(t)
+	if v := schemaVersion(t); v != 1 {
+		t.Fatalf("baseline schema version = %d, want 1", v)
+	}
+	if indexExists(t, "idx_positions_close_reason") {
+		t.Fatal("baseline database already has the close reason index")
+	}
+
+	// The binary is upgraded: the follow-up migrations run on the next start
+	migrations = all
+	if err := runMigrations(); err != nil {
+		t.Fatalf("runMigrations: %v", err)
+	}
+	if v := schemaVersion(t); v != SchemaVersion() {
+		t.Errorf("schema version = %d, want %d", v, SchemaVersion())
+	}
+	if !indexExists(t, "idx_positions_close_reason") {
+		t.Error("close reason index missing after migrating")
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	openTestDB(t)
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1", Exchange: "binance", Status: "stopped"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+
+	next := SchemaVersion() + 1
+	useMigrations(t, append(migrations[:len(migrations):len(migrations)], migration{next, "broken", func(tx *Tx) error {
+		if _, err := tx.Exec(`DELETE FROM traders`); err != nil {
+			return err
+		}
+		return fmt.Errorf("boom")
+	}}))
+
+	err := runMigrations()
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("migration %d (broken) failed: boom", next)) {
+		t.Fatalf("runMigrations = %v", err)
+	}
+	if v := schemaVersion(t); v != next-1 {
+		t.Errorf("schema version after failure = %d, want %d", v, next-1)
+	}
+	if _, err := NewTraderStore().Get("t1"); err != nil {
+		t.Errorf("the failed migration's changes were kept: %v", err)
+	}
+}
+
+func TestRefuseNewerSchema(t *testing.T) {
+	dataDir := openTestDB(t)
+	newer := SchemaVersion() + 1
+	if _, err := db.Exec(`INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)`,
+		newer, "from a newer binary", time.Now()); err != nil {
+		t.Fatalf("insert schema version: %v", err)
+	}
+	Close()
+
+	err := Init(dataDir, os.Getenv("TEST_DATABASE_URL"))
+	want := fmt.Sprintf("database schema version %d is newer than this binary supports (%d)", newer, SchemaVersion())
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("Init = %v, want %q", err, want)
+	}
+	if v := schemaVersion(t); v != newer {
+		t.Errorf("schema version = %d, want it left at %d", v, newer)
+	}
+}
@@ -83,73 +83,6 @@ func NewOrderStore() *OrderStore {
 	return &OrderStore{}
 }
 
-// InitTables creates the order tables
-func (s *OrderStore) InitTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS trader_orders (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		trader_id TEXT NOT NULL,
-		exchange_id TEXT,
-		exchange_type TEXT,
-		exchange_order_id TEXT,
-		client_order_id TEXT,
-		symbol TEXT NOT NULL,
-		side TEXT NOT NULL,
-		position_side TEXT,
-		type TEXT NOT NULL,
-		time_in_force TEXT,
-		quantity REAL NOT NULL,
-		price REAL,
-		stop_price REAL,
-		status TEXT NOT NULL,
-		filled_quantity REAL DEFAULT 0,
-		avg_fill_price REAL DEFAULT 0,
-		commission REAL DEFAULT 0,
-		leverage INTEGER DEFAULT 1,
-		reduce_only BOOLEAN DEFAULT 0,
-		close_position BOOLEAN DEFAULT 0,
-		working_type TEXT,
-		price_protect BOOLEAN DEFAULT 0,
-		order_action TEXT,
-		position_id INTEGER,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		filled_at DATETIME,
-		UNIQUE(exchange_id, exchange_order_id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_orders_trader ON trader_orders(trader_id);
-	CREATE INDEX IF NOT EXISTS idx_orders_symbol ON trader_orders(symbol);
-	CREATE INDEX IF NOT EXISTS idx_orders_status ON trader_orders(status);
-	CREATE INDEX IF NOT EXISTS idx_orders_exchange ON trader_orders(exchange_id, exchange_order_id);
-
-	CREATE TABLE IF NOT EXISTS trader_fills (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		trader_id TEXT NOT NULL,
-		order_id INTEGER,
-		exchange_id TEXT,
-		exchange_trade_id TEXT,
-		symbol TEXT NOT NULL,
-		side TEXT NOT NULL,
-		price REAL NOT NULL,
-		quantity REAL NOT NULL,
-		quote_quantity REAL,
-		commission REAL DEFAULT 0,
-		realized_pnl REAL DEFAULT 0,
-		is_maker BOOLEAN DEFAULT 0,
-		timestamp DATETIME NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(exchange_id, exchange_trade_id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_fills_trader ON trader_fills(trader_id);
-	CREATE INDEX IF NOT EXISTS idx_fills_order ON trader_fills(order_id);
-	CREATE INDEX IF NOT EXISTS idx_fills_symbol ON trader_fills(symbol);
-	`
-	_, err := db.Exec(query)
-	return err
-}
-
 // CreateOrder creates a new order with deduplication
 func (s *OrderStore) CreateOrder(order *TraderOrder) (int64, error) {
 	// Check if exists first
@@ -176,17 +109,13 @@ func (s *OrderStore) CreateOrder(order *TraderOrder) (int64, error) {
 		order_action, position_id
 	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := db.Exec(query,
+	return db.Insert(query,
 		order.TraderID, order.ExchangeID, order.ExchangeType, order.ExchangeOrderID, order.ClientOrderID,
 		order.Symbol, order.Side, order.PositionSide, order.Type, order.TimeInForce,
 		order.Quantity, order.Price, order.StopPrice, order.Status, order.Leverage,
 		order.ReduceOnly, order.ClosePosition, order.WorkingType, order.PriceProtect,
 		order.OrderAction, order.PositionID,
 	)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
 }
 
 // UpdateOrderStatus updates order status and fill info
@@ -216,18 +145,18 @@ func (s *OrderStore) GetOrderByExchangeID(exchangeID, exchangeOrderID string) (*
 		symbol, side, position_side, type, time_in_force,
 		quantity, price, stop_price, status, filled_quantity, avg_fill_price,
 		commission, leverage, reduce_only, close_position, working_type, price_protect,
-		order_action, position_id, created_at, updated_at, COALESCE(filled_at, '')
+		order_action, position_id, created_at, updated_at, filled_at
 	FROM trader_orders
 	WHERE exchange_id = ? AND exchange_order_id = ?
 	`
 	var order TraderOrder
-	var filledAtStr string
+	var filledAt sql.NullTime
 	err := db.QueryRow(query, exchangeID, exchangeOrderID).Scan(
 		&order.ID, &order.TraderID, &order.ExchangeID, &order.ExchangeType, &order.ExchangeOrderID, &order.ClientOrderID,
 		&order.Symbol, &order.Side, &order.PositionSide, &order.Type, &order.TimeInForce,
 		&order.Quantity, &order.Price, &order.StopPrice, &order.Status, &order.FilledQuantity, &order.AvgFillPrice,
 		&order.Commission, &order.Leverage, &order.ReduceOnly, &order.ClosePosition, &order.WorkingType, &order.PriceProtect,
-		&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAtStr,
+		&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -235,8 +164,8 @@ func (s *OrderStore) GetOrderByExchangeID(exchangeID, exchangeOrderID string) (*
 	if err != nil {
 		return nil, err
 	}
-	if filledAtStr != "" {
-		order.FilledAt, _ = time.Parse(time.RFC3339, filledAtStr)
+	if filledAt.Valid {
+		order.FilledAt = filledAt.Time
 	}
 	return &order, nil
 }
@@ -248,7 +177,7 @@ func (s *OrderStore) GetOrders(traderID string, limit int) ([]TraderOrder, error
 		symbol, side, position_side, type, time_in_force,
 		quantity, price, stop_price, status, filled_quantity, avg_fill_price,
 		commission, leverage, reduce_only, close_position, working_type, price_protect,
-		order_action, position_id, created_at, updated_at, COALESCE(filled_at, '')
+		order_action, position_id, created_at, updated_at, filled_at
 	FROM trader_orders
 	WHERE trader_id = ?
 	ORDER BY created_at DESC
@@ -263,19 +192,19 @@ func (s *OrderStore) GetOrders(traderID string, limit int) ([]TraderOrder, error
 	var orders []TraderOrder
 	for rows.Next() {
 		var order TraderOrder
-		var filledAtStr string
+		var filledAt sql.NullTime
 		err := rows.Scan(
 			&order.ID, &order.TraderID, &order.ExchangeID, &order.ExchangeType, &order.ExchangeOrderID, &order.ClientOrderID,
 			&order.Symbol, &order.Side, &order.PositionSide, &order.Type, &order.TimeInForce,
 			&order.Quantity, &order.Price, &order.StopPrice, &order.Status, &order.FilledQuantity, &order.AvgFillPrice,
 			&order.Commission, &order.Leverage, &order.ReduceOnly, &order.ClosePosition, &order.WorkingType, &order.PriceProtect,
-			&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAtStr,
+			&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		if filledAtStr != "" {
-			order.FilledAt, _ = time.Parse(time.RFC3339, filledAtStr)
+		if filledAt.Valid {
+			order.FilledAt = filledAt.Time
 		}
 		orders = append(orders, order)
 	}
@@ -306,15 +235,11 @@ func (s *OrderStore) CreateFill(fill *TraderFill) (int64, error) {
 		commission, realized_pnl, is_maker, timestamp
 	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := db.Exec(query,
+	return db.Insert(query,
 		fill.TraderID, fill.OrderID, fill.ExchangeID, fill.ExchangeTradeID,
 		fill.Symbol, fill.Side, fill.Price, fill.Quantity, fill.QuoteQuantity,
 		fill.Commission, fill.RealizedPnL, fill.IsMaker, fill.Timestamp,
 	)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
 }
 
 // GetFills gets fills for a trader
@@ -382,7 +307,7 @@ func (s *OrderStore) GetPendingOrders(traderID string) ([]TraderOrder, error) {
 		symbol, side, position_side, type, time_in_force,
 		quantity, price, stop_price, status, filled_quantity, avg_fill_price,
 		commission, leverage, reduce_only, close_position, working_type, price_protect,
-		order_action, position_id, created_at, updated_at, COALESCE(filled_at, '')
+		order_action, position_id, created_at, updated_at, filled_at
 	FROM trader_orders
 	WHERE trader_id = ? AND status IN (?, ?)
 	ORDER BY created_at DESC
@@ -396,19 +321,19 @@ func (s *OrderStore) GetPendingOrders(traderID string) ([]TraderOrder, error) {
 	var orders []TraderOrder
 	for rows.Next() {
 		var order TraderOrder
-		var filledAtStr string
+		var filledAt sql.NullTime
 		err := rows.Scan(
 			&order.ID, &order.TraderID, &order.ExchangeID, &order.ExchangeType, &order.ExchangeOrderID, &order.ClientOrderID,
 			&order.Symbol, &order.Side, &order.PositionSide, &order.Type, &order.TimeInForce,
 			&order.Quantity, &order.Price, &order.StopPrice, &order.Status, &order.FilledQuantity, &order.AvgFillPrice,
 			&order.Commission, &order.Leverage, &order.ReduceOnly, &order.ClosePosition, &order.WorkingType, &order.PriceProtect,
-			&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAtStr,
+			&order.OrderAction, &order.PositionID, &order.CreatedAt, &order.UpdatedAt, &filledAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		if filledAtStr != "" {
-			order.FilledAt, _ = time.Parse(time.RFC3339, filledAtStr)
+		if filledAt.Valid {
+			order.FilledAt = filledAt.Time
 		}
 		orders = append(orders, order)
 	}
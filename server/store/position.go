@@ -37,28 +37,41 @@ type TraderPosition struct {
 	EntryTime          time.Time `json:"entry_time"`
 	ExitTime           time.Time `json:"exit_time"`
 	RealizedPnL        float64   `json:"realized_pnl"`
-	Fee                float64   `json:"fee"`
+	Fee                float64   `json:"fee"`           // Commission of entries and exits
+	EntryFee           float64   `json:"entry_fee"`     // Part of Fee paid on entries and scale-ins
+	SlippageCost       float64   `json:"slippage_cost"` // USDT lost filling away from the decision-time price, negative if it gained
 	Leverage           int       `json:"leverage"`
 	Status             string    `json:"status"` // OPEN, CLOSED
 	CloseReason        string    `json:"close_reason"`
-	Source             string    `json:"source"` // system, manual, sync
+	Source             string    `json:"source"`        // system, manual, sync
+	PnLEstimated       bool      `json:"pnl_estimated"` // P&L or fees are local estimates, not exchange fills
+	Variant            string    `json:"variant,omitempty"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
 
 // TraderStats represents trading performance metrics
 type TraderStats struct {
-	TotalTrades    int     `json:"total_trades"`
-	WinTrades      int     `json:"win_trades"`
-	LossTrades     int     `json:"loss_trades"`
-	WinRate        float64 `json:"win_rate"`
-	ProfitFactor   float64 `json:"profit_factor"`
-	SharpeRatio    float64 `json:"sharpe_ratio"`
-	TotalPnL       float64 `json:"total_pnl"`
-	TotalFees      float64 `json:"total_fees"`
-	AvgWin         float64 `json:"avg_win"`
-	AvgLoss        float64 `json:"avg_loss"`
-	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	TotalTrades       int     `json:"total_trades"`
+	WinTrades         int     `json:"win_trades"`
+	LossTrades        int     `json:"loss_trades"`
+	WinRate           float64 `json:"win_rate"`
+	ProfitFactor      float64 `json:"profit_factor"`
+	SharpeRatio       float64 `json:"sharpe_ratio"`
+	TotalPnL          float64 `json:"total_pnl"`
+	TotalFees         float64 `json:"total_fees"`
+	EntryFees         float64 `json:"entry_fees"`
+	ExitFees          float64 `json:"exit_fees"`
+	TotalSlippageCost float64 `json:"total_slippage_cost"`
+	AvgSlippageBps    float64 `json:"avg_slippage_bps"` // Slippage cost over the notional the engine traded
+	AvgWin            float64 `json:"avg_win"`
+	AvgLoss           float64 `json:"avg_loss"`
+	MaxDrawdownPct    float64 `json:"max_drawdown_pct"`
+
+	// How the engine's entry orders filled
+	EntryFills             int     `json:"entry_fills"`               // Engine entries, open or closed, whose fills were recorded
+	MakerFillPct           float64 `json:"maker_fill_pct"`            // Average share of those entries filled as maker, the rest took liquidity
+	AvgEntryImprovementBps float64 `json:"avg_entry_improvement_bps"` // Average entry fill better than the decision-time price, negative when worse
 }
 
 // SymbolStats represents per-symbol performance
@@ -112,62 +125,22 @@ func NewPositionStore() *PositionStore {
 	return &PositionStore{}
 }
 
-// InitTables creates the position tables
-func (s *PositionStore) InitTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS trader_positions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		trader_id TEXT NOT NULL,
-		exchange_id TEXT,
-		exchange_type TEXT,
-		exchange_position_id TEXT,
-		symbol TEXT NOT NULL,
-		side TEXT NOT NULL,
-		entry_quantity REAL NOT NULL,
-		quantity REAL NOT NULL,
-		entry_price REAL NOT NULL,
-		exit_price REAL DEFAULT 0,
-		entry_order_id TEXT,
-		exit_order_id TEXT,
-		entry_time DATETIME NOT NULL,
-		exit_time DATETIME,
-		realized_pnl REAL DEFAULT 0,
-		fee REAL DEFAULT 0,
-		leverage INTEGER DEFAULT 1,
-		status TEXT NOT NULL DEFAULT 'OPEN',
-		close_reason TEXT,
-		source TEXT DEFAULT 'system',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_positions_trader ON trader_positions(trader_id);
-	CREATE INDEX IF NOT EXISTS idx_positions_symbol ON trader_positions(symbol);
-	CREATE INDEX IF NOT EXISTS idx_positions_status ON trader_positions(status);
-	CREATE INDEX IF NOT EXISTS idx_positions_exchange ON trader_positions(exchange_id, exchange_position_id);
-	`
-	_, err := db.Exec(query)
-	return err
-}
-
 // Create creates a new position
 func (s *PositionStore) Create(pos *TraderPosition) (int64, error) {
 	query := `
 	INSERT INTO trader_positions (
 		trader_id, exchange_id, exchange_type, exchange_position_id,
 		symbol, side, entry_quantity, quantity, entry_price,
-		entry_order_id, entry_time, leverage, status, source
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		entry_order_id, entry_time, leverage, status, source, variant,
+		fee, entry_fee, slippage_cost
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := db.Exec(query,
+	return db.Insert(query,
 		pos.TraderID, pos.ExchangeID, pos.ExchangeType, pos.ExchangePositionID,
 		pos.Symbol, pos.Side, pos.EntryQuantity, pos.Quantity, pos.EntryPrice,
-		pos.EntryOrderID, pos.EntryTime, pos.Leverage, PositionStatusOpen, pos.Source,
+		pos.EntryOrderID, pos.EntryTime, pos.Leverage, PositionStatusOpen, pos.Source, pos.Variant,
+		pos.Fee, pos.EntryFee, pos.SlippageCost,
 	)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
 }
 
 // GetOpenPositions returns all open positions for a trader
@@ -175,8 +148,8 @@ func (s *PositionStore) GetOpenPositions(traderID string) ([]TraderPosition, err
 	query := `
 	SELECT id, trader_id, exchange_id, exchange_type, exchange_position_id,
 		symbol, side, entry_quantity, quantity, entry_price, exit_price,
-		entry_order_id, exit_order_id, entry_time, COALESCE(exit_time, ''),
-		realized_pnl, fee, leverage, status, close_reason, source, created_at, updated_at
+		COALESCE(entry_order_id, ''), COALESCE(exit_order_id, ''), entry_time, exit_time,
+		realized_pnl, fee, entry_fee, slippage_cost, leverage, status, COALESCE(close_reason, ''), source, pnl_estimated, COALESCE(variant, ''), created_at, updated_at
 	FROM trader_positions
 	WHERE trader_id = ? AND status = ?
 	ORDER BY entry_time DESC
@@ -195,8 +168,8 @@ func (s *PositionStore) GetClosedPositions(traderID string, limit int) ([]Trader
 	query := `
 	SELECT id, trader_id, exchange_id, exchange_type, exchange_position_id,
 		symbol, side, entry_quantity, quantity, entry_price, exit_price,
-		entry_order_id, exit_order_id, entry_time, COALESCE(exit_time, ''),
-		realized_pnl, fee, leverage, status, close_reason, source, created_at, updated_at
+		COALESCE(entry_order_id, ''), COALESCE(exit_order_id, ''), entry_time, exit_time,
+		realized_pnl, fee, entry_fee, slippage_cost, leverage, status, COALESCE(close_reason, ''), source, pnl_estimated, COALESCE(variant, ''), created_at, updated_at
 	FROM trader_positions
 	WHERE trader_id = ? AND status = ?
 	ORDER BY exit_time DESC
@@ -211,24 +184,44 @@ func (s *PositionStore) GetClosedPositions(traderID string, limit int) ([]Trader
 	return s.scanPositions(rows)
 }
 
+// GetClosedBetween returns the positions a trader closed in [start, end), oldest first
+func (s *PositionStore) GetClosedBetween(traderID string, start, end time.Time) ([]TraderPosition, error) {
+	query := `
+	SELECT id, trader_id, exchange_id, exchange_type, exchange_position_id,
+		symbol, side, entry_quantity, quantity, entry_price, exit_price,
+		COALESCE(entry_order_id, ''), COALESCE(exit_order_id, ''), entry_time, exit_time,
+		realized_pnl, fee, entry_fee, slippage_cost, leverage, status, COALESCE(close_reason, ''), source, pnl_estimated, COALESCE(variant, ''), created_at, updated_at
+	FROM trader_positions
+	WHERE trader_id = ? AND status = ? AND exit_time >= ? AND exit_time < ?
+	ORDER BY exit_time ASC
+	`
+	rows, err := db.Query(query, traderID, PositionStatusClosed, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanPositions(rows)
+}
+
 // scanPositions scans rows into positions
 func (s *PositionStore) scanPositions(rows *sql.Rows) ([]TraderPosition, error) {
 	var positions []TraderPosition
 	for rows.Next() {
 		var pos TraderPosition
-		var exitTimeStr string
+		var exitTime sql.NullTime
 		err := rows.Scan(
 			&pos.ID, &pos.TraderID, &pos.ExchangeID, &pos.ExchangeType, &pos.ExchangePositionID,
 			&pos.Symbol, &pos.Side, &pos.EntryQuantity, &pos.Quantity, &pos.EntryPrice, &pos.ExitPrice,
-			&pos.EntryOrderID, &pos.ExitOrderID, &pos.EntryTime, &exitTimeStr,
-			&pos.RealizedPnL, &pos.Fee, &pos.Leverage, &pos.Status, &pos.CloseReason, &pos.Source,
+			&pos.EntryOrderID, &pos.ExitOrderID, &pos.EntryTime, &exitTime,
+			&pos.RealizedPnL, &pos.Fee, &pos.EntryFee, &pos.SlippageCost, &pos.Leverage, &pos.Status, &pos.CloseReason, &pos.Source, &pos.PnLEstimated, &pos.Variant,
 			&pos.CreatedAt, &pos.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		if exitTimeStr != "" {
-			pos.ExitTime, _ = time.Parse(time.RFC3339, exitTimeStr)
+		if exitTime.Valid {
+			pos.ExitTime = exitTime.Time
 		}
 		positions = append(positions, pos)
 	}
@@ -237,9 +230,15 @@ func (s *PositionStore) scanPositions(rows *sql.Rows) ([]TraderPosition, error)
 
 // UpdatePositionQuantityAndPrice handles scale-in with weighted average
 func (s *PositionStore) UpdatePositionQuantityAndPrice(id int64, addQty, addPrice float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Get current position
 	var currentQty, currentPrice float64
-	err := db.QueryRow("SELECT quantity, entry_price FROM trader_positions WHERE id = ?", id).
+	err = tx.QueryRow("SELECT quantity, entry_price FROM trader_positions WHERE id = ?", id).
 		Scan(&currentQty, &currentPrice)
 	if err != nil {
 		return err
@@ -255,15 +254,24 @@ func (s *PositionStore) UpdatePositionQuantityAndPrice(id int64, addQty, addPric
 	SET quantity = ?, entry_quantity = ?, entry_price = ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?
 	`
-	_, err = db.Exec(query, newQty, newEntryQty, newPrice, id)
-	return err
+	if _, err := tx.Exec(query, newQty, newEntryQty, newPrice, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// ReducePositionQuantity handles partial close with weighted exit
-func (s *PositionStore) ReducePositionQuantity(id int64, reduceQty, exitPrice, fee, pnl float64) error {
+// ReducePositionQuantity handles partial close with weighted exit.
+// estimated marks the row when pnl and fee didn't come from exchange fills.
+func (s *PositionStore) ReducePositionQuantity(id int64, reduceQty, exitPrice, fee, pnl float64, estimated bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Get current position
 	var currentQty, currentExitPrice, currentFee, currentPnL, entryQty float64
-	err := db.QueryRow(`
+	err = tx.QueryRow(`
 		SELECT quantity, exit_price, fee, realized_pnl, entry_quantity
 		FROM trader_positions WHERE id = ?
 	`, id).Scan(&currentQty, &currentExitPrice, &currentFee, &currentPnL, &entryQty)
@@ -288,24 +296,42 @@ func (s *PositionStore) ReducePositionQuantity(id int64, reduceQty, exitPrice, f
 
 	query := `
 	UPDATE trader_positions
-	SET quantity = ?, exit_price = ?, fee = ?, realized_pnl = ?, updated_at = CURRENT_TIMESTAMP
+	SET quantity = ?, exit_price = ?, fee = ?, realized_pnl = ?,
+		pnl_estimated = pnl_estimated OR ?, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?
 	`
-	_, err = db.Exec(query, newQty, newExitPrice, newFee, newPnL, id)
-	return err
+	if _, err := tx.Exec(query, newQty, newExitPrice, newFee, newPnL, estimated, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// ClosePosition marks a position as closed
-func (s *PositionStore) ClosePosition(id int64, exitPrice, fee, pnl float64, reason string) error {
+// ClosePosition marks a position as closed.
+// estimated marks the row when pnl and fee didn't come from exchange fills.
+func (s *PositionStore) ClosePosition(id int64, exitPrice, fee, pnl float64, reason string, estimated bool) error {
 	// Restore quantity to entry_quantity for historical display
 	query := `
 	UPDATE trader_positions
 	SET status = ?, exit_price = ?, exit_time = ?, fee = fee + ?,
 		realized_pnl = realized_pnl + ?, close_reason = ?,
+		pnl_estimated = pnl_estimated OR ?,
 		quantity = entry_quantity, updated_at = CURRENT_TIMESTAMP
 	WHERE id = ?
 	`
-	_, err := db.Exec(query, PositionStatusClosed, exitPrice, time.Now(), fee, pnl, reason, id)
+	_, err := db.Exec(query, PositionStatusClosed, exitPrice, time.Now(), fee, pnl, reason, estimated, id)
+	return err
+}
+
+// AddExecutionCosts adds a scale-in's commission and a fill's slippage cost
+// to a position
+func (s *PositionStore) AddExecutionCosts(id int64, entryFee, slippageCost float64) error {
+	query := `
+	UPDATE trader_positions
+	SET fee = fee + ?, entry_fee = entry_fee + ?, slippage_cost = slippage_cost + ?,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?
+	`
+	_, err := db.Exec(query, entryFee, entryFee, slippageCost, id)
 	return err
 }
 
@@ -316,18 +342,27 @@ func (s *PositionStore) GetFullStats(traderID string) (*TraderStats, error) {
 		return nil, err
 	}
 
+	stats := &TraderStats{}
+	if err := s.addEntryFillStats(traderID, stats); err != nil {
+		return nil, err
+	}
 	if len(positions) == 0 {
-		return &TraderStats{}, nil
+		return stats, nil
 	}
 
-	stats := &TraderStats{}
-	var totalWin, totalLoss float64
+	var totalWin, totalLoss, tradedNotional float64
 	var pnls []float64
 
 	for _, pos := range positions {
 		stats.TotalTrades++
 		stats.TotalPnL += pos.RealizedPnL
 		stats.TotalFees += pos.Fee
+		stats.EntryFees += pos.EntryFee
+		stats.TotalSlippageCost += pos.SlippageCost
+		// Slippage is only measured on the engine's own orders
+		if pos.Source == PositionSourceSystem {
+			tradedNotional += pos.EntryQuantity * (pos.EntryPrice + pos.ExitPrice)
+		}
 		pnls = append(pnls, pos.RealizedPnL)
 
 		if pos.RealizedPnL > 0 {
@@ -342,6 +377,10 @@ func (s *PositionStore) GetFullStats(traderID string) (*TraderStats, error) {
 	if stats.TotalTrades > 0 {
 		stats.WinRate = float64(stats.WinTrades) / float64(stats.TotalTrades) * 100
 	}
+	stats.ExitFees = stats.TotalFees - stats.EntryFees
+	if tradedNotional > 0 {
+		stats.AvgSlippageBps = stats.TotalSlippageCost / tradedNotional * 10000
+	}
 	if totalLoss > 0 {
 		stats.ProfitFactor = totalWin / totalLoss
 	}
@@ -361,6 +400,39 @@ func (s *PositionStore) GetFullStats(traderID string) (*TraderStats, error) {
 	return stats, nil
 }
 
+// GetRealizedPnLSince returns the realized P&L and count of positions closed since the given time
+func (s *PositionStore) GetRealizedPnLSince(traderID string, since time.Time) (float64, int, error) {
+	var pnl float64
+	var count int
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(realized_pnl), 0), COUNT(*)
+		FROM trader_positions
+		WHERE trader_id = ? AND status = ? AND exit_time >= ?
+	`, traderID, PositionStatusClosed, since).Scan(&pnl, &count)
+	return pnl, count, err
+}
+
+// RealizedTotals is what a trader has banked from its closed positions
+type RealizedTotals struct {
+	RealizedPnLToday float64 `json:"realized_pnl_today"` // Closed since the start of the trading day
+	RealizedPnLTotal float64 `json:"realized_pnl_total"` // Closed since the trader was created
+	FeesTotal        float64 `json:"fees_total"`         // Entry and exit fees of every closed position
+}
+
+// GetRealizedTotals sums the realized P&L of a trader's closed positions, all
+// of them and those closed since dayStart, and their fees. Partial closes count
+// once the position closes.
+func (s *PositionStore) GetRealizedTotals(traderID string, dayStart time.Time) (RealizedTotals, error) {
+	var totals RealizedTotals
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN exit_time >= ? THEN realized_pnl ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0), COALESCE(SUM(fee), 0)
+		FROM trader_positions
+		WHERE trader_id = ? AND status = ?
+	`, dayStart, traderID, PositionStatusClosed).Scan(&totals.RealizedPnLToday, &totals.RealizedPnLTotal, &totals.FeesTotal)
+	return totals, err
+}
+
 // GetHistorySummary returns comprehensive trading history for AI context
 func (s *PositionStore) GetHistorySummary(traderID string) (*HistorySummary, error) {
 	stats, err := s.GetFullStats(traderID)
@@ -411,7 +483,7 @@ func (s *PositionStore) getSymbolStats(traderID string) ([]SymbolStats, []Symbol
 		COUNT(*) as total,
 		SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END) as wins,
 		SUM(realized_pnl) as total_pnl,
-		AVG((julianday(exit_time) - julianday(entry_time)) * 24 * 60) as avg_hold
+		AVG(` + hoursBetween(db.dialect, "entry_time", "exit_time") + ` * 60) as avg_hold
 	FROM trader_positions
 	WHERE trader_id = ? AND status = 'CLOSED'
 	GROUP BY symbol
@@ -499,12 +571,13 @@ func (s *PositionStore) getDirectionStats(traderID string) (DirectionStats, Dire
 }
 
 func (s *PositionStore) getHoldingTimeStats(traderID string) ([]HoldingTimeStats, float64, error) {
+	held := hoursBetween(db.dialect, "entry_time", "exit_time")
 	query := `
 	SELECT
 		CASE
-			WHEN (julianday(exit_time) - julianday(entry_time)) * 24 < 1 THEN '<1h'
-			WHEN (julianday(exit_time) - julianday(entry_time)) * 24 < 4 THEN '1-4h'
-			WHEN (julianday(exit_time) - julianday(entry_time)) * 24 < 24 THEN '4-24h'
+			WHEN ` + held + ` < 1 THEN '<1h'
+			WHEN ` + held + ` < 4 THEN '1-4h'
+			WHEN ` + held + ` < 24 THEN '4-24h'
 			ELSE '>24h'
 		END as bucket,
 		COUNT(*) as total,
@@ -537,13 +610,40 @@ func (s *PositionStore) getHoldingTimeStats(traderID string) ([]HoldingTimeStats
 	// Calculate average hold time
 	var avgHold float64
 	err = db.QueryRow(`
-		SELECT AVG((julianday(exit_time) - julianday(entry_time)) * 24 * 60)
+		SELECT AVG(`+held+` * 60)
 		FROM trader_positions WHERE trader_id = ? AND status = 'CLOSED'
 	`, traderID).Scan(&avgHold)
 
 	return stats, avgHold, err
 }
 
+// LosingStreak returns how many of the trader's latest closes after since were
+// losses in a row. Like getStreaks, a close without profit counts as a loss.
+func (s *PositionStore) LosingStreak(traderID string, since time.Time) (int, error) {
+	rows, err := db.Query(`
+	SELECT realized_pnl FROM trader_positions
+	WHERE trader_id = ? AND status = ? AND exit_time > ?
+	ORDER BY exit_time DESC
+	`, traderID, PositionStatusClosed, since)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	streak := 0
+	for rows.Next() {
+		var pnl float64
+		if err := rows.Scan(&pnl); err != nil {
+			return 0, err
+		}
+		if pnl > 0 {
+			break
+		}
+		streak++
+	}
+	return streak, rows.Err()
+}
+
 func (s *PositionStore) getStreaks(traderID string) (int, int, int, error) {
 	positions, err := s.GetClosedPositions(traderID, 100)
 	if err != nil {
@@ -653,23 +753,51 @@ func calculateMaxDrawdown(pnls []float64) float64 {
 	return maxDrawdown
 }
 
+// Get returns one of a trader's positions, open or closed
+func (s *PositionStore) Get(traderID string, id int64) (*TraderPosition, error) {
+	query := `
+	SELECT id, trader_id, exchange_id, exchange_type, exchange_position_id,
+		symbol, side, entry_quantity, quantity, entry_price, exit_price,
+		COALESCE(entry_order_id, ''), COALESCE(exit_order_id, ''), entry_time, exit_time,
+		realized_pnl, fee, entry_fee, slippage_cost, leverage, status, COALESCE(close_reason, ''), source, pnl_estimated, COALESCE(variant, ''), created_at, updated_at
+	FROM trader_positions
+	WHERE trader_id = ? AND id = ?
+	`
+	var pos TraderPosition
+	var exitTime sql.NullTime
+	err := db.QueryRow(query, traderID, id).Scan(
+		&pos.ID, &pos.TraderID, &pos.ExchangeID, &pos.ExchangeType, &pos.ExchangePositionID,
+		&pos.Symbol, &pos.Side, &pos.EntryQuantity, &pos.Quantity, &pos.EntryPrice, &pos.ExitPrice,
+		&pos.EntryOrderID, &pos.ExitOrderID, &pos.EntryTime, &exitTime,
+		&pos.RealizedPnL, &pos.Fee, &pos.EntryFee, &pos.SlippageCost, &pos.Leverage, &pos.Status, &pos.CloseReason, &pos.Source, &pos.PnLEstimated, &pos.Variant,
+		&pos.CreatedAt, &pos.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if exitTime.Valid {
+		pos.ExitTime = exitTime.Time
+	}
+	return &pos, nil
+}
+
 // GetOpenPositionBySymbol returns open position for a symbol
 func (s *PositionStore) GetOpenPositionBySymbol(traderID, symbol, side string) (*TraderPosition, error) {
 	query := `
 	SELECT id, trader_id, exchange_id, exchange_type, exchange_position_id,
 		symbol, side, entry_quantity, quantity, entry_price, exit_price,
-		entry_order_id, exit_order_id, entry_time, COALESCE(exit_time, ''),
-		realized_pnl, fee, leverage, status, close_reason, source, created_at, updated_at
+		COALESCE(entry_order_id, ''), COALESCE(exit_order_id, ''), entry_time, exit_time,
+		realized_pnl, fee, entry_fee, slippage_cost, leverage, status, COALESCE(close_reason, ''), source, pnl_estimated, COALESCE(variant, ''), created_at, updated_at
 	FROM trader_positions
 	WHERE trader_id = ? AND symbol = ? AND side = ? AND status = ?
 	`
 	var pos TraderPosition
-	var exitTimeStr string
+	var exitTime sql.NullTime
 	err := db.QueryRow(query, traderID, symbol, side, PositionStatusOpen).Scan(
 		&pos.ID, &pos.TraderID, &pos.ExchangeID, &pos.ExchangeType, &pos.ExchangePositionID,
 		&pos.Symbol, &pos.Side, &pos.EntryQuantity, &pos.Quantity, &pos.EntryPrice, &pos.ExitPrice,
-		&pos.EntryOrderID, &pos.ExitOrderID, &pos.EntryTime, &exitTimeStr,
-		&pos.RealizedPnL, &pos.Fee, &pos.Leverage, &pos.Status, &pos.CloseReason, &pos.Source,
+		&pos.EntryOrderID, &pos.ExitOrderID, &pos.EntryTime, &exitTime,
+		&pos.RealizedPnL, &pos.Fee, &pos.EntryFee, &pos.SlippageCost, &pos.Leverage, &pos.Status, &pos.CloseReason, &pos.Source, &pos.PnLEstimated, &pos.Variant,
 		&pos.CreatedAt, &pos.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -678,8 +806,8 @@ func (s *PositionStore) GetOpenPositionBySymbol(traderID, symbol, side string) (
 	if err != nil {
 		return nil, err
 	}
-	if exitTimeStr != "" {
-		pos.ExitTime, _ = time.Parse(time.RFC3339, exitTimeStr)
+	if exitTime.Valid {
+		pos.ExitTime = exitTime.Time
 	}
 	return &pos, nil
 }
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"time"
+)
+
+// Position event types
+const (
+	PositionEventOpened          = "opened"
+	PositionEventScaledIn        = "scaled_in"
+	PositionEventPartialClose    = "partial_close"
+	PositionEventClosed          = "closed"
+	PositionEventStopLossMoved   = "stop_loss_moved"
+	PositionEventTakeProfitMoved = "take_profit_moved"
+
+	// Risk rules starting to watch a position
+	PositionEventTrailingStopActivated = "trailing_stop_activated"
+	PositionEventDrawdownArmed         = "drawdown_protection_armed"
+)
+
+// Position event sources
+const (
+	PositionEventSourceAI       = "ai"
+	PositionEventSourceAPI      = "api"
+	PositionEventSourceRisk     = "risk"     // Rule-based protections and risk controls
+	PositionEventSourceExchange = "exchange" // Found on the exchange by position sync
+)
+
+// PositionEvent records a step in a position's life: its fills, stop-loss and
+// take-profit moves, and the risk rules taking it on
+type PositionEvent struct {
+	ID          int64     `json:"id"`
+	TraderID    string    `json:"trader_id"`
+	PositionID  int64     `json:"position_id"` // trader_positions row, 0 if it wasn't found
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"` // long or short
+	Timestamp   time.Time `json:"timestamp"`
+	Type        string    `json:"type"`
+	OldPrice    float64   `json:"old_price"`              // SL/TP moves: 0 when there was no order
+	NewPrice    float64   `json:"new_price"`              // SL/TP moves: 0 when the order was removed; fills: the fill price; risk rules: the mark
+	Quantity    float64   `json:"quantity,omitempty"`     // Fills only
+	RealizedPnL float64   `json:"realized_pnl,omitempty"` // Partial and full closes
+	Fee         float64   `json:"fee,omitempty"`          // Fills only
+	Source      string    `json:"source"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// PositionEventStore handles position event persistence
+type PositionEventStore struct{}
+
+// NewPositionEventStore creates a new position event store
+func NewPositionEventStore() *PositionEventStore {
+	return &PositionEventStore{}
+}
+
+// Create records a position event
+func (s *PositionEventStore) Create(event *PositionEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	id, err := db.Insert(`
+		INSERT INTO position_events (trader_id, position_id, symbol, side, timestamp, type, old_price, new_price,
+			quantity, realized_pnl, fee, source, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.TraderID, event.PositionID, event.Symbol, event.Side, event.Timestamp, event.Type,
+		event.OldPrice, event.NewPrice, event.Quantity, event.RealizedPnL, event.Fee, event.Source, event.Reason)
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	return nil
+}
+
+// ListByPosition returns the events of one position, oldest first
+func (s *PositionEventStore) ListByPosition(traderID string, positionID int64) ([]*PositionEvent, error) {
+	return s.query(`
+		SELECT id, trader_id, position_id, symbol, COALESCE(side, ''), timestamp, type,
+			old_price, new_price, COALESCE(quantity, 0), COALESCE(realized_pnl, 0), COALESCE(fee, 0),
+			COALESCE(source, ''), COALESCE(reason, '')
+		FROM position_events
+		WHERE trader_id = ? AND position_id = ?
+		ORDER BY timestamp ASC, id ASC
+	`, traderID, positionID)
+}
+
+// ListBySymbol returns a trader's most recent events on symbol, newest first
+func (s *PositionEventStore) ListBySymbol(traderID, symbol string, limit int) ([]*PositionEvent, error) {
+	return s.query(`
+		SELECT id, trader_id, position_id, symbol, COALESCE(side, ''), timestamp, type,
+			old_price, new_price, COALESCE(quantity, 0), COALESCE(realized_pnl, 0), COALESCE(fee, 0),
+			COALESCE(source, ''), COALESCE(reason, '')
+		FROM position_events
+		WHERE trader_id = ? AND symbol = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`, traderID, symbol, limit)
+}
+
+func (s *PositionEventStore) query(query string, args ...interface{}) ([]*PositionEvent, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*PositionEvent
+	for rows.Next() {
+		var e PositionEvent
+		if err := rows.Scan(&e.ID, &e.TraderID, &e.PositionID, &e.Symbol, &e.Side, &e.Timestamp, &e.Type,
+			&e.OldPrice, &e.NewPrice, &e.Quantity, &e.RealizedPnL, &e.Fee, &e.Source, &e.Reason); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,9 @@
+//go:build postgres
+
+package store
+
+import (
+	_ "github.com/lib/pq"
+)
+
+const postgresAvailable = true
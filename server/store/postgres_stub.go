@@ -0,0 +1,6 @@
+//go:build !postgres
+
+package store
+
+// postgresAvailable reports whether the PostgreSQL driver is compiled in
+const postgresAvailable = false
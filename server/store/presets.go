@@ -0,0 +1,173 @@
+package store
+
+// Strategy preset names
+const (
+	PresetConservativeSwing = "conservative_swing"
+	PresetScalper           = "scalper"
+	PresetTurboDegen        = "turbo_degen"
+)
+
+// Preset versions, bumped whenever a preset's config changes so strategies
+// created from it can tell which one they got
+const (
+	presetConservativeSwingVersion = 1
+	presetScalperVersion           = 1
+	presetTurboDegenVersion        = 1
+)
+
+// StrategyPreset is a named, complete strategy config for a risk profile,
+// built on the default config
+type StrategyPreset struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title"`
+	Version     int            `json:"version"`
+	Description string         `json:"description"`
+	Differences []string       `json:"differences"` // What it changes from the default config
+	Config      StrategyConfig `json:"config"`
+}
+
+// StrategyPresets returns every preset, safest first. Each call builds them
+// anew, so callers may change the configs they get.
+func StrategyPresets() []StrategyPreset {
+	return []StrategyPreset{conservativeSwingPreset(), scalperPreset(), turboDegenPreset()}
+}
+
+// StrategyPresetByName returns the preset called name
+func StrategyPresetByName(name string) (StrategyPreset, bool) {
+	for _, p := range StrategyPresets() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return StrategyPreset{}, false
+}
+
+func conservativeSwingPreset() StrategyPreset {
+	cfg := DefaultStrategyConfig()
+	cfg.Indicators.PrimaryTimeframe = "1h"
+	cfg.Indicators.KlineCount = 150
+	cfg.Indicators.EnableBOLL = true
+	cfg.Indicators.ConfirmationTimeframe = "4h"
+	cfg.TradingInterval = 30
+
+	rc := &cfg.RiskControl
+	rc.MaxPositions = 2
+	rc.BTCETHMaxLeverage = 3
+	rc.AltcoinMaxLeverage = 2
+	rc.SizingMode = "atr_risk"
+	rc.RiskPerTradePct = 0.5
+	rc.MinConfidence = 90
+	rc.MaxDailyLossPct = 5
+	rc.StopTradingMins = 240
+	rc.MinHoldBeforeClose = 60
+	rc.EnableTrailingStop = true
+	rc.TrailingStopActivatePct = 2
+	rc.TrailingStopDistancePct = 1
+	rc.CooldownMinsAfterClose = 60
+	rc.CooldownMinsAfterStopLoss = 240
+	rc.MaxTotalDrawdownPct = 20
+	rc.MaxConsecutiveLosses = 4
+	rc.ExposureLimits = ExposureLimits{MaxNetLongPct: 300, MaxNetShortPct: 300}
+
+	return StrategyPreset{
+		Name:        PresetConservativeSwing,
+		Title:       "Conservative swing",
+		Version:     presetConservativeSwingVersion,
+		Description: "Few, high-conviction trades on hourly charts with low leverage and tight loss limits.",
+		Differences: []string{
+			"1h klines (150) with 4h confirmation and Bollinger Bands, a cycle every 30 minutes",
+			"At most 2 positions, leverage 3x BTC/ETH and 2x altcoins",
+			"ATR risk sizing at 0.5% of equity a trade, 90% minimum confidence",
+			"5% daily loss limit with a 4 hour pause, circuit breaker at 20% drawdown or 4 losses in a row",
+			"Trailing stop from 2% profit, 1 hour minimum hold, longer re-entry cooldowns",
+			"Net long and net short exposure capped at 3x equity",
+		},
+		Config: cfg,
+	}
+}
+
+func scalperPreset() StrategyPreset {
+	cfg := DefaultStrategyConfig()
+	cfg.Indicators.PrimaryTimeframe = "1m"
+	cfg.Indicators.ConfirmationTimeframe = "5m"
+	cfg.TradingInterval = 1
+
+	rc := &cfg.RiskControl
+	rc.BTCETHMaxLeverage = 10
+	rc.AltcoinMaxLeverage = 5
+	rc.MinConfidence = 80
+	rc.MinRiskRewardRatio = 1.5
+	rc.NoiseZoneLowerBound = -0.5
+	rc.NoiseZoneUpperBound = 0.5
+	rc.MinHoldBeforeClose = 2
+	rc.MaxDailyLossPct = 8
+	rc.StopTradingMins = 60
+	rc.EnableTrailingStop = true
+	rc.TrailingStopActivatePct = 0.5
+	rc.TrailingStopDistancePct = 0.25
+	rc.EnableMaxHoldDuration = true
+	rc.MaxHoldDurationMins = 60
+	rc.EnableSmartLossCut = true
+	rc.SmartLossCutMins = 10
+	rc.SmartLossCutPct = -0.5
+	rc.CooldownMinsAfterClose = 5
+	rc.CooldownMinsAfterStopLoss = 20
+	rc.RiskCheckIntervalSecs = 10
+
+	return StrategyPreset{
+		Name:        PresetScalper,
+		Title:       "Scalper",
+		Version:     presetScalperVersion,
+		Description: "Short holds on 1 minute charts, cutting losers and trailing winners quickly.",
+		Differences: []string{
+			"1m klines with 5m confirmation, a cycle every minute",
+			"Leverage 10x BTC/ETH and 5x altcoins",
+			"80% minimum confidence and 1.5:1 minimum reward/risk",
+			"Noise zone narrowed to ±0.5% with a 2 minute minimum hold",
+			"Trailing stop from 0.5% profit, 60 minute max hold, smart loss cut after 10 minutes under -0.5%",
+			"8% daily loss limit, short re-entry cooldowns, risk checks every 10 seconds",
+		},
+		Config: cfg,
+	}
+}
+
+func turboDegenPreset() StrategyPreset {
+	cfg := DefaultStrategyConfig()
+	cfg.TurboMode = true
+	cfg.Indicators.PrimaryTimeframe = "1m"
+	cfg.Indicators.EnableMultiTF = false
+	cfg.TradingInterval = 1
+
+	rc := &cfg.RiskControl
+	rc.MaxPositions = 5
+	rc.BTCETHMaxLeverage = 20
+	rc.AltcoinMaxLeverage = 10
+	rc.MinConfidence = 70
+	rc.MinRiskRewardRatio = 1.5
+	rc.EnableNoiseZoneProtection = false
+	rc.MinHoldBeforeClose = 0
+	rc.MaxDailyLossPct = 25
+	rc.StopTradingMins = 30
+	rc.EnableSmartLossCut = true
+	rc.SmartLossCutMins = 15
+	rc.SmartLossCutPct = -2
+	rc.CooldownMinsAfterClose = 0
+	rc.CooldownMinsAfterStopLoss = 10
+	rc.RiskCheckIntervalSecs = 10
+
+	return StrategyPreset{
+		Name:        PresetTurboDegen,
+		Title:       "Turbo degen",
+		Version:     presetTurboDegenVersion,
+		Description: "Turbo mode momentum chasing at high leverage. Expect large swings both ways.",
+		Differences: []string{
+			"Turbo mode on, 1m klines without multi-timeframe confirmation, a cycle every minute",
+			"Up to 5 positions, leverage 20x BTC/ETH and 10x altcoins",
+			"70% minimum confidence and 1.5:1 minimum reward/risk",
+			"No noise zone protection or minimum hold, no cooldown after a normal close",
+			"Smart loss cut after 15 minutes under -2%",
+			"25% daily loss limit with a 30 minute pause",
+		},
+		Config: cfg,
+	}
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"time"
+)
+
+// Risk event types
+const (
+	RiskEventDailyLossPause       = "daily_loss_pause"
+	RiskEventEmergencyStop        = "emergency_stop"
+	RiskEventConnectivityLost     = "connectivity_lost"
+	RiskEventConnectivityRestored = "connectivity_restored"
+	RiskEventCircuitBreaker       = "circuit_breaker"
+	RiskEventCircuitBreakerAck    = "circuit_breaker_ack"
+	RiskEventStaleDecision        = "stale_decision"
+	RiskEventEntryRecheck         = "entry_recheck"
+	RiskEventDecisionExpired      = "decision_expired"
+)
+
+// RiskEvent records a risk control stepping in on a trader
+type RiskEvent struct {
+	ID        int64     `json:"id"`
+	TraderID  string    `json:"trader_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+}
+
+// RiskEventStore handles risk event persistence
+type RiskEventStore struct{}
+
+// NewRiskEventStore creates a new risk event store
+func NewRiskEventStore() *RiskEventStore {
+	return &RiskEventStore{}
+}
+
+// Create records a risk event
+func (s *RiskEventStore) Create(event *RiskEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	id, err := db.Insert(`
+		INSERT INTO risk_events (trader_id, timestamp, type, message) VALUES (?, ?, ?, ?)
+	`, event.TraderID, event.Timestamp, event.Type, event.Message)
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	return nil
+}
+
+// ListBetween returns a trader's risk events in [start, end), oldest first
+func (s *RiskEventStore) ListBetween(traderID string, start, end time.Time) ([]*RiskEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, trader_id, timestamp, type, COALESCE(message, '')
+		FROM risk_events
+		WHERE trader_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC, id ASC
+	`, traderID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*RiskEvent
+	for rows.Next() {
+		var e RiskEvent
+		if err := rows.Scan(&e.ID, &e.TraderID, &e.Timestamp, &e.Type, &e.Message); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+
+	return events, rows.Err()
+}
@@ -13,19 +13,6 @@ func NewSettingsStore() *SettingsStore {
 	return &SettingsStore{}
 }
 
-// InitTables creates the settings table if it doesn't exist
-func (s *SettingsStore) InitTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS settings (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-	_, err := db.Exec(query)
-	return err
-}
-
 // Get retrieves a setting value by key
 func (s *SettingsStore) Get(key string) (string, error) {
 	var value string
@@ -39,8 +26,9 @@ func (s *SettingsStore) Get(key string) (string, error) {
 // Set saves a setting value
 func (s *SettingsStore) Set(key, value string) error {
 	_, err := db.Exec(`
-		INSERT OR REPLACE INTO settings (key, value, updated_at)
+		INSERT INTO settings (key, value, updated_at)
 		VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
 	`, key, value, time.Now())
 	return err
 }
@@ -80,8 +68,9 @@ func (s *SettingsStore) SetMultiple(settings map[string]string) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO settings (key, value, updated_at)
+		INSERT INTO settings (key, value, updated_at)
 		VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
 	`)
 	if err != nil {
 		return err
@@ -98,6 +87,53 @@ func (s *SettingsStore) SetMultiple(settings map[string]string) error {
 	return tx.Commit()
 }
 
+// Settings keys for the emergency halt
+const (
+	settingHalted   = "emergency_halted"
+	settingHaltedAt = "emergency_halted_at"
+)
+
+// HaltState is the global emergency halt. While halted no trader can be started.
+type HaltState struct {
+	Halted   bool      `json:"halted"`
+	HaltedAt time.Time `json:"halted_at,omitempty"`
+}
+
+// GetHaltState returns the persisted emergency halt state
+func (s *SettingsStore) GetHaltState() (*HaltState, error) {
+	halted, err := s.Get(settingHalted)
+	if err != nil {
+		return nil, err
+	}
+	state := &HaltState{Halted: halted == "true"}
+	if !state.Halted {
+		return state, nil
+	}
+
+	at, err := s.Get(settingHaltedAt)
+	if err != nil {
+		return nil, err
+	}
+	state.HaltedAt, _ = time.Parse(time.RFC3339, at)
+	return state, nil
+}
+
+// SetHalted persists the emergency halt
+func (s *SettingsStore) SetHalted(at time.Time) error {
+	return s.SetMultiple(map[string]string{
+		settingHalted:   "true",
+		settingHaltedAt: at.UTC().Format(time.RFC3339),
+	})
+}
+
+// ClearHalted lifts the emergency halt
+func (s *SettingsStore) ClearHalted() error {
+	if err := s.Delete(settingHaltedAt); err != nil {
+		return err
+	}
+	return s.Delete(settingHalted)
+}
+
 // GlobalSettings represents the app-wide configuration
 type GlobalSettings struct {
 	// OpenRouter AI Configuration
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SmartFindRunsKept is how many Smart Find runs are kept per trader
+const SmartFindRunsKept = 20
+
+// Smart Find triggers
+const (
+	SmartFindTriggerAuto   = "auto"   // Auto-refresh timer
+	SmartFindTriggerManual = "manual" // Refresh requested through the API
+)
+
+// SmartFindCandidate is a symbol shown to the AI in a Smart Find run
+type SmartFindCandidate struct {
+	Symbol         string  `json:"symbol"`
+	QuoteVolume    float64 `json:"quote_volume"`     // 24h volume in USDT
+	PriceChangePct float64 `json:"price_change_pct"` // 24h change, the volatility ranking
+}
+
+// SmartFindRun is one Smart Find run: what the AI was shown, what it picked
+// and which of its picks became the trading universe
+type SmartFindRun struct {
+	ID          int64                `json:"id"`
+	TraderID    string               `json:"trader_id"`
+	Timestamp   time.Time            `json:"timestamp"`
+	Trigger     string               `json:"trigger"`
+	Candidates  []SmartFindCandidate `json:"candidates"`
+	Recommended []string             `json:"recommended"` // Symbols the AI returned
+	Selected    []string             `json:"selected"`    // Tradable recommendations, applied as static coins
+	RawResponse string               `json:"raw_response"`
+	Error       string               `json:"error,omitempty"`
+}
+
+// SmartFindStore handles Smart Find run persistence
+type SmartFindStore struct{}
+
+// NewSmartFindStore creates a new Smart Find store
+func NewSmartFindStore() *SmartFindStore {
+	return &SmartFindStore{}
+}
+
+// Create saves a run and drops the trader's runs beyond SmartFindRunsKept
+func (s *SmartFindStore) Create(run *SmartFindRun) error {
+	if run.Timestamp.IsZero() {
+		run.Timestamp = time.Now()
+	}
+
+	candidates, err := json.Marshal(run.Candidates)
+	if err != nil {
+		return fmt.Errorf("failed to encode candidates: %w", err)
+	}
+	recommended, err := json.Marshal(run.Recommended)
+	if err != nil {
+		return fmt.Errorf("failed to encode recommended symbols: %w", err)
+	}
+	selected, err := json.Marshal(run.Selected)
+	if err != nil {
+		return fmt.Errorf("failed to encode selected symbols: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := tx.Insert(`
+		INSERT INTO smart_find_runs (trader_id, timestamp, trigger_type, candidates, recommended, selected, raw_response, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.TraderID, run.Timestamp, run.Trigger, string(candidates), string(recommended), string(selected),
+		run.RawResponse, run.Error)
+	if err != nil {
+		return err
+	}
+	run.ID = id
+
+	if _, err := tx.Exec(`
+		DELETE FROM smart_find_runs WHERE trader_id = ? AND id NOT IN (
+			SELECT id FROM smart_find_runs WHERE trader_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, run.TraderID, run.TraderID, SmartFindRunsKept); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns a trader's most recent runs, newest first
+func (s *SmartFindStore) List(traderID string, limit int) ([]*SmartFindRun, error) {
+	if limit <= 0 || limit > SmartFindRunsKept {
+		limit = SmartFindRunsKept
+	}
+
+	rows, err := db.Query(`
+		SELECT id, trader_id, timestamp, trigger_type, COALESCE(candidates, ''), COALESCE(recommended, ''),
+			COALESCE(selected, ''), COALESCE(raw_response, ''), COALESCE(error, '')
+		FROM smart_find_runs WHERE trader_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, traderID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*SmartFindRun, 0)
+	for rows.Next() {
+		var run SmartFindRun
+		var candidates, recommended, selected string
+		if err := rows.Scan(&run.ID, &run.TraderID, &run.Timestamp, &run.Trigger, &candidates, &recommended,
+			&selected, &run.RawResponse, &run.Error); err != nil {
+			return nil, err
+		}
+		for _, field := range []struct {
+			text string
+			dest interface{}
+		}{{candidates, &run.Candidates}, {recommended, &run.Recommended}, {selected, &run.Selected}} {
+			if field.text == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(field.text), field.dest); err != nil {
+				return nil, fmt.Errorf("smart find run %d: %w", run.ID, err)
+			}
+		}
+		runs = append(runs, &run)
+	}
+
+	return runs, rows.Err()
+}
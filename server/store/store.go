@@ -1,126 +1,141 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *sql.DB
+var db *DB
 
-func Init(dataDir string) error {
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return fmt.Errorf("failed to create data directory: %w", err)
-	}
+// SQLite allows one writer at a time. WAL lets readers run alongside it and
+// busy_timeout makes other writers wait their turn instead of failing with
+// "database is locked". Transactions take the write lock up front so a read
+// inside one can't deadlock against another writer when it upgrades.
+const (
+	sqliteParams   = "_journal_mode=WAL&_busy_timeout=10000&_foreign_keys=on&_txlock=immediate&_synchronous=NORMAL"
+	maxOpenConns   = 8
+	connMaxIdleFor = 5 * time.Minute
+)
 
-	dbPath := filepath.Join(dataDir, "trading.db")
+// Init opens the database and migrates it. databaseURL selects PostgreSQL
+// (postgres://...); when empty the SQLite file in dataDir is used.
+func Init(dataDir, databaseURL string) error {
 	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	if databaseURL != "" {
+		db, err = openPostgres(databaseURL)
+	} else {
+		db, err = openSQLite(dataDir)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
 
 	if err := db.Ping(); err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	if err := migrate(); err != nil {
+	if db.dialect == DialectSQLite {
+		var journalMode string
+		if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&journalMode); err == nil && journalMode != "wal" {
+			log.Printf("Warning: SQLite journal mode is %s, not WAL; concurrent access may hit lock errors", journalMode)
+		}
+	}
+
+	if err := runMigrations(); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Printf("Database initialized: %s", dbPath)
 	return nil
 }
 
-func GetDB() *sql.DB {
-	return db
-}
+func openSQLite(dataDir string) (*DB, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
 
-func Close() error {
-	if db != nil {
-		return db.Close()
+	dbPath := filepath.Join(dataDir, "trading.db")
+	conn, err := sql.Open("sqlite3", "file:"+dbPath+"?"+sqliteParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return nil
+	conn.SetMaxOpenConns(maxOpenConns)
+	conn.SetMaxIdleConns(maxOpenConns)
+	conn.SetConnMaxIdleTime(connMaxIdleFor)
+
+	log.Printf("Database: SQLite %s", dbPath)
+	return &DB{DB: conn, dialect: DialectSQLite}, nil
 }
 
-func migrate() error {
-	migrations := []string{
-		// Strategies table
-		`CREATE TABLE IF NOT EXISTS strategies (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			description TEXT DEFAULT '',
-			is_active BOOLEAN DEFAULT 0,
-			config TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Traders table
-		`CREATE TABLE IF NOT EXISTS traders (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			strategy_id TEXT,
-			exchange TEXT NOT NULL DEFAULT 'binance',
-			status TEXT DEFAULT 'stopped',
-			initial_balance REAL DEFAULT 0,
-			config TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (strategy_id) REFERENCES strategies(id)
-		)`,
-
-		// Decisions table (AI decision logs)
-		`CREATE TABLE IF NOT EXISTS decisions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			trader_id TEXT NOT NULL,
-			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-			market_data TEXT,
-			ai_response TEXT,
-			decisions TEXT,
-			executed BOOLEAN DEFAULT 0,
-			FOREIGN KEY (trader_id) REFERENCES traders(id)
-		)`,
-
-		// Create indexes
-		`CREATE INDEX IF NOT EXISTS idx_decisions_trader ON decisions(trader_id)`,
-	}
-
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("migration failed: %w", err)
-		}
+func openPostgres(databaseURL string) (*DB, error) {
+	if !strings.HasPrefix(databaseURL, "postgres://") && !strings.HasPrefix(databaseURL, "postgresql://") {
+		return nil, fmt.Errorf("unsupported DATABASE_URL scheme, expected postgres://")
 	}
-
-	// Initialize new stores
-	positionStore := NewPositionStore()
-	if err := positionStore.InitTables(); err != nil {
-		return fmt.Errorf("position store init failed: %w", err)
+	if !postgresAvailable {
+		return nil, fmt.Errorf("DATABASE_URL is set but this binary was built without PostgreSQL support (build with -tags postgres)")
 	}
 
-	orderStore := NewOrderStore()
-	if err := orderStore.InitTables(); err != nil {
-		return fmt.Errorf("order store init failed: %w", err)
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	conn.SetMaxOpenConns(maxOpenConns)
+	conn.SetMaxIdleConns(maxOpenConns)
+	conn.SetConnMaxIdleTime(connMaxIdleFor)
+
+	log.Printf("Database: PostgreSQL %s", redactURL(databaseURL))
+	return &DB{DB: conn, dialect: DialectPostgres}, nil
+}
 
-	equityStore := NewEquityStore()
-	if err := equityStore.InitTables(); err != nil {
-		return fmt.Errorf("equity store init failed: %w", err)
+// redactURL hides the password in a connection URL for logging
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid URL)"
 	}
+	return u.Redacted()
+}
 
-	tradeStore := NewTradeStore()
-	if err := tradeStore.InitTables(); err != nil {
-		return fmt.Errorf("trade store init failed: %w", err)
+// Probe checks that the database takes a write and reads it back. The write
+// is rolled back, so a read-only or locked database fails without leaving a
+// trace on a healthy one.
+func Probe(ctx context.Context) error {
+	sqlTx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
 	}
+	tx := &Tx{Tx: sqlTx, dialect: db.dialect}
+	defer tx.Rollback()
 
-	settingsStore := NewSettingsStore()
-	if err := settingsStore.InitTables(); err != nil {
-		return fmt.Errorf("settings store init failed: %w", err)
+	token := time.Now().Format(time.RFC3339Nano)
+	if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES (?, ?)`, "health_probe", token); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	var got string
+	if err := tx.QueryRow(`SELECT value FROM settings WHERE key = ?`, "health_probe").Scan(&got); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if got != token {
+		return fmt.Errorf("read back %q, wrote %q", got, token)
 	}
+	return nil
+}
+
+func GetDB() *sql.DB {
+	return db.DB
+}
 
+func Close() error {
+	if db != nil {
+		return db.Close()
+	}
 	return nil
 }
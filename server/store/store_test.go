@@ -0,0 +1,735 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTestDB opens a fresh database for a test. Tests run against SQLite
+// unless TEST_DATABASE_URL points at a throwaway PostgreSQL database, which
+// needs the driver compiled in:
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags postgres ./store
+//
+// The public schema of that database is dropped before each test. Returns the
+// data directory, for a test that reopens the database.
+func openTestDB(t *testing.T) string {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url != "" {
+		reset, err := openPostgres(url)
+		if err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+		if _, err := reset.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+			t.Fatalf("reset postgres schema: %v", err)
+		}
+		reset.Close()
+	}
+	dataDir := t.TempDir()
+	if err := Init(dataDir, url); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+	return dataDir
+}
+
+func TestConcurrentWritesDoNotLock(t *testing.T) {
+	openTestDB(t)
+
+	trader := &Trader{ID: "stress", Name: "stress", Exchange: "binance", Status: "stopped"}
+	if err := NewTraderStore().Create(trader); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+
+	const (
+		writers          = 4
+		writesPerWriter  = 50
+		positionsToClose = 20
+	)
+	decisions := NewDecisionStore()
+	positions := NewPositionStore()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*writesPerWriter+positionsToClose*2)
+	done := make(chan struct{})
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writesPerWriter; i++ {
+				err := decisions.Create(&Decision{
+					TraderID:   trader.ID,
+					AIResponse: fmt.Sprintf("writer %d decision %d", w, i),
+					Decisions:  "[]",
+				})
+				if err != nil {
+					errs <- fmt.Errorf("writer %d: %w", w, err)
+				}
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < positionsToClose; i++ {
+			id, err := positions.Create(&TraderPosition{
+				TraderID:      trader.ID,
+				Symbol:        "BTCUSDT",
+				Side:          "LONG",
+				EntryQuantity: 1,
+				Quantity:      1,
+				EntryPrice:    100,
+				EntryTime:     time.Now(),
+			})
+			if err != nil {
+				errs <- fmt.Errorf("create position: %w", err)
+				continue
+			}
+			if err := positions.ClosePosition(id, 101, 0.1, 1, "test", false); err != nil {
+				errs <- fmt.Errorf("close position: %w", err)
+			}
+		}
+	}()
+
+	// Read stats the way the API does while the writers run
+	var readers sync.WaitGroup
+	readers.Add(1)
+	reads := 0
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := positions.GetFullStats(trader.ID); err != nil {
+				errs <- fmt.Errorf("stats: %w", err)
+				return
+			}
+			if _, err := decisions.ListByTrader(trader.ID, 10); err != nil {
+				errs <- fmt.Errorf("list decisions: %w", err)
+				return
+			}
+			reads++
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+
+	for err := range errs {
+		if strings.Contains(err.Error(), "locked") || strings.Contains(err.Error(), "busy") {
+			t.Errorf("lock error surfaced: %v", err)
+		} else {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	got, err := decisions.ListByTrader(trader.ID, writers*writesPerWriter+1)
+	if err != nil {
+		t.Fatalf("list decisions: %v", err)
+	}
+	if len(got) != writers*writesPerWriter {
+		t.Errorf("decisions = %d, want %d", len(got), writers*writesPerWriter)
+	}
+	stats, err := positions.GetFullStats(trader.ID)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.TotalTrades != positionsToClose {
+		t.Errorf("closed trades = %d, want %d", stats.TotalTrades, positionsToClose)
+	}
+	if reads == 0 {
+		t.Error("reader never completed a pass")
+	}
+}
+
+// TestReportRangeQueries tests the [start, end) queries behind trader reports
+func TestReportRangeQueries(t *testing.T) {
+	openTestDB(t)
+
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1", Exchange: "binance"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	start := time.Now().Add(-time.Hour)
+	end := start.Add(30 * time.Minute)
+
+	calls := NewAICallStore()
+	for i, at := range []time.Time{start.Add(-time.Minute), start, start.Add(10 * time.Minute), end} {
+		call := &AICall{TraderID: "t1", Model: "m1", Timestamp: at, ParseOutcome: "ok", PromptTokens: 100 * (i + 1), CompletionTokens: 10, CostUSD: 0.01}
+		if i == 1 {
+			call.Retries, call.RetryReason, call.FirstResponse = 1, "no JSON found in response", "I'd hold"
+		}
+		if err := calls.Create(call); err != nil {
+			t.Fatalf("create AI call: %v", err)
+		}
+	}
+	usage, err := calls.GetUsage("t1", start, end)
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if usage.Calls != 2 || usage.PromptTokens != 500 || usage.CompletionTokens != 20 || fmt.Sprintf("%.2f", usage.CostUSD) != "0.02" || usage.Retries != 1 {
+		t.Errorf("usage = %+v, want the 2 calls inside the range", usage)
+	}
+	calls.Create(&AICall{TraderID: "t1", Model: "m2", Timestamp: start, ParseOutcome: "no JSON found in response", Retries: 1})
+	models, err := calls.ModelStats(start)
+	if err != nil || len(models) != 2 {
+		t.Fatalf("ModelStats = %+v, %v", models, err)
+	}
+	if m := models[0]; m.Model != "m2" || m.Calls != 1 || m.Retries != 1 || m.ParseFailures != 1 || m.RetryRate != 1 {
+		t.Errorf("most retried model = %+v", m)
+	}
+	if m := models[1]; m.Model != "m1" || m.Calls != 3 || m.Retries != 1 || m.ParseFailures != 0 {
+		t.Errorf("m1 = %+v", m)
+	}
+
+	risks := NewRiskEventStore()
+	risks.Create(&RiskEvent{TraderID: "t1", Timestamp: start.Add(5 * time.Minute), Type: RiskEventDailyLossPause, Message: "paused"})
+	risks.Create(&RiskEvent{TraderID: "t1", Timestamp: end.Add(time.Minute), Type: RiskEventEmergencyStop})
+	if events, err := risks.ListBetween("t1", start, end); err != nil || len(events) != 1 || events[0].Type != RiskEventDailyLossPause {
+		t.Errorf("ListBetween = %+v, %v", events, err)
+	}
+
+	positions := NewPositionStore()
+	for _, pnl := range []float64{5, -3} {
+		id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "LONG",
+			EntryQuantity: 1, Quantity: 1, EntryPrice: 100, EntryTime: start})
+		if err != nil {
+			t.Fatalf("create position: %v", err)
+		}
+		if err := positions.ClosePosition(id, 100+pnl, 0.1, pnl, "test", false); err != nil {
+			t.Fatalf("ClosePosition: %v", err)
+		}
+	}
+	if closed, err := positions.GetClosedBetween("t1", start, time.Now().Add(time.Minute)); err != nil || len(closed) != 2 || closed[0].RealizedPnL != 5 {
+		t.Errorf("GetClosedBetween = %+v, %v", closed, err)
+	}
+	if closed, _ := positions.GetClosedBetween("t1", start, end); len(closed) != 0 {
+		t.Errorf("positions closed after end returned: %+v", closed)
+	}
+}
+
+func TestLosingStreak(t *testing.T) {
+	openTestDB(t)
+	positions := NewPositionStore()
+	start := time.Now().Add(-time.Hour)
+
+	for _, pnl := range []float64{-4, 6, -1, 0, -2} {
+		id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "LONG",
+			EntryQuantity: 1, Quantity: 1, EntryPrice: 100, EntryTime: start})
+		if err != nil {
+			t.Fatalf("create position: %v", err)
+		}
+		if err := positions.ClosePosition(id, 100+pnl, 0.1, pnl, "test", false); err != nil {
+			t.Fatalf("ClosePosition: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// The breakeven close counts as a loss, the win ends the streak
+	if streak, err := positions.LosingStreak("t1", start); err != nil || streak != 3 {
+		t.Errorf("LosingStreak = %d, %v; want 3", streak, err)
+	}
+	if streak, _ := positions.LosingStreak("t1", time.Now()); streak != 0 {
+		t.Errorf("LosingStreak after the last close = %d, want 0", streak)
+	}
+}
+
+func TestRealizedTotals(t *testing.T) {
+	openTestDB(t)
+	positions := NewPositionStore()
+
+	var dayStart time.Time
+	for i, pnl := range []float64{40, -15, 25} {
+		if i == 1 {
+			time.Sleep(2 * time.Millisecond)
+			dayStart = time.Now()
+		}
+		id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "LONG",
+			EntryQuantity: 1, Quantity: 1, EntryPrice: 100, EntryTime: time.Now()})
+		if err != nil {
+			t.Fatalf("create position: %v", err)
+		}
+		if err := positions.ClosePosition(id, 100+pnl, 0.5, pnl, "test", false); err != nil {
+			t.Fatalf("ClosePosition: %v", err)
+		}
+	}
+	// Still open, so neither its fee nor any partial close counts yet
+	if _, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "ETHUSDT", Side: "LONG",
+		EntryQuantity: 1, Quantity: 1, EntryPrice: 100, Fee: 0.2, EntryTime: time.Now()}); err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+
+	totals, err := positions.GetRealizedTotals("t1", dayStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (RealizedTotals{RealizedPnLToday: 10, RealizedPnLTotal: 50, FeesTotal: 1.5}); totals != want {
+		t.Errorf("GetRealizedTotals = %+v, want %+v", totals, want)
+	}
+	if totals, _ := positions.GetRealizedTotals("t2", dayStart); totals != (RealizedTotals{}) {
+		t.Errorf("trader without positions = %+v", totals)
+	}
+}
+
+func TestExecutionCostStats(t *testing.T) {
+	openTestDB(t)
+	positions := NewPositionStore()
+
+	// Entry at 100 with 0.04 fee and 0.05 slippage, a scale-in, then a close at 110
+	id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "long",
+		EntryQuantity: 1, Quantity: 1, EntryPrice: 100, EntryTime: time.Now(), Source: PositionSourceSystem,
+		Fee: 0.04, EntryFee: 0.04, SlippageCost: 0.05})
+	if err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+	if err := positions.UpdatePositionQuantityAndPrice(id, 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := positions.AddExecutionCosts(id, 0.04, 0.03); err != nil {
+		t.Fatal(err)
+	}
+	if err := positions.ClosePosition(id, 110, 0.1, 20, "test", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := positions.AddExecutionCosts(id, 0, 0.12); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := positions.GetFullStats("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	if !near(stats.TotalFees, 0.18) || !near(stats.EntryFees, 0.08) || !near(stats.ExitFees, 0.1) {
+		t.Errorf("fees = %.2f total, %.2f entry, %.2f exit", stats.TotalFees, stats.EntryFees, stats.ExitFees)
+	}
+	// 0.2 lost over 2 * (100 + 110) traded
+	if !near(stats.TotalSlippageCost, 0.2) || !near(stats.AvgSlippageBps, 0.2/420*10000) {
+		t.Errorf("slippage = %.4f, %.4f bps", stats.TotalSlippageCost, stats.AvgSlippageBps)
+	}
+	if stats.EntryFills != 0 {
+		t.Errorf("entry fills = %d for a position without a recorded fill", stats.EntryFills)
+	}
+
+	// A maker entry 2 bps better than its decision, still open, and a taker
+	// entry without a decision price
+	improvement := 2.0
+	for _, f := range []EntryFill{
+		{OrderType: EntryOrderLimitChase, MakerPct: 100, ImprovementBps: &improvement},
+		{OrderType: EntryOrderMarket},
+	} {
+		id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "ETHUSDT", Side: "short",
+			EntryQuantity: 1, Quantity: 1, EntryPrice: 3000, EntryTime: time.Now(), Source: PositionSourceSystem})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := positions.SetEntryFill(id, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats, err = positions.GetFullStats("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.EntryFills != 2 || !near(stats.MakerFillPct, 50) || !near(stats.AvgEntryImprovementBps, 2) {
+		t.Errorf("entry fills = %d, %.1f%% maker, %.2f bps improvement", stats.EntryFills, stats.MakerFillPct, stats.AvgEntryImprovementBps)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	openTestDB(t)
+	keys := NewIdempotencyStore()
+	now := time.Now()
+
+	rec := &IdempotencyRecord{UserID: "u1", Key: "k1", RequestHash: "h1", Status: 200,
+		ContentType: "application/json", Body: []byte(`{"status":"started"}`), ExpiresAt: now.Add(time.Hour)}
+	if err := keys.Save(rec); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := keys.Get("u1", "k1", now)
+	if err != nil || got.RequestHash != "h1" || got.Status != 200 || string(got.Body) != `{"status":"started"}` {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	// Keys are scoped per user and expire
+	if _, err := keys.Get("u2", "k1", now); err != sql.ErrNoRows {
+		t.Errorf("another user's key = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := keys.Get("u1", "k1", now.Add(2*time.Hour)); err != sql.ErrNoRows {
+		t.Errorf("expired key = %v, want sql.ErrNoRows", err)
+	}
+
+	// An expired key can be reused
+	rec.RequestHash, rec.ExpiresAt = "h2", now.Add(3*time.Hour)
+	if err := keys.Save(rec); err != nil {
+		t.Fatalf("Save over existing key: %v", err)
+	}
+	if got, _ := keys.Get("u1", "k1", now.Add(2*time.Hour)); got == nil || got.RequestHash != "h2" {
+		t.Errorf("replaced record = %+v", got)
+	}
+
+	keys.Save(&IdempotencyRecord{UserID: "u1", Key: "old", RequestHash: "h", Status: 200, ExpiresAt: now.Add(-time.Minute)})
+	if n, err := keys.DeleteExpired(now); err != nil || n != 1 {
+		t.Errorf("DeleteExpired = %d, %v, want 1", n, err)
+	}
+}
+
+func TestPositionEvents(t *testing.T) {
+	openTestDB(t)
+	events := NewPositionEventStore()
+	start := time.Now().Add(-time.Hour)
+
+	for i, e := range []*PositionEvent{
+		{TraderID: "t1", PositionID: 1, Symbol: "BTCUSDT", Side: "long", Type: PositionEventClosed, NewPrice: 105, Quantity: 2, RealizedPnL: 10, Fee: 0.2, Source: PositionEventSourceRisk},
+		{TraderID: "t1", PositionID: 1, Symbol: "BTCUSDT", Side: "long", Type: PositionEventTakeProfitMoved, OldPrice: 110, NewPrice: 120, Source: PositionEventSourceAPI},
+		{TraderID: "t1", PositionID: 2, Symbol: "BTCUSDT", Side: "short", Type: PositionEventStopLossMoved, NewPrice: 130, Source: PositionEventSourceAPI},
+		{TraderID: "t2", PositionID: 3, Symbol: "BTCUSDT", Side: "long", Type: PositionEventStopLossMoved, NewPrice: 90, Source: PositionEventSourceAI},
+	} {
+		e.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := events.Create(e); err != nil || e.ID == 0 {
+			t.Fatalf("Create: id=%d, %v", e.ID, err)
+		}
+	}
+
+	got, err := events.ListByPosition("t1", 1)
+	if err != nil || len(got) != 2 || got[0].Type != PositionEventClosed || got[0].RealizedPnL != 10 || got[0].Fee != 0.2 || got[1].NewPrice != 120 {
+		t.Fatalf("ListByPosition = %+v, %v", got, err)
+	}
+	got, err = events.ListBySymbol("t1", "BTCUSDT", 2)
+	if err != nil || len(got) != 2 || got[0].PositionID != 2 || got[0].OldPrice != 0 {
+		t.Errorf("ListBySymbol = %+v, %v", got, err)
+	}
+}
+
+func TestDecisionPositionLinks(t *testing.T) {
+	openTestDB(t)
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	positions := NewPositionStore()
+	id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "long", EntryQuantity: 1, Quantity: 1,
+		EntryPrice: 100, EntryTime: time.Now(), Leverage: 5, Source: PositionSourceSystem})
+	if err != nil {
+		t.Fatalf("create position: %v", err)
+	}
+	if pos, err := positions.Get("t1", id); err != nil || pos.Symbol != "BTCUSDT" || pos.Status != PositionStatusOpen {
+		t.Fatalf("Get = %+v, %v", pos, err)
+	}
+	if _, err := positions.Get("t2", id); err != sql.ErrNoRows {
+		t.Errorf("Get for another trader = %v, want ErrNoRows", err)
+	}
+
+	decisions := NewDecisionStore()
+	for _, d := range []*Decision{
+		{TraderID: "t1", Decisions: `[{"symbol":"BTCUSDT","action":"BUY"}]`, PositionIDs: []int64{id}},
+		{TraderID: "t1", Decisions: `[{"symbol":"ETHUSDT","action":"HOLD"}]`},
+		{TraderID: "t1", Decisions: `[{"symbol":"BTCUSDT","action":"CLOSE"}]`, PositionIDs: []int64{id}},
+	} {
+		if err := decisions.Create(d); err != nil {
+			t.Fatalf("create decision: %v", err)
+		}
+	}
+
+	got, err := decisions.ListByPosition("t1", id)
+	if err != nil || len(got) != 2 || !strings.Contains(got[0].Decisions, "BUY") || !strings.Contains(got[1].Decisions, "CLOSE") {
+		t.Errorf("ListByPosition = %+v, %v", got, err)
+	}
+	if got, err := decisions.ListByPosition("t2", id); err != nil || len(got) != 0 {
+		t.Errorf("ListByPosition for another trader = %d, %v", len(got), err)
+	}
+}
+
+func TestBlockedDecisions(t *testing.T) {
+	openTestDB(t)
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	decisions := NewDecisionStore()
+	now := time.Now().Truncate(time.Second)
+	for i, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+		d := &Decision{TraderID: "t1", Decisions: `[]`, Blocked: []*BlockedDecision{{Symbol: symbol, Action: "BUY",
+			Timestamp: now.Add(time.Duration(i) * time.Minute), Confidence: 80, Reason: "symbol in cooldown"}}}
+		if err := decisions.Create(d); err != nil {
+			t.Fatalf("create decision: %v", err)
+		}
+		if b := d.Blocked[0]; b.ID == 0 || b.DecisionID != d.ID || b.TraderID != "t1" {
+			t.Errorf("blocked decision saved as %+v, want it linked to decision %d", b, d.ID)
+		}
+	}
+
+	got, err := decisions.ListBlocked("t1", 2)
+	if err != nil || len(got) != 2 || got[0].Symbol != "SOLUSDT" || got[1].Symbol != "ETHUSDT" || got[0].Reason != "symbol in cooldown" {
+		t.Fatalf("ListBlocked = %+v, %v; want the 2 newest", got, err)
+	}
+	if got, err := decisions.ListBlocked("t2", 10); err != nil || len(got) != 0 {
+		t.Errorf("ListBlocked for another trader = %d, %v", len(got), err)
+	}
+}
+
+func TestExperimentVariantStats(t *testing.T) {
+	openTestDB(t)
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	decisions := NewDecisionStore()
+	tagged := []*Decision{
+		{TraderID: "t1", Variant: "a", Decisions: `[{"symbol":"BTCUSDT","action":"BUY"},{"symbol":"ETHUSDT","action":"NONE","ai_failed":true}]`},
+		{TraderID: "t1", Variant: "b", Decisions: `[{"symbol":"BTCUSDT","action":"HOLD"},{"symbol":"ETHUSDT","action":"SELL","status":"order_failed:insufficient margin"}]`},
+		{TraderID: "t1", Variant: "a", Decisions: `[{"symbol":"BTCUSDT","action":"HOLD"},{"symbol":"SOLUSDT","action":"BUY","status":"executed","order_id":"42"}]`},
+		{TraderID: "t1", Decisions: `[{"symbol":"BTCUSDT","action":"BUY"}]`},
+	}
+	for _, d := range tagged {
+		if err := decisions.Create(d); err != nil {
+			t.Fatalf("create decision: %v", err)
+		}
+	}
+	if got, err := decisions.Get("t1", tagged[0].ID); err != nil || got.Variant != "a" {
+		t.Errorf("Get = %+v, %v; want variant a", got, err)
+	}
+	got, err := decisions.Get("t1", tagged[1].ID)
+	if err != nil || len(got.Executions) != 2 {
+		t.Fatalf("Get = %+v, %v; want two executions", got, err)
+	}
+	if failed := got.Executions[1]; failed.Status != DecisionStatusOrderFailed || failed.Detail != "insufficient margin" {
+		t.Errorf("failed sell = %+v", failed)
+	}
+	if hold := got.Executions[0]; hold.Status != DecisionStatusUnknown {
+		t.Errorf("hold without a status = %+v, want unknown", hold)
+	}
+	positions := NewPositionStore()
+	for _, p := range []struct {
+		variant string
+		pnl     float64
+		closed  bool
+	}{{"a", 30, true}, {"a", -10, true}, {"a", 0, false}, {"b", -5, true}, {"", 100, true}} {
+		id, err := positions.Create(&TraderPosition{TraderID: "t1", Symbol: "BTCUSDT", Side: "long", EntryQuantity: 1, Quantity: 1,
+			EntryPrice: 100, EntryTime: time.Now(), Leverage: 5, Source: PositionSourceSystem, Variant: p.variant})
+		if err != nil {
+			t.Fatalf("create position: %v", err)
+		}
+		if p.closed {
+			if _, err := db.Exec(`UPDATE trader_positions SET status = ?, realized_pnl = ? WHERE id = ?`, PositionStatusClosed, p.pnl, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stats, err := NewExperimentStore().VariantStats("t1")
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("VariantStats = %d variants, %v; want a and b", len(stats), err)
+	}
+	a := stats[0]
+	if a.Variant != "a" || a.Cycles != 2 || a.Decisions["BUY"] != 2 || a.Decisions["HOLD"] != 1 || a.Decisions["NONE"] != 1 || a.AIFailures != 1 {
+		t.Errorf("variant a decisions = %+v", a)
+	}
+	// Only the buy saved as executed counts, not the one from before statuses
+	if len(a.Executed) != 1 || a.Executed["BUY"] != 1 {
+		t.Errorf("variant a executed = %v, want one BUY", a.Executed)
+	}
+	if a.Positions != 2 || a.OpenPositions != 1 || a.Wins != 1 || a.WinRate != 50 || a.TotalPnL != 20 || a.AvgPnL != 10 {
+		t.Errorf("variant a positions = %+v", a)
+	}
+	if b := stats[1]; b.Variant != "b" || b.Cycles != 1 || len(b.Executed) != 0 || b.Positions != 1 || b.Wins != 0 || b.AvgPnL != -5 {
+		t.Errorf("variant b = %+v", b)
+	}
+}
+
+func TestCoinOverrides(t *testing.T) {
+	openTestDB(t)
+	overrides := NewCoinOverrideStore()
+
+	for _, o := range []struct{ trader, symbol, kind string }{
+		{"t1", "SOLUSDT", CoinOverridePin},
+		{"t1", "DOGEUSDT", CoinOverrideBan},
+		{"t2", "SOLUSDT", CoinOverrideBan},
+		{"t1", "SOLUSDT", CoinOverrideBan}, // Replaces the pin
+	} {
+		if _, err := overrides.Set(o.trader, o.symbol, o.kind); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	got, err := overrides.List("t1")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("List = %+v, %v", got, err)
+	}
+	for _, o := range got {
+		if o.Kind != CoinOverrideBan {
+			t.Errorf("%s kind = %s, want ban", o.Symbol, o.Kind)
+		}
+	}
+
+	if deleted, err := overrides.Delete("t1", "SOLUSDT"); err != nil || !deleted {
+		t.Errorf("Delete = %v, %v", deleted, err)
+	}
+	if deleted, err := overrides.Delete("t1", "SOLUSDT"); err != nil || deleted {
+		t.Errorf("second Delete = %v, %v, want false", deleted, err)
+	}
+	if got, err := overrides.List("t2"); err != nil || len(got) != 1 {
+		t.Errorf("other trader's overrides = %+v, %v", got, err)
+	}
+}
+
+func TestBacktestCheckpoints(t *testing.T) {
+	openTestDB(t)
+	checkpoints := NewBacktestCheckpointStore()
+
+	data := []byte(`{"next_bar": 100}`)
+	if err := checkpoints.Save(&BacktestCheckpoint{RunID: "bt_1", UserID: "u1", NextBar: 100, Data: data}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data = []byte(`{"next_bar": 200}`)
+	if err := checkpoints.Save(&BacktestCheckpoint{RunID: "bt_1", UserID: "u1", NextBar: 200, Data: data}); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+
+	got, err := checkpoints.List()
+	if err != nil || len(got) != 1 {
+		t.Fatalf("List = %+v, %v", got, err)
+	}
+	if got[0].NextBar != 200 || string(got[0].Data) != string(data) {
+		t.Errorf("checkpoint = bar %d, %s; want the second save", got[0].NextBar, got[0].Data)
+	}
+
+	if err := checkpoints.Delete("bt_1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, err := checkpoints.List(); err != nil || len(got) != 0 {
+		t.Errorf("List after Delete = %+v, %v", got, err)
+	}
+}
+
+func TestModelLatency(t *testing.T) {
+	openTestDB(t)
+	if err := NewTraderStore().Create(&Trader{ID: "t1", Name: "t1"}); err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	decisions := NewDecisionStore()
+	for _, raw := range []string{
+		`[{"symbol":"BTCUSDT","action":"BUY","latency":{"model":"fast","latency_ms":5000,"price_change_pct":0.2,"adverse_move_pct":0.2}},{"symbol":"ETHUSDT","action":"HOLD"}]`,
+		`[{"symbol":"BTCUSDT","action":"SELL","latency":{"model":"fast","latency_ms":7000,"price_change_pct":0.4,"adverse_move_pct":0}}]`,
+		`[{"symbol":"BTCUSDT","action":"BUY","latency":{"model":"slow","latency_ms":150000,"price_change_pct":1.5,"adverse_move_pct":1.5,"expired":true}}]`,
+	} {
+		if err := decisions.Create(&Decision{TraderID: "t1", Decisions: raw}); err != nil {
+			t.Fatalf("create decision: %v", err)
+		}
+	}
+
+	stats, err := NewLatencyStore().ModelLatency("t1", time.Now().Add(-time.Hour))
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("ModelLatency = %d models, %v; want fast and slow", len(stats), err)
+	}
+	fast, slow := stats[0], stats[1]
+	if fast.Model != "fast" || fast.Decisions != 2 || fast.P50LatencyMs != 5000 || fast.P95LatencyMs != 7000 || fast.Expired != 0 {
+		t.Errorf("fast = %+v", fast)
+	}
+	if math.Abs(fast.AvgPriceChangePct-0.3) > 1e-9 || math.Abs(fast.AvgAdverseMovePct-0.1) > 1e-9 {
+		t.Errorf("fast moves = %.3f%% / %.3f%% adverse, want 0.3%% / 0.1%%", fast.AvgPriceChangePct, fast.AvgAdverseMovePct)
+	}
+	if slow.Model != "slow" || slow.Decisions != 1 || slow.MaxLatencyMs != 150000 || slow.Expired != 1 {
+		t.Errorf("slow = %+v", slow)
+	}
+
+	if stats, err := NewLatencyStore().ModelLatency("t1", time.Now().Add(time.Hour)); err != nil || len(stats) != 0 {
+		t.Errorf("ModelLatency after the window = %+v, %v", stats, err)
+	}
+}
+
+func TestAIConfigGenerationParams(t *testing.T) {
+	var unset *AIConfig
+	if got := unset.GenerationParams(); got.Temperature != 0.7 || got.MaxTokens != 4096 || got.TopP != 0 || got.ReasoningEffort != "" {
+		t.Errorf("defaults = %+v", got)
+	}
+
+	zero, topP := 0.0, 0.9
+	cfg := &AIConfig{Temperature: &zero, TopP: &topP, MaxTokens: 2000, ReasoningEffort: "high"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	// An explicit 0 is a deterministic model, not a missing setting
+	if got := cfg.GenerationParams(); got.Temperature != 0 || got.TopP != 0.9 || got.MaxTokens != 2000 || got.ReasoningEffort != "high" {
+		t.Errorf("params = %+v", got)
+	}
+	if err := (&AIConfig{Provider: "ollama", Model: "llama3:70b", FallbackModels: []string{"mistral"}}).Validate(); err != nil {
+		t.Errorf("Validate(ollama): %v", err)
+	}
+
+	hot, noTopP := 2.5, 0.0
+	for _, bad := range []*AIConfig{
+		{Temperature: &hot},
+		{TopP: &noTopP},
+		{MaxTokens: -1},
+		{MaxTokens: MaxAITokens + 1},
+		{PromptTokenBudget: -1},
+		{PromptTokenBudget: MinPromptTokenBudget - 1},
+		{ReasoningEffort: "max"},
+		{Provider: "groq"},
+		{Provider: "ollama"},
+		{FallbackModels: []string{"openai/gpt-4o", " "}},
+		{FallbackModels: make([]string, MaxFallbackModels+1)},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("Validate(%+v) passed", *bad)
+		}
+	}
+}
+
+func TestIncomeHistory(t *testing.T) {
+	openTestDB(t)
+	incomes := NewIncomeStore()
+
+	base := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	entries := []IncomeEntry{
+		{TraderID: "t1", TranID: 1, IncomeType: "REALIZED_PNL", Category: IncomeCategoryRealizedPnL, Symbol: "BTCUSDT", Amount: 25, Time: base},
+		{TraderID: "t1", TranID: 1, IncomeType: "COMMISSION", Category: IncomeCategoryCommission, Symbol: "BTCUSDT", Amount: -1.5, Time: base},
+		{TraderID: "t1", TranID: 2, IncomeType: "FUNDING_FEE", Category: IncomeCategoryFunding, Symbol: "BTCUSDT", Amount: -0.5, Time: base.Add(time.Minute)},
+		{TraderID: "t1", TranID: 3, IncomeType: "TRANSFER", Category: IncomeCategoryTransfer, Amount: 500, Asset: "USDT", Time: base.Add(2 * time.Minute)},
+		{TraderID: "t1", TranID: 4, IncomeType: "TRANSFER", Category: IncomeCategoryTransfer, Amount: -200, Asset: "USDT", Time: base.Add(3 * time.Minute)},
+	}
+	added, err := incomes.Save(entries)
+	if err != nil || len(added) != len(entries) {
+		t.Fatalf("Save = %d added, %v", len(added), err)
+	}
+	// Fetching the same history again adds nothing
+	if added, err := incomes.Save(entries[2:]); err != nil || len(added) != 0 {
+		t.Errorf("Save again = %d added, %v", len(added), err)
+	}
+
+	latest, err := incomes.LatestTime("t1")
+	if err != nil || !latest.Equal(base.Add(3*time.Minute)) {
+		t.Errorf("LatestTime = %v, %v", latest, err)
+	}
+	if latest, _ := incomes.LatestTime("t2"); !latest.IsZero() {
+		t.Errorf("LatestTime without history = %v", latest)
+	}
+
+	totals, err := incomes.GetTotals("t1", base, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IncomeTotals{RealizedPnL: 25, Funding: -0.5, Commission: -1.5, Deposits: 500, Withdrawals: -200}
+	if totals != want || totals.NetTransfers() != 300 || totals.TradingIncome() != 23 {
+		t.Errorf("GetTotals = %+v, want %+v", totals, want)
+	}
+
+	transfers, err := incomes.ListBetween("t1", IncomeCategoryTransfer, base, time.Now())
+	if err != nil || len(transfers) != 2 || transfers[0].Amount != 500 || transfers[1].Asset != "USDT" {
+		t.Errorf("ListBetween transfers = %+v, %v", transfers, err)
+	}
+	if all, _ := incomes.ListBetween("t1", "", base, time.Now()); len(all) != 5 {
+		t.Errorf("ListBetween all = %d entries, want 5", len(all))
+	}
+}
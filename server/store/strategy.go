@@ -4,8 +4,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
+	"auto-trader-ahh/mcp"
+
 	"github.com/google/uuid"
 )
 
@@ -16,6 +20,9 @@ type Strategy struct {
 	Description string         `json:"description"`
 	IsActive    bool           `json:"is_active"`
 	Config      StrategyConfig `json:"config"`
+	OwnerUserID string         `json:"owner_user_id"`
+	Version     int            `json:"version"`              // 1 when created, +1 on every update
+	UpdatedBy   string         `json:"updated_by,omitempty"` // User who saved this version
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
@@ -37,6 +44,9 @@ type StrategyConfig struct {
 	// Custom AI prompt additions
 	CustomPrompt string `json:"custom_prompt"`
 
+	// Prompt A/B experiment: variants take CustomPrompt's place cycle by cycle
+	Experiment ExperimentConfig `json:"experiment"`
+
 	// Trading interval in minutes
 	TradingInterval int `json:"trading_interval"`
 
@@ -52,6 +62,33 @@ type StrategyConfig struct {
 	// Smart Find Auto-Refresh (cycles to find new risky symbols periodically)
 	SmartFindAutoRefresh   bool `json:"smart_find_auto_refresh"`   // Enable auto-refresh of smart find
 	SmartFindRefreshMins   int  `json:"smart_find_refresh_mins"`   // Interval in minutes (30, 60, 120, etc.)
+
+	// Trading schedule (weekly active windows)
+	Schedule ScheduleConfig `json:"schedule"`
+
+	// Trading day boundary for the daily loss baseline, daily reports and
+	// schedule windows without their own timezone
+	Timezone     string `json:"timezone"`       // IANA name, e.g. "Asia/Tokyo" (empty = UTC)
+	DayStartHour int    `json:"day_start_hour"` // Hour of the day, 0-23, in Timezone
+}
+
+// ScheduleConfig limits when the trader is active. Outside every window new
+// entries are blocked ("pause_entries") or the trading cycle is skipped
+// entirely ("full_pause"); the risk check loop keeps protecting open positions
+// either way.
+type ScheduleConfig struct {
+	Enabled     bool             `json:"enabled"`
+	Timezone    string           `json:"timezone"` // IANA name, e.g. "Europe/London" (empty = the strategy's timezone)
+	Windows     []ScheduleWindow `json:"windows"`
+	OutOfWindow string           `json:"out_of_window"` // "pause_entries" or "full_pause"
+}
+
+// ScheduleWindow is one weekly active period in the schedule's timezone.
+// An End at or before Start runs past midnight into the next day.
+type ScheduleWindow struct {
+	Day   int    `json:"day"`   // 0 = Sunday ... 6 = Saturday
+	Start string `json:"start"` // "HH:MM"
+	End   string `json:"end"`   // "HH:MM", "24:00" for end of day
 }
 
 // AIConfig defines AI model settings
@@ -61,12 +98,136 @@ type AIConfig struct {
 
 	// Reasoning model to use when reasoning is enabled (default: deepseek/deepseek-r1)
 	ReasoningModel string `json:"reasoning_model"`
+
+	// Where decisions are made: "" or "openrouter", or "ollama" for a local
+	// server. Model overrides the trader's model, and is required for Ollama.
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	// Models tried in order when the model fails to produce a decision:
+	// errors after retries, or a response unusable even after correction
+	FallbackModels []string `json:"fallback_models,omitempty"`
+
+	// Sampling settings; left out, requests keep temperature 0.7, 4096 max
+	// tokens and the provider's top_p
+	Temperature     *float64 `json:"temperature,omitempty"`      // 0-2
+	TopP            *float64 `json:"top_p,omitempty"`            // Above 0, up to 1
+	MaxTokens       int      `json:"max_tokens,omitempty"`       // Up to MaxAITokens
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"` // "low", "medium" or "high", for models that support it
+
+	// Estimated tokens a decision's user prompt may take, 0 for no limit.
+	// Longer prompts have their candles, recent trades and the market data of
+	// symbols without a position trimmed, in that order.
+	PromptTokenBudget int `json:"prompt_token_budget,omitempty"`
+}
+
+// MaxAITokens caps AIConfig.MaxTokens
+const MaxAITokens = 32768
+
+// MinPromptTokenBudget is the smallest AIConfig.PromptTokenBudget; below it
+// the trimming can't keep the instructions and a position in
+const MinPromptTokenBudget = 1000
+
+// MaxFallbackModels caps AIConfig.FallbackModels
+const MaxFallbackModels = 5
+
+// Validate checks the provider, the fallback models and that the sampling
+// settings are in range
+func (c *AIConfig) Validate() error {
+	switch c.Provider {
+	case "", mcp.ProviderOpenRouter:
+	case mcp.ProviderOllama:
+		if c.Model == "" {
+			return fmt.Errorf("model is required with the ollama provider")
+		}
+	default:
+		return fmt.Errorf("provider must be openrouter or ollama")
+	}
+	if len(c.FallbackModels) > MaxFallbackModels {
+		return fmt.Errorf("at most %d fallback_models", MaxFallbackModels)
+	}
+	for _, model := range c.FallbackModels {
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("fallback_models can't contain a blank model")
+		}
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if c.TopP != nil && (*c.TopP <= 0 || *c.TopP > 1) {
+		return fmt.Errorf("top_p must be above 0 and at most 1")
+	}
+	if c.MaxTokens < 0 || c.MaxTokens > MaxAITokens {
+		return fmt.Errorf("max_tokens must be between 1 and %d", MaxAITokens)
+	}
+	if c.PromptTokenBudget != 0 && c.PromptTokenBudget < MinPromptTokenBudget {
+		return fmt.Errorf("prompt_token_budget must be 0 or at least %d", MinPromptTokenBudget)
+	}
+	if c.ReasoningEffort != "" {
+		valid := false
+		for _, effort := range mcp.ReasoningEfforts {
+			valid = valid || c.ReasoningEffort == effort
+		}
+		if !valid {
+			return fmt.Errorf("reasoning_effort must be low, medium or high")
+		}
+	}
+	return nil
+}
+
+// GenerationParams returns the effective sampling settings, defaults filled in
+func (c *AIConfig) GenerationParams() mcp.GenerationParams {
+	params := mcp.DefaultGenerationParams()
+	if c == nil {
+		return params
+	}
+	if c.Temperature != nil {
+		params.Temperature = *c.Temperature
+	}
+	if c.TopP != nil {
+		params.TopP = *c.TopP
+	}
+	if c.MaxTokens > 0 {
+		params.MaxTokens = c.MaxTokens
+	}
+	params.ReasoningEffort = c.ReasoningEffort
+	return params
 }
 
 // CoinSourceConfig defines how to select coins
 type CoinSourceConfig struct {
-	SourceType  string   `json:"source_type"` // "static" | "dynamic"
+	SourceType  string   `json:"source_type"` // "static" | "dynamic" | "remote"
 	StaticCoins []string `json:"static_coins"`
+
+	// Remote list: a JSON or CSV list of symbols fetched from RemoteURL, the
+	// static coins until the first fetch succeeds
+	RemoteURL         string `json:"remote_url,omitempty"`
+	RemoteMaxSymbols  int    `json:"remote_max_symbols,omitempty"`  // The first this many symbols are used, 0 = 30
+	RemoteRefreshMins int    `json:"remote_refresh_mins,omitempty"` // Re-fetched this often, 0 = 60
+
+	// Smart Find universe filters, applied before the AI sees the candidates
+	SmartFindExcludeSymbols []string `json:"smart_find_exclude_symbols"` // Never picked, even when the AI recommends them
+	SmartFindIncludeOnly    []string `json:"smart_find_include_only"`    // When set, the only symbols Smart Find may pick
+	MinListingAgeDays       int      `json:"min_listing_age_days"`       // Skip symbols with less daily history (0 = off)
+	MaxFundingRateAbs       float64  `json:"max_funding_rate_abs"`       // Skip symbols whose |funding rate| % is above this (0 = off)
+}
+
+// CoinSourceRemote is the source type of a remote symbol list
+const CoinSourceRemote = "remote"
+
+// Validate checks the remote list settings when the remote source is selected
+func (c *CoinSourceConfig) Validate() error {
+	if c.SourceType != CoinSourceRemote {
+		return nil
+	}
+	u, err := url.Parse(c.RemoteURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("remote_url must be an http or https URL")
+	}
+	if c.RemoteMaxSymbols < 0 || c.RemoteRefreshMins < 0 {
+		return fmt.Errorf("remote_max_symbols and remote_refresh_mins can't be negative")
+	}
+	return nil
 }
 
 // IndicatorConfig defines which indicators to use
@@ -92,6 +253,12 @@ type IndicatorConfig struct {
 	MACDSlow   int   `json:"macd_slow"`   // e.g., 26
 	MACDSignal int   `json:"macd_signal"` // e.g., 9
 
+	BOLLStdDev float64 `json:"boll_std_dev"` // Band width in standard deviations, e.g., 2
+
+	// Support/resistance detection
+	SRLookback    int `json:"sr_lookback"`    // Klines searched for swing levels, e.g., 100
+	SRSensitivity int `json:"sr_sensitivity"` // Klines on each side a swing point must clear, e.g., 3
+
 	// Multi-Timeframe Confirmation
 	EnableMultiTF         bool   `json:"enable_multi_tf"`        // Check multiple timeframes before trading
 	ConfirmationTimeframe string `json:"confirmation_timeframe"` // Higher timeframe to confirm (e.g., "15m")
@@ -119,9 +286,20 @@ type RiskControlConfig struct {
 	MinPositionSizeBTCETH float64 `json:"min_position_size_btc_eth"` // Min position size for BTC/ETH (default: 60 USDT)
 	MinPositionUSD        float64 `json:"min_position_usd"`          // Legacy: single min for all (fallback)
 
+	// Position sizing mode
+	SizingMode      string  `json:"sizing_mode"`        // "fixed_pct" (default) or "atr_risk"
+	RiskPerTradePct float64 `json:"risk_per_trade_pct"` // atr_risk: % of equity lost if the ATR stop is hit (default: 1.0)
+	ATRStopMultiple float64 `json:"atr_stop_multiple"`  // atr_risk: stop distance in ATRs the size is computed for (default: 2.0)
+
 	// Margin and buffer
 	MaxMarginUsage float64 `json:"max_margin_usage"` // Max % of balance in margin (default: 90)
 	MarginBuffer   float64 `json:"margin_buffer"`    // Safety buffer multiplier (default: 0.98 = use 98% of max)
+	MaxSlippagePct float64 `json:"max_slippage_pct"` // Max % an entry may fill from the pre-trade price before warning, also the max expected slippage from the order book before skipping (default: 0.5, 0 = off)
+	MinDepthUSD    float64 `json:"min_depth_usd"`    // Order book depth within 0.5% of mid below which the AI is warned of low liquidity (default: 50000)
+
+	// Funding filter
+	MaxAdverseFundingRate     float64 `json:"max_adverse_funding_rate"`    // Funding % per 8h above which entries on the paying side are blocked (default: 0.05, 0 = off)
+	FundingOverrideConfidence int     `json:"funding_override_confidence"` // Min AI confidence to enter despite adverse funding (default: 95)
 
 	// AI decision thresholds
 	MinConfidence                int     `json:"min_confidence"`                  // Min AI confidence to trade (default: 70)
@@ -161,6 +339,151 @@ type RiskControlConfig struct {
 	EnableSmartLossCut bool    `json:"enable_smart_loss_cut"` // Enable time-based loss cutting
 	SmartLossCutMins   int     `json:"smart_loss_cut_mins"`   // Minutes before cutting losers (default: 30)
 	SmartLossCutPct    float64 `json:"smart_loss_cut_pct"`    // Loss % threshold for smart cut (default: -1.0 = -1%)
+
+	// RE-ENTRY COOLDOWN - Don't reopen a symbol right after closing it (0 = disabled)
+	CooldownMinsAfterClose    int `json:"cooldown_mins_after_close"`     // Minutes before reopening after a normal close (default: 15)
+	CooldownMinsAfterStopLoss int `json:"cooldown_mins_after_stop_loss"` // Minutes before reopening after a stop loss (default: 60)
+
+	// RISK CHECK LOOP - Rule-based protections run between AI cycles
+	RiskCheckIntervalSecs int `json:"risk_check_interval_secs"` // Seconds between risk checks (default: 20)
+
+	// STALE DECISION CHECK - The live position is re-fetched before every order; its side must match what the decision saw
+	PositionDriftTolerancePct float64 `json:"position_drift_tolerance_pct"` // Size change % tolerated before the order is aborted as stale (default: 10)
+
+	// ENTRY RECHECK - Re-fetch the price just before an entry and skip it if the market moved against it while the AI decided
+	EnableEntryRecheck        bool    `json:"enable_entry_recheck"`          // Skip entries the price moved against since the decision's market data (default: false)
+	EntryRecheckMaxAdversePct float64 `json:"entry_recheck_max_adverse_pct"` // Adverse move % tolerated, the decision's stop is never tolerated (default: 0.5)
+
+	// DECISION STALENESS - Discard entries whose market data got too old by the time of the order (0 = disabled)
+	MaxDecisionStalenessSecs int `json:"max_decision_staleness_secs"` // Seconds from the market data to the order before an entry is discarded
+
+	// ENTRY ORDERS - How new positions are opened; scale-ins and exits are always market orders
+	EntryOrderType        string `json:"entry_order_type"`         // "market" (default) or "limit_chase": post-only limit at the touch, re-priced while unfilled
+	LimitChaseWaitSecs    int    `json:"limit_chase_wait_secs"`    // Seconds each limit rests before it is re-priced (default: 5)
+	LimitChaseMaxReprices int    `json:"limit_chase_max_reprices"` // Re-prices after the first limit before giving up (default: 3)
+	LimitChaseFallback    string `json:"limit_chase_fallback"`     // What to do with the unfilled rest: "market" (default) or "cancel"
+
+	// DEAD-MAN SWITCH - Bound losses while the server can't reach Binance
+	EnableBackstopStop       bool    `json:"enable_backstop_stop"`       // Wide exchange SL on positions without one while trailing stop or smart loss cut manage them locally
+	BackstopStopPct          float64 `json:"backstop_stop_pct"`          // Backstop distance from entry, raw price % (default: 5.0)
+	AutoCancelSecs           int     `json:"auto_cancel_secs"`           // Binance auto-cancel countdown for open orders on symbols without a position, renewed each risk check (0 = disabled)
+	ExchangeFailureThreshold int     `json:"exchange_failure_threshold"` // Consecutive failed account/position fetches before connectivity counts as lost (default: 5)
+	FlattenOnReconnect       bool    `json:"flatten_on_reconnect"`       // Close all positions once connectivity returns after being lost (default: false)
+
+	// CORRELATION GUARD - Cap same-side exposure in symbols that move together (0 = disabled)
+	MaxCorrelatedExposure float64 `json:"max_correlated_exposure"` // Max notional of a correlated group as % of equity (default: 400)
+	CorrelationThreshold  float64 `json:"correlation_threshold"`   // 30-day return correlation above which symbols are grouped (default: 0.8)
+
+	// CIRCUIT BREAKER - Stop opening positions until acknowledged (0 = disabled)
+	MaxTotalDrawdownPct  float64 `json:"max_total_drawdown_pct"` // Max equity drawdown % from its all-time peak
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"` // Max losing closes in a row
+
+	// EXPOSURE LIMITS - Cap the book's direction and each symbol class, checked before every entry
+	ExposureLimits ExposureLimits `json:"exposure_limits"`
+}
+
+// Entry order types
+const (
+	EntryOrderMarket     = "market"      // Market order at whatever the book gives
+	EntryOrderLimitChase = "limit_chase" // Post-only limit at the touch, chased toward the market
+)
+
+// What a limit chase does with the quantity still unfilled after its last re-price
+const (
+	LimitChaseFallbackMarket = "market" // Fill it with a market order
+	LimitChaseFallbackCancel = "cancel" // Keep what filled, or skip the entry if nothing did
+)
+
+// ExposureLimits caps notional as % of equity (0 = disabled)
+type ExposureLimits struct {
+	MaxNetLongPct         float64 `json:"max_net_long_pct"`         // Long notional minus short notional
+	MaxNetShortPct        float64 `json:"max_net_short_pct"`        // Short notional minus long notional
+	MaxBTCETHNotionalPct  float64 `json:"max_btc_eth_notional_pct"` // BTC and ETH positions together, both sides
+	MaxAltcoinNotionalPct float64 `json:"max_altcoin_notional_pct"` // Altcoin positions together, both sides
+}
+
+// Validate checks the circuit breaker and exposure limits are in range
+func (c *RiskControlConfig) Validate() error {
+	if c.MaxTotalDrawdownPct < 0 || c.MaxTotalDrawdownPct >= 100 {
+		return fmt.Errorf("max_total_drawdown_pct must be between 0 and 100")
+	}
+	if c.MaxConsecutiveLosses < 0 {
+		return fmt.Errorf("max_consecutive_losses can't be negative")
+	}
+	if c.PositionDriftTolerancePct < 0 {
+		return fmt.Errorf("position_drift_tolerance_pct can't be negative")
+	}
+	if c.EntryRecheckMaxAdversePct < 0 {
+		return fmt.Errorf("entry_recheck_max_adverse_pct can't be negative")
+	}
+	if c.MaxDecisionStalenessSecs < 0 {
+		return fmt.Errorf("max_decision_staleness_secs can't be negative")
+	}
+	switch c.EntryOrderType {
+	case "", EntryOrderMarket, EntryOrderLimitChase:
+	default:
+		return fmt.Errorf("entry_order_type must be %q or %q", EntryOrderMarket, EntryOrderLimitChase)
+	}
+	if c.LimitChaseWaitSecs < 0 || c.LimitChaseMaxReprices < 0 {
+		return fmt.Errorf("limit_chase_wait_secs and limit_chase_max_reprices can't be negative")
+	}
+	switch c.LimitChaseFallback {
+	case "", LimitChaseFallbackMarket, LimitChaseFallbackCancel:
+	default:
+		return fmt.Errorf("limit_chase_fallback must be %q or %q", LimitChaseFallbackMarket, LimitChaseFallbackCancel)
+	}
+	l := c.ExposureLimits
+	if l.MaxNetLongPct < 0 || l.MaxNetShortPct < 0 || l.MaxBTCETHNotionalPct < 0 || l.MaxAltcoinNotionalPct < 0 {
+		return fmt.Errorf("exposure_limits can't be negative")
+	}
+	return nil
+}
+
+// Experiment variant assignment
+const (
+	ExperimentAlternate = "alternate" // Variants take turns, cycle by cycle
+	ExperimentRandom    = "random"    // A seeded random variant each cycle
+)
+
+// ExperimentConfig compares custom prompt variants on one trader: each
+// cycle runs one variant, and its decisions and positions are tagged with it
+type ExperimentConfig struct {
+	Enabled    bool            `json:"enabled"`
+	Assignment string          `json:"assignment"` // "alternate" (default) or "random"
+	Seed       int64           `json:"seed"`       // Random assignment repeats for the same seed
+	Variants   []PromptVariant `json:"variants"`
+}
+
+// PromptVariant is one custom prompt under test
+type PromptVariant struct {
+	ID           string `json:"id"`
+	CustomPrompt string `json:"custom_prompt"` // Replaces the strategy's custom prompt, may be empty
+}
+
+// Validate checks an enabled experiment has at least two uniquely named variants
+func (c *ExperimentConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Assignment {
+	case "", ExperimentAlternate, ExperimentRandom:
+	default:
+		return fmt.Errorf("assignment must be alternate or random")
+	}
+	if len(c.Variants) < 2 {
+		return fmt.Errorf("an experiment needs at least 2 variants")
+	}
+	seen := make(map[string]bool, len(c.Variants))
+	for _, v := range c.Variants {
+		if v.ID == "" {
+			return fmt.Errorf("every variant needs an id")
+		}
+		if seen[v.ID] {
+			return fmt.Errorf("duplicate variant id %q", v.ID)
+		}
+		seen[v.ID] = true
+	}
+	return nil
 }
 
 // DefaultStrategyConfig returns a sensible default strategy
@@ -184,9 +507,12 @@ func DefaultStrategyConfig() StrategyConfig {
 			RSIPeriod:        14,
 			ATRPeriod:        14,
 			BOLLPeriod:       20,
+			BOLLStdDev:       2,
 			MACDFast:         12,
 			MACDSlow:         26,
 			MACDSignal:       9,
+			SRLookback:       100,
+			SRSensitivity:    3,
 
 			// Multi-Timeframe Confirmation (enabled by default)
 			EnableMultiTF:         true,
@@ -207,9 +533,20 @@ func DefaultStrategyConfig() StrategyConfig {
 			MinPositionSize:       12.0, // USDT for altcoins
 			MinPositionSizeBTCETH: 60.0, // USDT for BTC/ETH
 
+			// Position sizing (fixed percent by default)
+			SizingMode:      "fixed_pct",
+			RiskPerTradePct: 1.0,
+			ATRStopMultiple: 2.0,
+
 			// Margin settings
 			MaxMarginUsage: 90.0,
 			MarginBuffer:   0.98, // Use 98% of max affordable
+			MaxSlippagePct: 0.5,  // Warn and re-base SL/TP when a fill lands 0.5% off, skip entries expected to
+			MinDepthUSD:    50000,
+
+			// Funding filter: 0.05% per 8h is five times the usual rate
+			MaxAdverseFundingRate:     0.05,
+			FundingOverrideConfidence: 95,
 
 			// AI thresholds
 			MinConfidence:                85,   // Raised from 70: Only trade on high confidence signals
@@ -249,6 +586,35 @@ func DefaultStrategyConfig() StrategyConfig {
 			EnableSmartLossCut: false,
 			SmartLossCutMins:   30,   // Cut if losing for 30 mins
 			SmartLossCutPct:    -1.0, // Only cut if loss > 1%
+
+			// Re-entry cooldown
+			CooldownMinsAfterClose:    15, // Skip reopening for 15 mins after a close
+			CooldownMinsAfterStopLoss: 60, // Longer wait after being stopped out
+
+			// Risk check loop
+			RiskCheckIntervalSecs: 20, // Refresh marks and check protections every 20s
+
+			// Stale decision check
+			PositionDriftTolerancePct: 10,
+
+			// Entry recheck (disabled by default - opt-in)
+			EnableEntryRecheck:        false,
+			EntryRecheckMaxAdversePct: 0.5,
+
+			// Entry orders
+			EntryOrderType:        EntryOrderMarket,
+			LimitChaseWaitSecs:    5,
+			LimitChaseMaxReprices: 3,
+			LimitChaseFallback:    LimitChaseFallbackMarket,
+
+			// Dead-man switch
+			EnableBackstopStop:       true,
+			BackstopStopPct:          5.0, // Well past any SL the AI would set
+			ExchangeFailureThreshold: 5,   // About 2 minutes of failed risk checks
+
+			// Correlation guard
+			MaxCorrelatedExposure: 400.0, // Correlated positions together up to 4x equity in notional
+			CorrelationThreshold:  0.8,
 		},
 		AI: AIConfig{
 			EnableReasoning: false,
@@ -260,6 +626,12 @@ func DefaultStrategyConfig() StrategyConfig {
 		// Smart Find Auto-Refresh (disabled by default - opt-in)
 		SmartFindAutoRefresh: false,
 		SmartFindRefreshMins: 60, // Default: 1 hour
+
+		// Trading schedule (disabled by default - always active)
+		Schedule: ScheduleConfig{
+			Timezone:    "UTC",
+			OutOfWindow: "pause_entries",
+		},
 	}
 }
 
@@ -274,6 +646,10 @@ func (s *StrategyStore) Create(strategy *Strategy) error {
 	if strategy.ID == "" {
 		strategy.ID = uuid.New().String()
 	}
+	if strategy.OwnerUserID == "" {
+		strategy.OwnerUserID = BootstrapAdminID
+	}
+	strategy.Version = 1
 	strategy.CreatedAt = time.Now()
 	strategy.UpdatedAt = time.Now()
 
@@ -283,14 +659,16 @@ func (s *StrategyStore) Create(strategy *Strategy) error {
 	}
 
 	_, err = db.Exec(`
-		INSERT INTO strategies (id, name, description, is_active, config, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO strategies (id, name, description, is_active, config, owner_user_id, version, updated_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, strategy.ID, strategy.Name, strategy.Description, strategy.IsActive, string(configJSON),
-		strategy.CreatedAt, strategy.UpdatedAt)
+		strategy.OwnerUserID, strategy.Version, strategy.UpdatedBy, strategy.CreatedAt, strategy.UpdatedAt)
 
 	return err
 }
 
+// Update saves a strategy as its next version, keeping the one it replaces in
+// the strategy's version history. strategy.Version is set to the new number.
 func (s *StrategyStore) Update(strategy *Strategy) error {
 	strategy.UpdatedAt = time.Now()
 
@@ -299,24 +677,55 @@ func (s *StrategyStore) Update(strategy *Strategy) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	_, err = db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO strategy_versions (strategy_id, version, name, description, config, edited_by, created_at)
+		SELECT id, version, name, description, config, updated_by, updated_at FROM strategies WHERE id = ?
+	`, strategy.ID); err != nil {
+		return fmt.Errorf("failed to keep the previous version: %w", err)
+	}
+	if _, err := tx.Exec(`
 		UPDATE strategies
-		SET name = ?, description = ?, is_active = ?, config = ?, updated_at = ?
+		SET name = ?, description = ?, is_active = ?, config = ?, version = version + 1, updated_by = ?, updated_at = ?
 		WHERE id = ?
 	`, strategy.Name, strategy.Description, strategy.IsActive, string(configJSON),
-		strategy.UpdatedAt, strategy.ID)
-
-	return err
+		strategy.UpdatedBy, strategy.UpdatedAt, strategy.ID); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(`SELECT version FROM strategies WHERE id = ?`, strategy.ID).Scan(&strategy.Version); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// Delete removes a strategy and detaches the traders that used it
 func (s *StrategyStore) Delete(id string) error {
-	_, err := db.Exec(`DELETE FROM strategies WHERE id = ?`, id)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE traders SET strategy_id = NULL WHERE strategy_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM strategy_versions WHERE strategy_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM strategies WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *StrategyStore) Get(id string) (*Strategy, error) {
 	row := db.QueryRow(`
-		SELECT id, name, description, is_active, config, created_at, updated_at
+		SELECT id, name, description, is_active, config, owner_user_id, version, updated_by, created_at, updated_at
 		FROM strategies WHERE id = ?
 	`, id)
 
@@ -325,9 +734,9 @@ func (s *StrategyStore) Get(id string) (*Strategy, error) {
 
 func (s *StrategyStore) GetActive() (*Strategy, error) {
 	row := db.QueryRow(`
-		SELECT id, name, description, is_active, config, created_at, updated_at
-		FROM strategies WHERE is_active = 1 LIMIT 1
-	`)
+		SELECT id, name, description, is_active, config, owner_user_id, version, updated_by, created_at, updated_at
+		FROM strategies WHERE is_active = ? LIMIT 1
+	`, true)
 
 	strategy, err := s.scanStrategy(row)
 	if err == sql.ErrNoRows {
@@ -344,12 +753,28 @@ func (s *StrategyStore) GetActive() (*Strategy, error) {
 
 func (s *StrategyStore) List() ([]*Strategy, error) {
 	rows, err := db.Query(`
-		SELECT id, name, description, is_active, config, created_at, updated_at
+		SELECT id, name, description, is_active, config, owner_user_id, version, updated_by, created_at, updated_at
 		FROM strategies ORDER BY created_at DESC
 	`)
 	if err != nil {
 		return nil, err
 	}
+	return s.collectStrategies(rows)
+}
+
+// ListByOwner returns the strategies owned by a user
+func (s *StrategyStore) ListByOwner(ownerUserID string) ([]*Strategy, error) {
+	rows, err := db.Query(`
+		SELECT id, name, description, is_active, config, owner_user_id, version, updated_by, created_at, updated_at
+		FROM strategies WHERE owner_user_id = ? ORDER BY created_at DESC
+	`, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectStrategies(rows)
+}
+
+func (s *StrategyStore) collectStrategies(rows *sql.Rows) ([]*Strategy, error) {
 	defer rows.Close()
 
 	var strategies []*Strategy
@@ -372,12 +797,12 @@ func (s *StrategyStore) SetActive(id string) error {
 	defer tx.Rollback()
 
 	// Deactivate all strategies
-	if _, err := tx.Exec(`UPDATE strategies SET is_active = 0`); err != nil {
+	if _, err := tx.Exec(`UPDATE strategies SET is_active = ?`, false); err != nil {
 		return err
 	}
 
 	// Activate the selected one
-	if _, err := tx.Exec(`UPDATE strategies SET is_active = 1 WHERE id = ?`, id); err != nil {
+	if _, err := tx.Exec(`UPDATE strategies SET is_active = ? WHERE id = ?`, true, id); err != nil {
 		return err
 	}
 
@@ -387,15 +812,23 @@ func (s *StrategyStore) SetActive(id string) error {
 func (s *StrategyStore) scanStrategy(row *sql.Row) (*Strategy, error) {
 	var strategy Strategy
 	var configJSON string
+	var ownerUserID sql.NullString
+	var updatedBy sql.NullString
 
 	err := row.Scan(
 		&strategy.ID, &strategy.Name, &strategy.Description,
-		&strategy.IsActive, &configJSON,
-		&strategy.CreatedAt, &strategy.UpdatedAt,
+		&strategy.IsActive, &configJSON, &ownerUserID,
+		&strategy.Version, &updatedBy, &strategy.CreatedAt, &strategy.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	strategy.UpdatedBy = updatedBy.String
+
+	strategy.OwnerUserID = BootstrapAdminID
+	if ownerUserID.Valid && ownerUserID.String != "" {
+		strategy.OwnerUserID = ownerUserID.String
+	}
 
 	if err := json.Unmarshal([]byte(configJSON), &strategy.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -407,15 +840,23 @@ func (s *StrategyStore) scanStrategy(row *sql.Row) (*Strategy, error) {
 func (s *StrategyStore) scanStrategyRow(rows *sql.Rows) (*Strategy, error) {
 	var strategy Strategy
 	var configJSON string
+	var ownerUserID sql.NullString
+	var updatedBy sql.NullString
 
 	err := rows.Scan(
 		&strategy.ID, &strategy.Name, &strategy.Description,
-		&strategy.IsActive, &configJSON,
-		&strategy.CreatedAt, &strategy.UpdatedAt,
+		&strategy.IsActive, &configJSON, &ownerUserID,
+		&strategy.Version, &updatedBy, &strategy.CreatedAt, &strategy.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	strategy.UpdatedBy = updatedBy.String
+
+	strategy.OwnerUserID = BootstrapAdminID
+	if ownerUserID.Valid && ownerUserID.String != "" {
+		strategy.OwnerUserID = ownerUserID.String
+	}
 
 	if err := json.Unmarshal([]byte(configJSON), &strategy.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// StrategyVersion is one saved config of a strategy. Versions are numbered
+// from 1, the config it was created with; each update keeps the version it
+// replaces, and the strategy row itself is the current one.
+type StrategyVersion struct {
+	StrategyID  string          `json:"strategy_id"`
+	Version     int             `json:"version"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Config      *StrategyConfig `json:"config,omitempty"` // Left out of listings
+	EditedBy    string          `json:"edited_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"` // When this version was saved
+	Current     bool            `json:"current"`
+}
+
+// currentVersion is the version a strategy row holds now
+func currentVersion(strategy *Strategy) *StrategyVersion {
+	cfg := strategy.Config
+	return &StrategyVersion{
+		StrategyID:  strategy.ID,
+		Version:     strategy.Version,
+		Name:        strategy.Name,
+		Description: strategy.Description,
+		Config:      &cfg,
+		EditedBy:    strategy.UpdatedBy,
+		CreatedAt:   strategy.UpdatedAt,
+		Current:     true,
+	}
+}
+
+// ListVersions returns a strategy's versions newest first, the current one
+// included, without their configs
+func (s *StrategyStore) ListVersions(strategy *Strategy) ([]*StrategyVersion, error) {
+	rows, err := db.Query(`
+		SELECT version, name, description, edited_by, created_at
+		FROM strategy_versions WHERE strategy_id = ? ORDER BY version DESC
+	`, strategy.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	current := currentVersion(strategy)
+	current.Config = nil
+	versions := []*StrategyVersion{current}
+	for rows.Next() {
+		v := &StrategyVersion{StrategyID: strategy.ID}
+		var description, editedBy sql.NullString
+		if err := rows.Scan(&v.Version, &v.Name, &description, &editedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		v.Description, v.EditedBy = description.String, editedBy.String
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns version n of a strategy with its config, sql.ErrNoRows
+// if there is no such version
+func (s *StrategyStore) GetVersion(strategy *Strategy, n int) (*StrategyVersion, error) {
+	if n == strategy.Version {
+		return currentVersion(strategy), nil
+	}
+
+	v := &StrategyVersion{StrategyID: strategy.ID, Version: n}
+	var description, editedBy sql.NullString
+	var configJSON string
+	err := db.QueryRow(`
+		SELECT name, description, config, edited_by, created_at
+		FROM strategy_versions WHERE strategy_id = ? AND version = ?
+	`, strategy.ID, n).Scan(&v.Name, &description, &configJSON, &editedBy, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	v.Description, v.EditedBy = description.String, editedBy.String
+
+	v.Config = &StrategyConfig{}
+	if err := json.Unmarshal([]byte(configJSON), v.Config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config of version %d: %w", n, err)
+	}
+	return v, nil
+}
+
+// ConfigChange is a field that differs between two strategy configs
+type ConfigChange struct {
+	Field string      `json:"field"` // JSON path, e.g. risk_control.max_leverage
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffStrategyConfigs lists the fields that changed from one config to
+// another, sorted by field. Lists are compared whole.
+func DiffStrategyConfigs(from, to StrategyConfig) ([]ConfigChange, error) {
+	fromFields, err := configFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := configFields(to)
+	if err != nil {
+		return nil, err
+	}
+	changes := []ConfigChange{}
+	diffFields("", fromFields, toFields, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// configFields is a config as its JSON object
+func configFields(cfg StrategyConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	return fields, json.Unmarshal(data, &fields)
+}
+
+func diffFields(prefix string, from, to map[string]interface{}, changes *[]ConfigChange) {
+	keys := make(map[string]bool, len(from)+len(to))
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+	for k := range keys {
+		field := prefix + k
+		a, b := from[k], to[k]
+		aObj, aIsObj := a.(map[string]interface{})
+		bObj, bIsObj := b.(map[string]interface{})
+		if aIsObj && bIsObj {
+			diffFields(field+".", aObj, bObj, changes)
+			continue
+		}
+		if !reflect.DeepEqual(a, b) {
+			*changes = append(*changes, ConfigChange{Field: field, From: a, To: b})
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestStrategyVersions(t *testing.T) {
+	openTestDB(t)
+	strategies := NewStrategyStore()
+
+	s := &Strategy{Name: "Trend", Config: DefaultStrategyConfig(), UpdatedBy: "alice"}
+	if err := strategies.Create(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != 1 {
+		t.Fatalf("created at version %d, want 1", s.Version)
+	}
+
+	original := s.Config
+	s.Config.RiskControl.MaxLeverage = original.RiskControl.MaxLeverage + 5
+	s.Config.CustomPrompt = "Only trade with the trend"
+	s.UpdatedBy = "bob"
+	if err := strategies.Update(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != 2 {
+		t.Fatalf("updated to version %d, want 2", s.Version)
+	}
+
+	current, err := strategies.Get(s.ID)
+	if err != nil || current.Version != 2 || current.UpdatedBy != "bob" {
+		t.Fatalf("current = %+v, %v", current, err)
+	}
+	versions, err := strategies.ListVersions(current)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("versions = %+v, %v", versions, err)
+	}
+	if v := versions[0]; v.Version != 2 || !v.Current || v.Config != nil {
+		t.Errorf("newest = %+v, want the current version without its config", v)
+	}
+	if v := versions[1]; v.Version != 1 || v.Current || v.EditedBy != "alice" {
+		t.Errorf("oldest = %+v, want version 1 by alice", v)
+	}
+
+	first, err := strategies.GetVersion(current, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Config.RiskControl.MaxLeverage != original.RiskControl.MaxLeverage || first.Config.CustomPrompt != original.CustomPrompt {
+		t.Errorf("version 1 config = %+v, want the original", first.Config.RiskControl)
+	}
+	if _, err := strategies.GetVersion(current, 3); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetVersion(3) = %v, want sql.ErrNoRows", err)
+	}
+
+	changes, err := DiffStrategyConfigs(*first.Config, current.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Field != "custom_prompt" || changes[1].Field != "risk_control.max_leverage" {
+		t.Errorf("diff = %+v, want custom_prompt and risk_control.max_leverage", changes)
+	}
+
+	if err := strategies.Delete(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM strategy_versions WHERE strategy_id = ?`, s.ID).Scan(&left); err != nil || left != 0 {
+		t.Errorf("%d versions left after delete, %v", left, err)
+	}
+}
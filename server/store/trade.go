@@ -1,7 +1,7 @@
 package store
 
 import (
-	"fmt"
+	"database/sql"
 	"time"
 )
 
@@ -23,40 +23,23 @@ type Trade struct {
 // TradeStore handles trade persistence
 type TradeStore struct{}
 
+// tradeUpsert overwrites a re-synced trade with the exchange's latest copy
+const tradeUpsert = `ON CONFLICT (id) DO UPDATE SET
+		trader_id = excluded.trader_id, symbol = excluded.symbol, side = excluded.side,
+		price = excluded.price, quantity = excluded.quantity, quote_qty = excluded.quote_qty,
+		realized_pnl = excluded.realized_pnl, commission = excluded.commission,
+		timestamp = excluded.timestamp, order_id = excluded.order_id`
+
 func NewTradeStore() *TradeStore {
 	return &TradeStore{}
 }
 
-// InitTables creates the trades table if it doesn't exist
-func (s *TradeStore) InitTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS trades (
-		id INTEGER PRIMARY KEY,
-		trader_id TEXT NOT NULL,
-		symbol TEXT NOT NULL,
-		side TEXT NOT NULL,
-		price REAL NOT NULL,
-		quantity REAL NOT NULL,
-		quote_qty REAL NOT NULL,
-		realized_pnl REAL DEFAULT 0,
-		commission REAL DEFAULT 0,
-		timestamp DATETIME NOT NULL,
-		order_id INTEGER,
-		UNIQUE(id, trader_id)
-	);
-	CREATE INDEX IF NOT EXISTS idx_trades_trader ON trades(trader_id);
-	CREATE INDEX IF NOT EXISTS idx_trades_timestamp ON trades(trader_id, timestamp DESC);
-	CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(trader_id, symbol);
-	`
-	_, err := db.Exec(query)
-	return err
-}
-
 // Save saves a trade to the database
 func (s *TradeStore) Save(trade *Trade) error {
 	_, err := db.Exec(`
-		INSERT OR REPLACE INTO trades (id, trader_id, symbol, side, price, quantity, quote_qty, realized_pnl, commission, timestamp, order_id)
+		INSERT INTO trades (id, trader_id, symbol, side, price, quantity, quote_qty, realized_pnl, commission, timestamp, order_id)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`+tradeUpsert+`
 	`, trade.ID, trade.TraderID, trade.Symbol, trade.Side, trade.Price, trade.Quantity,
 		trade.QuoteQty, trade.RealizedPnL, trade.Commission, trade.Timestamp, trade.OrderID)
 	return err
@@ -71,8 +54,9 @@ func (s *TradeStore) SaveBatch(trades []*Trade) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO trades (id, trader_id, symbol, side, price, quantity, quote_qty, realized_pnl, commission, timestamp, order_id)
+		INSERT INTO trades (id, trader_id, symbol, side, price, quantity, quote_qty, realized_pnl, commission, timestamp, order_id)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		` + tradeUpsert + `
 	`)
 	if err != nil {
 		return err
@@ -117,35 +101,17 @@ func (s *TradeStore) GetByTrader(traderID string, limit int) ([]*Trade, error) {
 
 // GetLastTradeTime returns the timestamp of the most recent trade for a trader (in milliseconds)
 func (s *TradeStore) GetLastTradeTime(traderID string) (int64, error) {
-	var timestampStr string
+	var last time.Time
 	err := db.QueryRow(`
-		SELECT COALESCE(MAX(timestamp), '') FROM trades WHERE trader_id = ?
-	`, traderID).Scan(&timestampStr)
-
-	if err != nil {
-		return 0, err
-	}
-	if timestampStr == "" {
+		SELECT timestamp FROM trades WHERE trader_id = ? ORDER BY timestamp DESC LIMIT 1
+	`, traderID).Scan(&last)
+	if err == sql.ErrNoRows {
 		return 0, nil
 	}
-
-	// Parse timestamp (SQLite defaults to RFC3339 format usually, or whatever was inserted)
-	// Since we insert time.Time, the driver usually inserts "2006-01-02 15:04:05.999999999-07:00"
-	// We'll try standard parsing.
-	t, err := time.Parse(time.RFC3339, timestampStr)
 	if err != nil {
-		// Try alternative layout if RFC3339 fails (e.g. without timezone or space instead of T)
-		t, err = time.Parse("2006-01-02 15:04:05.999999999-07:00", timestampStr)
-		if err != nil {
-			// One last try for simple format
-			t, err = time.Parse("2006-01-02 15:04:05", timestampStr)
-			if err != nil {
-				return 0, fmt.Errorf("failed to parse timestamp %s: %w", timestampStr, err)
-			}
-		}
+		return 0, err
 	}
-
-	return t.UnixMilli(), nil
+	return last.UnixMilli(), nil
 }
 
 // GetTotalPnL returns the total realized PnL for a trader
@@ -11,15 +11,19 @@ import (
 
 // Trader represents a trading bot instance
 type Trader struct {
-	ID             string       `json:"id"`
-	Name           string       `json:"name"`
-	StrategyID     string       `json:"strategy_id"`
-	Exchange       string       `json:"exchange"`
-	Status         string       `json:"status"` // "running", "stopped", "error"
-	InitialBalance float64      `json:"initial_balance"`
-	Config         TraderConfig `json:"config"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
+	ID              string       `json:"id"`
+	Name            string       `json:"name"`
+	StrategyID      string       `json:"strategy_id"`
+	StrategyVersion int          `json:"strategy_version,omitempty"` // Version of the strategy it was last started or reloaded with
+	Exchange        string       `json:"exchange"`
+	Status          string       `json:"status"` // "running", "stopped", "error"
+	InitialBalance  float64      `json:"initial_balance"`
+	Config          TraderConfig `json:"config"`
+	OwnerUserID     string       `json:"owner_user_id"`
+	Archived        bool         `json:"archived"` // Hidden from lists and can't be started, its history kept
+	ArchivedAt      *time.Time   `json:"archived_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
 }
 
 // TraderConfig holds trader-specific configuration
@@ -40,6 +44,10 @@ type TraderConfig struct {
 	APIKey    string `json:"api_key"`
 	SecretKey string `json:"secret_key"`
 	Testnet   bool   `json:"testnet"`
+
+	// What stopping the trader does with its open positions: "leave" (the
+	// default), "flatten" or "protect"
+	ShutdownPolicy string `json:"shutdown_policy,omitempty"`
 }
 
 // TraderStore handles trader persistence
@@ -56,6 +64,9 @@ func (s *TraderStore) Create(trader *Trader) error {
 	if trader.Status == "" {
 		trader.Status = "stopped"
 	}
+	if trader.OwnerUserID == "" {
+		trader.OwnerUserID = BootstrapAdminID
+	}
 	trader.CreatedAt = time.Now()
 	trader.UpdatedAt = time.Now()
 
@@ -65,10 +76,10 @@ func (s *TraderStore) Create(trader *Trader) error {
 	}
 
 	_, err = db.Exec(`
-		INSERT INTO traders (id, name, strategy_id, exchange, status, initial_balance, config, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, trader.ID, trader.Name, trader.StrategyID, trader.Exchange, trader.Status,
-		trader.InitialBalance, string(configJSON), trader.CreatedAt, trader.UpdatedAt)
+		INSERT INTO traders (id, name, strategy_id, exchange, status, initial_balance, config, owner_user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, trader.ID, trader.Name, nullableID(trader.StrategyID), trader.Exchange, trader.Status,
+		trader.InitialBalance, string(configJSON), trader.OwnerUserID, trader.CreatedAt, trader.UpdatedAt)
 
 	return err
 }
@@ -85,26 +96,84 @@ func (s *TraderStore) Update(trader *Trader) error {
 		UPDATE traders
 		SET name = ?, strategy_id = ?, exchange = ?, status = ?, initial_balance = ?, config = ?, updated_at = ?
 		WHERE id = ?
-	`, trader.Name, trader.StrategyID, trader.Exchange, trader.Status,
+	`, trader.Name, nullableID(trader.StrategyID), trader.Exchange, trader.Status,
 		trader.InitialBalance, string(configJSON), trader.UpdatedAt, trader.ID)
 
 	return err
 }
 
+// SetStrategyVersion records the version of its strategy a trader runs with,
+// so its results stay attributable after the strategy is edited
+func (s *TraderStore) SetStrategyVersion(id string, version int) error {
+	_, err := db.Exec(`UPDATE traders SET strategy_version = ? WHERE id = ?`, version, id)
+	return err
+}
+
 func (s *TraderStore) UpdateStatus(id, status string) error {
 	_, err := db.Exec(`UPDATE traders SET status = ?, updated_at = ? WHERE id = ?`,
 		status, time.Now(), id)
 	return err
 }
 
-func (s *TraderStore) Delete(id string) error {
-	_, err := db.Exec(`DELETE FROM traders WHERE id = ?`, id)
+// SetArchived archives a trader, or brings an archived one back. The trader
+// keeps its history either way.
+func (s *TraderStore) SetArchived(id string, archived bool) error {
+	var archivedAt interface{}
+	if archived {
+		archivedAt = time.Now()
+	}
+	_, err := db.Exec(`UPDATE traders SET archived_at = ?, updated_at = ? WHERE id = ?`,
+		archivedAt, time.Now(), id)
 	return err
 }
 
+// traderHistoryTables hold a trader's history by trader_id, children of the
+// traders row first so foreign keys hold while purging
+var traderHistoryTables = []string{
+	"decision_positions",
+	"decisions",
+	"blocked_decisions",
+	"ai_calls",
+	"position_events",
+	"trader_positions",
+	"trader_fills",
+	"trader_orders",
+	"trades",
+	"trader_equity_snapshots",
+	"trader_equity_rollups",
+	"trader_income",
+	"risk_events",
+	"trader_annotations",
+	"trader_coin_overrides",
+	"smart_find_runs",
+	"trader_engine_state",
+	"audit_log",
+}
+
+// Purge deletes a trader and everything recorded for it: decisions, positions,
+// orders, equity history, audit rows and saved engine state. It runs in one
+// transaction, so it either removes all of it or nothing.
+func (s *TraderStore) Purge(id string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range traderHistoryTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE trader_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM traders WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (s *TraderStore) Get(id string) (*Trader, error) {
 	row := db.QueryRow(`
-		SELECT id, name, strategy_id, exchange, status, initial_balance, config, created_at, updated_at
+		SELECT id, name, strategy_id, exchange, status, initial_balance, config, owner_user_id, strategy_version, archived_at, created_at, updated_at
 		FROM traders WHERE id = ?
 	`, id)
 
@@ -113,12 +182,28 @@ func (s *TraderStore) Get(id string) (*Trader, error) {
 
 func (s *TraderStore) List() ([]*Trader, error) {
 	rows, err := db.Query(`
-		SELECT id, name, strategy_id, exchange, status, initial_balance, config, created_at, updated_at
+		SELECT id, name, strategy_id, exchange, status, initial_balance, config, owner_user_id, strategy_version, archived_at, created_at, updated_at
 		FROM traders ORDER BY created_at DESC
 	`)
 	if err != nil {
 		return nil, err
 	}
+	return s.collectTraders(rows)
+}
+
+// ListByOwner returns the traders owned by a user
+func (s *TraderStore) ListByOwner(ownerUserID string) ([]*Trader, error) {
+	rows, err := db.Query(`
+		SELECT id, name, strategy_id, exchange, status, initial_balance, config, owner_user_id, strategy_version, archived_at, created_at, updated_at
+		FROM traders WHERE owner_user_id = ? ORDER BY created_at DESC
+	`, ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectTraders(rows)
+}
+
+func (s *TraderStore) collectTraders(rows *sql.Rows) ([]*Trader, error) {
 	defer rows.Close()
 
 	var traders []*Trader
@@ -137,19 +222,32 @@ func (s *TraderStore) scanTrader(row *sql.Row) (*Trader, error) {
 	var trader Trader
 	var configJSON string
 	var strategyID sql.NullString
+	var ownerUserID sql.NullString
+	var archivedAt sql.NullTime
+	var strategyVersion sql.NullInt64
 
 	err := row.Scan(
 		&trader.ID, &trader.Name, &strategyID, &trader.Exchange,
-		&trader.Status, &trader.InitialBalance, &configJSON,
-		&trader.CreatedAt, &trader.UpdatedAt,
+		&trader.Status, &trader.InitialBalance, &configJSON, &ownerUserID,
+		&strategyVersion, &archivedAt, &trader.CreatedAt, &trader.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	trader.StrategyVersion = int(strategyVersion.Int64)
 
 	if strategyID.Valid {
 		trader.StrategyID = strategyID.String
 	}
+	if archivedAt.Valid {
+		trader.Archived = true
+		trader.ArchivedAt = &archivedAt.Time
+	}
+
+	trader.OwnerUserID = BootstrapAdminID
+	if ownerUserID.Valid && ownerUserID.String != "" {
+		trader.OwnerUserID = ownerUserID.String
+	}
 
 	if err := json.Unmarshal([]byte(configJSON), &trader.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -162,19 +260,32 @@ func (s *TraderStore) scanTraderRow(rows *sql.Rows) (*Trader, error) {
 	var trader Trader
 	var configJSON string
 	var strategyID sql.NullString
+	var ownerUserID sql.NullString
+	var archivedAt sql.NullTime
+	var strategyVersion sql.NullInt64
 
 	err := rows.Scan(
 		&trader.ID, &trader.Name, &strategyID, &trader.Exchange,
-		&trader.Status, &trader.InitialBalance, &configJSON,
-		&trader.CreatedAt, &trader.UpdatedAt,
+		&trader.Status, &trader.InitialBalance, &configJSON, &ownerUserID,
+		&strategyVersion, &archivedAt, &trader.CreatedAt, &trader.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	trader.StrategyVersion = int(strategyVersion.Int64)
 
 	if strategyID.Valid {
 		trader.StrategyID = strategyID.String
 	}
+	if archivedAt.Valid {
+		trader.Archived = true
+		trader.ArchivedAt = &archivedAt.Time
+	}
+
+	trader.OwnerUserID = BootstrapAdminID
+	if ownerUserID.Valid && ownerUserID.String != "" {
+		trader.OwnerUserID = ownerUserID.String
+	}
 
 	if err := json.Unmarshal([]byte(configJSON), &trader.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -190,8 +301,27 @@ type Decision struct {
 	Timestamp  time.Time `json:"timestamp"`
 	MarketData string    `json:"market_data"`
 	AIResponse string    `json:"ai_response"`
-	Decisions  string    `json:"decisions"` // JSON array of decisions
-	Executed   bool      `json:"executed"`
+	Decisions  string    `json:"decisions"`         // JSON array of decisions
+	Executed   bool      `json:"executed"`          // At least one of the decisions' orders went through
+	Variant    string    `json:"variant,omitempty"` // Prompt experiment variant the cycle ran
+	// Positions the decisions opened, changed or closed, linked when the record is created
+	PositionIDs []int64 `json:"position_ids,omitempty"`
+	// Decisions a validator or risk rule kept from executing, saved with the record
+	Blocked []*BlockedDecision `json:"blocked,omitempty"`
+	// What became of each of the decisions, read from them when the record is loaded
+	Executions []*DecisionExecution `json:"executions,omitempty"`
+}
+
+// BlockedDecision is an AI decision a validator or risk rule kept from executing
+type BlockedDecision struct {
+	ID         int64     `json:"id"`
+	TraderID   string    `json:"trader_id"`
+	DecisionID int64     `json:"decision_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Symbol     string    `json:"symbol"`
+	Action     string    `json:"action"`
+	Confidence float64   `json:"confidence"`
+	Reason     string    `json:"reason"`
 }
 
 // DecisionStore handles decision persistence
@@ -204,23 +334,94 @@ func NewDecisionStore() *DecisionStore {
 func (s *DecisionStore) Create(decision *Decision) error {
 	decision.Timestamp = time.Now()
 
-	result, err := db.Exec(`
-		INSERT INTO decisions (trader_id, timestamp, market_data, ai_response, decisions, executed)
-		VALUES (?, ?, ?, ?, ?, ?)
+	id, err := db.Insert(`
+		INSERT INTO decisions (trader_id, timestamp, market_data, ai_response, decisions, executed, variant)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`, decision.TraderID, decision.Timestamp, decision.MarketData,
-		decision.AIResponse, decision.Decisions, decision.Executed)
+		decision.AIResponse, decision.Decisions, decision.Executed, decision.Variant)
 	if err != nil {
 		return err
 	}
 
-	id, _ := result.LastInsertId()
 	decision.ID = id
+	for _, positionID := range decision.PositionIDs {
+		if _, err := db.Exec(`
+			INSERT INTO decision_positions (decision_id, position_id, trader_id) VALUES (?, ?, ?)
+		`, id, positionID, decision.TraderID); err != nil {
+			return fmt.Errorf("failed to link decision %d to position %d: %w", id, positionID, err)
+		}
+	}
+	for _, b := range decision.Blocked {
+		b.TraderID, b.DecisionID = decision.TraderID, id
+		if b.Timestamp.IsZero() {
+			b.Timestamp = decision.Timestamp
+		}
+		b.ID, err = db.Insert(`
+			INSERT INTO blocked_decisions (trader_id, decision_id, timestamp, symbol, action, confidence, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, b.TraderID, b.DecisionID, b.Timestamp, b.Symbol, b.Action, b.Confidence, b.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to save blocked %s decision of decision %d: %w", b.Symbol, id, err)
+		}
+	}
 	return nil
 }
 
+// ListBlocked returns a trader's most recently blocked decisions, newest first
+func (s *DecisionStore) ListBlocked(traderID string, limit int) ([]*BlockedDecision, error) {
+	rows, err := db.Query(`
+		SELECT id, trader_id, decision_id, timestamp, symbol, action, confidence, COALESCE(reason, '')
+		FROM blocked_decisions WHERE trader_id = ?
+		ORDER BY timestamp DESC, id DESC LIMIT ?
+	`, traderID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []*BlockedDecision{}
+	for rows.Next() {
+		var b BlockedDecision
+		if err := rows.Scan(&b.ID, &b.TraderID, &b.DecisionID, &b.Timestamp,
+			&b.Symbol, &b.Action, &b.Confidence, &b.Reason); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, &b)
+	}
+	return blocked, rows.Err()
+}
+
+// ListByPosition returns the decision records linked to a position, oldest first
+func (s *DecisionStore) ListByPosition(traderID string, positionID int64) ([]*Decision, error) {
+	rows, err := db.Query(`
+		SELECT d.id, d.trader_id, d.timestamp, d.market_data, d.ai_response, d.decisions, d.executed, COALESCE(d.variant, '')
+		FROM decisions d
+		JOIN decision_positions dp ON dp.decision_id = d.id
+		WHERE dp.trader_id = ? AND dp.position_id = ?
+		ORDER BY d.timestamp ASC, d.id ASC
+	`, traderID, positionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []*Decision
+	for rows.Next() {
+		var d Decision
+		if err := rows.Scan(&d.ID, &d.TraderID, &d.Timestamp, &d.MarketData,
+			&d.AIResponse, &d.Decisions, &d.Executed, &d.Variant); err != nil {
+			return nil, err
+		}
+		d.Executions = parseExecutions(d.Decisions)
+		decisions = append(decisions, &d)
+	}
+
+	return decisions, rows.Err()
+}
+
 func (s *DecisionStore) ListByTrader(traderID string, limit int) ([]*Decision, error) {
 	rows, err := db.Query(`
-		SELECT id, trader_id, timestamp, market_data, ai_response, decisions, executed
+		SELECT id, trader_id, timestamp, market_data, ai_response, decisions, executed, COALESCE(variant, '')
 		FROM decisions WHERE trader_id = ?
 		ORDER BY timestamp DESC LIMIT ?
 	`, traderID, limit)
@@ -233,27 +434,54 @@ func (s *DecisionStore) ListByTrader(traderID string, limit int) ([]*Decision, e
 	for rows.Next() {
 		var d Decision
 		if err := rows.Scan(&d.ID, &d.TraderID, &d.Timestamp, &d.MarketData,
-			&d.AIResponse, &d.Decisions, &d.Executed); err != nil {
+			&d.AIResponse, &d.Decisions, &d.Executed, &d.Variant); err != nil {
 			return nil, err
 		}
+		d.Executions = parseExecutions(d.Decisions)
 		decisions = append(decisions, &d)
 	}
 
 	return decisions, rows.Err()
 }
 
+// Get returns one of a trader's decision records
+func (s *DecisionStore) Get(traderID string, id int64) (*Decision, error) {
+	row := db.QueryRow(`
+		SELECT id, trader_id, timestamp, market_data, ai_response, decisions, executed, COALESCE(variant, '')
+		FROM decisions WHERE trader_id = ? AND id = ?
+	`, traderID, id)
+
+	var d Decision
+	err := row.Scan(&d.ID, &d.TraderID, &d.Timestamp, &d.MarketData,
+		&d.AIResponse, &d.Decisions, &d.Executed, &d.Variant)
+	if err != nil {
+		return nil, err
+	}
+	d.Executions = parseExecutions(d.Decisions)
+	return &d, nil
+}
+
 func (s *DecisionStore) GetLatest(traderID string) (*Decision, error) {
 	row := db.QueryRow(`
-		SELECT id, trader_id, timestamp, market_data, ai_response, decisions, executed
+		SELECT id, trader_id, timestamp, market_data, ai_response, decisions, executed, COALESCE(variant, '')
 		FROM decisions WHERE trader_id = ?
 		ORDER BY timestamp DESC LIMIT 1
 	`, traderID)
 
 	var d Decision
 	err := row.Scan(&d.ID, &d.TraderID, &d.Timestamp, &d.MarketData,
-		&d.AIResponse, &d.Decisions, &d.Executed)
+		&d.AIResponse, &d.Decisions, &d.Executed, &d.Variant)
 	if err != nil {
 		return nil, err
 	}
+	d.Executions = parseExecutions(d.Decisions)
 	return &d, nil
 }
+
+// nullableID stores an unset reference as NULL so it passes the foreign key check
+func nullableID(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User roles
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// BootstrapAdminID is the owner assigned to resources created before
+// multi-user support and to requests authenticated with ACCESS_PASSKEY
+const BootstrapAdminID = "admin"
+
+// User represents an API user
+type User struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"` // "admin" or "user"
+	APIKeyPrefix string    `json:"api_key_prefix"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IsAdmin returns true if the user can see every resource
+func (u *User) IsAdmin() bool {
+	return u != nil && u.Role == RoleAdmin
+}
+
+// CanAccess returns true if the user owns the resource or is an admin
+func (u *User) CanAccess(ownerUserID string) bool {
+	if u == nil {
+		return false
+	}
+	if u.IsAdmin() {
+		return true
+	}
+	return ownerUserID == u.ID
+}
+
+// UserStore handles user persistence
+type UserStore struct{}
+
+func NewUserStore() *UserStore {
+	return &UserStore{}
+}
+
+// Create creates a new user and returns the plaintext API key.
+// The key is only stored hashed, so it cannot be recovered later.
+func (s *UserStore) Create(user *User) (string, error) {
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+	if user.Role != RoleAdmin && user.Role != RoleUser {
+		return "", fmt.Errorf("invalid role: %s", user.Role)
+	}
+	user.CreatedAt = time.Now()
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	user.APIKeyPrefix = apiKey[:8]
+
+	_, err = db.Exec(`
+		INSERT INTO users (id, name, role, api_key_hash, api_key_prefix, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.ID, user.Name, user.Role, HashAPIKey(apiKey), user.APIKeyPrefix, user.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return apiKey, nil
+}
+
+// GetByAPIKey looks up a user by plaintext API key
+func (s *UserStore) GetByAPIKey(apiKey string) (*User, error) {
+	row := db.QueryRow(`
+		SELECT id, name, role, api_key_prefix, created_at
+		FROM users WHERE api_key_hash = ?
+	`, HashAPIKey(apiKey))
+
+	var user User
+	if err := row.Scan(&user.ID, &user.Name, &user.Role, &user.APIKeyPrefix, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get retrieves a user by ID
+func (s *UserStore) Get(id string) (*User, error) {
+	row := db.QueryRow(`
+		SELECT id, name, role, api_key_prefix, created_at
+		FROM users WHERE id = ?
+	`, id)
+
+	var user User
+	if err := row.Scan(&user.ID, &user.Name, &user.Role, &user.APIKeyPrefix, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List returns all users
+func (s *UserStore) List() ([]*User, error) {
+	rows, err := db.Query(`
+		SELECT id, name, role, api_key_prefix, created_at
+		FROM users ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Role, &user.APIKeyPrefix, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+// Count returns the number of registered users
+func (s *UserStore) Count() (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	return count, err
+}
+
+// Delete removes a user
+func (s *UserStore) Delete(id string) error {
+	result, err := db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// HashAPIKey returns the hex SHA-256 digest stored for an API key
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey creates a random 32-byte API key
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+
+	"auto-trader-ahh/store"
+)
+
+// largeLossAnnotationPct is the loss of a single close, as % of the day's
+// starting balance, that gets marked on the equity curve
+const largeLossAnnotationPct = 2.0
+
+// riskEventAnnotations are the risk events that also mark the equity curve
+var riskEventAnnotations = map[string]string{
+	store.RiskEventDailyLossPause: store.AnnotationDailyLossPause,
+	store.RiskEventEmergencyStop:  store.AnnotationEmergencyStop,
+	store.RiskEventCircuitBreaker: store.AnnotationCircuitBreaker,
+}
+
+// annotate marks an event on the trader's equity curve
+func (e *Engine) annotate(kind, text string) {
+	if e.annotationStore == nil {
+		return
+	}
+	if err := e.annotationStore.Create(&store.Annotation{TraderID: e.id, Type: kind, Text: text}); err != nil {
+		log.Printf("[%s] Failed to save annotation: %v", e.name, err)
+	}
+}
+
+// annotateLargeLoss marks a close whose P&L lost more than
+// largeLossAnnotationPct of the day's starting balance
+func (e *Engine) annotateLargeLoss(symbol string, pnl float64, reason string) {
+	e.mu.RLock()
+	balance := e.initialBalance
+	e.mu.RUnlock()
+	if pnl >= 0 || balance <= 0 {
+		return
+	}
+	if lossPct := -pnl / balance * 100; lossPct >= largeLossAnnotationPct {
+		e.annotate(store.AnnotationLargeLoss, fmt.Sprintf("%s closed at a $%.2f loss, %.1f%% of the day's balance (%s)", symbol, -pnl, lossPct, reason))
+	}
+}
@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+func TestEquityCurveAnnotations(t *testing.T) {
+	e, sim, _ := paperEngine(t, map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000})
+	ctx := context.Background()
+	annotations := func() []*store.Annotation {
+		t.Helper()
+		list, err := store.NewAnnotationStore().ListBetween(e.id, time.Time{}, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	// Risk events that explain a move in equity mark it, the others don't
+	e.recordRiskEvent(store.RiskEventDailyLossPause, "daily loss limit reached, trading paused for 60 minutes")
+	e.recordRiskEvent(store.RiskEventEntryRecheck, "BTCUSDT open_long skipped: price moved")
+	if got := annotations(); len(got) != 1 || got[0].Type != store.AnnotationDailyLossPause {
+		t.Fatalf("annotations = %+v, want the daily loss pause", got)
+	}
+
+	open := func(symbol string) {
+		t.Helper()
+		d := &ai.TradingDecision{Symbol: symbol, Action: "open_long", Confidence: 90, Leverage: 5, StopLossPct: 10, TakeProfitPct: 30}
+		if _, err := e.executeTrade(ctx, symbol, d, false, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	closeAt := func(symbol string, price float64) {
+		t.Helper()
+		sim.SetPrice(symbol, price)
+		e.mu.RLock()
+		pos := *e.positions[symbol]
+		e.mu.RUnlock()
+		pos.MarkPrice = price
+		if err := e.forceClose(ctx, &pos, "test", closeReasonEmergency); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e.mu.Lock()
+	e.initialBalance = 10000
+	e.mu.Unlock()
+
+	// A small loss isn't marked, one past the threshold is
+	open("ETHUSDT")
+	closeAt("ETHUSDT", 2999)
+	open("BTCUSDT")
+	closeAt("BTCUSDT", 55000)
+
+	got := annotations()
+	if len(got) != 2 || got[1].Type != store.AnnotationLargeLoss {
+		t.Fatalf("annotations = %+v, want the BTCUSDT loss marked", got)
+	}
+}
@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+const (
+	// maxRecentBlocks is how many blocked decisions the engine keeps in memory
+	maxRecentBlocks = 10
+	// promptRecentBlocks is how many of them the prompt shows
+	promptRecentBlocks = 5
+	// maxBlockReasonLen caps each reason shown in the prompt
+	maxBlockReasonLen = 160
+)
+
+// recordBlock adds a decision a validator or risk rule kept from executing to
+// the rolling list, newest first
+func (e *Engine) recordBlock(b *store.BlockedDecision) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.recentBlocks = append([]*store.BlockedDecision{b}, e.recentBlocks...)
+	if len(e.recentBlocks) > maxRecentBlocks {
+		e.recentBlocks = e.recentBlocks[:maxRecentBlocks]
+	}
+}
+
+// recentBlockedDecisions returns the rolling list, newest first
+func (e *Engine) recentBlockedDecisions() []*store.BlockedDecision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]*store.BlockedDecision(nil), e.recentBlocks...)
+}
+
+// loadRecentBlocks fills the rolling list from the decision store, so a
+// restart keeps it
+func (e *Engine) loadRecentBlocks() {
+	if e.decisionStore == nil {
+		return
+	}
+	blocked, err := e.decisionStore.ListBlocked(e.id, maxRecentBlocks)
+	if err != nil {
+		log.Printf("[%s] Failed to load blocked decisions: %v", e.name, err)
+		return
+	}
+
+	e.mu.Lock()
+	e.recentBlocks = blocked
+	e.mu.Unlock()
+}
+
+// formatRecentBlocks renders the prompt section listing the newest blocked
+// decisions, empty when there are none
+func formatRecentBlocks(blocks []*store.BlockedDecision, now time.Time) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	if len(blocks) > promptRecentBlocks {
+		blocks = blocks[:promptRecentBlocks]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n--- Recently Rejected Decisions ---\n")
+	for _, b := range blocks {
+		reason := b.Reason
+		if len(reason) > maxBlockReasonLen {
+			reason = reason[:maxBlockReasonLen] + "..."
+		}
+		fmt.Fprintf(&sb, "- %s %s (%.0f%% confidence), %s ago: %s\n",
+			b.Symbol, b.Action, b.Confidence, formatAge(now.Sub(b.Timestamp)), reason)
+	}
+	sb.WriteString("These were NOT executed. Don't repeat a rejected decision unchanged: adjust it so the rule no longer applies, or HOLD.\n")
+	return sb.String()
+}
+
+// formatAge renders d as minutes, hours or days
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
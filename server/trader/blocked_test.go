@@ -0,0 +1,38 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+func TestRecentBlocksRollAndRender(t *testing.T) {
+	now := time.Now()
+	e := &Engine{}
+	for i := 0; i < maxRecentBlocks+3; i++ {
+		e.recordBlock(&store.BlockedDecision{Symbol: fmt.Sprintf("COIN%dUSDT", i), Action: "BUY", Confidence: 75,
+			Timestamp: now.Add(time.Duration(i-20) * time.Minute), Reason: "symbol in cooldown"})
+	}
+	blocks := e.recentBlockedDecisions()
+	if len(blocks) != maxRecentBlocks || blocks[0].Symbol != "COIN12USDT" {
+		t.Fatalf("kept %d blocks starting at %s, want %d starting at the newest", len(blocks), blocks[0].Symbol, maxRecentBlocks)
+	}
+
+	blocks[0].Reason = strings.Repeat("x", 500)
+	section := formatRecentBlocks(blocks, now)
+	if !strings.Contains(section, "Recently Rejected Decisions") || !strings.Contains(section, "NOT executed") {
+		t.Errorf("section missing its heading or guidance:\n%s", section)
+	}
+	if n := strings.Count(section, "\n- "); n != promptRecentBlocks {
+		t.Errorf("section lists %d blocks, want %d", n, promptRecentBlocks)
+	}
+	if !strings.Contains(section, "COIN12USDT BUY (75% confidence), 8m ago: "+strings.Repeat("x", maxBlockReasonLen)+"...\n") {
+		t.Errorf("newest block not first or its reason not truncated:\n%s", section)
+	}
+	if formatRecentBlocks(nil, now) != "" {
+		t.Error("no blocks rendered a section")
+	}
+}
@@ -0,0 +1,171 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// ErrCircuitBreakerNotTripped is returned when acknowledging a circuit breaker
+// that isn't tripped
+var ErrCircuitBreakerNotTripped = errors.New("circuit breaker is not tripped")
+
+// OverviewCircuitBreaker is the equity circuit breaker's state and limits
+type OverviewCircuitBreaker struct {
+	Tripped              bool       `json:"tripped"`
+	Reason               string     `json:"reason,omitempty"` // total_drawdown or consecutive_losses
+	Message              string     `json:"message,omitempty"`
+	TrippedAt            *time.Time `json:"tripped_at"`
+	PeakEquity           float64    `json:"peak_equity"`
+	DrawdownPct          float64    `json:"drawdown_pct"` // From the peak, 0 without a live account
+	MaxTotalDrawdownPct  float64    `json:"max_total_drawdown_pct"`
+	LosingStreak         int        `json:"losing_streak"`
+	MaxConsecutiveLosses int        `json:"max_consecutive_losses"`
+}
+
+// newOverviewCircuitBreaker fills in the overview from the breaker's state.
+// equity is 0 when the account isn't known.
+func newOverviewCircuitBreaker(trip *store.CircuitBreakerTrip, peak, equity float64, streak int, rc store.RiskControlConfig) OverviewCircuitBreaker {
+	o := OverviewCircuitBreaker{
+		PeakEquity:           peak,
+		DrawdownPct:          drawdownPct(peak, equity),
+		MaxTotalDrawdownPct:  rc.MaxTotalDrawdownPct,
+		LosingStreak:         streak,
+		MaxConsecutiveLosses: rc.MaxConsecutiveLosses,
+	}
+	if trip != nil {
+		trippedAt := trip.TrippedAt
+		o.Tripped, o.Reason, o.Message, o.TrippedAt = true, trip.Reason, trip.Message, &trippedAt
+	}
+	return o
+}
+
+// drawdownPct is how far equity is below peak, in percent
+func drawdownPct(peak, equity float64) float64 {
+	if peak <= 0 || equity <= 0 || equity >= peak {
+		return 0
+	}
+	return (peak - equity) / peak * 100
+}
+
+// evaluateCircuitBreaker returns the trip the limits call for, or nil when
+// neither is breached
+func evaluateCircuitBreaker(rc store.RiskControlConfig, peak, equity float64, streak int, now time.Time) *store.CircuitBreakerTrip {
+	if rc.MaxTotalDrawdownPct > 0 {
+		if dd := drawdownPct(peak, equity); dd >= rc.MaxTotalDrawdownPct {
+			return &store.CircuitBreakerTrip{
+				Reason:    store.CircuitBreakerTotalDrawdown,
+				Message:   fmt.Sprintf("equity $%.2f is %.2f%% below its peak $%.2f (limit %.2f%%)", equity, dd, peak, rc.MaxTotalDrawdownPct),
+				TrippedAt: now,
+			}
+		}
+	}
+	if rc.MaxConsecutiveLosses > 0 && streak >= rc.MaxConsecutiveLosses {
+		return &store.CircuitBreakerTrip{
+			Reason:    store.CircuitBreakerConsecutiveLosses,
+			Message:   fmt.Sprintf("%d losing trades in a row (limit %d)", streak, rc.MaxConsecutiveLosses),
+			TrippedAt: now,
+		}
+	}
+	return nil
+}
+
+// checkCircuitBreaker raises the peak equity and trips the breaker when the
+// drawdown from it or the losing streak reaches the strategy's limit. Called
+// after each cycle and risk check.
+func (e *Engine) checkCircuitBreaker() {
+	e.mu.Lock()
+	if e.account == nil {
+		e.mu.Unlock()
+		return
+	}
+	equity := e.account.TotalMarginBalance
+	if equity > e.peakEquity {
+		e.peakEquity = equity
+	}
+	peak, resetAt, tripped := e.peakEquity, e.breakerResetAt, e.circuitBreaker != nil
+	e.mu.Unlock()
+
+	if e.positionStore != nil {
+		streak, err := e.positionStore.LosingStreak(e.id, resetAt)
+		if err != nil {
+			log.Printf("[%s] Failed to get losing streak: %v", e.name, err)
+		} else {
+			e.mu.Lock()
+			e.losingStreak = streak
+			e.mu.Unlock()
+		}
+	}
+
+	if tripped || e.strategy == nil {
+		return
+	}
+
+	e.mu.Lock()
+	trip := evaluateCircuitBreaker(e.strategy.Config.RiskControl, peak, equity, e.losingStreak, time.Now())
+	e.circuitBreaker = trip
+	e.mu.Unlock()
+	if trip == nil {
+		return
+	}
+
+	log.Printf("[%s] 🛑 Circuit breaker tripped: %s. No new positions until acknowledged", e.name, trip.Message)
+	e.recordRiskEvent(store.RiskEventCircuitBreaker, trip.Message)
+	e.saveState()
+}
+
+// circuitBreakerTrip returns the current trip, nil when not tripped
+func (e *Engine) circuitBreakerTrip() *store.CircuitBreakerTrip {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.circuitBreaker
+}
+
+// AcknowledgeCircuitBreaker clears a tripped breaker so the trader opens
+// positions again. The peak is rebased to the current equity and earlier
+// losses stop counting toward the streak.
+func (e *Engine) AcknowledgeCircuitBreaker() (*store.CircuitBreakerTrip, error) {
+	e.mu.Lock()
+	trip := e.circuitBreaker
+	if trip == nil {
+		e.mu.Unlock()
+		return nil, ErrCircuitBreakerNotTripped
+	}
+	e.circuitBreaker = nil
+	e.breakerResetAt = time.Now()
+	e.losingStreak = 0
+	e.peakEquity = 0
+	if e.account != nil {
+		e.peakEquity = e.account.TotalMarginBalance
+	}
+	e.mu.Unlock()
+
+	log.Printf("[%s] Circuit breaker acknowledged, opening positions again", e.name)
+	e.recordRiskEvent(store.RiskEventCircuitBreakerAck, "circuit breaker acknowledged: "+trip.Message)
+	e.saveState()
+	return trip, nil
+}
+
+// acknowledgeSavedCircuitBreaker clears the breaker in a stopped trader's
+// saved state. The peak restarts from the equity at the next start.
+func acknowledgeSavedCircuitBreaker(stateStore *store.EngineStateStore, traderID string) (*store.CircuitBreakerTrip, error) {
+	state, err := stateStore.Get(traderID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.CircuitBreaker == nil {
+		return nil, ErrCircuitBreakerNotTripped
+	}
+
+	trip := state.CircuitBreaker
+	state.CircuitBreaker = nil
+	state.BreakerResetAt = time.Now()
+	state.PeakEquity = 0
+	if err := stateStore.Save(state); err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
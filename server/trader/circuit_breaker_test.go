@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestEvaluateCircuitBreaker tests which limit trips the breaker
+func TestEvaluateCircuitBreaker(t *testing.T) {
+	rc := store.RiskControlConfig{MaxTotalDrawdownPct: 20, MaxConsecutiveLosses: 4}
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		rc           store.RiskControlConfig
+		peak, equity float64
+		streak       int
+		wantReason   string
+	}{
+		{"within limits", rc, 1000, 850, 3, ""},
+		{"drawdown at the limit", rc, 1000, 800, 0, store.CircuitBreakerTotalDrawdown},
+		{"losing streak at the limit", rc, 1000, 990, 4, store.CircuitBreakerConsecutiveLosses},
+		{"disabled", store.RiskControlConfig{}, 1000, 100, 10, ""},
+		{"no peak yet", rc, 0, 500, 0, ""},
+	}
+	for _, tt := range tests {
+		trip := evaluateCircuitBreaker(tt.rc, tt.peak, tt.equity, tt.streak, now)
+		if tt.wantReason == "" {
+			if trip != nil {
+				t.Errorf("%s: tripped with %+v", tt.name, trip)
+			}
+			continue
+		}
+		if trip == nil || trip.Reason != tt.wantReason || !trip.TrippedAt.Equal(now) {
+			t.Errorf("%s: trip = %+v, want %s", tt.name, trip, tt.wantReason)
+		}
+	}
+}
+
+// TestCircuitBreakerBlocksEntriesUntilAcknowledged tests the trip, the entry
+// block and the acknowledgement rebasing the peak
+func TestCircuitBreakerBlocksEntriesUntilAcknowledged(t *testing.T) {
+	strategy := &store.Strategy{}
+	strategy.Config.TradingInterval = 5
+	strategy.Config.RiskControl.MaxTotalDrawdownPct = 10
+	e := &Engine{
+		strategy:   strategy,
+		running:    true,
+		account:    &exchange.AccountInfo{TotalMarginBalance: 1200},
+		peakEquity: 1000,
+		positions:  map[string]*exchange.Position{},
+	}
+
+	e.checkCircuitBreaker()
+	if e.peakEquity != 1200 || e.circuitBreakerTrip() != nil {
+		t.Fatalf("new high: peak %.2f, trip %+v; want peak 1200 and no trip", e.peakEquity, e.circuitBreakerTrip())
+	}
+
+	e.account.TotalMarginBalance = 1050
+	e.checkCircuitBreaker()
+	trip := e.circuitBreakerTrip()
+	if trip == nil || trip.Reason != store.CircuitBreakerTotalDrawdown {
+		t.Fatalf("12.5%% below the peak: trip = %+v, want total_drawdown", trip)
+	}
+	if o := e.Overview().CircuitBreaker; !o.Tripped || o.TrippedAt == nil || o.DrawdownPct != 12.5 {
+		t.Errorf("overview = %+v", o)
+	}
+
+	_, err := e.executeTrade(context.Background(), "BTCUSDT", &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long"}, false, nil)
+	if err == nil || !strings.Contains(err.Error(), "circuit breaker") {
+		t.Errorf("entry while tripped = %v, want it blocked", err)
+	}
+
+	// Staying down doesn't re-trip once acknowledged, the peak is now 1050
+	if _, err := e.AcknowledgeCircuitBreaker(); err != nil {
+		t.Fatalf("AcknowledgeCircuitBreaker: %v", err)
+	}
+	e.checkCircuitBreaker()
+	if e.circuitBreakerTrip() != nil || e.peakEquity != 1050 || e.breakerResetAt.IsZero() {
+		t.Errorf("after acknowledgement: trip %+v, peak %.2f", e.circuitBreakerTrip(), e.peakEquity)
+	}
+	if _, err := e.AcknowledgeCircuitBreaker(); err != ErrCircuitBreakerNotTripped {
+		t.Errorf("second acknowledgement = %v, want ErrCircuitBreakerNotTripped", err)
+	}
+}
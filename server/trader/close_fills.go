@@ -0,0 +1,179 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// PositionStore close reasons for closes made by the engine
+const (
+	closeReasonAI           = "ai_close"
+	closeReasonTrailingStop = "trailing_stop"
+	closeReasonMaxHold      = "max_hold"
+	closeReasonSmartLossCut = "smart_loss_cut"
+	closeReasonDrawdown     = "drawdown"
+	closeReasonDailyLoss    = "daily_loss"
+	closeReasonEmergency    = "emergency_stop"
+	closeReasonShutdown     = "shutdown"
+	closeReasonConnectivity = "connectivity_loss"
+)
+
+const (
+	fillPollAttempts = 3
+	fillPollDelay    = 500 * time.Millisecond
+)
+
+// closeFill is what a closing order actually did
+type closeFill struct {
+	Price     float64 // Quantity-weighted fill price
+	Qty       float64
+	PnL       float64 // Realized P&L before fees
+	Fee       float64 // Commission in USDT
+	Estimated bool    // Local estimate, exchange fills weren't available
+
+	SlippageCost float64 // USDT lost against the mark price the close was decided at, set by resolveCloseFill
+
+	PositionID int64 // PositionStore row settled, set by settleClose
+}
+
+// sumFills totals an order's fills. Commissions paid in other assets (BNB)
+// are converted with priceOf, which returns 0 for unknown assets.
+func sumFills(trades []exchange.Trade, priceOf func(asset string) float64) closeFill {
+	var fill closeFill
+	var value float64
+	for _, t := range trades {
+		fill.Qty += t.Qty
+		value += t.Price * t.Qty
+		fill.PnL += t.RealizedPnL
+
+		switch t.CommissionAsset {
+		case "", "USDT":
+			fill.Fee += t.Commission
+		default:
+			if price := priceOf(t.CommissionAsset); price > 0 {
+				fill.Fee += t.Commission * price
+			}
+		}
+	}
+	if fill.Qty > 0 {
+		fill.Price = value / fill.Qty
+	}
+	return fill
+}
+
+// estimateFill is the local fallback when the order's fills can't be read:
+// the order's average price, or the mark price without one
+func estimateFill(pos *exchange.Position, order *exchange.Order, qty float64) closeFill {
+	fill := closeFill{Price: pos.MarkPrice, Qty: qty, Estimated: true}
+	if order != nil && order.AvgPrice > 0 {
+		fill.Price = order.AvgPrice
+		if order.ExecutedQty > 0 {
+			fill.Qty = order.ExecutedQty
+		}
+	}
+
+	fill.PnL = (fill.Price - pos.EntryPrice) * fill.Qty
+	if pos.PositionAmt < 0 {
+		fill.PnL = -fill.PnL
+	}
+	return fill
+}
+
+// commissionPrice returns the priceOf for sumFills, which prices commission
+// assets in USDT from the ticker
+func (e *Engine) commissionPrice(ctx context.Context, symbol string) func(asset string) float64 {
+	return func(asset string) float64 {
+		ticker, err := e.binance.GetTicker(ctx, asset+"USDT")
+		if err != nil {
+			log.Printf("[%s][%s] Failed to price %s commission: %v", e.name, symbol, asset, err)
+			return 0
+		}
+		return ticker.Price
+	}
+}
+
+// fetchCloseFill waits for a closing order to fill and sums its fills from
+// the exchange
+func (e *Engine) fetchCloseFill(ctx context.Context, symbol string, orderID int64) (closeFill, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := e.binance.GetOrder(ctx, symbol, orderID)
+		if err != nil {
+			return closeFill{}, fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if order.Status == "FILLED" {
+			trades, err := e.binance.GetUserTrades(ctx, symbol, orderID)
+			if err != nil {
+				return closeFill{}, fmt.Errorf("failed to get fills: %w", err)
+			}
+			fill := sumFills(trades, e.commissionPrice(ctx, symbol))
+			// Fills can trail the order status by a moment
+			if fill.Qty >= order.ExecutedQty {
+				return fill, nil
+			}
+		}
+
+		if attempt >= fillPollAttempts {
+			return closeFill{}, fmt.Errorf("order %d not fully reported after %d attempts (status %s)", orderID, attempt, order.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return closeFill{}, ctx.Err()
+		case <-time.After(fillPollDelay):
+		}
+	}
+}
+
+// resolveCloseFill returns the exchange fills of a closing order for qty of
+// pos, falling back to the local estimate
+func (e *Engine) resolveCloseFill(ctx context.Context, pos *exchange.Position, order *exchange.Order, qty float64) closeFill {
+	var fill closeFill
+	if order == nil || order.OrderID == 0 {
+		fill = estimateFill(pos, order, qty)
+	} else if f, err := e.fetchCloseFill(ctx, pos.Symbol, order.OrderID); err != nil {
+		log.Printf("[%s][%s] Using estimated P&L for order %d: %v", e.name, pos.Symbol, order.OrderID, err)
+		fill = estimateFill(pos, order, qty)
+	} else {
+		fill = f
+	}
+	// Closing a long sells, closing a short buys
+	fill.SlippageCost = slippageCost(pos.PositionAmt < 0, pos.MarkPrice, fill.Price, fill.Qty)
+	return fill
+}
+
+// settleClose records a full close of pos in the PositionStore with the
+// order's actual fills and returns them
+func (e *Engine) settleClose(ctx context.Context, pos *exchange.Position, order *exchange.Order, reason string) closeFill {
+	fill := e.resolveCloseFill(ctx, pos, order, math.Abs(pos.PositionAmt))
+
+	log.Printf("[%s][%s] Close settled: %.4f @ $%.4f, P&L $%.2f, fee $%.4f (estimated: %v)",
+		e.name, pos.Symbol, fill.Qty, fill.Price, fill.PnL, fill.Fee, fill.Estimated)
+
+	if e.positionStore == nil {
+		return fill
+	}
+	row, err := e.positionStore.GetOpenPositionBySymbol(e.id, pos.Symbol, rowSide(pos.PositionAmt))
+	if err != nil {
+		log.Printf("[%s][%s] Failed to load position row for close: %v", e.name, pos.Symbol, err)
+		return fill
+	}
+	if row == nil {
+		return fill
+	}
+	if err := e.positionStore.ClosePosition(row.ID, fill.Price, fill.Fee, fill.PnL, reason, fill.Estimated); err != nil {
+		log.Printf("[%s][%s] Failed to record close: %v", e.name, pos.Symbol, err)
+		return fill
+	}
+	fill.PositionID = row.ID
+	e.annotateLargeLoss(pos.Symbol, fill.PnL, reason)
+	e.recordSlippage(row.ID, pos.Symbol, fill.SlippageCost)
+	e.recordFill(row, store.PositionEventClosed, fill.Price, fill.Qty, fill.PnL, fill.Fee, closeEventSource(reason), reason)
+	return fill
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"math"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// TestSumFills tests totalling an order's fills, including BNB commission
+func TestSumFills(t *testing.T) {
+	trades := []exchange.Trade{
+		{Price: 100, Qty: 1, RealizedPnL: 5, Commission: 0.05, CommissionAsset: "USDT"},
+		{Price: 103, Qty: 2, RealizedPnL: 16, Commission: 0.0002, CommissionAsset: "BNB"},
+		{Price: 103, Qty: 1, RealizedPnL: 8, Commission: 1, CommissionAsset: "XYZ"}, // Unpriced, ignored
+	}
+	prices := map[string]float64{"BNB": 500}
+
+	fill := sumFills(trades, func(asset string) float64 { return prices[asset] })
+
+	if fill.Qty != 4 {
+		t.Errorf("qty = %f, want 4", fill.Qty)
+	}
+	if math.Abs(fill.Price-102.25) > 0.0001 {
+		t.Errorf("price = %f, want 102.25", fill.Price)
+	}
+	if fill.PnL != 29 {
+		t.Errorf("P&L = %f, want 29", fill.PnL)
+	}
+	if math.Abs(fill.Fee-0.15) > 0.0001 {
+		t.Errorf("fee = %f, want 0.15", fill.Fee)
+	}
+	if fill.Estimated {
+		t.Error("exchange fills should not be flagged as estimated")
+	}
+}
+
+// TestEstimateFill tests the local P&L fallback for long and short closes
+func TestEstimateFill(t *testing.T) {
+	tests := []struct {
+		name      string
+		pos       *exchange.Position
+		order     *exchange.Order
+		qty       float64
+		wantPrice float64
+		wantPnL   float64
+	}{
+		{
+			name:      "Long with order fill price",
+			pos:       &exchange.Position{PositionAmt: 2, EntryPrice: 100, MarkPrice: 104},
+			order:     &exchange.Order{AvgPrice: 105, ExecutedQty: 2},
+			qty:       2,
+			wantPrice: 105,
+			wantPnL:   10,
+		},
+		{
+			name:      "Short without order falls back to mark price",
+			pos:       &exchange.Position{PositionAmt: -2, EntryPrice: 100, MarkPrice: 104},
+			qty:       1,
+			wantPrice: 104,
+			wantPnL:   -4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fill := estimateFill(tt.pos, tt.order, tt.qty)
+			if fill.Price != tt.wantPrice {
+				t.Errorf("price = %f, want %f", fill.Price, tt.wantPrice)
+			}
+			if math.Abs(fill.PnL-tt.wantPnL) > 0.0001 {
+				t.Errorf("P&L = %f, want %f", fill.PnL, tt.wantPnL)
+			}
+			if !fill.Estimated {
+				t.Error("local estimate should be flagged")
+			}
+		})
+	}
+}
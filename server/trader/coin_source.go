@@ -0,0 +1,294 @@
+package trader
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// Coin origins, for where a symbol of the trading universe came from
+const (
+	CoinOriginStatic  = "static"  // The strategy's static_coins, kept up to date by Smart Find
+	CoinOriginDynamic = "dynamic" // Top volume coins
+	CoinOriginRemote  = "remote"  // The strategy's remote list
+	CoinOriginDefault = "default" // The server's TRADING_PAIRS
+	CoinOriginPinned  = "pinned"  // Pinned through the API
+)
+
+const (
+	defaultRemoteCoinRefresh = 60 * time.Minute
+	defaultRemoteMaxSymbols  = 30
+	remoteCoinListTimeout    = 15 * time.Second
+	maxRemoteCoinListBytes   = 1 << 20
+)
+
+var coinSymbolPattern = regexp.MustCompile(`^[A-Z0-9]{2,30}$`)
+
+// CoinCandidate is a symbol of a trader's coin universe and its origin
+type CoinCandidate struct {
+	Symbol string `json:"symbol"`
+	Origin string `json:"origin"`
+}
+
+// remoteCoinList is the last list fetched from a strategy's remote source
+type remoteCoinList struct {
+	url       string
+	etag      string
+	symbols   []string
+	fetchedAt time.Time
+}
+
+// ParseCoinList reads symbols from a JSON array of strings or of objects with
+// a symbol field, a JSON object with a symbols array, or CSV: one row of
+// symbols, or one symbol per row in the first column. Symbols are upper-cased
+// and deduplicated; a header and anything that isn't a symbol are skipped.
+func ParseCoinList(body []byte) ([]string, error) {
+	body = bytes.TrimSpace(body)
+	var raw []string
+	switch {
+	case len(body) == 0:
+		return nil, fmt.Errorf("empty list")
+	case body[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("invalid JSON list: %w", err)
+		}
+		for _, item := range items {
+			var symbol string
+			if err := json.Unmarshal(item, &symbol); err != nil {
+				var obj struct {
+					Symbol string `json:"symbol"`
+				}
+				if err := json.Unmarshal(item, &obj); err != nil {
+					return nil, fmt.Errorf("invalid JSON list entry %s", item)
+				}
+				symbol = obj.Symbol
+			}
+			raw = append(raw, symbol)
+		}
+	case body[0] == '{':
+		var obj struct {
+			Symbols []string `json:"symbols"`
+		}
+		if err := json.Unmarshal(body, &obj); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %w", err)
+		}
+		raw = obj.Symbols
+	default:
+		r := csv.NewReader(bytes.NewReader(body))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(records) == 1 {
+			raw = records[0]
+		} else {
+			for _, record := range records {
+				raw = append(raw, record[0])
+			}
+		}
+	}
+
+	symbols := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, s := range raw {
+		symbol := strings.ToUpper(strings.TrimSpace(s))
+		if !coinSymbolPattern.MatchString(symbol) || symbol == "SYMBOL" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols in list")
+	}
+	return symbols, nil
+}
+
+// fetchCoinList downloads and parses the list at url. With the ETag of the
+// last download it returns notModified when the list hasn't changed.
+func fetchCoinList(ctx context.Context, client *http.Client, url, etag string) (symbols []string, newETag string, notModified bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteCoinListTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	req.Header.Set("Accept", "application/json, text/csv;q=0.9, */*;q=0.5")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteCoinListBytes))
+	if err != nil {
+		return nil, "", false, err
+	}
+	symbols, err = ParseCoinList(body)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return symbols, resp.Header.Get("ETag"), false, nil
+}
+
+// remoteCoins returns the strategy's remote list, fetched again once it is
+// older than the refresh interval, and capped at its max symbols. A failed
+// fetch keeps the last list, empty until one succeeds.
+func (e *Engine) remoteCoins(ctx context.Context, cfg *store.CoinSourceConfig) []string {
+	refresh := defaultRemoteCoinRefresh
+	if cfg.RemoteRefreshMins > 0 {
+		refresh = time.Duration(cfg.RemoteRefreshMins) * time.Minute
+	}
+	maxSymbols := defaultRemoteMaxSymbols
+	if cfg.RemoteMaxSymbols > 0 {
+		maxSymbols = cfg.RemoteMaxSymbols
+	}
+
+	e.coinMu.Lock()
+	list := e.remoteList
+	if list == nil || list.url != cfg.RemoteURL {
+		list = &remoteCoinList{url: cfg.RemoteURL}
+		e.remoteList = list
+	}
+	url, etag := list.url, list.etag
+	stale := time.Since(list.fetchedAt) >= refresh
+	if stale {
+		// Claim the refresh; a failure retries at the next one rather than every call
+		list.fetchedAt = time.Now()
+	}
+	e.coinMu.Unlock()
+
+	if stale {
+		symbols, newETag, notModified, err := fetchCoinList(ctx, http.DefaultClient, url, etag)
+		e.coinMu.Lock()
+		switch {
+		case err != nil:
+			log.Printf("[%s] Failed to fetch remote coin list, keeping %d symbols: %v", e.name, len(list.symbols), err)
+		case !notModified:
+			log.Printf("[%s] Updated remote coin list: %v", e.name, symbols)
+			list.symbols, list.etag = symbols, newETag
+		}
+		e.coinMu.Unlock()
+	}
+
+	e.coinMu.Lock()
+	defer e.coinMu.Unlock()
+	if len(list.symbols) > maxSymbols {
+		return append([]string(nil), list.symbols[:maxSymbols]...)
+	}
+	return append([]string(nil), list.symbols...)
+}
+
+// loadCoinOverrides reads the trader's pinned and banned symbols
+func (e *Engine) loadCoinOverrides() {
+	if e.coinOverrideStore == nil {
+		return
+	}
+	overrides, err := e.coinOverrideStore.List(e.id)
+	if err != nil {
+		log.Printf("[%s] Failed to load coin pins and bans: %v", e.name, err)
+		return
+	}
+	e.coinMu.Lock()
+	e.coinOverrides = overrides
+	e.coinMu.Unlock()
+}
+
+// ReloadCoinOverrides picks up pins and bans changed through the API
+func (e *Engine) ReloadCoinOverrides() {
+	e.loadCoinOverrides()
+}
+
+// CoinUniverse returns the symbols the trader analyzes with their origins:
+// the coin source's symbols and the pinned ones, minus the banned ones
+func (e *Engine) CoinUniverse() []CoinCandidate {
+	symbols, origin := e.sourceCoins()
+
+	e.coinMu.Lock()
+	overrides := e.coinOverrides
+	e.coinMu.Unlock()
+	return applyCoinOverrides(symbols, origin, overrides)
+}
+
+// applyCoinOverrides adds the pinned symbols to a source's and drops the
+// banned ones. Pins come first so they are always analyzed.
+func applyCoinOverrides(symbols []string, origin string, overrides []*store.CoinOverride) []CoinCandidate {
+	banned := make(map[string]bool)
+	universe := make([]CoinCandidate, 0, len(symbols)+len(overrides))
+	seen := make(map[string]bool)
+	for _, o := range overrides {
+		switch o.Kind {
+		case store.CoinOverrideBan:
+			banned[o.Symbol] = true
+		case store.CoinOverridePin:
+			if !seen[o.Symbol] {
+				seen[o.Symbol] = true
+				universe = append(universe, CoinCandidate{Symbol: o.Symbol, Origin: CoinOriginPinned})
+			}
+		}
+	}
+	for _, symbol := range symbols {
+		if banned[symbol] || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		universe = append(universe, CoinCandidate{Symbol: symbol, Origin: origin})
+	}
+	return universe
+}
+
+// coinOrigin returns where symbol of the trader's universe came from, empty
+// when it isn't in it
+func coinOrigin(universe []CoinCandidate, symbol string) string {
+	for _, c := range universe {
+		if c.Symbol == symbol {
+			return c.Origin
+		}
+	}
+	return ""
+}
+
+// withHeldSymbols appends the held symbols pairs doesn't list. They stay in
+// the analysis set, position-only, until their position closes, so the AI and
+// the risk rules keep fresh data after the universe drops a held symbol.
+func withHeldSymbols(pairs, held []string) []string {
+	all := append([]string(nil), pairs...)
+	for _, symbol := range held {
+		if !slices.Contains(all, symbol) {
+			all = append(all, symbol)
+		}
+	}
+	return all
+}
+
+// positionOnly reports whether symbol is held but no longer in the coin
+// universe: its position is managed, new entries and adds are blocked
+func (e *Engine) positionOnly(symbol string, pos *exchange.Position) bool {
+	return pos != nil && pos.PositionAmt != 0 && coinOrigin(e.CoinUniverse(), symbol) == ""
+}
@@ -0,0 +1,107 @@
+package trader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+func TestParseCoinList(t *testing.T) {
+	want := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+	for name, body := range map[string]string{
+		"json strings": `["btcusdt", "ETHUSDT", "SOLUSDT", "BTCUSDT"]`,
+		"json objects": `[{"symbol":"BTCUSDT","score":3},{"symbol":"ETHUSDT"},{"symbol":"SOLUSDT"}]`,
+		"json object":  `{"symbols":["BTCUSDT","ETHUSDT","SOLUSDT"]}`,
+		"csv row":      "BTCUSDT, ETHUSDT, SOLUSDT\n",
+		"csv column":   "symbol,score\nBTCUSDT,3\nETHUSDT,2\nSOLUSDT,1\n",
+	} {
+		got, err := ParseCoinList([]byte(body))
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("%s: ParseCoinList = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	for _, body := range []string{"", "[1, 2]", "{not json", "n/a"} {
+		if got, err := ParseCoinList([]byte(body)); err == nil {
+			t.Errorf("ParseCoinList(%q) = %v, want an error", body, got)
+		}
+	}
+}
+
+func TestFetchCoinListETag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`["BTCUSDT","ETHUSDT"]`))
+	}))
+	defer srv.Close()
+
+	symbols, etag, notModified, err := fetchCoinList(context.Background(), srv.Client(), srv.URL, "")
+	if err != nil || notModified || etag != `"v1"` || len(symbols) != 2 {
+		t.Fatalf("first fetch = %v %q %v %v", symbols, etag, notModified, err)
+	}
+	symbols, etag, notModified, err = fetchCoinList(context.Background(), srv.Client(), srv.URL, etag)
+	if err != nil || !notModified || etag != `"v1"` || symbols != nil {
+		t.Errorf("conditional fetch = %v %q %v %v, want not modified", symbols, etag, notModified, err)
+	}
+}
+
+func TestApplyCoinOverrides(t *testing.T) {
+	overrides := []*store.CoinOverride{
+		{Symbol: "DOGEUSDT", Kind: store.CoinOverridePin},
+		{Symbol: "ETHUSDT", Kind: store.CoinOverrideBan},
+		{Symbol: "SOLUSDT", Kind: store.CoinOverridePin},
+	}
+	got := applyCoinOverrides([]string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}, CoinOriginRemote, overrides)
+	want := []CoinCandidate{
+		{Symbol: "DOGEUSDT", Origin: CoinOriginPinned},
+		{Symbol: "SOLUSDT", Origin: CoinOriginPinned},
+		{Symbol: "BTCUSDT", Origin: CoinOriginRemote},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("applyCoinOverrides = %v, want %v", got, want)
+	}
+	if origin := coinOrigin(got, "ETHUSDT"); origin != "" {
+		t.Errorf("banned symbol has origin %q", origin)
+	}
+}
+
+func TestHeldSymbolDroppedFromUniverse(t *testing.T) {
+	strategy := &store.Strategy{}
+	strategy.Config.CoinSource.StaticCoins = []string{"BTCUSDT", "SOLUSDT"}
+	sol := &exchange.Position{Symbol: "SOLUSDT", PositionAmt: 10, EntryPrice: 150}
+	e := &Engine{strategy: strategy, running: true, positions: map[string]*exchange.Position{"SOLUSDT": sol}}
+
+	// A refresh swaps the held symbol out
+	strategy.Config.CoinSource.StaticCoins = []string{"BTCUSDT", "ETHUSDT"}
+
+	pairs := withHeldSymbols(e.getTradingPairs(), []string{"SOLUSDT"})
+	if want := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}; !slices.Equal(pairs, want) {
+		t.Fatalf("analyzed pairs = %v, want %v", pairs, want)
+	}
+	if !e.positionOnly("SOLUSDT", sol) || e.positionOnly("ETHUSDT", nil) {
+		t.Errorf("only the dropped held symbol should be position-only")
+	}
+
+	for _, action := range []string{"BUY", "SELL", "add_to_long"} {
+		_, err := e.executeTrade(context.Background(), "SOLUSDT", &ai.TradingDecision{Symbol: "SOLUSDT", Action: action}, true, sol)
+		if err == nil || !strings.Contains(err.Error(), "position-only") {
+			t.Errorf("%s on position-only symbol = %v, want it blocked", action, err)
+		}
+	}
+
+	// Once closed it leaves the analysis set
+	if pairs := withHeldSymbols(e.getTradingPairs(), nil); slices.Contains(pairs, "SOLUSDT") {
+		t.Errorf("closed symbol still analyzed: %v", pairs)
+	}
+}
@@ -0,0 +1,237 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/report"
+	"auto-trader-ahh/store"
+)
+
+const (
+	coldSnapshotTTL     = 15 * time.Second // How long a stopped trader's exchange snapshot is reused
+	coldSnapshotTimeout = 10 * time.Second
+)
+
+// errNoCredentials is returned for a stopped trader without exchange keys
+var errNoCredentials = errors.New("no exchange credentials configured")
+
+// coldSnapshot is a stopped trader's account and open positions fetched
+// straight from its exchange account
+type coldSnapshot struct {
+	account   *exchange.AccountInfo
+	positions []exchange.Position
+	fetchedAt time.Time
+}
+
+// coldSnapshots caches stopped traders' snapshots by trader, and the clients
+// they're fetched with by credentials
+type coldSnapshots struct {
+	mu        sync.Mutex
+	clients   map[string]*exchange.BinanceClient
+	snapshots map[string]*coldSnapshot
+
+	newClient func(apiKey, secretKey string, testnet bool) *exchange.BinanceClient
+}
+
+func newColdSnapshots() *coldSnapshots {
+	return &coldSnapshots{
+		clients:   make(map[string]*exchange.BinanceClient),
+		snapshots: make(map[string]*coldSnapshot),
+		newClient: exchange.NewBinanceClient,
+	}
+}
+
+// get returns traderID's snapshot, fetched again once older than
+// coldSnapshotTTL. Open positions another running trader owns on a shared
+// account are left out.
+func (c *coldSnapshots) get(ctx context.Context, traderID, apiKey, secretKey string, testnet bool) (*coldSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if snap, ok := c.snapshots[traderID]; ok && time.Since(snap.fetchedAt) < coldSnapshotTTL {
+		return snap, nil
+	}
+
+	client := c.client(apiKey, secretKey, testnet)
+	account, err := client.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	all, err := client.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	snap := &coldSnapshot{account: account, fetchedAt: time.Now()}
+	accountKey := client.AccountFingerprint()
+	for _, pos := range all {
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		if held, ok := accountOwners.owner(accountKey, pos.Symbol); ok && held.id != traderID {
+			continue
+		}
+		snap.positions = append(snap.positions, pos)
+	}
+	sort.Slice(snap.positions, func(i, j int) bool { return snap.positions[i].Symbol < snap.positions[j].Symbol })
+	c.snapshots[traderID] = snap
+	return snap, nil
+}
+
+// client returns the cached client for the credentials, creating it on first
+// use. The caller holds c.mu.
+func (c *coldSnapshots) client(apiKey, secretKey string, testnet bool) *exchange.BinanceClient {
+	credentials := fmt.Sprintf("%s|%s|%t", apiKey, secretKey, testnet)
+	client, ok := c.clients[credentials]
+	if !ok {
+		client = c.newClient(apiKey, secretKey, testnet)
+		c.clients[credentials] = client
+	}
+	return client
+}
+
+// coldSnapshot fetches a stopped trader's snapshot with its exchange keys
+func (m *EngineManager) coldSnapshot(trader *store.Trader) (*coldSnapshot, error) {
+	apiKey, secretKey, testnet := m.exchangeCredentials(trader)
+	if apiKey == "" {
+		return nil, errNoCredentials
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), coldSnapshotTimeout)
+	defer cancel()
+	return m.cold.get(ctx, trader.ID, apiKey, secretKey, testnet)
+}
+
+// coldStatus is the status of a stopped trader, with the positions on its
+// exchange account
+func (m *EngineManager) coldStatus(traderID string) map[string]interface{} {
+	status := map[string]interface{}{
+		"running":   false,
+		"live":      false,
+		"trader_id": traderID,
+		"message":   "Trader not running",
+	}
+	trader, err := m.traderStore.Get(traderID)
+	if err != nil {
+		return status
+	}
+	status["trader_name"] = trader.Name
+
+	snap, err := m.coldSnapshot(trader)
+	if err != nil {
+		status["snapshot_error"] = err.Error()
+		return status
+	}
+	positions := make([]map[string]interface{}, 0, len(snap.positions))
+	for _, pos := range snap.positions {
+		positions = append(positions, map[string]interface{}{
+			"symbol":    pos.Symbol,
+			"amount":    pos.PositionAmt,
+			"entry":     pos.EntryPrice,
+			"markPrice": pos.MarkPrice,
+			"pnl":       pos.UnrealizedProfit,
+			"leverage":  pos.Leverage,
+		})
+	}
+	status["positions"] = positions
+	status["fetched_at"] = snap.fetchedAt
+	return status
+}
+
+// coldAccount is a stopped trader's account from its exchange account, with
+// the realized P&L and fees of its closed positions
+func (m *EngineManager) coldAccount(traderID string) map[string]interface{} {
+	trader, err := m.traderStore.Get(traderID)
+	if err != nil {
+		return map[string]interface{}{"error": "Trader not running", "live": false}
+	}
+	snap, err := m.coldSnapshot(trader)
+	if err != nil {
+		return map[string]interface{}{"error": fmt.Sprintf("Trader not running, account unavailable: %v", err), "live": false}
+	}
+
+	day, _ := report.StrategyDay(m.traderStrategy(trader))
+	realized, err := store.NewPositionStore().GetRealizedTotals(traderID, day.Start(time.Now()))
+	if err != nil {
+		return map[string]interface{}{"error": fmt.Sprintf("failed to load realized P&L: %v", err), "live": false}
+	}
+
+	return map[string]interface{}{
+		"total_equity":       snap.account.TotalMarginBalance,
+		"wallet_balance":     snap.account.TotalWalletBalance,
+		"available":          snap.account.AvailableBalance,
+		"unrealized_pnl":     snap.account.TotalUnrealizedProfit,
+		"realized_pnl_today": realized.RealizedPnLToday,
+		"realized_pnl_total": realized.RealizedPnLTotal,
+		"fees_total":         realized.FeesTotal,
+		"live":               false,
+		"fetched_at":         snap.fetchedAt,
+	}
+}
+
+// coldPositions is a stopped trader's open positions on its exchange account
+// merged with its open position rows, for their entry time and source. A row
+// without a position on the exchange is listed with on_exchange false; on a
+// failed fetch every row is, alongside the error.
+func (m *EngineManager) coldPositions(traderID string) ([]map[string]interface{}, error) {
+	rows, err := store.NewPositionStore().GetOpenPositions(traderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load open positions: %w", err)
+	}
+
+	var snap *coldSnapshot
+	trader, err := m.traderStore.Get(traderID)
+	if err == nil {
+		snap, err = m.coldSnapshot(trader)
+	}
+
+	positions := make([]map[string]interface{}, 0, len(rows))
+	merged := make(map[int]bool)
+	if snap != nil {
+		for i := range snap.positions {
+			pos := &snap.positions[i]
+			view := positionView(pos)
+			view["on_exchange"] = true
+			for j, row := range rows {
+				if !merged[j] && row.Symbol == pos.Symbol && row.Side == rowSide(pos.PositionAmt) {
+					addRowMetadata(view, &row)
+					merged[j] = true
+					break
+				}
+			}
+			positions = append(positions, view)
+		}
+	}
+	for j, row := range rows {
+		if merged[j] {
+			continue
+		}
+		amount := row.Quantity
+		if row.Side == "short" {
+			amount = -amount
+		}
+		view := map[string]interface{}{
+			"symbol":      row.Symbol,
+			"side":        map[bool]string{true: "LONG", false: "SHORT"}[amount > 0],
+			"amount":      amount,
+			"entry_price": row.EntryPrice,
+			"leverage":    row.Leverage,
+			"on_exchange": false,
+		}
+		addRowMetadata(view, &row)
+		positions = append(positions, view)
+	}
+	return positions, err
+}
+
+// addRowMetadata adds what the trader tracks locally about a position
+func addRowMetadata(view map[string]interface{}, row *store.TraderPosition) {
+	view["position_id"] = row.ID
+	view["entry_time"] = row.EntryTime
+	view["source"] = row.Source
+}
@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/exchange/paper"
+	"auto-trader-ahh/store"
+)
+
+func TestStoppedTraderReadsExchange(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	exchange.SetRequestRate(0)
+	t.Cleanup(func() { exchange.SetRequestRate(exchange.DefaultRequestsPerSec) })
+
+	const secret = "paper-secret"
+	sim := paper.New(10000, secret)
+	sim.AddSymbol("BTCUSDT", 60000, 3)
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+	client := exchange.NewBinanceClientAt("paper-key", secret, srv.URL)
+
+	m := NewEngineManager(&config.Config{}, events.NewHub())
+	m.cold.newClient = func(apiKey, secretKey string, testnet bool) *exchange.BinanceClient {
+		return exchange.NewBinanceClientAt(apiKey, secretKey, srv.URL)
+	}
+	tr := &store.Trader{Name: "cold", Config: store.TraderConfig{APIKey: "paper-key", SecretKey: secret}}
+	if err := store.NewTraderStore().Create(tr); err != nil {
+		t.Fatal(err)
+	}
+
+	// A long opened while running, and a short row whose position closed
+	// while the trader was stopped
+	ctx := context.Background()
+	if _, err := client.PlaceOrder(ctx, "BTCUSDT", "BUY", "MARKET", 0.01, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	positions := store.NewPositionStore()
+	entry := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, row := range []*store.TraderPosition{
+		{TraderID: tr.ID, Symbol: "BTCUSDT", Side: "long", EntryQuantity: 0.01, Quantity: 0.01, EntryPrice: 60000, EntryTime: entry, Status: store.PositionStatusOpen, Source: "system"},
+		{TraderID: tr.ID, Symbol: "ETHUSDT", Side: "short", EntryQuantity: 1, Quantity: 1, EntryPrice: 3000, EntryTime: entry, Status: store.PositionStatusOpen, Source: "manual"},
+	} {
+		if _, err := positions.Create(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, live, err := m.GetPositions(tr.ID)
+	if err != nil || live {
+		t.Fatalf("GetPositions: live %v, err %v", live, err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("positions = %v, want the exchange's and the stale row", got)
+	}
+	btc, eth := got[0], got[1]
+	if btc["symbol"] != "BTCUSDT" || btc["on_exchange"] != true || btc["amount"] != 0.01 || btc["source"] != "system" {
+		t.Errorf("BTCUSDT = %v, want the exchange position with its row", btc)
+	}
+	if at, _ := btc["entry_time"].(time.Time); !at.Equal(entry) {
+		t.Errorf("entry_time = %v, want %v", btc["entry_time"], entry)
+	}
+	if eth["symbol"] != "ETHUSDT" || eth["on_exchange"] != false || eth["amount"] != -1.0 || eth["source"] != "manual" {
+		t.Errorf("ETHUSDT = %v, want the row off the exchange", eth)
+	}
+
+	account := m.GetAccount(tr.ID)
+	if account["live"] != false || account["error"] != nil || account["wallet_balance"].(float64) <= 0 {
+		t.Errorf("account = %v", account)
+	}
+	status := m.GetStatus(tr.ID)
+	if status["live"] != false || status["trader_name"] != "cold" || len(status["positions"].([]map[string]interface{})) != 1 {
+		t.Errorf("status = %v", status)
+	}
+
+	// Within the TTL the snapshot is reused
+	if _, err := client.ClosePosition(ctx, "BTCUSDT", 0.01); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := m.GetPositions(tr.ID); got[0]["on_exchange"] != true {
+		t.Errorf("snapshot refetched within the TTL: %v", got)
+	}
+}
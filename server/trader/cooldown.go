@@ -0,0 +1,97 @@
+package trader
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/store"
+)
+
+// cooldownRemaining returns how much longer a symbol closed at rec must wait
+// before it can be reopened under rc. Stop-loss closes use the SL cooldown.
+func cooldownRemaining(rc *store.RiskControlConfig, rec *store.CloseRecord, now time.Time) time.Duration {
+	if rc == nil || rec == nil {
+		return 0
+	}
+
+	mins := rc.CooldownMinsAfterClose
+	if rec.StopLoss {
+		mins = rc.CooldownMinsAfterStopLoss
+	}
+	if mins <= 0 {
+		return 0
+	}
+
+	remaining := rec.ClosedAt.Add(time.Duration(mins) * time.Minute).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// recordClose starts a symbol's re-entry cooldown
+func (e *Engine) recordClose(symbol string, stopLoss bool) {
+	e.mu.Lock()
+	e.lastCloses[symbol] = &store.CloseRecord{ClosedAt: time.Now(), StopLoss: stopLoss}
+	e.mu.Unlock()
+}
+
+// getCooldown returns the time left before symbol can be reopened and whether
+// it was stopped out
+func (e *Engine) getCooldown(symbol string) (time.Duration, bool) {
+	if e.strategy == nil {
+		return 0, false
+	}
+
+	e.mu.RLock()
+	rec := e.lastCloses[symbol]
+	e.mu.RUnlock()
+
+	if rec == nil {
+		return 0, false
+	}
+	return cooldownRemaining(&e.strategy.Config.RiskControl, rec, time.Now()), rec.StopLoss
+}
+
+// activeCooldownsLocked lists the symbols still in cooldown for the AI
+// context. Caller must hold e.mu.
+func (e *Engine) activeCooldownsLocked() []decision.SymbolCooldown {
+	if e.strategy == nil {
+		return nil
+	}
+	rc := &e.strategy.Config.RiskControl
+	now := time.Now()
+
+	var cooldowns []decision.SymbolCooldown
+	for symbol, rec := range e.lastCloses {
+		if remaining := cooldownRemaining(rc, rec, now); remaining > 0 {
+			cooldowns = append(cooldowns, decision.SymbolCooldown{
+				Symbol:        symbol,
+				RemainingMins: int(math.Ceil(remaining.Minutes())),
+				AfterStopLoss: rec.StopLoss,
+			})
+		}
+	}
+	sort.Slice(cooldowns, func(i, j int) bool { return cooldowns[i].Symbol < cooldowns[j].Symbol })
+	return cooldowns
+}
+
+// checkCooldown logs and returns remaining minutes if symbol is still in
+// cooldown, 0 otherwise
+func (e *Engine) checkCooldown(symbol string) int {
+	remaining, stopLoss := e.getCooldown(symbol)
+	if remaining <= 0 {
+		return 0
+	}
+
+	mins := int(math.Ceil(remaining.Minutes()))
+	kind := "close"
+	if stopLoss {
+		kind = "stop loss"
+	}
+	log.Printf("[%s][%s] ⏳ Symbol in cooldown after %s for %d more minutes, skipping open", e.name, symbol, kind, mins)
+	return mins
+}
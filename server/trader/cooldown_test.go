@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// TestCooldownRemaining tests the re-entry cooldown after normal and stop-loss closes
+func TestCooldownRemaining(t *testing.T) {
+	now := time.Now()
+	rc := &store.RiskControlConfig{
+		CooldownMinsAfterClose:    15,
+		CooldownMinsAfterStopLoss: 60,
+	}
+
+	tests := []struct {
+		name string
+		rc   *store.RiskControlConfig
+		rec  *store.CloseRecord
+		want time.Duration
+	}{
+		{
+			name: "Normal close 5 mins ago - 10 mins left",
+			rc:   rc,
+			rec:  &store.CloseRecord{ClosedAt: now.Add(-5 * time.Minute)},
+			want: 10 * time.Minute,
+		},
+		{
+			name: "Stop loss 5 mins ago - longer SL cooldown applies",
+			rc:   rc,
+			rec:  &store.CloseRecord{ClosedAt: now.Add(-5 * time.Minute), StopLoss: true},
+			want: 55 * time.Minute,
+		},
+		{
+			name: "Normal close 20 mins ago - cooldown over",
+			rc:   rc,
+			rec:  &store.CloseRecord{ClosedAt: now.Add(-20 * time.Minute)},
+			want: 0,
+		},
+		{
+			name: "Cooldown disabled",
+			rc:   &store.RiskControlConfig{},
+			rec:  &store.CloseRecord{ClosedAt: now, StopLoss: true},
+			want: 0,
+		},
+		{
+			name: "Never closed",
+			rc:   rc,
+			rec:  nil,
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cooldownRemaining(tt.rc, tt.rec, now)
+			if got != tt.want {
+				t.Errorf("cooldownRemaining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
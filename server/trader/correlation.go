@@ -0,0 +1,347 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+)
+
+const (
+	correlationInterval         = "4h"
+	correlationBars             = 181 // 30 days of 4h bars, 180 returns
+	minCorrelationBars          = 60  // Fewer shared returns falls back to the static grouping
+	correlationRefresh          = time.Hour
+	correlationBlockTTL         = time.Hour
+	defaultCorrelationThreshold = 0.8
+)
+
+// priceHistory is a symbol's cached klines for the correlation guard
+type priceHistory struct {
+	klines    []exchange.Kline
+	fetchedAt time.Time
+}
+
+// correlationBlock is a recent entry the guard refused
+type correlationBlock struct {
+	block decision.CorrelationBlock
+	at    time.Time
+}
+
+// exposure is one open (or proposed) position's notional
+type exposure struct {
+	Symbol   string
+	Side     string // long or short
+	Notional float64
+}
+
+// correlatedFunc reports whether two symbols move together, and whether that
+// was measured from history rather than the static fallback
+type correlatedFunc func(a, b string) (correlated, measured bool)
+
+// returnCorrelation returns the Pearson correlation of two symbols' log
+// returns over the bars they share. ok is false with fewer than
+// minCorrelationBars shared returns.
+func returnCorrelation(a, b []exchange.Kline) (corr float64, ok bool) {
+	closesB := make(map[int64]float64, len(b))
+	for _, k := range b {
+		closesB[k.OpenTime] = k.Close
+	}
+
+	var ra, rb []float64
+	prevA, prevB := 0.0, 0.0
+	for _, k := range a {
+		closeB, shared := closesB[k.OpenTime]
+		if !shared || k.Close <= 0 || closeB <= 0 {
+			prevA, prevB = 0, 0
+			continue
+		}
+		if prevA > 0 && prevB > 0 {
+			ra = append(ra, math.Log(k.Close/prevA))
+			rb = append(rb, math.Log(closeB/prevB))
+		}
+		prevA, prevB = k.Close, closeB
+	}
+	if len(ra) < minCorrelationBars {
+		return 0, false
+	}
+
+	n := float64(len(ra))
+	var meanA, meanB float64
+	for i := range ra {
+		meanA += ra[i]
+		meanB += rb[i]
+	}
+	meanA /= n
+	meanB /= n
+
+	var cov, varA, varB float64
+	for i := range ra {
+		da, db := ra[i]-meanA, rb[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
+
+// staticCorrelated is the fallback without enough history: all alts move as
+// one cluster, BTC and ETH each stand alone
+func staticCorrelated(a, b string) bool {
+	return !isBTCETH(a) && !isBTCETH(b)
+}
+
+// clusterExposures groups same-side positions whose symbols are correlated,
+// directly or through another held symbol. Clusters are sorted by notional,
+// largest first.
+func clusterExposures(positions []exposure, correlated correlatedFunc, equity float64) []decision.ExposureCluster {
+	parent := make([]int, len(positions))
+	static := make([]bool, len(positions))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			if positions[i].Side != positions[j].Side || positions[i].Symbol == positions[j].Symbol {
+				continue
+			}
+			ok, measured := correlated(positions[i].Symbol, positions[j].Symbol)
+			if !ok {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			parent[ri] = rj
+			static[rj] = static[rj] || static[ri] || !measured
+		}
+	}
+
+	byRoot := make(map[int]*decision.ExposureCluster)
+	var clusters []*decision.ExposureCluster
+	for i, p := range positions {
+		root := find(i)
+		c := byRoot[root]
+		if c == nil {
+			c = &decision.ExposureCluster{Side: p.Side}
+			byRoot[root] = c
+			clusters = append(clusters, c)
+		}
+		c.Symbols = append(c.Symbols, p.Symbol)
+		c.NotionalUSD += p.Notional
+		c.Static = c.Static || static[i]
+	}
+
+	result := make([]decision.ExposureCluster, 0, len(clusters))
+	for _, c := range clusters {
+		sort.Strings(c.Symbols)
+		if equity > 0 {
+			c.EquityPct = c.NotionalUSD / equity * 100
+		}
+		result = append(result, *c)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].NotionalUSD > result[j].NotionalUSD })
+	return result
+}
+
+// correlatedExposureCheck returns why opening candidate would push its
+// correlated cluster past maxPct of equity, or "" if it fits. A candidate
+// with nothing correlated held is left to the position size limits.
+func correlatedExposureCheck(candidate exposure, held []exposure, correlated correlatedFunc, equity, maxPct float64) string {
+	if maxPct <= 0 || equity <= 0 {
+		return ""
+	}
+
+	for _, c := range clusterExposures(append(held[:len(held):len(held)], candidate), correlated, equity) {
+		if c.Side != candidate.Side || len(c.Symbols) < 2 || !containsSymbol(c.Symbols, candidate.Symbol) {
+			continue
+		}
+		if c.EquityPct <= maxPct {
+			return ""
+		}
+		grouping := "correlated"
+		if c.Static {
+			grouping = "alt cluster"
+		}
+		return fmt.Sprintf("%s exposure %s would reach $%.2f (%.0f%% of equity), limit %.0f%%",
+			grouping, strings.Join(c.Symbols, "+"), c.NotionalUSD, c.EquityPct, maxPct)
+	}
+	return ""
+}
+
+func containsSymbol(symbols []string, symbol string) bool {
+	for _, s := range symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// correlationLimits returns the max correlated exposure (% of equity, 0 =
+// off) and the grouping threshold
+func (e *Engine) correlationLimits() (maxPct, threshold float64) {
+	if e.strategy == nil {
+		return 0, defaultCorrelationThreshold
+	}
+	rc := e.strategy.Config.RiskControl
+	threshold = rc.CorrelationThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultCorrelationThreshold
+	}
+	return rc.MaxCorrelatedExposure, threshold
+}
+
+// refreshPriceHistory fetches klines for symbols whose cached history is
+// stale. Failures keep the old history, or the static grouping without one.
+func (e *Engine) refreshPriceHistory(ctx context.Context, symbols []string) {
+	now := time.Now()
+	for _, symbol := range symbols {
+		e.correlationMu.RLock()
+		h := e.priceHistory[symbol]
+		e.correlationMu.RUnlock()
+		if h != nil && now.Sub(h.fetchedAt) < correlationRefresh {
+			continue
+		}
+
+		klines, err := e.binance.GetKlines(ctx, symbol, correlationInterval, correlationBars)
+		if err != nil {
+			log.Printf("[%s][%s] Failed to fetch correlation history: %v", e.name, symbol, err)
+			continue
+		}
+
+		e.correlationMu.Lock()
+		if e.priceHistory == nil {
+			e.priceHistory = make(map[string]*priceHistory)
+		}
+		e.priceHistory[symbol] = &priceHistory{klines: klines, fetchedAt: now}
+		e.correlationMu.Unlock()
+	}
+}
+
+// symbolsCorrelated compares cached history against the threshold, falling
+// back to the static grouping when either symbol's history is too short
+func (e *Engine) symbolsCorrelated(a, b string) (correlated, measured bool) {
+	_, threshold := e.correlationLimits()
+
+	e.correlationMu.RLock()
+	ha, hb := e.priceHistory[a], e.priceHistory[b]
+	e.correlationMu.RUnlock()
+
+	if ha != nil && hb != nil {
+		if corr, ok := returnCorrelation(ha.klines, hb.klines); ok {
+			return corr > threshold, true
+		}
+	}
+	return staticCorrelated(a, b), false
+}
+
+// heldExposuresLocked returns the notional of each open position. Caller must
+// hold e.mu.
+func (e *Engine) heldExposuresLocked() []exposure {
+	held := make([]exposure, 0, len(e.positions))
+	for _, pos := range e.positions {
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		price := pos.MarkPrice
+		if price <= 0 {
+			price = pos.EntryPrice
+		}
+		held = append(held, exposure{
+			Symbol:   pos.Symbol,
+			Side:     rowSide(pos.PositionAmt),
+			Notional: math.Abs(pos.PositionAmt) * price,
+		})
+	}
+	return held
+}
+
+// checkCorrelatedExposure blocks an entry that would push its correlated
+// group past MaxCorrelatedExposure, recording the block for the AI context
+func (e *Engine) checkCorrelatedExposure(ctx context.Context, symbol, side string, notional, equity float64) error {
+	maxPct, _ := e.correlationLimits()
+	if maxPct <= 0 {
+		return nil
+	}
+
+	e.mu.RLock()
+	held := e.heldExposuresLocked()
+	e.mu.RUnlock()
+	if len(held) == 0 {
+		return nil
+	}
+
+	symbols := []string{symbol}
+	for _, h := range held {
+		symbols = append(symbols, h.Symbol)
+	}
+	e.refreshPriceHistory(ctx, symbols)
+
+	candidate := exposure{Symbol: symbol, Side: side, Notional: notional}
+	reason := correlatedExposureCheck(candidate, held, e.symbolsCorrelated, equity, maxPct)
+	if reason == "" {
+		return nil
+	}
+
+	log.Printf("[%s][%s] Correlation guard blocked %s entry: %s", e.name, symbol, side, reason)
+	e.correlationMu.Lock()
+	if e.correlationBlocks == nil {
+		e.correlationBlocks = make(map[string]*correlationBlock)
+	}
+	e.correlationBlocks[symbol] = &correlationBlock{
+		block: decision.CorrelationBlock{Symbol: symbol, Side: side, Reason: reason},
+		at:    time.Now(),
+	}
+	e.correlationMu.Unlock()
+	return errors.New(reason)
+}
+
+// exposureContextLocked returns the current correlated groups and recent
+// blocks for the AI context, from cached history only. Caller must hold e.mu.
+func (e *Engine) exposureContextLocked(equity float64) ([]decision.ExposureCluster, []decision.CorrelationBlock) {
+	if maxPct, _ := e.correlationLimits(); maxPct <= 0 {
+		return nil, nil
+	}
+
+	clusters := clusterExposures(e.heldExposuresLocked(), e.symbolsCorrelated, equity)
+
+	var blocks []decision.CorrelationBlock
+	e.correlationMu.RLock()
+	for _, b := range e.correlationBlocks {
+		if time.Since(b.at) < correlationBlockTTL {
+			blocks = append(blocks, b.block)
+		}
+	}
+	e.correlationMu.RUnlock()
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Symbol < blocks[j].Symbol })
+	return clusters, blocks
+}
+
+// recentCorrelationBlock returns the guard's latest refusal for symbol, if
+// it's still fresh
+func (e *Engine) recentCorrelationBlock(symbol string) (decision.CorrelationBlock, bool) {
+	e.correlationMu.RLock()
+	defer e.correlationMu.RUnlock()
+	b := e.correlationBlocks[symbol]
+	if b == nil || time.Since(b.at) >= correlationBlockTTL {
+		return decision.CorrelationBlock{}, false
+	}
+	return b.block, true
+}
@@ -0,0 +1,156 @@
+package trader
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// syntheticKlines builds 4h klines whose closes follow the given returns
+func syntheticKlines(returns []float64) []exchange.Kline {
+	klines := make([]exchange.Kline, len(returns)+1)
+	price := 100.0
+	for i := range klines {
+		if i > 0 {
+			price *= math.Exp(returns[i-1])
+		}
+		klines[i] = exchange.Kline{OpenTime: int64(i) * 4 * 3600 * 1000, Close: price}
+	}
+	return klines
+}
+
+// TestReturnCorrelation tests correlation of aligned returns and the minimum history
+func TestReturnCorrelation(t *testing.T) {
+	base := make([]float64, 120)
+	inverse := make([]float64, 120)
+	for i := range base {
+		base[i] = 0.01 * math.Sin(float64(i)*0.7)
+		inverse[i] = -base[i]
+	}
+
+	if corr, ok := returnCorrelation(syntheticKlines(base), syntheticKlines(base)); !ok || math.Abs(corr-1) > 1e-9 {
+		t.Errorf("identical series: corr = %v, ok = %v, want 1", corr, ok)
+	}
+	if corr, ok := returnCorrelation(syntheticKlines(base), syntheticKlines(inverse)); !ok || math.Abs(corr+1) > 1e-9 {
+		t.Errorf("inverse series: corr = %v, ok = %v, want -1", corr, ok)
+	}
+	if _, ok := returnCorrelation(syntheticKlines(base[:30]), syntheticKlines(base[:30])); ok {
+		t.Error("30 returns should be too short to measure")
+	}
+
+	// Only bars present in both series count
+	shifted := syntheticKlines(base)
+	for i := range shifted {
+		shifted[i].OpenTime += 1000
+	}
+	if _, ok := returnCorrelation(syntheticKlines(base), shifted); ok {
+		t.Error("series without shared bars should not be measured")
+	}
+}
+
+// TestCorrelatedExposureCheck tests the guard with measured and static grouping
+func TestCorrelatedExposureCheck(t *testing.T) {
+	measured := func(pairs ...string) correlatedFunc {
+		return func(a, b string) (bool, bool) {
+			for _, p := range pairs {
+				if p == a+"/"+b || p == b+"/"+a {
+					return true, true
+				}
+			}
+			return false, true
+		}
+	}
+	static := func(a, b string) (bool, bool) { return staticCorrelated(a, b), false }
+
+	tests := []struct {
+		name       string
+		candidate  exposure
+		held       []exposure
+		correlated correlatedFunc
+		wantBlock  string
+	}{
+		{
+			name:       "Correlated group over the limit",
+			candidate:  exposure{Symbol: "SOLUSDT", Side: "long", Notional: 2000},
+			held:       []exposure{{Symbol: "BTCUSDT", Side: "long", Notional: 2000}, {Symbol: "ETHUSDT", Side: "long", Notional: 1500}},
+			correlated: measured("BTCUSDT/ETHUSDT", "ETHUSDT/SOLUSDT"),
+			wantBlock:  "BTCUSDT+ETHUSDT+SOLUSDT",
+		},
+		{
+			name:       "Correlated group within the limit",
+			candidate:  exposure{Symbol: "SOLUSDT", Side: "long", Notional: 1000},
+			held:       []exposure{{Symbol: "ETHUSDT", Side: "long", Notional: 1500}},
+			correlated: measured("ETHUSDT/SOLUSDT"),
+		},
+		{
+			name:       "Uncorrelated symbol is not grouped",
+			candidate:  exposure{Symbol: "XAUUSDT", Side: "long", Notional: 3000},
+			held:       []exposure{{Symbol: "BTCUSDT", Side: "long", Notional: 3000}},
+			correlated: measured(),
+		},
+		{
+			name:       "Opposite side is a hedge, not concentration",
+			candidate:  exposure{Symbol: "ETHUSDT", Side: "short", Notional: 3000},
+			held:       []exposure{{Symbol: "BTCUSDT", Side: "long", Notional: 3000}},
+			correlated: measured("BTCUSDT/ETHUSDT"),
+		},
+		{
+			name:       "Static fallback groups alts",
+			candidate:  exposure{Symbol: "DOGEUSDT", Side: "long", Notional: 2000},
+			held:       []exposure{{Symbol: "SOLUSDT", Side: "long", Notional: 2000}, {Symbol: "BTCUSDT", Side: "long", Notional: 4000}},
+			correlated: static,
+			wantBlock:  "alt cluster exposure DOGEUSDT+SOLUSDT",
+		},
+		{
+			name:       "Static fallback keeps BTC alone",
+			candidate:  exposure{Symbol: "BTCUSDT", Side: "long", Notional: 4000},
+			held:       []exposure{{Symbol: "SOLUSDT", Side: "long", Notional: 4000}},
+			correlated: static,
+		},
+	}
+
+	// $1000 equity, limit 350% -> $3500 of correlated notional
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := correlatedExposureCheck(tt.candidate, tt.held, tt.correlated, 1000, 350)
+			if tt.wantBlock == "" {
+				if got != "" {
+					t.Errorf("correlatedExposureCheck() = %q, want no block", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.wantBlock) {
+				t.Errorf("correlatedExposureCheck() = %q, want it to mention %q", got, tt.wantBlock)
+			}
+		})
+	}
+
+	held := []exposure{{Symbol: "SOLUSDT", Side: "long", Notional: 5000}}
+	if got := correlatedExposureCheck(exposure{Symbol: "DOGEUSDT", Side: "long", Notional: 1}, held, static, 1000, 0); got != "" {
+		t.Errorf("disabled guard blocked: %q", got)
+	}
+}
+
+// TestClusterExposures tests transitive grouping and the equity breakdown
+func TestClusterExposures(t *testing.T) {
+	positions := []exposure{
+		{Symbol: "BTCUSDT", Side: "long", Notional: 1000},
+		{Symbol: "SOLUSDT", Side: "long", Notional: 500},
+		{Symbol: "DOGEUSDT", Side: "long", Notional: 300},
+		{Symbol: "ETHUSDT", Side: "short", Notional: 200},
+	}
+	clusters := clusterExposures(positions, func(a, b string) (bool, bool) { return staticCorrelated(a, b), false }, 2000)
+
+	if len(clusters) != 3 {
+		t.Fatalf("got %d clusters, want 3: %+v", len(clusters), clusters)
+	}
+	if clusters[0].Symbols[0] != "BTCUSDT" || clusters[0].Static {
+		t.Errorf("largest cluster = %+v, want BTC alone", clusters[0])
+	}
+	alts := clusters[1]
+	if strings.Join(alts.Symbols, ",") != "DOGEUSDT,SOLUSDT" || alts.NotionalUSD != 800 || alts.EquityPct != 40 || !alts.Static {
+		t.Errorf("alt cluster = %+v, want DOGE+SOL $800 (40%%) static", alts)
+	}
+}
@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/market"
+)
+
+const defaultMarketDataConcurrency = 4
+
+// symbolData is a symbol's market data fetched at the start of a cycle
+type symbolData struct {
+	data      *market.MarketData
+	fetchedAt time.Time
+	skip      error // Not tradable, so nothing was fetched
+	err       error // Fetching failed
+}
+
+// marketDataConcurrency is how many symbols a cycle fetches at once
+func (e *Engine) marketDataConcurrency() int {
+	if e.cfg != nil && e.cfg.MarketDataConcurrency > 0 {
+		return e.cfg.MarketDataConcurrency
+	}
+	return defaultMarketDataConcurrency
+}
+
+// fetchCycleData fetches the market data of every symbol the cycle analyzes,
+// a few at a time with the exchange's request budget pacing them, so the last
+// symbol's data is as fresh as the first's when the AI sees it. BTC's stats
+// are fetched once for all of them.
+func (e *Engine) fetchCycleData(ctx context.Context, symbols []string) map[string]*symbolData {
+	start := time.Now()
+
+	timeframe := "5m"
+	klineCount := 100
+	if e.strategy != nil {
+		timeframe = e.strategy.Config.Indicators.PrimaryTimeframe
+		klineCount = e.strategy.Config.Indicators.KlineCount
+	}
+	indicators := e.marketIndicators()
+
+	results := make([]*symbolData, len(symbols))
+	sem := make(chan struct{}, e.marketDataConcurrency())
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Don't spend requests on a symbol we couldn't open; held positions are still managed
+			if err := e.untradable(symbol); err != nil {
+				results[i] = &symbolData{skip: err}
+				return
+			}
+			data, err := e.dataProvider.GetMarketDataWithIndicators(ctx, symbol, timeframe, klineCount, indicators)
+			results[i] = &symbolData{data: data, fetchedAt: time.Now(), err: err}
+		}(i, symbol)
+	}
+
+	// Global context for every symbol's prompt
+	btcStats, btcErr := e.binance.GetTickerStats(ctx, "BTCUSDT")
+	wg.Wait()
+
+	bySymbol := make(map[string]*symbolData, len(symbols))
+	fetched := 0
+	for i, symbol := range symbols {
+		r := results[i]
+		if r.data != nil {
+			fetched++
+			if btcErr == nil {
+				r.data.BTCPrice = btcStats.LastPrice
+				r.data.BTCChange24h = btcStats.PriceChange
+			}
+		}
+		bySymbol[symbol] = r
+	}
+	log.Printf("[%s] Fetched market data for %d/%d symbols in %v", e.name, fetched, len(symbols), time.Since(start).Round(time.Millisecond))
+	return bySymbol
+}
+
+// untradable returns why symbol can't be opened, nil when it can or when a
+// position in it is held and still needs managing. Symbols another trader on
+// the account holds are never analyzed.
+func (e *Engine) untradable(symbol string) error {
+	if owner, ok := e.heldElsewhere(symbol); ok {
+		log.Printf("[%s][%s] Held by trader %s on the same account, not analyzing", e.name, symbol, owner.name)
+		return fmt.Errorf("skipped: %s is held by trader %s on the same account", symbol, owner.name)
+	}
+	e.mu.RLock()
+	held, ok := e.positions[symbol]
+	e.mu.RUnlock()
+	if ok && held.PositionAmt != 0 {
+		return nil
+	}
+	if err := e.binance.CheckTradable(symbol); err != nil {
+		return fmt.Errorf("skipped: %w", err)
+	}
+	return nil
+}
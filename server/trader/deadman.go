@@ -0,0 +1,324 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// Dead-man switch defaults, used when the strategy leaves them at zero
+const (
+	defaultBackstopStopPct          = 5.0
+	defaultExchangeFailureThreshold = 5
+
+	// backstopRetryInterval spaces out attempts at a backstop Binance refused,
+	// e.g. because an untracked SL already holds the position's side
+	backstopRetryInterval = 5 * time.Minute
+)
+
+// deadMan tracks Binance connectivity and the exchange-side safety nets that
+// bound losses while the server can't reach it
+type deadMan struct {
+	mu             sync.Mutex
+	failures       int       // Consecutive failed account/position fetches
+	lost           bool      // failures reached the threshold
+	lostAt         time.Time // When the first of the failures happened
+	flattenPending bool      // Flatten once connectivity returns
+
+	backstopRetryAt map[string]time.Time // key: symbol -> next attempt after a refused backstop
+	autoCancel      map[string]bool      // key: symbol -> countdown armed on Binance
+}
+
+// exchangeFailureThreshold is how many fetches in a row may fail before
+// connectivity counts as lost
+func (e *Engine) exchangeFailureThreshold() int {
+	if e.strategy == nil || e.strategy.Config.RiskControl.ExchangeFailureThreshold <= 0 {
+		return defaultExchangeFailureThreshold
+	}
+	return e.strategy.Config.RiskControl.ExchangeFailureThreshold
+}
+
+// noteExchangeCall counts a GetAccountInfo or GetPositions result. Reaching
+// the failure threshold raises a critical event; the next success after that
+// ends the outage and, if configured, schedules a flatten.
+func (e *Engine) noteExchangeCall(err error) {
+	threshold := e.exchangeFailureThreshold()
+	d := &e.deadMan
+
+	d.mu.Lock()
+	if err != nil {
+		if d.failures == 0 {
+			d.lostAt = time.Now()
+		}
+		d.failures++
+		tripped := !d.lost && d.failures >= threshold
+		if tripped {
+			d.lost = true
+		}
+		failures, since := d.failures, d.lostAt
+		d.mu.Unlock()
+
+		if tripped {
+			e.connectivityLost(failures, since, err)
+		}
+		return
+	}
+
+	recovered, since := d.lost, d.lostAt
+	d.failures, d.lost = 0, false
+	flatten := recovered && e.strategy != nil && e.strategy.Config.RiskControl.FlattenOnReconnect
+	if flatten {
+		d.flattenPending = true
+	}
+	d.mu.Unlock()
+
+	if recovered {
+		msg := fmt.Sprintf("Binance reachable again after %v", time.Since(since).Round(time.Second))
+		if flatten {
+			msg += ", flattening positions"
+		}
+		log.Printf("[%s] ✅ %s", e.name, msg)
+		e.recordRiskEvent(store.RiskEventConnectivityRestored, msg)
+	}
+}
+
+// connectivityLost raises the critical event for an outage
+func (e *Engine) connectivityLost(failures int, since time.Time, err error) {
+	msg := fmt.Sprintf("Binance unreachable: %d account/position fetches failed since %s, last error: %v",
+		failures, since.UTC().Format(time.RFC3339), err)
+	log.Printf("[%s] 🚨 CRITICAL: %s. Positions rely on their exchange-side stops.", e.name, msg)
+	e.recordRiskEvent(store.RiskEventConnectivityLost, msg)
+	e.setLastError(msg)
+	if e.notifier != nil {
+		e.notifier.Broadcast(events.Event{
+			Type:      events.TypeError,
+			TraderID:  e.id,
+			Message:   msg,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// flattenAfterReconnect closes every position if an outage ended with
+// FlattenOnReconnect set. Positions that fail to close are tried again on the
+// next call.
+func (e *Engine) flattenAfterReconnect(ctx context.Context) {
+	d := &e.deadMan
+	d.mu.Lock()
+	pending := d.flattenPending
+	d.flattenPending = false
+	d.mu.Unlock()
+	if !pending {
+		return
+	}
+
+	e.mu.RLock()
+	positions := make([]exchange.Position, 0, len(e.positions))
+	for _, pos := range e.positions {
+		if pos.PositionAmt != 0 {
+			positions = append(positions, *pos)
+		}
+	}
+	e.mu.RUnlock()
+
+	closed, failed := 0, 0
+	for i := range positions {
+		if err := e.forceClose(ctx, &positions[i], "flatten after connectivity loss", closeReasonConnectivity); err != nil {
+			failed++
+			continue
+		}
+		closed++
+	}
+	if failed > 0 {
+		d.mu.Lock()
+		d.flattenPending = true
+		d.mu.Unlock()
+	}
+	log.Printf("[%s] Flatten after connectivity loss: %d closed, %d failed", e.name, closed, failed)
+	if closed > 0 || failed > 0 {
+		e.recordRiskEvent(store.RiskEventConnectivityRestored,
+			fmt.Sprintf("flattened %d position(s) after connectivity loss, %d failed", closed, failed))
+	}
+}
+
+// backstopStop returns the backstop stop price for pos at pct from its entry,
+// and the side that closes it
+func backstopStop(pos *exchange.Position, pct float64) (stop float64, closeSide string) {
+	if pos.PositionAmt > 0 {
+		return pos.EntryPrice * (1 - pct/100), "SELL"
+	}
+	return pos.EntryPrice * (1 + pct/100), "BUY"
+}
+
+// needsBackstop reports whether the strategy manages positions locally and
+// wants a backstop under them
+func needsBackstop(rc store.RiskControlConfig) bool {
+	return rc.EnableBackstopStop && (rc.EnableTrailingStop || rc.EnableSmartLossCut)
+}
+
+// maintainBackstops gives every position without a tracked exchange SL a wide
+// STOP_MARKET, so losses stay bounded if the local protections stop running.
+// Positions with an SL already have their backstop.
+func (e *Engine) maintainBackstops(ctx context.Context) {
+	if e.strategy == nil || e.binance == nil || !needsBackstop(e.strategy.Config.RiskControl) {
+		return
+	}
+	pct := e.strategy.Config.RiskControl.BackstopStopPct
+	if pct <= 0 {
+		pct = defaultBackstopStopPct
+	}
+
+	e.mu.RLock()
+	positions := make([]exchange.Position, 0, len(e.positions))
+	for _, pos := range e.positions {
+		if pos.PositionAmt != 0 && pos.EntryPrice > 0 {
+			positions = append(positions, *pos)
+		}
+	}
+	e.mu.RUnlock()
+
+	placed := false
+	now := time.Now()
+	for i := range positions {
+		pos := &positions[i]
+		e.bracketOrdersMutex.RLock()
+		bracket := e.bracketOrders[pos.Symbol]
+		e.bracketOrdersMutex.RUnlock()
+		if bracket != nil && bracket.StopLossOrderID > 0 {
+			continue
+		}
+
+		e.deadMan.mu.Lock()
+		retryAt := e.deadMan.backstopRetryAt[pos.Symbol]
+		e.deadMan.mu.Unlock()
+		if now.Before(retryAt) {
+			continue
+		}
+
+		stop, closeSide := backstopStop(pos, pct)
+		if (pos.PositionAmt > 0 && pos.MarkPrice > 0 && pos.MarkPrice <= stop) ||
+			(pos.PositionAmt < 0 && pos.MarkPrice >= stop) {
+			// It would trigger at once; the local protections decide instead
+			log.Printf("[%s][%s] Mark $%.4f is already past the backstop at $%.4f, not placing it", e.name, pos.Symbol, pos.MarkPrice, stop)
+			e.deferBackstop(pos.Symbol, now)
+			continue
+		}
+
+		order, err := e.binance.PlaceStopLoss(ctx, pos.Symbol, closeSide, 0, stop)
+		if err != nil {
+			log.Printf("[%s][%s] Backstop SL at $%.4f failed, retrying in %v: %v", e.name, pos.Symbol, stop, backstopRetryInterval, err)
+			e.deferBackstop(pos.Symbol, now)
+			continue
+		}
+		log.Printf("[%s][%s] 🛡️ Backstop SL placed at $%.4f (%.1f%% from entry $%.4f, AlgoID=%d)",
+			e.name, pos.Symbol, stop, pct, pos.EntryPrice, order.OrderID)
+
+		e.bracketOrdersMutex.Lock()
+		backstop := &BracketOrderIDs{StopLossOrderID: order.OrderID, EntryPrice: pos.EntryPrice, StopLossPct: pct}
+		if bracket != nil {
+			backstop.TakeProfitOrderID, backstop.TakeProfitPct = bracket.TakeProfitOrderID, bracket.TakeProfitPct
+		}
+		e.bracketOrders[pos.Symbol] = backstop
+		e.bracketOrdersMutex.Unlock()
+
+		e.deadMan.mu.Lock()
+		delete(e.deadMan.backstopRetryAt, pos.Symbol)
+		e.deadMan.mu.Unlock()
+		placed = true
+	}
+
+	if placed {
+		e.saveState()
+	}
+}
+
+// deferBackstop holds off the next backstop attempt for symbol
+func (e *Engine) deferBackstop(symbol string, now time.Time) {
+	e.deadMan.mu.Lock()
+	defer e.deadMan.mu.Unlock()
+	if e.deadMan.backstopRetryAt == nil {
+		e.deadMan.backstopRetryAt = make(map[string]time.Time)
+	}
+	e.deadMan.backstopRetryAt[symbol] = now.Add(backstopRetryInterval)
+}
+
+// autoCancelCountdown is the Binance auto-cancel countdown, at least three
+// risk checks long so one slow check doesn't let it expire. Zero is off.
+func (e *Engine) autoCancelCountdown() time.Duration {
+	if e.strategy == nil || e.strategy.Config.RiskControl.AutoCancelSecs <= 0 {
+		return 0
+	}
+	countdown := time.Duration(e.strategy.Config.RiskControl.AutoCancelSecs) * time.Second
+	if floor := 3 * e.getRiskCheckInterval(); countdown < floor {
+		countdown = floor
+	}
+	return countdown
+}
+
+// renewAutoCancel renews Binance's auto-cancel countdown on the trading pairs
+// without a position, so orders left there can't fill while the server is
+// offline. Symbols with a position are left without a countdown: it would
+// cancel their stops along with everything else.
+func (e *Engine) renewAutoCancel(ctx context.Context) {
+	if e.binance == nil {
+		return
+	}
+	countdown := e.autoCancelCountdown()
+
+	e.mu.RLock()
+	withPosition := make(map[string]bool, len(e.positions))
+	for symbol, pos := range e.positions {
+		if pos.PositionAmt != 0 {
+			withPosition[symbol] = true
+		}
+	}
+	e.mu.RUnlock()
+
+	want := make(map[string]bool)
+	if countdown > 0 {
+		for _, symbol := range e.getTradingPairs() {
+			if !withPosition[symbol] {
+				want[symbol] = true
+			}
+		}
+	}
+
+	d := &e.deadMan
+	d.mu.Lock()
+	armed := d.autoCancel
+	d.mu.Unlock()
+
+	next := make(map[string]bool, len(want))
+	for symbol := range want {
+		if err := e.binance.SetAutoCancel(ctx, symbol, countdown); err != nil {
+			log.Printf("[%s][%s] Auto-cancel renewal failed: %v", e.name, symbol, err)
+			next[symbol] = armed[symbol]
+			continue
+		}
+		if !armed[symbol] {
+			log.Printf("[%s][%s] Auto-cancel armed: open orders are cancelled if the server goes quiet for %v", e.name, symbol, countdown)
+		}
+		next[symbol] = true
+	}
+	for symbol := range armed {
+		if want[symbol] {
+			continue
+		}
+		if err := e.binance.SetAutoCancel(ctx, symbol, 0); err != nil {
+			log.Printf("[%s][%s] Auto-cancel disarm failed: %v", e.name, symbol, err)
+			next[symbol] = true
+			continue
+		}
+		log.Printf("[%s][%s] Auto-cancel disarmed", e.name, symbol)
+	}
+
+	d.mu.Lock()
+	d.autoCancel = next
+	d.mu.Unlock()
+}
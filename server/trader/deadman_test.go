@@ -0,0 +1,100 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestNoteExchangeCall tests that consecutive fetch failures trip the
+// connectivity alarm once and the next success schedules a flatten
+func TestNoteExchangeCall(t *testing.T) {
+	e := &Engine{name: "t", strategy: &store.Strategy{Config: store.StrategyConfig{RiskControl: store.RiskControlConfig{
+		ExchangeFailureThreshold: 3,
+		FlattenOnReconnect:       true,
+	}}}}
+	down := errors.New("dial tcp: i/o timeout")
+
+	e.noteExchangeCall(down)
+	e.noteExchangeCall(down)
+	e.noteExchangeCall(nil) // A success in between starts the count over
+	e.noteExchangeCall(down)
+	e.noteExchangeCall(down)
+	if e.deadMan.lost || e.lastError != "" {
+		t.Fatalf("lost after 2 consecutive failures, threshold is 3")
+	}
+
+	e.noteExchangeCall(down)
+	if !e.deadMan.lost || e.lastError == "" {
+		t.Fatal("connectivity not flagged lost at the threshold")
+	}
+	e.lastError = ""
+	e.noteExchangeCall(down)
+	if e.lastError != "" {
+		t.Error("alarm raised again during the same outage")
+	}
+
+	e.noteExchangeCall(nil)
+	if e.deadMan.lost || e.deadMan.failures != 0 || !e.deadMan.flattenPending {
+		t.Errorf("after reconnect: lost=%v failures=%d flattenPending=%v", e.deadMan.lost, e.deadMan.failures, e.deadMan.flattenPending)
+	}
+
+	// Without FlattenOnReconnect an outage only alerts
+	e = &Engine{name: "t", strategy: &store.Strategy{}}
+	for i := 0; i < defaultExchangeFailureThreshold; i++ {
+		e.noteExchangeCall(down)
+	}
+	e.noteExchangeCall(nil)
+	if e.deadMan.flattenPending {
+		t.Error("flatten scheduled without FlattenOnReconnect")
+	}
+}
+
+func TestBackstopStop(t *testing.T) {
+	long := &exchange.Position{PositionAmt: 1, EntryPrice: 100}
+	if stop, side := backstopStop(long, 5); side != "SELL" || stop < 94.99 || stop > 95.01 {
+		t.Errorf("long backstop = %v %s, want 95 SELL", stop, side)
+	}
+	short := &exchange.Position{PositionAmt: -1, EntryPrice: 100}
+	if stop, side := backstopStop(short, 5); side != "BUY" || stop < 104.99 || stop > 105.01 {
+		t.Errorf("short backstop = %v %s, want 105 BUY", stop, side)
+	}
+
+	tests := []struct {
+		rc   store.RiskControlConfig
+		want bool
+	}{
+		{store.RiskControlConfig{EnableBackstopStop: true, EnableTrailingStop: true}, true},
+		{store.RiskControlConfig{EnableBackstopStop: true, EnableSmartLossCut: true}, true},
+		{store.RiskControlConfig{EnableBackstopStop: true}, false}, // Exchange SL/TP only, nothing managed locally
+		{store.RiskControlConfig{EnableTrailingStop: true}, false},
+	}
+	for _, tt := range tests {
+		if got := needsBackstop(tt.rc); got != tt.want {
+			t.Errorf("needsBackstop(%+v) = %v", tt.rc, got)
+		}
+	}
+}
+
+func TestAutoCancelCountdown(t *testing.T) {
+	e := &Engine{strategy: &store.Strategy{Config: store.StrategyConfig{RiskControl: store.RiskControlConfig{
+		RiskCheckIntervalSecs: 20,
+	}}}}
+	if got := e.autoCancelCountdown(); got != 0 {
+		t.Errorf("disabled countdown = %v", got)
+	}
+
+	e.strategy.Config.RiskControl.AutoCancelSecs = 300
+	if got := e.autoCancelCountdown(); got != 5*time.Minute {
+		t.Errorf("countdown = %v, want 5m", got)
+	}
+
+	// Shorter than three risk checks would expire between renewals
+	e.strategy.Config.RiskControl.AutoCancelSecs = 30
+	if got := e.autoCancelCountdown(); got != time.Minute {
+		t.Errorf("countdown = %v, want 1m", got)
+	}
+}
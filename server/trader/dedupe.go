@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+)
+
+// An unchanged decision is still executed every this many repeats, so a block
+// that has since lifted, like an ended cooldown, can't hold it back for good
+const unchangedRecheckEvery = 6
+
+// decisionRepeat is the last decision made for a symbol and how many cycles
+// in a row it came back the same
+type decisionRepeat struct {
+	fingerprint string // decisionFingerprint of the decision
+	position    string // positionFingerprint it was made against
+	action      string
+	unchanged   int // Consecutive repeats since it was first made
+}
+
+// decisionFingerprint hashes the actionable part of a decision: symbol,
+// action, close size, leverage and stops, rounded so that noise in the
+// model's numbers doesn't count as a change. Confidence and reasoning don't.
+func decisionFingerprint(d *ai.TradingDecision) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%.0f|%d|%.1f|%.1f|%s|%s", d.Symbol, d.Action, d.ClosePercent, d.Leverage,
+		d.StopLossPct, d.TakeProfitPct, roundPrice(d.StopLoss), roundPrice(d.TakeProfit))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// roundPrice keeps 4 significant digits
+func roundPrice(price float64) string {
+	if price == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.4g", price)
+}
+
+// positionFingerprint identifies the state of symbol's position a decision was
+// made against: flat, or its size and entry
+func positionFingerprint(pos *exchange.Position, hasPosition bool) string {
+	if !hasPosition || pos == nil || pos.PositionAmt == 0 {
+		return "flat"
+	}
+	return fmt.Sprintf("%g@%g", pos.PositionAmt, pos.EntryPrice)
+}
+
+// unchangedDecisions returns how many cycles in a row symbol's decision came
+// back the same, and its action
+func (e *Engine) unchangedDecisions(symbol string) (int, string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	repeat := e.decisionRepeats[symbol]
+	if repeat == nil {
+		return 0, ""
+	}
+	return repeat.unchanged, repeat.action
+}
+
+// recordDecisionRepeat compares d with symbol's previous decision. It returns
+// the consecutive repeat count when d is the same decision against the same
+// position, and whether it should be skipped rather than executed again.
+func (e *Engine) recordDecisionRepeat(symbol string, d *ai.TradingDecision, position string) (int, bool) {
+	fingerprint := decisionFingerprint(d)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.decisionRepeats == nil {
+		e.decisionRepeats = make(map[string]*decisionRepeat)
+	}
+	prev := e.decisionRepeats[symbol]
+	if prev == nil || prev.fingerprint != fingerprint || prev.position != position {
+		e.decisionRepeats[symbol] = &decisionRepeat{fingerprint: fingerprint, position: position, action: d.Action}
+		return 0, false
+	}
+	prev.unchanged++
+	return prev.unchanged, prev.unchanged%unchangedRecheckEvery != 0
+}
@@ -0,0 +1,62 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+)
+
+func TestDecisionFingerprint(t *testing.T) {
+	base := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "BUY", Confidence: 80, Reasoning: "breakout", StopLossPct: 2, TakeProfitPct: 6, Leverage: 5}
+
+	same := *base
+	same.Confidence, same.Reasoning = 72, "still a breakout"
+	same.StopLossPct = 2.01 // Rounds to the same stop
+	if decisionFingerprint(&same) != decisionFingerprint(base) {
+		t.Errorf("confidence, reasoning or rounding noise changed the fingerprint")
+	}
+
+	for name, change := range map[string]func(d *ai.TradingDecision){
+		"action":   func(d *ai.TradingDecision) { d.Action = "SELL" },
+		"stop":     func(d *ai.TradingDecision) { d.StopLossPct = 2.5 },
+		"leverage": func(d *ai.TradingDecision) { d.Leverage = 10 },
+		"close":    func(d *ai.TradingDecision) { d.ClosePercent = 50 },
+	} {
+		changed := *base
+		change(&changed)
+		if decisionFingerprint(&changed) == decisionFingerprint(base) {
+			t.Errorf("%s change kept the fingerprint", name)
+		}
+	}
+}
+
+func TestRecordDecisionRepeat(t *testing.T) {
+	e := &Engine{}
+	hold := &ai.TradingDecision{Symbol: "ETHUSDT", Action: "HOLD"}
+	flat := positionFingerprint(nil, false)
+
+	if n, skip := e.recordDecisionRepeat("ETHUSDT", hold, flat); n != 0 || skip {
+		t.Fatalf("first decision = %d/%v, want 0/false", n, skip)
+	}
+	if n, skip := e.recordDecisionRepeat("ETHUSDT", hold, flat); n != 1 || !skip {
+		t.Fatalf("repeat = %d/%v, want 1/true", n, skip)
+	}
+	if n, action := e.unchangedDecisions("ETHUSDT"); n != 1 || action != "HOLD" {
+		t.Errorf("unchangedDecisions = %d %s, want 1 HOLD", n, action)
+	}
+
+	// A position change makes it a new decision
+	held := positionFingerprint(&exchange.Position{PositionAmt: 0.5, EntryPrice: 3000}, true)
+	if n, skip := e.recordDecisionRepeat("ETHUSDT", hold, held); n != 0 || skip {
+		t.Errorf("after position change = %d/%v, want 0/false", n, skip)
+	}
+
+	// Executed again every unchangedRecheckEvery repeats
+	for i := 1; i <= unchangedRecheckEvery; i++ {
+		n, skip := e.recordDecisionRepeat("ETHUSDT", hold, held)
+		if want := i < unchangedRecheckEvery; n != i || skip != want {
+			t.Errorf("repeat %d = %d/%v, want %d/%v", i, n, skip, i, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// ErrTradingHalted is returned when starting a trader during an emergency halt
+var ErrTradingHalted = errors.New("trading is halted, resume it before starting traders")
+
+// EmergencyAction is one step taken while halting or shutting down a trader
+type EmergencyAction struct {
+	Time     time.Time `json:"time"`
+	TraderID string    `json:"trader_id,omitempty"`
+	Action   string    `json:"action"` // stop, cancel_orders, flatten, ...
+	Symbol   string    `json:"symbol,omitempty"`
+	Result   string    `json:"result"` // "ok" or the error
+}
+
+// NewEmergencyAction records a step, with the error as its result
+func NewEmergencyAction(traderID, action, symbol string, err error) EmergencyAction {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	return EmergencyAction{
+		Time:     time.Now(),
+		TraderID: traderID,
+		Action:   action,
+		Symbol:   symbol,
+		Result:   result,
+	}
+}
+
+// emergencyStop stops the engine, cancels the open orders of every symbol it
+// tracks and, with flatten, market-closes its positions. Without flatten the
+// exchange-side SL/TP algo orders are left in place to keep protecting
+// positions; closing a position cancels its brackets.
+func (e *Engine) emergencyStop(ctx context.Context, flatten bool) []EmergencyAction {
+	var actions []EmergencyAction
+	record := func(action, symbol string, err error) {
+		actions = append(actions, NewEmergencyAction(e.id, action, symbol, err))
+	}
+
+	e.Stop()
+	record("stop", "", nil)
+	if flatten {
+		e.recordRiskEvent(store.RiskEventEmergencyStop, "emergency stop, positions flattened")
+	} else {
+		e.recordRiskEvent(store.RiskEventEmergencyStop, "emergency stop, open orders cancelled")
+	}
+
+	positions, err := e.exchangePositions(ctx)
+	if err != nil {
+		record("get_positions", "", err)
+	}
+	for _, symbol := range e.orderSymbols(positions) {
+		record("cancel_orders", symbol, e.binance.CancelAllOrders(ctx, symbol))
+	}
+
+	if !flatten {
+		return actions
+	}
+	for i := range positions {
+		pos := &positions[i]
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		record("flatten", pos.Symbol, e.forceClose(ctx, pos, "emergency stop", closeReasonEmergency))
+	}
+	return actions
+}
+
+// exchangePositions returns the exchange's view of the positions, the cache
+// can be a cycle old. If the exchange can't be reached the cache is returned
+// with the error. Positions other traders on the account hold are left out.
+func (e *Engine) exchangePositions(ctx context.Context) ([]exchange.Position, error) {
+	positions, err := e.binance.GetPositions(ctx)
+	if err != nil {
+		e.mu.RLock()
+		for _, pos := range e.positions {
+			positions = append(positions, *pos)
+		}
+		e.mu.RUnlock()
+		return positions, err
+	}
+	return e.ownPositions(positions), nil
+}
+
+// orderSymbols returns the symbols that can have open orders: the trading
+// pairs and any symbol with an open position, sorted
+func (e *Engine) orderSymbols(positions []exchange.Position) []string {
+	tracked := make(map[string]bool)
+	for _, symbol := range e.getTradingPairs() {
+		tracked[symbol] = true
+	}
+	for _, pos := range positions {
+		if pos.PositionAmt != 0 {
+			tracked[pos.Symbol] = true
+		}
+	}
+	symbols := make([]string, 0, len(tracked))
+	for symbol := range tracked {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// HaltAll is the kill switch: it persists the halt so no trader can be
+// started, then emergency-stops every running engine. Every step is returned
+// for the audit log.
+func (m *EngineManager) HaltAll(ctx context.Context, flatten bool) ([]EmergencyAction, error) {
+	if err := m.settingsStore.SetHalted(time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to persist halt: %w", err)
+	}
+	actions := []EmergencyAction{NewEmergencyAction("", "halt", "", nil)}
+	log.Printf("🚨 EMERGENCY STOP: halting all traders (flatten: %v)", flatten)
+
+	m.mu.Lock()
+	engines := m.engines
+	m.engines = make(map[string]*Engine)
+	m.mu.Unlock()
+
+	ids := make([]string, 0, len(engines))
+	for id := range engines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		actions = append(actions, engines[id].emergencyStop(ctx, flatten)...)
+		if err := m.traderStore.UpdateStatus(id, "stopped"); err != nil {
+			actions = append(actions, NewEmergencyAction(id, "update_status", "", err))
+		}
+		log.Printf("Emergency stopped trader: %s", id)
+	}
+	return actions, nil
+}
+
+// Resume lifts the emergency halt. Traders stay stopped until started again.
+func (m *EngineManager) Resume() error {
+	if err := m.settingsStore.ClearHalted(); err != nil {
+		return fmt.Errorf("failed to clear halt: %w", err)
+	}
+	log.Printf("Emergency halt lifted")
+	return nil
+}
+
+// IsHalted reports whether the emergency halt is in effect. A halt that
+// can't be read counts as halted.
+func (m *EngineManager) IsHalted() bool {
+	state, err := m.HaltState()
+	if err != nil {
+		log.Printf("Failed to read halt state: %v", err)
+		return true
+	}
+	return state.Halted
+}
+
+// HaltState returns the persisted emergency halt state
+func (m *EngineManager) HaltState() (*store.HaltState, error) {
+	return m.settingsStore.GetHaltState()
+}
@@ -0,0 +1,19 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNewEmergencyAction tests that a step's error becomes its audit result
+func TestNewEmergencyAction(t *testing.T) {
+	ok := NewEmergencyAction("t1", "cancel_orders", "BTCUSDT", nil)
+	if ok.Result != "ok" || ok.TraderID != "t1" || ok.Symbol != "BTCUSDT" || ok.Time.IsZero() {
+		t.Errorf("unexpected action: %+v", ok)
+	}
+
+	failed := NewEmergencyAction("t1", "flatten", "ETHUSDT", errors.New("insufficient margin"))
+	if failed.Result != "insufficient margin" {
+		t.Errorf("Result = %q, want the error", failed.Result)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,12 +20,15 @@ import (
 	"auto-trader-ahh/exchange"
 	"auto-trader-ahh/market"
 	"auto-trader-ahh/mcp"
+	"auto-trader-ahh/report"
 	"auto-trader-ahh/store"
 )
 
 // Notifier interfaces for broadcasting events
 type Notifier interface {
 	Broadcast(evt events.Event)
+	// Publish sends evt to the subscribers of one topic
+	Publish(topic string, evt events.Event)
 }
 
 type Engine struct {
@@ -34,6 +40,7 @@ type Engine struct {
 	aiClient     *ai.Client          // Legacy AI client (for backward compatibility)
 	binance      *exchange.BinanceClient
 	dataProvider *market.DataProvider
+	stream       *market.StreamManager // Live klines and mark prices for the traded symbols
 	notifier     Notifier
 
 	// Decision Engine (NOFX-style XML parsing with CoT)
@@ -46,23 +53,37 @@ type Engine struct {
 	stopCh  chan struct{}
 	mu      sync.RWMutex
 
+	// Whether this run has announced that the primary model failed over
+	fallbackAnnounced bool
+
 	// State
 	lastDecisions    map[string]*ai.TradingDecision
-	lastFullDecision *decision.FullDecision // Latest full decision with CoT
+	decisionRepeats  map[string]*decisionRepeat // key: symbol -> last decision and how often it repeated
+	lastFullDecision *decision.FullDecision     // Latest full decision with CoT
+	recentBlocks     []*store.BlockedDecision   // Decisions rules kept from executing, newest first
 	positions        map[string]*exchange.Position
 	account          *exchange.AccountInfo
 
 	// Stores
-	decisionStore *store.DecisionStore
-	equityStore   *store.EquityStore
-	tradeStore    *store.TradeStore
+	decisionStore   *store.DecisionStore
+	equityStore     *store.EquityStore
+	tradeStore      *store.TradeStore
+	aiCallStore     *store.AICallStore
+	stateStore      *store.EngineStateStore
+	positionStore   *store.PositionStore
+	smartFindStore  *store.SmartFindStore
+	riskEventStore  *store.RiskEventStore
+	annotationStore *store.AnnotationStore
+	posEventStore   *store.PositionEventStore
+	incomeStore     *store.IncomeStore
 
 	// Position Management - Peak P&L tracking
 	peakPnLCache      map[string]float64 // key: "symbol_side" -> peak P&L %
 	peakPnLCacheMutex sync.RWMutex
 
 	// Position Management - Hold duration tracking
-	positionFirstSeenTime map[string]int64 // key: "symbol_side" -> timestamp in ms
+	positionFirstSeenTime map[string]int64     // key: "symbol_side" -> timestamp in ms
+	unconfirmed           map[string]time.Time // key: symbol -> when an entry filled, until a fetch shows the position
 
 	// Daily Loss Tracking
 	dailyPnL       float64   // Today's realized + unrealized P&L
@@ -70,9 +91,69 @@ type Engine struct {
 	stopUntil      time.Time // Don't trade until this time (after daily loss trigger)
 	initialBalance float64   // Balance at start of day for daily loss calculation
 
+	// Realized P&L and fees of closed positions, refreshed each cycle and risk check
+	realized store.RealizedTotals
+
+	// Income history sync; transfers move the daily loss baseline
+	lastIncomeSync time.Time
+
+	// Circuit breaker: no new positions after a deep drawdown or losing
+	// streak until it is acknowledged
+	peakEquity     float64                   // All-time peak equity, rebased on acknowledgement
+	circuitBreaker *store.CircuitBreakerTrip // nil when not tripped
+	breakerResetAt time.Time                 // Last acknowledgement, earlier losses don't count
+	losingStreak   int                       // Losing closes in a row since breakerResetAt
+
+	// Prompt experiment
+	experimentCycles int64                // Cycles assigned a variant so far
+	cycleVariant     *store.PromptVariant // Variant of the running cycle, nil outside one
+
 	// Order sync
 	orderSyncStop chan struct{}
 
+	// Persisted so a restart keeps the cycle schedule
+	lastCycleAt time.Time
+
+	// Most recent cycle failure, cleared by a cycle that completes without one
+	lastError   string
+	lastErrorAt time.Time
+
+	// PositionStore reconciliation counters
+	positionSync PositionSyncStats
+
+	// Orders aborted because the position changed after the decision
+	staleDecisions StaleDecisionStats
+
+	// Decision prompts against the strategy's token budget
+	promptBudget PromptBudgetStats
+
+	// Re-entry cooldowns
+	lastCloses map[string]*store.CloseRecord // key: symbol -> most recent close
+
+	// Leverage last set on the exchange, to skip redundant SetLeverage calls
+	symbolLeverage map[string]int // key: symbol -> leverage
+
+	// Correlation guard
+	priceHistory      map[string]*priceHistory     // key: symbol -> cached klines
+	correlationBlocks map[string]*correlationBlock // key: symbol -> latest refused entry
+	correlationMu     sync.RWMutex
+
+	// Risk check loop
+	executing       map[string]bool // key: symbol -> order in flight
+	lastRiskCheckAt time.Time
+
+	// Symbol ownership among traders sharing the exchange account
+	owners     *positionOwners
+	accountKey string // Fingerprint of the exchange account, empty without credentials
+
+	// Liquidation distance check
+	leverageBrackets  map[string]*leverageBrackets // key: symbol -> maintenance margin tiers
+	liquidationWarned map[string]bool              // key: "symbol_side" -> warned while too close
+	liquidationMu     sync.Mutex
+
+	// Dead-man switch: connectivity tracking, backstop stops and auto-cancel
+	deadMan deadMan
+
 	// SL/TP Order Tracking
 	bracketOrders      map[string]*BracketOrderIDs // key: symbol -> SL/TP order IDs
 	bracketOrdersMutex sync.RWMutex
@@ -81,8 +162,17 @@ type Engine struct {
 	dynamicCoins       []string
 	lastDynamicRefresh time.Time
 
+	// Remote coin source and API pins and bans
+	coinOverrideStore *store.CoinOverrideStore
+	remoteList        *remoteCoinList
+	coinOverrides     []*store.CoinOverride
+	coinMu            sync.Mutex
+
 	// Smart Find Auto-Refresh
 	lastSmartFindRefresh time.Time
+	lastSmartFindRun     time.Time            // Any run, successful or not, for manual refresh spacing
+	smartFindMu          sync.Mutex           // One run at a time
+	listingTimes         map[string]time.Time // Symbol listing times for the age filter, guarded by smartFindMu
 }
 
 // BracketOrderIDs tracks stop-loss and take-profit order IDs for a position
@@ -100,15 +190,27 @@ type TradeLog struct {
 	Action      string
 	Decision    *ai.TradingDecision
 	RawAI       string
+	AICall      *ai.DecisionCall // Full prompts and response, nil if no AI call was made
 	MarketData  string
 	Error       string
-	CoTTrace    string  // Chain of thought from AI reasoning
-	RealizedPnL float64 // PnL realized when closing a position
+	CoTTrace    string                 // Chain of thought from AI reasoning
+	RealizedPnL float64                // PnL realized when closing a position
+	Unchanged   int                    // Cycles in a row the decision came back the same against an unchanged position
+	BlockReason string                 // Why a validator or risk rule kept the decision from executing
+	Latency     *store.DecisionLatency // From the market data to the order, nil if the decision didn't get that far
+	Status      string                 // What became of the decision, a store.DecisionStatus
+	PromptFit   decision.PromptFit     // Estimated prompt size and what the token budget trimmed
 }
 
 // NewEngine creates a new trading engine with strategy support
 func NewEngine(id, name string, aiClient *ai.Client, binance *exchange.BinanceClient, strategy *store.Strategy, traderCfg *store.TraderConfig, cfg *config.Config, notifier Notifier) *Engine {
-	dataProvider := market.NewDataProvider(binance)
+	stream := market.NewStreamManager(name, binance != nil && binance.IsTestnet())
+	dataProvider := market.NewDataProvider(binance, stream)
+
+	accountKey := ""
+	if binance != nil {
+		accountKey = binance.AccountFingerprint()
+	}
 
 	// Determine API Key and Model (Trader config > Global config)
 	apiKey := cfg.OpenRouterAPIKey
@@ -123,45 +225,91 @@ func NewEngine(id, name string, aiClient *ai.Client, binance *exchange.BinanceCl
 		}
 	}
 
-	// Create MCP client from config (uses OpenRouter by default)
-	mcpClient := mcp.NewOpenRouterClient(apiKey, model)
+	// A strategy's model applies over the trader's
+	if strategy != nil && strategy.Config.AI.Model != "" {
+		model = strategy.Config.AI.Model
+		if aiClient != nil {
+			aiClient.SetModel(model)
+		}
+	}
+
+	// Create MCP client from config (uses OpenRouter by default, or a local Ollama server)
+	var mcpClient mcp.AIClient
+	if strategy != nil && strategy.Config.AI.Provider == mcp.ProviderOllama {
+		mcpClient = mcp.NewOllamaClient(cfg.OllamaBaseURL, model)
+		if aiClient != nil {
+			aiClient.SetBackend(mcpClient)
+		}
+	} else {
+		mcpClient = mcp.NewOpenRouterClient(apiKey, model)
+	}
+
+	// A faulted exchange gets a faulted AI provider too
+	if binance != nil && binance.InjectingFaults() {
+		if faults := aiFaults(cfg); faults.Enabled() {
+			mcpClient = mcp.NewFaultyClient(mcpClient, faults)
+			if aiClient != nil {
+				aiClient.SetBackend(mcpClient)
+			}
+		}
+	}
+
+	// Traders take turns in the provider's call queue
+	if setter, ok := mcpClient.(mcp.CallerSetter); ok {
+		setter.SetCaller(id)
+	}
+	if aiClient != nil {
+		aiClient.SetCaller(id)
+	}
 
 	// Create decision engine with English language
 	decisionEngine := decision.NewEngine(mcpClient, decision.LangEnglish)
 
 	// Configure validation from strategy if available
-	if strategy != nil {
-		validationCfg := &decision.ValidationConfig{
-			AccountEquity:     10000, // Will be updated at runtime
-			BTCETHLeverage:    strategy.Config.RiskControl.BTCETHMaxLeverage,
-			AltcoinLeverage:   strategy.Config.RiskControl.AltcoinMaxLeverage,
-			BTCETHPosRatio:    strategy.Config.RiskControl.BTCETHMaxPositionValueRatio,
-			AltcoinPosRatio:   strategy.Config.RiskControl.AltcoinMaxPositionValueRatio,
-			MinPositionBTCETH: strategy.Config.RiskControl.MinPositionSizeBTCETH,
-			MinPositionAlt:    strategy.Config.RiskControl.MinPositionSize,
-			MinRiskReward:     strategy.Config.RiskControl.MinRiskRewardRatio,
+	validationCfg := newValidationConfig(strategy, 10000) // Equity will be updated at runtime
+	if binance != nil {
+		validationCfg.SymbolCheck = binance.CheckTradable
+	}
+	decisionEngine.SetValidationConfig(validationCfg)
+
+	params := generationParams(strategy)
+	decisionEngine.SetGenerationParams(params)
+	if aiClient != nil {
+		aiClient.SetGenerationParams(params)
+		if strategy != nil {
+			aiClient.SetFallbackModels(strategy.Config.AI.FallbackModels)
 		}
-		decisionEngine.SetValidationConfig(validationCfg)
 	}
 
 	return &Engine{
-		id:             id,
-		name:           name,
-		cfg:            cfg,
-		strategy:       strategy,
-		traderConfig:   traderCfg,
-		aiClient:       aiClient,
-		binance:        binance,
-		dataProvider:   dataProvider,
-		mcpClient:      mcpClient,
-		decisionEngine: decisionEngine,
-		startTime:      time.Now(),
-		stopCh:         make(chan struct{}),
-		lastDecisions:  make(map[string]*ai.TradingDecision),
-		positions:      make(map[string]*exchange.Position),
-		decisionStore:  store.NewDecisionStore(),
-		equityStore:    store.NewEquityStore(),
-		tradeStore:     store.NewTradeStore(),
+		id:              id,
+		name:            name,
+		cfg:             cfg,
+		strategy:        strategy,
+		traderConfig:    traderCfg,
+		aiClient:        aiClient,
+		binance:         binance,
+		dataProvider:    dataProvider,
+		stream:          stream,
+		mcpClient:       mcpClient,
+		decisionEngine:  decisionEngine,
+		startTime:       time.Now(),
+		stopCh:          make(chan struct{}),
+		lastDecisions:   make(map[string]*ai.TradingDecision),
+		positions:       make(map[string]*exchange.Position),
+		decisionStore:   store.NewDecisionStore(),
+		equityStore:     store.NewEquityStore(),
+		tradeStore:      store.NewTradeStore(),
+		aiCallStore:     newAICallStore(cfg),
+		stateStore:      store.NewEngineStateStore(),
+		positionStore:   store.NewPositionStore(),
+		smartFindStore:  store.NewSmartFindStore(),
+		riskEventStore:  store.NewRiskEventStore(),
+		annotationStore: store.NewAnnotationStore(),
+		posEventStore:   store.NewPositionEventStore(),
+		incomeStore:     store.NewIncomeStore(),
+
+		coinOverrideStore: store.NewCoinOverrideStore(),
 
 		// Initialize position management maps
 		peakPnLCache:          make(map[string]float64),
@@ -170,6 +318,20 @@ func NewEngine(id, name string, aiClient *ai.Client, binance *exchange.BinanceCl
 		// Initialize bracket orders tracking
 		bracketOrders: make(map[string]*BracketOrderIDs),
 
+		lastCloses: make(map[string]*store.CloseRecord),
+		executing:  make(map[string]bool),
+
+		owners:     accountOwners,
+		accountKey: accountKey,
+
+		symbolLeverage: make(map[string]int),
+
+		priceHistory:      make(map[string]*priceHistory),
+		correlationBlocks: make(map[string]*correlationBlock),
+
+		leverageBrackets:  make(map[string]*leverageBrackets),
+		liquidationWarned: make(map[string]bool),
+
 		// Initialize daily tracking
 		lastResetTime:  time.Now(),
 		initialBalance: 0,
@@ -186,6 +348,7 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.running = true
 	e.stopCh = make(chan struct{})
 	e.orderSyncStop = make(chan struct{})
+	e.fallbackAnnounced = false
 	e.mu.Unlock()
 
 	log.Printf("[%s] Starting trading engine...", e.name)
@@ -201,30 +364,38 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.lastResetTime = time.Now()
 	log.Printf("[%s] Connected to Binance. Balance: $%.2f", e.name, account.TotalWalletBalance)
 
-	// Set leverage for all pairs (separate limits for BTC/ETH vs altcoins)
+	// Pick up where the previous run left off
+	e.restoreState(ctx)
+	e.loadCoinOverrides()
+	e.refreshRealized()
+	e.loadRecentBlocks()
+
+	// Set leverage for all pairs (separate limits for BTC/ETH vs altcoins).
+	// Entries set their own leverage again if the decision asks for less.
 	coins := e.getTradingPairs()
 	for _, pair := range coins {
-		leverage := e.getLeverageLimit(pair)
-		if err := e.binance.SetLeverage(ctx, pair, leverage); err != nil {
-			log.Printf("[%s] Warning: failed to set leverage for %s: %v", e.name, pair, err)
-		} else {
-			log.Printf("[%s] Set leverage for %s to %dx", e.name, pair, leverage)
+		if _, err := e.ensureLeverage(ctx, pair, e.getLeverageLimit(pair)); err != nil {
+			log.Printf("[%s] Warning: %s: %v", e.name, pair, err)
 		}
 	}
 
+	// Stream market data for the pairs instead of polling REST each cycle
+	e.updateStreamSymbols()
+	e.stream.Start()
+
 	// Start background goroutines
 	go e.tradingLoop(ctx)
-	go e.startDrawdownMonitor(ctx)
+	go e.startRiskMonitor(ctx)
 	go e.startOrderSync(ctx)
 
+	e.publishLifecycle("started")
 	return nil
 }
 
 func (e *Engine) Stop() {
 	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	if !e.running {
+		e.mu.Unlock()
 		return
 	}
 
@@ -234,6 +405,15 @@ func (e *Engine) Stop() {
 		close(e.orderSyncStop)
 	}
 	e.running = false
+	e.mu.Unlock()
+
+	e.stream.Stop()
+	e.saveState()
+	// Claims are taken again from the saved state on restart
+	if e.ownsAccount() {
+		e.owners.releaseAll(e.id)
+	}
+	e.publishLifecycle("stopped")
 }
 
 func (e *Engine) IsRunning() bool {
@@ -254,13 +434,34 @@ func (e *Engine) SetStrategy(strategy *store.Strategy) {
 
 	oldSimpleMode := false
 	oldTrailingStop := false
+	var oldAI store.AIConfig
 	if e.strategy != nil {
 		oldSimpleMode = e.strategy.Config.SimpleMode
 		oldTrailingStop = e.strategy.Config.RiskControl.EnableTrailingStop
+		oldAI = e.strategy.Config.AI
+	}
+	if newAI := strategy.Config.AI; newAI.Provider != oldAI.Provider || newAI.Model != oldAI.Model {
+		log.Printf("[%s] Strategy updated: AI provider/model changes apply when the trader restarts", e.name)
 	}
 
 	e.strategy = strategy
 
+	params := generationParams(strategy)
+	if e.aiClient != nil {
+		if old := e.aiClient.GetGenerationParams(); old != params {
+			log.Printf("[%s] Strategy updated: AI params changed from %+v to %+v", e.name, old, params)
+		}
+		e.aiClient.SetGenerationParams(params)
+
+		if old, fallbacks := e.aiClient.GetFallbackModels(), strategy.Config.AI.FallbackModels; !slices.Equal(old, fallbacks) {
+			log.Printf("[%s] Strategy updated: fallback models changed from %v to %v", e.name, old, fallbacks)
+		}
+		e.aiClient.SetFallbackModels(strategy.Config.AI.FallbackModels)
+	}
+	if e.decisionEngine != nil {
+		e.decisionEngine.SetGenerationParams(params)
+	}
+
 	// Log important changes
 	newSimpleMode := strategy.Config.SimpleMode
 	newTrailingStop := strategy.Config.RiskControl.EnableTrailingStop
@@ -275,6 +476,15 @@ func (e *Engine) SetStrategy(strategy *store.Strategy) {
 	log.Printf("[%s] Strategy config reloaded successfully", e.name)
 }
 
+// generationParams returns the strategy's AI sampling settings, the defaults
+// without a strategy
+func generationParams(strategy *store.Strategy) mcp.GenerationParams {
+	if strategy == nil {
+		return mcp.DefaultGenerationParams()
+	}
+	return strategy.Config.AI.GenerationParams()
+}
+
 // GetStrategyID returns the current strategy ID
 func (e *Engine) GetStrategyID() string {
 	e.mu.RLock()
@@ -287,6 +497,16 @@ func (e *Engine) GetStrategyID() string {
 }
 
 func (e *Engine) getTradingPairs() []string {
+	universe := e.CoinUniverse()
+	pairs := make([]string, len(universe))
+	for i, c := range universe {
+		pairs[i] = c.Symbol
+	}
+	return pairs
+}
+
+// sourceCoins returns the symbols of the strategy's coin source and their origin
+func (e *Engine) sourceCoins() ([]string, string) {
 	if e.strategy != nil {
 		sourceType := e.strategy.Config.CoinSource.SourceType
 
@@ -301,7 +521,7 @@ func (e *Engine) getTradingPairs() []string {
 					log.Printf("[%s] Failed to fetch top coins, using previous list/static fallback: %v", e.name, err)
 					// Verify we have something to fall back to
 					if len(e.dynamicCoins) == 0 {
-						return e.strategy.Config.CoinSource.StaticCoins
+						return e.strategy.Config.CoinSource.StaticCoins, CoinOriginStatic
 					}
 				} else {
 					e.dynamicCoins = topCoins
@@ -309,15 +529,22 @@ func (e *Engine) getTradingPairs() []string {
 					log.Printf("[%s] Updated dynamic coin list: %v", e.name, e.dynamicCoins)
 				}
 			}
-			return e.dynamicCoins
+			return e.dynamicCoins, CoinOriginDynamic
+		}
+
+		// Remote list, the static list until one has been fetched
+		if sourceType == store.CoinSourceRemote {
+			if coins := e.remoteCoins(context.Background(), &e.strategy.Config.CoinSource); len(coins) > 0 {
+				return coins, CoinOriginRemote
+			}
 		}
 
 		// Fallback to static list
 		if len(e.strategy.Config.CoinSource.StaticCoins) > 0 {
-			return e.strategy.Config.CoinSource.StaticCoins
+			return e.strategy.Config.CoinSource.StaticCoins, CoinOriginStatic
 		}
 	}
-	return e.cfg.TradingPairs
+	return e.cfg.TradingPairs, CoinOriginDefault
 }
 
 func (e *Engine) getTradingInterval() time.Duration {
@@ -353,32 +580,18 @@ func (e *Engine) maybeRefreshSmartFind(ctx context.Context) {
 
 	log.Printf("[%s] 🔍 Smart Find Auto-Refresh triggered (interval: %d mins)", e.name, refreshMins)
 
-	// Get max positions to calculate target count (2x max positions)
-	maxPositions := 3
-	if e.strategy.Config.RiskControl.MaxPositions > 0 {
-		maxPositions = e.strategy.Config.RiskControl.MaxPositions
-	}
-	targetCount := maxPositions * 2
-
-	// Perform Smart Find
-	newCoins, err := e.runSmartFind(ctx, targetCount)
+	run, err := e.smartFind(ctx, store.SmartFindTriggerAuto)
 	if err != nil {
 		log.Printf("[%s] ⚠️ Smart Find Auto-Refresh failed: %v", e.name, err)
 		return
 	}
 
-	// Update strategy's static coins
-	e.mu.Lock()
-	e.strategy.Config.CoinSource.StaticCoins = newCoins
-	e.strategy.Config.CoinSource.SourceType = "static"
-	e.lastSmartFindRefresh = time.Now()
-	e.mu.Unlock()
-
-	log.Printf("[%s] ✅ Smart Find Auto-Refresh complete. New coins: %v", e.name, newCoins)
+	log.Printf("[%s] ✅ Smart Find Auto-Refresh complete. New coins: %v", e.name, run.Selected)
 }
 
-// runSmartFind finds risky symbols using AI analysis
-func (e *Engine) runSmartFind(ctx context.Context, targetCount int) ([]string, error) {
+// runSmartFind finds risky symbols using AI analysis, recording what the AI
+// was shown and returned in run
+func (e *Engine) runSmartFind(ctx context.Context, targetCount int, run *store.SmartFindRun) ([]string, error) {
 	// 1. Get 24h tickers from Binance
 	tickers, err := e.binance.Get24hTicker(ctx)
 	if err != nil {
@@ -392,37 +605,22 @@ func (e *Engine) runSmartFind(ctx context.Context, targetCount int) ([]string, e
 	}
 
 	// 3. Filter and prepare candidates
-	type MarketCoin struct {
-		Symbol      string
-		PriceChange float64
-		Volume      float64
-		QuoteVolume float64
-	}
-
-	var candidates []MarketCoin
+	filter := newSmartFindFilter(e.strategy.Config.CoinSource)
+	var candidates []smartFindCoin
 	for _, t := range tickers {
-		// Basic filter: USDT pairs, reasonable volume
-		if len(t.Symbol) > 4 && t.Symbol[len(t.Symbol)-4:] == "USDT" {
-			// Ensure symbol is actively trading (Futures)
-			if !e.binance.IsActiveSymbol(t.Symbol) {
-				continue
-			}
-
-			// Skip stables
-			if t.Symbol == "USDCUSDT" || t.Symbol == "FDUSDUSDT" || t.Symbol == "TUSDUSDT" || t.Symbol == "USDPUSDT" {
-				continue
-			}
-
-			// Only consider decent volume (>500k)
-			if t.QuoteVolume > 500000 {
-				candidates = append(candidates, MarketCoin{
-					Symbol:      t.Symbol,
-					PriceChange: t.PriceChange,
-					Volume:      t.Volume,
-					QuoteVolume: t.QuoteVolume,
-				})
-			}
+		if filter.symbolReason(t.Symbol, t.QuoteVolume) != "" {
+			continue
+		}
+		// Ensure symbol is a tradable USDT-M perpetual
+		if !e.binance.IsTradable(t.Symbol) {
+			continue
 		}
+		candidates = append(candidates, smartFindCoin{
+			Symbol:      t.Symbol,
+			PriceChange: t.PriceChange,
+			Volume:      t.Volume,
+			QuoteVolume: t.QuoteVolume,
+		})
 	}
 
 	// 4. Build prompt - Always sort by volatility for Smart Find (find movers, not just safe coins)
@@ -442,8 +640,9 @@ func (e *Engine) runSmartFind(ctx context.Context, targetCount int) ([]string, e
 		}
 		return absI > absJ
 	})
-	if len(candidates) > 30 {
-		candidates = candidates[:30]
+	candidates, err = e.screenSmartFindMarkets(ctx, candidates, filter, smartFindCandidates)
+	if err != nil {
+		return nil, err
 	}
 
 	if isTurbo {
@@ -468,6 +667,11 @@ Here are the Top 30 pairs by Volatility (Price Change):
 
 	for _, c := range candidates {
 		prompt += fmt.Sprintf("- %s: Vol=$%.0fM, Chg=%.2f%%\n", c.Symbol, c.QuoteVolume/1000000, c.PriceChange)
+		run.Candidates = append(run.Candidates, store.SmartFindCandidate{
+			Symbol:         c.Symbol,
+			QuoteVolume:    c.QuoteVolume,
+			PriceChangePct: c.PriceChange,
+		})
 	}
 
 	prompt += fmt.Sprintf(`
@@ -479,6 +683,7 @@ Result:`, targetCount)
 	if err != nil {
 		return nil, fmt.Errorf("AI request failed: %w", err)
 	}
+	run.RawResponse = response
 
 	// 6. Parse Response (Extract JSON array)
 	jsonStr := response
@@ -493,8 +698,29 @@ Result:`, targetCount)
 	if err := json.Unmarshal([]byte(jsonStr), &recommended); err != nil {
 		return nil, fmt.Errorf("failed to parse AI response: %w", err)
 	}
+	run.Recommended = recommended
+
+	// The AI sometimes invents symbols; drop anything the exchange can't trade
+	tradable, rejected := e.binance.FilterTradable(recommended)
+	for symbol, reason := range rejected {
+		log.Printf("[%s] Smart Find dropped %s: %s", e.name, symbol, reason)
+	}
+	// Nor anything the lists rule out, the AI doesn't always stick to the candidates
+	allowed := tradable[:0]
+	for _, symbol := range tradable {
+		if reason := filter.listReason(symbol); reason != "" {
+			log.Printf("[%s] Smart Find dropped %s: %s", e.name, symbol, reason)
+			continue
+		}
+		allowed = append(allowed, symbol)
+	}
+	tradable = allowed
+	if len(tradable) == 0 {
+		return nil, fmt.Errorf("no tradable symbols in AI response %v", recommended)
+	}
+	run.Selected = tradable
 
-	return recommended, nil
+	return tradable, nil
 }
 
 func (e *Engine) getMinConfidence() int {
@@ -504,16 +730,61 @@ func (e *Engine) getMinConfidence() int {
 	return 70
 }
 
+// marketIndicators returns the indicators the strategy enables. A strategy
+// with none enabled predates the flags and gets all of them.
+func (e *Engine) marketIndicators() market.Indicators {
+	if e.strategy == nil {
+		return market.DefaultIndicators()
+	}
+	ic := e.strategy.Config.Indicators
+	ind := market.Indicators{
+		EMA:           ic.EnableEMA,
+		MACD:          ic.EnableMACD,
+		RSI:           ic.EnableRSI,
+		ATR:           ic.EnableATR,
+		BOLL:          ic.EnableBOLL,
+		Volume:        ic.EnableVolume,
+		BOLLPeriod:    ic.BOLLPeriod,
+		BOLLStdDev:    ic.BOLLStdDev,
+		SRLookback:    ic.SRLookback,
+		SRSensitivity: ic.SRSensitivity,
+		MinDepthUSD:   e.strategy.Config.RiskControl.MinDepthUSD,
+
+		MaxAdverseFundingRate: e.strategy.Config.RiskControl.MaxAdverseFundingRate,
+		FundingMinConfidence:  e.fundingOverrideConfidence(),
+	}
+	if !ind.EMA && !ind.MACD && !ind.RSI && !ind.ATR && !ind.BOLL && !ind.Volume {
+		defaults := market.DefaultIndicators()
+		defaults.MinDepthUSD = ind.MinDepthUSD
+		defaults.MaxAdverseFundingRate, defaults.FundingMinConfidence = ind.MaxAdverseFundingRate, ind.FundingMinConfidence
+		return defaults
+	}
+	return ind
+}
+
 func (e *Engine) tradingLoop(ctx context.Context) {
 	interval := e.getTradingInterval()
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
 
 	log.Printf("[%s] Trading loop started (interval: %v)", e.name, interval)
 
-	// Run immediately on start
+	// Run immediately on start, unless a restart comes before the next cycle was due
+	if wait := e.resumeDelay(interval); wait > 0 {
+		log.Printf("[%s] Resuming schedule, next cycle in %v", e.name, wait.Round(time.Second))
+		select {
+		case <-e.stopCh:
+			log.Printf("[%s] Trading loop stopped", e.name)
+			return
+		case <-ctx.Done():
+			log.Printf("[%s] Context cancelled, stopping trading loop", e.name)
+			return
+		case <-time.After(wait):
+		}
+	}
 	e.runTradingCycle(ctx)
 
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-e.stopCh:
@@ -531,8 +802,13 @@ func (e *Engine) tradingLoop(ctx context.Context) {
 func (e *Engine) runTradingCycle(ctx context.Context) {
 	log.Printf("[%s] === Starting trading cycle ===", e.name)
 
+	e.mu.Lock()
+	e.lastCycleAt = time.Now()
+	e.mu.Unlock()
+	defer e.saveState()
+
 	// Reset daily P&L if new day
-	e.resetDailyPnLIfNeeded()
+	e.resetDailyPnLIfNeeded(time.Now())
 
 	// Check if trading is paused due to daily loss
 	if e.shouldStopTrading() {
@@ -543,16 +819,28 @@ func (e *Engine) runTradingCycle(ctx context.Context) {
 		return
 	}
 
+	// Outside the trading schedule a full pause skips the cycle; pause_entries
+	// still runs it so the AI can manage exits
+	if paused, behavior, next := e.scheduleStatus(time.Now()); paused && behavior == scheduleFullPause {
+		log.Printf("[%s] Outside trading schedule, skipping cycle (next active: %s)", e.name, formatNextActive(next))
+		return
+	}
+
 	// Check Trading Mode: If Copy Trading is enabled, switch to monitoring mode
 	if e.strategy != nil && e.strategy.Config.TradingMode == "copy_trade" {
 		e.runCopyTradingCycle(ctx)
 		return
 	}
 
+	// Errors seen this cycle; the last one is kept for the status summary
+	var cycleErr string
+
 	// Update account info
 	account, err := e.binance.GetAccountInfo(ctx)
+	e.noteExchangeCall(err)
 	if err != nil {
 		log.Printf("[%s] Error getting account info: %v", e.name, err)
+		cycleErr = fmt.Sprintf("getting account info: %v", err)
 	} else {
 		e.mu.Lock()
 		e.account = account
@@ -568,38 +856,27 @@ func (e *Engine) runTradingCycle(ctx context.Context) {
 			if account.TotalMarginBalance <= minBal {
 				log.Printf("[%s] 🚨 EMERGENCY SHUTDOWN TRIGGERED! Equity $%.2f is below safety limit $%.2f. Stopping trading cycle.",
 					e.name, account.TotalMarginBalance, minBal)
+				e.setLastError(fmt.Sprintf("emergency shutdown: equity $%.2f below $%.2f", account.TotalMarginBalance, minBal))
 				// We return immediately to prevent any further trading actions (opening OR managed closing)
 				// Existing positions will rely on their hard SL/TP orders on the exchange.
 				return
 			}
 		}
 
-		// Save equity snapshot
-		e.equityStore.Save(&store.EquitySnapshot{
-			TraderID:      e.id,
-			Timestamp:     time.Now(),
-			TotalEquity:   account.TotalMarginBalance,
-			Balance:       account.TotalWalletBalance,
-			UnrealizedPnL: account.TotalUnrealizedProfit,
-		})
+		e.saveEquitySnapshot(account)
 	}
+	e.syncIncome(ctx)
 
 	// Update positions
-	positions, err := e.binance.GetPositions(ctx)
-	if err != nil {
+	if err := e.refreshPositions(ctx); err != nil {
 		log.Printf("[%s] Error getting positions: %v", e.name, err)
-	} else {
-		e.mu.Lock()
-		e.positions = make(map[string]*exchange.Position)
-		for i := range positions {
-			e.positions[positions[i].Symbol] = &positions[i]
-		}
-		e.mu.Unlock()
+		cycleErr = fmt.Sprintf("getting positions: %v", err)
 	}
 
 	// Smart Find Auto-Refresh: Check if it's time to find new symbols
 	// This runs AFTER positions are updated so we know our current state
 	e.maybeRefreshSmartFind(ctx)
+	e.updateStreamSymbols()
 
 	// Determine pairs to analyze (Optimize AI Token Usage)
 	var pairsToAnalyze []string
@@ -626,25 +903,118 @@ func (e *Engine) runTradingCycle(ctx context.Context) {
 			e.name, len(activeSymbols), maxPositions)
 		pairsToAnalyze = activeSymbols
 	} else {
-		pairsToAnalyze = e.getTradingPairs()
+		pairsToAnalyze = withHeldSymbols(e.getTradingPairs(), activeSymbols)
 	}
 
-	// Process each trading pair
-	allDecisions := make([]map[string]interface{}, 0)
+	// A prompt experiment's variant is assigned before any AI call
+	e.startExperimentCycle()
+	defer e.endExperimentCycle()
+
+	// Fetch every pair's market data up front, then analyze each and execute
+	// the decisions closes first. The AI provider's scheduler paces the calls.
+	analysisStart := time.Now()
+	cycleData := e.fetchCycleData(ctx, pairsToAnalyze)
+	dataDuration := time.Since(analysisStart)
+
+	tradeLogs := make([]*TradeLog, 0, len(pairsToAnalyze))
+	var pending []*pendingTrade
 	for _, symbol := range pairsToAnalyze {
 		log.Printf("[%s] Analyzing %s...", e.name, symbol)
 
-		tradeLog := e.analyzeAndTrade(ctx, symbol)
+		tradeLog, p := e.analyzeSymbol(ctx, symbol, cycleData[symbol])
+		tradeLogs = append(tradeLogs, tradeLog)
+		if p != nil {
+			pending = append(pending, p)
+		}
+	}
+	e.executeClosesFirst(ctx, pending)
+	e.recordPromptFits(tradeLogs)
+	log.Printf("[%s] Analyzed %d symbols in %v (market data %v)", e.name, len(pairsToAnalyze),
+		time.Since(analysisStart).Round(time.Millisecond), dataDuration.Round(time.Millisecond))
+
+	allDecisions := make([]map[string]interface{}, 0)
+	aiCalls := make([]*store.AICall, 0)
+	var positionIDs []int64 // Positions this cycle's decisions acted on
+	var blocked []*store.BlockedDecision
+	executed := false // Whether any decision's order went through
+	for _, tradeLog := range tradeLogs {
+		symbol := tradeLog.Symbol
+		if call := tradeLog.AICall; call != nil {
+			outcome := "ok"
+			if call.ParseError != "" {
+				outcome = call.ParseError
+			}
+			aiCalls = append(aiCalls, &store.AICall{
+				TraderID:     e.id,
+				Symbol:       symbol,
+				Model:        call.Model,
+				Timestamp:    tradeLog.Timestamp,
+				SystemPrompt: call.SystemPrompt,
+				UserPrompt:   call.UserPrompt,
+				RawResponse:  call.Response,
+				Reasoning:    call.Reasoning,
+				ParseOutcome: outcome,
+				LatencyMs:    call.Latency.Milliseconds(),
+
+				Retries:       call.Retries,
+				RetryReason:   call.RetryReason,
+				FirstResponse: call.FirstResponse,
+
+				PromptTokens:     call.PromptTokens,
+				CompletionTokens: call.CompletionTokens,
+				CostUSD:          call.CostUSD,
+			})
+		}
 
 		decisionData := map[string]interface{}{
 			"symbol": symbol,
 			"action": "NONE",
+			"status": tradeLog.Status,
+		}
+		if tradeLog.Status == store.DecisionStatusExecuted {
+			executed = true
+			if tradeLog.Decision.OrderID != 0 {
+				decisionData["order_id"] = strconv.FormatInt(tradeLog.Decision.OrderID, 10)
+			}
+		}
+		if call := tradeLog.AICall; call != nil {
+			decisionData["ai_params"] = call.Params
+			if call.FallbackFrom != "" {
+				decisionData["ai_model"] = call.Model
+				decisionData["ai_fallback_from"] = call.FallbackFrom
+				e.announceFallback(symbol, call)
+			}
+			if call.Retries > 0 {
+				decisionData["ai_retries"] = call.Retries
+			}
+		}
+		if tradeLog.Unchanged > 0 {
+			decisionData["unchanged"] = tradeLog.Unchanged
+		}
+		if fit := tradeLog.PromptFit; fit.Tokens > 0 {
+			decisionData["prompt_tokens"] = fit.Tokens
+			if len(fit.Trimmed) > 0 {
+				decisionData["prompt_trimmed"] = fit.Trimmed
+			}
+		}
+		if strings.HasPrefix(tradeLog.Error, "AI decision failed") {
+			decisionData["ai_failed"] = true
 		}
 
-		if tradeLog.Error != "" {
+		skipped := strings.HasPrefix(tradeLog.Error, "skipped:")
+		if skipped && tradeLog.Decision != nil {
+			// The AI decided but a risk filter passed on the trade
+			log.Printf("[%s][%s] Skipped %s: %s", e.name, symbol, tradeLog.Decision.Action, tradeLog.Error)
+			decisionData["skip_reason"] = strings.TrimSpace(strings.TrimPrefix(tradeLog.Error, "skipped:"))
+		} else if tradeLog.Error != "" {
 			log.Printf("[%s][%s] Error: %s", e.name, symbol, tradeLog.Error)
 			decisionData["error"] = tradeLog.Error
-		} else if tradeLog.Decision != nil {
+			// Skipped and blocked symbols are the engine working as intended
+			if !skipped && !strings.HasPrefix(tradeLog.Error, "blocked:") {
+				cycleErr = symbol + ": " + tradeLog.Error
+			}
+		}
+		if tradeLog.Decision != nil && (tradeLog.Error == "" || skipped) {
 			log.Printf("[%s][%s] Decision: %s (Confidence: %.0f%%)",
 				e.name, symbol, tradeLog.Decision.Action, tradeLog.Decision.Confidence)
 			log.Printf("[%s][%s] Reasoning: %s", e.name, symbol, tradeLog.Decision.Reasoning)
@@ -652,68 +1022,141 @@ func (e *Engine) runTradingCycle(ctx context.Context) {
 			decisionData["action"] = tradeLog.Decision.Action
 			decisionData["confidence"] = tradeLog.Decision.Confidence
 			decisionData["reasoning"] = tradeLog.Decision.Reasoning
+			if tradeLog.Decision.RiskUSD > 0 {
+				decisionData["risk_usd"] = tradeLog.Decision.RiskUSD
+			}
+			if tradeLog.Decision.Leverage > 0 {
+				decisionData["leverage"] = tradeLog.Decision.Leverage
+			}
+			if tradeLog.Decision.ReferencePrice > 0 {
+				decisionData["reference_price"] = tradeLog.Decision.ReferencePrice
+			}
+			if tradeLog.Latency != nil {
+				decisionData["latency"] = tradeLog.Latency
+			}
 
 			// Include realized PnL if position was closed
 			if tradeLog.RealizedPnL != 0 {
 				decisionData["pnl"] = tradeLog.RealizedPnL
 				log.Printf("[%s][%s] Realized PnL: $%.2f", e.name, symbol, tradeLog.RealizedPnL)
 			}
+			if id := tradeLog.Decision.PositionID; id > 0 {
+				decisionData["position_id"] = id
+				decisionData["timestamp"] = tradeLog.Timestamp
+				positionIDs = append(positionIDs, id)
+			}
 		}
 
-		allDecisions = append(allDecisions, decisionData)
+		if tradeLog.Decision != nil && tradeLog.BlockReason != "" {
+			b := &store.BlockedDecision{
+				TraderID:   e.id,
+				Timestamp:  tradeLog.Timestamp,
+				Symbol:     symbol,
+				Action:     tradeLog.Decision.Action,
+				Confidence: tradeLog.Decision.Confidence,
+				Reason:     tradeLog.BlockReason,
+			}
+			e.recordBlock(b)
+			blocked = append(blocked, b)
+		}
 
-		// Small delay between pairs to avoid rate limits
-		time.Sleep(2 * time.Second)
+		allDecisions = append(allDecisions, decisionData)
 	}
 
 	// Save decision record
 	decisionsJSON, _ := json.Marshal(allDecisions)
-	e.decisionStore.Create(&store.Decision{
-		TraderID:  e.id,
-		Decisions: string(decisionsJSON),
-		Executed:  true,
-	})
+	decisionRecord := &store.Decision{
+		TraderID:    e.id,
+		Decisions:   string(decisionsJSON),
+		Executed:    executed,
+		Variant:     e.variantID(),
+		PositionIDs: positionIDs,
+		Blocked:     blocked,
+	}
+	if err := e.decisionStore.Create(decisionRecord); err != nil {
+		log.Printf("[%s] Failed to save decision record: %v", e.name, err)
+	}
+	e.saveAICalls(decisionRecord.ID, aiCalls)
 
 	// Check if daily loss limit has been exceeded
 	if e.checkDailyLoss() {
 		e.triggerTradingPause(ctx)
 	}
+	e.checkCircuitBreaker()
 
 	// Sync trade history from Binance (captures SL/TP fills)
 	e.syncTradeHistory(ctx)
 
+	e.setLastError(cycleErr)
 	log.Printf("[%s] === Trading cycle complete ===", e.name)
 }
 
-func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
-	tradeLog := &TradeLog{
-		Timestamp: time.Now(),
-		Symbol:    symbol,
+// setLastError records a cycle's failure, or clears the last one when msg is empty
+func (e *Engine) setLastError(msg string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastError = msg
+	if msg == "" {
+		e.lastErrorAt = time.Time{}
+	} else {
+		e.lastErrorAt = time.Now()
 	}
+}
 
-	// Get market data with strategy config
-	timeframe := "5m"
-	klineCount := 100
-	if e.strategy != nil {
-		timeframe = e.strategy.Config.Indicators.PrimaryTimeframe
-		klineCount = e.strategy.Config.Indicators.KlineCount
+// newAICallStore applies the configured per-field size cap
+func newAICallStore(cfg *config.Config) *store.AICallStore {
+	s := store.NewAICallStore()
+	if cfg != nil && cfg.AICallMaxFieldKB > 0 {
+		s.MaxFieldBytes = cfg.AICallMaxFieldKB * 1024
 	}
+	return s
+}
 
-	marketData, err := e.dataProvider.GetMarketDataWithConfig(ctx, symbol, timeframe, klineCount)
-	if err != nil {
-		tradeLog.Error = fmt.Sprintf("failed to get market data: %v", err)
-		return tradeLog
+// saveAICalls stores the cycle's AI calls against its decision record and
+// applies the retention limits
+func (e *Engine) saveAICalls(decisionID int64, calls []*store.AICall) {
+	if len(calls) == 0 {
+		return
+	}
+	for _, call := range calls {
+		call.DecisionID = decisionID
+		if err := e.aiCallStore.Create(call); err != nil {
+			log.Printf("[%s][%s] Failed to save AI call: %v", e.name, call.Symbol, err)
+		}
 	}
 
-	// Fetch BTC Global Context
-	btcStats, err := e.binance.GetTickerStats(ctx, "BTCUSDT")
-	if err == nil {
-		marketData.BTCPrice = btcStats.LastPrice
-		marketData.BTCChange24h = btcStats.PriceChange
+	if e.cfg == nil {
+		return
+	}
+	if err := e.aiCallStore.Prune(e.id, e.cfg.AICallRetentionDays, e.cfg.AICallMaxRows); err != nil {
+		log.Printf("[%s] Failed to prune AI calls: %v", e.name, err)
+	}
+}
+
+// analyzeSymbol asks the AI for symbol's decision on the market data fetched
+// for the cycle. It returns the trade to run in the cycle's execution pass,
+// nil when there's nothing to execute.
+func (e *Engine) analyzeSymbol(ctx context.Context, symbol string, fetched *symbolData) (*TradeLog, *pendingTrade) {
+	tradeLog := &TradeLog{
+		Timestamp: time.Now(),
+		Symbol:    symbol,
+		Status:    store.DecisionStatusNoDecision,
+	}
+
+	// Don't spend an AI call on a symbol we couldn't open
+	if fetched.skip != nil {
+		tradeLog.Error = fetched.skip.Error()
+		return tradeLog, nil
+	}
+	if fetched.err != nil {
+		tradeLog.Error = fmt.Sprintf("failed to get market data: %v", fetched.err)
+		return tradeLog, nil
 	}
+	marketData := fetched.data
 
-	// Format data for AI
-	formattedData := e.dataProvider.FormatForAI(marketData)
+	// The market data goes in front once the rest of the prompt is known, with
+	// as many candles as the strategy's token budget leaves room for
+	var formattedData string
 
 	// Inject Turbo Mode instructions
 	if e.strategy != nil && e.strategy.Config.TurboMode {
@@ -724,8 +1167,13 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 		formattedData += "- ENTRY: Enter immediately on Candle Close if trend aligns. Don't hesitate.\n"
 		formattedData += "- GOAL: Capture quick moves. Activity > Passivity.\n"
 	}
+	turbo := formattedData
 
-	tradeLog.MarketData = formattedData
+	// Where the symbol came from; a held symbol the universe dropped has none
+	origin := coinOrigin(e.CoinUniverse(), symbol)
+	if origin != "" {
+		formattedData += fmt.Sprintf("\nCandidate Origin: %s\n", origin)
+	}
 
 	// Add account info
 	e.mu.RLock()
@@ -734,6 +1182,11 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 		formattedData += fmt.Sprintf("Total Equity: $%.2f\n", e.account.TotalMarginBalance)
 		formattedData += fmt.Sprintf("Available Balance: $%.2f\n", e.account.AvailableBalance)
 		formattedData += fmt.Sprintf("Unrealized PnL: $%.2f\n", e.account.TotalUnrealizedProfit)
+		if budget := e.exposureBudgetLocked(e.account.TotalMarginBalance); budget.Enabled() {
+			formattedData += "\n--- Exposure Budget Remaining ---\n"
+			formattedData += decision.FormatExposureBudget(budget, decision.LangEnglish)
+			formattedData += "Entries past a limit are rejected. Closing a position frees its budget.\n"
+		}
 	}
 
 	// Add position info if exists
@@ -749,18 +1202,64 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 		formattedData += fmt.Sprintf("Hold Duration: %s\n", duration.Round(time.Second))
 
 		formattedData += fmt.Sprintf("Size: %.4f\n", pos.PositionAmt)
+		if entryQty := e.entryQuantities()[getPositionKey(symbol, rowSide(pos.PositionAmt))]; entryQty > math.Abs(pos.PositionAmt) {
+			formattedData += fmt.Sprintf("Entry Size: %.4f (%.0f%% already closed)\n", entryQty, (1-math.Abs(pos.PositionAmt)/entryQty)*100)
+		}
 		formattedData += fmt.Sprintf("Entry Price: $%.2f\n", pos.EntryPrice)
 		formattedData += fmt.Sprintf("Mark Price: $%.2f\n", pos.MarkPrice)
 		formattedData += fmt.Sprintf("Unrealized PnL: $%.2f\n", pos.UnrealizedProfit)
+		e.bracketOrdersMutex.RLock()
+		stopLoss, takeProfit := e.bracketOrders[symbol].levels(pos.PositionAmt > 0)
+		e.bracketOrdersMutex.RUnlock()
+		formattedData += fmt.Sprintf("Stop Loss: %s | Take Profit: %s\n", formatStopLevel(stopLoss), formatStopLevel(takeProfit))
+
+		e.refreshLeverageBrackets(ctx, symbol)
+		if liq := e.liquidationPrice(pos); liq > 0 {
+			formattedData += fmt.Sprintf("Liquidation: $%.2f (%.2f%% away)\n", liq,
+				exchange.LiquidationDistancePct(pos.PositionAmt > 0, pos.MarkPrice, liq))
+			e.checkLiquidationDistance(pos, liq, marketData.ATR)
+		}
+		if origin == "" && pos.PositionAmt != 0 {
+			formattedData += "POSITION-ONLY: symbol is no longer in the coin universe and is analyzed until this position closes. No new entries or adds - HOLD, move stops or CLOSE.\n"
+		}
 	} else {
 		formattedData += "\n--- No Current Position ---\n"
+		e.clearLiquidationWarnings(symbol)
+		formattedData += fmt.Sprintf("Max Leverage: %dx\n", e.getLeverageLimit(symbol))
+		if remaining, stopLoss := e.getCooldown(symbol); remaining > 0 {
+			reason := "recently closed"
+			if stopLoss {
+				reason = "stopped out"
+			}
+			formattedData += fmt.Sprintf("COOLDOWN: symbol in cooldown for %d more minutes (%s). Opening is blocked - answer HOLD.\n",
+				int(math.Ceil(remaining.Minutes())), reason)
+		}
+		if paused, _, next := e.scheduleStatus(time.Now()); paused {
+			formattedData += fmt.Sprintf("SCHEDULE: outside trading hours until %s. Opening is blocked - answer HOLD.\n", formatNextActive(next))
+		}
+		if block, ok := e.recentCorrelationBlock(symbol); ok {
+			formattedData += fmt.Sprintf("CORRELATION: last %s entry blocked, %s. Only open if held correlated positions were reduced.\n",
+				strings.ToUpper(block.Side), block.Reason)
+		}
 	}
 
 	// Add strategy rules
-	if e.strategy != nil && e.strategy.Config.CustomPrompt != "" {
-		formattedData += fmt.Sprintf("\n--- Strategy Rules ---\n%s\n", e.strategy.Config.CustomPrompt)
+	if rules := e.customPrompt(); rules != "" {
+		formattedData += fmt.Sprintf("\n--- Strategy Rules ---\n%s\n", rules)
 	}
 
+	if unchanged, action := e.unchangedDecisions(symbol); unchanged > 0 {
+		formattedData += fmt.Sprintf("\nREPEATING: your last %d decisions were the same %s with nothing about the position changed. Repeats are not executed again.\n",
+			unchanged+1, action)
+	}
+
+	formattedData += formatRecentBlocks(e.recentBlockedDecisions(), time.Now())
+
+	marketText, promptFit := e.fitMarketData(marketData, formattedData)
+	tradeLog.MarketData = marketText + turbo
+	tradeLog.PromptFit = promptFit
+	formattedData = marketText + formattedData
+
 	// Log if reasoning mode is enabled
 	if e.traderConfig != nil && e.traderConfig.EnableReasoning {
 		log.Printf("[%s][%s] Reasoning mode enabled, expecting chain-of-thought output", e.name, symbol)
@@ -768,19 +1267,23 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 
 	// Get AI decision - use simple prompt in Simple Mode
 	var decision *ai.TradingDecision
-	var rawResponse string
+	var aiCall *ai.DecisionCall
 	var aiErr error
 
 	if e.strategy != nil && e.strategy.Config.SimpleMode {
 		log.Printf("[%s][%s] 🌿 SIMPLE MODE: Using v1.4.7-style minimal prompt", e.name, symbol)
-		decision, rawResponse, aiErr = e.aiClient.GetTradingDecisionSimple(formattedData)
+		decision, aiCall, aiErr = e.aiClient.GetTradingDecisionSimple(formattedData)
 	} else {
-		decision, rawResponse, aiErr = e.aiClient.GetTradingDecision(formattedData)
+		decision, aiCall, aiErr = e.aiClient.GetTradingDecision(formattedData)
+	}
+	tradeLog.AICall = aiCall
+	if aiCall != nil {
+		tradeLog.RawAI = aiCall.Response
 	}
-	tradeLog.RawAI = rawResponse
 
 	if aiErr != nil {
 		tradeLog.Error = fmt.Sprintf("AI decision failed: %v", aiErr)
+		tradeLog.Status = store.DecisionStatusAIFailed
 		if e.notifier != nil {
 			e.notifier.Broadcast(events.Event{
 				Type:      events.TypeError,
@@ -790,9 +1293,12 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 				Timestamp: time.Now().UnixMilli(),
 			})
 		}
-		return tradeLog
+		return tradeLog, nil
 	}
 
+	decision.ATR = marketData.ATR
+	decision.ReferencePrice = marketData.CurrentPrice
+	decision.ReferenceTime = fetched.fetchedAt
 	tradeLog.Decision = decision
 	tradeLog.Action = decision.Action
 
@@ -801,65 +1307,91 @@ func (e *Engine) analyzeAndTrade(ctx context.Context, symbol string) *TradeLog {
 	e.lastDecisions[symbol] = decision
 	e.mu.Unlock()
 
+	// The same decision against the same position was already acted on
+	unchanged, skip := e.recordDecisionRepeat(symbol, decision, positionFingerprint(pos, hasPosition))
+	tradeLog.Unchanged = unchanged
+	if skip {
+		tradeLog.Error = fmt.Sprintf("skipped: unchanged for %d cycles", unchanged)
+		tradeLog.Status = store.DecisionStatusUnchanged
+		return tradeLog, nil
+	}
+
 	// Execute trade if confidence is high enough
 	minConfidence := float64(e.getMinConfidence())
-	if decision.Confidence >= minConfidence {
-		// Multi-Timeframe Confirmation (only for new positions)
-		if !hasPosition && (decision.Action == "BUY" || decision.Action == "SELL") {
-			if e.strategy != nil && e.strategy.Config.Indicators.EnableMultiTF {
-				confirmTF := e.strategy.Config.Indicators.ConfirmationTimeframe
-				if confirmTF == "" {
-					confirmTF = "15m"
-				}
+	if decision.Confidence < minConfidence {
+		log.Printf("[%s][%s] Confidence too low (%.0f%% < %.0f%%), skipping trade",
+			e.name, symbol, decision.Confidence, minConfidence)
+		tradeLog.Status = store.DecisionStatusSkippedLowConfidence
+		return tradeLog, nil
+	}
+	return tradeLog, &pendingTrade{log: tradeLog, hasPosition: hasPosition, pos: pos}
+}
 
-				// Get higher timeframe data
-				htfData, err := e.dataProvider.GetMarketDataWithConfig(ctx, symbol, confirmTF, 50)
-				if err != nil {
-					log.Printf("[%s][%s] Failed to get %s data for MTF confirmation: %v", e.name, symbol, confirmTF, err)
-					// Continue without confirmation if we can't get data
-				} else {
-					// Check if higher timeframe agrees with trade direction
-					htfBullish := htfData.EMA9 > htfData.EMA21
-					wantLong := decision.Action == "BUY"
-
-					if (wantLong && !htfBullish) || (!wantLong && htfBullish) {
-						log.Printf("[%s][%s] ❌ BLOCKED: Multi-TF disagreement. 5m says %s but %s shows %s trend (EMA9: %.2f, EMA21: %.2f)",
-							e.name, symbol, decision.Action, confirmTF,
-							map[bool]string{true: "BULLISH", false: "BEARISH"}[htfBullish],
-							htfData.EMA9, htfData.EMA21)
-						tradeLog.Error = fmt.Sprintf("blocked: %s timeframe disagrees (%s vs %s)",
-							confirmTF,
-							map[bool]string{true: "BULLISH", false: "BEARISH"}[htfBullish],
-							decision.Action)
-						return tradeLog
-					}
-					log.Printf("[%s][%s] ✅ Multi-TF confirmed: Both 5m and %s agree on %s",
-						e.name, symbol, confirmTF, decision.Action)
-				}
+// executePending runs a trade analyzeSymbol passed on, recording the outcome
+// in its trade log
+func (e *Engine) executePending(ctx context.Context, p *pendingTrade) {
+	tradeLog, symbol, decision := p.log, p.log.Symbol, p.log.Decision
+
+	// Multi-Timeframe Confirmation (only for new positions)
+	if !p.hasPosition && (decision.Action == "BUY" || decision.Action == "SELL") {
+		if e.strategy != nil && e.strategy.Config.Indicators.EnableMultiTF {
+			confirmTF := e.strategy.Config.Indicators.ConfirmationTimeframe
+			if confirmTF == "" {
+				confirmTF = "15m"
 			}
-		}
 
-		realizedPnL, err := e.executeTrade(ctx, symbol, decision, hasPosition, pos)
-		if err != nil {
-			tradeLog.Error = fmt.Sprintf("trade execution failed: %v", err)
-			if e.notifier != nil {
-				e.notifier.Broadcast(events.Event{
-					Type:      events.TypeError,
-					TraderID:  e.id,
-					Symbol:    symbol,
-					Message:   tradeLog.Error,
-					Timestamp: time.Now().UnixMilli(),
-				})
+			// Get higher timeframe data
+			htfData, err := e.dataProvider.GetMarketDataWithConfig(ctx, symbol, confirmTF, 50)
+			if err != nil {
+				log.Printf("[%s][%s] Failed to get %s data for MTF confirmation: %v", e.name, symbol, confirmTF, err)
+				// Continue without confirmation if we can't get data
+			} else {
+				// Check if higher timeframe agrees with trade direction
+				htfBullish := htfData.EMA9 > htfData.EMA21
+				wantLong := decision.Action == "BUY"
+
+				if (wantLong && !htfBullish) || (!wantLong && htfBullish) {
+					log.Printf("[%s][%s] ❌ BLOCKED: Multi-TF disagreement. 5m says %s but %s shows %s trend (EMA9: %.2f, EMA21: %.2f)",
+						e.name, symbol, decision.Action, confirmTF,
+						map[bool]string{true: "BULLISH", false: "BEARISH"}[htfBullish],
+						htfData.EMA9, htfData.EMA21)
+					tradeLog.Error = fmt.Sprintf("blocked: %s timeframe disagrees (%s vs %s)",
+						confirmTF,
+						map[bool]string{true: "BULLISH", false: "BEARISH"}[htfBullish],
+						decision.Action)
+					tradeLog.BlockReason = strings.TrimPrefix(tradeLog.Error, "blocked: ")
+					tradeLog.Status = store.DecisionStatus(store.DecisionStatusBlockedByRisk, tradeLog.BlockReason)
+					return
+				}
+				log.Printf("[%s][%s] ✅ Multi-TF confirmed: Both 5m and %s agree on %s",
+					e.name, symbol, confirmTF, decision.Action)
 			}
-		} else if realizedPnL != 0 {
-			tradeLog.RealizedPnL = realizedPnL
 		}
-	} else {
-		log.Printf("[%s][%s] Confidence too low (%.0f%% < %.0f%%), skipping trade",
-			e.name, symbol, decision.Confidence, minConfidence)
 	}
 
-	return tradeLog
+	realizedPnL, err := e.executeTrade(ctx, symbol, decision, p.hasPosition, p.pos)
+	tradeLog.Latency = e.decisionLatency(tradeLog, p.hasPosition, p.pos)
+	tradeLog.Status = executionStatus(decision.Action, err)
+	if err != nil && (strings.HasPrefix(err.Error(), "skipped:") || strings.HasPrefix(err.Error(), "blocked:")) {
+		// A risk filter passed on the trade, e.g. adverse funding, or
+		// the noise zone kept a position open
+		tradeLog.Error = err.Error()
+		reason := strings.TrimPrefix(strings.TrimPrefix(err.Error(), "skipped:"), "blocked:")
+		tradeLog.BlockReason = strings.TrimSpace(reason)
+	} else if err != nil {
+		tradeLog.Error = fmt.Sprintf("trade execution failed: %v", err)
+		if e.notifier != nil {
+			e.notifier.Broadcast(events.Event{
+				Type:      events.TypeError,
+				TraderID:  e.id,
+				Symbol:    symbol,
+				Message:   tradeLog.Error,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+	} else if realizedPnL != 0 {
+		tradeLog.RealizedPnL = realizedPnL
+	}
 }
 
 // executeTrade executes the trade and returns realized PnL (if closing) and error
@@ -869,6 +1401,66 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		return 0, fmt.Errorf("invalid symbol '%s' - cannot execute trade on ALL/empty symbol", symbol)
 	}
 
+	// A cycle still in flight when the engine stops places nothing more
+	if !e.IsRunning() {
+		return 0, fmt.Errorf("skipped: trader is stopped")
+	}
+
+	// Outside the trading schedule only exits are allowed
+	if isEntryAction(decision.Action) {
+		if paused, _, next := e.scheduleStatus(time.Now()); paused {
+			log.Printf("[%s][%s] Outside trading schedule, skipping %s", e.name, symbol, decision.Action)
+			return 0, fmt.Errorf("skipped: outside trading schedule (next active: %s)", formatNextActive(next))
+		}
+	}
+
+	// A tripped circuit breaker only allows exits until acknowledged
+	if isEntryAction(decision.Action) {
+		if trip := e.circuitBreakerTrip(); trip != nil {
+			log.Printf("[%s][%s] Circuit breaker tripped, skipping %s", e.name, symbol, decision.Action)
+			return 0, fmt.Errorf("skipped: circuit breaker tripped (%s), acknowledge it to open positions", trip.Message)
+		}
+	}
+
+	// A held symbol the coin universe dropped is only managed until it closes
+	if isEntryAction(decision.Action) && e.positionOnly(symbol, currentPos) {
+		log.Printf("[%s][%s] Position-only symbol, skipping %s", e.name, symbol, decision.Action)
+		return 0, fmt.Errorf("skipped: %s is position-only, no new entries", symbol)
+	}
+
+	// One order at a time per symbol, shared with the risk check loop
+	if !e.claimSymbol(symbol) {
+		log.Printf("[%s][%s] Another order is in flight, skipping %s", e.name, symbol, decision.Action)
+		return 0, fmt.Errorf("skipped: %s has an order in flight", symbol)
+	}
+	defer e.releaseSymbol(symbol)
+
+	// Another trader on the same account holds the symbol
+	if owner, ok := e.heldElsewhere(symbol); ok {
+		log.Printf("[%s][%s] Held by trader %s on the same account, skipping %s", e.name, symbol, owner.name, decision.Action)
+		return 0, fmt.Errorf("skipped: %s is held by trader %s on the same account", symbol, owner.name)
+	}
+	if isEntryAction(decision.Action) {
+		if owner, ok := e.claimOwnership(symbol); !ok {
+			log.Printf("[%s][%s] Held by trader %s on the same account, skipping %s", e.name, symbol, owner.name, decision.Action)
+			return 0, fmt.Errorf("skipped: %s is held by trader %s on the same account", symbol, owner.name)
+		}
+		defer e.releaseOwnershipIfFlat(symbol)
+	}
+
+	// The decision saw the position as of the cycle start; an exchange-side
+	// SL/TP may have closed it since
+	if placesOrder(decision.Action) {
+		if err := e.checkPositionUnchanged(ctx, symbol, decision.Action, hasPosition, currentPos); err != nil {
+			return 0, err
+		}
+	}
+
+	// Stop adjustments only replace the position's exchange-side orders
+	if decision.Action == "MOVE_STOP" || decision.Action == "MOVE_TP" {
+		return 0, e.moveStop(ctx, symbol, decision, hasPosition)
+	}
+
 	// Get account info for position sizing
 	account, err := e.binance.GetAccountInfo(ctx)
 	if err != nil {
@@ -881,11 +1473,45 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		return 0, fmt.Errorf("failed to get price: %w", err)
 	}
 
+	// Slow AI calls leave entries acting on old prices
+	if err := e.checkDecisionAge(symbol, decision, ticker.Price); err != nil {
+		return 0, err
+	}
+
+	// The market may have moved against an entry while the AI decided
+	if isEntryAction(decision.Action) {
+		if err := e.recheckEntry(symbol, decision, ticker.Price); err != nil {
+			return 0, err
+		}
+	}
+
+	// Entries on the side paying heavy funding need a higher confidence
+	if isEntryAction(decision.Action) {
+		if err := e.checkFunding(ctx, symbol, isLongEntry(decision.Action), decision.Confidence); err != nil {
+			log.Printf("[%s][%s] %v", e.name, symbol, err)
+			return 0, err
+		}
+	}
+
+	// Opening on the side already held scales into it
+	if hasPosition && isScaleIn(decision.Action, currentPos) {
+		return e.executeAdd(ctx, symbol, decision, currentPos, ticker.Price, account)
+	}
+	if decision.Action == "add_to_long" || decision.Action == "add_to_short" {
+		log.Printf("[%s][%s] No matching position to add to", e.name, symbol)
+		return 0, fmt.Errorf("skipped: no matching position to add to")
+	}
+
 	// For open actions, apply all risk controls
 	isOpenAction := decision.Action == "BUY" || decision.Action == "SELL" ||
 		decision.Action == "open_long" || decision.Action == "open_short"
 
 	if isOpenAction && !hasPosition {
+		// 0. Don't reopen a symbol that was just closed
+		if mins := e.checkCooldown(symbol); mins > 0 {
+			return 0, fmt.Errorf("skipped: %s for %d more minutes", cooldownSkip, mins)
+		}
+
 		// 1. Check max positions
 		if err := e.enforceMaxPositions(); err != nil {
 			log.Printf("[%s][%s] %v, skipping new position", e.name, symbol, err)
@@ -912,6 +1538,20 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 
 	leverage := e.getLeverageLimit(symbol)
 
+	// Entries run at the decision's leverage, capped by the symbol's class limit.
+	// An open position can keep the exchange on another leverage.
+	targetLeverage := 0
+	if isOpenAction && !hasPosition {
+		targetLeverage = e.targetLeverage(symbol, decision.Leverage)
+		effective, err := e.ensureLeverage(ctx, symbol, targetLeverage)
+		if err != nil {
+			log.Printf("[%s][%s] %v, skipping trade", e.name, symbol, err)
+			return 0, fmt.Errorf("skipped: %w", err)
+		}
+		leverage = effective
+		decision.Leverage = effective
+	}
+
 	// Get position percentage from strategy (fallback to legacy field, then config, then default 10%)
 	maxPosPct := e.getPositionPercent()
 	if e.strategy != nil && e.strategy.Config.RiskControl.MaxPositionPercent > 0 {
@@ -931,6 +1571,25 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 	// CRITICAL: Use `account` (fresh) not `e.account` (cached) to prevent over-leveraging
 	positionSizeUSD := (account.TotalMarginBalance * maxPosPct) / 100
 
+	// Externally sized decisions (debate consensus) bring their own margin; the caps below still apply
+	if decision.PositionSizeUSD > 0 {
+		positionSizeUSD = decision.PositionSizeUSD
+	}
+
+	// Stuck on another leverage: resize the margin so the notional is what the target would have opened
+	if targetLeverage > 0 && leverage != targetLeverage {
+		positionSizeUSD = positionSizeUSD * float64(targetLeverage) / float64(leverage)
+		log.Printf("[%s][%s] Margin adjusted to $%.2f for %dx instead of %dx", e.name, symbol, positionSizeUSD, leverage, targetLeverage)
+	}
+
+	// ATR risk sizing replaces the fixed percent for the engine's own entries. It
+	// sizes the notional, so the leverage adjustment above doesn't apply.
+	if decision.PositionSizeUSD <= 0 && isOpenAction && !hasPosition {
+		if margin, ok := e.atrRiskMargin(symbol, decision, equity, ticker.Price, leverage); ok {
+			positionSizeUSD = margin
+		}
+	}
+
 	// Apply margin safety check (COPIED FROM NOFX)
 	// ⚠️ Auto-adjust position size if insufficient margin
 	// Formula: totalRequired = positionSize/leverage + positionSize*0.001 + positionSize/leverage*0.01
@@ -982,6 +1641,7 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 	// With leverage, the actual position value = margin × leverage
 	actualPositionValue := positionSizeUSD * float64(leverage)
 	quantity := actualPositionValue / ticker.Price
+	decision.RiskUSD = e.atrStopRisk(decision, quantity)
 
 	log.Printf("[%s][%s] Position calculation: margin=$%.2f × %dx leverage = $%.2f position value → %.8f %s",
 		e.name, symbol, positionSizeUSD, leverage, actualPositionValue, quantity, symbol)
@@ -1011,6 +1671,25 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 			quantity, minQuantity, symbol, positionSizeUSD)
 	}
 
+	// Don't stack one correlated bet across several symbols
+	if isOpenAction && !hasPosition {
+		side := "long"
+		if decision.Action == "SELL" || decision.Action == "open_short" {
+			side = "short"
+		}
+		if err := e.checkCorrelatedExposure(ctx, symbol, side, actualPositionValue, equity); err != nil {
+			return 0, fmt.Errorf("skipped: %w", err)
+		}
+		if err := e.checkExposureLimits(symbol, side, actualPositionValue, equity); err != nil {
+			return 0, fmt.Errorf("skipped: %w", err)
+		}
+		// Illiquid books fill far from the price the decision saw
+		if err := e.checkExpectedSlippage(ctx, symbol, side, actualPositionValue); err != nil {
+			log.Printf("[%s][%s] %v, skipping trade", e.name, symbol, err)
+			return 0, fmt.Errorf("skipped: %w", err)
+		}
+	}
+
 	// CRITICAL: Before opening any new position, cancel any orphaned SL/TP orders for this symbol
 	// This prevents the "-4130: An open stop or take profit order...is existing" error
 	if !hasPosition && (decision.Action == "BUY" || decision.Action == "SELL" || decision.Action == "open_long" || decision.Action == "open_short") {
@@ -1019,10 +1698,6 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 
 	switch decision.Action {
 	case "BUY", "open_long":
-		if hasPosition && currentPos.PositionAmt > 0 {
-			log.Printf("[%s][%s] Already in LONG position, skipping BUY", e.name, symbol)
-			return 0, fmt.Errorf("skipped: already in LONG position")
-		}
 		// Require explicit close of opposite position (matching NOFX behavior)
 		if hasPosition && currentPos.PositionAmt < 0 {
 			log.Printf("[%s][%s] Already has SHORT position, close it first before opening LONG", e.name, symbol)
@@ -1030,43 +1705,17 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		}
 		log.Printf("[%s][%s] Opening LONG: %.4f @ $%.2f (margin: $%.2f, position: $%.2f, leverage: %dx)",
 			e.name, symbol, quantity, ticker.Price, positionSizeUSD, actualPositionValue, leverage)
-		openOrder, err := e.binance.PlaceOrder(ctx, symbol, "BUY", "MARKET", quantity, 0, false)
+		// Uses actual fill data, never the pre-trade ticker
+		fill, err := e.placeEntry(ctx, symbol, "BUY", quantity, ticker.Price, decision)
 		if err != nil {
+			log.Printf("[%s][%s] ❌ LONG order not filled: %v", e.name, symbol, err)
 			return 0, fmt.Errorf("failed to open long: %w", err)
 		}
+		entryPrice, filledQty := fill.Price, fill.Qty
+		log.Printf("[%s][%s] LONG filled: price=$%.4f, qty=%.4f (slippage %.2f%%)", e.name, symbol, entryPrice, filledQty, fill.SlippagePct)
+		e.checkSlippage(symbol, ticker.Price, fill)
 		e.setPositionFirstSeen(symbol, "LONG")
-
-		// Use actual fill data from order response
-		entryPrice := ticker.Price
-		filledQty := quantity
-		if openOrder != nil {
-			// Verify order was filled
-			if openOrder.Status != "FILLED" {
-				log.Printf("[%s][%s] ⚠️ LONG order status is %s (not FILLED), verifying position...",
-					e.name, symbol, openOrder.Status)
-				// Query actual position from Binance to get real fill data
-				if positions, err := e.binance.GetPositions(ctx); err == nil {
-					for _, pos := range positions {
-						if pos.Symbol == symbol && pos.PositionAmt > 0 {
-							filledQty = pos.PositionAmt
-							entryPrice = pos.EntryPrice
-							log.Printf("[%s][%s] Position verified from exchange: qty=%.4f, entry=$%.4f",
-								e.name, symbol, filledQty, entryPrice)
-							break
-						}
-					}
-				}
-			} else if openOrder.AvgPrice > 0 {
-				entryPrice = openOrder.AvgPrice
-				if openOrder.ExecutedQty > 0 {
-					filledQty = openOrder.ExecutedQty
-				}
-				log.Printf("[%s][%s] LONG filled: price=$%.4f, qty=%.4f", e.name, symbol, entryPrice, filledQty)
-			}
-		} else {
-			log.Printf("[%s][%s] ⚠️ No order response, using expected values: price=$%.4f, qty=%.4f",
-				e.name, symbol, entryPrice, filledQty)
-		}
+		e.awaitPosition(symbol)
 
 		// Update positions map with actual fill data
 		e.mu.Lock()
@@ -1079,6 +1728,8 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		}
 		e.mu.Unlock()
 
+		e.recordOpen(symbol, true, fill, leverage, decision)
+
 		// Place bracket orders (SL/TP) on exchange using actual entry price
 		// If trailing stop is enabled, only place SL - let TSL handle profits
 		slPct, tpPct := e.getSLTPPercentages(decision)
@@ -1093,10 +1744,6 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		}
 
 	case "SELL", "open_short":
-		if hasPosition && currentPos.PositionAmt < 0 {
-			log.Printf("[%s][%s] Already in SHORT position, skipping SELL", e.name, symbol)
-			return 0, fmt.Errorf("skipped: already in SHORT position")
-		}
 		// Require explicit close of opposite position (matching NOFX behavior)
 		if hasPosition && currentPos.PositionAmt > 0 {
 			log.Printf("[%s][%s] Already has LONG position, close it first before opening SHORT", e.name, symbol)
@@ -1104,43 +1751,17 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		}
 		log.Printf("[%s][%s] Opening SHORT: %.4f @ $%.2f (margin: $%.2f, position: $%.2f, leverage: %dx)",
 			e.name, symbol, quantity, ticker.Price, positionSizeUSD, actualPositionValue, leverage)
-		openOrder, err := e.binance.PlaceOrder(ctx, symbol, "SELL", "MARKET", quantity, 0, false)
+		// Uses actual fill data, never the pre-trade ticker
+		fill, err := e.placeEntry(ctx, symbol, "SELL", quantity, ticker.Price, decision)
 		if err != nil {
+			log.Printf("[%s][%s] ❌ SHORT order not filled: %v", e.name, symbol, err)
 			return 0, fmt.Errorf("failed to open short: %w", err)
 		}
+		entryPrice, filledQty := fill.Price, fill.Qty
+		log.Printf("[%s][%s] SHORT filled: price=$%.4f, qty=%.4f (slippage %.2f%%)", e.name, symbol, entryPrice, filledQty, fill.SlippagePct)
+		e.checkSlippage(symbol, ticker.Price, fill)
 		e.setPositionFirstSeen(symbol, "SHORT")
-
-		// Use actual fill data from order response
-		entryPrice := ticker.Price
-		filledQty := quantity
-		if openOrder != nil {
-			// Verify order was filled
-			if openOrder.Status != "FILLED" {
-				log.Printf("[%s][%s] ⚠️ SHORT order status is %s (not FILLED), verifying position...",
-					e.name, symbol, openOrder.Status)
-				// Query actual position from Binance to get real fill data
-				if positions, err := e.binance.GetPositions(ctx); err == nil {
-					for _, pos := range positions {
-						if pos.Symbol == symbol && pos.PositionAmt < 0 {
-							filledQty = -pos.PositionAmt // Convert to positive
-							entryPrice = pos.EntryPrice
-							log.Printf("[%s][%s] Position verified from exchange: qty=%.4f, entry=$%.4f",
-								e.name, symbol, filledQty, entryPrice)
-							break
-						}
-					}
-				}
-			} else if openOrder.AvgPrice > 0 {
-				entryPrice = openOrder.AvgPrice
-				if openOrder.ExecutedQty > 0 {
-					filledQty = openOrder.ExecutedQty
-				}
-				log.Printf("[%s][%s] SHORT filled: price=$%.4f, qty=%.4f", e.name, symbol, entryPrice, filledQty)
-			}
-		} else {
-			log.Printf("[%s][%s] ⚠️ No order response, using expected values: price=$%.4f, qty=%.4f",
-				e.name, symbol, entryPrice, filledQty)
-		}
+		e.awaitPosition(symbol)
 
 		// Update positions map with actual fill data
 		e.mu.Lock()
@@ -1153,6 +1774,8 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 		}
 		e.mu.Unlock()
 
+		e.recordOpen(symbol, false, fill, leverage, decision)
+
 		// Place bracket orders (SL/TP) on exchange using actual entry price
 		// If trailing stop is enabled, only place SL - let TSL handle profits
 		slPct, tpPct := e.getSLTPPercentages(decision)
@@ -1287,34 +1910,47 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 			}
 		}
 
+		// Partial closes keep the remainder and its protection in place
+		if pct := decision.ClosePercent; pct > 0 && pct < 100 {
+			if !partialLeavesDust(symbol, currentPos, pct) {
+				return e.executePartialClose(ctx, symbol, decision, currentPos, pct)
+			}
+			log.Printf("[%s][%s] Closing %.0f%% would leave dust, closing the whole position", e.name, symbol, pct)
+		}
+
 		// Estimate P&L before closing (for logging)
 		estimatedPnL := currentPos.UnrealizedProfit
 
 		log.Printf("[%s][%s] Closing %s position: %.4f (held for %v, estimated profit: $%.2f = %.2f%% ROE)",
 			e.name, symbol, side, currentPos.PositionAmt, holdDuration, estimatedPnL, roePnlPct)
 		closeOrder, err := e.binance.ClosePosition(ctx, symbol, currentPos.PositionAmt)
+		if isReduceOnlyRejected(err) {
+			// Nothing left to reduce: an exchange-side SL/TP got there first
+			e.resyncFlatPosition(ctx, symbol, side)
+			return 0, fmt.Errorf("skipped: %s position already closed on the exchange", side)
+		}
 		if err != nil {
 			return 0, fmt.Errorf("failed to close position: %w", err)
 		}
 		e.clearPositionTracking(symbol, side)
 		e.cancelBracketOrders(ctx, symbol)
-
-		// Calculate actual realized P&L from fill price
-		realizedPnL := estimatedPnL // Default to estimated if we can't calculate
-		if closeOrder != nil && closeOrder.AvgPrice > 0 && closeOrder.ExecutedQty > 0 {
-			// For LONG: P&L = (ExitPrice - EntryPrice) * Quantity
-			// For SHORT: P&L = (EntryPrice - ExitPrice) * Quantity
-			if currentPos.PositionAmt > 0 { // Long position
-				realizedPnL = (closeOrder.AvgPrice - currentPos.EntryPrice) * closeOrder.ExecutedQty
-			} else { // Short position
-				realizedPnL = (currentPos.EntryPrice - closeOrder.AvgPrice) * closeOrder.ExecutedQty
-			}
-			log.Printf("[%s][%s] Actual realized P&L: $%.2f (fill price: %.4f, entry: %.4f, qty: %.4f)",
-				e.name, symbol, realizedPnL, closeOrder.AvgPrice, currentPos.EntryPrice, closeOrder.ExecutedQty)
+		e.recordClose(symbol, false)
+		// The cycle's opens, run after its closes, see the exposure freed
+		e.mu.Lock()
+		delete(e.positions, symbol)
+		e.mu.Unlock()
+		if closeOrder != nil {
+			decision.OrderID = closeOrder.OrderID
 		}
 
+		// Realized P&L from the exchange fills, falling back to the fill price estimate
+		fill := e.settleClose(ctx, currentPos, closeOrder, closeReasonAI)
+		decision.PositionID = fill.PositionID
+		log.Printf("[%s][%s] Actual realized P&L: $%.2f (fill price: %.4f, entry: %.4f, qty: %.4f, fee: %.4f)",
+			e.name, symbol, fill.PnL, fill.Price, currentPos.EntryPrice, fill.Qty, fill.Fee)
+
 		// Return the realized PnL
-		return realizedPnL, nil
+		return fill.PnL, nil
 
 	case "HOLD", "hold", "wait":
 		log.Printf("[%s][%s] Holding - no action taken", e.name, symbol)
@@ -1326,6 +1962,30 @@ func (e *Engine) executeTrade(ctx context.Context, symbol string, decision *ai.T
 	return 0, nil
 }
 
+// announceFallback tells the operator, once per run, that the primary model
+// failed and decisions are coming from a fallback model
+func (e *Engine) announceFallback(symbol string, call *ai.DecisionCall) {
+	e.mu.Lock()
+	announced := e.fallbackAnnounced
+	e.fallbackAnnounced = true
+	e.mu.Unlock()
+	if announced {
+		return
+	}
+
+	msg := fmt.Sprintf("Primary model %s failed (%s), decided with fallback %s", call.FallbackFrom, call.FallbackReason, call.Model)
+	log.Printf("[%s][%s] ⚠️ %s", e.name, symbol, msg)
+	if e.notifier != nil {
+		e.notifier.Broadcast(events.Event{
+			Type:      events.TypeError,
+			TraderID:  e.id,
+			Symbol:    symbol,
+			Message:   msg,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
 // GetStatus returns current engine status
 func (e *Engine) GetStatus() map[string]interface{} {
 	e.mu.RLock()
@@ -1357,14 +2017,34 @@ func (e *Engine) GetStatus() map[string]interface{} {
 		strategyName = e.strategy.Name
 	}
 
+	var modelStats map[string]mcp.ModelStat // Decision calls and failures per model
+	if e.aiClient != nil {
+		modelStats = e.aiClient.ModelStats()
+	}
+
+	pausedBySchedule, _, nextActive := e.scheduleStatus(time.Now())
+	var nextActiveAt interface{}
+	if pausedBySchedule && !nextActive.IsZero() {
+		nextActiveAt = nextActive
+	}
+
 	return map[string]interface{}{
-		"trader_id":   e.id,
-		"trader_name": e.name,
-		"running":     e.running,
-		"strategy":    strategyName,
-		"pairs":       e.getTradingPairs(),
-		"positions":   positions,
-		"decisions":   decisions,
+		"trader_id":       e.id,
+		"trader_name":     e.name,
+		"running":         e.running,
+		"strategy":        strategyName,
+		"pairs":           e.getTradingPairs(),
+		"positions":       positions,
+		"decisions":       decisions,
+		"position_sync":   e.positionSync,
+		"stale_decisions": e.staleDecisions,
+		"prompt_budget":   e.promptBudget,
+		"model_stats":     modelStats,
+
+		"last_risk_check_at": e.lastRiskCheckAt,
+		"paused_by_schedule": pausedBySchedule,
+		"next_active_at":     nextActiveAt,
+		"trading_day_start":  e.tradingDay().Start(time.Now()),
 	}
 }
 
@@ -1378,10 +2058,13 @@ func (e *Engine) GetAccount() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
-		"total_equity":   e.account.TotalMarginBalance,
-		"wallet_balance": e.account.TotalWalletBalance,
-		"available":      e.account.AvailableBalance,
-		"unrealized_pnl": e.account.TotalUnrealizedProfit,
+		"total_equity":       e.account.TotalMarginBalance,
+		"wallet_balance":     e.account.TotalWalletBalance,
+		"available":          e.account.AvailableBalance,
+		"unrealized_pnl":     e.account.TotalUnrealizedProfit,
+		"realized_pnl_today": e.realized.RealizedPnLToday,
+		"realized_pnl_total": e.realized.RealizedPnLTotal,
+		"fees_total":         e.realized.FeesTotal,
 	}
 }
 
@@ -1392,30 +2075,35 @@ func (e *Engine) GetPositions() []map[string]interface{} {
 
 	positions := make([]map[string]interface{}, 0)
 	for _, pos := range e.positions {
-		pnlPct := 0.0
-		if pos.EntryPrice > 0 {
-			if pos.PositionAmt > 0 {
-				pnlPct = ((pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice) * 100
-			} else {
-				pnlPct = ((pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice) * 100
-			}
-		}
-
-		positions = append(positions, map[string]interface{}{
-			"symbol":      pos.Symbol,
-			"side":        map[bool]string{true: "LONG", false: "SHORT"}[pos.PositionAmt > 0],
-			"amount":      pos.PositionAmt,
-			"entry_price": pos.EntryPrice,
-			"mark_price":  pos.MarkPrice,
-			"pnl":         pos.UnrealizedProfit,
-			"pnl_percent": pnlPct,
-			"leverage":    pos.Leverage,
-		})
+		positions = append(positions, positionView(pos))
 	}
 
 	return positions
 }
 
+// positionView is a position as the API shows it
+func positionView(pos *exchange.Position) map[string]interface{} {
+	pnlPct := 0.0
+	if pos.EntryPrice > 0 {
+		if pos.PositionAmt > 0 {
+			pnlPct = ((pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice) * 100
+		} else {
+			pnlPct = ((pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice) * 100
+		}
+	}
+
+	return map[string]interface{}{
+		"symbol":      pos.Symbol,
+		"side":        map[bool]string{true: "LONG", false: "SHORT"}[pos.PositionAmt > 0],
+		"amount":      pos.PositionAmt,
+		"entry_price": pos.EntryPrice,
+		"mark_price":  pos.MarkPrice,
+		"pnl":         pos.UnrealizedProfit,
+		"pnl_percent": pnlPct,
+		"leverage":    pos.Leverage,
+	}
+}
+
 // =============================================================================
 // Decision Context Building
 // =============================================================================
@@ -1432,6 +2120,9 @@ func (e *Engine) buildDecisionContext(ctx context.Context) *decision.Context {
 			TotalEquity:      e.account.TotalMarginBalance,
 			AvailableBalance: e.account.AvailableBalance,
 			UnrealizedPnL:    e.account.TotalUnrealizedProfit,
+			RealizedPnLToday: e.realized.RealizedPnLToday,
+			RealizedPnLTotal: e.realized.RealizedPnLTotal,
+			FeesTotal:        e.realized.FeesTotal,
 			TotalPnL:         e.account.TotalUnrealizedProfit,
 			PositionCount:    len(e.positions),
 		}
@@ -1441,6 +2132,7 @@ func (e *Engine) buildDecisionContext(ctx context.Context) *decision.Context {
 	}
 
 	// Build position info
+	entryQtys := e.entryQuantities()
 	positions := make([]decision.PositionInfo, 0)
 	for _, pos := range e.positions {
 		if pos.PositionAmt == 0 {
@@ -1461,25 +2153,34 @@ func (e *Engine) buildDecisionContext(ctx context.Context) *decision.Context {
 			}
 		}
 
+		// Includes stops moved or cancelled through the API
+		e.bracketOrdersMutex.RLock()
+		stopLoss, takeProfit := e.bracketOrders[pos.Symbol].levels(pos.PositionAmt > 0)
+		e.bracketOrdersMutex.RUnlock()
+
 		positions = append(positions, decision.PositionInfo{
 			Symbol:           pos.Symbol,
 			Side:             side,
 			EntryPrice:       pos.EntryPrice,
 			MarkPrice:        pos.MarkPrice,
 			Quantity:         pos.PositionAmt,
+			EntryQuantity:    entryQtys[getPositionKey(pos.Symbol, side)],
 			Leverage:         pos.Leverage,
 			UnrealizedPnL:    pos.UnrealizedProfit,
 			UnrealizedPnLPct: pnlPct,
 			PeakPnLPct:       e.GetPeakPnL(pos.Symbol, side),
+			StopLoss:         stopLoss,
+			TakeProfit:       takeProfit,
+			LiquidationPrice: e.liquidationPrice(pos),
 		})
 	}
 
 	// Build candidate coins
 	candidateCoins := make([]decision.CandidateCoin, 0)
-	for _, symbol := range e.getTradingPairs() {
+	for _, coin := range e.CoinUniverse() {
 		candidateCoins = append(candidateCoins, decision.CandidateCoin{
-			Symbol:  symbol,
-			Sources: []string{"strategy"},
+			Symbol:  coin.Symbol,
+			Sources: []string{coin.Origin},
 		})
 	}
 
@@ -1517,6 +2218,9 @@ func (e *Engine) buildDecisionContext(ctx context.Context) *decision.Context {
 		}
 	}
 
+	maxCorrelatedPct, _ := e.correlationLimits()
+	clusters, correlationBlocks := e.exposureContextLocked(accountInfo.TotalEquity)
+
 	return &decision.Context{
 		CurrentTime:         time.Now().Format(time.RFC3339),
 		RuntimeMinutes:      int(time.Since(e.startTime).Minutes()),
@@ -1530,6 +2234,12 @@ func (e *Engine) buildDecisionContext(ctx context.Context) *decision.Context {
 		AltcoinPosRatio:     altcoinPosRatio,
 		NoiseZoneLowerBound: noiseZoneLower,
 		NoiseZoneUpperBound: noiseZoneUpper,
+		Cooldowns:           e.activeCooldownsLocked(),
+
+		Exposure:                 clusters,
+		MaxCorrelatedExposurePct: maxCorrelatedPct,
+		CorrelationBlocks:        correlationBlocks,
+		ExposureBudget:           e.exposureBudgetLocked(accountInfo.TotalEquity),
 	}
 }
 
@@ -1544,23 +2254,52 @@ func decisionToTradingDecision(d *decision.Decision) *ai.TradingDecision {
 		action = "SELL"
 	case decision.ActionCloseLong, decision.ActionCloseShort:
 		action = "CLOSE"
+	case decision.ActionMoveStop:
+		action = "MOVE_STOP"
+	case decision.ActionMoveTP:
+		action = "MOVE_TP"
 	case decision.ActionHold, decision.ActionWait:
 		action = "HOLD"
 	}
 
 	return &ai.TradingDecision{
-		Action:     action,
-		Symbol:     d.Symbol,
-		Confidence: float64(d.Confidence),
-		Reasoning:  d.Reasoning,
-		StopLoss:   d.StopLoss,
-		TakeProfit: d.TakeProfit,
+		Action:       action,
+		Symbol:       d.Symbol,
+		Confidence:   float64(d.Confidence),
+		Reasoning:    d.Reasoning,
+		StopLoss:     d.StopLoss,
+		TakeProfit:   d.TakeProfit,
+		ClosePercent: d.ClosePercent,
+		Leverage:     d.Leverage,
+	}
+}
+
+// newValidationConfig builds decision validation limits from a strategy's risk control
+func newValidationConfig(strategy *store.Strategy, equity float64) *decision.ValidationConfig {
+	if strategy == nil {
+		cfg := decision.DefaultValidationConfig()
+		cfg.AccountEquity = equity
+		return cfg
+	}
+	return &decision.ValidationConfig{
+		AccountEquity:      equity,
+		BTCETHLeverage:     strategy.Config.RiskControl.BTCETHMaxLeverage,
+		AltcoinLeverage:    strategy.Config.RiskControl.AltcoinMaxLeverage,
+		BTCETHPosRatio:     strategy.Config.RiskControl.BTCETHMaxPositionValueRatio,
+		AltcoinPosRatio:    strategy.Config.RiskControl.AltcoinMaxPositionValueRatio,
+		MinPositionBTCETH:  strategy.Config.RiskControl.MinPositionSizeBTCETH,
+		MinPositionAlt:     strategy.Config.RiskControl.MinPositionSize,
+		MinRiskReward:      strategy.Config.RiskControl.MinRiskRewardRatio,
+		MinStopDistancePct: decision.DefaultValidationConfig().MinStopDistancePct,
+		MaxMarginUsagePct:  strategy.Config.RiskControl.MaxMarginUsage,
+		ExposureLimits:     decision.ExposureLimits(strategy.Config.RiskControl.ExposureLimits),
 	}
 }
 
 // makeDecisionWithEngine uses the decision engine to make trading decisions
 func (e *Engine) makeDecisionWithEngine(ctx context.Context) (*decision.FullDecision, error) {
 	// Build context for decision making
+	e.refreshRealized()
 	decisionCtx := e.buildDecisionContext(ctx)
 
 	// Increment call count
@@ -1602,6 +2341,7 @@ func (e *Engine) GetDecisionEngineStatus() map[string]interface{} {
 		"call_count":      e.callCount,
 		"runtime_minutes": int(time.Since(e.startTime).Minutes()),
 		"has_mcp_client":  e.mcpClient != nil,
+		"parse_stats":     e.decisionEngine.ParseStats(),
 	}
 
 	if e.lastFullDecision != nil {
@@ -1609,6 +2349,8 @@ func (e *Engine) GetDecisionEngineStatus() map[string]interface{} {
 		status["last_ai_duration_ms"] = e.lastFullDecision.AIRequestDurationMs
 		status["last_cot_length"] = len(e.lastFullDecision.CoTTrace)
 		status["last_decision_count"] = len(e.lastFullDecision.Decisions)
+		status["last_parse_path"] = e.lastFullDecision.ParsePath
+		status["last_parse_retries"] = e.lastFullDecision.Retries
 	}
 
 	return status
@@ -1980,9 +2722,11 @@ func (e *Engine) clearPositionTracking(symbol, side string) {
 
 	e.mu.Lock()
 	delete(e.positionFirstSeenTime, key)
+	delete(e.unconfirmed, symbol)
 	e.mu.Unlock()
 
 	e.ClearPeakPnL(symbol, side)
+	e.releaseOwnership(symbol)
 }
 
 // =============================================================================
@@ -2152,7 +2896,9 @@ func (e *Engine) placeStopLossOnly(ctx context.Context, symbol string, isLong bo
 		}
 		for _, pos := range positions {
 			if pos.Symbol == symbol && pos.PositionAmt != 0 {
-				if _, closeErr := e.binance.ClosePosition(ctx, symbol, pos.PositionAmt); closeErr != nil {
+				if _, closeErr := e.binance.ClosePosition(ctx, symbol, pos.PositionAmt); isReduceOnlyRejected(closeErr) {
+					e.resyncFlatPosition(ctx, symbol, positionSide(pos.PositionAmt))
+				} else if closeErr != nil {
 					log.Printf("[%s][%s] ERROR: Failed to close unprotected position: %v", e.name, symbol, closeErr)
 				} else {
 					log.Printf("[%s][%s] Closed unprotected position for safety", e.name, symbol)
@@ -2235,10 +2981,8 @@ func (e *Engine) cancelBracketOrders(ctx context.Context, symbol string) {
 	// Cancel SL order (using CancelAlgoOrder since SL/TP are algo orders)
 	if bracket.StopLossOrderID > 0 {
 		if err := e.binance.CancelAlgoOrder(ctx, symbol, bracket.StopLossOrderID); err != nil {
-			errStr := err.Error()
 			// Check for "order not found" or already filled/cancelled
-			if strings.Contains(errStr, "Unknown order") || strings.Contains(errStr, "-2011") ||
-				strings.Contains(errStr, "Order does not exist") || strings.Contains(errStr, "-20123") {
+			if isUnknownOrder(err) {
 				slFilled = true
 				log.Printf("[%s][%s] 🔴 SL order was ALREADY FILLED/CANCELLED by exchange (Algo ID: %d)",
 					e.name, symbol, bracket.StopLossOrderID)
@@ -2253,10 +2997,8 @@ func (e *Engine) cancelBracketOrders(ctx context.Context, symbol string) {
 	// Cancel TP order (using CancelAlgoOrder since SL/TP are algo orders)
 	if bracket.TakeProfitOrderID > 0 {
 		if err := e.binance.CancelAlgoOrder(ctx, symbol, bracket.TakeProfitOrderID); err != nil {
-			errStr := err.Error()
 			// Check for "order not found" or already filled/cancelled
-			if strings.Contains(errStr, "Unknown order") || strings.Contains(errStr, "-2011") ||
-				strings.Contains(errStr, "Order does not exist") || strings.Contains(errStr, "-20123") {
+			if isUnknownOrder(err) {
 				tpFilled = true
 				log.Printf("[%s][%s] 🟢 TP order was ALREADY FILLED/CANCELLED by exchange (Algo ID: %d)",
 					e.name, symbol, bracket.TakeProfitOrderID)
@@ -2359,6 +3101,7 @@ func (e *Engine) triggerTradingPause(ctx context.Context) {
 	e.mu.Unlock()
 
 	log.Printf("[%s] 🛑 Trading paused until %s due to daily loss limit", e.name, e.stopUntil.Format(time.RFC3339))
+	e.recordRiskEvent(store.RiskEventDailyLossPause, fmt.Sprintf("daily loss limit reached, trading paused for %d minutes", pauseMins))
 
 	// Check if we should close all positions
 	if e.strategy.Config.RiskControl.ClosePositionsOnDailyLoss {
@@ -2367,6 +3110,20 @@ func (e *Engine) triggerTradingPause(ctx context.Context) {
 	}
 }
 
+// recordRiskEvent keeps a risk control action for the trader's reports, and
+// marks the equity curve with the ones that explain a move in it
+func (e *Engine) recordRiskEvent(eventType, message string) {
+	if kind, ok := riskEventAnnotations[eventType]; ok {
+		e.annotate(kind, message)
+	}
+	if e.riskEventStore == nil {
+		return
+	}
+	if err := e.riskEventStore.Create(&store.RiskEvent{TraderID: e.id, Type: eventType, Message: message}); err != nil {
+		log.Printf("[%s] Failed to save risk event: %v", e.name, err)
+	}
+}
+
 // closeAllPositions closes all open positions
 func (e *Engine) closeAllPositions(ctx context.Context, reason string) {
 	e.mu.RLock()
@@ -2386,65 +3143,117 @@ func (e *Engine) closeAllPositions(ctx context.Context, reason string) {
 	log.Printf("[%s] Closing %d position(s): %s", e.name, len(positions), reason)
 
 	for _, pos := range positions {
-		side := "LONG"
-		if pos.PositionAmt < 0 {
-			side = "SHORT"
-		}
+		e.forceClose(ctx, pos, reason, closeReasonDailyLoss)
+	}
+}
 
-		log.Printf("[%s][%s] Closing %s position: %.4f (reason: %s)",
-			e.name, pos.Symbol, side, pos.PositionAmt, reason)
+// forceClose market-closes pos outside the AI's control and records it like a
+// stop-out, so the symbol gets the stop-loss cooldown
+func (e *Engine) forceClose(ctx context.Context, pos *exchange.Position, reason, closeReason string) error {
+	side := "LONG"
+	if pos.PositionAmt < 0 {
+		side = "SHORT"
+	}
 
-		if _, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); err != nil {
-			log.Printf("[%s][%s] Failed to close position: %v", e.name, pos.Symbol, err)
-		} else {
-			log.Printf("[%s][%s] ✅ Position closed successfully", e.name, pos.Symbol)
-			e.clearPositionTracking(pos.Symbol, side)
-			e.cancelBracketOrders(ctx, pos.Symbol)
-		}
+	log.Printf("[%s][%s] Closing %s position: %.4f (reason: %s)",
+		e.name, pos.Symbol, side, pos.PositionAmt, reason)
+
+	order, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt)
+	if isReduceOnlyRejected(err) {
+		e.resyncFlatPosition(ctx, pos.Symbol, side)
+		return nil
 	}
+	if err != nil {
+		log.Printf("[%s][%s] Failed to close position: %v", e.name, pos.Symbol, err)
+		return err
+	}
+
+	log.Printf("[%s][%s] ✅ Position closed successfully", e.name, pos.Symbol)
+	e.clearPositionTracking(pos.Symbol, side)
+	e.cancelBracketOrders(ctx, pos.Symbol)
+	e.recordClose(pos.Symbol, true)
+	e.settleClose(ctx, pos, order, closeReason)
+	return nil
 }
 
-// resetDailyPnLIfNeeded resets daily P&L tracking at the start of a new day
-func (e *Engine) resetDailyPnLIfNeeded() {
+// resyncFlatPosition handles a reduce-only close Binance rejected because the
+// side position on symbol is already flat, closed by an exchange-side SL/TP,
+// by hand or by another close. Rather than retrying, positions are fetched
+// again, which records the close on the position's row; tracking is dropped
+// once the fetch confirms nothing is left.
+func (e *Engine) resyncFlatPosition(ctx context.Context, symbol, side string) {
+	log.Printf("[%s][%s] No %s position left on the exchange to reduce, resyncing positions", e.name, symbol, side)
+	e.mu.Lock()
+	delete(e.unconfirmed, symbol) // The exchange just said it isn't there
+	e.mu.Unlock()
+
+	if err := e.refreshPositions(ctx); err != nil {
+		log.Printf("[%s][%s] Position resync failed, dropping the %s position: %v", e.name, symbol, side, err)
+		e.mu.Lock()
+		delete(e.positions, symbol)
+		e.mu.Unlock()
+	}
+
+	e.mu.RLock()
+	pos := e.positions[symbol]
+	e.mu.RUnlock()
+	if pos != nil && pos.PositionAmt != 0 && positionSide(pos.PositionAmt) == side {
+		log.Printf("[%s][%s] %s position still open with %.4f after the rejected close", e.name, symbol, side, pos.PositionAmt)
+		return
+	}
+	e.clearPositionTracking(symbol, side)
+	e.cancelBracketOrders(ctx, symbol)
+}
+
+// tradingDay returns the strategy's trading day, UTC midnight if its settings
+// are invalid
+func (e *Engine) tradingDay() report.TradingDay {
+	day, err := report.StrategyDay(e.strategy)
+	if err != nil {
+		log.Printf("[%s] %v, using UTC days", e.name, err)
+	}
+	return day
+}
+
+// refreshRealized reloads the realized P&L and fees of the trader's closed positions
+func (e *Engine) refreshRealized() {
+	if e.positionStore == nil {
+		return
+	}
+	totals, err := e.positionStore.GetRealizedTotals(e.id, e.tradingDay().Start(time.Now()))
+	if err != nil {
+		log.Printf("[%s] Failed to get realized P&L: %v", e.name, err)
+		return
+	}
+
+	e.mu.Lock()
+	e.realized = totals
+	e.mu.Unlock()
+}
+
+// resetDailyPnLIfNeeded resets daily P&L tracking at the start of a new
+// trading day
+func (e *Engine) resetDailyPnLIfNeeded(now time.Time) {
+	dayStart := e.tradingDay().Start(now)
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Check if 24 hours have passed since last reset
-	if time.Since(e.lastResetTime) >= 24*time.Hour {
+	if e.lastResetTime.Before(dayStart) {
 		if e.account != nil {
 			e.initialBalance = e.account.TotalMarginBalance // Use TotalMarginBalance (includes unrealized P&L)
 		}
 		e.dailyPnL = 0
-		e.lastResetTime = time.Now()
+		e.lastResetTime = now
 		e.stopUntil = time.Time{} // Clear any pause
 		log.Printf("[%s] Daily P&L reset. New initial balance: $%.2f", e.name, e.initialBalance)
 	}
 }
 
 // =============================================================================
-// Drawdown Monitor Goroutine
+// Rule-based Position Protections
 // =============================================================================
 
-// startDrawdownMonitor starts background goroutine for drawdown checks
-func (e *Engine) startDrawdownMonitor(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	log.Printf("[%s] Drawdown monitor started", e.name)
-
-	for {
-		select {
-		case <-e.stopCh:
-			log.Printf("[%s] Drawdown monitor stopped", e.name)
-			return
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			e.checkPositionDrawdown(ctx)
-		}
-	}
-}
-
 // lastRiskSettingsLog tracks when we last logged risk settings (for rate limiting)
 var lastRiskSettingsLog = make(map[string]time.Time)
 var lastRiskSettingsLogMu sync.Mutex
@@ -2517,170 +3326,211 @@ func (e *Engine) checkPositionDrawdown(ctx context.Context) {
 			continue
 		}
 
-		// Calculate P&L percentages
-		// OPTION B: Split Logic
-		// 1. rawPnlPct (Price Move): Used for Smart Loss (don't cut on noise)
-		// 2. roePnlPct (Equity Move): Used for Trailing Stop & Drawdown (protect actual equity)
-		var rawPnlPct float64
-		var roePnlPct float64
-
-		if pos.EntryPrice > 0 {
-			if pos.PositionAmt > 0 {
-				rawPnlPct = ((pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice) * 100
-			} else {
-				rawPnlPct = ((pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice) * 100
-			}
+		// Leave symbols with an order in flight to the execution path
+		if !e.claimSymbol(pos.Symbol) {
+			continue
+		}
+		e.checkPositionRisk(ctx, e.withStreamMark(pos), rc, isSimpleMode, drawdownThreshold, minProfitForDrawdown)
+		e.releaseSymbol(pos.Symbol)
+	}
+}
 
-			// Apply leverage to get ROE
-			leverage := float64(pos.Leverage)
-			if leverage < 1 {
-				leverage = 1
-			}
-			roePnlPct = rawPnlPct * leverage
+// checkPositionRisk runs the rule-based protections for one position and
+// closes it when one triggers
+func (e *Engine) checkPositionRisk(ctx context.Context, pos *exchange.Position, rc store.RiskControlConfig, isSimpleMode bool, drawdownThreshold, minProfitForDrawdown float64) {
+	// Calculate P&L percentages
+	// OPTION B: Split Logic
+	// 1. rawPnlPct (Price Move): Used for Smart Loss (don't cut on noise)
+	// 2. roePnlPct (Equity Move): Used for Trailing Stop & Drawdown (protect actual equity)
+	var rawPnlPct float64
+	var roePnlPct float64
+
+	if pos.EntryPrice > 0 {
+		if pos.PositionAmt > 0 {
+			rawPnlPct = ((pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice) * 100
+		} else {
+			rawPnlPct = ((pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice) * 100
 		}
 
-		side := "LONG"
-		if pos.PositionAmt < 0 {
-			side = "SHORT"
+		// Apply leverage to get ROE
+		leverage := float64(pos.Leverage)
+		if leverage < 1 {
+			leverage = 1
 		}
+		roePnlPct = rawPnlPct * leverage
+	}
 
-		holdDuration := e.GetHoldDuration(pos.Symbol, side)
+	side := "LONG"
+	if pos.PositionAmt < 0 {
+		side = "SHORT"
+	}
 
-		// =====================================================================
-		// 1. TRAILING STOP LOSS - Lock in profits (Uses Raw % - same scale as Noise Zone)
-		// =====================================================================
-		if rc.EnableTrailingStop {
-			// activatePct: Set to 0 to activate immediately from entry (aggressive)
-			// Set to positive value (e.g., 1.0) to only activate after reaching that profit %
-			activatePct := rc.TrailingStopActivatePct
-			// NOTE: We don't override activatePct <= 0 anymore - 0 means immediate activation
-			trailDistPct := rc.TrailingStopDistancePct
-			if trailDistPct <= 0 {
-				trailDistPct = 0.5
-			}
+	holdDuration := e.GetHoldDuration(pos.Symbol, side)
 
-			// Update and get peak P&L (Using Raw % - consistent with Noise Zone)
-			e.UpdatePeakPnL(pos.Symbol, side, rawPnlPct)
-			peakPnL := e.GetPeakPnL(pos.Symbol, side)
-
-			// Check if trailing stop should activate
-			// If activatePct is 0 or negative, activate immediately from entry
-			if activatePct <= 0 || peakPnL >= activatePct {
-				// Calculate trailing stop level
-				trailingStopLevel := peakPnL - trailDistPct
-
-				if rawPnlPct <= trailingStopLevel {
-					log.Printf("[%s][%s] 📉 TRAILING STOP TRIGGERED: Peak=%.2f%%, Current=%.2f%%, TrailStop=%.2f%% (Raw)",
-						e.name, pos.Symbol, peakPnL, rawPnlPct, trailingStopLevel)
-
-					if _, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); err != nil {
-						log.Printf("[%s][%s] Failed to close position (trailing stop): %v", e.name, pos.Symbol, err)
-					} else {
-						log.Printf("[%s][%s] ✅ Closed position via trailing stop. Realized profit locked in.", e.name, pos.Symbol)
-						e.clearPositionTracking(pos.Symbol, side)
-						e.cancelBracketOrders(ctx, pos.Symbol)
-					}
-					continue // Move to next position
-				}
-			}
+	// Peak before this check, to spot rules activating
+	prevPeak := e.GetPeakPnL(pos.Symbol, side)
+
+	// =====================================================================
+	// 1. TRAILING STOP LOSS - Lock in profits (Uses Raw % - same scale as Noise Zone)
+	// =====================================================================
+	if rc.EnableTrailingStop {
+		// activatePct: Set to 0 to activate immediately from entry (aggressive)
+		// Set to positive value (e.g., 1.0) to only activate after reaching that profit %
+		activatePct := rc.TrailingStopActivatePct
+		// NOTE: We don't override activatePct <= 0 anymore - 0 means immediate activation
+		trailDistPct := rc.TrailingStopDistancePct
+		if trailDistPct <= 0 {
+			trailDistPct = 0.5
 		}
 
-		// =====================================================================
-		// 2. MAX HOLD DURATION - Force close positions held too long
-		// =====================================================================
-		if rc.EnableMaxHoldDuration {
-			maxHoldMins := rc.MaxHoldDurationMins
-			if maxHoldMins <= 0 {
-				maxHoldMins = 240 // Default 4 hours
-			}
+		// Update and get peak P&L (Using Raw % - consistent with Noise Zone)
+		e.UpdatePeakPnL(pos.Symbol, side, rawPnlPct)
+		peakPnL := e.GetPeakPnL(pos.Symbol, side)
+
+		if activatePct > 0 && crossedUp(prevPeak, peakPnL, activatePct) {
+			log.Printf("[%s][%s] Trailing stop activated: Peak=%.2f%% >= %.2f%%", e.name, pos.Symbol, peakPnL, activatePct)
+			e.recordRiskActivation(pos.Symbol, pos.PositionAmt, store.PositionEventTrailingStopActivated, pos.MarkPrice,
+				fmt.Sprintf("peak +%.2f%% reached the +%.2f%% activation, trailing %.2f%% behind", peakPnL, activatePct, trailDistPct))
+		}
 
-			maxHoldDuration := time.Duration(maxHoldMins) * time.Minute
+		// Check if trailing stop should activate
+		// If activatePct is 0 or negative, activate immediately from entry
+		if activatePct <= 0 || peakPnL >= activatePct {
+			// Calculate trailing stop level
+			trailingStopLevel := peakPnL - trailDistPct
 
-			if holdDuration >= maxHoldDuration {
-				log.Printf("[%s][%s] ⏰ MAX HOLD DURATION EXCEEDED: Held for %v (limit: %v). Force closing.",
-					e.name, pos.Symbol, holdDuration.Round(time.Minute), maxHoldDuration)
+			if rawPnlPct <= trailingStopLevel {
+				log.Printf("[%s][%s] 📉 TRAILING STOP TRIGGERED: Peak=%.2f%%, Current=%.2f%%, TrailStop=%.2f%% (Raw)",
+					e.name, pos.Symbol, peakPnL, rawPnlPct, trailingStopLevel)
 
-				if _, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); err != nil {
-					log.Printf("[%s][%s] Failed to close position (max hold): %v", e.name, pos.Symbol, err)
+				if order, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); isReduceOnlyRejected(err) {
+					e.resyncFlatPosition(ctx, pos.Symbol, side)
+				} else if err != nil {
+					log.Printf("[%s][%s] Failed to close position (trailing stop): %v", e.name, pos.Symbol, err)
 				} else {
-					log.Printf("[%s][%s] ✅ Closed position due to max hold duration. PnL: %.2f%% (Raw)", e.name, pos.Symbol, rawPnlPct)
+					log.Printf("[%s][%s] ✅ Closed position via trailing stop. Realized profit locked in.", e.name, pos.Symbol)
 					e.clearPositionTracking(pos.Symbol, side)
 					e.cancelBracketOrders(ctx, pos.Symbol)
+					e.recordClose(pos.Symbol, false)
+					e.settleClose(ctx, pos, order, closeReasonTrailingStop)
 				}
-				continue // Move to next position
+				return
 			}
 		}
+	}
 
-		// =====================================================================
-		// 3. SMART LOSS CUT - Cut positions underwater too long (Uses RAW %)
-		// =====================================================================
-		if rc.EnableSmartLossCut {
-			smartLossMins := rc.SmartLossCutMins
-			if smartLossMins <= 0 {
-				smartLossMins = 30 // Default 30 mins
-			}
-			smartLossPct := rc.SmartLossCutPct
-			if smartLossPct >= 0 {
-				smartLossPct = -1.0 // Default -1%
-			}
+	// =====================================================================
+	// 2. MAX HOLD DURATION - Force close positions held too long
+	// =====================================================================
+	if rc.EnableMaxHoldDuration {
+		maxHoldMins := rc.MaxHoldDurationMins
+		if maxHoldMins <= 0 {
+			maxHoldMins = 240 // Default 4 hours
+		}
 
-			smartLossDuration := time.Duration(smartLossMins) * time.Minute
+		maxHoldDuration := time.Duration(maxHoldMins) * time.Minute
 
-			// Only trigger if both conditions are met: underwater AND held long enough
-			// USES RAW P&L: We want to allow -1% Raw (approx -20% ROE) before giving up
-			// If we used ROE, -1% would trigger almost instantly on any noise
-			if rawPnlPct <= smartLossPct && holdDuration >= smartLossDuration {
-				log.Printf("[%s][%s] 🔪 SMART LOSS CUT: Position at %.2f%% Raw (ROE: %.2f%%) < %.2f%% for %v. Cutting losses.",
-					e.name, pos.Symbol, rawPnlPct, roePnlPct, smartLossPct, holdDuration.Round(time.Minute))
+		if holdDuration >= maxHoldDuration {
+			log.Printf("[%s][%s] ⏰ MAX HOLD DURATION EXCEEDED: Held for %v (limit: %v). Force closing.",
+				e.name, pos.Symbol, holdDuration.Round(time.Minute), maxHoldDuration)
 
-				if _, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); err != nil {
-					log.Printf("[%s][%s] Failed to close position (smart loss cut): %v", e.name, pos.Symbol, err)
-				} else {
-					log.Printf("[%s][%s] ✅ Cut losing position. Loss: %.2f%% (Raw)", e.name, pos.Symbol, rawPnlPct)
-					e.clearPositionTracking(pos.Symbol, side)
-					e.cancelBracketOrders(ctx, pos.Symbol)
-				}
-				continue // Move to next position
+			if order, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); isReduceOnlyRejected(err) {
+				e.resyncFlatPosition(ctx, pos.Symbol, side)
+			} else if err != nil {
+				log.Printf("[%s][%s] Failed to close position (max hold): %v", e.name, pos.Symbol, err)
+			} else {
+				log.Printf("[%s][%s] ✅ Closed position due to max hold duration. PnL: %.2f%% (Raw)", e.name, pos.Symbol, rawPnlPct)
+				e.clearPositionTracking(pos.Symbol, side)
+				e.cancelBracketOrders(ctx, pos.Symbol)
+				e.recordClose(pos.Symbol, false)
+				e.settleClose(ctx, pos, order, closeReasonMaxHold)
 			}
+			return
 		}
+	}
 
-		// =====================================================================
-		// 4. DRAWDOWN PROTECTION (Uses Raw % - same scale as Noise Zone & Trailing Stop)
-		// =====================================================================
-		// In Simple Mode, we skip ONLY this automatic drawdown protection
-		// (features 1-3 above are explicitly enabled by user, so they still run)
-		if isSimpleMode {
-			continue
+	// =====================================================================
+	// 3. SMART LOSS CUT - Cut positions underwater too long (Uses RAW %)
+	// =====================================================================
+	if rc.EnableSmartLossCut {
+		smartLossMins := rc.SmartLossCutMins
+		if smartLossMins <= 0 {
+			smartLossMins = 30 // Default 30 mins
 		}
-
-		// Update peak P&L (Using Raw % - consistent with other features)
-		e.UpdatePeakPnL(pos.Symbol, side, rawPnlPct)
-		peakPnL := e.GetPeakPnL(pos.Symbol, side)
-
-		// Only apply drawdown protection if we were profitable (in Raw % terms)
-		if peakPnL < minProfitForDrawdown {
-			continue
+		smartLossPct := rc.SmartLossCutPct
+		if smartLossPct >= 0 {
+			smartLossPct = -1.0 // Default -1%
 		}
 
-		// Calculate drawdown from peak (relative percentage, matching NOFX)
-		// Using Raw % values
-		var drawdownPct float64
-		if peakPnL > 0 && rawPnlPct < peakPnL {
-			drawdownPct = ((peakPnL - rawPnlPct) / peakPnL) * 100
-		}
+		smartLossDuration := time.Duration(smartLossMins) * time.Minute
 
-		if drawdownPct >= drawdownThreshold {
-			log.Printf("[%s][%s] Drawdown alert: Peak=%.2f%%, Current=%.2f%%, Drawdown=%.2f%% >= %.2f%% (Raw)",
-				e.name, pos.Symbol, peakPnL, rawPnlPct, drawdownPct, drawdownThreshold)
+		// Only trigger if both conditions are met: underwater AND held long enough
+		// USES RAW P&L: We want to allow -1% Raw (approx -20% ROE) before giving up
+		// If we used ROE, -1% would trigger almost instantly on any noise
+		if rawPnlPct <= smartLossPct && holdDuration >= smartLossDuration {
+			log.Printf("[%s][%s] 🔪 SMART LOSS CUT: Position at %.2f%% Raw (ROE: %.2f%%) < %.2f%% for %v. Cutting losses.",
+				e.name, pos.Symbol, rawPnlPct, roePnlPct, smartLossPct, holdDuration.Round(time.Minute))
 
-			// Close the position
-			log.Printf("[%s][%s] Closing position due to drawdown protection", e.name, pos.Symbol)
-			if _, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); err != nil {
-				log.Printf("[%s][%s] Failed to close position: %v", e.name, pos.Symbol, err)
+			if order, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); isReduceOnlyRejected(err) {
+				e.resyncFlatPosition(ctx, pos.Symbol, side)
+			} else if err != nil {
+				log.Printf("[%s][%s] Failed to close position (smart loss cut): %v", e.name, pos.Symbol, err)
 			} else {
+				log.Printf("[%s][%s] ✅ Cut losing position. Loss: %.2f%% (Raw)", e.name, pos.Symbol, rawPnlPct)
 				e.clearPositionTracking(pos.Symbol, side)
 				e.cancelBracketOrders(ctx, pos.Symbol)
+				e.recordClose(pos.Symbol, true)
+				e.settleClose(ctx, pos, order, closeReasonSmartLossCut)
 			}
+			return
+		}
+	}
+
+	// =====================================================================
+	// 4. DRAWDOWN PROTECTION (Uses Raw % - same scale as Noise Zone & Trailing Stop)
+	// =====================================================================
+	// In Simple Mode, we skip ONLY this automatic drawdown protection
+	// (features 1-3 above are explicitly enabled by user, so they still run)
+	if isSimpleMode {
+		return
+	}
+
+	// Update peak P&L (Using Raw % - consistent with other features)
+	e.UpdatePeakPnL(pos.Symbol, side, rawPnlPct)
+	peakPnL := e.GetPeakPnL(pos.Symbol, side)
+
+	if crossedUp(prevPeak, peakPnL, minProfitForDrawdown) {
+		e.recordRiskActivation(pos.Symbol, pos.PositionAmt, store.PositionEventDrawdownArmed, pos.MarkPrice,
+			fmt.Sprintf("peak +%.2f%% reached +%.2f%%, closes on a %.0f%% giveback", peakPnL, minProfitForDrawdown, drawdownThreshold))
+	}
+
+	// Only apply drawdown protection if we were profitable (in Raw % terms)
+	if peakPnL < minProfitForDrawdown {
+		return
+	}
+
+	// Calculate drawdown from peak (relative percentage, matching NOFX)
+	// Using Raw % values
+	var drawdownPct float64
+	if peakPnL > 0 && rawPnlPct < peakPnL {
+		drawdownPct = ((peakPnL - rawPnlPct) / peakPnL) * 100
+	}
+
+	if drawdownPct >= drawdownThreshold {
+		log.Printf("[%s][%s] Drawdown alert: Peak=%.2f%%, Current=%.2f%%, Drawdown=%.2f%% >= %.2f%% (Raw)",
+			e.name, pos.Symbol, peakPnL, rawPnlPct, drawdownPct, drawdownThreshold)
+
+		// Close the position
+		log.Printf("[%s][%s] Closing position due to drawdown protection", e.name, pos.Symbol)
+		if order, err := e.binance.ClosePosition(ctx, pos.Symbol, pos.PositionAmt); isReduceOnlyRejected(err) {
+			e.resyncFlatPosition(ctx, pos.Symbol, side)
+		} else if err != nil {
+			log.Printf("[%s][%s] Failed to close position: %v", e.name, pos.Symbol, err)
+		} else {
+			e.clearPositionTracking(pos.Symbol, side)
+			e.cancelBracketOrders(ctx, pos.Symbol)
+			e.recordClose(pos.Symbol, false)
+			e.settleClose(ctx, pos, order, closeReasonDrawdown)
 		}
 	}
 }
@@ -2712,14 +3562,37 @@ func (e *Engine) startOrderSync(ctx context.Context) {
 	}
 }
 
+// refreshPositions replaces the tracked positions with a fresh fetch and
+// records positions opened or closed outside the engine
+func (e *Engine) refreshPositions(ctx context.Context) error {
+	positions, err := e.binance.GetPositions(ctx)
+	e.noteExchangeCall(err)
+	if err != nil {
+		return err
+	}
+	positions = e.ownPositions(e.withUnconfirmedPositions(positions))
+	e.mu.Lock()
+	e.positions = make(map[string]*exchange.Position)
+	for i := range positions {
+		e.positions[positions[i].Symbol] = &positions[i]
+	}
+	e.mu.Unlock()
+	e.flattenAfterReconnect(ctx)
+
+	e.syncPositionStore(ctx, positions)
+	return nil
+}
+
 // syncOrdersFromBinance fetches and reconciles positions from Binance
-func (e *Engine) syncOrdersFromBinance(ctx context.Context) {
+func (e *Engine) syncOrdersFromBinance(ctx context.Context) error {
 	// Get positions from Binance
 	positions, err := e.binance.GetPositions(ctx)
+	e.noteExchangeCall(err)
 	if err != nil {
 		log.Printf("[%s] Order sync failed: %v", e.name, err)
-		return
+		return err
 	}
+	positions = e.ownPositions(e.withUnconfirmedPositions(positions))
 
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -2776,6 +3649,10 @@ func (e *Engine) syncOrdersFromBinance(ctx context.Context) {
 	// existing positions, but we preserve local state for positions not yet
 	// visible on exchange (due to API latency).
 	for symbol, newPos := range newPositions {
+		// An order in flight writes its own fill data when it completes
+		if e.executing[symbol] {
+			continue
+		}
 		e.positions[symbol] = newPos
 	}
 	// NOTE: We don't remove positions that aren't in newPositions here.
@@ -2788,4 +3665,5 @@ func (e *Engine) syncOrdersFromBinance(ctx context.Context) {
 	if err == nil {
 		e.account = account
 	}
+	return nil
 }
@@ -3,10 +3,8 @@ package trader
 import (
 	"context"
 	"log"
-	"time"
 
 	"auto-trader-ahh/exchange"
-	"auto-trader-ahh/store"
 )
 
 // runCopyTradingCycle runs a lightweight cycle for Copy Trading mode
@@ -31,13 +29,7 @@ func (e *Engine) runCopyTradingCycle(ctx context.Context) {
 		e.account = account
 
 		// Save equity snapshot for history/charts
-		e.equityStore.Save(&store.EquitySnapshot{
-			TraderID:      e.id,
-			Timestamp:     time.Now(),
-			TotalEquity:   account.TotalMarginBalance,
-			Balance:       account.TotalWalletBalance,
-			UnrealizedPnL: account.TotalUnrealizedProfit,
-		})
+		e.saveEquitySnapshot(account)
 		e.mu.Unlock()
 
 		log.Printf("[%s] Balance: $%.2f, Equity: $%.2f, Unrealized PnL: $%.2f",
@@ -49,6 +41,7 @@ func (e *Engine) runCopyTradingCycle(ctx context.Context) {
 	if err != nil {
 		log.Printf("[%s] Error getting positions: %v", e.name, err)
 	} else {
+		positions = e.ownPositions(e.withUnconfirmedPositions(positions))
 		e.mu.Lock()
 		e.positions = make(map[string]*exchange.Position)
 		activeCount := 0
@@ -62,6 +55,8 @@ func (e *Engine) runCopyTradingCycle(ctx context.Context) {
 		}
 		e.mu.Unlock()
 		log.Printf("[%s] Synced %d active positions", e.name, activeCount)
+
+		e.syncPositionStore(ctx, positions)
 	}
 
 	// 4. Sync Trade History (to capture copy executions)
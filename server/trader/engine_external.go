@@ -0,0 +1,259 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// ExternalDecision is a decision produced outside the trading loop.
+// PositionPct, when set, is the fraction of real equity to commit as margin
+// and takes precedence over PositionSizeUSD.
+type ExternalDecision struct {
+	decision.Decision
+	PositionPct float64
+}
+
+// ExecutionResult is the outcome of one externally supplied decision
+type ExecutionResult struct {
+	Symbol   string `json:"symbol"`
+	Action   string `json:"action"`
+	Executed bool   `json:"executed"`
+	Status   string `json:"status"` // A store.DecisionStatus
+	OrderID  string `json:"order_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	PositionID int64 `json:"position_id,omitempty"` // PositionStore row the decision opened or acted on
+}
+
+// ExecuteDecisions runs decisions made outside the trading loop (e.g. a debate
+// consensus) through this trader's risk controls and execution path.
+func (e *Engine) ExecuteDecisions(ctx context.Context, decisions []ExternalDecision) []ExecutionResult {
+	results := make([]ExecutionResult, len(decisions))
+	for i, d := range decisions {
+		results[i] = ExecutionResult{Symbol: d.Symbol, Action: d.Action, Status: store.DecisionStatusNoAction}
+	}
+
+	rejectAll := func(reason string) []ExecutionResult {
+		log.Printf("[%s] External decisions rejected: %s", e.name, reason)
+		for i := range results {
+			results[i].Error = reason
+			results[i].Status = executionStatus(results[i].Action, errors.New(reason))
+		}
+		return results
+	}
+
+	if !e.IsRunning() {
+		return rejectAll("rejected: trader is not running")
+	}
+
+	e.resetDailyPnLIfNeeded(time.Now())
+	if e.shouldStopTrading() {
+		e.mu.RLock()
+		stopUntil := e.stopUntil
+		e.mu.RUnlock()
+		return rejectAll(fmt.Sprintf("rejected: trading paused until %s (daily loss limit)", stopUntil.Format(time.RFC3339)))
+	}
+
+	if e.strategy != nil && e.strategy.Config.TradingMode == "copy_trade" {
+		return rejectAll("rejected: trader is in copy trading mode")
+	}
+
+	if paused, behavior, next := e.scheduleStatus(time.Now()); paused && behavior == scheduleFullPause {
+		return rejectAll(fmt.Sprintf("rejected: trader paused by schedule until %s", formatNextActive(next)))
+	}
+
+	// Size against fresh equity, not the cached account
+	account, err := e.binance.GetAccountInfo(ctx)
+	if err != nil {
+		return rejectAll(fmt.Sprintf("failed to get account info: %v", err))
+	}
+	positions, err := e.binance.GetPositions(ctx)
+	if err != nil {
+		return rejectAll(fmt.Sprintf("failed to get positions: %v", err))
+	}
+	positions = e.ownPositions(positions)
+
+	e.mu.Lock()
+	e.account = account
+	e.positions = make(map[string]*exchange.Position)
+	for i := range positions {
+		e.positions[positions[i].Symbol] = &positions[i]
+	}
+	e.mu.Unlock()
+
+	equity := account.TotalMarginBalance
+	if equity <= 0 {
+		equity = account.AvailableBalance
+	}
+	validationCfg := newValidationConfig(e.strategy, equity)
+	validationCfg.SymbolCheck = e.binance.CheckTradable
+	validationCfg.MarginUsed = account.TotalMarginBalance - account.AvailableBalance
+	validationCfg.Positions = make(map[string]*decision.PositionExposure)
+	marks := make(map[string]float64)
+	for _, pos := range positions {
+		if pos.PositionAmt != 0 {
+			marks[pos.Symbol] = pos.MarkPrice
+			validationCfg.Positions[pos.Symbol] = &decision.PositionExposure{
+				Side:     rowSide(pos.PositionAmt),
+				Notional: math.Abs(pos.PositionAmt) * pos.MarkPrice,
+			}
+		}
+	}
+
+	var positionIDs []int64
+	for i := range decisions {
+		d := decisions[i].Decision
+		res := &results[i]
+
+		if decision.IsPassiveAction(d.Action) {
+			continue
+		}
+
+		if minConf := e.getMinConfidence(); d.Confidence < minConf {
+			res.Error = fmt.Sprintf("skipped: confidence %d%% below trader minimum %d%%", d.Confidence, minConf)
+			res.Status = store.DecisionStatusSkippedLowConfidence
+			continue
+		}
+
+		var price float64
+		if decision.IsOpeningAction(d.Action) || decision.IsAddAction(d.Action) {
+			if d.Leverage <= 0 {
+				d.Leverage = e.getLeverageLimit(d.Symbol)
+			}
+			if pct := decisions[i].PositionPct; pct > 0 {
+				d.PositionSizeUSD = equity * pct * float64(d.Leverage)
+			}
+
+			// SL/TP are checked against the live price, not a midpoint guess
+			ticker, err := e.binance.GetTicker(ctx, d.Symbol)
+			if err != nil {
+				res.Error = fmt.Sprintf("failed to get price: %v", err)
+				res.Status = store.DecisionStatus(store.DecisionStatusOrderFailed, res.Error)
+				continue
+			}
+			if ticker.Price <= 0 {
+				res.Error = fmt.Sprintf("invalid price for %s", d.Symbol)
+				res.Status = store.DecisionStatus(store.DecisionStatusOrderFailed, res.Error)
+				continue
+			}
+			price = ticker.Price
+			validationCfg.CurrentPrices = map[string]float64{d.Symbol: price}
+		}
+
+		// Stop moves are checked against the position's mark price
+		if decision.IsStopAdjustment(d.Action) {
+			validationCfg.CurrentPrices = map[string]float64{d.Symbol: marks[d.Symbol]}
+		}
+
+		if err := decision.ValidateDecision(&d, validationCfg); err != nil {
+			res.Error = fmt.Sprintf("rejected: %v", err)
+			res.Status = store.DecisionStatus(store.DecisionStatusBlockedByRisk, err.Error())
+			log.Printf("[%s][%s] External %s %s", e.name, d.Symbol, d.Action, res.Error)
+			continue
+		}
+
+		e.mu.RLock()
+		pos := e.positions[d.Symbol]
+		e.mu.RUnlock()
+		hasPosition := pos != nil && pos.PositionAmt != 0
+
+		td := decisionToTradingDecision(&d)
+		if decision.IsOpeningAction(d.Action) {
+			// PositionSizeUSD is position value; the trader sizes by margin
+			td.PositionSizeUSD = d.PositionSizeUSD / float64(d.Leverage)
+			td.StopLossPct, td.TakeProfitPct = sltpPctFromPrices(td.Action == "BUY", price, td.StopLoss, td.TakeProfit)
+		} else if decision.IsAddAction(d.Action) {
+			td.PositionSizeUSD = d.PositionSizeUSD / float64(d.Leverage)
+		}
+
+		log.Printf("[%s][%s] Executing external %s (confidence: %d%%)", e.name, d.Symbol, d.Action, d.Confidence)
+		_, err := e.executeTrade(ctx, d.Symbol, td, hasPosition, pos)
+		res.Status = executionStatus(td.Action, err)
+		if err != nil {
+			res.Error = err.Error()
+			if e.notifier != nil {
+				e.notifier.Broadcast(events.Event{
+					Type:      events.TypeError,
+					TraderID:  e.id,
+					Symbol:    d.Symbol,
+					Message:   fmt.Sprintf("external decision failed: %v", err),
+					Timestamp: time.Now().UnixMilli(),
+				})
+			}
+			continue
+		}
+
+		res.Executed = res.Status == store.DecisionStatusExecuted
+		// Later decisions are validated against the exposure this one freed or used
+		decision.ApplyToPositions(validationCfg.Positions, &d)
+		if td.OrderID != 0 {
+			res.OrderID = strconv.FormatInt(td.OrderID, 10)
+		}
+		if td.PositionID > 0 {
+			res.PositionID = td.PositionID
+			positionIDs = append(positionIDs, td.PositionID)
+		}
+		if e.notifier != nil {
+			e.notifier.Broadcast(events.Event{
+				Type:      events.TypeTrade,
+				TraderID:  e.id,
+				Symbol:    d.Symbol,
+				Message:   fmt.Sprintf("Executed external %s", d.Action),
+				Data:      res,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+	}
+
+	// Record alongside the trader's own cycle decisions
+	executed := false
+	for _, res := range results {
+		executed = executed || res.Executed
+	}
+	resultsJSON, _ := json.Marshal(results)
+	if err := e.decisionStore.Create(&store.Decision{
+		TraderID:    e.id,
+		Decisions:   string(resultsJSON),
+		Executed:    executed,
+		PositionIDs: positionIDs,
+	}); err != nil {
+		log.Printf("[%s] Failed to save decision record: %v", e.name, err)
+	}
+
+	return results
+}
+
+// sltpPctFromPrices returns SL/TP distances from price in percent.
+// A level on the wrong side of price yields 0 so the strategy default applies.
+func sltpPctFromPrices(isLong bool, price, stopLoss, takeProfit float64) (slPct, tpPct float64) {
+	if price <= 0 {
+		return 0, 0
+	}
+	if isLong {
+		if stopLoss > 0 && stopLoss < price {
+			slPct = (price - stopLoss) / price * 100
+		}
+		if takeProfit > price {
+			tpPct = (takeProfit - price) / price * 100
+		}
+	} else {
+		if stopLoss > price {
+			slPct = (stopLoss - price) / price * 100
+		}
+		if takeProfit > 0 && takeProfit < price {
+			tpPct = (price - takeProfit) / price * 100
+		}
+	}
+	return slPct, tpPct
+}
@@ -0,0 +1,34 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSLTPPctFromPrices(t *testing.T) {
+	tests := []struct {
+		name       string
+		isLong     bool
+		price      float64
+		stopLoss   float64
+		takeProfit float64
+		wantSL     float64
+		wantTP     float64
+	}{
+		{"long", true, 100, 98, 106, 2, 6},
+		{"short", false, 100, 102, 94, 2, 6},
+		{"long with levels on wrong side falls back to defaults", true, 100, 101, 99, 0, 0},
+		{"short with levels on wrong side falls back to defaults", false, 100, 99, 101, 0, 0},
+		{"missing levels", true, 100, 0, 0, 0, 0},
+		{"no price", true, 0, 98, 106, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sl, tp := sltpPctFromPrices(tt.isLong, tt.price, tt.stopLoss, tt.takeProfit)
+			if math.Abs(sl-tt.wantSL) > 1e-9 || math.Abs(tp-tt.wantTP) > 1e-9 {
+				t.Errorf("sltpPctFromPrices() = (%.4f, %.4f), want (%.4f, %.4f)", sl, tp, tt.wantSL, tt.wantTP)
+			}
+		})
+	}
+}
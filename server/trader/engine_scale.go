@@ -0,0 +1,252 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// isScaleIn reports whether action adds to the held position: an explicit
+// add, or an open on the side already held
+func isScaleIn(action string, pos *exchange.Position) bool {
+	if pos == nil || pos.PositionAmt == 0 {
+		return false
+	}
+	switch action {
+	case decision.ActionAddLong, "BUY", decision.ActionOpenLong:
+		return pos.PositionAmt > 0
+	case decision.ActionAddShort, "SELL", decision.ActionOpenShort:
+		return pos.PositionAmt < 0
+	}
+	return false
+}
+
+// scaleInMargin sizes an add against what's left of the symbol's margin
+// budget. requested <= 0 takes the whole remainder.
+func scaleInMargin(target, existing, requested, minSize float64) (float64, error) {
+	remaining := target - existing
+	if remaining < minSize {
+		return 0, fmt.Errorf("no risk budget left ($%.2f of $%.2f margin used)", existing, target)
+	}
+
+	margin := requested
+	if margin <= 0 || margin > remaining {
+		margin = remaining
+	}
+	if margin < minSize {
+		return 0, fmt.Errorf("add margin $%.2f below minimum $%.2f", margin, minSize)
+	}
+	return margin, nil
+}
+
+// weightedEntry returns the average entry price after adding addQty at addPrice
+func weightedEntry(qty, entry, addQty, addPrice float64) float64 {
+	total := qty + addQty
+	if total <= 0 {
+		return addPrice
+	}
+	return (qty*entry + addQty*addPrice) / total
+}
+
+// executeAdd scales into the open position on symbol with a market order.
+// The existing bracket orders close the whole position, so they're kept.
+func (e *Engine) executeAdd(ctx context.Context, symbol string, td *ai.TradingDecision, currentPos *exchange.Position, price float64, account *exchange.AccountInfo) (float64, error) {
+	isLong := currentPos.PositionAmt > 0
+	side := positionSide(currentPos.PositionAmt)
+
+	equity := account.TotalMarginBalance
+	if equity <= 0 {
+		equity = account.AvailableBalance
+	}
+
+	leverage := currentPos.Leverage
+	if leverage <= 0 {
+		leverage = e.getLeverageLimit(symbol)
+	}
+
+	// The symbol's budget is what a fresh open would have been sized to
+	target := equity * e.getPositionPercent() / 100
+	target, _ = e.enforcePositionValueRatio(target, equity, symbol)
+	existing := math.Abs(currentPos.PositionAmt) * currentPos.EntryPrice / float64(leverage)
+
+	margin, err := scaleInMargin(target, existing, td.PositionSizeUSD, e.getMinPositionSize(symbol))
+	if err != nil {
+		log.Printf("[%s][%s] Cannot add to %s: %v", e.name, symbol, side, err)
+		return 0, fmt.Errorf("skipped: %w", err)
+	}
+
+	marginFactor := 1.01/float64(leverage) + 0.001
+	if maxAffordable := account.AvailableBalance / marginFactor; margin > maxAffordable {
+		log.Printf("[%s][%s] ⚠️ Add margin $%.2f exceeds max affordable $%.2f, capping", e.name, symbol, margin, maxAffordable)
+		margin = maxAffordable
+	}
+	margin = e.applyMarginBuffer(margin)
+	if err := e.enforceMinPositionSize(margin, symbol); err != nil {
+		return 0, fmt.Errorf("skipped: %w", err)
+	}
+
+	if e.strategy != nil {
+		used := account.TotalMarginBalance - account.AvailableBalance
+		if err := decision.CheckMarginUsage(used, margin, equity, e.strategy.Config.RiskControl.MaxMarginUsage); err != nil {
+			log.Printf("[%s][%s] ❌ REJECTED add: %v", e.name, symbol, err)
+			return 0, fmt.Errorf("skipped: %w", err)
+		}
+	}
+	if err := e.checkExposureLimits(symbol, rowSide(currentPos.PositionAmt), margin*float64(leverage), equity); err != nil {
+		return 0, fmt.Errorf("skipped: %w", err)
+	}
+
+	quantity := margin * float64(leverage) / price
+	orderSide := "BUY"
+	if !isLong {
+		orderSide = "SELL"
+	}
+
+	log.Printf("[%s][%s] Adding to %s: %.4f @ $%.2f (margin: $%.2f, existing margin: $%.2f, budget: $%.2f)",
+		e.name, symbol, side, quantity, price, margin, existing, target)
+	order, err := e.binance.PlaceOrder(ctx, symbol, orderSide, "MARKET", quantity, 0, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add to %s: %w", side, err)
+	}
+
+	if order != nil {
+		td.OrderID = order.OrderID
+	}
+	fill, err := e.confirmFill(ctx, symbol, order, price)
+	if err != nil {
+		log.Printf("[%s][%s] ❌ Add order not filled: %v", e.name, symbol, err)
+		return 0, fmt.Errorf("failed to add to %s: %w", side, err)
+	}
+	e.checkSlippage(symbol, price, fill)
+	fillPrice, filledQty := fill.Price, fill.Qty
+
+	held := math.Abs(currentPos.PositionAmt)
+	newEntry := weightedEntry(held, currentPos.EntryPrice, filledQty, fillPrice)
+	newAmt := held + filledQty
+	if !isLong {
+		newAmt = -newAmt
+	}
+
+	e.mu.Lock()
+	e.positions[symbol] = &exchange.Position{
+		Symbol:      symbol,
+		PositionAmt: newAmt,
+		EntryPrice:  newEntry,
+		MarkPrice:   fillPrice,
+		Leverage:    leverage,
+	}
+	e.mu.Unlock()
+
+	if e.positionStore != nil {
+		row, err := e.positionStore.GetOpenPositionBySymbol(e.id, symbol, rowSide(currentPos.PositionAmt))
+		if err != nil {
+			log.Printf("[%s][%s] Failed to load position row for add: %v", e.name, symbol, err)
+		} else if row != nil {
+			if err := e.positionStore.UpdatePositionQuantityAndPrice(row.ID, filledQty, fillPrice); err != nil {
+				log.Printf("[%s][%s] Failed to record add: %v", e.name, symbol, err)
+			}
+			if err := e.positionStore.AddExecutionCosts(row.ID, fill.Fee, fill.SlippageCost); err != nil {
+				log.Printf("[%s][%s] Failed to record add costs: %v", e.name, symbol, err)
+			}
+			td.PositionID = row.ID
+			e.recordFill(row, store.PositionEventScaledIn, fillPrice, filledQty, 0, fill.Fee, store.PositionEventSourceAI, td.Reasoning)
+		}
+	}
+
+	log.Printf("[%s][%s] Added %.4f @ $%.4f, %s now %.4f @ avg $%.4f",
+		e.name, symbol, filledQty, fillPrice, side, math.Abs(newAmt), newEntry)
+	return 0, nil
+}
+
+// executePartialClose closes pct percent of the position on symbol and
+// returns the realized P&L. Tracking, brackets and cooldown stay in place
+// for the remainder.
+func (e *Engine) executePartialClose(ctx context.Context, symbol string, td *ai.TradingDecision, currentPos *exchange.Position, pct float64) (float64, error) {
+	isLong := currentPos.PositionAmt > 0
+	side := positionSide(currentPos.PositionAmt)
+	held := math.Abs(currentPos.PositionAmt)
+	quantity := held * pct / 100
+
+	orderSide := "SELL"
+	if !isLong {
+		orderSide = "BUY"
+	}
+
+	log.Printf("[%s][%s] Closing %.0f%% of %s position: %.4f of %.4f", e.name, symbol, pct, side, quantity, held)
+	order, err := e.binance.PlaceOrder(ctx, symbol, orderSide, "MARKET", quantity, 0, true)
+	if isReduceOnlyRejected(err) {
+		e.resyncFlatPosition(ctx, symbol, side)
+		return 0, fmt.Errorf("skipped: %s position already reduced on the exchange", side)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to partially close position: %w", err)
+	}
+
+	if order != nil {
+		td.OrderID = order.OrderID
+	}
+	fill := e.resolveCloseFill(ctx, currentPos, order, quantity)
+
+	remaining := held - fill.Qty
+	if !isLong {
+		remaining = -remaining
+	}
+	e.mu.Lock()
+	if pos := e.positions[symbol]; pos != nil {
+		pos.PositionAmt = remaining
+	}
+	e.mu.Unlock()
+
+	if e.positionStore != nil {
+		row, err := e.positionStore.GetOpenPositionBySymbol(e.id, symbol, rowSide(currentPos.PositionAmt))
+		if err != nil {
+			log.Printf("[%s][%s] Failed to load position row for partial close: %v", e.name, symbol, err)
+		} else if row != nil {
+			if err := e.positionStore.ReducePositionQuantity(row.ID, fill.Qty, fill.Price, fill.Fee, fill.PnL, fill.Estimated); err != nil {
+				log.Printf("[%s][%s] Failed to record partial close: %v", e.name, symbol, err)
+			}
+			e.recordSlippage(row.ID, symbol, fill.SlippageCost)
+			td.PositionID = row.ID
+			e.recordFill(row, store.PositionEventPartialClose, fill.Price, fill.Qty, fill.PnL, fill.Fee, store.PositionEventSourceAI, td.Reasoning)
+		}
+	}
+
+	log.Printf("[%s][%s] Partial close filled: %.4f @ $%.4f, P&L $%.2f, fee $%.4f, %.4f left (estimated: %v)",
+		e.name, symbol, fill.Qty, fill.Price, fill.PnL, fill.Fee, math.Abs(remaining), fill.Estimated)
+	return fill.PnL, nil
+}
+
+// partialLeavesDust reports whether closing pct percent would leave a
+// remainder too small to trade, in which case the whole position is closed
+func partialLeavesDust(symbol string, pos *exchange.Position, pct float64) bool {
+	minNotional := 10.0
+	if isBTCETH(symbol) {
+		minNotional = 50.0
+	}
+	remaining := math.Abs(pos.PositionAmt) * (100 - pct) / 100
+	return remaining*pos.MarkPrice < minNotional
+}
+
+// entryQuantities returns the total size scaled into each open position,
+// keyed by getPositionKey with the lowercase side
+func (e *Engine) entryQuantities() map[string]float64 {
+	qtys := make(map[string]float64)
+	if e.positionStore == nil {
+		return qtys
+	}
+	rows, err := e.positionStore.GetOpenPositions(e.id)
+	if err != nil {
+		log.Printf("[%s] Failed to load entry quantities: %v", e.name, err)
+		return qtys
+	}
+	for _, row := range rows {
+		qtys[getPositionKey(row.Symbol, row.Side)] = row.EntryQuantity
+	}
+	return qtys
+}
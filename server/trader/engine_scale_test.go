@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"math"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// TestScaleInMargin tests sizing adds against the remaining margin budget
+func TestScaleInMargin(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    float64
+		existing  float64
+		requested float64
+		want      float64
+		wantErr   bool
+	}{
+		{"Unsized add takes the remainder", 100, 50, 0, 50, false},
+		{"Requested fits", 100, 50, 20, 20, false},
+		{"Requested capped to remainder", 100, 50, 80, 50, false},
+		{"Budget used up", 100, 95, 0, 0, true},
+		{"Requested below minimum", 100, 50, 5, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scaleInMargin(tt.target, tt.existing, tt.requested, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scaleInMargin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("scaleInMargin() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIsScaleIn tests which actions add to a held position
+func TestIsScaleIn(t *testing.T) {
+	long := &exchange.Position{Symbol: "BTCUSDT", PositionAmt: 0.1}
+	short := &exchange.Position{Symbol: "BTCUSDT", PositionAmt: -0.1}
+
+	tests := []struct {
+		action string
+		pos    *exchange.Position
+		want   bool
+	}{
+		{"BUY", long, true},
+		{"add_to_long", long, true},
+		{"SELL", long, false},
+		{"add_to_short", long, false},
+		{"SELL", short, true},
+		{"open_short", short, true},
+		{"CLOSE", short, false},
+		{"BUY", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := isScaleIn(tt.action, tt.pos); got != tt.want {
+			t.Errorf("isScaleIn(%s, %v) = %v, want %v", tt.action, tt.pos, got, tt.want)
+		}
+	}
+}
+
+// TestWeightedEntry tests the average entry after scaling in
+func TestWeightedEntry(t *testing.T) {
+	got := weightedEntry(1, 100, 1, 110)
+	if math.Abs(got-105) > 0.0001 {
+		t.Errorf("weightedEntry() = %f, want 105", got)
+	}
+}
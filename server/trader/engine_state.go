@@ -0,0 +1,254 @@
+package trader
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// restoredState is the in-memory tracking rebuilt from a stored EngineState
+type restoredState struct {
+	positions map[string]*exchange.Position
+	peakPnL   map[string]float64
+	firstSeen map[string]int64
+	brackets  map[string]*BracketOrderIDs
+	dropped   []string // Stored positions no longer open on the exchange
+}
+
+// reconcileState merges stored tracking with the exchange's positions. The
+// exchange is authoritative: stored peaks, hold times and bracket orders are only
+// kept for positions still open on the same side, and positions opened while the
+// engine was down start being tracked now.
+func reconcileState(state *store.EngineState, positions []exchange.Position, now time.Time) *restoredState {
+	restored := &restoredState{
+		positions: make(map[string]*exchange.Position),
+		peakPnL:   make(map[string]float64),
+		firstSeen: make(map[string]int64),
+		brackets:  make(map[string]*BracketOrderIDs),
+	}
+
+	open := make(map[string]bool)
+	for i := range positions {
+		pos := &positions[i]
+		restored.positions[pos.Symbol] = pos
+		if pos.PositionAmt == 0 {
+			continue
+		}
+
+		key := getPositionKey(pos.Symbol, positionSide(pos.PositionAmt))
+		open[key] = true
+
+		ps := state.Positions[key]
+		if ps == nil {
+			restored.firstSeen[key] = now.UnixMilli()
+			continue
+		}
+
+		restored.peakPnL[key] = ps.PeakPnLPct
+		restored.firstSeen[key] = ps.FirstSeenMs
+		if ps.StopLossOrderID != 0 || ps.TakeProfitOrderID != 0 {
+			restored.brackets[pos.Symbol] = &BracketOrderIDs{
+				StopLossOrderID:   ps.StopLossOrderID,
+				TakeProfitOrderID: ps.TakeProfitOrderID,
+				EntryPrice:        ps.EntryPrice,
+				StopLossPct:       ps.StopLossPct,
+				TakeProfitPct:     ps.TakeProfitPct,
+			}
+		}
+	}
+
+	for key := range state.Positions {
+		if !open[key] {
+			restored.dropped = append(restored.dropped, key)
+		}
+	}
+
+	return restored
+}
+
+// restoreState loads the state saved by a previous run and reconciles it with
+// the exchange. Called from Start after the account has been fetched.
+func (e *Engine) restoreState(ctx context.Context) {
+	if e.stateStore == nil {
+		return
+	}
+
+	state, err := e.stateStore.Get(e.id)
+	if err != nil {
+		log.Printf("[%s] Failed to load saved state, starting fresh: %v", e.name, err)
+		return
+	}
+	if state == nil {
+		e.seedPeakEquity()
+		return
+	}
+
+	dayStart := e.tradingDay().Start(time.Now())
+
+	e.mu.Lock()
+	e.lastCycleAt = state.LastCycleAt
+	// Keep the daily loss baseline unless its trading day is over
+	if state.DailyBalance > 0 && !state.DailyResetAt.Before(dayStart) {
+		e.initialBalance = state.DailyBalance
+		e.lastResetTime = state.DailyResetAt
+	}
+	if time.Now().Before(state.StopUntil) {
+		e.stopUntil = state.StopUntil
+	}
+	for symbol, rec := range state.LastCloses {
+		e.lastCloses[symbol] = rec
+	}
+	e.peakEquity = state.PeakEquity
+	e.circuitBreaker = state.CircuitBreaker
+	e.breakerResetAt = state.BreakerResetAt
+	e.experimentCycles = state.ExperimentCycles
+	e.mu.Unlock()
+
+	// State saved before the circuit breaker existed has no peak yet
+	if state.PeakEquity == 0 && state.BreakerResetAt.IsZero() {
+		e.seedPeakEquity()
+	}
+	if state.CircuitBreaker != nil {
+		log.Printf("[%s] Circuit breaker still tripped since %s: %s", e.name, state.CircuitBreaker.TrippedAt.Format(time.RFC3339), state.CircuitBreaker.Message)
+	}
+
+	// The positions this trader held are its own again
+	for _, saved := range state.Positions {
+		if owner, ok := e.claimOwnership(saved.Symbol); !ok {
+			log.Printf("[%s][%s] ⚠️ Saved position is held by trader %s on the same account", e.name, saved.Symbol, owner.name)
+		}
+	}
+
+	positions, err := e.binance.GetPositions(ctx)
+	if err != nil {
+		log.Printf("[%s] Failed to get positions, position tracking not restored: %v", e.name, err)
+		return
+	}
+	positions = e.ownPositions(positions)
+
+	restored := reconcileState(state, positions, time.Now())
+
+	e.mu.Lock()
+	e.positions = restored.positions
+	e.positionFirstSeenTime = restored.firstSeen
+	e.mu.Unlock()
+
+	e.peakPnLCacheMutex.Lock()
+	e.peakPnLCache = restored.peakPnL
+	e.peakPnLCacheMutex.Unlock()
+
+	e.bracketOrdersMutex.Lock()
+	e.bracketOrders = restored.brackets
+	e.bracketOrdersMutex.Unlock()
+
+	for _, key := range restored.dropped {
+		log.Printf("[%s] Saved position %s was closed while stopped, dropping its tracking", e.name, key)
+	}
+	log.Printf("[%s] Restored state from %s (last cycle %s, %d open position(s))",
+		e.name, state.UpdatedAt.Format(time.RFC3339), state.LastCycleAt.Format(time.RFC3339), len(restored.firstSeen))
+}
+
+// seedPeakEquity takes the peak equity from the recorded equity snapshots
+func (e *Engine) seedPeakEquity() {
+	if e.equityStore == nil {
+		return
+	}
+	peak, _, err := e.equityStore.GetPeakEquity(e.id)
+	if err != nil {
+		return // No snapshots yet, the peak starts from the current equity
+	}
+
+	e.mu.Lock()
+	if peak > e.peakEquity {
+		e.peakEquity = peak
+	}
+	e.mu.Unlock()
+}
+
+// saveState persists the runtime state. Called after every cycle and on Stop.
+func (e *Engine) saveState() {
+	if e.stateStore == nil {
+		return
+	}
+
+	state := &store.EngineState{
+		TraderID:   e.id,
+		Positions:  make(map[string]*store.PositionState),
+		LastCloses: make(map[string]*store.CloseRecord),
+	}
+
+	e.mu.RLock()
+	state.LastCycleAt = e.lastCycleAt
+	state.DailyBalance = e.initialBalance
+	state.DailyResetAt = e.lastResetTime
+	state.StopUntil = e.stopUntil
+	state.PeakEquity = e.peakEquity
+	state.CircuitBreaker = e.circuitBreaker
+	state.BreakerResetAt = e.breakerResetAt
+	state.ExperimentCycles = e.experimentCycles
+	for symbol, rec := range e.lastCloses {
+		state.LastCloses[symbol] = rec
+	}
+	for _, pos := range e.positions {
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		side := positionSide(pos.PositionAmt)
+		key := getPositionKey(pos.Symbol, side)
+		state.Positions[key] = &store.PositionState{
+			Symbol:      pos.Symbol,
+			Side:        side,
+			FirstSeenMs: e.positionFirstSeenTime[key],
+		}
+	}
+	e.mu.RUnlock()
+
+	e.peakPnLCacheMutex.RLock()
+	for key, ps := range state.Positions {
+		ps.PeakPnLPct = e.peakPnLCache[key]
+	}
+	e.peakPnLCacheMutex.RUnlock()
+
+	e.bracketOrdersMutex.RLock()
+	for _, ps := range state.Positions {
+		if bracket, ok := e.bracketOrders[ps.Symbol]; ok {
+			ps.StopLossOrderID = bracket.StopLossOrderID
+			ps.TakeProfitOrderID = bracket.TakeProfitOrderID
+			ps.EntryPrice = bracket.EntryPrice
+			ps.StopLossPct = bracket.StopLossPct
+			ps.TakeProfitPct = bracket.TakeProfitPct
+		}
+	}
+	e.bracketOrdersMutex.RUnlock()
+
+	if err := e.stateStore.Save(state); err != nil {
+		log.Printf("[%s] Failed to save state: %v", e.name, err)
+	}
+}
+
+// resumeDelay is how long to wait before the first cycle so a restart keeps the
+// previous schedule instead of analysing again right away
+func (e *Engine) resumeDelay(interval time.Duration) time.Duration {
+	e.mu.RLock()
+	lastCycle := e.lastCycleAt
+	e.mu.RUnlock()
+
+	if lastCycle.IsZero() {
+		return 0
+	}
+	if wait := interval - time.Since(lastCycle); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// positionSide maps a signed position amount to LONG or SHORT
+func positionSide(amt float64) string {
+	if amt < 0 {
+		return "SHORT"
+	}
+	return "LONG"
+}
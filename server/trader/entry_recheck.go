@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+// defaultEntryRecheckMaxAdversePct applies when the strategy enables the
+// entry recheck without a limit
+const defaultEntryRecheckMaxAdversePct = 0.5
+
+// entryRecheckBlock describes why an entry decided at reference shouldn't be
+// placed at price, "" when it still should: the price moved against it by
+// more than maxAdversePct, or reached its stop. The stop is stopPrice when
+// set, otherwise stopPct away from reference.
+func entryRecheckBlock(isLong bool, reference, price, stopPct, stopPrice, maxAdversePct float64) string {
+	if reference <= 0 || price <= 0 {
+		return ""
+	}
+	side, adverse := "long", (reference-price)/reference*100
+	if !isLong {
+		side, adverse = "short", (price-reference)/reference*100
+	}
+
+	stop := stopPrice
+	if stop <= 0 && stopPct > 0 {
+		stop = reference * (1 - stopPct/100)
+		if !isLong {
+			stop = reference * (1 + stopPct/100)
+		}
+	}
+	if stop > 0 && ((isLong && price <= stop) || (!isLong && price >= stop)) {
+		return fmt.Sprintf("price %g reached the %s stop %g since the decision at %g", price, side, stop, reference)
+	}
+	if adverse > maxAdversePct {
+		return fmt.Sprintf("price moved %.2f%% against the %s from %g to %g (limit %g%%)", adverse, side, reference, price, maxAdversePct)
+	}
+	return ""
+}
+
+// recheckEntry skips an entry the market moved against while the AI was
+// deciding, when the strategy enables the recheck. price is the ticker
+// fetched just before the order.
+func (e *Engine) recheckEntry(symbol string, decision *ai.TradingDecision, price float64) error {
+	if e.strategy == nil || !e.strategy.Config.RiskControl.EnableEntryRecheck {
+		return nil
+	}
+	maxAdversePct := e.strategy.Config.RiskControl.EntryRecheckMaxAdversePct
+	if maxAdversePct <= 0 {
+		maxAdversePct = defaultEntryRecheckMaxAdversePct
+	}
+
+	reason := entryRecheckBlock(isLongEntry(decision.Action), decision.ReferencePrice, price,
+		decision.StopLossPct, decision.StopLoss, maxAdversePct)
+	if reason == "" {
+		return nil
+	}
+
+	log.Printf("[%s][%s] ⚠️ Entry recheck failed, skipping %s: %s", e.name, symbol, decision.Action, reason)
+	e.recordRiskEvent(store.RiskEventEntryRecheck, fmt.Sprintf("%s %s skipped: %s", symbol, decision.Action, reason))
+	return fmt.Errorf("skipped: entry recheck, %s", reason)
+}
@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+func TestEntryRecheckBlock(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		isLong    bool
+		price     float64
+		stopPct   float64
+		stopPrice float64
+		want      string
+	}{
+		{"long, price rose", true, 101, 2, 0, ""},
+		{"long, small dip", true, 99.6, 2, 0, ""},
+		{"long, dip past the limit", true, 99.4, 2, 0, "moved 0.60% against the long"},
+		{"long, at the stop", true, 98, 2, 0, "reached the long stop 98"},
+		{"long, past an absolute stop", true, 99.7, 0, 99.8, "reached the long stop 99.8"},
+		{"short, price fell", false, 99, 2, 0, ""},
+		{"short, rise past the limit", false, 100.6, 2, 0, "moved 0.60% against the short"},
+		{"short, at the stop", false, 102, 2, 0, "reached the short stop 102"},
+	} {
+		got := entryRecheckBlock(tc.isLong, 100, tc.price, tc.stopPct, tc.stopPrice, 0.5)
+		if (tc.want == "") != (got == "") || !strings.Contains(got, tc.want) {
+			t.Errorf("%s: %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := entryRecheckBlock(true, 0, 90, 2, 0, 0.5); got != "" {
+		t.Errorf("no reference price: %q, want no block", got)
+	}
+}
+
+// TestEntryRecheckSkipsMovedEntry checks an entry the price moved against
+// since the decision never reaches the exchange, and one it didn't does
+func TestEntryRecheckSkipsMovedEntry(t *testing.T) {
+	e, sim, _ := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	e.strategy.Config.RiskControl.EnableEntryRecheck = true
+	e.strategy.Config.RiskControl.EntryRecheckMaxAdversePct = 0.5
+	ctx := context.Background()
+
+	// Decided at 60000, the price fell 1% while the AI answered
+	sim.SetPrice("BTCUSDT", 59400)
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6, ReferencePrice: 60000}
+	_, err := e.executeTrade(ctx, "BTCUSDT", d, false, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "skipped: entry recheck") || !strings.Contains(err.Error(), "from 60000 to 59400") {
+		t.Fatalf("entry after the drop = %v, want an entry recheck skip with both prices", err)
+	}
+	if fills := len(sim.Fills()); fills != 0 {
+		t.Errorf("%d fills, want none", fills)
+	}
+	events, err := store.NewRiskEventStore().ListBetween(e.id, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != store.RiskEventEntryRecheck {
+		t.Errorf("risk events = %+v, want one entry_recheck", events)
+	}
+
+	// The short side of the same move goes ahead
+	d = &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6, ReferencePrice: 60000}
+	if _, err := e.executeTrade(ctx, "BTCUSDT", d, false, nil); err != nil {
+		t.Fatalf("short after the drop = %v", err)
+	}
+	if fills := len(sim.Fills()); fills == 0 {
+		t.Error("short wasn't placed")
+	}
+}
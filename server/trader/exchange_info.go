@@ -0,0 +1,166 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// ErrMainnetUnconfirmed is returned when starting a trader whose exchange
+// client points at mainnet and whose strategy runs turbo mode, without the
+// caller confirming it
+var ErrMainnetUnconfirmed = errors.New("trader would run a turbo mode strategy on mainnet, confirm it to start")
+
+// ExchangeInfo is what a trader's exchange account and API key allow, for
+// catching a wrong network or a key without futures before the first cycle
+type ExchangeInfo struct {
+	Network     string            `json:"network"` // mainnet, testnet or custom
+	BaseURL     string            `json:"base_url"`
+	KeySource   string            `json:"key_source"` // trader, global or none
+	CanTrade    bool              `json:"can_trade"`
+	CanDeposit  bool              `json:"can_deposit"`
+	CanWithdraw bool              `json:"can_withdraw"`
+	FeeTier     int               `json:"fee_tier"`
+	Balances    []ExchangeBalance `json:"balances"`              // Assets with a balance
+	Permissions *KeyPermissions   `json:"permissions,omitempty"` // Mainnet keys only
+	Problems    []string          `json:"problems"`              // Empty when the trader can trade
+}
+
+// ExchangeBalance is one asset of the futures account
+type ExchangeBalance struct {
+	Asset     string  `json:"asset"`
+	Wallet    float64 `json:"wallet"`
+	Available float64 `json:"available"`
+	Margin    float64 `json:"margin"`
+}
+
+// KeyPermissions is what the API key was created to allow
+type KeyPermissions struct {
+	Futures       bool `json:"futures"`
+	SpotAndMargin bool `json:"spot_and_margin"`
+	Withdrawals   bool `json:"withdrawals"`
+	IPRestricted  bool `json:"ip_restricted"`
+}
+
+// ExchangeNetwork names the network a trader's exchange client points at:
+// the running engine's client, or for a stopped trader the base URL Start
+// would give its client
+func (m *EngineManager) ExchangeNetwork(t *store.Trader) string {
+	if engine, err := m.runningEngine(t.ID); err == nil && engine.binance != nil {
+		return engine.binance.Network()
+	}
+	_, _, testnet := m.exchangeCredentials(t)
+	return exchange.NetworkOf(exchange.FuturesBaseURL(testnet))
+}
+
+// mainnetTurbo reports whether starting the trader with strategy would run
+// turbo mode on mainnet
+func (m *EngineManager) mainnetTurbo(t *store.Trader, strategy *store.Strategy) bool {
+	if strategy == nil || !strategy.Config.TurboMode {
+		return false
+	}
+	_, _, testnet := m.exchangeCredentials(t)
+	return exchange.NetworkOf(exchange.FuturesBaseURL(testnet)) == exchange.NetworkMainnet
+}
+
+// ExchangeInfo checks the account and API key a trader trades with. Problems
+// the key or account has are listed rather than returned; the error is for
+// an exchange that couldn't be asked.
+func (m *EngineManager) ExchangeInfo(ctx context.Context, t *store.Trader) (*ExchangeInfo, error) {
+	apiKey, secretKey, testnet := m.exchangeCredentials(t)
+	info := &ExchangeInfo{KeySource: "global", Balances: []ExchangeBalance{}, Problems: []string{}}
+	if t.Config.APIKey != "" {
+		info.KeySource = "trader"
+	}
+
+	var client *exchange.BinanceClient
+	if engine, err := m.runningEngine(t.ID); err == nil && engine.binance != nil {
+		client = engine.binance
+	} else if apiKey != "" {
+		m.cold.mu.Lock()
+		client = m.cold.client(apiKey, secretKey, testnet)
+		m.cold.mu.Unlock()
+	}
+	if client == nil {
+		info.KeySource = "none"
+		info.BaseURL = exchange.FuturesBaseURL(testnet)
+		info.Network = exchange.NetworkOf(info.BaseURL)
+		info.Problems = append(info.Problems, "No API key configured on the trader or in BINANCE_API_KEY")
+		return info, nil
+	}
+	info.Network, info.BaseURL = client.Network(), client.BaseURL()
+
+	ctx, cancel := context.WithTimeout(ctx, coldSnapshotTimeout)
+	defer cancel()
+
+	// Spot-only keys can still read their own permissions
+	if info.Network == exchange.NetworkMainnet {
+		perms, err := client.GetAPIKeyPermissions(ctx)
+		if err != nil && !keyRejected(err) {
+			return nil, fmt.Errorf("failed to get API key permissions: %w", err)
+		}
+		if perms != nil {
+			info.Permissions = &KeyPermissions{
+				Futures:       perms.EnableFutures,
+				SpotAndMargin: perms.EnableSpotAndMarginTrading,
+				Withdrawals:   perms.EnableWithdrawals,
+				IPRestricted:  perms.IPRestrict,
+			}
+			if !perms.EnableFutures {
+				info.Problems = append(info.Problems, "The API key doesn't have futures trading enabled")
+			}
+			if perms.EnableWithdrawals {
+				info.Problems = append(info.Problems, "The API key can withdraw funds; a trading key shouldn't")
+			}
+		}
+	}
+
+	account, err := client.GetAccountDetails(ctx)
+	if keyRejected(err) {
+		var apiErr *exchange.APIError
+		errors.As(err, &apiErr)
+		info.Problems = append(info.Problems, fmt.Sprintf(
+			"The futures %s rejected the API key (%s): check it is a %s key with futures enabled and this server's IP allowed",
+			info.Network, apiErr.Msg, info.Network))
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	info.CanTrade, info.CanDeposit, info.CanWithdraw = account.CanTrade, account.CanDeposit, account.CanWithdraw
+	info.FeeTier = account.FeeTier
+	var available float64
+	for _, a := range account.Assets {
+		if a.WalletBalance == 0 && a.MarginBalance == 0 {
+			continue
+		}
+		info.Balances = append(info.Balances, ExchangeBalance{Asset: a.Asset, Wallet: a.WalletBalance, Available: a.AvailableBalance, Margin: a.MarginBalance})
+		available += a.AvailableBalance
+	}
+	if !account.CanTrade {
+		info.Problems = append(info.Problems, "The futures account can't trade")
+	}
+	if available <= 0 {
+		info.Problems = append(info.Problems, "The futures account has no available margin")
+	}
+	return info, nil
+}
+
+// keyRejected reports whether Binance refused the API key itself: invalid,
+// for another network, missing the permission or not allowed from this IP
+func keyRejected(err error) bool {
+	var apiErr *exchange.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case -2014, -2015, -1022:
+		return true
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/exchange/paper"
+	"auto-trader-ahh/store"
+)
+
+func TestExchangeInfo(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	exchange.SetRequestRate(0)
+	t.Cleanup(func() { exchange.SetRequestRate(exchange.DefaultRequestsPerSec) })
+
+	const secret = "paper-secret"
+	srv := httptest.NewServer(paper.New(10000, secret))
+	t.Cleanup(srv.Close)
+
+	m := NewEngineManager(&config.Config{}, events.NewHub())
+	m.cold.newClient = func(apiKey, secretKey string, testnet bool) *exchange.BinanceClient {
+		return exchange.NewBinanceClientAt(apiKey, secretKey, srv.URL)
+	}
+	ctx := context.Background()
+
+	info, err := m.ExchangeInfo(ctx, &store.Trader{ID: "t1", Config: store.TraderConfig{APIKey: "paper-key", SecretKey: secret}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Network != exchange.NetworkCustom || info.KeySource != "trader" || !info.CanTrade || len(info.Problems) != 0 {
+		t.Errorf("info = %+v, want a tradable custom exchange", info)
+	}
+	if len(info.Balances) != 1 || info.Balances[0].Asset != "USDT" || info.Balances[0].Available != 10000 {
+		t.Errorf("balances = %+v, want 10000 USDT", info.Balances)
+	}
+
+	// A wrong secret is the key being rejected, not the exchange failing
+	info, err = m.ExchangeInfo(ctx, &store.Trader{ID: "t2", Config: store.TraderConfig{APIKey: "paper-key", SecretKey: "wrong"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Problems) != 1 || info.CanTrade {
+		t.Errorf("rejected key = %+v, want its problem listed", info)
+	}
+
+	// Without keys it says so before asking the exchange. The trader's own
+	// testnet flag goes with its own keys, so the global network applies.
+	m.cfg.BinanceTestnet = true
+	info, err = m.ExchangeInfo(ctx, &store.Trader{ID: "t3"})
+	if err != nil || info.KeySource != "none" || info.Network != exchange.NetworkTestnet || len(info.Problems) != 1 {
+		t.Errorf("no keys = %+v, %v", info, err)
+	}
+}
+
+func TestMainnetTurboNeedsConfirmation(t *testing.T) {
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	strategy := &store.Strategy{Name: "degen", Config: store.DefaultStrategyConfig()}
+	strategy.Config.TurboMode = true
+	if err := store.NewStrategyStore().Create(strategy); err != nil {
+		t.Fatal(err)
+	}
+	m := NewEngineManager(&config.Config{}, events.NewHub())
+
+	mainnet := &store.Trader{Name: "main", StrategyID: strategy.ID}
+	if err := store.NewTraderStore().Create(mainnet); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ExchangeNetwork(mainnet); got != exchange.NetworkMainnet {
+		t.Errorf("network = %s, want mainnet", got)
+	}
+	if err := m.Start(mainnet.ID, false); !errors.Is(err, ErrMainnetUnconfirmed) {
+		t.Errorf("unconfirmed start = %v, want ErrMainnetUnconfirmed", err)
+	}
+
+	testnet := &store.Trader{StrategyID: strategy.ID, Config: store.TraderConfig{APIKey: "k", SecretKey: "s", Testnet: true}}
+	if m.mainnetTurbo(testnet, strategy) || m.ExchangeNetwork(testnet) != exchange.NetworkTestnet {
+		t.Error("a testnet trader needs no confirmation")
+	}
+	strategy.Config.TurboMode = false
+	if m.mainnetTurbo(mainnet, strategy) {
+		t.Error("a strategy without turbo mode needs no confirmation")
+	}
+}
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"context"
+	"log"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+)
+
+// pendingTrade is an analyzed decision waiting for the cycle's execution pass
+type pendingTrade struct {
+	log         *TradeLog // Holds the decision and takes the outcome
+	hasPosition bool      // The position as the AI saw it
+	pos         *exchange.Position
+}
+
+// isCloseAction reports whether action closes a position
+func isCloseAction(action string) bool {
+	switch action {
+	case "CLOSE", decision.ActionCloseLong, decision.ActionCloseShort:
+		return true
+	}
+	return false
+}
+
+// runClosesFirst runs exec on the closes of pending, then afterCloses, then on
+// the stop moves and everything else, each batch in pending's order. As in a
+// backtest, a close analyzed after an open frees its margin before the open
+// needs it.
+func runClosesFirst(pending []*pendingTrade, exec func(*pendingTrade), afterCloses func()) {
+	var closes, moves, rest []*pendingTrade
+	for _, p := range pending {
+		switch action := p.log.Decision.Action; {
+		case isCloseAction(action):
+			closes = append(closes, p)
+		case action == "MOVE_STOP" || action == "MOVE_TP":
+			moves = append(moves, p)
+		default:
+			rest = append(rest, p)
+		}
+	}
+
+	for _, p := range closes {
+		exec(p)
+	}
+	if len(closes) > 0 && afterCloses != nil {
+		afterCloses()
+	}
+	for _, p := range moves {
+		exec(p)
+	}
+	for _, p := range rest {
+		exec(p)
+	}
+}
+
+// executeClosesFirst executes the cycle's pending trades, closes first, and
+// re-fetches the balance between the closes and the opens
+func (e *Engine) executeClosesFirst(ctx context.Context, pending []*pendingTrade) {
+	runClosesFirst(pending,
+		func(p *pendingTrade) { e.executePending(ctx, p) },
+		func() { e.refreshBalanceAfterCloses(ctx) })
+}
+
+// refreshBalanceAfterCloses updates the account from the exchange and logs the
+// margin the cycle's closes freed
+func (e *Engine) refreshBalanceAfterCloses(ctx context.Context) {
+	account, err := e.binance.GetAccountInfo(ctx)
+	e.noteExchangeCall(err)
+	if err != nil {
+		log.Printf("[%s] Error refreshing account info after closes: %v", e.name, err)
+		return
+	}
+
+	e.mu.Lock()
+	var before float64
+	if e.account != nil {
+		before = e.account.AvailableBalance
+	}
+	e.account = account
+	e.mu.Unlock()
+
+	log.Printf("[%s] Closes freed $%.2f margin (available $%.2f -> $%.2f)",
+		e.name, account.AvailableBalance-before, before, account.AvailableBalance)
+}
@@ -0,0 +1,134 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/ai"
+)
+
+// TestRunClosesFirst tests an open that only fits in the margin a close
+// analyzed after it frees
+func TestRunClosesFirst(t *testing.T) {
+	trade := func(symbol, action string, margin float64) *pendingTrade {
+		return &pendingTrade{log: &TradeLog{
+			Symbol:   symbol,
+			Decision: &ai.TradingDecision{Action: action, PositionSizeUSD: margin},
+		}}
+	}
+	pending := []*pendingTrade{
+		trade("ADAUSDT", "BUY", 150),
+		trade("BTCUSDT", "MOVE_STOP", 0),
+		trade("ETHUSDT", "CLOSE", 0),
+		trade("SOLUSDT", "HOLD", 0),
+	}
+
+	// 100 available, 120 held as margin by the ETH position
+	available := 100.0
+	margins := map[string]float64{"BTCUSDT": 200, "ETHUSDT": 120}
+	var order []string
+	var refreshedAt float64
+	exec := func(p *pendingTrade) {
+		order = append(order, p.log.Symbol)
+		switch p.log.Decision.Action {
+		case "CLOSE":
+			available += margins[p.log.Symbol]
+			delete(margins, p.log.Symbol)
+		case "BUY":
+			if need := p.log.Decision.PositionSizeUSD; need > available {
+				p.log.Error = fmt.Sprintf("insufficient margin: need %.0f, have %.0f", need, available)
+			} else {
+				available -= need
+			}
+		}
+	}
+	runClosesFirst(pending, exec, func() { refreshedAt = available })
+
+	if got := fmt.Sprint(order); got != "[ETHUSDT BTCUSDT ADAUSDT SOLUSDT]" {
+		t.Errorf("execution order %s", got)
+	}
+	if refreshedAt != 220 {
+		t.Errorf("balance refreshed at %.0f, want 220 after the close", refreshedAt)
+	}
+	if err := pending[0].log.Error; err != "" {
+		t.Errorf("open failed: %s", err)
+	}
+	if available != 70 {
+		t.Errorf("available = %.0f, want 70", available)
+	}
+
+	// Without a close the balance isn't re-fetched
+	refreshed := false
+	runClosesFirst(pending[:2], func(*pendingTrade) {}, func() { refreshed = true })
+	if refreshed {
+		t.Error("balance re-fetched without a close")
+	}
+}
+
+// TestExecuteClosesFirstFreesMargin runs a cycle on a paper exchange where an
+// open analyzed before a close only fits in the margin that close frees
+func TestExecuteClosesFirstFreesMargin(t *testing.T) {
+	e, sim, client := paperEngine(t, map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000})
+	e.strategy.Config.RiskControl.MinPositionSizeBTCETH = 200
+	ctx := context.Background()
+
+	// An ETH long ties up all but ~$20 of the margin at the symbol's 20x
+	if _, err := client.PlaceOrder(ctx, "ETHUSDT", "BUY", "MARKET", 66, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.refreshPositions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	account, err := client.GetAccountInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.mu.Lock()
+	e.account = account
+	eth := e.positions["ETHUSDT"]
+	e.mu.Unlock()
+	before := account.AvailableBalance
+
+	open := func() *ai.TradingDecision {
+		return &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90, Leverage: 5,
+			StopLossPct: 2, TakeProfitPct: 6, PositionSizeUSD: 500}
+	}
+	if _, err := e.executeTrade(ctx, "BTCUSDT", open(), false, nil); err == nil || !strings.Contains(err.Error(), "insufficient margin") {
+		t.Fatalf("open with $%.2f available = %v, want insufficient margin", before, err)
+	}
+
+	// The AI analyzed the open first
+	pending := []*pendingTrade{
+		{log: &TradeLog{Symbol: "BTCUSDT", Decision: open()}},
+		{log: &TradeLog{Symbol: "ETHUSDT", Decision: &ai.TradingDecision{Symbol: "ETHUSDT", Action: "close_long", Confidence: 90}},
+			hasPosition: true, pos: eth},
+	}
+	e.executeClosesFirst(ctx, pending)
+
+	for _, p := range pending {
+		if p.log.Error != "" {
+			t.Errorf("%s %s: %s", p.log.Symbol, p.log.Decision.Action, p.log.Error)
+		}
+	}
+	if held := sim.Positions(); held["ETHUSDT"] != 0 || held["BTCUSDT"] <= 0 {
+		t.Errorf("exchange holds %v, want only a BTC long", held)
+	}
+
+	// The cached balance is the one fetched between the close and the open:
+	// the close's margin back, the open's not yet taken
+	after, err := client.GetAccountInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.mu.RLock()
+	refreshed := e.account.AvailableBalance
+	e.mu.RUnlock()
+	if refreshed < 9000 {
+		t.Errorf("balance refreshed to $%.2f, want the ETH margin freed (was $%.2f)", refreshed, before)
+	}
+	if used := refreshed - after.AvailableBalance; used < 400 || used > 500 {
+		t.Errorf("the open used $%.2f of the refreshed $%.2f, want ~$490 of margin", used, refreshed)
+	}
+}
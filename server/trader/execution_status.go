@@ -0,0 +1,35 @@
+package trader
+
+import (
+	"strings"
+
+	"auto-trader-ahh/store"
+)
+
+// cooldownSkip starts the reason executeTrade skips an entry in its
+// symbol's cooldown with
+const cooldownSkip = "symbol in cooldown"
+
+// executionStatus is the store.DecisionStatus of a decision executeTrade
+// returned err for. Skips and rejections by a risk rule are blocks, other
+// errors failed orders, and a decision that places nothing isn't executed.
+func executionStatus(action string, err error) string {
+	if err == nil {
+		if placesOrder(action) {
+			return store.DecisionStatusExecuted
+		}
+		return store.DecisionStatusNoAction
+	}
+
+	msg := err.Error()
+	for _, prefix := range []string{"skipped:", "blocked:", "rejected:"} {
+		if reason, ok := strings.CutPrefix(msg, prefix); ok {
+			reason = strings.TrimSpace(reason)
+			if strings.HasPrefix(reason, cooldownSkip) {
+				return store.DecisionStatusCooldown
+			}
+			return store.DecisionStatus(store.DecisionStatusBlockedByRisk, reason)
+		}
+	}
+	return store.DecisionStatus(store.DecisionStatusOrderFailed, msg)
+}
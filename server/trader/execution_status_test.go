@@ -0,0 +1,57 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+func TestExecutionStatus(t *testing.T) {
+	for _, tc := range []struct {
+		action string
+		err    error
+		want   string
+	}{
+		{"open_long", nil, store.DecisionStatusExecuted},
+		{"MOVE_STOP", nil, store.DecisionStatusExecuted},
+		{"HOLD", nil, store.DecisionStatusNoAction},
+		{"BUY", errors.New("skipped: symbol in cooldown for 5 more minutes"), store.DecisionStatusCooldown},
+		{"BUY", errors.New("skipped: funding too high"), "blocked_by_risk:funding too high"},
+		{"CLOSE", errors.New("blocked: PnL 0.10% in noise zone"), "blocked_by_risk:PnL 0.10% in noise zone"},
+		{"open_short", errors.New("rejected: trader is not running"), "blocked_by_risk:trader is not running"},
+		{"BUY", errors.New("failed to place order: -2019 margin is insufficient"), "order_failed:failed to place order: -2019 margin is insufficient"},
+	} {
+		if got := executionStatus(tc.action, tc.err); got != tc.want {
+			t.Errorf("%s, %v: %q, want %q", tc.action, tc.err, got, tc.want)
+		}
+	}
+}
+
+// TestExecutePendingStatus checks an entry that went through is recorded as
+// executed with its order, and a repeat the engine turned down as blocked
+func TestExecutePendingStatus(t *testing.T) {
+	e, _, _ := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	ctx := context.Background()
+
+	open := func() *TradeLog {
+		d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6}
+		tradeLog := &TradeLog{Symbol: "BTCUSDT", Decision: d, Status: store.DecisionStatusNoDecision}
+		e.executePending(ctx, &pendingTrade{log: tradeLog})
+		return tradeLog
+	}
+
+	first := open()
+	if first.Status != store.DecisionStatusExecuted || first.Decision.OrderID == 0 {
+		t.Fatalf("first entry = %q, order %d; want executed with an order", first.Status, first.Decision.OrderID)
+	}
+
+	// Decided as if flat while the long is already open
+	second := open()
+	status, detail := store.ParseDecisionStatus(second.Status)
+	if status != store.DecisionStatusBlockedByRisk || detail != second.BlockReason || second.Decision.OrderID != 0 {
+		t.Errorf("second entry = %q, block %q, order %d; want blocked_by_risk with the block reason", second.Status, second.BlockReason, second.Decision.OrderID)
+	}
+}
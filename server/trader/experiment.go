@@ -0,0 +1,72 @@
+package trader
+
+import (
+	"log"
+	"math/rand/v2"
+
+	"auto-trader-ahh/store"
+)
+
+// assignVariant picks the variant for an experiment's cycle n, counting from
+// 0. Random assignment depends only on the seed and n, so a restart continues
+// the same sequence.
+func assignVariant(cfg store.ExperimentConfig, n int64) *store.PromptVariant {
+	if !cfg.Enabled || len(cfg.Variants) == 0 {
+		return nil
+	}
+	i := int(n % int64(len(cfg.Variants)))
+	if cfg.Assignment == store.ExperimentRandom {
+		i = rand.New(rand.NewPCG(uint64(cfg.Seed), uint64(n))).IntN(len(cfg.Variants))
+	}
+	return &cfg.Variants[i]
+}
+
+// startExperimentCycle assigns the cycle's prompt variant, if the strategy
+// runs an experiment, and saves the assignment before any AI call so a
+// failing cycle still counts toward its variant
+func (e *Engine) startExperimentCycle() {
+	if e.strategy == nil || !e.strategy.Config.Experiment.Enabled {
+		return
+	}
+
+	e.mu.Lock()
+	variant := assignVariant(e.strategy.Config.Experiment, e.experimentCycles)
+	e.experimentCycles++
+	e.cycleVariant = variant
+	e.mu.Unlock()
+
+	log.Printf("[%s] 🧪 Experiment: cycle runs prompt variant %s", e.name, variant.ID)
+	e.saveState()
+}
+
+// endExperimentCycle clears the cycle's variant, so positions opened
+// between cycles aren't attributed to it
+func (e *Engine) endExperimentCycle() {
+	e.mu.Lock()
+	e.cycleVariant = nil
+	e.mu.Unlock()
+}
+
+// variantID returns the running cycle's variant, empty outside an experiment
+func (e *Engine) variantID() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.cycleVariant == nil {
+		return ""
+	}
+	return e.cycleVariant.ID
+}
+
+// customPrompt returns the strategy rules for the prompt: the cycle's
+// variant during an experiment, else the strategy's custom prompt
+func (e *Engine) customPrompt() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.cycleVariant != nil {
+		return e.cycleVariant.CustomPrompt
+	}
+	if e.strategy == nil {
+		return ""
+	}
+	return e.strategy.Config.CustomPrompt
+}
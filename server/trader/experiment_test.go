@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/store"
+)
+
+func TestAssignVariant(t *testing.T) {
+	cfg := store.ExperimentConfig{Enabled: true, Variants: []store.PromptVariant{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	for n, want := range []string{"a", "b", "c", "a", "b"} {
+		if got := assignVariant(cfg, int64(n)).ID; got != want {
+			t.Errorf("alternate cycle %d = %s, want %s", n, got, want)
+		}
+	}
+
+	cfg.Assignment, cfg.Seed = store.ExperimentRandom, 7
+	counts := map[string]int{}
+	for n := int64(0); n < 300; n++ {
+		v := assignVariant(cfg, n)
+		if again := assignVariant(cfg, n); again.ID != v.ID {
+			t.Fatalf("random cycle %d assigned %s then %s, want the same for the same seed", n, v.ID, again.ID)
+		}
+		counts[v.ID]++
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if counts[id] < 60 {
+			t.Errorf("random assignment ran %s %d times in 300 cycles: %v", id, counts[id], counts)
+		}
+	}
+
+	cfg.Enabled = false
+	if assignVariant(cfg, 0) != nil {
+		t.Error("disabled experiment assigned a variant")
+	}
+}
+
+func TestExperimentCyclePrompt(t *testing.T) {
+	strategy := &store.Strategy{}
+	strategy.Config.CustomPrompt = "base rules"
+	strategy.Config.Experiment = store.ExperimentConfig{Enabled: true,
+		Variants: []store.PromptVariant{{ID: "terse", CustomPrompt: "be terse"}, {ID: "none"}}}
+	e := &Engine{strategy: strategy}
+
+	if got := e.customPrompt(); got != "base rules" {
+		t.Errorf("outside a cycle prompt = %q, want the strategy's", got)
+	}
+	e.startExperimentCycle()
+	if e.variantID() != "terse" || e.customPrompt() != "be terse" {
+		t.Errorf("first cycle ran %s with %q, want terse", e.variantID(), e.customPrompt())
+	}
+	e.endExperimentCycle()
+	e.startExperimentCycle()
+	if e.variantID() != "none" || e.customPrompt() != "" {
+		t.Errorf("second cycle ran %s with %q, want none without rules", e.variantID(), e.customPrompt())
+	}
+	e.endExperimentCycle()
+	if e.variantID() != "" || e.experimentCycles != 2 {
+		t.Errorf("after two cycles variant %q, %d cycles; want none, 2", e.variantID(), e.experimentCycles)
+	}
+}
@@ -0,0 +1,55 @@
+package trader
+
+import (
+	"log"
+
+	"auto-trader-ahh/decision"
+)
+
+// exposureLimits returns the strategy's net direction and symbol class limits
+func (e *Engine) exposureLimits() decision.ExposureLimits {
+	if e.strategy == nil {
+		return decision.ExposureLimits{}
+	}
+	return decision.ExposureLimits(e.strategy.Config.RiskControl.ExposureLimits)
+}
+
+// heldExposureLocked returns the notional of each open position. Caller must
+// hold e.mu.
+func (e *Engine) heldExposureLocked() decision.Exposure {
+	var x decision.Exposure
+	for _, h := range e.heldExposuresLocked() {
+		x = x.Add(h.Symbol, h.Side, h.Notional)
+	}
+	return x
+}
+
+// exposureBudgetLocked returns the exposure limits and what's held against
+// them for the AI context, nil without limits. Caller must hold e.mu.
+func (e *Engine) exposureBudgetLocked(equity float64) *decision.ExposureBudget {
+	limits := e.exposureLimits()
+	if !limits.Enabled() {
+		return nil
+	}
+	return &decision.ExposureBudget{Limits: limits, Exposure: e.heldExposureLocked(), Equity: equity}
+}
+
+// checkExposureLimits refuses an entry or add of notional on side that would
+// break the strategy's exposure limits. Closes earlier in the cycle have
+// already left e.positions, so the budget they freed is available.
+func (e *Engine) checkExposureLimits(symbol, side string, notional, equity float64) error {
+	limits := e.exposureLimits()
+	if !limits.Enabled() {
+		return nil
+	}
+
+	e.mu.RLock()
+	held := e.heldExposureLocked()
+	e.mu.RUnlock()
+
+	if err := limits.Check(held, symbol, side, notional, equity); err != nil {
+		log.Printf("[%s][%s] Exposure limit blocked %s entry: %v", e.name, symbol, side, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package trader
+
+import (
+	"log"
+	"time"
+
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/mcp"
+)
+
+// exchangeFaults is the exchange fault injection cfg asks for
+func exchangeFaults(cfg *config.Config) exchange.FaultConfig {
+	faults := exchange.FaultConfig{
+		Latency:            time.Duration(cfg.FaultLatencyMs) * time.Millisecond,
+		LatencyJitter:      time.Duration(cfg.FaultLatencyJitterMs) * time.Millisecond,
+		ErrorRate:          cfg.FaultErrorRate,
+		TimestampErrorRate: cfg.FaultTimestampErrorRate,
+		PositionDelay:      time.Duration(cfg.FaultPositionDelaySecs) * time.Second,
+		PartialFillRate:    cfg.FaultPartialFillRate,
+	}
+	if cfg.FaultEndpointErrorRates != "" {
+		rates, err := exchange.ParseEndpointRates(cfg.FaultEndpointErrorRates)
+		if err != nil {
+			log.Printf("⚠️ Ignoring FAULT_ENDPOINT_ERROR_RATES: %v", err)
+		} else {
+			faults.EndpointErrorRates = rates
+		}
+	}
+	return faults
+}
+
+// aiFaults is the AI fault injection cfg asks for
+func aiFaults(cfg *config.Config) mcp.FaultConfig {
+	return mcp.FaultConfig{
+		Latency:   time.Duration(cfg.FaultAILatencyMs) * time.Millisecond,
+		ErrorRate: cfg.FaultAIErrorRate,
+	}
+}
+
+// injectFaults turns on the configured fault injection for a trader's
+// exchange client. Live accounts are never faulted.
+func injectFaults(cfg *config.Config, traderName string, binance *exchange.BinanceClient) {
+	if cfg == nil || !cfg.FaultInjection {
+		return
+	}
+	if !binance.IsTestnet() {
+		log.Printf("[%s] ⚠️ FAULT_INJECTION is ignored on mainnet", traderName)
+		return
+	}
+	// Even with no exchange faults, so the engine knows to fault its AI calls
+	binance.InjectFaults(exchangeFaults(cfg))
+}
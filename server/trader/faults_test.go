@@ -0,0 +1,191 @@
+package trader
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/config"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/exchange/paper"
+	"auto-trader-ahh/store"
+)
+
+func TestExchangeFaultsFromConfig(t *testing.T) {
+	faults := exchangeFaults(&config.Config{
+		FaultLatencyMs:          50,
+		FaultErrorRate:          0.1,
+		FaultEndpointErrorRates: "POST /fapi/v1/order=0.5",
+		FaultPositionDelaySecs:  3,
+	})
+	if faults.Latency != 50*time.Millisecond || faults.PositionDelay != 3*time.Second || faults.ErrorRate != 0.1 {
+		t.Errorf("faults = %+v", faults)
+	}
+	if faults.EndpointErrorRates["POST /fapi/v1/order"] != 0.5 {
+		t.Errorf("endpoint rates = %v", faults.EndpointErrorRates)
+	}
+
+	// A bad override is dropped, the rest still applies
+	faults = exchangeFaults(&config.Config{FaultErrorRate: 0.2, FaultEndpointErrorRates: "/fapi/v1/order"})
+	if faults.EndpointErrorRates != nil || faults.ErrorRate != 0.2 {
+		t.Errorf("faults = %+v", faults)
+	}
+}
+
+// paperEngine is a running engine, with a database, trading prices on a
+// fresh paper exchange. Nothing stops it; tests drive it directly.
+func paperEngine(t *testing.T, prices map[string]float64) (*Engine, *paper.Exchange, *exchange.BinanceClient) {
+	t.Helper()
+	if err := store.Init(t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	exchange.SetRequestRate(0)
+	t.Cleanup(func() { exchange.SetRequestRate(exchange.DefaultRequestsPerSec) })
+
+	const secret = "paper-secret"
+	sim := paper.New(10000, secret)
+	for symbol, price := range prices {
+		sim.AddSymbol(symbol, price, 3)
+	}
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+	client := exchange.NewBinanceClientAt("paper-key", secret, srv.URL)
+
+	strategy := &store.Strategy{ID: "paper", Name: "paper", Config: store.DefaultStrategyConfig()}
+	rc := &strategy.Config.RiskControl
+	rc.EnableNoiseZoneProtection = false
+	rc.NoiseZoneLowerBound = -1.5
+	rc.CooldownMinsAfterClose = 0
+	rc.CooldownMinsAfterStopLoss = 0
+	rc.MaxCorrelatedExposure = 0
+
+	e := NewEngine("paper-"+t.Name(), "paper", nil, client, strategy, &store.TraderConfig{}, &config.Config{}, nil)
+	e.owners = newPositionOwners()
+	e.running = true
+	return e, sim, client
+}
+
+// TestTradingUnderExchangeFaults runs a trader against a paper exchange behind
+// injected faults and checks the engine, the position store and the exchange
+// agree at the end
+func TestTradingUnderExchangeFaults(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs trades against a simulated exchange")
+	}
+	grace := positionVisibilityGrace
+	positionVisibilityGrace = 3 * time.Second
+	t.Cleanup(func() { positionVisibilityGrace = grace })
+
+	prices := map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000}
+	e, sim, client := paperEngine(t, prices)
+	client.InjectFaults(exchange.FaultConfig{
+		Latency:            time.Millisecond,
+		LatencyJitter:      2 * time.Millisecond,
+		ErrorRate:          0.08,
+		TimestampErrorRate: 0.05,
+		PositionDelay:      1500 * time.Millisecond,
+		PartialFillRate:    0.3,
+		Seed:               42,
+	})
+
+	ctx := context.Background()
+
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 12; round++ {
+		// Drift prices, sometimes through a stop or target
+		for symbol, price := range prices {
+			prices[symbol] = price * (1 + (rng.Float64()-0.5)*0.06)
+			sim.SetPrice(symbol, prices[symbol])
+		}
+
+		if err := e.refreshPositions(ctx); err != nil {
+			t.Logf("round %d: refresh: %v", round, err)
+		}
+		for symbol := range prices {
+			e.mu.RLock()
+			pos, has := e.positions[symbol]
+			e.mu.RUnlock()
+			if has && pos.PositionAmt == 0 {
+				has = false
+			}
+
+			d := &ai.TradingDecision{Symbol: symbol, Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6}
+			switch {
+			case has && pos.PositionAmt > 0:
+				d.Action = "close_long"
+			case has:
+				d.Action = "close_short"
+			case rng.Intn(2) == 0:
+				d.Action = "open_long"
+			default:
+				d.Action = "open_short"
+			}
+			if _, err := e.executeTrade(ctx, symbol, d, has, pos); err != nil {
+				t.Logf("round %d: %s %s: %v", round, d.Action, symbol, err)
+			}
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	// Let hidden positions show and the grace for them pass, then reconcile
+	// until a fetch gets through
+	time.Sleep(positionVisibilityGrace)
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		if err = e.refreshPositions(ctx); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("final refresh: %v", err)
+	}
+
+	stats := client.FaultStats()
+	t.Logf("%s; faults: %+v", sim, stats)
+	if stats.Errors == 0 || stats.PartialFills == 0 || stats.HiddenPositions == 0 {
+		t.Errorf("harness didn't inject every fault: %+v", stats)
+	}
+
+	// No double entries: every entry fill started from flat
+	for _, f := range sim.Fills() {
+		if !f.ReduceOnly && f.Trigger == "" && f.PositionBefore != 0 {
+			t.Errorf("order %d on %s entered on top of position %.3f", f.OrderID, f.Symbol, f.PositionBefore)
+		}
+	}
+
+	// No orphans: the engine tracks every exchange position and nothing else
+	onExchange := sim.Positions()
+	e.mu.RLock()
+	for symbol, amt := range onExchange {
+		pos, ok := e.positions[symbol]
+		if !ok || math.Abs(pos.PositionAmt-amt) > 1e-9 {
+			t.Errorf("exchange holds %s %.3f, engine tracks %+v", symbol, amt, pos)
+		}
+	}
+	for symbol, pos := range e.positions {
+		if pos.PositionAmt != 0 && onExchange[symbol] == 0 {
+			t.Errorf("engine tracks %s %.3f the exchange doesn't hold", symbol, pos.PositionAmt)
+		}
+	}
+	e.mu.RUnlock()
+
+	// The position store matches the exchange
+	rows, err := store.NewPositionStore().GetOpenPositions(e.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(onExchange) {
+		t.Errorf("%d open position rows, exchange holds %d positions: %v", len(rows), len(onExchange), onExchange)
+	}
+	for _, row := range rows {
+		amt := onExchange[row.Symbol]
+		if rowSide(amt) != row.Side || math.Abs(math.Abs(amt)-row.Quantity) > 1e-9 {
+			t.Errorf("row %s %s qty %.3f, exchange holds %.3f", row.Symbol, row.Side, row.Quantity, amt)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/market"
+)
+
+// defaultFundingOverrideConfidence is the confidence an entry needs to go
+// ahead despite adverse funding when the strategy leaves it at zero
+const defaultFundingOverrideConfidence = 95
+
+// fundingOverrideConfidence is the AI confidence that lets an entry through
+// the funding filter
+func (e *Engine) fundingOverrideConfidence() int {
+	if e.strategy == nil || e.strategy.Config.RiskControl.FundingOverrideConfidence <= 0 {
+		return defaultFundingOverrideConfidence
+	}
+	return e.strategy.Config.RiskControl.FundingOverrideConfidence
+}
+
+// checkFunding skips an entry on the side paying more funding than the
+// strategy's MaxAdverseFundingRate, unless the AI is confident enough to
+// override it. Without a funding rate the entry goes ahead.
+func (e *Engine) checkFunding(ctx context.Context, symbol string, isLong bool, confidence float64) error {
+	if e.strategy == nil || e.strategy.Config.RiskControl.MaxAdverseFundingRate <= 0 {
+		return nil
+	}
+	index, err := e.binance.GetFundingRate(ctx, symbol)
+	if err != nil {
+		log.Printf("[%s][%s] Funding rate unavailable, skipping funding check: %v", e.name, symbol, err)
+		return nil
+	}
+
+	maxPct := e.strategy.Config.RiskControl.MaxAdverseFundingRate
+	minConfidence := e.fundingOverrideConfidence()
+	if err := fundingBlock(index.LastFundingRate, isLong, confidence, maxPct, minConfidence); err != nil {
+		return err
+	}
+	if market.AdverseFundingPct(index.LastFundingRate, isLong) > maxPct {
+		log.Printf("[%s][%s] Funding %.4f%% per 8h is against the entry, confidence %.0f%% overrides the %.4f%% limit",
+			e.name, symbol, index.LastFundingRate*100, confidence, maxPct)
+	}
+	return nil
+}
+
+// isLongEntry reports whether an entry action goes long
+func isLongEntry(action string) bool {
+	switch action {
+	case "BUY", decision.ActionOpenLong, decision.ActionAddLong:
+		return true
+	}
+	return false
+}
+
+// fundingBlock returns the skip error for an entry whose side pays more than
+// maxPct funding per interval at rate, nil if it doesn't or confidence
+// reaches minConfidence
+func fundingBlock(rate float64, isLong bool, confidence, maxPct float64, minConfidence int) error {
+	adverse := market.AdverseFundingPct(rate, isLong)
+	if adverse <= maxPct || confidence >= float64(minConfidence) {
+		return nil
+	}
+	side := "short"
+	if isLong {
+		side = "long"
+	}
+	return fmt.Errorf("skipped: funding %.4f%% per 8h (~%.3f%%/day) paid by a %s is above the %.4f%% limit, confidence %.0f%% is below the %d%% needed to override",
+		rate*100, adverse*market.FundingIntervalsPerDay, side, maxPct, confidence, minConfidence)
+}
@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFundingBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       float64
+		isLong     bool
+		confidence float64
+		blocked    bool
+	}{
+		{"long paying 0.1%", 0.001, true, 80, true},
+		{"long paying 0.1% with override confidence", 0.001, true, 95, false},
+		{"short receiving 0.1%", 0.001, false, 80, false},
+		{"short paying 0.08%", -0.0008, false, 80, true},
+		{"long paying the usual 0.01%", 0.0001, true, 80, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fundingBlock(tt.rate, tt.isLong, tt.confidence, 0.05, 95)
+			if (err != nil) != tt.blocked {
+				t.Fatalf("fundingBlock() = %v, blocked %v", err, tt.blocked)
+			}
+			if err != nil && !strings.HasPrefix(err.Error(), "skipped: funding") {
+				t.Errorf("skip reason %q doesn't start with \"skipped: funding\"", err)
+			}
+		})
+	}
+
+	for action, want := range map[string]bool{"BUY": true, "open_long": true, "add_to_long": true, "SELL": false, "add_to_short": false} {
+		if got := isLongEntry(action); got != want {
+			t.Errorf("isLongEntry(%q) = %v", action, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package trader
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+const (
+	incomeSyncInterval = time.Minute
+	incomeBackfill     = 7 * 24 * time.Hour // History fetched the first time, Binance's default window
+	incomePageLimit    = 1000
+	incomeMaxPages     = 10 // Per sync; the rest is picked up next time
+)
+
+// incomeCategory groups an exchange income type for the stats
+func incomeCategory(incomeType string) string {
+	switch {
+	case incomeType == exchange.IncomeRealizedPnL:
+		return store.IncomeCategoryRealizedPnL
+	case incomeType == exchange.IncomeFundingFee:
+		return store.IncomeCategoryFunding
+	case incomeType == exchange.IncomeCommission:
+		return store.IncomeCategoryCommission
+	case exchange.IsTransferIncome(incomeType):
+		return store.IncomeCategoryTransfer
+	default:
+		return store.IncomeCategoryOther
+	}
+}
+
+// incomeEntries converts exchange income history to store entries
+func incomeEntries(traderID string, incomes []exchange.Income) []store.IncomeEntry {
+	entries := make([]store.IncomeEntry, 0, len(incomes))
+	for _, in := range incomes {
+		entries = append(entries, store.IncomeEntry{
+			TraderID:   traderID,
+			TranID:     in.TranID,
+			IncomeType: in.IncomeType,
+			Category:   incomeCategory(in.IncomeType),
+			Symbol:     in.Symbol,
+			Amount:     in.Income,
+			Asset:      in.Asset,
+			Info:       in.Info,
+			Time:       time.UnixMilli(in.Time).UTC(),
+		})
+	}
+	return entries
+}
+
+// transfersAfter nets the transfers in entries made after since
+func transfersAfter(entries []store.IncomeEntry, since time.Time) float64 {
+	var net float64
+	for _, e := range entries {
+		if e.Category == store.IncomeCategoryTransfer && e.Time.After(since) {
+			net += e.Amount
+		}
+	}
+	return net
+}
+
+// syncIncome records income history added since the last sync, at most once
+// a minute, and moves the daily loss baseline by the new transfers
+func (e *Engine) syncIncome(ctx context.Context) {
+	if e.incomeStore == nil || e.binance == nil {
+		return
+	}
+	e.mu.Lock()
+	if time.Since(e.lastIncomeSync) < incomeSyncInterval {
+		e.mu.Unlock()
+		return
+	}
+	e.lastIncomeSync = time.Now()
+	e.mu.Unlock()
+
+	latest, err := e.incomeStore.LatestTime(e.id)
+	if err != nil {
+		log.Printf("[%s] Failed to read income history: %v", e.name, err)
+		return
+	}
+	since := time.Now().Add(-incomeBackfill).UnixMilli()
+	if !latest.IsZero() {
+		// Entries from the same millisecond are skipped when saved
+		since = latest.UnixMilli()
+	}
+
+	var added []store.IncomeEntry
+	for page := 0; page < incomeMaxPages; page++ {
+		incomes, err := e.binance.GetIncomeHistory(ctx, "", "", since, incomePageLimit)
+		if err != nil {
+			log.Printf("[%s] Failed to get income history: %v", e.name, err)
+			break
+		}
+		saved, err := e.incomeStore.Save(incomeEntries(e.id, incomes))
+		added = append(added, saved...)
+		if err != nil {
+			log.Printf("[%s] Failed to save income history: %v", e.name, err)
+			break
+		}
+		if len(incomes) < incomePageLimit {
+			break
+		}
+		next := incomes[len(incomes)-1].Time
+		if next <= since {
+			next = since + 1 // A full page in one millisecond
+		}
+		since = next
+	}
+
+	if e.applyTransfers(added) {
+		e.saveState()
+	}
+}
+
+// applyTransfers moves the daily loss baseline by the transfers made since it
+// was taken, so a deposit can't hide a losing day and a withdrawal doesn't
+// count as a loss. Returns whether the baseline moved.
+func (e *Engine) applyTransfers(entries []store.IncomeEntry) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	shift := transfersAfter(entries, e.lastResetTime)
+	if shift == 0 || e.initialBalance <= 0 {
+		return false
+	}
+	e.initialBalance += shift
+	log.Printf("[%s] Net transfer of $%.2f, daily loss baseline moved to $%.2f", e.name, shift, e.initialBalance)
+	return true
+}
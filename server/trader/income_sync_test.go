@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+func TestIncomeCategory(t *testing.T) {
+	for incomeType, want := range map[string]string{
+		exchange.IncomeRealizedPnL:      store.IncomeCategoryRealizedPnL,
+		exchange.IncomeFundingFee:       store.IncomeCategoryFunding,
+		exchange.IncomeCommission:       store.IncomeCategoryCommission,
+		exchange.IncomeTransfer:         store.IncomeCategoryTransfer,
+		exchange.IncomeInternalTransfer: store.IncomeCategoryTransfer,
+		"INSURANCE_CLEAR":               store.IncomeCategoryOther,
+	} {
+		if got := incomeCategory(incomeType); got != want {
+			t.Errorf("incomeCategory(%s) = %s, want %s", incomeType, got, want)
+		}
+	}
+}
+
+// TestTransfersMoveDailyBaseline tests that a withdrawal doesn't trip the
+// daily loss limit and a deposit doesn't hide a losing day
+func TestTransfersMoveDailyBaseline(t *testing.T) {
+	dayStart := time.Now().Add(-time.Hour)
+	strategy := &store.Strategy{Config: store.StrategyConfig{RiskControl: store.RiskControlConfig{MaxDailyLossPct: 5}}}
+	transfer := func(amount float64, at time.Time) []store.IncomeEntry {
+		return incomeEntries("t1", []exchange.Income{
+			{IncomeType: exchange.IncomeTransfer, Income: amount, Asset: "USDT", Time: at.UnixMilli(), TranID: 1},
+			{IncomeType: exchange.IncomeFundingFee, Symbol: "BTCUSDT", Income: -3, Time: at.UnixMilli(), TranID: 2},
+		})
+	}
+
+	// Withdrew 300 of 1000 and lost 10 trading
+	e := &Engine{name: "test", strategy: strategy, initialBalance: 1000, lastResetTime: dayStart,
+		account: &exchange.AccountInfo{TotalMarginBalance: 690}}
+	if !e.applyTransfers(transfer(-300, time.Now())) || e.initialBalance != 700 {
+		t.Fatalf("baseline after withdrawal = %v, want 700", e.initialBalance)
+	}
+	if e.checkDailyLoss() {
+		t.Error("withdrawal tripped the daily loss limit")
+	}
+
+	// Deposited 500 and lost 100 trading
+	e = &Engine{name: "test", strategy: strategy, initialBalance: 1000, lastResetTime: dayStart,
+		account: &exchange.AccountInfo{TotalMarginBalance: 1400}}
+	e.applyTransfers(transfer(500, time.Now()))
+	if !e.checkDailyLoss() {
+		t.Errorf("deposit hid a 100 loss, baseline %v", e.initialBalance)
+	}
+
+	// Transfers before the baseline was taken are already in it
+	e = &Engine{name: "test", strategy: strategy, initialBalance: 1000, lastResetTime: dayStart}
+	if e.applyTransfers(transfer(500, dayStart.Add(-time.Minute))) || e.initialBalance != 1000 {
+		t.Errorf("earlier transfer moved the baseline to %v", e.initialBalance)
+	}
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// maxDecisionStaleness is how old an entry's market data may be when its
+// order is placed, 0 for no limit
+func (e *Engine) maxDecisionStaleness() time.Duration {
+	if e.strategy == nil {
+		return 0
+	}
+	return time.Duration(e.strategy.Config.RiskControl.MaxDecisionStalenessSecs) * time.Second
+}
+
+// decisionExpired reports whether an entry waited longer than the strategy
+// allows between its market data and its order. Exits are never discarded.
+func (e *Engine) decisionExpired(decision *ai.TradingDecision) bool {
+	limit := e.maxDecisionStaleness()
+	return limit > 0 && isEntryAction(decision.Action) && !decision.ReferenceTime.IsZero() && decision.ExecutionDelay > limit
+}
+
+// checkDecisionAge times decision from its market data to the price fetched
+// just before its order, and discards an entry that took too long
+func (e *Engine) checkDecisionAge(symbol string, decision *ai.TradingDecision, price float64) error {
+	if decision.ReferenceTime.IsZero() {
+		return nil
+	}
+	decision.ExecutionDelay = time.Since(decision.ReferenceTime)
+	decision.ExecutionPrice = price
+	if !e.decisionExpired(decision) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("market data %.0fs old at the order (limit %.0fs), price %g then, %g now",
+		decision.ExecutionDelay.Seconds(), e.maxDecisionStaleness().Seconds(), decision.ReferencePrice, price)
+	log.Printf("[%s][%s] ⚠️ Decision expired, discarding %s: %s", e.name, symbol, decision.Action, reason)
+	e.recordRiskEvent(store.RiskEventDecisionExpired, fmt.Sprintf("%s %s discarded: %s", symbol, decision.Action, reason))
+	return fmt.Errorf("skipped: decision expired, %s", reason)
+}
+
+// decisionLatency is how long the trade log's decision waited for its order
+// and how the price moved meanwhile, nil when it never reached the price
+// check. The move counts as adverse against the side the decision opens, or
+// the side of the position it acts on.
+func (e *Engine) decisionLatency(tradeLog *TradeLog, hasPosition bool, pos *exchange.Position) *store.DecisionLatency {
+	d := tradeLog.Decision
+	if d == nil || d.ExecutionPrice <= 0 || d.ReferencePrice <= 0 {
+		return nil
+	}
+
+	change := (d.ExecutionPrice - d.ReferencePrice) / d.ReferencePrice * 100
+	isLong := isLongEntry(d.Action)
+	if !isEntryAction(d.Action) && hasPosition && pos != nil {
+		isLong = pos.PositionAmt > 0
+	}
+	adverse := -change
+	if !isLong {
+		adverse = change
+	}
+
+	latency := &store.DecisionLatency{
+		LatencyMs:      d.ExecutionDelay.Milliseconds(),
+		PriceChangePct: math.Abs(change),
+		AdverseMovePct: math.Max(adverse, 0),
+		Expired:        e.decisionExpired(d),
+	}
+	if tradeLog.AICall != nil {
+		latency.Model = tradeLog.AICall.Model
+	}
+	return latency
+}
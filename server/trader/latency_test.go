@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestExpiredEntryDiscarded checks an entry whose market data is older than
+// the strategy allows is discarded with its latency recorded, while an exit
+// as old still goes through
+func TestExpiredEntryDiscarded(t *testing.T) {
+	e, sim, client := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	e.strategy.Config.RiskControl.MaxDecisionStalenessSecs = 90
+	ctx := context.Background()
+
+	sim.SetPrice("BTCUSDT", 60300)
+	decidedAt := time.Now().Add(-2 * time.Minute)
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6,
+		ReferencePrice: 60000, ReferenceTime: decidedAt}
+	_, err := e.executeTrade(ctx, "BTCUSDT", d, false, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "skipped: decision expired") {
+		t.Fatalf("two minute old entry = %v, want it discarded", err)
+	}
+	if fills := len(sim.Fills()); fills != 0 {
+		t.Errorf("%d fills, want none", fills)
+	}
+
+	tradeLog := &TradeLog{Decision: d, AICall: &ai.DecisionCall{Model: "deepseek/deepseek-r1"}}
+	latency := e.decisionLatency(tradeLog, false, nil)
+	if latency == nil || latency.Model != "deepseek/deepseek-r1" || !latency.Expired || latency.LatencyMs < 120000 {
+		t.Fatalf("latency = %+v", latency)
+	}
+	if latency.PriceChangePct < 0.49 || latency.PriceChangePct > 0.51 || latency.AdverseMovePct != latency.PriceChangePct {
+		t.Errorf("moves = %.3f%% / %.3f%% adverse, want 0.5%% against the short", latency.PriceChangePct, latency.AdverseMovePct)
+	}
+
+	events, err := store.NewRiskEventStore().ListBetween(e.id, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != store.RiskEventDecisionExpired {
+		t.Errorf("risk events = %+v, want one decision_expired", events)
+	}
+
+	// An exit decided as long ago still closes; the move up was in the long's favor
+	if _, err := client.PlaceOrder(ctx, "BTCUSDT", "BUY", "MARKET", 0.01, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	pos := &exchange.Position{Symbol: "BTCUSDT", PositionAmt: 0.01, EntryPrice: 60300}
+	d = &ai.TradingDecision{Symbol: "BTCUSDT", Action: "close_long", Confidence: 90, ReferencePrice: 60000, ReferenceTime: decidedAt}
+	if _, err := e.executeTrade(ctx, "BTCUSDT", d, true, pos); err != nil {
+		t.Fatalf("old exit = %v, want it executed", err)
+	}
+	latency = e.decisionLatency(&TradeLog{Decision: d}, true, pos)
+	if latency == nil || latency.Expired || latency.AdverseMovePct != 0 {
+		t.Errorf("exit latency = %+v", latency)
+	}
+}
@@ -0,0 +1,93 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// targetLeverage returns the leverage for a new entry: what the decision asked
+// for, capped at the symbol's class limit. No request uses the limit.
+func (e *Engine) targetLeverage(symbol string, requested int) int {
+	limit := e.getLeverageLimit(symbol)
+	if requested > 0 && requested < limit {
+		return requested
+	}
+	return limit
+}
+
+// isLeverageLocked reports whether Binance refused a leverage change because
+// of an open position on the symbol
+func isLeverageLocked(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-4161") || // Leverage reduction not supported with open positions
+		strings.Contains(msg, "-2027") || // Exceeded the maximum allowable position at current leverage
+		strings.Contains(msg, "-2028") // Leverage is smaller than permitted for the open position
+}
+
+// ensureLeverage sets symbol's leverage to target before an entry and returns
+// the leverage the order will actually use. The last value set is cached to
+// skip redundant calls. When an open position keeps Binance from changing it,
+// the symbol's current leverage is returned instead.
+func (e *Engine) ensureLeverage(ctx context.Context, symbol string, target int) (int, error) {
+	e.mu.RLock()
+	current := e.symbolLeverage[symbol]
+	e.mu.RUnlock()
+	if current == target {
+		return target, nil
+	}
+
+	err := e.binance.SetLeverage(ctx, symbol, target)
+	if err == nil {
+		log.Printf("[%s][%s] Set leverage to %dx", e.name, symbol, target)
+		e.setSymbolLeverage(symbol, target)
+		return target, nil
+	}
+	if !isLeverageLocked(err) {
+		return 0, fmt.Errorf("failed to set leverage to %dx: %w", target, err)
+	}
+
+	if current <= 0 {
+		current = e.exchangeLeverage(ctx, symbol)
+	}
+	if current <= 0 {
+		return 0, fmt.Errorf("leverage locked by an open position and its current value is unknown: %w", err)
+	}
+	log.Printf("[%s][%s] ⚠️ Can't change leverage to %dx with a position open, keeping %dx", e.name, symbol, target, current)
+	e.setSymbolLeverage(symbol, current)
+	return current, nil
+}
+
+// exchangeLeverage returns the leverage of symbol's open position on the
+// exchange, 0 if there's none
+func (e *Engine) exchangeLeverage(ctx context.Context, symbol string) int {
+	positions, err := e.binance.GetPositions(ctx)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to read current leverage: %v", e.name, symbol, err)
+		return 0
+	}
+	for _, pos := range positions {
+		if pos.Symbol == symbol && pos.Leverage > 0 {
+			return pos.Leverage
+		}
+	}
+	return 0
+}
+
+// setSymbolLeverage caches the leverage last set on the exchange
+func (e *Engine) setSymbolLeverage(symbol string, leverage int) {
+	e.mu.Lock()
+	if e.symbolLeverage == nil {
+		e.symbolLeverage = make(map[string]int)
+	}
+	e.symbolLeverage[symbol] = leverage
+	e.mu.Unlock()
+}
+
+// cachedLeverage returns the leverage last set for symbol, 0 if unknown
+func (e *Engine) cachedLeverage(symbol string) int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.symbolLeverage[symbol]
+}
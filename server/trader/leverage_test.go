@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"auto-trader-ahh/store"
+)
+
+// TestTargetLeverage tests that requested leverage is capped by the symbol's class limit
+func TestTargetLeverage(t *testing.T) {
+	e := &Engine{strategy: &store.Strategy{Config: store.StrategyConfig{RiskControl: store.RiskControlConfig{
+		BTCETHMaxLeverage:  10,
+		AltcoinMaxLeverage: 20,
+	}}}}
+
+	tests := []struct {
+		name      string
+		symbol    string
+		requested int
+		want      int
+	}{
+		{"BTC below limit", "BTCUSDT", 5, 5},
+		{"BTC above limit", "BTCUSDT", 25, 10},
+		{"Alt above BTC limit", "SOLUSDT", 15, 15},
+		{"Alt above limit", "SOLUSDT", 50, 20},
+		{"No request uses the limit", "ETHUSDT", 0, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.targetLeverage(tt.symbol, tt.requested); got != tt.want {
+				t.Errorf("targetLeverage(%s, %d) = %d, want %d", tt.symbol, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIsLeverageLocked tests recognizing Binance's refusals with an open position
+func TestIsLeverageLocked(t *testing.T) {
+	locked := errors.New(`API error (status 400): {"code":-4161,"msg":"Leverage reduction is not supported in Isolated Margin Mode with open positions."}`)
+	if !isLeverageLocked(locked) {
+		t.Error("-4161 should count as locked")
+	}
+	other := errors.New(`API error (status 400): {"code":-1121,"msg":"Invalid symbol."}`)
+	if isLeverageLocked(other) {
+		t.Error("-1121 should not count as locked")
+	}
+}
+
+// TestEnsureLeverageCached tests that an already-set leverage skips the exchange
+func TestEnsureLeverageCached(t *testing.T) {
+	// No exchange client: a call would panic
+	e := &Engine{symbolLeverage: map[string]int{"BTCUSDT": 5}}
+
+	got, err := e.ensureLeverage(context.Background(), "BTCUSDT", 5)
+	if err != nil || got != 5 {
+		t.Errorf("ensureLeverage() = %d, %v, want 5 from cache", got, err)
+	}
+}
@@ -0,0 +1,281 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// defaultLimitChaseWait applies when the strategy chases limits without a wait
+const defaultLimitChaseWait = 5 * time.Second
+
+// limitChase is how an entry's post-only limit is chased toward the market
+type limitChase struct {
+	wait        time.Duration // Each limit rests this long before it is re-priced
+	maxReprices int           // Re-prices after the first limit
+	fallback    string        // store.LimitChaseFallbackMarket or store.LimitChaseFallbackCancel
+}
+
+// entryChase returns the strategy's limit chase, false when entries are
+// market orders
+func (e *Engine) entryChase() (limitChase, bool) {
+	if e.strategy == nil || e.strategy.Config.RiskControl.EntryOrderType != store.EntryOrderLimitChase {
+		return limitChase{}, false
+	}
+	rc := e.strategy.Config.RiskControl
+	chase := limitChase{
+		wait:        time.Duration(rc.LimitChaseWaitSecs) * time.Second,
+		maxReprices: rc.LimitChaseMaxReprices,
+		fallback:    rc.LimitChaseFallback,
+	}
+	if chase.wait <= 0 {
+		chase.wait = defaultLimitChaseWait
+	}
+	if chase.fallback == "" {
+		chase.fallback = store.LimitChaseFallbackMarket
+	}
+	return chase, true
+}
+
+// placeEntry opens quantity of symbol on side (BUY or SELL) with the
+// strategy's entry order type and returns the confirmed fill. refPrice is the
+// pre-trade price slippage is measured from. The decision takes the ID of the
+// last order that filled.
+func (e *Engine) placeEntry(ctx context.Context, symbol, side string, quantity, refPrice float64, decision *ai.TradingDecision) (*orderFill, error) {
+	if chase, ok := e.entryChase(); ok {
+		return e.chaseEntry(ctx, symbol, side, quantity, refPrice, chase, decision)
+	}
+
+	order, err := e.binance.PlaceOrder(ctx, symbol, side, "MARKET", quantity, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	if order != nil {
+		decision.OrderID = order.OrderID
+	}
+	fill, err := e.confirmFill(ctx, symbol, order, refPrice)
+	if err != nil {
+		return nil, err
+	}
+	fill.OrderType = store.EntryOrderMarket
+	return fill, nil
+}
+
+// chaseEntry rests a post-only limit at the touch, re-pricing it to the
+// current touch after every wait that leaves it unfilled, up to the chase's
+// re-prices. The rest is then filled at market or cancelled. An engine stop
+// cancels the chase without the fallback, keeping what filled.
+func (e *Engine) chaseEntry(ctx context.Context, symbol, side string, quantity, refPrice float64, chase limitChase, decision *ai.TradingDecision) (*orderFill, error) {
+	ctx, cancel := e.untilStopped(ctx)
+	defer cancel()
+	// Orders still have to be cancelled and read after a stop
+	bookCtx := context.WithoutCancel(ctx)
+
+	var done []*exchange.Order // Final states of the chase's limits
+	var live *exchange.Order   // The limit resting on the book
+	filled := func() float64 {
+		var qty float64
+		for _, o := range done {
+			qty += o.ExecutedQty
+		}
+		return qty
+	}
+
+	for attempt := 0; attempt <= chase.maxReprices && ctx.Err() == nil; attempt++ {
+		remaining := e.binance.RoundQuantity(symbol, quantity-filled())
+		if remaining <= 0 {
+			break
+		}
+		price, err := e.touchPrice(ctx, symbol, side)
+		if err != nil {
+			log.Printf("[%s][%s] Limit chase has no touch price: %v", e.name, symbol, err)
+			break
+		}
+
+		if live == nil {
+			live, err = e.binance.PlaceLimitOrder(ctx, symbol, side, remaining, price, true)
+		} else {
+			old, replacement, replaceErr := e.binance.ReplaceLimitOrder(ctx, symbol, live.OrderID, price, true)
+			if old != nil && orderOpen(old) {
+				log.Printf("[%s][%s] Limit chase couldn't re-price order %d: %v", e.name, symbol, live.OrderID, replaceErr)
+				live = old
+				break
+			}
+			if old != nil {
+				done = append(done, old)
+			}
+			live, err = replacement, replaceErr
+			if live == nil && err == nil {
+				break // Filled before the cancel landed
+			}
+		}
+		if errors.Is(err, exchange.ErrPostOnlyRejected) {
+			// The market moved through the touch; the next attempt prices from the new one
+			log.Printf("[%s][%s] Post-only %s at %g would have taken liquidity, re-pricing", e.name, symbol, side, price)
+			live = nil
+			continue
+		}
+		if err != nil {
+			log.Printf("[%s][%s] Limit chase order failed: %v", e.name, symbol, err)
+			live = nil
+			break
+		}
+
+		log.Printf("[%s][%s] Limit %s %.4f at %g (attempt %d of %d)", e.name, symbol, side, remaining, price, attempt+1, chase.maxReprices+1)
+		live = e.awaitLimitFill(ctx, symbol, live, chase.wait)
+		if !orderOpen(live) {
+			done = append(done, live)
+			live = nil
+		}
+	}
+
+	if live != nil {
+		if err := e.binance.CancelOrder(bookCtx, symbol, live.OrderID); err != nil {
+			log.Printf("[%s][%s] Failed to cancel chased order %d: %v", e.name, symbol, live.OrderID, err)
+		}
+		if final, err := e.binance.GetOrder(bookCtx, symbol, live.OrderID); err == nil {
+			live = final
+		}
+		done = append(done, live)
+	}
+
+	fill := &orderFill{OrderType: store.EntryOrderLimitChase}
+	var notional float64
+	for _, o := range done {
+		if o.ExecutedQty <= 0 {
+			continue
+		}
+		f := &orderFill{Price: o.AvgPrice, Qty: o.ExecutedQty}
+		if f.Price <= 0 {
+			f.Price = o.Price
+		}
+		e.readEntryFills(bookCtx, symbol, o.OrderID, f)
+		fill.Qty += f.Qty
+		fill.MakerQty += f.Qty
+		fill.Fee += f.Fee
+		notional += f.Price * f.Qty
+		decision.OrderID = o.OrderID
+	}
+
+	remaining := e.binance.RoundQuantity(symbol, quantity-fill.Qty)
+	stopped := ctx.Err() != nil
+	if remaining > 0 && !stopped && chase.fallback == store.LimitChaseFallbackMarket {
+		log.Printf("[%s][%s] Limit chase left %.4f unfilled after %d re-prices, filling it at market", e.name, symbol, remaining, chase.maxReprices)
+		market, err := e.placeMarketRest(ctx, symbol, side, remaining, refPrice)
+		if err != nil && fill.Qty == 0 {
+			return nil, fmt.Errorf("limit chase unfilled and the market fallback failed: %w", err)
+		}
+		if err != nil {
+			log.Printf("[%s][%s] Market fallback failed, keeping the %.4f the limits filled: %v", e.name, symbol, fill.Qty, err)
+		} else {
+			fill.Qty += market.fill.Qty
+			fill.Fee += market.fill.Fee
+			notional += market.fill.Price * market.fill.Qty
+			decision.OrderID = market.orderID
+		}
+	}
+
+	if fill.Qty <= 0 {
+		if stopped {
+			return nil, fmt.Errorf("limit entry cancelled unfilled: trader stopped")
+		}
+		return nil, fmt.Errorf("limit entry not filled after %d re-prices, cancelled", chase.maxReprices)
+	}
+	fill.Price = notional / fill.Qty
+	fill.SlippagePct = slippagePct(refPrice, fill.Price)
+	fill.SlippageCost = slippageCost(side == "BUY", refPrice, fill.Price, fill.Qty)
+	if remaining := e.binance.RoundQuantity(symbol, quantity-fill.Qty); remaining > 0 {
+		log.Printf("[%s][%s] ⚠️ Partial entry: %.4f of %.4f filled", e.name, symbol, fill.Qty, quantity)
+	}
+	log.Printf("[%s][%s] Limit chase filled %.4f at %g, %.0f%% as maker", e.name, symbol, fill.Qty, fill.Price, fill.MakerQty/fill.Qty*100)
+	return fill, nil
+}
+
+// marketRest is the market order that filled what a limit chase left
+type marketRest struct {
+	fill    *orderFill
+	orderID int64
+}
+
+// placeMarketRest fills quantity of symbol at market
+func (e *Engine) placeMarketRest(ctx context.Context, symbol, side string, quantity, refPrice float64) (*marketRest, error) {
+	order, err := e.binance.PlaceOrder(ctx, symbol, side, "MARKET", quantity, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	fill, err := e.confirmFill(ctx, symbol, order, refPrice)
+	if err != nil {
+		return nil, err
+	}
+	return &marketRest{fill: fill, orderID: order.OrderID}, nil
+}
+
+// touchPrice is the best price a post-only order on side can rest at: the
+// best bid for a buy, the best ask for a sell
+func (e *Engine) touchPrice(ctx context.Context, symbol, side string) (float64, error) {
+	book, err := e.binance.GetDepth(ctx, symbol, 5)
+	if err != nil {
+		return 0, err
+	}
+	levels := book.Bids
+	if side == "SELL" {
+		levels = book.Asks
+	}
+	if len(levels) == 0 || levels[0].Price <= 0 {
+		return 0, fmt.Errorf("empty order book")
+	}
+	return levels[0].Price, nil
+}
+
+// awaitLimitFill polls a resting limit until it fills, leaves the book or
+// wait passes, and returns its latest state
+func (e *Engine) awaitLimitFill(ctx context.Context, symbol string, order *exchange.Order, wait time.Duration) *exchange.Order {
+	deadline := time.Now().Add(wait)
+	for orderOpen(order) {
+		left := time.Until(deadline)
+		if left <= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return order
+		case <-time.After(min(orderFillPollDelay, left)):
+		}
+
+		latest, err := e.binance.GetOrder(ctx, symbol, order.OrderID)
+		if err != nil {
+			log.Printf("[%s][%s] Failed to poll order %d: %v", e.name, symbol, order.OrderID, err)
+			continue
+		}
+		order = latest
+	}
+	return order
+}
+
+// orderOpen reports whether order is still on the book
+func orderOpen(order *exchange.Order) bool {
+	done, _ := orderFillState(order)
+	return !done
+}
+
+// untilStopped returns a context that is also cancelled when the engine stops
+func (e *Engine) untilStopped(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	e.mu.RLock()
+	stop := e.stopCh
+	e.mu.RUnlock()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+func TestLimitChaseFillsAsMaker(t *testing.T) {
+	e, sim, _ := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	rc := &e.strategy.Config.RiskControl
+	rc.EntryOrderType = store.EntryOrderLimitChase
+	rc.LimitChaseWaitSecs, rc.LimitChaseMaxReprices, rc.LimitChaseFallback = 3, 0, store.LimitChaseFallbackCancel
+
+	// The price dips to the resting bid a moment after it's placed
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		sim.SetPrice("BTCUSDT", 59990)
+	}()
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long", ReferencePrice: 60000}
+	fill, err := e.placeEntry(context.Background(), "BTCUSDT", "BUY", 0.01, 60000, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fill.Qty != 0.01 || fill.MakerQty != fill.Qty || fill.Price >= 60000 || fill.OrderType != store.EntryOrderLimitChase {
+		t.Errorf("fill = %+v, want 0.01 at the bid as maker", fill)
+	}
+	if fills := sim.Fills(); len(fills) != 1 || !fills[0].Maker || d.OrderID != fills[0].OrderID {
+		t.Errorf("exchange fills = %+v, order %d; want the one maker fill", fills, d.OrderID)
+	}
+}
+
+func TestLimitChaseFallback(t *testing.T) {
+	e, sim, _ := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	rc := &e.strategy.Config.RiskControl
+	rc.EntryOrderType = store.EntryOrderLimitChase
+	rc.LimitChaseWaitSecs, rc.LimitChaseMaxReprices, rc.LimitChaseFallback = 1, 0, store.LimitChaseFallbackCancel
+	ctx := context.Background()
+
+	// The price never comes down to the bid
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long"}
+	if _, err := e.placeEntry(ctx, "BTCUSDT", "BUY", 0.01, 60000, d); err == nil || !strings.Contains(err.Error(), "not filled") {
+		t.Fatalf("unfilled chase with the cancel fallback = %v, want not filled", err)
+	}
+	if fills := len(sim.Fills()); fills != 0 {
+		t.Fatalf("%d fills, want none", fills)
+	}
+
+	// With the market fallback the rest is taken
+	rc.LimitChaseMaxReprices, rc.LimitChaseFallback = 1, store.LimitChaseFallbackMarket
+	fill, err := e.placeEntry(ctx, "BTCUSDT", "SELL", 0.01, 60000, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fill.Qty != 0.01 || fill.MakerQty != 0 || fill.OrderType != store.EntryOrderLimitChase {
+		t.Errorf("fill = %+v, want 0.01 taken at market", fill)
+	}
+	if fills := sim.Fills(); len(fills) != 1 || fills[0].Maker || fills[0].Side != "SELL" {
+		t.Errorf("exchange fills = %+v, want one market sell", fills)
+	}
+}
+
+// TestLimitChaseStopsWithEngine checks stopping the engine ends a chase
+// without the market fallback and leaves no order on the book
+func TestLimitChaseStopsWithEngine(t *testing.T) {
+	e, sim, client := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	rc := &e.strategy.Config.RiskControl
+	rc.EntryOrderType = store.EntryOrderLimitChase
+	rc.LimitChaseWaitSecs, rc.LimitChaseMaxReprices, rc.LimitChaseFallback = 5, 3, store.LimitChaseFallbackMarket
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		e.Stop()
+	}()
+	start := time.Now()
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long"}
+	_, err := e.placeEntry(context.Background(), "BTCUSDT", "BUY", 0.01, 60000, d)
+	if err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Fatalf("chase during stop = %v, want it cancelled", err)
+	}
+	if took := time.Since(start); took > 3*time.Second {
+		t.Errorf("chase took %v to notice the stop", took)
+	}
+	if fills := len(sim.Fills()); fills != 0 {
+		t.Errorf("%d fills, want none", fills)
+	}
+	if open, err := client.GetOpenOrders(context.Background(), "BTCUSDT"); err != nil || len(open) != 0 {
+		t.Errorf("open orders = %+v, %v; want none", open, err)
+	}
+}
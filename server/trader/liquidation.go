@@ -0,0 +1,135 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+)
+
+const (
+	leverageBracketRefresh = 24 * time.Hour
+	liquidationATRMultiple = 2.0 // Warn when liquidation is closer than this many ATRs
+)
+
+// leverageBrackets is a symbol's cached maintenance margin tiers
+type leverageBrackets struct {
+	brackets  []exchange.LeverageBracket
+	fetchedAt time.Time
+}
+
+// refreshLeverageBrackets fetches symbol's maintenance margin tiers once a
+// day. Failures keep the old tiers, or the defaults without any, until the
+// next refresh.
+func (e *Engine) refreshLeverageBrackets(ctx context.Context, symbol string) {
+	if e.binance == nil {
+		return
+	}
+	now := time.Now()
+	e.liquidationMu.Lock()
+	cached := e.leverageBrackets[symbol]
+	e.liquidationMu.Unlock()
+	if cached != nil && now.Sub(cached.fetchedAt) < leverageBracketRefresh {
+		return
+	}
+
+	brackets, err := e.binance.GetLeverageBrackets(ctx, symbol)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to fetch leverage brackets: %v", e.name, symbol, err)
+		if cached != nil {
+			brackets = cached.brackets
+		}
+	}
+
+	e.liquidationMu.Lock()
+	if e.leverageBrackets == nil {
+		e.leverageBrackets = make(map[string]*leverageBrackets)
+	}
+	e.leverageBrackets[symbol] = &leverageBrackets{brackets: brackets, fetchedAt: now}
+	e.liquidationMu.Unlock()
+}
+
+// liquidationPrice returns the exchange's liquidation price for pos, or one
+// from the symbol's maintenance margin tiers with isolated margin at the
+// position's leverage when the exchange reports none
+func (e *Engine) liquidationPrice(pos *exchange.Position) float64 {
+	if pos.LiquidationPrice > 0 {
+		return pos.LiquidationPrice
+	}
+
+	e.liquidationMu.Lock()
+	var brackets []exchange.LeverageBracket
+	if cached := e.leverageBrackets[pos.Symbol]; cached != nil {
+		brackets = cached.brackets
+	}
+	e.liquidationMu.Unlock()
+	if len(brackets) == 0 {
+		brackets = exchange.DefaultLeverageBrackets(pos.Symbol)
+	}
+
+	leverage := pos.Leverage
+	if leverage <= 0 {
+		leverage = 1
+	}
+	qty := math.Abs(pos.PositionAmt)
+	margin := qty * pos.EntryPrice / float64(leverage)
+	return exchange.LiquidationPrice(pos.PositionAmt > 0, pos.EntryPrice, qty, margin, brackets)
+}
+
+// liquidationTooClose reports whether liq is within liquidationATRMultiple
+// ATRs of mark
+func liquidationTooClose(mark, liq, atr float64) bool {
+	if liq <= 0 || atr <= 0 {
+		return false
+	}
+	return math.Abs(mark-liq) < liquidationATRMultiple*atr
+}
+
+// checkLiquidationDistance warns once per position when its liquidation
+// price comes within a normal move of the mark, and again if it drifts back
+// out and in
+func (e *Engine) checkLiquidationDistance(pos *exchange.Position, liq, atr float64) {
+	key := getPositionKey(pos.Symbol, rowSide(pos.PositionAmt))
+
+	e.liquidationMu.Lock()
+	if !liquidationTooClose(pos.MarkPrice, liq, atr) {
+		delete(e.liquidationWarned, key)
+		e.liquidationMu.Unlock()
+		return
+	}
+	if e.liquidationWarned[key] {
+		e.liquidationMu.Unlock()
+		return
+	}
+	if e.liquidationWarned == nil {
+		e.liquidationWarned = make(map[string]bool)
+	}
+	e.liquidationWarned[key] = true
+	e.liquidationMu.Unlock()
+
+	msg := fmt.Sprintf("%s liquidation at $%.4f is within %.0f ATR of mark $%.4f (%.2f%% away, ATR $%.4f)",
+		rowSide(pos.PositionAmt), liq, liquidationATRMultiple, pos.MarkPrice,
+		exchange.LiquidationDistancePct(pos.PositionAmt > 0, pos.MarkPrice, liq), atr)
+	log.Printf("[%s][%s] ⚠️ %s", e.name, pos.Symbol, msg)
+	if e.notifier != nil {
+		e.notifier.Broadcast(events.Event{
+			Type:      events.TypeError,
+			TraderID:  e.id,
+			Symbol:    pos.Symbol,
+			Message:   msg,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// clearLiquidationWarnings forgets the warnings of symbol's closed positions
+func (e *Engine) clearLiquidationWarnings(symbol string) {
+	e.liquidationMu.Lock()
+	delete(e.liquidationWarned, getPositionKey(symbol, "long"))
+	delete(e.liquidationWarned, getPositionKey(symbol, "short"))
+	e.liquidationMu.Unlock()
+}
@@ -0,0 +1,80 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+)
+
+type recordingNotifier struct {
+	events    []events.Event
+	published []events.TopicEvent
+}
+
+func (n *recordingNotifier) Broadcast(evt events.Event) { n.events = append(n.events, evt) }
+
+func (n *recordingNotifier) Publish(topic string, evt events.Event) {
+	n.published = append(n.published, events.TopicEvent{Topic: topic, Event: evt})
+}
+
+func TestLiquidationTooClose(t *testing.T) {
+	tests := []struct {
+		mark, liq, atr float64
+		want           bool
+	}{
+		{100, 95, 3, true},
+		{100, 105, 3, true}, // Short side
+		{100, 90, 3, false}, // More than 2 ATR away
+		{100, 0, 3, false},  // No liquidation price
+		{100, 99, 0, false}, // No ATR
+	}
+	for _, tt := range tests {
+		if got := liquidationTooClose(tt.mark, tt.liq, tt.atr); got != tt.want {
+			t.Errorf("liquidationTooClose(%v, %v, %v) = %v", tt.mark, tt.liq, tt.atr, got)
+		}
+	}
+}
+
+func TestCheckLiquidationDistanceWarnsOnce(t *testing.T) {
+	n := &recordingNotifier{}
+	e := &Engine{name: "t", notifier: n}
+	pos := &exchange.Position{Symbol: "SOLUSDT", PositionAmt: 10, EntryPrice: 100, MarkPrice: 96, Leverage: 20}
+
+	e.checkLiquidationDistance(pos, 95, 1)
+	e.checkLiquidationDistance(pos, 95, 1)
+	if len(n.events) != 1 {
+		t.Fatalf("warned %d times while too close, want once", len(n.events))
+	}
+
+	// Out of range and back in warns again
+	pos.MarkPrice = 100
+	e.checkLiquidationDistance(pos, 95, 1)
+	pos.MarkPrice = 96
+	e.checkLiquidationDistance(pos, 95, 1)
+	if len(n.events) != 2 {
+		t.Fatalf("warned %d times, want a second warning after recovering", len(n.events))
+	}
+
+	// A new position after a close starts fresh
+	e.clearLiquidationWarnings("SOLUSDT")
+	e.checkLiquidationDistance(pos, 95, 1)
+	if len(n.events) != 3 {
+		t.Fatalf("warned %d times, want one for the new position", len(n.events))
+	}
+}
+
+func TestEngineLiquidationPrice(t *testing.T) {
+	e := &Engine{}
+	pos := &exchange.Position{Symbol: "BTCUSDT", PositionAmt: 1, EntryPrice: 40_000, Leverage: 10, LiquidationPrice: 36_500}
+	if got := e.liquidationPrice(pos); got != 36_500 {
+		t.Errorf("liquidationPrice = %v, want the exchange's 36500", got)
+	}
+
+	// Without one from the exchange, isolated margin at the position's leverage
+	pos.LiquidationPrice = 0
+	want := exchange.LiquidationPrice(true, 40_000, 1, 4_000, exchange.DefaultLeverageBrackets("BTCUSDT"))
+	if got := e.liquidationPrice(pos); got != want || got <= 36_000 {
+		t.Errorf("liquidationPrice = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// publish sends evt to the subscribers of one of the trader's streams
+func (e *Engine) publish(stream string, evt events.Event) {
+	if e.notifier == nil {
+		return
+	}
+	evt.TraderID = e.id
+	e.notifier.Publish(events.TraderTopic(e.id, stream), evt)
+}
+
+// publishPositions pushes the open positions at their latest marks, in the
+// shape of GET /api/positions
+func (e *Engine) publishPositions() {
+	e.mu.RLock()
+	positions := make([]*exchange.Position, 0, len(e.positions))
+	for _, pos := range e.positions {
+		positions = append(positions, pos)
+	}
+	e.mu.RUnlock()
+
+	views := make([]map[string]interface{}, 0, len(positions))
+	for _, pos := range positions {
+		views = append(views, positionView(e.withStreamMark(pos)))
+	}
+	e.publish(events.StreamPositions, events.Event{
+		Type:    events.TypePositions,
+		Message: "positions",
+		Data:    map[string]interface{}{"positions": views},
+	})
+}
+
+// saveEquitySnapshot stores the account's equity for the history charts and
+// pushes it to subscribers
+func (e *Engine) saveEquitySnapshot(account *exchange.AccountInfo) {
+	snapshot := &store.EquitySnapshot{
+		TraderID:      e.id,
+		Timestamp:     time.Now(),
+		TotalEquity:   account.TotalMarginBalance,
+		Balance:       account.TotalWalletBalance,
+		UnrealizedPnL: account.TotalUnrealizedProfit,
+	}
+	e.equityStore.Save(snapshot)
+	e.publish(events.StreamEquity, events.Event{
+		Type:    events.TypeEquity,
+		Message: "equity snapshot",
+		Data:    snapshot,
+	})
+}
+
+// publishLifecycle tells subscribers the engine started or stopped
+func (e *Engine) publishLifecycle(state string) {
+	e.publish(events.StreamLifecycle, events.Event{
+		Type:    events.TypeLifecycle,
+		Message: e.name + " " + state,
+		Data:    map[string]string{"state": state},
+	})
+}
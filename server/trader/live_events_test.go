@@ -0,0 +1,33 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+)
+
+func TestPublishPositionsAndLifecycle(t *testing.T) {
+	n := &recordingNotifier{}
+	e := &Engine{id: "t1", name: "t", notifier: n, positions: map[string]*exchange.Position{
+		"BTCUSDT": {Symbol: "BTCUSDT", PositionAmt: 0.1, EntryPrice: 100000, MarkPrice: 101000, UnrealizedProfit: 100, Leverage: 10},
+	}}
+
+	e.publishPositions()
+	e.publishLifecycle("stopped")
+
+	if len(n.published) != 2 {
+		t.Fatalf("published %d events, want 2", len(n.published))
+	}
+	pos := n.published[0]
+	if pos.Topic != "trader:t1:positions" || pos.Type != events.TypePositions || pos.TraderID != "t1" {
+		t.Errorf("positions event = %+v", pos)
+	}
+	views := pos.Data.(map[string]interface{})["positions"].([]map[string]interface{})
+	if len(views) != 1 || views[0]["side"] != "LONG" || views[0]["pnl_percent"] != 1.0 {
+		t.Errorf("positions = %v, want the REST shape", views)
+	}
+	if lc := n.published[1]; lc.Topic != "trader:t1:lifecycle" || lc.Data.(map[string]string)["state"] != "stopped" {
+		t.Errorf("lifecycle event = %+v", lc)
+	}
+}
@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"time"
+)
+
+// LoopHealth tells whether a trader's decision loop is still cycling
+type LoopHealth struct {
+	LastCycleAt  *time.Time `json:"last_cycle_at"` // nil before the first cycle
+	IntervalSecs int        `json:"interval_secs"`
+	Stale        bool       `json:"stale"` // No cycle within twice the interval
+}
+
+// LoopHealth reports the decision loop's liveness. Cycles skipped by a pause
+// or the schedule still count, only a loop that stopped ticking is stale.
+func (e *Engine) LoopHealth(now time.Time) LoopHealth {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	interval := e.getTradingInterval()
+	h := LoopHealth{IntervalSecs: int(interval.Seconds())}
+
+	since := e.startTime
+	if !e.lastCycleAt.IsZero() {
+		last := e.lastCycleAt
+		h.LastCycleAt = &last
+		if last.After(since) {
+			since = last
+		}
+	}
+	h.Stale = now.Sub(since) > 2*interval
+	return h
+}
+
+// LoopHealth returns the decision loop liveness of every running engine
+func (m *EngineManager) LoopHealth() map[string]LoopHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	health := make(map[string]LoopHealth, len(m.engines))
+	for id, engine := range m.engines {
+		if engine.IsRunning() {
+			health[id] = engine.LoopHealth(now)
+		}
+	}
+	return health
+}
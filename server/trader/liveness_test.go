@@ -0,0 +1,30 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// TestLoopHealth tests that a loop is stale only after two missed intervals
+func TestLoopHealth(t *testing.T) {
+	now := time.Now()
+	strategy := &store.Strategy{Config: store.StrategyConfig{TradingInterval: 5}}
+
+	fresh := &Engine{strategy: strategy, startTime: now.Add(-time.Hour), lastCycleAt: now.Add(-7 * time.Minute)}
+	if h := fresh.LoopHealth(now); h.Stale || h.IntervalSecs != 300 || h.LastCycleAt == nil {
+		t.Errorf("cycle 7m ago on a 5m interval = %+v, want live", h)
+	}
+
+	stale := &Engine{strategy: strategy, startTime: now.Add(-time.Hour), lastCycleAt: now.Add(-11 * time.Minute)}
+	if h := stale.LoopHealth(now); !h.Stale {
+		t.Errorf("cycle 11m ago on a 5m interval = %+v, want stale", h)
+	}
+
+	// A restored last cycle from before the engine started doesn't count against it
+	restarted := &Engine{strategy: strategy, startTime: now.Add(-time.Minute), lastCycleAt: now.Add(-time.Hour)}
+	if h := restarted.LoopHealth(now); h.Stale {
+		t.Errorf("just started engine = %+v, want live", h)
+	}
+}
@@ -2,6 +2,7 @@ package trader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -10,16 +11,25 @@ import (
 	"auto-trader-ahh/config"
 	"auto-trader-ahh/events"
 	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/market"
 	"auto-trader-ahh/store"
 )
 
+// ErrAlreadyRunning is returned when starting a trader that is running
+var ErrAlreadyRunning = errors.New("trader is already running")
+
+// ErrTraderArchived is returned when starting an archived trader
+var ErrTraderArchived = errors.New("trader is archived, unarchive it before starting")
+
 // EngineManager manages multiple trading engine instances
 type EngineManager struct {
 	cfg           *config.Config
 	engines       map[string]*Engine
 	traderStore   *store.TraderStore
 	strategyStore *store.StrategyStore
+	settingsStore *store.SettingsStore
 	hub           *events.Hub
+	cold          *coldSnapshots // Exchange snapshots of stopped traders
 	mu            sync.RWMutex
 }
 
@@ -29,18 +39,25 @@ func NewEngineManager(cfg *config.Config, hub *events.Hub) *EngineManager {
 		engines:       make(map[string]*Engine),
 		traderStore:   store.NewTraderStore(),
 		strategyStore: store.NewStrategyStore(),
+		settingsStore: store.NewSettingsStore(),
 		hub:           hub,
+		cold:          newColdSnapshots(),
 	}
 }
 
-// Start starts a trader by ID
-func (m *EngineManager) Start(traderID string) error {
+// Start starts a trader by ID. A trader that would run a turbo mode strategy
+// on mainnet needs confirmMainnet, or ErrMainnetUnconfirmed is returned.
+func (m *EngineManager) Start(traderID string, confirmMainnet bool) error {
+	if m.IsHalted() {
+		return ErrTradingHalted
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check if already running
 	if engine, exists := m.engines[traderID]; exists && engine.IsRunning() {
-		return fmt.Errorf("trader %s is already running", traderID)
+		return ErrAlreadyRunning
 	}
 
 	// Load trader from database
@@ -48,18 +65,15 @@ func (m *EngineManager) Start(traderID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load trader: %w", err)
 	}
+	if trader.Archived {
+		return ErrTraderArchived
+	}
 
-	// Load strategy
-	var strategy *store.Strategy
-	if trader.StrategyID != "" {
-		strategy, err = m.strategyStore.Get(trader.StrategyID)
-		if err != nil {
-			log.Printf("Warning: failed to load strategy %s, using default", trader.StrategyID)
-			strategy, _ = m.strategyStore.GetActive()
-		}
-	} else {
-		strategy, _ = m.strategyStore.GetActive()
+	strategy := m.traderStrategy(trader)
+	if !confirmMainnet && m.mainnetTurbo(trader, strategy) {
+		return ErrMainnetUnconfirmed
 	}
+	m.recordStrategyVersion(traderID, strategy)
 
 	// Create AI client (using trader-specific settings or fallback to global)
 	apiKey := trader.Config.OpenRouterAPIKey
@@ -76,18 +90,13 @@ func (m *EngineManager) Start(traderID string) error {
 	aiClient := ai.NewClient(apiKey, model)
 
 	// Create exchange client
-	binanceKey := trader.Config.APIKey
-	binanceSecret := trader.Config.SecretKey
-	testnet := trader.Config.Testnet
-	if binanceKey == "" {
-		binanceKey = m.cfg.BinanceAPIKey
-		binanceSecret = m.cfg.BinanceSecretKey
-		testnet = m.cfg.BinanceTestnet
-	}
+	binanceKey, binanceSecret, testnet := m.exchangeCredentials(trader)
 	binanceClient := exchange.NewBinanceClient(binanceKey, binanceSecret, testnet)
+	injectFaults(m.cfg, trader.Name, binanceClient)
 
 	// Create engine
 	engine := NewEngine(traderID, trader.Name, aiClient, binanceClient, strategy, &trader.Config, m.cfg, m.hub)
+	m.warnSharedAccount(engine)
 
 	// Start engine
 	ctx := context.Background()
@@ -100,28 +109,110 @@ func (m *EngineManager) Start(traderID string) error {
 	return nil
 }
 
-// Stop stops a trader by ID
-func (m *EngineManager) Stop(traderID string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// traderStrategy loads a trader's strategy, the active one when it has none
+// or it can't be loaded
+func (m *EngineManager) traderStrategy(trader *store.Trader) *store.Strategy {
+	if trader.StrategyID != "" {
+		strategy, err := m.strategyStore.Get(trader.StrategyID)
+		if err == nil {
+			return strategy
+		}
+		log.Printf("Warning: failed to load strategy %s, using default", trader.StrategyID)
+	}
+	strategy, _ := m.strategyStore.GetActive()
+	return strategy
+}
 
-	if engine, exists := m.engines[traderID]; exists {
-		engine.Stop()
-		delete(m.engines, traderID)
-		log.Printf("Stopped trader: %s", traderID)
+// recordStrategyVersion notes on the trader the version of the strategy it
+// runs with, none for the built-in default, and marks a change from the
+// version it ran before on its equity curve
+func (m *EngineManager) recordStrategyVersion(traderID string, strategy *store.Strategy) {
+	version := 0
+	if strategy != nil {
+		version = strategy.Version
 	}
+	previous := 0
+	if t, err := m.traderStore.Get(traderID); err == nil {
+		previous = t.StrategyVersion
+	}
+	if err := m.traderStore.SetStrategyVersion(traderID, version); err != nil {
+		log.Printf("Failed to record strategy version of trader %s: %v", traderID, err)
+	}
+	if previous == 0 || previous == version {
+		return
+	}
+
+	text := fmt.Sprintf("built-in default strategy, was v%d", previous)
+	if strategy != nil {
+		text = fmt.Sprintf("strategy %s v%d, was v%d", strategy.Name, version, previous)
+	}
+	if err := store.NewAnnotationStore().Create(&store.Annotation{TraderID: traderID, Type: store.AnnotationStrategyVersion, Text: text}); err != nil {
+		log.Printf("Failed to annotate strategy version of trader %s: %v", traderID, err)
+	}
+}
+
+// exchangeCredentials returns the Binance keys a trader trades with, its own
+// or the global ones
+func (m *EngineManager) exchangeCredentials(trader *store.Trader) (apiKey, secretKey string, testnet bool) {
+	if trader.Config.APIKey != "" {
+		return trader.Config.APIKey, trader.Config.SecretKey, trader.Config.Testnet
+	}
+	return m.cfg.BinanceAPIKey, m.cfg.BinanceSecretKey, m.cfg.BinanceTestnet
 }
 
-// StopAll stops all running traders
-func (m *EngineManager) StopAll() {
+// Stop stops a trader by ID under its shutdown policy and reports what was
+// done. Returns nil if it wasn't running.
+func (m *EngineManager) Stop(traderID string) *ShutdownReport {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	engine, exists := m.engines[traderID]
+	delete(m.engines, traderID)
+	m.mu.Unlock()
 
-	for id, engine := range m.engines {
-		engine.Stop()
-		log.Printf("Stopped trader: %s", id)
+	if !exists {
+		return nil
 	}
+	// Outside the lock, the policy can take up to shutdownTimeout
+	report := engine.shutdown(context.Background(), m.shutdownPolicy(traderID, engine))
+	logShutdown(traderID, report)
+	return report
+}
+
+// StopAll stops all running traders under their shutdown policies
+func (m *EngineManager) StopAll() map[string]*ShutdownReport {
+	m.mu.Lock()
+	engines := m.engines
 	m.engines = make(map[string]*Engine)
+	m.mu.Unlock()
+
+	return m.shutdownAll(engines)
+}
+
+// RestoreRunning restarts the traders whose stored status is "running", e.g.
+// after a crash or redeploy. A trader that fails to start is marked "error".
+func (m *EngineManager) RestoreRunning() {
+	if m.IsHalted() {
+		log.Printf("Trading is halted, not restarting traders until resumed")
+		return
+	}
+
+	traders, err := m.traderStore.List()
+	if err != nil {
+		log.Printf("Failed to list traders for auto-restart: %v", err)
+		return
+	}
+
+	for _, t := range traders {
+		if t.Status != "running" {
+			continue
+		}
+		// It was running before the restart, so mainnet was already confirmed
+		if err := m.Start(t.ID, true); err != nil {
+			log.Printf("Failed to auto-restart trader %s (%s): %v", t.Name, t.ID, err)
+			m.traderStore.UpdateStatus(t.ID, "error")
+			continue
+		}
+		log.Printf("Auto-restarted trader: %s (%s)", t.Name, t.ID)
+	}
 }
 
 // IsRunning checks if a trader is running
@@ -135,46 +226,181 @@ func (m *EngineManager) IsRunning(traderID string) bool {
 	return false
 }
 
-// GetStatus returns the status of a trader
-func (m *EngineManager) GetStatus(traderID string) map[string]interface{} {
+// engine returns a trader's engine, nil when it isn't started
+func (m *EngineManager) engine(traderID string) *Engine {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	return m.engines[traderID]
+}
 
-	if engine, exists := m.engines[traderID]; exists {
-		return engine.GetStatus()
+// GetStatus returns the status of a trader. "live" tells whether it came from
+// its engine or, when stopped, from a fetch of its exchange account.
+func (m *EngineManager) GetStatus(traderID string) map[string]interface{} {
+	if engine := m.engine(traderID); engine != nil {
+		status := engine.GetStatus()
+		status["live"] = true
+		return status
 	}
+	return m.coldStatus(traderID)
+}
 
-	return map[string]interface{}{
-		"running":   false,
-		"trader_id": traderID,
-		"message":   "Trader not running",
+// GetAccount returns account info for a trader, fetched from its exchange
+// account when it isn't running
+func (m *EngineManager) GetAccount(traderID string) map[string]interface{} {
+	if engine := m.engine(traderID); engine != nil {
+		account := engine.GetAccount()
+		account["live"] = true
+		return account
 	}
+	return m.coldAccount(traderID)
 }
 
-// GetAccount returns account info for a trader
-func (m *EngineManager) GetAccount(traderID string) map[string]interface{} {
+// GetPositions returns positions for a trader and whether they came from its
+// running engine. A stopped trader's are fetched from its exchange account and
+// merged with its open position rows; the rows are still returned when the
+// fetch fails.
+func (m *EngineManager) GetPositions(traderID string) ([]map[string]interface{}, bool, error) {
+	if engine := m.engine(traderID); engine != nil {
+		return engine.GetPositions(), true, nil
+	}
+	positions, err := m.coldPositions(traderID)
+	return positions, false, err
+}
+
+// GetOverview returns a running trader's dashboard state, or nil when it isn't running
+func (m *EngineManager) GetOverview(traderID string) *Overview {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if engine, exists := m.engines[traderID]; exists {
-		return engine.GetAccount()
+	if engine, exists := m.engines[traderID]; exists && engine.IsRunning() {
+		return engine.Overview()
+	}
+	return nil
+}
+
+// AcknowledgeCircuitBreaker clears a trader's tripped circuit breaker, in its
+// engine when running and in its saved state otherwise
+func (m *EngineManager) AcknowledgeCircuitBreaker(traderID string) (*store.CircuitBreakerTrip, error) {
+	if engine, err := m.runningEngine(traderID); err == nil {
+		return engine.AcknowledgeCircuitBreaker()
+	}
+
+	trip, err := acknowledgeSavedCircuitBreaker(store.NewEngineStateStore(), traderID)
+	if err != nil {
+		return nil, err
+	}
+	store.NewRiskEventStore().Create(&store.RiskEvent{
+		TraderID: traderID,
+		Type:     store.RiskEventCircuitBreakerAck,
+		Message:  "circuit breaker acknowledged while stopped: " + trip.Message,
+	})
+	return trip, nil
+}
+
+// SavedCircuitBreaker returns a stopped trader's circuit breaker from its saved state
+func (m *EngineManager) SavedCircuitBreaker(t *store.Trader) (OverviewCircuitBreaker, error) {
+	state, err := store.NewEngineStateStore().Get(t.ID)
+	if err != nil {
+		return OverviewCircuitBreaker{}, err
+	}
+	if state == nil {
+		state = &store.EngineState{}
 	}
 
-	return map[string]interface{}{
-		"error": "Trader not running",
+	var rc store.RiskControlConfig
+	if strategy, err := m.strategyStore.Get(t.StrategyID); err == nil && strategy != nil {
+		rc = strategy.Config.RiskControl
+	}
+	streak, err := store.NewPositionStore().LosingStreak(t.ID, state.BreakerResetAt)
+	if err != nil {
+		return OverviewCircuitBreaker{}, err
 	}
+	return newOverviewCircuitBreaker(state.CircuitBreaker, state.PeakEquity, 0, streak, rc), nil
 }
 
-// GetPositions returns positions for a trader
-func (m *EngineManager) GetPositions(traderID string) []map[string]interface{} {
+// ExecuteDecisions hands externally produced decisions to a running trader
+func (m *EngineManager) ExecuteDecisions(ctx context.Context, traderID string, decisions []ExternalDecision) ([]ExecutionResult, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	engine, exists := m.engines[traderID]
+	m.mu.RUnlock()
 
-	if engine, exists := m.engines[traderID]; exists {
-		return engine.GetPositions()
+	if !exists || !engine.IsRunning() {
+		return nil, fmt.Errorf("trader %s is not running", traderID)
+	}
+
+	return engine.ExecuteDecisions(ctx, decisions), nil
+}
+
+// RefreshSmartFind runs Smart Find now on a running trader
+func (m *EngineManager) RefreshSmartFind(ctx context.Context, traderID string) (*store.SmartFindRun, error) {
+	m.mu.RLock()
+	engine, exists := m.engines[traderID]
+	m.mu.RUnlock()
+
+	if !exists || !engine.IsRunning() {
+		return nil, fmt.Errorf("trader %s is not running", traderID)
+	}
+
+	return engine.RefreshSmartFind(ctx)
+}
+
+// OpenOrders returns a running trader's open orders grouped by symbol
+func (m *EngineManager) OpenOrders(ctx context.Context, traderID string) (map[string][]OpenOrder, error) {
+	engine, err := m.runningEngine(traderID)
+	if err != nil {
+		return nil, err
+	}
+	return engine.OpenOrders(ctx)
+}
+
+// CancelOrder cancels one of a running trader's open orders
+func (m *EngineManager) CancelOrder(ctx context.Context, traderID string, orderID int64) (*OpenOrder, error) {
+	engine, err := m.runningEngine(traderID)
+	if err != nil {
+		return nil, err
+	}
+	return engine.CancelOrder(ctx, orderID)
+}
+
+// SetStops replaces the SL/TP of a running trader's position
+func (m *EngineManager) SetStops(ctx context.Context, traderID, symbol string, stopLoss, takeProfit *float64) (*StopsUpdate, error) {
+	engine, err := m.runningEngine(traderID)
+	if err != nil {
+		return nil, err
 	}
+	return engine.SetStops(ctx, symbol, stopLoss, takeProfit)
+}
+
+// ReloadCoinOverrides has a loaded trader pick up its changed pins and bans
+func (m *EngineManager) ReloadCoinOverrides(traderID string) {
+	m.mu.RLock()
+	engine, exists := m.engines[traderID]
+	m.mu.RUnlock()
+
+	if exists {
+		engine.ReloadCoinOverrides()
+	}
+}
 
-	return []map[string]interface{}{}
+// CoinUniverse returns a running trader's effective coin universe
+func (m *EngineManager) CoinUniverse(traderID string) ([]CoinCandidate, error) {
+	engine, err := m.runningEngine(traderID)
+	if err != nil {
+		return nil, err
+	}
+	return engine.CoinUniverse(), nil
+}
+
+// runningEngine returns the engine of a running trader
+func (m *EngineManager) runningEngine(traderID string) (*Engine, error) {
+	m.mu.RLock()
+	engine, exists := m.engines[traderID]
+	m.mu.RUnlock()
+
+	if !exists || !engine.IsRunning() {
+		return nil, fmt.Errorf("trader %s is not running", traderID)
+	}
+	return engine, nil
 }
 
 // GetRunningTraders returns list of running trader IDs
@@ -189,6 +415,32 @@ func (m *EngineManager) GetRunningTraders() []string {
 	return ids
 }
 
+// StreamHealth returns the market stream state of each running trader
+func (m *EngineManager) StreamHealth() map[string]market.StreamHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	health := make(map[string]market.StreamHealth, len(m.engines))
+	for id, engine := range m.engines {
+		if engine.IsRunning() {
+			health[id] = engine.StreamHealth()
+		}
+	}
+	return health
+}
+
+// StaleDecisions returns each running trader's orders aborted as stale
+func (m *EngineManager) StaleDecisions() map[string]StaleDecisionStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]StaleDecisionStats, len(m.engines))
+	for id, engine := range m.engines {
+		stats[id] = engine.StaleDecisions()
+	}
+	return stats
+}
+
 // GetHub returns the event hub
 func (m *EngineManager) GetHub() *events.Hub {
 	return m.hub
@@ -208,9 +460,10 @@ func (m *EngineManager) ReloadStrategyForTraders(strategyID string) error {
 
 	// Push to all running engines that use this strategy
 	updatedCount := 0
-	for _, engine := range m.engines {
+	for id, engine := range m.engines {
 		if engine.GetStrategyID() == strategyID {
 			engine.SetStrategy(strategy)
+			m.recordStrategyVersion(id, strategy)
 			updatedCount++
 		}
 	}
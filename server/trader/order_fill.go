@@ -0,0 +1,200 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/market"
+)
+
+const (
+	orderFillTimeout   = 5 * time.Second
+	orderFillPollDelay = 500 * time.Millisecond
+)
+
+// orderFill is the confirmed result of an entry order
+type orderFill struct {
+	Price       float64 // Average fill price
+	Qty         float64 // Executed quantity, below the requested size on a partial fill
+	SlippagePct float64 // Distance of Price from the pre-trade price, in percent
+
+	Fee          float64 // Commission in USDT, 0 when the fills couldn't be read
+	SlippageCost float64 // USDT lost against the pre-trade price, negative if the fill was better
+
+	OrderType string  // store.EntryOrderMarket or store.EntryOrderLimitChase
+	MakerQty  float64 // Part of Qty that filled resting on the book
+}
+
+// orderFillState classifies an order: done once it can't fill any further,
+// with an error if it ended without filling anything
+func orderFillState(order *exchange.Order) (done bool, err error) {
+	switch order.Status {
+	case "FILLED":
+		return true, nil
+	case "CANCELED", "EXPIRED", "REJECTED":
+		if order.ExecutedQty > 0 {
+			return true, nil
+		}
+		return true, fmt.Errorf("order %d %s without a fill", order.OrderID, order.Status)
+	default: // NEW, PARTIALLY_FILLED
+		return false, nil
+	}
+}
+
+// slippagePct returns how far fillPrice is from refPrice, in percent
+func slippagePct(refPrice, fillPrice float64) float64 {
+	if refPrice <= 0 || fillPrice <= 0 {
+		return 0
+	}
+	return math.Abs(fillPrice-refPrice) / refPrice * 100
+}
+
+// slippageCost is what filling qty at fillPrice instead of refPrice cost a buy
+// (isBuy) or a sell, in USDT; negative when the fill was better
+func slippageCost(isBuy bool, refPrice, fillPrice, qty float64) float64 {
+	if refPrice <= 0 || fillPrice <= 0 {
+		return 0
+	}
+	cost := (fillPrice - refPrice) * qty
+	if !isBuy {
+		cost = -cost
+	}
+	return cost
+}
+
+// confirmFill polls an entry order until it stops filling. An order still
+// open at the timeout has its remainder cancelled and keeps what filled.
+func (e *Engine) confirmFill(ctx context.Context, symbol string, order *exchange.Order, refPrice float64) (*orderFill, error) {
+	if order == nil {
+		return nil, fmt.Errorf("no order response")
+	}
+
+	deadline := time.Now().Add(orderFillTimeout)
+	for {
+		done, err := orderFillState(order)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			log.Printf("[%s][%s] ⚠️ Order %d still %s after %v, cancelling the remainder",
+				e.name, symbol, order.OrderID, order.Status, orderFillTimeout)
+			if err := e.binance.CancelOrder(ctx, symbol, order.OrderID); err != nil {
+				log.Printf("[%s][%s] Failed to cancel order %d: %v", e.name, symbol, order.OrderID, err)
+			}
+			if latest, err := e.binance.GetOrder(ctx, symbol, order.OrderID); err == nil {
+				order = latest
+			}
+			if order.ExecutedQty <= 0 {
+				return nil, fmt.Errorf("order %d not filled within %v", order.OrderID, orderFillTimeout)
+			}
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(orderFillPollDelay):
+		}
+
+		latest, err := e.binance.GetOrder(ctx, symbol, order.OrderID)
+		if err != nil {
+			log.Printf("[%s][%s] Failed to poll order %d: %v", e.name, symbol, order.OrderID, err)
+			continue
+		}
+		order = latest
+	}
+
+	fill := &orderFill{Price: order.AvgPrice, Qty: order.ExecutedQty}
+	if fill.Price <= 0 {
+		fill.Price = refPrice
+	}
+	e.readEntryFills(ctx, symbol, order.OrderID, fill)
+	fill.SlippagePct = slippagePct(refPrice, fill.Price)
+	fill.SlippageCost = slippageCost(order.Side == "BUY", refPrice, fill.Price, fill.Qty)
+
+	if order.Status != "FILLED" {
+		log.Printf("[%s][%s] ⚠️ Partial fill: %.4f of %.4f (status %s)",
+			e.name, symbol, order.ExecutedQty, order.OrigQty, order.Status)
+	}
+	return fill, nil
+}
+
+// readEntryFills takes an entry's VWAP and commission from its fills. Without
+// them the order's average price stands and the fee stays 0.
+func (e *Engine) readEntryFills(ctx context.Context, symbol string, orderID int64, fill *orderFill) {
+	if orderID == 0 {
+		return
+	}
+	trades, err := e.binance.GetUserTrades(ctx, symbol, orderID)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to get fills of order %d, fee unknown: %v", e.name, symbol, orderID, err)
+		return
+	}
+	fills := sumFills(trades, e.commissionPrice(ctx, symbol))
+	if fills.Qty >= fill.Qty {
+		fill.Price = fills.Price
+	}
+	fill.Fee = fills.Fee
+}
+
+// checkSlippage warns when a fill landed further from the pre-trade price than
+// the strategy allows. Brackets are then placed from the fill, not the
+// pre-trade levels.
+func (e *Engine) checkSlippage(symbol string, refPrice float64, fill *orderFill) {
+	if e.strategy == nil {
+		return
+	}
+	maxPct := e.strategy.Config.RiskControl.MaxSlippagePct
+	if maxPct <= 0 || fill.SlippagePct <= maxPct {
+		return
+	}
+
+	msg := fmt.Sprintf("slippage %.2f%% exceeds max %.2f%% (expected $%.4f, filled $%.4f); SL/TP recomputed from the fill",
+		fill.SlippagePct, maxPct, refPrice, fill.Price)
+	log.Printf("[%s][%s] ⚠️ %s", e.name, symbol, msg)
+	if e.notifier != nil {
+		e.notifier.Broadcast(events.Event{
+			Type:      events.TypeError,
+			TraderID:  e.id,
+			Symbol:    symbol,
+			Message:   msg,
+			Timestamp: time.Now().UnixMilli(),
+		})
+	}
+}
+
+// checkExpectedSlippage rejects an entry whose size would walk the order book
+// further from mid than the strategy's MaxSlippagePct. Without a book the
+// entry goes ahead; fills are still checked by checkSlippage.
+func (e *Engine) checkExpectedSlippage(ctx context.Context, symbol, side string, notional float64) error {
+	if e.strategy == nil {
+		return nil
+	}
+	maxPct := e.strategy.Config.RiskControl.MaxSlippagePct
+	if maxPct <= 0 {
+		return nil
+	}
+
+	book, err := e.binance.GetDepth(ctx, symbol, market.DepthLimit)
+	if err != nil {
+		log.Printf("[%s][%s] Order book unavailable, skipping slippage estimate: %v", e.name, symbol, err)
+		return nil
+	}
+	expected, ok := market.ExpectedSlippagePct(book, side, notional)
+	if !ok {
+		return fmt.Errorf("order book too thin to fill $%.2f", notional)
+	}
+	if expected > maxPct {
+		return fmt.Errorf("expected slippage %.2f%% for $%.2f exceeds max %.2f%%", expected, notional, maxPct)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package trader
+
+import (
+	"math"
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+// TestOrderFillState tests when an entry order counts as done and failed
+func TestOrderFillState(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    exchange.Order
+		wantDone bool
+		wantErr  bool
+	}{
+		{"Filled", exchange.Order{Status: "FILLED", ExecutedQty: 1}, true, false},
+		{"Still new", exchange.Order{Status: "NEW"}, false, false},
+		{"Partially filled, still working", exchange.Order{Status: "PARTIALLY_FILLED", ExecutedQty: 0.4}, false, false},
+		{"Expired after a partial fill", exchange.Order{Status: "EXPIRED", ExecutedQty: 0.4}, true, false},
+		{"Expired without a fill", exchange.Order{Status: "EXPIRED"}, true, true},
+		{"Rejected", exchange.Order{Status: "REJECTED"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := orderFillState(&tt.order)
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSlippagePct tests slippage in both directions
+func TestSlippagePct(t *testing.T) {
+	if got := slippagePct(100, 101); math.Abs(got-1) > 0.0001 {
+		t.Errorf("slippagePct(100, 101) = %f, want 1", got)
+	}
+	if got := slippagePct(100, 99.5); math.Abs(got-0.5) > 0.0001 {
+		t.Errorf("slippagePct(100, 99.5) = %f, want 0.5", got)
+	}
+	if got := slippagePct(0, 100); got != 0 {
+		t.Errorf("slippagePct without a reference = %f, want 0", got)
+	}
+}
+
+func TestSlippageCost(t *testing.T) {
+	tests := []struct {
+		name           string
+		isBuy          bool
+		ref, fill, qty float64
+		want           float64
+	}{
+		{"buy filled higher", true, 100, 100.5, 2, 1},
+		{"buy filled lower", true, 100, 99.5, 2, -1},
+		{"sell filled lower", false, 100, 99.5, 2, 1},
+		{"sell filled higher", false, 100, 100.5, 2, -1},
+		{"no reference", true, 0, 100, 2, 0},
+	}
+	for _, tt := range tests {
+		if got := slippageCost(tt.isBuy, tt.ref, tt.fill, tt.qty); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: slippageCost = %f, want %f", tt.name, got, tt.want)
+		}
+	}
+}
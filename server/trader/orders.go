@@ -0,0 +1,445 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+var (
+	// ErrOrderNotFound is returned when cancelling an order that isn't open on
+	// one of the trader's symbols
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrNoPosition is returned when setting stops on a symbol without a position
+	ErrNoPosition = errors.New("no open position")
+	// ErrInvalidStops wraps stop levels that would trigger at once
+	ErrInvalidStops = errors.New("invalid stops")
+)
+
+// Roles of the tracked bracket orders among the open orders
+const (
+	orderRoleStopLoss   = "stop_loss"
+	orderRoleTakeProfit = "take_profit"
+)
+
+// OpenOrder is an order resting on Binance: a regular order such as a limit,
+// or an algo order such as the SL/TP brackets
+type OpenOrder struct {
+	OrderID  int64   `json:"order_id"` // AlgoID for algo orders
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Type     string  `json:"type"`     // LIMIT, STOP_MARKET, TAKE_PROFIT_MARKET, ...
+	Price    float64 `json:"price"`    // Limit price, or the trigger price of a stop
+	Quantity float64 `json:"quantity"` // 0 for stops that close the whole position
+	Algo     bool    `json:"algo"`
+	Role     string  `json:"role,omitempty"` // stop_loss or take_profit for the tracked brackets
+	Time     int64   `json:"time"`
+}
+
+// StopsUpdate is the result of replacing a position's protective orders
+type StopsUpdate struct {
+	Symbol     string            `json:"symbol"`
+	StopLoss   float64           `json:"stop_loss"`   // 0 when the position has none
+	TakeProfit float64           `json:"take_profit"` // 0 when the position has none
+	Actions    []EmergencyAction `json:"actions"`
+}
+
+// levels returns the SL/TP prices the bracket was placed at, 0 for a side
+// without an order. The percentages are signed: a negative StopLossPct is a
+// stop past the entry in the position's favor.
+func (b *BracketOrderIDs) levels(isLong bool) (stopLoss, takeProfit float64) {
+	if b == nil || b.EntryPrice <= 0 {
+		return 0, 0
+	}
+	dir := 1.0
+	if !isLong {
+		dir = -1
+	}
+	if b.StopLossOrderID > 0 {
+		stopLoss = b.EntryPrice * (1 - dir*b.StopLossPct/100)
+	}
+	if b.TakeProfitOrderID > 0 {
+		takeProfit = b.EntryPrice * (1 + dir*b.TakeProfitPct/100)
+	}
+	return stopLoss, takeProfit
+}
+
+// bracketPcts is the inverse of levels: the signed distances of stopLoss and
+// takeProfit from entry
+func bracketPcts(isLong bool, entry, stopLoss, takeProfit float64) (slPct, tpPct float64) {
+	dir := 1.0
+	if !isLong {
+		dir = -1
+	}
+	if stopLoss > 0 {
+		slPct = dir * (entry - stopLoss) / entry * 100
+	}
+	if takeProfit > 0 {
+		tpPct = dir * (takeProfit - entry) / entry * 100
+	}
+	return slPct, tpPct
+}
+
+// validateStops checks that neither level would trigger at once: for a long
+// the SL must be below the mark price and the TP above it, the reverse for a
+// short. A zero level is no order.
+func validateStops(isLong bool, mark, stopLoss, takeProfit float64) error {
+	if stopLoss < 0 || takeProfit < 0 {
+		return fmt.Errorf("%w: levels can't be negative", ErrInvalidStops)
+	}
+	if mark <= 0 {
+		return nil
+	}
+	side := "long"
+	below, above := stopLoss, takeProfit
+	if !isLong {
+		side = "short"
+		below, above = takeProfit, stopLoss
+	}
+	if below > 0 && below >= mark {
+		return fmt.Errorf("%w: %s %v must be below the mark price %v for a %s",
+			ErrInvalidStops, stopName(isLong, true), below, mark, side)
+	}
+	if above > 0 && above <= mark {
+		return fmt.Errorf("%w: %s %v must be above the mark price %v for a %s",
+			ErrInvalidStops, stopName(isLong, false), above, mark, side)
+	}
+	return nil
+}
+
+// stopName names the level validateStops checks below (or above) the mark
+func stopName(isLong, below bool) string {
+	if isLong == below {
+		return "stop_loss"
+	}
+	return "take_profit"
+}
+
+// OpenOrders returns the open regular and algo orders on the trader's symbols,
+// grouped by symbol. The tracked SL/TP brackets carry their role.
+func (e *Engine) OpenOrders(ctx context.Context) (map[string][]OpenOrder, error) {
+	regular, err := e.binance.GetOpenOrders(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+	algo, err := e.binance.GetOpenAlgoOrders(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open algo orders: %w", err)
+	}
+
+	e.mu.RLock()
+	positions := make([]exchange.Position, 0, len(e.positions))
+	for _, pos := range e.positions {
+		positions = append(positions, *pos)
+	}
+	e.mu.RUnlock()
+	tracked := make(map[string]bool)
+	for _, symbol := range e.orderSymbols(positions) {
+		tracked[symbol] = true
+	}
+
+	e.bracketOrdersMutex.RLock()
+	defer e.bracketOrdersMutex.RUnlock()
+
+	grouped := make(map[string][]OpenOrder)
+	add := func(o exchange.Order, isAlgo bool) {
+		if !tracked[o.Symbol] {
+			return
+		}
+		order := OpenOrder{
+			OrderID:  o.OrderID,
+			Symbol:   o.Symbol,
+			Side:     o.Side,
+			Type:     o.Type,
+			Price:    o.Price,
+			Quantity: o.OrigQty,
+			Algo:     isAlgo,
+			Time:     o.Time,
+		}
+		if bracket := e.bracketOrders[o.Symbol]; isAlgo && bracket != nil {
+			switch o.OrderID {
+			case bracket.StopLossOrderID:
+				order.Role = orderRoleStopLoss
+			case bracket.TakeProfitOrderID:
+				order.Role = orderRoleTakeProfit
+			}
+		}
+		grouped[o.Symbol] = append(grouped[o.Symbol], order)
+	}
+	for _, o := range regular {
+		add(o, false)
+	}
+	for _, o := range algo {
+		add(o, true)
+	}
+
+	for _, orders := range grouped {
+		sort.Slice(orders, func(i, j int) bool { return orders[i].Time < orders[j].Time })
+	}
+	return grouped, nil
+}
+
+// CancelOrder cancels one of the trader's open orders by its ID, the AlgoID
+// for SL/TP. Cancelling a tracked bracket order stops tracking it, so the
+// next AI context shows the position without it.
+func (e *Engine) CancelOrder(ctx context.Context, orderID int64) (*OpenOrder, error) {
+	grouped, err := e.OpenOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var order *OpenOrder
+	for _, orders := range grouped {
+		for i := range orders {
+			if orders[i].OrderID == orderID {
+				order = &orders[i]
+			}
+		}
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if order.Algo {
+		err = e.binance.CancelAlgoOrder(ctx, order.Symbol, orderID)
+	} else {
+		err = e.binance.CancelOrder(ctx, order.Symbol, orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[%s][%s] Order %d (%s @ $%.4f) cancelled through the API", e.name, order.Symbol, orderID, order.Type, order.Price)
+
+	if order.Role != "" {
+		e.bracketOrdersMutex.Lock()
+		if bracket := e.bracketOrders[order.Symbol]; bracket != nil {
+			if order.Role == orderRoleStopLoss {
+				bracket.StopLossOrderID, bracket.StopLossPct = 0, 0
+			} else {
+				bracket.TakeProfitOrderID, bracket.TakeProfitPct = 0, 0
+			}
+			if bracket.StopLossOrderID == 0 && bracket.TakeProfitOrderID == 0 {
+				delete(e.bracketOrders, order.Symbol)
+			}
+		}
+		e.bracketOrdersMutex.Unlock()
+		e.saveState()
+	}
+	return order, nil
+}
+
+// SetStops moves the SL/TP of the position on symbol. A nil level keeps the
+// current one and a zero level removes it. Every step is returned for the
+// audit log.
+func (e *Engine) SetStops(ctx context.Context, symbol string, stopLoss, takeProfit *float64) (*StopsUpdate, error) {
+	return e.setStops(ctx, symbol, stopLoss, takeProfit, store.PositionEventSourceAPI, "")
+}
+
+// setStops replaces the SL/TP orders of the position on symbol whose level
+// changes, leaving the other side's order alone. Binance allows one
+// closePosition stop per side, so the old order is cancelled before the new
+// one is placed; a side whose cancel fails keeps its old order. Each moved
+// level is recorded as a position event from source.
+func (e *Engine) setStops(ctx context.Context, symbol string, stopLoss, takeProfit *float64, source, reason string) (*StopsUpdate, error) {
+	positions, err := e.binance.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	var pos *exchange.Position
+	for i := range positions {
+		if positions[i].Symbol == symbol && positions[i].PositionAmt != 0 {
+			pos = &positions[i]
+		}
+	}
+	if pos == nil || pos.EntryPrice <= 0 {
+		return nil, fmt.Errorf("%w on %s", ErrNoPosition, symbol)
+	}
+	isLong := pos.PositionAmt > 0
+
+	e.bracketOrdersMutex.RLock()
+	var bracket BracketOrderIDs
+	if b := e.bracketOrders[symbol]; b != nil {
+		bracket = *b
+	}
+	e.bracketOrdersMutex.RUnlock()
+
+	oldSL, oldTP := bracket.levels(isLong)
+	level := func(requested *float64, current float64) float64 {
+		if requested == nil {
+			return current
+		}
+		if *requested > 0 {
+			return e.binance.RoundPrice(symbol, *requested)
+		}
+		return *requested
+	}
+	sl, tp := level(stopLoss, oldSL), level(takeProfit, oldTP)
+	moveSL, moveTP := sl != oldSL, tp != oldTP
+
+	// Only the levels being moved need to clear the mark
+	checkSL, checkTP := 0.0, 0.0
+	if moveSL {
+		checkSL = sl
+	}
+	if moveTP {
+		checkTP = tp
+	}
+	if err := validateStops(isLong, pos.MarkPrice, checkSL, checkTP); err != nil {
+		return nil, err
+	}
+
+	update := &StopsUpdate{Symbol: symbol, StopLoss: oldSL, TakeProfit: oldTP}
+	record := func(action string, err error) {
+		update.Actions = append(update.Actions, NewEmergencyAction(e.id, action, symbol, err))
+	}
+	closeSide := "SELL"
+	if !isLong {
+		closeSide = "BUY"
+	}
+
+	// replace swaps one side's order, returning its new ID and level. On a
+	// failed cancel the old order stays; on a failed placement the side has none.
+	var placeErr error
+	replace := func(oldID int64, oldLevel, newLevel float64, place func(context.Context, string, string, float64, float64) (*exchange.Order, error), name string) (int64, float64) {
+		if oldID > 0 {
+			err := e.binance.CancelAlgoOrder(ctx, symbol, oldID)
+			if err != nil && isUnknownOrder(err) {
+				err = nil // Already gone
+			}
+			record("cancel_algo_order@"+strconv.FormatInt(oldID, 10), err)
+			if err != nil {
+				placeErr = err
+				return oldID, oldLevel
+			}
+		}
+		if newLevel <= 0 {
+			return 0, 0
+		}
+		order, err := place(ctx, symbol, closeSide, 0, newLevel)
+		record("place_"+name+"@"+formatLevel(newLevel), err)
+		if err != nil {
+			placeErr = err
+			return 0, 0
+		}
+		return order.OrderID, newLevel
+	}
+
+	slID, tpID := bracket.StopLossOrderID, bracket.TakeProfitOrderID
+	if moveSL {
+		slID, update.StopLoss = replace(slID, oldSL, sl, e.binance.PlaceStopLoss, "stop_loss")
+	}
+	if moveTP {
+		tpID, update.TakeProfit = replace(tpID, oldTP, tp, e.binance.PlaceTakeProfit, "take_profit")
+	}
+
+	e.bracketOrdersMutex.Lock()
+	if slID > 0 || tpID > 0 {
+		placed := &BracketOrderIDs{StopLossOrderID: slID, TakeProfitOrderID: tpID, EntryPrice: pos.EntryPrice}
+		placed.StopLossPct, placed.TakeProfitPct = bracketPcts(isLong, pos.EntryPrice, update.StopLoss, update.TakeProfit)
+		e.bracketOrders[symbol] = placed
+	} else {
+		delete(e.bracketOrders, symbol)
+	}
+	e.bracketOrdersMutex.Unlock()
+	e.saveState()
+
+	if update.StopLoss != oldSL {
+		e.recordPositionEvent(pos, store.PositionEventStopLossMoved, oldSL, update.StopLoss, source, reason)
+	}
+	if update.TakeProfit != oldTP {
+		e.recordPositionEvent(pos, store.PositionEventTakeProfitMoved, oldTP, update.TakeProfit, source, reason)
+	}
+
+	log.Printf("[%s][%s] Stops set (%s): SL $%.4f -> $%.4f, TP $%.4f -> $%.4f (entry $%.4f, mark $%.4f)",
+		e.name, symbol, source, oldSL, update.StopLoss, oldTP, update.TakeProfit, pos.EntryPrice, pos.MarkPrice)
+	if placeErr != nil {
+		e.setLastError(fmt.Sprintf("%s: replacing stops failed, position may be unprotected: %v", symbol, placeErr))
+		return update, placeErr
+	}
+	return update, nil
+}
+
+// moveStop carries out a MOVE_STOP or MOVE_TP decision, replacing that side's
+// exchange order at the decision's StopLoss or TakeProfit price
+func (e *Engine) moveStop(ctx context.Context, symbol string, d *ai.TradingDecision, hasPosition bool) error {
+	if !hasPosition {
+		log.Printf("[%s][%s] No position to %s", e.name, symbol, d.Action)
+		return fmt.Errorf("skipped: no position to move the stop of")
+	}
+	var stopLoss, takeProfit *float64
+	if d.Action == "MOVE_STOP" {
+		if d.StopLoss <= 0 {
+			return fmt.Errorf("skipped: MOVE_STOP without a stop_loss price")
+		}
+		stopLoss = &d.StopLoss
+	} else {
+		if d.TakeProfit <= 0 {
+			return fmt.Errorf("skipped: MOVE_TP without a take_profit price")
+		}
+		takeProfit = &d.TakeProfit
+	}
+
+	_, err := e.setStops(ctx, symbol, stopLoss, takeProfit, store.PositionEventSourceAI, d.Reasoning)
+	e.mu.RLock()
+	if pos := e.positions[symbol]; pos != nil && pos.PositionAmt != 0 {
+		d.PositionID = e.openPositionID(symbol, rowSide(pos.PositionAmt))
+	}
+	e.mu.RUnlock()
+	return err
+}
+
+// formatStopLevel formats an SL/TP price for the AI, "none" without an order
+func formatStopLevel(price float64) string {
+	if price <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("$%.4f", price)
+}
+
+// recordPositionEvent keeps a change to pos for the position's history
+func (e *Engine) recordPositionEvent(pos *exchange.Position, eventType string, oldPrice, newPrice float64, source, reason string) {
+	side := rowSide(pos.PositionAmt)
+	e.savePositionEvent(&store.PositionEvent{
+		PositionID: e.openPositionID(pos.Symbol, side),
+		Symbol:     pos.Symbol,
+		Side:       side,
+		Type:       eventType,
+		OldPrice:   oldPrice,
+		NewPrice:   newPrice,
+		Source:     source,
+		Reason:     reason,
+	})
+}
+
+// isUnknownOrder reports whether Binance refused a cancel because the order
+// is no longer open, i.e. it was filled or cancelled already
+func isUnknownOrder(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Unknown order") || strings.Contains(msg, "-2011") ||
+		strings.Contains(msg, "Order does not exist") || strings.Contains(msg, "-20123")
+}
+
+// isReduceOnlyRejected reports whether Binance refused a reduce-only order
+// because there is no position left for it to reduce
+func isReduceOnlyRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, exchange.ErrReduceOnlyRejected) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "-2022") || strings.Contains(msg, "ReduceOnly Order is rejected")
+}
+
+// formatLevel formats a stop level for the audit log without float noise
+func formatLevel(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestBracketLevels tests that stop levels survive the round trip through the
+// signed percentages brackets are tracked with
+func TestBracketLevels(t *testing.T) {
+	tests := []struct {
+		name             string
+		isLong           bool
+		stopLoss, target float64
+	}{
+		{"long", true, 95, 110},
+		{"long, stop in profit", true, 102, 110},
+		{"short", false, 105, 90},
+		{"short, stop in profit", false, 98, 90},
+		{"stop only", true, 95, 0},
+	}
+	for _, tt := range tests {
+		slPct, tpPct := bracketPcts(tt.isLong, 100, tt.stopLoss, tt.target)
+		b := &BracketOrderIDs{StopLossOrderID: 1, EntryPrice: 100, StopLossPct: slPct, TakeProfitPct: tpPct}
+		if tt.target > 0 {
+			b.TakeProfitOrderID = 2
+		}
+		sl, tp := b.levels(tt.isLong)
+		if math.Abs(sl-tt.stopLoss) > 1e-9 || math.Abs(tp-tt.target) > 1e-9 {
+			t.Errorf("%s: levels = %v/%v, want %v/%v", tt.name, sl, tp, tt.stopLoss, tt.target)
+		}
+	}
+
+	// A stop in profit has a negative distance, which breakevenStop relies on
+	if slPct, _ := bracketPcts(true, 100, 102, 0); slPct >= 0 {
+		t.Errorf("long stop above entry = %v%%, want negative", slPct)
+	}
+	if sl, tp := (*BracketOrderIDs)(nil).levels(true); sl != 0 || tp != 0 {
+		t.Errorf("no bracket = %v/%v", sl, tp)
+	}
+}
+
+func TestValidateStops(t *testing.T) {
+	tests := []struct {
+		name             string
+		isLong           bool
+		stopLoss, target float64
+		ok               bool
+	}{
+		{"long", true, 95, 110, true},
+		{"long, stop in profit", true, 99, 0, true},
+		{"long stop above mark", true, 101, 110, false},
+		{"long target below mark", true, 95, 99, false},
+		{"short", false, 105, 90, true},
+		{"short stop below mark", false, 99, 90, false},
+		{"short target above mark", false, 105, 101, false},
+		{"removing both", true, 0, 0, true},
+		{"negative", true, -1, 0, false},
+	}
+	for _, tt := range tests {
+		err := validateStops(tt.isLong, 100, tt.stopLoss, tt.target)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, ErrInvalidStops)) {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
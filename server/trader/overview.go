@@ -0,0 +1,165 @@
+package trader
+
+import (
+	"math"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// Overview is a running engine's live state for the trader dashboard
+type Overview struct {
+	Account        *OverviewAccount       `json:"account"`
+	Positions      []OverviewPosition     `json:"positions"`
+	Daily          OverviewDaily          `json:"daily"`
+	Margin         OverviewMargin         `json:"margin"`
+	Cycle          OverviewCycle          `json:"cycle"`
+	CircuitBreaker OverviewCircuitBreaker `json:"circuit_breaker"`
+}
+
+// OverviewAccount is the latest account snapshot from the exchange
+type OverviewAccount struct {
+	TotalEquity   float64 `json:"total_equity"`
+	WalletBalance float64 `json:"wallet_balance"`
+	Available     float64 `json:"available"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// OverviewPosition is an open position with its P&L on price and on margin
+type OverviewPosition struct {
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"` // LONG or SHORT
+	Amount        float64 `json:"amount"`
+	EntryPrice    float64 `json:"entry_price"`
+	MarkPrice     float64 `json:"mark_price"`
+	Leverage      int     `json:"leverage"`
+	Notional      float64 `json:"notional"`
+	Margin        float64 `json:"margin"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	PnLPct        float64 `json:"pnl_pct"` // Price move in the position's favor
+	ROEPct        float64 `json:"roe_pct"` // PnLPct times leverage, return on margin
+}
+
+// OverviewDaily tracks the current daily loss window
+type OverviewDaily struct {
+	Since        time.Time  `json:"since"`
+	StartEquity  float64    `json:"start_equity"`
+	PnL          float64    `json:"pnl"` // Equity change since the window started
+	PnLPct       float64    `json:"pnl_pct"`
+	LossLimitPct float64    `json:"loss_limit_pct"` // 0 when disabled
+	LimitActive  bool       `json:"limit_active"`   // Limit enabled and a start equity to measure from
+	HeadroomUSD  float64    `json:"headroom_usd"`   // Further loss before the limit triggers
+	PausedUntil  *time.Time `json:"paused_until"`   // Set while the daily loss pause is on
+
+	// Filled in by the caller from the PositionStore
+	RealizedPnL  float64 `json:"realized_pnl"`
+	ClosedTrades int     `json:"closed_trades"`
+}
+
+// OverviewMargin compares margin in use with the strategy cap
+type OverviewMargin struct {
+	UsagePct    float64 `json:"usage_pct"`
+	MaxUsagePct float64 `json:"max_usage_pct"`
+	HeadroomPct float64 `json:"headroom_pct"`
+}
+
+// OverviewCycle is the decision loop schedule
+type OverviewCycle struct {
+	IntervalSecs     int        `json:"interval_secs"`
+	LastCycleAt      *time.Time `json:"last_cycle_at"`
+	NextCycleAt      *time.Time `json:"next_cycle_at"`
+	PausedBySchedule bool       `json:"paused_by_schedule"`
+	NextActiveAt     *time.Time `json:"next_active_at"`
+}
+
+// Overview returns the engine's account, positions and risk headroom
+func (e *Engine) Overview() *Overview {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	pausedBySchedule, _, nextActive := e.scheduleStatus(now)
+	interval := e.getTradingInterval()
+
+	o := &Overview{Positions: make([]OverviewPosition, 0, len(e.positions))}
+
+	var equity float64
+	if e.account != nil {
+		equity = e.account.TotalMarginBalance
+		o.Account = &OverviewAccount{
+			TotalEquity:   e.account.TotalMarginBalance,
+			WalletBalance: e.account.TotalWalletBalance,
+			Available:     e.account.AvailableBalance,
+			UnrealizedPnL: e.account.TotalUnrealizedProfit,
+		}
+	}
+
+	for _, pos := range e.positions {
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		p := OverviewPosition{
+			Symbol:        pos.Symbol,
+			Side:          "LONG",
+			Amount:        pos.PositionAmt,
+			EntryPrice:    pos.EntryPrice,
+			MarkPrice:     pos.MarkPrice,
+			Leverage:      pos.Leverage,
+			Notional:      math.Abs(pos.PositionAmt) * pos.MarkPrice,
+			UnrealizedPnL: pos.UnrealizedProfit,
+		}
+		if pos.PositionAmt < 0 {
+			p.Side = "SHORT"
+		}
+		if pos.Leverage > 0 {
+			p.Margin = p.Notional / float64(pos.Leverage)
+		}
+		if pos.EntryPrice > 0 {
+			p.PnLPct = (pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice * 100
+			if pos.PositionAmt < 0 {
+				p.PnLPct = -p.PnLPct
+			}
+			p.ROEPct = p.PnLPct * float64(max(pos.Leverage, 1))
+		}
+		o.Positions = append(o.Positions, p)
+	}
+
+	o.Daily = OverviewDaily{Since: e.lastResetTime, StartEquity: e.initialBalance}
+	if e.initialBalance > 0 && e.account != nil {
+		o.Daily.PnL = equity - e.initialBalance
+		o.Daily.PnLPct = o.Daily.PnL / e.initialBalance * 100
+	}
+	if e.stopUntil.After(now) {
+		stopUntil := e.stopUntil
+		o.Daily.PausedUntil = &stopUntil
+	}
+
+	var rc store.RiskControlConfig
+	if e.strategy != nil {
+		rc = e.strategy.Config.RiskControl
+		o.Daily.LossLimitPct = rc.MaxDailyLossPct
+		if rc.MaxDailyLossPct > 0 && e.initialBalance > 0 && e.account != nil {
+			floor := e.initialBalance * (1 - rc.MaxDailyLossPct/100)
+			o.Daily.HeadroomUSD = math.Max(equity-floor, 0)
+			o.Daily.LimitActive = true
+		}
+		o.Margin.MaxUsagePct = rc.MaxMarginUsage
+	}
+	o.CircuitBreaker = newOverviewCircuitBreaker(e.circuitBreaker, e.peakEquity, equity, e.losingStreak, rc)
+	if equity > 0 {
+		o.Margin.UsagePct = (equity - e.account.AvailableBalance) / equity * 100
+	}
+	if o.Margin.MaxUsagePct > 0 {
+		o.Margin.HeadroomPct = math.Max(o.Margin.MaxUsagePct-o.Margin.UsagePct, 0)
+	}
+
+	o.Cycle = OverviewCycle{IntervalSecs: int(interval.Seconds()), PausedBySchedule: pausedBySchedule}
+	if !e.lastCycleAt.IsZero() {
+		last, next := e.lastCycleAt, e.lastCycleAt.Add(interval)
+		o.Cycle.LastCycleAt, o.Cycle.NextCycleAt = &last, &next
+	}
+	if pausedBySchedule && !nextActive.IsZero() {
+		o.Cycle.NextActiveAt = &nextActive
+	}
+	return o
+}
@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestOverview tests the dashboard figures derived from the engine's live state
+func TestOverview(t *testing.T) {
+	lastCycle := time.Now().Add(-time.Minute)
+	e := &Engine{
+		strategy: &store.Strategy{Config: store.StrategyConfig{
+			TradingInterval: 5,
+			RiskControl:     store.RiskControlConfig{MaxDailyLossPct: 10, MaxMarginUsage: 50},
+		}},
+		account: &exchange.AccountInfo{TotalMarginBalance: 950, TotalWalletBalance: 960, AvailableBalance: 760},
+		positions: map[string]*exchange.Position{
+			"BTCUSDT": {Symbol: "BTCUSDT", PositionAmt: 0.01, EntryPrice: 50000, MarkPrice: 51000, Leverage: 10, UnrealizedProfit: 10},
+			"ETHUSDT": {Symbol: "ETHUSDT", PositionAmt: -1, EntryPrice: 2000, MarkPrice: 2100, Leverage: 5, UnrealizedProfit: -100},
+			"SOLUSDT": {Symbol: "SOLUSDT"},
+		},
+		initialBalance: 1000,
+		lastResetTime:  time.Now().Add(-time.Hour),
+		lastCycleAt:    lastCycle,
+	}
+
+	o := e.Overview()
+
+	if o.Account == nil || o.Account.TotalEquity != 950 {
+		t.Fatalf("account = %+v", o.Account)
+	}
+	if len(o.Positions) != 2 {
+		t.Fatalf("positions = %d, want 2 (flat position skipped)", len(o.Positions))
+	}
+	for _, p := range o.Positions {
+		switch p.Symbol {
+		case "BTCUSDT":
+			if p.Side != "LONG" || math.Abs(p.PnLPct-2) > 1e-9 || math.Abs(p.ROEPct-20) > 1e-9 {
+				t.Errorf("BTC = %+v, want LONG 2%% / 20%% ROE", p)
+			}
+			if math.Abs(p.Margin-51) > 1e-9 {
+				t.Errorf("BTC margin = %v, want 51", p.Margin)
+			}
+		case "ETHUSDT":
+			if p.Side != "SHORT" || math.Abs(p.PnLPct+5) > 1e-9 || math.Abs(p.ROEPct+25) > 1e-9 {
+				t.Errorf("ETH = %+v, want SHORT -5%% / -25%% ROE", p)
+			}
+		}
+	}
+
+	// Down 50 on the day with a 10% limit on 1000 leaves 50 before it triggers
+	if o.Daily.PnL != -50 || !o.Daily.LimitActive || math.Abs(o.Daily.HeadroomUSD-50) > 1e-9 {
+		t.Errorf("daily = %+v", o.Daily)
+	}
+	if math.Abs(o.Margin.UsagePct-20) > 1e-9 || math.Abs(o.Margin.HeadroomPct-30) > 1e-9 {
+		t.Errorf("margin = %+v, want 20%% used, 30%% headroom", o.Margin)
+	}
+	if o.Cycle.IntervalSecs != 300 || o.Cycle.NextCycleAt == nil || !o.Cycle.NextCycleAt.Equal(lastCycle.Add(5*time.Minute)) {
+		t.Errorf("cycle = %+v", o.Cycle)
+	}
+}
+
+// TestOverviewDailyLimitDisabled tests that no headroom is reported without a limit
+func TestOverviewDailyLimitDisabled(t *testing.T) {
+	e := &Engine{
+		strategy:       &store.Strategy{Config: store.StrategyConfig{TradingInterval: 5}},
+		account:        &exchange.AccountInfo{TotalMarginBalance: 900},
+		initialBalance: 1000,
+	}
+
+	o := e.Overview()
+	if o.Daily.LimitActive || o.Daily.HeadroomUSD != 0 {
+		t.Errorf("daily = %+v, want no limit", o.Daily)
+	}
+	if o.Cycle.LastCycleAt != nil || o.Cycle.NextCycleAt != nil {
+		t.Errorf("cycle = %+v, want no cycle times before the first cycle", o.Cycle)
+	}
+}
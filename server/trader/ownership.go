@@ -0,0 +1,230 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+)
+
+// positionOwners records which trader holds each symbol on an exchange
+// account. Traders sharing an account see each other's positions, and one
+// trading a symbol another holds would net into or close its position.
+type positionOwners struct {
+	mu     sync.Mutex
+	owners map[ownerKey]symbolOwner
+}
+
+type ownerKey struct {
+	account string
+	symbol  string
+}
+
+// symbolOwner is the trader holding a symbol
+type symbolOwner struct {
+	id   string
+	name string
+}
+
+// accountOwners is shared by every engine in the process
+var accountOwners = newPositionOwners()
+
+func newPositionOwners() *positionOwners {
+	return &positionOwners{owners: make(map[ownerKey]symbolOwner)}
+}
+
+// claim gives symbol on account to owner. If another trader holds it, that
+// trader is returned with false.
+func (o *positionOwners) claim(account, symbol string, owner symbolOwner) (symbolOwner, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := ownerKey{account, symbol}
+	if held, ok := o.owners[key]; ok && held.id != owner.id {
+		return held, false
+	}
+	o.owners[key] = owner
+	return owner, true
+}
+
+// release gives up trader id's claim on symbol, if it has one
+func (o *positionOwners) release(account, symbol, id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := ownerKey{account, symbol}
+	if held, ok := o.owners[key]; ok && held.id == id {
+		delete(o.owners, key)
+	}
+}
+
+// releaseAll gives up every claim trader id holds
+func (o *positionOwners) releaseAll(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for key, held := range o.owners {
+		if held.id == id {
+			delete(o.owners, key)
+		}
+	}
+}
+
+// owner returns the trader holding symbol on account
+func (o *positionOwners) owner(account, symbol string) (symbolOwner, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	held, ok := o.owners[ownerKey{account, symbol}]
+	return held, ok
+}
+
+// claimed returns the symbols trader id holds on account, sorted
+func (o *positionOwners) claimed(account, id string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var symbols []string
+	for key, held := range o.owners {
+		if key.account == account && held.id == id {
+			symbols = append(symbols, key.symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// ownsAccount reports whether the engine takes part in symbol ownership. An
+// engine without credentials has no account to share.
+func (e *Engine) ownsAccount() bool {
+	return e.owners != nil && e.accountKey != ""
+}
+
+// heldElsewhere returns the other trader holding symbol on the engine's account
+func (e *Engine) heldElsewhere(symbol string) (symbolOwner, bool) {
+	if !e.ownsAccount() {
+		return symbolOwner{}, false
+	}
+	held, ok := e.owners.owner(e.accountKey, symbol)
+	if !ok || held.id == e.id {
+		return symbolOwner{}, false
+	}
+	return held, true
+}
+
+// claimOwnership claims symbol for the engine before it opens a position.
+// If another trader holds it, that trader is returned with false.
+func (e *Engine) claimOwnership(symbol string) (symbolOwner, bool) {
+	self := symbolOwner{id: e.id, name: e.name}
+	if !e.ownsAccount() {
+		return self, true
+	}
+	return e.owners.claim(e.accountKey, symbol, self)
+}
+
+// releaseOwnership gives up the engine's claim on symbol once its position closed
+func (e *Engine) releaseOwnership(symbol string) {
+	if e.ownsAccount() {
+		e.owners.release(e.accountKey, symbol, e.id)
+	}
+}
+
+// releaseOwnershipIfFlat gives up the claim on symbol unless the engine now
+// holds a position on it, so a failed entry doesn't keep the symbol
+func (e *Engine) releaseOwnershipIfFlat(symbol string) {
+	e.mu.RLock()
+	pos, ok := e.positions[symbol]
+	e.mu.RUnlock()
+	if !ok || pos.PositionAmt == 0 {
+		e.releaseOwnership(symbol)
+	}
+}
+
+// ownPositions drops the positions other traders on the account hold from a
+// fresh exchange fetch and releases the engine's claims on symbols whose
+// positions are gone. Positions nobody claimed are kept, as before traders
+// could share an account.
+func (e *Engine) ownPositions(positions []exchange.Position) []exchange.Position {
+	if !e.ownsAccount() {
+		return positions
+	}
+
+	open := make(map[string]bool)
+	owned := make([]exchange.Position, 0, len(positions))
+	for _, pos := range positions {
+		if _, ok := e.heldElsewhere(pos.Symbol); ok {
+			continue
+		}
+		if pos.PositionAmt != 0 {
+			open[pos.Symbol] = true
+		}
+		owned = append(owned, pos)
+	}
+
+	for _, symbol := range e.owners.claimed(e.accountKey, e.id) {
+		if open[symbol] {
+			continue
+		}
+		// An order in flight may have filled after the fetch
+		e.mu.RLock()
+		inFlight := e.executing[symbol]
+		e.mu.RUnlock()
+		if !inFlight {
+			log.Printf("[%s][%s] Position closed, releasing the symbol to other traders on the account", e.name, symbol)
+			e.owners.release(e.accountKey, symbol, e.id)
+		}
+	}
+	return owned
+}
+
+// sharedSymbols returns the symbols in both a and b, sorted
+func sharedSymbols(a, b []string) []string {
+	in := make(map[string]bool, len(a))
+	for _, symbol := range a {
+		in[symbol] = true
+	}
+	var shared []string
+	for _, symbol := range b {
+		if in[symbol] {
+			shared = append(shared, symbol)
+			delete(in, symbol)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// warnSharedAccount warns when a trader about to start shares its exchange
+// account with a running trader and they trade the same symbols. They still
+// run; each symbol goes to the first to open it. Called with m.mu held.
+func (m *EngineManager) warnSharedAccount(engine *Engine) {
+	if engine.accountKey == "" {
+		return
+	}
+	pairs := engine.getTradingPairs()
+	for _, other := range m.engines {
+		if other.id == engine.id || other.accountKey != engine.accountKey || !other.IsRunning() {
+			continue
+		}
+		shared := sharedSymbols(pairs, other.getTradingPairs())
+		if len(shared) == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("Trader %s shares its exchange account with running trader %s and both trade %s. Each symbol is left to the trader that opens it first.",
+			engine.name, other.name, strings.Join(shared, ", "))
+		log.Printf("🚨 WARNING: %s", msg)
+		if m.hub != nil {
+			m.hub.Broadcast(events.Event{
+				Type:      events.TypeError,
+				TraderID:  engine.id,
+				Message:   msg,
+				Timestamp: time.Now().UnixMilli(),
+			})
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package trader
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+)
+
+// ownershipEngine is a running engine on account, sharing owners with the
+// other engines of a test
+func ownershipEngine(id, account string, owners *positionOwners) *Engine {
+	return &Engine{
+		id:                    id,
+		name:                  id,
+		running:               true,
+		owners:                owners,
+		accountKey:            account,
+		positions:             make(map[string]*exchange.Position),
+		executing:             make(map[string]bool),
+		positionFirstSeenTime: make(map[string]int64),
+		peakPnLCache:          make(map[string]float64),
+	}
+}
+
+func TestSharedAccountOwnership(t *testing.T) {
+	owners := newPositionOwners()
+	a := ownershipEngine("alpha", "acct", owners)
+	b := ownershipEngine("beta", "acct", owners)
+	other := ownershipEngine("gamma", "other-acct", owners)
+
+	// alpha opens BTC
+	if _, ok := a.claimOwnership("BTCUSDT"); !ok {
+		t.Fatal("alpha couldn't claim a free symbol")
+	}
+	a.positions["BTCUSDT"] = &exchange.Position{Symbol: "BTCUSDT", PositionAmt: 0.01}
+
+	// The account's positions as both engines fetch them
+	fetched := []exchange.Position{
+		{Symbol: "BTCUSDT", PositionAmt: 0.01},
+		{Symbol: "ETHUSDT", PositionAmt: 0.5}, // Opened outside any trader
+	}
+	if got := a.ownPositions(fetched); len(got) != 2 {
+		t.Errorf("alpha kept %d positions, want 2", len(got))
+	}
+	got := b.ownPositions(fetched)
+	if len(got) != 1 || got[0].Symbol != "ETHUSDT" {
+		t.Errorf("beta kept %+v, want only the unclaimed ETHUSDT", got)
+	}
+
+	// beta skips every decision on alpha's symbol
+	for _, action := range []string{"open_long", "open_short", "close_long"} {
+		_, err := b.executeTrade(context.Background(), "BTCUSDT", &ai.TradingDecision{Action: action}, false, nil)
+		if err == nil || !strings.Contains(err.Error(), "held by trader alpha") {
+			t.Errorf("beta %s on BTCUSDT: err = %v, want held by alpha", action, err)
+		}
+	}
+	if err := b.untradable("BTCUSDT"); err == nil || !strings.HasPrefix(err.Error(), "skipped:") {
+		t.Errorf("beta analyzes alpha's symbol: %v", err)
+	}
+	if _, ok := b.claimOwnership("BTCUSDT"); ok {
+		t.Error("beta claimed alpha's symbol")
+	}
+
+	// Another account is unaffected
+	if _, ok := other.heldElsewhere("BTCUSDT"); ok {
+		t.Error("a trader on another account sees alpha's claim")
+	}
+
+	// alpha's position closes on the exchange: the symbol is free again
+	a.ownPositions(fetched[1:])
+	if owner, ok := b.heldElsewhere("BTCUSDT"); ok {
+		t.Errorf("BTCUSDT still held by %s after its position closed", owner.name)
+	}
+	if _, ok := b.claimOwnership("BTCUSDT"); !ok {
+		t.Error("beta couldn't claim the released symbol")
+	}
+}
+
+func TestOwnershipRelease(t *testing.T) {
+	owners := newPositionOwners()
+	a := ownershipEngine("alpha", "acct", owners)
+	b := ownershipEngine("beta", "acct", owners)
+
+	// A failed entry leaves no position, so the claim goes
+	a.claimOwnership("SOLUSDT")
+	a.releaseOwnershipIfFlat("SOLUSDT")
+	if _, ok := b.heldElsewhere("SOLUSDT"); ok {
+		t.Error("failed entry kept the claim")
+	}
+
+	// A filled one keeps it
+	a.claimOwnership("SOLUSDT")
+	a.positions["SOLUSDT"] = &exchange.Position{Symbol: "SOLUSDT", PositionAmt: 2}
+	a.releaseOwnershipIfFlat("SOLUSDT")
+	if _, ok := b.heldElsewhere("SOLUSDT"); !ok {
+		t.Fatal("open position lost its claim")
+	}
+
+	// An order in flight keeps the claim while the fetch doesn't show it yet
+	a.executing["SOLUSDT"] = true
+	a.ownPositions(nil)
+	if _, ok := b.heldElsewhere("SOLUSDT"); !ok {
+		t.Error("claim released while an order was in flight")
+	}
+	delete(a.executing, "SOLUSDT")
+
+	// Closing by the engine releases it
+	a.clearPositionTracking("SOLUSDT", "LONG")
+	if _, ok := b.heldElsewhere("SOLUSDT"); ok {
+		t.Error("close kept the claim")
+	}
+
+	// Only the owner releases
+	a.claimOwnership("XRPUSDT")
+	owners.release("acct", "XRPUSDT", "beta")
+	owners.releaseAll("beta")
+	if _, ok := b.heldElsewhere("XRPUSDT"); !ok {
+		t.Error("beta released alpha's claim")
+	}
+	owners.releaseAll("alpha")
+	if _, ok := b.heldElsewhere("XRPUSDT"); ok {
+		t.Error("releaseAll kept alpha's claim")
+	}
+}
+
+func TestOwnershipWithoutAccount(t *testing.T) {
+	owners := newPositionOwners()
+	a := ownershipEngine("alpha", "", owners)
+	b := ownershipEngine("beta", "", owners)
+
+	a.claimOwnership("BTCUSDT")
+	if _, ok := b.claimOwnership("BTCUSDT"); !ok {
+		t.Error("engines without credentials share no account")
+	}
+	positions := []exchange.Position{{Symbol: "BTCUSDT", PositionAmt: 1}}
+	if got := b.ownPositions(positions); len(got) != 1 {
+		t.Errorf("kept %d positions, want 1", len(got))
+	}
+}
+
+func TestSharedSymbols(t *testing.T) {
+	got := sharedSymbols([]string{"SOLUSDT", "BTCUSDT", "ETHUSDT"}, []string{"ETHUSDT", "DOGEUSDT", "BTCUSDT", "BTCUSDT"})
+	if want := []string{"BTCUSDT", "ETHUSDT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sharedSymbols = %v, want %v", got, want)
+	}
+	if got := sharedSymbols([]string{"BTCUSDT"}, []string{"ETHUSDT"}); len(got) != 0 {
+		t.Errorf("sharedSymbols = %v, want none", got)
+	}
+}
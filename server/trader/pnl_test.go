@@ -2,8 +2,10 @@ package trader
 
 import (
 	"testing"
+	"time"
 
 	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
 )
 
 // TestCalculateRealizedPnL tests the P&L calculation logic
@@ -569,6 +571,68 @@ func TestPositionSyncMergeLogic(t *testing.T) {
 	}
 }
 
+// TestRestoredStateMergeLogic tests that saved tracking is only kept for
+// positions the exchange still has open on the same side
+func TestRestoredStateMergeLogic(t *testing.T) {
+	now := time.Now()
+	opened := now.Add(-2 * time.Hour).UnixMilli()
+
+	// Simulate state saved before a restart
+	saved := &store.EngineState{
+		Positions: map[string]*store.PositionState{
+			"BTCUSDT_LONG": {Symbol: "BTCUSDT", Side: "LONG", PeakPnLPct: 3.2, FirstSeenMs: opened, StopLossOrderID: 11, TakeProfitOrderID: 12},
+			"ETHUSDT_LONG": {Symbol: "ETHUSDT", Side: "LONG", PeakPnLPct: 1.5, FirstSeenMs: opened}, // Flipped to short while down
+			"XRPUSDT_LONG": {Symbol: "XRPUSDT", Side: "LONG", PeakPnLPct: 0.8, FirstSeenMs: opened}, // SL hit while down
+		},
+	}
+
+	// Simulate positions from exchange after the restart
+	exchangePositions := []exchange.Position{
+		{Symbol: "BTCUSDT", PositionAmt: 0.1},  // Still open
+		{Symbol: "ETHUSDT", PositionAmt: -0.5}, // Now short
+		{Symbol: "XRPUSDT", PositionAmt: 0},    // Closed
+		{Symbol: "SOLUSDT", PositionAmt: 10},   // Opened while down
+	}
+
+	restored := reconcileState(saved, exchangePositions, now)
+
+	// Verify BTCUSDT keeps its peak, hold time and bracket orders
+	if restored.peakPnL["BTCUSDT_LONG"] != 3.2 {
+		t.Errorf("BTCUSDT peak P&L = %f, want 3.2", restored.peakPnL["BTCUSDT_LONG"])
+	}
+	if restored.firstSeen["BTCUSDT_LONG"] != opened {
+		t.Errorf("BTCUSDT first seen = %d, want %d", restored.firstSeen["BTCUSDT_LONG"], opened)
+	}
+	if b := restored.brackets["BTCUSDT"]; b == nil || b.StopLossOrderID != 11 || b.TakeProfitOrderID != 12 {
+		t.Errorf("BTCUSDT bracket orders not restored: %+v", b)
+	}
+
+	// Verify the flipped ETHUSDT starts fresh instead of inheriting the long's peak
+	if _, exists := restored.peakPnL["ETHUSDT_LONG"]; exists {
+		t.Error("ETHUSDT long tracking should be dropped after the flip")
+	}
+	if restored.firstSeen["ETHUSDT_SHORT"] != now.UnixMilli() {
+		t.Errorf("ETHUSDT short first seen = %d, want now", restored.firstSeen["ETHUSDT_SHORT"])
+	}
+
+	// Verify closed XRPUSDT is dropped
+	if _, exists := restored.firstSeen["XRPUSDT_LONG"]; exists {
+		t.Error("Closed position XRPUSDT should not be tracked")
+	}
+
+	// Verify SOLUSDT is tracked from now
+	if restored.firstSeen["SOLUSDT_LONG"] != now.UnixMilli() {
+		t.Errorf("SOLUSDT first seen = %d, want now", restored.firstSeen["SOLUSDT_LONG"])
+	}
+
+	if len(restored.dropped) != 2 {
+		t.Errorf("Dropped count = %d, want 2 (%v)", len(restored.dropped), restored.dropped)
+	}
+	if len(restored.positions) != 4 {
+		t.Errorf("Positions count = %d, want 4", len(restored.positions))
+	}
+}
+
 // TestTrailingStopRequiresProfit tests that TSL won't trigger at 0% profit
 // This fixes the bug where TSL could close positions at a loss when:
 // - activatePct = 0 (immediate activation)
@@ -0,0 +1,173 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/store"
+)
+
+// openPositionID returns the open PositionStore row for symbol and side
+// ("long" or "short"), 0 if there is none
+func (e *Engine) openPositionID(symbol, side string) int64 {
+	if e.positionStore == nil {
+		return 0
+	}
+	row, err := e.positionStore.GetOpenPositionBySymbol(e.id, symbol, side)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to load position row: %v", e.name, symbol, err)
+		return 0
+	}
+	if row == nil {
+		return 0
+	}
+	return row.ID
+}
+
+// recordOpen adds the PositionStore row for a position the engine just opened
+// and links the decision to it. Position sync finds the row and leaves it be.
+func (e *Engine) recordOpen(symbol string, isLong bool, fill *orderFill, leverage int, d *ai.TradingDecision) {
+	if e.positionStore == nil {
+		return
+	}
+	now := time.Now()
+	side := "long"
+	if !isLong {
+		side = "short"
+	}
+	row := &store.TraderPosition{
+		TraderID:           e.id,
+		ExchangeID:         syncExchangeID,
+		ExchangeType:       syncExchangeType,
+		ExchangePositionID: fmt.Sprintf("%s_%d", getPositionKey(symbol, side), now.UnixMilli()),
+		Symbol:             symbol,
+		Side:               side,
+		EntryQuantity:      fill.Qty,
+		Quantity:           fill.Qty,
+		EntryPrice:         fill.Price,
+		EntryTime:          now,
+		Fee:                fill.Fee,
+		EntryFee:           fill.Fee,
+		SlippageCost:       fill.SlippageCost,
+		Leverage:           leverage,
+		Source:             store.PositionSourceSystem,
+		Variant:            e.variantID(),
+	}
+	if d.OrderID != 0 {
+		row.EntryOrderID = strconv.FormatInt(d.OrderID, 10)
+	}
+	id, err := e.positionStore.Create(row)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to record opened position: %v", e.name, symbol, err)
+		return
+	}
+	d.PositionID = id
+	e.recordEntryFill(id, symbol, isLong, fill, d)
+
+	e.savePositionEvent(&store.PositionEvent{
+		PositionID: id,
+		Symbol:     symbol,
+		Side:       side,
+		Type:       store.PositionEventOpened,
+		NewPrice:   fill.Price,
+		Quantity:   fill.Qty,
+		Fee:        fill.Fee,
+		Source:     store.PositionEventSourceAI,
+		Reason:     d.Reasoning,
+	})
+}
+
+// recordEntryFill keeps how PositionStore row id's opening order filled: its
+// order type, the share filled as maker, and the price against the one the
+// decision was made at
+func (e *Engine) recordEntryFill(id int64, symbol string, isLong bool, fill *orderFill, d *ai.TradingDecision) {
+	if fill.OrderType == "" || fill.Qty <= 0 {
+		return
+	}
+	entry := store.EntryFill{OrderType: fill.OrderType, MakerPct: fill.MakerQty / fill.Qty * 100}
+	if ref := d.ReferencePrice; ref > 0 {
+		improvement := (ref - fill.Price) / ref * 10000
+		if !isLong {
+			improvement = -improvement
+		}
+		entry.ImprovementBps = &improvement
+	}
+	if err := e.positionStore.SetEntryFill(id, entry); err != nil {
+		log.Printf("[%s][%s] Failed to record entry fill: %v", e.name, symbol, err)
+	}
+}
+
+// recordSlippage adds a close's slippage cost to PositionStore row id
+func (e *Engine) recordSlippage(id int64, symbol string, cost float64) {
+	if cost == 0 {
+		return
+	}
+	if err := e.positionStore.AddExecutionCosts(id, 0, cost); err != nil {
+		log.Printf("[%s][%s] Failed to record slippage: %v", e.name, symbol, err)
+	}
+}
+
+// recordFill keeps a scale-in, partial close or close of a PositionStore row
+// for the position's history
+func (e *Engine) recordFill(row *store.TraderPosition, eventType string, price, qty, pnl, fee float64, source, reason string) {
+	e.savePositionEvent(&store.PositionEvent{
+		PositionID:  row.ID,
+		Symbol:      row.Symbol,
+		Side:        row.Side,
+		Type:        eventType,
+		NewPrice:    price,
+		Quantity:    qty,
+		RealizedPnL: pnl,
+		Fee:         fee,
+		Source:      source,
+		Reason:      reason,
+	})
+}
+
+// recordRiskActivation notes a risk rule starting to watch the position on
+// symbol, at the given mark
+func (e *Engine) recordRiskActivation(symbol string, amt float64, eventType string, mark float64, reason string) {
+	side := rowSide(amt)
+	e.savePositionEvent(&store.PositionEvent{
+		PositionID: e.openPositionID(symbol, side),
+		Symbol:     symbol,
+		Side:       side,
+		Type:       eventType,
+		NewPrice:   mark,
+		Quantity:   math.Abs(amt),
+		Source:     store.PositionEventSourceRisk,
+		Reason:     reason,
+	})
+}
+
+func (e *Engine) savePositionEvent(event *store.PositionEvent) {
+	if e.posEventStore == nil {
+		return
+	}
+	event.TraderID = e.id
+	if err := e.posEventStore.Create(event); err != nil {
+		log.Printf("[%s][%s] Failed to save position event: %v", e.name, event.Symbol, err)
+	}
+}
+
+// closeEventSource is who closed a position, by its close reason
+func closeEventSource(reason string) string {
+	switch reason {
+	case closeReasonAI:
+		return store.PositionEventSourceAI
+	case syncCloseReason:
+		return store.PositionEventSourceExchange
+	default:
+		return store.PositionEventSourceRisk
+	}
+}
+
+// crossedUp reports whether a peak P&L moving from prev to peak reached
+// threshold for the first time
+func crossedUp(prev, peak, threshold float64) bool {
+	return prev < threshold && peak >= threshold
+}
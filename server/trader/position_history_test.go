@@ -0,0 +1,37 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/store"
+)
+
+func TestCrossedUp(t *testing.T) {
+	tests := []struct {
+		prev, peak, threshold float64
+		want                  bool
+	}{
+		{0, 1.2, 1, true},
+		{0.8, 1, 1, true},
+		{1.2, 1.5, 1, false}, // Already past it on an earlier check
+		{0.2, 0.9, 1, false},
+	}
+	for _, tt := range tests {
+		if got := crossedUp(tt.prev, tt.peak, tt.threshold); got != tt.want {
+			t.Errorf("crossedUp(%v, %v, %v) = %v", tt.prev, tt.peak, tt.threshold, got)
+		}
+	}
+}
+
+func TestCloseEventSource(t *testing.T) {
+	for reason, want := range map[string]string{
+		closeReasonAI:           store.PositionEventSourceAI,
+		syncCloseReason:         store.PositionEventSourceExchange,
+		closeReasonTrailingStop: store.PositionEventSourceRisk,
+		closeReasonEmergency:    store.PositionEventSourceRisk,
+	} {
+		if got := closeEventSource(reason); got != want {
+			t.Errorf("closeEventSource(%s) = %s, want %s", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/events"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+const (
+	syncExchangeID   = "binance"
+	syncExchangeType = "futures"
+	syncCloseReason  = "closed_on_exchange" // Closed by exchange-side SL/TP or outside the engine
+)
+
+// positionVisibilityGrace is how long a position the engine just opened is
+// kept while fetches don't show it yet. A var so tests can shorten it.
+var positionVisibilityGrace = 30 * time.Second
+
+// PositionSyncStats counts PositionStore reconciliation actions since start
+type PositionSyncStats struct {
+	Opened     int       `json:"opened"`
+	Closed     int       `json:"closed"`
+	LastSyncAt time.Time `json:"last_sync_at"`
+}
+
+// positionStoreDiff is what a reconciliation pass has to change
+type positionStoreDiff struct {
+	missing []exchange.Position    // Open on the exchange without an open row
+	gone    []store.TraderPosition // Open rows whose position left the exchange
+}
+
+// diffPositionStore compares open PositionStore rows with exchange positions.
+// Rows are matched by symbol and side, so a flipped position closes the old
+// row and opens a new one.
+func diffPositionStore(rows []store.TraderPosition, positions []exchange.Position) positionStoreDiff {
+	open := make(map[string]bool)
+	for _, pos := range positions {
+		if pos.PositionAmt != 0 {
+			open[getPositionKey(pos.Symbol, rowSide(pos.PositionAmt))] = true
+		}
+	}
+
+	var diff positionStoreDiff
+	tracked := make(map[string]bool)
+	for _, row := range rows {
+		key := getPositionKey(row.Symbol, row.Side)
+		if open[key] {
+			tracked[key] = true
+			continue
+		}
+		diff.gone = append(diff.gone, row)
+	}
+
+	for _, pos := range positions {
+		if pos.PositionAmt != 0 && !tracked[getPositionKey(pos.Symbol, rowSide(pos.PositionAmt))] {
+			diff.missing = append(diff.missing, pos)
+		}
+	}
+
+	return diff
+}
+
+// summarizeClose derives a closed row's exit price, realized P&L and fees from
+// the income and trade history since it was opened. found is false when
+// neither history has anything for the position.
+func summarizeClose(row store.TraderPosition, incomes []exchange.Income, trades []exchange.Trade) (exitPrice, pnl, fee float64, found bool) {
+	since := row.EntryTime.UnixMilli()
+
+	hasIncomePnL := false
+	hasIncomeFee := false
+	for _, inc := range incomes {
+		if inc.Symbol != row.Symbol || inc.Time < since {
+			continue
+		}
+		switch inc.IncomeType {
+		case exchange.IncomeRealizedPnL:
+			pnl += inc.Income
+			hasIncomePnL = true
+		case exchange.IncomeCommission:
+			fee -= inc.Income
+			hasIncomeFee = true
+		}
+	}
+
+	// Closing fills sell a long and buy back a short
+	closeSide := "SELL"
+	if row.Side == "short" {
+		closeSide = "BUY"
+	}
+
+	var closedQty, closedValue, tradePnL, tradeFee float64
+	for _, t := range trades {
+		if t.Symbol != row.Symbol || t.Time < since || t.Side != closeSide {
+			continue
+		}
+		closedQty += t.Qty
+		closedValue += t.Price * t.Qty
+		tradePnL += t.RealizedPnL
+		tradeFee += t.Commission
+	}
+
+	if !hasIncomePnL {
+		pnl = tradePnL
+	}
+	if !hasIncomeFee {
+		fee = tradeFee
+	}
+
+	if closedQty > 0 {
+		exitPrice = closedValue / closedQty
+	} else if row.Quantity > 0 && hasIncomePnL {
+		// No fills to average, so back the price out of the P&L
+		if row.Side == "short" {
+			exitPrice = row.EntryPrice - pnl/row.Quantity
+		} else {
+			exitPrice = row.EntryPrice + pnl/row.Quantity
+		}
+	}
+
+	return exitPrice, pnl, fee, hasIncomePnL || hasIncomeFee || closedQty > 0
+}
+
+// awaitPosition notes that an entry on symbol just filled, so fetches that
+// don't show the position yet don't drop it
+func (e *Engine) awaitPosition(symbol string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.unconfirmed == nil {
+		e.unconfirmed = make(map[string]time.Time)
+	}
+	e.unconfirmed[symbol] = time.Now()
+}
+
+// withUnconfirmedPositions adds the positions the engine opened that a fresh
+// fetch leaves out, until a fetch first shows them or positionVisibilityGrace
+// passes. The exchange can take a moment to show a filled entry, and dropping
+// it would let the next decision open the symbol a second time and the store
+// sync close its row.
+func (e *Engine) withUnconfirmedPositions(positions []exchange.Position) []exchange.Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.unconfirmed) == 0 {
+		return positions
+	}
+
+	fetched := make(map[string]int, len(positions))
+	for i, pos := range positions {
+		fetched[pos.Symbol] = i
+	}
+
+	merged := append([]exchange.Position(nil), positions...)
+	for symbol, filledAt := range e.unconfirmed {
+		i, ok := fetched[symbol]
+		if ok && positions[i].PositionAmt != 0 {
+			delete(e.unconfirmed, symbol) // Visible now
+			continue
+		}
+		local, tracked := e.positions[symbol]
+		if !tracked || local.PositionAmt == 0 || time.Since(filledAt) > positionVisibilityGrace {
+			delete(e.unconfirmed, symbol)
+			continue
+		}
+		log.Printf("[%s][%s] Position opened %s ago not on the exchange yet, keeping it",
+			e.name, symbol, time.Since(filledAt).Round(time.Millisecond))
+		if ok {
+			merged[i] = *local
+		} else {
+			merged = append(merged, *local)
+		}
+	}
+	return merged
+}
+
+// syncPositionStore reconciles the position lifecycle table with the exchange,
+// recording positions opened or closed outside the engine
+func (e *Engine) syncPositionStore(ctx context.Context, positions []exchange.Position) {
+	if e.positionStore == nil {
+		return
+	}
+
+	rows, err := e.positionStore.GetOpenPositions(e.id)
+	if err != nil {
+		log.Printf("[%s] Position sync failed: %v", e.name, err)
+		return
+	}
+
+	diff := diffPositionStore(rows, positions)
+	opened, closed := 0, 0
+
+	for _, pos := range diff.missing {
+		now := time.Now()
+		side := rowSide(pos.PositionAmt)
+		row := &store.TraderPosition{
+			TraderID:           e.id,
+			ExchangeID:         syncExchangeID,
+			ExchangeType:       syncExchangeType,
+			ExchangePositionID: fmt.Sprintf("%s_%d", getPositionKey(pos.Symbol, side), now.UnixMilli()),
+			Symbol:             pos.Symbol,
+			Side:               side,
+			EntryQuantity:      math.Abs(pos.PositionAmt),
+			Quantity:           math.Abs(pos.PositionAmt),
+			EntryPrice:         pos.EntryPrice,
+			EntryTime:          now,
+			Leverage:           pos.Leverage,
+			Source:             store.PositionSourceSync,
+		}
+		if row.Leverage <= 0 {
+			row.Leverage = e.cachedLeverage(pos.Symbol)
+		}
+		id, err := e.positionStore.Create(row)
+		if err != nil {
+			log.Printf("[%s][%s] Failed to record synced position: %v", e.name, pos.Symbol, err)
+			continue
+		}
+		row.ID = id
+		opened++
+		e.recordFill(row, store.PositionEventOpened, row.EntryPrice, row.Quantity, 0, 0, store.PositionEventSourceExchange, "found on the exchange")
+
+		log.Printf("[%s][%s] Synced external %s position (qty %.4f @ %.4f)", e.name, pos.Symbol, side, row.Quantity, row.EntryPrice)
+		e.notifyPositionSync(pos.Symbol, fmt.Sprintf("Synced external %s position", side), row)
+	}
+
+	for _, row := range diff.gone {
+		exitPrice, pnl, fee, found := e.closeDetails(ctx, row)
+		if err := e.positionStore.ClosePosition(row.ID, exitPrice, fee, pnl, syncCloseReason, !found); err != nil {
+			log.Printf("[%s][%s] Failed to close synced position: %v", e.name, row.Symbol, err)
+			continue
+		}
+		closed++
+		e.recordFill(&row, store.PositionEventClosed, exitPrice, row.Quantity, pnl, fee, store.PositionEventSourceExchange, syncCloseReason)
+
+		// Exchange-side closes at a loss are the bracket stop loss
+		e.recordExchangeClose(row, pnl < 0)
+
+		log.Printf("[%s][%s] %s position closed on exchange @ %.4f (P&L: $%.2f, fee: $%.4f)",
+			e.name, row.Symbol, row.Side, exitPrice, pnl, fee)
+		e.notifyPositionSync(row.Symbol, fmt.Sprintf("%s position closed on exchange", row.Side), map[string]interface{}{
+			"position_id":  row.ID,
+			"side":         row.Side,
+			"exit_price":   exitPrice,
+			"realized_pnl": pnl,
+			"fee":          fee,
+			"reason":       syncCloseReason,
+		})
+	}
+
+	e.mu.Lock()
+	e.positionSync.Opened += opened
+	e.positionSync.Closed += closed
+	e.positionSync.LastSyncAt = time.Now()
+	e.mu.Unlock()
+}
+
+// closeDetails looks up how a position closed on the exchange. Without any
+// history it falls back to the current price and found is false.
+func (e *Engine) closeDetails(ctx context.Context, row store.TraderPosition) (exitPrice, pnl, fee float64, found bool) {
+	since := row.EntryTime.UnixMilli()
+
+	incomes, err := e.binance.GetIncomeHistory(ctx, row.Symbol, "", since, 1000)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to get income history: %v", e.name, row.Symbol, err)
+	}
+	trades, err := e.binance.GetTradeHistory(ctx, row.Symbol, since, 1000)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to get trade history: %v", e.name, row.Symbol, err)
+	}
+
+	exitPrice, pnl, fee, found = summarizeClose(row, incomes, trades)
+	if exitPrice > 0 {
+		return exitPrice, pnl, fee, found
+	}
+
+	ticker, err := e.binance.GetTicker(ctx, row.Symbol)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to get price for closed position: %v", e.name, row.Symbol, err)
+		return row.EntryPrice, pnl, fee, found
+	}
+	if !found {
+		// Estimate from the current price
+		pnl = (ticker.Price - row.EntryPrice) * row.Quantity
+		if row.Side == "short" {
+			pnl = -pnl
+		}
+	}
+	return ticker.Price, pnl, fee, found
+}
+
+// recordExchangeClose starts the cooldown for a close the engine didn't make.
+// Closes the engine made already have a newer record.
+func (e *Engine) recordExchangeClose(row store.TraderPosition, stopLoss bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if rec := e.lastCloses[row.Symbol]; rec != nil && rec.ClosedAt.After(row.EntryTime) {
+		return
+	}
+	e.lastCloses[row.Symbol] = &store.CloseRecord{ClosedAt: time.Now(), StopLoss: stopLoss}
+}
+
+func (e *Engine) notifyPositionSync(symbol, message string, data interface{}) {
+	if e.notifier == nil {
+		return
+	}
+	e.notifier.Broadcast(events.Event{
+		Type:      events.TypeInfo,
+		TraderID:  e.id,
+		Symbol:    symbol,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// rowSide maps a signed position amount to the PositionStore side
+func rowSide(amt float64) string {
+	return strings.ToLower(positionSide(amt))
+}
@@ -0,0 +1,125 @@
+package trader
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestDiffPositionStore tests which rows are opened and closed by position sync
+func TestDiffPositionStore(t *testing.T) {
+	rows := []store.TraderPosition{
+		{ID: 1, Symbol: "BTCUSDT", Side: "long"},  // Still open
+		{ID: 2, Symbol: "ETHUSDT", Side: "long"},  // Flipped to short on exchange
+		{ID: 3, Symbol: "XRPUSDT", Side: "short"}, // Closed by exchange-side SL
+	}
+	positions := []exchange.Position{
+		{Symbol: "BTCUSDT", PositionAmt: 0.1},
+		{Symbol: "ETHUSDT", PositionAmt: -0.5},
+		{Symbol: "XRPUSDT", PositionAmt: 0},
+		{Symbol: "SOLUSDT", PositionAmt: 10}, // Opened in the Binance app
+	}
+
+	diff := diffPositionStore(rows, positions)
+
+	gone := make(map[int64]bool)
+	for _, row := range diff.gone {
+		gone[row.ID] = true
+	}
+	if len(diff.gone) != 2 || !gone[2] || !gone[3] {
+		t.Errorf("gone rows = %v, want ETHUSDT long and XRPUSDT short", diff.gone)
+	}
+
+	missing := make(map[string]bool)
+	for _, pos := range diff.missing {
+		missing[getPositionKey(pos.Symbol, rowSide(pos.PositionAmt))] = true
+	}
+	if len(diff.missing) != 2 || !missing["ETHUSDT_short"] || !missing["SOLUSDT_long"] {
+		t.Errorf("missing positions = %v, want ETHUSDT short and SOLUSDT long", missing)
+	}
+}
+
+// TestSummarizeClose tests exit price, P&L and fee derivation for positions
+// closed outside the engine
+func TestSummarizeClose(t *testing.T) {
+	entryTime := time.Now().Add(-time.Hour)
+	since := entryTime.UnixMilli()
+
+	tests := []struct {
+		name      string
+		row       store.TraderPosition
+		incomes   []exchange.Income
+		trades    []exchange.Trade
+		wantExit  float64
+		wantPnL   float64
+		wantFee   float64
+		wantFound bool
+	}{
+		{
+			name: "Long closed by TP - income P&L, trade exit price",
+			row:  store.TraderPosition{Symbol: "BTCUSDT", Side: "long", EntryPrice: 50000, Quantity: 0.1, EntryTime: entryTime},
+			incomes: []exchange.Income{
+				{Symbol: "BTCUSDT", IncomeType: exchange.IncomeRealizedPnL, Income: 100, Time: since + 1000},
+				{Symbol: "BTCUSDT", IncomeType: exchange.IncomeCommission, Income: -2.5, Time: since + 1000},
+				{Symbol: "BTCUSDT", IncomeType: exchange.IncomeRealizedPnL, Income: 999, Time: since - 1000}, // Before entry
+			},
+			trades: []exchange.Trade{
+				{Symbol: "BTCUSDT", Side: "SELL", Price: 51000, Qty: 0.1, Time: since + 1000},
+				{Symbol: "BTCUSDT", Side: "BUY", Price: 50000, Qty: 0.1, Time: since + 10}, // Entry fill
+			},
+			wantExit:  51000,
+			wantPnL:   100,
+			wantFee:   2.5,
+			wantFound: true,
+		},
+		{
+			name: "Short closed by SL - no fills, price backed out of P&L",
+			row:  store.TraderPosition{Symbol: "ETHUSDT", Side: "short", EntryPrice: 3000, Quantity: 2, EntryTime: entryTime},
+			incomes: []exchange.Income{
+				{Symbol: "ETHUSDT", IncomeType: exchange.IncomeRealizedPnL, Income: -60, Time: since + 1000},
+			},
+			wantExit:  3030,
+			wantPnL:   -60,
+			wantFound: true,
+		},
+		{
+			name: "No income - falls back to trade P&L and commission",
+			row:  store.TraderPosition{Symbol: "SOLUSDT", Side: "short", EntryPrice: 100, Quantity: 10, EntryTime: entryTime},
+			trades: []exchange.Trade{
+				{Symbol: "SOLUSDT", Side: "BUY", Price: 95, Qty: 4, RealizedPnL: 20, Commission: 0.2, Time: since + 1000},
+				{Symbol: "SOLUSDT", Side: "BUY", Price: 90, Qty: 6, RealizedPnL: 60, Commission: 0.3, Time: since + 2000},
+			},
+			wantExit:  92,
+			wantPnL:   80,
+			wantFee:   0.5,
+			wantFound: true,
+		},
+		{
+			name:      "No history",
+			row:       store.TraderPosition{Symbol: "XRPUSDT", Side: "long", EntryPrice: 0.5, Quantity: 100, EntryTime: entryTime},
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exit, pnl, fee, found := summarizeClose(tt.row, tt.incomes, tt.trades)
+
+			if found != tt.wantFound {
+				t.Errorf("found = %v, want %v", found, tt.wantFound)
+			}
+			if math.Abs(exit-tt.wantExit) > 0.0001 {
+				t.Errorf("exit price = %f, want %f", exit, tt.wantExit)
+			}
+			if math.Abs(pnl-tt.wantPnL) > 0.0001 {
+				t.Errorf("P&L = %f, want %f", pnl, tt.wantPnL)
+			}
+			if math.Abs(fee-tt.wantFee) > 0.0001 {
+				t.Errorf("fee = %f, want %f", fee, tt.wantFee)
+			}
+		})
+	}
+}
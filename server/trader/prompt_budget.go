@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"fmt"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/market"
+)
+
+// promptKeepCandles is the fewest candles a token budget trims a symbol's
+// market data to
+const promptKeepCandles = 3
+
+// promptTrimWarnCycles is how many cycles in a row must trim a prompt before
+// the trader status warns about the budget
+const promptTrimWarnCycles = 3
+
+// PromptBudgetStats is how the decision prompts fared against the strategy's
+// token budget
+type PromptBudgetStats struct {
+	Budget        int    `json:"budget"`         // 0 without a budget
+	LastTokens    int    `json:"last_tokens"`    // Largest prompt of the last cycle, estimated
+	TrimmedCycles int    `json:"trimmed_cycles"` // Cycles in a row that trimmed a prompt
+	Warning       string `json:"warning,omitempty"`
+}
+
+// promptTokenBudget is the strategy's budget for a decision prompt, 0 for none
+func (e *Engine) promptTokenBudget() int {
+	if e.strategy == nil {
+		return 0
+	}
+	return e.strategy.Config.AI.PromptTokenBudget
+}
+
+// fitMarketData formats a symbol's market data for a prompt that goes on
+// with rest. While the prompt is over the strategy's token budget the oldest
+// candles are dropped, down to promptKeepCandles; a symbol's prompt has no
+// recent trades or other symbols to trim after them.
+func (e *Engine) fitMarketData(data *market.MarketData, rest string) (string, decision.PromptFit) {
+	text := e.dataProvider.FormatForAI(data)
+	fit := decision.PromptFit{Budget: e.promptTokenBudget(), Tokens: decision.EstimateTokens(text + rest)}
+	for candles := min(len(data.Klines), market.PromptCandles) - 1; fit.Over() && candles >= promptKeepCandles; candles-- {
+		text = e.dataProvider.FormatForAIWithCandles(data, candles)
+		fit.Tokens = decision.EstimateTokens(text + rest)
+		fit.Trimmed = []string{decision.TrimOldCandles}
+	}
+	return text, fit
+}
+
+// recordPromptFits updates the prompt budget stats with a cycle's prompts,
+// warning once trimming happens every cycle
+func (e *Engine) recordPromptFits(tradeLogs []*TradeLog) {
+	stats := PromptBudgetStats{Budget: e.promptTokenBudget()}
+	var trimmed, over bool
+	for _, tradeLog := range tradeLogs {
+		fit := tradeLog.PromptFit
+		stats.LastTokens = max(stats.LastTokens, fit.Tokens)
+		trimmed = trimmed || len(fit.Trimmed) > 0
+		over = over || fit.Over()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if trimmed || over {
+		stats.TrimmedCycles = e.promptBudget.TrimmedCycles + 1
+	}
+	switch {
+	case over:
+		stats.Warning = fmt.Sprintf("A prompt of ~%d tokens was still over the %d-token budget after trimming; raise the strategy's prompt_token_budget or trade fewer symbols",
+			stats.LastTokens, stats.Budget)
+	case stats.TrimmedCycles >= promptTrimWarnCycles:
+		stats.Warning = fmt.Sprintf("Prompts were trimmed to fit the %d-token budget in each of the last %d cycles; raise the strategy's prompt_token_budget or trade fewer symbols",
+			stats.Budget, stats.TrimmedCycles)
+	}
+	e.promptBudget = stats
+}
+
+// PromptBudget returns how the last cycles' prompts fared against the
+// strategy's token budget
+func (e *Engine) PromptBudget() PromptBudgetStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.promptBudget
+}
@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"strings"
+	"testing"
+
+	"auto-trader-ahh/decision"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/market"
+	"auto-trader-ahh/store"
+)
+
+func TestFitMarketData(t *testing.T) {
+	strategy := &store.Strategy{Config: store.DefaultStrategyConfig()}
+	e := &Engine{strategy: strategy, dataProvider: market.NewDataProvider(nil, nil)}
+	data := &market.MarketData{Symbol: "BTCUSDT", CurrentPrice: 97000}
+	for i := 0; i < 20; i++ {
+		data.Klines = append(data.Klines, exchange.Kline{Open: 96000, High: 97500, Low: 95500, Close: 97000})
+	}
+	const rest = "\n--- No Current Position ---\n"
+
+	text, fit := e.fitMarketData(data, rest)
+	if !strings.Contains(text, "Last 10 Candles") || len(fit.Trimmed) != 0 || fit.Tokens != decision.EstimateTokens(text+rest) {
+		t.Fatalf("no budget: fit = %+v", fit)
+	}
+
+	// Dropping the oldest candles makes room
+	strategy.Config.AI.PromptTokenBudget = fit.Tokens - 60
+	text, fit = e.fitMarketData(data, rest)
+	if fit.Over() || len(fit.Trimmed) != 1 || fit.Trimmed[0] != decision.TrimOldCandles || strings.Contains(text, "Last 10 Candles") {
+		t.Errorf("fit = %+v, want old candles trimmed to fit", fit)
+	}
+
+	// Down to the latest few, then it's sent over
+	strategy.Config.AI.PromptTokenBudget = 1
+	text, fit = e.fitMarketData(data, rest)
+	if !fit.Over() || !strings.Contains(text, "Last 3 Candles") {
+		t.Errorf("fit = %+v, want the latest %d candles kept", fit, promptKeepCandles)
+	}
+}
+
+func TestPromptBudgetWarning(t *testing.T) {
+	strategy := &store.Strategy{Config: store.DefaultStrategyConfig()}
+	strategy.Config.AI.PromptTokenBudget = 2000
+	e := &Engine{strategy: strategy}
+	trimmed := []*TradeLog{
+		{PromptFit: decision.PromptFit{Budget: 2000, Tokens: 1900, Trimmed: []string{decision.TrimOldCandles}}},
+		{PromptFit: decision.PromptFit{Budget: 2000, Tokens: 1200}},
+	}
+
+	for i := 1; i < promptTrimWarnCycles; i++ {
+		e.recordPromptFits(trimmed)
+		if s := e.PromptBudget(); s.TrimmedCycles != i || s.Warning != "" || s.LastTokens != 1900 {
+			t.Fatalf("cycle %d: %+v", i, s)
+		}
+	}
+	e.recordPromptFits(trimmed)
+	if s := e.Summary(); !strings.Contains(s.PromptWarning, "each of the last 3 cycles") {
+		t.Errorf("warning = %q", s.PromptWarning)
+	}
+
+	// A cycle that fits without trimming starts the count over
+	e.recordPromptFits([]*TradeLog{{PromptFit: decision.PromptFit{Budget: 2000, Tokens: 1200}}})
+	if s := e.PromptBudget(); s.TrimmedCycles != 0 || s.Warning != "" {
+		t.Errorf("after an untrimmed cycle = %+v", s)
+	}
+}
@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+func TestIsReduceOnlyRejected(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{fmt.Errorf("%w: %w", exchange.ErrReduceOnlyRejected, &exchange.APIError{StatusCode: 400, Code: -2022}), true},
+		{fmt.Errorf("failed to close position: %w", exchange.ErrReduceOnlyRejected), true},
+		{errors.New(`API error (status 400): {"code":-2022,"msg":"ReduceOnly Order is rejected."}`), true},
+		{&exchange.APIError{StatusCode: 400, Code: -2019, Msg: "Margin is insufficient."}, false},
+	} {
+		if got := isReduceOnlyRejected(tc.err); got != tc.want {
+			t.Errorf("isReduceOnlyRejected(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// TestCloseOfFlatPositionResyncs closes a position the exchange already
+// closed: the reduce-only close must not open a short, and the resync records
+// the close instead of the engine retrying it
+func TestCloseOfFlatPositionResyncs(t *testing.T) {
+	e, sim, client := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	ctx := context.Background()
+
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6}
+	if _, err := e.executeTrade(ctx, "BTCUSDT", d, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	e.mu.RLock()
+	pos := *e.positions["BTCUSDT"]
+	e.mu.RUnlock()
+
+	// Closed by hand on the exchange before the engine's own close
+	if _, err := client.ClosePosition(ctx, "BTCUSDT", pos.PositionAmt); err != nil {
+		t.Fatal(err)
+	}
+	fills := len(sim.Fills())
+	if err := e.forceClose(ctx, &pos, "test", closeReasonEmergency); err != nil {
+		t.Fatalf("close of a flat position = %v, want it treated as closed", err)
+	}
+
+	if got := len(sim.Fills()); got != fills {
+		t.Errorf("%d fills after the close, want %d", got, fills)
+	}
+	if amt := sim.Positions()["BTCUSDT"]; amt != 0 {
+		t.Errorf("exchange position = %g, want flat", amt)
+	}
+	e.mu.RLock()
+	_, tracked := e.positions["BTCUSDT"]
+	e.mu.RUnlock()
+	if tracked {
+		t.Error("engine still tracks the position")
+	}
+	positions := store.NewPositionStore()
+	if open, err := positions.GetOpenPositions(e.id); err != nil || len(open) != 0 {
+		t.Errorf("open rows = %+v, %v; want none", open, err)
+	}
+	closed, err := positions.GetClosedPositions(e.id, 10)
+	if err != nil || len(closed) != 1 || closed[0].CloseReason != syncCloseReason {
+		t.Errorf("closed rows = %+v, %v; want one closed on the exchange", closed, err)
+	}
+}
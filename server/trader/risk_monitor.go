@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	defaultRiskCheckInterval = 20 * time.Second
+	minRiskCheckInterval     = 5 * time.Second // Keeps the loop inside Binance rate limits
+)
+
+// getRiskCheckInterval returns the time between rule-based risk checks
+func (e *Engine) getRiskCheckInterval() time.Duration {
+	if e.strategy == nil || e.strategy.Config.RiskControl.RiskCheckIntervalSecs <= 0 {
+		return defaultRiskCheckInterval
+	}
+	interval := time.Duration(e.strategy.Config.RiskControl.RiskCheckIntervalSecs) * time.Second
+	if interval < minRiskCheckInterval {
+		return minRiskCheckInterval
+	}
+	return interval
+}
+
+// startRiskMonitor runs the rule-based protections on their own schedule so a
+// long AI trading interval doesn't leave positions unwatched
+func (e *Engine) startRiskMonitor(ctx context.Context) {
+	log.Printf("[%s] Risk monitor started (%v interval)", e.name, e.getRiskCheckInterval())
+
+	for {
+		// Re-read each time so strategy changes apply without a restart
+		timer := time.NewTimer(e.getRiskCheckInterval())
+		select {
+		case <-e.stopCh:
+			timer.Stop()
+			log.Printf("[%s] Risk monitor stopped", e.name)
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			e.runRiskCheck(ctx)
+		}
+	}
+}
+
+// runRiskCheck refreshes positions and marks, then evaluates daily loss, the
+// circuit breaker and the per-position protections. No AI call is made.
+func (e *Engine) runRiskCheck(ctx context.Context) {
+	// Stale marks could trigger the wrong close, so skip the pass instead
+	if err := e.syncOrdersFromBinance(ctx); err != nil {
+		log.Printf("[%s] Risk check skipped: %v", e.name, err)
+		return
+	}
+
+	e.resetDailyPnLIfNeeded(time.Now())
+	e.syncIncome(ctx)
+	if !e.shouldStopTrading() && e.checkDailyLoss() {
+		e.triggerTradingPause(ctx)
+	}
+	e.checkCircuitBreaker()
+	e.refreshRealized()
+
+	e.flattenAfterReconnect(ctx)
+	e.checkPositionDrawdown(ctx)
+	e.maintainBackstops(ctx)
+	e.renewAutoCancel(ctx)
+	e.publishPositions()
+
+	e.mu.Lock()
+	e.lastRiskCheckAt = time.Now()
+	e.mu.Unlock()
+}
+
+// claimSymbol marks symbol as having an order in flight. It returns false if
+// another order already holds it.
+func (e *Engine) claimSymbol(symbol string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.executing[symbol] {
+		return false
+	}
+	e.executing[symbol] = true
+	return true
+}
+
+// releaseSymbol clears a claim taken with claimSymbol
+func (e *Engine) releaseSymbol(symbol string) {
+	e.mu.Lock()
+	delete(e.executing, symbol)
+	e.mu.Unlock()
+}
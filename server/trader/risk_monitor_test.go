@@ -0,0 +1,52 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// TestClaimSymbol tests that the risk loop and execution path can't act on a
+// symbol at the same time
+func TestClaimSymbol(t *testing.T) {
+	e := &Engine{executing: make(map[string]bool)}
+
+	if !e.claimSymbol("BTCUSDT") {
+		t.Fatal("first claim should succeed")
+	}
+	if e.claimSymbol("BTCUSDT") {
+		t.Error("second claim on a busy symbol should fail")
+	}
+	if !e.claimSymbol("ETHUSDT") {
+		t.Error("claim on another symbol should succeed")
+	}
+
+	e.releaseSymbol("BTCUSDT")
+	if !e.claimSymbol("BTCUSDT") {
+		t.Error("claim after release should succeed")
+	}
+}
+
+// TestGetRiskCheckInterval tests the configured interval, default and floor
+func TestGetRiskCheckInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		secs int
+		want time.Duration
+	}{
+		{"Unset uses default", 0, 20 * time.Second},
+		{"Configured", 45, 45 * time.Second},
+		{"Too fast is floored", 1, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Engine{strategy: &store.Strategy{}}
+			e.strategy.Config.RiskControl.RiskCheckIntervalSecs = tt.secs
+			if got := e.getRiskCheckInterval(); got != tt.want {
+				t.Errorf("getRiskCheckInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"auto-trader-ahh/report"
+	"auto-trader-ahh/store"
+)
+
+// Out-of-window behaviors
+const (
+	schedulePauseEntries = "pause_entries"
+	scheduleFullPause    = "full_pause"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// parseClock parses "HH:MM" into minutes after midnight. "24:00" is allowed
+// as an end of day.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	if h == 24 && m == 0 {
+		return minutesPerDay, nil
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// weekSpan returns a window as minutes after Sunday 00:00. end can run past
+// the end of the week for a Saturday window that crosses midnight.
+func weekSpan(w store.ScheduleWindow) (start, end int, err error) {
+	if w.Day < 0 || w.Day > 6 {
+		return 0, 0, fmt.Errorf("invalid day %d, expected 0 (Sunday) to 6 (Saturday)", w.Day)
+	}
+	s, err := parseClock(w.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if s == minutesPerDay {
+		return 0, 0, fmt.Errorf("invalid start time %q", w.Start)
+	}
+	e, err := parseClock(w.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	if e <= s {
+		e += minutesPerDay // Runs past midnight
+	}
+	base := w.Day * minutesPerDay
+	return base + s, base + e, nil
+}
+
+// scheduleLocation returns the schedule's timezone, falling back to the
+// strategy's and then UTC
+func scheduleLocation(sc *store.ScheduleConfig, strategyTimezone string) (*time.Location, error) {
+	timezone := sc.Timezone
+	if timezone == "" {
+		timezone = strategyTimezone
+	}
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return loc, nil
+}
+
+// ValidateTradingDay checks a strategy's timezone and day start hour
+func ValidateTradingDay(cfg *store.StrategyConfig) error {
+	_, err := report.NewTradingDay(cfg.Timezone, cfg.DayStartHour)
+	return err
+}
+
+// ValidateSchedule checks a schedule's timezone, behavior and windows, and
+// that no two windows overlap
+func ValidateSchedule(sc *store.ScheduleConfig) error {
+	if sc == nil || !sc.Enabled {
+		return nil
+	}
+	if _, err := scheduleLocation(sc, ""); err != nil {
+		return err
+	}
+	switch sc.OutOfWindow {
+	case "", schedulePauseEntries, scheduleFullPause:
+	default:
+		return fmt.Errorf("invalid out_of_window %q, expected %q or %q", sc.OutOfWindow, schedulePauseEntries, scheduleFullPause)
+	}
+	if len(sc.Windows) == 0 {
+		return fmt.Errorf("schedule enabled without any windows")
+	}
+
+	type span struct{ start, end int }
+	spans := make([]span, len(sc.Windows))
+	for i, w := range sc.Windows {
+		start, end, err := weekSpan(w)
+		if err != nil {
+			return fmt.Errorf("window %d: %w", i+1, err)
+		}
+		spans[i] = span{start, end}
+	}
+
+	// The week wraps, so a Saturday night window can overlap Sunday morning
+	for i := range spans {
+		for j := i + 1; j < len(spans); j++ {
+			a, b := spans[i], spans[j]
+			for _, shift := range []int{-minutesPerWeek, 0, minutesPerWeek} {
+				if a.start < b.end+shift && b.start+shift < a.end {
+					return fmt.Errorf("windows %d and %d overlap", i+1, j+1)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// scheduleActive reports whether now falls inside one of the schedule's
+// windows. Windows are wall-clock times, so they follow DST shifts.
+func scheduleActive(sc *store.ScheduleConfig, loc *time.Location, now time.Time) bool {
+	local := now.In(loc)
+	m := int(local.Weekday())*minutesPerDay + local.Hour()*60 + local.Minute()
+
+	for _, w := range sc.Windows {
+		start, end, err := weekSpan(w)
+		if err != nil {
+			continue
+		}
+		if (m >= start && m < end) || (m+minutesPerWeek >= start && m+minutesPerWeek < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextScheduleStart returns when the next window after now opens, or the zero
+// time if the schedule has no valid windows. Start times are built from
+// calendar dates rather than by adding durations, so they land on the right
+// wall-clock time across DST changes.
+func nextScheduleStart(sc *store.ScheduleConfig, loc *time.Location, now time.Time) time.Time {
+	local := now.In(loc)
+	var next time.Time
+
+	for offset := 0; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		for _, w := range sc.Windows {
+			if w.Day != int(day.Weekday()) {
+				continue
+			}
+			startMin, err := parseClock(w.Start)
+			if err != nil || startMin == minutesPerDay {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), startMin/60, startMin%60, 0, 0, loc)
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// scheduleStatus returns whether the trader is outside its schedule at now,
+// the out-of-window behavior, and when the schedule next becomes active
+func (e *Engine) scheduleStatus(now time.Time) (paused bool, behavior string, nextActive time.Time) {
+	if e.strategy == nil || !e.strategy.Config.Schedule.Enabled {
+		return false, "", time.Time{}
+	}
+	sc := &e.strategy.Config.Schedule
+
+	loc, err := scheduleLocation(sc, e.strategy.Config.Timezone)
+	if err != nil {
+		log.Printf("[%s] %v, using UTC for the trading schedule", e.name, err)
+		loc = time.UTC
+	}
+	if scheduleActive(sc, loc, now) {
+		return false, "", time.Time{}
+	}
+
+	behavior = sc.OutOfWindow
+	if behavior == "" {
+		behavior = schedulePauseEntries
+	}
+	return true, behavior, nextScheduleStart(sc, loc, now)
+}
+
+// isEntryAction reports whether action opens or adds to a position
+func isEntryAction(action string) bool {
+	switch action {
+	case "BUY", "SELL", "open_long", "open_short", "add_to_long", "add_to_short":
+		return true
+	}
+	return false
+}
+
+// formatNextActive formats the schedule's next start for logs and errors
+func formatNextActive(next time.Time) string {
+	if next.IsZero() {
+		return "unknown"
+	}
+	return next.Format(time.RFC3339)
+}
@@ -0,0 +1,180 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// TestValidateSchedule tests window parsing and overlap detection
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		sc      store.ScheduleConfig
+		wantErr bool
+	}{
+		{
+			name: "Disabled schedule is not checked",
+			sc:   store.ScheduleConfig{Timezone: "Not/AZone"},
+		},
+		{
+			name: "Weekday windows",
+			sc: store.ScheduleConfig{Enabled: true, Timezone: "UTC", Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "09:00", End: "17:00"},
+				{Day: 2, Start: "09:00", End: "17:00"},
+			}},
+		},
+		{
+			name: "Back-to-back windows don't overlap",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "22:00", End: "02:00"},
+				{Day: 2, Start: "02:00", End: "24:00"},
+			}},
+		},
+		{
+			name: "Overlap on the same day",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 3, Start: "08:00", End: "12:00"},
+				{Day: 3, Start: "11:00", End: "15:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Overnight window overlaps the next day",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "22:00", End: "03:00"},
+				{Day: 2, Start: "01:00", End: "05:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Saturday night overlaps Sunday morning",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 0, Start: "00:00", End: "06:00"},
+				{Day: 6, Start: "23:00", End: "01:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "No windows",
+			sc:      store.ScheduleConfig{Enabled: true},
+			wantErr: true,
+		},
+		{
+			name: "Bad timezone",
+			sc: store.ScheduleConfig{Enabled: true, Timezone: "Not/AZone", Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "09:00", End: "17:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Bad behavior",
+			sc: store.ScheduleConfig{Enabled: true, OutOfWindow: "sleep", Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "09:00", End: "17:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Bad time",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 1, Start: "9:00", End: "25:00"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Bad day",
+			sc: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+				{Day: 7, Start: "09:00", End: "17:00"},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchedule(&tt.sc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestScheduleActive tests window membership, including windows past midnight
+func TestScheduleActive(t *testing.T) {
+	sc := &store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+		{Day: 1, Start: "09:00", End: "17:00"}, // Monday
+		{Day: 6, Start: "22:00", End: "02:00"}, // Saturday night into Sunday
+	}}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"Monday inside", time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), true},
+		{"Monday at start", time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), true},
+		{"Monday at end", time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC), false},
+		{"Tuesday", time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC), false},
+		{"Saturday night", time.Date(2024, 3, 9, 23, 30, 0, 0, time.UTC), true},
+		{"Sunday after midnight", time.Date(2024, 3, 10, 1, 30, 0, 0, time.UTC), true},
+		{"Sunday after window", time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleActive(sc, time.UTC, tt.now); got != tt.want {
+				t.Errorf("scheduleActive(%s) = %v, want %v", tt.now.Weekday(), got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNextScheduleStartDST tests that the next start keeps its wall-clock time
+// across a DST change
+func TestNextScheduleStartDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	sc := &store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+		{Day: 1, Start: "09:30", End: "16:00"},
+	}}
+
+	// Friday before US DST starts on Sunday 2024-03-10
+	now := time.Date(2024, 3, 8, 18, 0, 0, 0, loc)
+	next := nextScheduleStart(sc, loc, now)
+
+	want := time.Date(2024, 3, 11, 9, 30, 0, 0, loc)
+	if !next.Equal(want) {
+		t.Errorf("nextScheduleStart() = %v, want %v", next, want)
+	}
+	if next.In(loc).Hour() != 9 || next.In(loc).Minute() != 30 {
+		t.Errorf("next start moved off wall-clock time: %v", next.In(loc))
+	}
+	if scheduleActive(sc, loc, now) {
+		t.Error("Friday evening should be outside the schedule")
+	}
+	if !scheduleActive(sc, loc, next) {
+		t.Error("schedule should be active at its next start")
+	}
+}
+
+// TestNextScheduleStartSameDay tests a window later the same day
+func TestNextScheduleStartSameDay(t *testing.T) {
+	sc := &store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+		{Day: 1, Start: "09:00", End: "12:00"},
+		{Day: 1, Start: "14:00", End: "18:00"},
+	}}
+	now := time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC) // Monday
+
+	want := time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC)
+	if got := nextScheduleStart(sc, time.UTC, now); !got.Equal(want) {
+		t.Errorf("nextScheduleStart() = %v, want %v", got, want)
+	}
+
+	if got := nextScheduleStart(&store.ScheduleConfig{}, time.UTC, now); !got.IsZero() {
+		t.Errorf("nextScheduleStart() without windows = %v, want zero", got)
+	}
+}
@@ -0,0 +1,198 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// Shutdown policies: what stopping a trader does with its open positions
+const (
+	ShutdownLeave   = "leave"   // Positions and their SL/TP orders stay as they are
+	ShutdownFlatten = "flatten" // Cancel orders and market-close every position
+	ShutdownProtect = "protect" // Every position keeps an SL at breakeven or better
+)
+
+// shutdownTimeout bounds the exchange calls a shutdown policy makes, so a
+// slow exchange can't hold up a stop or the process exit
+const shutdownTimeout = 30 * time.Second
+
+// ShutdownReport is what stopping a trader did under its shutdown policy
+type ShutdownReport struct {
+	Policy  string            `json:"policy"`
+	Actions []EmergencyAction `json:"actions"`
+}
+
+// ValidateShutdownPolicy checks a trader's shutdown policy. Empty means leave.
+func ValidateShutdownPolicy(policy string) error {
+	switch policy {
+	case "", ShutdownLeave, ShutdownFlatten, ShutdownProtect:
+		return nil
+	}
+	return fmt.Errorf("invalid shutdown_policy %q, expected %q, %q or %q", policy, ShutdownLeave, ShutdownFlatten, ShutdownProtect)
+}
+
+// shutdown stops the engine and applies the policy to its positions
+func (e *Engine) shutdown(ctx context.Context, policy string) *ShutdownReport {
+	if policy == "" {
+		policy = ShutdownLeave
+	}
+	report := &ShutdownReport{Policy: policy}
+	record := func(action, symbol string, err error) {
+		report.Actions = append(report.Actions, NewEmergencyAction(e.id, action, symbol, err))
+	}
+
+	e.Stop()
+	record("stop", "", nil)
+	if policy == ShutdownLeave || e.binance == nil {
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	positions, err := e.exchangePositions(ctx)
+	if err != nil {
+		record("get_positions", "", err)
+	}
+
+	if policy == ShutdownFlatten {
+		e.annotate(store.AnnotationFlatten, "stopped with the flatten policy, open orders cancelled and positions closed")
+		for _, symbol := range e.orderSymbols(positions) {
+			record("cancel_orders", symbol, e.binance.CancelAllOrders(ctx, symbol))
+		}
+	}
+	for i := range positions {
+		pos := &positions[i]
+		if pos.PositionAmt == 0 {
+			continue
+		}
+		if policy == ShutdownFlatten {
+			record("flatten", pos.Symbol, e.forceClose(ctx, pos, "shutdown", closeReasonShutdown))
+			continue
+		}
+		e.protectPosition(ctx, pos, record)
+	}
+
+	// Stop saved the state before the closes and new stops
+	e.saveState()
+	return report
+}
+
+// protectPosition moves the stop of pos to breakeven. A position that is at a
+// loss can't get a breakeven stop, it would trigger at once, so it is closed;
+// so is one whose stop can't be placed.
+func (e *Engine) protectPosition(ctx context.Context, pos *exchange.Position, record func(action, symbol string, err error)) {
+	e.bracketOrdersMutex.RLock()
+	bracket := e.bracketOrders[pos.Symbol]
+	e.bracketOrdersMutex.RUnlock()
+
+	stop, ok := breakevenStop(pos, bracket)
+	if !ok {
+		log.Printf("[%s][%s] Position is at a loss, closing it instead of leaving it without a breakeven stop", e.name, pos.Symbol)
+		record("flatten", pos.Symbol, e.forceClose(ctx, pos, "shutdown, no breakeven stop possible", closeReasonShutdown))
+		return
+	}
+	if stop == 0 {
+		record("keep_stop", pos.Symbol, nil)
+		return
+	}
+
+	// Binance allows one closePosition stop per side, replace the brackets
+	e.cancelBracketOrders(ctx, pos.Symbol)
+	if err := e.binance.CancelAllOrders(ctx, pos.Symbol); err != nil {
+		record("cancel_orders", pos.Symbol, err)
+	}
+
+	closeSide := "SELL"
+	if pos.PositionAmt < 0 {
+		closeSide = "BUY"
+	}
+	order, err := e.binance.PlaceStopLoss(ctx, pos.Symbol, closeSide, 0, stop)
+	record("breakeven_stop", pos.Symbol, err)
+	if err != nil {
+		record("flatten", pos.Symbol, e.forceClose(ctx, pos, "shutdown, breakeven stop failed", closeReasonShutdown))
+		return
+	}
+	log.Printf("[%s][%s] Breakeven SL placed at $%.4f for shutdown", e.name, pos.Symbol, stop)
+
+	e.bracketOrdersMutex.Lock()
+	e.bracketOrders[pos.Symbol] = &BracketOrderIDs{
+		StopLossOrderID: order.OrderID,
+		EntryPrice:      pos.EntryPrice,
+	}
+	e.bracketOrdersMutex.Unlock()
+}
+
+// breakevenStop returns the stop price that keeps pos from closing at a loss,
+// or 0 if its tracked stop already does. ok is false when the mark price
+// isn't past the entry in the position's favor.
+func breakevenStop(pos *exchange.Position, bracket *BracketOrderIDs) (stop float64, ok bool) {
+	if bracket != nil && bracket.StopLossOrderID > 0 && bracket.StopLossPct <= 0 {
+		return 0, true
+	}
+	if pos.PositionAmt > 0 && pos.MarkPrice > pos.EntryPrice {
+		return pos.EntryPrice, true
+	}
+	if pos.PositionAmt < 0 && pos.MarkPrice > 0 && pos.MarkPrice < pos.EntryPrice {
+		return pos.EntryPrice, true
+	}
+	return 0, false
+}
+
+// shutdownPolicy is the trader's saved policy, so a change made while the
+// trader runs applies to its next stop
+func (m *EngineManager) shutdownPolicy(traderID string, engine *Engine) string {
+	if t, err := m.traderStore.Get(traderID); err == nil {
+		return t.Config.ShutdownPolicy
+	}
+	if engine.traderConfig != nil {
+		return engine.traderConfig.ShutdownPolicy
+	}
+	return ""
+}
+
+// shutdownAll stops engines under their shutdown policies, in parallel so the
+// timeout bounds the whole shutdown, and returns the reports by trader ID
+func (m *EngineManager) shutdownAll(engines map[string]*Engine) map[string]*ShutdownReport {
+	reports := make(map[string]*ShutdownReport, len(engines))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for id, engine := range engines {
+		wg.Add(1)
+		go func(id string, engine *Engine) {
+			defer wg.Done()
+			report := engine.shutdown(context.Background(), m.shutdownPolicy(id, engine))
+			mu.Lock()
+			reports[id] = report
+			mu.Unlock()
+		}(id, engine)
+	}
+	wg.Wait()
+
+	ids := make([]string, 0, len(reports))
+	for id := range reports {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		logShutdown(id, reports[id])
+	}
+	return reports
+}
+
+// logShutdown logs a stopped trader and any step that failed
+func logShutdown(traderID string, report *ShutdownReport) {
+	log.Printf("Stopped trader: %s (shutdown policy: %s)", traderID, report.Policy)
+	for _, a := range report.Actions {
+		if a.Result != "ok" {
+			log.Printf("Shutdown of trader %s: %s %s failed: %s", traderID, a.Action, a.Symbol, a.Result)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package trader
+
+import (
+	"testing"
+
+	"auto-trader-ahh/exchange"
+)
+
+func TestValidateShutdownPolicy(t *testing.T) {
+	for _, policy := range []string{"", ShutdownLeave, ShutdownFlatten, ShutdownProtect} {
+		if err := ValidateShutdownPolicy(policy); err != nil {
+			t.Errorf("%q: %v", policy, err)
+		}
+	}
+	if err := ValidateShutdownPolicy("close"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
+
+// TestBreakevenStop tests which positions get a breakeven stop on a protect
+// shutdown and which have to be closed
+func TestBreakevenStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      exchange.Position
+		bracket  *BracketOrderIDs
+		wantStop float64
+		wantOK   bool
+	}{
+		{"long in profit", exchange.Position{PositionAmt: 1, EntryPrice: 100, MarkPrice: 105}, nil, 100, true},
+		{"short in profit", exchange.Position{PositionAmt: -1, EntryPrice: 100, MarkPrice: 95}, nil, 100, true},
+		{"long at a loss", exchange.Position{PositionAmt: 1, EntryPrice: 100, MarkPrice: 95}, nil, 0, false},
+		{"short at a loss", exchange.Position{PositionAmt: -1, EntryPrice: 100, MarkPrice: 105}, nil, 0, false},
+		{"long at entry", exchange.Position{PositionAmt: 1, EntryPrice: 100, MarkPrice: 100}, nil, 0, false},
+		{"short without a mark", exchange.Position{PositionAmt: -1, EntryPrice: 100}, nil, 0, false},
+		{"stop below entry is replaced", exchange.Position{PositionAmt: 1, EntryPrice: 100, MarkPrice: 105},
+			&BracketOrderIDs{StopLossOrderID: 7, EntryPrice: 100, StopLossPct: 2}, 100, true},
+		{"stop at breakeven is kept", exchange.Position{PositionAmt: 1, EntryPrice: 100, MarkPrice: 95},
+			&BracketOrderIDs{StopLossOrderID: 7, EntryPrice: 100}, 0, true},
+	}
+	for _, tt := range tests {
+		stop, ok := breakevenStop(&tt.pos, tt.bracket)
+		if stop != tt.wantStop || ok != tt.wantOK {
+			t.Errorf("%s = %v, %v, want %v, %v", tt.name, stop, ok, tt.wantStop, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"log"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/decision"
+)
+
+// sizingMode returns the strategy's position sizing mode, fixed_pct by default
+func (e *Engine) sizingMode() string {
+	if e.strategy == nil || e.strategy.Config.RiskControl.SizingMode == "" {
+		return decision.SizingFixedPct
+	}
+	return e.strategy.Config.RiskControl.SizingMode
+}
+
+// atrSizingParams returns the risk per trade and ATR stop multiple, with defaults
+func (e *Engine) atrSizingParams() (riskPct, multiple float64) {
+	riskPct, multiple = 1.0, 2.0
+	if e.strategy == nil {
+		return riskPct, multiple
+	}
+	rc := e.strategy.Config.RiskControl
+	if rc.RiskPerTradePct > 0 {
+		riskPct = rc.RiskPerTradePct
+	}
+	if rc.ATRStopMultiple > 0 {
+		multiple = rc.ATRStopMultiple
+	}
+	return riskPct, multiple
+}
+
+// atrRiskMargin returns the margin for a new position sized so a stop k*ATR
+// away loses RiskPerTradePct of equity. ok is false when ATR sizing is off or
+// there's no ATR, leaving the fixed percent size in place. The usual caps
+// still apply to the result.
+func (e *Engine) atrRiskMargin(symbol string, td *ai.TradingDecision, equity, price float64, leverage int) (margin float64, ok bool) {
+	if e.sizingMode() != decision.SizingATRRisk {
+		return 0, false
+	}
+
+	riskPct, multiple := e.atrSizingParams()
+	value, riskUSD, err := decision.ATRRiskSize(equity, riskPct, td.ATR, multiple, price, 0)
+	if err != nil {
+		log.Printf("[%s][%s] ATR sizing unavailable, using fixed percent: %v", e.name, symbol, err)
+		return 0, false
+	}
+
+	margin = value / float64(leverage)
+	log.Printf("[%s][%s] ATR sizing: ATR $%.4f × %.1f stop, risking $%.2f (%.2f%% of equity) → position $%.2f, margin $%.2f",
+		e.name, symbol, td.ATR, multiple, riskUSD, riskPct, value, margin)
+	return margin, true
+}
+
+// atrStopRisk returns what quantity loses at the ATR stop when the position
+// was ATR-sized, 0 otherwise
+func (e *Engine) atrStopRisk(td *ai.TradingDecision, quantity float64) float64 {
+	if e.sizingMode() != decision.SizingATRRisk || td.ATR <= 0 {
+		return 0
+	}
+	_, multiple := e.atrSizingParams()
+	return quantity * td.ATR * multiple
+}
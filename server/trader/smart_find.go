@@ -0,0 +1,257 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"auto-trader-ahh/store"
+)
+
+// smartFindMinSpacing is the least time between a manual Smart Find refresh
+// and the previous run, each one is an AI call
+const smartFindMinSpacing = 5 * time.Minute
+
+// Manual Smart Find refresh errors
+var (
+	ErrSmartFindTooSoon = errors.New("smart find ran too recently")
+	ErrSmartFindRunning = errors.New("smart find is already running")
+)
+
+const (
+	smartFindMinVolume  = 500000 // Least 24h quote volume (USDT) for a candidate
+	smartFindCandidates = 30     // Candidates shown to the AI
+)
+
+var smartFindStables = map[string]bool{
+	"USDCUSDT": true, "FDUSDUSDT": true, "TUSDUSDT": true, "USDPUSDT": true,
+}
+
+// smartFindCoin is a ticker that passed the candidate filters
+type smartFindCoin struct {
+	Symbol      string
+	PriceChange float64
+	Volume      float64
+	QuoteVolume float64
+}
+
+// smartFindFilter restricts the symbols Smart Find may pick
+type smartFindFilter struct {
+	exclude        map[string]bool
+	includeOnly    map[string]bool // Empty allows every symbol
+	minListingAge  time.Duration   // 0 = off
+	maxFundingRate float64         // Absolute rate per funding interval as a fraction, 0 = off
+}
+
+// newSmartFindFilter builds the filter from the coin source settings
+func newSmartFindFilter(cs store.CoinSourceConfig) smartFindFilter {
+	symbolSet := func(symbols []string) map[string]bool {
+		set := make(map[string]bool, len(symbols))
+		for _, s := range symbols {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				set[s] = true
+			}
+		}
+		return set
+	}
+
+	f := smartFindFilter{
+		exclude:     symbolSet(cs.SmartFindExcludeSymbols),
+		includeOnly: symbolSet(cs.SmartFindIncludeOnly),
+	}
+	if cs.MinListingAgeDays > 0 {
+		f.minListingAge = time.Duration(cs.MinListingAgeDays) * 24 * time.Hour
+	}
+	if cs.MaxFundingRateAbs > 0 {
+		f.maxFundingRate = cs.MaxFundingRateAbs / 100
+	}
+	return f
+}
+
+// listReason returns why the exclude or include-only list rules symbol out,
+// "" if it doesn't
+func (f smartFindFilter) listReason(symbol string) string {
+	if f.exclude[symbol] {
+		return "excluded"
+	}
+	if len(f.includeOnly) > 0 && !f.includeOnly[symbol] {
+		return "not in include list"
+	}
+	return ""
+}
+
+// symbolReason returns why a ticker can't be a candidate on its symbol and
+// volume alone, "" if it can
+func (f smartFindFilter) symbolReason(symbol string, quoteVolume float64) string {
+	if len(symbol) <= 4 {
+		return "symbol too short"
+	}
+	if !strings.HasSuffix(symbol, "USDT") {
+		return "not USDT pair"
+	}
+	if smartFindStables[symbol] {
+		return "stablecoin"
+	}
+	if reason := f.listReason(symbol); reason != "" {
+		return reason
+	}
+	if quoteVolume <= smartFindMinVolume {
+		return "volume below 500k"
+	}
+	return ""
+}
+
+// marketReason returns why a candidate's listing age or funding rules it
+// out, "" if neither does. A zero listedAt means the age couldn't be read.
+func (f smartFindFilter) marketReason(listedAt time.Time, fundingRate float64, now time.Time) string {
+	if f.minListingAge > 0 {
+		if listedAt.IsZero() {
+			return "listing age unknown"
+		}
+		if age := now.Sub(listedAt); age < f.minListingAge {
+			return fmt.Sprintf("listed %.0f days ago, minimum %.0f", age.Hours()/24, f.minListingAge.Hours()/24)
+		}
+	}
+	if f.maxFundingRate > 0 && math.Abs(fundingRate) > f.maxFundingRate {
+		return fmt.Sprintf("funding rate %.4f%% beyond ±%.4f%%", fundingRate*100, f.maxFundingRate*100)
+	}
+	return ""
+}
+
+// screenSmartFindMarkets applies the listing age and funding filters to
+// candidates, best first, until limit pass. Listing times are cached, a
+// symbol's first kline doesn't move.
+func (e *Engine) screenSmartFindMarkets(ctx context.Context, candidates []smartFindCoin, f smartFindFilter, limit int) ([]smartFindCoin, error) {
+	if f.minListingAge <= 0 && f.maxFundingRate <= 0 {
+		if len(candidates) > limit {
+			candidates = candidates[:limit]
+		}
+		return candidates, nil
+	}
+
+	funding := make(map[string]float64)
+	if f.maxFundingRate > 0 {
+		index, err := e.binance.GetPremiumIndex(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch funding rates: %w", err)
+		}
+		for _, p := range index {
+			funding[p.Symbol] = p.LastFundingRate
+		}
+	}
+
+	now := time.Now()
+	kept := make([]smartFindCoin, 0, limit)
+	for _, c := range candidates {
+		if len(kept) >= limit {
+			break
+		}
+
+		var listedAt time.Time
+		if f.minListingAge > 0 {
+			listedAt = e.listingTime(ctx, c.Symbol)
+		}
+		if reason := f.marketReason(listedAt, funding[c.Symbol], now); reason != "" {
+			log.Printf("[%s] Smart Find skipped %s: %s", e.name, c.Symbol, reason)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept, nil
+}
+
+// listingTime returns when symbol was listed, zero if it can't be read.
+// Called with smartFindMu held, which guards the cache.
+func (e *Engine) listingTime(ctx context.Context, symbol string) time.Time {
+	if t, ok := e.listingTimes[symbol]; ok {
+		return t
+	}
+	t, err := e.binance.GetListingTime(ctx, symbol)
+	if err != nil {
+		log.Printf("[%s][%s] Failed to read listing time: %v", e.name, symbol, err)
+		return time.Time{}
+	}
+	if e.listingTimes == nil {
+		e.listingTimes = make(map[string]time.Time)
+	}
+	e.listingTimes[symbol] = t
+	return t
+}
+
+// smartFindTargetCount is how many symbols Smart Find picks, twice the max
+// positions
+func (e *Engine) smartFindTargetCount() int {
+	maxPositions := 3
+	if e.strategy.Config.RiskControl.MaxPositions > 0 {
+		maxPositions = e.strategy.Config.RiskControl.MaxPositions
+	}
+	return maxPositions * 2
+}
+
+// smartFind runs Smart Find, records the run and makes its picks the trading
+// universe
+func (e *Engine) smartFind(ctx context.Context, trigger string) (*store.SmartFindRun, error) {
+	if !e.smartFindMu.TryLock() {
+		return nil, ErrSmartFindRunning
+	}
+	defer e.smartFindMu.Unlock()
+
+	run := &store.SmartFindRun{TraderID: e.id, Timestamp: time.Now(), Trigger: trigger}
+	newCoins, err := e.runSmartFind(ctx, e.smartFindTargetCount(), run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if e.smartFindStore != nil {
+		if serr := e.smartFindStore.Create(run); serr != nil {
+			log.Printf("[%s] Failed to record Smart Find run: %v", e.name, serr)
+		}
+	}
+
+	e.mu.Lock()
+	e.lastSmartFindRun = run.Timestamp
+	if err == nil {
+		// Update strategy's static coins
+		e.strategy.Config.CoinSource.StaticCoins = newCoins
+		e.strategy.Config.CoinSource.SourceType = "static"
+		e.lastSmartFindRefresh = time.Now()
+	}
+	e.mu.Unlock()
+
+	if err == nil {
+		e.updateStreamSymbols()
+	}
+
+	return run, err
+}
+
+// smartFindWait returns how long a manual refresh must wait after the last run
+func smartFindWait(lastRun, now time.Time) time.Duration {
+	if lastRun.IsZero() {
+		return 0
+	}
+	if wait := lastRun.Add(smartFindMinSpacing).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// RefreshSmartFind runs Smart Find now instead of waiting for the timer
+func (e *Engine) RefreshSmartFind(ctx context.Context) (*store.SmartFindRun, error) {
+	if e.strategy == nil {
+		return nil, fmt.Errorf("trader has no strategy")
+	}
+
+	e.mu.RLock()
+	wait := smartFindWait(e.lastSmartFindRun, time.Now())
+	e.mu.RUnlock()
+	if wait > 0 {
+		return nil, fmt.Errorf("%w, try again in %s", ErrSmartFindTooSoon, wait.Round(time.Second))
+	}
+
+	log.Printf("[%s] 🔍 Smart Find manual refresh triggered", e.name)
+	return e.smartFind(ctx, store.SmartFindTriggerManual)
+}
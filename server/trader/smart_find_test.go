@@ -1,7 +1,9 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -306,6 +308,7 @@ func TestSmartFindCandidateFiltering(t *testing.T) {
 		name         string
 		symbol       string
 		quoteVolume  float64
+		coinSource   store.CoinSourceConfig
 		expectPass   bool
 		rejectReason string
 	}{
@@ -369,44 +372,58 @@ func TestSmartFindCandidateFiltering(t *testing.T) {
 			quoteVolume: 500001, // Just above threshold
 			expectPass:  true,
 		},
-	}
-
-	stablecoins := map[string]bool{
-		"USDCUSDT":  true,
-		"FDUSDUSDT": true,
-		"TUSDUSDT":  true,
-		"USDPUSDT":  true,
+		{
+			name:         "Excluded symbol - rejected",
+			symbol:       "PEPEUSDT",
+			quoteVolume:  50000000,
+			coinSource:   store.CoinSourceConfig{SmartFindExcludeSymbols: []string{"pepeusdt "}},
+			expectPass:   false,
+			rejectReason: "excluded",
+		},
+		{
+			name:        "Symbol not on the exclude list - passes",
+			symbol:      "SOLUSDT",
+			quoteVolume: 50000000,
+			coinSource:  store.CoinSourceConfig{SmartFindExcludeSymbols: []string{"PEPEUSDT"}},
+			expectPass:  true,
+		},
+		{
+			name:         "Outside the include list - rejected",
+			symbol:       "WIFUSDT",
+			quoteVolume:  50000000,
+			coinSource:   store.CoinSourceConfig{SmartFindIncludeOnly: []string{"BTCUSDT", "SOLUSDT"}},
+			expectPass:   false,
+			rejectReason: "not in include list",
+		},
+		{
+			name:        "On the include list - passes",
+			symbol:      "SOLUSDT",
+			quoteVolume: 50000000,
+			coinSource:  store.CoinSourceConfig{SmartFindIncludeOnly: []string{"BTCUSDT", "SOLUSDT"}},
+			expectPass:  true,
+		},
+		{
+			name:         "Exclude wins over include",
+			symbol:       "SOLUSDT",
+			quoteVolume:  50000000,
+			coinSource:   store.CoinSourceConfig{SmartFindExcludeSymbols: []string{"SOLUSDT"}, SmartFindIncludeOnly: []string{"SOLUSDT"}},
+			expectPass:   false,
+			rejectReason: "excluded",
+		},
+		{
+			name:         "Included symbol still needs volume",
+			symbol:       "SOLUSDT",
+			quoteVolume:  100000,
+			coinSource:   store.CoinSourceConfig{SmartFindIncludeOnly: []string{"SOLUSDT"}},
+			expectPass:   false,
+			rejectReason: "volume below 500k",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Simulate the filtering logic from runSmartFind
-			passes := true
-			reason := ""
-
-			// Check symbol length
-			if len(tt.symbol) <= 4 {
-				passes = false
-				reason = "symbol too short"
-			}
-
-			// Check USDT suffix
-			if passes && (len(tt.symbol) <= 4 || tt.symbol[len(tt.symbol)-4:] != "USDT") {
-				passes = false
-				reason = "not USDT pair"
-			}
-
-			// Check stablecoin
-			if passes && stablecoins[tt.symbol] {
-				passes = false
-				reason = "stablecoin"
-			}
-
-			// Check volume
-			if passes && tt.quoteVolume <= 500000 {
-				passes = false
-				reason = "volume below 500k"
-			}
+			reason := newSmartFindFilter(tt.coinSource).symbolReason(tt.symbol, tt.quoteVolume)
+			passes := reason == ""
 
 			if passes != tt.expectPass {
 				t.Errorf("Filter result = %v, want %v (symbol: %s, reason: %s)",
@@ -420,6 +437,100 @@ func TestSmartFindCandidateFiltering(t *testing.T) {
 	}
 }
 
+// TestSmartFindMarketFiltering tests the listing age and funding rate filters
+func TestSmartFindMarketFiltering(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+
+	tests := []struct {
+		name         string
+		coinSource   store.CoinSourceConfig
+		listedAt     time.Time
+		fundingRate  float64
+		expectPass   bool
+		rejectReason string
+	}{
+		{
+			name:        "Filters off - fresh listing with extreme funding passes",
+			listedAt:    daysAgo(2),
+			fundingRate: 0.02,
+			expectPass:  true,
+		},
+		{
+			name:         "Fresh listing - rejected",
+			coinSource:   store.CoinSourceConfig{MinListingAgeDays: 30},
+			listedAt:     daysAgo(2),
+			expectPass:   false,
+			rejectReason: "listed 2 days ago",
+		},
+		{
+			name:       "Old enough listing - passes",
+			coinSource: store.CoinSourceConfig{MinListingAgeDays: 30},
+			listedAt:   daysAgo(400),
+			expectPass: true,
+		},
+		{
+			name:         "Unknown listing age - rejected",
+			coinSource:   store.CoinSourceConfig{MinListingAgeDays: 30},
+			expectPass:   false,
+			rejectReason: "listing age unknown",
+		},
+		{
+			name:         "Positive funding above the limit - rejected",
+			coinSource:   store.CoinSourceConfig{MaxFundingRateAbs: 0.1}, // 0.1%
+			listedAt:     daysAgo(400),
+			fundingRate:  0.0015,
+			expectPass:   false,
+			rejectReason: "funding rate 0.1500%",
+		},
+		{
+			name:         "Negative funding above the limit - rejected",
+			coinSource:   store.CoinSourceConfig{MaxFundingRateAbs: 0.1},
+			fundingRate:  -0.003,
+			expectPass:   false,
+			rejectReason: "funding rate -0.3000%",
+		},
+		{
+			name:        "Funding within the limit - passes",
+			coinSource:  store.CoinSourceConfig{MaxFundingRateAbs: 0.1},
+			fundingRate: -0.0005,
+			expectPass:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := newSmartFindFilter(tt.coinSource).marketReason(tt.listedAt, tt.fundingRate, now)
+			if passes := reason == ""; passes != tt.expectPass {
+				t.Errorf("Filter result = %v, want %v (reason: %s)", passes, tt.expectPass, reason)
+			}
+			if !tt.expectPass && !strings.HasPrefix(reason, tt.rejectReason) {
+				t.Errorf("Reject reason = %s, want prefix %s", reason, tt.rejectReason)
+			}
+		})
+	}
+}
+
+// TestSmartFindRecommendationLists tests that AI picks outside the lists are dropped
+func TestSmartFindRecommendationLists(t *testing.T) {
+	filter := newSmartFindFilter(store.CoinSourceConfig{
+		SmartFindExcludeSymbols: []string{"PEPEUSDT"},
+		SmartFindIncludeOnly:    []string{"BTCUSDT", "ETHUSDT", "PEPEUSDT"},
+	})
+
+	want := map[string]string{
+		"BTCUSDT":  "",
+		"ETHUSDT":  "",
+		"PEPEUSDT": "excluded",
+		"WIFUSDT":  "not in include list",
+	}
+	for symbol, wantReason := range want {
+		if got := filter.listReason(symbol); got != wantReason {
+			t.Errorf("listReason(%s) = %q, want %q", symbol, got, wantReason)
+		}
+	}
+}
+
 // TestSmartFindTurboModePrompt tests that Turbo Mode affects the prompt style
 func TestSmartFindTurboModePrompt(t *testing.T) {
 	tests := []struct {
@@ -568,3 +679,40 @@ func TestSmartFindVolumeSorting(t *testing.T) {
 		}
 	}
 }
+
+// TestSmartFindWait tests the spacing between manual Smart Find refreshes
+func TestSmartFindWait(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		want    time.Duration
+	}{
+		{"Never run", time.Time{}, 0},
+		{"Just ran", now, smartFindMinSpacing},
+		{"Ran 2 minutes ago", now.Add(-2 * time.Minute), smartFindMinSpacing - 2*time.Minute},
+		{"Ran long ago", now.Add(-time.Hour), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := smartFindWait(tt.lastRun, now); got != tt.want {
+				t.Errorf("smartFindWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRefreshSmartFindSpacing tests that a refresh right after a run is refused
+func TestRefreshSmartFindSpacing(t *testing.T) {
+	e := &Engine{
+		strategy:         &store.Strategy{Config: store.DefaultStrategyConfig()},
+		lastSmartFindRun: time.Now().Add(-time.Minute),
+	}
+
+	_, err := e.RefreshSmartFind(context.Background())
+	if !errors.Is(err, ErrSmartFindTooSoon) {
+		t.Errorf("RefreshSmartFind() error = %v, want ErrSmartFindTooSoon", err)
+	}
+}
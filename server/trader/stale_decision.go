@@ -0,0 +1,119 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// defaultPositionDriftTolerancePct applies when the strategy doesn't set one
+const defaultPositionDriftTolerancePct = 10.0
+
+// StaleDecisionStats counts orders aborted because the position changed
+// after the decision was made
+type StaleDecisionStats struct {
+	Aborts      int        `json:"aborts"`
+	LastAbortAt *time.Time `json:"last_abort_at,omitempty"`
+}
+
+// placesOrder reports whether executing action sends an order to the exchange
+func placesOrder(action string) bool {
+	switch action {
+	case "CLOSE", "close_long", "close_short", "MOVE_STOP", "MOVE_TP":
+		return true
+	}
+	return isEntryAction(action)
+}
+
+// positionDrift describes how the live position differs from the one a
+// decision assumed (nil when flat), "" while it still matches: the same side,
+// and a size within tolerancePct of the assumed one
+func positionDrift(assumed, live *exchange.Position, tolerancePct float64) string {
+	var assumedAmt, liveAmt float64
+	if assumed != nil {
+		assumedAmt = assumed.PositionAmt
+	}
+	if live != nil {
+		liveAmt = live.PositionAmt
+	}
+
+	switch {
+	case assumedAmt == 0 && liveAmt == 0:
+		return ""
+	case assumedAmt == 0:
+		return fmt.Sprintf("decided while flat, now %s %g", positionSide(liveAmt), math.Abs(liveAmt))
+	case liveAmt == 0:
+		return fmt.Sprintf("decided on %s %g, now flat", positionSide(assumedAmt), math.Abs(assumedAmt))
+	case (assumedAmt > 0) != (liveAmt > 0):
+		return fmt.Sprintf("decided on %s %g, now %s %g", positionSide(assumedAmt), math.Abs(assumedAmt),
+			positionSide(liveAmt), math.Abs(liveAmt))
+	}
+
+	drift := math.Abs(liveAmt-assumedAmt) / math.Abs(assumedAmt) * 100
+	if drift > tolerancePct {
+		return fmt.Sprintf("%s size went from %g to %g (%.1f%%, tolerance %g%%)", positionSide(liveAmt),
+			math.Abs(assumedAmt), math.Abs(liveAmt), drift, tolerancePct)
+	}
+	return ""
+}
+
+// positionDriftTolerance is the strategy's size tolerance for the stale
+// decision check
+func (e *Engine) positionDriftTolerance() float64 {
+	if e.strategy != nil && e.strategy.Config.RiskControl.PositionDriftTolerancePct > 0 {
+		return e.strategy.Config.RiskControl.PositionDriftTolerancePct
+	}
+	return defaultPositionDriftTolerancePct
+}
+
+// checkPositionUnchanged re-fetches symbol's position before an order and
+// aborts the order when it no longer matches the position the decision was
+// made under, e.g. an exchange-side TP closed it mid-cycle and an entry would
+// now open the other way. The next cycle decides again on the live position.
+func (e *Engine) checkPositionUnchanged(ctx context.Context, symbol, action string, hasPosition bool, currentPos *exchange.Position) error {
+	live, err := e.binance.GetPosition(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s position before the order: %w", symbol, err)
+	}
+
+	// A position the engine just opened may not show yet
+	if live == nil {
+		e.mu.RLock()
+		filledAt, waiting := e.unconfirmed[symbol]
+		local := e.positions[symbol]
+		e.mu.RUnlock()
+		if waiting && local != nil && time.Since(filledAt) <= positionVisibilityGrace {
+			live = local
+		}
+	}
+
+	assumed := currentPos
+	if !hasPosition {
+		assumed = nil
+	}
+	reason := positionDrift(assumed, live, e.positionDriftTolerance())
+	if reason == "" {
+		return nil
+	}
+
+	log.Printf("[%s][%s] ⚠️ Stale decision, aborting %s: %s", e.name, symbol, action, reason)
+	now := time.Now()
+	e.mu.Lock()
+	e.staleDecisions.Aborts++
+	e.staleDecisions.LastAbortAt = &now
+	e.mu.Unlock()
+	e.recordRiskEvent(store.RiskEventStaleDecision, fmt.Sprintf("%s %s aborted: %s", symbol, action, reason))
+	return fmt.Errorf("skipped: stale decision, %s", reason)
+}
+
+// StaleDecisions returns the orders aborted as stale since start
+func (e *Engine) StaleDecisions() StaleDecisionStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.staleDecisions
+}
@@ -0,0 +1,89 @@
+package trader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-trader-ahh/ai"
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+func TestPositionDrift(t *testing.T) {
+	long := &exchange.Position{PositionAmt: 1}
+	tests := []struct {
+		name    string
+		assumed *exchange.Position
+		live    *exchange.Position
+		stale   string // Part of the reason, "" when still fresh
+	}{
+		{"flat stays flat", nil, nil, ""},
+		{"unchanged", long, &exchange.Position{PositionAmt: 1}, ""},
+		{"within tolerance", long, &exchange.Position{PositionAmt: 0.95}, ""},
+		{"closed by TP", long, nil, "now flat"},
+		{"opened meanwhile", nil, &exchange.Position{PositionAmt: -2}, "decided while flat, now SHORT 2"},
+		{"flipped", long, &exchange.Position{PositionAmt: -1}, "now SHORT 1"},
+		{"partly closed", long, &exchange.Position{PositionAmt: 0.5}, "50.0%"},
+		{"zero amount is flat", long, &exchange.Position{}, "now flat"},
+	}
+	for _, tt := range tests {
+		got := positionDrift(tt.assumed, tt.live, 10)
+		if (tt.stale == "") != (got == "") || !strings.Contains(got, tt.stale) {
+			t.Errorf("%s: drift = %q, want %q", tt.name, got, tt.stale)
+		}
+	}
+}
+
+// TestStaleDecisionAborted trades on a paper exchange whose position changes
+// between the decision and its order
+func TestStaleDecisionAborted(t *testing.T) {
+	e, sim, client := paperEngine(t, map[string]float64{"BTCUSDT": 60000})
+	ctx := context.Background()
+
+	// Decided on a long the exchange closed since, e.g. at its TP
+	if _, err := client.PlaceOrder(ctx, "BTCUSDT", "BUY", "MARKET", 0.01, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	assumed := &exchange.Position{Symbol: "BTCUSDT", PositionAmt: 0.01, EntryPrice: 60000, MarkPrice: 60000}
+	if _, err := client.ClosePosition(ctx, "BTCUSDT", 0.01); err != nil {
+		t.Fatal(err)
+	}
+	fills := len(sim.Fills())
+	_, err := e.executeTrade(ctx, "BTCUSDT", &ai.TradingDecision{Symbol: "BTCUSDT", Action: "close_long", Confidence: 90}, true, assumed)
+	if err == nil || !strings.Contains(err.Error(), "stale decision") {
+		t.Fatalf("close after the TP = %v, want a stale decision", err)
+	}
+
+	// Decided while flat, and a position opened meanwhile: an opposite
+	// entry would net into it
+	if _, err := client.PlaceOrder(ctx, "BTCUSDT", "BUY", "MARKET", 0.01, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	fills++
+	d := &ai.TradingDecision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 90, Leverage: 5, StopLossPct: 2, TakeProfitPct: 6}
+	if _, err := e.executeTrade(ctx, "BTCUSDT", d, false, nil); err == nil || !strings.Contains(err.Error(), "now LONG 0.01") {
+		t.Fatalf("short on a long opened meanwhile = %v, want a stale decision", err)
+	}
+
+	if got := len(sim.Fills()); got != fills {
+		t.Errorf("%d fills, want %d: a stale order reached the exchange", got, fills)
+	}
+	if stats := e.StaleDecisions(); stats.Aborts != 2 || stats.LastAbortAt == nil {
+		t.Errorf("stats = %+v, want 2 aborts", stats)
+	}
+	events, err := store.NewRiskEventStore().ListBetween(e.id, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := 0
+	for _, ev := range events {
+		if ev.Type == store.RiskEventStaleDecision {
+			stale++
+		}
+	}
+	if stale != 2 {
+		t.Errorf("%d stale_decision risk events, want 2", stale)
+	}
+}
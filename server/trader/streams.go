@@ -0,0 +1,47 @@
+package trader
+
+import (
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/market"
+)
+
+// updateStreamSymbols points the market streams at the trading pairs plus any
+// symbol with an open position, so the risk loop keeps live marks after Smart
+// Find swaps a held symbol out
+func (e *Engine) updateStreamSymbols() {
+	if e.stream == nil {
+		return
+	}
+
+	symbols := append([]string(nil), e.getTradingPairs()...)
+	e.mu.RLock()
+	for symbol, pos := range e.positions {
+		if pos.PositionAmt != 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+	e.mu.RUnlock()
+
+	e.stream.SetSymbols(symbols)
+}
+
+// withStreamMark returns pos re-marked at the streamed mark price when it's
+// fresh, pos itself otherwise
+func (e *Engine) withStreamMark(pos *exchange.Position) *exchange.Position {
+	if e.stream == nil {
+		return pos
+	}
+	mark, ok := e.stream.MarkPrice(pos.Symbol)
+	if !ok || mark == pos.MarkPrice {
+		return pos
+	}
+	marked := *pos
+	marked.MarkPrice = mark
+	marked.UnrealizedProfit = pos.PositionAmt * (mark - pos.EntryPrice)
+	return &marked
+}
+
+// StreamHealth returns the state of the engine's market data streams
+func (e *Engine) StreamHealth() market.StreamHealth {
+	return e.stream.Health()
+}
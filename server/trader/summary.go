@@ -0,0 +1,55 @@
+package trader
+
+import (
+	"time"
+)
+
+// Summary is a running trader's state in a few fields, for listing many
+// traders at once without a request per trader
+type Summary struct {
+	Running       bool       `json:"running"`
+	Equity        float64    `json:"equity"`         // 0 until the account is first fetched
+	OpenPositions int        `json:"open_positions"` // Positions with a non-zero amount
+	LastCycleAt   *time.Time `json:"last_cycle_at"`  // nil before the first cycle
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	PromptWarning string     `json:"prompt_warning,omitempty"` // Prompts keep being trimmed to the strategy's token budget
+}
+
+// Summary reports the engine's runtime state
+func (e *Engine) Summary() Summary {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	s := Summary{Running: e.running, LastError: e.lastError, PromptWarning: e.promptBudget.Warning}
+	if e.account != nil {
+		s.Equity = e.account.TotalMarginBalance
+	}
+	for _, pos := range e.positions {
+		if pos.PositionAmt != 0 {
+			s.OpenPositions++
+		}
+	}
+	if !e.lastCycleAt.IsZero() {
+		last := e.lastCycleAt
+		s.LastCycleAt = &last
+	}
+	if !e.lastErrorAt.IsZero() {
+		at := e.lastErrorAt
+		s.LastErrorAt = &at
+	}
+	return s
+}
+
+// Snapshot returns the summary of every loaded engine, keyed by trader ID.
+// Traders without an engine aren't included.
+func (m *EngineManager) Snapshot() map[string]Summary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summaries := make(map[string]Summary, len(m.engines))
+	for id, engine := range m.engines {
+		summaries[id] = engine.Summary()
+	}
+	return summaries
+}
@@ -0,0 +1,44 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+)
+
+// TestSummary tests the compact state listed for each trader
+func TestSummary(t *testing.T) {
+	e := &Engine{
+		running: true,
+		account: &exchange.AccountInfo{TotalMarginBalance: 950},
+		positions: map[string]*exchange.Position{
+			"BTCUSDT": {Symbol: "BTCUSDT", PositionAmt: 0.01},
+			"ETHUSDT": {Symbol: "ETHUSDT", PositionAmt: -1},
+			"SOLUSDT": {Symbol: "SOLUSDT"},
+		},
+		lastCycleAt: time.Now().Add(-time.Minute),
+	}
+
+	s := e.Summary()
+	if !s.Running || s.Equity != 950 || s.OpenPositions != 2 || s.LastCycleAt == nil {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.LastError != "" || s.LastErrorAt != nil {
+		t.Errorf("error before any failure = %q at %v", s.LastError, s.LastErrorAt)
+	}
+
+	e.setLastError("getting positions: timeout")
+	if s := e.Summary(); s.LastError != "getting positions: timeout" || s.LastErrorAt == nil {
+		t.Errorf("after a failed cycle = %+v", s)
+	}
+	e.setLastError("")
+	if s := e.Summary(); s.LastError != "" || s.LastErrorAt != nil {
+		t.Errorf("after a clean cycle = %+v", s)
+	}
+
+	// Before the first cycle and account fetch
+	if s := (&Engine{}).Summary(); s.Running || s.Equity != 0 || s.LastCycleAt != nil {
+		t.Errorf("new engine = %+v", s)
+	}
+}
@@ -0,0 +1,85 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"auto-trader-ahh/exchange"
+	"auto-trader-ahh/store"
+)
+
+// TestDailyResetNewYorkDST tests the daily loss baseline of a trader whose
+// day starts at 17:00 New York time, across both DST changes. The days are 23
+// and 25 hours long, so a fixed 24 hours would reset too late and too early.
+func TestDailyResetNewYorkDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	strategy := &store.Strategy{Config: store.StrategyConfig{Timezone: "America/New_York", DayStartHour: 17}}
+
+	tests := []struct {
+		name      string
+		lastReset time.Time
+		now       time.Time
+		wantReset bool
+	}{
+		{"spring, before the 23 hour day ends", time.Date(2024, 3, 9, 17, 5, 0, 0, loc), time.Date(2024, 3, 10, 16, 30, 0, 0, loc), false},
+		{"spring, day over after 23 hours", time.Date(2024, 3, 9, 17, 5, 0, 0, loc), time.Date(2024, 3, 10, 17, 0, 0, 0, loc), true},
+		{"fall, 24 hours into the 25 hour day", time.Date(2024, 11, 2, 17, 5, 0, 0, loc), time.Date(2024, 11, 3, 16, 30, 0, 0, loc), false},
+		{"fall, day over", time.Date(2024, 11, 2, 17, 5, 0, 0, loc), time.Date(2024, 11, 3, 17, 0, 0, 0, loc), true},
+	}
+	for _, tt := range tests {
+		e := &Engine{
+			name:           "test",
+			strategy:       strategy,
+			account:        &exchange.AccountInfo{TotalMarginBalance: 900},
+			initialBalance: 1000,
+			dailyPnL:       -100,
+			lastResetTime:  tt.lastReset,
+		}
+		e.resetDailyPnLIfNeeded(tt.now)
+
+		reset := e.dailyPnL == 0
+		if reset != tt.wantReset {
+			t.Errorf("%s: reset = %v, want %v", tt.name, reset, tt.wantReset)
+		}
+		if reset && (e.initialBalance != 900 || !e.lastResetTime.Equal(tt.now)) {
+			t.Errorf("%s: baseline = %v at %s", tt.name, e.initialBalance, e.lastResetTime)
+		}
+	}
+}
+
+// TestScheduleUsesStrategyTimezone tests that a schedule without its own
+// timezone follows the strategy's
+func TestScheduleUsesStrategyTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	e := &Engine{name: "test", strategy: &store.Strategy{Config: store.StrategyConfig{
+		Timezone: "Asia/Tokyo",
+		Schedule: store.ScheduleConfig{Enabled: true, Windows: []store.ScheduleWindow{
+			{Day: 1, Start: "09:00", End: "17:00"},
+		}},
+	}}}
+
+	// Monday 10:00 in Tokyo is 01:00 UTC
+	if paused, _, _ := e.scheduleStatus(time.Date(2024, 3, 4, 1, 0, 0, 0, time.UTC)); paused {
+		t.Error("paused inside the Tokyo window")
+	}
+	if paused, _, _ := e.scheduleStatus(time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)); !paused {
+		t.Error("active at 10:00 UTC, 19:00 in Tokyo")
+	}
+}
+
+func TestValidateTradingDay(t *testing.T) {
+	if err := ValidateTradingDay(&store.StrategyConfig{Timezone: "America/New_York", DayStartHour: 17}); err != nil {
+		t.Errorf("valid trading day: %v", err)
+	}
+	if err := ValidateTradingDay(&store.StrategyConfig{Timezone: "New York"}); err == nil {
+		t.Error("invalid timezone accepted")
+	}
+	if err := ValidateTradingDay(&store.StrategyConfig{DayStartHour: 24}); err == nil {
+		t.Error("invalid start hour accepted")
+	}
+}